@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bantuaku/backend/config"
+	"github.com/bantuaku/backend/handlers"
+	"github.com/bantuaku/backend/services/storage"
+)
+
+// runAnonymize implements `bantuaku anonymize`, which replaces the personal data
+// of companies in the configured database with realistic fakes. It is meant to
+// run against a staging copy of production data and returns the exit code.
+func runAnonymize(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("anonymize", flag.ContinueOnError)
+	companies := fs.String("company", "", "Comma-separated IDs of the companies to anonymize (required)")
+	salt := fs.String("salt", "", "Salt for the fakes; reuse it to get the same fakes across runs (default: random)")
+	password := fs.String("password", "", "Password to give anonymized users so staging can sign in as them (default: none)")
+	confirm := fs.Bool("confirm", false, "Rewrite the data; without it the command only shows what it would do")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bantuaku anonymize -company <id>[,<id>...] [-salt s] [-password p] -confirm")
+		fmt.Fprintln(fs.Output(), "Rewrites data in DATABASE_URL in place. Run it on a staging copy, never on production.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	var ids []string
+	for _, id := range strings.Split(*companies, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	fmt.Printf("Database: %s\n", maskDatabaseURL(cfg.DatabaseURL))
+	if !*confirm {
+		fmt.Printf("Would anonymize %d companies: %s\nRe-run with -confirm to rewrite their data.\n", len(ids), strings.Join(ids, ", "))
+		return 0
+	}
+	if *salt == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		*salt = hex.EncodeToString(b)
+	}
+
+	db, err := storage.NewPostgres(cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	h, err := handlers.New(db, nil, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	opts := handlers.AnonymizeOptions{Salt: *salt, Password: *password}
+	failed := 0
+	for _, id := range ids {
+		result, err := h.AnonymizeCompany(ctx, id, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+			failed++
+			continue
+		}
+		out, _ := json.Marshal(result)
+		fmt.Println(string(out))
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bantuaku/backend/config"
+	"github.com/bantuaku/backend/logger"
+)
+
+// runCheckConfig implements `bantuaku check-config`, which prints which features
+// the configuration enables and any problems with it, and returns the exit
+// code: 1 when the server would refuse to start
+func runCheckConfig(cfg *config.Config) int {
+	report := cfg.Check()
+
+	fmt.Printf("Environment: %s\n\n", cfg.Environment)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FEATURE\tSTATUS\tREQUIRES\tWHILE OFF")
+	for _, f := range report.Features {
+		status, note := "on", ""
+		if !f.Enabled {
+			status, note = "off", f.Note
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Name, status, f.Requires, note)
+	}
+	tw.Flush()
+
+	if len(report.Problems) == 0 {
+		fmt.Println("\nNo configuration problems found")
+		return 0
+	}
+	fmt.Println()
+	for _, p := range report.Problems {
+		level := "warning"
+		if p.Fatal {
+			level = "error"
+		}
+		fmt.Printf("%-7s  %s: %s\n", level, p.Key, p.Message)
+	}
+	if errs := report.Errors(); len(errs) > 0 {
+		fmt.Printf("\n%d error(s): the server would refuse to start\n", len(errs))
+		return 1
+	}
+	return 0
+}
+
+// checkConfig logs the feature matrix and configuration problems at startup,
+// and reports whether the server may start
+func checkConfig(cfg *config.Config, log *logger.Logger) bool {
+	report := cfg.Check()
+	for _, f := range report.Features {
+		if f.Enabled {
+			log.Info("Feature enabled", "feature", f.Name)
+		} else {
+			log.Info("Feature disabled", "feature", f.Name, "requires", f.Requires, "effect", f.Note)
+		}
+	}
+	for _, p := range report.Problems {
+		if p.Fatal {
+			log.Error("Invalid configuration", "key", p.Key, "problem", p.Message)
+		} else {
+			log.Warn("Configuration warning", "key", p.Key, "problem", p.Message)
+		}
+	}
+	if errs := report.Errors(); len(errs) > 0 {
+		log.Error("Refusing to start with invalid configuration; run `bantuaku check-config` for a full report", "errors", len(errs))
+		return false
+	}
+	return true
+}
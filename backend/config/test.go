@@ -7,13 +7,76 @@ import (
 // LoadTest loads test configuration
 func LoadTest() *Config {
 	return &Config{
+		Environment:   "test",
 		DatabaseURL:   getTestDatabaseURL(),
 		RedisURL:      getTestRedisURL(),
 		JWTSecret:     "test-jwt-secret",
 		KolosalAPIKey: "", // Disable Kolosal.ai in tests
-		CORSOrigin:    "http://localhost:3000",
+		CORSOrigins:   "http://localhost:3000",
+		CORSMaxAge:    600,
 		Port:          "8080",
 		LogLevel:      "debug",
+		StorageDir:    os.TempDir(),
+		StorageRegion: "id-jkt",
+
+		SlowMoverDays:      30,
+		SlowMoverScanHours: 0, // Scheduled jobs stay off in tests
+
+		NotificationSummaryMinutes: 0,
+
+		MarketResearchReuseDays: 7,
+		MarketMonitorHours:      0,
+
+		ChatRetainMessages:  200,
+		ChatArchiveHours:    0,
+		ChatCacheHours:      0,
+		ChatCacheSimilarity: 92,
+		ChatAlternateModel:  "",
+		ChatSuggestionHours: 0,
+
+		OpenRouterAPIKey:         "", // Chat stays on Kolosal in tests
+		OpenRouterAllowFallbacks: true,
+
+		AIQueueWorkers:     2,
+		AIQueueDepth:       4,
+		AIQueueWaitSeconds: 1,
+
+		SLOObjectives:  "api=/api/v1:99.9:1000",
+		SLOWindowHours: 24,
+		SLOBurnAlert:   10,
+
+		AppURL:           "http://localhost:3000",
+		APIURL:           "http://localhost:8080",
+		SMTPFrom:         "Bantuaku <noreply@bantuaku.id>", // No SMTP host: emails are logged
+		InviteTokenHours: 72,
+		ResetTokenHours:  2,
+
+		AuditEncryptionKey: "test-audit-key",
+		AuditPayloadDays:   30,
+
+		ProviderLogSamplePercent: 0, // No provider call recording in tests
+		ProviderLogTTLHours:      72,
+		AIUsageRetentionDays:     0, // No AI usage purge in tests
+
+		ExportRetentionHours: 72,
+		ExportLinkMinutes:    15,
+
+		GeocoderURL: "", // No geocoding in tests
+
+		OCRMinConfidence: 60,
+		OCRVisionModel:   "",
+		OCRTesseractLang: "ind+eng",
+
+		ComplianceReminderHours: 0,
+
+		DormancyMonths:    0, // No dormancy scans in tests
+		DormancyGraceDays: 30,
+		DormancyScanHours: 24,
+
+		UsageLimitsEnabled: true,
+		UsageSnapshotHours: 0,
+
+		BillingSyncMinutes: 0,
 	}
 }
 
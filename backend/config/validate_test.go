@@ -0,0 +1,111 @@
+package config
+
+import "testing"
+
+func problemKeys(r Report, fatal bool) map[string]bool {
+	keys := map[string]bool{}
+	for _, p := range r.Problems {
+		if p.Fatal == fatal {
+			keys[p.Key] = true
+		}
+	}
+	return keys
+}
+
+func TestCheckDevelopmentDefaults(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("DATABASE_URL", "")
+	r := Load().Check()
+	if errs := r.Errors(); len(errs) != 0 {
+		t.Fatalf("development defaults should start, got %+v", errs)
+	}
+	if !problemKeys(r, false)["JWT_SECRET"] {
+		t.Error("expected a warning about the development JWT secret")
+	}
+}
+
+func TestCheckProductionRefusesDefaults(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("DATABASE_URL", "")
+	errs := problemKeys(Load().Check(), true)
+	for _, key := range []string{"JWT_SECRET", "DATABASE_URL"} {
+		if !errs[key] {
+			t.Errorf("expected an error for %s", key)
+		}
+	}
+}
+
+func TestCheckInvalidValues(t *testing.T) {
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_USERNAME", "mailer")
+	t.Setenv("SMTP_PASSWORD", "")
+	t.Setenv("CHAT_ARCHIVE_HOURS", "daily")
+	t.Setenv("OCR_MIN_CONFIDENCE", "150")
+	t.Setenv("APP_URL", "bantuaku.id")
+	t.Setenv("STORAGE_REGION", "sg-sgp")
+	c := Load()
+	if c.ChatArchiveHours != 24 {
+		t.Errorf("unparsable value should keep the default, got %d", c.ChatArchiveHours)
+	}
+	errs := problemKeys(c.Check(), true)
+	for _, key := range []string{"SMTP_PASSWORD", "CHAT_ARCHIVE_HOURS", "OCR_MIN_CONFIDENCE", "APP_URL", "STORAGE_REGION"} {
+		if !errs[key] {
+			t.Errorf("expected an error for %s", key)
+		}
+	}
+}
+
+func TestCheckFeatures(t *testing.T) {
+	c := LoadTest()
+	c.ExaAPIKey = "exa"
+	c.MarketMonitorHours = 0
+	enabled := map[string]bool{}
+	for _, f := range c.Check().Features {
+		enabled[f.Name] = f.Enabled
+	}
+	if enabled["AI (Kolosal)"] || !enabled["Market research (Exa)"] || enabled["Market monitoring"] {
+		t.Errorf("unexpected feature matrix %v", enabled)
+	}
+}
+
+func TestDevMocks(t *testing.T) {
+	t.Setenv("DEV_MOCKS", "true")
+	t.Setenv("KOLOSAL_API_KEY", "")
+	t.Setenv("OPENROUTER_API_KEY", "real-key")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	c := Load()
+	if c.KolosalAPIKey == "" || c.ExaAPIKey == "" || c.OpenRouterAPIKey != "" || c.SMTPHost != "" {
+		t.Errorf("mocks should replace providers, got %+v", c)
+	}
+	if len(c.Check().Errors()) != 0 {
+		t.Error("mocks are allowed in development")
+	}
+	c.Environment = "production"
+	if !problemKeys(c.Check(), true)["DEV_MOCKS"] {
+		t.Error("mocks must be refused in production")
+	}
+}
+
+func TestCheckBilling(t *testing.T) {
+	c := LoadTest()
+	c.BillingProvider = "midtrans"
+	if !problemKeys(c.Check(), true)["MIDTRANS_SERVER_KEY"] {
+		t.Error("expected an error for a missing Midtrans key")
+	}
+	c.BillingProvider = "paypal"
+	if !problemKeys(c.Check(), true)["BILLING_PROVIDER"] {
+		t.Error("expected an error for an unknown gateway")
+	}
+}
+
+func TestCheckStorageRegion(t *testing.T) {
+	for region, valid := range map[string]bool{"id-jkt": true, "sg-sin": true, "sg-sgp": false, "": false, "ID-JKT": false} {
+		c := Load()
+		c.StorageRegion = region
+		if got := problemKeys(c.Check(), true)["STORAGE_REGION"]; got == valid {
+			t.Errorf("region %q: error reported = %v", region, got)
+		}
+	}
+}
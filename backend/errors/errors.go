@@ -27,11 +27,14 @@ const (
 	ErrCodeNotFound      ErrorCode = "not_found"
 	ErrCodeConflict      ErrorCode = "conflict"
 	ErrCodeLimitExceeded ErrorCode = "limit_exceeded"
+	ErrCodeRateLimited   ErrorCode = "rate_limited"
 
 	// System errors
-	ErrCodeInternal ErrorCode = "internal_error"
-	ErrCodeDatabase ErrorCode = "database_error"
-	ErrCodeExternal ErrorCode = "external_service_error"
+	ErrCodeInternal    ErrorCode = "internal_error"
+	ErrCodeDatabase    ErrorCode = "database_error"
+	ErrCodeExternal    ErrorCode = "external_service_error"
+	ErrCodeOverload    ErrorCode = "service_overloaded"
+	ErrCodeMaintenance ErrorCode = "maintenance"
 
 	// Business logic errors
 	ErrCodeBusiness          ErrorCode = "business_rule_violation"
@@ -40,11 +43,13 @@ const (
 
 // AppError represents an application error with structured information
 type AppError struct {
-	Code       ErrorCode `json:"code"`
-	Message    string    `json:"message"`
-	Details    string    `json:"details,omitempty"`
-	Timestamp  string    `json:"timestamp"`
-	StackTrace string    `json:"stack_trace,omitempty"`
+	Code       ErrorCode   `json:"code"`
+	Message    string      `json:"message"`
+	Details    string      `json:"details,omitempty"`
+	Timestamp  string      `json:"timestamp"`
+	StackTrace string      `json:"stack_trace,omitempty"`
+	Usage      interface{} `json:"usage,omitempty"` // Set on limit_exceeded errors
+	cause      error
 }
 
 // Error implements the error interface
@@ -52,6 +57,17 @@ func (e *AppError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the underlying error, if one was attached with WithCause
+func (e *AppError) Unwrap() error {
+	return e.cause
+}
+
+// WithCause attaches the underlying error so callers can inspect it with errors.As
+func (e *AppError) WithCause(err error) *AppError {
+	e.cause = err
+	return e
+}
+
 // NewAppError creates a new application error
 func NewAppError(code ErrorCode, message, details string) *AppError {
 	return &AppError{
@@ -117,12 +133,39 @@ func NewExternalServiceError(service, message, details string) *AppError {
 	return NewAppError(ErrCodeExternal, errorMessage, details)
 }
 
+// NewOverloadError creates a temporarily-unavailable error with a retry hint
+func NewOverloadError(message string, retryAfter time.Duration) *AppError {
+	details := fmt.Sprintf("Retry after %d seconds", int(retryAfter.Seconds()+0.999))
+	return NewAppError(ErrCodeOverload, message, details)
+}
+
+// NewMaintenanceError creates the error returned while the platform is under
+// maintenance
+func NewMaintenanceError(message string, retryAfter time.Duration) *AppError {
+	details := fmt.Sprintf("Retry after %d seconds", int(retryAfter.Seconds()+0.999))
+	return NewAppError(ErrCodeMaintenance, message, details)
+}
+
 // NewBusinessRuleError creates a business rule violation error
 func NewBusinessRuleError(rule, message string) *AppError {
 	errorMessage := fmt.Sprintf("Business rule violation (%s): %s", rule, message)
 	return NewAppError(ErrCodeBusiness, errorMessage, "")
 }
 
+// NewRateLimitError creates a too-many-requests error
+func NewRateLimitError(retryAfter time.Duration) *AppError {
+	details := fmt.Sprintf("Retry after %d seconds", int(retryAfter.Seconds()+0.999))
+	return NewAppError(ErrCodeRateLimited, "Too many requests", details)
+}
+
+// NewLimitExceededError creates a plan limit error carrying the usage that
+// reached the limit, so clients can show what was used and when it resets
+func NewLimitExceededError(message string, usage interface{}) *AppError {
+	appErr := NewAppError(ErrCodeLimitExceeded, message, "Upgrade your plan or wait for the limit to reset")
+	appErr.Usage = usage
+	return appErr
+}
+
 // NewInsufficientStockError creates an insufficient stock error
 func NewInsufficientStockError(productID string, requested, available int) *AppError {
 	message := "Insufficient stock"
@@ -159,6 +202,10 @@ func HTTPStatusFromErrorCode(code ErrorCode) int {
 		return 409
 	case ErrCodeBusiness, ErrCodeInsufficientStock, ErrCodeLimitExceeded:
 		return 422
+	case ErrCodeRateLimited:
+		return 429
+	case ErrCodeOverload, ErrCodeMaintenance:
+		return 503
 	case ErrCodeTokenExpired:
 		return 419
 	default:
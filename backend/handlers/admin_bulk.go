@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/bulkops"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// bulkItemAuditActions is the audit action recorded for each user a bulk
+// operation touches, matching the single-user equivalents where they exist
+var bulkItemAuditActions = map[string]string{
+	bulkops.ActionSuspend:    "user.suspend",
+	bulkops.ActionActivate:   "user.activate",
+	bulkops.ActionChangePlan: "company.subscription.update",
+	bulkops.ActionEmail:      "user.email",
+}
+
+// errSuspendedAccount is returned when a suspended user tries to sign in
+func errSuspendedAccount() *errors.AppError {
+	return errors.NewForbiddenError("This account has been suspended; contact support to restore access")
+}
+
+// BulkUsersRequest selects users by ID or by filter, plus the action's parameters
+type BulkUsersRequest struct {
+	UserIDs []string        `json:"user_ids,omitempty"`
+	Filter  *bulkops.Filter `json:"filter,omitempty"`
+	bulkops.Params
+}
+
+// AdminBulkSuspendUsers suspends the selected users in the background (admin only)
+func (h *Handler) AdminBulkSuspendUsers(w http.ResponseWriter, r *http.Request) {
+	h.startBulkOperation(w, r, bulkops.ActionSuspend)
+}
+
+// AdminBulkActivateUsers lifts the suspension of the selected users in the background (admin only)
+func (h *Handler) AdminBulkActivateUsers(w http.ResponseWriter, r *http.Request) {
+	h.startBulkOperation(w, r, bulkops.ActionActivate)
+}
+
+// AdminBulkChangePlan moves the companies owned by the selected users to a plan
+// in the background (admin only)
+func (h *Handler) AdminBulkChangePlan(w http.ResponseWriter, r *http.Request) {
+	h.startBulkOperation(w, r, bulkops.ActionChangePlan)
+}
+
+// AdminBulkEmailUsers emails the selected users in the background (admin only).
+// Suspended users are skipped.
+func (h *Handler) AdminBulkEmailUsers(w http.ResponseWriter, r *http.Request) {
+	h.startBulkOperation(w, r, bulkops.ActionEmail)
+}
+
+// ListBulkOperations lists bulk operations, newest first (admin only)
+func (h *Handler) ListBulkOperations(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM bulk_operations`).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count bulk operations"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, bulkOperationSelect+`
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list bulk operations"), r)
+		return
+	}
+	defer rows.Close()
+
+	ops := []models.BulkOperation{}
+	for rows.Next() {
+		op, err := scanBulkOperation(rows)
+		if err != nil {
+			continue
+		}
+		ops = append(ops, *op)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    ops,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// GetBulkOperation returns a bulk operation with a page of its per-user
+// results, optionally filtered by ?status= (admin only)
+func (h *Handler) GetBulkOperation(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	id := r.PathValue("id")
+	op, err := scanBulkOperation(h.db.Pool().QueryRow(ctx, bulkOperationSelect+` WHERE id = $1`, id))
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Bulk operation"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load bulk operation"), r)
+		return
+	}
+
+	conditions := []string{"i.operation_id = $1"}
+	args := []interface{}{id}
+	if status := r.URL.Query().Get("status"); status != "" {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("i.status = $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM bulk_operation_items i WHERE `+where, args...).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count bulk operation items"), r)
+		return
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := h.db.Pool().Query(ctx, fmt.Sprintf(`
+		SELECT i.user_id, COALESCE(u.email, ''), i.status, COALESCE(i.message, ''), i.processed_at
+		FROM bulk_operation_items i
+		LEFT JOIN users u ON u.id = i.user_id
+		WHERE %s
+		ORDER BY i.processed_at NULLS LAST, i.user_id
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list bulk operation items"), r)
+		return
+	}
+	defer rows.Close()
+
+	items := []models.BulkOperationItem{}
+	for rows.Next() {
+		var item models.BulkOperationItem
+		if err := rows.Scan(&item.UserID, &item.Email, &item.Status, &item.Message, &item.ProcessedAt); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    items,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+		Extra: map[string]interface{}{
+			"operation": op,
+		},
+	})
+}
+
+// startBulkOperation resolves the targeted users, records the operation with a
+// pending item per user and runs it in the background
+func (h *Handler) startBulkOperation(w http.ResponseWriter, r *http.Request, action string) {
+	var req BulkUsersRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := bulkops.Validate(action, req.Params); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid request", err.Error()), r)
+		return
+	}
+	byFilter := req.Filter != nil && !req.Filter.IsEmpty()
+	if byFilter == (len(req.UserIDs) > 0) {
+		h.respondError(w, errors.NewValidationError("Invalid selection", "provide either user_ids or a non-empty filter"), r)
+		return
+	}
+	if len(req.UserIDs) > bulkops.MaxTargets {
+		h.respondError(w, errors.NewValidationError("Too many users", fmt.Sprintf("at most %d users per operation", bulkops.MaxTargets)), r)
+		return
+	}
+	if byFilter {
+		if err := req.Filter.Validate(); err != nil {
+			h.respondError(w, errors.NewValidationError("Invalid filter", err.Error()), r)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	var targets []string
+	seen := map[string]bool{}
+	for _, id := range req.UserIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			targets = append(targets, id)
+		}
+	}
+	var filterJSON []byte
+	if byFilter {
+		var err error
+		if targets, err = h.bulkFilterTargets(ctx, *req.Filter); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "select bulk operation users"), r)
+			return
+		}
+		if len(targets) > bulkops.MaxTargets {
+			h.respondError(w, errors.NewValidationError("Too many users",
+				fmt.Sprintf("the filter matches more than %d users; narrow it down", bulkops.MaxTargets)), r)
+			return
+		}
+		filterJSON, _ = json.Marshal(req.Filter)
+	}
+	if len(targets) == 0 {
+		h.respondError(w, errors.NewValidationError("No users selected", "no users match the selection"), r)
+		return
+	}
+
+	paramsJSON, _ := json.Marshal(req.Params)
+	opID := uuid.New().String()
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO bulk_operations (id, action, params, filter, status, total, requested_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NOW())
+	`, opID, action, paramsJSON, filterJSON, bulkops.StatusPending, len(targets), middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create bulk operation"), r)
+		return
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO bulk_operation_items (operation_id, user_id, status)
+		SELECT $1, unnest($2::text[]), $3
+	`, opID, targets, bulkops.ItemPending)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create bulk operation items"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	// The clone keeps the actor and request metadata for per-user audit entries
+	h.startJob(ctx, jobBulkOperation, opID, bulkOperationJobTimeout, func(ctx context.Context) {
+		h.runBulkOperation(r.Clone(ctx), opID, action, req.Params)
+	})
+
+	logger.Info("Bulk operation started", "operation_id", opID, "action", action, "users", len(targets),
+		"admin_id", middleware.GetUserID(ctx))
+
+	op, err := scanBulkOperation(h.db.Pool().QueryRow(ctx, bulkOperationSelect+` WHERE id = $1`, opID))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load bulk operation"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusAccepted, op)
+}
+
+// runBulkOperation applies action to every pending item, recording each
+// result and an audit entry per user. Shutdown stops it between users and
+// fails the operation; items not reached stay pending.
+func (h *Handler) runBulkOperation(r *http.Request, opID, action string, params bulkops.Params) {
+	ctx := r.Context()
+	// Failures are still recorded after ctx is cancelled
+	final := context.WithoutCancel(ctx)
+	log := jobLogger(ctx)
+	h.db.Pool().Exec(ctx, `
+		UPDATE bulk_operations SET status = $2, started_at = NOW() WHERE id = $1
+	`, opID, bulkops.StatusRunning)
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT user_id FROM bulk_operation_items WHERE operation_id = $1 AND status = $2 ORDER BY user_id
+	`, opID, bulkops.ItemPending)
+	if err != nil {
+		log.Error("Failed to load bulk operation items", "error", err.Error())
+		h.db.Pool().Exec(final, `
+			UPDATE bulk_operations SET status = $2, finished_at = NOW() WHERE id = $1
+		`, opID, bulkops.StatusFailed)
+		return
+	}
+	var userIDs []string
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			userIDs = append(userIDs, id)
+		}
+	}
+	rows.Close()
+
+	actorID := middleware.GetUserID(ctx)
+	counts := map[string]int{}
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			log.Warn("Bulk operation interrupted", "action", action, "error", ctx.Err().Error(),
+				"succeeded", counts[bulkops.ItemSucceeded], "skipped", counts[bulkops.ItemSkipped], "failed", counts[bulkops.ItemFailed])
+			h.db.Pool().Exec(final, `
+				UPDATE bulk_operations SET status = $2, finished_at = NOW() WHERE id = $1
+			`, opID, bulkops.StatusFailed)
+			return
+		}
+		status, message := h.applyBulkAction(ctx, actorID, action, params, userID)
+		counts[status]++
+		h.db.Pool().Exec(ctx, `
+			UPDATE bulk_operation_items SET status = $3, message = NULLIF($4, ''), processed_at = NOW()
+			WHERE operation_id = $1 AND user_id = $2
+		`, opID, userID, status, message)
+		h.db.Pool().Exec(ctx, `
+			UPDATE bulk_operations
+			SET succeeded = succeeded + ($2 = $3)::int, skipped = skipped + ($2 = $4)::int, failed = failed + ($2 = $5)::int
+			WHERE id = $1
+		`, opID, status, bulkops.ItemSucceeded, bulkops.ItemSkipped, bulkops.ItemFailed)
+		h.recordAudit(ctx, r, bulkItemAuditActions[action], userID, bulkItemAuditStatus(status), nil)
+	}
+
+	h.db.Pool().Exec(ctx, `
+		UPDATE bulk_operations SET status = $2, finished_at = NOW() WHERE id = $1
+	`, opID, bulkops.StatusCompleted)
+	log.Info("Bulk operation completed", "action", action,
+		"succeeded", counts[bulkops.ItemSucceeded], "skipped", counts[bulkops.ItemSkipped], "failed", counts[bulkops.ItemFailed])
+}
+
+// applyBulkAction applies action to one user and returns the item status and
+// a message explaining skips and failures
+func (h *Handler) applyBulkAction(ctx context.Context, actorID, action string, params bulkops.Params, userID string) (string, string) {
+	var email, role string
+	var suspended bool
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT email, COALESCE(role, 'user'), suspended_at IS NOT NULL FROM users WHERE id = $1
+	`, userID).Scan(&email, &role, &suspended)
+	if err == pgx.ErrNoRows {
+		return bulkops.ItemFailed, "user not found"
+	}
+	if err != nil {
+		return bulkops.ItemFailed, err.Error()
+	}
+
+	switch action {
+	case bulkops.ActionSuspend:
+		if userID == actorID {
+			return bulkops.ItemSkipped, "you cannot suspend yourself"
+		}
+		if role == "super_admin" {
+			return bulkops.ItemSkipped, "super admins cannot be suspended"
+		}
+		if suspended {
+			return bulkops.ItemSkipped, "already suspended"
+		}
+		_, err = h.db.Pool().Exec(ctx, `
+			UPDATE users SET suspended_at = NOW(), suspended_reason = NULLIF($2, '') WHERE id = $1
+		`, userID, params.Reason)
+
+	case bulkops.ActionActivate:
+		if !suspended {
+			return bulkops.ItemSkipped, "not suspended"
+		}
+		_, err = h.db.Pool().Exec(ctx, `
+			UPDATE users SET suspended_at = NULL, suspended_reason = NULL WHERE id = $1
+		`, userID)
+
+	case bulkops.ActionChangePlan:
+		rows, qerr := h.db.Pool().Query(ctx, `
+			UPDATE companies SET subscription_plan = $2, plan_expires_at = NULL, updated_at = NOW()
+			WHERE owner_user_id = $1 AND COALESCE(subscription_plan, 'free') <> $2
+			RETURNING id
+		`, userID, params.Plan)
+		if qerr != nil {
+			return bulkops.ItemFailed, qerr.Error()
+		}
+		var companyIDs []string
+		for rows.Next() {
+			var id string
+			if rows.Scan(&id) == nil {
+				companyIDs = append(companyIDs, id)
+			}
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			break
+		}
+		if len(companyIDs) == 0 {
+			return bulkops.ItemSkipped, "owns no company on another plan"
+		}
+		h.invalidateCompanyPlans(ctx, companyIDs...)
+		return bulkops.ItemSucceeded, fmt.Sprintf("%s moved to %s", strings.Join(companyIDs, ", "), params.Plan)
+
+	case bulkops.ActionEmail:
+		if suspended {
+			return bulkops.ItemSkipped, "user is suspended"
+		}
+		var company string
+		h.db.Pool().QueryRow(ctx, `
+			SELECT name FROM companies WHERE owner_user_id = $1 ORDER BY created_at LIMIT 1
+		`, userID).Scan(&company)
+		err = h.sendEmail(ctx, bulkops.Email(params, email, company))
+	}
+
+	if err != nil {
+		return bulkops.ItemFailed, err.Error()
+	}
+	return bulkops.ItemSucceeded, ""
+}
+
+// bulkFilterTargets returns the IDs of users matching f, at most one more than
+// bulkops.MaxTargets so callers can tell the limit was exceeded
+func (h *Handler) bulkFilterTargets(ctx context.Context, f bulkops.Filter) ([]string, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if f.Role != "" {
+		args = append(args, f.Role)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(u.role, 'user') = $%d", len(args)))
+	}
+	if f.Plan != "" {
+		args = append(args, f.Plan)
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM companies c WHERE c.owner_user_id = u.id AND COALESCE(c.subscription_plan, 'free') = $%d)", len(args)))
+	}
+	if f.EmailDomain != "" {
+		args = append(args, strings.ToLower(f.EmailDomain))
+		conditions = append(conditions, fmt.Sprintf("split_part(lower(u.email), '@', 2) = $%d", len(args)))
+	}
+	if f.CreatedAfter != "" {
+		args = append(args, f.CreatedAfter)
+		conditions = append(conditions, fmt.Sprintf("u.created_at >= $%d::date", len(args)))
+	}
+	if f.CreatedBefore != "" {
+		args = append(args, f.CreatedBefore)
+		conditions = append(conditions, fmt.Sprintf("u.created_at < $%d::date", len(args)))
+	}
+	if f.Suspended != nil {
+		args = append(args, *f.Suspended)
+		conditions = append(conditions, fmt.Sprintf("(u.suspended_at IS NOT NULL) = $%d", len(args)))
+	}
+
+	args = append(args, bulkops.MaxTargets+1)
+	rows, err := h.db.Pool().Query(ctx, fmt.Sprintf(`
+		SELECT u.id FROM users u WHERE %s ORDER BY u.id LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// bulkItemAuditStatus maps an item status to the status code of its audit entry
+func bulkItemAuditStatus(status string) int {
+	switch status {
+	case bulkops.ItemSucceeded:
+		return http.StatusOK
+	case bulkops.ItemSkipped:
+		return http.StatusConflict
+	}
+	return http.StatusUnprocessableEntity
+}
+
+const bulkOperationSelect = `
+	SELECT id, action, params, filter, status, total, succeeded, skipped, failed, COALESCE(requested_by, ''),
+		created_at, started_at, finished_at
+	FROM bulk_operations`
+
+func scanBulkOperation(row pgx.Row) (*models.BulkOperation, error) {
+	var op models.BulkOperation
+	var params, filter []byte
+	err := row.Scan(&op.ID, &op.Action, &params, &filter, &op.Status, &op.Total, &op.Succeeded, &op.Skipped,
+		&op.Failed, &op.RequestedBy, &op.CreatedAt, &op.StartedAt, &op.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	op.Params = json.RawMessage(params)
+	if len(filter) > 0 {
+		op.Filter = json.RawMessage(filter)
+	}
+	return &op, nil
+}
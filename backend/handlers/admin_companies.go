@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateSubscriptionRequest changes a company's subscription plan
+type UpdateSubscriptionRequest struct {
+	Plan string `json:"plan" validate:"required,oneof:free|pro|enterprise"`
+}
+
+// AdminUpdateCompanySubscription changes a company's plan. Plans set by an admin
+// do not lapse. The cached plan used for rate limits is dropped so the change
+// applies immediately.
+func (h *Handler) AdminUpdateCompanySubscription(w http.ResponseWriter, r *http.Request) {
+	var req UpdateSubscriptionRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	companyID := r.PathValue("id")
+	var previous string
+	err := h.db.Pool().QueryRow(ctx, `
+		UPDATE companies c SET subscription_plan = $2, plan_expires_at = NULL, updated_at = NOW()
+		FROM (SELECT id, COALESCE(subscription_plan, 'free') AS plan FROM companies WHERE id = $1 FOR UPDATE) old
+		WHERE c.id = old.id
+		RETURNING old.plan
+	`, companyID, req.Plan).Scan(&previous)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update subscription plan"), r)
+		return
+	}
+	h.invalidateCompanyPlans(ctx, companyID)
+	logger.Info("Company plan changed by admin", "company_id", companyID, "from", previous, "to", req.Plan,
+		"admin_id", middleware.GetUserID(ctx))
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{
+		"company_id":    companyID,
+		"plan":          req.Plan,
+		"previous_plan": previous,
+	})
+}
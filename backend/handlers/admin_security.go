@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/ipaccess"
+	"github.com/bantuaku/backend/services/mailer"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AddIPAllowEntryRequest adds a range to the admin IP allowlist
+type AddIPAllowEntryRequest struct {
+	CIDR        string `json:"cidr" validate:"required,max:50"`
+	Description string `json:"description,omitempty" validate:"max:255"`
+}
+
+// isPrivilegedRole reports whether a platform role is subject to admin session checks
+func isPrivilegedRole(role string) bool {
+	return role == "admin" || role == "super_admin"
+}
+
+// adminDeviceApprovalTTL bounds how long an emailed device approval link works
+const adminDeviceApprovalTTL = time.Hour
+
+// AdminDeviceApprovalRequest approves a device with the token from its email
+type AdminDeviceApprovalRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// registerAdminLogin enforces the IP allowlist for an admin login and checks the
+// device it comes from. An admin's first device is approved on sign-in; any
+// other new device is refused until the admin approves it from an emailed link,
+// so sending a new X-Device-ID does not get around a revoked device. The owner
+// is emailed when an approved device signs in from a new network. It returns the
+// device ID to embed in the session token.
+func (h *Handler) registerAdminLogin(ctx context.Context, r *http.Request, userID, email string) (string, error) {
+	ip := middleware.ClientIP(r)
+	if !h.adminIPAllowed(ctx, ip) {
+		logger.Warn("Admin login from address outside the allowlist", "user_id", userID, "ip", ip)
+		return "", errors.NewForbiddenError("Admin sign-in is not allowed from this network")
+	}
+
+	key := adminDeviceKey(r)
+	if key == "" {
+		return "", errors.NewValidationError("Device ID is required", "admin sign-in needs a random X-Device-ID header of 16 to 200 characters")
+	}
+	network := ipaccess.Network(ip)
+	userAgent := r.UserAgent()
+
+	var deviceID string
+	var revoked, approved bool
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, revoked_at IS NOT NULL, approved_at IS NOT NULL FROM admin_devices WHERE user_id = $1 AND device_key = $2
+	`, userID, key).Scan(&deviceID, &revoked, &approved)
+	if err != nil && err != pgx.ErrNoRows {
+		return "", errors.NewDatabaseError(err, "load admin device")
+	}
+	if revoked {
+		logger.Warn("Admin login from revoked device", "user_id", userID, "device_id", deviceID, "ip", ip)
+		return "", errors.NewForbiddenError("This device has been revoked; ask a super admin to review your devices")
+	}
+
+	var hasDevices, knownNetwork bool
+	h.db.Pool().QueryRow(ctx, `
+		SELECT COUNT(*) > 0, COALESCE(BOOL_OR(last_network = $2), FALSE) FROM admin_devices WHERE user_id = $1
+	`, userID, network).Scan(&hasDevices, &knownNetwork)
+
+	newDevice := err == pgx.ErrNoRows
+	if newDevice {
+		deviceID = uuid.New().String()
+		approved = !hasDevices
+		_, err = h.db.Pool().Exec(ctx, `
+			INSERT INTO admin_devices (id, user_id, device_key, user_agent, first_ip, last_ip, last_network, approved_at)
+			VALUES ($1, $2, $3, $4, $5, $5, $6, CASE WHEN $7 THEN NOW() END)
+		`, deviceID, userID, key, userAgent, ip, network, approved)
+	} else {
+		_, err = h.db.Pool().Exec(ctx, `
+			UPDATE admin_devices SET last_ip = $2, last_network = $3, user_agent = $4, last_seen_at = NOW()
+			WHERE id = $1
+		`, deviceID, ip, network, userAgent)
+	}
+	if err != nil {
+		return "", errors.NewDatabaseError(err, "record admin device")
+	}
+
+	if !approved {
+		if err := h.requestAdminDeviceApproval(ctx, deviceID, email, userAgent, ip); err != nil {
+			return "", err
+		}
+		logger.Warn("Admin login from unapproved device", "user_id", userID, "device_id", deviceID, "ip", ip, "new_device", newDevice)
+		return "", errors.NewBusinessRuleError("device_approval_required",
+			"this device is new: approve it from the link sent to your email, then sign in again")
+	}
+
+	if hasDevices && !knownNetwork {
+		logger.Warn("Admin login from new network", "user_id", userID, "device_id", deviceID, "ip", ip)
+		msg := mailer.NewAdminLoginEmail(email, userAgent, ip, false, time.Now())
+		if err := h.sendEmail(ctx, msg); err != nil {
+			logger.Error("Failed to send admin login alert", "user_id", userID, "error", err.Error())
+		}
+	}
+	return deviceID, nil
+}
+
+// requestAdminDeviceApproval emails the admin a link approving the device,
+// replacing any earlier link for it
+func (h *Handler) requestAdminDeviceApproval(ctx context.Context, deviceID, email, userAgent, ip string) error {
+	token := randomToken()
+	expires := time.Now().Add(adminDeviceApprovalTTL)
+	if _, err := h.db.Pool().Exec(ctx, `
+		UPDATE admin_devices SET approval_token_hash = $2, approval_expires_at = $3 WHERE id = $1
+	`, deviceID, hashPasswordToken(token), expires); err != nil {
+		return errors.NewDatabaseError(err, "store device approval")
+	}
+	link := strings.TrimRight(h.config.AppURL, "/") + "/admin/devices/approve?token=" + url.QueryEscape(token)
+	if err := h.sendEmail(ctx, mailer.NewAdminDeviceApprovalEmail(email, userAgent, ip, link, expires)); err != nil {
+		return errors.NewInternalError(err, "Failed to send the device approval email")
+	}
+	return nil
+}
+
+// ApproveAdminDevice approves a device with the token emailed when it first
+// tried to sign in. The admin then signs in again from that device.
+func (h *Handler) ApproveAdminDevice(w http.ResponseWriter, r *http.Request) {
+	var req AdminDeviceApprovalRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	var deviceID, userID string
+	err := h.db.Pool().QueryRow(r.Context(), `
+		UPDATE admin_devices SET approved_at = NOW(), approval_token_hash = NULL, approval_expires_at = NULL
+		WHERE approval_token_hash = $1 AND approval_expires_at > NOW() AND revoked_at IS NULL
+		RETURNING id, user_id
+	`, hashPasswordToken(strings.TrimSpace(req.Token))).Scan(&deviceID, &userID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewValidationError("Invalid or expired link", "sign in again from the device to get a new link"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "approve admin device"), r)
+		return
+	}
+
+	logger.Info("Admin device approved", "device_id", deviceID, "user_id", userID)
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"status": "approved"})
+}
+
+// ValidateAdminSession runs on every admin-only request (see
+// middleware.SetAdminSessionCheck): the client address must be allowlisted and
+// the token's device must not have been revoked nor the admin suspended
+func (h *Handler) ValidateAdminSession(r *http.Request) error {
+	ctx := r.Context()
+	ip := middleware.ClientIP(r)
+	if !h.adminIPAllowed(ctx, ip) {
+		return errors.NewForbiddenError("Admin access is not allowed from this network")
+	}
+
+	deviceID := middleware.GetDeviceID(ctx)
+	if deviceID == "" {
+		return errors.NewUnauthorizedError("Session predates device tracking, please sign in again")
+	}
+	var active, suspended bool
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT d.revoked_at IS NULL AND d.approved_at IS NOT NULL, u.suspended_at IS NOT NULL
+		FROM admin_devices d
+		JOIN users u ON u.id = d.user_id
+		WHERE d.id = $1 AND d.user_id = $2
+	`, deviceID, middleware.GetUserID(ctx)).Scan(&active, &suspended)
+	if err != nil || !active {
+		return errors.NewUnauthorizedError("This device has been revoked")
+	}
+	if suspended {
+		return errSuspendedAccount()
+	}
+	return nil
+}
+
+// ListAdminDevices lists the caller's devices. Super admins may review another
+// admin's devices with ?user_id= or every admin's with ?all=true.
+func (h *Handler) ListAdminDevices(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	q := r.URL.Query()
+	all := false
+	if q.Get("user_id") != "" || q.Get("all") == "true" {
+		if middleware.GetRole(ctx) != "super_admin" {
+			h.respondError(w, errors.NewForbiddenError("Only a super admin can review other admins' devices"), r)
+			return
+		}
+		userID = q.Get("user_id")
+		all = userID == ""
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT d.id, d.user_id, u.email, COALESCE(d.user_agent, ''), COALESCE(d.first_ip, ''), COALESCE(d.last_ip, ''),
+			d.first_seen_at, d.last_seen_at, d.approved_at, d.revoked_at
+		FROM admin_devices d
+		JOIN users u ON u.id = d.user_id
+		WHERE $2 OR d.user_id = $1
+		ORDER BY d.revoked_at IS NOT NULL, d.last_seen_at DESC
+		LIMIT 200
+	`, userID, all)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list admin devices"), r)
+		return
+	}
+	defer rows.Close()
+
+	current := middleware.GetDeviceID(ctx)
+	devices := []models.AdminDevice{}
+	for rows.Next() {
+		var d models.AdminDevice
+		if err := rows.Scan(&d.ID, &d.UserID, &d.Email, &d.UserAgent, &d.FirstIP, &d.LastIP,
+			&d.FirstSeenAt, &d.LastSeenAt, &d.ApprovedAt, &d.RevokedAt); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan admin device"), r)
+			return
+		}
+		d.Current = d.ID == current
+		devices = append(devices, d)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"devices": devices})
+}
+
+// RevokeAdminDevice revokes a device: its sessions stop working immediately and
+// it can no longer be used to sign in. Admins may revoke their own devices;
+// super admins any device.
+func (h *Handler) RevokeAdminDevice(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	anyUser := middleware.GetRole(ctx) == "super_admin"
+
+	tag, err := h.db.Pool().Exec(ctx, `
+		UPDATE admin_devices SET revoked_at = NOW(), revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL AND ($3 OR user_id = $2)
+	`, r.PathValue("id"), userID, anyUser)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "revoke admin device"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Device"), r)
+		return
+	}
+
+	logger.Info("Admin device revoked", "device_id", r.PathValue("id"), "revoked_by", userID)
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// ListAdminIPAllowlist returns the admin IP allowlist; empty means unrestricted
+func (h *Handler) ListAdminIPAllowlist(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.loadAdminIPAllowlist(r.Context())
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list ip allowlist"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"entries":   entries,
+		"enforced":  len(entries) > 0,
+		"client_ip": middleware.ClientIP(r),
+	})
+}
+
+// AddAdminIPAllowlistEntry adds a range to the allowlist (super admin only). The
+// first entry turns enforcement on, so it must cover the caller's own address.
+func (h *Handler) AddAdminIPAllowlistEntry(w http.ResponseWriter, r *http.Request) {
+	var req AddIPAllowEntryRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	prefix, err := ipaccess.ParsePrefix(req.CIDR)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid CIDR", err.Error()), r)
+		return
+	}
+
+	ctx := r.Context()
+	entries, err := h.loadAdminIPAllowlist(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list ip allowlist"), r)
+		return
+	}
+	cidrs := []string{prefix.String()}
+	for _, e := range entries {
+		cidrs = append(cidrs, e.CIDR)
+	}
+	if !ipaccess.Allowed(middleware.ClientIP(r), cidrs) {
+		h.respondError(w, errors.NewBusinessRuleError("admin_lockout", "the allowlist would not include your current address "+middleware.ClientIP(r)), r)
+		return
+	}
+
+	entry := models.AdminIPAllowEntry{
+		ID:          uuid.New().String(),
+		CIDR:        prefix.String(),
+		Description: strings.TrimSpace(req.Description),
+		CreatedBy:   middleware.GetUserID(ctx),
+	}
+	err = h.db.Pool().QueryRow(ctx, `
+		INSERT INTO admin_ip_allowlist (id, cidr, description, created_by)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		ON CONFLICT (cidr) DO NOTHING
+		RETURNING created_at
+	`, entry.ID, entry.CIDR, entry.Description, entry.CreatedBy).Scan(&entry.CreatedAt)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewConflictError("Range already allowlisted", entry.CIDR), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "add ip allowlist entry"), r)
+		return
+	}
+
+	logger.Info("Admin IP allowlist entry added", "cidr", entry.CIDR, "admin_id", entry.CreatedBy)
+	h.respondJSON(w, r, http.StatusCreated, entry)
+}
+
+// DeleteAdminIPAllowlistEntry removes a range (super admin only), unless that
+// would lock the caller out. Removing the last entry turns enforcement off.
+func (h *Handler) DeleteAdminIPAllowlistEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	entries, err := h.loadAdminIPAllowlist(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list ip allowlist"), r)
+		return
+	}
+
+	found := false
+	var remaining []string
+	for _, e := range entries {
+		if e.ID == id {
+			found = true
+			continue
+		}
+		remaining = append(remaining, e.CIDR)
+	}
+	if !found {
+		h.respondError(w, errors.NewNotFoundError("Allowlist entry"), r)
+		return
+	}
+	if !ipaccess.Allowed(middleware.ClientIP(r), remaining) {
+		h.respondError(w, errors.NewBusinessRuleError("admin_lockout", "removing this range would block your current address "+middleware.ClientIP(r)), r)
+		return
+	}
+
+	if _, err := h.db.Pool().Exec(ctx, `DELETE FROM admin_ip_allowlist WHERE id = $1`, id); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete ip allowlist entry"), r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminIPAllowed checks an address against the allowlist. If the allowlist can't
+// be read the address is rejected rather than silently allowed.
+func (h *Handler) adminIPAllowed(ctx context.Context, ip string) bool {
+	entries, err := h.loadAdminIPAllowlist(ctx)
+	if err != nil {
+		logger.Error("Failed to load admin IP allowlist", "error", err.Error())
+		return false
+	}
+	cidrs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		cidrs = append(cidrs, e.CIDR)
+	}
+	return ipaccess.Allowed(ip, cidrs)
+}
+
+func (h *Handler) loadAdminIPAllowlist(ctx context.Context) ([]models.AdminIPAllowEntry, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, cidr, COALESCE(description, ''), COALESCE(created_by, ''), created_at
+		FROM admin_ip_allowlist
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AdminIPAllowEntry{}
+	for rows.Next() {
+		var e models.AdminIPAllowEntry
+		if err := rows.Scan(&e.ID, &e.CIDR, &e.Description, &e.CreatedBy, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// adminDeviceKey identifies the client device by the random, locally stored
+// X-Device-ID web and mobile clients send; "" when it is missing or implausible.
+// The user agent is not used instead, as anyone can send the same one.
+func adminDeviceKey(r *http.Request) string {
+	id := strings.TrimSpace(r.Header.Get("X-Device-ID"))
+	if len(id) < 16 || len(id) > 200 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/mailer"
+	"github.com/bantuaku/backend/validation"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password token purposes
+const (
+	passwordTokenInvite = "invite"
+	passwordTokenReset  = "reset"
+)
+
+// AdminCreateUserRequest creates a user with a store. Without a password the user
+// is emailed an invitation to set one.
+type AdminCreateUserRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password,omitempty"`
+	StoreName string `json:"store_name" validate:"required,max:255"`
+	Industry  string `json:"industry,omitempty" validate:"max:100"`
+	Role      string `json:"role,omitempty"`
+}
+
+// AdminCreateUserResponse describes the created user
+type AdminCreateUserResponse struct {
+	UserID          string     `json:"user_id"`
+	StoreID         string     `json:"store_id"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	InvitationSent  bool       `json:"invitation_sent"`
+	InviteExpiresAt *time.Time `json:"invite_expires_at,omitempty"`
+}
+
+// PasswordLinkResponse reports an emailed set-password link
+type PasswordLinkResponse struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	Purpose   string    `json:"purpose"`
+	Sent      bool      `json:"sent"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SetPasswordRequest redeems an emailed invitation or reset token
+type SetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min:6"`
+}
+
+// AdminCreateUser creates a user and store. Supplying a password sets it directly;
+// omitting it leaves the account without one and emails a set-password invitation.
+func (h *Handler) AdminCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req AdminCreateUserRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	if req.Role == "" {
+		req.Role = "user"
+	}
+	switch req.Role {
+	case "user":
+	case "admin", "super_admin":
+		if middleware.GetRole(r.Context()) != "super_admin" {
+			h.respondError(w, errors.NewForbiddenError("Only a super admin can create admin users"), r)
+			return
+		}
+	default:
+		h.respondError(w, errors.NewValidationError("Invalid role", "role must be user, admin or super_admin"), r)
+		return
+	}
+	if req.Password != "" && len(req.Password) < 6 {
+		h.respondError(w, errors.NewValidationError("Password too short", "password must be at least 6 characters"), r)
+		return
+	}
+
+	ctx := r.Context()
+	var existingID string
+	if err := h.db.Pool().QueryRow(ctx, "SELECT id FROM users WHERE email = $1", req.Email).Scan(&existingID); err == nil {
+		h.respondError(w, errors.NewConflictError("Email already registered", "A user with this email already exists"), r)
+		return
+	}
+
+	var passwordHash *string
+	if req.Password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			h.respondError(w, errors.NewInternalError(err, "Failed to process password"), r)
+			return
+		}
+		s := string(hashed)
+		passwordHash = &s
+	}
+
+	userID := uuid.New().String()
+	storeID := uuid.New().String()
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO users (id, email, password_hash, role, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, userID, req.Email, passwordHash, req.Role, time.Now()); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create user"), r)
+		return
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO companies (id, owner_user_id, name, industry, subscription_plan, status, created_at)
+		VALUES ($1, $2, $3, $4, 'free', 'active', $5)
+	`, storeID, userID, req.StoreName, req.Industry, time.Now()); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create store"), r)
+		return
+	}
+	// Login finds the user's company through their membership
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO company_members (company_id, user_id, role, source)
+		VALUES ($1, $2, 'owner', 'owner')
+	`, storeID, userID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "add company owner"), r)
+		return
+	}
+
+	resp := AdminCreateUserResponse{UserID: userID, StoreID: storeID, Email: req.Email, Role: req.Role}
+	var token string
+	if passwordHash == nil {
+		expires := time.Now().Add(time.Duration(h.config.InviteTokenHours) * time.Hour)
+		token, err = issuePasswordToken(ctx, tx, userID, passwordTokenInvite, middleware.GetUserID(ctx), expires)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "create invitation"), r)
+			return
+		}
+		resp.InviteExpiresAt = &expires
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	if token != "" {
+		msg := mailer.InviteEmail(req.Email, h.setPasswordLink(token), *resp.InviteExpiresAt)
+		if err := h.sendEmail(ctx, msg); err != nil {
+			// The user exists; the admin can send a fresh link with the reset action
+			logger.Error("Failed to send invitation email", "user_id", userID, "error", err.Error())
+		} else {
+			resp.InvitationSent = true
+		}
+	}
+
+	h.respondJSON(w, r, http.StatusCreated, resp)
+}
+
+// AdminSendPasswordResetLink emails an existing user a one-time link to set a new
+// password. Users who never accepted their invitation get a fresh invitation.
+func (h *Handler) AdminSendPasswordResetLink(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+	ctx := r.Context()
+
+	var email string
+	var hasPassword, ssoManaged bool
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT email, password_hash IS NOT NULL, sso_config_id IS NOT NULL FROM users WHERE id = $1
+	`, userID).Scan(&email, &hasPassword, &ssoManaged)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("user"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load user"), r)
+		return
+	}
+	if ssoManaged {
+		h.respondError(w, errors.NewBusinessRuleError("sso_managed", "the user signs in through single sign-on and has no password"), r)
+		return
+	}
+
+	purpose, hours := passwordTokenReset, h.config.ResetTokenHours
+	if !hasPassword {
+		purpose, hours = passwordTokenInvite, h.config.InviteTokenHours
+	}
+	expires := time.Now().Add(time.Duration(hours) * time.Hour)
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Only the newest link works
+	if _, err := tx.Exec(ctx, `
+		UPDATE password_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "revoke password tokens"), r)
+		return
+	}
+	token, err := issuePasswordToken(ctx, tx, userID, purpose, middleware.GetUserID(ctx), expires)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create password token"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	msg := mailer.ResetEmail(email, h.setPasswordLink(token), expires)
+	if purpose == passwordTokenInvite {
+		msg = mailer.InviteEmail(email, h.setPasswordLink(token), expires)
+	}
+	if err := h.sendEmail(ctx, msg); err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to send email"), r)
+		return
+	}
+
+	logger.Info("Password link sent", "user_id", userID, "purpose", purpose, "admin_id", middleware.GetUserID(ctx))
+	h.respondJSON(w, r, http.StatusOK, PasswordLinkResponse{
+		UserID:    userID,
+		Email:     email,
+		Purpose:   purpose,
+		Sent:      true,
+		ExpiresAt: expires,
+	})
+}
+
+// SetPassword redeems an invitation or reset token and sets the user's password.
+// The token is single-use; redeeming it revokes the user's other outstanding links.
+func (h *Handler) SetPassword(w http.ResponseWriter, r *http.Request) {
+	var req SetPasswordRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to process password"), r)
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var userID string
+	err = tx.QueryRow(ctx, `
+		UPDATE password_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`, hashPasswordToken(req.Token)).Scan(&userID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewValidationError("Invalid or expired link", "request a new link from your administrator"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "redeem password token"), r)
+		return
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET password_hash = $2 WHERE id = $1 AND sso_config_id IS NULL`, userID, string(hashed))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "set password"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewValidationError("Invalid or expired link", "this account signs in through single sign-on"), r)
+		return
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE password_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "revoke password tokens"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"status": "password_set"})
+}
+
+// setPasswordLink builds the web app URL that redeems a password token
+func (h *Handler) setPasswordLink(token string) string {
+	return strings.TrimRight(h.config.AppURL, "/") + "/set-password?token=" + url.QueryEscape(token)
+}
+
+// issuePasswordToken stores the hash of a new random token and returns the token
+func issuePasswordToken(ctx context.Context, tx pgx.Tx, userID, purpose, createdBy string, expires time.Time) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	var creator *string
+	if createdBy != "" {
+		creator = &createdBy
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO password_tokens (token_hash, user_id, purpose, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+	`, hashPasswordToken(token), userID, purpose, expires, creator); err != nil {
+		return "", fmt.Errorf("insert password token: %w", err)
+	}
+	return token, nil
+}
+
+func hashPasswordToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AdminDeleteUser deletes a user account. Admin accounts can only be deleted by a
+// super admin, nobody can delete themselves, and owners must have their
+// companies removed or transferred first, since deleting the owner cascades to
+// the company's data.
+func (h *Handler) AdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := r.PathValue("id")
+	if userID == middleware.GetUserID(ctx) {
+		h.respondError(w, errors.NewBusinessRuleError("self_delete", "You cannot delete your own account"), r)
+		return
+	}
+
+	var role string
+	var ownedCompanies int
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(role, 'user'), (SELECT COUNT(*) FROM companies WHERE owner_user_id = u.id)
+		FROM users u WHERE id = $1
+	`, userID).Scan(&role, &ownedCompanies)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("User"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load user"), r)
+		return
+	}
+	if isPrivilegedRole(role) && middleware.GetRole(ctx) != "super_admin" {
+		h.respondError(w, errors.NewForbiddenError("Only a super admin can delete admin users"), r)
+		return
+	}
+	if ownedCompanies > 0 {
+		h.respondError(w, errors.NewConflictError("User owns companies",
+			fmt.Sprintf("the user owns %d companies; transfer or delete them first", ownedCompanies)), r)
+		return
+	}
+
+	if _, err := h.db.Pool().Exec(ctx, "DELETE FROM users WHERE id = $1", userID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete user"), r)
+		return
+	}
+	logger.Info("User deleted by admin", "user_id", userID, "admin_id", middleware.GetUserID(ctx))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -12,26 +12,29 @@ import (
 
 	"bantuaku/backend/services/kolosal"
 
+	"github.com/bantuaku/backend/errors"
 	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/portfolio"
 )
 
 // AIAnalyze handles AI analysis questions
 func (h *Handler) AIAnalyze(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
 	var req models.AIAnalyzeRequest
-	if err := parseJSON(r, &req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid request body", ""), r)
 		return
 	}
 
 	if strings.TrimSpace(req.Question) == "" {
-		respondError(w, http.StatusBadRequest, "Question is required")
+		h.respondError(w, errors.NewValidationError("Question is required", ""), r)
 		return
 	}
 
@@ -41,7 +44,7 @@ func (h *Handler) AIAnalyze(w http.ResponseWriter, r *http.Request) {
 	if err == nil && cached != "" {
 		var response models.AIAnalyzeResponse
 		if json.Unmarshal([]byte(cached), &response) == nil {
-			respondJSON(w, http.StatusOK, response)
+			h.respondJSON(w, r, http.StatusOK, response)
 			return
 		}
 	}
@@ -51,7 +54,7 @@ func (h *Handler) AIAnalyze(w http.ResponseWriter, r *http.Request) {
 	storeContext := h.gatherStoreContext(ctx, storeID)
 
 	// Build prompt
-	systemPrompt := buildSystemPrompt()
+	systemPrompt := h.withBrandVoice(ctx, storeID, buildSystemPrompt())
 	userPrompt := buildUserPrompt(req.Question, storeContext)
 
 	// Call Kolosal.ai (or return mock response if no API key)
@@ -60,9 +63,9 @@ func (h *Handler) AIAnalyze(w http.ResponseWriter, r *http.Request) {
 	dataSources := []string{}
 
 	if h.config.KolosalAPIKey != "" {
-		client := kolosal.NewClient(h.config.KolosalAPIKey)
+		client := h.kolosalClient()
 
-		resp, err := client.CreateChatCompletion(ctx, kolosal.ChatCompletionRequest{
+		resp, err := client.CreateChatCompletion(aispend.WithFeature(ctx, aispend.FeatureChat, ""), kolosal.ChatCompletionRequest{
 			Model: "default", // Use default model from Kolosal.ai
 			Messages: []kolosal.ChatCompletionMessage{
 				{Role: "system", Content: systemPrompt},
@@ -95,7 +98,7 @@ func (h *Handler) AIAnalyze(w http.ResponseWriter, r *http.Request) {
 	cacheData, _ := json.Marshal(response)
 	h.redis.Set(ctx, cacheKey, string(cacheData), 24*time.Hour)
 
-	respondJSON(w, http.StatusOK, response)
+	h.respondJSON(w, r, http.StatusOK, response)
 }
 
 // StoreContext holds contextual data for AI
@@ -105,6 +108,8 @@ type StoreContext struct {
 	TopProducts   []ProductSummary
 	RecentRevenue float64
 	ForecastData  string
+	FocusProducts []string
+	Health        string // Health score and weakest factors to prioritize
 }
 
 type ProductSummary struct {
@@ -150,6 +155,15 @@ func (h *Handler) gatherStoreContext(ctx context.Context, storeID string) StoreC
 		WHERE store_id = $1 AND sale_date >= $2
 	`, storeID, time.Now().AddDate(0, 0, -30)).Scan(&sc.RecentRevenue)
 
+	// A-class products the advice should prioritize
+	if results, err := h.classifyPortfolio(ctx, storeID, defaultPortfolioWindowDays); err == nil {
+		sc.FocusProducts = portfolio.FocusProducts(results)
+	}
+
+	if score, err := h.computeBusinessHealth(ctx, storeID, h.companyLocation(ctx, storeID)); err == nil {
+		sc.Health = healthPromptContext(score)
+	}
+
 	return sc
 }
 
@@ -184,6 +198,14 @@ func buildUserPrompt(question string, sc StoreContext) string {
 		}
 	}
 
+	if len(sc.FocusProducts) > 0 {
+		sb.WriteString(fmt.Sprintf("\nProduk fokus (kelas A, prioritaskan dalam saran forecast dan marketing): %s\n", strings.Join(sc.FocusProducts, ", ")))
+	}
+
+	if sc.Health != "" {
+		sb.WriteString("\n" + sc.Health + "\n")
+	}
+
 	sb.WriteString(fmt.Sprintf("\nPertanyaan: %s", question))
 
 	return sb.String()
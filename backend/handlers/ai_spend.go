@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/cachebus"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/mailer"
+	"github.com/bantuaku/backend/services/settings"
+)
+
+// aiSpendStatusTTL bounds how stale a cached spend status may be
+const aiSpendStatusTTL = time.Minute
+
+// aiSpendStatus is the spend of a company and of the platform against their
+// caps this month
+type aiSpendStatus struct {
+	Global  aispend.Status `json:"global"`
+	Company aispend.Status `json:"company"`
+}
+
+// Level is the more restricted level of the two caps
+func (s aiSpendStatus) Level() string {
+	return aispend.Worst(s.Global.Level, s.Company.Level)
+}
+
+// AISpendModelUsage is the spend on one model this month
+type AISpendModelUsage struct {
+	Model            string  `json:"model"`
+	Calls            int     `json:"calls"`
+	EstimatedCalls   int     `json:"estimated_calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// AISpendCompany is a company's spend against its cap this month
+type AISpendCompany struct {
+	CompanyID   string `json:"company_id"`
+	CompanyName string `json:"company_name"`
+	CustomCap   bool   `json:"custom_cap"` // False when the platform default applies
+	aispend.Status
+}
+
+// UpdateAISpendCapRequest sets a company's monthly cap; null returns it to the
+// platform default and 0 makes it unlimited
+type UpdateAISpendCapRequest struct {
+	CapUSD *float64 `json:"cap_usd"`
+}
+
+// aiSpendSettings returns the admin-configured caps
+func (h *Handler) aiSpendSettings() aispend.Settings {
+	var s aispend.Settings
+	h.settings.Decode(settings.KeyAISpend, &s)
+	return s
+}
+
+// GetAISpend returns this month's AI spend: the global cap status, spend per
+// model and the companies spending the most (admin only)
+func (h *Handler) GetAISpend(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	month := aispend.MonthStart(time.Now())
+	cfg := h.aiSpendSettings()
+
+	var spent float64
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COALESCE(SUM(cost_usd), 0)::float8 FROM ai_spend WHERE month = $1`, month).Scan(&spent); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load AI spend"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT model, SUM(calls)::int, SUM(estimated_calls)::int, SUM(prompt_tokens)::bigint, SUM(completion_tokens)::bigint, SUM(cost_usd)::float8
+		FROM ai_spend WHERE month = $1
+		GROUP BY model ORDER BY SUM(cost_usd) DESC
+	`, month)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load AI spend"), r)
+		return
+	}
+	models := []AISpendModelUsage{}
+	for rows.Next() {
+		var m AISpendModelUsage
+		if rows.Scan(&m.Model, &m.Calls, &m.EstimatedCalls, &m.PromptTokens, &m.CompletionTokens, &m.CostUSD) == nil {
+			models = append(models, m)
+		}
+	}
+	rows.Close()
+
+	rows, err = h.db.Pool().Query(ctx, `
+		SELECT c.id, c.name, c.ai_spend_cap_usd IS NOT NULL, COALESCE(c.ai_spend_cap_usd, $2)::float8, SUM(s.cost_usd)::float8
+		FROM ai_spend s JOIN companies c ON c.id = s.company_id
+		WHERE s.month = $1
+		GROUP BY c.id, c.name, c.ai_spend_cap_usd
+		ORDER BY SUM(s.cost_usd) DESC
+		LIMIT 50
+	`, month, cfg.CompanyMonthlyUSD)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load AI spend"), r)
+		return
+	}
+	companies := []AISpendCompany{}
+	for rows.Next() {
+		var c AISpendCompany
+		var cap, companySpent float64
+		if rows.Scan(&c.CompanyID, &c.CompanyName, &c.CustomCap, &cap, &companySpent) == nil {
+			c.Status = aispend.NewStatus(companySpent, cap, cfg.Threshold())
+			companies = append(companies, c)
+		}
+	}
+	rows.Close()
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"month":     month.Format("2006-01"),
+		"settings":  cfg,
+		"global":    aispend.NewStatus(spent, cfg.GlobalMonthlyUSD, cfg.Threshold()),
+		"models":    models,
+		"companies": companies,
+	})
+}
+
+// UpdateAISpendSettings replaces the AI spending caps (super admin only)
+func (h *Handler) UpdateAISpendSettings(w http.ResponseWriter, r *http.Request) {
+	var req aispend.Settings
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	req.CheapModel = strings.TrimSpace(req.CheapModel)
+	if err := req.Validate(); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid AI spend settings", err.Error()), r)
+		return
+	}
+
+	ctx := r.Context()
+	if err := h.saveSetting(ctx, settings.KeyAISpend, req); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save AI spend settings"), r)
+		return
+	}
+	h.invalidate(ctx, cachebus.KindSettings)
+	h.spend.Delete()
+
+	logger.Info("AI spend settings updated", "global_monthly_usd", req.GlobalMonthlyUSD, "company_monthly_usd", req.CompanyMonthlyUSD,
+		"admin_id", middleware.GetUserID(ctx))
+	h.respondJSON(w, r, http.StatusOK, req)
+}
+
+// AdminUpdateAISpendCap sets one company's monthly AI spend cap (admin only)
+func (h *Handler) AdminUpdateAISpendCap(w http.ResponseWriter, r *http.Request) {
+	var req UpdateAISpendCapRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.CapUSD != nil && *req.CapUSD < 0 {
+		h.respondError(w, errors.NewValidationError("Validation failed", "cap_usd: must not be negative"), r)
+		return
+	}
+
+	ctx := r.Context()
+	companyID := r.PathValue("id")
+	tag, err := h.db.Pool().Exec(ctx, `UPDATE companies SET ai_spend_cap_usd = $2, updated_at = NOW() WHERE id = $1`, companyID, req.CapUSD)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update AI spend cap"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	h.spend.Delete(companyID)
+
+	status, err := h.loadAISpendStatus(ctx, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load AI spend"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"company_id": companyID,
+		"custom_cap": req.CapUSD != nil,
+		"status":     status.Company,
+	})
+}
+
+// aiSpend returns the spend status of a company ("" for the platform only),
+// cached briefly since it is checked before every completion
+func (h *Handler) aiSpend(ctx context.Context, companyID string) aiSpendStatus {
+	if cached, ok := h.spend.Get(companyID); ok {
+		var status aiSpendStatus
+		if json.Unmarshal([]byte(cached), &status) == nil {
+			return status
+		}
+	}
+	status, err := h.loadAISpendStatus(ctx, companyID)
+	if err != nil {
+		logger.Warn("Failed to load AI spend", "company_id", companyID, "error", err.Error())
+	}
+	return status
+}
+
+// loadAISpendStatus reads the month's spend and refreshes the cache
+func (h *Handler) loadAISpendStatus(ctx context.Context, companyID string) (aiSpendStatus, error) {
+	cfg := h.aiSpendSettings()
+	status := aiSpendStatus{
+		Global:  aispend.NewStatus(0, 0, cfg.Threshold()),
+		Company: aispend.NewStatus(0, 0, cfg.Threshold()),
+	}
+	var global, company, cap float64
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(SUM(cost_usd), 0)::float8,
+			COALESCE(SUM(cost_usd) FILTER (WHERE company_id = $2 AND $2 <> ''), 0)::float8,
+			COALESCE((SELECT ai_spend_cap_usd FROM companies WHERE id = $2), $3)::float8
+		FROM ai_spend WHERE month = $1
+	`, aispend.MonthStart(time.Now()), companyID, cfg.CompanyMonthlyUSD).Scan(&global, &company, &cap)
+	if err != nil {
+		return status, err
+	}
+	status.Global = aispend.NewStatus(global, cfg.GlobalMonthlyUSD, cfg.Threshold())
+	if companyID != "" {
+		status.Company = aispend.NewStatus(company, cap, cfg.Threshold())
+	}
+	if data, err := json.Marshal(status); err == nil {
+		h.spend.Set(companyID, string(data))
+	}
+	return status, nil
+}
+
+// spendModel downgrades completions to the cheaper model while the platform
+// or the calling company is past a cap's throttle threshold
+func (h *Handler) spendModel(ctx context.Context, model string) string {
+	cheap := h.aiSpendSettings().CheapModel
+	if cheap == "" {
+		return ""
+	}
+	if h.aiSpend(ctx, middleware.GetCompanyID(ctx)).Level() == aispend.LevelOK {
+		return ""
+	}
+	return cheap
+}
+
+// aiJobsDeferred reports whether non-urgent AI work for a company, such as
+// scheduled jobs, should wait because a spending cap is reached. Deferred jobs
+// stay due and run once the cap is raised or the month turns over.
+func (h *Handler) aiJobsDeferred(ctx context.Context, companyID string) bool {
+	return h.aiSpend(ctx, companyID).Level() == aispend.LevelCapped
+}
+
+// spendRecorder meters the token spend of every provider call
+type spendRecorder struct {
+	h *Handler
+}
+
+func (s spendRecorder) Record(ctx context.Context, call kolosal.Call) {
+	// Rejected calls are not billed; a stream cut short after starting is
+	if call.StatusCode != http.StatusOK {
+		return
+	}
+	h := s.h
+	ctx = context.WithoutCancel(ctx)
+	cfg := h.aiSpendSettings()
+	usage := aispend.ParseUsage(call.Request, call.Response)
+	if aispend.IsOCR(call.Endpoint) {
+		usage = aispend.OCRUsage(call.Response)
+	}
+	if usage.Model == "" {
+		usage.Model = kolosal.DefaultModel
+	}
+	estimated := 0
+	if usage.Estimated {
+		estimated = 1
+	}
+	cost := cfg.Cost(usage)
+	companyID := middleware.GetCompanyID(ctx)
+	h.recordAIUsage(ctx, companyID, call.Endpoint, usage, cost)
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO ai_spend (company_id, month, model, calls, estimated_calls, prompt_tokens, completion_tokens, cost_usd, updated_at)
+		VALUES ($1, $2, $3, 1, $4, $5, $6, $7, NOW())
+		ON CONFLICT (company_id, month, model) DO UPDATE
+		SET calls = ai_spend.calls + 1, estimated_calls = ai_spend.estimated_calls + EXCLUDED.estimated_calls,
+			prompt_tokens = ai_spend.prompt_tokens + EXCLUDED.prompt_tokens,
+			completion_tokens = ai_spend.completion_tokens + EXCLUDED.completion_tokens,
+			cost_usd = ai_spend.cost_usd + EXCLUDED.cost_usd, updated_at = NOW()
+	`, companyID, aispend.MonthStart(time.Now()), usage.Model, estimated, usage.PromptTokens, usage.CompletionTokens, cost)
+	if err != nil {
+		logger.Error("Failed to record AI spend", "company_id", companyID, "model", usage.Model, "error", err.Error())
+		return
+	}
+	if cfg.GlobalMonthlyUSD <= 0 && cfg.CompanyMonthlyUSD <= 0 && companyID == "" {
+		return
+	}
+
+	status, err := h.loadAISpendStatus(ctx, companyID)
+	if err != nil {
+		return
+	}
+	h.alertAISpend(ctx, "", status.Global)
+	if companyID != "" {
+		h.alertAISpend(ctx, companyID, status.Company)
+	}
+}
+
+// alertAISpend tells platform admins, once per level and month, that a cap's
+// throttle threshold or the cap itself was reached. scope is a company ID, or
+// "" for the global cap.
+func (h *Handler) alertAISpend(ctx context.Context, scope string, status aispend.Status) {
+	if status.Level == aispend.LevelOK {
+		return
+	}
+	tag, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO ai_spend_alerts (scope, month, level, spent_usd, cap_usd)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, month, level) DO NOTHING
+	`, scope, aispend.MonthStart(time.Now()), status.Level, status.SpentUSD, status.CapUSD)
+	if err != nil || tag.RowsAffected() == 0 {
+		return
+	}
+
+	name := "platform"
+	if scope != "" {
+		h.db.Pool().QueryRow(ctx, `SELECT name FROM companies WHERE id = $1`, scope).Scan(&name)
+	}
+	logger.Warn("AI spend cap alert", "scope", name, "company_id", scope, "level", status.Level,
+		"spent_usd", status.SpentUSD, "cap_usd", status.CapUSD)
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT email FROM users WHERE role IN ('admin', 'super_admin') AND suspended_at IS NULL
+	`)
+	if err != nil {
+		logger.Warn("Failed to list admins for AI spend alert", "error", err.Error())
+		return
+	}
+	var emails []string
+	for rows.Next() {
+		var email string
+		if rows.Scan(&email) == nil && email != "" {
+			emails = append(emails, email)
+		}
+	}
+	rows.Close()
+	link := strings.TrimRight(h.config.AppURL, "/") + "/admin/ai-spend"
+	for _, email := range emails {
+		if err := h.sendEmail(ctx, mailer.AISpendAlertEmail(email, name, status.Level, status.SpentUSD, status.CapUSD, link)); err != nil {
+			logger.Warn("Failed to email AI spend alert", "error", err.Error())
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/services/aispend"
+)
+
+// AIFeatureUsage is the AI usage of one feature over a period
+type AIFeatureUsage struct {
+	Feature          string  `json:"feature"`
+	Calls            int     `json:"calls"`
+	EstimatedCalls   int     `json:"estimated_calls"`
+	References       int     `json:"references"` // Distinct jobs, insights, conversations or records served
+	Companies        int     `json:"companies"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	CostPerReference float64 `json:"cost_per_reference,omitempty"` // Average cost of one job or record
+	CostPerCompany   float64 `json:"cost_per_company,omitempty"`
+}
+
+// AICompanyUsage is a company's AI cost over a period, per feature
+type AICompanyUsage struct {
+	CompanyID   string             `json:"company_id"`
+	CompanyName string             `json:"company_name"`
+	Plan        string             `json:"plan"`
+	Calls       int                `json:"calls"`
+	CostUSD     float64            `json:"cost_usd"`
+	Features    map[string]float64 `json:"features"` // Cost per feature
+}
+
+// recordAIUsage stores one provider call with what it was made for
+func (h *Handler) recordAIUsage(ctx context.Context, companyID, endpoint string, usage aispend.Usage, cost float64) {
+	a := aispend.AttributionOf(ctx, endpoint)
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO ai_usage_events (company_id, feature, reference_id, step, endpoint, model, prompt_tokens, completion_tokens, estimated, cost_usd)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, $9, $10)
+	`, companyID, a.Feature, a.ReferenceID, a.Step, endpoint, usage.Model, usage.PromptTokens, usage.CompletionTokens, usage.Estimated, cost)
+	if err != nil {
+		logger.Error("Failed to record AI usage", "company_id", companyID, "feature", a.Feature, "error", err.Error())
+	}
+}
+
+// GetAIUsageByFeature reports AI cost per feature, and per company and
+// feature, between ?from= and ?to= (YYYY-MM-DD, UTC; default this month).
+// ?company_id= narrows the report to one company (admin only).
+func (h *Handler) GetAIUsageByFeature(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	from, to := aispend.MonthStart(time.Now()), time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	for _, bound := range []struct {
+		param string
+		dest  *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		s := q.Get(bound.param)
+		if s == "" {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			h.respondError(w, errors.NewValidationError("Invalid "+bound.param, bound.param+" must be a date in YYYY-MM-DD format"), r)
+			return
+		}
+		if bound.param == "to" {
+			day = day.AddDate(0, 0, 1)
+		}
+		*bound.dest = day
+	}
+	if !from.Before(to) {
+		h.respondError(w, errors.NewValidationError("Invalid range", "from must not be after to"), r)
+		return
+	}
+	companyID := q.Get("company_id")
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT feature, COUNT(*)::int, COUNT(*) FILTER (WHERE estimated)::int, COUNT(DISTINCT reference_id)::int,
+			COUNT(DISTINCT NULLIF(company_id, ''))::int, COALESCE(SUM(prompt_tokens), 0)::bigint,
+			COALESCE(SUM(completion_tokens), 0)::bigint, COALESCE(SUM(cost_usd), 0)::float8
+		FROM ai_usage_events
+		WHERE created_at >= $1 AND created_at < $2 AND ($3 = '' OR company_id = $3)
+		GROUP BY feature
+		ORDER BY SUM(cost_usd) DESC
+	`, from, to, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load AI usage"), r)
+		return
+	}
+	features := []AIFeatureUsage{}
+	var total float64
+	for rows.Next() {
+		var f AIFeatureUsage
+		if err := rows.Scan(&f.Feature, &f.Calls, &f.EstimatedCalls, &f.References, &f.Companies,
+			&f.PromptTokens, &f.CompletionTokens, &f.CostUSD); err != nil {
+			rows.Close()
+			h.respondError(w, errors.NewDatabaseError(err, "scan AI usage"), r)
+			return
+		}
+		if f.References > 0 {
+			f.CostPerReference = roundUSD(f.CostUSD / float64(f.References))
+		}
+		if f.Companies > 0 {
+			f.CostPerCompany = roundUSD(f.CostUSD / float64(f.Companies))
+		}
+		total += f.CostUSD
+		f.CostUSD = roundUSD(f.CostUSD)
+		features = append(features, f)
+	}
+	rows.Close()
+
+	rows, err = h.db.Pool().Query(ctx, `
+		WITH top AS (
+			SELECT company_id, SUM(cost_usd) AS cost FROM ai_usage_events
+			WHERE created_at >= $1 AND created_at < $2 AND company_id <> '' AND ($3 = '' OR company_id = $3)
+			GROUP BY company_id
+			ORDER BY cost DESC
+			LIMIT 50
+		)
+		SELECT e.company_id, c.name, COALESCE(c.subscription_plan, 'free'), e.feature, COUNT(*)::int, SUM(e.cost_usd)::float8
+		FROM ai_usage_events e
+		JOIN top t ON t.company_id = e.company_id
+		JOIN companies c ON c.id = e.company_id
+		WHERE e.created_at >= $1 AND e.created_at < $2
+		GROUP BY e.company_id, c.name, c.subscription_plan, e.feature, t.cost
+		ORDER BY t.cost DESC, e.company_id
+	`, from, to, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load AI usage"), r)
+		return
+	}
+	defer rows.Close()
+	companies := []*AICompanyUsage{}
+	for rows.Next() {
+		var id, name, plan, feature string
+		var calls int
+		var cost float64
+		if err := rows.Scan(&id, &name, &plan, &feature, &calls, &cost); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan AI usage"), r)
+			return
+		}
+		if len(companies) == 0 || companies[len(companies)-1].CompanyID != id {
+			companies = append(companies, &AICompanyUsage{CompanyID: id, CompanyName: name, Plan: plan, Features: map[string]float64{}})
+		}
+		c := companies[len(companies)-1]
+		c.Calls += calls
+		c.CostUSD = roundUSD(c.CostUSD + cost)
+		c.Features[feature] = roundUSD(cost)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"from":      from.Format("2006-01-02"),
+		"to":        to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"cost_usd":  roundUSD(total),
+		"features":  features,
+		"companies": companies,
+	})
+}
+
+// PurgeAIUsage deletes per-call AI usage older than AI_USAGE_RETENTION_DAYS.
+// It is run periodically from main.
+func (h *Handler) PurgeAIUsage(ctx context.Context) {
+	cutoff := time.Now().AddDate(0, 0, -h.config.AIUsageRetentionDays)
+	tag, err := h.db.Pool().Exec(ctx, `DELETE FROM ai_usage_events WHERE created_at < $1`, cutoff)
+	if err != nil {
+		logger.Error("Failed to purge AI usage", "error", err.Error())
+		return
+	}
+	if n := tag.RowsAffected(); n > 0 {
+		logger.Info("Purged old AI usage", "count", n, "before", cutoff.Format(time.RFC3339))
+	}
+}
+
+// roundUSD rounds an amount to a hundredth of a cent
+func roundUSD(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/quality"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SalesAnomaly is a flagged sales record with product context
+type SalesAnomaly struct {
+	quality.Anomaly
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Quantity    float64   `json:"quantity"`
+	Price       float64   `json:"price"`
+	SaleDate    time.Time `json:"sale_date"`
+	Source      string    `json:"source"`
+}
+
+// ReviewSaleRequest represents the user's decision on a flagged sales record
+type ReviewSaleRequest struct {
+	Action   string  `json:"action" validate:"required,oneof:confirm|correct|exclude"`
+	Quantity float64 `json:"quantity"` // In the product's unit; required when action is "correct"
+}
+
+// ListSalesAnomalies flags suspicious sales records that have not been reviewed yet
+func (h *Handler) ListSalesAnomalies(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	window := defaultQualityWindowDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 7 || n > maxQualityWindowDays {
+			h.respondError(w, errors.NewValidationError("Invalid days", "days must be between 7 and 365"), r)
+			return
+		}
+		window = n
+	}
+
+	// Reviewed rows stay in the baseline so confirmed spikes still shape the median
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT s.id, s.product_id, p.name, s.quantity, s.price, s.sale_date, s.source, s.review_status IS NOT NULL
+		FROM sales_history s
+		JOIN products p ON p.id = s.product_id
+		WHERE s.company_id = $1 AND s.sale_date >= $2 AND NOT COALESCE(s.excluded, false)
+	`, companyID, localDate(time.Now(), h.companyLocation(r.Context(), companyID)).AddDate(0, 0, -window))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sales history"), r)
+		return
+	}
+	defer rows.Close()
+
+	var records []quality.SaleRecord
+	details := map[int64]SalesAnomaly{}
+	reviewed := map[int64]bool{}
+	for rows.Next() {
+		var rec quality.SaleRecord
+		var productName string
+		var isReviewed bool
+		if err := rows.Scan(&rec.ID, &rec.ProductID, &productName, &rec.Quantity, &rec.Price, &rec.SaleDate, &rec.Source, &isReviewed); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan sales history"), r)
+			return
+		}
+		records = append(records, rec)
+		reviewed[rec.ID] = isReviewed
+		details[rec.ID] = SalesAnomaly{
+			ProductID:   rec.ProductID,
+			ProductName: productName,
+			Quantity:    rec.Quantity,
+			Price:       rec.Price,
+			SaleDate:    rec.SaleDate,
+			Source:      rec.Source,
+		}
+	}
+
+	anomalies := []SalesAnomaly{}
+	for _, a := range quality.DetectAnomalies(records) {
+		if reviewed[a.SaleID] {
+			continue
+		}
+		item := details[a.SaleID]
+		item.Anomaly = a
+		anomalies = append(anomalies, item)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, anomalies)
+}
+
+// ReviewSale confirms, corrects or excludes a sales record.
+// Excluded records are ignored by forecasting and quality scoring.
+func (h *Handler) ReviewSale(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	saleID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid sale ID", r.PathValue("id")), r)
+		return
+	}
+
+	var req ReviewSaleRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.Action == "correct" && req.Quantity <= 0 {
+		h.respondError(w, errors.NewValidationError("Validation failed", "quantity: must be greater than 0 when correcting"), r)
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	var productID string
+	switch req.Action {
+	case "confirm":
+		err = h.db.Pool().QueryRow(r.Context(), `
+			UPDATE sales_history SET review_status = 'confirmed', excluded = false,
+				reviewed_by = NULLIF($3, ''), reviewed_at = NOW()
+			WHERE id = $1 AND company_id = $2
+			RETURNING product_id
+		`, saleID, companyID, userID).Scan(&productID)
+	case "correct":
+		err = h.db.Pool().QueryRow(r.Context(), `
+			UPDATE sales_history SET review_status = 'corrected', excluded = false,
+				original_quantity = COALESCE(original_quantity, quantity), quantity = $4,
+				reviewed_by = NULLIF($3, ''), reviewed_at = NOW()
+			WHERE id = $1 AND company_id = $2
+			RETURNING product_id
+		`, saleID, companyID, userID, req.Quantity).Scan(&productID)
+	case "exclude":
+		err = h.db.Pool().QueryRow(r.Context(), `
+			UPDATE sales_history SET review_status = 'excluded', excluded = true,
+				reviewed_by = NULLIF($3, ''), reviewed_at = NOW()
+			WHERE id = $1 AND company_id = $2
+			RETURNING product_id
+		`, saleID, companyID, userID).Scan(&productID)
+	}
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Sale"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "review sale"), r)
+		return
+	}
+
+	// Forecast inputs changed
+	h.redis.Delete(r.Context(), fmt.Sprintf("forecast:%s", productID))
+
+	logger.Info("Sale reviewed", "company_id", companyID, "sale_id", saleID, "action", req.Action)
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"sale_id":    saleID,
+		"product_id": productID,
+		"action":     req.Action,
+	})
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/services/anonymize"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AnonymizeOptions controls an anonymization run
+type AnonymizeOptions struct {
+	Salt     string // Keys the fakes; the same salt maps the same values to the same fakes
+	Password string // When set, anonymized users can sign in with this password; otherwise they have none
+}
+
+// AnonymizeResult counts the rows an anonymization run rewrote
+type AnonymizeResult struct {
+	CompanyID       string `json:"company_id"`
+	CompanyName     string `json:"company_name"` // The fake name
+	Users           int    `json:"users"`
+	Conversations   int    `json:"conversations"`
+	Messages        int    `json:"messages"`
+	ArchivesDropped int    `json:"archives_dropped"`
+	Notifications   int    `json:"notifications"`
+	Files           int    `json:"files"`
+	Integrations    int    `json:"integrations"`
+	DataSources     int    `json:"data_sources"`
+}
+
+// AnonymizeCompany rewrites a company's data in place so it can be used to
+// reproduce production issues in staging: the company profile, its users'
+// emails, conversation and message text, notifications, file names and
+// integration details are replaced by deterministic fakes, and credentials are
+// removed. Products and sales are kept as they are. Platform admins are left
+// untouched. It runs in one transaction and is meant for a staging copy of the
+// database, never production; it is run by the `anonymize` subcommand.
+func (h *Handler) AnonymizeCompany(ctx context.Context, companyID string, opts AnonymizeOptions) (*AnonymizeResult, error) {
+	f := anonymize.New(opts.Salt)
+	var passwordHash *string
+	if opts.Password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(opts.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		s := string(hashed)
+		passwordHash = &s
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var name, description, website string
+	var socialJSON, marketplacesJSON []byte
+	err = tx.QueryRow(ctx, `
+		SELECT name, COALESCE(description, ''), COALESCE(website, ''), social_media_handles, marketplaces
+		FROM companies WHERE id = $1 FOR UPDATE
+	`, companyID).Scan(&name, &description, &website, &socialJSON, &marketplacesJSON)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("company %s not found", companyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Originals that may be repeated in free text, with their fakes
+	known := map[string]string{name: f.CompanyName(name)}
+	if website != "" {
+		known[website] = f.URL(website)
+	}
+	social := fakeStringMap(socialJSON, f.Handle, known)
+	marketplaces := fakeStringMap(marketplacesJSON, func(v string) string {
+		if len(v) > 4 && (v[:4] == "http" || v[:4] == "www.") {
+			return f.URL(v)
+		}
+		return f.Handle(v)
+	}, known)
+
+	result := &AnonymizeResult{CompanyID: companyID, CompanyName: known[name]}
+
+	// Users: the owner, members and anyone who chatted in the company
+	rows, err := tx.Query(ctx, `
+		SELECT id, email FROM users
+		WHERE COALESCE(role, 'user') NOT IN ('admin', 'super_admin')
+			AND id IN (
+				SELECT owner_user_id FROM companies WHERE id = $1
+				UNION SELECT user_id FROM company_members WHERE company_id = $1
+				UNION SELECT user_id FROM conversations WHERE company_id = $1
+			)
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	users := map[string]string{}
+	for rows.Next() {
+		var id, email string
+		if err := rows.Scan(&id, &email); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		users[id] = email
+	}
+	rows.Close()
+	for id, email := range users {
+		known[email] = f.Email(email)
+		if _, err := tx.Exec(ctx, `
+			UPDATE users SET email = $2, password_hash = $3, sso_config_id = NULL, sso_subject = NULL WHERE id = $1
+		`, id, known[email], passwordHash); err != nil {
+			return nil, fmt.Errorf("anonymize user %s: %w", id, err)
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM password_tokens WHERE user_id = $1`, id); err != nil {
+			return nil, err
+		}
+		result.Users++
+	}
+
+	socialOut, _ := json.Marshal(social)
+	marketplacesOut, _ := json.Marshal(marketplaces)
+	if _, err := tx.Exec(ctx, `
+		UPDATE companies SET name = $2, description = NULLIF($3, ''), website = NULLIF($4, ''),
+			social_media_handles = $5, marketplaces = $6, updated_at = NOW()
+		WHERE id = $1
+	`, companyID, known[name], f.Text(description), f.URL(website), nullJSON(socialJSON, socialOut),
+		nullJSON(marketplacesJSON, marketplacesOut)); err != nil {
+		return nil, fmt.Errorf("anonymize company: %w", err)
+	}
+
+	// Conversations and messages keep their shape but lose personal details
+	if result.Conversations, err = scrubColumn(ctx, tx, f, known,
+		`SELECT id, COALESCE(title, '') FROM conversations WHERE company_id = $1`,
+		`UPDATE conversations SET title = NULLIF($2, '') WHERE id = $1`, companyID); err != nil {
+		return nil, err
+	}
+	if result.Messages, err = scrubColumn(ctx, tx, f, known, `
+		SELECT m.id, m.content FROM messages m JOIN conversations c ON c.id = m.conversation_id WHERE c.company_id = $1
+	`, `UPDATE messages SET content = $2 WHERE id = $1`, companyID); err != nil {
+		return nil, err
+	}
+	if _, err = scrubColumn(ctx, tx, f, known, `
+		SELECT m.id, m.structured_payload::text FROM messages m JOIN conversations c ON c.id = m.conversation_id
+		WHERE c.company_id = $1 AND m.structured_payload IS NOT NULL
+	`, `UPDATE messages SET structured_payload = $2::jsonb WHERE id = $1`, companyID); err != nil {
+		return nil, err
+	}
+	// Archived messages are compressed; dropping them is simpler than rewriting them
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM message_archives WHERE conversation_id IN (SELECT id FROM conversations WHERE company_id = $1)
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	result.ArchivesDropped = int(tag.RowsAffected())
+
+	if result.Notifications, err = scrubColumn(ctx, tx, f, known,
+		`SELECT id, message FROM notifications WHERE company_id = $1`,
+		`UPDATE notifications SET message = $2 WHERE id = $1`, companyID); err != nil {
+		return nil, err
+	}
+	if _, err = scrubColumn(ctx, tx, f, known,
+		`SELECT id, title FROM notifications WHERE company_id = $1`,
+		`UPDATE notifications SET title = $2 WHERE id = $1`, companyID); err != nil {
+		return nil, err
+	}
+
+	// File names can identify customers; the stored files themselves stay in
+	// production storage and are not copied
+	rows, err = tx.Query(ctx, `SELECT id, original_filename FROM file_uploads WHERE company_id = $1`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]string{}
+	for rows.Next() {
+		var id, filename string
+		if err := rows.Scan(&id, &filename); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		files[id] = fmt.Sprintf("file-%d%s", len(files)+1, path.Ext(filename))
+	}
+	rows.Close()
+	for id, filename := range files {
+		if _, err := tx.Exec(ctx, `UPDATE file_uploads SET original_filename = $2 WHERE id = $1`, id, filename); err != nil {
+			return nil, err
+		}
+		result.Files++
+	}
+
+	// Integrations lose their credentials and point at fake stores
+	tag, err = tx.Exec(ctx, `
+		UPDATE integrations SET
+			metadata = jsonb_build_object('store_url', 'https://' || left(md5(id), 10) || '.example.com'),
+			status = 'disconnected', error_message = ''
+		WHERE company_id = $1
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	result.Integrations = int(tag.RowsAffected())
+	tag, err = tx.Exec(ctx, `
+		UPDATE data_sources SET meta = NULL, webhook_secret = CASE WHEN webhook_secret IS NULL THEN NULL ELSE md5(random()::text) END
+		WHERE company_id = $1
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	result.DataSources = int(tag.RowsAffected())
+	if _, err := tx.Exec(ctx, `DELETE FROM sso_configs WHERE company_id = $1`, companyID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	logger.Info("Company anonymized", "company_id", companyID, "users", result.Users, "messages", result.Messages)
+	return result, nil
+}
+
+// scrubColumn runs Scrub over one text column of the rows selected by query
+// ($1 = companyID) and writes changed values back with update ($1 = id, $2 = text)
+func scrubColumn(ctx context.Context, tx pgx.Tx, f *anonymize.Faker, known map[string]string, query, update, companyID string) (int, error) {
+	rows, err := tx.Query(ctx, query, companyID)
+	if err != nil {
+		return 0, err
+	}
+	changed := map[string]string{}
+	for rows.Next() {
+		var id, text string
+		if err := rows.Scan(&id, &text); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if scrubbed := f.Scrub(text, known); scrubbed != text {
+			changed[id] = scrubbed
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	for id, text := range changed {
+		if _, err := tx.Exec(ctx, update, id, text); err != nil {
+			return 0, err
+		}
+	}
+	return len(changed), nil
+}
+
+// fakeStringMap replaces every value of a JSON object of strings, recording the
+// originals in known
+func fakeStringMap(raw []byte, fake func(string) string, known map[string]string) map[string]string {
+	values := map[string]string{}
+	if len(raw) == 0 || json.Unmarshal(raw, &values) != nil {
+		return values
+	}
+	for k, v := range values {
+		if v != "" {
+			known[v] = fake(v)
+			values[k] = known[v]
+		}
+	}
+	return values
+}
+
+// nullJSON keeps a NULL column NULL
+func nullJSON(original, replacement []byte) []byte {
+	if len(original) == 0 {
+		return nil
+	}
+	return replacement
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/semcache"
+
+	"github.com/google/uuid"
+)
+
+// maxCachedAnswerCandidates bounds how many cached questions a lookup compares against
+const maxCachedAnswerCandidates = 2000
+
+// cachedAnswerLookup carries an embedded question from lookup to store, so a
+// cache miss is embedded only once
+type cachedAnswerLookup struct {
+	scope    string
+	question string
+	vector   []float32
+}
+
+// answerCacheEnabled reports whether general chat answers are cached
+func (h *Handler) answerCacheEnabled() bool {
+	return h.config.ChatCacheHours > 0 && h.config.KolosalAPIKey != ""
+}
+
+// answerCacheScope separates cached answers by the brand voice they were written in
+func (h *Handler) answerCacheScope(ctx context.Context, companyID string) string {
+	voice := h.withBrandVoice(ctx, companyID, "")
+	if voice == "" {
+		return "default"
+	}
+	sum := sha256.Sum256([]byte(voice))
+	return hex.EncodeToString(sum[:8])
+}
+
+// lookupCachedAnswer embeds the question and returns a cached answer to a similar
+// question in the same scope. The returned lookup is nil when the question is
+// not cacheable or could not be embedded.
+func (h *Handler) lookupCachedAnswer(ctx context.Context, client *kolosal.Client, companyID, message string) (string, *cachedAnswerLookup) {
+	question := semcache.Normalize(message)
+	if !semcache.Cacheable(question) {
+		return "", nil
+	}
+
+	resp, err := client.CreateEmbeddings(ctx, kolosal.EmbeddingRequest{Model: h.config.EmbeddingModel, Input: []string{question}})
+	if err != nil || len(resp.Data) != 1 {
+		return "", nil
+	}
+	lookup := &cachedAnswerLookup{scope: h.answerCacheScope(ctx, companyID), question: question, vector: resp.Data[0].Embedding}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, answer, embedding
+		FROM chat_answer_cache
+		WHERE scope = $1 AND model = $2 AND expires_at > NOW()
+		ORDER BY hits DESC, created_at DESC
+		LIMIT $3
+	`, lookup.scope, h.config.EmbeddingModel, maxCachedAnswerCandidates)
+	if err != nil {
+		return "", lookup
+	}
+	var ids, answers []string
+	var vectors [][]float32
+	for rows.Next() {
+		var id, answer string
+		var vector []float32
+		if rows.Scan(&id, &answer, &vector) == nil {
+			ids = append(ids, id)
+			answers = append(answers, answer)
+			vectors = append(vectors, vector)
+		}
+	}
+	rows.Close()
+
+	threshold := float64(h.config.ChatCacheSimilarity) / 100
+	if threshold <= 0 {
+		threshold = semcache.DefaultSimilarity
+	}
+	i, score := semcache.Match(lookup.vector, vectors, threshold)
+	if i < 0 {
+		return "", lookup
+	}
+
+	h.db.Pool().Exec(ctx, `UPDATE chat_answer_cache SET hits = hits + 1 WHERE id = $1`, ids[i])
+	logger.Debug("Chat answer served from cache", "scope", lookup.scope, "score", fmt.Sprintf("%.3f", score))
+	return answers[i], lookup
+}
+
+// storeCachedAnswer caches an answer to a general question, pruning expired entries of its scope
+func (h *Handler) storeCachedAnswer(ctx context.Context, lookup *cachedAnswerLookup, answer string) {
+	h.db.Pool().Exec(ctx, `DELETE FROM chat_answer_cache WHERE scope = $1 AND expires_at <= NOW()`, lookup.scope)
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO chat_answer_cache (id, scope, question, answer, model, embedding, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), $7)
+	`, uuid.New().String(), lookup.scope, lookup.question, answer, h.config.EmbeddingModel, lookup.vector,
+		time.Now().Add(time.Duration(h.config.ChatCacheHours)*time.Hour))
+	if err != nil {
+		logger.Warn("Failed to cache chat answer", "error", err.Error())
+	}
+}
+
+// invalidateAnswerCache drops every cached answer, e.g. after the regulations
+// index changes and cached explanations may be outdated
+func (h *Handler) invalidateAnswerCache(ctx context.Context, reason string) {
+	tag, err := h.db.Pool().Exec(ctx, `DELETE FROM chat_answer_cache`)
+	if err != nil {
+		logger.Warn("Failed to invalidate chat answer cache", "reason", reason, "error", err.Error())
+		return
+	}
+	logger.Info("Chat answer cache invalidated", "reason", reason, "entries", tag.RowsAffected())
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/auditlog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxAuditBody bounds how much of a request body is captured; larger bodies
+// are recorded by size only
+const maxAuditBody = 64 << 10
+
+// auditResourceParams are the path values tried, in order, as an entry's resource ID
+var auditResourceParams = []string{"id", "name", "plan", "namespace"}
+
+// auditStatusWriter remembers the status code written by the wrapped handler
+type auditStatusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *auditStatusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *auditStatusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Audited records an audit_logs entry for every call to an admin mutation. With
+// capture set, the request body is also stored, with secrets redacted and
+// encrypted, for AuditPayloadDays; only super admins can read it back.
+func (h *Handler) Audited(action string, capture bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if capture && h.audit != nil && r.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		}
+
+		sw := &auditStatusWriter{ResponseWriter: w}
+		next(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+
+		var resourceID string
+		for _, name := range auditResourceParams {
+			if resourceID = r.PathValue(name); resourceID != "" {
+				break
+			}
+		}
+		var payload []byte
+		if len(body) > maxAuditBody {
+			payload, _ = json.Marshal(map[string]interface{}{"truncated_bytes": len(body)})
+		} else {
+			payload = auditlog.Redact(body)
+		}
+		// The request may have been cancelled once the response was written
+		ctx := context.WithoutCancel(r.Context())
+		h.recordAudit(ctx, r, action, resourceID, sw.status, payload)
+	}
+}
+
+// recordAudit inserts an audit entry and, when given, its encrypted payload
+func (h *Handler) recordAudit(ctx context.Context, r *http.Request, action, resourceID string, status int, payload []byte) {
+	id := uuid.New().String()
+	requestID, _ := ctx.Value(middleware.RequestIDKey).(string)
+	actorID := middleware.GetUserID(ctx)
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO audit_logs (id, actor_user_id, actor_role, action, method, path, resource_id, status_code, ip, request_id)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10)
+	`, id, actorID, middleware.GetRole(ctx), action, r.Method, r.URL.Path, resourceID, status,
+		middleware.ClientIP(r), requestID)
+	if err != nil {
+		logger.Error("Failed to record audit entry", "action", action, "actor", actorID, "error", err.Error())
+		return
+	}
+	if len(payload) == 0 || h.audit == nil {
+		return
+	}
+
+	sealed, err := h.audit.Seal(payload, []byte(id))
+	if err == nil {
+		_, err = h.db.Pool().Exec(ctx, `
+			INSERT INTO audit_payloads (audit_log_id, ciphertext, expires_at) VALUES ($1, $2, $3)
+		`, id, sealed, time.Now().AddDate(0, 0, h.config.AuditPayloadDays))
+	}
+	if err != nil {
+		logger.Error("Failed to store audit payload", "audit_log_id", id, "error", err.Error())
+	}
+}
+
+// ListAuditLogs lists audit entries, newest first, filtered by ?action=,
+// ?actor_user_id= and ?resource_id= (platform admin only)
+func (h *Handler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	q := r.URL.Query()
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	if action := q.Get("action"); action != "" {
+		args = append(args, strings.TrimSuffix(action, "*")+"%")
+		conditions = append(conditions, fmt.Sprintf("a.action LIKE $%d", len(args)))
+	}
+	if actor := q.Get("actor_user_id"); actor != "" {
+		args = append(args, actor)
+		conditions = append(conditions, fmt.Sprintf("a.actor_user_id = $%d", len(args)))
+	}
+	if resource := q.Get("resource_id"); resource != "" {
+		args = append(args, resource)
+		conditions = append(conditions, fmt.Sprintf("a.resource_id = $%d", len(args)))
+	}
+
+	ctx := r.Context()
+	where := strings.Join(conditions, " AND ")
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM audit_logs a WHERE `+where, args...).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count audit logs"), r)
+		return
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := h.db.Pool().Query(ctx, fmt.Sprintf(`
+		SELECT a.id, COALESCE(a.actor_user_id, ''), COALESCE(u.email, ''), a.actor_role, a.action, a.method, a.path,
+			COALESCE(a.resource_id, ''), a.status_code, COALESCE(a.ip, ''), COALESCE(a.request_id, ''),
+			EXISTS (SELECT 1 FROM audit_payloads p WHERE p.audit_log_id = a.id AND p.expires_at > NOW()),
+			a.created_at
+		FROM audit_logs a
+		LEFT JOIN users u ON u.id = a.actor_user_id
+		WHERE %s
+		ORDER BY a.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list audit logs"), r)
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.AuditLog{}
+	for rows.Next() {
+		var e models.AuditLog
+		if err := rows.Scan(&e.ID, &e.ActorUserID, &e.ActorEmail, &e.ActorRole, &e.Action, &e.Method, &e.Path,
+			&e.ResourceID, &e.StatusCode, &e.IP, &e.RequestID, &e.HasPayload, &e.CreatedAt); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    entries,
+		ItemsKey: "entries",
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// GetAuditPayload decrypts the request body captured for an audit entry (super
+// admin only). Reading a payload is itself audited by the route.
+func (h *Handler) GetAuditPayload(w http.ResponseWriter, r *http.Request) {
+	if h.audit == nil {
+		h.respondError(w, errors.NewBusinessRuleError("audit_capture_disabled", "Audit payload capture is not configured"), r)
+		return
+	}
+
+	id := r.PathValue("id")
+	var sealed []byte
+	var expiresAt time.Time
+	err := h.db.Pool().QueryRow(r.Context(), `
+		SELECT ciphertext, expires_at FROM audit_payloads WHERE audit_log_id = $1 AND expires_at > NOW()
+	`, id).Scan(&sealed, &expiresAt)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Audit payload"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load audit payload"), r)
+		return
+	}
+
+	plain, err := h.audit.Open(sealed, []byte(id))
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Audit payload could not be decrypted; was the encryption key changed?"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"audit_log_id": id,
+		"body":         json.RawMessage(plain),
+		"expires_at":   expiresAt,
+	})
+}
+
+// PurgeAuditPayloads deletes captured request bodies past their retention. The
+// audit entries themselves are kept. It is run periodically from main.
+func (h *Handler) PurgeAuditPayloads(ctx context.Context) {
+	tag, err := h.db.Pool().Exec(ctx, `DELETE FROM audit_payloads WHERE expires_at <= NOW()`)
+	if err != nil {
+		logger.Error("Failed to purge audit payloads", "error", err.Error())
+		return
+	}
+	if n := tag.RowsAffected(); n > 0 {
+		logger.Info("Purged expired audit payloads", "count", n)
+	}
+}
@@ -102,7 +102,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 
 	// Insert store
 	_, err = tx.Exec(ctx, `
-		INSERT INTO stores (id, user_id, store_name, industry, subscription_plan, status, created_at)
+		INSERT INTO companies (id, owner_user_id, name, industry, subscription_plan, status, created_at)
 		VALUES ($1, $2, $3, $4, 'free', 'active', $5)
 	`, storeID, userID, req.StoreName, req.Industry, time.Now())
 	if err != nil {
@@ -111,6 +111,17 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The founder owns the company; others join by invite
+	_, err = tx.Exec(ctx, `
+		INSERT INTO company_members (company_id, user_id, role, source)
+		VALUES ($1, $2, 'owner', 'owner')
+	`, storeID, userID)
+	if err != nil {
+		appErr := errors.NewDatabaseError(err, "add company owner")
+		h.respondError(w, appErr, r)
+		return
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		appErr := errors.NewDatabaseError(err, "commit transaction")
 		h.respondError(w, appErr, r)
@@ -118,14 +129,14 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate JWT token
-	token, err := h.generateToken(userID, storeID)
+	token, err := h.generateToken(userID, storeID, "user")
 	if err != nil {
 		appErr := errors.NewInternalError(err, "Failed to generate token")
 		h.respondError(w, appErr, r)
 		return
 	}
 
-	h.respondJSON(w, http.StatusCreated, AuthResponse{
+	h.respondJSON(w, r, http.StatusCreated, AuthResponse{
 		Token:     token,
 		UserID:    userID,
 		StoreID:   storeID,
@@ -159,11 +170,19 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Get user by email
-	var userID, passwordHash string
+	var userID, passwordHash, role string
+	var ssoManaged, suspended bool
 	err := h.db.Pool().QueryRow(ctx, `
-		SELECT id, password_hash FROM users WHERE email = $1
-	`, req.Email).Scan(&userID, &passwordHash)
-	if err != nil {
+		SELECT id, COALESCE(password_hash, ''), COALESCE(role, 'user'), sso_config_id IS NOT NULL, suspended_at IS NOT NULL
+		FROM users WHERE email = $1
+	`, req.Email).Scan(&userID, &passwordHash, &role, &ssoManaged, &suspended)
+	if err == nil && ssoManaged {
+		appErr := errors.NewForbiddenError("This account signs in through your organization's single sign-on")
+		h.respondError(w, appErr, r)
+		return
+	}
+	// Invited users have no password until they follow their set-password link
+	if err != nil || passwordHash == "" {
 		appErr := errors.NewUnauthorizedError("Invalid email or password")
 		h.respondError(w, appErr, r)
 		return
@@ -176,10 +195,20 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get store for this user
+	// Suspension is only revealed to someone who knows the password
+	if suspended {
+		h.respondError(w, errSuspendedAccount(), r)
+		return
+	}
+
+	// Get store for this user: an active company they belong to, preferring
+	// ones they own; the rest are reached through /company/switch
 	var storeID, storeName, plan string
 	err = h.db.Pool().QueryRow(ctx, `
-		SELECT id, store_name, subscription_plan FROM stores WHERE user_id = $1 AND status = 'active' LIMIT 1
+		SELECT c.id, c.name, c.subscription_plan
+		FROM company_members m JOIN companies c ON c.id = m.company_id
+		WHERE m.user_id = $1 AND c.status IN ('active', 'archived')
+		ORDER BY c.status = 'active' DESC, m.role = 'owner' DESC, m.created_at LIMIT 1
 	`, userID).Scan(&storeID, &storeName, &plan)
 	if err != nil {
 		appErr := errors.NewInternalError(err, "Failed to fetch store")
@@ -187,15 +216,26 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Admin sessions are bound to an allowlisted address and a known device
+	var deviceID string
+	if isPrivilegedRole(role) {
+		deviceID, err = h.registerAdminLogin(ctx, r, userID, req.Email)
+		if err != nil {
+			h.respondError(w, err, r)
+			return
+		}
+	}
+	h.touchCompanyActivity(ctx, storeID)
+
 	// Generate JWT token
-	token, err := h.generateToken(userID, storeID)
+	token, err := h.generateSessionToken(userID, storeID, role, deviceID)
 	if err != nil {
 		appErr := errors.NewInternalError(err, "Failed to generate token")
 		h.respondError(w, appErr, r)
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, AuthResponse{
+	h.respondJSON(w, r, http.StatusOK, AuthResponse{
 		Token:     token,
 		UserID:    userID,
 		StoreID:   storeID,
@@ -204,13 +244,23 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (h *Handler) generateToken(userID, storeID string) (string, error) {
+func (h *Handler) generateToken(userID, storeID, role string) (string, error) {
+	return h.generateSessionToken(userID, storeID, role, "")
+}
+
+// generateSessionToken issues a session token; admin sessions carry the ID of
+// the device they were issued to so revoking the device ends them
+func (h *Handler) generateSessionToken(userID, storeID, role, deviceID string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id":  userID,
 		"store_id": storeID,
+		"role":     role,
 		"exp":      time.Now().Add(24 * time.Hour).Unix(),
 		"iat":      time.Now().Unix(),
 	}
+	if deviceID != "" {
+		claims["device_id"] = deviceID
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(h.config.JWTSecret))
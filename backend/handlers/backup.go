@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+
+	"github.com/google/uuid"
+)
+
+// RestoreBackupResponse represents the result of restoring a company snapshot
+type RestoreBackupResponse struct {
+	BackupID              string    `json:"backup_id"`
+	CompanyID             string    `json:"company_id"`
+	ProductsRestored      int       `json:"products_restored"`
+	SalesRestored         int       `json:"sales_restored"`
+	ConversationsRestored int       `json:"conversations_restored"`
+	RestoredAt            time.Time `json:"restored_at"`
+}
+
+// CreateCompanyBackup snapshots the authenticated company's data into object storage
+func (h *Handler) CreateCompanyBackup(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	ctx := r.Context()
+
+	snapshot, err := h.buildCompanySnapshot(ctx, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "build company snapshot"), r)
+		return
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(snapshot); err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to encode snapshot"), r)
+		return
+	}
+	if err := gz.Close(); err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to compress snapshot"), r)
+		return
+	}
+
+	backup := models.CompanyBackup{
+		ID:                uuid.New().String(),
+		CompanyID:         companyID,
+		SnapshotVersion:   snapshot.Version,
+		StorageRegion:     h.storageRegionForCompany(ctx, companyID),
+		ProductCount:      len(snapshot.Products),
+		SalesCount:        len(snapshot.Sales),
+		ConversationCount: len(snapshot.Conversations),
+		CreatedBy:         middleware.GetUserID(ctx),
+		CreatedAt:         snapshot.CreatedAt,
+	}
+
+	key := fmt.Sprintf("%s/backups/%s.json.gz", companyID, backup.ID)
+	backup.StoragePath, backup.SizeBytes, err = h.files.Put(ctx, backup.StorageRegion, key, &buf)
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to store snapshot"), r)
+		return
+	}
+
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO company_backups (id, company_id, snapshot_version, storage_path, storage_region, size_bytes,
+			product_count, sales_count, conversation_count, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11)
+	`, backup.ID, backup.CompanyID, backup.SnapshotVersion, backup.StoragePath, backup.StorageRegion, backup.SizeBytes,
+		backup.ProductCount, backup.SalesCount, backup.ConversationCount, backup.CreatedBy, backup.CreatedAt)
+	if err != nil {
+		h.files.Delete(backup.StoragePath)
+		h.respondError(w, errors.NewDatabaseError(err, "record company backup"), r)
+		return
+	}
+
+	logger.Info("Company backup created", "company_id", companyID, "backup_id", backup.ID, "size_bytes", backup.SizeBytes)
+
+	h.respondJSON(w, r, http.StatusCreated, backup)
+}
+
+// ListCompanyBackups lists snapshots for the authenticated company
+func (h *Handler) ListCompanyBackups(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	backups, err := h.listBackups(r.Context(), companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list company backups"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"backups": backups,
+	})
+}
+
+// AdminListCompanyBackups lists snapshots for any company (admin only)
+func (h *Handler) AdminListCompanyBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := h.listBackups(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list company backups"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"backups": backups,
+	})
+}
+
+// RestoreCompanyBackup replaces a company's products, sales and settings with a snapshot (admin only).
+// Conversation metadata is re-created only where it was deleted; existing conversations are left intact.
+func (h *Handler) RestoreCompanyBackup(w http.ResponseWriter, r *http.Request) {
+	companyID := r.PathValue("id")
+	backupID := r.PathValue("backup_id")
+	ctx := r.Context()
+
+	var backup models.CompanyBackup
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, snapshot_version, storage_path FROM company_backups WHERE id = $1 AND company_id = $2
+	`, backupID, companyID).Scan(&backup.ID, &backup.SnapshotVersion, &backup.StoragePath)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Backup"), r)
+		return
+	}
+
+	if backup.SnapshotVersion > models.SnapshotVersion {
+		h.respondError(w, errors.NewBusinessRuleError("snapshot_version",
+			fmt.Sprintf("snapshot version %d is newer than supported version %d", backup.SnapshotVersion, models.SnapshotVersion)), r)
+		return
+	}
+
+	snapshot, err := h.readCompanySnapshot(backup.StoragePath)
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to read snapshot"), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	socialJSON, _ := json.Marshal(snapshot.Settings.SocialMediaHandles)
+	marketplacesJSON, _ := json.Marshal(snapshot.Settings.Marketplaces)
+	_, err = tx.Exec(ctx, `
+		UPDATE companies
+		SET name = $2, description = $3, industry = $4, business_model = $5, founded_year = $6,
+			location_region = $7, city = $8, country = $9, website = $10,
+			social_media_handles = $11, marketplaces = $12, timezone = COALESCE(NULLIF($13, ''), timezone),
+			updated_at = NOW()
+		WHERE id = $1
+	`, companyID, snapshot.Settings.Name, snapshot.Settings.Description, snapshot.Settings.Industry,
+		snapshot.Settings.BusinessModel, snapshot.Settings.FoundedYear, snapshot.Settings.LocationRegion,
+		snapshot.Settings.City, snapshot.Settings.Country, snapshot.Settings.Website, socialJSON, marketplacesJSON,
+		snapshot.Settings.Timezone)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "restore company settings"), r)
+		return
+	}
+
+	// Products cascade to their sales history, so both are rebuilt from the snapshot
+	if _, err = tx.Exec(ctx, `DELETE FROM products WHERE company_id = $1`, companyID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "clear products"), r)
+		return
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM sales_history WHERE company_id = $1`, companyID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "clear sales history"), r)
+		return
+	}
+
+	resp := RestoreBackupResponse{BackupID: backupID, CompanyID: companyID}
+
+	for _, p := range snapshot.Products {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO products (id, company_id, name, sku, category, unit_price, cost, unit, is_active, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11)
+		`, p.ID, companyID, p.Name, p.SKU, p.Category, p.UnitPrice, p.Cost, p.Unit, p.IsActive, p.CreatedAt, p.UpdatedAt)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "restore product "+p.ID), r)
+			return
+		}
+		resp.ProductsRestored++
+	}
+
+	for _, s := range snapshot.Sales {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO sales_history (id, company_id, product_id, quantity, price, sale_date, source, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (id) DO NOTHING
+		`, s.ID, companyID, s.ProductID, s.Quantity, s.Price, s.SaleDate, s.Source, s.CreatedAt)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "restore sale"), r)
+			return
+		}
+		resp.SalesRestored++
+	}
+
+	for _, c := range snapshot.Conversations {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO conversations (id, company_id, user_id, title, purpose, created_at, updated_at)
+			SELECT $1, $2, $3, $4, $5, $6, $7
+			WHERE EXISTS (SELECT 1 FROM users WHERE id = $3)
+			ON CONFLICT (id) DO NOTHING
+		`, c.ID, companyID, c.UserID, c.Title, c.Purpose, c.CreatedAt, c.UpdatedAt)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "restore conversation"), r)
+			return
+		}
+		resp.ConversationsRestored += int(tag.RowsAffected())
+	}
+
+	resp.RestoredAt = time.Now()
+	_, err = tx.Exec(ctx, `
+		UPDATE company_backups SET restored_at = $2, restored_by = NULLIF($3, '') WHERE id = $1
+	`, backupID, resp.RestoredAt, middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "mark backup restored"), r)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	// Cached forecasts refer to the pre-restore sales history
+	for _, p := range snapshot.Products {
+		h.redis.Delete(ctx, fmt.Sprintf("forecast:%s", p.ID))
+	}
+
+	logger.Info("Company backup restored", "company_id", companyID, "backup_id", backupID,
+		"restored_by", middleware.GetUserID(ctx))
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// buildCompanySnapshot collects the company's restorable data
+func (h *Handler) buildCompanySnapshot(ctx context.Context, companyID string) (*models.CompanySnapshot, error) {
+	snapshot := &models.CompanySnapshot{
+		Version:       models.SnapshotVersion,
+		CompanyID:     companyID,
+		CreatedAt:     time.Now(),
+		Products:      []models.SnapshotProduct{},
+		Sales:         []models.SnapshotSale{},
+		Conversations: []models.SnapshotConversation{},
+	}
+
+	var socialJSON, marketplacesJSON []byte
+	s := &snapshot.Settings
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT name, COALESCE(description, ''), COALESCE(industry, ''), COALESCE(business_model, ''), founded_year,
+			COALESCE(location_region, ''), COALESCE(city, ''), COALESCE(country, 'ID'), COALESCE(website, ''),
+			social_media_handles, marketplaces, COALESCE(timezone, '')
+		FROM companies
+		WHERE id = $1
+	`, companyID).Scan(&s.Name, &s.Description, &s.Industry, &s.BusinessModel, &s.FoundedYear,
+		&s.LocationRegion, &s.City, &s.Country, &s.Website, &socialJSON, &marketplacesJSON, &s.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	if len(socialJSON) > 0 {
+		json.Unmarshal(socialJSON, &s.SocialMediaHandles)
+	}
+	if len(marketplacesJSON) > 0 {
+		json.Unmarshal(marketplacesJSON, &s.Marketplaces)
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, name, COALESCE(sku, ''), COALESCE(category, ''), COALESCE(unit_price, 0), COALESCE(cost, 0),
+			COALESCE(unit, ''), COALESCE(is_active, true), created_at, updated_at
+		FROM products
+		WHERE company_id = $1
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var p models.SnapshotProduct
+		if err := rows.Scan(&p.ID, &p.Name, &p.SKU, &p.Category, &p.UnitPrice, &p.Cost, &p.Unit, &p.IsActive,
+			&p.CreatedAt, &p.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snapshot.Products = append(snapshot.Products, p)
+	}
+	rows.Close()
+
+	rows, err = h.db.Pool().Query(ctx, `
+		SELECT id, product_id, quantity, COALESCE(price, 0), sale_date, source, created_at
+		FROM sales_history
+		WHERE company_id = $1
+		ORDER BY id
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var sale models.SnapshotSale
+		if err := rows.Scan(&sale.ID, &sale.ProductID, &sale.Quantity, &sale.Price, &sale.SaleDate, &sale.Source,
+			&sale.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		snapshot.Sales = append(snapshot.Sales, sale)
+	}
+	rows.Close()
+
+	rows, err = h.db.Pool().Query(ctx, `
+		SELECT id, user_id, COALESCE(title, ''), COALESCE(purpose, ''), created_at, updated_at
+		FROM conversations
+		WHERE company_id = $1
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c models.SnapshotConversation
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Title, &c.Purpose, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		snapshot.Conversations = append(snapshot.Conversations, c)
+	}
+
+	return snapshot, rows.Err()
+}
+
+// readCompanySnapshot loads and decompresses a snapshot from object storage
+func (h *Handler) readCompanySnapshot(storagePath string) (*models.CompanySnapshot, error) {
+	f, err := h.files.Open(storagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var snapshot models.CompanySnapshot
+	if err := json.NewDecoder(io.LimitReader(gz, 512<<20)).Decode(&snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (h *Handler) listBackups(ctx context.Context, companyID string) ([]models.CompanyBackup, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, company_id, snapshot_version, COALESCE(storage_region, ''), size_bytes, product_count,
+			sales_count, conversation_count, COALESCE(created_by, ''), restored_at, COALESCE(restored_by, ''), created_at
+		FROM company_backups
+		WHERE company_id = $1
+		ORDER BY created_at DESC
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	backups := []models.CompanyBackup{}
+	for rows.Next() {
+		var b models.CompanyBackup
+		if err := rows.Scan(&b.ID, &b.CompanyID, &b.SnapshotVersion, &b.StorageRegion, &b.SizeBytes, &b.ProductCount,
+			&b.SalesCount, &b.ConversationCount, &b.CreatedBy, &b.RestoredAt, &b.RestoredBy, &b.CreatedAt); err != nil {
+			continue
+		}
+		backups = append(backups, b)
+	}
+	return backups, nil
+}
@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/billing"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateCheckoutRequest buys a period of a paid plan
+type CreateCheckoutRequest struct {
+	Plan string `json:"plan" validate:"required,oneof:pro|enterprise"`
+}
+
+// BillingResponse is the company's plan and what paid plans cost
+type BillingResponse struct {
+	Provider      string             `json:"provider,omitempty"` // Empty when self-serve billing is off
+	Plan          string             `json:"plan"`
+	PlanExpiresAt *time.Time         `json:"plan_expires_at,omitempty"` // Unset for free and admin-set plans
+	Prices        []models.PlanPrice `json:"prices"`
+}
+
+const billingOrderSelect = `
+	SELECT id, company_id, COALESCE(requested_by, ''), provider, plan, amount, status, COALESCE(payment_type, ''),
+		COALESCE(redirect_url, ''), paid_at, plan_expires_at, created_at, updated_at
+	FROM billing_orders`
+
+func scanBillingOrder(row pgx.Row) (*models.BillingOrder, error) {
+	var o models.BillingOrder
+	err := row.Scan(&o.ID, &o.CompanyID, &o.RequestedBy, &o.Provider, &o.Plan, &o.Amount, &o.Status, &o.PaymentType,
+		&o.RedirectURL, &o.PaidAt, &o.PlanExpiresAt, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != billing.StatusPending {
+		o.RedirectURL = ""
+	}
+	return &o, nil
+}
+
+// GetBilling returns the company's plan, when a paid plan lapses and the
+// prices of paid plans
+func (h *Handler) GetBilling(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	resp := BillingResponse{Prices: []models.PlanPrice{}}
+	if h.billing != nil {
+		resp.Provider = h.billing.Name()
+	}
+	err := h.db.Pool().QueryRow(r.Context(), `
+		SELECT COALESCE(subscription_plan, 'free'), plan_expires_at FROM companies WHERE id = $1
+	`, companyID).Scan(&resp.Plan, &resp.PlanExpiresAt)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load plan"), r)
+		return
+	}
+	for plan, amount := range billing.Prices {
+		resp.Prices = append(resp.Prices, models.PlanPrice{
+			Plan:       plan,
+			Amount:     amount,
+			Currency:   "IDR",
+			PeriodDays: int(billing.Period.Hours() / 24),
+		})
+	}
+	sort.Slice(resp.Prices, func(i, j int) bool { return resp.Prices[i].Amount < resp.Prices[j].Amount })
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// CreateBillingCheckout starts a plan purchase and returns the gateway's
+// payment page. The plan changes once the gateway reports the payment.
+func (h *Handler) CreateBillingCheckout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	if h.billing == nil {
+		h.respondError(w, errors.NewBusinessRuleError("billing_disabled", "online payment is not available; contact support to change plans"), r)
+		return
+	}
+
+	var req CreateCheckoutRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	amount, ok := billing.PlanPrice(req.Plan)
+	if !ok {
+		h.respondError(w, errors.NewValidationError("Invalid plan", "plan must be one of: pro, enterprise"), r)
+		return
+	}
+
+	var companyName, currentPlan, email string
+	var expiresAt *time.Time
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT c.name, COALESCE(c.subscription_plan, 'free'), c.plan_expires_at, COALESCE(u.email, '')
+		FROM companies c LEFT JOIN users u ON u.id = $2
+		WHERE c.id = $1
+	`, companyID, middleware.GetUserID(ctx)).Scan(&companyName, &currentPlan, &expiresAt, &email)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company"), r)
+		return
+	}
+	// A plan set by an admin has no expiry, so paying would not extend it
+	if currentPlan != "free" && expiresAt == nil {
+		h.respondError(w, errors.NewBusinessRuleError("plan_managed", "the plan is managed by Bantuaku; contact support to change it"), r)
+		return
+	}
+	if currentPlan != "free" && currentPlan != req.Plan {
+		h.respondError(w, errors.NewBusinessRuleError("plan_active", fmt.Sprintf("the %s plan is active until %s; renew it or wait until it ends", currentPlan, expiresAt.Format("2006-01-02"))), r)
+		return
+	}
+
+	orderID := uuid.New().String()
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO billing_orders (id, company_id, requested_by, provider, plan, amount, status)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+	`, orderID, companyID, middleware.GetUserID(ctx), h.billing.Name(), req.Plan, amount, billing.StatusPending)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create billing order"), r)
+		return
+	}
+
+	session, err := h.billing.CreateCheckout(ctx, billing.Checkout{
+		OrderID:       orderID,
+		Plan:          req.Plan,
+		Amount:        amount,
+		CustomerName:  companyName,
+		CustomerEmail: email,
+		FinishURL:     h.config.AppURL + "/settings/billing",
+	})
+	if err != nil {
+		logger.Warn("Checkout failed", "company_id", companyID, "order_id", orderID, "provider", h.billing.Name(), "error", err.Error())
+		h.db.Pool().Exec(context.WithoutCancel(ctx), `
+			UPDATE billing_orders SET status = $2, updated_at = NOW() WHERE id = $1
+		`, orderID, billing.StatusFailed)
+		h.respondError(w, errors.NewExternalServiceError(h.billing.Name(), "Failed to start payment", err.Error()), r)
+		return
+	}
+	if _, err := h.db.Pool().Exec(ctx, `UPDATE billing_orders SET redirect_url = $2 WHERE id = $1`, orderID, session.RedirectURL); err != nil {
+		logger.Warn("Failed to store checkout redirect", "company_id", companyID, "order_id", orderID, "error", err.Error())
+	}
+
+	logger.Info("Checkout started", "company_id", companyID, "order_id", orderID, "plan", req.Plan, "amount", amount)
+	h.respondJSON(w, r, http.StatusCreated, map[string]interface{}{
+		"order_id":     orderID,
+		"plan":         req.Plan,
+		"amount":       amount,
+		"token":        session.Token,
+		"redirect_url": session.RedirectURL,
+	})
+}
+
+// ListBillingOrders lists the company's plan purchases, newest first
+func (h *Handler) ListBillingOrders(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM billing_orders WHERE company_id = $1`, companyID).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count billing orders"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, billingOrderSelect+`
+		WHERE company_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`, companyID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list billing orders"), r)
+		return
+	}
+	defer rows.Close()
+
+	orders := []models.BillingOrder{}
+	for rows.Next() {
+		order, err := scanBillingOrder(rows)
+		if err != nil {
+			continue
+		}
+		orders = append(orders, *order)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    orders,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// ReceiveBillingNotification applies a payment status change reported by the
+// gateway. It is not behind auth: the provider's signature is the credential.
+// Notifications are retried by the gateway until answered with 200, and may
+// repeat, so applying one twice changes nothing.
+func (h *Handler) ReceiveBillingNotification(w http.ResponseWriter, r *http.Request) {
+	if h.billing == nil || r.PathValue("provider") != h.billing.Name() {
+		h.respondError(w, errors.NewNotFoundError("Billing provider"), r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Failed to read body", err.Error()), r)
+		return
+	}
+	event, err := h.billing.ParseNotification(body)
+	if stderrors.Is(err, billing.ErrInvalidSignature) {
+		h.respondError(w, errors.NewUnauthorizedError("Invalid notification signature"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid notification", err.Error()), r)
+		return
+	}
+
+	order, changed, err := h.applyBillingEvent(r.Context(), event)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if changed {
+		h.billingOrderChanged(r.Context(), order)
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"order_id": order.ID, "status": order.Status})
+}
+
+// applyBillingEvent records a status change on its order and applies
+// billingPlanChange to the company's plan. Changes billing.CanTransition does
+// not allow are ignored.
+func (h *Handler) applyBillingEvent(ctx context.Context, event billing.Event) (*models.BillingOrder, bool, error) {
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return nil, false, errors.NewDatabaseError(err, "begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	order, err := scanBillingOrder(tx.QueryRow(ctx, billingOrderSelect+` WHERE id = $1 AND provider = $2 FOR UPDATE`,
+		event.OrderID, h.billing.Name()))
+	if err == pgx.ErrNoRows {
+		return nil, false, errors.NewNotFoundError("Billing order")
+	}
+	if err != nil {
+		return nil, false, errors.NewDatabaseError(err, "load billing order")
+	}
+
+	if !billing.CanTransition(order.Status, event.Status) {
+		return order, false, nil
+	}
+
+	now := time.Now()
+	var current *time.Time
+	if event.Status == billing.StatusPaid || event.Status == billing.StatusRefunded {
+		if err := tx.QueryRow(ctx, `SELECT plan_expires_at FROM companies WHERE id = $1 FOR UPDATE`, order.CompanyID).Scan(&current); err != nil {
+			return nil, false, errors.NewDatabaseError(err, "load plan")
+		}
+	}
+	change, err := billingPlanChange(order, event, current, now)
+	if err != nil {
+		logger.Warn("Payment amount does not match order", "order_id", order.ID, "company_id", order.CompanyID,
+			"expected", order.Amount, "paid", event.Amount)
+		return nil, false, err
+	}
+	if change.update {
+		if _, err := tx.Exec(ctx, `
+			UPDATE companies SET subscription_plan = COALESCE(NULLIF($2, ''), subscription_plan), plan_expires_at = $3, updated_at = NOW()
+			WHERE id = $1
+		`, order.CompanyID, change.plan, change.expiresAt); err != nil {
+			return nil, false, errors.NewDatabaseError(err, "update plan")
+		}
+	}
+	if event.Status == billing.StatusPaid {
+		order.PaidAt, order.PlanExpiresAt = &now, change.expiresAt
+	}
+
+	order.Status, order.PaymentType, order.UpdatedAt = event.Status, event.PaymentType, now
+	_, err = tx.Exec(ctx, `
+		UPDATE billing_orders
+		SET status = $2, provider_status = $3, payment_type = NULLIF($4, ''), transaction_id = NULLIF($5, ''),
+			paid_at = COALESCE(paid_at, $6), plan_expires_at = COALESCE(plan_expires_at, $7), updated_at = NOW()
+		WHERE id = $1
+	`, order.ID, event.Status, event.ProviderStatus, event.PaymentType, event.TransactionID, order.PaidAt, order.PlanExpiresAt)
+	if err != nil {
+		return nil, false, errors.NewDatabaseError(err, "update billing order")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, errors.NewDatabaseError(err, "commit transaction")
+	}
+	return order, true, nil
+}
+
+// billingPlan is the company plan an order's status change leads to
+type billingPlan struct {
+	update    bool       // Whether the company's plan changes at all
+	plan      string     // Empty keeps the current plan
+	expiresAt *time.Time // nil for the free plan
+}
+
+// billingPlanChange works out the company's plan after an allowed status
+// change, given the plan's current expiry. A payment extends the order's plan
+// by a period. A refund takes that period back and moves the company to the
+// free plan at once when no time is left; plans without an expiry were set by
+// an admin and are left alone.
+func billingPlanChange(order *models.BillingOrder, event billing.Event, current *time.Time, now time.Time) (billingPlan, error) {
+	switch event.Status {
+	case billing.StatusPaid:
+		if event.Amount != order.Amount {
+			return billingPlan{}, errors.NewValidationError("Amount mismatch", "the paid amount does not match the order")
+		}
+		expiresAt := billing.Extend(current, now)
+		return billingPlan{update: true, plan: order.Plan, expiresAt: &expiresAt}, nil
+	case billing.StatusRefunded:
+		if current == nil {
+			return billingPlan{}, nil
+		}
+		expiresAt, ended := billing.Shorten(*current, now)
+		if ended {
+			return billingPlan{update: true, plan: "free"}, nil
+		}
+		return billingPlan{update: true, expiresAt: &expiresAt}, nil
+	}
+	return billingPlan{}, nil
+}
+
+// billingOrderChanged applies a recorded status change outside the database:
+// cached plans are dropped and the company is told about payments
+func (h *Handler) billingOrderChanged(ctx context.Context, order *models.BillingOrder) {
+	logger.Info("Billing order updated", "order_id", order.ID, "company_id", order.CompanyID, "plan", order.Plan,
+		"status", order.Status, "payment_type", order.PaymentType)
+	if order.Status != billing.StatusPaid && order.Status != billing.StatusRefunded {
+		return
+	}
+	h.invalidateCompanyPlans(ctx, order.CompanyID)
+	if order.Status != billing.StatusPaid {
+		return
+	}
+	_, err := h.notify(ctx, order.CompanyID, models.Notification{
+		Type:    models.NotificationPlanPaid,
+		Title:   "Pembayaran diterima",
+		Message: fmt.Sprintf("Paket %s aktif hingga %s.", order.Plan, order.PlanExpiresAt.Format("02-01-2006")),
+		Data: map[string]interface{}{
+			"order_id":        order.ID,
+			"plan":            order.Plan,
+			"plan_expires_at": order.PlanExpiresAt,
+		},
+	}, "billing:"+order.ID)
+	if err != nil {
+		logger.Error("Failed to notify plan payment", "order_id", order.ID, "error", err.Error())
+	}
+}
+
+// ExpireLapsedPlans moves companies whose paid plan ran out back to the free
+// plan. Plans set by an admin have no expiry and are left alone. It is run
+// periodically from main.
+func (h *Handler) ExpireLapsedPlans(ctx context.Context) {
+	rows, err := h.db.Pool().Query(ctx, `
+		UPDATE companies c SET subscription_plan = 'free', plan_expires_at = NULL, updated_at = NOW()
+		FROM (SELECT id, subscription_plan AS plan FROM companies WHERE plan_expires_at <= NOW() FOR UPDATE) old
+		WHERE c.id = old.id
+		RETURNING c.id, COALESCE(old.plan, 'free')
+	`)
+	if err != nil {
+		logger.Error("Failed to expire lapsed plans", "error", err.Error())
+		return
+	}
+	lapsed := map[string]string{}
+	for rows.Next() {
+		var companyID, plan string
+		if rows.Scan(&companyID, &plan) == nil {
+			lapsed[companyID] = plan
+		}
+	}
+	rows.Close()
+	if len(lapsed) == 0 {
+		return
+	}
+
+	companyIDs := make([]string, 0, len(lapsed))
+	for companyID, plan := range lapsed {
+		companyIDs = append(companyIDs, companyID)
+		_, err := h.notify(ctx, companyID, models.Notification{
+			Type:    models.NotificationPlanLapsed,
+			Title:   "Paket berakhir",
+			Message: fmt.Sprintf("Paket %s Anda telah berakhir dan kembali ke paket gratis. Perpanjang untuk kembali menikmati batas yang lebih tinggi.", plan),
+			Data:    map[string]interface{}{"plan": plan},
+		}, "")
+		if err != nil {
+			logger.Error("Failed to notify lapsed plan", "company_id", companyID, "error", err.Error())
+		}
+	}
+	h.invalidateCompanyPlans(ctx, companyIDs...)
+	logger.Info("Expired lapsed plans", "count", len(companyIDs))
+}
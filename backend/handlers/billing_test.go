@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/billing"
+)
+
+func TestBillingPlanChange(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { v := now.Add(d); return &v }
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		from    string
+		event   billing.Event
+		current *time.Time
+		allowed bool
+		want    billingPlan
+		wantErr errors.ErrorCode
+	}{
+		{
+			name:    "pending to paid starts the plan",
+			from:    billing.StatusPending,
+			event:   billing.Event{Status: billing.StatusPaid, Amount: 149000},
+			allowed: true,
+			want:    billingPlan{update: true, plan: "pro", expiresAt: at(billing.Period)},
+		},
+		{
+			name:    "pending to paid adds to the time left",
+			from:    billing.StatusPending,
+			event:   billing.Event{Status: billing.StatusPaid, Amount: 149000},
+			current: at(5 * day),
+			allowed: true,
+			want:    billingPlan{update: true, plan: "pro", expiresAt: at(5*day + billing.Period)},
+		},
+		{
+			name:    "paid with the wrong amount",
+			from:    billing.StatusPending,
+			event:   billing.Event{Status: billing.StatusPaid, Amount: 1000},
+			allowed: true,
+			wantErr: errors.ErrCodeValidation,
+		},
+		{
+			name:    "pending to failed leaves the plan",
+			from:    billing.StatusPending,
+			event:   billing.Event{Status: billing.StatusFailed},
+			allowed: true,
+		},
+		{
+			name:    "paid to refunded with a renewal left",
+			from:    billing.StatusPaid,
+			event:   billing.Event{Status: billing.StatusRefunded},
+			current: at(billing.Period + 5*day),
+			allowed: true,
+			want:    billingPlan{update: true, expiresAt: at(5 * day)},
+		},
+		{
+			name:    "paid to refunded downgrades at once",
+			from:    billing.StatusPaid,
+			event:   billing.Event{Status: billing.StatusRefunded},
+			current: at(billing.Period - day),
+			allowed: true,
+			want:    billingPlan{update: true, plan: "free"},
+		},
+		{
+			name:    "refund of a plan set by an admin",
+			from:    billing.StatusPaid,
+			event:   billing.Event{Status: billing.StatusRefunded},
+			allowed: true,
+		},
+		{name: "paid again", from: billing.StatusPaid, event: billing.Event{Status: billing.StatusPaid, Amount: 149000}},
+		{name: "paid to failed", from: billing.StatusPaid, event: billing.Event{Status: billing.StatusFailed}},
+		{name: "refunded to paid", from: billing.StatusRefunded, event: billing.Event{Status: billing.StatusPaid, Amount: 149000}},
+		{name: "expired to paid", from: billing.StatusExpired, event: billing.Event{Status: billing.StatusPaid, Amount: 149000}},
+		{name: "failed to paid", from: billing.StatusFailed, event: billing.Event{Status: billing.StatusPaid, Amount: 149000}},
+		{name: "pending to pending", from: billing.StatusPending, event: billing.Event{Status: billing.StatusPending}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := billing.CanTransition(tt.from, tt.event.Status); got != tt.allowed {
+				t.Fatalf("transition %s -> %s allowed = %v, want %v", tt.from, tt.event.Status, got, tt.allowed)
+			}
+			if !tt.allowed {
+				return
+			}
+			order := &models.BillingOrder{ID: "order-1", Plan: "pro", Amount: 149000, Status: tt.from}
+			got, err := billingPlanChange(order, tt.event, tt.current, now)
+			if tt.wantErr != "" {
+				if errors.GetErrorCode(err) != tt.wantErr {
+					t.Errorf("error = %v, want code %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.update != tt.want.update || got.plan != tt.want.plan || !sameTime(got.expiresAt, tt.want.expiresAt) {
+				t.Errorf("billingPlanChange = %+v (expires %v), want %+v (expires %v)", got, got.expiresAt, tt.want, tt.want.expiresAt)
+			}
+		})
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/brandvoice"
+)
+
+// AssistantPreferencesResponse is the company's brand voice and whether its plan applies it
+type AssistantPreferencesResponse struct {
+	Preferences brandvoice.Preferences `json:"preferences"`
+	Enabled     bool                   `json:"enabled"` // False on the free plan; preferences are kept but not applied
+}
+
+// GetAssistantPreferences returns the company's assistant preferences
+func (h *Handler) GetAssistantPreferences(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	prefs, plan, err := h.loadAssistantPreferences(r.Context(), companyID)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, AssistantPreferencesResponse{Preferences: prefs, Enabled: brandVoicePlan(plan)})
+}
+
+// UpdateAssistantPreferences replaces the company's assistant preferences. Only
+// paid plans may set them; send an empty object to clear them.
+func (h *Handler) UpdateAssistantPreferences(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var prefs brandvoice.Preferences
+	if err := h.parseJSON(r, &prefs); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	prefs = prefs.Normalize()
+	if err := prefs.Validate(); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid assistant preferences", err.Error()), r)
+		return
+	}
+
+	ctx := r.Context()
+	_, plan, err := h.loadAssistantPreferences(ctx, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	if !brandVoicePlan(plan) {
+		h.respondError(w, errors.NewBusinessRuleError("plan", "assistant preferences require a pro plan"), r)
+		return
+	}
+
+	raw, _ := json.Marshal(prefs)
+	_, err = h.db.Pool().Exec(ctx, `
+		UPDATE companies SET assistant_preferences = $2, updated_at = NOW() WHERE id = $1
+	`, companyID, raw)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update assistant preferences"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, AssistantPreferencesResponse{Preferences: prefs, Enabled: true})
+}
+
+// withBrandVoice appends the company's brand voice to a system prompt when its plan allows it
+func (h *Handler) withBrandVoice(ctx context.Context, companyID, systemPrompt string) string {
+	if companyID == "" {
+		return systemPrompt
+	}
+	prefs, plan, err := h.loadAssistantPreferences(ctx, companyID)
+	if err != nil || !brandVoicePlan(plan) {
+		return systemPrompt
+	}
+	// Stored preferences are re-validated in case the rules tightened since they were saved
+	if err := prefs.Validate(); err != nil {
+		logger.Warn("Ignoring invalid assistant preferences", "company_id", companyID, "error", err.Error())
+		return systemPrompt
+	}
+	return brandvoice.Apply(systemPrompt, prefs)
+}
+
+// loadAssistantPreferences returns the company's stored preferences and subscription plan
+func (h *Handler) loadAssistantPreferences(ctx context.Context, companyID string) (brandvoice.Preferences, string, error) {
+	var prefs brandvoice.Preferences
+	var raw []byte
+	var plan string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT assistant_preferences, COALESCE(subscription_plan, 'free') FROM companies WHERE id = $1
+	`, companyID).Scan(&raw, &plan)
+	if err != nil {
+		return prefs, "", err
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &prefs); err != nil {
+			logger.Warn("Failed to decode assistant preferences", "company_id", companyID, "error", err.Error())
+		}
+	}
+	return prefs, plan, nil
+}
+
+// brandVoicePlan reports whether a subscription plan includes assistant preferences
+func brandVoicePlan(plan string) bool {
+	return plan != "" && plan != "free"
+}
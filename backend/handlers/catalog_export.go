@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/catalog"
+	"github.com/bantuaku/backend/services/kolosal"
+)
+
+// catalogCategoryCacheTTL keeps AI category suggestions for repeated exports
+const catalogCategoryCacheTTL = 7 * 24 * time.Hour
+
+// ExportCatalog downloads the company's active products as a bulk-upload file for
+// a marketplace (?format=shopee|tokopedia|woocommerce). The seller's categories are
+// translated to marketplace category paths suggested by the AI provider, unless
+// ?categories=original is given.
+func (h *Handler) ExportCatalog(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	format, ok := catalog.Get(r.URL.Query().Get("format"))
+	if !ok {
+		names := make([]string, 0, len(catalog.Formats()))
+		for _, f := range catalog.Formats() {
+			names = append(names, f.Name)
+		}
+		h.respondError(w, errors.NewValidationError("Invalid export format", "use one of: "+strings.Join(names, ", ")), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT name, COALESCE(sku, ''), COALESCE(category, ''), COALESCE(unit_price, 0), COALESCE(is_active, TRUE)
+		FROM products
+		WHERE company_id = $1 AND COALESCE(is_active, TRUE)
+		ORDER BY category, name
+	`, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load products"), r)
+		return
+	}
+	var products []catalog.Product
+	for rows.Next() {
+		var p catalog.Product
+		if rows.Scan(&p.Name, &p.SKU, &p.Category, &p.Price, &p.Active) == nil {
+			products = append(products, p)
+		}
+	}
+	rows.Close()
+	if len(products) == 0 {
+		h.respondError(w, errors.NewValidationError("No active products to export", ""), r)
+		return
+	}
+
+	var mapping map[string]string
+	suggestions := "none"
+	if r.URL.Query().Get("categories") != "original" {
+		mapping, suggestions = h.marketplaceCategories(r.Context(), companyID, format, products)
+	}
+
+	date := time.Now().In(h.companyLocation(r.Context(), companyID)).Format("20060102")
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, format.Filename(date)))
+	w.Header().Set("X-Category-Suggestions", suggestions)
+	if err := format.Write(w, format.Rows(products, mapping)); err != nil {
+		logger.Error("Failed to write catalog export", "company_id", companyID, "format", format.Name, "error", err.Error())
+	}
+}
+
+// marketplaceCategories suggests a marketplace category for each of the seller's
+// categories. It returns the mapping and its origin: "ai", "cached" or "none".
+func (h *Handler) marketplaceCategories(ctx context.Context, companyID string, format catalog.Format, products []catalog.Product) (map[string]string, string) {
+	categories := catalog.Categories(products)
+	if len(categories) == 0 || h.config.KolosalAPIKey == "" {
+		return nil, "none"
+	}
+	if len(categories) > catalog.MaxCategories {
+		categories = categories[:catalog.MaxCategories]
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(categories, "\n")))
+	cacheKey := fmt.Sprintf("catalog_categories:%s:%s:%s", companyID, format.Name, hex.EncodeToString(sum[:8]))
+	if cached, err := h.redis.Get(ctx, cacheKey); err == nil && cached != "" {
+		var mapping map[string]string
+		if json.Unmarshal([]byte(cached), &mapping) == nil {
+			return mapping, "cached"
+		}
+	}
+
+	examples := map[string][]string{}
+	for _, p := range products {
+		c := strings.TrimSpace(p.Category)
+		if len(examples[c]) < 3 {
+			examples[c] = append(examples[c], p.Name)
+		}
+	}
+
+	client := h.kolosalClient()
+	resp, err := client.CreateChatCompletion(aispend.WithFeature(ctx, aispend.FeatureCatalog, ""), kolosal.ChatCompletionRequest{
+		Model: "default",
+		Messages: []kolosal.ChatCompletionMessage{
+			{Role: "system", Content: "Kamu ahli katalog marketplace Indonesia (Shopee, Tokopedia, WooCommerce)."},
+			{Role: "user", Content: catalog.BuildCategoryPrompt(format, categories, examples)},
+		},
+		MaxTokens:   1000,
+		Temperature: 0.2,
+	})
+	if err != nil || len(resp.Choices) == 0 {
+		if err != nil {
+			logger.Warn("Catalog category suggestion failed", "company_id", companyID, "format", format.Name, "error", err.Error())
+		}
+		return nil, "none"
+	}
+	mapping, err := catalog.ParseCategoryMapping(resp.Choices[0].Message.Content, categories)
+	if err != nil {
+		logger.Warn("Catalog category suggestion unusable", "company_id", companyID, "format", format.Name, "error", err.Error())
+		return nil, "none"
+	}
+
+	if data, err := json.Marshal(mapping); err == nil {
+		h.redis.Set(ctx, cacheKey, string(data), catalogCategoryCacheTTL)
+	}
+	return mapping, "ai"
+}
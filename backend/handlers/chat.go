@@ -1,15 +1,25 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/chatattach"
+	"github.com/bantuaku/backend/services/chatpayload"
 	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/metering"
 	"github.com/bantuaku/backend/validation"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // StartConversationRequest represents a request to start a new conversation
@@ -28,7 +38,9 @@ type StartConversationResponse struct {
 type SendMessageRequest struct {
 	ConversationID string   `json:"conversation_id" validate:"required"`
 	Message        string   `json:"message" validate:"required"`
-	FileUploadIDs  []string `json:"file_upload_ids,omitempty"`
+	FileUploadIDs  []string `json:"file_upload_ids,omitempty"` // Uploads whose content the assistant reads with the message
+
+	attachments []models.FileUpload // Resolved FileUploadIDs
 }
 
 // SendMessageResponse represents the response when sending a message
@@ -36,7 +48,8 @@ type SendMessageResponse struct {
 	MessageID             string                 `json:"message_id"`
 	AssistantReply        string                 `json:"assistant_reply"`
 	StructuredPayload     map[string]interface{} `json:"structured_payload,omitempty"`
-	UpdatedProfileSummary map[string]interface{} `json:"updated_profile_summary,omitempty"`
+	UpdatedProfileSummary *CompanyProfileSummary `json:"updated_profile_summary,omitempty"` // Set when the assistant changed the company profile
+	Cached                bool                   `json:"cached,omitempty"`                  // Reply reused from an answer to a similar question
 }
 
 // GetConversationsResponse represents a list of conversations
@@ -46,22 +59,46 @@ type GetConversationsResponse struct {
 
 // ConversationSummary represents a summary of a conversation
 type ConversationSummary struct {
-	ID            string    `json:"id"`
-	Title         string    `json:"title"`
-	Purpose       string    `json:"purpose"`
-	CreatedAt     time.Time `json:"created_at"`
-	LastMessageAt time.Time `json:"last_message_at"`
+	ID            string     `json:"id"`
+	Title         string     `json:"title"`
+	Purpose       string     `json:"purpose"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastMessageAt time.Time  `json:"last_message_at"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty"`
 }
 
-// GetMessagesResponse represents a list of messages
+// GetMessagesResponse represents the recent messages of a conversation. Older
+// messages are archived and represented by the rolling summary.
 type GetMessagesResponse struct {
-	Messages []models.Message `json:"messages"`
+	Messages      []models.Message `json:"messages"`
+	Summary       string           `json:"summary,omitempty"`
+	ArchivedCount int              `json:"archived_count"`
+	HasMore       bool             `json:"has_more"` // Older messages exist beyond those returned
 }
 
+// Chat history limits
+const (
+	defaultRecentMessages = 50
+	maxRecentMessages     = 200
+	chatHistoryMessages   = 10 // Previous messages sent to the model with each new one
+)
+
+// Chat completion defaults
+const (
+	defaultChatModel       = kolosal.DefaultModel
+	defaultChatTemperature = 0.7
+)
+
+// chatAssistantPrompt is the assistant's base instruction, before company context
+const chatAssistantPrompt = "Kamu adalah Asisten Bantuaku, AI assistant untuk membantu UMKM Indonesia. Jawab dalam Bahasa Indonesia yang natural dan ramah. Untuk angka penjualan, ambil datanya dengan tool get_sales_analytics dan jangan mengarang angka."
+
 // StartConversation creates a new conversation
 func (h *Handler) StartConversation(w http.ResponseWriter, r *http.Request) {
-	_ = r.Context().Value("user_id")  // TODO: Use userID when implementing DB storage
-	_ = r.Context().Value("store_id") // TODO: Update to company_id
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
 
 	var req StartConversationRequest
 	if err := h.parseJSON(r, &req); err != nil {
@@ -74,102 +111,368 @@ func (h *Handler) StartConversation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: Implement conversation creation in database
-	// For now, return a mock response
 	conversationID := uuid.New().String()
 	title := "New Conversation"
 	if req.Purpose == "onboarding" {
 		title = "Onboarding"
 	}
+	now := time.Now()
+
+	_, err := h.db.Pool().Exec(r.Context(), `
+		INSERT INTO conversations (id, company_id, user_id, title, purpose, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+	`, conversationID, companyID, middleware.GetUserID(r.Context()), title, req.Purpose, now)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create conversation"), r)
+		return
+	}
 
-	h.respondJSON(w, http.StatusOK, StartConversationResponse{
+	h.respondJSON(w, r, http.StatusOK, StartConversationResponse{
 		ConversationID: conversationID,
 		Title:          title,
-		CreatedAt:      time.Now(),
+		CreatedAt:      now,
 	})
 }
 
-// SendMessage handles sending a message in a conversation
+// SendMessage stores a user message, answers it with the assistant (given the
+// conversation's rolling summary and recent history) and stores the reply
 func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
-	_ = r.Context().Value("user_id")  // TODO: Use userID when implementing DB storage
-	_ = r.Context().Value("store_id") // TODO: Update to company_id
+	ctx := r.Context()
+	companyID, req, summary, history, ok := h.acceptChatMessage(w, r)
+	if !ok {
+		return
+	}
 
+	answer := h.answerChatMessage(ctx, companyID, req, summary, history, nil)
+
+	messageID, err := h.saveMessage(ctx, req.ConversationID, "assistant", answer.reply, answer.payload)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save assistant reply"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, h.sendMessageResponse(ctx, companyID, messageID, answer))
+}
+
+// chatAnswer is the assistant's reply to a message
+type chatAnswer struct {
+	reply          string
+	payload        map[string]interface{} // Structured payload of the reply
+	cached         bool                   // Reused from an answer to a similar question
+	profileChanged bool                   // A tool changed the company profile
+}
+
+// sendMessageResponse is the response to a stored reply, with the company
+// profile summary when the reply changed the profile
+func (h *Handler) sendMessageResponse(ctx context.Context, companyID, messageID string, answer chatAnswer) SendMessageResponse {
+	resp := SendMessageResponse{
+		MessageID:         messageID,
+		AssistantReply:    answer.reply,
+		StructuredPayload: answer.payload,
+		Cached:            answer.cached,
+	}
+	if answer.profileChanged {
+		summary, err := h.companyProfileSummary(ctx, companyID)
+		if err != nil {
+			logger.Warn("Failed to load company profile summary", "company_id", companyID, "error", err.Error())
+		}
+		resp.UpdatedProfileSummary = summary
+	}
+	return resp
+}
+
+// acceptChatMessage validates a message for a conversation of the company,
+// charges it against the plan's chat limit and stores it, returning the
+// conversation's summary and recent history. It responds with the error when
+// the message is refused.
+func (h *Handler) acceptChatMessage(w http.ResponseWriter, r *http.Request) (string, SendMessageRequest, string, []models.Message, bool) {
+	ctx := r.Context()
 	var req SendMessageRequest
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return "", req, "", nil, false
+	}
+
 	if err := h.parseJSON(r, &req); err != nil {
 		h.respondError(w, err, r)
-		return
+		return "", req, "", nil, false
 	}
 
 	if err := validation.Validate(&req); err != nil {
 		h.respondError(w, err, r)
-		return
+		return "", req, "", nil, false
+	}
+
+	summary, err := h.conversationSummary(ctx, companyID, req.ConversationID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Conversation"), r)
+		return "", req, "", nil, false
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load conversation"), r)
+		return "", req, "", nil, false
+	}
+	history, err := h.recentMessages(ctx, req.ConversationID, chatHistoryMessages)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load conversation history"), r)
+		return "", req, "", nil, false
+	}
+	if req.attachments, err = h.resolveChatAttachments(ctx, companyID, middleware.GetUserID(ctx), req.FileUploadIDs); err != nil {
+		h.respondError(w, err, r)
+		return "", req, "", nil, false
 	}
+	usage, err := h.consumeUsage(ctx, companyID, metering.MetricChatMessages, 1)
+	setUsageHeaders(w, usage)
+	if err != nil {
+		h.respondError(w, err, r)
+		return "", req, "", nil, false
+	}
+	messageID, err := h.saveMessage(ctx, req.ConversationID, "user", req.Message, nil)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save message"), r)
+		return "", req, "", nil, false
+	}
+	if err := h.linkMessageAttachments(ctx, messageID, req.attachments); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save message attachments"), r)
+		return "", req, "", nil, false
+	}
+	return companyID, req, summary, history, true
+}
+
+// answerChatMessage produces the assistant's reply to a message, from the
+// answer cache when it has one for an opening question. With stream set, the
+// reply is sent as it is generated.
+func (h *Handler) answerChatMessage(ctx context.Context, companyID string, req SendMessageRequest, summary string, history []models.Message, stream *eventStream) chatAnswer {
+	if h.config.KolosalAPIKey == "" {
+		return chatAnswer{reply: "Terima kasih atas pesan Anda. Fitur AI chat sedang dalam pengembangan. Silakan coba lagi nanti."}
+	}
+	// Use Kolosal.ai for chat completion
+	ctx = aispend.WithFeature(ctx, aispend.FeatureChat, req.ConversationID)
+	client := h.kolosalClient()
 
-	// TODO: Implement message handling with AI assistant
-	// For now, return a mock response or use Kolosal.ai if API key is available
-	messageID := uuid.New().String()
-	var assistantReply string
-	var structuredPayload map[string]interface{}
-
-	if h.config.KolosalAPIKey != "" {
-		// Use Kolosal.ai for chat completion
-		client := kolosal.NewClient(h.config.KolosalAPIKey)
-		ctx := r.Context()
-
-		systemPrompt := "Kamu adalah Asisten Bantuaku, AI assistant untuk membantu UMKM Indonesia. Jawab dalam Bahasa Indonesia yang natural dan ramah."
-		userPrompt := req.Message
-
-		resp, err := client.CreateChatCompletion(ctx, kolosal.ChatCompletionRequest{
-			Model: "default",
-			Messages: []kolosal.ChatCompletionMessage{
-				{Role: "system", Content: systemPrompt},
-				{Role: "user", Content: userPrompt},
-			},
-			MaxTokens:   1000,
-			Temperature: 0.7,
-		})
-
-		if err == nil && len(resp.Choices) > 0 {
-			assistantReply = resp.Choices[0].Message.Content
-		} else {
-			assistantReply = "Terima kasih atas pesan Anda. Saya sedang memproses permintaan Anda."
+	// Only opening questions are shared: later turns depend on the conversation,
+	// and questions about attached files on the files
+	var cacheLookup *cachedAnswerLookup
+	if h.answerCacheEnabled() && len(history) == 0 && summary == "" && len(req.attachments) == 0 {
+		started := time.Now()
+		var answer string
+		if answer, cacheLookup = h.lookupCachedAnswer(ctx, client, companyID, req.Message); answer != "" {
+			h.recordChatUsage(ctx, req, time.Since(started), nil, false)
+			return chatAnswer{reply: answer, payload: replyPayload(answer, nil), cached: true}
 		}
-	} else {
-		assistantReply = "Terima kasih atas pesan Anda. Fitur AI chat sedang dalam pengembangan. Silakan coba lagi nanti."
 	}
+	reply, blocks, tools := h.generateChatReply(ctx, client, companyID, req, summary, history, cacheLookup, stream)
+	return chatAnswer{reply: reply, payload: replyPayload(reply, blocks), profileChanged: h.changesProfile(tools)}
+}
 
-	h.respondJSON(w, http.StatusOK, SendMessageResponse{
-		MessageID:         messageID,
-		AssistantReply:    assistantReply,
-		StructuredPayload: structuredPayload,
-	})
+// replyPayload is a reply's structured payload: the components built from the
+// tools it called, and its task list as a checklist
+func replyPayload(reply string, blocks []chatpayload.Block) map[string]interface{} {
+	if checklist := chatpayload.ChecklistFromMarkdown(reply); checklist != nil {
+		blocks = append(blocks, *checklist)
+	}
+	return chatpayload.Payload(blocks)
+}
+
+// generateChatReply asks the model for a reply, returning it with the
+// components built from the tools it called and the tools' names. Answers that
+// needed none of the company's data are cached when cacheLookup is set.
+func (h *Handler) generateChatReply(ctx context.Context, client *kolosal.Client, companyID string, req SendMessageRequest, summary string, history []models.Message, cacheLookup *cachedAnswerLookup, stream *eventStream) (string, []chatpayload.Block, []string) {
+	question := chatattach.Question(req.Message, h.chatAttachmentFiles(ctx, req.attachments))
+	messages := chatMessages(h.chatSystemPrompt(ctx, companyID, summary), history, question)
+
+	started := time.Now()
+	reply, tools, blocks, err := h.streamChatWithTools(ctx, client, companyID, messages, defaultChatModel, defaultChatTemperature, stream)
+	h.recordChatUsage(ctx, req, time.Since(started), tools, err != nil)
+	if err != nil {
+		if stream != nil {
+			// What was streamed so far is replaced by the fallback in the done event
+			stream.send(chatEventError, map[string]string{"message": "Asisten gagal menjawab. Silakan coba lagi."})
+		}
+		return "Terima kasih atas pesan Anda. Saya sedang memproses permintaan Anda.", nil, tools
+	}
+
+	if cacheLookup != nil && len(tools) == 0 {
+		h.storeCachedAnswer(ctx, cacheLookup, reply)
+	}
+	return reply, blocks, tools
 }
 
-// GetConversations retrieves all conversations for a company
+// chatSystemPrompt is the assistant's instructions for a company, with the
+// conversation's rolling summary
+func (h *Handler) chatSystemPrompt(ctx context.Context, companyID, summary string) string {
+	systemPrompt := h.withBrandVoice(ctx, companyID, chatAssistantPrompt)
+	if score, err := h.computeBusinessHealth(ctx, companyID, h.companyLocation(ctx, companyID)); err == nil {
+		if line := healthPromptContext(score); line != "" {
+			systemPrompt += "\n\n" + line
+		}
+	}
+	if summary != "" {
+		systemPrompt += "\n\nRingkasan percakapan sebelumnya:\n" + summary
+	}
+	return systemPrompt
+}
+
+// chatMessages builds a completion request's messages from the system prompt,
+// the user and assistant turns of history, and the new question. Past turns
+// name their attachments; only the new question carries file content.
+func chatMessages(systemPrompt string, history []models.Message, question string) []kolosal.ChatCompletionMessage {
+	messages := []kolosal.ChatCompletionMessage{{Role: "system", Content: systemPrompt}}
+	for _, m := range history {
+		if m.Sender != "user" && m.Sender != "assistant" {
+			continue
+		}
+		content := m.Content
+		if len(m.Attachments) > 0 {
+			names := make([]string, len(m.Attachments))
+			for i, a := range m.Attachments {
+				names[i] = a.OriginalFilename
+			}
+			content += "\n" + chatattach.Names(names)
+		}
+		messages = append(messages, kolosal.ChatCompletionMessage{Role: m.Sender, Content: content})
+	}
+	return append(messages, kolosal.ChatCompletionMessage{Role: "user", Content: question})
+}
+
+// GetConversations retrieves the company's conversations, most recently active
+// first. Archived conversations are left out, or listed alone with ?archived=true.
 func (h *Handler) GetConversations(w http.ResponseWriter, r *http.Request) {
-	_ = r.Context().Value("user_id")  // TODO: Use userID when implementing DB storage
-	_ = r.Context().Value("store_id") // TODO: Update to company_id
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	archived := r.URL.Query().Get("archived") == "true"
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT id, COALESCE(title, ''), COALESCE(purpose, ''), created_at, COALESCE(updated_at, created_at), archived_at
+		FROM conversations
+		WHERE company_id = $1 AND (archived_at IS NOT NULL) = $2
+		ORDER BY COALESCE(updated_at, created_at) DESC
+	`, companyID, archived)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list conversations"), r)
+		return
+	}
+	defer rows.Close()
+
+	conversations := []ConversationSummary{}
+	for rows.Next() {
+		var c ConversationSummary
+		if rows.Scan(&c.ID, &c.Title, &c.Purpose, &c.CreatedAt, &c.LastMessageAt, &c.ArchivedAt) == nil {
+			conversations = append(conversations, c)
+		}
+	}
 
-	// TODO: Implement conversation retrieval from database
-	// For now, return empty list
-	h.respondJSON(w, http.StatusOK, GetConversationsResponse{
-		Conversations: []ConversationSummary{},
+	h.respondJSON(w, r, http.StatusOK, GetConversationsResponse{
+		Conversations: conversations,
 	})
 }
 
-// GetMessages retrieves messages for a conversation
+// GetMessages returns the most recent messages of a conversation (?limit=, default 50)
+// with the rolling summary of archived history. Archived messages are available
+// from the conversation archive endpoints.
 func (h *Handler) GetMessages(w http.ResponseWriter, r *http.Request) {
-	_ = r.Context().Value("user_id") // TODO: Use userID when implementing DB storage
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
 	conversationID := r.URL.Query().Get("conversation_id")
 
 	if conversationID == "" {
 		h.respondError(w, errors.NewValidationError("conversation_id is required", ""), r)
 		return
 	}
+	limit := defaultRecentMessages
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxRecentMessages {
+			h.respondError(w, errors.NewValidationError("Invalid limit", fmt.Sprintf("limit must be between 1 and %d", maxRecentMessages)), r)
+			return
+		}
+		limit = n
+	}
 
-	// TODO: Implement message retrieval from database
-	// For now, return empty list
-	h.respondJSON(w, http.StatusOK, GetMessagesResponse{
-		Messages: []models.Message{},
-	})
+	ctx := r.Context()
+	resp := GetMessagesResponse{}
+	var liveCount int
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(summary, ''), archived_message_count,
+			(SELECT COUNT(*) FROM messages WHERE conversation_id = c.id)
+		FROM conversations c
+		WHERE id = $1 AND company_id = $2
+	`, conversationID, companyID).Scan(&resp.Summary, &resp.ArchivedCount, &liveCount)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Conversation"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load conversation"), r)
+		return
+	}
+
+	resp.Messages, err = h.recentMessages(ctx, conversationID, limit)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load messages"), r)
+		return
+	}
+	resp.HasMore = liveCount > len(resp.Messages) || resp.ArchivedCount > 0
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// conversationSummary returns a company's conversation's rolling summary, or pgx.ErrNoRows
+func (h *Handler) conversationSummary(ctx context.Context, companyID, conversationID string) (string, error) {
+	var summary string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(summary, '') FROM conversations WHERE id = $1 AND company_id = $2
+	`, conversationID, companyID).Scan(&summary)
+	return summary, err
+}
+
+// recentMessages returns the last limit live messages of a conversation, oldest first
+func (h *Handler) recentMessages(ctx context.Context, conversationID string, limit int) ([]models.Message, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, conversation_id, sender, content, structured_payload, file_upload_id, created_at
+		FROM (
+			SELECT * FROM messages WHERE conversation_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2
+		) recent
+		ORDER BY created_at, id
+	`, conversationID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+	for rows.Next() {
+		var m models.Message
+		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Sender, &m.Content, &m.StructuredPayload, &m.FileUploadID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return messages, h.loadMessageAttachments(ctx, messages)
+}
+
+// saveMessage appends a message to a conversation and bumps its activity time.
+// A new message brings an archived conversation back to the list.
+func (h *Handler) saveMessage(ctx context.Context, conversationID, sender, content string, payload map[string]interface{}) (string, error) {
+	id := uuid.New().String()
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO messages (id, conversation_id, sender, content, structured_payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+	`, id, conversationID, sender, content, payload)
+	if err != nil {
+		return "", err
+	}
+	h.db.Pool().Exec(ctx, `UPDATE conversations SET updated_at = NOW(), archived_at = NULL WHERE id = $1`, conversationID)
+	return id, nil
 }
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/chatstats"
+	"github.com/bantuaku/backend/services/kolosal"
+
+	"github.com/google/uuid"
+)
+
+// Chat analytics limits
+const (
+	defaultChatAnalyticsDays = 30
+	maxChatAnalyticsDays     = 180
+	maxTopicQuestions        = 200
+	maxChatTopics            = 8
+	chatTopicsCacheTTL       = 6 * time.Hour
+)
+
+// recordChatUsage logs one assistant exchange for analytics. Failures are only logged.
+func (h *Handler) recordChatUsage(ctx context.Context, req SendMessageRequest, elapsed time.Duration, tools []string, failed bool) {
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		return
+	}
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO chat_usage_events (id, company_id, user_id, conversation_id, question, response_ms, tools, failed, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, NOW())
+	`, uuid.New().String(), companyID, middleware.GetUserID(ctx), req.ConversationID, truncateRunes(req.Message, 2000),
+		elapsed.Milliseconds(), tools, failed)
+	if err != nil {
+		logger.Warn("Failed to record chat usage", "company_id", companyID, "error", err.Error())
+	}
+}
+
+// parseAnalyticsDays reads ?days= for analytics endpoints
+func parseAnalyticsDays(r *http.Request) (int, error) {
+	days := defaultChatAnalyticsDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxChatAnalyticsDays {
+			return 0, errors.NewValidationError("Invalid days", fmt.Sprintf("days must be between 1 and %d", maxChatAnalyticsDays))
+		}
+		days = n
+	}
+	return days, nil
+}
+
+// GetChatAnalytics returns the company's assistant usage over the last ?days= (default 30):
+// messages per day, average response time, most-used tools and clustered question topics
+func (h *Handler) GetChatAnalytics(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	days, err := parseAnalyticsDays(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	loc := h.companyLocation(ctx, companyID)
+	since := time.Now().AddDate(0, 0, -days)
+	analytics := models.ChatAnalytics{Days: days, MessagesPerDay: []models.ChatDailyCount{}, TopTools: []models.ChatToolUsage{}, Topics: []models.ChatTopic{}}
+
+	err = h.db.Pool().QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE failed), COALESCE(AVG(response_ms) FILTER (WHERE NOT failed), 0)::float8
+		FROM chat_usage_events
+		WHERE company_id = $1 AND created_at >= $2
+	`, companyID, since).Scan(&analytics.TotalMessages, &analytics.FailedMessages, &analytics.AvgResponseMs)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "aggregate chat usage"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT (created_at AT TIME ZONE $3)::date, COUNT(*)
+		FROM chat_usage_events
+		WHERE company_id = $1 AND created_at >= $2
+		GROUP BY 1
+		ORDER BY 1
+	`, companyID, since, loc.String())
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count chat messages per day"), r)
+		return
+	}
+	for rows.Next() {
+		var day time.Time
+		var c models.ChatDailyCount
+		if rows.Scan(&day, &c.Count) == nil {
+			c.Date = day.Format("2006-01-02")
+			analytics.MessagesPerDay = append(analytics.MessagesPerDay, c)
+		}
+	}
+	rows.Close()
+
+	rows, err = h.db.Pool().Query(ctx, `
+		SELECT tool, COUNT(*)
+		FROM chat_usage_events, UNNEST(tools) AS tool
+		WHERE company_id = $1 AND created_at >= $2
+		GROUP BY tool
+		ORDER BY 2 DESC, 1
+		LIMIT 10
+	`, companyID, since)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count chat tool usage"), r)
+		return
+	}
+	for rows.Next() {
+		var t models.ChatToolUsage
+		if rows.Scan(&t.Tool, &t.Calls) == nil {
+			analytics.TopTools = append(analytics.TopTools, t)
+		}
+	}
+	rows.Close()
+
+	topics, err := h.chatTopics(ctx, companyID, since, days)
+	if err != nil {
+		logger.Warn("Failed to cluster chat topics", "company_id", companyID, "error", err.Error())
+		analytics.TopicsUnavailable = true
+	} else if topics == nil {
+		analytics.TopicsUnavailable = true
+	} else {
+		analytics.Topics = topics
+	}
+
+	h.respondJSON(w, r, http.StatusOK, analytics)
+}
+
+// chatTopics clusters the company's recent questions by embedding similarity. The
+// result is cached, so repeated views do not re-embed. It returns nil when
+// embeddings are not configured.
+func (h *Handler) chatTopics(ctx context.Context, companyID string, since time.Time, days int) ([]models.ChatTopic, error) {
+	if h.config.KolosalAPIKey == "" {
+		return nil, nil
+	}
+	cacheKey := fmt.Sprintf("chat_topics:%s:%d", companyID, days)
+	if cached, err := h.redis.Get(ctx, cacheKey); err == nil && cached != "" {
+		var topics []models.ChatTopic
+		if json.Unmarshal([]byte(cached), &topics) == nil {
+			return topics, nil
+		}
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT question FROM chat_usage_events
+		WHERE company_id = $1 AND created_at >= $2
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, companyID, since, maxTopicQuestions)
+	if err != nil {
+		return nil, err
+	}
+	var questions []string
+	for rows.Next() {
+		var q string
+		if rows.Scan(&q) == nil {
+			questions = append(questions, q)
+		}
+	}
+	rows.Close()
+
+	topics := []models.ChatTopic{}
+	if len(questions) > 0 {
+		resp, err := h.kolosalClient().CreateEmbeddings(aispend.WithFeature(ctx, aispend.FeatureChatAnalytics, ""), kolosal.EmbeddingRequest{
+			Model: h.config.EmbeddingModel,
+			Input: questions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		vectors := make([][]float32, len(questions))
+		for _, d := range resp.Data {
+			if d.Index < len(vectors) {
+				vectors[d.Index] = d.Embedding
+			}
+		}
+		for _, t := range chatstats.Cluster(questions, vectors, chatstats.DefaultTopicThreshold, maxChatTopics) {
+			topics = append(topics, models.ChatTopic{Label: t.Label, Count: t.Count, Examples: t.Examples})
+		}
+	}
+
+	if data, err := json.Marshal(topics); err == nil {
+		h.redis.Set(ctx, cacheKey, string(data), chatTopicsCacheTTL)
+	}
+	return topics, nil
+}
+
+// AdminChatEngagement aggregates assistant usage per subscription plan over the last ?days=
+func (h *Handler) AdminChatEngagement(w http.ResponseWriter, r *http.Request) {
+	days, err := parseAnalyticsDays(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT COALESCE(c.subscription_plan, 'free') AS plan,
+			COUNT(DISTINCT c.id),
+			COUNT(DISTINCT e.company_id),
+			COUNT(e.id),
+			COALESCE(AVG(e.response_ms) FILTER (WHERE NOT e.failed), 0)::float8
+		FROM companies c
+		LEFT JOIN chat_usage_events e ON e.company_id = c.id AND e.created_at >= NOW() - make_interval(days => $1)
+		WHERE COALESCE(c.status, 'active') = 'active'
+		GROUP BY 1
+		ORDER BY 1
+	`, days)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "aggregate chat engagement"), r)
+		return
+	}
+	defer rows.Close()
+
+	plans := []models.ChatPlanEngagement{}
+	for rows.Next() {
+		var p models.ChatPlanEngagement
+		if err := rows.Scan(&p.Plan, &p.Companies, &p.ActiveCompanies, &p.Messages, &p.AvgResponseMs); err != nil {
+			continue
+		}
+		if p.ActiveCompanies > 0 {
+			p.MessagesPerActive = float64(p.Messages) / float64(p.ActiveCompanies)
+		}
+		plans = append(plans, p)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"days":  days,
+		"plans": plans,
+	})
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/chatarchive"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MessageArchive describes one compressed batch of archived messages
+type MessageArchive struct {
+	ID             string    `json:"id"`
+	MessageCount   int       `json:"message_count"`
+	FirstMessageAt time.Time `json:"first_message_at"`
+	LastMessageAt  time.Time `json:"last_message_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ArchiveConversations moves messages beyond CHAT_RETAIN_MESSAGES per conversation
+// into compressed archives and folds them into the conversation's rolling summary.
+// It is run periodically from main.
+func (h *Handler) ArchiveConversations(ctx context.Context) {
+	retain := h.config.ChatRetainMessages
+	if retain < 1 {
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT conversation_id FROM messages
+		GROUP BY conversation_id
+		HAVING COUNT(*) > $1
+	`, retain)
+	if err != nil {
+		logger.Error("Message archival failed to list conversations", "error", err.Error())
+		return
+	}
+	var conversationIDs []string
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			conversationIDs = append(conversationIDs, id)
+		}
+	}
+	rows.Close()
+
+	archived := 0
+	for _, id := range conversationIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		n, err := h.archiveConversation(ctx, id, retain)
+		if err != nil {
+			logger.Warn("Message archival failed", "conversation_id", id, "error", err.Error())
+			continue
+		}
+		archived += n
+	}
+
+	logger.Info("Message archival completed", "conversations", len(conversationIDs), "messages", archived)
+}
+
+// archiveConversation archives all but the newest retain messages of one conversation
+func (h *Handler) archiveConversation(ctx context.Context, conversationID string, retain int) (int, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, conversation_id, sender, content, structured_payload, file_upload_id, created_at
+		FROM messages
+		WHERE conversation_id = $1
+		ORDER BY created_at DESC, id DESC
+		OFFSET $2
+	`, conversationID, retain)
+	if err != nil {
+		return 0, err
+	}
+	var messages []models.Message
+	for rows.Next() {
+		var m models.Message
+		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Sender, &m.Content, &m.StructuredPayload, &m.FileUploadID, &m.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		messages = append(messages, m)
+	}
+	rows.Close()
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	// Oldest first, as they will be read back
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	payload, err := chatarchive.Compress(messages)
+	if err != nil {
+		return 0, err
+	}
+
+	var previous string
+	h.db.Pool().QueryRow(ctx, `SELECT COALESCE(summary, '') FROM conversations WHERE id = $1`, conversationID).Scan(&previous)
+	summary := h.rollingSummary(aispend.WithFeature(ctx, aispend.FeatureChatSummary, conversationID), previous, messages)
+
+	ids := make([]string, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO message_archives (id, conversation_id, message_count, first_message_at, last_message_at, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`, uuid.New().String(), conversationID, len(messages), messages[0].CreatedAt, messages[len(messages)-1].CreatedAt, payload)
+	if err != nil {
+		return 0, fmt.Errorf("save archive: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM messages WHERE id = ANY($1)`, ids); err != nil {
+		return 0, fmt.Errorf("delete archived messages: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE conversations SET summary = $2, archived_message_count = archived_message_count + $3 WHERE id = $1
+	`, conversationID, summary, len(messages))
+	if err != nil {
+		return 0, fmt.Errorf("update conversation summary: %w", err)
+	}
+	return len(messages), tx.Commit(ctx)
+}
+
+// rollingSummary folds archived messages into the conversation summary, using AI
+// when available and a rule-based note otherwise
+func (h *Handler) rollingSummary(ctx context.Context, previous string, messages []models.Message) string {
+	archived := make([]chatarchive.Message, len(messages))
+	for i, m := range messages {
+		archived[i] = chatarchive.Message{Sender: m.Sender, Content: m.Content, CreatedAt: m.CreatedAt}
+	}
+	if text, ok := h.aiText(ctx, "Kamu merangkum percakapan bisnis. Jawab dalam Bahasa Indonesia.",
+		chatarchive.BuildSummaryPrompt(previous, archived), 800); ok {
+		return chatarchive.Trim(text)
+	}
+	return chatarchive.RuleSummary(previous, archived)
+}
+
+// ListConversationArchives lists the archived message batches of a conversation, oldest first
+func (h *Handler) ListConversationArchives(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	ctx := r.Context()
+	conversationID := r.PathValue("id")
+	if _, err := h.conversationSummary(ctx, companyID, conversationID); err != nil {
+		h.respondError(w, errors.NewNotFoundError("Conversation"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, message_count, first_message_at, last_message_at, created_at
+		FROM message_archives
+		WHERE conversation_id = $1
+		ORDER BY first_message_at
+	`, conversationID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list message archives"), r)
+		return
+	}
+	defer rows.Close()
+
+	archives := []MessageArchive{}
+	for rows.Next() {
+		var a MessageArchive
+		if rows.Scan(&a.ID, &a.MessageCount, &a.FirstMessageAt, &a.LastMessageAt, &a.CreatedAt) == nil {
+			archives = append(archives, a)
+		}
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"archives": archives,
+	})
+}
+
+// GetConversationArchive returns the messages of one archived batch
+func (h *Handler) GetConversationArchive(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var payload []byte
+	err := h.db.Pool().QueryRow(r.Context(), `
+		SELECT a.payload
+		FROM message_archives a
+		JOIN conversations c ON c.id = a.conversation_id
+		WHERE a.id = $1 AND a.conversation_id = $2 AND c.company_id = $3
+	`, r.PathValue("archive_id"), r.PathValue("id"), companyID).Scan(&payload)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Message archive"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load message archive"), r)
+		return
+	}
+
+	messages := []models.Message{}
+	if err := chatarchive.Decompress(payload, &messages); err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to read message archive"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, GetMessagesResponse{Messages: messages})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/chatattach"
+	"github.com/bantuaku/backend/services/docparse"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// resolveChatAttachments loads the uploads attached to a message, in the order
+// given. Every file must be one the user may see.
+func (h *Handler) resolveChatAttachments(ctx context.Context, companyID, userID string, ids []string) ([]models.FileUpload, error) {
+	seen := map[string]bool{}
+	var unique []string
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	if len(unique) > chatattach.MaxFiles {
+		return nil, errors.NewValidationError("Too many attachments",
+			fmt.Sprintf("at most %d files can be attached to a message", chatattach.MaxFiles))
+	}
+
+	files := make([]models.FileUpload, 0, len(unique))
+	for _, id := range unique {
+		f, err := h.accessibleFile(ctx, id, companyID, userID)
+		if err == pgx.ErrNoRows {
+			return nil, errors.NewNotFoundError("File")
+		}
+		if err != nil {
+			return nil, errors.NewDatabaseError(err, "load attachment")
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// linkMessageAttachments associates the attached files with the stored
+// message. The first file also fills the message's legacy file_upload_id.
+func (h *Handler) linkMessageAttachments(ctx context.Context, messageID string, files []models.FileUpload) error {
+	if len(files) == 0 {
+		return nil
+	}
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for i, f := range files {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO message_attachments (message_id, file_id, position) VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, messageID, f.ID, i); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `UPDATE messages SET file_upload_id = $2 WHERE id = $1`, messageID, files[0].ID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// loadMessageAttachments fills in the files attached to each message
+func (h *Handler) loadMessageAttachments(ctx context.Context, messages []models.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	ids := make([]string, len(messages))
+	index := make(map[string]int, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+		index[m.ID] = i
+	}
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT a.message_id, f.id, f.original_filename, f.source_type
+		FROM message_attachments a
+		JOIN file_uploads f ON f.id = a.file_id
+		WHERE a.message_id = ANY($1)
+		ORDER BY a.message_id, a.position
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var messageID string
+		var a models.MessageAttachment
+		if err := rows.Scan(&messageID, &a.FileID, &a.OriginalFilename, &a.SourceType); err != nil {
+			return err
+		}
+		m := &messages[index[messageID]]
+		m.Attachments = append(m.Attachments, a)
+	}
+	return rows.Err()
+}
+
+// messageAttachmentFiles loads the files attached to a stored message, in the
+// order they were attached
+func (h *Handler) messageAttachmentFiles(ctx context.Context, messageID string) ([]models.FileUpload, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT f.id, f.company_id, f.user_id, f.source_type, f.original_filename, f.storage_path, f.status
+		FROM message_attachments a
+		JOIN file_uploads f ON f.id = a.file_id
+		WHERE a.message_id = $1
+		ORDER BY a.position
+	`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var files []models.FileUpload
+	for rows.Next() {
+		var f models.FileUpload
+		if err := rows.Scan(&f.ID, &f.CompanyID, &f.UserID, &f.SourceType, &f.OriginalFilename, &f.StoragePath, &f.Status); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// chatAttachmentFiles prepares attached files for the prompt. Files without a
+// parse result are parsed (or read by OCR) now; files too long for their
+// share of the prompt are summarized by AI, falling back to a truncated
+// excerpt. Files that cannot be read are noted rather than failing the reply.
+func (h *Handler) chatAttachmentFiles(ctx context.Context, files []models.FileUpload) []chatattach.File {
+	budget := chatattach.Budget(len(files))
+	result := make([]chatattach.File, 0, len(files))
+	for _, f := range files {
+		file := chatattach.File{Name: f.OriginalFilename}
+		content, err := h.attachmentContent(ctx, f)
+		if err != nil {
+			logger.Warn("Failed to read chat attachment", "file_id", f.ID, "error", err.Error())
+			file.Error = "isi berkas tidak dapat diekstrak"
+			result = append(result, file)
+			continue
+		}
+		file.Text = content.Text
+		if !chatattach.Fits(content.Text, budget) {
+			if summary, ok := h.attachmentSummary(ctx, f, content.Text, budget); ok {
+				file.Text, file.Summary = summary, true
+			}
+		}
+		result = append(result, file)
+	}
+	return result
+}
+
+// attachmentContent returns an attachment's extracted content, parsing the
+// stored file when it has no parse result yet
+func (h *Handler) attachmentContent(ctx context.Context, f models.FileUpload) (FileContentResponse, error) {
+	content, err := h.fileParseResult(ctx, f.ID)
+	if err != pgx.ErrNoRows {
+		return content, err
+	}
+
+	// Photos are read from the image prepared for OCR when there is one
+	parsePath := f.StoragePath
+	if f.SourceType == docparse.KindImage {
+		var ocrImagePath string
+		h.db.Pool().QueryRow(ctx, `SELECT COALESCE(ocr_image_path, '') FROM file_uploads WHERE id = $1`, f.ID).Scan(&ocrImagePath)
+		if ocrImagePath != "" {
+			parsePath = ocrImagePath
+		}
+	}
+	parsed, outcome, parseErr := h.parseStoredFile(ctx, parsePath, f.SourceType)
+	if parseErr != nil {
+		h.db.Pool().Exec(ctx, `
+			UPDATE file_uploads SET status = 'failed', error_message = $2 WHERE id = $1
+		`, f.ID, truncateRunes(parseErr.Error(), 1000))
+		return content, parseErr
+	}
+	if err := h.saveParseResult(ctx, f.ID, parsed, outcome); err != nil {
+		logger.Warn("Failed to store parse result", "file_id", f.ID, "error", err.Error())
+	} else {
+		h.db.Pool().Exec(ctx, `
+			UPDATE file_uploads SET status = 'processed', error_message = NULL, processed_at = NOW() WHERE id = $1
+		`, f.ID)
+	}
+	logger.Info("Parsed chat attachment", "file_id", f.ID, "parser", parsed.Parser)
+	return FileContentResponse{FileID: f.ID, Parser: parsed.Parser, Text: parsed.Text, Tables: parsed.Tables, Truncated: parsed.Truncated}, nil
+}
+
+// attachmentSummary summarizes a long attachment for the prompt, reusing the
+// summary stored with its parse result
+func (h *Handler) attachmentSummary(ctx context.Context, f models.FileUpload, text string, budget int) (string, bool) {
+	var cached string
+	h.db.Pool().QueryRow(ctx, `SELECT COALESCE(summary, '') FROM file_parse_results WHERE file_id = $1`, f.ID).Scan(&cached)
+	if cached != "" {
+		return cached, true
+	}
+
+	summary, ok := h.aiText(ctx, "Kamu merangkum dokumen bisnis UMKM. Jawab dalam Bahasa Indonesia.",
+		chatattach.BuildSummaryPrompt(f.OriginalFilename, text, budget), 1200)
+	if !ok {
+		return "", false
+	}
+	if _, err := h.db.Pool().Exec(ctx, `UPDATE file_parse_results SET summary = $2 WHERE file_id = $1`, f.ID, summary); err != nil {
+		logger.Warn("Failed to store attachment summary", "file_id", f.ID, "error", err.Error())
+	}
+	return summary, true
+}
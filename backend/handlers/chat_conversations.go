@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/membership"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ArchiveConversationRequest archives or restores a conversation
+type ArchiveConversationRequest struct {
+	Archived *bool `json:"archived,omitempty"` // Defaults to true
+}
+
+// DeleteConversation deletes a conversation with its messages and message
+// archives. Answer feedback is kept, and usage analytics lose only the link.
+func (h *Handler) DeleteConversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID, conversationID, ok := h.authorizeConversationChange(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `DELETE FROM messages WHERE conversation_id = $1`, conversationID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete messages"), r)
+		return
+	}
+	messages := tag.RowsAffected()
+	if _, err := tx.Exec(ctx, `DELETE FROM message_archives WHERE conversation_id = $1`, conversationID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete message archives"), r)
+		return
+	}
+	if _, err := tx.Exec(ctx, `UPDATE chat_usage_events SET conversation_id = NULL WHERE conversation_id = $1`, conversationID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "unlink chat usage events"), r)
+		return
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM conversations WHERE id = $1 AND company_id = $2`, conversationID, companyID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete conversation"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	logger.Info("Conversation deleted", "company_id", companyID, "conversation_id", conversationID, "messages", messages)
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"message":          "Conversation deleted",
+		"messages_deleted": messages,
+	})
+}
+
+// SetConversationArchived archives a conversation, hiding it from the default
+// conversation list, or restores it with {"archived": false}. Sending a message
+// to an archived conversation also restores it.
+func (h *Handler) SetConversationArchived(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req ArchiveConversationRequest
+	if r.ContentLength > 0 {
+		if err := h.parseJSON(r, &req); err != nil {
+			h.respondError(w, err, r)
+			return
+		}
+	}
+	companyID, conversationID, ok := h.authorizeConversationChange(w, r)
+	if !ok {
+		return
+	}
+	archived := req.Archived == nil || *req.Archived
+
+	var c ConversationSummary
+	err := h.db.Pool().QueryRow(ctx, `
+		UPDATE conversations SET archived_at = CASE WHEN $3 THEN COALESCE(archived_at, NOW()) END
+		WHERE id = $1 AND company_id = $2
+		RETURNING id, COALESCE(title, ''), COALESCE(purpose, ''), created_at, COALESCE(updated_at, created_at), archived_at
+	`, conversationID, companyID, archived).Scan(&c.ID, &c.Title, &c.Purpose, &c.CreatedAt, &c.LastMessageAt, &c.ArchivedAt)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "archive conversation"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, c)
+}
+
+// authorizeConversationChange checks that the conversation in the path belongs
+// to the company, and that the user started it or owns the company. It responds
+// with the error otherwise.
+func (h *Handler) authorizeConversationChange(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return "", "", false
+	}
+	conversationID := r.PathValue("id")
+	starter, err := h.conversationStarter(ctx, companyID, conversationID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Conversation"), r)
+		return "", "", false
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load conversation"), r)
+		return "", "", false
+	}
+	if starter != middleware.GetUserID(ctx) && middleware.GetCompanyRole(ctx) != membership.RoleOwner {
+		h.respondError(w, errors.NewForbiddenError("Only the member who started this conversation or a company owner can change it"), r)
+		return "", "", false
+	}
+	return companyID, conversationID, true
+}
+
+// conversationStarter returns the user who started a conversation of the company
+func (h *Handler) conversationStarter(ctx context.Context, companyID, conversationID string) (string, error) {
+	var userID string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT user_id FROM conversations WHERE id = $1 AND company_id = $2
+	`, conversationID, companyID).Scan(&userID)
+	return userID, err
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/services/kolosal"
+)
+
+// Server-sent events of a streamed chat reply
+const (
+	chatEventStart = "start" // The message was accepted
+	chatEventDelta = "delta" // A piece of the reply
+	chatEventTool  = "tool"  // A tool call started or finished
+	chatEventError = "error" // Generation failed; the done event carries the fallback reply
+	chatEventDone  = "done"  // The stored reply, as SendMessage returns it
+)
+
+// chatStreamWriteTimeout outlasts the slowest streamed completion, with time
+// for tool calls
+const chatStreamWriteTimeout = kolosal.StreamTimeout + time.Minute
+
+// eventStream writes server-sent events to a client
+type eventStream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newEventStream starts an event stream response, lifting the server's write
+// timeout to writeTimeout for it
+func newEventStream(w http.ResponseWriter, writeTimeout time.Duration) *eventStream {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		logger.Warn("Failed to extend write deadline for event stream", "error", err.Error())
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering events
+	w.WriteHeader(http.StatusOK)
+	return &eventStream{w: w, rc: rc}
+}
+
+// send writes one event and flushes it to the client
+func (s *eventStream) send(event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// ping writes a comment, keeping idle connections from being closed by proxies
+func (s *eventStream) ping() error {
+	if _, err := fmt.Fprint(s.w, ": ping\n\n"); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// SendMessageStream is SendMessage answering as server-sent events: start,
+// then delta events with pieces of the reply and tool events as the assistant
+// calls tools, and finally done with the stored reply. Requests refused before
+// generation starts get the same JSON errors as SendMessage.
+func (h *Handler) SendMessageStream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID, req, summary, history, ok := h.acceptChatMessage(w, r)
+	if !ok {
+		return
+	}
+
+	stream := newEventStream(w, chatStreamWriteTimeout)
+	if stream.send(chatEventStart, map[string]string{"conversation_id": req.ConversationID}) != nil {
+		return
+	}
+
+	answer := h.answerChatMessage(ctx, companyID, req, summary, history, stream)
+	if ctx.Err() != nil {
+		logger.Info("Chat stream closed by client", "conversation_id", req.ConversationID)
+		return
+	}
+	if answer.cached || h.config.KolosalAPIKey == "" {
+		// Nothing was streamed; send the whole reply as one piece
+		stream.send(chatEventDelta, map[string]string{"content": answer.reply})
+	}
+
+	messageID, err := h.saveMessage(ctx, req.ConversationID, "assistant", answer.reply, answer.payload)
+	if err != nil {
+		logger.Error("Failed to save streamed assistant reply", "conversation_id", req.ConversationID, "error", err.Error())
+		stream.send(chatEventError, map[string]string{"message": "Balasan gagal disimpan."})
+		return
+	}
+	stream.send(chatEventDone, h.sendMessageResponse(ctx, companyID, messageID, answer))
+}
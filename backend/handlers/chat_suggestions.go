@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/purchasing"
+	"github.com/bantuaku/backend/services/suggestions"
+
+	"github.com/google/uuid"
+)
+
+// ChatSuggestion is a suggestion in a company's feed
+type ChatSuggestion struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Title     string    `json:"title"`
+	Prompt    string    `json:"prompt"` // Send as the message when the user picks the suggestion
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetChatSuggestions returns the company's current suggestions, generating the
+// first ones on demand
+func (h *Handler) GetChatSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var generated bool
+	if err := h.db.Pool().QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM chat_suggestions WHERE company_id = $1)`, companyID).Scan(&generated); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load suggestions"), r)
+		return
+	}
+	if !generated {
+		if err := h.refreshChatSuggestions(ctx, companyID); err != nil {
+			logger.Warn("Failed to generate suggestions", "company_id", companyID, "error", err.Error())
+		}
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, kind, title, prompt, created_at FROM chat_suggestions
+		WHERE company_id = $1 AND dismissed_at IS NULL AND expires_at > NOW()
+		ORDER BY priority DESC, created_at DESC
+		LIMIT $2
+	`, companyID, suggestions.PerCompany)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load suggestions"), r)
+		return
+	}
+	defer rows.Close()
+	feed := []ChatSuggestion{}
+	for rows.Next() {
+		var s ChatSuggestion
+		if err := rows.Scan(&s.ID, &s.Kind, &s.Title, &s.Prompt, &s.CreatedAt); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan suggestion"), r)
+			return
+		}
+		feed = append(feed, s)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"suggestions": feed})
+}
+
+// DismissChatSuggestion hides a suggestion; it is not offered again
+func (h *Handler) DismissChatSuggestion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	tag, err := h.db.Pool().Exec(ctx, `
+		UPDATE chat_suggestions SET dismissed_at = COALESCE(dismissed_at, NOW()), dismissed_by = COALESCE(dismissed_by, NULLIF($3, ''))
+		WHERE id = $1 AND company_id = $2
+	`, r.PathValue("id"), companyID, middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "dismiss suggestion"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Suggestion"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "Suggestion dismissed"})
+}
+
+// RefreshChatSuggestions regenerates the suggestion feed of every active
+// company. It is run periodically from main.
+func (h *Handler) RefreshChatSuggestions(ctx context.Context) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id FROM companies WHERE COALESCE(status, 'active') = 'active' AND merged_into IS NULL
+	`)
+	if err != nil {
+		logger.Error("Suggestion refresh failed to list companies", "error", err.Error())
+		return
+	}
+	var companyIDs []string
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			companyIDs = append(companyIDs, id)
+		}
+	}
+	rows.Close()
+
+	failed := 0
+	for _, companyID := range companyIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := h.refreshChatSuggestions(ctx, companyID); err != nil {
+			logger.Warn("Failed to refresh suggestions", "company_id", companyID, "error", err.Error())
+			failed++
+		}
+	}
+	logger.Info("Suggestion refresh completed", "companies", len(companyIDs), "failed", failed)
+}
+
+// refreshChatSuggestions replaces a company's suggestions with ones picked from
+// its current data events. Suggestions that are still relevant keep their ID,
+// and dismissed ones are never offered again.
+func (h *Handler) refreshChatSuggestions(ctx context.Context, companyID string) error {
+	candidates, err := h.suggestionCandidates(ctx, companyID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT kind || ':' || subject FROM chat_suggestions WHERE company_id = $1 AND dismissed_at IS NOT NULL
+	`, companyID)
+	if err != nil {
+		return err
+	}
+	dismissed := map[string]bool{}
+	for rows.Next() {
+		var key string
+		if rows.Scan(&key) == nil {
+			dismissed[key] = true
+		}
+	}
+	rows.Close()
+
+	picked := suggestions.Pick(candidates, dismissed, suggestions.PerCompany)
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	keys := []string{}
+	expires := time.Now().Add(suggestions.TTL)
+	for _, s := range picked {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO chat_suggestions (id, company_id, kind, subject, title, prompt, priority, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (company_id, kind, subject) DO UPDATE
+			SET title = EXCLUDED.title, prompt = EXCLUDED.prompt, priority = EXCLUDED.priority, expires_at = EXCLUDED.expires_at
+			WHERE chat_suggestions.dismissed_at IS NULL
+		`, uuid.New().String(), companyID, s.Kind, s.Subject, s.Title, s.Prompt, s.Priority, expires)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, s.Key())
+	}
+	// Suggestions no longer backed by the data leave the feed
+	_, err = tx.Exec(ctx, `
+		UPDATE chat_suggestions SET expires_at = NOW()
+		WHERE company_id = $1 AND dismissed_at IS NULL AND expires_at > NOW() AND kind || ':' || subject <> ALL($2)
+	`, companyID, keys)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// suggestionCandidates collects suggestions from the company's data events:
+// purchase orders due soon, slow movers and a large week-over-week revenue
+// change
+func (h *Handler) suggestionCandidates(ctx context.Context, companyID string) ([]suggestions.Suggestion, error) {
+	now := time.Now().In(h.companyLocation(ctx, companyID))
+	today := localDate(now, now.Location())
+	var candidates []suggestions.Suggestion
+
+	orders, _, err := h.purchasePlan(ctx, companyID, now, purchasing.DefaultHorizonDays, purchasing.DefaultReviewDays)
+	if err != nil {
+		return nil, err
+	}
+	due := today.AddDate(0, 0, suggestions.DueDays).Format("2006-01-02")
+	ordered := map[string]bool{}
+	for _, o := range orders {
+		// The first order of each product; later ones follow from acting on it
+		if o.OrderDate <= due && !ordered[o.ProductID] {
+			candidates = append(candidates, suggestions.Reorder(o, today))
+		}
+		ordered[o.ProductID] = true
+	}
+
+	flagged, err := h.detectSlowMovers(ctx, companyID, h.config.SlowMoverDays)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range flagged {
+		candidates = append(candidates, suggestions.SlowMover(m))
+	}
+
+	start := today.AddDate(0, 0, -6)
+	var current, previous float64
+	err = h.db.Pool().QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(quantity * price) FILTER (WHERE sale_date >= $2), 0)::float8,
+			COALESCE(SUM(quantity * price) FILTER (WHERE sale_date < $2), 0)::float8
+		FROM sales_history
+		WHERE company_id = $1 AND sale_date >= $3 AND NOT COALESCE(excluded, false)
+	`, companyID, start, start.AddDate(0, 0, -7)).Scan(&current, &previous)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := suggestions.SalesTrend(current, previous, start); ok {
+		candidates = append(candidates, s)
+	}
+	return candidates, nil
+}
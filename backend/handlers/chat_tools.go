@@ -0,0 +1,609 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/chatpayload"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/membership"
+	"github.com/bantuaku/backend/services/queryspec"
+	"github.com/bantuaku/backend/services/salesanalytics"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxToolRounds bounds how many times the model may call tools before answering
+const maxToolRounds = 3
+
+// chatTool is a function the assistant can call during a conversation
+type chatTool struct {
+	definition kolosal.ToolFunction
+	run        func(ctx context.Context, companyID string, args json.RawMessage) (interface{}, error)
+	blocks     func(result interface{}) []chatpayload.Block // Rich components for the reply, optional
+	// writes marks tools that change the company's data; viewers are neither
+	// offered them nor allowed to run them
+	writes bool
+	// mutatesProfile marks tools that change the company profile; replies
+	// that called one carry the updated profile summary
+	mutatesProfile bool
+}
+
+// chatTools returns the tools available to the assistant
+func (h *Handler) chatTools() map[string]chatTool {
+	tools := []chatTool{
+		{
+			definition: kolosal.ToolFunction{
+				Name:        "calculate_pricing",
+				Description: "Hitung titik impas (break-even) dan rentang harga jual yang disarankan untuk satu produk berdasarkan modal, harga, dan volume penjualan.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"product_name":  map[string]interface{}{"type": "string", "description": "Nama produk"},
+						"target_margin": map[string]interface{}{"type": "number", "description": "Target margin kotor dalam persen, default 30"},
+						"fixed_costs":   map[string]interface{}{"type": "number", "description": "Biaya tetap per bulan untuk produk ini dalam rupiah"},
+					},
+					"required": []string{"product_name"},
+				},
+			},
+			run: h.runPricingTool,
+		},
+		{
+			definition: kolosal.ToolFunction{
+				Name:        "read_uploaded_file",
+				Description: "Baca isi teks dan tabel dari file yang diunggah pengguna (PDF, XLSX, DOCX, CSV), misalnya laporan penjualan atau dokumen pemasok.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"file_name": map[string]interface{}{"type": "string", "description": "Nama atau sebagian nama file; kosongkan untuk file terbaru"},
+					},
+				},
+			},
+			run: h.runReadFileTool,
+		},
+		{
+			definition: kolosal.ToolFunction{
+				Name:        "search_knowledge_base",
+				Description: "Cari panduan resmi Bantuaku (perizinan, pemasaran, keuangan, operasional). Utamakan hasil ini dan sertakan URL artikel sebagai sumber jawaban.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"query": map[string]interface{}{"type": "string", "description": "Topik atau pertanyaan yang dicari"},
+					},
+					"required": []string{"query"},
+				},
+			},
+			run:    h.runKnowledgeBaseTool,
+			blocks: knowledgeBaseBlocks,
+		},
+		{
+			definition: kolosal.ToolFunction{
+				Name:        "get_sales_analytics",
+				Description: "Ambil angka penjualan yang tepat dari data pengguna: omzet, jumlah terjual, jumlah transaksi, atau harga rata-rata untuk suatu periode, bisa dibandingkan dengan periode sebelumnya dan dikelompokkan per produk, kanal, hari, minggu, atau bulan. Selalu gunakan tool ini untuk pertanyaan tentang angka penjualan, jangan menebak.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"metric":       map[string]interface{}{"type": "string", "enum": salesanalytics.Metrics(), "description": "revenue (omzet, rupiah), quantity (jumlah terjual), transactions (jumlah catatan penjualan), average_price (harga rata-rata per unit)"},
+						"period":       map[string]interface{}{"type": "string", "enum": salesanalytics.Periods(), "description": "Periode; abaikan bila memakai from dan to"},
+						"from":         map[string]interface{}{"type": "string", "description": "Tanggal awal YYYY-MM-DD untuk rentang khusus"},
+						"to":           map[string]interface{}{"type": "string", "description": "Tanggal akhir YYYY-MM-DD untuk rentang khusus"},
+						"product_name": map[string]interface{}{"type": "string", "description": "Batasi ke satu produk"},
+						"channel":      map[string]interface{}{"type": "string", "description": "Batasi ke satu kanal penjualan"},
+						"group_by":     map[string]interface{}{"type": "string", "enum": salesanalytics.GroupBys(), "description": "Kelompokkan hasil; kosongkan untuk satu angka"},
+						"compare":      map[string]interface{}{"type": "boolean", "description": "Bandingkan dengan periode sebelumnya yang setara, default true"},
+					},
+					"required": []string{"metric"},
+				},
+			},
+			run:    h.runSalesAnalyticsTool,
+			blocks: salesAnalyticsBlocks,
+		},
+		{
+			definition: kolosal.ToolFunction{
+				Name: "query_data",
+				Description: "Jalankan kueri terstruktur (bukan SQL) atas data pengguna untuk pertanyaan yang tidak bisa dijawab get_sales_analytics, lalu ringkas tabel hasilnya. Dataset dan kolom yang tersedia: " + queryspec.Describe() +
+					". Agregat: sum, avg, min, max, count, count_distinct. Operator filter: eq, ne, gt, gte, lt, lte, contains, in. Tanggal ditulis YYYY-MM-DD.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"dataset": map[string]interface{}{"type": "string", "enum": []string{"sales", "products"}},
+						"columns": map[string]interface{}{
+							"type":        "array",
+							"description": "Kolom yang diambil; kolom tanpa agregat harus ada di group_by bila ada agregat",
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"field":     map[string]interface{}{"type": "string"},
+									"aggregate": map[string]interface{}{"type": "string", "enum": []string{"sum", "avg", "min", "max", "count", "count_distinct"}},
+								},
+							},
+						},
+						"filters": map[string]interface{}{
+							"type": "array",
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"field": map[string]interface{}{"type": "string"},
+									"op":    map[string]interface{}{"type": "string", "enum": []string{"eq", "ne", "gt", "gte", "lt", "lte", "contains", "in"}},
+									"value": map[string]interface{}{"description": "Teks, angka, boolean, tanggal YYYY-MM-DD, atau daftar nilai untuk in"},
+								},
+								"required": []string{"field", "op", "value"},
+							},
+						},
+						"group_by": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						"order_by": map[string]interface{}{
+							"type": "array",
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"column": map[string]interface{}{"type": "string", "description": "Nama kolom hasil: field, atau agregat_field seperti sum_revenue"},
+									"desc":   map[string]interface{}{"type": "boolean"},
+								},
+								"required": []string{"column"},
+							},
+						},
+						"limit": map[string]interface{}{"type": "integer", "description": "Jumlah baris, default 50, maksimal 200"},
+					},
+					"required": []string{"dataset", "columns"},
+				},
+			},
+			run:    h.runQueryDataTool,
+			blocks: queryDataBlocks,
+		},
+		{
+			definition: kolosal.ToolFunction{
+				Name:        "update_company_profile",
+				Description: "Simpan data profil usaha yang disebutkan pengguna, misalnya saat onboarding. Isi hanya kolom yang disebutkan pengguna dengan jelas; kolom lain biarkan kosong. Hasilnya menyebutkan kolom profil yang masih kosong.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"description":    map[string]interface{}{"type": "string", "description": "Deskripsi singkat usaha"},
+						"industry":       map[string]interface{}{"type": "string", "description": "Bidang usaha, misalnya kuliner atau fesyen"},
+						"business_model": map[string]interface{}{"type": "string", "description": "Model bisnis, misalnya B2C, B2B, reseller, atau produksi sendiri"},
+						"founded_year":   map[string]interface{}{"type": "integer", "description": "Tahun usaha berdiri"},
+						"website":        map[string]interface{}{"type": "string", "description": "Alamat situs web atau toko online"},
+					},
+				},
+			},
+			run:            h.runUpdateProfileTool,
+			writes:         true,
+			mutatesProfile: true,
+		},
+	}
+
+	byName := make(map[string]chatTool, len(tools))
+	for _, t := range tools {
+		byName[t.definition.Name] = t
+	}
+	return byName
+}
+
+// chatWithTools runs a completion with model, executing any tool calls the model makes and feeding
+// the results back until it produces a final answer. It also returns the names of the tools called.
+// Completions go through OpenRouter, with its model fallbacks, when that is configured.
+func (h *Handler) chatWithTools(ctx context.Context, client *kolosal.Client, companyID string, messages []kolosal.ChatCompletionMessage, model string, temperature float64) (string, []string, error) {
+	reply, used, _, err := h.streamChatWithTools(ctx, client, companyID, messages, model, temperature, nil)
+	return reply, used, err
+}
+
+// streamChatWithTools is chatWithTools, streaming the answer and tool progress
+// to stream when it is set. It also returns the rich components built from the
+// tools' results.
+func (h *Handler) streamChatWithTools(ctx context.Context, client *kolosal.Client, companyID string, messages []kolosal.ChatCompletionMessage, model string, temperature float64, stream *eventStream) (string, []string, []chatpayload.Block, error) {
+	var used []string
+	var blocks []chatpayload.Block
+	completions := h.chatCompletionClient(client)
+	tools := h.chatTools()
+	var defs []kolosal.Tool
+	if companyID != "" {
+		canWrite := membership.CanWrite(middleware.GetCompanyRole(ctx))
+		for _, t := range tools {
+			if t.writes && !canWrite {
+				continue
+			}
+			defs = append(defs, kolosal.Tool{Type: "function", Function: t.definition})
+		}
+	}
+
+	for round := 0; ; round++ {
+		req := kolosal.ChatCompletionRequest{
+			Model:       model,
+			Messages:    messages,
+			MaxTokens:   1000,
+			Temperature: temperature,
+		}
+		if round < maxToolRounds {
+			req.Tools = defs
+		}
+
+		var resp *kolosal.ChatCompletionResponse
+		var err error
+		if stream != nil {
+			resp, err = completions.CreateChatCompletionStream(ctx, req, func(content string) error {
+				return stream.send(chatEventDelta, map[string]string{"content": content})
+			})
+		} else {
+			resp, err = completions.CreateChatCompletion(ctx, req)
+		}
+		if err != nil {
+			return "", used, blocks, err
+		}
+		if len(resp.Choices) == 0 {
+			return "", used, blocks, fmt.Errorf("empty completion")
+		}
+		msg := resp.Choices[0].Message
+		if len(msg.ToolCalls) == 0 || round >= maxToolRounds {
+			return msg.Content, used, blocks, nil
+		}
+
+		messages = append(messages, msg)
+		for _, call := range msg.ToolCalls {
+			used = append(used, call.Function.Name)
+			if stream != nil {
+				if err := stream.send(chatEventTool, map[string]string{"tool": call.Function.Name, "status": "started"}); err != nil {
+					return "", used, blocks, err
+				}
+			}
+			content, toolBlocks := h.runChatTool(ctx, tools, companyID, call)
+			blocks = append(blocks, toolBlocks...)
+			messages = append(messages, kolosal.ChatCompletionMessage{
+				Role:       "tool",
+				ToolCallID: call.ID,
+				Content:    content,
+			})
+			if stream != nil {
+				if err := stream.send(chatEventTool, map[string]string{"tool": call.Function.Name, "status": "finished"}); err != nil {
+					return "", used, blocks, err
+				}
+			}
+		}
+	}
+}
+
+// changesProfile reports whether any of the called tools changes the company profile
+func (h *Handler) changesProfile(called []string) bool {
+	tools := h.chatTools()
+	for _, name := range called {
+		if tools[name].mutatesProfile {
+			return true
+		}
+	}
+	return false
+}
+
+// runChatTool executes one tool call and returns its JSON-encoded result or
+// error, with the rich components built from the result
+func (h *Handler) runChatTool(ctx context.Context, tools map[string]chatTool, companyID string, call kolosal.ToolCall) (string, []chatpayload.Block) {
+	tool, ok := tools[call.Function.Name]
+	if !ok {
+		return fmt.Sprintf(`{"error": "unknown tool %q"}`, call.Function.Name), nil
+	}
+	// The chat endpoints are open to viewers, so tools check the role themselves
+	if tool.writes && !membership.CanWrite(middleware.GetCompanyRole(ctx)) {
+		logger.Warn("Chat tool refused for read-only member", "tool", call.Function.Name, "company_id", companyID)
+		return `{"error": "peran Anda hanya dapat melihat data, jadi tindakan ini tidak dijalankan"}`, nil
+	}
+
+	result, err := tool.run(ctx, companyID, json.RawMessage(call.Function.Arguments))
+	if err != nil {
+		logger.Warn("Chat tool failed", "tool", call.Function.Name, "company_id", companyID, "error", err.Error())
+		out, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return string(out), nil
+	}
+	out, _ := json.Marshal(result)
+	var blocks []chatpayload.Block
+	if tool.blocks != nil {
+		blocks = tool.blocks(result)
+		for i := range blocks {
+			blocks[i].Source = call.Function.Name
+		}
+	}
+	return string(out), blocks
+}
+
+func (h *Handler) runPricingTool(ctx context.Context, companyID string, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ProductName  string  `json:"product_name"`
+		TargetMargin float64 `json:"target_margin"`
+		FixedCosts   float64 `json:"fixed_costs"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	productID, err := h.findProductByName(ctx, companyID, params.ProductName)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("produk %q tidak ditemukan", params.ProductName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.productPricing(ctx, companyID, productID, params.TargetMargin, params.FixedCosts)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (h *Handler) runReadFileTool(ctx context.Context, companyID string, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		FileName string `json:"file_name"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	content, err := h.findParsedFile(ctx, companyID, middleware.GetUserID(ctx), strings.TrimSpace(params.FileName))
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("file %q tidak ditemukan", params.FileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	text := content.Text
+	truncated := content.Truncated
+	if runes := []rune(text); len(runes) > maxFileExcerpt {
+		text = string(runes[:maxFileExcerpt])
+		truncated = true
+	}
+	return map[string]interface{}{
+		"file_name": content.OriginalFilename,
+		"text":      text,
+		"truncated": truncated,
+	}, nil
+}
+
+// findProductByName resolves a product by exact name first, then by partial match
+func (h *Handler) findProductByName(ctx context.Context, companyID, name string) (string, error) {
+	var id string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id FROM products
+		WHERE company_id = $1 AND name ILIKE '%' || $2 || '%'
+		ORDER BY LOWER(name) = LOWER($2) DESC, LENGTH(name)
+		LIMIT 1
+	`, companyID, name).Scan(&id)
+	return id, err
+}
+
+func (h *Handler) runKnowledgeBaseTool(ctx context.Context, companyID string, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(params.Query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if h.config.KolosalAPIKey == "" {
+		return nil, fmt.Errorf("knowledge base search is not configured")
+	}
+
+	// Attributed to the chat answer the tool is called for
+	citations, err := h.knowledgeBaseCitations(ctx, h.kolosalClient(), params.Query, maxKBChatCitations)
+	if err != nil {
+		return nil, err
+	}
+	return knowledgeBaseResult{Articles: citations}, nil
+}
+
+// knowledgeBaseResult is the result of the search_knowledge_base tool
+type knowledgeBaseResult struct {
+	Articles []models.KBCitation `json:"articles"`
+}
+
+// knowledgeBaseBlocks cites the articles found
+func knowledgeBaseBlocks(result interface{}) []chatpayload.Block {
+	r, ok := result.(knowledgeBaseResult)
+	if !ok || len(r.Articles) == 0 {
+		return nil
+	}
+	b := chatpayload.Block{Type: chatpayload.TypeCitations}
+	for _, a := range r.Articles {
+		b.Citations = append(b.Citations, chatpayload.Citation{Title: a.Title, URL: a.URL, Excerpt: a.Excerpt})
+	}
+	return []chatpayload.Block{b}
+}
+
+// runSalesAnalyticsTool answers sales questions with exact numbers from the
+// salesanalytics query templates
+func (h *Handler) runSalesAnalyticsTool(ctx context.Context, companyID string, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Metric      string `json:"metric"`
+		Period      string `json:"period"`
+		From        string `json:"from"`
+		To          string `json:"to"`
+		ProductName string `json:"product_name"`
+		Channel     string `json:"channel"`
+		GroupBy     string `json:"group_by"`
+		Compare     *bool  `json:"compare"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	q := salesanalytics.Params{Metric: params.Metric, GroupBy: params.GroupBy, Channel: strings.TrimSpace(params.Channel)}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	loc := h.companyLocation(ctx, companyID)
+	var current, previous salesanalytics.Range
+	var err error
+	if params.From != "" || params.To != "" {
+		current, previous, err = salesanalytics.Custom(params.From, params.To, loc)
+	} else {
+		if params.Period == "" {
+			params.Period = "this_month"
+		}
+		current, previous, err = salesanalytics.Resolve(params.Period, localDate(time.Now(), loc))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := salesanalytics.Result{Metric: q.Metric, Period: current, Channel: q.Channel, GroupBy: q.GroupBy}
+	if name := strings.TrimSpace(params.ProductName); name != "" {
+		err := h.db.Pool().QueryRow(ctx, `
+			SELECT id, name FROM products
+			WHERE company_id = $1 AND merged_into IS NULL AND name ILIKE '%' || $2 || '%'
+			ORDER BY LOWER(name) = LOWER($2) DESC, LENGTH(name)
+			LIMIT 1
+		`, companyID, name).Scan(&q.ProductID, &result.Product)
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("produk %q tidak ditemukan", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Value, err = h.salesAnalyticsValue(ctx, companyID, salesanalytics.Params{Metric: q.Metric, ProductID: q.ProductID, Channel: q.Channel}, current); err != nil {
+		return nil, err
+	}
+	if q.GroupBy != "" {
+		from, to := current.Bounds()
+		rows, err := h.db.Pool().Query(ctx, q.SQL(), companyID, from, to, q.ProductID, q.Channel)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		result.Groups = []salesanalytics.Group{}
+		for rows.Next() {
+			var g salesanalytics.Group
+			if err := rows.Scan(&g.Key, &g.Value); err != nil {
+				return nil, err
+			}
+			g.Value = salesanalytics.Round(g.Value)
+			result.Groups = append(result.Groups, g)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if params.Compare == nil || *params.Compare {
+		value, err := h.salesAnalyticsValue(ctx, companyID, salesanalytics.Params{Metric: q.Metric, ProductID: q.ProductID, Channel: q.Channel}, previous)
+		if err != nil {
+			return nil, err
+		}
+		result.Compare(previous, value)
+	}
+	return result, nil
+}
+
+// salesAnalyticsBlocks charts grouped results, and tables an ungrouped result
+// against the previous period
+func salesAnalyticsBlocks(result interface{}) []chatpayload.Block {
+	r, ok := result.(salesanalytics.Result)
+	if !ok {
+		return nil
+	}
+	title := fmt.Sprintf("%s %s – %s", r.Metric, r.Period.From, r.Period.To)
+	unit := ""
+	if r.Metric == "revenue" || r.Metric == "average_price" {
+		unit = "IDR"
+	}
+	if len(r.Groups) > 0 {
+		kind := chatpayload.ChartBar
+		if r.GroupBy == "day" || r.GroupBy == "week" || r.GroupBy == "month" {
+			kind = chatpayload.ChartLine
+		}
+		chart := chatpayload.Chart{Kind: kind, Unit: unit, Series: []chatpayload.Series{{Name: r.Metric}}}
+		table := chatpayload.Table{Columns: []string{r.GroupBy, r.Metric}}
+		for _, g := range r.Groups {
+			chart.Labels = append(chart.Labels, g.Key)
+			chart.Series[0].Values = append(chart.Series[0].Values, g.Value)
+			table.Rows = append(table.Rows, []interface{}{g.Key, g.Value})
+		}
+		return []chatpayload.Block{
+			{Type: chatpayload.TypeChart, Title: title, Chart: &chart},
+			{Type: chatpayload.TypeTable, Title: title, Table: &table},
+		}
+	}
+	if r.Previous == nil {
+		return nil
+	}
+	table := chatpayload.Table{
+		Columns: []string{"period", "from", "to", r.Metric},
+		Rows: [][]interface{}{
+			{"current", r.Period.From, r.Period.To, r.Value},
+			{"previous", r.Previous.Period.From, r.Previous.Period.To, r.Previous.Value},
+		},
+	}
+	return []chatpayload.Block{{Type: chatpayload.TypeTable, Title: title, Table: &table}}
+}
+
+// salesAnalyticsValue computes an ungrouped metric over a range
+func (h *Handler) salesAnalyticsValue(ctx context.Context, companyID string, q salesanalytics.Params, r salesanalytics.Range) (float64, error) {
+	from, to := r.Bounds()
+	var value float64
+	err := h.db.Pool().QueryRow(ctx, q.SQL(), companyID, from, to, q.ProductID, q.Channel).Scan(&value)
+	return salesanalytics.Round(value), err
+}
+
+// runQueryDataTool runs a query spec from the assistant as a company-scoped,
+// read-only query with a statement timeout, returning its rows as a table
+func (h *Handler) runQueryDataTool(ctx context.Context, companyID string, args json.RawMessage) (interface{}, error) {
+	var spec queryspec.Spec
+	if err := json.Unmarshal(args, &spec); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	q, err := queryspec.Build(spec, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := h.db.Pool().BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", queryspec.Timeout.Milliseconds())); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, q.SQL, q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	table := chatpayload.Table{Columns: q.Columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if len(table.Rows) == q.Limit {
+			table.Truncated = true
+			break
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if t, ok := v.(time.Time); ok {
+				values[i] = t.Format("2006-01-02")
+			}
+		}
+		table.Rows = append(table.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// queryDataBlocks shows the query's rows as a table
+func queryDataBlocks(result interface{}) []chatpayload.Block {
+	table, ok := result.(chatpayload.Table)
+	if !ok || len(table.Rows) == 0 {
+		return nil
+	}
+	return []chatpayload.Block{{Type: chatpayload.TypeTable, Table: &table}}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/kolosal"
+)
+
+func TestRunChatToolChecksRole(t *testing.T) {
+	ran := false
+	tools := map[string]chatTool{
+		"change_data": {
+			definition: kolosal.ToolFunction{Name: "change_data"},
+			writes:     true,
+			run: func(ctx context.Context, companyID string, args json.RawMessage) (interface{}, error) {
+				ran = true
+				return map[string]bool{"saved": true}, nil
+			},
+		},
+	}
+	call := kolosal.ToolCall{ID: "call-1"}
+	call.Function.Name = "change_data"
+	call.Function.Arguments = "{}"
+
+	h := &Handler{}
+	viewer := context.WithValue(context.Background(), middleware.CompanyRoleKey, "viewer")
+	out, _ := h.runChatTool(viewer, tools, "company-1", call)
+	if ran || !strings.Contains(out, "error") {
+		t.Errorf("viewer ran a writing tool: %s", out)
+	}
+
+	manager := context.WithValue(context.Background(), middleware.CompanyRoleKey, "manager")
+	if out, _ := h.runChatTool(manager, tools, "company-1", call); !ran || out != `{"saved":true}` {
+		t.Errorf("manager could not run the tool: %s", out)
+	}
+}
+
+func TestChatToolsMarkWrites(t *testing.T) {
+	for name, tool := range (&Handler{}).chatTools() {
+		if tool.mutatesProfile && !tool.writes {
+			t.Errorf("%s changes the profile but is not marked as writing", name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/validation"
+)
+
+const defaultCompanyTimezone = "Asia/Jakarta"
+
+// companyTimezones lists the Indonesian time zones a company can pick
+var companyTimezones = map[string]string{
+	"Asia/Jakarta":  "WIB",
+	"Asia/Makassar": "WITA",
+	"Asia/Jayapura": "WIT",
+}
+
+// CompanySettingsResponse represents the company-level settings
+type CompanySettingsResponse struct {
+	Timezone      string `json:"timezone"`
+	TimezoneLabel string `json:"timezone_label"`
+}
+
+// UpdateCompanySettingsRequest represents a request to update company settings
+type UpdateCompanySettingsRequest struct {
+	Timezone string `json:"timezone" validate:"required,oneof:Asia/Jakarta|Asia/Makassar|Asia/Jayapura"`
+}
+
+// GetCompanySettings returns the authenticated company's settings
+func (h *Handler) GetCompanySettings(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var tz string
+	err := h.db.Pool().QueryRow(r.Context(), `
+		SELECT COALESCE(timezone, $2) FROM companies WHERE id = $1
+	`, companyID, defaultCompanyTimezone).Scan(&tz)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, CompanySettingsResponse{Timezone: tz, TimezoneLabel: companyTimezones[tz]})
+}
+
+// UpdateCompanySettings updates the authenticated company's settings
+func (h *Handler) UpdateCompanySettings(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req UpdateCompanySettingsRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	tag, err := h.db.Pool().Exec(r.Context(), `
+		UPDATE companies SET timezone = $2, updated_at = NOW() WHERE id = $1
+	`, companyID, req.Timezone)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update company settings"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, CompanySettingsResponse{Timezone: req.Timezone, TimezoneLabel: companyTimezones[req.Timezone]})
+}
+
+// companyLocation returns the company's time zone, defaulting to WIB
+func (h *Handler) companyLocation(ctx context.Context, companyID string) *time.Location {
+	var tz string
+	err := h.db.Pool().QueryRow(ctx, `SELECT COALESCE(timezone, '') FROM companies WHERE id = $1`, companyID).Scan(&tz)
+	if err != nil || tz == "" {
+		tz = defaultCompanyTimezone
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		logger.Warn("Invalid company time zone, using default", "company_id", companyID, "timezone", tz)
+		loc, _ = time.LoadLocation(defaultCompanyTimezone)
+	}
+	if loc == nil {
+		// No tzdata on the host; WIB has no DST so a fixed zone is exact
+		loc = time.FixedZone("WIB", 7*60*60)
+	}
+	return loc
+}
+
+// localDate returns the calendar day of t in loc, as a UTC-midnight value for DATE columns
+func localDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,583 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/cachebus"
+	"github.com/bantuaku/backend/services/mailer"
+	"github.com/bantuaku/backend/services/membership"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// companyRoleTTL is how long an instance keeps a member's role in memory; role
+// changes and removals are also pushed to every instance through the cache bus
+const companyRoleTTL = time.Minute
+
+// CompanyMember is a user with access to a company
+type CompanyMember struct {
+	UserID   string    `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	Source   string    `json:"source"` // owner, invite, sso, merge, manual
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// CompanyInvite is an open invitation to join a company
+type CompanyInvite struct {
+	ID          string    `json:"id"`
+	CompanyID   string    `json:"company_id"`
+	CompanyName string    `json:"company_name,omitempty"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	InvitedBy   string    `json:"invited_by,omitempty"` // Email of the inviting owner
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// CompanyMembership is a company the user belongs to
+type CompanyMembership struct {
+	CompanyID   string `json:"company_id"`
+	CompanyName string `json:"company_name"`
+	Role        string `json:"role"`
+	Plan        string `json:"plan"`
+	Status      string `json:"status"`
+}
+
+// CreateCompanyInviteRequest invites an email address with a role
+type CreateCompanyInviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"` // manager or viewer
+}
+
+// UpdateCompanyMemberRequest changes a member's role
+type UpdateCompanyMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// SwitchCompanyRequest selects the company a new session token is issued for
+type SwitchCompanyRequest struct {
+	CompanyID string `json:"company_id"`
+}
+
+// pendingInvite selects invites, aliased i, that are neither accepted,
+// declined nor revoked
+const pendingInvite = `i.accepted_at IS NULL AND i.declined_at IS NULL AND i.revoked_at IS NULL`
+
+// CompanyRole returns the user's role in a company, or "" when they are not a
+// member. It is installed as the Auth middleware's access check.
+func (h *Handler) CompanyRole(ctx context.Context, userID, companyID string) (string, error) {
+	key := userID + ":" + companyID
+	if role, ok := h.members.Get(key); ok {
+		return role, nil
+	}
+	// The recorded owner counts as one even before they have a member row
+	var role string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(
+			(SELECT role FROM company_members WHERE company_id = $1 AND user_id = $2),
+			(SELECT 'owner' FROM companies WHERE id = $1 AND owner_user_id = $2),
+			'')
+	`, companyID, userID).Scan(&role)
+	if err != nil {
+		return "", err
+	}
+	h.members.Set(key, role)
+	return role, nil
+}
+
+// dropCompanyRole drops a member's cached role on every instance
+func (h *Handler) dropCompanyRole(ctx context.Context, userID, companyID string) {
+	h.invalidate(ctx, cachebus.KindMembership, userID+":"+companyID)
+}
+
+// ListCompanyMembers returns the company's members and open invites
+func (h *Handler) ListCompanyMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT m.user_id, u.email, m.role, m.source, m.created_at
+		FROM company_members m JOIN users u ON u.id = m.user_id
+		WHERE m.company_id = $1
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'manager' THEN 1 ELSE 2 END, m.created_at
+	`, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company members"), r)
+		return
+	}
+	members := []CompanyMember{}
+	for rows.Next() {
+		var m CompanyMember
+		if err := rows.Scan(&m.UserID, &m.Email, &m.Role, &m.Source, &m.JoinedAt); err != nil {
+			rows.Close()
+			h.respondError(w, errors.NewDatabaseError(err, "scan company member"), r)
+			return
+		}
+		members = append(members, m)
+	}
+	rows.Close()
+
+	invites, err := h.loadCompanyInvites(ctx, `i.company_id = $1`, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company invites"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"role":    middleware.GetCompanyRole(ctx),
+		"members": members,
+		"invites": invites,
+	})
+}
+
+// CreateCompanyInvite invites an email address to join the company and emails
+// the invitation (owner only). Inviting an address again renews its invite.
+func (h *Handler) CreateCompanyInvite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	var req CreateCompanyInviteRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	req.Email = strings.TrimSpace(strings.ToLower(req.Email))
+	if req.Email == "" || !strings.Contains(req.Email, "@") {
+		h.respondError(w, errors.NewValidationError("Valid email is required", "email: missing or invalid"), r)
+		return
+	}
+	if req.Role == "" {
+		req.Role = membership.RoleViewer
+	}
+	if !membership.Invitable(req.Role) {
+		h.respondError(w, errors.NewValidationError("Invalid role", "role must be manager or viewer; promote a member to make them an owner"), r)
+		return
+	}
+
+	var member bool
+	var pending int
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT
+			EXISTS(SELECT 1 FROM company_members m JOIN users u ON u.id = m.user_id WHERE m.company_id = $1 AND u.email = $2),
+			(SELECT COUNT(*) FROM company_invites i WHERE i.company_id = $1 AND i.email <> $2 AND i.expires_at > NOW() AND `+pendingInvite+`)
+	`, companyID, req.Email).Scan(&member, &pending)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "check company invites"), r)
+		return
+	}
+	if member {
+		h.respondError(w, errors.NewConflictError("Already a member", "this email already belongs to a member of the company"), r)
+		return
+	}
+	if pending >= membership.MaxPendingInvites {
+		h.respondError(w, errors.NewBusinessRuleError("Too many open invites",
+			fmt.Sprintf("the company has %d open invites; revoke some first", pending)), r)
+		return
+	}
+
+	userID := middleware.GetUserID(ctx)
+	expires := time.Now().Add(membership.InviteTTL)
+	var inviteID string
+	err = h.db.Pool().QueryRow(ctx, `
+		INSERT INTO company_invites (id, company_id, email, role, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (company_id, email) WHERE accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL DO UPDATE
+		SET role = EXCLUDED.role, invited_by = EXCLUDED.invited_by, created_at = NOW(), expires_at = EXCLUDED.expires_at
+		RETURNING id
+	`, uuid.New().String(), companyID, req.Email, req.Role, userID, expires).Scan(&inviteID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create company invite"), r)
+		return
+	}
+
+	var companyName, inviter string
+	if err := h.db.Pool().QueryRow(ctx, `
+		SELECT c.name, u.email FROM companies c, users u WHERE c.id = $1 AND u.id = $2
+	`, companyID, userID).Scan(&companyName, &inviter); err != nil {
+		logger.Warn("Failed to load company invite details", "company_id", companyID, "error", err.Error())
+	} else if err := h.sendEmail(ctx, mailer.CompanyInviteEmail(req.Email, companyName, inviter, req.Role, h.config.AppURL+"/invites", expires)); err != nil {
+		// The invite stays valid; it is also listed to the invitee once they sign in
+		logger.Warn("Failed to send company invite email", "company_id", companyID, "invite_id", inviteID, "error", err.Error())
+	}
+
+	logger.Info("Company invite created", "company_id", companyID, "invite_id", inviteID, "role", req.Role)
+
+	h.respondJSON(w, r, http.StatusCreated, CompanyInvite{
+		ID:        inviteID,
+		CompanyID: companyID,
+		Email:     req.Email,
+		Role:      req.Role,
+		InvitedBy: inviter,
+		CreatedAt: time.Now(),
+		ExpiresAt: expires,
+	})
+}
+
+// RevokeCompanyInvite withdraws an open invite of the company (owner only)
+func (h *Handler) RevokeCompanyInvite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	tag, err := h.db.Pool().Exec(ctx, `
+		UPDATE company_invites i SET revoked_at = NOW()
+		WHERE i.id = $1 AND i.company_id = $2 AND `+pendingInvite, r.PathValue("id"), companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "revoke company invite"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Invite"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "Invite revoked"})
+}
+
+// ListReceivedInvites returns the open invites sent to the user's email
+func (h *Handler) ListReceivedInvites(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	invites, err := h.loadCompanyInvites(ctx, `i.email = (SELECT email FROM users WHERE id = $1)`, middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load invites"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, invites)
+}
+
+// AcceptCompanyInvite makes the user a member of the inviting company with the
+// invited role. The invite must be addressed to the user's email.
+func (h *Handler) AcceptCompanyInvite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var invite CompanyInvite
+	var invitedBy *string
+	err = tx.QueryRow(ctx, `
+		SELECT i.id, i.company_id, c.name, i.role, i.invited_by, i.expires_at
+		FROM company_invites i JOIN companies c ON c.id = i.company_id
+		WHERE i.id = $1 AND i.email = (SELECT email FROM users WHERE id = $2) AND `+pendingInvite+`
+			AND c.merged_into IS NULL
+		FOR UPDATE OF i
+	`, r.PathValue("id"), userID).Scan(&invite.ID, &invite.CompanyID, &invite.CompanyName, &invite.Role, &invitedBy, &invite.ExpiresAt)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Invite"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load invite"), r)
+		return
+	}
+	if time.Now().After(invite.ExpiresAt) {
+		h.respondError(w, errors.NewBusinessRuleError("Invite expired", "ask the company owner to invite you again"), r)
+		return
+	}
+
+	// An owner accepting a stale invite keeps ownership
+	var role string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO company_members (company_id, user_id, role, source, invited_by)
+		VALUES ($1, $2, $3, 'invite', $4)
+		ON CONFLICT (company_id, user_id) DO UPDATE
+		SET role = CASE WHEN company_members.role = 'owner' THEN 'owner' ELSE EXCLUDED.role END
+		RETURNING role
+	`, invite.CompanyID, userID, invite.Role, invitedBy).Scan(&role)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "add company member"), r)
+		return
+	}
+	if _, err := tx.Exec(ctx, `UPDATE company_invites SET accepted_at = NOW() WHERE id = $1`, invite.ID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "accept invite"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+	h.dropCompanyRole(ctx, userID, invite.CompanyID)
+
+	logger.Info("Company invite accepted", "company_id", invite.CompanyID, "invite_id", invite.ID, "user_id", userID, "role", role)
+
+	m, err := h.loadCompanyMembership(ctx, userID, invite.CompanyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load membership"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, m)
+}
+
+// DeclineCompanyInvite turns down an invite addressed to the user's email
+func (h *Handler) DeclineCompanyInvite(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tag, err := h.db.Pool().Exec(ctx, `
+		UPDATE company_invites i SET declined_at = NOW()
+		WHERE i.id = $1 AND i.email = (SELECT email FROM users WHERE id = $2) AND `+pendingInvite,
+		r.PathValue("id"), middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "decline invite"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Invite"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "Invite declined"})
+}
+
+// UpdateCompanyMember changes a member's role (owner only)
+func (h *Handler) UpdateCompanyMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	var req UpdateCompanyMemberRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if !membership.Valid(req.Role) {
+		h.respondError(w, errors.NewValidationError("Invalid role", "role must be one of: "+strings.Join(membership.Roles, ", ")), r)
+		return
+	}
+	userID := r.PathValue("user_id")
+	if err := h.changeCompanyMember(ctx, companyID, userID, req.Role); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	logger.Info("Company member role changed", "company_id", companyID, "user_id", userID, "role", req.Role)
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"user_id": userID, "role": req.Role})
+}
+
+// RemoveCompanyMember takes a member's access to the company away (owner only)
+func (h *Handler) RemoveCompanyMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	userID := r.PathValue("user_id")
+	if err := h.changeCompanyMember(ctx, companyID, userID, ""); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	logger.Info("Company member removed", "company_id", companyID, "user_id", userID)
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "Member removed"})
+}
+
+// LeaveCompany removes the user from the token's company. The last owner
+// cannot leave, and neither can a user with no other company.
+func (h *Handler) LeaveCompany(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	userID := middleware.GetUserID(ctx)
+	var others int
+	if err := h.db.Pool().QueryRow(ctx, `
+		SELECT COUNT(*) FROM company_members m JOIN companies c ON c.id = m.company_id
+		WHERE m.user_id = $1 AND m.company_id <> $2 AND c.merged_into IS NULL
+	`, userID, companyID).Scan(&others); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load memberships"), r)
+		return
+	}
+	if others == 0 {
+		h.respondError(w, errors.NewBusinessRuleError("Cannot leave your only company", "join or create another company first"), r)
+		return
+	}
+	if err := h.changeCompanyMember(ctx, companyID, userID, ""); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	logger.Info("Company member left", "company_id", companyID, "user_id", userID)
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "You left the company"})
+}
+
+// ListMyCompanies returns the companies the user belongs to
+func (h *Handler) ListMyCompanies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT c.id, c.name, m.role, COALESCE(c.subscription_plan, 'free'), COALESCE(c.status, 'active')
+		FROM company_members m JOIN companies c ON c.id = m.company_id
+		WHERE m.user_id = $1 AND c.merged_into IS NULL
+		ORDER BY c.name
+	`, middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load memberships"), r)
+		return
+	}
+	defer rows.Close()
+
+	companies := []CompanyMembership{}
+	for rows.Next() {
+		var m CompanyMembership
+		if err := rows.Scan(&m.CompanyID, &m.CompanyName, &m.Role, &m.Plan, &m.Status); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan membership"), r)
+			return
+		}
+		companies = append(companies, m)
+	}
+	h.respondJSON(w, r, http.StatusOK, companies)
+}
+
+// SwitchCompany issues a session token for another company the user belongs to
+func (h *Handler) SwitchCompany(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req SwitchCompanyRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	userID := middleware.GetUserID(ctx)
+	m, err := h.loadCompanyMembership(ctx, userID, req.CompanyID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load membership"), r)
+		return
+	}
+	h.touchCompanyActivity(ctx, m.CompanyID)
+
+	token, err := h.generateSessionToken(userID, m.CompanyID, middleware.GetRole(ctx), middleware.GetDeviceID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to generate token"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, AuthResponse{
+		Token:     token,
+		UserID:    userID,
+		StoreID:   m.CompanyID,
+		StoreName: m.CompanyName,
+		Plan:      m.Plan,
+	})
+}
+
+// changeCompanyMember changes a member's role, or removes them when role is
+// empty, keeping at least one owner and the company's recorded owner in step
+func (h *Handler) changeCompanyMember(ctx context.Context, companyID, userID, role string) error {
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return errors.NewDatabaseError(err, "begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking every member serializes changes, so two owners cannot demote
+	// each other at once
+	rows, err := tx.Query(ctx, `SELECT user_id, role FROM company_members WHERE company_id = $1 FOR UPDATE`, companyID)
+	if err != nil {
+		return errors.NewDatabaseError(err, "load company members")
+	}
+	var current string
+	owners := 0
+	for rows.Next() {
+		var id, r string
+		if err := rows.Scan(&id, &r); err != nil {
+			rows.Close()
+			return errors.NewDatabaseError(err, "scan company member")
+		}
+		if id == userID {
+			current = r
+		}
+		if r == membership.RoleOwner {
+			owners++
+		}
+	}
+	rows.Close()
+	if current == "" {
+		return errors.NewNotFoundError("Member")
+	}
+	if err := membership.CheckChange(current, role, owners); err != nil {
+		return errors.NewBusinessRuleError("Role change not allowed", err.Error())
+	}
+
+	if role == "" {
+		_, err = tx.Exec(ctx, `DELETE FROM company_members WHERE company_id = $1 AND user_id = $2`, companyID, userID)
+	} else {
+		_, err = tx.Exec(ctx, `UPDATE company_members SET role = $3 WHERE company_id = $1 AND user_id = $2`, companyID, userID, role)
+	}
+	if err != nil {
+		return errors.NewDatabaseError(err, "change company member")
+	}
+	// Owner emails and billing go to the recorded owner, which must stay an owner
+	if _, err := tx.Exec(ctx, `
+		UPDATE companies SET owner_user_id = (
+			SELECT user_id FROM company_members WHERE company_id = $1 AND role = 'owner' ORDER BY created_at LIMIT 1
+		), updated_at = NOW()
+		WHERE id = $1 AND owner_user_id NOT IN (SELECT user_id FROM company_members WHERE company_id = $1 AND role = 'owner')
+	`, companyID); err != nil {
+		return errors.NewDatabaseError(err, "update company owner")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.NewDatabaseError(err, "commit transaction")
+	}
+	h.dropCompanyRole(ctx, userID, companyID)
+	return nil
+}
+
+// loadCompanyMembership loads a company the user belongs to
+func (h *Handler) loadCompanyMembership(ctx context.Context, userID, companyID string) (CompanyMembership, error) {
+	m := CompanyMembership{CompanyID: companyID}
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT c.name, m.role, COALESCE(c.subscription_plan, 'free'), COALESCE(c.status, 'active')
+		FROM company_members m JOIN companies c ON c.id = m.company_id
+		WHERE m.user_id = $1 AND m.company_id = $2 AND c.merged_into IS NULL
+	`, userID, companyID).Scan(&m.CompanyName, &m.Role, &m.Plan, &m.Status)
+	return m, err
+}
+
+// loadCompanyInvites loads the open invites matching where, whose only
+// parameter is arg
+func (h *Handler) loadCompanyInvites(ctx context.Context, where string, arg string) ([]CompanyInvite, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT i.id, i.company_id, c.name, i.email, i.role, COALESCE(u.email, ''), i.created_at, i.expires_at
+		FROM company_invites i
+		JOIN companies c ON c.id = i.company_id
+		LEFT JOIN users u ON u.id = i.invited_by
+		WHERE `+where+` AND i.expires_at > NOW() AND `+pendingInvite+`
+			AND c.merged_into IS NULL
+		ORDER BY i.created_at DESC
+	`, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []CompanyInvite{}
+	for rows.Next() {
+		var i CompanyInvite
+		if err := rows.Scan(&i.ID, &i.CompanyID, &i.CompanyName, &i.Email, &i.Role, &i.InvitedBy, &i.CreatedAt, &i.ExpiresAt); err != nil {
+			return nil, err
+		}
+		invites = append(invites, i)
+	}
+	return invites, rows.Err()
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/companyprofile"
+	"github.com/bantuaku/backend/services/membership"
+)
+
+// CompanyProfileSummary is the company profile with how complete it is. Chat
+// replies carry it after the assistant changed the profile, so onboarding
+// progress updates without another request.
+type CompanyProfileSummary struct {
+	Company      models.Company              `json:"company"`
+	Completeness companyprofile.Completeness `json:"completeness"`
+}
+
+// companyProfileSummary loads the company's profile and measures its completeness
+func (h *Handler) companyProfileSummary(ctx context.Context, companyID string) (*CompanyProfileSummary, error) {
+	var c models.Company
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, COALESCE(owner_user_id, ''), name, COALESCE(description, ''), COALESCE(industry, ''),
+			COALESCE(business_model, ''), founded_year, COALESCE(location_region, ''), COALESCE(city, ''),
+			COALESCE(country, 'ID'), COALESCE(website, ''), created_at, updated_at
+		FROM companies WHERE id = $1
+	`, companyID).Scan(&c.ID, &c.OwnerUserID, &c.Name, &c.Description, &c.Industry, &c.BusinessModel, &c.FoundedYear,
+		&c.LocationRegion, &c.City, &c.Country, &c.Website, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	filled := map[string]bool{
+		"name":            strings.TrimSpace(c.Name) != "",
+		"industry":        strings.TrimSpace(c.Industry) != "",
+		"description":     strings.TrimSpace(c.Description) != "",
+		"business_model":  strings.TrimSpace(c.BusinessModel) != "",
+		"location_region": strings.TrimSpace(c.LocationRegion) != "",
+		"city":            strings.TrimSpace(c.City) != "",
+		"founded_year":    c.FoundedYear != nil,
+		"website":         strings.TrimSpace(c.Website) != "",
+	}
+	return &CompanyProfileSummary{Company: c, Completeness: companyprofile.Assess(filled)}, nil
+}
+
+// runUpdateProfileTool saves the profile details the user told the assistant.
+// Fields left out are kept. The location is not set here: it needs region
+// codes, which the location settings pick. Viewers, who may ask the assistant
+// but not change the company, are refused.
+func (h *Handler) runUpdateProfileTool(ctx context.Context, companyID string, args json.RawMessage) (interface{}, error) {
+	if !membership.CanWrite(middleware.GetCompanyRole(ctx)) {
+		return nil, fmt.Errorf("peran Anda hanya dapat melihat data; minta pemilik atau manajer untuk mengubah profil usaha")
+	}
+	var params struct {
+		Description   string `json:"description"`
+		Industry      string `json:"industry"`
+		BusinessModel string `json:"business_model"`
+		FoundedYear   int    `json:"founded_year"`
+		Website       string `json:"website"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	params.Description = strings.TrimSpace(params.Description)
+	params.Industry = strings.TrimSpace(params.Industry)
+	params.BusinessModel = strings.TrimSpace(params.BusinessModel)
+	params.Website = strings.TrimSpace(params.Website)
+	switch {
+	case params.Description == "" && params.Industry == "" && params.BusinessModel == "" && params.FoundedYear == 0 && params.Website == "":
+		return nil, fmt.Errorf("tidak ada data profil yang diisi")
+	case len(params.Description) > 2000:
+		return nil, fmt.Errorf("deskripsi terlalu panjang, maksimal 2000 karakter")
+	case len(params.Industry) > 100 || len(params.BusinessModel) > 100:
+		return nil, fmt.Errorf("bidang usaha dan model bisnis maksimal 100 karakter")
+	case len(params.Website) > 255:
+		return nil, fmt.Errorf("situs web maksimal 255 karakter")
+	case params.FoundedYear != 0 && (params.FoundedYear < 1900 || params.FoundedYear > time.Now().Year()):
+		return nil, fmt.Errorf("tahun berdiri harus antara 1900 dan %d", time.Now().Year())
+	}
+
+	_, err := h.db.Pool().Exec(ctx, `
+		UPDATE companies SET
+			description = COALESCE(NULLIF($2, ''), description),
+			industry = COALESCE(NULLIF($3, ''), industry),
+			business_model = COALESCE(NULLIF($4, ''), business_model),
+			founded_year = COALESCE(NULLIF($5, 0), founded_year),
+			website = COALESCE(NULLIF($6, ''), website),
+			updated_at = NOW()
+		WHERE id = $1
+	`, companyID, params.Description, params.Industry, params.BusinessModel, params.FoundedYear, params.Website)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Company profile updated from chat", "company_id", companyID)
+
+	summary, err := h.companyProfileSummary(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"saved":          true,
+		"percent":        summary.Completeness.Percent,
+		"missing_fields": summary.Completeness.Missing,
+	}, nil
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/bantuaku/backend/middleware"
+)
+
+func TestUpdateProfileToolRefusesViewers(t *testing.T) {
+	h := &Handler{} // No database: a viewer must be refused before any write
+	ctx := context.WithValue(context.Background(), middleware.CompanyRoleKey, "viewer")
+	result, err := h.runUpdateProfileTool(ctx, "company-1", json.RawMessage(`{"industry": "kuliner"}`))
+	if err == nil || result != nil {
+		t.Fatalf("expected a viewer to be refused, got %v, %v", result, err)
+	}
+
+	// Managers get past the role check to validation
+	ctx = context.WithValue(context.Background(), middleware.CompanyRoleKey, "manager")
+	if _, err := h.runUpdateProfileTool(ctx, "company-1", json.RawMessage(`{}`)); err == nil || err.Error() != "tidak ada data profil yang diisi" {
+		t.Errorf("expected the empty update to fail validation, got %v", err)
+	}
+}
@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/compliance"
+	"github.com/bantuaku/backend/services/kbli"
+	"github.com/bantuaku/backend/services/regions"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateCompanyLegalFormRequest sets a company's legal form
+type UpdateCompanyLegalFormRequest struct {
+	LegalForm string `json:"legal_form" validate:"required,oneof:perorangan|ud|cv|firma|pt|pt_perorangan|koperasi"`
+}
+
+// UpdateComplianceItemRequest records progress on a checklist requirement
+type UpdateComplianceItemRequest struct {
+	Status          string `json:"status" validate:"required,oneof:missing|in_progress|obtained|not_applicable"`
+	ReferenceNumber string `json:"reference_number" validate:"max:100"`
+	ObtainedOn      string `json:"obtained_on"`    // YYYY-MM-DD
+	ExpiresOn       string `json:"expires_on"`     // YYYY-MM-DD; reminders are sent before this date
+	FileUploadID    string `json:"file_upload_id"` // The permit document, uploaded via /api/v1/files/upload
+	Notes           string `json:"notes" validate:"max:1000"`
+}
+
+// ComplianceRuleRequest creates or replaces a compliance rule
+type ComplianceRuleRequest struct {
+	Requirement   string   `json:"requirement" validate:"required,max:30"`
+	Title         string   `json:"title" validate:"required,max:200"`
+	Description   string   `json:"description" validate:"max:2000"`
+	Authority     string   `json:"authority" validate:"max:200"`
+	ReferenceURL  string   `json:"reference_url" validate:"max:500"`
+	KBLIPrefixes  []string `json:"kbli_prefixes"`
+	LegalForms    []string `json:"legal_forms"`
+	ProvinceCodes []string `json:"province_codes"`
+	Priority      int      `json:"priority"`
+	Active        *bool    `json:"active"`
+}
+
+// GetComplianceChecklist returns the permits and certificates that apply to
+// the authenticated company, from its KBLI code, legal form and province, with
+// the progress recorded on each
+func (h *Handler) GetComplianceChecklist(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	profile, err := h.complianceProfile(r.Context(), companyID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company profile"), r)
+		return
+	}
+	checklist, err := h.complianceChecklist(r.Context(), companyID, profile)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "build compliance checklist"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, checklist)
+}
+
+// UpdateComplianceItem records the company's progress on a requirement
+func (h *Handler) UpdateComplianceItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req UpdateComplianceItemRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	obtainedOn, err := parseNullableDate(req.ObtainedOn, "obtained_on")
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	expiresOn, err := parseNullableDate(req.ExpiresOn, "expires_on")
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.FileUploadID != "" {
+		if _, err := h.accessibleFile(ctx, req.FileUploadID, companyID, middleware.GetUserID(ctx)); err != nil {
+			h.respondError(w, errors.NewValidationError("Invalid file_upload_id", "file not found"), r)
+			return
+		}
+	}
+
+	requirement := strings.ToUpper(r.PathValue("requirement"))
+	var known bool
+	if err := h.db.Pool().QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM compliance_rules WHERE requirement = $1)
+	`, requirement).Scan(&known); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "check requirement"), r)
+		return
+	}
+	if !known {
+		h.respondError(w, errors.NewNotFoundError("Requirement"), r)
+		return
+	}
+
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO company_compliance (company_id, requirement, status, reference_number, obtained_on, expires_on,
+			file_upload_id, notes, updated_by, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NOW())
+		ON CONFLICT (company_id, requirement) DO UPDATE SET
+			status = EXCLUDED.status, reference_number = EXCLUDED.reference_number, obtained_on = EXCLUDED.obtained_on,
+			expires_on = EXCLUDED.expires_on, file_upload_id = EXCLUDED.file_upload_id, notes = EXCLUDED.notes,
+			updated_by = EXCLUDED.updated_by, updated_at = NOW()
+	`, companyID, requirement, req.Status, strings.TrimSpace(req.ReferenceNumber), obtainedOn, expiresOn,
+		req.FileUploadID, strings.TrimSpace(req.Notes), middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update compliance item"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"requirement": requirement, "status": req.Status})
+}
+
+// UpdateCompanyLegalForm sets the authenticated company's legal form, which
+// decides several checklist requirements
+func (h *Handler) UpdateCompanyLegalForm(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req UpdateCompanyLegalFormRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	tag, err := h.db.Pool().Exec(r.Context(), `
+		UPDATE companies SET legal_form = $2, updated_at = NOW() WHERE id = $1
+	`, companyID, req.LegalForm)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update legal form"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"legal_form": req.LegalForm})
+}
+
+// ListComplianceRules lists every compliance rule, including inactive ones
+// (platform admin only)
+func (h *Handler) ListComplianceRules(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT id, requirement, title, description, authority, reference_url, kbli_prefixes, legal_forms,
+			province_codes, priority, active, updated_at
+		FROM compliance_rules
+		ORDER BY priority, requirement, id
+	`)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list compliance rules"), r)
+		return
+	}
+	defer rows.Close()
+
+	rules := []models.ComplianceRule{}
+	for rows.Next() {
+		var rule models.ComplianceRule
+		if err := rows.Scan(&rule.ID, &rule.Requirement, &rule.Title, &rule.Description, &rule.Authority,
+			&rule.ReferenceURL, &rule.KBLIPrefixes, &rule.LegalForms, &rule.ProvinceCodes, &rule.Priority,
+			&rule.Active, &rule.UpdatedAt); err != nil {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"rules": rules})
+}
+
+// CreateComplianceRule adds a compliance rule (platform admin only)
+func (h *Handler) CreateComplianceRule(w http.ResponseWriter, r *http.Request) {
+	req, err := h.parseComplianceRule(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	id := uuid.New().String()
+	_, err = h.db.Pool().Exec(r.Context(), `
+		INSERT INTO compliance_rules (id, requirement, title, description, authority, reference_url, kbli_prefixes,
+			legal_forms, province_codes, priority, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, id, req.Requirement, req.Title, req.Description, req.Authority, req.ReferenceURL, req.KBLIPrefixes,
+		req.LegalForms, req.ProvinceCodes, req.Priority, *req.Active)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create compliance rule"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusCreated, map[string]string{"id": id})
+}
+
+// UpdateComplianceRule replaces a compliance rule (platform admin only)
+func (h *Handler) UpdateComplianceRule(w http.ResponseWriter, r *http.Request) {
+	req, err := h.parseComplianceRule(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	tag, err := h.db.Pool().Exec(r.Context(), `
+		UPDATE compliance_rules SET requirement = $2, title = $3, description = $4, authority = $5, reference_url = $6,
+			kbli_prefixes = $7, legal_forms = $8, province_codes = $9, priority = $10, active = $11, updated_at = NOW()
+		WHERE id = $1
+	`, r.PathValue("id"), req.Requirement, req.Title, req.Description, req.Authority, req.ReferenceURL,
+		req.KBLIPrefixes, req.LegalForms, req.ProvinceCodes, req.Priority, *req.Active)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update compliance rule"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Compliance rule"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"id": r.PathValue("id")})
+}
+
+// DeleteComplianceRule deletes a compliance rule; recorded progress on its
+// requirement is kept (platform admin only)
+func (h *Handler) DeleteComplianceRule(w http.ResponseWriter, r *http.Request) {
+	tag, err := h.db.Pool().Exec(r.Context(), `DELETE FROM compliance_rules WHERE id = $1`, r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete compliance rule"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Compliance rule"), r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseComplianceRule reads and validates a rule, normalizing its constraints
+func (h *Handler) parseComplianceRule(r *http.Request) (*ComplianceRuleRequest, error) {
+	var req ComplianceRuleRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		return nil, err
+	}
+	req.Requirement = strings.ToUpper(strings.TrimSpace(req.Requirement))
+	if err := validation.Validate(&req); err != nil {
+		return nil, err
+	}
+	if req.Active == nil {
+		active := true
+		req.Active = &active
+	}
+	if req.Priority == 0 {
+		req.Priority = 50
+	}
+
+	req.KBLIPrefixes = trimAll(req.KBLIPrefixes)
+	for _, prefix := range req.KBLIPrefixes {
+		if _, _, _, err := kbli.ParseCode(prefix); err != nil || !isDigits(prefix) {
+			return nil, errors.NewValidationError("Invalid kbli_prefixes", "prefixes must be 2 to 5 digit KBLI codes")
+		}
+	}
+	req.LegalForms = trimAll(req.LegalForms)
+	for _, form := range req.LegalForms {
+		if !compliance.ValidLegalForm(form) {
+			return nil, errors.NewValidationError("Invalid legal_forms", "unknown legal form "+form)
+		}
+	}
+	req.ProvinceCodes = trimAll(req.ProvinceCodes)
+	for _, code := range req.ProvinceCodes {
+		if level, _, err := regions.ParseCode(code); err != nil || level != regions.LevelProvince {
+			return nil, errors.NewValidationError("Invalid province_codes", code+" is not a province code")
+		}
+	}
+	return &req, nil
+}
+
+// complianceProfile reads the fields the rules are evaluated against
+func (h *Handler) complianceProfile(ctx context.Context, companyID string) (compliance.Profile, error) {
+	var p compliance.Profile
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(industry_kbli, ''), COALESCE(legal_form, ''), COALESCE(province_code, '')
+		FROM companies WHERE id = $1
+	`, companyID).Scan(&p.KBLICode, &p.LegalForm, &p.ProvinceCode)
+	return p, err
+}
+
+// complianceChecklist evaluates the active rules for a profile and attaches the
+// company's recorded progress
+func (h *Handler) complianceChecklist(ctx context.Context, companyID string, profile compliance.Profile) (*models.ComplianceChecklist, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, requirement, title, description, authority, reference_url, kbli_prefixes, legal_forms,
+			province_codes, priority
+		FROM compliance_rules WHERE active
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var rules []compliance.Rule
+	for rows.Next() {
+		var rule compliance.Rule
+		if err := rows.Scan(&rule.ID, &rule.Requirement, &rule.Title, &rule.Description, &rule.Authority,
+			&rule.ReferenceURL, &rule.KBLIPrefixes, &rule.LegalForms, &rule.Provinces, &rule.Priority); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	progress := map[string]models.ComplianceItem{}
+	rows, err = h.db.Pool().Query(ctx, `
+		SELECT cc.requirement, cc.status, COALESCE(cc.reference_number, ''), cc.obtained_on, cc.expires_on,
+			COALESCE(cc.notes, ''), cc.updated_at, COALESCE(f.id, ''), COALESCE(f.original_filename, '')
+		FROM company_compliance cc
+		LEFT JOIN file_uploads f ON f.id = cc.file_upload_id
+		WHERE cc.company_id = $1
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var p models.ComplianceItem
+		var updatedAt time.Time
+		var doc models.ComplianceDocument
+		if err := rows.Scan(&p.Requirement, &p.Status, &p.ReferenceNumber, &p.ObtainedOn, &p.ExpiresOn, &p.Notes,
+			&updatedAt, &doc.FileUploadID, &doc.OriginalFilename); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.UpdatedAt = &updatedAt
+		if doc.FileUploadID != "" {
+			p.Document = &doc
+		}
+		progress[p.Requirement] = p
+	}
+	rows.Close()
+
+	checklist := &models.ComplianceChecklist{
+		KBLICode:     profile.KBLICode,
+		LegalForm:    profile.LegalForm,
+		ProvinceCode: profile.ProvinceCode,
+		Missing:      []string{},
+		Items:        []models.ComplianceItem{},
+	}
+	for field, value := range map[string]string{"kbli_code": profile.KBLICode, "legal_form": profile.LegalForm, "province_code": profile.ProvinceCode} {
+		if value == "" {
+			checklist.Missing = append(checklist.Missing, field)
+		}
+	}
+	sort.Strings(checklist.Missing)
+
+	now := time.Now().In(h.companyLocation(ctx, companyID))
+	for _, it := range compliance.Evaluate(rules, profile) {
+		item := progress[it.Requirement]
+		item.Requirement = it.Requirement
+		item.Title = it.Title
+		item.Description = it.Description
+		item.Authority = it.Authority
+		item.ReferenceURL = it.ReferenceURL
+		item.Reasons = it.Reasons
+		item.RuleIDs = it.RuleIDs
+		if item.Status == "" {
+			item.Status = compliance.StatusMissing
+		}
+		if item.Status == compliance.StatusObtained && item.ExpiresOn != nil {
+			item.Expired = compliance.Expired(*item.ExpiresOn, now) // Needs renewing; no longer counts as obtained
+		}
+		if item.Status != compliance.StatusNotApplicable {
+			checklist.Total++
+			if item.Status == compliance.StatusObtained && !item.Expired {
+				checklist.Done++
+			}
+		}
+		checklist.Items = append(checklist.Items, item)
+	}
+
+	logger.Debug("Compliance checklist built", "company_id", companyID, "items", len(checklist.Items), "rules", len(rules))
+	return checklist, nil
+}
+
+// RemindComplianceExpiry notifies companies of obtained permits that expire
+// within compliance.DefaultReminderDays, and once more when they have expired.
+// It is run periodically from main.
+func (h *Handler) RemindComplianceExpiry(ctx context.Context) {
+	horizon := 0
+	for _, d := range compliance.DefaultReminderDays {
+		if d > horizon {
+			horizon = d
+		}
+	}
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT cc.company_id, cc.requirement, cc.expires_on, COALESCE(cc.reference_number, ''),
+			COALESCE((SELECT MIN(title) FROM compliance_rules cr WHERE cr.requirement = cc.requirement), cc.requirement)
+		FROM company_compliance cc
+		JOIN companies c ON c.id = cc.company_id AND COALESCE(c.status, 'active') = 'active'
+		WHERE cc.status = 'obtained' AND cc.expires_on IS NOT NULL
+			AND cc.expires_on <= CURRENT_DATE + $1::int
+			AND cc.expires_on >= CURRENT_DATE - 7 -- Long-expired permits were already reported
+	`, horizon+1)
+	if err != nil {
+		logger.Error("Failed to scan permit expiries", "error", err.Error())
+		return
+	}
+	type expiring struct {
+		companyID, requirement, reference, title string
+		expiresOn                                time.Time
+	}
+	var due []expiring
+	for rows.Next() {
+		var e expiring
+		if err := rows.Scan(&e.companyID, &e.requirement, &e.expiresOn, &e.reference, &e.title); err != nil {
+			continue
+		}
+		due = append(due, e)
+	}
+	rows.Close()
+
+	sent := 0
+	for _, e := range due {
+		threshold, ok := compliance.ReminderDue(e.expiresOn, time.Now().In(h.companyLocation(ctx, e.companyID)), compliance.DefaultReminderDays)
+		if !ok {
+			continue
+		}
+		date := e.expiresOn.Format("2006-01-02")
+		n := models.Notification{
+			Type:  models.NotificationPermitExpiry,
+			Title: fmt.Sprintf("%s segera berakhir", e.title),
+			Message: fmt.Sprintf("%s Anda berlaku sampai %s. Segera ajukan perpanjangan agar usaha tetap berizin.",
+				e.title, date),
+			Data: map[string]interface{}{
+				"requirement":      e.requirement,
+				"expires_on":       date,
+				"reference_number": e.reference,
+				"days_before":      threshold,
+			},
+		}
+		if threshold == 0 {
+			n.Title = fmt.Sprintf("%s sudah berakhir", e.title)
+			n.Message = fmt.Sprintf("%s Anda berakhir pada %s. Perbarui izin lalu unggah dokumen barunya di daftar perizinan.", e.title, date)
+		}
+		created, err := h.notify(ctx, e.companyID, n, fmt.Sprintf("permit_expiry:%s:%s:%d", e.requirement, date, threshold))
+		if err != nil {
+			logger.Warn("Failed to notify permit expiry", "company_id", e.companyID, "requirement", e.requirement, "error", err.Error())
+			continue
+		}
+		if created {
+			sent++
+		}
+	}
+	if sent > 0 {
+		logger.Info("Permit expiry reminders sent", "count", sent)
+	}
+}
+
+// parseNullableDate parses a YYYY-MM-DD field, returning nil when it is empty
+func parseNullableDate(value, field string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, errors.NewValidationError("Invalid "+field, field+" must be YYYY-MM-DD")
+	}
+	return &t, nil
+}
+
+// trimAll trims values and drops empty ones
+func trimAll(values []string) []string {
+	out := []string{}
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
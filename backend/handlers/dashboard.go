@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
 	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
 )
@@ -12,7 +14,7 @@ import (
 func (h *Handler) DashboardSummary(w http.ResponseWriter, r *http.Request) {
 	companyID := middleware.GetCompanyID(r.Context())
 	if companyID == "" {
-		respondError(w, http.StatusUnauthorized, "Company not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
 		return
 	}
 
@@ -27,8 +29,9 @@ func (h *Handler) DashboardSummary(w http.ResponseWriter, r *http.Request) {
 		WHERE id = $1
 	`, companyID).Scan(&summary.CompanyName, &summary.CompanyIndustry, &summary.CompanyLocation)
 
-	// Revenue this month
-	firstOfMonth := time.Now().AddDate(0, 0, -time.Now().Day()+1)
+	// Revenue this month, with month boundaries in the company's time zone
+	now := time.Now().In(h.companyLocation(ctx, companyID))
+	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
 	h.db.Pool().QueryRow(ctx, `
 		SELECT COALESCE(SUM(quantity * price), 0)
 		FROM sales_history
@@ -91,11 +94,23 @@ func (h *Handler) DashboardSummary(w http.ResponseWriter, r *http.Request) {
 		&summary.InsightsSummary.Regulation,
 	)
 
+	// Business health score, recorded daily for the trend chart
+	if health, err := h.businessHealth(ctx, companyID, defaultHealthHistoryDays); err == nil {
+		summary.Health = health
+	}
+
+	// Products due for a reorder
+	if reorder, err := h.reorderSummary(ctx, companyID, now); err == nil {
+		summary.Reorder = reorder
+	} else {
+		logger.Warn("Failed to build reorder summary", "company_id", companyID, "error", err.Error())
+	}
+
 	// Recent conversations (last 5)
 	rows, err := h.db.Pool().Query(ctx, `
 		SELECT id, COALESCE(title, 'Percakapan') as title, updated_at
 		FROM conversations
-		WHERE company_id = $1
+		WHERE company_id = $1 AND archived_at IS NULL
 		ORDER BY updated_at DESC
 		LIMIT 5
 	`, companyID)
@@ -123,14 +138,16 @@ func (h *Handler) DashboardSummary(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Recent file uploads (last 5)
+	// Recent file uploads (last 5) the user may see
 	rows2, err := h.db.Pool().Query(ctx, `
-		SELECT id, original_filename, source_type, status, created_at
-		FROM file_uploads
-		WHERE company_id = $1
-		ORDER BY created_at DESC
+		SELECT f.id, f.original_filename, f.source_type, f.status, f.created_at
+		FROM file_uploads f
+		WHERE f.company_id = $1
+			AND (f.visibility = 'company' OR f.user_id = $2
+				OR EXISTS (SELECT 1 FROM file_shares s WHERE s.file_id = f.id AND s.user_id = $2))
+		ORDER BY f.created_at DESC
 		LIMIT 5
-	`, companyID)
+	`, companyID, middleware.GetUserID(ctx))
 	if err == nil {
 		defer rows2.Close()
 		for rows2.Next() {
@@ -143,5 +160,5 @@ func (h *Handler) DashboardSummary(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	respondJSON(w, http.StatusOK, summary)
+	h.respondJSON(w, r, http.StatusOK, summary)
 }
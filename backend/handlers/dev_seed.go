@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/bantuaku/backend/logger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Demo account seeded in development mock mode
+const (
+	DemoEmail    = "demo@bantuaku.id"
+	DemoPassword = "demo123"
+)
+
+// demoSalesDays is how much sales history the demo company gets
+const demoSalesDays = 90
+
+// demoProducts are the demo company's catalog: name, SKU, category, price and
+// cost in rupiah, and base daily sales
+var demoProducts = []struct {
+	name, sku, category string
+	price, cost         float64
+	daily               int
+}{
+	{"Kopi Arabica Premium 250g", "KOP-ARB-250", "Minuman", 85000, 45000, 4},
+	{"Teh Hijau Organik 100g", "TEH-HIJ-100", "Minuman", 35000, 18000, 3},
+	{"Gula Aren Bubuk 500g", "GUL-ARE-500", "Bahan Makanan", 42000, 22000, 5},
+	{"Madu Hutan Asli 350ml", "MAD-HUT-350", "Makanan Sehat", 125000, 75000, 1},
+	{"Keripik Pisang Coklat 200g", "KER-PIS-200", "Snack", 28000, 14000, 6},
+	{"Sambal Bawang Premium 150g", "SAM-BAW-150", "Bumbu", 32000, 16000, 4},
+	{"Kacang Mete Panggang 250g", "KAC-MET-250", "Snack", 65000, 35000, 2},
+	{"Minyak Kelapa VCO 500ml", "MIN-VCO-500", "Makanan Sehat", 95000, 55000, 1},
+}
+
+// SeedDemoCompany creates the demo account, company, catalog and sales history
+// unless the demo account exists. It is run at startup with DEV_MOCKS so a
+// fresh database is ready to use. Sales follow a fixed weekly pattern, so every
+// run seeds the same data.
+func (h *Handler) SeedDemoCompany(ctx context.Context) error {
+	var exists bool
+	if err := h.db.Pool().QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE email = $1)`, DemoEmail).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(DemoPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	userID, companyID, now := uuid.New().String(), uuid.New().String(), time.Now()
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO users (id, email, password_hash, created_at) VALUES ($1, $2, $3, $4)
+	`, userID, DemoEmail, string(hashed), now); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO companies (id, owner_user_id, name, industry, city, subscription_plan, status, created_at)
+		VALUES ($1, $2, 'Toko Berkah Jaya', 'retail', 'Jakarta', 'pro', 'active', $3)
+	`, companyID, userID, now); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO company_members (company_id, user_id, role, source) VALUES ($1, $2, 'owner', 'owner')
+	`, companyID, userID); err != nil {
+		return err
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	var batch pgx.Batch
+	for i, p := range demoProducts {
+		productID := uuid.New().String()
+		batch.Queue(`
+			INSERT INTO products (id, company_id, name, sku, category, unit_price, cost, is_active, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE, $8, $8)
+		`, productID, companyID, p.name, p.sku, p.category, p.price, p.cost, now)
+		for day := demoSalesDays; day >= 1; day-- {
+			date := today.AddDate(0, 0, -day)
+			quantity := p.daily + (day+i)%3
+			if wd := date.Weekday(); wd == time.Saturday || wd == time.Sunday {
+				quantity += p.daily/2 + 1
+			}
+			batch.Queue(`
+				INSERT INTO sales_history (company_id, product_id, quantity, price, sale_date, source, created_at)
+				VALUES ($1, $2, $3, $4, $5, 'manual', $6)
+			`, companyID, productID, quantity, p.price, date, now)
+		}
+	}
+	if err := tx.SendBatch(ctx, &batch).Close(); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	logger.Info("Seeded demo company", "email", DemoEmail, "company_id", companyID, "products", len(demoProducts), "sales_days", demoSalesDays)
+	return nil
+}
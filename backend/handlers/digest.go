@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+)
+
+// GetWeeklyDigest returns the last seven days of sales plus reminders for
+// high-impact plan tasks that are still open
+func (h *Handler) GetWeeklyDigest(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	ctx := r.Context()
+	loc := h.companyLocation(ctx, companyID)
+	today := localDate(time.Now(), loc)
+	start := today.AddDate(0, 0, -6)
+	prevStart := start.AddDate(0, 0, -7)
+
+	digest := models.WeeklyDigest{
+		PeriodStart: start.Format("2006-01-02"),
+		PeriodEnd:   today.Format("2006-01-02"),
+		Reminders:   []models.StrategyTask{},
+	}
+
+	var lastWeek float64
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(quantity * price) FILTER (WHERE sale_date >= $2), 0),
+			COALESCE(SUM(quantity * price) FILTER (WHERE sale_date < $2), 0)
+		FROM sales_history
+		WHERE company_id = $1 AND sale_date >= $3 AND NOT COALESCE(excluded, false)
+	`, companyID, start, prevStart).Scan(&digest.Revenue, &lastWeek)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load weekly revenue"), r)
+		return
+	}
+	if lastWeek > 0 {
+		digest.RevenueTrend = (digest.Revenue - lastWeek) / lastWeek * 100
+	}
+
+	h.db.Pool().QueryRow(ctx, `
+		SELECT p.name
+		FROM products p
+		JOIN sales_history s ON p.id = s.product_id
+		WHERE p.company_id = $1 AND s.sale_date >= $2 AND NOT COALESCE(s.excluded, false)
+		GROUP BY p.id, p.name
+		ORDER BY SUM(s.quantity * s.price) DESC
+		LIMIT 1
+	`, companyID, start).Scan(&digest.TopSellingProduct)
+
+	month, _ := planMonth("", loc)
+	if plan, err := h.loadStrategyPlan(ctx, companyID, month); err == nil {
+		digest.PlanProgress = &plan.Progress
+		for _, t := range plan.Tasks {
+			if t.Status == models.TaskStatusTodo && t.Impact == "high" {
+				digest.Reminders = append(digest.Reminders, t)
+			}
+		}
+	}
+	digest.Message = digestMessage(digest)
+
+	h.respondJSON(w, r, http.StatusOK, digest)
+}
+
+// digestMessage writes the nag line shown at the top of the digest
+func digestMessage(d models.WeeklyDigest) string {
+	if d.PlanProgress == nil {
+		return "Belum ada rencana aksi bulan ini. Buat rencana agar langkah minggu depan lebih terarah."
+	}
+	if len(d.Reminders) == 0 {
+		return fmt.Sprintf("Semua tugas berdampak tinggi sudah selesai (%d dari %d tugas). Pertahankan!",
+			d.PlanProgress.Done, d.PlanProgress.Total)
+	}
+
+	titles := make([]string, 0, 3)
+	for i, t := range d.Reminders {
+		if i == 3 {
+			break
+		}
+		titles = append(titles, t.Title)
+	}
+	msg := fmt.Sprintf("Masih ada %d tugas berdampak tinggi yang belum selesai: %s", len(d.Reminders), strings.Join(titles, ", "))
+	if len(d.Reminders) > len(titles) {
+		msg += ", dan lainnya"
+	}
+	return msg + "."
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/dormancy"
+	"github.com/bantuaku/backend/services/mailer"
+	"github.com/bantuaku/backend/services/notifyprefs"
+)
+
+func (h *Handler) dormancyPolicy() dormancy.Policy {
+	return dormancy.Policy{InactiveMonths: h.config.DormancyMonths, GraceDays: h.config.DormancyGraceDays}
+}
+
+// GetCompanyLifecycle returns whether the company is active, flagged dormant
+// or archived
+func (h *Handler) GetCompanyLifecycle(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	lifecycle, err := h.loadCompanyLifecycle(r.Context(), companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company lifecycle"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, lifecycle)
+}
+
+// ReactivateCompany brings an archived or dormant company back: scheduled jobs
+// pick it up again on their next run
+func (h *Handler) ReactivateCompany(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	ctx := r.Context()
+	tag, err := h.db.Pool().Exec(ctx, `
+		UPDATE companies
+		SET status = $2, archived_at = NULL, dormant_at = NULL, last_active_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND (status = $3 OR dormant_at IS NOT NULL)
+	`, companyID, dormancy.StatusActive, dormancy.StatusArchived)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "reactivate company"), r)
+		return
+	}
+	if tag.RowsAffected() > 0 {
+		logger.Info("Company reactivated", "company_id", companyID, "user_id", middleware.GetUserID(ctx))
+	}
+
+	lifecycle, err := h.loadCompanyLifecycle(ctx, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company lifecycle"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, lifecycle)
+}
+
+// ScanDormantCompanies flags companies without activity for DORMANCY_MONTHS,
+// warning their owners, archives those still inactive DORMANCY_GRACE_DAYS
+// later and clears the flag of companies that became active again. It is run
+// periodically from main.
+func (h *Handler) ScanDormantCompanies(ctx context.Context) {
+	// Sign-ins, recorded sales, chats and uploads all count as activity
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT c.id, c.name, c.dormant_at,
+			GREATEST(c.created_at, c.last_active_at,
+				(SELECT MAX(s.created_at) FROM sales_history s WHERE s.company_id = c.id),
+				(SELECT MAX(cv.updated_at) FROM conversations cv WHERE cv.company_id = c.id),
+				(SELECT MAX(f.created_at) FROM file_uploads f WHERE f.company_id = c.id))
+		FROM companies c
+		WHERE COALESCE(c.status, 'active') = 'active' AND c.merged_into IS NULL
+	`)
+	if err != nil {
+		logger.Error("Dormancy scan failed to list companies", "error", err.Error())
+		return
+	}
+	type candidate struct {
+		id, name   string
+		dormantAt  *time.Time
+		lastActive time.Time
+	}
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if rows.Scan(&c.id, &c.name, &c.dormantAt, &c.lastActive) == nil {
+			candidates = append(candidates, c)
+		}
+	}
+	rows.Close()
+
+	policy := h.dormancyPolicy()
+	now := time.Now()
+	flagged, archived, cleared := 0, 0, 0
+	for _, c := range candidates {
+		switch policy.Decide(c.lastActive, c.dormantAt, now) {
+		case dormancy.Flag:
+			if _, err := h.db.Pool().Exec(ctx, `UPDATE companies SET dormant_at = $2 WHERE id = $1`, c.id, now); err != nil {
+				logger.Error("Failed to flag dormant company", "company_id", c.id, "error", err.Error())
+				continue
+			}
+			h.warnDormantCompany(ctx, c.id, c.name, policy.ArchiveAt(now))
+			flagged++
+		case dormancy.Archive:
+			_, err := h.db.Pool().Exec(ctx, `
+				UPDATE companies SET status = $2, archived_at = NOW() WHERE id = $1
+			`, c.id, dormancy.StatusArchived)
+			if err != nil {
+				logger.Error("Failed to archive dormant company", "company_id", c.id, "error", err.Error())
+				continue
+			}
+			archived++
+		case dormancy.Clear:
+			h.db.Pool().Exec(ctx, `UPDATE companies SET dormant_at = NULL WHERE id = $1`, c.id)
+			cleared++
+		}
+	}
+
+	if flagged+archived+cleared > 0 {
+		logger.Info("Dormancy scan completed", "companies", len(candidates), "flagged", flagged,
+			"archived", archived, "cleared", cleared)
+	}
+}
+
+// warnDormantCompany tells a newly flagged company, in the app and by email to
+// its owner, when it will be archived. During quiet hours the email is left to
+// the morning summary.
+func (h *Handler) warnDormantCompany(ctx context.Context, companyID, name string, archiveAt time.Time) {
+	_, err := h.notify(ctx, companyID, models.Notification{
+		Type:    models.NotificationDormancy,
+		Title:   "Perusahaan Anda akan diarsipkan",
+		Message: fmt.Sprintf("Belum ada aktivitas di %s selama %d bulan. Tanpa aktivitas, perusahaan ini diarsipkan pada %s.", name, h.config.DormancyMonths, archiveAt.Format("02 Jan 2006")),
+		Data: map[string]interface{}{
+			"archive_at": archiveAt,
+		},
+	}, "dormant:"+companyID+":"+archiveAt.Format("2006-01-02"))
+	if err != nil {
+		logger.Warn("Failed to create dormancy notification", "company_id", companyID, "error", err.Error())
+	}
+
+	var ownerID, ownerEmail string
+	h.db.Pool().QueryRow(ctx, `
+		SELECT u.id, u.email FROM companies c JOIN users u ON u.id = c.owner_user_id WHERE c.id = $1
+	`, companyID).Scan(&ownerID, &ownerEmail)
+	if ownerEmail == "" || !h.wantsNotification(ctx, ownerID, companyID, models.NotificationDormancy, notifyprefs.ChannelEmail) {
+		return
+	}
+	if _, held := h.holdNotification(ctx, companyID, models.NotificationDormancy); held {
+		return
+	}
+	link := strings.TrimRight(h.config.AppURL, "/") + "/login"
+	if err := h.sendEmail(ctx, mailer.DormancyEmail(ownerEmail, name, link, archiveAt)); err != nil {
+		logger.Warn("Failed to email dormancy warning", "company_id", companyID, "error", err.Error())
+	}
+}
+
+// touchCompanyActivity records a sign-in as company activity
+func (h *Handler) touchCompanyActivity(ctx context.Context, companyID string) {
+	if _, err := h.db.Pool().Exec(ctx, `UPDATE companies SET last_active_at = NOW() WHERE id = $1`, companyID); err != nil {
+		logger.Warn("Failed to record company activity", "company_id", companyID, "error", err.Error())
+	}
+}
+
+func (h *Handler) loadCompanyLifecycle(ctx context.Context, companyID string) (*models.CompanyLifecycle, error) {
+	var l models.CompanyLifecycle
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(status, 'active'), last_active_at, dormant_at, archived_at FROM companies WHERE id = $1
+	`, companyID).Scan(&l.Status, &l.LastActiveAt, &l.DormantAt, &l.ArchivedAt)
+	if err != nil {
+		return nil, err
+	}
+	if l.DormantAt != nil && l.Status != dormancy.StatusArchived {
+		archiveAt := h.dormancyPolicy().ArchiveAt(*l.DormantAt)
+		l.ArchiveAt = &archiveAt
+	}
+	return &l, nil
+}
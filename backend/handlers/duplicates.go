@@ -0,0 +1,597 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/dedupe"
+	"github.com/bantuaku/backend/services/embeddings"
+	"github.com/bantuaku/backend/services/inventory"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultDuplicateMinScore = 0.85
+	maxDuplicateCandidates   = 100
+	maxDuplicateScan         = 2000 // Most recent records compared per scan; comparison is pairwise
+	maxProductMergeSources   = 20   // Duplicates merged into a product per request
+)
+
+// MergeDuplicateRequest merges source into target
+type MergeDuplicateRequest struct {
+	SourceID string `json:"source_id" validate:"required"`
+	TargetID string `json:"target_id" validate:"required"`
+}
+
+// duplicateRecord is a scanned record with its display summary
+type duplicateRecord struct {
+	dedupe.Record
+	summary models.DuplicateRecord
+}
+
+// ListDuplicateCompanies finds companies that look registered twice: similar
+// names, compared by embedding when AI is configured, plus shared owner,
+// website or city (platform admin only)
+func (h *Handler) ListDuplicateCompanies(w http.ResponseWriter, r *http.Request) {
+	minScore, limit, err := parseDuplicateParams(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT c.id, c.name, COALESCE(c.owner_user_id, ''), COALESCE(u.email, ''), COALESCE(c.website, ''),
+			LOWER(COALESCE(c.city, '')), c.created_at,
+			(SELECT COUNT(*) FROM products p WHERE p.company_id = c.id AND p.merged_into IS NULL),
+			(SELECT COUNT(*) FROM sales_history s WHERE s.company_id = c.id)
+		FROM companies c
+		LEFT JOIN users u ON u.id = c.owner_user_id
+		WHERE c.merged_into IS NULL
+		ORDER BY c.created_at DESC
+		LIMIT $1
+	`, maxDuplicateScan)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list companies"), r)
+		return
+	}
+	var records []duplicateRecord
+	for rows.Next() {
+		var d duplicateRecord
+		var owner, website, city string
+		if err := rows.Scan(&d.ID, &d.Name, &owner, &d.summary.Detail, &website, &city, &d.summary.CreatedAt,
+			&d.summary.Products, &d.summary.Sales); err != nil {
+			continue
+		}
+		d.Meta = map[string]string{"owner": owner, "website": dedupe.NormalizeDomain(website), "city": city}
+		records = append(records, d)
+	}
+	rows.Close()
+
+	candidates, err := h.duplicateCandidates(ctx, "companies", records, minScore, limit)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"candidates": candidates, "scanned": len(records)})
+}
+
+// ListDuplicateProducts finds products of one company that look entered twice:
+// similar names plus shared SKU, category or unit (platform admin only)
+func (h *Handler) ListDuplicateProducts(w http.ResponseWriter, r *http.Request) {
+	minScore, limit, err := parseDuplicateParams(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	companyID := r.PathValue("id")
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT p.id, p.name, COALESCE(p.sku, ''), LOWER(COALESCE(p.category, '')), LOWER(COALESCE(p.unit, '')),
+			p.created_at, (SELECT COUNT(*) FROM sales_history s WHERE s.product_id = p.id)
+		FROM products p
+		WHERE p.company_id = $1 AND p.merged_into IS NULL
+		ORDER BY p.created_at DESC
+		LIMIT $2
+	`, companyID, maxDuplicateScan)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list products"), r)
+		return
+	}
+	var records []duplicateRecord
+	for rows.Next() {
+		var d duplicateRecord
+		var category, unit string
+		if err := rows.Scan(&d.ID, &d.Name, &d.summary.Detail, &category, &unit, &d.summary.CreatedAt, &d.summary.Sales); err != nil {
+			continue
+		}
+		d.Meta = map[string]string{"sku": d.summary.Detail, "category": category, "unit": unit}
+		records = append(records, d)
+	}
+	rows.Close()
+
+	candidates, err := h.duplicateCandidates(ctx, "products:"+companyID, records, minScore, limit)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"candidates": candidates, "scanned": len(records)})
+}
+
+// MergeDuplicateProducts moves a product's sales history and images onto
+// another product of the same company and deactivates it (platform admin only)
+func (h *Handler) MergeDuplicateProducts(w http.ResponseWriter, r *http.Request) {
+	var req MergeDuplicateRequest
+	if err := h.parseMergeRequest(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var companies []string
+	rows, err := tx.Query(ctx, `
+		SELECT company_id FROM products WHERE id = ANY($1) AND merged_into IS NULL FOR UPDATE
+	`, []string{req.SourceID, req.TargetID})
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "lock products"), r)
+		return
+	}
+	for rows.Next() {
+		var c string
+		rows.Scan(&c)
+		companies = append(companies, c)
+	}
+	rows.Close()
+	if len(companies) != 2 {
+		h.respondError(w, errors.NewNotFoundError("Unmerged product"), r)
+		return
+	}
+	if companies[0] != companies[1] {
+		h.respondError(w, errors.NewBusinessRuleError("same_company", "Products can only be merged within a company"), r)
+		return
+	}
+
+	moved := map[string]int{}
+	if err := mergeProductTx(ctx, tx, req.SourceID, req.TargetID, moved); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "merge products"), r)
+		return
+	}
+	merge, err := recordMergeTx(ctx, tx, "product", req.SourceID, req.TargetID, moved, middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "record merge"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.dropForecastCache(ctx, req.SourceID, req.TargetID)
+	logger.Info("Duplicate products merged", "source_id", req.SourceID, "target_id", req.TargetID, "moved", moved)
+	h.respondJSON(w, r, http.StatusOK, merge)
+}
+
+// MergeProductsRequest merges duplicates of one product into it
+type MergeProductsRequest struct {
+	TargetID  string   `json:"target_id" validate:"required"`
+	SourceIDs []string `json:"source_ids" validate:"required"`
+}
+
+// MergeProducts consolidates duplicates of a product of the company into it:
+// their sales history and images move over, details the target lacks are
+// copied, and their names and SKUs are kept as aliases so later imports match
+// the target. Each merge is logged.
+func (h *Handler) MergeProducts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req MergeProductsRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	sources := []string{}
+	seen := map[string]bool{req.TargetID: true}
+	for _, id := range req.SourceIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			sources = append(sources, id)
+		}
+	}
+	if len(sources) == 0 {
+		h.respondError(w, errors.NewValidationError("Cannot merge a product into itself", "source_ids must name other products"), r)
+		return
+	}
+	if len(sources) > maxProductMergeSources {
+		h.respondError(w, errors.NewValidationError("Too many products", fmt.Sprintf("at most %d products per merge", maxProductMergeSources)), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	all := append([]string{req.TargetID}, sources...)
+	rows, err := tx.Query(ctx, `
+		SELECT id, COALESCE(unit, '') FROM products
+		WHERE id = ANY($1) AND company_id = $2 AND merged_into IS NULL
+		FOR UPDATE
+	`, all, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "lock products"), r)
+		return
+	}
+	unitOf := map[string]string{}
+	for rows.Next() {
+		var id, unit string
+		if rows.Scan(&id, &unit) == nil {
+			unitOf[id] = unit
+		}
+	}
+	rows.Close()
+	if len(unitOf) != len(all) {
+		h.respondError(w, errors.NewNotFoundError("Unmerged product"), r)
+		return
+	}
+	// Sales quantities are stored in the product's unit
+	for _, id := range sources {
+		if unitOf[id] != unitOf[req.TargetID] {
+			h.respondError(w, errors.NewBusinessRuleError("same_unit",
+				fmt.Sprintf("Product %s is sold in %q, not %q like the target; change its unit first", id, unitOf[id], unitOf[req.TargetID])), r)
+			return
+		}
+	}
+
+	userID := middleware.GetUserID(ctx)
+	merges := []*models.DuplicateMerge{}
+	for _, id := range sources {
+		moved := map[string]int{}
+		if err := mergeProductTx(ctx, tx, id, req.TargetID, moved); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "merge product "+id), r)
+			return
+		}
+		merge, err := recordMergeTx(ctx, tx, "product", id, req.TargetID, moved, userID)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "record merge"), r)
+			return
+		}
+		merges = append(merges, merge)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.dropForecastCache(ctx, all...)
+	logger.Info("Products merged", "company_id", companyID, "target_id", req.TargetID, "sources", sources, "user_id", userID)
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"target_id": req.TargetID,
+		"merges":    merges,
+	})
+}
+
+// MergeDuplicateCompanies merges a company registered twice into the one that
+// is kept: products and their sales history, conversations, files and members
+// move to the target, products with a SKU the target already has are merged
+// into the target's product, and the source is left as an empty, inactive
+// company pointing at the target. Other data, such as settings and
+// integrations, stays with the source. (platform admin only)
+func (h *Handler) MergeDuplicateCompanies(w http.ResponseWriter, r *http.Request) {
+	var req MergeDuplicateRequest
+	if err := h.parseMergeRequest(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var locked int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM (SELECT id FROM companies WHERE id = ANY($1) AND merged_into IS NULL FOR UPDATE) c
+	`, []string{req.SourceID, req.TargetID}).Scan(&locked)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "lock companies"), r)
+		return
+	}
+	if locked != 2 {
+		h.respondError(w, errors.NewNotFoundError("Unmerged company"), r)
+		return
+	}
+
+	moved := map[string]int{}
+	// Products whose SKU the target already uses are merged into that product
+	rows, err := tx.Query(ctx, `
+		SELECT s.id, t.id FROM products s
+		JOIN products t ON t.company_id = $2 AND t.sku = s.sku AND t.merged_into IS NULL
+		WHERE s.company_id = $1 AND s.merged_into IS NULL AND COALESCE(s.sku, '') <> ''
+	`, req.SourceID, req.TargetID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "match products by SKU"), r)
+		return
+	}
+	pairs := [][2]string{}
+	for rows.Next() {
+		var s, t string
+		if rows.Scan(&s, &t) == nil {
+			pairs = append(pairs, [2]string{s, t})
+		}
+	}
+	rows.Close()
+	var affected []string
+	for _, p := range pairs {
+		if err := mergeProductTx(ctx, tx, p[0], p[1], moved); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "merge product "+p[0]), r)
+			return
+		}
+		affected = append(affected, p[0], p[1])
+	}
+
+	moves := []struct{ table, sql string }{
+		{"products", `UPDATE products SET company_id = $2, updated_at = NOW() WHERE company_id = $1`},
+		{"sales_history", `UPDATE sales_history SET company_id = $2 WHERE company_id = $1`},
+		{"conversations", `UPDATE conversations SET company_id = $2 WHERE company_id = $1`},
+		{"file_uploads", `UPDATE file_uploads SET company_id = $2 WHERE company_id = $1`},
+		{"product_aliases", `
+			UPDATE product_aliases a SET company_id = $2 WHERE a.company_id = $1
+			AND NOT EXISTS (SELECT 1 FROM product_aliases b WHERE b.company_id = $2 AND b.kind = a.kind AND b.alias = a.alias)`},
+		{"company_members", `
+			INSERT INTO company_members (company_id, user_id, role, source)
+			SELECT $2, user_id, CASE WHEN role = 'owner' THEN 'manager' ELSE role END, source FROM company_members WHERE company_id = $1
+			UNION SELECT $2, owner_user_id, 'manager', 'merge' FROM companies WHERE id = $1 AND owner_user_id IS NOT NULL
+			ON CONFLICT (company_id, user_id) DO NOTHING`},
+	}
+	for _, m := range moves {
+		tag, err := tx.Exec(ctx, m.sql, req.SourceID, req.TargetID)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "move "+m.table), r)
+			return
+		}
+		moved[m.table] += int(tag.RowsAffected())
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE companies SET status = 'merged', merged_into = $2, merged_at = NOW(), updated_at = NOW() WHERE id = $1
+	`, req.SourceID, req.TargetID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "mark company merged"), r)
+		return
+	}
+	merge, err := recordMergeTx(ctx, tx, "company", req.SourceID, req.TargetID, moved, middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "record merge"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.dropForecastCache(ctx, affected...)
+	logger.Info("Duplicate companies merged", "source_id", req.SourceID, "target_id", req.TargetID, "moved", moved)
+	h.respondJSON(w, r, http.StatusOK, merge)
+}
+
+// mergeProductTx moves a product's sales history, images and stock on hand to
+// target and deactivates it. The source keeps existing, pointing at target, so
+// references elsewhere stay valid; its SKU is released to the surviving
+// product. Details the target lacks are taken from the source, and the
+// source's name and SKU become aliases of the target for import matching.
+func mergeProductTx(ctx context.Context, tx pgx.Tx, sourceID, targetID string, moved map[string]int) error {
+	var companyID, name, sku, category string
+	var unitPrice, cost float64
+	var stock *float64
+	err := tx.QueryRow(ctx, `
+		SELECT company_id, name, COALESCE(sku, ''), COALESCE(category, ''), COALESCE(unit_price, 0)::float8, COALESCE(cost, 0)::float8,
+			stock_on_hand::float8
+		FROM products WHERE id = $1
+	`, sourceID).Scan(&companyID, &name, &sku, &category, &unitPrice, &cost, &stock)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE sales_history SET product_id = $2 WHERE product_id = $1`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+	moved["product_sales"] += int(tag.RowsAffected())
+	tag, err = tx.Exec(ctx, `UPDATE product_images SET product_id = $2, is_primary = FALSE WHERE product_id = $1`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+	moved["product_images"] += int(tag.RowsAffected())
+	// The source's stock on hand joins the target's
+	if stock != nil && *stock != 0 {
+		note := fmt.Sprintf("Digabung dari %s", name)
+		if _, err := moveStock(ctx, tx, stockMove{companyID: companyID, productID: sourceID, kind: inventory.KindAdjustment, quantity: -*stock, note: note}); err != nil {
+			return err
+		}
+		if _, err := moveStock(ctx, tx, stockMove{companyID: companyID, productID: targetID, kind: inventory.KindAdjustment, quantity: *stock, note: note, track: true}); err != nil {
+			return err
+		}
+	}
+	// Cached forecasts of both products no longer match their sales
+	if _, err := tx.Exec(ctx, `DELETE FROM forecasts WHERE product_id = ANY($1)`, []string{sourceID, targetID}); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE products SET is_active = FALSE, sku = NULL, merged_into = $2, merged_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+	moved["products_merged"]++
+
+	_, err = tx.Exec(ctx, `
+		UPDATE products SET
+			sku = COALESCE(NULLIF(sku, ''), NULLIF($2, '')),
+			category = COALESCE(NULLIF(category, ''), NULLIF($3, '')),
+			unit_price = CASE WHEN COALESCE(unit_price, 0) = 0 THEN $4 ELSE unit_price END,
+			cost = CASE WHEN COALESCE(cost, 0) = 0 THEN $5 ELSE cost END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, targetID, sku, category, unitPrice, cost)
+	if err != nil {
+		return err
+	}
+
+	// Aliases of the source follow it, so chains of merges keep matching
+	tag, err = tx.Exec(ctx, `UPDATE product_aliases SET product_id = $2 WHERE product_id = $1`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+	moved["product_aliases"] += int(tag.RowsAffected())
+	aliases := map[string]string{"name": importKey(name), "sku": importKey(sku)}
+	for kind, alias := range aliases {
+		if alias == "" {
+			continue
+		}
+		tag, err = tx.Exec(ctx, `
+			INSERT INTO product_aliases (company_id, kind, alias, product_id, merged_from)
+			SELECT company_id, $2, $3, id, $4 FROM products WHERE id = $1
+			ON CONFLICT (company_id, kind, alias) DO UPDATE SET product_id = EXCLUDED.product_id, merged_from = EXCLUDED.merged_from
+		`, targetID, kind, alias, sourceID)
+		if err != nil {
+			return err
+		}
+		moved["product_aliases"] += int(tag.RowsAffected())
+	}
+	return nil
+}
+
+func recordMergeTx(ctx context.Context, tx pgx.Tx, kind, sourceID, targetID string, moved map[string]int, userID string) (*models.DuplicateMerge, error) {
+	merge := &models.DuplicateMerge{ID: uuid.New().String(), Kind: kind, SourceID: sourceID, TargetID: targetID, Moved: moved}
+	movedJSON, _ := json.Marshal(moved)
+	_, err := tx.Exec(ctx, `
+		INSERT INTO duplicate_merges (id, kind, source_id, target_id, moved, merged_by)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+	`, merge.ID, kind, sourceID, targetID, movedJSON, userID)
+	return merge, err
+}
+
+// duplicateCandidates embeds the records' names when AI is configured, caching
+// them in the duplicates namespace under source, and pairs up likely duplicates
+func (h *Handler) duplicateCandidates(ctx context.Context, source string, records []duplicateRecord, minScore float64, limit int) ([]models.DuplicateCandidate, error) {
+	if h.config.KolosalAPIKey != "" && len(records) > 1 {
+		if err := h.embedDuplicateNames(ctx, source, records); err != nil {
+			// Lexical comparison still finds most duplicates
+			logger.Warn("Duplicate name embedding failed; comparing names lexically", "source", source, "error", err.Error())
+			for i := range records {
+				records[i].Vector = nil
+			}
+		}
+	}
+
+	plain := make([]dedupe.Record, len(records))
+	byID := map[string]models.DuplicateRecord{}
+	for i, d := range records {
+		plain[i] = d.Record
+		d.summary.ID, d.summary.Name = d.ID, d.Name
+		byID[d.ID] = d.summary
+	}
+
+	candidates := []models.DuplicateCandidate{}
+	for _, c := range dedupe.Candidates(plain, minScore, limit) {
+		candidates = append(candidates, models.DuplicateCandidate{
+			A: byID[c.A], B: byID[c.B], Score: c.Score, NameScore: c.NameScore, Shared: c.Shared,
+		})
+	}
+	return candidates, nil
+}
+
+// embedDuplicateNames stores the names as chunks of one source, so unchanged
+// names are not embedded again, and loads their vectors
+func (h *Handler) embedDuplicateNames(ctx context.Context, source string, records []duplicateRecord) error {
+	names := make([]string, len(records))
+	for i, d := range records {
+		names[i] = dedupe.NormalizeName(d.Name)
+		if names[i] == "" {
+			names[i] = d.Name
+		}
+	}
+	vectors, err := h.embedTexts(ctx, embeddings.NamespaceDuplicates, source, names)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		records[i].Vector = vectors[i]
+	}
+	return nil
+}
+
+// dropForecastCache removes cached forecasts of products whose sales changed
+func (h *Handler) dropForecastCache(ctx context.Context, productIDs ...string) {
+	if h.redis == nil {
+		return
+	}
+	for _, id := range productIDs {
+		h.redis.Delete(ctx, fmt.Sprintf("forecast:%s", id))
+	}
+}
+
+func (h *Handler) parseMergeRequest(r *http.Request, req *MergeDuplicateRequest) error {
+	if err := h.parseJSON(r, req); err != nil {
+		return err
+	}
+	if err := validation.Validate(*req); err != nil {
+		return err
+	}
+	if req.SourceID == req.TargetID {
+		return errors.NewValidationError("Cannot merge a record into itself", "source_id and target_id must differ")
+	}
+	return nil
+}
+
+// parseDuplicateParams reads ?min_score= (0-1) and ?limit=
+func parseDuplicateParams(r *http.Request) (float64, int, error) {
+	minScore, limit := defaultDuplicateMinScore, 50
+	q := r.URL.Query()
+	if s := q.Get("min_score"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v <= 0 || v > 1 {
+			return 0, 0, errors.NewValidationError("Invalid min_score", "min_score must be between 0 and 1")
+		}
+		minScore = v
+	}
+	if s := q.Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 || v > maxDuplicateCandidates {
+			return 0, 0, errors.NewValidationError("Invalid limit", fmt.Sprintf("limit must be between 1 and %d", maxDuplicateCandidates))
+		}
+		limit = v
+	}
+	return minScore, limit, nil
+}
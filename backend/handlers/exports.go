@@ -0,0 +1,512 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/exportjob"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// exportStaleAfter is how long a pending or running export may go without
+// finishing before it is assumed lost, e.g. to a restart, and marked failed
+const exportStaleAfter = 6 * time.Hour
+
+// exportDownloadTimeout bounds how long sending an export artifact may take
+const exportDownloadTimeout = 10 * time.Minute
+
+// RequestExportRequest represents a request for a background export
+type RequestExportRequest struct {
+	Kind string `json:"kind"`
+	From string `json:"from,omitempty"` // YYYY-MM-DD; sales and conversations only
+	To   string `json:"to,omitempty"`   // YYYY-MM-DD; sales and conversations only
+}
+
+// RequestExport queues an export of the company's data. The export runs in the
+// background and a notification is sent once it can be downloaded.
+func (h *Handler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req RequestExportRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	format, ok := exportjob.FormatFor(req.Kind)
+	if !ok {
+		h.respondError(w, errors.NewValidationError("Invalid kind", "kind must be one of "+strings.Join(exportjob.Kinds(), ", ")), r)
+		return
+	}
+	params := models.ExportParams{From: req.From, To: req.To}
+	if err := validateExportRange(params); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	var active string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id FROM export_jobs
+		WHERE company_id = $1 AND kind = $2 AND status IN ($3, $4) AND created_at > $5
+		LIMIT 1
+	`, companyID, req.Kind, exportjob.StatusPending, exportjob.StatusRunning, time.Now().Add(-exportStaleAfter)).Scan(&active)
+	if err == nil {
+		h.respondError(w, errors.NewConflictError("Export already running", "export "+active+" is still in progress"), r)
+		return
+	}
+	if err != pgx.ErrNoRows {
+		h.respondError(w, errors.NewDatabaseError(err, "find active export"), r)
+		return
+	}
+
+	paramsJSON, _ := json.Marshal(params)
+	jobID := uuid.New().String()
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO export_jobs (id, company_id, requested_by, kind, format, params, status, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, NOW())
+	`, jobID, companyID, middleware.GetUserID(ctx), req.Kind, format.Name, paramsJSON, exportjob.StatusPending)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create export job"), r)
+		return
+	}
+
+	h.startJob(ctx, jobExport, jobID, exportJobTimeout, func(ctx context.Context) {
+		h.processExport(ctx, jobID, companyID)
+	})
+
+	job, err := h.loadExportJob(ctx, companyID, jobID)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusAccepted, job)
+}
+
+// ListExports lists the company's exports, newest first
+func (h *Handler) ListExports(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM export_jobs WHERE company_id = $1`, companyID).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count exports"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, exportJobSelect+`
+		WHERE company_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`, companyID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list exports"), r)
+		return
+	}
+	defer rows.Close()
+
+	jobs := []models.ExportJob{}
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			continue
+		}
+		h.signExportDownload(job)
+		jobs = append(jobs, *job)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    jobs,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// GetExport returns an export job; completed jobs carry a short-lived download URL
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	job, err := h.loadExportJob(r.Context(), companyID, r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, job)
+}
+
+// DownloadExport serves an export artifact. It is not behind auth: the signed
+// expires/signature query parameters from GetExport are the credential, so the
+// link can be opened directly by the browser.
+func (h *Handler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	q := r.URL.Query()
+	if !exportjob.Verify(h.config.JWTSecret, id, q.Get("expires"), q.Get("signature"), time.Now()) {
+		h.respondError(w, errors.NewUnauthorizedError("Download link is invalid or has expired"), r)
+		return
+	}
+
+	var kind, storagePath string
+	var finishedAt time.Time
+	err := h.db.Pool().QueryRow(r.Context(), `
+		SELECT kind, storage_path, finished_at FROM export_jobs
+		WHERE id = $1 AND status = $2 AND storage_path IS NOT NULL
+	`, id, exportjob.StatusCompleted).Scan(&kind, &storagePath, &finishedAt)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Export"), r)
+		return
+	}
+
+	f, err := h.files.Open(storagePath)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Export"), r)
+		return
+	}
+	defer f.Close()
+
+	format, _ := exportjob.FormatFor(kind)
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="bantuaku-%s-%s%s"`,
+		kind, finishedAt.Format("20060102"), format.Extension))
+	// Large artifacts take longer to send than the server's write timeout allows
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportDownloadTimeout))
+	if _, err := io.Copy(w, f); err != nil {
+		logger.Error("Failed to send export", "export_id", id, "error", err.Error())
+	}
+}
+
+// PurgeExpiredExports deletes export artifacts past their retention period and
+// fails exports that were interrupted before finishing. It is run periodically
+// from main.
+func (h *Handler) PurgeExpiredExports(ctx context.Context) {
+	_, err := h.db.Pool().Exec(ctx, `
+		UPDATE export_jobs SET status = $1, error = 'export was interrupted', finished_at = NOW()
+		WHERE status IN ($2, $3) AND created_at <= $4
+	`, exportjob.StatusFailed, exportjob.StatusPending, exportjob.StatusRunning, time.Now().Add(-exportStaleAfter))
+	if err != nil {
+		logger.Error("Failed to fail stale exports", "error", err.Error())
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, COALESCE(storage_path, '') FROM export_jobs WHERE status = $1 AND expires_at <= NOW()
+	`, exportjob.StatusCompleted)
+	if err != nil {
+		logger.Error("Failed to find expired exports", "error", err.Error())
+		return
+	}
+	var ids, paths []string
+	for rows.Next() {
+		var id, path string
+		if rows.Scan(&id, &path) == nil {
+			ids = append(ids, id)
+			paths = append(paths, path)
+		}
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return
+	}
+
+	h.deleteStoredFiles(paths...)
+	if _, err := h.db.Pool().Exec(ctx, `
+		UPDATE export_jobs SET status = $1, storage_path = NULL WHERE id = ANY($2)
+	`, exportjob.StatusExpired, ids); err != nil {
+		logger.Error("Failed to expire exports", "error", err.Error())
+		return
+	}
+	logger.Info("Purged expired exports", "count", len(ids))
+}
+
+// processExport writes the export artifact to object storage and notifies the
+// company when it is ready. Shutdown aborts the upload and fails the export.
+func (h *Handler) processExport(ctx context.Context, jobID, companyID string) {
+	// Failures are still recorded after ctx is cancelled
+	final := context.WithoutCancel(ctx)
+	log := jobLogger(ctx)
+	h.db.Pool().Exec(ctx, `
+		UPDATE export_jobs SET status = $2, started_at = NOW() WHERE id = $1
+	`, jobID, exportjob.StatusRunning)
+
+	job, err := h.loadExportJob(ctx, companyID, jobID)
+	if err != nil {
+		log.Error("Failed to load export job", "error", err.Error())
+		return
+	}
+	format, _ := exportjob.FormatFor(job.Kind)
+	region := h.storageRegionForCompany(ctx, companyID)
+	key := fmt.Sprintf("%s/exports/%s%s", companyID, jobID, format.Extension)
+
+	// Stream straight into storage so large exports are never held in memory
+	pr, pw := io.Pipe()
+	var rowCount int
+	go func() {
+		var err error
+		rowCount, err = h.writeExport(ctx, pw, companyID, job.Kind, job.Params)
+		pw.CloseWithError(err)
+	}()
+	storagePath, size, err := h.files.Put(ctx, region, key, pr)
+	pr.Close()
+	if err != nil {
+		h.deleteStoredFiles(storagePath)
+		if h.interruptedByShutdown(ctx) {
+			err = fmt.Errorf("interrupted by server shutdown: %w", err)
+		}
+		log.Warn("Export failed", "kind", job.Kind, "error", err.Error())
+		h.db.Pool().Exec(final, `
+			UPDATE export_jobs SET status = $2, error = $3, finished_at = NOW() WHERE id = $1
+		`, jobID, exportjob.StatusFailed, truncateRunes(err.Error(), 1000))
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(h.config.ExportRetentionHours) * time.Hour)
+	_, err = h.db.Pool().Exec(ctx, `
+		UPDATE export_jobs
+		SET status = $2, storage_path = $3, storage_region = $4, size_bytes = $5, row_count = $6,
+			finished_at = NOW(), expires_at = $7
+		WHERE id = $1
+	`, jobID, exportjob.StatusCompleted, storagePath, region, size, rowCount, expiresAt)
+	if err != nil {
+		h.deleteStoredFiles(storagePath)
+		log.Error("Failed to record export", "error", err.Error())
+		return
+	}
+
+	log.Info("Export completed", "kind", job.Kind,
+		"rows", rowCount, "size_bytes", size)
+
+	_, err = h.notify(ctx, companyID, models.Notification{
+		Type:    models.NotificationExportReady,
+		Title:   "Ekspor data siap diunduh",
+		Message: fmt.Sprintf("Ekspor %s Anda sudah selesai dan tersedia selama %d jam.", job.Kind, h.config.ExportRetentionHours),
+		Data: map[string]interface{}{
+			"export_id": jobID,
+			"kind":      job.Kind,
+		},
+	}, "export:"+jobID)
+	if err != nil {
+		log.Error("Failed to notify export ready", "error", err.Error())
+	}
+}
+
+// writeExport writes the artifact for kind to w and returns the number of
+// records written
+func (h *Handler) writeExport(ctx context.Context, w io.Writer, companyID, kind string, params models.ExportParams) (int, error) {
+	switch kind {
+	case exportjob.KindSales:
+		return h.writeSalesExport(ctx, w, companyID, params)
+	case exportjob.KindConversations:
+		return h.writeConversationsExport(ctx, w, companyID, params)
+	case exportjob.KindAccount:
+		snapshot, err := h.buildCompanySnapshot(ctx, companyID)
+		if err != nil {
+			return 0, err
+		}
+		gz := gzip.NewWriter(w)
+		if err := json.NewEncoder(gz).Encode(snapshot); err != nil {
+			return 0, err
+		}
+		return len(snapshot.Products) + len(snapshot.Sales) + len(snapshot.Conversations), gz.Close()
+	}
+	return 0, fmt.Errorf("unknown export kind: %s", kind)
+}
+
+func (h *Handler) writeSalesExport(ctx context.Context, w io.Writer, companyID string, params models.ExportParams) (int, error) {
+	conditions := []string{"s.company_id = $1"}
+	args := []interface{}{companyID}
+	if params.From != "" {
+		args = append(args, params.From)
+		conditions = append(conditions, fmt.Sprintf("s.sale_date >= $%d::date", len(args)))
+	}
+	if params.To != "" {
+		args = append(args, params.To)
+		conditions = append(conditions, fmt.Sprintf("s.sale_date <= $%d::date", len(args)))
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT s.id, s.sale_date, s.product_id, COALESCE(p.name, ''), COALESCE(p.sku, ''), s.quantity,
+			COALESCE(s.price, 0), COALESCE(s.channel, ''), s.source, COALESCE(s.excluded, false)
+		FROM sales_history s
+		LEFT JOIN products p ON p.id = s.product_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY s.sale_date, s.id
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "sale_date", "product_id", "product_name", "sku", "quantity", "price", "channel", "source", "excluded"})
+	count := 0
+	for rows.Next() {
+		var (
+			id                                    int64
+			saleDate                              time.Time
+			productID, name, sku, channel, source string
+			quantity, price                       float64
+			excluded                              bool
+		)
+		if err := rows.Scan(&id, &saleDate, &productID, &name, &sku, &quantity, &price, &channel, &source, &excluded); err != nil {
+			return count, err
+		}
+		cw.Write([]string{
+			strconv.FormatInt(id, 10), saleDate.Format("2006-01-02"), productID, name, sku,
+			strconv.FormatFloat(quantity, 'f', -1, 64), strconv.FormatFloat(price, 'f', 2, 64),
+			channel, source, strconv.FormatBool(excluded),
+		})
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	cw.Flush()
+	return count, cw.Error()
+}
+
+func (h *Handler) writeConversationsExport(ctx context.Context, w io.Writer, companyID string, params models.ExportParams) (int, error) {
+	conditions := []string{"c.company_id = $1"}
+	args := []interface{}{companyID}
+	if params.From != "" {
+		args = append(args, params.From)
+		conditions = append(conditions, fmt.Sprintf("m.created_at >= $%d::date", len(args)))
+	}
+	if params.To != "" {
+		args = append(args, params.To)
+		conditions = append(conditions, fmt.Sprintf("m.created_at < $%d::date + 1", len(args)))
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT c.id, COALESCE(c.title, ''), m.id, m.sender, m.content, m.created_at
+		FROM messages m
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY c.created_at, c.id, m.created_at
+	`, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	enc := json.NewEncoder(w)
+	count := 0
+	for rows.Next() {
+		var line struct {
+			ConversationID    string    `json:"conversation_id"`
+			ConversationTitle string    `json:"conversation_title,omitempty"`
+			MessageID         string    `json:"message_id"`
+			Sender            string    `json:"sender"`
+			Content           string    `json:"content"`
+			CreatedAt         time.Time `json:"created_at"`
+		}
+		if err := rows.Scan(&line.ConversationID, &line.ConversationTitle, &line.MessageID, &line.Sender,
+			&line.Content, &line.CreatedAt); err != nil {
+			return count, err
+		}
+		if err := enc.Encode(line); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// signExportDownload sets a download URL on completed jobs, valid for
+// EXPORT_LINK_MINUTES or until the artifact expires, whichever is sooner
+func (h *Handler) signExportDownload(job *models.ExportJob) {
+	if job.Status != exportjob.StatusCompleted || job.ExpiresAt == nil {
+		return
+	}
+	expires := time.Now().Add(time.Duration(h.config.ExportLinkMinutes) * time.Minute)
+	if job.ExpiresAt.Before(expires) {
+		expires = *job.ExpiresAt
+	}
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("signature", exportjob.Sign(h.config.JWTSecret, job.ID, expires))
+	job.DownloadURL = "/api/v1/exports/" + job.ID + "/download?" + q.Encode()
+}
+
+func validateExportRange(params models.ExportParams) error {
+	var from, to time.Time
+	var err error
+	if params.From != "" {
+		if from, err = time.Parse("2006-01-02", params.From); err != nil {
+			return errors.NewValidationError("Invalid from", "from must be a date in YYYY-MM-DD format")
+		}
+	}
+	if params.To != "" {
+		if to, err = time.Parse("2006-01-02", params.To); err != nil {
+			return errors.NewValidationError("Invalid to", "to must be a date in YYYY-MM-DD format")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return errors.NewValidationError("Invalid range", "to must not be before from")
+	}
+	return nil
+}
+
+const exportJobSelect = `
+	SELECT id, company_id, COALESCE(requested_by, ''), kind, format, params, status, COALESCE(storage_path, ''),
+		COALESCE(storage_region, ''), size_bytes, row_count, COALESCE(error, ''), created_at, started_at,
+		finished_at, expires_at
+	FROM export_jobs`
+
+func scanExportJob(row pgx.Row) (*models.ExportJob, error) {
+	var job models.ExportJob
+	var params []byte
+	err := row.Scan(&job.ID, &job.CompanyID, &job.RequestedBy, &job.Kind, &job.Format, &params, &job.Status,
+		&job.StoragePath, &job.StorageRegion, &job.SizeBytes, &job.RowCount, &job.Error, &job.CreatedAt,
+		&job.StartedAt, &job.FinishedAt, &job.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(params, &job.Params)
+	return &job, nil
+}
+
+func (h *Handler) loadExportJob(ctx context.Context, companyID, id string) (*models.ExportJob, error) {
+	job, err := scanExportJob(h.db.Pool().QueryRow(ctx, exportJobSelect+`
+		WHERE id = $1 AND company_id = $2
+	`, id, companyID))
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewNotFoundError("Export")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load export job")
+	}
+	h.signExportDownload(job)
+	return job, nil
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/docparse"
+	"github.com/bantuaku/backend/services/imaging"
+	"github.com/bantuaku/backend/services/ocr"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxFileExcerpt bounds the text of an uploaded file handed to the chat model
+const maxFileExcerpt = 4000
+
+// FileContentResponse is the extracted content of an uploaded file
+type FileContentResponse struct {
+	FileID           string           `json:"file_id"`
+	OriginalFilename string           `json:"original_filename"`
+	Parser           string           `json:"parser"`
+	Text             string           `json:"text"`
+	Tables           []docparse.Table `json:"tables"`
+	Truncated        bool             `json:"truncated"`
+	OCREngine        string           `json:"ocr_engine,omitempty"`     // Engine whose text was accepted, for scanned PDFs
+	OCRConfidence    *float64         `json:"ocr_confidence,omitempty"` // 0-1
+	ParsedAt         time.Time        `json:"parsed_at"`
+}
+
+// parseStoredFile extracts the content of a stored upload. Images, and PDFs
+// without a text layer or without pdftotext installed, go through the OCR
+// chain; its outcome is returned for quality monitoring.
+func (h *Handler) parseStoredFile(ctx context.Context, storagePath, sourceType string) (*docparse.Result, *ocr.Outcome, error) {
+	f, err := h.files.Open(storagePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open stored file: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read stored file: %w", err)
+	}
+
+	doc := ocr.Document{Data: data, ContentType: http.DetectContentType(data)}
+	var res *docparse.Result
+	if sourceType != docparse.KindImage {
+		parseCtx, cancel := context.WithTimeout(ctx, parseTimeout)
+		res, err = docparse.Parse(parseCtx, sourceType, data)
+		cancel()
+		if sourceType != docparse.KindPDF || (err == nil && strings.TrimSpace(res.Text) != "") {
+			return res, nil, err
+		}
+	}
+
+	outcome, ocrErr := ocr.Run(aispend.WithFeature(ctx, aispend.FeatureOCR, ""), h.ocrEngines(), float64(h.config.OCRMinConfidence)/100, doc)
+	if ocrErr != nil {
+		if err != nil {
+			return nil, outcome, fmt.Errorf("%v; ocr: %w", err, ocrErr)
+		}
+		return nil, outcome, fmt.Errorf("ocr: %w", ocrErr)
+	}
+	logger.Info("Scanned document read by OCR", "engine", outcome.Engine, "confidence", outcome.Confidence, "attempts", len(outcome.Attempts))
+	return &docparse.Result{Parser: "ocr", Text: outcome.Text, Tables: []docparse.Table{}}, outcome, nil
+}
+
+// storeOCRImage prepares a stored photo for OCR (upright, scaled, contrast
+// stretched, compressed) and stores the result under key next to the original.
+// It returns the derived image's path and what was done to it, or an empty
+// path when the photo could not be prepared and OCR should read the original.
+func (h *Handler) storeOCRImage(ctx context.Context, region, key, originalPath string) (string, *imaging.OCRParams) {
+	f, err := h.files.Open(originalPath)
+	if err != nil {
+		logger.Warn("Failed to open image for OCR preprocessing", "path", originalPath, "error", err.Error())
+		return "", nil
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		logger.Warn("Failed to read image for OCR preprocessing", "path", originalPath, "error", err.Error())
+		return "", nil
+	}
+
+	prepared, params, err := imaging.PrepareForOCR(data)
+	if err != nil {
+		logger.Warn("OCR preprocessing failed", "path", originalPath, "error", err.Error())
+		return "", nil
+	}
+	path, _, err := h.files.Put(ctx, region, key+prepared.Extension, bytes.NewReader(prepared.Data))
+	if err != nil {
+		logger.Warn("Failed to store OCR image", "path", originalPath, "region", region, "error", err.Error())
+		return "", nil
+	}
+	logger.Info("Prepared image for OCR", "path", path, "rotation", params.Rotation, "scale", params.Scale,
+		"contrast_low", params.ContrastLow, "contrast_high", params.ContrastHigh,
+		"original_bytes", params.OriginalBytes, "bytes", params.Bytes)
+	return path, params
+}
+
+// ocrEngines lists the OCR fallback chain: Kolosal OCR and the vision model
+// when configured, then local Tesseract
+func (h *Handler) ocrEngines() []ocr.Engine {
+	var engines []ocr.Engine
+	if h.config.KolosalAPIKey != "" {
+		client := h.kolosalClient()
+		engines = append(engines, ocr.Kolosal{Client: client, Language: "id"}) // Indonesian
+		if h.config.OCRVisionModel != "" {
+			engines = append(engines, ocr.Vision{Client: client, Model: h.config.OCRVisionModel})
+		}
+	}
+	return append(engines, ocr.Tesseract{Language: h.config.OCRTesseractLang})
+}
+
+// saveParseResult stores the extracted content of an upload, with the OCR
+// outcome when the text came from OCR
+func (h *Handler) saveParseResult(ctx context.Context, fileID string, res *docparse.Result, outcome *ocr.Outcome) error {
+	tables, err := json.Marshal(res.Tables)
+	if err != nil {
+		return err
+	}
+	var engine string
+	var confidence *float64
+	var attempts []byte
+	if outcome != nil {
+		engine, confidence = outcome.Engine, &outcome.Confidence
+		if attempts, err = json.Marshal(outcome.Attempts); err != nil {
+			return err
+		}
+	}
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO file_parse_results (file_id, parser, text, tables, truncated, ocr_engine, ocr_confidence, ocr_attempts, parsed_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, NOW())
+		ON CONFLICT (file_id) DO UPDATE SET
+			parser = EXCLUDED.parser, text = EXCLUDED.text, tables = EXCLUDED.tables,
+			truncated = EXCLUDED.truncated, ocr_engine = EXCLUDED.ocr_engine,
+			ocr_confidence = EXCLUDED.ocr_confidence, ocr_attempts = EXCLUDED.ocr_attempts,
+			parsed_at = EXCLUDED.parsed_at, summary = NULL
+	`, fileID, res.Parser, res.Text, tables, res.Truncated, engine, confidence, attempts)
+	return err
+}
+
+// fileParseResult loads the extracted content of an upload
+func (h *Handler) fileParseResult(ctx context.Context, fileID string) (FileContentResponse, error) {
+	c := FileContentResponse{FileID: fileID}
+	var tables []byte
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT parser, text, tables, truncated, COALESCE(ocr_engine, ''), ocr_confidence::float8, parsed_at
+		FROM file_parse_results
+		WHERE file_id = $1
+	`, fileID).Scan(&c.Parser, &c.Text, &tables, &c.Truncated, &c.OCREngine, &c.OCRConfidence, &c.ParsedAt)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(tables, &c.Tables); err != nil || c.Tables == nil {
+		c.Tables = []docparse.Table{}
+	}
+	return c, nil
+}
+
+// GetFileContent returns the text and tables extracted from an uploaded file
+func (h *Handler) GetFileContent(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	userID := middleware.GetUserID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	f, err := h.accessibleFile(r.Context(), r.PathValue("id"), companyID, userID)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("File"), r)
+		return
+	}
+
+	content, err := h.fileParseResult(r.Context(), f.ID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("File content"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load file content"), r)
+		return
+	}
+	content.OriginalFilename = f.OriginalFilename
+	h.logFileAccess(r.Context(), f.ID, userID, "view", "content")
+
+	h.respondJSON(w, r, http.StatusOK, content)
+}
+
+// findParsedFile returns the newest parsed upload the user may see whose name
+// contains fileName, or the newest parsed upload when fileName is empty
+func (h *Handler) findParsedFile(ctx context.Context, companyID, userID, fileName string) (FileContentResponse, error) {
+	var fileID, name string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT f.id, f.original_filename
+		FROM file_uploads f
+		JOIN file_parse_results p ON p.file_id = f.id
+		WHERE f.company_id = $1
+			AND (f.visibility = 'company' OR f.user_id = $2
+				OR EXISTS (SELECT 1 FROM file_shares s WHERE s.file_id = f.id AND s.user_id = $2))
+			AND ($3 = '' OR f.original_filename ILIKE '%' || $3 || '%')
+		ORDER BY f.created_at DESC
+		LIMIT 1
+	`, companyID, userID, fileName).Scan(&fileID, &name)
+	if err != nil {
+		return FileContentResponse{}, err
+	}
+	content, err := h.fileParseResult(ctx, fileID)
+	content.OriginalFilename = name
+	return content, err
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxFileAccessEntries bounds the access log returned for one file
+const maxFileAccessEntries = 100
+
+// UpdateFileVisibilityRequest changes who in the company can see a file
+type UpdateFileVisibilityRequest struct {
+	Visibility string `json:"visibility" validate:"required"`
+}
+
+// ShareFileRequest grants one user access to a file
+type ShareFileRequest struct {
+	UserID string `json:"user_id" validate:"required,max:36"`
+}
+
+// validFileVisibility reports whether v is a known visibility level
+func validFileVisibility(v string) bool {
+	return v == models.FileVisibilityCompany || v == models.FileVisibilityPrivate
+}
+
+// accessibleFile loads a file of the company that userID may see
+func (h *Handler) accessibleFile(ctx context.Context, fileID, companyID, userID string) (models.FileUpload, error) {
+	var f models.FileUpload
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT f.id, f.company_id, f.user_id, f.source_type, f.original_filename, f.storage_path,
+			COALESCE(f.storage_region, ''), COALESCE(f.mime_type, ''), f.size_bytes, f.status, f.visibility,
+			COALESCE(f.error_message, ''), f.preprocessing, f.created_at, f.processed_at
+		FROM file_uploads f
+		WHERE f.id = $1 AND f.company_id = $2
+			AND (f.visibility = 'company' OR f.user_id = $3
+				OR EXISTS (SELECT 1 FROM file_shares s WHERE s.file_id = f.id AND s.user_id = $3))
+	`, fileID, companyID, userID).Scan(&f.ID, &f.CompanyID, &f.UserID, &f.SourceType, &f.OriginalFilename, &f.StoragePath,
+		&f.StorageRegion, &f.MimeType, &f.SizeBytes, &f.Status, &f.Visibility, &f.ErrorMessage, &f.Preprocessing, &f.CreatedAt, &f.ProcessedAt)
+	return f, err
+}
+
+// ownedFile loads a file the user uploaded; only uploaders manage visibility and shares
+func (h *Handler) ownedFile(w http.ResponseWriter, r *http.Request) (models.FileUpload, bool) {
+	companyID := middleware.GetCompanyID(r.Context())
+	userID := middleware.GetUserID(r.Context())
+	if companyID == "" || userID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return models.FileUpload{}, false
+	}
+
+	f, err := h.accessibleFile(r.Context(), r.PathValue("id"), companyID, userID)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("File"), r)
+		return f, false
+	}
+	if f.UserID != userID {
+		h.respondError(w, errors.NewForbiddenError("Only the uploader can manage access to this file"), r)
+		return f, false
+	}
+	return f, true
+}
+
+// logFileAccess appends to a file's access log
+func (h *Handler) logFileAccess(ctx context.Context, fileID, userID, action, detail string) {
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO file_access_log (file_id, user_id, action, detail, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NOW())
+	`, fileID, userID, action, detail)
+	if err != nil {
+		logger.Warn("Failed to log file access", "file_id", fileID, "action", action, "error", err.Error())
+	}
+}
+
+// UpdateFileVisibility makes a file private or company-wide (uploader only)
+func (h *Handler) UpdateFileVisibility(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.ownedFile(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateFileVisibilityRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if !validFileVisibility(req.Visibility) {
+		h.respondError(w, errors.NewValidationError("Invalid visibility", "use company or private"), r)
+		return
+	}
+
+	_, err := h.db.Pool().Exec(r.Context(), `UPDATE file_uploads SET visibility = $2 WHERE id = $1`, f.ID, req.Visibility)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update file visibility"), r)
+		return
+	}
+	h.logFileAccess(r.Context(), f.ID, f.UserID, "visibility", req.Visibility)
+
+	f.Visibility = req.Visibility
+	h.respondJSON(w, r, http.StatusOK, f)
+}
+
+// ListFileShares lists the users a file is shared with (uploader only)
+func (h *Handler) ListFileShares(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.ownedFile(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT s.user_id, u.email, s.shared_by, s.created_at
+		FROM file_shares s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.file_id = $1
+		ORDER BY s.created_at
+	`, f.ID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list file shares"), r)
+		return
+	}
+	defer rows.Close()
+
+	shares := []models.FileShare{}
+	for rows.Next() {
+		var s models.FileShare
+		if rows.Scan(&s.UserID, &s.Email, &s.SharedBy, &s.CreatedAt) == nil {
+			shares = append(shares, s)
+		}
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"visibility": f.Visibility,
+		"shares":     shares,
+	})
+}
+
+// ShareFile grants a user access to a file (uploader only). Shares matter for
+// private files; company-wide files are already visible to everyone in the company.
+func (h *Handler) ShareFile(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.ownedFile(w, r)
+	if !ok {
+		return
+	}
+
+	var req ShareFileRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.UserID == f.UserID {
+		h.respondError(w, errors.NewValidationError("Cannot share a file with its uploader", ""), r)
+		return
+	}
+
+	var share models.FileShare
+	err := h.db.Pool().QueryRow(r.Context(), `SELECT id, email FROM users WHERE id = $1`, req.UserID).Scan(&share.UserID, &share.Email)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("User"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load user"), r)
+		return
+	}
+
+	err = h.db.Pool().QueryRow(r.Context(), `
+		INSERT INTO file_shares (file_id, user_id, shared_by, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (file_id, user_id) DO UPDATE SET shared_by = file_shares.shared_by
+		RETURNING shared_by, created_at
+	`, f.ID, req.UserID, f.UserID).Scan(&share.SharedBy, &share.CreatedAt)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "share file"), r)
+		return
+	}
+	h.logFileAccess(r.Context(), f.ID, f.UserID, "share", req.UserID)
+
+	h.respondJSON(w, r, http.StatusCreated, share)
+}
+
+// UnshareFile revokes a user's access to a file (uploader only)
+func (h *Handler) UnshareFile(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.ownedFile(w, r)
+	if !ok {
+		return
+	}
+
+	userID := r.PathValue("user_id")
+	tag, err := h.db.Pool().Exec(r.Context(), `DELETE FROM file_shares WHERE file_id = $1 AND user_id = $2`, f.ID, userID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "unshare file"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("File share"), r)
+		return
+	}
+	h.logFileAccess(r.Context(), f.ID, f.UserID, "unshare", userID)
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "File unshared"})
+}
+
+// GetFileAccessLog returns the most recent access log entries of a file (uploader only)
+func (h *Handler) GetFileAccessLog(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.ownedFile(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT user_id, action, COALESCE(detail, ''), created_at
+		FROM file_access_log
+		WHERE file_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, f.ID, maxFileAccessEntries)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load file access log"), r)
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.FileAccessEntry{}
+	for rows.Next() {
+		var e models.FileAccessEntry
+		if rows.Scan(&e.UserID, &e.Action, &e.Detail, &e.CreatedAt) == nil {
+			entries = append(entries, e)
+		}
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"entries": entries,
+	})
+}
@@ -1,24 +1,27 @@
 package handlers
 
 import (
-	"encoding/base64"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/bantuaku/backend/errors"
 	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
-	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/docparse"
+	"github.com/bantuaku/backend/services/imaging"
+	"github.com/bantuaku/backend/services/metering"
+	"github.com/bantuaku/backend/services/storage"
 
 	"github.com/google/uuid"
 )
 
 const (
-	maxFileSize = 10 * 1024 * 1024 // 10MB
-	uploadDir   = "./uploads"
+	maxFileSize  = 10 * 1024 * 1024 // 10MB
+	parseTimeout = 30 * time.Second // Bounds document parsing within a request
 )
 
 // UploadFileResponse represents the response when uploading a file
@@ -28,13 +31,18 @@ type UploadFileResponse struct {
 	MimeType         string                `json:"mime_type"`
 	SizeBytes        int64                 `json:"size_bytes"`
 	Status           string                `json:"status"`
+	StorageRegion    string                `json:"storage_region"`
+	Visibility       string                `json:"visibility"`
+	ErrorMessage     string                `json:"error_message,omitempty"`
 	ExtractedData    *models.ExtractedData `json:"extracted_data,omitempty"`
 }
 
-// UploadFile handles file uploads (CSV/XLSX/PDF)
+// UploadFile handles file uploads (CSV/XLSX/DOCX/PDF, and JPEG/PNG photos of
+// receipts) and extracts their content for chat and imports. An optional "visibility" form
+// field makes the file private to the uploader; files are company-wide by default.
 func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
-	_ = r.Context().Value("user_id")  // TODO: Use userID when implementing DB storage
-	_ = r.Context().Value("store_id") // TODO: Update to company_id
+	userID := middleware.GetUserID(r.Context())
+	companyID := middleware.GetCompanyID(r.Context())
 
 	// Parse multipart form
 	err := r.ParseMultipartForm(maxFileSize)
@@ -43,6 +51,15 @@ func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	visibility := r.FormValue("visibility")
+	if visibility == "" {
+		visibility = models.FileVisibilityCompany
+	}
+	if !validFileVisibility(visibility) {
+		h.respondError(w, errors.NewValidationError("Invalid visibility", "use company or private"), r)
+		return
+	}
+
 	file, header, err := r.FormFile("file")
 	if err != nil {
 		h.respondError(w, fmt.Errorf("failed to get file: %w", err), r)
@@ -62,38 +79,40 @@ func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
 	switch ext {
 	case ".csv":
 		sourceType = "csv"
-	case ".xlsx", ".xls":
+	case ".xlsx":
 		sourceType = "xlsx"
+	case ".xls":
+		h.respondError(w, errors.NewValidationError("Legacy .xls spreadsheets are not supported", "save the file as .xlsx or .csv and upload it again"), r)
+		return
+	case ".docx":
+		sourceType = "docx"
 	case ".pdf":
 		sourceType = "pdf"
+	case ".jpg", ".jpeg", ".png":
+		sourceType = docparse.KindImage
 	default:
 		h.respondError(w, fmt.Errorf("unsupported file type: %s", ext), r)
 		return
 	}
 
-	// Create upload directory if it doesn't exist
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		logger.Error("Failed to create upload directory", "error", err.Error())
-		h.respondError(w, fmt.Errorf("failed to create upload directory"), r)
+	usage, err := h.consumeUsage(r.Context(), companyID, metering.MetricFileUploads, 1)
+	setUsageHeaders(w, usage)
+	if err != nil {
+		h.respondError(w, err, r)
 		return
 	}
 
+	// Pin the file to the company's data residency region
+	region := h.storageRegionForCompany(r.Context(), companyID)
+
 	// Generate unique filename
 	fileID := uuid.New().String()
-	filename := fmt.Sprintf("%s%s", fileID, ext)
-	storagePath := filepath.Join(uploadDir, filename)
+	filename := fmt.Sprintf("%s/%s%s", companyID, fileID, ext)
 
 	// Save file
-	dst, err := os.Create(storagePath)
+	storagePath, _, err := h.files.Put(r.Context(), region, filename, file)
 	if err != nil {
-		logger.Error("Failed to create file", "error", err.Error())
-		h.respondError(w, fmt.Errorf("failed to save file"), r)
-		return
-	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, file); err != nil {
-		logger.Error("Failed to copy file", "error", err.Error())
+		logger.Error("Failed to store file", "error", err.Error(), "region", region)
 		h.respondError(w, fmt.Errorf("failed to save file"), r)
 		return
 	}
@@ -106,56 +125,94 @@ func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		MimeType:         header.Header.Get("Content-Type"),
 		SizeBytes:        header.Size,
 		Status:           "uploaded",
+		StorageRegion:    region,
+		Visibility:       visibility,
 	}
 
-	// Process file based on type
-	if sourceType == "pdf" && h.config.KolosalAPIKey != "" {
-		// Use Kolosal.ai OCR for PDF processing
-		ctx := r.Context()
-		client := kolosal.NewClient(h.config.KolosalAPIKey)
-
-		// Read file from disk and encode to base64
-		savedFile, err := os.Open(storagePath)
-		if err == nil {
-			defer savedFile.Close()
-			fileBytes, err := io.ReadAll(savedFile)
-			if err == nil {
-				imageBase64 := base64.StdEncoding.EncodeToString(fileBytes)
-
-				_, err := client.OCR(ctx, kolosal.OCRRequest{
-					Image:    imageBase64,
-					Language: "id", // Indonesian
-				})
-
-				if err == nil {
-					response.Status = "processed"
-					// TODO: Parse OCR text to extract structured data (products, sales)
-					// For now, just mark as processed
-					logger.Info("PDF processed with OCR", "file_id", fileUploadID)
-				} else {
-					response.Status = "failed"
-					logger.Error("OCR processing failed", "error", err.Error())
-				}
-			}
+	// Photos are cleaned up for OCR first; the derived image is kept next to
+	// the original
+	parsePath := storagePath
+	var ocrImagePath string
+	var preprocessing *imaging.OCRParams
+	if sourceType == docparse.KindImage {
+		ocrImagePath, preprocessing = h.storeOCRImage(r.Context(), region, fmt.Sprintf("%s/%s_ocr", companyID, fileID), storagePath)
+		if ocrImagePath != "" {
+			parsePath = ocrImagePath
 		}
-	} else if sourceType == "csv" || sourceType == "xlsx" {
-		// TODO: Implement CSV/XLSX parsing
-		// For now, mark as uploaded
-		response.Status = "uploaded"
 	}
 
-	h.respondJSON(w, http.StatusOK, response)
+	// Extract text and tables so chat and imports can use the file
+	parsed, ocrOutcome, parseErr := h.parseStoredFile(r.Context(), parsePath, sourceType)
+	if parseErr != nil {
+		response.Status = "failed"
+		response.ErrorMessage = parseErr.Error()
+		logger.Warn("File parsing failed", "file_id", fileUploadID, "source_type", sourceType, "error", parseErr.Error())
+	} else {
+		response.Status = "processed"
+	}
+
+	// Record the upload, including where it physically lives
+	var processedAt *time.Time
+	if response.Status == "processed" {
+		now := time.Now()
+		processedAt = &now
+	}
+	_, err = h.db.Pool().Exec(r.Context(), `
+		INSERT INTO file_uploads (id, company_id, user_id, source_type, original_filename, storage_path,
+			storage_region, mime_type, size_bytes, status, visibility, error_message, created_at, processed_at,
+			ocr_image_path, preprocessing)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, ''), $13, $14, NULLIF($15, ''), $16)
+	`, fileUploadID, companyID, userID, sourceType, header.Filename, storagePath,
+		region, response.MimeType, header.Size, response.Status, visibility, response.ErrorMessage, time.Now(), processedAt,
+		ocrImagePath, preprocessing)
+	if err != nil {
+		h.deleteStoredFiles(storagePath, ocrImagePath)
+		h.respondError(w, errors.NewDatabaseError(err, "record file upload"), r)
+		return
+	}
+	if parsed != nil {
+		if err := h.saveParseResult(r.Context(), fileUploadID, parsed, ocrOutcome); err != nil {
+			logger.Warn("Failed to store parse result", "file_id", fileUploadID, "error", err.Error())
+		}
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response)
 }
 
-// GetFile retrieves file upload information
+// GetFile retrieves file upload information. Private files are only visible to
+// their uploader and users they are shared with; every view is logged.
 func (h *Handler) GetFile(w http.ResponseWriter, r *http.Request) {
-	fileID := r.URL.Query().Get("id")
+	companyID := middleware.GetCompanyID(r.Context())
+	userID := middleware.GetUserID(r.Context())
+	fileID := r.PathValue("id")
 	if fileID == "" {
 		h.respondError(w, errors.NewValidationError("file id is required", ""), r)
 		return
 	}
 
-	// TODO: Implement file retrieval from database
-	// For now, return error
-	h.respondError(w, fmt.Errorf("file not found"), r)
+	f, err := h.accessibleFile(r.Context(), fileID, companyID, userID)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("File"), r)
+		return
+	}
+	h.logFileAccess(r.Context(), f.ID, userID, "view", "")
+
+	h.respondJSON(w, r, http.StatusOK, f)
+}
+
+// storageRegionForCompany resolves where a company's files must be stored.
+// A partner-level region overrides the deployment default.
+func (h *Handler) storageRegionForCompany(ctx context.Context, companyID string) string {
+	var region string
+	h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(p.storage_region, '')
+		FROM companies c
+		JOIN partners p ON p.id = c.partner_id
+		WHERE c.id = $1
+	`, companyID).Scan(&region)
+
+	if storage.IsValidRegion(region) {
+		return region
+	}
+	return h.files.DefaultRegion()
 }
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadFileRejectsLegacyXLS(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("file", "penjualan.xls")
+	part.Write([]byte{0xD0, 0xCF, 0x11, 0xE0}) // OLE2 header of binary .xls files
+	mw.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/files/upload", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	(&Handler{}).UploadFile(w, r)
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), ".xlsx") {
+		t.Errorf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/coldstart"
+	"github.com/bantuaku/backend/services/dedupe"
+	"github.com/bantuaku/backend/services/embeddings"
+)
+
+// maxAnalogCandidates bounds the candidates compared per source
+const maxAnalogCandidates = 200
+
+// AnalogForecastResponse is a provisional forecast for a product without
+// enough sales history, based on similar products
+type AnalogForecastResponse struct {
+	ProductID     string             `json:"product_id"`
+	ProductName   string             `json:"product_name"`
+	Unit          string             `json:"unit"`
+	Method        string             `json:"method"` // analog
+	Provisional   bool               `json:"provisional"`
+	LowConfidence bool               `json:"low_confidence"`
+	Forecast30d   int                `json:"forecast_30d"`
+	Forecast60d   int                `json:"forecast_60d"`
+	Forecast90d   int                `json:"forecast_90d"`
+	DailyRate     float64            `json:"daily_rate"`
+	Confidence    float64            `json:"confidence"`
+	Analogs       []coldstart.Analog `json:"analogs"`
+	GeneratedAt   time.Time          `json:"generated_at"`
+}
+
+// GetAnalogForecast forecasts a product with too little sales history from
+// similar products: same category and price band and a similar name, taken
+// from the company's own catalog and, anonymized, from other companies in its
+// industry. The result is provisional and always flagged low-confidence.
+func (h *Handler) GetAnalogForecast(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	productID := r.PathValue("product_id")
+
+	target := coldstart.Product{ID: productID, CompanyID: companyID}
+	var unit string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT name, COALESCE(category, ''), COALESCE(unit_price, 0)::float8, COALESCE(unit, 'pcs')
+		FROM products WHERE id = $1 AND company_id = $2 AND merged_into IS NULL
+	`, productID, companyID).Scan(&target.Name, &target.Category, &target.Price, &unit)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	from := localDate(time.Now(), h.companyLocation(ctx, companyID)).AddDate(0, 0, -forecastWindowDays)
+	var salesDays int
+	err = h.db.Pool().QueryRow(ctx, `
+		SELECT COUNT(DISTINCT sale_date) FROM sales_history
+		WHERE product_id = $1 AND company_id = $2 AND sale_date >= $3 AND NOT COALESCE(excluded, false)
+	`, productID, companyID, from).Scan(&salesDays)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count sales days"), r)
+		return
+	}
+	if salesDays >= coldstart.MinSalesDays {
+		h.respondError(w, errors.NewBusinessRuleError("has_history",
+			"Produk ini sudah punya cukup data penjualan; gunakan GET /api/v1/forecasts/{product_id}."), r)
+		return
+	}
+
+	candidates, err := h.analogCandidates(ctx, target, unit, from)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load analog products"), r)
+		return
+	}
+	if h.config.KolosalAPIKey != "" && len(candidates) > 0 {
+		if err := h.embedAnalogNames(ctx, &target, candidates); err != nil {
+			// Trigram comparison of the names still works
+			logger.Warn("Analog name embedding failed; comparing names lexically", "company_id", companyID, "error", err.Error())
+			target.Vector = nil
+			for i := range candidates {
+				candidates[i].Vector = nil
+			}
+		}
+	}
+
+	analogs := coldstart.Select(target, candidates)
+	if len(analogs) == 0 {
+		h.respondError(w, errors.NewBusinessRuleError("no_analogs",
+			"Belum ada produk serupa dengan data penjualan yang cukup untuk membuat prakiraan sementara. Catat penjualan produk ini selama beberapa hari."), r)
+		return
+	}
+	daily, confidence := coldstart.Forecast(analogs)
+
+	h.respondJSON(w, r, http.StatusOK, AnalogForecastResponse{
+		ProductID:     productID,
+		ProductName:   target.Name,
+		Unit:          unit,
+		Method:        "analog",
+		Provisional:   true,
+		LowConfidence: true,
+		Forecast30d:   int(math.Round(daily * 30)),
+		Forecast60d:   int(math.Round(daily * 60)),
+		Forecast90d:   int(math.Round(daily * 90)),
+		DailyRate:     math.Round(daily*100) / 100,
+		Confidence:    confidence,
+		Analogs:       analogs,
+		GeneratedAt:   time.Now(),
+	})
+}
+
+// analogCandidates loads products sold in the same unit with enough history
+// since from: the company's own, and those of other active companies in the
+// same industry. Industry candidates must share the target's category when it
+// has one.
+func (h *Handler) analogCandidates(ctx context.Context, target coldstart.Product, unit string, from time.Time) ([]coldstart.Product, error) {
+	queries := []struct {
+		source string
+		where  string
+		arg    string // $4
+	}{
+		{coldstart.SourceCompany, `
+			WHERE p.company_id = $1 AND p.id <> $4`, target.ID},
+		{coldstart.SourceIndustry, `
+			JOIN companies c ON c.id = p.company_id
+			JOIN companies me ON me.id = $1
+			WHERE p.company_id <> $1
+				AND COALESCE(c.status, 'active') = 'active' AND c.merged_into IS NULL
+				AND (c.industry_kbli = me.industry_kbli
+					OR (me.industry_kbli IS NULL AND LOWER(TRIM(c.industry)) = LOWER(TRIM(me.industry))))
+				AND ($4 = '' OR LOWER(TRIM(p.category)) = LOWER(TRIM($4)))`, target.Category},
+	}
+
+	var candidates []coldstart.Product
+	for _, q := range queries {
+		rows, err := h.db.Pool().Query(ctx, fmt.Sprintf(`
+			SELECT p.id, p.company_id, p.name, COALESCE(p.category, ''), COALESCE(p.unit_price, 0)::float8,
+				(SUM(s.quantity) / COUNT(DISTINCT s.sale_date))::float8
+			FROM products p
+			JOIN sales_history s ON s.product_id = p.id AND s.sale_date >= $2 AND NOT COALESCE(s.excluded, false)
+			%s
+				AND p.merged_into IS NULL AND COALESCE(p.unit, 'pcs') = $3
+			GROUP BY p.id
+			HAVING COUNT(DISTINCT s.sale_date) >= %d
+			ORDER BY COUNT(DISTINCT s.sale_date) DESC
+			LIMIT %d
+		`, q.where, coldstart.MinSalesDays, maxAnalogCandidates), target.CompanyID, from, unit, q.arg)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			p := coldstart.Product{Source: q.source}
+			if err := rows.Scan(&p.ID, &p.CompanyID, &p.Name, &p.Category, &p.Price, &p.DailyRate); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			candidates = append(candidates, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return candidates, nil
+}
+
+// embedAnalogNames sets the name embeddings of the target and its candidates,
+// reusing those cached for duplicate detection
+func (h *Handler) embedAnalogNames(ctx context.Context, target *coldstart.Product, candidates []coldstart.Product) error {
+	names := make([]string, 0, len(candidates)+1)
+	for _, p := range append([]coldstart.Product{*target}, candidates...) {
+		name := dedupe.NormalizeName(p.Name)
+		if name == "" {
+			name = strings.TrimSpace(p.Name)
+		}
+		names = append(names, name)
+	}
+	vectors, err := h.embedTexts(ctx, embeddings.NamespaceDuplicates, "analogs:"+target.CompanyID, names)
+	if err != nil {
+		return err
+	}
+	target.Vector = vectors[0]
+	for i := range candidates {
+		candidates[i].Vector = vectors[i+1]
+	}
+	return nil
+}
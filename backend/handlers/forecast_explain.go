@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/forecastexplain"
+	"github.com/bantuaku/backend/services/kolosal"
+)
+
+// forecastExplanationCacheTTL bounds how long an AI explanation is reused. The
+// key includes the numbers it explains, so new sales never hit a stale one.
+const forecastExplanationCacheTTL = 24 * time.Hour
+
+// forecastWindowDays is the sales history forecasts are built from
+const forecastWindowDays = 90
+
+// ForecastExplanation is the breakdown of a product's forecast
+type ForecastExplanation struct {
+	ProductID         string                          `json:"product_id"`
+	ProductName       string                          `json:"product_name"`
+	Unit              string                          `json:"unit"`
+	Forecast30d       int                             `json:"forecast_30d"`
+	Components        forecastexplain.Components      `json:"components"`
+	Seasonality       []forecastexplain.WeekdayFactor `json:"seasonality"`
+	Adjustments       []forecastexplain.Adjustment    `json:"adjustments"`
+	Facts             []string                        `json:"facts"`
+	Explanation       string                          `json:"explanation"`
+	ExplanationSource string                          `json:"explanation_source"` // ai, cached, template
+}
+
+// ExplainForecast breaks a product's forecast down into the numbers behind it
+// (recent average, smoothed level, trend, weekly pattern and the user's data
+// corrections) with a plain-Indonesian explanation grounded in them
+func (h *Handler) ExplainForecast(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	productID := r.PathValue("product_id")
+
+	var productName, unit string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT name, COALESCE(unit, 'pcs') FROM products WHERE id = $1 AND company_id = $2
+	`, productID, companyID).Scan(&productName, &unit)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	// The same history GetForecast builds the forecast from
+	now := time.Now().In(h.companyLocation(ctx, companyID))
+	today := localDate(now, now.Location())
+	from := today.AddDate(0, 0, -forecastWindowDays)
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT sale_date, SUM(quantity)::float8
+		FROM sales_history
+		WHERE product_id = $1 AND company_id = $2 AND sale_date >= $3 AND NOT COALESCE(excluded, false)
+		GROUP BY sale_date
+		ORDER BY sale_date ASC
+	`, productID, companyID, from)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sales history"), r)
+		return
+	}
+	var salesData []float64
+	var points []forecastexplain.Point
+	for rows.Next() {
+		var p forecastexplain.Point
+		if rows.Scan(&p.Date, &p.Quantity) == nil {
+			salesData = append(salesData, p.Quantity)
+			points = append(points, p)
+		}
+	}
+	rows.Close()
+
+	adjustments, err := h.forecastAdjustments(ctx, companyID, productID, from)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sales corrections"), r)
+		return
+	}
+
+	components := forecastComponents(salesData)
+	components.WindowDays = forecastWindowDays
+	e := forecastexplain.Explanation{
+		ProductName: productName,
+		Unit:        unit,
+		Forecast30d: int(math.Round(components.DailyRate * 30)),
+		Components:  components,
+		Weekdays:    forecastexplain.WeekdayFactors(points, from, today.AddDate(0, 0, -1)),
+		Adjustments: adjustments,
+	}
+	explanation, source := h.forecastExplanationText(ctx, companyID, productID, e)
+
+	resp := ForecastExplanation{
+		ProductID:         productID,
+		ProductName:       productName,
+		Unit:              unit,
+		Forecast30d:       e.Forecast30d,
+		Components:        components,
+		Seasonality:       e.Weekdays,
+		Adjustments:       adjustments,
+		Facts:             e.Facts(),
+		Explanation:       explanation,
+		ExplanationSource: source,
+	}
+	if resp.Seasonality == nil {
+		resp.Seasonality = []forecastexplain.WeekdayFactor{}
+	}
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// forecastComponents computes the parts of forecastDailyRate's estimate
+func forecastComponents(salesData []float64) forecastexplain.Components {
+	daily, _, algorithm := forecastDailyRate(salesData)
+	c := forecastexplain.Components{Algorithm: algorithm, DailyRate: daily, DaysWithSales: len(salesData)}
+	if len(salesData) > 0 {
+		sum := 0.0
+		for _, v := range salesData {
+			sum += v
+		}
+		c.WindowAverage = sum / float64(len(salesData))
+	}
+	if algorithm == "ensemble" {
+		c.RecentAverage = simpleMovingAverage(salesData, 7)
+		c.SmoothedLevel = exponentialSmoothing(salesData, 0.3)
+		c.TrendPerDay = trendSlope(salesData)
+		c.TrendProjection = trendExtraction(salesData)
+	}
+	return c
+}
+
+// forecastAdjustments lists the product's corrected and excluded sales records
+// since from
+func (h *Handler) forecastAdjustments(ctx context.Context, companyID, productID string, from time.Time) ([]forecastexplain.Adjustment, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT review_status, sale_date, quantity::float8, COALESCE(original_quantity, 0)::float8
+		FROM sales_history
+		WHERE product_id = $1 AND company_id = $2 AND sale_date >= $3 AND review_status IN ('corrected', 'excluded')
+		ORDER BY sale_date DESC
+	`, productID, companyID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	adjustments := []forecastexplain.Adjustment{}
+	for rows.Next() {
+		var a forecastexplain.Adjustment
+		if err := rows.Scan(&a.Kind, &a.Date, &a.Quantity, &a.OriginalQuantity); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, a)
+	}
+	return adjustments, rows.Err()
+}
+
+// forecastExplanationText asks the AI to explain the forecast from its facts,
+// falling back to the facts themselves when AI is unavailable
+func (h *Handler) forecastExplanationText(ctx context.Context, companyID, productID string, e forecastexplain.Explanation) (string, string) {
+	if h.config.KolosalAPIKey == "" {
+		return e.Template(), "template"
+	}
+
+	prompt := e.Prompt()
+	sum := sha256.Sum256([]byte(prompt))
+	cacheKey := fmt.Sprintf("forecast_explain:%s:%s", productID, hex.EncodeToString(sum[:8]))
+	if h.redis != nil {
+		if cached, err := h.redis.Get(ctx, cacheKey); err == nil && cached != "" {
+			var text string
+			if json.Unmarshal([]byte(cached), &text) == nil {
+				return text, "cached"
+			}
+		}
+	}
+
+	resp, err := h.chatCompletionClient(h.kolosalClient()).CreateChatCompletion(aispend.WithFeature(ctx, aispend.FeatureForecastExplanation, productID), kolosal.ChatCompletionRequest{
+		Model: "default",
+		Messages: []kolosal.ChatCompletionMessage{
+			{Role: "system", Content: "Kamu analis penjualan yang menjelaskan angka kepada pemilik UMKM Indonesia dengan jujur dan sederhana."},
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   400,
+		Temperature: 0.2,
+	})
+	if err != nil || len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		if err != nil {
+			logger.Warn("Forecast explanation failed", "company_id", companyID, "product_id", productID, "error", err.Error())
+		}
+		return e.Template(), "template"
+	}
+
+	text := strings.TrimSpace(resp.Choices[0].Message.Content)
+	if h.redis != nil {
+		if data, err := json.Marshal(text); err == nil {
+			h.redis.Set(ctx, cacheKey, string(data), forecastExplanationCacheTTL)
+		}
+	}
+	return text, "ai"
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/forecasting"
+	"github.com/bantuaku/backend/services/quality"
+)
+
+// ForecastSeriesResponse is a product's forecast split into days, weeks or
+// 30-day periods
+type ForecastSeriesResponse struct {
+	ProductID   string               `json:"product_id"`
+	ProductName string               `json:"product_name"`
+	Granularity string               `json:"granularity"`
+	Model       string               `json:"model"`
+	DailyRate   float64              `json:"daily_rate"`
+	Confidence  float64              `json:"confidence"`
+	Periods     []forecasting.Period `json:"periods"`
+	Total       float64              `json:"total"`
+	DataQuality *quality.Report      `json:"data_quality,omitempty"`
+	GeneratedAt time.Time            `json:"generated_at"`
+}
+
+// GetForecastSeries forecasts a product per ?granularity=: daily for the next 30
+// days, weekly for the next 13 weeks or monthly (the default) for the next
+// three 30-day periods. Daily and weekly forecasts need the pro plan.
+func (h *Handler) GetForecastSeries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	productID := r.PathValue("product_id")
+
+	granularity := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("granularity")))
+	if granularity == "" {
+		granularity = forecasting.GranularityMonthly
+	}
+	spec, ok := forecasting.Lookup(granularity)
+	if !ok {
+		h.respondError(w, errors.NewValidationError("Invalid granularity", "granularity must be one of: daily, weekly, monthly"), r)
+		return
+	}
+	if !spec.Allows(h.CompanyPlan(ctx, companyID)) {
+		h.respondError(w, errors.NewBusinessRuleError("pro_plan_required", granularity+" forecasts are available on the pro and enterprise plans"), r)
+		return
+	}
+
+	now := time.Now().In(h.companyLocation(ctx, companyID))
+	today := localDate(now, now.Location())
+	historyFrom := today.AddDate(0, 0, -max(spec.HistoryDays, forecastWindowDays))
+	all, products, err := h.loadDailySales(ctx, companyID, productID, historyFrom)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sales history"), r)
+		return
+	}
+	if len(products) == 0 {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+	points := all[productID]
+
+	// The rate and quality check use the same 90 days as GetForecast, so the
+	// periods add up to its totals when there is no weekly pattern or trend
+	windowFrom := today.AddDate(0, 0, -forecastWindowDays)
+	var window []quality.DailyPoint
+	var salesData []float64
+	for _, p := range points {
+		if !p.Date.Before(windowFrom) {
+			window = append(window, p)
+			salesData = append(salesData, p.Quantity)
+		}
+	}
+	dataQuality := quality.Assess(window, now, forecastWindowDays)
+	if dataQuality.Blocking() && r.URL.Query().Get("force") != "true" {
+		h.respondError(w, errors.NewBusinessRuleError("data_quality",
+			"Data penjualan belum cukup untuk membuat prediksi. Lihat GET /api/v1/sales/quality untuk saran perbaikan, atau GET /api/v1/forecasts/{product_id}/analog untuk prakiraan sementara dari produk serupa."), r)
+		return
+	}
+	daily, confidence, _ := forecastDailyRate(salesData)
+
+	var periods []forecasting.Period
+	switch spec.Granularity {
+	case forecasting.GranularityDaily:
+		periods = forecasting.Daily(points, daily, today.AddDate(0, 0, -spec.HistoryDays), today, spec.Periods)
+	case forecasting.GranularityWeekly:
+		periods = forecasting.Weekly(points, daily, today.AddDate(0, 0, -spec.HistoryDays), today, spec.Periods)
+	default:
+		periods = forecasting.Monthly(daily, today, spec.Periods)
+	}
+	total := 0.0
+	for _, p := range periods {
+		total += p.Quantity
+	}
+
+	h.respondJSON(w, r, http.StatusOK, ForecastSeriesResponse{
+		ProductID:   productID,
+		ProductName: products[0].name,
+		Granularity: spec.Granularity,
+		Model:       spec.Model,
+		DailyRate:   math.Round(daily*100) / 100,
+		Confidence:  confidence,
+		Periods:     periods,
+		Total:       math.Round(total*100) / 100,
+		DataQuality: &dataQuality,
+		GeneratedAt: time.Now(),
+	})
+}
@@ -5,24 +5,30 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"sort"
 	"time"
 
+	"github.com/bantuaku/backend/errors"
 	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/portfolio"
+	"github.com/bantuaku/backend/services/quality"
 	"github.com/google/uuid"
 )
 
 // ForecastResponse represents a forecast response with additional context
 type ForecastResponse struct {
 	models.Forecast
-	ProductName     string       `json:"product_name"`
-	HistoricalSales []DailySales `json:"historical_sales,omitempty"`
+	ProductName     string          `json:"product_name"`
+	Unit            string          `json:"unit"` // Forecast quantities are in this unit
+	HistoricalSales []DailySales    `json:"historical_sales,omitempty"`
+	DataQuality     *quality.Report `json:"data_quality,omitempty"`
 }
 
 // DailySales represents aggregated daily sales
 type DailySales struct {
-	Date     string `json:"date"`
-	Quantity int    `json:"quantity"`
+	Date     string  `json:"date"`
+	Quantity float64 `json:"quantity"`
 }
 
 // GetForecast returns the forecast for a specific product
@@ -31,7 +37,7 @@ func (h *Handler) GetForecast(w http.ResponseWriter, r *http.Request) {
 	productID := r.PathValue("product_id")
 
 	if productID == "" {
-		respondError(w, http.StatusBadRequest, "Product ID is required")
+		h.respondError(w, errors.NewValidationError("Product ID is required", ""), r)
 		return
 	}
 
@@ -41,92 +47,68 @@ func (h *Handler) GetForecast(w http.ResponseWriter, r *http.Request) {
 	if err == nil && cached != "" {
 		var forecast ForecastResponse
 		if json.Unmarshal([]byte(cached), &forecast) == nil {
-			respondJSON(w, http.StatusOK, forecast)
+			h.respondJSON(w, r, http.StatusOK, forecast)
 			return
 		}
 	}
 
 	// Verify product belongs to store
-	var productName string
+	var productName, productUnit string
 	err = h.db.Pool().QueryRow(r.Context(), `
-		SELECT product_name FROM products WHERE id = $1 AND store_id = $2
-	`, productID, storeID).Scan(&productName)
+		SELECT product_name, COALESCE(unit, 'pcs') FROM products WHERE id = $1 AND store_id = $2
+	`, productID, storeID).Scan(&productName, &productUnit)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "Product not found")
+		h.respondError(w, errors.NewAppError(errors.ErrCodeNotFound, "Product not found", ""), r)
 		return
 	}
 
-	// Get historical sales (last 90 days)
+	// Get historical sales (last 90 days, in the company's time zone)
+	now := time.Now().In(h.companyLocation(r.Context(), storeID))
 	rows, err := h.db.Pool().Query(r.Context(), `
 		SELECT sale_date, SUM(quantity) as total_qty
 		FROM sales_history
-		WHERE product_id = $1 AND store_id = $2 AND sale_date >= $3
+		WHERE product_id = $1 AND store_id = $2 AND sale_date >= $3 AND NOT COALESCE(excluded, false)
 		GROUP BY sale_date
 		ORDER BY sale_date ASC
-	`, productID, storeID, time.Now().AddDate(0, 0, -90))
+	`, productID, storeID, localDate(now, now.Location()).AddDate(0, 0, -90))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch sales history")
+		h.respondError(w, errors.NewInternalError(err, "Failed to fetch sales history"), r)
 		return
 	}
 	defer rows.Close()
 
 	var salesData []float64
 	var historicalSales []DailySales
+	var points []quality.DailyPoint
 	for rows.Next() {
 		var date time.Time
-		var qty int
+		var qty float64
 		if rows.Scan(&date, &qty) == nil {
-			salesData = append(salesData, float64(qty))
+			salesData = append(salesData, qty)
 			historicalSales = append(historicalSales, DailySales{
 				Date:     date.Format("2006-01-02"),
 				Quantity: qty,
 			})
+			points = append(points, quality.DailyPoint{Date: date, Quantity: qty})
 		}
 	}
 
-	// Calculate forecast
-	var forecast30d, forecast60d, forecast90d int
-	var confidence float64
-	algorithm := "ensemble"
-
-	if len(salesData) >= 7 {
-		// Simple Moving Average (7-day)
-		sma := simpleMovingAverage(salesData, 7)
-
-		// Exponential Smoothing
-		es := exponentialSmoothing(salesData, 0.3)
-
-		// Trend extraction
-		trend := trendExtraction(salesData)
-
-		// Ensemble prediction
-		predicted := sma*0.4 + es*0.35 + trend*0.25
+	// Refuse to forecast from series that are too sparse unless explicitly forced
+	dataQuality := quality.Assess(points, now, 90)
+	if dataQuality.Blocking() && r.URL.Query().Get("force") != "true" {
+		h.respondError(w, errors.NewBusinessRuleError("data_quality",
+			"Data penjualan belum cukup untuk membuat prediksi. Lihat GET /api/v1/sales/quality untuk saran perbaikan, atau GET /api/v1/forecasts/{product_id}/analog untuk prakiraan sementara dari produk serupa."), r)
+		return
+	}
 
-		forecast30d = int(math.Round(predicted * 30))
-		forecast60d = int(math.Round(predicted * 60))
-		forecast90d = int(math.Round(predicted * 90))
+	// Calculate forecast
+	daily, confidence, algorithm := forecastDailyRate(salesData)
+	forecast30d := int(math.Round(daily * 30))
+	forecast60d := int(math.Round(daily * 60))
+	forecast90d := int(math.Round(daily * 90))
 
-		confidence = calculateConfidence(salesData, predicted)
-	} else if len(salesData) > 0 {
-		// Simple average for limited data
-		sum := 0.0
-		for _, v := range salesData {
-			sum += v
-		}
-		avg := sum / float64(len(salesData))
-
-		forecast30d = int(math.Round(avg * 30))
-		forecast60d = int(math.Round(avg * 60))
-		forecast90d = int(math.Round(avg * 90))
-		confidence = 0.5 // Lower confidence for limited data
-		algorithm = "simple_average"
-	} else {
-		// No data
-		forecast30d = 0
-		forecast60d = 0
-		forecast90d = 0
-		confidence = 0
-		algorithm = "no_data"
+	if algorithm != "no_data" {
+		h.recordForecastSnapshot(r.Context(), storeID, productID, localDate(now, now.Location()), float64(forecast30d), algorithm)
 	}
 
 	forecastResp := ForecastResponse{
@@ -142,55 +124,66 @@ func (h *Handler) GetForecast(w http.ResponseWriter, r *http.Request) {
 			ExpiresAt:   time.Now().Add(time.Hour),
 		},
 		ProductName:     productName,
+		Unit:            productUnit,
 		HistoricalSales: historicalSales,
+		DataQuality:     &dataQuality,
 	}
 
 	// Cache the result
 	cacheData, _ := json.Marshal(forecastResp)
 	h.redis.Set(r.Context(), cacheKey, string(cacheData), time.Hour)
 
-	respondJSON(w, http.StatusOK, forecastResp)
+	h.respondJSON(w, r, http.StatusOK, forecastResp)
 }
 
 // GetRecommendations returns demand forecast recommendations for all products
 func (h *Handler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
 	// Get all products with their sales data
 	rows, err := h.db.Pool().Query(r.Context(), `
-		SELECT p.id, p.product_name,
+		SELECT p.id, p.product_name, COALESCE(p.unit, 'pcs'),
 			COALESCE(SUM(s.quantity), 0) as total_sales,
 			COUNT(DISTINCT s.sale_date) as days_with_sales
 		FROM products p
 		LEFT JOIN sales_history s ON p.id = s.product_id 
-			AND s.sale_date >= $2
+			AND s.sale_date >= $2 AND NOT COALESCE(s.excluded, false)
 		WHERE p.store_id = $1
-		GROUP BY p.id, p.product_name
+		GROUP BY p.id, p.product_name, p.unit
 		ORDER BY total_sales DESC
-	`, storeID, time.Now().AddDate(0, 0, -30))
+	`, storeID, localDate(time.Now(), h.companyLocation(r.Context(), storeID)).AddDate(0, 0, -30))
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch recommendations")
+		h.respondError(w, errors.NewInternalError(err, "Failed to fetch recommendations"), r)
 		return
 	}
 	defer rows.Close()
 
+	// Focus products come first so owners see what matters most
+	classes := map[string]portfolio.Classification{}
+	if results, err := h.classifyPortfolio(r.Context(), storeID, defaultPortfolioWindowDays); err == nil {
+		for _, c := range results {
+			classes[c.ProductID] = c
+		}
+	}
+
 	recommendations := []models.Recommendation{}
 	for rows.Next() {
-		var productID, productName string
-		var totalSales, daysWithSales int
+		var productID, productName, unit string
+		var totalSales float64
+		var daysWithSales int
 
-		if rows.Scan(&productID, &productName, &totalSales, &daysWithSales) != nil {
+		if rows.Scan(&productID, &productName, &unit, &totalSales, &daysWithSales) != nil {
 			continue
 		}
 
 		// Calculate projected demand
 		avgDailySales := 0.0
 		if daysWithSales > 0 {
-			avgDailySales = float64(totalSales) / float64(daysWithSales)
+			avgDailySales = totalSales / float64(daysWithSales)
 		}
 
 		// Projected 30-day demand
@@ -203,13 +196,18 @@ func (h *Handler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
 			reason = "Tidak ada proyeksi permintaan berdasarkan data penjualan."
 		} else if projected30d < 10 {
 			riskLevel = "low"
-			reason = fmt.Sprintf("Proyeksi permintaan rendah: %d unit dalam 30 hari ke depan.", projected30d)
+			reason = fmt.Sprintf("Proyeksi permintaan rendah: %d %s dalam 30 hari ke depan.", projected30d, unit)
 		} else if projected30d < 50 {
 			riskLevel = "medium"
-			reason = fmt.Sprintf("Proyeksi permintaan sedang: %d unit dalam 30 hari ke depan.", projected30d)
+			reason = fmt.Sprintf("Proyeksi permintaan sedang: %d %s dalam 30 hari ke depan.", projected30d, unit)
 		} else {
 			riskLevel = "high"
-			reason = fmt.Sprintf("Proyeksi permintaan tinggi: %d unit dalam 30 hari ke depan. Pastikan ketersediaan produk.", projected30d)
+			reason = fmt.Sprintf("Proyeksi permintaan tinggi: %d %s dalam 30 hari ke depan. Pastikan ketersediaan produk.", projected30d, unit)
+		}
+
+		class := classes[productID]
+		if class.FocusProduct {
+			reason += fmt.Sprintf(" Produk fokus (kelas A): menyumbang %.0f%% omzet, prioritaskan ketersediaan dan promosinya.", class.RevenueShare)
 		}
 
 		recommendations = append(recommendations, models.Recommendation{
@@ -218,14 +216,47 @@ func (h *Handler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
 			ProjectedDemand: projected30d,
 			Reason:          reason,
 			RiskLevel:       riskLevel,
+			ABCClass:        class.Class,
+			FocusProduct:    class.FocusProduct,
 		})
 	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].FocusProduct && !recommendations[j].FocusProduct
+	})
 
-	respondJSON(w, http.StatusOK, recommendations)
+	h.respondJSON(w, r, http.StatusOK, recommendations)
 }
 
 // Forecasting helper functions
 
+// forecastDailyRate predicts units sold per day from daily sales, returning the
+// rate, a confidence score and the algorithm used
+func forecastDailyRate(salesData []float64) (float64, float64, string) {
+	if len(salesData) >= 7 {
+		// Simple Moving Average (7-day)
+		sma := simpleMovingAverage(salesData, 7)
+
+		// Exponential Smoothing
+		es := exponentialSmoothing(salesData, 0.3)
+
+		// Trend extraction
+		trend := trendExtraction(salesData)
+
+		// Ensemble prediction
+		predicted := sma*0.4 + es*0.35 + trend*0.25
+		return predicted, calculateConfidence(salesData, predicted), "ensemble"
+	}
+	if len(salesData) > 0 {
+		// Simple average for limited data
+		sum := 0.0
+		for _, v := range salesData {
+			sum += v
+		}
+		return sum / float64(len(salesData)), 0.5, "simple_average" // Lower confidence for limited data
+	}
+	return 0, 0, "no_data"
+}
+
 func simpleMovingAverage(data []float64, period int) float64 {
 	if len(data) < period {
 		period = len(data)
@@ -254,6 +285,17 @@ func exponentialSmoothing(data []float64, alpha float64) float64 {
 }
 
 func trendExtraction(data []float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	// Project forward (average + trend for 7 days)
+	lastValue := data[len(data)-1]
+	return lastValue + trendSlope(data)*7
+}
+
+// trendSlope is the least-squares change per data point
+func trendSlope(data []float64) float64 {
 	n := float64(len(data))
 	if n == 0 {
 		return 0
@@ -276,14 +318,10 @@ func trendExtraction(data []float64) float64 {
 		sumDenominator += x * x
 	}
 
-	trend := 0.0
-	if sumDenominator != 0 {
-		trend = sumNumerator / sumDenominator
+	if sumDenominator == 0 {
+		return 0
 	}
-
-	// Project forward (average + trend for 7 days)
-	lastValue := data[len(data)-1]
-	return lastValue + trend*7
+	return sumNumerator / sumDenominator
 }
 
 func calculateConfidence(data []float64, forecast float64) float64 {
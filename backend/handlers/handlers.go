@@ -1,63 +1,146 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/bantuaku/backend/config"
 	"github.com/bantuaku/backend/errors"
 	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/auditlog"
+	"github.com/bantuaku/backend/services/billing"
+	"github.com/bantuaku/backend/services/cachebus"
+	"github.com/bantuaku/backend/services/geocode"
+	"github.com/bantuaku/backend/services/jobwatch"
+	"github.com/bantuaku/backend/services/lifecycle"
+	"github.com/bantuaku/backend/services/mailer"
+	"github.com/bantuaku/backend/services/maintenance"
+	"github.com/bantuaku/backend/services/ratelimit"
+	"github.com/bantuaku/backend/services/settings"
+	"github.com/bantuaku/backend/services/slo"
 	"github.com/bantuaku/backend/services/storage"
+	"github.com/bantuaku/backend/services/workqueue"
 )
 
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
-	db     *storage.Postgres
-	redis  *storage.Redis
-	config *config.Config
+	db         *storage.Postgres
+	redis      *storage.Redis
+	files      *storage.FileStore
+	config     *config.Config
+	rateLimits *ratelimit.Registry
+	limiter    *ratelimit.Limiter
+	semaphore  *ratelimit.Semaphore
+	aiQueue    *workqueue.Queue
+	slo        *slo.Tracker
+	settings   *settings.Store
+	maint      *maintenance.Switch
+	bus        *cachebus.Bus
+	plans      *cachebus.Local    // Company plans, in front of the Redis cache
+	spend      *cachebus.Local    // AI spend status per company, in front of ai_spend
+	members    *cachebus.Local    // Company roles per user and company, in front of company_members
+	jobs       *jobwatch.Hub      // Clients following prediction job progress
+	lifecycle  *lifecycle.Manager // Background jobs, cancelled and awaited at shutdown
+	mailer     mailer.Mailer
+	audit      *auditlog.Sealer // Nil when audit payload capture is disabled
+	geocoder   *geocode.Client  // Nil when geocoding is disabled
+	billing    billing.Provider // Nil when self-serve billing is disabled
 }
 
-// New creates a new Handler with dependencies
-func New(db *storage.Postgres, redis *storage.Redis, cfg *config.Config) *Handler {
-	return &Handler{
-		db:     db,
-		redis:  redis,
-		config: cfg,
+// New creates a new Handler with dependencies. It fails when STORAGE_REGION
+// is unknown rather than pinning files to another region.
+func New(db *storage.Postgres, redis *storage.Redis, cfg *config.Config) (*Handler, error) {
+	files, err := storage.NewFileStore(cfg.StorageDir, cfg.StorageRegion)
+	if err != nil {
+		return nil, fmt.Errorf("STORAGE_REGION: %w", err)
 	}
+
+	h := &Handler{
+		db:         db,
+		redis:      redis,
+		files:      files,
+		config:     cfg,
+		rateLimits: ratelimit.NewRegistry(),
+		settings:   settings.NewStore(),
+		maint:      maintenance.NewSwitch(),
+		aiQueue:    workqueue.New(cfg.AIQueueWorkers, cfg.AIQueueDepth),
+		mailer: mailer.New(mailer.Config{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		}),
+	}
+	objectives, err := slo.ParseObjectives(cfg.SLOObjectives)
+	if err != nil {
+		logger.Warn("Invalid SLO objectives, SLO tracking disabled", "error", err.Error())
+	}
+	h.slo = slo.NewTracker(objectives, time.Duration(cfg.SLOWindowHours)*time.Hour)
+	if cfg.AuditEncryptionKey != "" {
+		h.audit, _ = auditlog.NewSealer(cfg.AuditEncryptionKey)
+	}
+	if cfg.GeocoderURL != "" {
+		h.geocoder = geocode.NewClient(cfg.GeocoderURL, "Bantuaku/1.0 (+"+cfg.APIURL+")")
+	}
+	switch cfg.BillingProvider {
+	case "midtrans":
+		h.billing = billing.NewMidtrans(cfg.MidtransServerKey, cfg.MidtransProduction)
+	}
+	if redis != nil {
+		h.limiter = ratelimit.NewLimiter(redis.Client(), h.rateLimits)
+		h.semaphore = ratelimit.NewSemaphore(redis.Client(), h.rateLimits)
+		h.bus = cachebus.New(redis.Client())
+	} else {
+		h.bus = cachebus.New(nil)
+	}
+	h.plans = cachebus.NewLocal(companyPlanLocalTTL)
+	h.spend = cachebus.NewLocal(aiSpendStatusTTL)
+	h.members = cachebus.NewLocal(companyRoleTTL)
+	h.jobs = jobwatch.NewHub()
+	h.lifecycle = lifecycle.New(context.Background())
+	h.bus.Subscribe(cachebus.KindSettings, func(ctx context.Context, _ []string) { h.ReloadSettings(ctx) })
+	h.bus.Subscribe(cachebus.KindRateLimits, func(ctx context.Context, _ []string) { h.ReloadRateLimits(ctx) })
+	h.bus.Subscribe(cachebus.KindPrediction, func(_ context.Context, jobIDs []string) { h.jobs.Notify(jobIDs...) })
+	h.bus.Subscribe(cachebus.KindCompanyPlan, func(_ context.Context, companyIDs []string) { h.plans.Delete(companyIDs...) })
+	h.bus.Subscribe(cachebus.KindMembership, func(_ context.Context, keys []string) { h.members.Delete(keys...) })
+	return h, nil
 }
 
 // HealthCheck returns the health status of the API
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	// Create contextual logger
-	log := logger.With("request_id", r.Context().Value("request_id"))
+	log := logger.With("request_id", r.Context().Value(middleware.RequestIDKey))
 
 	log.Info("Health check requested")
 
-	respondJSON(w, http.StatusOK, map[string]string{
+	h.respondJSON(w, r, http.StatusOK, map[string]string{
 		"status":  "ok",
 		"service": "bantuaku-api",
 	})
 }
 
-// respondJSON sends a JSON response
-func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if data != nil {
-		json.NewEncoder(w).Encode(data)
-	}
+// respondJSON sends a JSON response, enveloped when the client asked for it
+func (h *Handler) respondJSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	response.JSON(w, r, status, data)
 }
 
 // respondError sends an error response with proper logging
 func (h *Handler) respondError(w http.ResponseWriter, err error, r *http.Request) {
 	// Create contextual logger
-	log := logger.With("request_id", r.Context().Value("request_id"))
+	log := logger.With("request_id", r.Context().Value(middleware.RequestIDKey))
 
 	// Log the error
 	log.LogError(err, "Handler error", r.Context())
 
 	// Write JSON error response
-	errors.WriteJSONError(w, err, errors.GetErrorCode(err))
+	response.Error(w, r, err)
 }
 
 // parseJSON parses JSON request body with error handling
@@ -68,21 +151,24 @@ func (h *Handler) parseJSON(r *http.Request, v interface{}) error {
 	return nil
 }
 
-// Mock respondJSON 函数以保持向后兼容性
-func respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if data != nil {
-		json.NewEncoder(w).Encode(data)
-	}
-}
-
-// Mock respondError 函数以保持向后兼容性
-func respondError(w http.ResponseWriter, status int, message string) {
-	errors.WriteJSONError(w, errors.NewAppError(errors.ErrCodeInternal, message, ""), errors.ErrCodeInternal)
-}
+// Pagination defaults for list endpoints
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
 
-// Mock parseJSON 函数以保持向后兼容性
-func parseJSON(r *http.Request, v interface{}) error {
-	return json.NewDecoder(r.Body).Decode(v)
+// parsePage reads ?page= (1-based) and ?page_size= with defaults and bounds
+func (h *Handler) parsePage(r *http.Request) (page, pageSize int, err error) {
+	page, pageSize = 1, defaultPageSize
+	if s := r.URL.Query().Get("page"); s != "" {
+		if page, err = strconv.Atoi(s); err != nil || page < 1 {
+			return 0, 0, errors.NewValidationError("Invalid page", "page must be a positive integer")
+		}
+	}
+	if s := r.URL.Query().Get("page_size"); s != "" {
+		if pageSize, err = strconv.Atoi(s); err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return 0, 0, errors.NewValidationError("Invalid page_size", fmt.Sprintf("page_size must be between 1 and %d", maxPageSize))
+		}
+	}
+	return page, pageSize, nil
 }
@@ -72,10 +72,15 @@ func setupTestHandler(t *testing.T) (*Handler, *testDB) {
 	cfg := &config.Config{
 		JWTSecret:     "test-jwt-secret",
 		KolosalAPIKey: "",
-		CORSOrigin:    "http://localhost:3000",
+		CORSOrigins:   "http://localhost:3000",
+		StorageDir:    t.TempDir(),
+		StorageRegion: "id-jkt",
 	}
 
-	handler := New(db, redis, cfg)
+	handler, err := New(db, redis, cfg)
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
 
 	return handler, db
 }
@@ -326,7 +331,7 @@ func TestProductHandlers(t *testing.T) {
 	}
 
 	// Generate JWT token
-	token, err := handler.generateToken(userID, storeID)
+	token, err := handler.generateToken(userID, storeID, "user")
 	if err != nil {
 		t.Fatalf("Failed to generate test token: %v", err)
 	}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/health"
+	"github.com/bantuaku/backend/services/quality"
+)
+
+const (
+	defaultHealthHistoryDays = 30
+	// Forecasts younger than this have no full month of actuals to compare against
+	forecastAccuracyHorizonDays  = 30
+	forecastAccuracyLookbackDays = 120
+)
+
+// GetBusinessHealth returns the current health score and its history (?days=N, default 30)
+func (h *Handler) GetBusinessHealth(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	days := defaultHealthHistoryDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxQualityWindowDays {
+			h.respondError(w, errors.NewValidationError("Invalid days", "days must be between 1 and 365"), r)
+			return
+		}
+		days = n
+	}
+
+	bh, err := h.businessHealth(r.Context(), companyID, days)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "compute business health"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, bh)
+}
+
+// businessHealth computes today's score, records it and attaches the recent history
+func (h *Handler) businessHealth(ctx context.Context, companyID string, historyDays int) (*models.BusinessHealth, error) {
+	loc := h.companyLocation(ctx, companyID)
+	score, err := h.computeBusinessHealth(ctx, companyID, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	today := localDate(time.Now(), loc)
+	factors, _ := json.Marshal(score.Factors)
+	if _, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO business_health_scores (company_id, score_date, score, level, factors, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (company_id, score_date) DO UPDATE
+		SET score = EXCLUDED.score, level = EXCLUDED.level, factors = EXCLUDED.factors, created_at = NOW()
+	`, companyID, today, score.Score, score.Level, factors); err != nil {
+		logger.Warn("Failed to record business health", "company_id", companyID, "error", err.Error())
+	}
+
+	bh := &models.BusinessHealth{Score: score.Score, Level: score.Level, History: []models.HealthPoint{}}
+	for _, f := range score.Factors {
+		bh.Factors = append(bh.Factors, models.HealthFactor(f))
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT score_date, score, level FROM business_health_scores
+		WHERE company_id = $1 AND score_date > $2
+		ORDER BY score_date
+	`, companyID, today.AddDate(0, 0, -historyDays))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p models.HealthPoint
+		var date time.Time
+		if err := rows.Scan(&date, &p.Score, &p.Level); err != nil {
+			return nil, err
+		}
+		p.Date = date.Format("2006-01-02")
+		bh.History = append(bh.History, p)
+	}
+	return bh, rows.Err()
+}
+
+// computeBusinessHealth gathers the inputs for each health factor
+func (h *Handler) computeBusinessHealth(ctx context.Context, companyID string, loc *time.Location) (health.Score, error) {
+	var in health.Inputs
+	now := time.Now().In(loc)
+	today := localDate(now, loc)
+	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	// Compare month-to-date with the same number of days last month so early-month scores aren't skewed
+	elapsed := today.Sub(firstOfMonth) + 24*time.Hour
+	lastMonthStart := firstOfMonth.AddDate(0, -1, 0)
+	var thisMonth, lastMonth, costedRevenue, costedMargin float64
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(s.quantity * s.price) FILTER (WHERE s.sale_date >= $2), 0),
+			COALESCE(SUM(s.quantity * s.price) FILTER (WHERE s.sale_date >= $3 AND s.sale_date < $4), 0),
+			COALESCE(SUM(s.quantity * s.price) FILTER (WHERE s.sale_date >= $5 AND p.cost > 0), 0),
+			COALESCE(SUM(s.quantity * (s.price - p.cost)) FILTER (WHERE s.sale_date >= $5 AND p.cost > 0), 0)
+		FROM sales_history s
+		JOIN products p ON p.id = s.product_id
+		WHERE s.company_id = $1 AND s.sale_date >= LEAST($3, $5) AND NOT COALESCE(s.excluded, false)
+	`, companyID, firstOfMonth, lastMonthStart, lastMonthStart.Add(elapsed), today.AddDate(0, 0, -defaultPortfolioWindowDays)).Scan(
+		&thisMonth, &lastMonth, &costedRevenue, &costedMargin)
+	if err != nil {
+		return health.Score{}, err
+	}
+	if lastMonth > 0 {
+		trend := (thisMonth - lastMonth) / lastMonth * 100
+		in.RevenueTrend = &trend
+	}
+	if costedRevenue > 0 {
+		margin := costedMargin / costedRevenue * 100
+		in.GrossMargin = &margin
+	}
+
+	points, products, err := h.loadDailySales(ctx, companyID, "", today.AddDate(0, 0, -defaultQualityWindowDays))
+	if err != nil {
+		return health.Score{}, err
+	}
+	for _, p := range products {
+		in.QualityScores = append(in.QualityScores, quality.Assess(points[p.id], now, defaultQualityWindowDays).Score)
+	}
+
+	if results, err := h.classifyPortfolio(ctx, companyID, defaultPortfolioWindowDays); err == nil {
+		for _, c := range results {
+			in.RevenueByProduct = append(in.RevenueByProduct, c.Revenue)
+		}
+	}
+
+	in.ForecastError = h.forecastError(ctx, companyID, today)
+
+	return health.Compute(in), nil
+}
+
+// forecastError returns the mean absolute percentage error of 30-day forecasts made
+// 30-120 days ago against what actually sold, or nil when there is nothing to compare
+func (h *Handler) forecastError(ctx context.Context, companyID string, today time.Time) *float64 {
+	var mape *float64
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT AVG(LEAST(ABS(actual - f.forecast_30d) / actual * 100, 100))
+		FROM forecast_snapshots f
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(s.quantity), 0) AS actual
+			FROM sales_history s
+			WHERE s.product_id = f.product_id AND s.sale_date >= f.forecast_date
+				AND s.sale_date < f.forecast_date + $3::int AND NOT COALESCE(s.excluded, false)
+		) a
+		WHERE f.company_id = $1 AND f.forecast_date <= $2::date - $3::int AND f.forecast_date > $2::date - $4::int
+			AND actual > 0
+	`, companyID, today, forecastAccuracyHorizonDays, forecastAccuracyLookbackDays).Scan(&mape)
+	if err != nil {
+		return nil
+	}
+	return mape
+}
+
+// recordForecastSnapshot keeps one 30-day forecast per product per day for accuracy tracking
+func (h *Handler) recordForecastSnapshot(ctx context.Context, companyID, productID string, day time.Time, forecast30d float64, algorithm string) {
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO forecast_snapshots (product_id, company_id, forecast_date, forecast_30d, algorithm, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (product_id, forecast_date) DO UPDATE
+		SET forecast_30d = EXCLUDED.forecast_30d, algorithm = EXCLUDED.algorithm
+	`, productID, companyID, day, forecast30d, algorithm)
+	if err != nil {
+		logger.Warn("Failed to record forecast snapshot", "product_id", productID, "error", err.Error())
+	}
+}
+
+// healthPromptContext tells the assistant which weak areas to prioritize
+func healthPromptContext(s health.Score) string {
+	if s.Level == health.LevelNoData {
+		return ""
+	}
+
+	var weak []string
+	for _, f := range s.Weakest(2) {
+		if f.Score < 70 {
+			weak = append(weak, fmt.Sprintf("%s (%d/100: %s)", f.Label, f.Score, f.Detail))
+		}
+	}
+	line := fmt.Sprintf("Skor kesehatan bisnis: %d/100 (%s).", s.Score, s.Level)
+	if len(weak) > 0 {
+		line += " Faktor terlemah, prioritaskan saran untuk ini: " + strings.Join(weak, "; ")
+	}
+	return line
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/importer"
+	"github.com/bantuaku/backend/services/kolosal"
+
+	"github.com/google/uuid"
+)
+
+// maxImportSourceLength matches import_column_mappings.source
+const maxImportSourceLength = 64
+
+// SuggestImportMappingResponse proposes how a spreadsheet's columns map to the import fields
+type SuggestImportMappingResponse struct {
+	Source      string              `json:"source,omitempty"`
+	Headers     []string            `json:"headers"`
+	Samples     [][]string          `json:"samples"`
+	Mapping     map[string]string   `json:"mapping"`
+	SuggestedBy string              `json:"suggested_by"` // "saved", "ai" or "rules"
+	Valid       bool                `json:"valid"`        // Mapping covers every required field
+	Fields      []importer.FieldDoc `json:"fields"`
+}
+
+// SaveImportMappingRequest confirms a column mapping for a source
+type SaveImportMappingRequest struct {
+	Mapping map[string]string `json:"mapping"`
+	Headers []string          `json:"headers"`
+}
+
+// validImportSource trims a source name and reports whether it is usable
+func validImportSource(source string) (string, bool) {
+	source = strings.TrimSpace(source)
+	return source, source != "" && len(source) <= maxImportSourceLength && source != importer.CustomAdapterName
+}
+
+// loadImportMapping loads the saved column mapping of a source
+func (h *Handler) loadImportMapping(ctx context.Context, companyID, source string) (models.ImportColumnMapping, error) {
+	m := models.ImportColumnMapping{CompanyID: companyID}
+	var mapping, headers []byte
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, source, mapping, headers, COALESCE(created_by, ''), created_at, updated_at
+		FROM import_column_mappings
+		WHERE company_id = $1 AND source = $2
+	`, companyID, strings.TrimSpace(source)).Scan(&m.ID, &m.Source, &mapping, &headers, &m.CreatedBy, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return m, err
+	}
+	json.Unmarshal(mapping, &m.Mapping)
+	json.Unmarshal(headers, &m.Headers)
+	return m, nil
+}
+
+// SuggestImportMapping reads the header and a few rows of an uploaded spreadsheet
+// ("file" or "file_id") and proposes a column mapping. A mapping saved for the
+// "source" form value is reused when it still fits the file; otherwise the AI
+// provider is asked, falling back to matching known column names.
+func (h *Handler) SuggestImportMapping(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxFileSize); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid multipart form", err.Error()), r)
+		return
+	}
+	rows, err := h.readImportTable(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	header := rows[0]
+	samples := [][]string{}
+	for _, row := range rows[1:] {
+		if len(samples) >= importer.MaxMappingSamples {
+			break
+		}
+		if strings.TrimSpace(strings.Join(row, "")) != "" {
+			samples = append(samples, row)
+		}
+	}
+
+	resp := SuggestImportMappingResponse{
+		Source:  strings.TrimSpace(r.FormValue("source")),
+		Headers: header,
+		Samples: samples,
+		Fields:  importer.MappingFields,
+	}
+
+	if resp.Source != "" {
+		saved, err := h.loadImportMapping(r.Context(), companyID, resp.Source)
+		if err == nil && importer.ValidateMapping(saved.Mapping, header) == nil {
+			resp.Mapping, resp.SuggestedBy, resp.Valid = saved.Mapping, "saved", true
+			h.respondJSON(w, r, http.StatusOK, resp)
+			return
+		}
+	}
+
+	resp.Mapping, resp.SuggestedBy = importer.SuggestMapping(header, samples), "rules"
+	if h.config.KolosalAPIKey != "" {
+		client := h.kolosalClient()
+		completion, err := client.CreateChatCompletion(aispend.WithFeature(r.Context(), aispend.FeatureImportMapping, ""), kolosal.ChatCompletionRequest{
+			Model: "default",
+			Messages: []kolosal.ChatCompletionMessage{
+				{Role: "system", Content: "Kamu membantu UMKM Indonesia mengimpor data penjualan dari spreadsheet."},
+				{Role: "user", Content: importer.BuildMappingPrompt(header, samples)},
+			},
+			MaxTokens:   400,
+			Temperature: 0.1,
+		})
+		if err == nil && len(completion.Choices) > 0 {
+			if mapping, perr := importer.ParseMapping(completion.Choices[0].Message.Content, header); perr == nil {
+				resp.Mapping, resp.SuggestedBy = mapping, "ai"
+			} else {
+				logger.Warn("Falling back to rule-based column mapping", "company_id", companyID, "error", perr.Error())
+			}
+		} else if err != nil {
+			logger.Warn("Falling back to rule-based column mapping", "company_id", companyID, "error", err.Error())
+		}
+	}
+	resp.Valid = importer.ValidateMapping(resp.Mapping, header) == nil
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// ListImportMappings returns the company's saved column mappings
+func (h *Handler) ListImportMappings(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT id, source, mapping, headers, COALESCE(created_by, ''), created_at, updated_at
+		FROM import_column_mappings
+		WHERE company_id = $1
+		ORDER BY source
+	`, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list column mappings"), r)
+		return
+	}
+	defer rows.Close()
+
+	mappings := []models.ImportColumnMapping{}
+	for rows.Next() {
+		m := models.ImportColumnMapping{CompanyID: companyID}
+		var mapping, headers []byte
+		if rows.Scan(&m.ID, &m.Source, &mapping, &headers, &m.CreatedBy, &m.CreatedAt, &m.UpdatedAt) == nil {
+			json.Unmarshal(mapping, &m.Mapping)
+			json.Unmarshal(headers, &m.Headers)
+			mappings = append(mappings, m)
+		}
+	}
+
+	h.respondJSON(w, r, http.StatusOK, mappings)
+}
+
+// SaveImportMapping confirms a column mapping for a source, replacing any saved one.
+// Files from the source can then be imported with the "custom" adapter.
+func (h *Handler) SaveImportMapping(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	source, ok := validImportSource(r.PathValue("source"))
+	if !ok {
+		h.respondError(w, errors.NewValidationError("Invalid source name", "use 1-64 characters other than \"custom\""), r)
+		return
+	}
+
+	var req SaveImportMappingRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	mapping := map[string]string{}
+	for field, column := range req.Mapping {
+		if column = strings.TrimSpace(column); column != "" {
+			mapping[field] = column
+		}
+	}
+	if err := importer.ValidateMapping(mapping, req.Headers); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid column mapping", err.Error()), r)
+		return
+	}
+	if req.Headers == nil {
+		req.Headers = []string{}
+	}
+
+	mappingJSON, _ := json.Marshal(mapping)
+	headersJSON, _ := json.Marshal(req.Headers)
+	m := models.ImportColumnMapping{CompanyID: companyID, Source: source, Mapping: mapping, Headers: req.Headers}
+	err := h.db.Pool().QueryRow(r.Context(), `
+		INSERT INTO import_column_mappings (id, company_id, source, mapping, headers, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NOW(), NOW())
+		ON CONFLICT (company_id, source) DO UPDATE SET
+			mapping = EXCLUDED.mapping, headers = EXCLUDED.headers, updated_at = NOW()
+		RETURNING id, COALESCE(created_by, ''), created_at, updated_at
+	`, uuid.New().String(), companyID, source, mappingJSON, headersJSON, middleware.GetUserID(r.Context())).
+		Scan(&m.ID, &m.CreatedBy, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save column mapping"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, m)
+}
+
+// DeleteImportMapping removes a saved column mapping
+func (h *Handler) DeleteImportMapping(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	tag, err := h.db.Pool().Exec(r.Context(), `
+		DELETE FROM import_column_mappings WHERE company_id = $1 AND source = $2
+	`, companyID, strings.TrimSpace(r.PathValue("source")))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete column mapping"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Column mapping"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "Column mapping deleted"})
+}
@@ -0,0 +1,480 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/docparse"
+	"github.com/bantuaku/backend/services/importer"
+	"github.com/bantuaku/backend/services/units"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const importPreviewRows = 20
+
+// ImportPreviewResponse shows how an export file will be imported without writing anything
+type ImportPreviewResponse struct {
+	Adapter     string              `json:"adapter"`
+	Mapping     map[string]string   `json:"mapping"`
+	TotalRows   int                 `json:"total_rows"`
+	SampleRows  []importer.Row      `json:"sample_rows"`
+	NewProducts []string            `json:"new_products"`
+	Errors      []importer.RowError `json:"errors"`
+}
+
+// ImportResultResponse summarizes a completed import. In a dry run nothing is
+// saved and the counts are what the import would have done.
+type ImportResultResponse struct {
+	Adapter         string              `json:"adapter"`
+	DryRun          bool                `json:"dry_run"`
+	Mapping         map[string]string   `json:"mapping"`
+	SalesImported   int                 `json:"sales_imported"`
+	ProductsCreated int                 `json:"products_created"`
+	Skipped         int                 `json:"skipped"`
+	Errors          []importer.RowError `json:"errors"` // Rejected rows, in file order
+}
+
+// importOptions control how parsed rows are saved
+type importOptions struct {
+	source         string // sales_history.source of the imported sales
+	createProducts bool   // Create products missing from the catalog instead of rejecting their rows
+	dryRun         bool   // Roll everything back instead of committing
+}
+
+// ListImportAdapters returns the supported export formats and their column mappings
+func (h *Handler) ListImportAdapters(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, r, http.StatusOK, importer.Adapters())
+}
+
+// PreviewImport parses an uploaded export and reports what would be imported
+func (h *Handler) PreviewImport(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	adapter, result, ok := h.parseImportUpload(w, r, r.PathValue("adapter"))
+	if !ok {
+		return
+	}
+
+	products, err := h.loadImportProducts(r.Context(), h.db.Pool(), companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load products"), r)
+		return
+	}
+
+	resp := ImportPreviewResponse{
+		Adapter:     adapter.Name,
+		Mapping:     result.Mapping,
+		TotalRows:   len(result.Rows),
+		SampleRows:  result.Rows,
+		NewProducts: []string{},
+		Errors:      result.Errors,
+	}
+	if len(resp.SampleRows) > importPreviewRows {
+		resp.SampleRows = resp.SampleRows[:importPreviewRows]
+	}
+
+	seen := map[string]bool{}
+	unitCache := map[string]importProductUnits{}
+	for _, row := range result.Rows {
+		productID := products.match(row)
+		if _, err := h.importSale(r.Context(), h.db.Pool(), unitCache, productID, row); err != nil {
+			resp.Errors = append(resp.Errors, importer.RowError{Line: row.Line, Column: result.Mapping[importer.FieldUnit], Value: row.Unit, Error: err.Error()})
+			continue
+		}
+		if productID != "" {
+			continue
+		}
+		key := strings.ToLower(row.ProductName)
+		if !seen[key] {
+			seen[key] = true
+			resp.NewProducts = append(resp.NewProducts, row.ProductName)
+		}
+	}
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// RunImport imports sales (and missing products) from an uploaded export file
+func (h *Handler) RunImport(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	adapter, result, ok := h.parseImportUpload(w, r, r.PathValue("adapter"))
+	if !ok {
+		return
+	}
+	if len(result.Rows) == 0 {
+		h.respondError(w, errors.NewValidationError("No valid rows found in file", fmt.Sprintf("%d rows rejected", len(result.Errors))), r)
+		return
+	}
+
+	resp, err := h.importRows(r.Context(), companyID, adapter, result, importOptions{
+		source:         adapter.Name,
+		createProducts: true,
+		dryRun:         isDryRun(r),
+	})
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// importRows saves parsed rows as sales in one transaction. Rows whose unit
+// does not fit their product, or, without createProducts, whose product is not
+// in the catalog, are reported as errors and skipped. A dry run does all the
+// same work and rolls it back, so its report matches what a real run would do.
+func (h *Handler) importRows(ctx context.Context, companyID string, adapter importer.Adapter, result *importer.Result, opts importOptions) (*ImportResultResponse, error) {
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	products, err := h.loadImportProducts(ctx, tx, companyID)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load products")
+	}
+
+	resp := &ImportResultResponse{Adapter: adapter.Name, DryRun: opts.dryRun, Mapping: result.Mapping, Errors: result.Errors}
+	touched := map[string]bool{}
+	unitCache := map[string]importProductUnits{}
+	now := time.Now()
+
+	for _, row := range result.Rows {
+		productID := products.match(row)
+		if productID == "" && !opts.createProducts {
+			resp.Errors = append(resp.Errors, importer.RowError{Line: row.Line, Column: result.Mapping[importer.FieldProductName], Value: row.ProductName, Error: "Produk tidak ditemukan di katalog"})
+			continue
+		}
+		sale, err := h.importSale(ctx, tx, unitCache, productID, row)
+		if err != nil {
+			resp.Errors = append(resp.Errors, importer.RowError{Line: row.Line, Column: result.Mapping[importer.FieldUnit], Value: row.Unit, Error: err.Error()})
+			continue
+		}
+
+		if productID == "" {
+			productID = uuid.New().String()
+			_, err = tx.Exec(ctx, `
+				INSERT INTO products (id, company_id, name, sku, category, unit_price, unit, created_at, updated_at)
+				VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $8)
+			`, productID, companyID, row.ProductName, row.SKU, row.Category, sale.Price, sale.Unit, now)
+			if err != nil {
+				return nil, errors.NewDatabaseError(err, "create product")
+			}
+			products.add(productID, row.ProductName, row.SKU)
+			resp.ProductsCreated++
+			// Later rows for the new product need no lookup
+			if unit, ok := units.Get(sale.Unit); ok {
+				unitCache[productID] = importProductUnits{unit: unit, custom: []units.Unit{}}
+			}
+		}
+
+		var saleID int64
+		err = tx.QueryRow(ctx, `
+			INSERT INTO sales_history (company_id, product_id, quantity, price, sale_date, source, unit, unit_quantity, channel, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)
+			RETURNING id
+		`, companyID, productID, sale.Quantity, sale.Price, row.Date, opts.source, sale.Unit, sale.UnitQuantity, row.Channel, now).Scan(&saleID)
+		if err != nil {
+			return nil, errors.NewDatabaseError(err, "insert sale")
+		}
+		if err := saleStock(ctx, tx, companyID, productID, &saleID, sale.Quantity, ""); err != nil {
+			return nil, errors.NewDatabaseError(err, "update stock")
+		}
+		touched[productID] = true
+		resp.SalesImported++
+	}
+	resp.Skipped = len(resp.Errors)
+	sort.SliceStable(resp.Errors, func(i, j int) bool { return resp.Errors[i].Line < resp.Errors[j].Line })
+
+	if opts.dryRun {
+		return resp, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.NewDatabaseError(err, "commit transaction")
+	}
+
+	// Invalidate forecast cache for products that received new sales
+	for productID := range touched {
+		h.redis.Delete(ctx, fmt.Sprintf("forecast:%s", productID))
+	}
+	h.alertLowStock(ctx, companyID, mapKeys(touched)...)
+
+	logger.Info("Sales imported", "company_id", companyID, "adapter", adapter.Name,
+		"sales", resp.SalesImported, "products_created", resp.ProductsCreated, "skipped", resp.Skipped)
+	return resp, nil
+}
+
+// isDryRun reports whether the request asks for a dry run with dry_run=true,
+// in the query or the form
+func isDryRun(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.FormValue("dry_run"))
+	return dryRun
+}
+
+// parseImportUpload resolves the named adapter and parses the uploaded CSV or
+// XLSX export, or the "file_id" of a previously uploaded file. The "custom"
+// adapter reads the file with the column mapping in the "mapping" form value, a
+// JSON object of field to column, or else with the saved mapping named by
+// "source". Optional "date_format" (e.g. "DD/MM/YYYY") and "decimal" ("," or
+// ".") values override how dates and amounts are read. It writes the error
+// response itself and returns ok=false on failure.
+func (h *Handler) parseImportUpload(w http.ResponseWriter, r *http.Request, name string) (importer.Adapter, *importer.Result, bool) {
+	adapter, found := importer.Get(name)
+	if !found && name != importer.CustomAdapterName {
+		h.respondError(w, errors.NewNotFoundError("Import adapter"), r)
+		return adapter, nil, false
+	}
+
+	if err := r.ParseMultipartForm(maxFileSize); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid multipart form", err.Error()), r)
+		return adapter, nil, false
+	}
+
+	var inline map[string]string
+	if name == importer.CustomAdapterName && r.FormValue("mapping") != "" {
+		if err := json.Unmarshal([]byte(r.FormValue("mapping")), &inline); err != nil {
+			h.respondError(w, errors.NewValidationError("Invalid column mapping", err.Error()), r)
+			return adapter, nil, false
+		}
+		adapter = importer.CustomAdapter("Pemetaan kolom", inline)
+	} else if name == importer.CustomAdapterName {
+		m, err := h.loadImportMapping(r.Context(), middleware.GetCompanyID(r.Context()), r.FormValue("source"))
+		if err == pgx.ErrNoRows {
+			h.respondError(w, errors.NewNotFoundError("Column mapping"), r)
+			return adapter, nil, false
+		}
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "load column mapping"), r)
+			return adapter, nil, false
+		}
+		adapter = importer.CustomAdapter(m.Source, m.Mapping)
+	}
+
+	adapter, err := adapter.WithFormats(r.FormValue("date_format"), r.FormValue("decimal"))
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid import format", err.Error()), r)
+		return adapter, nil, false
+	}
+
+	rows, err := h.readImportTable(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return adapter, nil, false
+	}
+	if inline != nil {
+		if err := importer.ValidateMapping(inline, rows[0]); err != nil {
+			h.respondError(w, errors.NewValidationError("Invalid column mapping", err.Error()), r)
+			return adapter, nil, false
+		}
+	}
+	result, err := importer.ParseTable(adapter, rows)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Could not read export file", err.Error()), r)
+		return adapter, nil, false
+	}
+
+	return adapter, result, true
+}
+
+// readImportTable returns the rows of an uploaded CSV or XLSX file ("file"), or of the
+// first table extracted from a previously uploaded file ("file_id"). The multipart
+// form must already be parsed.
+func (h *Handler) readImportTable(r *http.Request) ([][]string, error) {
+	if fileID := r.FormValue("file_id"); fileID != "" {
+		return h.uploadedImportTable(r, fileID)
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, errors.NewValidationError("File is required", "file")
+	}
+	defer file.Close()
+
+	if header.Size > maxFileSize {
+		return nil, errors.NewValidationError(fmt.Sprintf("File size exceeds maximum of %d bytes", maxFileSize), "file")
+	}
+	kind := docparse.KindFromFilename(header.Filename)
+	if kind != docparse.KindCSV && kind != docparse.KindXLSX {
+		return nil, errors.NewValidationError("Only CSV and XLSX exports are supported", strings.ToLower(filepath.Ext(header.Filename)))
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, errors.NewValidationError("Could not read export file", err.Error())
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), parseTimeout)
+	defer cancel()
+	parsed, err := docparse.Parse(ctx, kind, data)
+	if err != nil {
+		return nil, errors.NewValidationError("Could not read export file", err.Error())
+	}
+	return firstTable(parsed.Tables)
+}
+
+// uploadedImportTable returns the first table extracted from an uploaded file the user may see
+func (h *Handler) uploadedImportTable(r *http.Request, fileID string) ([][]string, error) {
+	companyID := middleware.GetCompanyID(r.Context())
+	f, err := h.accessibleFile(r.Context(), fileID, companyID, middleware.GetUserID(r.Context()))
+	if err != nil {
+		return nil, errors.NewNotFoundError("File")
+	}
+	content, err := h.fileParseResult(r.Context(), f.ID)
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewValidationError("File has no extracted content", f.OriginalFilename)
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load file content")
+	}
+	return firstTable(content.Tables)
+}
+
+// firstTable returns the rows of the first non-empty table
+func firstTable(tables []docparse.Table) ([][]string, error) {
+	for _, t := range tables {
+		if len(t.Rows) > 0 {
+			return t.Rows, nil
+		}
+	}
+	return nil, errors.NewValidationError("Could not read export file", "no table found in file")
+}
+
+// importProductUnits caches a product's units during an import
+type importProductUnits struct {
+	unit   units.Unit
+	custom []units.Unit
+}
+
+// importSale normalizes a row to its product's unit. For rows that will create a
+// new product (productID empty), the row's unit becomes the product unit.
+func (h *Handler) importSale(ctx context.Context, q importQuerier, cache map[string]importProductUnits, productID string, row importer.Row) (saleQuantity, error) {
+	if productID == "" {
+		code := row.Unit
+		if code == "" {
+			code = units.DefaultUnit
+		}
+		unit, ok := units.Get(code)
+		if !ok {
+			return saleQuantity{}, fmt.Errorf("Satuan %q tidak dikenali", row.Unit)
+		}
+		return convertSale(unit, nil, row.Quantity, row.UnitPrice, "")
+	}
+
+	pu, err := h.cachedProductUnits(ctx, q, cache, productID)
+	if err != nil {
+		return saleQuantity{}, err
+	}
+	return convertSale(pu.unit, pu.custom, row.Quantity, row.UnitPrice, row.Unit)
+}
+
+// cachedProductUnits loads a product's units once per import
+func (h *Handler) cachedProductUnits(ctx context.Context, q importQuerier, cache map[string]importProductUnits, productID string) (importProductUnits, error) {
+	if pu, ok := cache[productID]; ok {
+		return pu, nil
+	}
+	unit, custom, err := h.loadProductUnits(ctx, q, productID)
+	if err != nil {
+		return importProductUnits{}, err
+	}
+	pu := importProductUnits{unit: unit, custom: custom}
+	cache[productID] = pu
+	return pu, nil
+}
+
+// importQuerier is satisfied by both the pool and a transaction
+type importQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// importProducts indexes a company's existing products by SKU and lowercase
+// name, including the aliases left by merged duplicates
+type importProducts struct {
+	bySKU  map[string]string
+	byName map[string]string
+}
+
+func (h *Handler) loadImportProducts(ctx context.Context, q importQuerier, companyID string) (*importProducts, error) {
+	rows, err := q.Query(ctx, `
+		SELECT id, name, COALESCE(sku, '') FROM products WHERE company_id = $1 AND merged_into IS NULL
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	p := &importProducts{bySKU: map[string]string{}, byName: map[string]string{}}
+	for rows.Next() {
+		var id, name, sku string
+		if err := rows.Scan(&id, &name, &sku); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		p.add(id, name, sku)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(ctx, `SELECT kind, alias, product_id FROM product_aliases WHERE company_id = $1`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind, alias, id string
+		if err := rows.Scan(&kind, &alias, &id); err != nil {
+			return nil, err
+		}
+		// A product's own name or SKU wins over an alias
+		index := p.byName
+		if kind == "sku" {
+			index = p.bySKU
+		}
+		if _, ok := index[alias]; !ok {
+			index[alias] = id
+		}
+	}
+	return p, rows.Err()
+}
+
+func (p *importProducts) add(id, name, sku string) {
+	if sku != "" {
+		p.bySKU[importKey(sku)] = id
+	}
+	p.byName[importKey(name)] = id
+}
+
+// match returns the product ID for a row, preferring SKU over name
+func (p *importProducts) match(row importer.Row) string {
+	if row.SKU != "" {
+		if id, ok := p.bySKU[importKey(row.SKU)]; ok {
+			return id
+		}
+	}
+	return p.byName[importKey(row.ProductName)]
+}
+
+// importKey is how imports compare product names and SKUs
+func importKey(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/prediction"
+	"github.com/bantuaku/backend/services/trendkeywords"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AddIndustryKeywordsRequest adds shared keywords to an industry
+type AddIndustryKeywordsRequest struct {
+	Industry string   `json:"industry"`
+	Keywords []string `json:"keywords"`
+}
+
+// MarketKeywordsResponse is what a company's market research tracks
+type MarketKeywordsResponse struct {
+	Industry string   `json:"industry"`
+	Keywords []string `json:"keywords"` // The company's own, from its last prediction
+	Shared   []string `json:"shared"`   // Tracked centrally for the company's industry
+}
+
+// GetMarketKeywords returns the company's own trends keywords and the shared
+// keywords of its industry
+func (h *Handler) GetMarketKeywords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	resp := MarketKeywordsResponse{Keywords: []string{}}
+	var result []byte
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(c.industry, ''), k.result
+		FROM companies c
+		LEFT JOIN LATERAL (`+latestKeywordsQuery+`) k ON true
+		WHERE c.id = $1
+	`, companyID).Scan(&resp.Industry, &result)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load keywords"), r)
+		return
+	}
+	if own := ownKeywords(result); own != nil {
+		resp.Keywords = own
+	}
+	if resp.Shared, err = h.sharedKeywords(ctx, resp.Industry); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load shared keywords"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// latestKeywordsQuery selects the keywords step result of a company's latest
+// prediction, for a lateral join on companies c
+const latestKeywordsQuery = `
+	SELECT s.result
+	FROM prediction_jobs j
+	JOIN prediction_job_steps s ON s.job_id = j.id AND s.step = '` + prediction.StepKeywords + `' AND s.status = '` + prediction.StatusCompleted + `'
+	WHERE j.company_id = c.id
+	ORDER BY j.created_at DESC
+	LIMIT 1`
+
+// ownKeywords returns the keywords a prediction derived for the company
+// itself, leaving out the shared ones it added
+func ownKeywords(result []byte) []string {
+	if len(result) == 0 {
+		return nil
+	}
+	var k prediction.KeywordsResult
+	if json.Unmarshal(result, &k) != nil {
+		return nil
+	}
+	shared := map[string]bool{}
+	for _, s := range k.Shared {
+		shared[trendkeywords.Normalize(s)] = true
+	}
+	own := []string{}
+	for _, kw := range k.Keywords {
+		if !shared[trendkeywords.Normalize(kw)] {
+			own = append(own, kw)
+		}
+	}
+	return own
+}
+
+// sharedKeywords lists the shared keywords of an industry, alphabetically
+func (h *Handler) sharedKeywords(ctx context.Context, industry string) ([]string, error) {
+	keywords := []string{}
+	industry = trendkeywords.Normalize(industry)
+	if industry == "" {
+		return keywords, nil
+	}
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT keyword FROM industry_keywords WHERE industry = $1 ORDER BY keyword
+	`, industry)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, k)
+	}
+	return keywords, rows.Err()
+}
+
+// GetKeywordCoverage reports, per industry, how many companies track trends
+// keywords of their own, how many are left without any, the keywords several
+// companies track separately and the shared keywords (platform admin only).
+// ?top= caps the keywords listed per industry (default 10).
+func (h *Handler) GetKeywordCoverage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	top := 10
+	if s := r.URL.Query().Get("top"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 100 {
+			h.respondError(w, errors.NewValidationError("Invalid top", "top must be between 1 and 100"), r)
+			return
+		}
+		top = n
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT COALESCE(c.industry, ''), k.result
+		FROM companies c
+		LEFT JOIN LATERAL (`+latestKeywordsQuery+`) k ON true
+		WHERE c.merged_into IS NULL AND c.archived_at IS NULL
+	`)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company keywords"), r)
+		return
+	}
+	var companies []trendkeywords.Company
+	for rows.Next() {
+		var c trendkeywords.Company
+		var result []byte
+		if err := rows.Scan(&c.Industry, &result); err != nil {
+			rows.Close()
+			h.respondError(w, errors.NewDatabaseError(err, "load company keywords"), r)
+			return
+		}
+		c.Keywords = ownKeywords(result)
+		companies = append(companies, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company keywords"), r)
+		return
+	}
+
+	shared := map[string][]string{}
+	rows, err = h.db.Pool().Query(ctx, `SELECT industry, keyword FROM industry_keywords ORDER BY industry, keyword`)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load shared keywords"), r)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var industry, keyword string
+		if err := rows.Scan(&industry, &keyword); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "load shared keywords"), r)
+			return
+		}
+		shared[industry] = append(shared[industry], keyword)
+	}
+	if err := rows.Err(); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load shared keywords"), r)
+		return
+	}
+
+	industries := trendkeywords.Coverage(companies, shared, top)
+	totals := map[string]int{"companies": 0, "companies_with_keywords": 0, "uncovered": 0, "duplicate_keywords": 0}
+	for _, c := range industries {
+		totals["companies"] += c.Companies
+		totals["companies_with_keywords"] += c.CompaniesWithKeywords
+		totals["uncovered"] += c.Uncovered
+		totals["duplicate_keywords"] += c.DuplicateKeywords
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"industries": industries,
+		"totals":     totals,
+	})
+}
+
+// ListIndustryKeywords lists the shared keywords, of one ?industry= or of all
+// (platform admin only)
+func (h *Handler) ListIndustryKeywords(w http.ResponseWriter, r *http.Request) {
+	industry := trendkeywords.Normalize(r.URL.Query().Get("industry"))
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT id, industry, keyword, created_by, created_at
+		FROM industry_keywords
+		WHERE $1 = '' OR industry = $1
+		ORDER BY industry, keyword
+	`, industry)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list industry keywords"), r)
+		return
+	}
+	defer rows.Close()
+
+	keywords := []models.IndustryKeyword{}
+	for rows.Next() {
+		var k models.IndustryKeyword
+		if err := rows.Scan(&k.ID, &k.Industry, &k.Keyword, &k.CreatedBy, &k.CreatedAt); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "list industry keywords"), r)
+			return
+		}
+		keywords = append(keywords, k)
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"keywords": keywords})
+}
+
+// AddIndustryKeywords adds shared keywords to an industry; keywords it already
+// has are skipped (platform admin only)
+func (h *Handler) AddIndustryKeywords(w http.ResponseWriter, r *http.Request) {
+	var req AddIndustryKeywordsRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	industry := trendkeywords.Normalize(req.Industry)
+	switch {
+	case industry == "" || len(industry) > trendkeywords.MaxKeywordLength:
+		h.respondError(w, errors.NewValidationError("Validation failed",
+			fmt.Sprintf("industry: required, at most %d characters", trendkeywords.MaxKeywordLength)), r)
+		return
+	case len(req.Keywords) == 0 || len(req.Keywords) > trendkeywords.MaxPerRequest:
+		h.respondError(w, errors.NewValidationError("Validation failed",
+			fmt.Sprintf("keywords: between 1 and %d keywords", trendkeywords.MaxPerRequest)), r)
+		return
+	}
+	keywords := []string{}
+	seen := map[string]bool{}
+	for _, k := range req.Keywords {
+		k = trendkeywords.Normalize(k)
+		if len(k) > trendkeywords.MaxKeywordLength {
+			h.respondError(w, errors.NewValidationError("Validation failed",
+				fmt.Sprintf("keywords: %q is longer than %d characters", k, trendkeywords.MaxKeywordLength)), r)
+			return
+		}
+		if k != "" && !seen[k] {
+			seen[k] = true
+			keywords = append(keywords, k)
+		}
+	}
+
+	ctx := r.Context()
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+	added := []string{}
+	for _, k := range keywords {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO industry_keywords (id, industry, keyword, created_by, created_at)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NOW())
+			ON CONFLICT (industry, keyword) DO NOTHING
+		`, uuid.New().String(), industry, k, middleware.GetUserID(ctx))
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "add industry keyword"), r)
+			return
+		}
+		if tag.RowsAffected() > 0 {
+			added = append(added, k)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+	logger.Info("Industry keywords added", "industry", industry, "added", len(added))
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"industry": industry,
+		"added":    added,
+		"skipped":  len(keywords) - len(added),
+	})
+}
+
+// DeleteIndustryKeyword stops sharing a keyword with an industry (platform admin only)
+func (h *Handler) DeleteIndustryKeyword(w http.ResponseWriter, r *http.Request) {
+	tag, err := h.db.Pool().Exec(r.Context(), `DELETE FROM industry_keywords WHERE id = $1`, r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete industry keyword"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Industry keyword"), r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,484 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultBaselineDays = 30
+	maxBaselineDays     = 180
+
+	// Sales changes within this percentage are reported as no change
+	impactThresholdPercent = 5.0
+	// Minimum days after adoption before an impact verdict is given
+	minImpactDays = 7
+)
+
+// AdoptInsightRequest marks a recommendation from an insight as adopted
+type AdoptInsightRequest struct {
+	Recommendation string `json:"recommendation" validate:"required,max:1000"`
+	ProductID      string `json:"product_id,omitempty"`
+	BaselineDays   int    `json:"baseline_days,omitempty"` // Days compared before and after adoption, default 30
+	Notes          string `json:"notes,omitempty"`
+}
+
+// InsightActionRequest links a campaign or price change to an adopted recommendation
+type InsightActionRequest struct {
+	AdoptionID  string   `json:"adoption_id" validate:"required"`
+	Type        string   `json:"type" validate:"required,oneof:campaign|price_change|other"`
+	Description string   `json:"description" validate:"required,max:1000"`
+	ProductID   string   `json:"product_id,omitempty"`
+	OldPrice    *float64 `json:"old_price,omitempty"`
+	NewPrice    *float64 `json:"new_price,omitempty"` // Required for price changes
+	Channel     string   `json:"channel,omitempty"`
+	StartedOn   string   `json:"started_on,omitempty"` // YYYY-MM-DD, defaults to today
+	EndedOn     string   `json:"ended_on,omitempty"`
+}
+
+// InsightOutcomeRequest records an outcome metric for an adopted recommendation
+type InsightOutcomeRequest struct {
+	AdoptionID    string   `json:"adoption_id" validate:"required"`
+	Metric        string   `json:"metric" validate:"required,max:100"`
+	BaselineValue *float64 `json:"baseline_value,omitempty"`
+	Value         float64  `json:"value"`
+	MeasuredOn    string   `json:"measured_on,omitempty"` // YYYY-MM-DD, defaults to today
+	Notes         string   `json:"notes,omitempty"`
+}
+
+// InsightOutcomesResponse shows whether following an insight moved the numbers
+type InsightOutcomesResponse struct {
+	InsightID string                   `json:"insight_id"`
+	Type      string                   `json:"type"`
+	CreatedAt time.Time                `json:"created_at"`
+	Adoptions []models.InsightAdoption `json:"adoptions"`
+}
+
+// AdoptInsight marks one of an insight's recommendations as adopted
+func (h *Handler) AdoptInsight(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req AdoptInsightRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.BaselineDays == 0 {
+		req.BaselineDays = defaultBaselineDays
+	}
+	if req.BaselineDays < minImpactDays || req.BaselineDays > maxBaselineDays {
+		h.respondError(w, errors.NewValidationError("Invalid baseline_days", "baseline_days must be between 7 and 180"), r)
+		return
+	}
+
+	ctx := r.Context()
+	insightID := r.PathValue("id")
+	if _, _, err := h.loadInsightHeader(ctx, companyID, insightID); err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Insight"), r)
+		return
+	} else if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load insight"), r)
+		return
+	}
+	if req.ProductID != "" && !h.companyOwnsProduct(ctx, companyID, req.ProductID) {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	adoption := models.InsightAdoption{
+		ID:             uuid.New().String(),
+		InsightID:      insightID,
+		Recommendation: req.Recommendation,
+		BaselineDays:   req.BaselineDays,
+		Notes:          req.Notes,
+		Actions:        []models.InsightAction{},
+		Outcomes:       []models.InsightMetric{},
+	}
+	if req.ProductID != "" {
+		adoption.ProductID = &req.ProductID
+	}
+	err := h.db.Pool().QueryRow(ctx, `
+		INSERT INTO insight_adoptions (id, insight_id, company_id, recommendation, product_id, baseline_days, notes, adopted_by, adopted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NOW())
+		RETURNING adopted_at
+	`, adoption.ID, insightID, companyID, req.Recommendation, adoption.ProductID, req.BaselineDays, req.Notes,
+		middleware.GetUserID(ctx)).Scan(&adoption.AdoptedAt)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "adopt insight"), r)
+		return
+	}
+
+	logger.Info("Insight recommendation adopted", "company_id", companyID, "insight_id", insightID, "adoption_id", adoption.ID)
+
+	h.respondJSON(w, r, http.StatusCreated, adoption)
+}
+
+// CreateInsightAction links a campaign or price change to an adopted recommendation
+func (h *Handler) CreateInsightAction(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req InsightActionRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.Type == models.InsightActionPriceChange && req.NewPrice == nil {
+		h.respondError(w, errors.NewValidationError("new_price is required", "price changes must include the new price"), r)
+		return
+	}
+
+	ctx := r.Context()
+	loc := h.companyLocation(ctx, companyID)
+	startedOn, err := parseOptionalDate(req.StartedOn, loc)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid started_on", "started_on must be formatted as YYYY-MM-DD"), r)
+		return
+	}
+	var endedOn *time.Time
+	if req.EndedOn != "" {
+		d, err := time.Parse("2006-01-02", req.EndedOn)
+		if err != nil || d.Before(startedOn) {
+			h.respondError(w, errors.NewValidationError("Invalid ended_on", "ended_on must be a YYYY-MM-DD date on or after started_on"), r)
+			return
+		}
+		endedOn = &d
+	}
+
+	if !h.adoptionBelongsToInsight(ctx, companyID, r.PathValue("id"), req.AdoptionID) {
+		h.respondError(w, errors.NewNotFoundError("Adoption"), r)
+		return
+	}
+	if req.ProductID != "" && !h.companyOwnsProduct(ctx, companyID, req.ProductID) {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	action := models.InsightAction{
+		ID:          uuid.New().String(),
+		AdoptionID:  req.AdoptionID,
+		Type:        req.Type,
+		Description: req.Description,
+		OldPrice:    req.OldPrice,
+		NewPrice:    req.NewPrice,
+		Channel:     req.Channel,
+		StartedOn:   startedOn,
+		EndedOn:     endedOn,
+	}
+	if req.ProductID != "" {
+		action.ProductID = &req.ProductID
+	}
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO insight_actions (id, adoption_id, company_id, action_type, description, product_id,
+			old_price, new_price, channel, started_on, ended_on, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, $11, NOW())
+	`, action.ID, action.AdoptionID, companyID, action.Type, action.Description, action.ProductID,
+		action.OldPrice, action.NewPrice, action.Channel, action.StartedOn, action.EndedOn)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create insight action"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusCreated, action)
+}
+
+// CreateInsightOutcome records an outcome metric for an adopted recommendation
+func (h *Handler) CreateInsightOutcome(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req InsightOutcomeRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	measuredOn, err := parseOptionalDate(req.MeasuredOn, h.companyLocation(ctx, companyID))
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid measured_on", "measured_on must be formatted as YYYY-MM-DD"), r)
+		return
+	}
+	if !h.adoptionBelongsToInsight(ctx, companyID, r.PathValue("id"), req.AdoptionID) {
+		h.respondError(w, errors.NewNotFoundError("Adoption"), r)
+		return
+	}
+
+	metric := models.InsightMetric{
+		ID:            uuid.New().String(),
+		AdoptionID:    req.AdoptionID,
+		Metric:        req.Metric,
+		BaselineValue: req.BaselineValue,
+		Value:         req.Value,
+		MeasuredOn:    measuredOn,
+		Notes:         req.Notes,
+	}
+	metric.Change = metricChange(metric)
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO insight_outcomes (id, adoption_id, company_id, metric, baseline_value, value, measured_on, notes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NOW())
+	`, metric.ID, metric.AdoptionID, companyID, metric.Metric, metric.BaselineValue, metric.Value, metric.MeasuredOn, metric.Notes)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create insight outcome"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusCreated, metric)
+}
+
+// GetInsightOutcomes lists adopted recommendations with their actions, reported metrics
+// and the sales impact measured before vs. after adoption
+func (h *Handler) GetInsightOutcomes(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	ctx := r.Context()
+	insightID := r.PathValue("id")
+	resp := InsightOutcomesResponse{InsightID: insightID, Adoptions: []models.InsightAdoption{}}
+	var err error
+	resp.Type, resp.CreatedAt, err = h.loadInsightHeader(ctx, companyID, insightID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Insight"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load insight"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, recommendation, product_id, baseline_days, COALESCE(notes, ''), adopted_at
+		FROM insight_adoptions
+		WHERE insight_id = $1 AND company_id = $2
+		ORDER BY adopted_at
+	`, insightID, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list insight adoptions"), r)
+		return
+	}
+	for rows.Next() {
+		a := models.InsightAdoption{InsightID: insightID, Actions: []models.InsightAction{}, Outcomes: []models.InsightMetric{}}
+		if err := rows.Scan(&a.ID, &a.Recommendation, &a.ProductID, &a.BaselineDays, &a.Notes, &a.AdoptedAt); err != nil {
+			rows.Close()
+			h.respondError(w, errors.NewDatabaseError(err, "scan insight adoption"), r)
+			return
+		}
+		resp.Adoptions = append(resp.Adoptions, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list insight adoptions"), r)
+		return
+	}
+
+	loc := h.companyLocation(ctx, companyID)
+	for i := range resp.Adoptions {
+		a := &resp.Adoptions[i]
+		if a.Actions, err = h.loadInsightActions(ctx, a.ID); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "list insight actions"), r)
+			return
+		}
+		if a.Outcomes, err = h.loadInsightMetrics(ctx, a.ID); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "list insight outcomes"), r)
+			return
+		}
+		if a.SalesImpact, err = h.measureSalesImpact(ctx, companyID, *a, loc); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "measure sales impact"), r)
+			return
+		}
+	}
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+func (h *Handler) loadInsightHeader(ctx context.Context, companyID, insightID string) (string, time.Time, error) {
+	var insightType string
+	var createdAt time.Time
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT type, created_at FROM insights WHERE id = $1 AND company_id = $2
+	`, insightID, companyID).Scan(&insightType, &createdAt)
+	return insightType, createdAt, err
+}
+
+func (h *Handler) adoptionBelongsToInsight(ctx context.Context, companyID, insightID, adoptionID string) bool {
+	var exists bool
+	h.db.Pool().QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM insight_adoptions WHERE id = $1 AND insight_id = $2 AND company_id = $3)
+	`, adoptionID, insightID, companyID).Scan(&exists)
+	return exists
+}
+
+func (h *Handler) companyOwnsProduct(ctx context.Context, companyID, productID string) bool {
+	var exists bool
+	h.db.Pool().QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND company_id = $2)
+	`, productID, companyID).Scan(&exists)
+	return exists
+}
+
+func (h *Handler) loadInsightActions(ctx context.Context, adoptionID string) ([]models.InsightAction, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, adoption_id, action_type, description, product_id, old_price, new_price, COALESCE(channel, ''), started_on, ended_on
+		FROM insight_actions WHERE adoption_id = $1
+		ORDER BY started_on, created_at
+	`, adoptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []models.InsightAction{}
+	for rows.Next() {
+		var a models.InsightAction
+		if err := rows.Scan(&a.ID, &a.AdoptionID, &a.Type, &a.Description, &a.ProductID, &a.OldPrice, &a.NewPrice,
+			&a.Channel, &a.StartedOn, &a.EndedOn); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+func (h *Handler) loadInsightMetrics(ctx context.Context, adoptionID string) ([]models.InsightMetric, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, adoption_id, metric, baseline_value, value, measured_on, COALESCE(notes, '')
+		FROM insight_outcomes WHERE adoption_id = $1
+		ORDER BY measured_on, created_at
+	`, adoptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metrics := []models.InsightMetric{}
+	for rows.Next() {
+		var m models.InsightMetric
+		if err := rows.Scan(&m.ID, &m.AdoptionID, &m.Metric, &m.BaselineValue, &m.Value, &m.MeasuredOn, &m.Notes); err != nil {
+			return nil, err
+		}
+		m.Change = metricChange(m)
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
+// measureSalesImpact compares average daily sales in the baseline window before adoption
+// with the same-length window starting on the adoption day
+func (h *Handler) measureSalesImpact(ctx context.Context, companyID string, a models.InsightAdoption, loc *time.Location) (*models.SalesImpact, error) {
+	adopted := localDate(a.AdoptedAt, loc)
+	today := localDate(time.Now(), loc)
+	baselineFrom := adopted.AddDate(0, 0, -a.BaselineDays)
+	afterTo := adopted.AddDate(0, 0, a.BaselineDays-1)
+	if afterTo.After(today) {
+		afterTo = today
+	}
+	observed := int(afterTo.Sub(adopted).Hours()/24) + 1
+
+	var productID string
+	if a.ProductID != nil {
+		productID = *a.ProductID
+	}
+
+	impact := &models.SalesImpact{
+		BaselineFrom: baselineFrom.Format("2006-01-02"),
+		BaselineTo:   adopted.AddDate(0, 0, -1).Format("2006-01-02"),
+		AfterFrom:    adopted.Format("2006-01-02"),
+		AfterTo:      afterTo.Format("2006-01-02"),
+		DaysObserved: observed,
+	}
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(quantity * price) FILTER (WHERE sale_date < $3), 0),
+			COALESCE(SUM(quantity) FILTER (WHERE sale_date < $3), 0),
+			COALESCE(SUM(quantity * price) FILTER (WHERE sale_date >= $3), 0),
+			COALESCE(SUM(quantity) FILTER (WHERE sale_date >= $3), 0)
+		FROM sales_history
+		WHERE company_id = $1 AND sale_date >= $2 AND sale_date <= $4
+			AND ($5 = '' OR product_id = $5) AND NOT COALESCE(excluded, false)
+	`, companyID, baselineFrom, adopted, afterTo, productID).Scan(
+		&impact.BaselineRevenue, &impact.BaselineQuantity, &impact.AfterRevenue, &impact.AfterQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	impact.BaselineRevenue /= float64(a.BaselineDays)
+	impact.BaselineQuantity /= float64(a.BaselineDays)
+	impact.AfterRevenue /= float64(observed)
+	impact.AfterQuantity /= float64(observed)
+	impact.RevenueChange = percentChange(impact.BaselineRevenue, impact.AfterRevenue)
+	impact.QuantityChange = percentChange(impact.BaselineQuantity, impact.AfterQuantity)
+	impact.Verdict = impactVerdict(observed, impact.BaselineRevenue, impact.RevenueChange)
+	return impact, nil
+}
+
+// impactVerdict classifies the revenue change once enough days have been observed
+func impactVerdict(daysObserved int, baseline, change float64) string {
+	switch {
+	case daysObserved < minImpactDays || baseline == 0:
+		return "too_early"
+	case change >= impactThresholdPercent:
+		return "improved"
+	case change <= -impactThresholdPercent:
+		return "declined"
+	default:
+		return "no_change"
+	}
+}
+
+func percentChange(before, after float64) float64 {
+	if before == 0 {
+		return 0
+	}
+	return (after - before) / before * 100
+}
+
+func metricChange(m models.InsightMetric) *float64 {
+	if m.BaselineValue == nil || *m.BaselineValue == 0 {
+		return nil
+	}
+	change := percentChange(*m.BaselineValue, m.Value)
+	return &change
+}
+
+// parseOptionalDate parses YYYY-MM-DD, defaulting to today in loc
+func parseOptionalDate(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		return localDate(time.Now(), loc), nil
+	}
+	return time.Parse("2006-01-02", s)
+}
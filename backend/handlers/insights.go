@@ -1,10 +1,18 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/compliance"
+	"github.com/bantuaku/backend/services/kbli"
+	"github.com/bantuaku/backend/services/regions"
 	"github.com/bantuaku/backend/validation"
 
 	"github.com/google/uuid"
@@ -76,7 +84,9 @@ func (h *Handler) GenerateForecastInsight(w http.ResponseWriter, r *http.Request
 		"message":   "Forecast akan dihasilkan setelah data penjualan tersedia. Silakan input data melalui AI Assistant.",
 	}
 
-	h.respondJSON(w, http.StatusOK, InsightResponse{
+	h.saveInsight(r, insightID, "forecast", req, result)
+
+	h.respondJSON(w, r, http.StatusOK, InsightResponse{
 		InsightID: insightID,
 		Type:      "forecast",
 		Result:    result,
@@ -108,7 +118,9 @@ func (h *Handler) GenerateMarketInsight(w http.ResponseWriter, r *http.Request)
 		"message": "Prediksi pasar akan dihasilkan setelah koneksi data eksternal tersedia.",
 	}
 
-	h.respondJSON(w, http.StatusOK, InsightResponse{
+	h.saveInsight(r, insightID, "market_prediction", req, result)
+
+	h.respondJSON(w, r, http.StatusOK, InsightResponse{
 		InsightID: insightID,
 		Type:      "market_prediction",
 		Result:    result,
@@ -139,7 +151,9 @@ func (h *Handler) GenerateMarketingInsight(w http.ResponseWriter, r *http.Reques
 		"message":         "Rekomendasi marketing akan dihasilkan setelah AI Assistant mengumpulkan informasi tentang bisnis Anda.",
 	}
 
-	h.respondJSON(w, http.StatusOK, InsightResponse{
+	h.saveInsight(r, insightID, "marketing_recommendation", req, result)
+
+	h.respondJSON(w, r, http.StatusOK, InsightResponse{
 		InsightID: insightID,
 		Type:      "marketing_recommendation",
 		Result:    result,
@@ -147,9 +161,18 @@ func (h *Handler) GenerateMarketingInsight(w http.ResponseWriter, r *http.Reques
 	})
 }
 
-// GenerateRegulationInsight generates government regulation insights
+// GenerateRegulationInsight returns the compliance checklist that applies to
+// the company, evaluated deterministically from its KBLI code, legal form and
+// province. industry (a KBLI code), legal_form and region (a province code) in
+// the request override the stored profile, e.g. to see what changes when
+// incorporating as a PT.
 func (h *Handler) GenerateRegulationInsight(w http.ResponseWriter, r *http.Request) {
-	_ = r.Context().Value("user_id") // TODO: Use userID when implementing DB storage
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
 
 	var req RegulationInsightRequest
 	if err := h.parseJSON(r, &req); err != nil {
@@ -162,15 +185,53 @@ func (h *Handler) GenerateRegulationInsight(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// TODO: Implement regulation fetching using connectors (Indonesia regulation scraper)
-	// For now, return mock response
+	profile, err := h.complianceProfile(ctx, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company profile"), r)
+		return
+	}
+	if req.Industry != "" {
+		if level, _, _, err := kbli.ParseCode(req.Industry); err != nil || level != kbli.LevelSubclass {
+			h.respondError(w, errors.NewValidationError("Invalid industry", "industry must be a 5-digit KBLI code"), r)
+			return
+		}
+		profile.KBLICode = req.Industry
+	}
+	if req.LegalForm != "" {
+		if !compliance.ValidLegalForm(req.LegalForm) {
+			h.respondError(w, errors.NewValidationError("Invalid legal_form", "unknown legal form "+req.LegalForm), r)
+			return
+		}
+		profile.LegalForm = req.LegalForm
+	}
+	if req.Region != "" {
+		if level, _, err := regions.ParseCode(req.Region); err != nil || level != regions.LevelProvince {
+			h.respondError(w, errors.NewValidationError("Invalid region", "region must be a province code"), r)
+			return
+		}
+		profile.ProvinceCode = req.Region
+	}
+
+	checklist, err := h.complianceChecklist(ctx, companyID, profile)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "build compliance checklist"), r)
+		return
+	}
+	message := fmt.Sprintf("%d dari %d perizinan sudah dipenuhi.", checklist.Done, checklist.Total)
+	if len(checklist.Missing) > 0 {
+		message += " Lengkapi KBLI, bentuk usaha dan provinsi bisnis Anda untuk daftar perizinan yang lengkap."
+	}
+
 	insightID := uuid.New().String()
 	result := map[string]interface{}{
+		"checklist":   checklist,
 		"regulations": []models.Regulation{},
-		"message":     "Informasi peraturan akan ditampilkan setelah AI Assistant mengetahui industri dan lokasi bisnis Anda.",
+		"message":     message,
 	}
 
-	h.respondJSON(w, http.StatusOK, InsightResponse{
+	h.saveInsight(r, insightID, "gov_regulation", req, result)
+
+	h.respondJSON(w, r, http.StatusOK, InsightResponse{
 		InsightID: insightID,
 		Type:      "gov_regulation",
 		Result:    result,
@@ -180,13 +241,55 @@ func (h *Handler) GenerateRegulationInsight(w http.ResponseWriter, r *http.Reque
 
 // GetInsights retrieves insight history for a company
 func (h *Handler) GetInsights(w http.ResponseWriter, r *http.Request) {
-	_ = r.Context().Value("user_id")    // TODO: Use userID when implementing DB storage
-	_ = r.URL.Query().Get("company_id") // TODO: Use companyID when implementing DB storage
-	_ = r.URL.Query().Get("type")       // TODO: Use insightType when implementing filtering
-
-	// TODO: Implement insight retrieval from database
-	// For now, return empty list
-	h.respondJSON(w, http.StatusOK, map[string]interface{}{
-		"insights": []models.Insight{},
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	insightType := r.URL.Query().Get("type")
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT id, company_id, type, input_context, result, created_at
+		FROM insights
+		WHERE company_id = $1 AND ($2 = '' OR type = $2)
+		ORDER BY created_at DESC
+		LIMIT 50
+	`, companyID, insightType)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list insights"), r)
+		return
+	}
+	defer rows.Close()
+
+	insights := []models.Insight{}
+	for rows.Next() {
+		var in models.Insight
+		if err := rows.Scan(&in.ID, &in.CompanyID, &in.Type, &in.InputContext, &in.Result, &in.CreatedAt); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan insight"), r)
+			return
+		}
+		insights = append(insights, in)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"insights": insights,
 	})
 }
+
+// saveInsight stores a generated insight so recommendations can later be adopted and tracked
+func (h *Handler) saveInsight(r *http.Request, insightID, insightType string, input interface{}, result map[string]interface{}) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		return
+	}
+
+	inputJSON, _ := json.Marshal(input)
+	resultJSON, _ := json.Marshal(result)
+	_, err := h.db.Pool().Exec(r.Context(), `
+		INSERT INTO insights (id, company_id, type, input_context, result, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+	`, insightID, companyID, insightType, inputJSON, resultJSON)
+	if err != nil {
+		logger.Warn("Failed to store insight", "company_id", companyID, "type", insightType, "error", err.Error())
+	}
+}
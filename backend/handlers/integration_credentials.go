@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/mailer"
+	"github.com/bantuaku/backend/services/notifyprefs"
+	"github.com/bantuaku/backend/services/woocommerce"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// wooRotationTTL bounds how long a started key rotation waits for WooCommerce's callback
+const wooRotationTTL = 30 * time.Minute
+
+// UpdateWooCommerceCredentialsRequest replaces the stored API keys by hand
+type UpdateWooCommerceCredentialsRequest struct {
+	ConsumerKey    string `json:"consumer_key" validate:"required,max:255"`
+	ConsumerSecret string `json:"consumer_secret" validate:"required,max:255"`
+}
+
+// wooKeyCallback is the key pair WooCommerce POSTs after the merchant approves
+// an app-authorization request
+type wooKeyCallback struct {
+	KeyID          json.Number     `json:"key_id"`
+	UserID         json.RawMessage `json:"user_id"` // Echoes our rotation token; string or number
+	ConsumerKey    string          `json:"consumer_key"`
+	ConsumerSecret string          `json:"consumer_secret"`
+	KeyPermissions string          `json:"key_permissions"`
+}
+
+// wooRotation is kept in Redis between starting a rotation and WooCommerce's callback
+type wooRotation struct {
+	CompanyID     string `json:"company_id"`
+	IntegrationID string `json:"integration_id"`
+}
+
+// wooIntegration is a stored WooCommerce connection
+type wooIntegration struct {
+	ID             string
+	StoreURL       string
+	ConsumerKey    string
+	ConsumerSecret string
+}
+
+// StartWooCommerceRotation begins rotating the WooCommerce API keys. It returns
+// the store's authorization page: once the merchant approves, WooCommerce
+// generates a new key pair and delivers it to WooCommerceRotationCallback, which
+// verifies and swaps it in. keys_page_url is the fallback for creating keys by
+// hand and submitting them to UpdateWooCommerceCredentials.
+func (h *Handler) StartWooCommerceRotation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	if h.redis == nil {
+		h.respondError(w, errors.NewInternalError(fmt.Errorf("redis is not configured"), "Key rotation is unavailable"), r)
+		return
+	}
+
+	integration, err := h.loadWooIntegration(ctx, companyID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("WooCommerce integration"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load integration"), r)
+		return
+	}
+
+	token := randomToken()
+	data, _ := json.Marshal(wooRotation{CompanyID: companyID, IntegrationID: integration.ID})
+	if err := h.redis.Set(ctx, "woo_rotation:"+token, string(data), wooRotationTTL); err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to start key rotation"), r)
+		return
+	}
+
+	returnURL := strings.TrimRight(h.config.AppURL, "/") + "/integrations?rotation=woocommerce"
+	callbackURL := strings.TrimRight(h.config.APIURL, "/") + "/api/v1/integrations/woocommerce/credentials/callback"
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"authorize_url":      woocommerce.AuthorizeURL(integration.StoreURL, "Bantuaku", token, returnURL, callbackURL),
+		"keys_page_url":      woocommerce.KeysPageURL(integration.StoreURL),
+		"current_key_suffix": woocommerce.KeySuffix(integration.ConsumerKey),
+		"expires_in":         int(wooRotationTTL.Seconds()),
+	})
+}
+
+// WooCommerceRotationCallback receives a new key pair from WooCommerce. The
+// request is only accepted for an unexpired, single-use rotation token, and the
+// keys are verified against the store before they replace the stored ones.
+func (h *Handler) WooCommerceRotationCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req wooKeyCallback
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid callback body", err.Error()), r)
+		return
+	}
+	token := strings.Trim(string(req.UserID), `"`)
+	if token == "" || req.ConsumerKey == "" || req.ConsumerSecret == "" || h.redis == nil {
+		h.respondError(w, errors.NewValidationError("Incomplete key callback", ""), r)
+		return
+	}
+
+	key := "woo_rotation:" + token
+	raw, err := h.redis.Get(ctx, key)
+	var rotation wooRotation
+	if err != nil || raw == "" || json.Unmarshal([]byte(raw), &rotation) != nil {
+		h.respondError(w, errors.NewUnauthorizedError("Unknown or expired key rotation"), r)
+		return
+	}
+	h.redis.Delete(ctx, key)
+
+	integration, err := h.loadWooIntegration(ctx, rotation.CompanyID)
+	if err != nil || integration.ID != rotation.IntegrationID {
+		h.respondError(w, errors.NewNotFoundError("WooCommerce integration"), r)
+		return
+	}
+	if err := h.rotateWooCredentials(ctx, rotation.CompanyID, integration, req.ConsumerKey, req.ConsumerSecret); err != nil {
+		logger.Warn("WooCommerce key rotation failed", "company_id", rotation.CompanyID, "error", err.Error())
+		h.respondError(w, err, r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"status": "rotated"})
+}
+
+// UpdateWooCommerceCredentials verifies API keys created by hand and swaps them
+// in for the stored ones
+func (h *Handler) UpdateWooCommerceCredentials(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req UpdateWooCommerceCredentialsRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	integration, err := h.loadWooIntegration(ctx, companyID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("WooCommerce integration"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load integration"), r)
+		return
+	}
+	if err := h.rotateWooCredentials(ctx, companyID, integration, strings.TrimSpace(req.ConsumerKey), strings.TrimSpace(req.ConsumerSecret)); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{
+		"status":  "connected",
+		"message": "Kunci API baru aktif. Cabut kunci lama (…" + woocommerce.KeySuffix(integration.ConsumerKey) + ") di WooCommerce.",
+	})
+}
+
+// rotateWooCredentials verifies a new key pair and swaps it in atomically: the
+// update only applies if the stored key is still the one the rotation started
+// from, so concurrent rotations can't overwrite each other
+func (h *Handler) rotateWooCredentials(ctx context.Context, companyID string, integration *wooIntegration, consumerKey, consumerSecret string) error {
+	if consumerKey == integration.ConsumerKey {
+		return errors.NewValidationError("New key is the same as the current key", "create a new key pair in WooCommerce")
+	}
+	if err := woocommerce.VerifyCredentials(ctx, integration.StoreURL, consumerKey, consumerSecret); err != nil {
+		if err == woocommerce.ErrUnauthorized {
+			return errors.NewValidationError("WooCommerce rejected the new credentials", "check the key has read access")
+		}
+		return errors.NewValidationError("Could not reach the WooCommerce store", err.Error())
+	}
+
+	tag, err := h.db.Pool().Exec(ctx, `
+		UPDATE integrations SET
+			metadata = metadata || jsonb_build_object(
+				'consumer_key', $3::text, 'consumer_secret', $4::text, 'previous_key_suffix', $5::text),
+			status = 'connected', error_message = '', auth_failed_at = NULL, credentials_rotated_at = NOW()
+		WHERE id = $1 AND company_id = $2 AND metadata->>'consumer_key' = $6
+	`, integration.ID, companyID, consumerKey, consumerSecret, woocommerce.KeySuffix(integration.ConsumerKey), integration.ConsumerKey)
+	if err != nil {
+		return errors.NewDatabaseError(err, "swap integration credentials")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.NewConflictError("Credentials changed during rotation", "reload the integration and try again")
+	}
+
+	logger.Info("WooCommerce credentials rotated", "company_id", companyID, "integration_id", integration.ID,
+		"old_key", woocommerce.KeySuffix(integration.ConsumerKey), "new_key", woocommerce.KeySuffix(consumerKey))
+	return nil
+}
+
+// integrationAuthFailed pauses an integration whose credentials were rejected
+// during sync and tells the company owner, in-app and by email, to rotate them.
+// Only the first failure notifies; later syncs find the integration already paused.
+func (h *Handler) integrationAuthFailed(ctx context.Context, companyID, platform, detail string) {
+	tag, err := h.db.Pool().Exec(ctx, `
+		UPDATE integrations SET status = 'auth_failed', error_message = $3, auth_failed_at = NOW()
+		WHERE company_id = $1 AND platform = $2 AND status <> 'auth_failed'
+	`, companyID, platform, detail)
+	h.recordIntegrationRun(ctx, companyID, platform, fmt.Errorf("%s", detail))
+	if err != nil {
+		logger.Error("Failed to flag integration auth failure", "company_id", companyID, "platform", platform, "error", err.Error())
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		return
+	}
+	logger.Warn("Integration credentials rejected", "company_id", companyID, "platform", platform, "detail", detail)
+
+	title := fmt.Sprintf("Koneksi %s terputus", platform)
+	message := fmt.Sprintf("%s menolak kunci API yang tersimpan, sehingga sinkronisasi dihentikan. Buat kunci baru di menu Integrasi agar data penjualan tetap terbarui.", platform)
+	if _, err := h.notify(ctx, companyID, models.Notification{
+		Type:    models.NotificationIntegrationAuth,
+		Title:   title,
+		Message: message,
+		Data:    map[string]interface{}{"platform": platform},
+	}, ""); err != nil {
+		logger.Warn("Failed to create integration notification", "company_id", companyID, "error", err.Error())
+	}
+
+	var ownerID, ownerEmail string
+	h.db.Pool().QueryRow(ctx, `
+		SELECT u.id, u.email FROM companies c JOIN users u ON u.id = c.owner_user_id WHERE c.id = $1
+	`, companyID).Scan(&ownerID, &ownerEmail)
+	if ownerEmail == "" || !h.wantsNotification(ctx, ownerID, companyID, models.NotificationIntegrationAuth, notifyprefs.ChannelEmail) {
+		return
+	}
+	link := strings.TrimRight(h.config.AppURL, "/") + "/integrations"
+	if err := h.sendEmail(ctx, mailer.IntegrationAuthFailedEmail(ownerEmail, platform, link)); err != nil {
+		logger.Error("Failed to send integration alert", "company_id", companyID, "error", err.Error())
+	}
+}
+
+func (h *Handler) loadWooIntegration(ctx context.Context, companyID string) (*wooIntegration, error) {
+	var id string
+	var metadata map[string]string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, metadata FROM integrations WHERE company_id = $1 AND platform = 'woocommerce'
+	`, companyID).Scan(&id, &metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &wooIntegration{
+		ID:             id,
+		StoreURL:       metadata["store_url"],
+		ConsumerKey:    metadata["consumer_key"],
+		ConsumerSecret: metadata["consumer_secret"],
+	}, nil
+}
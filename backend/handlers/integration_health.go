@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/integrationhealth"
+	"github.com/bantuaku/backend/services/mailer"
+	"github.com/bantuaku/backend/services/market"
+)
+
+// storeSyncStaleAfter is how long store data may go unsynced before forecasts are
+// considered to be running on stale sales
+const storeSyncStaleAfter = 7 * 24 * time.Hour
+
+// platformScope is the integration_health company_id of platform-wide services
+const platformScope = ""
+
+// IntegrationHealth is the state of one integration
+type IntegrationHealth struct {
+	Service       string     `json:"service"`
+	Label         string     `json:"label"`
+	Kind          string     `json:"kind"` // "store", "data" or "notification"
+	Configured    bool       `json:"configured"`
+	Status        string     `json:"status"` // See integrationhealth statuses
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	FailureStreak int        `json:"failure_streak"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"` // Absent for manual or on-demand integrations
+	Detail        string     `json:"detail,omitempty"`
+}
+
+// IntegrationHealthResponse summarizes a company's integrations
+type IntegrationHealthResponse struct {
+	Overall      string              `json:"overall"`
+	Integrations []IntegrationHealth `json:"integrations"`
+	CheckedAt    time.Time           `json:"checked_at"`
+}
+
+// FailingIntegration is an integration of any company that is failing, for admins
+type FailingIntegration struct {
+	CompanyID     string     `json:"company_id,omitempty"`
+	CompanyName   string     `json:"company_name,omitempty"`
+	Service       string     `json:"service"`
+	FailureStreak int        `json:"failure_streak"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// integrationRun is a stored integration_health row
+type integrationRun struct {
+	LastSuccessAt *time.Time
+	LastFailureAt *time.Time
+	LastError     string
+	FailureStreak int
+}
+
+// storePlatformLabels names store platforms whose names aren't simply capitalized
+var storePlatformLabels = map[string]string{
+	"woocommerce": "WooCommerce",
+	"shopify":     "Shopify",
+	"shopee":      "Shopee",
+	"tokopedia":   "Tokopedia",
+}
+
+// GetIntegrationHealth summarizes every integration the company relies on (store
+// connections, market data sources and email) with their last success, failure
+// streak and next scheduled run, so broken connections surface before forecasts go stale
+func (h *Handler) GetIntegrationHealth(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	runs, err := h.loadIntegrationRuns(ctx, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load integration health"), r)
+		return
+	}
+	now := time.Now()
+	var items []IntegrationHealth
+
+	// Store platforms
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT platform, COALESCE(status, ''), last_sync, COALESCE(error_message, '')
+		FROM integrations
+		WHERE company_id = $1
+		ORDER BY platform
+	`, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list integrations"), r)
+		return
+	}
+	connected := map[string]bool{}
+	for rows.Next() {
+		var platform, status, errorMessage string
+		var lastSync *time.Time
+		if rows.Scan(&platform, &status, &lastSync, &errorMessage) != nil {
+			continue
+		}
+		connected[platform] = true
+		run := runs[platform]
+		if lastSync != nil && (run.LastSuccessAt == nil || lastSync.After(*run.LastSuccessAt)) {
+			run.LastSuccessAt = lastSync
+		}
+		if run.LastError == "" {
+			run.LastError = errorMessage
+		}
+		item := newIntegrationHealth(platform, platformLabel(platform), "store", status != "disconnected", run,
+			storeSyncStaleAfter, status == "auth_failed", now)
+		item.Detail = "Synced on demand; run a sync to keep forecasts current"
+		if status == "auth_failed" {
+			item.Detail = "The platform rejected the stored API keys; rotate them to resume syncing"
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if !connected["woocommerce"] {
+		items = append(items, newIntegrationHealth("woocommerce", "WooCommerce", "store", false, integrationRun{}, 0, false, now))
+	}
+
+	// Market research (Exa), refreshed by the market monitor on the plan's interval
+	exa := newIntegrationHealth("exa", "Exa market research", "data", h.config.ExaAPIKey != "", runs["exa"], 0, false, now)
+	if exa.Configured {
+		plan := h.CompanyPlan(ctx, companyID)
+		interval := market.MonitorInterval(plan)
+		exa = newIntegrationHealth("exa", exa.Label, "data", true, runs["exa"], 2*interval, false, now)
+		if h.config.MarketMonitorHours > 0 {
+			var last time.Time
+			h.db.Pool().QueryRow(ctx, `
+				SELECT COALESCE(MAX(created_at), 'epoch'::timestamptz) FROM market_snapshots WHERE company_id = $1
+			`, companyID).Scan(&last)
+			next := last.Add(interval)
+			if next.Before(now) {
+				next = now.Add(time.Duration(h.config.MarketMonitorHours) * time.Hour)
+			}
+			exa.NextRunAt = &next
+		} else {
+			exa.Detail = "Market monitoring is off; research runs only on request"
+		}
+	}
+	items = append(items, exa)
+
+	items = append(items, IntegrationHealth{
+		Service: "google_trends",
+		Label:   "Google Trends",
+		Kind:    "data",
+		Status:  integrationhealth.StatusNotConfigured,
+		Detail:  "Not connected; market trends use sample data",
+	})
+
+	// Email is shared by the whole platform
+	email := newIntegrationHealth("email", "Email", "notification", h.config.SMTPHost != "", runs[platformScope+":email"], 0, false, now)
+	if !email.Configured {
+		email.Detail = "SMTP is not configured; emails are logged instead of sent"
+	}
+	items = append(items, email)
+
+	statuses := make([]string, len(items))
+	for i, item := range items {
+		statuses[i] = item.Status
+	}
+	h.respondJSON(w, r, http.StatusOK, IntegrationHealthResponse{
+		Overall:      integrationhealth.Overall(statuses),
+		Integrations: items,
+		CheckedAt:    now,
+	})
+}
+
+// AdminFailingIntegrations lists integrations of all companies that are failing
+// or had their credentials rejected, worst first (platform admin only)
+func (h *Handler) AdminFailingIntegrations(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT ih.company_id, COALESCE(c.name, ''), ih.service, ih.failure_streak,
+			ih.last_success_at, ih.last_failure_at, COALESCE(ih.last_error, '')
+		FROM integration_health ih
+		LEFT JOIN companies c ON c.id = ih.company_id
+		WHERE ih.failure_streak > 0
+		UNION ALL
+		SELECT i.company_id, COALESCE(c.name, ''), i.platform, 0, i.last_sync, i.auth_failed_at, COALESCE(i.error_message, '')
+		FROM integrations i
+		LEFT JOIN companies c ON c.id = i.company_id
+		WHERE i.status = 'auth_failed'
+			AND NOT EXISTS (
+				SELECT 1 FROM integration_health ih
+				WHERE ih.company_id = i.company_id AND ih.service = i.platform AND ih.failure_streak > 0
+			)
+		ORDER BY 4 DESC, 6 DESC NULLS LAST
+		LIMIT 200
+	`)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list failing integrations"), r)
+		return
+	}
+	defer rows.Close()
+
+	failing := []FailingIntegration{}
+	for rows.Next() {
+		var f FailingIntegration
+		if err := rows.Scan(&f.CompanyID, &f.CompanyName, &f.Service, &f.FailureStreak,
+			&f.LastSuccessAt, &f.LastFailureAt, &f.LastError); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan failing integration"), r)
+			return
+		}
+		failing = append(failing, f)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"integrations": failing})
+}
+
+// recordIntegrationRun stores the outcome of a run of an integration. Pass
+// platformScope as companyID for platform-wide services.
+func (h *Handler) recordIntegrationRun(ctx context.Context, companyID, service string, runErr error) {
+	var err error
+	if runErr == nil {
+		_, err = h.db.Pool().Exec(ctx, `
+			INSERT INTO integration_health (company_id, service, last_success_at, failure_streak, updated_at)
+			VALUES ($1, $2, NOW(), 0, NOW())
+			ON CONFLICT (company_id, service) DO UPDATE
+			SET last_success_at = NOW(), failure_streak = 0, updated_at = NOW()
+		`, companyID, service)
+	} else {
+		_, err = h.db.Pool().Exec(ctx, `
+			INSERT INTO integration_health (company_id, service, last_failure_at, last_error, failure_streak, updated_at)
+			VALUES ($1, $2, NOW(), $3, 1, NOW())
+			ON CONFLICT (company_id, service) DO UPDATE
+			SET last_failure_at = NOW(), last_error = EXCLUDED.last_error,
+				failure_streak = integration_health.failure_streak + 1, updated_at = NOW()
+		`, companyID, service, truncateRunes(runErr.Error(), 1000))
+	}
+	if err != nil {
+		logger.Warn("Failed to record integration run", "company_id", companyID, "service", service, "error", err.Error())
+	}
+}
+
+// sendEmail sends an email and records the outcome for the email integration
+func (h *Handler) sendEmail(ctx context.Context, msg mailer.Message) error {
+	err := h.mailer.Send(ctx, msg)
+	h.recordIntegrationRun(ctx, platformScope, "email", err)
+	return err
+}
+
+// loadIntegrationRuns returns the company's integration_health rows by service,
+// plus platform-wide rows keyed "<platformScope>:service"
+func (h *Handler) loadIntegrationRuns(ctx context.Context, companyID string) (map[string]integrationRun, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT company_id, service, last_success_at, last_failure_at, COALESCE(last_error, ''), failure_streak
+		FROM integration_health
+		WHERE company_id = $1 OR company_id = $2
+	`, companyID, platformScope)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := map[string]integrationRun{}
+	for rows.Next() {
+		var scope, service string
+		var run integrationRun
+		if err := rows.Scan(&scope, &service, &run.LastSuccessAt, &run.LastFailureAt, &run.LastError, &run.FailureStreak); err != nil {
+			return nil, err
+		}
+		if scope == platformScope {
+			service = platformScope + ":" + service
+		}
+		runs[service] = run
+	}
+	return runs, rows.Err()
+}
+
+func newIntegrationHealth(service, label, kind string, configured bool, run integrationRun, staleAfter time.Duration, authFailed bool, now time.Time) IntegrationHealth {
+	item := IntegrationHealth{
+		Service:    service,
+		Label:      label,
+		Kind:       kind,
+		Configured: configured,
+		Status: integrationhealth.Status(integrationhealth.Run{
+			Configured:    configured,
+			AuthFailed:    authFailed,
+			LastSuccessAt: run.LastSuccessAt,
+			LastFailureAt: run.LastFailureAt,
+			FailureStreak: run.FailureStreak,
+		}, staleAfter, now),
+	}
+	if configured {
+		item.LastSuccessAt = run.LastSuccessAt
+		item.LastFailureAt = run.LastFailureAt
+		item.FailureStreak = run.FailureStreak
+		if run.FailureStreak > 0 || authFailed {
+			item.LastError = run.LastError
+		}
+	}
+	return item
+}
+
+func platformLabel(platform string) string {
+	if label, ok := storePlatformLabels[platform]; ok {
+		return label
+	}
+	if platform == "" {
+		return platform
+	}
+	return strings.ToUpper(platform[:1]) + platform[1:]
+}
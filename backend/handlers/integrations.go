@@ -8,8 +8,10 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/bantuaku/backend/errors"
 	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/woocommerce"
 	"github.com/google/uuid"
 )
 
@@ -33,18 +35,18 @@ type WooCommerceSyncStatusResponse struct {
 func (h *Handler) WooCommerceConnect(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
 	var req WooCommerceConnectRequest
-	if err := parseJSON(r, &req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid request body", ""), r)
 		return
 	}
 
 	if req.StoreURL == "" || req.ConsumerKey == "" || req.ConsumerSecret == "" {
-		respondError(w, http.StatusBadRequest, "Store URL, consumer key, and consumer secret are required")
+		h.respondError(w, errors.NewValidationError("Store URL, consumer key, and consumer secret are required", ""), r)
 		return
 	}
 
@@ -58,13 +60,13 @@ func (h *Handler) WooCommerceConnect(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := client.Do(testReq)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to connect to WooCommerce store")
+		h.respondError(w, errors.NewValidationError("Failed to connect to WooCommerce store", ""), r)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		respondError(w, http.StatusBadRequest, "Invalid WooCommerce credentials")
+		h.respondError(w, errors.NewValidationError("Invalid WooCommerce credentials", ""), r)
 		return
 	}
 
@@ -98,11 +100,11 @@ func (h *Handler) WooCommerceConnect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to save integration")
+		h.respondError(w, errors.NewInternalError(err, "Failed to save integration"), r)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{
+	h.respondJSON(w, r, http.StatusOK, map[string]string{
 		"status":  "connected",
 		"message": "WooCommerce store connected successfully",
 	})
@@ -112,7 +114,7 @@ func (h *Handler) WooCommerceConnect(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) WooCommerceSyncStatus(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
@@ -124,7 +126,7 @@ func (h *Handler) WooCommerceSyncStatus(w http.ResponseWriter, r *http.Request)
 	`, storeID).Scan(&integration.ID, &integration.StoreID, &integration.Platform, &integration.Status, &integration.LastSync, &integration.ErrorMessage, &integration.Metadata)
 
 	if err != nil {
-		respondJSON(w, http.StatusOK, WooCommerceSyncStatusResponse{
+		h.respondJSON(w, r, http.StatusOK, WooCommerceSyncStatusResponse{
 			Status: "disconnected",
 		})
 		return
@@ -140,7 +142,7 @@ func (h *Handler) WooCommerceSyncStatus(w http.ResponseWriter, r *http.Request)
 		SELECT COUNT(*) FROM sales_history WHERE store_id = $1 AND source = 'woocommerce'
 	`, storeID).Scan(&orderCount)
 
-	respondJSON(w, http.StatusOK, WooCommerceSyncStatusResponse{
+	h.respondJSON(w, r, http.StatusOK, WooCommerceSyncStatusResponse{
 		Status:       integration.Status,
 		LastSync:     integration.LastSync,
 		ProductCount: productCount,
@@ -153,7 +155,7 @@ func (h *Handler) WooCommerceSyncStatus(w http.ResponseWriter, r *http.Request)
 func (h *Handler) WooCommerceSyncNow(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
@@ -165,7 +167,7 @@ func (h *Handler) WooCommerceSyncNow(w http.ResponseWriter, r *http.Request) {
 	`, storeID).Scan(&metadataJSON)
 
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "WooCommerce not connected")
+		h.respondError(w, errors.NewValidationError("WooCommerce not connected", ""), r)
 		return
 	}
 
@@ -187,10 +189,16 @@ func (h *Handler) WooCommerceSyncNow(w http.ResponseWriter, r *http.Request) {
 	productResp, err := client.Do(productReq)
 	if err != nil {
 		h.updateIntegrationError(r.Context(), storeID, "Failed to fetch products: "+err.Error())
-		respondError(w, http.StatusInternalServerError, "Failed to fetch products from WooCommerce")
+		h.recordIntegrationRun(r.Context(), storeID, "woocommerce", err)
+		h.respondError(w, errors.NewInternalError(err, "Failed to fetch products from WooCommerce"), r)
 		return
 	}
 	defer productResp.Body.Close()
+	if woocommerce.IsAuthFailure(productResp.StatusCode) {
+		h.integrationAuthFailed(r.Context(), storeID, "woocommerce", fmt.Sprintf("WooCommerce returned %d for the stored API keys", productResp.StatusCode))
+		h.respondError(w, errors.NewExternalServiceError("WooCommerce", "rejected the stored API keys; rotate them under Integrations", ""), r)
+		return
+	}
 
 	var wooProducts []struct {
 		ID    int64  `json:"id"`
@@ -230,10 +238,16 @@ func (h *Handler) WooCommerceSyncNow(w http.ResponseWriter, r *http.Request) {
 	orderResp, err := client.Do(orderReq)
 	if err != nil {
 		h.updateIntegrationError(r.Context(), storeID, "Failed to fetch orders: "+err.Error())
-		respondError(w, http.StatusInternalServerError, "Failed to fetch orders from WooCommerce")
+		h.recordIntegrationRun(r.Context(), storeID, "woocommerce", err)
+		h.respondError(w, errors.NewInternalError(err, "Failed to fetch orders from WooCommerce"), r)
 		return
 	}
 	defer orderResp.Body.Close()
+	if woocommerce.IsAuthFailure(orderResp.StatusCode) {
+		h.integrationAuthFailed(r.Context(), storeID, "woocommerce", fmt.Sprintf("WooCommerce returned %d for the stored API keys", orderResp.StatusCode))
+		h.respondError(w, errors.NewExternalServiceError("WooCommerce", "rejected the stored API keys; rotate them under Integrations", ""), r)
+		return
+	}
 
 	var wooOrders []struct {
 		ID          int64  `json:"id"`
@@ -280,8 +294,9 @@ func (h *Handler) WooCommerceSyncNow(w http.ResponseWriter, r *http.Request) {
 		UPDATE integrations SET last_sync = $1, error_message = ''
 		WHERE store_id = $2 AND platform = 'woocommerce'
 	`, now, storeID)
+	h.recordIntegrationRun(r.Context(), storeID, "woocommerce", nil)
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
 		"status":          "success",
 		"products_synced": syncedProducts,
 		"orders_synced":   syncedOrders,
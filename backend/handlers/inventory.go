@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/inventory"
+	"github.com/bantuaku/backend/services/quality"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// StockMovementRequest records stock received or corrected by hand
+type StockMovementRequest struct {
+	ProductID string  `json:"product_id" validate:"required"`
+	Kind      string  `json:"kind" validate:"required"` // purchase or adjustment
+	Quantity  float64 `json:"quantity"`                 // In the product's unit; adjustments are signed
+	Note      string  `json:"note" validate:"max:500"`
+}
+
+// UpdateStockRequest sets a product's counted stock and reorder point. Omitted
+// fields are kept.
+type UpdateStockRequest struct {
+	StockOnHand       *float64 `json:"stock_on_hand,omitempty"` // Counted stock; the difference is recorded as an adjustment
+	ReorderPoint      *float64 `json:"reorder_point,omitempty"` // Stock at or below which a low-stock notification is sent
+	ClearReorderPoint bool     `json:"clear_reorder_point,omitempty"`
+	Note              string   `json:"note" validate:"max:500"`
+}
+
+// inventoryStatusFilters are the ?status= values of ListInventory
+var inventoryStatusFilters = map[string]string{
+	inventory.StatusInStock:    `p.stock_on_hand > 0 AND (p.reorder_point IS NULL OR p.stock_on_hand > p.reorder_point)`,
+	inventory.StatusLow:        `p.stock_on_hand > 0 AND p.stock_on_hand <= p.reorder_point`,
+	inventory.StatusOut:        `p.stock_on_hand <= 0`,
+	inventory.StatusNotTracked: `p.stock_on_hand IS NULL`,
+	"tracked":                  `p.stock_on_hand IS NOT NULL`,
+}
+
+const stockLevelSelect = `
+	SELECT p.id, p.name, COALESCE(p.sku, ''), COALESCE(p.unit, 'pcs'), p.stock_on_hand::float8, p.reorder_point::float8,
+		(SELECT MAX(m.created_at) FROM stock_movements m WHERE m.product_id = p.id)
+	FROM products p
+	WHERE p.company_id = $1 AND COALESCE(p.is_active, true) AND p.merged_into IS NULL`
+
+// ListInventory returns the company's active products with their stock on
+// hand, status and forecast demand, by name. ?status= narrows the list to
+// in_stock, low, out_of_stock, not_tracked or tracked.
+func (h *Handler) ListInventory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	where := ""
+	if status := r.URL.Query().Get("status"); status != "" {
+		filter, ok := inventoryStatusFilters[status]
+		if !ok {
+			h.respondError(w, errors.NewValidationError("Invalid status", "status must be in_stock, low, out_of_stock, not_tracked or tracked"), r)
+			return
+		}
+		where = " AND " + filter
+	}
+
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM products p WHERE p.company_id = $1
+		AND COALESCE(p.is_active, true) AND p.merged_into IS NULL`+where, companyID).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count products"), r)
+		return
+	}
+	rows, err := h.db.Pool().Query(ctx, stockLevelSelect+where+`
+		ORDER BY p.name
+		LIMIT $2 OFFSET $3
+	`, companyID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list stock levels"), r)
+		return
+	}
+	levels, err := scanStockLevels(rows)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list stock levels"), r)
+		return
+	}
+	if err := h.addStockDemand(ctx, companyID, "", levels); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sales"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    levels,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// GetStockLevel returns a product's stock on hand, status and forecast demand
+func (h *Handler) GetStockLevel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	level, err := h.loadStockLevel(ctx, companyID, r.PathValue("product_id"))
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, level)
+}
+
+// UpdateStockLevel records a stock take and sets the reorder point. Setting
+// stock_on_hand on a product that was not tracked starts tracking it.
+func (h *Handler) UpdateStockLevel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	productID := r.PathValue("product_id")
+
+	var req UpdateStockRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.StockOnHand != nil && *req.StockOnHand < 0 {
+		h.respondError(w, errors.NewValidationError("Validation failed", "stock_on_hand: cannot be negative"), r)
+		return
+	}
+	if req.ReorderPoint != nil && *req.ReorderPoint < 0 {
+		h.respondError(w, errors.NewValidationError("Validation failed", "reorder_point: cannot be negative"), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var onHand *float64
+	err = tx.QueryRow(ctx, `
+		SELECT stock_on_hand::float8 FROM products
+		WHERE id = $1 AND company_id = $2 AND merged_into IS NULL
+		FOR UPDATE
+	`, productID, companyID).Scan(&onHand)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load stock"), r)
+		return
+	}
+
+	if req.ReorderPoint != nil || req.ClearReorderPoint {
+		_, err = tx.Exec(ctx, `
+			UPDATE products SET reorder_point = $3, low_stock_alerted_at = NULL, updated_at = NOW()
+			WHERE id = $1 AND company_id = $2
+		`, productID, companyID, req.ReorderPoint)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "update reorder point"), r)
+			return
+		}
+	}
+	if req.StockOnHand != nil {
+		current := 0.0
+		if onHand != nil {
+			current = *onHand
+		}
+		note := req.Note
+		if note == "" {
+			note = "Stock opname"
+		}
+		_, err = moveStock(ctx, tx, stockMove{
+			companyID: companyID, productID: productID, kind: inventory.KindAdjustment,
+			quantity: *req.StockOnHand - current, note: note, userID: middleware.GetUserID(ctx), track: true,
+		})
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "record stock take"), r)
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+	h.alertLowStock(ctx, companyID, productID)
+
+	level, err := h.loadStockLevel(ctx, companyID, productID)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, level)
+}
+
+// RecordStockMovement records stock received from a supplier (purchase) or a
+// signed correction (adjustment). Recording a movement for a product that was
+// not tracked starts tracking it from zero.
+func (h *Handler) RecordStockMovement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req StockMovementRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	delta, ok := inventory.Delta(req.Kind, req.Quantity)
+	if !ok {
+		h.respondError(w, errors.NewValidationError("Validation failed",
+			"kind must be purchase, with a positive quantity, or adjustment, with a non-zero quantity; sales are recorded from sales"), r)
+		return
+	}
+	if !h.productBelongsToCompany(ctx, req.ProductID, companyID) {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	movement, err := moveStock(ctx, tx, stockMove{
+		companyID: companyID, productID: req.ProductID, kind: req.Kind,
+		quantity: delta, note: req.Note, userID: middleware.GetUserID(ctx), track: true,
+	})
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "record stock movement"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+	h.alertLowStock(ctx, companyID, req.ProductID)
+
+	logger.Info("Stock movement recorded", "company_id", companyID, "product_id", req.ProductID,
+		"kind", req.Kind, "quantity", delta, "balance", movement.Balance)
+
+	h.respondJSON(w, r, http.StatusCreated, movement)
+}
+
+// ListStockMovements returns the company's stock movements, newest first.
+// ?product_id= and ?kind= narrow the list.
+func (h *Handler) ListStockMovements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	productID, kind := r.URL.Query().Get("product_id"), r.URL.Query().Get("kind")
+	switch kind {
+	case "", inventory.KindPurchase, inventory.KindSale, inventory.KindAdjustment:
+	default:
+		h.respondError(w, errors.NewValidationError("Invalid kind", "kind must be purchase, sale or adjustment"), r)
+		return
+	}
+
+	const where = `WHERE m.company_id = $1 AND ($2 = '' OR m.product_id = $2) AND ($3 = '' OR m.kind = $3)`
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM stock_movements m `+where, companyID, productID, kind).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count stock movements"), r)
+		return
+	}
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT m.id, m.product_id, p.name, m.kind, m.quantity::float8, m.balance::float8, m.sale_id,
+			COALESCE(m.note, ''), COALESCE(m.created_by, ''), m.created_at
+		FROM stock_movements m
+		JOIN products p ON p.id = m.product_id
+		`+where+`
+		ORDER BY m.created_at DESC, m.id DESC
+		LIMIT $4 OFFSET $5
+	`, companyID, productID, kind, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list stock movements"), r)
+		return
+	}
+	defer rows.Close()
+
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.ProductName, &m.Kind, &m.Quantity, &m.Balance, &m.SaleID,
+			&m.Note, &m.CreatedBy, &m.CreatedAt); err != nil {
+			continue
+		}
+		movements = append(movements, m)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    movements,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// loadStockLevel loads one product's stock level with its forecast demand
+func (h *Handler) loadStockLevel(ctx context.Context, companyID, productID string) (*models.StockLevel, error) {
+	rows, err := h.db.Pool().Query(ctx, stockLevelSelect+` AND p.id = $2`, companyID, productID)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load stock level")
+	}
+	levels, err := scanStockLevels(rows)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load stock level")
+	}
+	if len(levels) == 0 {
+		return nil, errors.NewNotFoundError("Product")
+	}
+	if err := h.addStockDemand(ctx, companyID, productID, levels); err != nil {
+		return nil, errors.NewDatabaseError(err, "load sales")
+	}
+	return &levels[0], nil
+}
+
+func scanStockLevels(rows pgx.Rows) ([]models.StockLevel, error) {
+	defer rows.Close()
+	levels := []models.StockLevel{}
+	for rows.Next() {
+		var l models.StockLevel
+		if err := rows.Scan(&l.ProductID, &l.ProductName, &l.SKU, &l.Unit, &l.OnHand, &l.ReorderPoint, &l.LastMovedAt); err != nil {
+			return nil, err
+		}
+		l.Status = inventory.Status(l.OnHand, l.ReorderPoint)
+		levels = append(levels, l)
+	}
+	return levels, rows.Err()
+}
+
+// addStockDemand fills in the forecast demand of levels, with the same checks
+// and rate as GetForecast, and how long the stock on hand lasts at it.
+// productID limits the sales loaded to one product.
+func (h *Handler) addStockDemand(ctx context.Context, companyID, productID string, levels []models.StockLevel) error {
+	if len(levels) == 0 {
+		return nil
+	}
+	now := time.Now().In(h.companyLocation(ctx, companyID))
+	points, _, err := h.loadDailySales(ctx, companyID, productID, localDate(now, now.Location()).AddDate(0, 0, -forecastWindowDays))
+	if err != nil {
+		return err
+	}
+	for i := range levels {
+		series := points[levels[i].ProductID]
+		if quality.Assess(series, now, forecastWindowDays).Blocking() {
+			continue
+		}
+		salesData := make([]float64, len(series))
+		for j, p := range series {
+			salesData[j] = p.Quantity
+		}
+		rate, _, _ := forecastDailyRate(salesData)
+		if rate <= 0 {
+			continue
+		}
+		levels[i].DailyDemand = rate
+		levels[i].Demand30d = int(math.Round(rate * 30))
+		if levels[i].OnHand != nil {
+			levels[i].DaysOfCover = inventory.DaysOfCover(*levels[i].OnHand, rate)
+		}
+	}
+	return nil
+}
+
+// stockMove is a change to a product's stock on hand
+type stockMove struct {
+	companyID string
+	productID string
+	kind      string
+	quantity  float64 // Signed, in the product's unit
+	saleID    *int64
+	note      string
+	userID    string
+	track     bool // Start tracking a product whose stock was never recorded, from zero
+}
+
+// moveStock applies m to the product's stock on hand and records the movement
+// in the same transaction. Products whose stock was never recorded are not
+// tracked; unless m.track is set they are left alone and nil is returned.
+func moveStock(ctx context.Context, tx pgx.Tx, m stockMove) (*models.StockMovement, error) {
+	var balance float64
+	err := tx.QueryRow(ctx, `
+		UPDATE products SET stock_on_hand = COALESCE(stock_on_hand, 0) + $3,
+			low_stock_alerted_at = CASE WHEN reorder_point IS NULL OR COALESCE(stock_on_hand, 0) + $3 > reorder_point
+				THEN NULL ELSE low_stock_alerted_at END,
+			updated_at = NOW()
+		WHERE id = $1 AND company_id = $2 AND (stock_on_hand IS NOT NULL OR $4)
+		RETURNING stock_on_hand::float8
+	`, m.productID, m.companyID, m.quantity, m.track).Scan(&balance)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	movement := &models.StockMovement{ProductID: m.productID, Kind: m.kind, Quantity: m.quantity, Balance: balance,
+		SaleID: m.saleID, Note: m.note, CreatedBy: m.userID}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO stock_movements (company_id, product_id, kind, quantity, balance, sale_id, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''))
+		RETURNING id, created_at
+	`, m.companyID, m.productID, m.kind, m.quantity, balance, m.saleID, m.note, m.userID).Scan(&movement.ID, &movement.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// saleStock takes a sale's quantity, in the product's unit, out of the
+// product's stock when the product is tracked. A negative quantity puts stock
+// back, for sales that were corrected or deleted.
+func saleStock(ctx context.Context, tx pgx.Tx, companyID, productID string, saleID *int64, quantity float64, note string) error {
+	_, err := moveStock(ctx, tx, stockMove{
+		companyID: companyID, productID: productID, kind: inventory.KindSale,
+		quantity: -quantity, saleID: saleID, note: note,
+	})
+	return err
+}
+
+// alertLowStock notifies the company about the given products that have
+// reached their reorder point. A product alerts once per dip: the mark is
+// cleared when its stock rises above the reorder point again.
+func (h *Handler) alertLowStock(ctx context.Context, companyID string, productIDs ...string) {
+	if len(productIDs) == 0 {
+		return
+	}
+	rows, err := h.db.Pool().Query(ctx, `
+		UPDATE products SET low_stock_alerted_at = NOW()
+		WHERE company_id = $1 AND id = ANY($2) AND stock_on_hand <= reorder_point AND low_stock_alerted_at IS NULL
+		RETURNING id, name, COALESCE(unit, 'pcs'), stock_on_hand::float8, reorder_point::float8, low_stock_alerted_at
+	`, companyID, productIDs)
+	if err != nil {
+		logger.Warn("Low stock check failed", "company_id", companyID, "error", err.Error())
+		return
+	}
+	type lowStock struct {
+		productID, name, unit string
+		onHand, reorderPoint  float64
+		alertedAt             time.Time
+	}
+	var low []lowStock
+	for rows.Next() {
+		var l lowStock
+		if rows.Scan(&l.productID, &l.name, &l.unit, &l.onHand, &l.reorderPoint, &l.alertedAt) == nil {
+			low = append(low, l)
+		}
+	}
+	rows.Close()
+
+	for _, l := range low {
+		title := fmt.Sprintf("Stok %s menipis", l.name)
+		if l.onHand <= 0 {
+			title = fmt.Sprintf("Stok %s habis", l.name)
+		}
+		_, err := h.notify(ctx, companyID, models.Notification{
+			Type:    models.NotificationLowStock,
+			Title:   title,
+			Message: fmt.Sprintf("Sisa %s %s, di bawah titik pemesanan ulang %s %s. Lihat rencana pembelian untuk jumlah yang perlu dipesan.", formatQuantity(l.onHand), l.unit, formatQuantity(l.reorderPoint), l.unit),
+			Data: map[string]interface{}{
+				"product_id":    l.productID,
+				"stock_on_hand": l.onHand,
+				"reorder_point": l.reorderPoint,
+			},
+		}, fmt.Sprintf("low_stock:%s:%d", l.productID, l.alertedAt.Unix()))
+		if err != nil {
+			logger.Warn("Failed to create low stock notification", "company_id", companyID, "product_id", l.productID, "error", err.Error())
+		}
+	}
+}
+
+// formatQuantity drops the decimals of whole quantities
+func formatQuantity(q float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", q), "0"), ".")
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/lifecycle"
+)
+
+// Kinds of background jobs started by requests, and how long each may run
+const (
+	jobPrediction    = "prediction"
+	jobExport        = "export"
+	jobBulkOperation = "bulk_operation"
+
+	predictionJobTimeout    = 20 * time.Minute
+	exportJobTimeout        = 30 * time.Minute
+	bulkOperationJobTimeout = 30 * time.Minute
+)
+
+// jobInfo identifies the background job a context belongs to
+type jobInfo struct {
+	kind string
+	id   string
+}
+
+type jobInfoKey struct{}
+
+// Lifecycle returns the manager of background work, stopped by main at shutdown
+func (h *Handler) Lifecycle() *lifecycle.Manager {
+	return h.lifecycle
+}
+
+// startJob runs a background job started by a request. The job's context is
+// built by jobContext, and shutdown cancels the job and waits for it.
+func (h *Handler) startJob(parent context.Context, kind, jobID string, timeout time.Duration, run func(ctx context.Context)) {
+	ctx, cancel := h.jobContext(parent, kind, jobID, timeout)
+	started := h.lifecycle.Go(func() {
+		defer cancel()
+		run(ctx)
+	})
+	if !started {
+		cancel()
+		jobLogger(ctx).Warn("Background job not started, server is shutting down")
+	}
+}
+
+// jobContext derives a background job's context from the request that started
+// it. It keeps the request's values (request ID, company, user and role, for
+// logs, audit entries and spend attribution) but not its cancellation: it ends
+// after timeout or when the server shuts down.
+func (h *Handler) jobContext(parent context.Context, kind, jobID string, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := context.WithValue(context.WithoutCancel(parent), jobInfoKey{}, jobInfo{kind: kind, id: jobID})
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	stop := context.AfterFunc(h.lifecycle.Context(), cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// jobLogger returns a logger tagged with the job in ctx and the request that
+// started it
+func jobLogger(ctx context.Context) *logger.Logger {
+	var args []any
+	if job, ok := ctx.Value(jobInfoKey{}).(jobInfo); ok {
+		args = append(args, "job", job.kind, "job_id", job.id)
+	}
+	if requestID, _ := ctx.Value(middleware.RequestIDKey).(string); requestID != "" {
+		args = append(args, "request_id", requestID)
+	}
+	if companyID := middleware.GetCompanyID(ctx); companyID != "" {
+		args = append(args, "company_id", companyID)
+	}
+	return logger.With(args...)
+}
+
+// interruptedByShutdown reports whether a job failed because the server is
+// shutting down rather than on its own
+func (h *Handler) interruptedByShutdown(ctx context.Context) bool {
+	return ctx.Err() != nil && h.lifecycle.Context().Err() != nil
+}
@@ -0,0 +1,520 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/dedupe"
+	"github.com/bantuaku/backend/services/embeddings"
+	"github.com/bantuaku/backend/services/kbli"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultKBLISearchLimit = 10
+	maxKBLISearchLimit     = 50
+	maxKBLISuggestScan     = 500 // Companies mapped per suggestion run
+	minKBLIEmbeddingScore  = 0.5
+	minKBLILexicalScore    = 0.35
+)
+
+// UpdateCompanyIndustryRequest sets a company's KBLI code. The label defaults
+// to the KBLI title.
+type UpdateCompanyIndustryRequest struct {
+	KBLICode string `json:"kbli_code" validate:"required,max:5"`
+	Label    string `json:"label" validate:"max:100"`
+}
+
+// SearchKBLI autocompletes KBLI categories by code prefix or title words
+// (?q=restoran, ?q=561), most specific first. ?level=kelompok limits results to
+// 5-digit codes.
+func (h *Handler) SearchKBLI(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	term := strings.TrimSpace(q.Get("q"))
+	if term == "" {
+		h.respondError(w, errors.NewValidationError("Missing q", "q is required"), r)
+		return
+	}
+	limit := defaultKBLISearchLimit
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxKBLISearchLimit {
+			h.respondError(w, errors.NewValidationError("Invalid limit", fmt.Sprintf("limit must be between 1 and %d", maxKBLISearchLimit)), r)
+			return
+		}
+		limit = n
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+	if _, _, _, err := kbli.ParseCode(strings.ToUpper(term)); err == nil || isDigits(term) {
+		args = append(args, strings.ToUpper(term)+"%")
+		conditions = append(conditions, fmt.Sprintf("code LIKE $%d", len(args)))
+	} else {
+		for _, word := range strings.Fields(term) {
+			args = append(args, "%"+word+"%")
+			conditions = append(conditions, fmt.Sprintf("title ILIKE $%d", len(args)))
+		}
+	}
+	if level := q.Get("level"); level != "" {
+		args = append(args, level)
+		conditions = append(conditions, fmt.Sprintf("level = $%d", len(args)))
+	}
+
+	args = append(args, limit)
+	rows, err := h.db.Pool().Query(r.Context(), fmt.Sprintf(`
+		SELECT code, title, level, section, COALESCE(parent_code, '') FROM kbli_categories
+		WHERE %s
+		ORDER BY length(code) DESC, code
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args)), args...)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "search KBLI"), r)
+		return
+	}
+	defer rows.Close()
+
+	results := []models.KBLICategory{}
+	for rows.Next() {
+		var c models.KBLICategory
+		if err := rows.Scan(&c.Code, &c.Title, &c.Level, &c.Section, &c.ParentCode); err != nil {
+			continue
+		}
+		results = append(results, c)
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"results": results})
+}
+
+// GetCompanyIndustry returns the authenticated company's industry, its KBLI
+// code and any suggested code awaiting confirmation
+func (h *Handler) GetCompanyIndustry(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	ind, err := h.loadCompanyIndustry(r.Context(), companyID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company industry"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, ind)
+}
+
+// UpdateCompanyIndustry sets the authenticated company's KBLI code and label.
+// A pending suggestion is confirmed if it proposed the same code, otherwise rejected.
+func (h *Handler) UpdateCompanyIndustry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req UpdateCompanyIndustryRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	req.KBLICode = strings.ToUpper(strings.TrimSpace(req.KBLICode))
+	req.Label = strings.TrimSpace(req.Label)
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	if err := h.applyKBLICode(ctx, companyID, req.KBLICode, req.Label, ""); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	ind, err := h.loadCompanyIndustry(ctx, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company industry"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, ind)
+}
+
+// GenerateKBLISuggestions proposes KBLI codes for companies that only have a
+// free-text industry (platform admin only). Industries are matched to KBLI
+// titles by embedding when AI is configured, otherwise by spelling. Suggestions
+// are applied only once confirmed, by an admin or by the company itself.
+func (h *Handler) GenerateKBLISuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	categories, err := h.kbliMatchCategories(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load KBLI categories"), r)
+		return
+	}
+	if len(categories) == 0 {
+		h.respondError(w, errors.NewBusinessRuleError("kbli_not_loaded", "No KBLI categories are loaded"), r)
+		return
+	}
+
+	// Companies without a code or a pending suggestion, skipping industries
+	// whose suggestion was rejected
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT c.id, TRIM(c.industry) FROM companies c
+		WHERE c.industry_kbli IS NULL AND TRIM(COALESCE(c.industry, '')) <> '' AND c.merged_into IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM kbli_suggestions s WHERE s.company_id = c.id
+					AND (s.status = 'pending' OR (s.status = 'rejected' AND s.industry = TRIM(c.industry)))
+			)
+		ORDER BY c.created_at DESC
+		LIMIT $1
+	`, maxKBLISuggestScan)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list unmapped companies"), r)
+		return
+	}
+	companies := map[string]string{}
+	var industries []string
+	seen := map[string]bool{}
+	for rows.Next() {
+		var id, industry string
+		if err := rows.Scan(&id, &industry); err != nil {
+			continue
+		}
+		companies[id] = industry
+		if key := strings.ToLower(industry); !seen[key] {
+			seen[key] = true
+			industries = append(industries, industry)
+		}
+	}
+	rows.Close()
+
+	matches, method := h.matchKBLI(ctx, industries, categories)
+	suggested := 0
+	for id, industry := range companies {
+		m, ok := matches[strings.ToLower(industry)]
+		if !ok {
+			continue
+		}
+		_, err := h.db.Pool().Exec(ctx, `
+			INSERT INTO kbli_suggestions (id, company_id, industry, kbli_code, score, method)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT DO NOTHING
+		`, uuid.New().String(), id, industry, m.code, m.score, method)
+		if err != nil {
+			logger.Warn("Failed to save KBLI suggestion", "company_id", id, "error", err.Error())
+			continue
+		}
+		suggested++
+	}
+
+	logger.Info("KBLI suggestions generated", "scanned", len(companies), "suggested", suggested, "method", method)
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"companies_scanned": len(companies),
+		"suggested":         suggested,
+		"method":            method,
+	})
+}
+
+// ListKBLISuggestions lists suggestions, newest first, filtered by ?status=
+// (default pending) (platform admin only)
+func (h *Handler) ListKBLISuggestions(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	if status != "pending" && status != "confirmed" && status != "rejected" {
+		h.respondError(w, errors.NewValidationError("Invalid status", "status must be pending, confirmed or rejected"), r)
+		return
+	}
+
+	ctx := r.Context()
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM kbli_suggestions WHERE status = $1`, status).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count KBLI suggestions"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT s.id, s.company_id, c.name, s.industry, s.kbli_code, k.title, s.score, s.method, s.status,
+			s.decided_at, s.created_at
+		FROM kbli_suggestions s
+		JOIN companies c ON c.id = s.company_id
+		JOIN kbli_categories k ON k.code = s.kbli_code
+		WHERE s.status = $1
+		ORDER BY s.created_at DESC
+		LIMIT $2 OFFSET $3
+	`, status, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list KBLI suggestions"), r)
+		return
+	}
+	defer rows.Close()
+
+	items := []models.KBLISuggestion{}
+	for rows.Next() {
+		var s models.KBLISuggestion
+		if err := rows.Scan(&s.ID, &s.CompanyID, &s.CompanyName, &s.Industry, &s.KBLICode, &s.KBLITitle, &s.Score,
+			&s.Method, &s.Status, &s.DecidedAt, &s.CreatedAt); err != nil {
+			continue
+		}
+		items = append(items, s)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    items,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// ConfirmKBLISuggestion applies a pending suggestion's code to its company,
+// keeping the company's free-text label (platform admin only)
+func (h *Handler) ConfirmKBLISuggestion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var companyID, code, industry string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT company_id, kbli_code, industry FROM kbli_suggestions WHERE id = $1 AND status = 'pending'
+	`, r.PathValue("id")).Scan(&companyID, &code, &industry)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Pending KBLI suggestion"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load KBLI suggestion"), r)
+		return
+	}
+
+	if err := h.applyKBLICode(ctx, companyID, code, industry, middleware.GetUserID(ctx)); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"status": "confirmed", "company_id": companyID, "kbli_code": code})
+}
+
+// RejectKBLISuggestion rejects a pending suggestion; the same industry text is
+// not suggested again (platform admin only)
+func (h *Handler) RejectKBLISuggestion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tag, err := h.db.Pool().Exec(ctx, `
+		UPDATE kbli_suggestions SET status = 'rejected', decided_by = NULLIF($2, ''), decided_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, r.PathValue("id"), middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "reject KBLI suggestion"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Pending KBLI suggestion"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"status": "rejected"})
+}
+
+// applyKBLICode sets a company's KBLI code and label (the KBLI title when
+// label is empty) and settles its pending suggestion
+func (h *Handler) applyKBLICode(ctx context.Context, companyID, code, label, decidedBy string) error {
+	var title string
+	err := h.db.Pool().QueryRow(ctx, `SELECT title FROM kbli_categories WHERE code = $1`, code).Scan(&title)
+	if err == pgx.ErrNoRows {
+		return errors.NewValidationError("Unknown KBLI code", fmt.Sprintf("%s is not a KBLI 2020 code", code))
+	}
+	if err != nil {
+		return errors.NewDatabaseError(err, "load KBLI category")
+	}
+	if label == "" {
+		label = title
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return errors.NewDatabaseError(err, "begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE companies SET industry = $2, industry_kbli = $3, updated_at = NOW() WHERE id = $1
+	`, companyID, label, code)
+	if err != nil {
+		return errors.NewDatabaseError(err, "update company industry")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.NewNotFoundError("Company")
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE kbli_suggestions
+		SET status = CASE WHEN kbli_code = $2 THEN 'confirmed' ELSE 'rejected' END,
+			decided_by = NULLIF($3, ''), decided_at = NOW()
+		WHERE company_id = $1 AND status = 'pending'
+	`, companyID, code, decidedBy)
+	if err != nil {
+		return errors.NewDatabaseError(err, "settle KBLI suggestion")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return errors.NewDatabaseError(err, "commit transaction")
+	}
+
+	logger.Info("Company KBLI code set", "company_id", companyID, "kbli_code", code, "decided_by", decidedBy)
+	return nil
+}
+
+// loadCompanyIndustry reads a company's industry, KBLI code and pending suggestion
+func (h *Handler) loadCompanyIndustry(ctx context.Context, companyID string) (*models.CompanyIndustry, error) {
+	var ind models.CompanyIndustry
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(c.industry, ''), COALESCE(c.industry_kbli, ''), COALESCE(k.title, ''), COALESCE(k.section, '')
+		FROM companies c LEFT JOIN kbli_categories k ON k.code = c.industry_kbli
+		WHERE c.id = $1
+	`, companyID).Scan(&ind.Industry, &ind.KBLICode, &ind.KBLITitle, &ind.Section)
+	if err != nil {
+		return nil, err
+	}
+
+	var s models.KBLISuggestion
+	err = h.db.Pool().QueryRow(ctx, `
+		SELECT s.id, s.company_id, s.industry, s.kbli_code, k.title, s.score, s.method, s.status, s.created_at
+		FROM kbli_suggestions s JOIN kbli_categories k ON k.code = s.kbli_code
+		WHERE s.company_id = $1 AND s.status = 'pending'
+	`, companyID).Scan(&s.ID, &s.CompanyID, &s.Industry, &s.KBLICode, &s.KBLITitle, &s.Score, &s.Method, &s.Status, &s.CreatedAt)
+	if err == nil {
+		ind.Suggestion = &s
+	} else if err != pgx.ErrNoRows {
+		return nil, err
+	}
+	return &ind, nil
+}
+
+// kbliMatchCategories returns the categories industries are matched against:
+// the 5-digit codes when the full list is loaded, otherwise whatever is
+// (at least the sections)
+func (h *Handler) kbliMatchCategories(ctx context.Context) ([]models.KBLICategory, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT code, title, level, section FROM kbli_categories
+		WHERE level = $1 OR NOT EXISTS (SELECT 1 FROM kbli_categories WHERE level = $1)
+		ORDER BY code
+	`, kbli.LevelSubclass)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var categories []models.KBLICategory
+	for rows.Next() {
+		var c models.KBLICategory
+		if err := rows.Scan(&c.Code, &c.Title, &c.Level, &c.Section); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// kbliMatch is the best category for an industry text
+type kbliMatch struct {
+	code  string
+	score float64
+}
+
+// matchKBLI finds the best category for each industry, keyed by lowercased
+// industry. It returns the method used.
+func (h *Handler) matchKBLI(ctx context.Context, industries []string, categories []models.KBLICategory) (map[string]kbliMatch, string) {
+	matches := map[string]kbliMatch{}
+	if len(industries) == 0 {
+		return matches, "lexical"
+	}
+
+	if h.config.KolosalAPIKey != "" {
+		titles := make([]string, len(categories))
+		for i, c := range categories {
+			titles[i] = c.Title
+		}
+		catVectors, err := h.embedTexts(ctx, embeddings.NamespaceKBLI, "kbli:categories", titles)
+		var indVectors [][]float32
+		if err == nil {
+			indVectors, err = h.embedTexts(ctx, embeddings.NamespaceKBLI, "kbli:industries", industries)
+		}
+		if err == nil {
+			for i, industry := range industries {
+				if best := embeddings.TopK(indVectors[i], catVectors, 1, minKBLIEmbeddingScore); len(best) == 1 {
+					matches[strings.ToLower(industry)] = kbliMatch{categories[best[0].Index].Code, best[0].Score}
+				}
+			}
+			return matches, "embedding"
+		}
+		logger.Warn("KBLI embedding failed, matching by spelling", "error", err.Error())
+	}
+
+	for _, industry := range industries {
+		var best kbliMatch
+		for _, c := range categories {
+			score := dedupe.NameSimilarity(industry, c.Title)
+			if strings.EqualFold(industry, c.Title) {
+				score = 1
+			}
+			if score > best.score {
+				best = kbliMatch{c.Code, score}
+			}
+		}
+		if best.score >= minKBLILexicalScore {
+			matches[strings.ToLower(industry)] = best
+		}
+	}
+	return matches, "lexical"
+}
+
+// ImportKBLI upserts KBLI categories, parents first. It is run by the `kbli` subcommand.
+func (h *Handler) ImportKBLI(ctx context.Context, list []kbli.Category) (int, error) {
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Codes lengthen with depth, so going by length inserts parents first
+	for length := 1; length <= 5; length++ {
+		for _, c := range list {
+			if len(c.Code) != length {
+				continue
+			}
+			_, err := tx.Exec(ctx, `
+				INSERT INTO kbli_categories (code, title, level, section, parent_code) VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+				ON CONFLICT (code) DO UPDATE SET title = EXCLUDED.title
+			`, c.Code, c.Title, c.Level, c.Section, c.ParentCode)
+			if err != nil {
+				return 0, fmt.Errorf("KBLI %s: %w", c.Code, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	logger.Info("KBLI categories imported", "count", len(list))
+	return len(list), nil
+}
+
+// isDigits reports whether s is non-empty and all ASCII digits
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,588 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/embeddings"
+	"github.com/bantuaku/backend/services/kb"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Knowledge-base retrieval settings
+const (
+	kbCitationMinScore       = 0.3
+	kbExcerptRunes           = 300
+	maxKBRegulationCitations = 3 // Guides added to the regulations step of a prediction
+	maxKBChatCitations       = 5
+)
+
+// kbArticleColumns are selected by scanKBArticle, in order
+const kbArticleColumns = `id, slug, title, category, summary, status, pinned, version, chunk_count,
+	indexed_version IS NOT DISTINCT FROM version, published_at, created_at, updated_at`
+
+// KBArticleRequest creates or replaces a knowledge-base article
+type KBArticleRequest struct {
+	Title    string `json:"title" validate:"required,max:200"`
+	Slug     string `json:"slug" validate:"max:80"` // Defaults to the slugified title
+	Category string `json:"category" validate:"required,oneof:perizinan|pemasaran|keuangan|operasional|umum"`
+	Summary  string `json:"summary" validate:"max:1000"`
+	Body     string `json:"body" validate:"required"`
+	Status   string `json:"status" validate:"oneof:draft|published|archived"` // Defaults to draft
+	Pinned   bool   `json:"pinned"`
+}
+
+// ListKBArticles returns published articles, pinned first (?category=, ?q= to
+// match titles and summaries, ?page=, ?page_size=)
+func (h *Handler) ListKBArticles(w http.ResponseWriter, r *http.Request) {
+	h.listKBArticles(w, r, kb.StatusPublished)
+}
+
+// GetKBArticle returns a published article with its body by slug
+func (h *Handler) GetKBArticle(w http.ResponseWriter, r *http.Request) {
+	article, err := h.loadKBArticle(r.Context(), `slug = $1 AND status = 'published'`, r.PathValue("slug"))
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Article"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load article"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, article)
+}
+
+// AdminListKBArticles returns articles in any status (?status=, ?category=, ?q=,
+// ?page=, ?page_size=) (platform admin only)
+func (h *Handler) AdminListKBArticles(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status != "" && status != kb.StatusDraft && status != kb.StatusPublished && status != kb.StatusArchived {
+		h.respondError(w, errors.NewValidationError("Invalid status", "status must be draft, published or archived"), r)
+		return
+	}
+	h.listKBArticles(w, r, status)
+}
+
+// AdminGetKBArticle returns an article with its body by ID (platform admin only)
+func (h *Handler) AdminGetKBArticle(w http.ResponseWriter, r *http.Request) {
+	article, err := h.loadKBArticle(r.Context(), `id = $1`, r.PathValue("id"))
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Article"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load article"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, article)
+}
+
+// CreateKBArticle saves a new article as version 1 and, when published, embeds
+// it for retrieval (platform admin only)
+func (h *Handler) CreateKBArticle(w http.ResponseWriter, r *http.Request) {
+	req, err := h.parseKBArticle(r, "")
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	id := uuid.New().String()
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO kb_articles (id, slug, title, category, summary, body, status, pinned, version, created_by, updated_by,
+			published_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 1, $9, $9, CASE WHEN $7 = 'published' THEN NOW() END, NOW(), NOW())
+	`, id, req.Slug, req.Title, req.Category, req.Summary, req.Body, req.Status, req.Pinned, userID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create article"), r)
+		return
+	}
+	if err := insertKBVersion(ctx, tx, id, 1, req, userID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save article version"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.respondKBArticle(w, r, id, http.StatusCreated)
+}
+
+// UpdateKBArticle replaces an article. Changed content is saved as a new
+// version; the embeddings follow the published content (platform admin only).
+func (h *Handler) UpdateKBArticle(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	req, err := h.parseKBArticle(r, id)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var current KBArticleRequest
+	var version int
+	err = tx.QueryRow(ctx, `
+		SELECT title, category, summary, body, version FROM kb_articles WHERE id = $1 FOR UPDATE
+	`, id).Scan(&current.Title, &current.Category, &current.Summary, &current.Body, &version)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Article"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load article"), r)
+		return
+	}
+	if current.Title != req.Title || current.Category != req.Category || current.Summary != req.Summary || current.Body != req.Body {
+		version++
+		if err := insertKBVersion(ctx, tx, id, version, req, userID); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "save article version"), r)
+			return
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE kb_articles SET slug = $2, title = $3, category = $4, summary = $5, body = $6, status = $7, pinned = $8,
+			version = $9, updated_by = $10, updated_at = NOW(),
+			published_at = CASE WHEN $7 = 'published' THEN COALESCE(published_at, NOW()) ELSE published_at END
+		WHERE id = $1
+	`, id, req.Slug, req.Title, req.Category, req.Summary, req.Body, req.Status, req.Pinned, version, userID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update article"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.respondKBArticle(w, r, id, http.StatusOK)
+}
+
+// DeleteKBArticle removes an article, its history and its embeddings (platform admin only)
+func (h *Handler) DeleteKBArticle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	tag, err := h.db.Pool().Exec(ctx, `DELETE FROM kb_articles WHERE id = $1`, id)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete article"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Article"), r)
+		return
+	}
+	if err := h.clearKBChunks(ctx, id); err != nil {
+		logger.Warn("Failed to remove article embeddings", "article_id", id, "error", err.Error())
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListKBArticleVersions returns an article's saved revisions, newest first (platform admin only)
+func (h *Handler) ListKBArticleVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	var exists bool
+	if err := h.db.Pool().QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM kb_articles WHERE id = $1)`, id).Scan(&exists); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load article"), r)
+		return
+	}
+	if !exists {
+		h.respondError(w, errors.NewNotFoundError("Article"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT version, title, category, summary, body, COALESCE(edited_by, ''), created_at
+		FROM kb_article_versions
+		WHERE article_id = $1
+		ORDER BY version DESC
+	`, id)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list article versions"), r)
+		return
+	}
+	defer rows.Close()
+
+	versions := []models.KBArticleVersion{}
+	for rows.Next() {
+		var v models.KBArticleVersion
+		if err := rows.Scan(&v.Version, &v.Title, &v.Category, &v.Summary, &v.Body, &v.EditedBy, &v.CreatedAt); err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"versions": versions,
+	})
+}
+
+// RestoreKBArticleVersion makes an earlier revision's content the article's
+// newest version, keeping the history intact (platform admin only)
+func (h *Handler) RestoreKBArticleVersion(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	restore, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || restore < 1 {
+		h.respondError(w, errors.NewValidationError("Invalid version", "version must be a positive integer"), r)
+		return
+	}
+
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var version int
+	if err := tx.QueryRow(ctx, `SELECT version FROM kb_articles WHERE id = $1 FOR UPDATE`, id).Scan(&version); err != nil {
+		if err == pgx.ErrNoRows {
+			h.respondError(w, errors.NewNotFoundError("Article"), r)
+		} else {
+			h.respondError(w, errors.NewDatabaseError(err, "load article"), r)
+		}
+		return
+	}
+	var content KBArticleRequest
+	err = tx.QueryRow(ctx, `
+		SELECT title, category, summary, body FROM kb_article_versions WHERE article_id = $1 AND version = $2
+	`, id, restore).Scan(&content.Title, &content.Category, &content.Summary, &content.Body)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Article version"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load article version"), r)
+		return
+	}
+	if restore == version {
+		h.respondError(w, errors.NewValidationError("Invalid version", "version is already the current version"), r)
+		return
+	}
+
+	version++
+	if err := insertKBVersion(ctx, tx, id, version, &content, userID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save article version"), r)
+		return
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE kb_articles SET title = $2, category = $3, summary = $4, body = $5, version = $6, updated_by = $7, updated_at = NOW()
+		WHERE id = $1
+	`, id, content.Title, content.Category, content.Summary, content.Body, version, userID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "restore article version"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.respondKBArticle(w, r, id, http.StatusOK)
+}
+
+// IndexKBArticle retries embedding an article whose last indexing failed (platform admin only)
+func (h *Handler) IndexKBArticle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if _, err := h.loadKBArticle(ctx, `id = $1`, id); err != nil {
+		if err == pgx.ErrNoRows {
+			h.respondError(w, errors.NewNotFoundError("Article"), r)
+		} else {
+			h.respondError(w, errors.NewDatabaseError(err, "load article"), r)
+		}
+		return
+	}
+	if h.config.KolosalAPIKey == "" {
+		h.respondError(w, errors.NewExternalServiceError("Kolosal.ai", "Embeddings are not configured", "KOLOSAL_API_KEY is not set"), r)
+		return
+	}
+	if err := h.indexKBArticle(ctx, id); err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Article indexing failed"), r)
+		return
+	}
+	h.respondKBArticle(w, r, id, http.StatusOK)
+}
+
+// knowledgeBaseCitations retrieves the published articles most relevant to
+// query, pinned articles first
+func (h *Handler) knowledgeBaseCitations(ctx context.Context, client *kolosal.Client, query string, limit int) ([]models.KBCitation, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, slug, title, pinned FROM kb_articles WHERE status = 'published' AND indexed_version IS NOT NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	articles := map[string]models.KBCitation{}
+	ids := []string{}
+	for rows.Next() {
+		var c models.KBCitation
+		if rows.Scan(&c.ArticleID, &c.Slug, &c.Title, &c.Pinned) == nil {
+			articles[c.ArticleID] = c
+			ids = append(ids, c.ArticleID)
+		}
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	matches, err := h.searchVectors(ctx, client, embeddings.NamespaceKnowledgeBase, ids, query, limit*3, kbCitationMinScore)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]kb.Hit, 0, len(matches))
+	excerpts := map[string]string{}
+	for _, m := range matches {
+		hits = append(hits, kb.Hit{ArticleID: m.SourceID, Pinned: articles[m.SourceID].Pinned, Score: m.Score})
+		if _, ok := excerpts[m.SourceID]; !ok {
+			excerpts[m.SourceID] = truncateRunes(m.Content, kbExcerptRunes) // Matches are best first
+		}
+	}
+
+	citations := []models.KBCitation{}
+	for _, hit := range kb.Rank(hits, limit) {
+		c := articles[hit.ArticleID]
+		c.URL = strings.TrimRight(h.config.AppURL, "/") + "/kb/" + c.Slug
+		c.Excerpt = excerpts[hit.ArticleID]
+		c.Score = hit.Score
+		citations = append(citations, c)
+	}
+	return citations, nil
+}
+
+// indexKBArticle embeds a published article's current version, or removes the
+// embeddings of an article that is not published
+func (h *Handler) indexKBArticle(ctx context.Context, id string) error {
+	var title, summary, body, status string
+	var version int
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT title, summary, body, status, version FROM kb_articles WHERE id = $1
+	`, id).Scan(&title, &summary, &body, &status, &version)
+	if err != nil {
+		return err
+	}
+	if status != kb.StatusPublished {
+		return h.clearKBChunks(ctx, id)
+	}
+
+	text := title + "\n\n"
+	if summary != "" {
+		text += summary + "\n\n"
+	}
+	chunks := embeddings.Chunk(text+body, embeddings.DefaultChunkSize, embeddings.DefaultChunkOverlap)
+	var usage models.EmbeddingUsage
+	if err := h.storeChunks(aispend.WithFeature(ctx, aispend.FeatureKnowledgeBase, id), h.kolosalClient(), embeddings.NamespaceKnowledgeBase, id, chunks, &usage); err != nil {
+		return err
+	}
+	if _, err := h.db.Pool().Exec(ctx, `
+		UPDATE kb_articles SET chunk_count = $2, indexed_version = $3 WHERE id = $1
+	`, id, len(chunks), version); err != nil {
+		return err
+	}
+	if _, err := h.collectOrphanEmbeddings(ctx, embeddings.NamespaceKnowledgeBase); err != nil {
+		logger.Warn("Failed to collect knowledge base embeddings", "error", err.Error())
+	}
+	h.invalidateAnswerCache(ctx, "knowledge base article indexed")
+	return nil
+}
+
+// clearKBChunks removes an article from retrieval
+func (h *Handler) clearKBChunks(ctx context.Context, id string) error {
+	tag, err := h.db.Pool().Exec(ctx, `
+		DELETE FROM embedding_chunks WHERE namespace = $1 AND source_id = $2
+	`, embeddings.NamespaceKnowledgeBase, id)
+	if err != nil {
+		return err
+	}
+	if _, err := h.db.Pool().Exec(ctx, `
+		UPDATE kb_articles SET chunk_count = 0, indexed_version = NULL WHERE id = $1
+	`, id); err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		if _, err := h.collectOrphanEmbeddings(ctx, embeddings.NamespaceKnowledgeBase); err != nil {
+			return err
+		}
+		h.invalidateAnswerCache(ctx, "knowledge base article withdrawn")
+	}
+	return nil
+}
+
+// respondKBArticle brings an article's embeddings in line with its saved
+// status and content and responds with it. A failed indexing does not undo
+// the save; the article reports indexed false until it is retried.
+func (h *Handler) respondKBArticle(w http.ResponseWriter, r *http.Request, id string, status int) {
+	ctx := r.Context()
+	article, err := h.loadKBArticle(ctx, `id = $1`, id)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load article"), r)
+		return
+	}
+
+	if article.Status != kb.StatusPublished {
+		err = h.clearKBChunks(ctx, id)
+	} else if !article.Indexed && h.config.KolosalAPIKey != "" {
+		err = h.indexKBArticle(ctx, id)
+	}
+	if err != nil {
+		logger.Warn("Failed to index knowledge base article", "article_id", id, "error", err.Error())
+	}
+	if reloaded, err := h.loadKBArticle(ctx, `id = $1`, id); err == nil {
+		article = reloaded
+	}
+	h.respondJSON(w, r, status, article)
+}
+
+func (h *Handler) listKBArticles(w http.ResponseWriter, r *http.Request, status string) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	category := r.URL.Query().Get("category")
+	if category != "" && !kb.ValidCategory(category) {
+		h.respondError(w, errors.NewValidationError("Invalid category", "category must be one of "+strings.Join(kb.Categories, ", ")), r)
+		return
+	}
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	ctx := r.Context()
+	where := `($1 = '' OR status = $1) AND ($2 = '' OR category = $2)
+		AND ($3 = '' OR title ILIKE '%' || $3 || '%' OR summary ILIKE '%' || $3 || '%')`
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM kb_articles WHERE `+where, status, category, query).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count articles"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT `+kbArticleColumns+`
+		FROM kb_articles
+		WHERE `+where+`
+		ORDER BY pinned DESC, COALESCE(published_at, updated_at) DESC
+		LIMIT $4 OFFSET $5
+	`, status, category, query, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list articles"), r)
+		return
+	}
+	defer rows.Close()
+
+	items := []models.KBArticle{}
+	for rows.Next() {
+		a, err := scanKBArticle(rows)
+		if err != nil {
+			continue
+		}
+		items = append(items, *a)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    items,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+		Extra: map[string]interface{}{
+			"categories": kb.Categories,
+		},
+	})
+}
+
+// loadKBArticle loads one article with its body matching where, which takes one argument
+func (h *Handler) loadKBArticle(ctx context.Context, where string, arg string) (*models.KBArticle, error) {
+	var body string
+	article, err := scanKBArticle(h.db.Pool().QueryRow(ctx, `
+		SELECT `+kbArticleColumns+`, body FROM kb_articles WHERE `+where, arg), &body)
+	if err != nil {
+		return nil, err
+	}
+	article.Body = body
+	return article, nil
+}
+
+// parseKBArticle validates an article request, defaulting its slug and status,
+// and rejects slugs used by another article than id
+func (h *Handler) parseKBArticle(r *http.Request, id string) (*KBArticleRequest, error) {
+	var req KBArticleRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		return nil, err
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.Summary = strings.TrimSpace(req.Summary)
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Status == "" {
+		req.Status = kb.StatusDraft
+	}
+	if err := validation.Validate(&req); err != nil {
+		return nil, err
+	}
+
+	if req.Slug == "" {
+		req.Slug = kb.Slugify(req.Title)
+	} else if kb.Slugify(req.Slug) != req.Slug {
+		return nil, errors.NewValidationError("Invalid slug", "slug must be lowercase letters, digits and single hyphens")
+	}
+	if req.Slug == "" {
+		return nil, errors.NewValidationError("Invalid slug", "title has no letters or digits to build a slug from")
+	}
+	var other string
+	err := h.db.Pool().QueryRow(r.Context(), `SELECT id FROM kb_articles WHERE slug = $1 AND id <> $2`, req.Slug, id).Scan(&other)
+	if err == nil {
+		return nil, errors.NewConflictError("Slug already used", "another article uses the slug "+req.Slug)
+	}
+	if err != pgx.ErrNoRows {
+		return nil, errors.NewDatabaseError(err, "check article slug")
+	}
+	return &req, nil
+}
+
+func insertKBVersion(ctx context.Context, tx pgx.Tx, articleID string, version int, content *KBArticleRequest, userID string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO kb_article_versions (article_id, version, title, category, summary, body, edited_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NOW())
+	`, articleID, version, content.Title, content.Category, content.Summary, content.Body, userID)
+	return err
+}
+
+// scanKBArticle scans kbArticleColumns followed by any extra destinations
+func scanKBArticle(row pgx.Row, extra ...interface{}) (*models.KBArticle, error) {
+	var a models.KBArticle
+	dest := append([]interface{}{&a.ID, &a.Slug, &a.Title, &a.Category, &a.Summary, &a.Status, &a.Pinned, &a.Version,
+		&a.ChunkCount, &a.Indexed, &a.PublishedAt, &a.CreatedAt, &a.UpdatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
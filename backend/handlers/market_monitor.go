@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/market"
+
+	"github.com/google/uuid"
+)
+
+// marketMonitorCandidate is an active company and when its market was last snapshotted
+type marketMonitorCandidate struct {
+	ID           string
+	Name         string
+	Industry     string
+	City         string
+	Plan         string
+	LastSnapshot time.Time
+}
+
+// MonitorMarkets re-runs market research for each active company whose plan
+// interval has elapsed, diffs it against the previous snapshot and raises a
+// market shift insight and notification when new articles appear. It is run
+// periodically from main.
+func (h *Handler) MonitorMarkets(ctx context.Context) {
+	if h.config.ExaAPIKey == "" {
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT c.id, c.name, COALESCE(c.industry, ''), COALESCE(c.city, ''), COALESCE(c.subscription_plan, 'free'),
+			COALESCE((SELECT MAX(created_at) FROM market_snapshots s WHERE s.company_id = c.id), 'epoch'::timestamptz)
+		FROM companies c
+		WHERE COALESCE(c.status, 'active') = 'active'
+	`)
+	if err != nil {
+		logger.Error("Market monitoring failed to list companies", "error", err.Error())
+		return
+	}
+	var candidates []marketMonitorCandidate
+	for rows.Next() {
+		var c marketMonitorCandidate
+		if rows.Scan(&c.ID, &c.Name, &c.Industry, &c.City, &c.Plan, &c.LastSnapshot) == nil {
+			if c.LastSnapshot.Unix() == 0 {
+				c.LastSnapshot = time.Time{}
+			}
+			candidates = append(candidates, c)
+		}
+	}
+	rows.Close()
+
+	now := time.Now()
+	monitored, shifts, deferred := 0, 0, 0
+	for _, c := range candidates {
+		if ctx.Err() != nil {
+			return
+		}
+		if !market.Due(c.Plan, c.LastSnapshot, now) {
+			continue
+		}
+		// A company at its AI spending cap stays due until the cap lifts
+		if h.aiJobsDeferred(ctx, c.ID) {
+			deferred++
+			continue
+		}
+		shifted, err := h.monitorCompanyMarket(middleware.WithCompanyID(ctx, c.ID), c)
+		if err != nil {
+			logger.Warn("Market monitoring failed", "company_id", c.ID, "error", err.Error())
+			continue
+		}
+		monitored++
+		if shifted {
+			shifts++
+		}
+	}
+
+	logger.Info("Market monitoring completed", "companies", monitored, "shifts", shifts, "deferred", deferred)
+}
+
+// monitorCompanyMarket takes a fresh market snapshot for one company and reports
+// whether it differed from the previous one
+func (h *Handler) monitorCompanyMarket(ctx context.Context, c marketMonitorCandidate) (bool, error) {
+	query := market.BuildQuery(c.Industry, c.City, h.topProductCategories(ctx, c.ID))
+	if query == "" {
+		return false, nil
+	}
+
+	var previousIDs []string
+	var previousQuery string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT query, article_ids FROM market_snapshots
+		WHERE company_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, c.ID).Scan(&previousQuery, &previousIDs)
+	hasPrevious := err == nil
+
+	research, err := h.marketResearch(ctx, c.ID, query, false)
+	if err != nil {
+		return false, err
+	}
+
+	current := make([]market.Article, len(research.Articles))
+	currentIDs := make([]string, len(research.Articles))
+	for i, a := range research.Articles {
+		current[i] = market.Article{ID: a.ID, Title: a.Title, Excerpt: a.Excerpt}
+		currentIDs[i] = a.ID
+	}
+	previous := make([]market.Article, len(previousIDs))
+	for i, id := range previousIDs {
+		previous[i] = market.Article{ID: id}
+	}
+	shift := market.Diff(previous, current)
+
+	// The first snapshot, or one taken after the query changed, is only a baseline
+	shifted := hasPrevious && previousQuery == query && shift.Changed()
+	var insightID *string
+	if shifted {
+		id, err := h.recordMarketShift(ctx, c, query, shift)
+		if err != nil {
+			return false, err
+		}
+		insightID = &id
+	}
+
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO market_snapshots (id, company_id, query, article_ids, new_articles, insight_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`, uuid.New().String(), c.ID, query, currentIDs, len(shift.New), insightID)
+	if err != nil {
+		return false, fmt.Errorf("save market snapshot: %w", err)
+	}
+	return shifted, nil
+}
+
+// recordMarketShift stores the "what changed in your market" insight and notifies the company
+func (h *Handler) recordMarketShift(ctx context.Context, c marketMonitorCandidate, query string, shift market.Shift) (string, error) {
+	insightID := uuid.New().String()
+	summary, source := h.marketShiftSummary(aispend.WithFeature(ctx, aispend.FeatureMarketInsight, insightID), c.ID, c.Name, query, shift)
+
+	newArticles := make([]map[string]string, len(shift.New))
+	for i, a := range shift.New {
+		newArticles[i] = map[string]string{"id": a.ID, "title": a.Title}
+	}
+	inputJSON, _ := json.Marshal(map[string]interface{}{"query": query})
+	resultJSON, _ := json.Marshal(map[string]interface{}{
+		"summary":          summary,
+		"summary_source":   source,
+		"new_articles":     newArticles,
+		"dropped_articles": len(shift.Dropped),
+	})
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO insights (id, company_id, type, input_context, result, created_at)
+		VALUES ($1, $2, 'market_shift', $3, $4, NOW())
+	`, insightID, c.ID, inputJSON, resultJSON)
+	if err != nil {
+		return "", fmt.Errorf("save market shift insight: %w", err)
+	}
+
+	_, err = h.notify(ctx, c.ID, models.Notification{
+		Type:    models.NotificationMarketShift,
+		Title:   "Apa yang berubah di pasar Anda",
+		Message: summary,
+		Data:    map[string]interface{}{"insight_id": insightID, "new_articles": len(shift.New)},
+	}, "market_shift:"+insightID)
+	if err != nil {
+		logger.Warn("Failed to create market shift notification", "company_id", c.ID, "error", err.Error())
+	}
+	return insightID, nil
+}
+
+// marketShiftSummary asks the AI provider to summarize the new articles, falling back to a template
+func (h *Handler) marketShiftSummary(ctx context.Context, companyID, companyName, query string, shift market.Shift) (string, string) {
+	if h.config.KolosalAPIKey != "" {
+		client := h.kolosalClient()
+		resp, err := client.CreateChatCompletion(ctx, kolosal.ChatCompletionRequest{
+			Model: "default",
+			Messages: []kolosal.ChatCompletionMessage{
+				{Role: "system", Content: h.withBrandVoice(ctx, companyID, "Kamu adalah analis pasar untuk UMKM Indonesia. Jawab dalam Bahasa Indonesia.")},
+				{Role: "user", Content: market.BuildPrompt(companyName, query, shift)},
+			},
+			MaxTokens:   400,
+			Temperature: 0.3,
+		})
+		if err == nil && len(resp.Choices) > 0 && strings.TrimSpace(resp.Choices[0].Message.Content) != "" {
+			return strings.TrimSpace(resp.Choices[0].Message.Content), "ai"
+		}
+		if err != nil {
+			logger.Warn("Falling back to template market shift summary", "error", err.Error())
+		}
+	}
+	return market.Summary(query, shift), "rules"
+}
+
+// topProductCategories returns the company's product categories by revenue over the last 90 days
+func (h *Handler) topProductCategories(ctx context.Context, companyID string) []string {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT p.category
+		FROM products p
+		LEFT JOIN sales_history s ON s.product_id = p.id AND s.sale_date >= CURRENT_DATE - 90 AND NOT COALESCE(s.excluded, false)
+		WHERE p.company_id = $1 AND COALESCE(p.category, '') != '' AND COALESCE(p.is_active, true)
+		GROUP BY p.category
+		ORDER BY COALESCE(SUM(s.quantity * s.price), 0) DESC
+		LIMIT 3
+	`, companyID)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var categories []string
+	for rows.Next() {
+		var c string
+		if rows.Scan(&c) == nil {
+			categories = append(categories, c)
+		}
+	}
+	return categories
+}
@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/devmock"
+	"github.com/bantuaku/backend/services/embeddings"
+	"github.com/bantuaku/backend/services/exa"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+)
+
+// Market research limits
+const (
+	marketResearchResults      = 8
+	marketResearchExcerptChars = 1000
+)
+
+// MarketResearchRequest represents a market research query
+type MarketResearchRequest struct {
+	Query string `json:"query" validate:"required,max:300"`
+}
+
+// ResearchMarket searches the web for a query and archives the articles. A query
+// repeated within MARKET_RESEARCH_REUSE_DAYS is answered from the archive instead.
+func (h *Handler) ResearchMarket(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req MarketResearchRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	research, err := h.marketResearch(r.Context(), companyID, req.Query, true)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, research)
+}
+
+// SearchMarketResearch runs a semantic search over the company's archived research (?q=, ?limit=)
+func (h *Handler) SearchMarketResearch(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		h.respondError(w, errors.NewValidationError("Missing query", "q is required"), r)
+		return
+	}
+	limit := defaultVectorSearchLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxVectorSearchLimit {
+			h.respondError(w, errors.NewValidationError("Invalid limit", fmt.Sprintf("limit must be between 1 and %d", maxVectorSearchLimit)), r)
+			return
+		}
+		limit = n
+	}
+	if h.config.KolosalAPIKey == "" {
+		h.respondError(w, errors.NewExternalServiceError("Kolosal.ai", "Embeddings are not configured", "KOLOSAL_API_KEY is not set"), r)
+		return
+	}
+
+	ctx := r.Context()
+	articles, err := h.loadMarketArticles(ctx, companyID, `TRUE`)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load market research"), r)
+		return
+	}
+	byID := map[string]models.MarketResearchArticle{}
+	ids := []string{}
+	for _, a := range articles {
+		byID[a.ID] = a
+		ids = append(ids, a.ID)
+	}
+
+	client := h.kolosalClient()
+	ctx = aispend.WithFeature(ctx, aispend.FeatureMarketResearch, "")
+	matches, err := h.searchVectors(ctx, client, embeddings.NamespaceMarketResearch, ids, query, limit, 0)
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Market research search failed"), r)
+		return
+	}
+
+	results := []models.MarketResearchArticle{}
+	for _, m := range matches {
+		a := byID[m.SourceID]
+		a.Score = m.Score
+		results = append(results, a)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"query":    query,
+		"articles": results,
+	})
+}
+
+// marketResearch returns archived articles for a recently repeated query (when reuse
+// is set), or runs a new web search and archives (and embeds) its results
+func (h *Handler) marketResearch(ctx context.Context, companyID, query string, reuse bool) (*models.MarketResearch, error) {
+	query = strings.TrimSpace(query)
+	queryHash := embeddings.ContentHash(query)
+
+	if reuse {
+		if research, ok := h.archivedResearch(ctx, companyID, query, queryHash); ok {
+			return research, nil
+		}
+	}
+
+	if h.config.ExaAPIKey == "" {
+		return nil, errors.NewExternalServiceError("Exa", "Market research is not configured", "EXA_API_KEY is not set")
+	}
+	resp, err := h.exaClient().Search(ctx, exa.SearchRequest{
+		Query:      query,
+		NumResults: marketResearchResults,
+		Contents:   &exa.SearchContents{Text: &exa.TextOptions{MaxCharacters: marketResearchExcerptChars}},
+	})
+	h.recordIntegrationRun(ctx, companyID, "exa", err)
+	if err != nil {
+		return nil, errors.NewExternalServiceError("Exa", "Market research search failed", err.Error()).WithCause(err)
+	}
+
+	research := &models.MarketResearch{Query: query, CreatedAt: time.Now(), Articles: []models.MarketResearchArticle{}}
+	queryID := uuid.New().String()
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO market_research_queries (id, company_id, query, query_hash, result_count, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, queryID, companyID, query, queryHash, len(resp.Results), research.CreatedAt)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "save research query")
+	}
+
+	for rank, result := range resp.Results {
+		if result.URL == "" {
+			continue
+		}
+		a := models.MarketResearchArticle{
+			URL:       result.URL,
+			Title:     truncateRunes(strings.TrimSpace(result.Title), 500),
+			Excerpt:   strings.TrimSpace(result.Text),
+			FetchedAt: research.CreatedAt,
+		}
+		if a.Title == "" {
+			a.Title = a.URL
+		}
+		if t, err := time.Parse(time.RFC3339, result.PublishedDate); err == nil {
+			a.PublishedAt = &t
+		}
+
+		err := h.db.Pool().QueryRow(ctx, `
+			INSERT INTO market_research_articles (id, company_id, url, title, excerpt, published_at, fetched_at)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7)
+			ON CONFLICT (company_id, url) DO UPDATE
+			SET title = EXCLUDED.title, excerpt = EXCLUDED.excerpt,
+				published_at = COALESCE(EXCLUDED.published_at, market_research_articles.published_at),
+				fetched_at = EXCLUDED.fetched_at
+			RETURNING id
+		`, uuid.New().String(), companyID, a.URL, a.Title, a.Excerpt, a.PublishedAt, a.FetchedAt).Scan(&a.ID)
+		if err != nil {
+			return nil, errors.NewDatabaseError(err, "save research article")
+		}
+		_, err = h.db.Pool().Exec(ctx, `
+			INSERT INTO market_research_query_articles (query_id, article_id, rank)
+			VALUES ($1, $2, $3)
+			ON CONFLICT DO NOTHING
+		`, queryID, a.ID, rank)
+		if err != nil {
+			return nil, errors.NewDatabaseError(err, "link research article")
+		}
+		research.Articles = append(research.Articles, a)
+	}
+
+	h.embedMarketArticles(ctx, research.Articles)
+	return research, nil
+}
+
+// archivedResearch returns the articles of the same query run within the reuse window
+func (h *Handler) archivedResearch(ctx context.Context, companyID, query, queryHash string) (*models.MarketResearch, bool) {
+	var queryID string
+	var createdAt time.Time
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, created_at FROM market_research_queries
+		WHERE company_id = $1 AND query_hash = $2 AND created_at >= NOW() - make_interval(days => $3)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, companyID, queryHash, h.config.MarketResearchReuseDays).Scan(&queryID, &createdAt)
+	if err != nil {
+		return nil, false
+	}
+
+	articles, err := h.loadMarketArticles(ctx, companyID, `id IN (
+		SELECT article_id FROM market_research_query_articles WHERE query_id = $2
+	)`, queryID)
+	if err != nil {
+		logger.Warn("Failed to load archived research", "company_id", companyID, "error", err.Error())
+		return nil, false
+	}
+	return &models.MarketResearch{Query: query, Reused: true, CreatedAt: createdAt, Articles: articles}, true
+}
+
+// embedMarketArticles indexes article title and excerpt for semantic search.
+// Failures are logged; the articles stay archived and can be embedded on a later fetch.
+func (h *Handler) embedMarketArticles(ctx context.Context, articles []models.MarketResearchArticle) {
+	if h.config.KolosalAPIKey == "" {
+		return
+	}
+	client := h.kolosalClient()
+	ctx = aispend.WithFeature(ctx, aispend.FeatureMarketResearch, "")
+	var usage models.EmbeddingUsage
+	for _, a := range articles {
+		text := strings.TrimSpace(a.Title + "\n\n" + a.Excerpt)
+		if err := h.storeChunks(ctx, client, embeddings.NamespaceMarketResearch, a.ID, []string{text}, &usage); err != nil {
+			logger.Warn("Failed to embed market research article", "article_id", a.ID, "error", err.Error())
+			return
+		}
+	}
+}
+
+// loadMarketArticles returns a company's archived articles matching filter, newest first.
+// The company ID is bound as $1; extra arguments start at $2.
+func (h *Handler) loadMarketArticles(ctx context.Context, companyID, filter string, extra ...interface{}) ([]models.MarketResearchArticle, error) {
+	args := append([]interface{}{companyID}, extra...)
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, url, title, COALESCE(excerpt, ''), published_at, fetched_at
+		FROM market_research_articles
+		WHERE company_id = $1 AND `+filter+`
+		ORDER BY COALESCE(published_at, fetched_at) DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	articles := []models.MarketResearchArticle{}
+	for rows.Next() {
+		var a models.MarketResearchArticle
+		if err := rows.Scan(&a.ID, &a.URL, &a.Title, &a.Excerpt, &a.PublishedAt, &a.FetchedAt); err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+	return articles, rows.Err()
+}
+
+// truncateRunes shortens s to at most n characters
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
+
+// exaClient returns the Exa search client, answered in process with DEV_MOCKS
+func (h *Handler) exaClient() *exa.Client {
+	client := exa.NewClient(h.config.ExaAPIKey)
+	if h.config.DevMocks {
+		client.HTTPClient = devmock.HTTPClient()
+	}
+	return client
+}
@@ -0,0 +1,581 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/chatattach"
+	"github.com/bantuaku/backend/services/chateval"
+	"github.com/bantuaku/backend/services/embeddings"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/metering"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// regenerationNamespaces are searched for passages when a variant adds retrieved context
+var regenerationNamespaces = []string{embeddings.NamespaceKnowledgeBase, embeddings.NamespaceRegulations}
+
+// MessageFeedbackRequest rates an assistant answer. Regenerate on a thumbs-down
+// also produces an alternative answer with the next variant.
+type MessageFeedbackRequest struct {
+	Rating     string `json:"rating" validate:"required,oneof:up|down"`
+	Reason     string `json:"reason" validate:"oneof:incorrect|incomplete|irrelevant|other"`
+	Comment    string `json:"comment" validate:"max:1000"`
+	Regenerate bool   `json:"regenerate"`
+}
+
+// RegenerateMessageRequest asks for an alternative answer. An empty variant
+// picks the next one not yet tried for the message.
+type RegenerateMessageRequest struct {
+	Variant string `json:"variant" validate:"oneof:more_context|hybrid|alternate_model"`
+}
+
+// assistantMessage is an answer being rated or regenerated
+type assistantMessage struct {
+	id             string
+	conversationID string
+	content        string
+	createdAt      time.Time
+}
+
+// SubmitMessageFeedback records a thumbs up or down on an assistant answer
+func (h *Handler) SubmitMessageFeedback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req MessageFeedbackRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.Regenerate && req.Rating != "down" {
+		h.respondError(w, errors.NewValidationError("Invalid regenerate", "only a thumbs-down can regenerate the answer"), r)
+		return
+	}
+	if req.Regenerate && h.config.KolosalAPIKey == "" {
+		h.respondError(w, errors.NewExternalServiceError("Kolosal.ai", "AI chat is not configured", "KOLOSAL_API_KEY is not set"), r)
+		return
+	}
+
+	msg, err := h.loadAssistantMessage(ctx, companyID, r.PathValue("id"))
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Message"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load message"), r)
+		return
+	}
+
+	feedback := models.MessageFeedback{MessageID: msg.id, Rating: req.Rating, Reason: req.Reason, Comment: strings.TrimSpace(req.Comment)}
+	err = h.db.Pool().QueryRow(ctx, `
+		INSERT INTO message_feedback (message_id, company_id, user_id, rating, reason, comment, created_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), NULLIF($6, ''), NOW(), NOW())
+		ON CONFLICT (message_id) DO UPDATE
+		SET user_id = EXCLUDED.user_id, rating = EXCLUDED.rating, reason = EXCLUDED.reason,
+			comment = EXCLUDED.comment, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`, msg.id, companyID, middleware.GetUserID(ctx), feedback.Rating, feedback.Reason, feedback.Comment).Scan(&feedback.UpdatedAt)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save feedback"), r)
+		return
+	}
+
+	resp := map[string]interface{}{"feedback": feedback}
+	if req.Regenerate {
+		regeneration, err := h.regenerateMessage(ctx, companyID, msg, "")
+		if err != nil {
+			h.respondError(w, err, r)
+			return
+		}
+		resp["regeneration"] = regeneration
+	}
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// RegenerateMessage answers a question again with different retrieval or
+// model parameters, keeping the original answer until an alternative is accepted
+func (h *Handler) RegenerateMessage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req RegenerateMessageRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if h.config.KolosalAPIKey == "" {
+		h.respondError(w, errors.NewExternalServiceError("Kolosal.ai", "AI chat is not configured", "KOLOSAL_API_KEY is not set"), r)
+		return
+	}
+
+	msg, err := h.loadAssistantMessage(ctx, companyID, r.PathValue("id"))
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Message"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load message"), r)
+		return
+	}
+
+	usage, err := h.consumeUsage(ctx, companyID, metering.MetricChatMessages, 1)
+	setUsageHeaders(w, usage)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	regeneration, err := h.regenerateMessage(ctx, companyID, msg, req.Variant)
+	if err != nil {
+		h.refundUsage(ctx, companyID, metering.MetricChatMessages, 1)
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, regeneration)
+}
+
+// ListMessageRegenerations returns a message's feedback and the alternatives
+// generated for it, the original answer first
+func (h *Handler) ListMessageRegenerations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	msg, err := h.loadAssistantMessage(ctx, companyID, r.PathValue("id"))
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Message"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load message"), r)
+		return
+	}
+
+	var feedback *models.MessageFeedback
+	var f models.MessageFeedback
+	err = h.db.Pool().QueryRow(ctx, `
+		SELECT message_id, rating, COALESCE(reason, ''), COALESCE(comment, ''), updated_at
+		FROM message_feedback WHERE message_id = $1
+	`, msg.id).Scan(&f.MessageID, &f.Rating, &f.Reason, &f.Comment, &f.UpdatedAt)
+	if err == nil {
+		feedback = &f
+	} else if err != pgx.ErrNoRows {
+		h.respondError(w, errors.NewDatabaseError(err, "load feedback"), r)
+		return
+	}
+
+	regenerations, err := h.messageRegenerations(ctx, msg.id)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list regenerations"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"feedback":      feedback,
+		"regenerations": regenerations,
+	})
+}
+
+// AcceptMessageRegeneration makes a regenerated (or the original) answer the
+// message's content and records it as the variant the user preferred
+func (h *Handler) AcceptMessageRegeneration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	msg, err := h.loadAssistantMessage(ctx, companyID, r.PathValue("id"))
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Message"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load message"), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var content string
+	err = tx.QueryRow(ctx, `
+		UPDATE message_regenerations SET accepted = TRUE, accepted_at = NOW()
+		WHERE id = $1 AND message_id = $2
+		RETURNING content
+	`, r.PathValue("regeneration_id"), msg.id).Scan(&content)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Regeneration"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "accept regeneration"), r)
+		return
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE message_regenerations SET accepted = FALSE, accepted_at = NULL WHERE message_id = $1 AND id <> $2
+	`, msg.id, r.PathValue("regeneration_id")); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "accept regeneration"), r)
+		return
+	}
+	if _, err := tx.Exec(ctx, `UPDATE messages SET content = $2 WHERE id = $1`, msg.id, content); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update message"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	regenerations, err := h.messageRegenerations(ctx, msg.id)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list regenerations"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"message_id":    msg.id,
+		"content":       content,
+		"regenerations": regenerations,
+	})
+}
+
+// AdminChatEvaluationDataset returns, for the last ?days= (default 30), every
+// disliked answer paired with the alternative its user accepted, and how often
+// each variant was accepted (platform admin only)
+func (h *Handler) AdminChatEvaluationDataset(w http.ResponseWriter, r *http.Request) {
+	days, err := parseAnalyticsDays(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT a.message_id, a.question, COALESCE(o.content, ''), a.content, a.variant,
+			ARRAY(SELECT variant FROM message_regenerations t WHERE t.message_id = a.message_id AND t.variant <> 'original' ORDER BY t.created_at),
+			COALESCE(f.reason, ''), a.accepted_at
+		FROM message_regenerations a
+		LEFT JOIN message_regenerations o ON o.message_id = a.message_id AND o.variant = 'original'
+		LEFT JOIN message_feedback f ON f.message_id = a.message_id
+		WHERE a.accepted AND a.accepted_at >= NOW() - make_interval(days => $1)
+		ORDER BY a.accepted_at DESC
+	`, days)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load evaluation dataset"), r)
+		return
+	}
+	defer rows.Close()
+
+	examples := []models.ChatEvalExample{}
+	for rows.Next() {
+		var e models.ChatEvalExample
+		if err := rows.Scan(&e.MessageID, &e.Question, &e.OriginalAnswer, &e.AcceptedAnswer, &e.AcceptedVariant,
+			&e.VariantsTried, &e.Reason, &e.AcceptedAt); err != nil {
+			continue
+		}
+		examples = append(examples, e)
+	}
+	rows.Close()
+
+	statRows, err := h.db.Pool().Query(ctx, `
+		SELECT variant, COUNT(*), COUNT(*) FILTER (WHERE accepted)
+		FROM message_regenerations
+		WHERE created_at >= NOW() - make_interval(days => $1)
+		GROUP BY variant
+		ORDER BY variant
+	`, days)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "aggregate variants"), r)
+		return
+	}
+	defer statRows.Close()
+
+	variants := []models.ChatVariantStats{}
+	for statRows.Next() {
+		var s models.ChatVariantStats
+		if err := statRows.Scan(&s.Variant, &s.Generated, &s.Accepted); err != nil {
+			continue
+		}
+		if s.Generated > 0 {
+			s.Rate = float64(s.Accepted) / float64(s.Generated)
+		}
+		variants = append(variants, s)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"days":     days,
+		"examples": examples,
+		"variants": variants,
+	})
+}
+
+// regenerateMessage answers msg's question again with the named variant, or
+// the next untried one, and stores the result. The first regeneration also
+// stores the original answer so it can be accepted back.
+func (h *Handler) regenerateMessage(ctx context.Context, companyID string, msg *assistantMessage, name string) (*models.MessageRegeneration, error) {
+	rows, err := h.db.Pool().Query(ctx, `SELECT variant FROM message_regenerations WHERE message_id = $1`, msg.id)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "list regenerations")
+	}
+	var tried []string
+	for rows.Next() {
+		var v string
+		if rows.Scan(&v) == nil {
+			tried = append(tried, v)
+		}
+	}
+	rows.Close()
+
+	variants := chateval.Variants(h.config.ChatAlternateModel)
+	var variant chateval.Variant
+	var ok bool
+	if name != "" {
+		if variant, ok = chateval.Lookup(variants, name); !ok {
+			return nil, errors.NewValidationError("Invalid variant", "variant "+name+" is not available")
+		}
+		for _, t := range tried {
+			if t == name {
+				return nil, errors.NewConflictError("Variant already tried", "this answer was already regenerated with "+name)
+			}
+		}
+	} else if variant, ok = chateval.Next(variants, tried); !ok {
+		return nil, errors.NewBusinessRuleError("regeneration_exhausted", "Every regeneration variant has been tried for this answer")
+	}
+
+	var questionID, question string
+	var asked time.Time
+	err = h.db.Pool().QueryRow(ctx, `
+		SELECT id, content, created_at FROM messages
+		WHERE conversation_id = $1 AND sender = 'user' AND created_at <= $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, msg.conversationID, msg.createdAt).Scan(&questionID, &question, &asked)
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewBusinessRuleError("no_question", "This answer has no question to regenerate from")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load question")
+	}
+	history, err := h.messagesBefore(ctx, msg.conversationID, asked, variant.HistoryMessages)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load conversation history")
+	}
+	summary, err := h.conversationSummary(ctx, companyID, msg.conversationID)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load conversation")
+	}
+	attachments, err := h.messageAttachmentFiles(ctx, questionID)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load message attachments")
+	}
+
+	ctx = aispend.WithFeature(ctx, aispend.FeatureChatRegeneration, msg.id)
+	client := h.kolosalClient()
+	systemPrompt := h.chatSystemPrompt(ctx, companyID, summary)
+	passages := h.regenerationContext(ctx, client, question, variant)
+	contents := make([]string, len(passages))
+	for i, p := range passages {
+		contents[i] = p.Content
+	}
+	systemPrompt = withReferences(systemPrompt, contents)
+	model := variant.Model
+	if model == "" {
+		model = defaultChatModel
+	}
+
+	started := time.Now()
+	prompt := chatattach.Question(question, h.chatAttachmentFiles(ctx, attachments))
+	reply, tools, err := h.chatWithTools(ctx, client, companyID, chatMessages(systemPrompt, history, prompt), model, variant.Temperature)
+	h.recordChatUsage(ctx, SendMessageRequest{ConversationID: msg.conversationID, Message: question}, time.Since(started), tools, err != nil)
+	if err != nil {
+		return nil, errors.NewExternalServiceError("Kolosal.ai", "Could not regenerate the answer", err.Error())
+	}
+
+	regeneration := &models.MessageRegeneration{
+		ID:            uuid.New().String(),
+		MessageID:     msg.id,
+		Variant:       variant.Name,
+		Model:         model,
+		ContextChunks: len(passages),
+		Content:       reply,
+		CreatedAt:     time.Now(),
+	}
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if len(tried) == 0 {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO message_regenerations (id, message_id, company_id, question, variant, model, content, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (message_id, variant) DO NOTHING
+		`, uuid.New().String(), msg.id, companyID, question, chateval.VariantOriginal, defaultChatModel, msg.content, msg.createdAt); err != nil {
+			return nil, errors.NewDatabaseError(err, "save original answer")
+		}
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO message_regenerations (id, message_id, company_id, question, variant, model, context_chunks, content, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, regeneration.ID, msg.id, companyID, question, regeneration.Variant, regeneration.Model, regeneration.ContextChunks,
+		regeneration.Content, regeneration.CreatedAt)
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "save regeneration")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.NewDatabaseError(err, "commit transaction")
+	}
+	return regeneration, nil
+}
+
+// regenerationContext retrieves the passages a variant adds to the prompt.
+// Search failures only reduce the context.
+func (h *Handler) regenerationContext(ctx context.Context, client *kolosal.Client, question string, variant chateval.Variant) []chateval.Passage {
+	if variant.ContextChunks == 0 {
+		return nil
+	}
+	fetch := variant.ContextChunks
+	if variant.Hybrid {
+		fetch *= 3 // Keyword reranking needs candidates beyond the top by similarity
+	}
+
+	var passages []chateval.Passage
+	for _, namespace := range regenerationNamespaces {
+		matches, err := h.searchVectors(ctx, client, namespace, nil, question, fetch, 0)
+		if err != nil {
+			logger.Warn("Regeneration retrieval failed", "namespace", namespace, "error", err.Error())
+			continue
+		}
+		for _, m := range matches {
+			passages = append(passages, chateval.Passage{SourceID: m.SourceID, Content: m.Content, Score: m.Score})
+		}
+	}
+
+	if variant.Hybrid {
+		return chateval.Rerank(question, passages, variant.ContextChunks)
+	}
+	sort.SliceStable(passages, func(i, j int) bool { return passages[i].Score > passages[j].Score })
+	if len(passages) > variant.ContextChunks {
+		passages = passages[:variant.ContextChunks]
+	}
+	return passages
+}
+
+// withReferences appends numbered retrieved passages to a system prompt
+func withReferences(systemPrompt string, passages []string) string {
+	if len(passages) == 0 {
+		return systemPrompt
+	}
+	var b strings.Builder
+	b.WriteString(systemPrompt)
+	b.WriteString("\n\nReferensi yang mungkin relevan (gunakan bila sesuai):")
+	for i, p := range passages {
+		fmt.Fprintf(&b, "\n[%d] %s", i+1, p)
+	}
+	return b.String()
+}
+
+// loadAssistantMessage loads an assistant message in one of the company's conversations
+func (h *Handler) loadAssistantMessage(ctx context.Context, companyID, messageID string) (*assistantMessage, error) {
+	var m assistantMessage
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT m.id, m.conversation_id, m.content, m.created_at
+		FROM messages m
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE m.id = $1 AND c.company_id = $2 AND m.sender = 'assistant'
+	`, messageID, companyID).Scan(&m.id, &m.conversationID, &m.content, &m.createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// messagesBefore returns up to limit live messages sent before a time, oldest first
+func (h *Handler) messagesBefore(ctx context.Context, conversationID string, before time.Time, limit int) ([]models.Message, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, conversation_id, sender, content, structured_payload, file_upload_id, created_at
+		FROM (
+			SELECT * FROM messages WHERE conversation_id = $1 AND created_at < $2 ORDER BY created_at DESC, id DESC LIMIT $3
+		) earlier
+		ORDER BY created_at, id
+	`, conversationID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.Message{}
+	for rows.Next() {
+		var m models.Message
+		if err := rows.Scan(&m.ID, &m.ConversationID, &m.Sender, &m.Content, &m.StructuredPayload, &m.FileUploadID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	return messages, h.loadMessageAttachments(ctx, messages)
+}
+
+func (h *Handler) messageRegenerations(ctx context.Context, messageID string) ([]models.MessageRegeneration, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, message_id, variant, model, context_chunks, content, accepted, created_at, accepted_at
+		FROM message_regenerations
+		WHERE message_id = $1
+		ORDER BY created_at
+	`, messageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	regenerations := []models.MessageRegeneration{}
+	for rows.Next() {
+		var g models.MessageRegeneration
+		if err := rows.Scan(&g.ID, &g.MessageID, &g.Variant, &g.Model, &g.ContextChunks, &g.Content, &g.Accepted,
+			&g.CreatedAt, &g.AcceptedAt); err != nil {
+			return nil, err
+		}
+		regenerations = append(regenerations, g)
+	}
+	return regenerations, rows.Err()
+}
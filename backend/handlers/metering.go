@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/metering"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetUsage returns the company's usage of every metered action against its
+// plan's limits
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	plan := h.CompanyPlan(ctx, companyID)
+	now := time.Now()
+	usage := make([]metering.Usage, 0, len(metering.Metrics))
+	for _, m := range metering.Metrics {
+		u, err := h.meteredUsage(ctx, companyID, plan, m, now)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "load usage"), r)
+			return
+		}
+		usage = append(usage, u)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"plan":            plan,
+		"limits_enforced": h.config.UsageLimitsEnabled,
+		"metrics":         usage,
+	})
+}
+
+// GetPlanPreview compares the company's usage this period against the limits
+// of ?plan=, listing the blocked actions that plan would make available again,
+// for upgrade prompts after a limit error
+func (h *Handler) GetPlanPreview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	plan := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("plan")))
+	if !metering.KnownPlan(plan) {
+		h.respondError(w, errors.NewValidationError("Invalid plan", "plan must be one of: free, pro, enterprise"), r)
+		return
+	}
+
+	currentPlan := h.CompanyPlan(ctx, companyID)
+	now := time.Now()
+	comparisons := make([]metering.PlanComparison, 0, len(metering.Metrics))
+	unlocked := []string{}
+	for _, m := range metering.Metrics {
+		u, err := h.meteredUsage(ctx, companyID, currentPlan, m, now)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "load usage"), r)
+			return
+		}
+		c := metering.Compare(m, u, plan, now)
+		if c.Unblocks {
+			unlocked = append(unlocked, m.Name)
+		}
+		comparisons = append(comparisons, c)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"current_plan": currentPlan,
+		"plan":         plan,
+		"metrics":      comparisons,
+		"unlocks":      unlocked,
+	})
+}
+
+// consumeUsage takes n units of a metered action for the company, failing with
+// a limit_exceeded error when that would go over the plan's limit. Counter
+// metrics are incremented atomically, so concurrent requests cannot overshoot;
+// query metrics are only checked, since the action's own row counts them. The
+// usage after the action is returned for the response's usage headers, also
+// when the limit was reached.
+func (h *Handler) consumeUsage(ctx context.Context, companyID, name string, n int64) (metering.Usage, error) {
+	m, ok := metering.Lookup(name)
+	if !ok {
+		return metering.Usage{}, errors.NewInternalError(fmt.Errorf("unknown metric %q", name), "Usage metering failed")
+	}
+	plan := h.CompanyPlan(ctx, companyID)
+	now := time.Now()
+	limit := m.Limit(plan)
+	if !h.config.UsageLimitsEnabled {
+		limit = metering.Unlimited
+	}
+
+	if m.Source == metering.SourceQuery {
+		u, err := h.meteredUsage(ctx, companyID, plan, m, now)
+		if err != nil {
+			return metering.Usage{}, errors.NewDatabaseError(err, "load usage")
+		}
+		u = u.WithLimit(limit)
+		if !u.Allows(n) {
+			return u, errors.NewLimitExceededError(u.Message(m.Label), u)
+		}
+		u.Used += n
+		return u.WithLimit(limit), nil
+	}
+
+	var count int64
+	err := h.db.Pool().QueryRow(ctx, `
+		INSERT INTO usage_counters (company_id, metric, period_start, count, updated_at)
+		SELECT $1::varchar, $2::varchar, $3::timestamptz, $4::bigint, NOW()
+		WHERE $5::bigint < 0 OR $4 <= $5
+		ON CONFLICT (company_id, metric, period_start) DO UPDATE
+		SET count = usage_counters.count + EXCLUDED.count, updated_at = NOW()
+		WHERE $5 < 0 OR usage_counters.count + EXCLUDED.count <= $5
+		RETURNING count
+	`, companyID, m.Name, m.PeriodStart(now), n, limit).Scan(&count)
+	if err == pgx.ErrNoRows {
+		u, err := h.meteredUsage(ctx, companyID, plan, m, now)
+		if err != nil {
+			return metering.Usage{}, errors.NewDatabaseError(err, "load usage")
+		}
+		return u, errors.NewLimitExceededError(u.Message(m.Label), u)
+	}
+	if err != nil {
+		return metering.Usage{}, errors.NewDatabaseError(err, "record usage")
+	}
+	return metering.NewUsage(m, plan, count, now).WithLimit(limit), nil
+}
+
+// setUsageHeaders reports a metered action's usage on the response, so clients
+// can show quotas and hold back before hitting the limit. Limit and remaining
+// are -1 when unlimited; the reset is a Unix time, left out for running totals.
+func setUsageHeaders(w http.ResponseWriter, u metering.Usage) {
+	if u.Metric == "" {
+		return
+	}
+	w.Header().Set("X-Usage-Metric", u.Metric)
+	w.Header().Set("X-Usage-Limit", strconv.FormatInt(u.Limit, 10))
+	w.Header().Set("X-Usage-Used", strconv.FormatInt(u.Used, 10))
+	w.Header().Set("X-Usage-Remaining", strconv.FormatInt(u.Remaining, 10))
+	if u.ResetsAt != nil {
+		w.Header().Set("X-Usage-Reset", strconv.FormatInt(u.ResetsAt.Unix(), 10))
+	}
+}
+
+// refundUsage gives back counter units consumed by an action that failed
+func (h *Handler) refundUsage(ctx context.Context, companyID, name string, n int64) {
+	m, ok := metering.Lookup(name)
+	if !ok || m.Source != metering.SourceCounter {
+		return
+	}
+	_, err := h.db.Pool().Exec(ctx, `
+		UPDATE usage_counters SET count = GREATEST(count - $4, 0), updated_at = NOW()
+		WHERE company_id = $1 AND metric = $2 AND period_start = $3
+	`, companyID, m.Name, m.PeriodStart(time.Now()), n)
+	if err != nil {
+		logger.Warn("Failed to refund usage", "company_id", companyID, "metric", name, "error", err.Error())
+	}
+}
+
+// meteredUsage counts a metric's usage in its current period
+func (h *Handler) meteredUsage(ctx context.Context, companyID, plan string, m metering.Metric, now time.Time) (metering.Usage, error) {
+	start, end := m.Bounds(now)
+	used, err := h.usageBetween(ctx, companyID, m, start, end)
+	if err != nil {
+		return metering.Usage{}, err
+	}
+	return metering.NewUsage(m, plan, used, now), nil
+}
+
+// usageBetween counts a metric's usage in [start, end)
+func (h *Handler) usageBetween(ctx context.Context, companyID string, m metering.Metric, start, end time.Time) (int64, error) {
+	var used int64
+	var err error
+	if m.Source == metering.SourceQuery {
+		err = h.db.Pool().QueryRow(ctx, m.Query, companyID, start, end).Scan(&used)
+	} else {
+		err = h.db.Pool().QueryRow(ctx, `
+			SELECT COALESCE(SUM(count), 0)::bigint FROM usage_counters
+			WHERE company_id = $1 AND metric = $2 AND period_start >= $3 AND period_start < $4
+		`, companyID, m.Name, start, end).Scan(&used)
+	}
+	return used, err
+}
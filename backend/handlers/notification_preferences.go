@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/notifyprefs"
+)
+
+// notificationEvents maps each notification type to the event type its
+// preferences are set under
+var notificationEvents = map[string]string{
+	models.NotificationSlowMover:       notifyprefs.EventReorders,
+	models.NotificationMarketShift:     notifyprefs.EventPredictions,
+	models.NotificationIntegrationAuth: notifyprefs.EventIntegrations,
+	models.NotificationPermitExpiry:    notifyprefs.EventRegulations,
+	models.NotificationExportReady:     notifyprefs.EventExports,
+	models.NotificationDormancy:        notifyprefs.EventAccount,
+	models.NotificationPlanPaid:        notifyprefs.EventAccount,
+	models.NotificationPlanLapsed:      notifyprefs.EventAccount,
+	models.NotificationLowStock:        notifyprefs.EventReorders,
+}
+
+// NotificationChannel is a delivery channel and whether the plan includes it
+type NotificationChannel struct {
+	Channel   string `json:"channel"`
+	Available bool   `json:"available"`
+}
+
+// NotificationPreferencesResponse is the user's effective preferences next to
+// the plan's defaults
+type NotificationPreferencesResponse struct {
+	Plan        string                  `json:"plan"`
+	Channels    []NotificationChannel   `json:"channels"`
+	Preferences notifyprefs.Preferences `json:"preferences"`
+	Defaults    notifyprefs.Preferences `json:"defaults"`
+}
+
+// UpdateNotificationPreferencesRequest lists the settings to change; others
+// keep their current value
+type UpdateNotificationPreferencesRequest struct {
+	Settings []notifyprefs.Setting `json:"settings"`
+}
+
+// GetNotificationPreferences returns which event types the user receives over
+// each channel
+func (h *Handler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	h.respondNotificationPreferences(w, r, middleware.GetUserID(ctx), companyID)
+}
+
+// UpdateNotificationPreferences turns event types on or off per channel
+func (h *Handler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	userID := middleware.GetUserID(ctx)
+	if companyID == "" || userID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if len(req.Settings) == 0 {
+		h.respondError(w, errors.NewValidationError("Validation failed", "settings: at least one setting is required"), r)
+		return
+	}
+	plan := h.CompanyPlan(ctx, companyID)
+	for _, s := range req.Settings {
+		if err := notifyprefs.Validate(s, plan); err != nil {
+			h.respondError(w, errors.NewValidationError("Invalid setting", err.Error()), r)
+			return
+		}
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+	for _, s := range req.Settings {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO notification_preferences (user_id, company_id, event, channel, enabled, updated_at)
+			VALUES ($1, $2, $3, $4, $5, NOW())
+			ON CONFLICT (user_id, company_id, event, channel) DO UPDATE
+			SET enabled = EXCLUDED.enabled, updated_at = NOW()
+		`, userID, companyID, s.Event, s.Channel, s.Enabled)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "save notification preferences"), r)
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	logger.Info("Notification preferences updated", "company_id", companyID, "user_id", userID, "settings", len(req.Settings))
+
+	h.respondNotificationPreferences(w, r, userID, companyID)
+}
+
+// ResetNotificationPreferences drops the user's settings, returning to the
+// plan's defaults
+func (h *Handler) ResetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	userID := middleware.GetUserID(ctx)
+	if companyID == "" || userID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	_, err := h.db.Pool().Exec(ctx, `
+		DELETE FROM notification_preferences WHERE user_id = $1 AND company_id = $2
+	`, userID, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "reset notification preferences"), r)
+		return
+	}
+	h.respondNotificationPreferences(w, r, userID, companyID)
+}
+
+func (h *Handler) respondNotificationPreferences(w http.ResponseWriter, r *http.Request, userID, companyID string) {
+	ctx := r.Context()
+	plan := h.CompanyPlan(ctx, companyID)
+	prefs, err := h.notificationPreferences(ctx, userID, companyID, plan)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load notification preferences"), r)
+		return
+	}
+	channels := make([]NotificationChannel, 0, len(notifyprefs.Channels))
+	for _, c := range notifyprefs.Channels {
+		channels = append(channels, NotificationChannel{Channel: c, Available: notifyprefs.Available(c, plan)})
+	}
+	h.respondJSON(w, r, http.StatusOK, NotificationPreferencesResponse{
+		Plan:        plan,
+		Channels:    channels,
+		Preferences: prefs,
+		Defaults:    notifyprefs.Defaults(plan),
+	})
+}
+
+// notificationPreferences returns a user's preferences: the plan's defaults
+// with the user's saved settings applied
+func (h *Handler) notificationPreferences(ctx context.Context, userID, companyID, plan string) (notifyprefs.Preferences, error) {
+	prefs := notifyprefs.Defaults(plan)
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT event, channel, enabled FROM notification_preferences WHERE user_id = $1 AND company_id = $2
+	`, userID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var settings []notifyprefs.Setting
+	for rows.Next() {
+		var s notifyprefs.Setting
+		if err := rows.Scan(&s.Event, &s.Channel, &s.Enabled); err != nil {
+			return nil, err
+		}
+		settings = append(settings, s)
+	}
+	prefs.Apply(settings, plan)
+	return prefs, rows.Err()
+}
+
+// wantsNotification reports whether a user receives notifications of
+// notificationType over channel. It fails open, so a preference lookup error
+// never swallows an alert.
+func (h *Handler) wantsNotification(ctx context.Context, userID, companyID, notificationType, channel string) bool {
+	event, ok := notificationEvents[notificationType]
+	if !ok || userID == "" {
+		return true
+	}
+	prefs, err := h.notificationPreferences(ctx, userID, companyID, h.CompanyPlan(ctx, companyID))
+	if err != nil {
+		logger.Warn("Failed to load notification preferences", "company_id", companyID, "user_id", userID, "error", err.Error())
+		return true
+	}
+	return prefs.Allows(event, channel)
+}
+
+// mutedNotificationTypes lists the notification types a user turned off in the
+// app
+func (h *Handler) mutedNotificationTypes(ctx context.Context, userID, companyID string) []string {
+	muted := []string{}
+	if userID == "" {
+		return muted
+	}
+	prefs, err := h.notificationPreferences(ctx, userID, companyID, h.CompanyPlan(ctx, companyID))
+	if err != nil {
+		logger.Warn("Failed to load notification preferences", "company_id", companyID, "user_id", userID, "error", err.Error())
+		return muted
+	}
+	for notificationType, event := range notificationEvents {
+		if !prefs.Allows(event, notifyprefs.ChannelInApp) {
+			muted = append(muted, notificationType)
+		}
+	}
+	return muted
+}
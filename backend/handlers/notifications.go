@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+
+	"github.com/google/uuid"
+)
+
+// NotificationsResponse lists recent notifications with the unread count
+type NotificationsResponse struct {
+	Unread        int                   `json:"unread"`
+	Notifications []models.Notification `json:"notifications"`
+}
+
+// ListNotifications returns the company's 50 most recent notifications,
+// leaving out the types the user turned off in the app and those held for
+// quiet hours
+func (h *Handler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+	muted := h.mutedNotificationTypes(r.Context(), middleware.GetUserID(r.Context()), companyID)
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT id, type, title, message, data, read_at, created_at
+		FROM notifications
+		WHERE company_id = $1 AND (NOT $2 OR read_at IS NULL) AND type <> ALL($3)
+			AND (held_until IS NULL OR held_until <= NOW())
+		ORDER BY COALESCE(held_until, created_at) DESC
+		LIMIT 50
+	`, companyID, unreadOnly, muted)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list notifications"), r)
+		return
+	}
+	defer rows.Close()
+
+	resp := NotificationsResponse{Notifications: []models.Notification{}}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.Type, &n.Title, &n.Message, &n.Data, &n.ReadAt, &n.CreatedAt); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan notification"), r)
+			return
+		}
+		resp.Notifications = append(resp.Notifications, n)
+	}
+
+	h.db.Pool().QueryRow(r.Context(), `
+		SELECT COUNT(*) FROM notifications
+		WHERE company_id = $1 AND read_at IS NULL AND type <> ALL($2) AND (held_until IS NULL OR held_until <= NOW())
+	`, companyID, muted).Scan(&resp.Unread)
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// MarkNotificationRead marks a notification as read
+func (h *Handler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	tag, err := h.db.Pool().Exec(r.Context(), `
+		UPDATE notifications SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND company_id = $2
+	`, r.PathValue("id"), companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "mark notification read"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Notification"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"status": "read"})
+}
+
+// notify stores a notification unless one with the same dedupe key already exists.
+// It reports whether a new notification was created. Non-urgent notifications
+// raised during the company's quiet hours are held until they end.
+func (h *Handler) notify(ctx context.Context, companyID string, n models.Notification, dedupeKey string) (bool, error) {
+	data, _ := json.Marshal(n.Data)
+	var heldUntil *time.Time
+	if until, held := h.holdNotification(ctx, companyID, n.Type); held {
+		heldUntil = &until
+	}
+	tag, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO notifications (id, company_id, type, title, message, data, dedupe_key, held_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, NOW())
+		ON CONFLICT (company_id, dedupe_key) WHERE dedupe_key IS NOT NULL DO NOTHING
+	`, uuid.New().String(), companyID, n.Type, n.Title, n.Message, data, dedupeKey, heldUntil)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
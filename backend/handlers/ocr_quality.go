@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/ocr"
+)
+
+// AdminOCRQuality reports, per OCR engine, how often it was tried, failed and
+// had its text accepted, with the confidence of accepted results, over the
+// last ?days= (default 7) days (platform admin only)
+func (h *Handler) AdminOCRQuality(w http.ResponseWriter, r *http.Request) {
+	days := defaultFailureWindowDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxFailureWindowDays {
+			h.respondError(w, errors.NewValidationError("Invalid days", fmt.Sprintf("days must be between 1 and %d", maxFailureWindowDays)), r)
+			return
+		}
+		days = n
+	}
+
+	ctx := r.Context()
+	byEngine := map[string]*models.OCREngineStats{}
+	for _, name := range ocr.EngineNames {
+		byEngine[name] = &models.OCREngineStats{Engine: name}
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT a->>'engine', COUNT(*), COUNT(*) FILTER (WHERE a ? 'error')
+		FROM file_parse_results p, jsonb_array_elements(p.ocr_attempts) a
+		WHERE p.ocr_attempts IS NOT NULL AND p.parsed_at >= NOW() - make_interval(days => $1)
+		GROUP BY 1
+	`, days)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "aggregate ocr attempts"), r)
+		return
+	}
+	for rows.Next() {
+		var name string
+		var attempts, failures int
+		if rows.Scan(&name, &attempts, &failures) == nil && byEngine[name] != nil {
+			byEngine[name].Attempts, byEngine[name].Failures = attempts, failures
+		}
+	}
+	rows.Close()
+
+	rows, err = h.db.Pool().Query(ctx, `
+		SELECT ocr_engine, COUNT(*), COALESCE(AVG(ocr_confidence), 0)::float8,
+			COUNT(*) FILTER (WHERE ocr_confidence < $2)
+		FROM file_parse_results
+		WHERE ocr_engine IS NOT NULL AND parsed_at >= NOW() - make_interval(days => $1)
+		GROUP BY 1
+	`, days, float64(h.config.OCRMinConfidence)/100)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "aggregate ocr results"), r)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var s models.OCREngineStats
+		if rows.Scan(&name, &s.Accepted, &s.AvgConfidence, &s.LowConfidence) == nil && byEngine[name] != nil {
+			byEngine[name].Accepted, byEngine[name].AvgConfidence, byEngine[name].LowConfidence = s.Accepted, s.AvgConfidence, s.LowConfidence
+		}
+	}
+
+	// Report engines in chain order, including those not tried in the window
+	engines := []models.OCREngineStats{}
+	for _, name := range ocr.EngineNames {
+		engines = append(engines, *byEngine[name])
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"days":           days,
+		"min_confidence": float64(h.config.OCRMinConfidence) / 100,
+		"engines":        engines,
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/portfolio"
+)
+
+const defaultPortfolioWindowDays = 90
+
+// PortfolioResponse is the ABC classification of a company's products
+type PortfolioResponse struct {
+	WindowDays    int                        `json:"window_days"`
+	TotalRevenue  float64                    `json:"total_revenue"`
+	TotalMargin   float64                    `json:"total_margin"`
+	Summary       []portfolio.Summary        `json:"summary"`
+	FocusProducts []string                   `json:"focus_products"`
+	Products      []portfolio.Classification `json:"products"`
+}
+
+// GetPortfolio classifies products into A/B/C classes by revenue and margin
+func (h *Handler) GetPortfolio(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	window := defaultPortfolioWindowDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 7 || n > maxQualityWindowDays {
+			h.respondError(w, errors.NewValidationError("Invalid days", "days must be between 7 and 365"), r)
+			return
+		}
+		window = n
+	}
+
+	results, err := h.classifyPortfolio(r.Context(), companyID, window)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "classify products"), r)
+		return
+	}
+
+	resp := PortfolioResponse{
+		WindowDays:    window,
+		Summary:       portfolio.Summarize(results),
+		FocusProducts: portfolio.FocusProducts(results),
+		Products:      results,
+	}
+	for _, c := range results {
+		resp.TotalRevenue += c.Revenue
+		resp.TotalMargin += c.Margin
+	}
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// classifyPortfolio loads per-product revenue and margin over the window and runs ABC analysis
+func (h *Handler) classifyPortfolio(ctx context.Context, companyID string, windowDays int) ([]portfolio.Classification, error) {
+	since := localDate(time.Now(), h.companyLocation(ctx, companyID)).AddDate(0, 0, -windowDays)
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT p.id, p.name,
+			COALESCE(SUM(s.quantity), 0),
+			COALESCE(SUM(s.quantity * s.price), 0),
+			COALESCE(SUM(s.quantity * (s.price - COALESCE(p.cost, 0))), 0)
+		FROM products p
+		LEFT JOIN sales_history s ON s.product_id = p.id AND s.sale_date >= $2 AND NOT COALESCE(s.excluded, false)
+		WHERE p.company_id = $1 AND COALESCE(p.is_active, true)
+		GROUP BY p.id, p.name
+	`, companyID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []portfolio.Item
+	for rows.Next() {
+		var it portfolio.Item
+		if err := rows.Scan(&it.ProductID, &it.ProductName, &it.Quantity, &it.Revenue, &it.Margin); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return portfolio.Classify(items), nil
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/prediction"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Failure analytics window, in days
+const (
+	defaultFailureWindowDays = 7
+	maxFailureWindowDays     = 90
+)
+
+// AdminListPredictionJobs lists prediction jobs across all companies, newest first.
+// Supports ?page=, ?page_size=, ?status=, ?company_id= and ?error= (a case-insensitive
+// substring of the job error).
+func (h *Handler) AdminListPredictionJobs(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	q := r.URL.Query()
+	conditions := []string{"TRUE"}
+	args := []interface{}{}
+	if status := q.Get("status"); status != "" {
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("j.status = $%d", len(args)))
+	}
+	if companyID := q.Get("company_id"); companyID != "" {
+		args = append(args, companyID)
+		conditions = append(conditions, fmt.Sprintf("j.company_id = $%d", len(args)))
+	}
+	if e := strings.TrimSpace(q.Get("error")); e != "" {
+		args = append(args, "%"+e+"%")
+		conditions = append(conditions, fmt.Sprintf("j.error ILIKE $%d", len(args)))
+	}
+
+	ctx := r.Context()
+	where := strings.Join(conditions, " AND ")
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM prediction_jobs j WHERE `+where, args...).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count prediction jobs"), r)
+		return
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := h.db.Pool().Query(ctx, fmt.Sprintf(`
+		SELECT j.id, j.company_id, c.name, j.status, COALESCE(j.current_step, ''), COALESCE(j.error, ''),
+			j.created_at, j.started_at, j.finished_at
+		FROM prediction_jobs j
+		JOIN companies c ON c.id = j.company_id
+		WHERE %s
+		ORDER BY j.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list prediction jobs"), r)
+		return
+	}
+	defer rows.Close()
+
+	jobs := []models.AdminPredictionJob{}
+	for rows.Next() {
+		var j models.AdminPredictionJob
+		if err := rows.Scan(&j.ID, &j.CompanyID, &j.CompanyName, &j.Status, &j.CurrentStep, &j.Error,
+			&j.CreatedAt, &j.StartedAt, &j.FinishedAt); err != nil {
+			continue
+		}
+		if j.StartedAt != nil && j.FinishedAt != nil {
+			d := j.FinishedAt.Sub(*j.StartedAt).Seconds()
+			j.DurationSeconds = &d
+		}
+		jobs = append(jobs, j)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    jobs,
+		ItemsKey: "jobs",
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// AdminGetPredictionJob returns any company's prediction job with step-level
+// timings and failure attribution
+func (h *Handler) AdminGetPredictionJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var j models.AdminPredictionJob
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT j.id, j.company_id, c.name, j.status, COALESCE(j.current_step, ''), COALESCE(j.error, ''),
+			j.created_at, j.started_at, j.finished_at
+		FROM prediction_jobs j
+		JOIN companies c ON c.id = j.company_id
+		WHERE j.id = $1
+	`, r.PathValue("job_id")).Scan(&j.ID, &j.CompanyID, &j.CompanyName, &j.Status, &j.CurrentStep, &j.Error,
+		&j.CreatedAt, &j.StartedAt, &j.FinishedAt)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Prediction job"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load prediction job"), r)
+		return
+	}
+	if j.StartedAt != nil && j.FinishedAt != nil {
+		d := j.FinishedAt.Sub(*j.StartedAt).Seconds()
+		j.DurationSeconds = &d
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT step, status, EXTRACT(EPOCH FROM (finished_at - started_at))::float8,
+			COALESCE(error, ''), COALESCE(error_provider, ''), COALESCE(error_kind, '')
+		FROM prediction_job_steps
+		WHERE job_id = $1
+		ORDER BY position
+	`, j.ID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load prediction steps"), r)
+		return
+	}
+	defer rows.Close()
+
+	steps := []models.PredictionStepTiming{}
+	for rows.Next() {
+		var s models.PredictionStepTiming
+		if err := rows.Scan(&s.Step, &s.Status, &s.DurationSeconds, &s.Error, &s.ErrorProvider, &s.ErrorKind); err != nil {
+			continue
+		}
+		steps = append(steps, s)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"job":   j,
+		"steps": steps,
+	})
+}
+
+// AdminPredictionFailures aggregates step outcomes over the last ?days= (default 7)
+// per step and per provider, to surface systemic issues such as a provider outage
+func (h *Handler) AdminPredictionFailures(w http.ResponseWriter, r *http.Request) {
+	days := defaultFailureWindowDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxFailureWindowDays {
+			h.respondError(w, errors.NewValidationError("Invalid days", fmt.Sprintf("days must be between 1 and %d", maxFailureWindowDays)), r)
+			return
+		}
+		days = n
+	}
+
+	ctx := r.Context()
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT step,
+			COUNT(*) FILTER (WHERE status = $2),
+			COUNT(*) FILTER (WHERE status = $3),
+			COUNT(*) FILTER (WHERE status = $4),
+			COALESCE(AVG(EXTRACT(EPOCH FROM (finished_at - started_at))) FILTER (WHERE status = $2), 0)::float8
+		FROM prediction_job_steps
+		WHERE finished_at >= NOW() - make_interval(days => $1)
+		GROUP BY step
+	`, days, prediction.StatusCompleted, prediction.StatusFailed, prediction.StatusTimedOut)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "aggregate prediction steps"), r)
+		return
+	}
+	byStep := map[string]models.PredictionStepFailures{}
+	for rows.Next() {
+		var s models.PredictionStepFailures
+		if rows.Scan(&s.Step, &s.Completed, &s.Failed, &s.TimedOut, &s.AvgDurationSeconds) == nil {
+			byStep[s.Step] = s
+		}
+	}
+	rows.Close()
+
+	// Report steps in execution order, including those with no runs in the window
+	steps := []models.PredictionStepFailures{}
+	for _, step := range prediction.Steps {
+		s := byStep[step]
+		s.Step = step
+		steps = append(steps, s)
+	}
+
+	rows, err = h.db.Pool().Query(ctx, `
+		SELECT COALESCE(error_provider, 'internal'), COALESCE(error_kind, 'error'), COUNT(*),
+			ARRAY_AGG(DISTINCT step), MAX(finished_at)
+		FROM prediction_job_steps
+		WHERE status IN ($2, $3) AND finished_at >= NOW() - make_interval(days => $1)
+		GROUP BY 1, 2
+		ORDER BY 3 DESC
+	`, days, prediction.StatusFailed, prediction.StatusTimedOut)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "aggregate prediction failures"), r)
+		return
+	}
+	defer rows.Close()
+
+	providers := []models.PredictionProviderFailures{}
+	for rows.Next() {
+		var p models.PredictionProviderFailures
+		if rows.Scan(&p.Provider, &p.Kind, &p.Failures, &p.Steps, &p.LastSeen) == nil {
+			providers = append(providers, p)
+		}
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"days":        days,
+		"by_step":     steps,
+		"by_provider": providers,
+	})
+}
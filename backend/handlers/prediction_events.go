@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/cachebus"
+	"github.com/bantuaku/backend/services/prediction"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Server-sent events of a prediction job
+const (
+	predictionEventProgress  = "progress"  // Job status and progress; sent on connect and after every change
+	predictionEventStep      = "step"      // A step started, completed, failed or timed out
+	predictionEventCompleted = "completed" // The job completed; carries the job with its results
+	predictionEventFailed    = "failed"    // The job failed; carries the job with its error
+)
+
+const (
+	// predictionStreamMaxAge is how long an event stream stays open; clients
+	// reconnect to keep following a longer job
+	predictionStreamMaxAge = 15 * time.Minute
+	// predictionStreamPoll reloads the job in case a progress notification from
+	// another instance was missed
+	predictionStreamPoll = 5 * time.Second
+	// predictionStreamPing keeps idle streams from being closed by proxies
+	predictionStreamPing = 20 * time.Second
+)
+
+// PredictionProgressEvent is the state of a prediction job as streamed to the dashboard
+type PredictionProgressEvent struct {
+	JobID       string                    `json:"job_id"`
+	Status      string                    `json:"status"`
+	CurrentStep string                    `json:"current_step,omitempty"`
+	Progress    models.PredictionProgress `json:"progress"`
+	Percent     int                       `json:"percent"`
+	Steps       []PredictionStepEvent     `json:"steps"`
+}
+
+// PredictionStepEvent is the state of one step, without its result; completed
+// results are read from /api/v1/predictions/{job_id}/results/{step}
+type PredictionStepEvent struct {
+	JobID      string     `json:"job_id"`
+	Step       string     `json:"step"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// predictionStreamState is what a stream has already told its client
+type predictionStreamState struct {
+	status string
+	steps  map[string]string // Step status
+}
+
+// StreamPredictionEvents follows a prediction job as server-sent events:
+// progress on connect and after every change, step as each step starts and
+// finishes, and finally completed or failed, after which the stream ends.
+func (h *Handler) StreamPredictionEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	jobID := r.PathValue("job_id")
+
+	// Watch before the first load so no change in between is missed
+	changed, stop := h.jobs.Watch(jobID)
+	defer stop()
+	job, err := h.loadPredictionJob(ctx, companyID, jobID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Prediction job"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load prediction job"), r)
+		return
+	}
+
+	stream := newEventStream(w, predictionStreamMaxAge+time.Minute)
+	state := predictionStreamState{steps: map[string]string{}}
+	if done, err := sendPredictionEvents(stream, job, &state); done || err != nil {
+		return
+	}
+
+	maxAge := time.NewTimer(predictionStreamMaxAge)
+	defer maxAge.Stop()
+	poll := time.NewTicker(predictionStreamPoll)
+	defer poll.Stop()
+	ping := time.NewTicker(predictionStreamPing)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-maxAge.C:
+			return
+		case <-ping.C:
+			if stream.ping() != nil {
+				return
+			}
+			continue
+		case <-changed:
+		case <-poll.C:
+		}
+
+		job, err := h.loadPredictionJob(ctx, companyID, jobID)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("Failed to reload prediction job for event stream", "job_id", jobID, "error", err.Error())
+			}
+			return
+		}
+		if done, err := sendPredictionEvents(stream, job, &state); done || err != nil {
+			return
+		}
+	}
+}
+
+// sendPredictionEvents sends what changed in job since the last call: a step
+// event per step whose status changed, then progress, then completed or failed
+// once the job has finished, reporting whether it has
+func sendPredictionEvents(stream *eventStream, job *models.PredictionJob, state *predictionStreamState) (bool, error) {
+	first := state.status == ""
+	changed := job.Status != state.status
+	state.status = job.Status
+
+	steps := make([]PredictionStepEvent, len(job.Steps))
+	for i, s := range job.Steps {
+		steps[i] = PredictionStepEvent{JobID: job.ID, Step: s.Step, Status: s.Status, Error: s.Error, StartedAt: s.StartedAt, FinishedAt: s.FinishedAt}
+		if state.steps[s.Step] == s.Status {
+			continue
+		}
+		state.steps[s.Step] = s.Status
+		changed = true
+		if first {
+			continue // The progress event carries the state on connect
+		}
+		if err := stream.send(predictionEventStep, steps[i]); err != nil {
+			return true, err
+		}
+	}
+
+	if changed {
+		progress := PredictionProgressEvent{
+			JobID:       job.ID,
+			Status:      job.Status,
+			CurrentStep: job.CurrentStep,
+			Progress:    job.Progress,
+			Steps:       steps,
+		}
+		if job.Progress.Total > 0 {
+			progress.Percent = job.Progress.Completed * 100 / job.Progress.Total
+		}
+		if err := stream.send(predictionEventProgress, progress); err != nil {
+			return true, err
+		}
+	}
+
+	switch job.Status {
+	case prediction.StatusCompleted:
+		return true, stream.send(predictionEventCompleted, job)
+	case prediction.StatusFailed:
+		return true, stream.send(predictionEventFailed, job)
+	}
+	return false, nil
+}
+
+// predictionProgressed tells the clients following a job, on every instance,
+// that it changed
+func (h *Handler) predictionProgressed(ctx context.Context, jobID string) {
+	h.invalidate(ctx, cachebus.KindPrediction, jobID)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/prediction"
+)
+
+// predictionStatuses are the job statuses accepted by the ?status= filter
+var predictionStatuses = []string{
+	prediction.StatusPending,
+	prediction.StatusRunning,
+	prediction.StatusCompleted,
+	prediction.StatusFailed,
+	prediction.StatusTimedOut,
+}
+
+// ListPredictionJobs returns the company's prediction runs, newest first, for the
+// analysis history screen. Supports ?page=, ?page_size=, ?status= and a ?from= /
+// ?to= date range (YYYY-MM-DD, inclusive, in the company's timezone).
+func (h *Handler) ListPredictionJobs(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	q := r.URL.Query()
+	conditions := []string{"company_id = $1"}
+	args := []interface{}{companyID}
+
+	if status := q.Get("status"); status != "" {
+		valid := false
+		for _, s := range predictionStatuses {
+			valid = valid || s == status
+		}
+		if !valid {
+			h.respondError(w, errors.NewValidationError("Invalid status", "status must be one of "+strings.Join(predictionStatuses, ", ")), r)
+			return
+		}
+		args = append(args, status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	if q.Get("from") != "" || q.Get("to") != "" {
+		loc := h.companyLocation(ctx, companyID)
+		for _, bound := range []struct{ param, op string }{{"from", ">="}, {"to", "<"}} {
+			s := q.Get(bound.param)
+			if s == "" {
+				continue
+			}
+			day, err := time.ParseInLocation("2006-01-02", s, loc)
+			if err != nil {
+				h.respondError(w, errors.NewValidationError("Invalid "+bound.param, bound.param+" must be a date in YYYY-MM-DD format"), r)
+				return
+			}
+			if bound.param == "to" {
+				day = day.AddDate(0, 0, 1)
+			}
+			args = append(args, day)
+			conditions = append(conditions, fmt.Sprintf("created_at %s $%d", bound.op, len(args)))
+		}
+	}
+
+	where := strings.Join(conditions, " AND ")
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM prediction_jobs WHERE `+where, args...).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count prediction jobs"), r)
+		return
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := h.db.Pool().Query(ctx, fmt.Sprintf(`
+		SELECT id, status, COALESCE(error, ''), created_at, started_at, finished_at
+		FROM prediction_jobs
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list prediction jobs"), r)
+		return
+	}
+	jobs := []models.PredictionJobSummary{}
+	jobIDs := []string{}
+	for rows.Next() {
+		var j models.PredictionJobSummary
+		if err := rows.Scan(&j.ID, &j.Status, &j.Error, &j.CreatedAt, &j.StartedAt, &j.FinishedAt); err != nil {
+			rows.Close()
+			h.respondError(w, errors.NewDatabaseError(err, "scan prediction job"), r)
+			return
+		}
+		if j.StartedAt != nil && j.FinishedAt != nil {
+			d := j.FinishedAt.Sub(*j.StartedAt).Seconds()
+			j.DurationSeconds = &d
+		}
+		jobs = append(jobs, j)
+		jobIDs = append(jobIDs, j.ID)
+	}
+	rows.Close()
+
+	if err := h.summarizePredictionSteps(ctx, jobs, jobIDs); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load prediction steps"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    jobs,
+		ItemsKey: "jobs",
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// summarizePredictionSteps fills in step counts and the sections with data for the listed jobs
+func (h *Handler) summarizePredictionSteps(ctx context.Context, jobs []models.PredictionJobSummary, jobIDs []string) error {
+	if len(jobIDs) == 0 {
+		return nil
+	}
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT job_id, step, status, result
+		FROM prediction_job_steps
+		WHERE job_id = ANY($1)
+	`, jobIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type stepCounts struct {
+		completed, total int
+		results          prediction.Results
+	}
+	byJob := map[string]*stepCounts{}
+	for rows.Next() {
+		var jobID, step, status string
+		var result []byte
+		if err := rows.Scan(&jobID, &step, &status, &result); err != nil {
+			return err
+		}
+		c := byJob[jobID]
+		if c == nil {
+			c = &stepCounts{}
+			byJob[jobID] = c
+		}
+		c.total++
+		if status != prediction.StatusCompleted {
+			continue
+		}
+		c.completed++
+		if len(result) > 0 {
+			// An undecodable result is simply not counted as data
+			_ = c.results.Set(step, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		jobs[i].SectionsWithData = []string{}
+		if c := byJob[jobs[i].ID]; c != nil {
+			jobs[i].StepsCompleted = c.completed
+			jobs[i].StepsTotal = c.total
+			jobs[i].SectionsWithData = c.results.SectionsWithData()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,792 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/embeddings"
+	"github.com/bantuaku/backend/services/exa"
+	"github.com/bantuaku/backend/services/kbli"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/market"
+	"github.com/bantuaku/backend/services/metering"
+	"github.com/bantuaku/backend/services/portfolio"
+	"github.com/bantuaku/backend/services/prediction"
+	"github.com/bantuaku/backend/services/trendkeywords"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// predictionStep computes one section of a prediction job from the sections before it
+type predictionStep func(ctx context.Context, companyID string, results *prediction.Results) (interface{}, error)
+
+// PredictionDiffResponse compares two completed prediction jobs
+type PredictionDiffResponse struct {
+	JobID         string          `json:"job_id"`
+	PreviousJobID string          `json:"previous_job_id"`
+	Diff          prediction.Diff `json:"diff"`
+	Summary       string          `json:"summary"`
+	SummarySource string          `json:"summary_source"` // "ai" or "rules"
+}
+
+// PredictionStepResponse is one step of a prediction job along with the job status
+type PredictionStepResponse struct {
+	JobID     string `json:"job_id"`
+	JobStatus string `json:"job_status"`
+	models.PredictionStep
+}
+
+// StartPrediction starts a prediction job for the company unless one is already running
+func (h *Handler) StartPrediction(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	ctx := r.Context()
+	if active, err := h.activePredictionJobID(ctx, companyID); err == nil && active != "" {
+		h.respondError(w, errors.NewConflictError("Prediction already running", "job "+active+" is still in progress"), r)
+		return
+	}
+	usage, err := h.consumeUsage(ctx, companyID, metering.MetricPredictions, 1)
+	setUsageHeaders(w, usage)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	jobID := uuid.New().String()
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO prediction_jobs (id, company_id, status, created_at)
+		VALUES ($1, $2, $3, NOW())
+	`, jobID, companyID, prediction.StatusPending)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create prediction job"), r)
+		return
+	}
+	for i, step := range prediction.Steps {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO prediction_job_steps (job_id, step, position, status)
+			VALUES ($1, $2, $3, $4)
+		`, jobID, step, i, prediction.StatusPending)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "create prediction steps"), r)
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.startJob(ctx, jobPrediction, jobID, predictionJobTimeout, func(ctx context.Context) {
+		h.processJob(ctx, jobID, companyID)
+	})
+
+	job, err := h.loadPredictionJob(ctx, companyID, jobID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load prediction job"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusAccepted, job)
+}
+
+// GetActiveJob returns the company's pending or running prediction job, if any
+func (h *Handler) GetActiveJob(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	jobID, err := h.activePredictionJobID(r.Context(), companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "find active prediction job"), r)
+		return
+	}
+	if jobID == "" {
+		h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"job": nil})
+		return
+	}
+
+	job, err := h.loadPredictionJob(r.Context(), companyID, jobID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load prediction job"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"job": job})
+}
+
+// GetJob returns a prediction job with its step results
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	job, err := h.loadPredictionJob(r.Context(), companyID, r.PathValue("job_id"))
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Prediction job"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, job)
+}
+
+// GetJobStepResult returns one step of a prediction job, so completed sections can
+// be rendered while later steps are still running. The result is set once the
+// step has completed.
+func (h *Handler) GetJobStepResult(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	step := r.PathValue("step")
+	if !prediction.IsStep(step) {
+		h.respondError(w, errors.NewValidationError("Invalid step", "step must be one of "+strings.Join(prediction.Steps, ", ")), r)
+		return
+	}
+
+	var resp PredictionStepResponse
+	var result []byte
+	err := h.db.Pool().QueryRow(r.Context(), `
+		SELECT j.id, j.status, s.step, s.status, s.result, COALESCE(s.error, ''), s.started_at, s.finished_at
+		FROM prediction_jobs j
+		JOIN prediction_job_steps s ON s.job_id = j.id
+		WHERE j.id = $1 AND j.company_id = $2 AND s.step = $3
+	`, r.PathValue("job_id"), companyID, step).Scan(&resp.JobID, &resp.JobStatus, &resp.Step, &resp.Status, &result,
+		&resp.Error, &resp.StartedAt, &resp.FinishedAt)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Prediction job"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load prediction step"), r)
+		return
+	}
+	if resp.Status == prediction.StatusCompleted && len(result) > 0 {
+		resp.Result = json.RawMessage(result)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// DiffPredictionJobs compares two completed prediction jobs section by section
+// and summarizes the notable changes
+func (h *Handler) DiffPredictionJobs(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	jobID, previousID := r.PathValue("job_id"), r.PathValue("previous_job_id")
+	if jobID == previousID {
+		h.respondError(w, errors.NewValidationError("Invalid jobs", "compare two different jobs"), r)
+		return
+	}
+
+	ctx := r.Context()
+	current, err := h.loadPredictionResults(ctx, companyID, jobID)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	previous, err := h.loadPredictionResults(ctx, companyID, previousID)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	diff := prediction.Compare(*previous, *current)
+	resp := PredictionDiffResponse{JobID: jobID, PreviousJobID: previousID, Diff: diff, SummarySource: "rules"}
+	if text, ok := h.aiText(ctx, h.withBrandVoice(ctx, companyID, "Kamu adalah analis bisnis untuk UMKM Indonesia. Jawab dalam Bahasa Indonesia."),
+		prediction.BuildDiffPrompt(*previous, *current, diff), 500); ok {
+		resp.Summary, resp.SummarySource = text, "ai"
+	} else {
+		resp.Summary = prediction.Summary(diff)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// processJob runs each step of a prediction job in order, storing results as
+// they complete. A failed or timed-out step fails the job; earlier results are
+// kept. Shutdown cancels the running step and fails the job the same way.
+func (h *Handler) processJob(ctx context.Context, jobID, companyID string) {
+	ctx = aispend.WithFeature(middleware.WithCompanyID(ctx, companyID), aispend.FeaturePrediction, jobID)
+	// Failures are still recorded after ctx is cancelled
+	final := context.WithoutCancel(ctx)
+	log := jobLogger(ctx)
+	h.db.Pool().Exec(ctx, `
+		UPDATE prediction_jobs SET status = $2, started_at = NOW() WHERE id = $1
+	`, jobID, prediction.StatusRunning)
+	h.predictionProgressed(ctx, jobID)
+
+	steps := h.predictionSteps()
+	var results prediction.Results
+	for _, step := range prediction.Steps {
+		h.db.Pool().Exec(ctx, `UPDATE prediction_jobs SET current_step = $2 WHERE id = $1`, jobID, step)
+		h.db.Pool().Exec(ctx, `
+			UPDATE prediction_job_steps SET status = $3, started_at = NOW() WHERE job_id = $1 AND step = $2
+		`, jobID, step, prediction.StatusRunning)
+		h.predictionProgressed(ctx, jobID)
+
+		result, err := h.runPredictionStep(ctx, step, steps[step], companyID, &results)
+		var raw []byte
+		if err == nil {
+			raw, err = json.Marshal(result)
+		}
+		if err == nil {
+			err = results.Set(step, raw)
+		}
+		if err != nil {
+			status, jobError := prediction.StatusFailed, fmt.Sprintf("%s: %s", step, err.Error())
+			switch {
+			case h.interruptedByShutdown(ctx):
+				jobError = fmt.Sprintf("%s: interrupted by server shutdown", step)
+			case stderrors.Is(err, context.DeadlineExceeded):
+				status = prediction.StatusTimedOut
+				jobError = fmt.Sprintf("%s: timed out after %s", step, prediction.StepTimeout(step))
+			}
+			provider, kind := classifyStepFailure(err)
+			log.Warn("Prediction step failed", "step", step, "status", status,
+				"provider", provider, "kind", kind, "error", err.Error())
+			h.db.Pool().Exec(final, `
+				UPDATE prediction_job_steps SET status = $3, error = $4, error_provider = $5, error_kind = $6, finished_at = NOW()
+				WHERE job_id = $1 AND step = $2
+			`, jobID, step, status, err.Error(), provider, kind)
+			h.db.Pool().Exec(final, `
+				UPDATE prediction_jobs SET status = $2, error = $3, finished_at = NOW() WHERE id = $1
+			`, jobID, prediction.StatusFailed, jobError)
+			h.predictionProgressed(final, jobID)
+			return
+		}
+
+		h.db.Pool().Exec(ctx, `
+			UPDATE prediction_job_steps SET status = $3, result = $4, finished_at = NOW() WHERE job_id = $1 AND step = $2
+		`, jobID, step, prediction.StatusCompleted, raw)
+		h.predictionProgressed(ctx, jobID)
+	}
+
+	h.db.Pool().Exec(ctx, `
+		UPDATE prediction_jobs SET status = $2, current_step = NULL, finished_at = NOW() WHERE id = $1
+	`, jobID, prediction.StatusCompleted)
+	h.predictionProgressed(ctx, jobID)
+	log.Info("Prediction job completed")
+}
+
+// runPredictionStep runs a step under its deadline, retrying transient provider errors
+func (h *Handler) runPredictionStep(ctx context.Context, step string, run predictionStep, companyID string, results *prediction.Results) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(aispend.WithStep(ctx, step), prediction.StepTimeout(step))
+	defer cancel()
+
+	var result interface{}
+	err := prediction.Retry(ctx, prediction.DefaultRetry, isTransientProviderError, func() error {
+		var err error
+		result, err = run(ctx, companyID, results)
+		return err
+	})
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		// Report the deadline rather than whatever call it interrupted
+		return nil, ctx.Err()
+	}
+	return result, err
+}
+
+// isTransientProviderError reports whether an AI or search provider error is
+// worth retrying: rate limits, 5xx responses and network timeouts
+func isTransientProviderError(err error) bool {
+	var provider interface{ Transient() bool }
+	if stderrors.As(err, &provider) {
+		return provider.Transient()
+	}
+	var netErr net.Error
+	return stderrors.As(err, &netErr) && netErr.Timeout()
+}
+
+// classifyStepFailure attributes a step error to the provider that caused it and
+// the kind of failure, so operators can tell a provider outage from a bug
+func classifyStepFailure(err error) (provider, kind string) {
+	provider, kind = "internal", "error"
+	var kolosalErr *kolosal.APIError
+	var exaErr *exa.APIError
+	var netErr net.Error
+	status := 0
+	switch {
+	case stderrors.As(err, &kolosalErr):
+		provider, status = "kolosal", kolosalErr.StatusCode
+	case stderrors.As(err, &exaErr):
+		provider, status = "exa", exaErr.StatusCode
+	case stderrors.As(err, &netErr):
+		provider = "network"
+	}
+
+	switch {
+	case stderrors.Is(err, context.DeadlineExceeded) || (netErr != nil && netErr.Timeout()):
+		kind = "timeout"
+	case status == http.StatusTooManyRequests:
+		kind = "rate_limited"
+	case status >= 500:
+		kind = "server_error"
+	case status >= 400:
+		kind = "client_error"
+	}
+	return provider, kind
+}
+
+func (h *Handler) predictionSteps() map[string]predictionStep {
+	return map[string]predictionStep{
+		prediction.StepKeywords:    h.predictKeywords,
+		prediction.StepMarket:      h.predictMarket,
+		prediction.StepMarketing:   h.predictMarketing,
+		prediction.StepRegulations: h.predictRegulations,
+		prediction.StepForecast:    h.predictForecast,
+	}
+}
+
+// predictKeywords derives search keywords from the industry, top categories and best sellers
+func (h *Handler) predictKeywords(ctx context.Context, companyID string, _ *prediction.Results) (interface{}, error) {
+	var industry string
+	h.db.Pool().QueryRow(ctx, `SELECT COALESCE(industry, '') FROM companies WHERE id = $1`, companyID).Scan(&industry)
+
+	keywords := []string{}
+	seen := map[string]bool{}
+	add := func(k string) {
+		k = strings.TrimSpace(k)
+		if k != "" && !seen[strings.ToLower(k)] {
+			seen[strings.ToLower(k)] = true
+			keywords = append(keywords, k)
+		}
+	}
+	add(industry)
+	for _, c := range h.topProductCategories(ctx, companyID) {
+		add(c)
+	}
+
+	classified, err := h.classifyPortfolio(ctx, companyID, defaultPortfolioWindowDays)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range classified {
+		if i == 5 {
+			break
+		}
+		add(c.ProductName)
+	}
+
+	// Shared industry keywords fill in, notably for new companies with no
+	// sales or products yet
+	shared, err := h.sharedKeywords(ctx, industry)
+	if err != nil {
+		return nil, err
+	}
+	merged := trendkeywords.Merge(keywords, shared, trendkeywords.MaxSharedInPrompt)
+	return prediction.KeywordsResult{Keywords: merged, Shared: merged[len(keywords):]}, nil
+}
+
+// predictMarket researches the market for the keywords and summarizes the outlook
+func (h *Handler) predictMarket(ctx context.Context, companyID string, results *prediction.Results) (interface{}, error) {
+	var industry, city string
+	h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(industry, ''), COALESCE(city, '') FROM companies WHERE id = $1
+	`, companyID).Scan(&industry, &city)
+
+	var terms []string
+	if results.Keywords != nil {
+		terms = results.Keywords.Keywords
+	}
+	query := market.BuildQuery(industry, city, terms)
+	if query == "" || h.config.ExaAPIKey == "" {
+		return prediction.MarketResult{Summary: "Prediksi pasar belum tersedia: lengkapi industri bisnis Anda dan aktifkan riset pasar."}, nil
+	}
+
+	research, err := h.marketResearch(ctx, companyID, query, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result := prediction.MarketResult{Sources: []string{}}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Berdasarkan artikel berikut tentang \"%s\", buat prediksi pasar untuk 1-3 bulan ke depan:\n", query)
+	for _, a := range research.Articles {
+		result.Sources = append(result.Sources, a.URL)
+		fmt.Fprintf(&b, "\n- %s (%s): %s", a.Title, a.URL, truncateRunes(a.Excerpt, 400))
+	}
+	if density := h.regionalDensity(ctx, companyID); density != "" {
+		fmt.Fprintf(&b, "\n\n%s Pertimbangkan tingkat persaingan lokal ini.", density)
+	}
+	b.WriteString("\n\nTulis maksimal 5 kalimat dalam bahasa Indonesia. Jangan mengarang fakta di luar artikel.")
+	b.WriteString(" Akhiri dengan bagian \"Sumber Data\" berisi URL artikel yang kamu gunakan, hanya dari daftar di atas.")
+
+	system := h.withBrandVoice(ctx, companyID, "Kamu adalah analis pasar untuk UMKM Indonesia. Jawab dalam Bahasa Indonesia.")
+	if out, ok := h.aiStructured(ctx, system, b.String(), prediction.StepMarket, 800); ok {
+		result.Outlook = out.(*prediction.MarketOutlook)
+		result.Summary, result.Grounding = groundText(result.Outlook.Summary, result.Sources)
+		result.Outlook.Summary = result.Summary
+	} else if text, ok := h.aiText(ctx, system, b.String(), 600); ok {
+		result.Summary, result.Grounding = groundText(text, result.Sources)
+	} else {
+		titles := make([]string, 0, 3)
+		for i, a := range research.Articles {
+			if i == 3 {
+				break
+			}
+			titles = append(titles, a.Title)
+		}
+		result.Summary = fmt.Sprintf("Ditemukan %d artikel terkait pasar Anda. Sorotan: %s.", len(research.Articles), strings.Join(titles, "; "))
+	}
+	return result, nil
+}
+
+// predictMarketing recommends marketing actions around the focus products and market outlook
+func (h *Handler) predictMarketing(ctx context.Context, companyID string, results *prediction.Results) (interface{}, error) {
+	classified, err := h.classifyPortfolio(ctx, companyID, defaultPortfolioWindowDays)
+	if err != nil {
+		return nil, err
+	}
+	focus := portfolio.FocusProducts(classified)
+	result := prediction.MarketingResult{FocusProducts: focus}
+
+	var b strings.Builder
+	b.WriteString("Buat 3 rekomendasi marketing konkret untuk bulan depan bagi UMKM ini.\n")
+	if len(focus) > 0 {
+		fmt.Fprintf(&b, "\nProduk fokus (penyumbang omzet dan margin terbesar): %s", strings.Join(focus, ", "))
+	}
+	if results.Keywords != nil && len(results.Keywords.Keywords) > 0 {
+		fmt.Fprintf(&b, "\nKata kunci bisnis: %s", strings.Join(results.Keywords.Keywords, ", "))
+	}
+	// Marketing builds on the market research, so only its sources may be cited
+	var marketSources []string
+	if results.Market != nil && results.Market.Summary != "" {
+		fmt.Fprintf(&b, "\nPrediksi pasar: %s", results.Market.Summary)
+		marketSources = results.Market.Sources
+	}
+	b.WriteString("\n\nJawab dalam bahasa Indonesia, singkat dan bisa langsung dijalankan.")
+
+	system := h.withBrandVoice(ctx, companyID, "Kamu adalah konsultan marketing untuk UMKM Indonesia. Jawab dalam Bahasa Indonesia.")
+	if out, ok := h.aiStructured(ctx, system, b.String(), prediction.StepMarketing, 800); ok {
+		result.Plan = out.(*prediction.MarketingPlan)
+		result.Summary, result.Grounding = groundText(result.Plan.Summary, marketSources)
+		result.Plan.Summary = result.Summary
+	} else if text, ok := h.aiText(ctx, system, b.String(), 600); ok {
+		result.Summary, result.Grounding = groundText(text, marketSources)
+	} else if len(focus) > 0 {
+		result.Summary = fmt.Sprintf("Prioritaskan promosi untuk %s: tampilkan di posisi teratas katalog, buat paket bundling dengan produk yang kurang laku, dan ajak pelanggan lama untuk membeli kembali.", strings.Join(focus, ", "))
+	} else {
+		result.Summary = "Catat penjualan secara rutin agar produk unggulan dapat dikenali dan dijadikan fokus promosi."
+	}
+	return result, nil
+}
+
+// predictRegulations finds indexed regulations relevant to the industry and keywords
+func (h *Handler) predictRegulations(ctx context.Context, companyID string, results *prediction.Results) (interface{}, error) {
+	result := prediction.RegulationsResult{Regulations: []string{}}
+	if h.config.KolosalAPIKey == "" {
+		result.Summary = "Informasi peraturan belum tersedia."
+		return result, nil
+	}
+
+	// The KBLI title matches regulation wording better than a free-text industry
+	var industry, kbliCode, kbliTitle string
+	h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(c.industry, ''), COALESCE(c.industry_kbli, ''), COALESCE(k.title, '')
+		FROM companies c LEFT JOIN kbli_categories k ON k.code = c.industry_kbli
+		WHERE c.id = $1
+	`, companyID).Scan(&industry, &kbliCode, &kbliTitle)
+	query := "perizinan dan peraturan usaha " + industry
+	if kbliTitle != "" && !strings.EqualFold(kbliTitle, industry) {
+		query += " " + kbliTitle
+	}
+	if kbliCode != "" {
+		industry += " (KBLI " + kbli.Label(kbliCode, kbliTitle) + ")"
+	}
+	if results.Keywords != nil {
+		query += " " + strings.Join(results.Keywords.Keywords, " ")
+	}
+
+	client := h.kolosalClient()
+	matches, err := h.searchVectors(ctx, client, embeddings.NamespaceRegulations, nil, query, 8, 0.3)
+	if err != nil {
+		return nil, err
+	}
+	var sourceIDs []string
+	for _, m := range matches {
+		sourceIDs = append(sourceIDs, m.SourceID)
+	}
+	rows, err := h.db.Pool().Query(ctx, `SELECT title, source_url FROM regulation_documents WHERE id = ANY($1) ORDER BY title`, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var title, sourceURL string
+		if rows.Scan(&title, &sourceURL) == nil {
+			result.Regulations = append(result.Regulations, title)
+			result.Sources = append(result.Sources, sourceURL)
+		}
+	}
+
+	// Curated guides written by our team are cited ahead of scraped regulations
+	citations, err := h.knowledgeBaseCitations(ctx, client, query, maxKBRegulationCitations)
+	if err != nil {
+		logger.Warn("Knowledge base search failed", "company_id", companyID, "error", err.Error())
+	}
+	if len(citations) > 0 {
+		var titles, urls []string
+		for _, c := range citations {
+			titles = append(titles, c.Title)
+			urls = append(urls, c.URL)
+		}
+		result.Regulations = append(titles, result.Regulations...)
+		result.Sources = append(urls, result.Sources...)
+	}
+
+	if len(result.Regulations) == 0 {
+		result.Summary = "Belum ditemukan peraturan yang relevan dengan bisnis Anda."
+		return result, nil
+	}
+	result.Summary = fmt.Sprintf("%d peraturan relevan dengan bisnis Anda. Pastikan izin dan kewajibannya sudah dipenuhi.", len(result.Regulations))
+
+	prompt := fmt.Sprintf("Untuk usaha di bidang %s, jelaskan kewajiban dan prioritas dari peraturan berikut:\n- %s\n\nGunakan judul peraturan persis seperti pada daftar.",
+		industry, strings.Join(result.Regulations, "\n- "))
+	if out, ok := h.aiStructured(ctx, h.withBrandVoice(ctx, companyID, "Kamu adalah konsultan perizinan usaha di Indonesia. Jawab dalam Bahasa Indonesia."), prompt, prediction.StepRegulations, 800); ok {
+		brief := out.(*prediction.RegulationsBrief)
+		known := map[string]string{}
+		for _, title := range result.Regulations {
+			known[strings.ToLower(title)] = title
+		}
+		// Keep only items for regulations that were actually retrieved
+		result.Items = []prediction.RegulationItem{}
+		var removed []string
+		for _, item := range brief.Items {
+			if title, ok := known[strings.ToLower(strings.TrimSpace(item.Title))]; ok {
+				item.Title = title
+				result.Items = append(result.Items, item)
+			} else {
+				removed = append(removed, item.Title)
+			}
+		}
+		summary, urls := prediction.CheckCitations(brief.Summary, result.Sources)
+		grounding := prediction.NewGrounding(len(brief.Items)+urls.Cited, len(result.Items)+urls.Verified, append(removed, urls.Removed...))
+		result.Summary, result.Grounding = summary, &grounding
+	}
+	return result, nil
+}
+
+// predictForecast forecasts 30-day demand for every active product
+func (h *Handler) predictForecast(ctx context.Context, companyID string, _ *prediction.Results) (interface{}, error) {
+	since := localDate(time.Now(), h.companyLocation(ctx, companyID)).AddDate(0, 0, -90)
+	points, products, err := h.loadDailySales(ctx, companyID, "", since)
+	if err != nil {
+		return nil, err
+	}
+
+	result := prediction.ForecastResult{Products: []prediction.ProductForecast{}}
+	for _, p := range products {
+		sales := make([]float64, len(points[p.id]))
+		for i, pt := range points[p.id] {
+			sales[i] = pt.Quantity
+		}
+		daily, _, algorithm := forecastDailyRate(sales)
+		units := float64(int(daily*30 + 0.5))
+		result.TotalUnits30d += units
+		result.Products = append(result.Products, prediction.ProductForecast{
+			ProductID:   p.id,
+			ProductName: p.name,
+			Units30d:    units,
+			Algorithm:   algorithm,
+		})
+	}
+	sort.SliceStable(result.Products, func(i, j int) bool {
+		return result.Products[i].Units30d > result.Products[j].Units30d
+	})
+	return result, nil
+}
+
+// aiText runs a single-turn completion, reporting false when AI is unavailable or fails
+func (h *Handler) aiText(ctx context.Context, system, prompt string, maxTokens int) (string, bool) {
+	if h.config.KolosalAPIKey == "" {
+		return "", false
+	}
+	client := h.kolosalClient()
+	resp, err := client.CreateChatCompletion(ctx, kolosal.ChatCompletionRequest{
+		Model: "default",
+		Messages: []kolosal.ChatCompletionMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   maxTokens,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		logger.Warn("AI completion failed", "error", err.Error())
+		return "", false
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), true
+}
+
+// groundText strips citations of sources that were not retrieved from an AI narrative
+func groundText(text string, sources []string) (string, *prediction.Grounding) {
+	cleaned, grounding := prediction.CheckCitations(text, sources)
+	if len(grounding.Removed) > 0 {
+		logger.Warn("Removed unverified citations from AI output", "removed", len(grounding.Removed), "cited", grounding.Cited)
+	}
+	return cleaned, &grounding
+}
+
+// aiStructured asks the provider for a step's output as a forced function call
+// following prediction.Schema, returning the validated typed value. It reports
+// false when AI is unavailable or the output does not match the schema.
+func (h *Handler) aiStructured(ctx context.Context, system, prompt, step string, maxTokens int) (interface{}, bool) {
+	if h.config.KolosalAPIKey == "" {
+		return nil, false
+	}
+	name := "submit_" + step
+	client := h.kolosalClient()
+	resp, err := client.CreateChatCompletion(ctx, kolosal.ChatCompletionRequest{
+		Model: "default",
+		Messages: []kolosal.ChatCompletionMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   maxTokens,
+		Temperature: 0.3,
+		Tools: []kolosal.Tool{{
+			Type: "function",
+			Function: kolosal.ToolFunction{
+				Name:        name,
+				Description: "Kirim hasil analisis dalam format terstruktur",
+				Parameters:  prediction.Schema(step),
+			},
+		}},
+		ToolChoice: kolosal.ForcedToolChoice(name),
+	})
+	if err != nil {
+		logger.Warn("Structured AI completion failed", "step", step, "error", err.Error())
+		return nil, false
+	}
+	if len(resp.Choices) == 0 {
+		return nil, false
+	}
+
+	// Providers without function calling may still answer with the JSON as content
+	msg := resp.Choices[0].Message
+	raw := strings.TrimSpace(msg.Content)
+	for _, call := range msg.ToolCalls {
+		if call.Function.Name == name {
+			raw = call.Function.Arguments
+			break
+		}
+	}
+	out, err := prediction.DecodeStructured(step, []byte(raw))
+	if err != nil {
+		logger.Warn("Discarding structured AI output", "step", step, "error", err.Error())
+		return nil, false
+	}
+	return out, true
+}
+
+// activePredictionJobID returns the company's pending or running job, or "" if there is none
+func (h *Handler) activePredictionJobID(ctx context.Context, companyID string) (string, error) {
+	var jobID string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id FROM prediction_jobs
+		WHERE company_id = $1 AND status IN ($2, $3)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, companyID, prediction.StatusPending, prediction.StatusRunning).Scan(&jobID)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	return jobID, err
+}
+
+// loadPredictionJob loads a company's job and its steps in execution order
+func (h *Handler) loadPredictionJob(ctx context.Context, companyID, jobID string) (*models.PredictionJob, error) {
+	job := &models.PredictionJob{Steps: []models.PredictionStep{}}
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, status, COALESCE(current_step, ''), COALESCE(error, ''), created_at, started_at, finished_at
+		FROM prediction_jobs
+		WHERE id = $1 AND company_id = $2
+	`, jobID, companyID).Scan(&job.ID, &job.Status, &job.CurrentStep, &job.Error, &job.CreatedAt, &job.StartedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT step, status, result, COALESCE(error, ''), started_at, finished_at
+		FROM prediction_job_steps
+		WHERE job_id = $1
+		ORDER BY position
+	`, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.PredictionStep
+		var result []byte
+		if err := rows.Scan(&s.Step, &s.Status, &result, &s.Error, &s.StartedAt, &s.FinishedAt); err != nil {
+			return nil, err
+		}
+		if len(result) > 0 {
+			s.Result = json.RawMessage(result)
+		}
+		if s.Status == prediction.StatusCompleted {
+			job.Progress.Completed++
+		}
+		job.Steps = append(job.Steps, s)
+	}
+	job.Progress.Total = len(job.Steps)
+	return job, rows.Err()
+}
+
+// loadPredictionResults decodes the step results of a completed job
+func (h *Handler) loadPredictionResults(ctx context.Context, companyID, jobID string) (*prediction.Results, error) {
+	job, err := h.loadPredictionJob(ctx, companyID, jobID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("Prediction job")
+	}
+	if job.Status != prediction.StatusCompleted {
+		return nil, errors.NewValidationError("Prediction job not completed", fmt.Sprintf("job %s is %s", jobID, job.Status))
+	}
+
+	var results prediction.Results
+	for _, s := range job.Steps {
+		if s.Result == nil {
+			continue
+		}
+		if err := results.Set(s.Step, s.Result); err != nil {
+			return nil, errors.NewInternalError(err, "Failed to decode prediction results")
+		}
+	}
+	return &results, nil
+}
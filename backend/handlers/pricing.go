@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/pricing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// pricingVolumeDays is the sales history averaged into the monthly volume
+const pricingVolumeDays = 90
+
+// PricingCalculateRequest runs the calculator on numbers supplied by the user
+type PricingCalculateRequest struct {
+	pricing.Input
+	ProductName string `json:"product_name,omitempty"`
+}
+
+// PricingResponse is a pricing analysis with an explanation
+type PricingResponse struct {
+	ProductID       string         `json:"product_id,omitempty"`
+	ProductName     string         `json:"product_name,omitempty"`
+	Analysis        pricing.Result `json:"analysis"`
+	Narrative       string         `json:"narrative"`
+	NarrativeSource string         `json:"narrative_source"` // "ai" or "rules"
+}
+
+// CalculatePricing computes break-even volume and a price range from the request body
+func (h *Handler) CalculatePricing(w http.ResponseWriter, r *http.Request) {
+	var req PricingCalculateRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := req.Input.Validate(); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid pricing input", err.Error()), r)
+		return
+	}
+
+	name := req.ProductName
+	if name == "" {
+		name = "Produk ini"
+	}
+	result := pricing.Analyze(req.Input)
+
+	h.respondJSON(w, r, http.StatusOK, PricingResponse{
+		ProductName:     req.ProductName,
+		Analysis:        result,
+		Narrative:       pricing.Narrative(name, result),
+		NarrativeSource: "rules",
+	})
+}
+
+// GetProductPricing analyzes a product's price from its cost, current price and sales volume.
+// Optional query parameters: target_margin (percent), fixed_costs (monthly rupiah).
+func (h *Handler) GetProductPricing(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var targetMargin, fixedCosts float64
+	for name, dst := range map[string]*float64{"target_margin": &targetMargin, "fixed_costs": &fixedCosts} {
+		if s := r.URL.Query().Get(name); s != "" {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				h.respondError(w, errors.NewValidationError("Invalid "+name, name+" must be a number"), r)
+				return
+			}
+			*dst = v
+		}
+	}
+
+	resp, err := h.productPricing(r.Context(), companyID, r.PathValue("id"), targetMargin, fixedCosts)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// productPricing loads a product's numbers, analyzes them and writes a narrative
+func (h *Handler) productPricing(ctx context.Context, companyID, productID string, targetMargin, fixedCosts float64) (*PricingResponse, error) {
+	resp := &PricingResponse{ProductID: productID}
+	in := pricing.Input{FixedCosts: fixedCosts, TargetMargin: targetMargin}
+
+	since := localDate(time.Now(), h.companyLocation(ctx, companyID)).AddDate(0, 0, -pricingVolumeDays)
+	var soldQty, soldRevenue float64
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT p.name, COALESCE(p.cost, 0), COALESCE(p.unit_price, 0),
+			COALESCE(SUM(s.quantity), 0), COALESCE(SUM(s.quantity * s.price), 0)
+		FROM products p
+		LEFT JOIN sales_history s ON s.product_id = p.id AND s.sale_date >= $3 AND NOT COALESCE(s.excluded, false)
+		WHERE p.id = $1 AND p.company_id = $2
+		GROUP BY p.id, p.name, p.cost, p.unit_price
+	`, productID, companyID, since).Scan(&resp.ProductName, &in.UnitCost, &in.Price, &soldQty, &soldRevenue)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, err
+		}
+		return nil, errors.NewDatabaseError(err, "load product pricing")
+	}
+
+	// Prefer the realized average price when the list price is missing
+	if in.Price == 0 && soldQty > 0 {
+		in.Price = soldRevenue / soldQty
+	}
+	in.MonthlyVolume = soldQty / pricingVolumeDays * 30
+	if err := in.Validate(); err != nil {
+		return nil, errors.NewValidationError("Cannot analyze pricing", err.Error()+"; set the product cost first")
+	}
+
+	resp.Analysis = pricing.Analyze(in)
+	resp.Narrative, resp.NarrativeSource = h.pricingNarrative(ctx, resp.ProductName, resp.Analysis)
+	return resp, nil
+}
+
+// pricingNarrative asks the AI provider to explain the numbers, falling back to a template
+func (h *Handler) pricingNarrative(ctx context.Context, productName string, result pricing.Result) (string, string) {
+	if h.config.KolosalAPIKey != "" {
+		ctx = aispend.WithFeature(ctx, aispend.FeaturePricing, "")
+		client := h.kolosalClient()
+		resp, err := client.CreateChatCompletion(ctx, kolosal.ChatCompletionRequest{
+			Model: "default",
+			Messages: []kolosal.ChatCompletionMessage{
+				{Role: "system", Content: "Kamu adalah konsultan harga untuk UMKM Indonesia. Jawab dalam Bahasa Indonesia."},
+				{Role: "user", Content: pricing.BuildPrompt(productName, result)},
+			},
+			MaxTokens:   400,
+			Temperature: 0.3,
+		})
+		if err == nil && len(resp.Choices) > 0 && strings.TrimSpace(resp.Choices[0].Message.Content) != "" {
+			return strings.TrimSpace(resp.Choices[0].Message.Content), "ai"
+		}
+		if err != nil {
+			logger.Warn("Falling back to template pricing narrative", "error", err.Error())
+		}
+	}
+	return pricing.Narrative(productName, result), "rules"
+}
@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/imaging"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	maxProductImageSize = 5 * 1024 * 1024 // 5MB
+	maxProductImages    = 10              // Images per product
+)
+
+// productImageURL returns the API path serving an image variant ("original" or "thumbnail")
+func productImageURL(productID, imageID, variant string) string {
+	return fmt.Sprintf("/api/v1/products/%s/images/%s/%s", productID, imageID, variant)
+}
+
+// loadProductImages returns a product's images, primary first
+func (h *Handler) loadProductImages(ctx context.Context, productID string) ([]models.ProductImage, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, content_type, width, height, size_bytes, is_primary, created_at
+		FROM product_images
+		WHERE product_id = $1
+		ORDER BY is_primary DESC, created_at
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := []models.ProductImage{}
+	for rows.Next() {
+		img := models.ProductImage{ProductID: productID}
+		if err := rows.Scan(&img.ID, &img.ContentType, &img.Width, &img.Height,
+			&img.SizeBytes, &img.IsPrimary, &img.CreatedAt); err != nil {
+			return nil, err
+		}
+		img.URL = productImageURL(productID, img.ID, "original")
+		img.ThumbnailURL = productImageURL(productID, img.ID, "thumbnail")
+		images = append(images, img)
+	}
+	return images, rows.Err()
+}
+
+// attachProductImages fills in the primary image URLs of listed products
+func (h *Handler) attachProductImages(ctx context.Context, products []models.Product) {
+	if len(products) == 0 {
+		return
+	}
+	ids := make([]string, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT product_id, id FROM product_images WHERE product_id = ANY($1) AND is_primary
+	`, ids)
+	if err != nil {
+		logger.Warn("Failed to load product images", "error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	primary := map[string]string{}
+	for rows.Next() {
+		var productID, imageID string
+		if rows.Scan(&productID, &imageID) == nil {
+			primary[productID] = imageID
+		}
+	}
+	for i, p := range products {
+		if imageID, ok := primary[p.ID]; ok {
+			products[i].ImageURL = productImageURL(p.ID, imageID, "original")
+			products[i].ThumbnailURL = productImageURL(p.ID, imageID, "thumbnail")
+		}
+	}
+}
+
+// productImagePaths returns the stored files of a product's images
+func (h *Handler) productImagePaths(ctx context.Context, productID string) []string {
+	rows, err := h.db.Pool().Query(ctx, `SELECT storage_path, thumbnail_path FROM product_images WHERE product_id = $1`, productID)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var original, thumb string
+		if rows.Scan(&original, &thumb) == nil {
+			paths = append(paths, original, thumb)
+		}
+	}
+	return paths
+}
+
+// deleteStoredFiles removes files from the file store, logging failures
+func (h *Handler) deleteStoredFiles(paths ...string) {
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if err := h.files.Delete(p); err != nil {
+			logger.Warn("Failed to delete stored file", "path", p, "error", err.Error())
+		}
+	}
+}
+
+// ListProductImages returns a product's images, primary first
+func (h *Handler) ListProductImages(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	productID := r.PathValue("id")
+	if !h.productBelongsToCompany(r.Context(), productID, companyID) {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	images, err := h.loadProductImages(r.Context(), productID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load product images"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, images)
+}
+
+// UploadProductImage attaches a JPEG, PNG or GIF image ("image" form field) to a
+// product and generates its thumbnail. The product's first image becomes its
+// primary image, as does any image uploaded with "primary=true".
+func (h *Handler) UploadProductImage(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	productID := r.PathValue("id")
+	if !h.productBelongsToCompany(r.Context(), productID, companyID) {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	var count int
+	h.db.Pool().QueryRow(r.Context(), `SELECT COUNT(*) FROM product_images WHERE product_id = $1`, productID).Scan(&count)
+	if count >= maxProductImages {
+		h.respondError(w, errors.NewBusinessRuleError("max_product_images",
+			fmt.Sprintf("A product can have at most %d images", maxProductImages)), r)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxProductImageSize+1024*1024)
+	if err := r.ParseMultipartForm(maxProductImageSize); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid multipart form", err.Error()), r)
+		return
+	}
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Image is required", "image"), r)
+		return
+	}
+	defer file.Close()
+	if header.Size > maxProductImageSize {
+		h.respondError(w, errors.NewValidationError(fmt.Sprintf("Image exceeds maximum of %d bytes", maxProductImageSize), "image"), r)
+		return
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Could not read image", err.Error()), r)
+		return
+	}
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Only JPEG, PNG and GIF images are supported", err.Error()), r)
+		return
+	}
+	thumb, err := imaging.Encode(imaging.Thumbnail(img, imaging.ThumbnailSize), format)
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "generate thumbnail"), r)
+		return
+	}
+
+	// Store the original as uploaded, next to its thumbnail, in the company's region
+	region := h.storageRegionForCompany(r.Context(), companyID)
+	imageID := uuid.New().String()
+	ext := map[string]string{"jpeg": ".jpg", "png": ".png", "gif": ".gif"}[format]
+	key := fmt.Sprintf("%s/products/%s/%s", companyID, productID, imageID)
+
+	storagePath, size, err := h.files.Put(r.Context(), region, key+ext, bytes.NewReader(data))
+	if err != nil {
+		logger.Error("Failed to store product image", "error", err.Error(), "region", region)
+		h.respondError(w, errors.NewInternalError(err, "store image"), r)
+		return
+	}
+	thumbPath, _, err := h.files.Put(r.Context(), region, key+"_thumb"+thumb.Extension, bytes.NewReader(thumb.Data))
+	if err != nil {
+		h.deleteStoredFiles(storagePath)
+		logger.Error("Failed to store product thumbnail", "error", err.Error(), "region", region)
+		h.respondError(w, errors.NewInternalError(err, "store thumbnail"), r)
+		return
+	}
+
+	makePrimary := count == 0
+	if v, err := strconv.ParseBool(r.FormValue("primary")); err == nil && v {
+		makePrimary = true
+	}
+
+	image := models.ProductImage{
+		ID:           imageID,
+		ProductID:    productID,
+		URL:          productImageURL(productID, imageID, "original"),
+		ThumbnailURL: productImageURL(productID, imageID, "thumbnail"),
+		ContentType:  imaging.ContentType(format),
+		Width:        img.Bounds().Dx(),
+		Height:       img.Bounds().Dy(),
+		SizeBytes:    size,
+		IsPrimary:    makePrimary,
+		CreatedAt:    time.Now(),
+	}
+
+	err = h.insertProductImage(r.Context(), companyID, region, storagePath, thumbPath, image)
+	if err != nil {
+		h.deleteStoredFiles(storagePath, thumbPath)
+		h.respondError(w, errors.NewDatabaseError(err, "record product image"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusCreated, image)
+}
+
+// insertProductImage records an uploaded image, unsetting the previous primary image if it replaces it
+func (h *Handler) insertProductImage(ctx context.Context, companyID, region, storagePath, thumbPath string, image models.ProductImage) error {
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if image.IsPrimary {
+		if _, err := tx.Exec(ctx, `UPDATE product_images SET is_primary = FALSE WHERE product_id = $1 AND is_primary`, image.ProductID); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO product_images (id, company_id, product_id, storage_path, thumbnail_path, storage_region,
+			content_type, width, height, size_bytes, is_primary, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, image.ID, companyID, image.ProductID, storagePath, thumbPath, region,
+		image.ContentType, image.Width, image.Height, image.SizeBytes, image.IsPrimary, image.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetPrimaryProductImage makes an image the one shown in product listings
+func (h *Handler) SetPrimaryProductImage(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	productID, imageID := r.PathValue("id"), r.PathValue("image_id")
+	if !h.productBelongsToCompany(r.Context(), productID, companyID) {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(r.Context())
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	if _, err := tx.Exec(r.Context(), `UPDATE product_images SET is_primary = FALSE WHERE product_id = $1 AND is_primary`, productID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "set primary image"), r)
+		return
+	}
+	tag, err := tx.Exec(r.Context(), `UPDATE product_images SET is_primary = TRUE WHERE id = $1 AND product_id = $2`, imageID, productID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "set primary image"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Product image"), r)
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "Primary image updated"})
+}
+
+// DeleteProductImage removes an image and its stored files. When the primary
+// image is removed, the oldest remaining image becomes primary.
+func (h *Handler) DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	productID, imageID := r.PathValue("id"), r.PathValue("image_id")
+	if !h.productBelongsToCompany(r.Context(), productID, companyID) {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(r.Context())
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
+	var storagePath, thumbPath string
+	var wasPrimary bool
+	err = tx.QueryRow(r.Context(), `
+		DELETE FROM product_images WHERE id = $1 AND product_id = $2
+		RETURNING storage_path, thumbnail_path, is_primary
+	`, imageID, productID).Scan(&storagePath, &thumbPath, &wasPrimary)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Product image"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete product image"), r)
+		return
+	}
+	if wasPrimary {
+		_, err = tx.Exec(r.Context(), `
+			UPDATE product_images SET is_primary = TRUE
+			WHERE id = (SELECT id FROM product_images WHERE product_id = $1 ORDER BY created_at LIMIT 1)
+		`, productID)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "promote primary image"), r)
+			return
+		}
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+	h.deleteStoredFiles(storagePath, thumbPath)
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "Product image deleted"})
+}
+
+// ServeProductImage streams an image ("original") or its thumbnail ("thumbnail")
+func (h *Handler) ServeProductImage(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	variant := r.PathValue("variant")
+	if variant != "original" && variant != "thumbnail" {
+		h.respondError(w, errors.NewNotFoundError("Image variant"), r)
+		return
+	}
+
+	var storagePath, thumbPath, contentType string
+	var createdAt time.Time
+	err := h.db.Pool().QueryRow(r.Context(), `
+		SELECT storage_path, thumbnail_path, content_type, created_at
+		FROM product_images
+		WHERE id = $1 AND product_id = $2 AND company_id = $3
+	`, r.PathValue("image_id"), r.PathValue("id"), companyID).Scan(&storagePath, &thumbPath, &contentType, &createdAt)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Product image"), r)
+		return
+	}
+
+	path := storagePath
+	if variant == "thumbnail" {
+		path = thumbPath
+		contentType = "image/jpeg"
+		if strings.HasSuffix(thumbPath, ".png") {
+			contentType = "image/png"
+		}
+	}
+	f, err := h.files.Open(path)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Product image"), r)
+		return
+	}
+	defer f.Close()
+
+	// Image files never change; a new upload gets a new id
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400, immutable")
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", createdAt, rs)
+		return
+	}
+	io.Copy(w, f)
+}
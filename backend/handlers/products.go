@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/bantuaku/backend/errors"
 	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
 	"github.com/google/uuid"
@@ -31,7 +32,7 @@ type UpdateProductRequest struct {
 func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
@@ -60,7 +61,7 @@ func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
 
 	rows, err := h.db.Pool().Query(r.Context(), query, args...)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch products")
+		h.respondError(w, errors.NewInternalError(err, "Failed to fetch products"), r)
 		return
 	}
 	defer rows.Close()
@@ -74,30 +75,31 @@ func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
 		}
 		products = append(products, p)
 	}
+	h.attachProductImages(r.Context(), products)
 
-	respondJSON(w, http.StatusOK, products)
+	h.respondJSON(w, r, http.StatusOK, products)
 }
 
 // CreateProduct creates a new product
 func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
 	var req CreateProductRequest
-	if err := parseJSON(r, &req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid request body", ""), r)
 		return
 	}
 
 	if req.ProductName == "" {
-		respondError(w, http.StatusBadRequest, "Product name is required")
+		h.respondError(w, errors.NewValidationError("Product name is required", ""), r)
 		return
 	}
 	if req.UnitPrice < 0 {
-		respondError(w, http.StatusBadRequest, "Unit price cannot be negative")
+		h.respondError(w, errors.NewValidationError("Unit price cannot be negative", ""), r)
 		return
 	}
 
@@ -110,7 +112,7 @@ func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	`, productID, storeID, req.ProductName, req.SKU, req.Category, req.UnitPrice, req.Cost, now, now)
 
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create product")
+		h.respondError(w, errors.NewInternalError(err, "Failed to create product"), r)
 		return
 	}
 
@@ -126,7 +128,7 @@ func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:   now,
 	}
 
-	respondJSON(w, http.StatusCreated, product)
+	h.respondJSON(w, r, http.StatusCreated, product)
 }
 
 // GetProduct returns a single product by ID
@@ -135,7 +137,7 @@ func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	productID := r.PathValue("id")
 
 	if productID == "" {
-		respondError(w, http.StatusBadRequest, "Product ID is required")
+		h.respondError(w, errors.NewValidationError("Product ID is required", ""), r)
 		return
 	}
 
@@ -147,11 +149,13 @@ func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	`, productID, storeID).Scan(&p.ID, &p.StoreID, &p.ProductName, &p.SKU, &p.Category, &p.UnitPrice, &p.Cost, &p.CreatedAt, &p.UpdatedAt)
 
 	if err != nil {
-		respondError(w, http.StatusNotFound, "Product not found")
+		h.respondError(w, errors.NewAppError(errors.ErrCodeNotFound, "Product not found", ""), r)
 		return
 	}
+	products := []models.Product{p}
+	h.attachProductImages(r.Context(), products)
 
-	respondJSON(w, http.StatusOK, p)
+	h.respondJSON(w, r, http.StatusOK, products[0])
 }
 
 // UpdateProduct updates an existing product
@@ -160,13 +164,13 @@ func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	productID := r.PathValue("id")
 
 	if productID == "" {
-		respondError(w, http.StatusBadRequest, "Product ID is required")
+		h.respondError(w, errors.NewValidationError("Product ID is required", ""), r)
 		return
 	}
 
 	var req UpdateProductRequest
-	if err := parseJSON(r, &req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid request body", ""), r)
 		return
 	}
 
@@ -183,12 +187,12 @@ func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	`, productID, storeID, req.ProductName, req.SKU, req.Category, req.UnitPrice, req.Cost, time.Now())
 
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update product")
+		h.respondError(w, errors.NewInternalError(err, "Failed to update product"), r)
 		return
 	}
 
 	if result.RowsAffected() == 0 {
-		respondError(w, http.StatusNotFound, "Product not found")
+		h.respondError(w, errors.NewAppError(errors.ErrCodeNotFound, "Product not found", ""), r)
 		return
 	}
 
@@ -199,7 +203,7 @@ func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		FROM products WHERE id = $1
 	`, productID).Scan(&p.ID, &p.StoreID, &p.ProductName, &p.SKU, &p.Category, &p.UnitPrice, &p.Cost, &p.CreatedAt, &p.UpdatedAt)
 
-	respondJSON(w, http.StatusOK, p)
+	h.respondJSON(w, r, http.StatusOK, p)
 }
 
 // DeleteProduct deletes a product
@@ -208,23 +212,27 @@ func (h *Handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	productID := r.PathValue("id")
 
 	if productID == "" {
-		respondError(w, http.StatusBadRequest, "Product ID is required")
+		h.respondError(w, errors.NewValidationError("Product ID is required", ""), r)
 		return
 	}
 
+	// Image rows cascade with the product; their stored files are removed afterwards
+	imagePaths := h.productImagePaths(r.Context(), productID)
+
 	result, err := h.db.Pool().Exec(r.Context(), `
 		DELETE FROM products WHERE id = $1 AND store_id = $2
 	`, productID, storeID)
 
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to delete product")
+		h.respondError(w, errors.NewInternalError(err, "Failed to delete product"), r)
 		return
 	}
 
 	if result.RowsAffected() == 0 {
-		respondError(w, http.StatusNotFound, "Product not found")
+		h.respondError(w, errors.NewAppError(errors.ErrCodeNotFound, "Product not found", ""), r)
 		return
 	}
+	h.deleteStoredFiles(imagePaths...)
 
-	respondJSON(w, http.StatusOK, map[string]string{"message": "Product deleted"})
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "Product deleted"})
 }
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/devmock"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/openrouter"
+	"github.com/bantuaku/backend/services/providerlog"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// kolosalClient returns a Kolosal client that meters the spend of its calls,
+// downgrading completions near a spending cap, and records a sample of them
+// when PROVIDER_LOG_SAMPLE_PERCENT is set
+func (h *Handler) kolosalClient() *kolosal.Client {
+	client := kolosal.NewClient(h.config.KolosalAPIKey)
+	client.Recorder = h.providerRecorder("kolosal")
+	client.ModelFor = h.spendModel
+	if h.config.DevMocks {
+		client.HTTPClient = devmock.HTTPClient()
+	}
+	return client
+}
+
+// providerRecorder returns the recorder of a provider's calls: spend metering,
+// plus the sampled call log when enabled
+func (h *Handler) providerRecorder(provider string) kolosal.Recorder {
+	if h.config.ProviderLogSamplePercent > 0 {
+		return recorders{spendRecorder{h: h}, providerRecorder{h: h, provider: provider}}
+	}
+	return spendRecorder{h: h}
+}
+
+// recorders passes each call to several recorders in turn
+type recorders []kolosal.Recorder
+
+func (rs recorders) Record(ctx context.Context, call kolosal.Call) {
+	for _, r := range rs {
+		r.Record(ctx, call)
+	}
+}
+
+// chatCompletionClient returns the client chat completions go to: OpenRouter,
+// with its model fallbacks and provider preferences, when OPENROUTER_API_KEY is
+// set, otherwise client. Embeddings stay on Kolosal since stored vectors come
+// from its model.
+func (h *Handler) chatCompletionClient(client *kolosal.Client) *kolosal.Client {
+	if h.config.OpenRouterAPIKey == "" {
+		return client
+	}
+	router := openrouter.NewClient(h.config.OpenRouterAPIKey, openrouter.Options{
+		Models:             h.config.OpenRouterModels,
+		ProviderOrder:      h.config.OpenRouterProviderOrder,
+		AllowFallbacks:     h.config.OpenRouterAllowFallbacks,
+		MaxPromptPrice:     h.config.OpenRouterMaxPromptPrice,
+		MaxCompletionPrice: h.config.OpenRouterMaxCompletionPrice,
+	})
+	router.Recorder = h.providerRecorder("openrouter")
+	router.ModelFor = h.spendModel
+	return router
+}
+
+// providerRecorder stores sampled provider calls in provider_calls
+type providerRecorder struct {
+	h        *Handler
+	provider string
+}
+
+func (p providerRecorder) Record(ctx context.Context, call kolosal.Call) {
+	// Only replayable calls are kept; OCR requests carry whole images
+	if call.Endpoint != kolosal.EndpointChatCompletions && call.Endpoint != kolosal.EndpointEmbeddings {
+		return
+	}
+	if rand.Intn(100) >= p.h.config.ProviderLogSamplePercent {
+		return
+	}
+
+	request := providerlog.Redact(call.Request)
+	if request == nil {
+		return
+	}
+	var errMsg string
+	if call.Err != nil {
+		errMsg = truncateRunes(providerlog.ScrubText(call.Err.Error()), 1000)
+	}
+	// The call may have been made on behalf of a request that is already done
+	ctx = context.WithoutCancel(ctx)
+	_, err := p.h.db.Pool().Exec(ctx, `
+		INSERT INTO provider_calls (id, provider, endpoint, company_id, request, response, status_code, error, duration_ms, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''), $9, $10)
+	`, uuid.New().String(), p.provider, call.Endpoint, middleware.GetCompanyID(ctx), request, providerlog.Redact(call.Response),
+		call.StatusCode, errMsg, call.Duration.Milliseconds(),
+		time.Now().Add(time.Duration(p.h.config.ProviderLogTTLHours)*time.Hour))
+	if err != nil {
+		logger.Error("Failed to record provider call", "endpoint", call.Endpoint, "error", err.Error())
+	}
+}
+
+// ListProviderCalls lists recorded provider calls, newest first, filtered by
+// ?endpoint= and ?company_id= (super admin only). Bodies are left out; fetch a
+// single call to see them.
+func (h *Handler) ListProviderCalls(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	q := r.URL.Query()
+	conditions := []string{"expires_at > NOW()"}
+	args := []interface{}{}
+	if endpoint := q.Get("endpoint"); endpoint != "" {
+		args = append(args, endpoint)
+		conditions = append(conditions, fmt.Sprintf("endpoint = $%d", len(args)))
+	}
+	if companyID := q.Get("company_id"); companyID != "" {
+		args = append(args, companyID)
+		conditions = append(conditions, fmt.Sprintf("company_id = $%d", len(args)))
+	}
+
+	ctx := r.Context()
+	where := strings.Join(conditions, " AND ")
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM provider_calls WHERE `+where, args...).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count provider calls"), r)
+		return
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := h.db.Pool().Query(ctx, fmt.Sprintf(`
+		SELECT id, provider, endpoint, COALESCE(company_id, ''), status_code, COALESCE(error, ''), duration_ms, created_at, expires_at
+		FROM provider_calls
+		WHERE %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list provider calls"), r)
+		return
+	}
+	defer rows.Close()
+
+	calls := []models.ProviderCall{}
+	for rows.Next() {
+		var c models.ProviderCall
+		if err := rows.Scan(&c.ID, &c.Provider, &c.Endpoint, &c.CompanyID, &c.StatusCode, &c.Error,
+			&c.DurationMs, &c.CreatedAt, &c.ExpiresAt); err != nil {
+			continue
+		}
+		calls = append(calls, c)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    calls,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// GetProviderCall returns a recorded provider call with its redacted request
+// and response (super admin only)
+func (h *Handler) GetProviderCall(w http.ResponseWriter, r *http.Request) {
+	call, err := h.loadProviderCall(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, call)
+}
+
+// ReplayProviderCall sends a recorded request to the provider again and
+// returns the new response next to the original (super admin only). The
+// request is replayed as stored, i.e. with secrets and personal data redacted,
+// and the replay itself is not recorded.
+func (h *Handler) ReplayProviderCall(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	call, err := h.loadProviderCall(ctx, r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	// The recorded body already carries any routing options
+	client := kolosal.NewClient(h.config.KolosalAPIKey)
+	if h.config.DevMocks {
+		client.HTTPClient = devmock.HTTPClient()
+	}
+	if call.Provider == "openrouter" {
+		if h.config.OpenRouterAPIKey == "" {
+			h.respondError(w, errors.NewBusinessRuleError("openrouter_disabled", "OpenRouter is no longer configured"), r)
+			return
+		}
+		client = openrouter.NewClient(h.config.OpenRouterAPIKey, openrouter.Options{})
+	}
+
+	started := time.Now()
+	status, body, err := client.Replay(ctx, call.Endpoint, call.Request)
+	if err != nil {
+		h.respondError(w, errors.NewExternalServiceError(call.Provider, "Replay failed", err.Error()), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"call": call,
+		"replay": map[string]interface{}{
+			"status_code": status,
+			"response":    json.RawMessage(providerlog.Redact(body)),
+			"duration_ms": time.Since(started).Milliseconds(),
+		},
+	})
+}
+
+// PurgeProviderCalls deletes recorded provider calls past their TTL. It is run
+// periodically from main.
+func (h *Handler) PurgeProviderCalls(ctx context.Context) {
+	tag, err := h.db.Pool().Exec(ctx, `DELETE FROM provider_calls WHERE expires_at <= NOW()`)
+	if err != nil {
+		logger.Error("Failed to purge provider calls", "error", err.Error())
+		return
+	}
+	if n := tag.RowsAffected(); n > 0 {
+		logger.Info("Purged expired provider calls", "count", n)
+	}
+}
+
+func (h *Handler) loadProviderCall(ctx context.Context, id string) (*models.ProviderCall, error) {
+	var c models.ProviderCall
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, provider, endpoint, COALESCE(company_id, ''), request, response, status_code, COALESCE(error, ''),
+			duration_ms, created_at, expires_at
+		FROM provider_calls
+		WHERE id = $1 AND expires_at > NOW()
+	`, id).Scan(&c.ID, &c.Provider, &c.Endpoint, &c.CompanyID, &c.Request, &c.Response, &c.StatusCode, &c.Error,
+		&c.DurationMs, &c.CreatedAt, &c.ExpiresAt)
+	if err == pgx.ErrNoRows {
+		return nil, errors.NewNotFoundError("Provider call")
+	}
+	if err != nil {
+		return nil, errors.NewDatabaseError(err, "load provider call")
+	}
+	return &c, nil
+}
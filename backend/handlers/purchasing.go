@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/purchasing"
+	"github.com/bantuaku/backend/services/quality"
+	"github.com/bantuaku/backend/validation"
+)
+
+// PurchasingSettingsRequest sets how a product is bought. Empty or zero
+// fields clear the setting.
+type PurchasingSettingsRequest struct {
+	SupplierName     string   `json:"supplier_name" validate:"max:255"`
+	LeadTimeDays     *int     `json:"lead_time_days,omitempty"` // Days from order to delivery
+	MinOrderQuantity float64  `json:"min_order_quantity"`       // In the product's unit
+	SafetyStock      *float64 `json:"safety_stock,omitempty"`   // In the product's unit; unset computes it from sales
+}
+
+// PurchasePlan lists the orders to place to meet forecast demand
+type PurchasePlan struct {
+	GeneratedAt time.Time             `json:"generated_at"`
+	HorizonDays int                   `json:"horizon_days"`
+	ReviewDays  int                   `json:"review_days"`
+	Orders      []purchasing.Order    `json:"orders"`
+	TotalCost   float64               `json:"total_cost"`
+	Skipped     []PurchasePlanSkipped `json:"skipped"`
+}
+
+// PurchasePlanSkipped is a product left out of the plan
+type PurchasePlanSkipped struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Reason      string `json:"reason"` // insufficient_data, no_demand
+}
+
+// UpdatePurchasingSettings sets a product's supplier, lead time, minimum order
+// quantity and safety stock
+func (h *Handler) UpdatePurchasingSettings(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	productID := r.PathValue("id")
+
+	var req PurchasingSettingsRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	req.SupplierName = strings.TrimSpace(req.SupplierName)
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.LeadTimeDays != nil && (*req.LeadTimeDays < 0 || *req.LeadTimeDays > purchasing.MaxLeadTimeDays) {
+		h.respondError(w, errors.NewValidationError("Validation failed", fmt.Sprintf("lead_time_days: must be between 0 and %d", purchasing.MaxLeadTimeDays)), r)
+		return
+	}
+	if req.MinOrderQuantity < 0 {
+		h.respondError(w, errors.NewValidationError("Validation failed", "min_order_quantity: cannot be negative"), r)
+		return
+	}
+	if req.SafetyStock != nil && *req.SafetyStock < 0 {
+		h.respondError(w, errors.NewValidationError("Validation failed", "safety_stock: cannot be negative"), r)
+		return
+	}
+
+	tag, err := h.db.Pool().Exec(r.Context(), `
+		UPDATE products SET supplier_name = NULLIF($3, ''), lead_time_days = $4,
+			min_order_quantity = NULLIF($5::numeric, 0), safety_stock = $6, updated_at = NOW()
+		WHERE id = $1 AND company_id = $2 AND merged_into IS NULL
+	`, productID, companyID, req.SupplierName, req.LeadTimeDays, req.MinOrderQuantity, req.SafetyStock)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update purchasing settings"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"product_id":         productID,
+		"supplier_name":      req.SupplierName,
+		"lead_time_days":     req.LeadTimeDays,
+		"min_order_quantity": req.MinOrderQuantity,
+		"safety_stock":       req.SafetyStock,
+	})
+}
+
+// GetPurchasePlan turns the forecasts of the company's active products into the
+// orders to place over the next ?days= (default 28), one per product every
+// ?review_days= (default 7), using each product's cost, supplier, lead time
+// and minimum order quantity. ?format=csv downloads the plan.
+func (h *Handler) GetPurchasePlan(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	horizon := purchasing.DefaultHorizonDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > purchasing.MaxHorizonDays {
+			h.respondError(w, errors.NewValidationError("Invalid days", fmt.Sprintf("days must be between 1 and %d", purchasing.MaxHorizonDays)), r)
+			return
+		}
+		horizon = n
+	}
+	review := purchasing.DefaultReviewDays
+	if s := r.URL.Query().Get("review_days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > horizon {
+			h.respondError(w, errors.NewValidationError("Invalid review_days", "review_days must be between 1 and days"), r)
+			return
+		}
+		review = n
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		h.respondError(w, errors.NewValidationError("Invalid format", "format must be json or csv"), r)
+		return
+	}
+
+	now := time.Now().In(h.companyLocation(ctx, companyID))
+	today := localDate(now, now.Location())
+	orders, skipped, err := h.purchasePlan(ctx, companyID, now, horizon, review)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "build purchase plan"), r)
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="bantuaku-purchase-plan-%s.csv"`, today.Format("20060102")))
+		if err := purchasing.WriteCSV(w, orders); err != nil {
+			logger.Error("Failed to write purchase plan", "company_id", companyID, "error", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, PurchasePlan{
+		GeneratedAt: time.Now(),
+		HorizonDays: horizon,
+		ReviewDays:  review,
+		Orders:      orders,
+		TotalCost:   purchasing.TotalCost(orders),
+		Skipped:     skipped,
+	})
+}
+
+// purchasePlan plans the company's orders over horizon days from now, listing
+// the products left out
+func (h *Handler) purchasePlan(ctx context.Context, companyID string, now time.Time, horizon, review int) ([]purchasing.Order, []PurchasePlanSkipped, error) {
+	today := localDate(now, now.Location())
+	points, _, err := h.loadDailySales(ctx, companyID, "", today.AddDate(0, 0, -forecastWindowDays))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, name, COALESCE(sku, ''), COALESCE(unit, 'pcs'), COALESCE(supplier_name, ''),
+			lead_time_days, COALESCE(min_order_quantity, 0)::float8, COALESCE(cost, 0)::float8
+		FROM products
+		WHERE company_id = $1 AND COALESCE(is_active, true) AND merged_into IS NULL
+		ORDER BY name
+	`, companyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var items []purchasing.Item
+	skipped := []PurchasePlanSkipped{}
+	for rows.Next() {
+		var it purchasing.Item
+		if err := rows.Scan(&it.ProductID, &it.Name, &it.SKU, &it.Unit, &it.Supplier, &it.LeadTimeDays, &it.MinOrderQuantity, &it.UnitCost); err != nil {
+			return nil, nil, err
+		}
+		// The same checks and rate as GetForecast
+		series := points[it.ProductID]
+		if quality.Assess(series, now, forecastWindowDays).Blocking() {
+			skipped = append(skipped, PurchasePlanSkipped{ProductID: it.ProductID, ProductName: it.Name, Reason: "insufficient_data"})
+			continue
+		}
+		salesData := make([]float64, len(series))
+		for i, p := range series {
+			salesData[i] = p.Quantity
+		}
+		if it.DailyRate, _, _ = forecastDailyRate(salesData); it.DailyRate <= 0 {
+			skipped = append(skipped, PurchasePlanSkipped{ProductID: it.ProductID, ProductName: it.Name, Reason: "no_demand"})
+			continue
+		}
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	orders := purchasing.Build(items, today, horizon, review)
+	if orders == nil {
+		orders = []purchasing.Order{}
+	}
+	return orders, skipped, nil
+}
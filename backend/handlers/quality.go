@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/quality"
+)
+
+const (
+	defaultQualityWindowDays = 90
+	maxQualityWindowDays     = 365
+)
+
+// ProductQuality is the data-quality report for a single product
+type ProductQuality struct {
+	ProductID   string         `json:"product_id"`
+	ProductName string         `json:"product_name"`
+	Quality     quality.Report `json:"quality"`
+}
+
+// SalesQualityResponse summarizes sales data quality across a company's products
+type SalesQualityResponse struct {
+	WindowDays int              `json:"window_days"`
+	Summary    map[string]int   `json:"summary"` // Product count per quality level
+	Products   []ProductQuality `json:"products"`
+}
+
+// GetSalesQuality scores each product's sales history and suggests fixes
+func (h *Handler) GetSalesQuality(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	window := defaultQualityWindowDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 7 || n > maxQualityWindowDays {
+			h.respondError(w, errors.NewValidationError("Invalid days", "days must be between 7 and 365"), r)
+			return
+		}
+		window = n
+	}
+	productID := r.URL.Query().Get("product_id")
+
+	now := time.Now().In(h.companyLocation(r.Context(), companyID))
+	points, names, err := h.loadDailySales(r.Context(), companyID, productID, localDate(now, now.Location()).AddDate(0, 0, -window))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sales history"), r)
+		return
+	}
+	if productID != "" && len(names) == 0 {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	resp := SalesQualityResponse{
+		WindowDays: window,
+		Summary: map[string]int{
+			quality.LevelGood:         0,
+			quality.LevelFair:         0,
+			quality.LevelPoor:         0,
+			quality.LevelInsufficient: 0,
+		},
+		Products: []ProductQuality{},
+	}
+	for _, p := range names {
+		report := quality.Assess(points[p.id], now, window)
+		resp.Summary[report.Level]++
+		resp.Products = append(resp.Products, ProductQuality{ProductID: p.id, ProductName: p.name, Quality: report})
+	}
+
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+type qualityProduct struct {
+	id   string
+	name string
+}
+
+// loadDailySales returns active products and their daily sales totals since the given date
+func (h *Handler) loadDailySales(ctx context.Context, companyID, productID string, since time.Time) (map[string][]quality.DailyPoint, []qualityProduct, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT p.id, p.name, s.sale_date, COALESCE(SUM(s.quantity), 0)
+		FROM products p
+		LEFT JOIN sales_history s ON s.product_id = p.id AND s.company_id = p.company_id AND s.sale_date >= $2
+			AND NOT COALESCE(s.excluded, false)
+		WHERE p.company_id = $1 AND COALESCE(p.is_active, true)
+			AND ($3 = '' OR p.id = $3)
+		GROUP BY p.id, p.name, s.sale_date
+		ORDER BY p.name, s.sale_date
+	`, companyID, since, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	points := map[string][]quality.DailyPoint{}
+	var products []qualityProduct
+	for rows.Next() {
+		var id, name string
+		var date *time.Time
+		var qty float64
+		if err := rows.Scan(&id, &name, &date, &qty); err != nil {
+			return nil, nil, err
+		}
+		if _, seen := points[id]; !seen {
+			points[id] = []quality.DailyPoint{}
+			products = append(products, qualityProduct{id: id, name: name})
+		}
+		if date != nil {
+			points[id] = append(points[id], quality.DailyPoint{Date: *date, Quantity: qty})
+		}
+	}
+	return points, products, rows.Err()
+}
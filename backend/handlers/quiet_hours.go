@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/mailer"
+	"github.com/bantuaku/backend/services/notifyprefs"
+	"github.com/bantuaku/backend/services/quiethours"
+)
+
+// urgentNotifications are delivered at once, even during quiet hours
+var urgentNotifications = map[string]bool{
+	models.NotificationIntegrationAuth: true, // Syncing has stopped until the owner acts
+}
+
+// QuietHoursResponse is the company's quiet hours in its time zone
+type QuietHoursResponse struct {
+	quiethours.Window
+	Timezone      string `json:"timezone"`
+	TimezoneLabel string `json:"timezone_label"`
+}
+
+// GetQuietHours returns when the company's non-urgent notifications are held
+func (h *Handler) GetQuietHours(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	window, err := h.quietHours(r.Context(), companyID)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	h.respondQuietHours(w, r, companyID, window)
+}
+
+// UpdateQuietHours sets the company's quiet hours
+func (h *Handler) UpdateQuietHours(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req quiethours.Window
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid quiet hours", err.Error()), r)
+		return
+	}
+
+	tag, err := h.db.Pool().Exec(r.Context(), `
+		UPDATE companies SET quiet_hours_enabled = $2, quiet_hours_start = $3, quiet_hours_end = $4, updated_at = NOW()
+		WHERE id = $1
+	`, companyID, req.Enabled, req.Start, req.End)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update quiet hours"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	h.respondQuietHours(w, r, companyID, req)
+}
+
+func (h *Handler) respondQuietHours(w http.ResponseWriter, r *http.Request, companyID string, window quiethours.Window) {
+	tz := h.companyLocation(r.Context(), companyID).String()
+	h.respondJSON(w, r, http.StatusOK, QuietHoursResponse{Window: window, Timezone: tz, TimezoneLabel: companyTimezones[tz]})
+}
+
+// quietHours loads the company's quiet hours
+func (h *Handler) quietHours(ctx context.Context, companyID string) (quiethours.Window, error) {
+	var w quiethours.Window
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT quiet_hours_enabled, quiet_hours_start, quiet_hours_end FROM companies WHERE id = $1
+	`, companyID).Scan(&w.Enabled, &w.Start, &w.End)
+	return w, err
+}
+
+// holdNotification returns until when a notification of notificationType
+// raised now is held, if at all
+func (h *Handler) holdNotification(ctx context.Context, companyID, notificationType string) (time.Time, bool) {
+	window, err := h.quietHours(ctx, companyID)
+	if err != nil {
+		window = quiethours.Default()
+	}
+	now := time.Now().In(h.companyLocation(ctx, companyID))
+	return window.Hold(now, urgentNotifications[notificationType])
+}
+
+// DeliverHeldNotifications releases notifications whose quiet hours ended and
+// emails each company owner one summary of them, instead of a message per
+// notification overnight. It is run periodically from main.
+func (h *Handler) DeliverHeldNotifications(ctx context.Context) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT DISTINCT company_id FROM notifications
+		WHERE held_until IS NOT NULL AND released_at IS NULL AND held_until <= NOW()
+	`)
+	if err != nil {
+		logger.Error("Notification summary failed to list companies", "error", err.Error())
+		return
+	}
+	var companyIDs []string
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			companyIDs = append(companyIDs, id)
+		}
+	}
+	rows.Close()
+
+	released, emailed := 0, 0
+	for _, companyID := range companyIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		n, sent, err := h.deliverHeldNotifications(ctx, companyID)
+		if err != nil {
+			logger.Warn("Failed to deliver held notifications", "company_id", companyID, "error", err.Error())
+			continue
+		}
+		released += n
+		if sent {
+			emailed++
+		}
+	}
+	if released > 0 {
+		logger.Info("Held notifications delivered", "companies", len(companyIDs), "notifications", released, "summaries_emailed", emailed)
+	}
+}
+
+// deliverHeldNotifications releases a company's due notifications and emails
+// the owner a summary of those they want by email
+func (h *Handler) deliverHeldNotifications(ctx context.Context, companyID string) (int, bool, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		UPDATE notifications SET released_at = NOW()
+		WHERE company_id = $1 AND held_until IS NOT NULL AND released_at IS NULL AND held_until <= NOW()
+		RETURNING type, title, message
+	`, companyID)
+	if err != nil {
+		return 0, false, err
+	}
+	type held struct{ typ, title, message string }
+	var notifications []held
+	for rows.Next() {
+		var n held
+		if rows.Scan(&n.typ, &n.title, &n.message) == nil {
+			notifications = append(notifications, n)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, false, err
+	}
+
+	var ownerID, ownerEmail, name string
+	h.db.Pool().QueryRow(ctx, `
+		SELECT u.id, u.email, c.name FROM companies c JOIN users u ON u.id = c.owner_user_id WHERE c.id = $1
+	`, companyID).Scan(&ownerID, &ownerEmail, &name)
+	if ownerEmail == "" {
+		return len(notifications), false, nil
+	}
+	var lines []string
+	for _, n := range notifications {
+		if h.wantsNotification(ctx, ownerID, companyID, n.typ, notifyprefs.ChannelEmail) {
+			lines = append(lines, fmt.Sprintf("%s: %s", n.title, n.message))
+		}
+	}
+	if len(lines) == 0 {
+		return len(notifications), false, nil
+	}
+	link := strings.TrimRight(h.config.AppURL, "/") + "/notifications"
+	if err := h.sendEmail(ctx, mailer.NotificationSummaryEmail(ownerEmail, name, lines, link)); err != nil {
+		logger.Warn("Failed to email notification summary", "company_id", companyID, "error", err.Error())
+		return len(notifications), false, nil
+	}
+	return len(notifications), true, nil
+}
@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/embeddings"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/rageval"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ragEvalNamespaces are the namespaces an evaluation case may target
+var ragEvalNamespaces = []string{embeddings.NamespaceRegulations, embeddings.NamespaceKnowledgeBase}
+
+// RAGEvalOptions configures an evaluation run
+type RAGEvalOptions struct {
+	Label   string // Free-form name for the run, e.g. a release or branch
+	Tag     string // Only run active cases with this tag; empty runs all
+	TopK    int    // Chunks retrieved per question
+	Answers bool   // Also generate and score answers, which calls the chat model
+}
+
+// RAGEvalCaseRequest creates or replaces an evaluation case
+type RAGEvalCaseRequest struct {
+	Question        string   `json:"question" validate:"required,max:1000"`
+	Namespace       string   `json:"namespace" validate:"oneof:regulations|knowledge_base"`
+	ExpectedSources []string `json:"expected_sources"`
+	ExpectedAnswer  string   `json:"expected_answer" validate:"max:4000"`
+	Tags            []string `json:"tags"`
+	Active          *bool    `json:"active"`
+}
+
+// ListRAGEvalCases returns the evaluation questions (platform admin only)
+func (h *Handler) ListRAGEvalCases(w http.ResponseWriter, r *http.Request) {
+	cases, err := h.loadRAGEvalCases(r.Context(), false, "")
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list evaluation cases"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"cases": cases,
+	})
+}
+
+// CreateRAGEvalCase adds an evaluation question (platform admin only)
+func (h *Handler) CreateRAGEvalCase(w http.ResponseWriter, r *http.Request) {
+	req, err := h.parseRAGEvalCase(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	id := uuid.New().String()
+	_, err = h.db.Pool().Exec(r.Context(), `
+		INSERT INTO rag_eval_cases (id, question, namespace, expected_sources, expected_answer, tags, active)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+	`, id, req.Question, req.Namespace, req.ExpectedSources, req.ExpectedAnswer, req.Tags, *req.Active)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create evaluation case"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusCreated, map[string]string{"id": id})
+}
+
+// UpdateRAGEvalCase replaces an evaluation question (platform admin only)
+func (h *Handler) UpdateRAGEvalCase(w http.ResponseWriter, r *http.Request) {
+	req, err := h.parseRAGEvalCase(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	tag, err := h.db.Pool().Exec(r.Context(), `
+		UPDATE rag_eval_cases SET question = $2, namespace = NULLIF($3, ''), expected_sources = $4, expected_answer = $5,
+			tags = $6, active = $7, updated_at = NOW()
+		WHERE id = $1
+	`, r.PathValue("id"), req.Question, req.Namespace, req.ExpectedSources, req.ExpectedAnswer, req.Tags, *req.Active)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update evaluation case"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Evaluation case"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"id": r.PathValue("id")})
+}
+
+// DeleteRAGEvalCase removes an evaluation question; past results keep its text (platform admin only)
+func (h *Handler) DeleteRAGEvalCase(w http.ResponseWriter, r *http.Request) {
+	tag, err := h.db.Pool().Exec(r.Context(), `DELETE FROM rag_eval_cases WHERE id = $1`, r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete evaluation case"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Evaluation case"), r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRAGEvalRuns returns evaluation runs, newest first, to follow the metrics
+// over time (platform admin only)
+func (h *Handler) ListRAGEvalRuns(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM rag_eval_runs`).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count evaluation runs"), r)
+		return
+	}
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT `+ragEvalRunColumns+` FROM rag_eval_runs
+		ORDER BY started_at DESC
+		LIMIT $1 OFFSET $2
+	`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list evaluation runs"), r)
+		return
+	}
+	defer rows.Close()
+
+	items := []models.RAGEvalRun{}
+	for rows.Next() {
+		run, err := scanRAGEvalRun(rows)
+		if err != nil {
+			continue
+		}
+		items = append(items, *run)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    items,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// GetRAGEvalRun returns a run with its per-case results (platform admin only)
+func (h *Handler) GetRAGEvalRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	run, err := scanRAGEvalRun(h.db.Pool().QueryRow(ctx, `
+		SELECT `+ragEvalRunColumns+` FROM rag_eval_runs WHERE id = $1
+	`, r.PathValue("id")))
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Evaluation run"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load evaluation run"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT case_id, question, retrieved, COALESCE(answer, ''), source_precision, source_recall, reciprocal_rank,
+			faithfulness, answer_coverage
+		FROM rag_eval_results
+		WHERE run_id = $1
+		ORDER BY question
+	`, run.ID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load evaluation results"), r)
+		return
+	}
+	defer rows.Close()
+
+	results := []models.RAGEvalResult{}
+	for rows.Next() {
+		var res models.RAGEvalResult
+		if err := rows.Scan(&res.CaseID, &res.Question, &res.Retrieved, &res.Answer, &res.Precision, &res.Recall,
+			&res.ReciprocalRank, &res.Faithfulness, &res.AnswerCoverage); err != nil {
+			continue
+		}
+		results = append(results, res)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"run":     run,
+		"results": results,
+	})
+}
+
+// RunRAGEvaluation runs every active case (with opts.Tag) against the current
+// retrieval stack, and with opts.Answers the chat prompt too, and records the
+// scored run. A run that fails part-way is kept with status failed.
+func (h *Handler) RunRAGEvaluation(ctx context.Context, opts RAGEvalOptions) (*models.RAGEvalRun, error) {
+	if h.config.KolosalAPIKey == "" {
+		return nil, fmt.Errorf("KOLOSAL_API_KEY is not set")
+	}
+	if opts.TopK <= 0 {
+		opts.TopK = defaultVectorSearchLimit
+	}
+	cases, err := h.loadRAGEvalCases(ctx, true, opts.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("load cases: %w", err)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("no active evaluation cases")
+	}
+
+	run := &models.RAGEvalRun{
+		ID:             uuid.New().String(),
+		Label:          opts.Label,
+		EmbeddingModel: h.config.EmbeddingModel,
+		TopK:           opts.TopK,
+		Status:         "running",
+		StartedAt:      time.Now(),
+	}
+	if opts.Answers {
+		run.ChatModel = defaultChatModel
+	}
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO rag_eval_runs (id, label, embedding_model, chat_model, top_k, status, started_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)
+	`, run.ID, run.Label, run.EmbeddingModel, run.ChatModel, run.TopK, run.Status, run.StartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("create run: %w", err)
+	}
+
+	client := h.kolosalClient()
+	ctx = aispend.WithFeature(ctx, aispend.FeatureEvaluation, run.ID)
+	var scores []rageval.Scores
+	var runErr error
+	for _, c := range cases {
+		var s rageval.Scores
+		if s, runErr = h.evaluateRAGCase(ctx, client, run.ID, c, opts); runErr != nil {
+			runErr = fmt.Errorf("case %s: %w", c.ID, runErr)
+			break
+		}
+		scores = append(scores, s)
+	}
+
+	summary := rageval.Summarize(scores)
+	run.Cases = summary.Cases
+	run.Precision, run.Recall, run.MRR = summary.Precision, summary.Recall, summary.MRR
+	run.Faithfulness, run.AnswerCoverage = summary.Faithfulness, summary.AnswerCoverage
+	run.Status = "completed"
+	if runErr != nil {
+		run.Status = "failed"
+		run.Error = runErr.Error()
+	}
+	finished := time.Now()
+	run.FinishedAt = &finished
+
+	_, err = h.db.Pool().Exec(ctx, `
+		UPDATE rag_eval_runs SET cases = $2, source_precision = $3, source_recall = $4, mrr = $5, faithfulness = $6, answer_coverage = $7,
+			status = $8, error = NULLIF($9, ''), finished_at = $10
+		WHERE id = $1
+	`, run.ID, run.Cases, run.Precision, run.Recall, run.MRR, run.Faithfulness, run.AnswerCoverage,
+		run.Status, run.Error, run.FinishedAt)
+	if err != nil {
+		return run, fmt.Errorf("save run: %w", err)
+	}
+	return run, runErr
+}
+
+// PreviousRAGEvalRun returns the latest completed run started before run, or pgx.ErrNoRows
+func (h *Handler) PreviousRAGEvalRun(ctx context.Context, run *models.RAGEvalRun) (*models.RAGEvalRun, error) {
+	return scanRAGEvalRun(h.db.Pool().QueryRow(ctx, `
+		SELECT `+ragEvalRunColumns+` FROM rag_eval_runs
+		WHERE status = 'completed' AND id <> $1 AND started_at < $2
+		ORDER BY started_at DESC
+		LIMIT 1
+	`, run.ID, run.StartedAt))
+}
+
+// evaluateRAGCase retrieves (and optionally answers) one case and records its scores
+func (h *Handler) evaluateRAGCase(ctx context.Context, client *kolosal.Client, runID string, c models.RAGEvalCase, opts RAGEvalOptions) (rageval.Scores, error) {
+	namespaces := ragEvalNamespaces
+	if c.Namespace != "" {
+		namespaces = []string{c.Namespace}
+	}
+
+	var matches []models.VectorMatch
+	for _, namespace := range namespaces {
+		found, err := h.searchVectors(ctx, client, namespace, nil, c.Question, opts.TopK, 0)
+		if err != nil {
+			return rageval.Scores{}, err
+		}
+		matches = append(matches, found...)
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > opts.TopK {
+		matches = matches[:opts.TopK]
+	}
+
+	retrieved, err := h.sourceKeys(ctx, matches)
+	if err != nil {
+		return rageval.Scores{}, err
+	}
+	var s rageval.Scores
+	var precision, recall, rr *float64
+	if len(c.ExpectedSources) > 0 {
+		s.HasSources = true
+		s.Precision, s.Recall, s.ReciprocalRank = rageval.Retrieval(retrieved, c.ExpectedSources)
+		precision, recall, rr = &s.Precision, &s.Recall, &s.ReciprocalRank
+	}
+
+	var answer string
+	var faithfulness, coverage *float64
+	if opts.Answers {
+		contents := make([]string, len(matches))
+		for i, m := range matches {
+			contents[i] = m.Content
+		}
+		messages := chatMessages(withReferences(chatAssistantPrompt, contents), nil, c.Question)
+		if answer, _, err = h.chatWithTools(ctx, client, "", messages, defaultChatModel, defaultChatTemperature); err != nil {
+			return rageval.Scores{}, err
+		}
+		s.HasAnswer = true
+		s.Faithfulness = rageval.Faithfulness(answer, contents)
+		faithfulness = &s.Faithfulness
+		if c.ExpectedAnswer != "" {
+			s.HasExpected = true
+			s.AnswerCoverage = rageval.Coverage(answer, c.ExpectedAnswer)
+			coverage = &s.AnswerCoverage
+		}
+	}
+
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO rag_eval_results (run_id, case_id, question, retrieved, answer, source_precision, source_recall, reciprocal_rank,
+			faithfulness, answer_coverage)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10)
+	`, runID, c.ID, c.Question, retrieved, answer, precision, recall, rr, faithfulness, coverage)
+	if err != nil {
+		return rageval.Scores{}, fmt.Errorf("save result: %w", err)
+	}
+	return s, nil
+}
+
+// sourceKeys maps matches to the stable keys evaluation cases expect, in
+// order without duplicates: regulation source URLs and knowledge-base slugs
+func (h *Handler) sourceKeys(ctx context.Context, matches []models.VectorMatch) ([]string, error) {
+	ids := map[string][]string{}
+	for _, m := range matches {
+		ids[m.Namespace] = append(ids[m.Namespace], m.SourceID)
+	}
+	keys := map[string]string{}
+	queries := map[string]string{
+		embeddings.NamespaceRegulations:   `SELECT id, source_url FROM regulation_documents WHERE id = ANY($1)`,
+		embeddings.NamespaceKnowledgeBase: `SELECT id, slug FROM kb_articles WHERE id = ANY($1)`,
+	}
+	for namespace, sourceIDs := range ids {
+		rows, err := h.db.Pool().Query(ctx, queries[namespace], sourceIDs)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id, key string
+			if rows.Scan(&id, &key) == nil {
+				keys[namespace+"/"+id] = key
+			}
+		}
+		rows.Close()
+	}
+
+	seen := map[string]bool{}
+	retrieved := []string{}
+	for _, m := range matches {
+		key := keys[m.Namespace+"/"+m.SourceID]
+		if key == "" {
+			key = m.SourceID // Source removed since indexing
+		}
+		if !seen[key] {
+			seen[key] = true
+			retrieved = append(retrieved, key)
+		}
+	}
+	return retrieved, nil
+}
+
+// loadRAGEvalCases returns evaluation cases, optionally only active ones with tag
+func (h *Handler) loadRAGEvalCases(ctx context.Context, activeOnly bool, tag string) ([]models.RAGEvalCase, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, question, COALESCE(namespace, ''), expected_sources, expected_answer, tags, active, updated_at
+		FROM rag_eval_cases
+		WHERE (NOT $1 OR active) AND ($2 = '' OR $2 = ANY(tags))
+		ORDER BY created_at, id
+	`, activeOnly, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cases := []models.RAGEvalCase{}
+	for rows.Next() {
+		var c models.RAGEvalCase
+		if err := rows.Scan(&c.ID, &c.Question, &c.Namespace, &c.ExpectedSources, &c.ExpectedAnswer, &c.Tags,
+			&c.Active, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	return cases, rows.Err()
+}
+
+func (h *Handler) parseRAGEvalCase(r *http.Request) (*RAGEvalCaseRequest, error) {
+	var req RAGEvalCaseRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		return nil, err
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	req.ExpectedAnswer = strings.TrimSpace(req.ExpectedAnswer)
+	if err := validation.Validate(&req); err != nil {
+		return nil, err
+	}
+	req.ExpectedSources = trimAll(req.ExpectedSources)
+	req.Tags = trimAll(req.Tags)
+	if len(req.ExpectedSources) == 0 && req.ExpectedAnswer == "" {
+		return nil, errors.NewValidationError("Nothing to evaluate", "provide expected_sources, expected_answer or both")
+	}
+	if req.Active == nil {
+		active := true
+		req.Active = &active
+	}
+	return &req, nil
+}
+
+// ragEvalRunColumns are selected by scanRAGEvalRun, in order
+const ragEvalRunColumns = `id, label, embedding_model, COALESCE(chat_model, ''), top_k, cases, source_precision, source_recall, mrr,
+	faithfulness, answer_coverage, status, COALESCE(error, ''), started_at, finished_at`
+
+func scanRAGEvalRun(row pgx.Row) (*models.RAGEvalRun, error) {
+	var run models.RAGEvalRun
+	if err := row.Scan(&run.ID, &run.Label, &run.EmbeddingModel, &run.ChatModel, &run.TopK, &run.Cases, &run.Precision,
+		&run.Recall, &run.MRR, &run.Faithfulness, &run.AnswerCoverage, &run.Status, &run.Error, &run.StartedAt,
+		&run.FinishedAt); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
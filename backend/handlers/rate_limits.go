@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/services/cachebus"
+	"github.com/bantuaku/backend/services/ratelimit"
+	"github.com/bantuaku/backend/services/workqueue"
+)
+
+// rateLimitNamePattern restricts profile and plan names
+var rateLimitNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+// companyPlanCacheTTL bounds how long a plan change takes to reach the rate limiter
+const companyPlanCacheTTL = 5 * time.Minute
+
+// companyPlanLocalTTL is how long an instance keeps a plan in memory; plan
+// changes are also pushed to every instance through the cache bus
+const companyPlanLocalTTL = time.Minute
+
+// RateLimitProfile is a live rate limit profile as shown to admins
+type RateLimitProfile struct {
+	Name          string `json:"name"`
+	Requests      int    `json:"requests"`
+	WindowSeconds int    `json:"window_seconds"`
+	Burst         int    `json:"burst"`
+	Overridden    bool   `json:"overridden"` // False when the built-in default applies
+}
+
+// RateLimitPlanMultiplier scales every profile for one subscription plan
+type RateLimitPlanMultiplier struct {
+	Plan       string  `json:"plan"`
+	Multiplier float64 `json:"multiplier"`
+	Overridden bool    `json:"overridden"`
+}
+
+// RateLimitSettings is the full live rate limit configuration
+type RateLimitSettings struct {
+	Profiles        []RateLimitProfile        `json:"profiles"`
+	PlanMultipliers []RateLimitPlanMultiplier `json:"plan_multipliers"`
+}
+
+// UpdateRateLimitProfileRequest sets a profile's limits
+type UpdateRateLimitProfileRequest struct {
+	Requests      int `json:"requests"`
+	WindowSeconds int `json:"window_seconds"`
+	Burst         int `json:"burst"`
+}
+
+// UpdatePlanMultiplierRequest sets a plan's throughput multiplier
+type UpdatePlanMultiplierRequest struct {
+	Multiplier float64 `json:"multiplier"`
+}
+
+// RateLimiter returns the limiter used by rate limited routes, nil without Redis
+func (h *Handler) RateLimiter() *ratelimit.Limiter {
+	return h.limiter
+}
+
+// Semaphore returns the per-company concurrency limiter, nil without Redis
+func (h *Handler) Semaphore() *ratelimit.Semaphore {
+	return h.semaphore
+}
+
+// AIQueue returns the work queue in front of AI-calling endpoints
+func (h *Handler) AIQueue() *workqueue.Queue {
+	return h.aiQueue
+}
+
+// CacheBus returns the bus that spreads cache invalidations across instances
+func (h *Handler) CacheBus() *cachebus.Bus {
+	return h.bus
+}
+
+// CompanyPlan returns a company's subscription plan for rate limit scaling,
+// cached briefly in memory and in Redis
+func (h *Handler) CompanyPlan(ctx context.Context, companyID string) string {
+	if plan, ok := h.plans.Get(companyID); ok {
+		return plan
+	}
+	cacheKey := "company_plan:" + companyID
+	if h.redis != nil {
+		if plan, err := h.redis.Get(ctx, cacheKey); err == nil && plan != "" {
+			h.plans.Set(companyID, plan)
+			return plan
+		}
+	}
+
+	var plan string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(subscription_plan, 'free') FROM companies WHERE id = $1
+	`, companyID).Scan(&plan)
+	if err != nil {
+		return "free"
+	}
+	if h.redis != nil {
+		h.redis.Set(ctx, cacheKey, plan, companyPlanCacheTTL)
+	}
+	h.plans.Set(companyID, plan)
+	return plan
+}
+
+// invalidateCompanyPlans drops cached plans of companies whose plan changed,
+// on every instance
+func (h *Handler) invalidateCompanyPlans(ctx context.Context, companyIDs ...string) {
+	if h.redis != nil {
+		keys := make([]string, len(companyIDs))
+		for i, id := range companyIDs {
+			keys[i] = "company_plan:" + id
+		}
+		h.redis.Delete(ctx, keys...)
+	}
+	h.invalidate(ctx, cachebus.KindCompanyPlan, companyIDs...)
+}
+
+// invalidate publishes a cache invalidation to every instance, this one
+// included
+func (h *Handler) invalidate(ctx context.Context, kind string, keys ...string) {
+	if err := h.bus.Publish(ctx, kind, keys...); err != nil {
+		logger.Warn("Failed to publish cache invalidation", "kind", kind, "error", err.Error())
+	}
+}
+
+// ReloadRateLimits loads admin overrides into the live registry. It is run at
+// startup and periodically from main so every instance picks up changes.
+func (h *Handler) ReloadRateLimits(ctx context.Context) {
+	profiles, multipliers, err := h.loadRateLimitOverrides(ctx)
+	if err != nil {
+		logger.Warn("Failed to reload rate limits, keeping current profiles", "error", err.Error())
+		return
+	}
+	h.rateLimits.Replace(profiles, multipliers)
+}
+
+// loadRateLimitOverrides reads profile and plan multiplier overrides from the database
+func (h *Handler) loadRateLimitOverrides(ctx context.Context) (map[string]ratelimit.Profile, map[string]float64, error) {
+	rows, err := h.db.Pool().Query(ctx, `SELECT name, requests, window_seconds, burst FROM rate_limit_profiles`)
+	if err != nil {
+		return nil, nil, err
+	}
+	profiles := map[string]ratelimit.Profile{}
+	for rows.Next() {
+		var p ratelimit.Profile
+		var windowSeconds int
+		if err := rows.Scan(&p.Name, &p.Requests, &windowSeconds, &p.Burst); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		p.Window = time.Duration(windowSeconds) * time.Second
+		profiles[p.Name] = p
+	}
+	rows.Close()
+
+	rows, err = h.db.Pool().Query(ctx, `SELECT plan, multiplier::float8 FROM rate_limit_plan_multipliers`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	multipliers := map[string]float64{}
+	for rows.Next() {
+		var plan string
+		var m float64
+		if err := rows.Scan(&plan, &m); err != nil {
+			return nil, nil, err
+		}
+		multipliers[plan] = m
+	}
+	return profiles, multipliers, nil
+}
+
+// rateLimitSettings describes the live registry, marking admin overrides
+func (h *Handler) rateLimitSettings(ctx context.Context) (RateLimitSettings, error) {
+	overrides, planOverrides, err := h.loadRateLimitOverrides(ctx)
+	if err != nil {
+		return RateLimitSettings{}, err
+	}
+	h.rateLimits.Replace(overrides, planOverrides)
+
+	settings := RateLimitSettings{Profiles: []RateLimitProfile{}, PlanMultipliers: []RateLimitPlanMultiplier{}}
+	for name, p := range h.rateLimits.Profiles() {
+		_, overridden := overrides[name]
+		settings.Profiles = append(settings.Profiles, RateLimitProfile{
+			Name:          name,
+			Requests:      p.Requests,
+			WindowSeconds: int(p.Window / time.Second),
+			Burst:         p.Burst,
+			Overridden:    overridden,
+		})
+	}
+	for plan, m := range h.rateLimits.Multipliers() {
+		_, overridden := planOverrides[plan]
+		settings.PlanMultipliers = append(settings.PlanMultipliers, RateLimitPlanMultiplier{Plan: plan, Multiplier: m, Overridden: overridden})
+	}
+	sort.Slice(settings.Profiles, func(i, j int) bool { return settings.Profiles[i].Name < settings.Profiles[j].Name })
+	sort.Slice(settings.PlanMultipliers, func(i, j int) bool { return settings.PlanMultipliers[i].Plan < settings.PlanMultipliers[j].Plan })
+	return settings, nil
+}
+
+// respondRateLimitSettings reloads the registry and returns the live settings
+func (h *Handler) respondRateLimitSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.rateLimitSettings(r.Context())
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load rate limits"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, settings)
+}
+
+// GetRateLimits returns the live rate limit profiles and plan multipliers
+func (h *Handler) GetRateLimits(w http.ResponseWriter, r *http.Request) {
+	h.respondRateLimitSettings(w, r)
+}
+
+// UpdateRateLimitProfile creates or replaces a rate limit profile
+func (h *Handler) UpdateRateLimitProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !rateLimitNamePattern.MatchString(name) {
+		h.respondError(w, errors.NewValidationError("Invalid profile name", "use lowercase letters, digits, - and _"), r)
+		return
+	}
+
+	var req UpdateRateLimitProfileRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	profile := ratelimit.Profile{
+		Name:     name,
+		Requests: req.Requests,
+		Window:   time.Duration(req.WindowSeconds) * time.Second,
+		Burst:    req.Burst,
+	}
+	if err := profile.Validate(); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid rate limit profile", err.Error()), r)
+		return
+	}
+
+	_, err := h.db.Pool().Exec(r.Context(), `
+		INSERT INTO rate_limit_profiles (name, requests, window_seconds, burst, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET requests = EXCLUDED.requests, window_seconds = EXCLUDED.window_seconds, burst = EXCLUDED.burst, updated_at = NOW()
+	`, name, req.Requests, req.WindowSeconds, req.Burst)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save rate limit profile"), r)
+		return
+	}
+
+	logger.Info("Rate limit profile updated", "profile", name, "requests", req.Requests, "window_seconds", req.WindowSeconds, "burst", req.Burst)
+	h.invalidate(r.Context(), cachebus.KindRateLimits)
+	h.respondRateLimitSettings(w, r)
+}
+
+// DeleteRateLimitProfile removes an override, restoring the built-in default if there is one
+func (h *Handler) DeleteRateLimitProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	tag, err := h.db.Pool().Exec(r.Context(), `DELETE FROM rate_limit_profiles WHERE name = $1`, name)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete rate limit profile"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Rate limit profile override"), r)
+		return
+	}
+
+	logger.Info("Rate limit profile reset", "profile", name)
+	h.invalidate(r.Context(), cachebus.KindRateLimits)
+	h.respondRateLimitSettings(w, r)
+}
+
+// UpdatePlanMultiplier sets the throughput multiplier of a subscription plan
+func (h *Handler) UpdatePlanMultiplier(w http.ResponseWriter, r *http.Request) {
+	plan := r.PathValue("plan")
+	if !rateLimitNamePattern.MatchString(plan) {
+		h.respondError(w, errors.NewValidationError("Invalid plan name", "use lowercase letters, digits, - and _"), r)
+		return
+	}
+
+	var req UpdatePlanMultiplierRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.Multiplier <= 0 || req.Multiplier > 1000 {
+		h.respondError(w, errors.NewValidationError("Invalid multiplier", "multiplier must be above 0 and at most 1000"), r)
+		return
+	}
+
+	_, err := h.db.Pool().Exec(r.Context(), `
+		INSERT INTO rate_limit_plan_multipliers (plan, multiplier, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (plan) DO UPDATE SET multiplier = EXCLUDED.multiplier, updated_at = NOW()
+	`, plan, req.Multiplier)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save plan multiplier"), r)
+		return
+	}
+
+	logger.Info("Rate limit plan multiplier updated", "plan", plan, "multiplier", req.Multiplier)
+	h.invalidate(r.Context(), cachebus.KindRateLimits)
+	h.respondRateLimitSettings(w, r)
+}
+
+// DeletePlanMultiplier removes a plan multiplier override
+func (h *Handler) DeletePlanMultiplier(w http.ResponseWriter, r *http.Request) {
+	plan := r.PathValue("plan")
+	tag, err := h.db.Pool().Exec(r.Context(), `DELETE FROM rate_limit_plan_multipliers WHERE plan = $1`, plan)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete plan multiplier"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Plan multiplier override"), r)
+		return
+	}
+
+	logger.Info("Rate limit plan multiplier reset", "plan", plan)
+	h.invalidate(r.Context(), cachebus.KindRateLimits)
+	h.respondRateLimitSettings(w, r)
+}
+
+// ConcurrencyLimitStatus is one operation's concurrency limit and saturation on this instance
+type ConcurrencyLimitStatus struct {
+	ratelimit.ConcurrencyStats
+	Slots             int `json:"slots"` // At a plan multiplier of 1
+	LeaseSeconds      int `json:"lease_seconds"`
+	RetryAfterSeconds int `json:"retry_after_seconds"`
+}
+
+// GetConcurrencyStats returns per-operation concurrency limits with acquired,
+// rejected and in-flight counts since this instance started, plus the AI work queue
+func (h *Handler) GetConcurrencyStats(w http.ResponseWriter, r *http.Request) {
+	stats := map[string]ratelimit.ConcurrencyStats{}
+	if h.semaphore != nil {
+		for _, st := range h.semaphore.Stats() {
+			stats[st.Operation] = st
+		}
+	}
+
+	operations := []ConcurrencyLimitStatus{}
+	for op, limit := range ratelimit.DefaultConcurrencyLimits {
+		st, ok := stats[op]
+		if !ok {
+			st.Operation = op
+		}
+		operations = append(operations, ConcurrencyLimitStatus{
+			ConcurrencyStats:  st,
+			Slots:             limit.Slots,
+			LeaseSeconds:      int(limit.Lease / time.Second),
+			RetryAfterSeconds: int(limit.RetryAfter / time.Second),
+		})
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].Operation < operations[j].Operation })
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"enabled":    h.semaphore != nil,
+		"operations": operations,
+		"ai_queue":   h.aiQueue.Stats(),
+	})
+}
@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/geocode"
+	"github.com/bantuaku/backend/services/regions"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// minRegionGroup is the smallest number of companies a regional aggregate may
+// describe, so statistics never single out a business
+const minRegionGroup = 3
+
+// mapPointLimit caps the companies returned for the admin map
+const mapPointLimit = 2000
+
+// regionColumns maps a region level to the companies column holding its code
+var regionColumns = map[string]string{
+	regions.LevelProvince: "province_code",
+	regions.LevelRegency:  "regency_code",
+	regions.LevelDistrict: "district_code",
+}
+
+// UpdateCompanyLocationRequest sets a company's region and, optionally, coordinates
+type UpdateCompanyLocationRequest struct {
+	ProvinceCode string   `json:"province_code" validate:"required"`
+	RegencyCode  string   `json:"regency_code"`
+	DistrictCode string   `json:"district_code"`
+	Address      string   `json:"address" validate:"max:500"`
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+}
+
+// ListRegions lists the children of ?parent= (a province or regency code), or
+// the provinces when no parent is given
+func (h *Handler) ListRegions(w http.ResponseWriter, r *http.Request) {
+	parent := r.URL.Query().Get("parent")
+	if parent != "" {
+		if _, _, err := regions.ParseCode(parent); err != nil {
+			h.respondError(w, errors.NewValidationError("Invalid parent", err.Error()), r)
+			return
+		}
+	}
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT code, name, level, COALESCE(parent_code, '') FROM regions
+		WHERE COALESCE(parent_code, '') = $1
+		ORDER BY code
+	`, parent)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list regions"), r)
+		return
+	}
+	defer rows.Close()
+
+	list := []regions.Region{}
+	for rows.Next() {
+		var reg regions.Region
+		if err := rows.Scan(&reg.Code, &reg.Name, &reg.Level, &reg.ParentCode); err != nil {
+			continue
+		}
+		list = append(list, reg)
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{"regions": list})
+}
+
+// GetCompanyLocation returns the authenticated company's location
+func (h *Handler) GetCompanyLocation(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	loc, err := h.loadCompanyLocation(r.Context(), companyID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company location"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, loc)
+}
+
+// UpdateCompanyLocation sets the authenticated company's region. Codes must be
+// known Kemendagri regions nested in each other. Without coordinates the
+// address is geocoded when a geocoder is configured. The region names are also
+// written to location_region and city, which market research uses.
+func (h *Handler) UpdateCompanyLocation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req UpdateCompanyLocationRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	req.Address = strings.TrimSpace(req.Address)
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := regions.CheckChain(req.ProvinceCode, req.RegencyCode, req.DistrictCode); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid region", err.Error()), r)
+		return
+	}
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		h.respondError(w, errors.NewValidationError("Invalid coordinates", "latitude and longitude must be given together"), r)
+		return
+	}
+	if req.Latitude != nil && !regions.InIndonesia(*req.Latitude, *req.Longitude) {
+		h.respondError(w, errors.NewValidationError("Invalid coordinates", "coordinates must be in Indonesia"), r)
+		return
+	}
+
+	codes := []string{req.ProvinceCode}
+	for _, c := range []string{req.RegencyCode, req.DistrictCode} {
+		if c != "" {
+			codes = append(codes, c)
+		}
+	}
+	names, err := h.regionNames(ctx, codes)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load regions"), r)
+		return
+	}
+	for _, c := range codes {
+		if names[c] == "" {
+			h.respondError(w, errors.NewValidationError("Unknown region", fmt.Sprintf("region %s is not in the region list", c)), r)
+			return
+		}
+	}
+
+	lat, lng, geocoded := req.Latitude, req.Longitude, false
+	if lat == nil && h.geocoder != nil {
+		parts := []string{req.Address, names[req.DistrictCode], names[req.RegencyCode], names[req.ProvinceCode]}
+		if p, ok := h.geocodeLocation(ctx, parts); ok {
+			lat, lng, geocoded = &p.Latitude, &p.Longitude, true
+		}
+	}
+
+	_, err = h.db.Pool().Exec(ctx, `
+		UPDATE companies SET
+			province_code = $2, regency_code = NULLIF($3, ''), district_code = NULLIF($4, ''),
+			address = NULLIF($5, ''), latitude = $6, longitude = $7, geocoded = $8,
+			location_region = $9, city = NULLIF($10, ''), updated_at = NOW()
+		WHERE id = $1
+	`, companyID, req.ProvinceCode, req.RegencyCode, req.DistrictCode, req.Address, lat, lng, geocoded,
+		names[req.ProvinceCode], names[req.RegencyCode])
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update company location"), r)
+		return
+	}
+
+	loc, err := h.loadCompanyLocation(ctx, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company location"), r)
+		return
+	}
+	logger.Info("Company location updated", "company_id", companyID, "province", req.ProvinceCode, "regency", req.RegencyCode, "geocoded", geocoded)
+	h.respondJSON(w, r, http.StatusOK, loc)
+}
+
+// GetRegionStats aggregates the companies in a region: how many there are, how
+// many products they sell and their industries. Industries with fewer than
+// minRegionGroup companies are folded into "lainnya".
+func (h *Handler) GetRegionStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	code := r.PathValue("code")
+	level, _, err := regions.ParseCode(code)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid region code", err.Error()), r)
+		return
+	}
+
+	stats := models.RegionStats{Code: code, Level: level, Industries: []models.IndustryCount{}}
+	err = h.db.Pool().QueryRow(ctx, `SELECT name FROM regions WHERE code = $1`, code).Scan(&stats.Name)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Region"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load region"), r)
+		return
+	}
+
+	column := regionColumns[level]
+	err = h.db.Pool().QueryRow(ctx, fmt.Sprintf(`
+		SELECT COUNT(*),
+			(SELECT COUNT(*) FROM products p JOIN companies pc ON pc.id = p.company_id
+			 WHERE pc.%[1]s = $1 AND pc.merged_into IS NULL AND COALESCE(pc.status, 'active') = 'active'
+				AND p.merged_into IS NULL)
+		FROM companies c WHERE c.%[1]s = $1 AND c.merged_into IS NULL AND COALESCE(c.status, 'active') = 'active'
+	`, column), code).Scan(&stats.Companies, &stats.Products)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count region companies"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, fmt.Sprintf(`
+		SELECT COALESCE(NULLIF(industry, ''), 'lainnya'), COUNT(*) FROM companies
+		WHERE %s = $1 AND merged_into IS NULL AND COALESCE(status, 'active') = 'active'
+		GROUP BY 1
+	`, column), code)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count region industries"), r)
+		return
+	}
+	defer rows.Close()
+	other := 0
+	for rows.Next() {
+		var ic models.IndustryCount
+		if err := rows.Scan(&ic.Industry, &ic.Companies); err != nil {
+			continue
+		}
+		if ic.Companies < minRegionGroup || ic.Industry == "lainnya" {
+			other += ic.Companies
+			continue
+		}
+		stats.Industries = append(stats.Industries, ic)
+	}
+	sort.Slice(stats.Industries, func(i, j int) bool { return stats.Industries[i].Companies > stats.Industries[j].Companies })
+	if other > 0 {
+		stats.Industries = append(stats.Industries, models.IndustryCount{Industry: "lainnya", Companies: other})
+	}
+
+	h.respondJSON(w, r, http.StatusOK, stats)
+}
+
+// AdminCompanyDirectory lists companies with their regions, filtered by
+// ?province=, ?regency=, ?district=, ?industry=, ?q= (name) and
+// ?bbox=minLat,minLng,maxLat,maxLng (platform admin only)
+func (h *Handler) AdminCompanyDirectory(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	where, args, err := companyDirectoryFilter(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM companies c WHERE `+where, args...).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count companies"), r)
+		return
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	items, err := h.queryDirectory(ctx, fmt.Sprintf(`WHERE %s ORDER BY c.name LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args)), args)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list companies"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    items,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// AdminCompanyMap returns the companies with coordinates for a map, with the
+// same filters as the directory, up to mapPointLimit (platform admin only)
+func (h *Handler) AdminCompanyMap(w http.ResponseWriter, r *http.Request) {
+	where, args, err := companyDirectoryFilter(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	args = append(args, mapPointLimit+1)
+	items, err := h.queryDirectory(r.Context(), fmt.Sprintf(`
+		WHERE %s AND c.latitude IS NOT NULL ORDER BY c.created_at LIMIT $%d
+	`, where, len(args)), args)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list company coordinates"), r)
+		return
+	}
+
+	truncated := len(items) > mapPointLimit
+	if truncated {
+		items = items[:mapPointLimit]
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"points":    items,
+		"truncated": truncated, // Narrow the filters or bbox to see every company
+	})
+}
+
+// companyDirectoryFilter builds the WHERE clause (on alias c) for the directory filters
+func companyDirectoryFilter(r *http.Request) (string, []interface{}, error) {
+	q := r.URL.Query()
+	conditions := []string{"c.merged_into IS NULL"}
+	args := []interface{}{}
+	for _, param := range []string{"province", "regency", "district"} {
+		code := q.Get(param)
+		if code == "" {
+			continue
+		}
+		if level, _, err := regions.ParseCode(code); err != nil || level != param {
+			return "", nil, errors.NewValidationError("Invalid "+param, fmt.Sprintf("%s is not a %s code", code, param))
+		}
+		args = append(args, code)
+		conditions = append(conditions, fmt.Sprintf("c.%s_code = $%d", param, len(args)))
+	}
+	if industry := q.Get("industry"); industry != "" {
+		args = append(args, industry)
+		conditions = append(conditions, fmt.Sprintf("c.industry ILIKE $%d", len(args)))
+	}
+	if name := q.Get("q"); name != "" {
+		args = append(args, "%"+name+"%")
+		conditions = append(conditions, fmt.Sprintf("c.name ILIKE $%d", len(args)))
+	}
+	if bbox := q.Get("bbox"); bbox != "" {
+		parts := strings.Split(bbox, ",")
+		var box [4]float64
+		var err error
+		for i := 0; i < len(parts) && i < 4; i++ {
+			if box[i], err = strconv.ParseFloat(strings.TrimSpace(parts[i]), 64); err != nil {
+				break
+			}
+		}
+		if len(parts) != 4 || err != nil || box[0] > box[2] || box[1] > box[3] {
+			return "", nil, errors.NewValidationError("Invalid bbox", "bbox must be minLat,minLng,maxLat,maxLng")
+		}
+		args = append(args, box[0], box[1], box[2], box[3])
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf("c.latitude BETWEEN $%d AND $%d AND c.longitude BETWEEN $%d AND $%d", n-3, n-1, n-2, n))
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// queryDirectory selects directory entries; tail holds the WHERE, ORDER and LIMIT clauses
+func (h *Handler) queryDirectory(ctx context.Context, tail string, args []interface{}) ([]models.DirectoryCompany, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT c.id, c.name, COALESCE(c.industry, ''), COALESCE(c.status, ''),
+			COALESCE(p.name, ''), COALESCE(rg.name, ''), COALESCE(d.name, ''), c.latitude, c.longitude
+		FROM companies c
+		LEFT JOIN regions p ON p.code = c.province_code
+		LEFT JOIN regions rg ON rg.code = c.regency_code
+		LEFT JOIN regions d ON d.code = c.district_code
+	`+tail, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.DirectoryCompany{}
+	for rows.Next() {
+		var c models.DirectoryCompany
+		if err := rows.Scan(&c.ID, &c.Name, &c.Industry, &c.Status, &c.Province, &c.Regency, &c.District,
+			&c.Latitude, &c.Longitude); err != nil {
+			return nil, err
+		}
+		items = append(items, c)
+	}
+	return items, rows.Err()
+}
+
+// loadCompanyLocation reads a company's location with its region names
+func (h *Handler) loadCompanyLocation(ctx context.Context, companyID string) (*models.CompanyLocation, error) {
+	var loc models.CompanyLocation
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(c.province_code, ''), COALESCE(p.name, ''), COALESCE(c.regency_code, ''), COALESCE(rg.name, ''),
+			COALESCE(c.district_code, ''), COALESCE(d.name, ''), COALESCE(c.address, ''), c.latitude, c.longitude, c.geocoded
+		FROM companies c
+		LEFT JOIN regions p ON p.code = c.province_code
+		LEFT JOIN regions rg ON rg.code = c.regency_code
+		LEFT JOIN regions d ON d.code = c.district_code
+		WHERE c.id = $1
+	`, companyID).Scan(&loc.ProvinceCode, &loc.Province, &loc.RegencyCode, &loc.Regency, &loc.DistrictCode,
+		&loc.District, &loc.Address, &loc.Latitude, &loc.Longitude, &loc.Geocoded)
+	if err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// regionNames returns the names of the known codes among codes
+func (h *Handler) regionNames(ctx context.Context, codes []string) (map[string]string, error) {
+	rows, err := h.db.Pool().Query(ctx, `SELECT code, name FROM regions WHERE code = ANY($1)`, codes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := map[string]string{}
+	for rows.Next() {
+		var code, name string
+		if err := rows.Scan(&code, &name); err != nil {
+			return nil, err
+		}
+		names[code] = name
+	}
+	return names, rows.Err()
+}
+
+// geocodeLocation looks up the most specific address it can from parts, most
+// specific first, dropping the leading part while nothing matches. Failures are
+// logged; the location is saved without coordinates.
+func (h *Handler) geocodeLocation(ctx context.Context, parts []string) (geocode.Point, bool) {
+	var kept []string
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, geocode.DefaultTimeout)
+	defer cancel()
+	for len(kept) > 0 {
+		p, err := h.geocoder.Search(ctx, strings.Join(kept, ", ")+", Indonesia")
+		if err == nil && regions.InIndonesia(p.Latitude, p.Longitude) {
+			return p, true
+		}
+		if err != nil && err != geocode.ErrNotFound {
+			logger.Warn("Geocoding failed", "query", strings.Join(kept, ", "), "error", err.Error())
+			return geocode.Point{}, false
+		}
+		kept = kept[1:]
+	}
+	return geocode.Point{}, false
+}
+
+// regionalDensity describes how many businesses share the company's regency
+// (or province) and industry, for market prediction prompts. It returns "" when
+// the company has no region.
+func (h *Handler) regionalDensity(ctx context.Context, companyID string) string {
+	var industry, code, name string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(c.industry, ''), r.code, r.name
+		FROM companies c JOIN regions r ON r.code = COALESCE(c.regency_code, c.province_code)
+		WHERE c.id = $1
+	`, companyID).Scan(&industry, &code, &name)
+	if err != nil {
+		return ""
+	}
+
+	level, _, _ := regions.ParseCode(code)
+	column := regionColumns[level]
+	var total, same int
+	err = h.db.Pool().QueryRow(ctx, fmt.Sprintf(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE $2 <> '' AND industry ILIKE $2)
+		FROM companies WHERE %s = $1 AND merged_into IS NULL AND COALESCE(status, 'active') = 'active' AND id <> $3
+	`, column), code, industry, companyID).Scan(&total, &same)
+	if err != nil {
+		logger.Warn("Failed to count regional companies", "company_id", companyID, "error", err.Error())
+		return ""
+	}
+
+	line := fmt.Sprintf("Kepadatan usaha lokal: di %s terdapat %d UMKM lain yang memakai Bantuaku", name, total)
+	switch {
+	case industry == "":
+	case same >= minRegionGroup:
+		line += fmt.Sprintf(", %d di antaranya di industri %s", same, industry)
+	default:
+		line += fmt.Sprintf(", kurang dari %d di industri %s", minRegionGroup, industry)
+	}
+	return line + "."
+}
+
+// ImportRegions upserts regencies and districts (and province names) from the
+// official region list, parents first. It is run by the `regions` subcommand.
+func (h *Handler) ImportRegions(ctx context.Context, list []regions.Region) (int, error) {
+	sort.SliceStable(list, func(i, j int) bool { return len(list[i].Code) < len(list[j].Code) })
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, reg := range list {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO regions (code, name, level, parent_code) VALUES ($1, $2, $3, NULLIF($4, ''))
+			ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name
+		`, reg.Code, reg.Name, reg.Level, reg.ParentCode)
+		if err != nil {
+			return 0, fmt.Errorf("region %s: %w", reg.Code, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	logger.Info("Regions imported", "count", len(list))
+	return len(list), nil
+}
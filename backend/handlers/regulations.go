@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/embeddings"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+)
+
+// Regulation indexing limits
+const (
+	maxRegulationDocuments = 50
+	embeddingBatchSize     = 64 // Inputs per embeddings API call
+)
+
+// IndexRegulationsRequest represents a batch of regulation documents to (re-)index
+type IndexRegulationsRequest struct {
+	Documents []RegulationDocumentInput `json:"documents"`
+}
+
+// RegulationDocumentInput is the full text of one regulation source
+type RegulationDocumentInput struct {
+	SourceURL string `json:"source_url" validate:"required,max:2000"`
+	Title     string `json:"title" validate:"required,max:500"`
+	Category  string `json:"category,omitempty" validate:"max:100"`
+	Content   string `json:"content" validate:"required"`
+}
+
+// IndexRegulations chunks and embeds regulation documents (platform admin only).
+// Chunks are content-addressed: unchanged documents are skipped and chunks whose
+// hash already has an embedding are reused, so re-indexing only pays for new text.
+func (h *Handler) IndexRegulations(w http.ResponseWriter, r *http.Request) {
+	var req IndexRegulationsRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if len(req.Documents) == 0 || len(req.Documents) > maxRegulationDocuments {
+		h.respondError(w, errors.NewValidationError("Invalid documents", fmt.Sprintf("provide between 1 and %d documents", maxRegulationDocuments)), r)
+		return
+	}
+	for i := range req.Documents {
+		if err := validation.Validate(&req.Documents[i]); err != nil {
+			h.respondError(w, err, r)
+			return
+		}
+	}
+	if h.config.KolosalAPIKey == "" {
+		h.respondError(w, errors.NewExternalServiceError("Kolosal.ai", "Embeddings are not configured", "KOLOSAL_API_KEY is not set"), r)
+		return
+	}
+
+	job := models.RegulationIndexJob{ID: uuid.New().String(), Status: "completed", StartedAt: time.Now()}
+	ctx := aispend.WithFeature(r.Context(), aispend.FeatureRegulations, job.ID)
+	client := h.kolosalClient()
+
+	var indexErr error
+	for _, doc := range req.Documents {
+		if indexErr = h.indexRegulationDocument(ctx, client, doc, &job); indexErr != nil {
+			break
+		}
+	}
+	if indexErr == nil {
+		job.OrphansRemoved, indexErr = h.collectOrphanEmbeddings(ctx, embeddings.NamespaceRegulations)
+	}
+	if indexErr != nil {
+		job.Status = "failed"
+		job.Error = indexErr.Error()
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	if err := h.saveRegulationIndexJob(ctx, job); err != nil {
+		logger.Warn("Failed to record regulation index job", "job_id", job.ID, "error", err.Error())
+	}
+
+	// Cached chat answers may explain regulations that just changed
+	if job.Documents > job.DocumentsUnchanged || job.OrphansRemoved > 0 {
+		h.invalidateAnswerCache(ctx, "regulations reindexed")
+	}
+
+	if indexErr != nil {
+		h.respondError(w, errors.NewInternalError(indexErr, "Regulation indexing failed"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, job)
+}
+
+// ListRegulationIndexJobs returns the 20 most recent indexing summaries (platform admin only)
+func (h *Handler) ListRegulationIndexJobs(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT id, status, documents, documents_unchanged, chunks_total, chunks_reused, chunks_embedded,
+			tokens_spent, tokens_saved, orphans_removed, COALESCE(error, ''), started_at, finished_at
+		FROM regulation_index_jobs
+		ORDER BY started_at DESC
+		LIMIT 20
+	`)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list regulation index jobs"), r)
+		return
+	}
+	defer rows.Close()
+
+	jobs := []models.RegulationIndexJob{}
+	for rows.Next() {
+		var j models.RegulationIndexJob
+		if err := rows.Scan(&j.ID, &j.Status, &j.Documents, &j.DocumentsUnchanged, &j.ChunksTotal, &j.ChunksReused,
+			&j.ChunksEmbedded, &j.TokensSpent, &j.TokensSaved, &j.OrphansRemoved, &j.Error, &j.StartedAt, &j.FinishedAt); err != nil {
+			continue
+		}
+		jobs = append(jobs, j)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"jobs": jobs,
+	})
+}
+
+// indexRegulationDocument chunks a document into the regulations namespace,
+// skipping it entirely when its content has not changed since the last run
+func (h *Handler) indexRegulationDocument(ctx context.Context, client *kolosal.Client, doc RegulationDocumentInput, job *models.RegulationIndexJob) error {
+	job.Documents++
+	docHash := embeddings.ContentHash(doc.Content)
+
+	var docID, storedHash string
+	var chunkCount int
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, content_hash, chunk_count FROM regulation_documents WHERE source_url = $1
+	`, doc.SourceURL).Scan(&docID, &storedHash, &chunkCount)
+	if err == nil && storedHash == docHash && chunkCount > 0 {
+		saved, err := h.sourceEmbeddingTokens(ctx, embeddings.NamespaceRegulations, docID)
+		if err != nil {
+			return err
+		}
+		job.DocumentsUnchanged++
+		job.ChunksTotal += chunkCount
+		job.ChunksReused += chunkCount
+		job.TokensSaved += saved
+		return nil
+	}
+	if docID == "" {
+		docID = uuid.New().String()
+	}
+
+	chunks := embeddings.Chunk(doc.Content, embeddings.DefaultChunkSize, embeddings.DefaultChunkOverlap)
+	if err := h.storeChunks(ctx, client, embeddings.NamespaceRegulations, docID, chunks, &job.EmbeddingUsage); err != nil {
+		return err
+	}
+
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO regulation_documents (id, source_url, title, category, content_hash, chunk_count, indexed_at, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, NOW(), NOW())
+		ON CONFLICT (source_url) DO UPDATE
+		SET title = EXCLUDED.title, category = EXCLUDED.category, content_hash = EXCLUDED.content_hash,
+			chunk_count = EXCLUDED.chunk_count, indexed_at = EXCLUDED.indexed_at
+	`, docID, doc.SourceURL, doc.Title, doc.Category, docHash, len(chunks))
+	if err != nil {
+		return fmt.Errorf("save regulation document: %w", err)
+	}
+	return nil
+}
+
+func (h *Handler) saveRegulationIndexJob(ctx context.Context, job models.RegulationIndexJob) error {
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO regulation_index_jobs (id, status, documents, documents_unchanged, chunks_total, chunks_reused,
+			chunks_embedded, tokens_spent, tokens_saved, orphans_removed, error, started_at, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12, $13)
+	`, job.ID, job.Status, job.Documents, job.DocumentsUnchanged, job.ChunksTotal, job.ChunksReused,
+		job.ChunksEmbedded, job.TokensSpent, job.TokensSaved, job.OrphansRemoved, job.Error, job.StartedAt, job.FinishedAt)
+	return err
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/purchasing"
+	"github.com/bantuaku/backend/services/quality"
+	"github.com/bantuaku/backend/services/reorder"
+)
+
+// dashboardReorderAlerts caps the reorders listed on the dashboard
+const dashboardReorderAlerts = 5
+
+// ReorderRecommendations lists when to restock each product and how much
+type ReorderRecommendations struct {
+	GeneratedAt     time.Time                `json:"generated_at"`
+	ReviewDays      int                      `json:"review_days"`
+	OrderNow        int                      `json:"order_now"`
+	OrderSoon       int                      `json:"order_soon"`
+	Recommendations []reorder.Recommendation `json:"recommendations"`
+	Skipped         []PurchasePlanSkipped    `json:"skipped"`
+}
+
+// GetReorderRecommendations recommends a reorder point, order quantity and
+// reorder date for each active product with forecast demand, from its stock on
+// hand, lead time and safety stock. Orders cover ?review_days= (default 7) of
+// demand; ?status= keeps one status (order_now, order_soon, ok, not_tracked).
+func (h *Handler) GetReorderRecommendations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	review := purchasing.DefaultReviewDays
+	if s := r.URL.Query().Get("review_days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > purchasing.MaxHorizonDays {
+			h.respondError(w, errors.NewValidationError("Invalid review_days", fmt.Sprintf("review_days must be between 1 and %d", purchasing.MaxHorizonDays)), r)
+			return
+		}
+		review = n
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", reorder.StatusOrderNow, reorder.StatusOrderSoon, reorder.StatusOK, reorder.StatusNotTracked:
+	default:
+		h.respondError(w, errors.NewValidationError("Invalid status", "status must be order_now, order_soon, ok or not_tracked"), r)
+		return
+	}
+
+	now := time.Now().In(h.companyLocation(ctx, companyID))
+	recs, skipped, err := h.reorderRecommendations(ctx, companyID, now, review)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "build reorder recommendations"), r)
+		return
+	}
+
+	resp := ReorderRecommendations{GeneratedAt: time.Now(), ReviewDays: review, Recommendations: recs, Skipped: skipped}
+	resp.OrderNow, resp.OrderSoon = reorder.Due(recs)
+	if status != "" {
+		resp.Recommendations = []reorder.Recommendation{}
+		for _, rec := range recs {
+			if rec.Status == status {
+				resp.Recommendations = append(resp.Recommendations, rec)
+			}
+		}
+	}
+	h.respondJSON(w, r, http.StatusOK, resp)
+}
+
+// reorderRecommendations recommends reorders for the company's active
+// products, listing the products left out
+func (h *Handler) reorderRecommendations(ctx context.Context, companyID string, now time.Time, review int) ([]reorder.Recommendation, []PurchasePlanSkipped, error) {
+	today := localDate(now, now.Location())
+	points, _, err := h.loadDailySales(ctx, companyID, "", today.AddDate(0, 0, -forecastWindowDays))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, name, COALESCE(sku, ''), COALESCE(unit, 'pcs'), COALESCE(supplier_name, ''),
+			lead_time_days, safety_stock::float8, COALESCE(min_order_quantity, 0)::float8,
+			COALESCE(cost, 0)::float8, stock_on_hand::float8
+		FROM products
+		WHERE company_id = $1 AND COALESCE(is_active, true) AND merged_into IS NULL
+		ORDER BY name
+	`, companyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var items []reorder.Item
+	skipped := []PurchasePlanSkipped{}
+	for rows.Next() {
+		var it reorder.Item
+		if err := rows.Scan(&it.ProductID, &it.Name, &it.SKU, &it.Unit, &it.Supplier, &it.LeadTimeDays, &it.SafetyStock,
+			&it.MinOrderQuantity, &it.UnitCost, &it.OnHand); err != nil {
+			return nil, nil, err
+		}
+		// The same checks and rate as GetForecast
+		series := points[it.ProductID]
+		if quality.Assess(series, now, forecastWindowDays).Blocking() {
+			skipped = append(skipped, PurchasePlanSkipped{ProductID: it.ProductID, ProductName: it.Name, Reason: "insufficient_data"})
+			continue
+		}
+		salesData := make([]float64, len(series))
+		for i, p := range series {
+			salesData[i] = p.Quantity
+		}
+		if it.DailyRate, _, _ = forecastDailyRate(salesData); it.DailyRate <= 0 {
+			skipped = append(skipped, PurchasePlanSkipped{ProductID: it.ProductID, ProductName: it.Name, Reason: "no_demand"})
+			continue
+		}
+		it.DailyStdDev = reorder.StdDev(salesData)
+		items = append(items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return reorder.Build(items, today, review), skipped, nil
+}
+
+// reorderSummary condenses the reorder recommendations for the dashboard
+func (h *Handler) reorderSummary(ctx context.Context, companyID string, now time.Time) (*models.ReorderSummary, error) {
+	recs, _, err := h.reorderRecommendations(ctx, companyID, now, purchasing.DefaultReviewDays)
+	if err != nil {
+		return nil, err
+	}
+	summary := &models.ReorderSummary{Next: []models.ReorderAlert{}}
+	summary.OrderNow, summary.OrderSoon = reorder.Due(recs)
+	for _, rec := range recs {
+		if len(summary.Next) == dashboardReorderAlerts ||
+			(rec.Status != reorder.StatusOrderNow && rec.Status != reorder.StatusOrderSoon) {
+			break // Due recommendations come first
+		}
+		summary.Next = append(summary.Next, models.ReorderAlert{
+			ProductID:     rec.ProductID,
+			ProductName:   rec.ProductName,
+			Status:        rec.Status,
+			ReorderDate:   rec.ReorderDate,
+			OrderQuantity: rec.OrderQuantity,
+			Unit:          rec.Unit,
+		})
+	}
+	return summary, nil
+}
@@ -1,67 +1,74 @@
 package handlers
 
 import (
-	"encoding/csv"
 	"fmt"
-	"io"
 	"net/http"
-	"strconv"
 	"time"
 
+	"github.com/bantuaku/backend/errors"
 	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/importer"
 )
 
 // RecordSaleRequest represents a manual sale entry
 type RecordSaleRequest struct {
 	ProductID string    `json:"product_id"`
-	Quantity  int       `json:"quantity"`
-	Price     float64   `json:"price"`
+	Quantity  float64   `json:"quantity"`
+	Unit      string    `json:"unit,omitempty"` // Defaults to the product's unit
+	Price     float64   `json:"price"`          // Per entered unit
 	SaleDate  time.Time `json:"sale_date"`
 }
 
-// ImportResult represents the result of a CSV import
+// ImportResult represents the result of a CSV import. In a dry run nothing is
+// saved and SuccessCount is how many rows would be imported.
 type ImportResult struct {
-	SuccessCount int           `json:"success_count"`
-	Errors       []ImportError `json:"errors"`
+	SuccessCount int               `json:"success_count"`
+	DryRun       bool              `json:"dry_run"`
+	Mapping      map[string]string `json:"mapping"` // Field to the file's column it was read from
+	Errors       []ImportError     `json:"errors"`
 }
 
-// ImportError represents an error during import
+// ImportError is a rejected row: its row number in the file (the header is
+// row 1), the column and value at fault, and why
 type ImportError struct {
-	Row   int    `json:"row"`
-	Error string `json:"error"`
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Error  string `json:"error"`
 }
 
 // RecordSale records a single manual sale
 func (h *Handler) RecordSale(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
 	var req RecordSaleRequest
-	if err := parseJSON(r, &req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid request body", ""), r)
 		return
 	}
 
 	// Validate input
 	if req.ProductID == "" {
-		respondError(w, http.StatusBadRequest, "Product ID is required")
+		h.respondError(w, errors.NewValidationError("Product ID is required", ""), r)
 		return
 	}
 	if req.Quantity <= 0 {
-		respondError(w, http.StatusBadRequest, "Quantity must be greater than 0")
+		h.respondError(w, errors.NewValidationError("Quantity must be greater than 0", ""), r)
 		return
 	}
 	if req.Price < 0 {
-		respondError(w, http.StatusBadRequest, "Price cannot be negative")
+		h.respondError(w, errors.NewValidationError("Price cannot be negative", ""), r)
 		return
 	}
 	if req.SaleDate.IsZero() {
 		req.SaleDate = time.Now()
 	}
+	req.SaleDate = localDate(req.SaleDate, h.companyLocation(r.Context(), storeID))
 
 	// Verify product belongs to store
 	var productExists bool
@@ -69,182 +76,115 @@ func (h *Handler) RecordSale(w http.ResponseWriter, r *http.Request) {
 		SELECT EXISTS(SELECT 1 FROM products WHERE id = $1 AND store_id = $2)
 	`, req.ProductID, storeID).Scan(&productExists)
 	if err != nil || !productExists {
-		respondError(w, http.StatusBadRequest, "Product not found")
+		h.respondError(w, errors.NewValidationError("Product not found", ""), r)
 		return
 	}
 
-	// Insert sale record
+	// Normalize quantity and price to the product's unit
+	productUnit, customUnits, err := h.loadProductUnits(r.Context(), h.db.Pool(), req.ProductID)
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to load product units"), r)
+		return
+	}
+	sale, err := convertSale(productUnit, customUnits, req.Quantity, req.Price, req.Unit)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid quantity", err.Error()), r)
+		return
+	}
+
+	// Insert sale record and take it out of stock
+	tx, err := h.db.Pool().Begin(r.Context())
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(r.Context())
+
 	var saleID int64
-	err = h.db.Pool().QueryRow(r.Context(), `
-		INSERT INTO sales_history (store_id, product_id, quantity, price, sale_date, source, created_at)
-		VALUES ($1, $2, $3, $4, $5, 'manual', $6)
+	err = tx.QueryRow(r.Context(), `
+		INSERT INTO sales_history (store_id, product_id, quantity, price, sale_date, source, unit, unit_quantity, created_at)
+		VALUES ($1, $2, $3, $4, $5, 'manual', $6, $7, $8)
 		RETURNING id
-	`, storeID, req.ProductID, req.Quantity, req.Price, req.SaleDate, time.Now()).Scan(&saleID)
+	`, storeID, req.ProductID, sale.Quantity, sale.Price, req.SaleDate, sale.Unit, sale.UnitQuantity, time.Now()).Scan(&saleID)
 
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to record sale")
+		h.respondError(w, errors.NewInternalError(err, "Failed to record sale"), r)
+		return
+	}
+	if err := saleStock(r.Context(), tx, storeID, req.ProductID, &saleID, sale.Quantity, ""); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update stock"), r)
+		return
+	}
+	if err := tx.Commit(r.Context()); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
 		return
 	}
 
 	// Invalidate forecast cache for this product
 	cacheKey := fmt.Sprintf("forecast:%s", req.ProductID)
 	h.redis.Delete(r.Context(), cacheKey)
+	h.alertLowStock(r.Context(), storeID, req.ProductID)
 
-	respondJSON(w, http.StatusCreated, models.Sale{
+	h.respondJSON(w, r, http.StatusCreated, models.Sale{
 		ID:        saleID,
 		StoreID:   storeID,
 		ProductID: req.ProductID,
-		Quantity:  req.Quantity,
-		Price:     req.Price,
+		Quantity:  sale.Quantity,
+		Unit:      productUnit.Code,
+		Price:     sale.Price,
 		SaleDate:  req.SaleDate,
 		Source:    "manual",
 		CreatedAt: time.Now(),
 	})
 }
 
-// ImportCSV handles bulk CSV sales import
+// ImportCSV imports sales from a CSV or XLSX file laid out like Bantuaku's
+// template, or in any layout given a "mapping" of fields to columns (or the
+// saved mapping named by "source"). Rows naming products missing from the
+// catalog are rejected. With dry_run=true nothing is saved and the report shows
+// what would be imported, so the spreadsheet can be fixed before committing.
 func (h *Handler) ImportCSV(w http.ResponseWriter, r *http.Request) {
-	storeID := middleware.GetStoreID(r.Context())
-	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
 		return
 	}
-
-	// Parse multipart form (max 10MB)
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to parse form data")
+	if err := r.ParseMultipartForm(maxFileSize); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid multipart form", err.Error()), r)
 		return
 	}
 
-	file, _, err := r.FormFile("file")
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "File is required")
+	name := importer.TemplateAdapterName
+	if r.FormValue("mapping") != "" || r.FormValue("source") != "" {
+		name = importer.CustomAdapterName
+	}
+	adapter, parsed, ok := h.parseImportUpload(w, r, name)
+	if !ok {
 		return
 	}
-	defer file.Close()
-
-	// Parse CSV
-	reader := csv.NewReader(file)
 
-	// Read header
-	header, err := reader.Read()
+	imported, err := h.importRows(r.Context(), companyID, adapter, parsed, importOptions{source: "csv", dryRun: isDryRun(r)})
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Failed to read CSV header")
+		h.respondError(w, err, r)
 		return
 	}
-
-	// Map column indices
-	colMap := make(map[string]int)
-	for i, col := range header {
-		colMap[col] = i
-	}
-
-	// Verify required columns
-	requiredCols := []string{"product_name", "quantity", "sale_date"}
-	for _, col := range requiredCols {
-		if _, exists := colMap[col]; !exists {
-			respondError(w, http.StatusBadRequest, fmt.Sprintf("Missing required column: %s", col))
-			return
-		}
+	result := ImportResult{
+		SuccessCount: imported.SalesImported,
+		DryRun:       imported.DryRun,
+		Mapping:      imported.Mapping,
+		Errors:       make([]ImportError, 0, len(imported.Errors)),
 	}
-
-	// Build product name to ID mapping
-	productMap := make(map[string]string)
-	rows, err := h.db.Pool().Query(r.Context(), `
-		SELECT id, product_name FROM products WHERE store_id = $1
-	`, storeID)
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var id, name string
-			if rows.Scan(&id, &name) == nil {
-				productMap[name] = id
-			}
-		}
+	for _, e := range imported.Errors {
+		result.Errors = append(result.Errors, ImportError{Row: e.Line, Column: e.Column, Value: e.Value, Error: e.Error})
 	}
-
-	result := ImportResult{}
-	rowNum := 1 // Header is row 0
-
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		rowNum++
-
-		if err != nil {
-			result.Errors = append(result.Errors, ImportError{Row: rowNum, Error: "Failed to parse row"})
-			continue
-		}
-
-		// Extract values
-		productName := record[colMap["product_name"]]
-		quantityStr := record[colMap["quantity"]]
-		saleDateStr := record[colMap["sale_date"]]
-
-		priceStr := "0"
-		if idx, exists := colMap["price"]; exists && idx < len(record) {
-			priceStr = record[idx]
-		}
-
-		// Find product ID
-		productID, exists := productMap[productName]
-		if !exists {
-			result.Errors = append(result.Errors, ImportError{Row: rowNum, Error: fmt.Sprintf("Product not found: %s", productName)})
-			continue
-		}
-
-		// Parse quantity
-		quantity, err := strconv.Atoi(quantityStr)
-		if err != nil || quantity <= 0 {
-			result.Errors = append(result.Errors, ImportError{Row: rowNum, Error: "Invalid quantity"})
-			continue
-		}
-
-		// Parse price
-		price, _ := strconv.ParseFloat(priceStr, 64)
-
-		// Parse date (try multiple formats)
-		var saleDate time.Time
-		dateFormats := []string{"2006-01-02", "02/01/2006", "01/02/2006", "2006/01/02"}
-		for _, format := range dateFormats {
-			if parsed, err := time.Parse(format, saleDateStr); err == nil {
-				saleDate = parsed
-				break
-			}
-		}
-		if saleDate.IsZero() {
-			result.Errors = append(result.Errors, ImportError{Row: rowNum, Error: "Invalid date format"})
-			continue
-		}
-
-		// Insert sale
-		_, err = h.db.Pool().Exec(r.Context(), `
-			INSERT INTO sales_history (store_id, product_id, quantity, price, sale_date, source, created_at)
-			VALUES ($1, $2, $3, $4, $5, 'csv', $6)
-		`, storeID, productID, quantity, price, saleDate, time.Now())
-
-		if err != nil {
-			result.Errors = append(result.Errors, ImportError{Row: rowNum, Error: "Database error"})
-			continue
-		}
-
-		result.SuccessCount++
-
-		// Invalidate forecast cache for this product
-		cacheKey := fmt.Sprintf("forecast:%s", productID)
-		h.redis.Delete(r.Context(), cacheKey)
-	}
-
-	respondJSON(w, http.StatusOK, result)
+	h.respondJSON(w, r, http.StatusOK, result)
 }
 
 // ListSales returns sales history for the store
 func (h *Handler) ListSales(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
@@ -276,7 +216,7 @@ func (h *Handler) ListSales(w http.ResponseWriter, r *http.Request) {
 
 	rows, err := h.db.Pool().Query(r.Context(), query, args...)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to fetch sales")
+		h.respondError(w, errors.NewInternalError(err, "Failed to fetch sales"), r)
 		return
 	}
 	defer rows.Close()
@@ -291,5 +231,5 @@ func (h *Handler) ListSales(w http.ResponseWriter, r *http.Request) {
 		sales = append(sales, s)
 	}
 
-	respondJSON(w, http.StatusOK, sales)
+	h.respondJSON(w, r, http.StatusOK, sales)
 }
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/salesbulk"
+)
+
+// BulkSalesRequest selects sales records by filter and the action to apply.
+// ExpectedCount, taken from the preview, is required to apply the action.
+type BulkSalesRequest struct {
+	Action        string           `json:"action"`
+	Filter        salesbulk.Filter `json:"filter"`
+	Params        salesbulk.Params `json:"params"`
+	ExpectedCount *int             `json:"expected_count,omitempty"`
+}
+
+// BulkSalesPreview describes the records a bulk sales action would change
+type BulkSalesPreview struct {
+	Action   string  `json:"action"`
+	Matched  int     `json:"matched"` // Records the action would change
+	Products int     `json:"products"`
+	Quantity float64 `json:"quantity"`
+	From     string  `json:"from,omitempty"` // Earliest sale date
+	To       string  `json:"to,omitempty"`   // Latest sale date
+	Batches  int     `json:"batches"`
+}
+
+// PreviewBulkSales counts the sales records a bulk action would change, without
+// changing them
+func (h *Handler) PreviewBulkSales(w http.ResponseWriter, r *http.Request) {
+	companyID, req, ok := h.parseBulkSalesRequest(w, r)
+	if !ok {
+		return
+	}
+	preview, err := h.previewBulkSales(r.Context(), companyID, req)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "preview bulk sales change"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, preview)
+}
+
+// ApplyBulkSales applies a bulk action to the selected sales records in
+// batches, recording each change in the record's change trail. It refuses to
+// run when the selection no longer matches the previewed count.
+func (h *Handler) ApplyBulkSales(w http.ResponseWriter, r *http.Request) {
+	companyID, req, ok := h.parseBulkSalesRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.ExpectedCount == nil {
+		h.respondError(w, errors.NewValidationError("Validation failed", "expected_count: required; preview the change first"), r)
+		return
+	}
+
+	ctx := r.Context()
+	preview, err := h.previewBulkSales(ctx, companyID, req)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "preview bulk sales change"), r)
+		return
+	}
+	if preview.Matched != *req.ExpectedCount {
+		h.respondError(w, errors.NewConflictError("The selection changed since the preview",
+			fmt.Sprintf("%d records now match, %d expected; preview again", preview.Matched, *req.ExpectedCount)), r)
+		return
+	}
+	if preview.Matched > salesbulk.MaxRecords {
+		h.respondError(w, errors.NewValidationError("Too many records",
+			fmt.Sprintf("the filter matches more than %d records; narrow it down", salesbulk.MaxRecords)), r)
+		return
+	}
+
+	where, args := bulkSalesWhere(companyID, req)
+	userID := middleware.GetUserID(ctx)
+	products := map[string]bool{}
+	updated, batches := 0, 0
+	var lastID int64
+	for {
+		n, last, err := h.applyBulkSalesBatch(ctx, companyID, userID, req, where, args, lastID, products)
+		if err != nil {
+			logger.Error("Bulk sales change failed", "company_id", companyID, "action", req.Action, "updated", updated, "error", err.Error())
+			h.dropForecastCache(ctx, mapKeys(products)...)
+			h.respondError(w, errors.NewDatabaseError(err, fmt.Sprintf("apply bulk sales change (%d records were already changed)", updated)), r)
+			return
+		}
+		if n == 0 {
+			break
+		}
+		updated += n
+		batches++
+		lastID = last
+		if n < salesbulk.BatchSize {
+			break
+		}
+	}
+
+	h.dropForecastCache(ctx, mapKeys(products)...)
+
+	logger.Info("Bulk sales change applied", "company_id", companyID, "action", req.Action, "updated", updated, "batches", batches)
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"action":   req.Action,
+		"updated":  updated,
+		"batches":  batches,
+		"products": len(products),
+	})
+}
+
+// parseBulkSalesRequest reads and validates a bulk sales request, responding
+// with the error when it is invalid
+func (h *Handler) parseBulkSalesRequest(w http.ResponseWriter, r *http.Request) (string, BulkSalesRequest, bool) {
+	var req BulkSalesRequest
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return "", req, false
+	}
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return "", req, false
+	}
+	req.Params.Channel = strings.TrimSpace(req.Params.Channel)
+	if req.Filter.IsEmpty() {
+		h.respondError(w, errors.NewValidationError("Invalid filter", "the filter must set at least one field"), r)
+		return "", req, false
+	}
+	if err := req.Filter.Validate(); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid filter", err.Error()), r)
+		return "", req, false
+	}
+	if err := salesbulk.Validate(req.Action, req.Params, req.Filter); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid request", err.Error()), r)
+		return "", req, false
+	}
+
+	if req.Action == salesbulk.ActionRemapProduct {
+		ctx := r.Context()
+		if !h.productBelongsToCompany(ctx, req.Params.ProductID, companyID) {
+			h.respondError(w, errors.NewNotFoundError("Product"), r)
+			return "", req, false
+		}
+		// Quantities are stored in the product's unit, so they only carry over
+		// to a product with the same unit
+		from, _, err := h.loadProductUnits(ctx, h.db.Pool(), req.Filter.ProductID)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "load product units"), r)
+			return "", req, false
+		}
+		to, _, err := h.loadProductUnits(ctx, h.db.Pool(), req.Params.ProductID)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "load product units"), r)
+			return "", req, false
+		}
+		if from.Code != to.Code {
+			h.respondError(w, errors.NewValidationError("Invalid request",
+				fmt.Sprintf("products use different units (%s and %s)", from.Code, to.Code)), r)
+			return "", req, false
+		}
+	}
+	return companyID, req, true
+}
+
+// previewBulkSales summarizes the records req would change
+func (h *Handler) previewBulkSales(ctx context.Context, companyID string, req BulkSalesRequest) (BulkSalesPreview, error) {
+	where, args := bulkSalesWhere(companyID, req)
+	p := BulkSalesPreview{Action: req.Action}
+	var from, to *time.Time
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(DISTINCT product_id), COALESCE(SUM(quantity), 0)::float8, MIN(sale_date), MAX(sale_date)
+		FROM sales_history
+		WHERE `+where, args...).Scan(&p.Matched, &p.Products, &p.Quantity, &from, &to)
+	if err != nil {
+		return p, err
+	}
+	if from != nil && to != nil {
+		p.From, p.To = from.Format("2006-01-02"), to.Format("2006-01-02")
+	}
+	p.Batches = (p.Matched + salesbulk.BatchSize - 1) / salesbulk.BatchSize
+	return p, nil
+}
+
+// applyBulkSalesBatch changes the next batch of matching records after lastID
+// in one transaction, returning how many were changed and the last ID
+func (h *Handler) applyBulkSalesBatch(ctx context.Context, companyID, userID string, req BulkSalesRequest, where string, args []interface{}, lastID int64, products map[string]bool) (int, int64, error) {
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return 0, lastID, err
+	}
+	defer tx.Rollback(ctx)
+
+	batchArgs := append(append([]interface{}{}, args...), lastID, salesbulk.BatchSize)
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		SELECT id, `+saleRecordColumns+`
+		FROM sales_history
+		WHERE %s AND id > $%d
+		ORDER BY id
+		LIMIT $%d
+		FOR UPDATE
+	`, where, len(batchArgs)-1, len(batchArgs)), batchArgs...)
+	if err != nil {
+		return 0, lastID, err
+	}
+	type change struct {
+		id     int64
+		before saleRecord
+	}
+	var batch []change
+	for rows.Next() {
+		var c change
+		if c.before, err = scanSaleRecord(rows, &c.id); err != nil {
+			rows.Close()
+			return 0, lastID, err
+		}
+		batch = append(batch, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, lastID, err
+	}
+
+	for _, c := range batch {
+		after := c.before
+		switch req.Action {
+		case salesbulk.ActionAssignChannel:
+			after.Channel = req.Params.Channel
+		case salesbulk.ActionRemapProduct:
+			after.ProductID = req.Params.ProductID
+		case salesbulk.ActionShiftDates:
+			after.SaleDate = c.before.SaleDate.AddDate(0, 0, req.Params.Days)
+		}
+		_, err := tx.Exec(ctx, `
+			UPDATE sales_history SET product_id = $3, sale_date = $4, channel = NULLIF($5, '')
+			WHERE id = $1 AND company_id = $2
+		`, c.id, companyID, after.ProductID, after.SaleDate, after.Channel)
+		if err != nil {
+			return 0, lastID, err
+		}
+		if err := recordSaleChange(ctx, tx, c.id, companyID, userID, "update", c.before, &after); err != nil {
+			return 0, lastID, err
+		}
+		products[c.before.ProductID] = true
+		products[after.ProductID] = true
+		lastID = c.id
+	}
+	return len(batch), lastID, tx.Commit(ctx)
+}
+
+// bulkSalesWhere builds the condition selecting the company's records that
+// match req's filter and that its action would actually change
+func bulkSalesWhere(companyID string, req BulkSalesRequest) (string, []interface{}) {
+	f := req.Filter
+	args := []interface{}{companyID}
+	conditions := []string{"company_id = $1"}
+	add := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+	if f.ProductID != "" {
+		add("product_id = $%d", f.ProductID)
+	}
+	if f.Source != "" {
+		add("source = $%d", f.Source)
+	}
+	if f.Channel != "" {
+		add("channel = $%d", f.Channel)
+	}
+	if f.WithoutChannel {
+		conditions = append(conditions, "COALESCE(channel, '') = ''")
+	}
+	if f.From != "" {
+		add("sale_date >= $%d::date", f.From)
+	}
+	if f.To != "" {
+		add("sale_date <= $%d::date", f.To)
+	}
+	if req.Action == salesbulk.ActionAssignChannel {
+		add("COALESCE(channel, '') <> $%d", req.Params.Channel)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// mapKeys returns the keys of a set
+func mapKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
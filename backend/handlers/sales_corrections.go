@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpdateSaleRequest replaces a sales record. An empty product ID or sale date
+// keeps the record's current one.
+type UpdateSaleRequest struct {
+	ProductID string    `json:"product_id"`
+	Quantity  float64   `json:"quantity"`
+	Unit      string    `json:"unit,omitempty"` // Defaults to the product's unit
+	Price     float64   `json:"price"`          // Per entered unit
+	SaleDate  time.Time `json:"sale_date"`
+}
+
+// saleRecord is the state of a sales record kept in its change trail
+type saleRecord struct {
+	ProductID    string    `json:"product_id"`
+	Quantity     float64   `json:"quantity"`
+	Unit         string    `json:"unit,omitempty"`
+	UnitQuantity float64   `json:"unit_quantity"`
+	Price        float64   `json:"price"`
+	SaleDate     time.Time `json:"sale_date"`
+	Source       string    `json:"source"`
+	Channel      string    `json:"channel,omitempty"`
+}
+
+// saleRecordColumns selects a saleRecord from sales_history
+const saleRecordColumns = `product_id, quantity, COALESCE(unit, ''), COALESCE(unit_quantity, quantity),
+	COALESCE(price, 0), sale_date, source, COALESCE(channel, '')`
+
+// scanSaleRecord scans the columns of saleRecordColumns, after any columns in dest
+func scanSaleRecord(row pgx.Row, dest ...any) (saleRecord, error) {
+	var s saleRecord
+	dest = append(dest, &s.ProductID, &s.Quantity, &s.Unit, &s.UnitQuantity, &s.Price, &s.SaleDate, &s.Source, &s.Channel)
+	return s, row.Scan(dest...)
+}
+
+// UpdateSale corrects a sales record of the company, recording the change and
+// dropping the cached forecasts of the products involved
+func (h *Handler) UpdateSale(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	saleID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid sale ID", r.PathValue("id")), r)
+		return
+	}
+
+	var req UpdateSaleRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if req.Quantity <= 0 {
+		h.respondError(w, errors.NewValidationError("Validation failed", "quantity: must be greater than 0"), r)
+		return
+	}
+	if req.Price < 0 {
+		h.respondError(w, errors.NewValidationError("Validation failed", "price: cannot be negative"), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockSale(ctx, tx, saleID, companyID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Sale"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sale"), r)
+		return
+	}
+
+	after := saleRecord{ProductID: before.ProductID, SaleDate: before.SaleDate, Source: before.Source, Channel: before.Channel}
+	if req.ProductID != "" && req.ProductID != before.ProductID {
+		if !h.productBelongsToCompany(ctx, req.ProductID, companyID) {
+			h.respondError(w, errors.NewNotFoundError("Product"), r)
+			return
+		}
+		after.ProductID = req.ProductID
+	}
+	if !req.SaleDate.IsZero() {
+		after.SaleDate = localDate(req.SaleDate, h.companyLocation(ctx, companyID))
+	}
+
+	productUnit, customUnits, err := h.loadProductUnits(ctx, tx, after.ProductID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load product units"), r)
+		return
+	}
+	sale, err := convertSale(productUnit, customUnits, req.Quantity, req.Price, req.Unit)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid quantity", err.Error()), r)
+		return
+	}
+	after.Quantity, after.Price, after.Unit, after.UnitQuantity = sale.Quantity, sale.Price, sale.Unit, sale.UnitQuantity
+
+	_, err = tx.Exec(ctx, `
+		UPDATE sales_history SET product_id = $3, quantity = $4, price = $5, sale_date = $6, unit = $7, unit_quantity = $8
+		WHERE id = $1 AND company_id = $2
+	`, saleID, companyID, after.ProductID, after.Quantity, after.Price, after.SaleDate, after.Unit, after.UnitQuantity)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update sale"), r)
+		return
+	}
+	// Put the old quantity back and take the new one out
+	if after.ProductID != before.ProductID || after.Quantity != before.Quantity {
+		note := fmt.Sprintf("Penjualan #%d dikoreksi", saleID)
+		if err := saleStock(ctx, tx, companyID, before.ProductID, &saleID, -before.Quantity, note); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "update stock"), r)
+			return
+		}
+		if err := saleStock(ctx, tx, companyID, after.ProductID, &saleID, after.Quantity, note); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "update stock"), r)
+			return
+		}
+	}
+	if err := recordSaleChange(ctx, tx, saleID, companyID, middleware.GetUserID(ctx), "update", before, &after); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "record sale change"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	// Forecast inputs changed, for the old product too when the sale moved
+	h.dropForecastCache(ctx, before.ProductID, after.ProductID)
+	h.alertLowStock(ctx, companyID, after.ProductID)
+
+	logger.Info("Sale updated", "company_id", companyID, "sale_id", saleID)
+
+	h.respondJSON(w, r, http.StatusOK, models.Sale{
+		ID:        saleID,
+		StoreID:   companyID,
+		ProductID: after.ProductID,
+		Quantity:  after.Quantity,
+		Unit:      productUnit.Code,
+		Price:     after.Price,
+		SaleDate:  after.SaleDate,
+		Source:    after.Source,
+	})
+}
+
+// DeleteSale removes a sales record of the company, keeping its last state in
+// the change trail
+func (h *Handler) DeleteSale(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	companyID := middleware.GetCompanyID(ctx)
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	saleID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid sale ID", r.PathValue("id")), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := lockSale(ctx, tx, saleID, companyID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Sale"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sale"), r)
+		return
+	}
+	// The movement outlives the sale, so it names the sale instead of linking it
+	if err := saleStock(ctx, tx, companyID, before.ProductID, nil, -before.Quantity, fmt.Sprintf("Penjualan #%d dihapus", saleID)); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update stock"), r)
+		return
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM sales_history WHERE id = $1 AND company_id = $2`, saleID, companyID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete sale"), r)
+		return
+	}
+	if err := recordSaleChange(ctx, tx, saleID, companyID, middleware.GetUserID(ctx), "delete", before, nil); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "record sale change"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.dropForecastCache(ctx, before.ProductID)
+
+	logger.Info("Sale deleted", "company_id", companyID, "sale_id", saleID)
+
+	h.respondJSON(w, r, http.StatusOK, map[string]string{"message": "Sale deleted"})
+}
+
+// ListSaleChanges returns the change trail of a sales record, newest first.
+// It stays available after the record is deleted.
+func (h *Handler) ListSaleChanges(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	saleID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid sale ID", r.PathValue("id")), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT id, sale_id, action, before, after, COALESCE(changed_by, ''), created_at
+		FROM sales_history_changes
+		WHERE company_id = $1 AND sale_id = $2
+		ORDER BY created_at DESC, id DESC
+	`, companyID, saleID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sale changes"), r)
+		return
+	}
+	defer rows.Close()
+
+	changes := []models.SaleChange{}
+	for rows.Next() {
+		var c models.SaleChange
+		if err := rows.Scan(&c.ID, &c.SaleID, &c.Action, &c.Before, &c.After, &c.ChangedBy, &c.CreatedAt); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan sale change"), r)
+			return
+		}
+		changes = append(changes, c)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, changes)
+}
+
+// lockSale loads a sales record of the company for update
+func lockSale(ctx context.Context, tx pgx.Tx, saleID int64, companyID string) (saleRecord, error) {
+	return scanSaleRecord(tx.QueryRow(ctx, `
+		SELECT `+saleRecordColumns+`
+		FROM sales_history
+		WHERE id = $1 AND company_id = $2
+		FOR UPDATE
+	`, saleID, companyID))
+}
+
+// recordSaleChange adds an entry to a sales record's change trail; after is nil
+// for deletions
+func recordSaleChange(ctx context.Context, tx pgx.Tx, saleID int64, companyID, userID, action string, before saleRecord, after *saleRecord) error {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	var afterJSON []byte
+	if after != nil {
+		if afterJSON, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO sales_history_changes (sale_id, company_id, action, before, after, changed_by)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+	`, saleID, companyID, action, beforeJSON, afterJSON, userID)
+	return err
+}
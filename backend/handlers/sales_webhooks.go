@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/importer"
+	"github.com/bantuaku/backend/services/units"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	salesWebhookType      = "pos_webhook"
+	salesWebhookSignature = "X-Bantuaku-Signature"
+	salesWebhookTimestamp = "X-Bantuaku-Timestamp" // Unix seconds, part of the signed content
+	maxWebhookBodySize    = 1 << 20                // 1MB
+	maxWebhookItems       = 500
+	// webhookTolerance is how far a delivery's timestamp may be from now;
+	// replays inside it are caught by the external_id dedupe
+	webhookTolerance = 5 * time.Minute
+)
+
+// CreateSalesWebhookRequest represents a request to register a POS/QRIS webhook source
+type CreateSalesWebhookRequest struct {
+	Provider string `json:"provider" validate:"required,max:100"`
+}
+
+// SalesWebhookReceipt is returned to the provider after a delivery
+type SalesWebhookReceipt struct {
+	ExternalID    string `json:"external_id"`
+	Status        string `json:"status"`
+	ItemsIngested int    `json:"items_ingested"`
+}
+
+// CreateSalesWebhook registers a new inbound sales webhook for the company.
+// The signing secret is only returned in this response.
+func (h *Handler) CreateSalesWebhook(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req CreateSalesWebhookRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to generate webhook secret"), r)
+		return
+	}
+
+	source := models.SalesWebhookSource{
+		ID:        uuid.New().String(),
+		Provider:  strings.ToLower(strings.TrimSpace(req.Provider)),
+		Status:    "active",
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+	source.URL = salesWebhookURL(source.ID)
+
+	_, err = h.db.Pool().Exec(r.Context(), `
+		INSERT INTO data_sources (id, company_id, type, provider, status, webhook_secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+	`, source.ID, companyID, salesWebhookType, source.Provider, source.Status, secret, source.CreatedAt)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create webhook source"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusCreated, source)
+}
+
+// ListSalesWebhooks returns the company's webhook sources with ingestion stats
+func (h *Handler) ListSalesWebhooks(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(r.Context(), `
+		SELECT ds.id, ds.provider, ds.status, ds.created_at,
+			COUNT(e.id) FILTER (WHERE e.status = 'accepted'),
+			COUNT(e.id) FILTER (WHERE e.status = 'duplicate'),
+			COUNT(e.id) FILTER (WHERE e.status = 'rejected'),
+			COALESCE(SUM(e.item_count) FILTER (WHERE e.status = 'accepted'), 0),
+			MAX(e.received_at)
+		FROM data_sources ds
+		LEFT JOIN sales_webhook_events e ON e.data_source_id = ds.id
+		WHERE ds.company_id = $1 AND ds.type = $2
+		GROUP BY ds.id
+		ORDER BY ds.created_at DESC
+	`, companyID, salesWebhookType)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list webhook sources"), r)
+		return
+	}
+	defer rows.Close()
+
+	sources := []models.SalesWebhookSource{}
+	for rows.Next() {
+		var s models.SalesWebhookSource
+		if err := rows.Scan(&s.ID, &s.Provider, &s.Status, &s.CreatedAt,
+			&s.Stats.Accepted, &s.Stats.Duplicates, &s.Stats.Rejected, &s.Stats.ItemsIngested,
+			&s.Stats.LastReceivedAt); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "scan webhook source"), r)
+			return
+		}
+		s.URL = salesWebhookURL(s.ID)
+		sources = append(sources, s)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, sources)
+}
+
+// RotateSalesWebhookSecret issues a new signing secret for a webhook source
+func (h *Handler) RotateSalesWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to generate webhook secret"), r)
+		return
+	}
+
+	var source models.SalesWebhookSource
+	err = h.db.Pool().QueryRow(r.Context(), `
+		UPDATE data_sources SET webhook_secret = $3, updated_at = NOW()
+		WHERE id = $1 AND company_id = $2 AND type = $4
+		RETURNING id, provider, status, created_at
+	`, r.PathValue("id"), companyID, secret, salesWebhookType).Scan(&source.ID, &source.Provider, &source.Status, &source.CreatedAt)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Webhook source"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "rotate webhook secret"), r)
+		return
+	}
+	source.Secret = secret
+	source.URL = salesWebhookURL(source.ID)
+
+	h.respondJSON(w, r, http.StatusOK, source)
+}
+
+// ReceiveSalesWebhook ingests a transaction pushed by a POS/QRIS provider.
+// Requests are authenticated with an HMAC-SHA256 signature of the timestamp
+// header, a ".", and the raw body; deliveries older than webhookTolerance are
+// refused so a captured request cannot be replayed later.
+func (h *Handler) ReceiveSalesWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sourceID := r.PathValue("id")
+
+	var companyID, provider, status, secret string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT company_id, provider, status, COALESCE(webhook_secret, '')
+		FROM data_sources WHERE id = $1 AND type = $2
+	`, sourceID, salesWebhookType).Scan(&companyID, &provider, &status, &secret)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Webhook source"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load webhook source"), r)
+		return
+	}
+	if status != "active" {
+		h.respondError(w, errors.NewForbiddenError("Webhook source is not active"), r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize+1))
+	if err != nil || len(body) > maxWebhookBodySize {
+		h.respondError(w, errors.NewValidationError("Invalid request body", "body must be at most 1MB"), r)
+		return
+	}
+	timestamp := r.Header.Get(salesWebhookTimestamp)
+	if secret == "" || !validWebhookSignature(secret, timestamp, body, r.Header.Get(salesWebhookSignature)) {
+		h.respondError(w, errors.NewUnauthorizedError("Invalid webhook signature"), r)
+		return
+	}
+	if !freshWebhookTimestamp(timestamp, time.Now()) {
+		h.respondError(w, errors.NewUnauthorizedError("Webhook timestamp is missing or too old"), r)
+		return
+	}
+
+	var payload models.SalesWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		h.recordWebhookEvent(ctx, sourceID, "", models.WebhookEventRejected, 0, "invalid JSON")
+		h.respondError(w, errors.NewValidationError("Invalid JSON format", err.Error()), r)
+		return
+	}
+	if err := validateWebhookPayload(&payload); err != nil {
+		h.recordWebhookEvent(ctx, sourceID, payload.ExternalID, models.WebhookEventRejected, len(payload.Items), err.Error())
+		h.respondError(w, err, r)
+		return
+	}
+	if payload.OccurredAt.IsZero() {
+		payload.OccurredAt = time.Now()
+	}
+	saleDate := localDate(payload.OccurredAt, h.companyLocation(ctx, companyID))
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// The partial unique index on accepted events makes this the dedupe point
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO sales_webhook_events (data_source_id, external_id, status, item_count)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (data_source_id, external_id) WHERE status = 'accepted' DO NOTHING
+	`, sourceID, payload.ExternalID, models.WebhookEventAccepted, len(payload.Items))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "record webhook event"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		tx.Rollback(ctx)
+		h.recordWebhookEvent(ctx, sourceID, payload.ExternalID, models.WebhookEventDuplicate, len(payload.Items), "")
+		h.respondJSON(w, r, http.StatusOK, SalesWebhookReceipt{ExternalID: payload.ExternalID, Status: models.WebhookEventDuplicate})
+		return
+	}
+
+	products, err := h.loadImportProducts(ctx, tx, companyID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load products"), r)
+		return
+	}
+
+	touched := map[string]bool{}
+	unitCache := map[string]importProductUnits{}
+	for i, item := range payload.Items {
+		row := importer.Row{ProductName: item.ProductName, SKU: item.SKU}
+		productID := products.match(row)
+		if productID == "" {
+			productUnit := units.DefaultUnit
+			if _, ok := units.Get(item.Unit); ok {
+				productUnit = item.Unit
+			}
+			productID = uuid.New().String()
+			_, err = tx.Exec(ctx, `
+				INSERT INTO products (id, company_id, name, sku, unit_price, unit, created_at, updated_at)
+				VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, NOW(), NOW())
+			`, productID, companyID, item.ProductName, item.SKU, item.UnitPrice, productUnit)
+			if err != nil {
+				h.respondError(w, errors.NewDatabaseError(err, "create product"), r)
+				return
+			}
+			products.add(productID, item.ProductName, item.SKU)
+		}
+
+		pu, err := h.cachedProductUnits(ctx, tx, unitCache, productID)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "load product units"), r)
+			return
+		}
+		sale, err := convertSale(pu.unit, pu.custom, item.Quantity, item.UnitPrice, item.Unit)
+		if err != nil {
+			tx.Rollback(ctx)
+			reason := fmt.Sprintf("items[%d]: %s", i, err.Error())
+			h.recordWebhookEvent(ctx, sourceID, payload.ExternalID, models.WebhookEventRejected, len(payload.Items), reason)
+			h.respondError(w, errors.NewValidationError("Validation failed", reason), r)
+			return
+		}
+
+		var saleID int64
+		err = tx.QueryRow(ctx, `
+			INSERT INTO sales_history (company_id, product_id, quantity, price, sale_date, source, data_source_id, external_id,
+				unit, unit_quantity, created_at)
+			VALUES ($1, $2, $3, $4, $5, 'webhook', $6, $7, $8, $9, NOW())
+			RETURNING id
+		`, companyID, productID, sale.Quantity, sale.Price, saleDate, sourceID, payload.ExternalID, sale.Unit, sale.UnitQuantity).Scan(&saleID)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "insert sale"), r)
+			return
+		}
+		if err := saleStock(ctx, tx, companyID, productID, &saleID, sale.Quantity, ""); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "update stock"), r)
+			return
+		}
+		touched[productID] = true
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	// Invalidate forecast cache for products that received new sales
+	for productID := range touched {
+		h.redis.Delete(ctx, fmt.Sprintf("forecast:%s", productID))
+	}
+	h.alertLowStock(ctx, companyID, mapKeys(touched)...)
+
+	logger.Info("Sales webhook ingested", "company_id", companyID, "source_id", sourceID,
+		"provider", provider, "external_id", payload.ExternalID, "items", len(payload.Items))
+
+	h.respondJSON(w, r, http.StatusOK, SalesWebhookReceipt{
+		ExternalID:    payload.ExternalID,
+		Status:        models.WebhookEventAccepted,
+		ItemsIngested: len(payload.Items),
+	})
+}
+
+// recordWebhookEvent logs a non-accepted delivery for ingestion stats
+func (h *Handler) recordWebhookEvent(ctx context.Context, sourceID, externalID, status string, itemCount int, reason string) {
+	_, err := h.db.Pool().Exec(ctx, `
+		INSERT INTO sales_webhook_events (data_source_id, external_id, status, item_count, error)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''))
+	`, sourceID, externalID, status, itemCount, reason)
+	if err != nil {
+		logger.Warn("Failed to record webhook event", "source_id", sourceID, "status", status, "error", err.Error())
+	}
+}
+
+func validateWebhookPayload(p *models.SalesWebhookPayload) error {
+	if err := validation.Validate(p); err != nil {
+		return err
+	}
+	if len(p.Items) == 0 || len(p.Items) > maxWebhookItems {
+		return errors.NewValidationError("Validation failed", fmt.Sprintf("items: must contain between 1 and %d entries", maxWebhookItems))
+	}
+	for i, item := range p.Items {
+		switch {
+		case strings.TrimSpace(item.ProductName) == "" && strings.TrimSpace(item.SKU) == "":
+			return errors.NewValidationError("Validation failed", fmt.Sprintf("items[%d]: product_name or sku is required", i))
+		case item.Quantity <= 0:
+			return errors.NewValidationError("Validation failed", fmt.Sprintf("items[%d].quantity: must be greater than 0", i))
+		case item.UnitPrice < 0:
+			return errors.NewValidationError("Validation failed", fmt.Sprintf("items[%d].unit_price: must not be negative", i))
+		}
+		if strings.TrimSpace(item.ProductName) == "" {
+			p.Items[i].ProductName = item.SKU
+		}
+	}
+	return nil
+}
+
+// validWebhookSignature checks a hex HMAC-SHA256 signature of timestamp + "." +
+// body, optionally prefixed with "sha256="
+func validWebhookSignature(secret, timestamp string, body []byte, signature string) bool {
+	got, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if err != nil || len(got) == 0 || timestamp == "" {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// freshWebhookTimestamp reports whether a Unix-seconds timestamp is within
+// webhookTolerance of now, in either direction to allow for clock skew
+func freshWebhookTimestamp(timestamp string, now time.Time) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(sec, 0))
+	return age <= webhookTolerance && age >= -webhookTolerance
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func salesWebhookURL(sourceID string) string {
+	return fmt.Sprintf("/api/v1/webhooks/sales/%s/ingest", sourceID)
+}
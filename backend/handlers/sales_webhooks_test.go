@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidWebhookSignature(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"external_id":"trx-1","items":[{"product_name":"Kopi","quantity":2,"unit_price":15000}]}`)
+	ts := "1780000000"
+	sig := signWebhook(secret, ts, body)
+	bodyOnly := hmac.New(sha256.New, []byte(secret))
+	bodyOnly.Write(body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{name: "valid", secret: secret, timestamp: ts, body: body, signature: sig, want: true},
+		{name: "with sha256= prefix", secret: secret, timestamp: ts, body: body, signature: "sha256=" + sig, want: true},
+		{name: "wrong secret", secret: "whsec_other", timestamp: ts, body: body, signature: sig},
+		{name: "body changed", secret: secret, timestamp: ts, body: []byte(`{"external_id":"trx-2"}`), signature: sig},
+		{name: "timestamp changed", secret: secret, timestamp: "1780000300", body: body, signature: sig},
+		{name: "timestamp missing", secret: secret, body: body, signature: signWebhook(secret, "", body)},
+		{name: "signature of the body alone", secret: secret, timestamp: ts, body: body, signature: hex.EncodeToString(bodyOnly.Sum(nil))},
+		{name: "not hex", secret: secret, timestamp: ts, body: body, signature: "zzzz"},
+		{name: "empty", secret: secret, timestamp: ts, body: body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validWebhookSignature(tt.secret, tt.timestamp, tt.body, tt.signature); got != tt.want {
+				t.Errorf("validWebhookSignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreshWebhookTimestamp(t *testing.T) {
+	now := time.Unix(1780000000, 0)
+	unix := func(d time.Duration) string { return strconv.FormatInt(now.Add(d).Unix(), 10) }
+
+	tests := []struct {
+		name      string
+		timestamp string
+		want      bool
+	}{
+		{"now", unix(0), true},
+		{"at the tolerance", unix(-webhookTolerance), true},
+		{"stale", unix(-webhookTolerance - time.Second), false},
+		{"slightly ahead", unix(time.Minute), true},
+		{"far in the future", unix(webhookTolerance + time.Second), false},
+		{"missing", "", false},
+		{"not a number", "yesterday", false},
+		{"milliseconds", strconv.FormatInt(now.UnixMilli(), 10), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := freshWebhookTimestamp(tt.timestamp, now); got != tt.want {
+				t.Errorf("freshWebhookTimestamp(%q) = %v, want %v", tt.timestamp, got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/bantuaku/backend/errors"
 	"github.com/bantuaku/backend/middleware"
 	"github.com/bantuaku/backend/models"
 )
@@ -16,7 +17,7 @@ func (h *Handler) GetSentiment(w http.ResponseWriter, r *http.Request) {
 	productID := r.PathValue("product_id")
 
 	if productID == "" {
-		respondError(w, http.StatusBadRequest, "Product ID is required")
+		h.respondError(w, errors.NewValidationError("Product ID is required", ""), r)
 		return
 	}
 
@@ -26,7 +27,7 @@ func (h *Handler) GetSentiment(w http.ResponseWriter, r *http.Request) {
 	if err == nil && cached != "" {
 		var sentiment models.SentimentData
 		if json.Unmarshal([]byte(cached), &sentiment) == nil {
-			respondJSON(w, http.StatusOK, sentiment)
+			h.respondJSON(w, r, http.StatusOK, sentiment)
 			return
 		}
 	}
@@ -37,7 +38,7 @@ func (h *Handler) GetSentiment(w http.ResponseWriter, r *http.Request) {
 		SELECT product_name FROM products WHERE id = $1 AND store_id = $2
 	`, productID, storeID).Scan(&productName)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "Product not found")
+		h.respondError(w, errors.NewAppError(errors.ErrCodeNotFound, "Product not found", ""), r)
 		return
 	}
 
@@ -49,14 +50,14 @@ func (h *Handler) GetSentiment(w http.ResponseWriter, r *http.Request) {
 	cacheData, _ := json.Marshal(sentiment)
 	h.redis.Set(r.Context(), cacheKey, string(cacheData), 6*time.Hour)
 
-	respondJSON(w, http.StatusOK, sentiment)
+	h.respondJSON(w, r, http.StatusOK, sentiment)
 }
 
 // GetMarketTrends returns market trend data
 func (h *Handler) GetMarketTrends(w http.ResponseWriter, r *http.Request) {
 	storeID := middleware.GetStoreID(r.Context())
 	if storeID == "" {
-		respondError(w, http.StatusUnauthorized, "Store not found in context")
+		h.respondError(w, errors.NewUnauthorizedError("Store not found in context"), r)
 		return
 	}
 
@@ -66,7 +67,7 @@ func (h *Handler) GetMarketTrends(w http.ResponseWriter, r *http.Request) {
 	if err == nil && cached != "" {
 		var trends []models.MarketTrend
 		if json.Unmarshal([]byte(cached), &trends) == nil {
-			respondJSON(w, http.StatusOK, trends)
+			h.respondJSON(w, r, http.StatusOK, trends)
 			return
 		}
 	}
@@ -94,7 +95,7 @@ func (h *Handler) GetMarketTrends(w http.ResponseWriter, r *http.Request) {
 	cacheData, _ := json.Marshal(trends)
 	h.redis.Set(r.Context(), cacheKey, string(cacheData), 24*time.Hour)
 
-	respondJSON(w, http.StatusOK, trends)
+	h.respondJSON(w, r, http.StatusOK, trends)
 }
 
 // Sample data generators for MVP demo
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/cachebus"
+	"github.com/bantuaku/backend/services/maintenance"
+	"github.com/bantuaku/backend/services/settings"
+)
+
+// UpdateMaintenanceRequest turns maintenance mode on or off
+type UpdateMaintenanceRequest struct {
+	Enabled        bool       `json:"enabled"`
+	Message        string     `json:"message,omitempty"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+	AllowedPaths   []string   `json:"allowed_paths,omitempty"`
+	AllowedUserIDs []string   `json:"allowed_user_ids,omitempty"`
+}
+
+// Maintenance returns the live maintenance mode switch
+func (h *Handler) Maintenance() *maintenance.Switch {
+	return h.maint
+}
+
+// ReloadSettings loads platform settings and applies them. It is run at
+// startup and periodically from main so every instance picks up changes.
+func (h *Handler) ReloadSettings(ctx context.Context) {
+	rows, err := h.db.Pool().Query(ctx, `SELECT key, value FROM platform_settings`)
+	if err != nil {
+		logger.Warn("Failed to reload platform settings, keeping current values", "error", err.Error())
+		return
+	}
+	values := map[string]json.RawMessage{}
+	for rows.Next() {
+		var key string
+		var value []byte
+		if rows.Scan(&key, &value) == nil {
+			values[key] = value
+		}
+	}
+	rows.Close()
+	h.settings.Replace(values)
+
+	var mode maintenance.Mode
+	h.settings.Decode(settings.KeyMaintenance, &mode)
+	if previous := h.maint.Current(); previous.Enabled != mode.Enabled {
+		logger.Info("Maintenance mode changed", "enabled", mode.Enabled)
+	}
+	h.maint.Set(mode)
+}
+
+// GetMaintenance returns the maintenance mode setting (super admin only)
+func (h *Handler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, r, http.StatusOK, h.maint.Current())
+}
+
+// UpdateMaintenance turns maintenance mode on or off for every instance
+// without a redeploy (super admin only)
+func (h *Handler) UpdateMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req UpdateMaintenanceRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	now := time.Now()
+	mode := maintenance.Mode{
+		Enabled:        req.Enabled,
+		Message:        strings.TrimSpace(req.Message),
+		EndsAt:         req.EndsAt,
+		AllowedUserIDs: req.AllowedUserIDs,
+		UpdatedBy:      middleware.GetUserID(ctx),
+		UpdatedAt:      &now,
+	}
+	for _, p := range req.AllowedPaths {
+		if p = strings.TrimSpace(p); p != "" {
+			mode.AllowedPaths = append(mode.AllowedPaths, p)
+		}
+	}
+	if err := mode.Validate(); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid maintenance settings", err.Error()), r)
+		return
+	}
+	if len(mode.Message) > 1000 {
+		h.respondError(w, errors.NewValidationError("Invalid maintenance settings", "message must be at most 1000 characters"), r)
+		return
+	}
+
+	if err := h.saveSetting(ctx, settings.KeyMaintenance, mode); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save maintenance settings"), r)
+		return
+	}
+	h.invalidate(ctx, cachebus.KindSettings)
+	h.dropStatusPageCache(ctx)
+
+	logger.Info("Maintenance settings updated", "enabled", mode.Enabled, "admin_id", mode.UpdatedBy)
+	h.respondJSON(w, r, http.StatusOK, h.maint.Current())
+}
+
+// saveSetting stores a platform setting; publish a settings invalidation for
+// instances to apply it
+func (h *Handler) saveSetting(ctx context.Context, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = h.db.Pool().Exec(ctx, `
+		INSERT INTO platform_settings (key, value, updated_by, updated_at)
+		VALUES ($1, $2, NULLIF($3, ''), NOW())
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_by = EXCLUDED.updated_by, updated_at = NOW()
+	`, key, data, middleware.GetUserID(ctx))
+	return err
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/slo"
+
+	"github.com/google/uuid"
+)
+
+// SLOTracker returns the tracker fed by the metrics middleware
+func (h *Handler) SLOTracker() *slo.Tracker {
+	return h.slo
+}
+
+// GetSLOStatus reports availability, p95 latency and error budget of each
+// endpoint group over the SLO window, for status pages. Figures cover the
+// traffic served by the instance that answers.
+func (h *Handler) GetSLOStatus(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"window_hours": int(h.slo.Window().Hours()),
+		"objectives":   h.slo.Status(time.Now(), h.config.SLOBurnAlert),
+		"generated_at": time.Now().UTC(),
+	})
+}
+
+// ListSLOAlerts lists SLO alerts, newest first (admin only)
+func (h *Handler) ListSLOAlerts(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM slo_alerts`).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count SLO alerts"), r)
+		return
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, slo_name, state, availability::float8, p95_ms, burn_rate_5m::float8, burn_rate_1h::float8,
+			fired_at, resolved_at
+		FROM slo_alerts
+		ORDER BY fired_at DESC
+		LIMIT $1 OFFSET $2
+	`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list SLO alerts"), r)
+		return
+	}
+	defer rows.Close()
+
+	alerts := []models.SLOAlert{}
+	for rows.Next() {
+		var a models.SLOAlert
+		if err := rows.Scan(&a.ID, &a.SLOName, &a.State, &a.Availability, &a.P95Ms, &a.BurnRate5m, &a.BurnRate1h,
+			&a.FiredAt, &a.ResolvedAt); err != nil {
+			continue
+		}
+		alerts = append(alerts, a)
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    alerts,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// CheckSLOAlerts raises an alert for each objective that is burning or has
+// breached, and resolves alerts of objectives that recovered. Alerts are sent
+// to SLO_ALERT_WEBHOOK_URL when set. It is run every minute from main.
+func (h *Handler) CheckSLOAlerts(ctx context.Context) {
+	for _, s := range h.slo.Status(time.Now(), h.config.SLOBurnAlert) {
+		if s.State == slo.StateOK {
+			var alertID string
+			err := h.db.Pool().QueryRow(ctx, `
+				UPDATE slo_alerts SET resolved_at = NOW() WHERE slo_name = $1 AND resolved_at IS NULL RETURNING id
+			`, s.Name).Scan(&alertID)
+			if err == nil {
+				logger.Info("SLO recovered", "slo", s.Name, "alert_id", alertID)
+				h.sendSLOAlert(ctx, "slo.resolved", s, fmt.Sprintf("[resolved] %s is back within its objective (%.3f%% available, p95 %dms)",
+					s.Name, s.Availability, s.P95Ms))
+			}
+			continue
+		}
+
+		// The open-alert index lets only one instance raise it
+		tag, err := h.db.Pool().Exec(ctx, `
+			INSERT INTO slo_alerts (id, slo_name, state, availability, p95_ms, burn_rate_5m, burn_rate_1h)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (slo_name) WHERE resolved_at IS NULL DO NOTHING
+		`, uuid.New().String(), s.Name, s.State, s.Availability, s.P95Ms, s.BurnRate5m, s.BurnRate1h)
+		if err != nil {
+			logger.Error("Failed to record SLO alert", "slo", s.Name, "error", err.Error())
+			continue
+		}
+		if tag.RowsAffected() == 0 {
+			continue
+		}
+		logger.Warn("SLO alert", "slo", s.Name, "state", s.State, "availability", s.Availability, "p95_ms", s.P95Ms,
+			"burn_rate_5m", s.BurnRate5m, "burn_rate_1h", s.BurnRate1h)
+		h.sendSLOAlert(ctx, "slo."+s.State, s, fmt.Sprintf("[%s] %s: %.3f%% available (target %.2f%%), p95 %dms (target %dms), burn rate %.1fx over 5m / %.1fx over 1h",
+			s.State, s.Name, s.Availability, s.AvailabilityTarget, s.P95Ms, s.LatencyTargetMs, s.BurnRate5m, s.BurnRate1h))
+	}
+}
+
+// sendSLOAlert posts an alert event to the configured webhook
+func (h *Handler) sendSLOAlert(ctx context.Context, event string, s slo.Status, text string) {
+	if h.config.SLOAlertWebhookURL == "" {
+		return
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"event": event,
+		"slo":   s,
+		"text":  text,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.config.SLOAlertWebhookURL, bytes.NewReader(body))
+	if err != nil {
+		logger.Error("Invalid SLO alert webhook", "error", err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Warn("Failed to send SLO alert", "event", event, "slo", s.Name, "error", err.Error())
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger.Warn("SLO alert webhook rejected the alert", "event", event, "slo", s.Name, "status", resp.StatusCode)
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/slowmovers"
+)
+
+// slowMoverWindowDays is the length of each window velocity is compared over
+const slowMoverWindowDays = 30
+
+// SlowMoversResponse lists products that stopped selling or are slowing down
+type SlowMoversResponse struct {
+	NoSalesDays int                    `json:"no_sales_days"`
+	WindowDays  int                    `json:"window_days"`
+	Products    []slowmovers.SlowMover `json:"products"`
+}
+
+// GetSlowMovers flags dead stock (no sales in ?days=N) and products with declining velocity
+func (h *Handler) GetSlowMovers(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	days := h.config.SlowMoverDays
+	if s := r.URL.Query().Get("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 7 || n > maxQualityWindowDays {
+			h.respondError(w, errors.NewValidationError("Invalid days", "days must be between 7 and 365"), r)
+			return
+		}
+		days = n
+	}
+
+	flagged, err := h.detectSlowMovers(r.Context(), companyID, days)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "detect slow movers"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, SlowMoversResponse{NoSalesDays: days, WindowDays: slowMoverWindowDays, Products: flagged})
+}
+
+// ScanSlowMovers runs slow-mover detection for every active company and raises a
+// notification per newly flagged product. It is run periodically from main.
+func (h *Handler) ScanSlowMovers(ctx context.Context) {
+	rows, err := h.db.Pool().Query(ctx, `SELECT id FROM companies WHERE COALESCE(status, 'active') = 'active'`)
+	if err != nil {
+		logger.Error("Slow mover scan failed to list companies", "error", err.Error())
+		return
+	}
+	var companyIDs []string
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			companyIDs = append(companyIDs, id)
+		}
+	}
+	rows.Close()
+
+	created := 0
+	for _, companyID := range companyIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		flagged, err := h.detectSlowMovers(ctx, companyID, h.config.SlowMoverDays)
+		if err != nil {
+			logger.Warn("Slow mover scan failed", "company_id", companyID, "error", err.Error())
+			continue
+		}
+		for _, m := range flagged {
+			ok, err := h.notify(ctx, companyID, slowMoverNotification(m), slowMoverDedupeKey(m, time.Now()))
+			if err != nil {
+				logger.Warn("Failed to create slow mover notification", "company_id", companyID, "product_id", m.ProductID, "error", err.Error())
+				continue
+			}
+			if ok {
+				created++
+			}
+		}
+	}
+
+	logger.Info("Slow mover scan completed", "companies", len(companyIDs), "notifications", created)
+}
+
+func (h *Handler) detectSlowMovers(ctx context.Context, companyID string, noSalesDays int) ([]slowmovers.SlowMover, error) {
+	today := localDate(time.Now(), h.companyLocation(ctx, companyID))
+	recentFrom := today.AddDate(0, 0, -slowMoverWindowDays)
+	priorFrom := recentFrom.AddDate(0, 0, -slowMoverWindowDays)
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT p.id, p.name,
+			COALESCE($2::date - MAX(s.sale_date), -1),
+			$2::date - p.created_at::date,
+			COALESCE(SUM(s.quantity) FILTER (WHERE s.sale_date >= $3), 0),
+			COALESCE(SUM(s.quantity) FILTER (WHERE s.sale_date >= $4 AND s.sale_date < $3), 0)
+		FROM products p
+		LEFT JOIN sales_history s ON s.product_id = p.id AND NOT COALESCE(s.excluded, false)
+		WHERE p.company_id = $1 AND COALESCE(p.is_active, true)
+		GROUP BY p.id, p.name, p.created_at
+	`, companyID, today, recentFrom, priorFrom)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []slowmovers.ProductSales
+	for rows.Next() {
+		var p slowmovers.ProductSales
+		if err := rows.Scan(&p.ProductID, &p.ProductName, &p.DaysSinceLastSale, &p.AgeDays, &p.RecentQuantity, &p.PriorQuantity); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return slowmovers.Detect(products, noSalesDays, slowMoverWindowDays), nil
+}
+
+func slowMoverNotification(m slowmovers.SlowMover) models.Notification {
+	title := fmt.Sprintf("%s penjualannya melambat", m.ProductName)
+	if m.Reason == slowmovers.ReasonNoSales {
+		title = fmt.Sprintf("%s tidak terjual", m.ProductName)
+	}
+	return models.Notification{
+		Type:    models.NotificationSlowMover,
+		Title:   title,
+		Message: m.Suggestion,
+		Data: map[string]interface{}{
+			"product_id": m.ProductID,
+			"reason":     m.Reason,
+			"action":     m.Action,
+		},
+	}
+}
+
+// slowMoverDedupeKey allows one alert per product, reason and month
+func slowMoverDedupeKey(m slowmovers.SlowMover, now time.Time) string {
+	return fmt.Sprintf("slow_mover:%s:%s:%s", m.ProductID, m.Reason, now.Format("2006-01"))
+}
@@ -0,0 +1,690 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/oidc"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ssoStateTTL bounds how long a user may take to sign in at their identity provider
+const ssoStateTTL = 10 * time.Minute
+
+// ssoEnterprisePlan is the company plan that may configure its own identity provider
+const ssoEnterprisePlan = "enterprise"
+
+// SSOConfigRequest configures an OIDC identity provider. ClientSecret may be
+// omitted when updating to keep the stored one.
+type SSOConfigRequest struct {
+	Issuer         string            `json:"issuer" validate:"required,max:500"`
+	ClientID       string            `json:"client_id" validate:"required,max:255"`
+	ClientSecret   string            `json:"client_secret,omitempty"`
+	EmailDomains   []string          `json:"email_domains"`
+	DomainClaim    string            `json:"domain_claim,omitempty" validate:"max:100"`
+	CompanyMapping map[string]string `json:"company_mapping,omitempty"`
+	DefaultRole    string            `json:"default_role,omitempty" validate:"oneof:manager|viewer"`
+	Enabled        *bool             `json:"enabled,omitempty"`
+}
+
+// SSOCallbackRequest completes a login with the code and state the identity
+// provider returned to the web app
+type SSOCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// ssoConfig is a stored provider including its client secret
+type ssoConfig struct {
+	models.SSOConfig
+	clientSecret string
+}
+
+// ssoState is kept in Redis between the start of a login and its callback
+type ssoState struct {
+	ConfigID string `json:"config_id"`
+	Nonce    string `json:"nonce"`
+}
+
+// GetCompanySSO returns a company's identity provider (platform admin only)
+func (h *Handler) GetCompanySSO(w http.ResponseWriter, r *http.Request) {
+	h.getSSOConfig(w, r, "company_id", r.PathValue("id"))
+}
+
+// PutCompanySSO creates or updates a company's identity provider. Only companies
+// on the enterprise plan may use SSO.
+func (h *Handler) PutCompanySSO(w http.ResponseWriter, r *http.Request) {
+	companyID := r.PathValue("id")
+	if plan := h.CompanyPlan(r.Context(), companyID); plan != ssoEnterprisePlan {
+		h.respondError(w, errors.NewBusinessRuleError("enterprise_plan_required", "single sign-on is available on the enterprise plan"), r)
+		return
+	}
+	h.putSSOConfig(w, r, "company_id", companyID)
+}
+
+// DeleteCompanySSO removes a company's identity provider. Its users keep their
+// accounts and can be sent a set-password link.
+func (h *Handler) DeleteCompanySSO(w http.ResponseWriter, r *http.Request) {
+	h.deleteSSOConfig(w, r, "company_id", r.PathValue("id"))
+}
+
+// GetPartnerSSO returns a partner's identity provider (partner owners and platform admins)
+func (h *Handler) GetPartnerSSO(w http.ResponseWriter, r *http.Request) {
+	partnerID := r.PathValue("id")
+	if !h.canManagePartner(r, partnerID, true) {
+		h.respondError(w, errors.NewForbiddenError("Only the partner owner can manage single sign-on"), r)
+		return
+	}
+	h.getSSOConfig(w, r, "partner_id", partnerID)
+}
+
+// PutPartnerSSO creates or updates the identity provider shared by a partner's
+// companies. company_mapping routes each domain_claim value to one of them.
+func (h *Handler) PutPartnerSSO(w http.ResponseWriter, r *http.Request) {
+	partnerID := r.PathValue("id")
+	if !h.canManagePartner(r, partnerID, true) {
+		h.respondError(w, errors.NewForbiddenError("Only the partner owner can manage single sign-on"), r)
+		return
+	}
+	h.putSSOConfig(w, r, "partner_id", partnerID)
+}
+
+// DeletePartnerSSO removes a partner's identity provider
+func (h *Handler) DeletePartnerSSO(w http.ResponseWriter, r *http.Request) {
+	partnerID := r.PathValue("id")
+	if !h.canManagePartner(r, partnerID, true) {
+		h.respondError(w, errors.NewForbiddenError("Only the partner owner can manage single sign-on"), r)
+		return
+	}
+	h.deleteSSOConfig(w, r, "partner_id", partnerID)
+}
+
+// StartSSO begins a login for ?email= at the identity provider configured for
+// the email's domain and returns the URL to send the browser to
+func (h *Handler) StartSSO(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	domain := oidc.EmailDomain(r.URL.Query().Get("email"))
+	if domain == "" {
+		h.respondError(w, errors.NewValidationError("Valid email is required", "pass ?email= to find your organization's sign-in"), r)
+		return
+	}
+	if h.redis == nil {
+		h.respondError(w, errors.NewInternalError(fmt.Errorf("redis is not configured"), "Single sign-on is unavailable"), r)
+		return
+	}
+
+	var configID string
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id FROM sso_configs WHERE enabled AND $1 = ANY(email_domains) LIMIT 1
+	`, domain).Scan(&configID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("SSO provider for "+domain), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "find sso provider"), r)
+		return
+	}
+	cfg, err := h.loadSSOConfig(ctx, "id", configID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sso provider"), r)
+		return
+	}
+
+	provider, err := oidc.NewClient().Discover(ctx, cfg.Issuer)
+	if err != nil {
+		logger.Warn("SSO discovery failed", "config_id", cfg.ID, "error", err.Error())
+		h.respondError(w, errors.NewInternalError(err, "Identity provider is unreachable"), r)
+		return
+	}
+
+	state, nonce := randomToken(), randomToken()
+	data, _ := json.Marshal(ssoState{ConfigID: cfg.ID, Nonce: nonce})
+	if err := h.redis.Set(ctx, "sso_state:"+state, string(data), ssoStateTTL); err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to start single sign-on"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"authorization_url": provider.AuthCodeURL(cfg.ClientID, h.ssoRedirectURI(), state, nonce),
+		"expires_in":        int(ssoStateTTL.Seconds()),
+	})
+}
+
+// SSOCallback completes a login: it redeems the code, verifies the ID token,
+// provisions the user into the mapped company on first login and returns a session token
+func (h *Handler) SSOCallback(w http.ResponseWriter, r *http.Request) {
+	var req SSOCallbackRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	cfg, claims, err := h.redeemSSOCallback(r.Context(), req)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	h.completeSSOLogin(w, r, cfg, claims)
+}
+
+// LinkSSO links the signed-in account to the identity provider of its email
+// domain, with the code and state of a sign-in started with StartSSO. Accounts
+// that signing in does not link on its own (admins, partner members and members
+// of other companies) are linked here, by someone holding both the session and
+// the provider identity.
+func (h *Handler) LinkSSO(w http.ResponseWriter, r *http.Request) {
+	var req SSOCallbackRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	cfg, claims, err := h.redeemSSOCallback(ctx, req)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	identity, err := ssoIdentity(cfg, claims)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	var email, linkedConfig string
+	err = h.db.Pool().QueryRow(ctx, `
+		SELECT email, COALESCE(sso_config_id, '') FROM users WHERE id = $1
+	`, userID).Scan(&email, &linkedConfig)
+	if err != nil {
+		h.respondError(w, errors.NewNotFoundError("User"), r)
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(email), identity.Email) {
+		h.respondError(w, errors.NewForbiddenError("The identity provider signed in a different email than your account's"), r)
+		return
+	}
+	if linkedConfig != "" && linkedConfig != cfg.ID {
+		h.respondError(w, errors.NewConflictError("Account is managed by another identity provider", ""), r)
+		return
+	}
+
+	var taken bool
+	h.db.Pool().QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM users WHERE sso_config_id = $1 AND sso_subject = $2 AND id <> $3)
+	`, cfg.ID, identity.Subject, userID).Scan(&taken)
+	if taken {
+		h.respondError(w, errors.NewConflictError("This identity is linked to another account", ""), r)
+		return
+	}
+	if _, err := h.db.Pool().Exec(ctx, `
+		UPDATE users SET sso_config_id = $2, sso_subject = $3 WHERE id = $1
+	`, userID, cfg.ID, identity.Subject); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "link sso user"), r)
+		return
+	}
+
+	logger.Info("SSO account linked", "user_id", userID, "config_id", cfg.ID)
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"linked":    true,
+		"config_id": cfg.ID,
+	})
+}
+
+// redeemSSOCallback consumes the sign-in state and returns its provider and the
+// verified ID token claims
+func (h *Handler) redeemSSOCallback(ctx context.Context, req SSOCallbackRequest) (*ssoConfig, jwt.MapClaims, error) {
+	if h.redis == nil {
+		return nil, nil, errors.NewInternalError(fmt.Errorf("redis is not configured"), "Single sign-on is unavailable")
+	}
+	stateKey := "sso_state:" + req.State
+	raw, err := h.redis.Get(ctx, stateKey)
+	var state ssoState
+	if err != nil || raw == "" || json.Unmarshal([]byte(raw), &state) != nil {
+		return nil, nil, errors.NewUnauthorizedError("Sign-in session expired, please try again")
+	}
+	h.redis.Delete(ctx, stateKey)
+
+	cfg, err := h.loadSSOConfig(ctx, "id", state.ConfigID)
+	if err != nil || !cfg.Enabled {
+		return nil, nil, errors.NewUnauthorizedError("Single sign-on is no longer enabled")
+	}
+
+	claims, err := h.verifySSOCode(ctx, cfg, req.Code, state.Nonce)
+	if err != nil {
+		logger.Warn("SSO login failed", "config_id", cfg.ID, "error", err.Error())
+		return nil, nil, errors.NewUnauthorizedError("Sign-in with your identity provider failed")
+	}
+	return cfg, claims, nil
+}
+
+// verifySSOCode redeems an authorization code and returns the verified ID token claims
+func (h *Handler) verifySSOCode(ctx context.Context, cfg *ssoConfig, code, nonce string) (jwt.MapClaims, error) {
+	client := oidc.NewClient()
+	provider, err := client.Discover(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	rawIDToken, err := client.Exchange(ctx, provider, cfg.ClientID, cfg.clientSecret, h.ssoRedirectURI(), code)
+	if err != nil {
+		return nil, err
+	}
+	return client.Verify(ctx, provider, rawIDToken, cfg.ClientID, nonce)
+}
+
+// completeSSOLogin maps verified claims to a company, provisions the user and
+// responds with a session token
+func (h *Handler) completeSSOLogin(w http.ResponseWriter, r *http.Request, cfg *ssoConfig, claims jwt.MapClaims) {
+	ctx := r.Context()
+	identity, err := ssoIdentity(cfg, claims)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	companyID := cfg.CompanyID
+	if companyID == "" {
+		value := oidc.ClaimValue(claims, cfg.DomainClaim)
+		companyID = cfg.CompanyMapping[value]
+		if companyID == "" {
+			h.respondError(w, errors.NewForbiddenError(fmt.Sprintf("No company is mapped to %s %q", cfg.DomainClaim, value)), r)
+			return
+		}
+	}
+
+	var companyName, plan string
+	err = h.db.Pool().QueryRow(ctx, `
+		SELECT name, COALESCE(subscription_plan, 'free') FROM companies
+		WHERE id = $1 AND ($2 = '' OR partner_id = $2)
+	`, companyID, cfg.PartnerID).Scan(&companyName, &plan)
+	if err != nil {
+		h.respondError(w, errors.NewForbiddenError("The mapped company does not exist"), r)
+		return
+	}
+	if cfg.CompanyID != "" && plan != ssoEnterprisePlan {
+		h.respondError(w, errors.NewBusinessRuleError("enterprise_plan_required", "single sign-on is available on the enterprise plan"), r)
+		return
+	}
+
+	userID, role, err := h.provisionSSOUser(ctx, cfg, identity, companyID)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	var suspended bool
+	h.db.Pool().QueryRow(ctx, `SELECT suspended_at IS NOT NULL FROM users WHERE id = $1`, userID).Scan(&suspended)
+	if suspended {
+		h.respondError(w, errSuspendedAccount(), r)
+		return
+	}
+
+	var deviceID string
+	if isPrivilegedRole(role) {
+		deviceID, err = h.registerAdminLogin(ctx, r, userID, identity.Email)
+		if err != nil {
+			h.respondError(w, err, r)
+			return
+		}
+	}
+
+	h.touchCompanyActivity(ctx, companyID)
+
+	token, err := h.generateSessionToken(userID, companyID, role, deviceID)
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Failed to generate token"), r)
+		return
+	}
+
+	logger.Info("SSO login", "user_id", userID, "company_id", companyID, "config_id", cfg.ID)
+	h.respondJSON(w, r, http.StatusOK, AuthResponse{
+		Token:     token,
+		UserID:    userID,
+		StoreID:   companyID,
+		StoreName: companyName,
+		Plan:      plan,
+	})
+}
+
+// ssoIdentity returns the identity of verified claims, provided the provider
+// serves its email domain
+func ssoIdentity(cfg *ssoConfig, claims jwt.MapClaims) (oidc.Identity, error) {
+	identity, err := oidc.IdentityFromClaims(claims)
+	if err != nil {
+		return identity, errors.NewUnauthorizedError(err.Error())
+	}
+	domain := oidc.EmailDomain(identity.Email)
+	for _, d := range cfg.EmailDomains {
+		if d == domain {
+			return identity, nil
+		}
+	}
+	return identity, errors.NewForbiddenError(fmt.Sprintf("This identity provider does not sign in %s accounts", domain))
+}
+
+// ssoAccount is an existing account with the email of an identity it is not
+// linked to
+type ssoAccount struct {
+	Role          string
+	LinkedConfig  string
+	LinkedSubject string
+	OtherCompany  bool // A member of a company other than the one signing in
+	Partner       bool // A member of a partner
+}
+
+// ssoAutoLinkError returns why signing in may not link an existing account to
+// the identity, or nil when it may. Only plain users of the signing-in company,
+// or of none, are linked on sign-in; the rest must link with LinkSSO.
+func ssoAutoLinkError(cfg *ssoConfig, acct ssoAccount) error {
+	switch {
+	case acct.LinkedConfig != "" && acct.LinkedConfig != cfg.ID:
+		return errors.NewConflictError("Account is managed by another identity provider", "")
+	case acct.LinkedConfig != "":
+		return errors.NewConflictError("Account is linked to another identity at this provider", "")
+	case acct.Role != "user" || acct.OtherCompany || acct.Partner:
+		return errors.NewForbiddenError("Sign in with your password and link single sign-on from your account first")
+	}
+	return nil
+}
+
+// provisionSSOUser finds or creates the user for an identity and makes them a
+// member of the company. An existing account with the same email is linked when
+// ssoAutoLinkError allows; it keeps its password, which stops working for login
+// while SSO manages the account, and pending reset links are revoked.
+func (h *Handler) provisionSSOUser(ctx context.Context, cfg *ssoConfig, identity oidc.Identity, companyID string) (string, string, error) {
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return "", "", errors.NewDatabaseError(err, "begin transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	var userID, role string
+	var acct ssoAccount
+	err = tx.QueryRow(ctx, `
+		SELECT id, COALESCE(role, 'user'), COALESCE(sso_config_id, ''), COALESCE(sso_subject, ''),
+			EXISTS (SELECT 1 FROM company_members m WHERE m.user_id = users.id AND m.company_id <> $4),
+			EXISTS (SELECT 1 FROM partner_members p WHERE p.user_id = users.id)
+		FROM users
+		WHERE (sso_config_id = $1 AND sso_subject = $2) OR email = $3
+		ORDER BY (sso_config_id = $1 AND sso_subject = $2) DESC NULLS LAST
+		LIMIT 1
+	`, cfg.ID, identity.Subject, identity.Email, companyID).Scan(&userID, &role, &acct.LinkedConfig, &acct.LinkedSubject,
+		&acct.OtherCompany, &acct.Partner)
+	acct.Role = role
+	switch {
+	case err == pgx.ErrNoRows:
+		userID, role = uuid.New().String(), "user"
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO users (id, email, password_hash, role, sso_config_id, sso_subject, created_at)
+			VALUES ($1, $2, NULL, $3, $4, $5, NOW())
+		`, userID, identity.Email, role, cfg.ID, identity.Subject); err != nil {
+			return "", "", errors.NewDatabaseError(err, "create sso user")
+		}
+	case err != nil:
+		return "", "", errors.NewDatabaseError(err, "find sso user")
+	case acct.LinkedConfig == cfg.ID && acct.LinkedSubject == identity.Subject:
+		// Already linked
+	default:
+		if err := ssoAutoLinkError(cfg, acct); err != nil {
+			logger.Warn("SSO account not linked", "user_id", userID, "config_id", cfg.ID, "reason", err.Error())
+			return "", "", err
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE users SET sso_config_id = $2, sso_subject = $3 WHERE id = $1
+		`, userID, cfg.ID, identity.Subject); err != nil {
+			return "", "", errors.NewDatabaseError(err, "link sso user")
+		}
+		if _, err := tx.Exec(ctx, `
+			UPDATE password_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL
+		`, userID); err != nil {
+			return "", "", errors.NewDatabaseError(err, "revoke password tokens")
+		}
+	}
+
+	// Keep the role of existing members; only new members get the provider default
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO company_members (company_id, user_id, role, source)
+		VALUES ($1, $2, $3, 'sso')
+		ON CONFLICT (company_id, user_id) DO NOTHING
+	`, companyID, userID, cfg.DefaultRole); err != nil {
+		return "", "", errors.NewDatabaseError(err, "add company member")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", "", errors.NewDatabaseError(err, "commit transaction")
+	}
+	return userID, role, nil
+}
+
+func (h *Handler) getSSOConfig(w http.ResponseWriter, r *http.Request, column, ownerID string) {
+	cfg, err := h.loadSSOConfig(r.Context(), column, ownerID)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("SSO configuration"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load sso configuration"), r)
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, cfg.SSOConfig)
+}
+
+func (h *Handler) putSSOConfig(w http.ResponseWriter, r *http.Request, column, ownerID string) {
+	var req SSOConfigRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	req.Issuer = strings.TrimRight(strings.TrimSpace(req.Issuer), "/")
+	if u, err := url.Parse(req.Issuer); err != nil || u.Scheme != "https" || u.Host == "" {
+		h.respondError(w, errors.NewValidationError("Invalid issuer", "issuer must be an https URL"), r)
+		return
+	}
+	if req.DomainClaim == "" {
+		req.DomainClaim = "email_domain"
+	}
+	// Provisioned users only read until someone grants them more
+	if req.DefaultRole == "" {
+		req.DefaultRole = "viewer"
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	existing, err := h.loadSSOConfig(ctx, column, ownerID)
+	if err != nil && err != pgx.ErrNoRows {
+		h.respondError(w, errors.NewDatabaseError(err, "load sso configuration"), r)
+		return
+	}
+	role := middleware.GetRole(ctx)
+	domains, err := ssoDomains(req.EmailDomains, existing, role == "admin" || role == "super_admin")
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	if column == "partner_id" {
+		if len(req.CompanyMapping) == 0 {
+			h.respondError(w, errors.NewValidationError("company_mapping is required", "map each "+req.DomainClaim+" value to one of the partner's companies"), r)
+			return
+		}
+		for value, companyID := range req.CompanyMapping {
+			var exists bool
+			h.db.Pool().QueryRow(ctx, `
+				SELECT EXISTS (SELECT 1 FROM companies WHERE id = $1 AND partner_id = $2)
+			`, companyID, ownerID).Scan(&exists)
+			if !exists {
+				h.respondError(w, errors.NewValidationError("Company is not part of this partner", fmt.Sprintf("%s -> %s", value, companyID)), r)
+				return
+			}
+		}
+	} else {
+		req.CompanyMapping = map[string]string{}
+	}
+
+	// A domain may only route to one provider
+	var clash string
+	err = h.db.Pool().QueryRow(ctx, fmt.Sprintf(`
+		SELECT d FROM sso_configs, unnest(email_domains) d
+		WHERE d = ANY($1) AND %s IS DISTINCT FROM $2
+		LIMIT 1
+	`, column), domains, ownerID).Scan(&clash)
+	if err == nil {
+		h.respondError(w, errors.NewConflictError("Email domain already uses another identity provider", clash), r)
+		return
+	}
+
+	if req.ClientSecret == "" {
+		if existing == nil {
+			h.respondError(w, errors.NewValidationError("client_secret is required", ""), r)
+			return
+		}
+		req.ClientSecret = existing.clientSecret
+	}
+
+	if _, err := oidc.NewClient().Discover(ctx, req.Issuer); err != nil {
+		h.respondError(w, errors.NewValidationError("Identity provider discovery failed", err.Error()), r)
+		return
+	}
+
+	mapping, _ := json.Marshal(req.CompanyMapping)
+	id := uuid.New().String()
+	if existing != nil {
+		id = existing.ID
+	}
+	if _, err := h.db.Pool().Exec(ctx, fmt.Sprintf(`
+		INSERT INTO sso_configs (id, %s, issuer, client_id, client_secret, email_domains, domain_claim,
+			company_mapping, default_role, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (%s) DO UPDATE SET
+			issuer = EXCLUDED.issuer, client_id = EXCLUDED.client_id, client_secret = EXCLUDED.client_secret,
+			email_domains = EXCLUDED.email_domains, domain_claim = EXCLUDED.domain_claim,
+			company_mapping = EXCLUDED.company_mapping, default_role = EXCLUDED.default_role,
+			enabled = EXCLUDED.enabled, updated_at = NOW()
+	`, column, column), id, ownerID, req.Issuer, req.ClientID, req.ClientSecret, domains, req.DomainClaim,
+		mapping, req.DefaultRole, enabled); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "save sso configuration"), r)
+		return
+	}
+
+	logger.Info("SSO configuration saved", column, ownerID, "issuer", req.Issuer, "admin_id", middleware.GetUserID(ctx))
+	h.getSSOConfig(w, r, column, ownerID)
+}
+
+// publicMailDomains are mailbox providers anyone can sign up with, so no
+// organisation owns their addresses
+var publicMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "yahoo.com": true, "yahoo.co.id": true, "ymail.com": true,
+	"hotmail.com": true, "outlook.com": true, "live.com": true, "msn.com": true, "icloud.com": true,
+	"me.com": true, "aol.com": true, "proton.me": true, "protonmail.com": true, "gmx.com": true,
+	"mail.com": true, "zoho.com": true, "yandex.com": true, "rocketmail.com": true,
+}
+
+// ssoDomains normalizes the email domains requested for a provider. Domains
+// decide which accounts a provider may sign in, so only platform admins
+// assign them; partner owners can only keep the ones already assigned.
+func ssoDomains(requested []string, existing *ssoConfig, admin bool) ([]string, error) {
+	domains := []string{}
+	for _, d := range requested {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@"))
+		if d == "" || !strings.Contains(d, ".") {
+			return nil, errors.NewValidationError("Invalid email domain", d)
+		}
+		if publicMailDomains[d] {
+			return nil, errors.NewValidationError("Public email domains cannot use single sign-on", d)
+		}
+		domains = append(domains, d)
+	}
+	if admin {
+		if len(domains) == 0 {
+			return nil, errors.NewValidationError("At least one email domain is required", "email_domains routes staff logins to this provider")
+		}
+		return domains, nil
+	}
+
+	if existing == nil || len(existing.EmailDomains) == 0 {
+		return nil, errors.NewForbiddenError("Email domains are assigned by Bantuaku after checking ownership; contact support to set up single sign-on")
+	}
+	if len(domains) == 0 {
+		return existing.EmailDomains, nil
+	}
+	assigned := map[string]bool{}
+	for _, d := range existing.EmailDomains {
+		assigned[d] = true
+	}
+	for _, d := range domains {
+		if !assigned[d] {
+			return nil, errors.NewForbiddenError("Email domains are assigned by Bantuaku after checking ownership; contact support to add " + d)
+		}
+	}
+	return domains, nil
+}
+
+func (h *Handler) deleteSSOConfig(w http.ResponseWriter, r *http.Request, column, ownerID string) {
+	tag, err := h.db.Pool().Exec(r.Context(), fmt.Sprintf(`DELETE FROM sso_configs WHERE %s = $1`, column), ownerID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete sso configuration"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("SSO configuration"), r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadSSOConfig loads a provider by id, company_id or partner_id
+func (h *Handler) loadSSOConfig(ctx context.Context, column, value string) (*ssoConfig, error) {
+	var cfg ssoConfig
+	err := h.db.Pool().QueryRow(ctx, fmt.Sprintf(`
+		SELECT id, COALESCE(company_id, ''), COALESCE(partner_id, ''), issuer, client_id, client_secret,
+			email_domains, domain_claim, company_mapping, default_role, enabled, created_at, updated_at
+		FROM sso_configs
+		WHERE %s = $1
+	`, column), value).Scan(&cfg.ID, &cfg.CompanyID, &cfg.PartnerID, &cfg.Issuer, &cfg.ClientID, &cfg.clientSecret,
+		&cfg.EmailDomains, &cfg.DomainClaim, &cfg.CompanyMapping, &cfg.DefaultRole, &cfg.Enabled, &cfg.CreatedAt, &cfg.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	cfg.HasSecret = cfg.clientSecret != ""
+	return &cfg, nil
+}
+
+// ssoRedirectURI is the web app page that receives the provider's redirect and
+// posts the code to SSOCallback
+func (h *Handler) ssoRedirectURI() string {
+	return strings.TrimRight(h.config.AppURL, "/") + "/sso/callback"
+}
+
+func randomToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
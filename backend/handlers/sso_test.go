@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testSSOConfig() *ssoConfig {
+	return &ssoConfig{SSOConfig: models.SSOConfig{
+		ID:           "cfg-1",
+		CompanyID:    "company-1",
+		EmailDomains: []string{"koperasi.co.id"},
+		DomainClaim:  "email_domain",
+		DefaultRole:  "manager",
+		Enabled:      true,
+	}}
+}
+
+// TestCompleteSSOLoginRejectsIdentity checks that identities are refused before
+// any account is looked up
+func TestCompleteSSOLoginRejectsIdentity(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		status int
+	}{
+		{
+			name:   "email_verified missing",
+			claims: jwt.MapClaims{"sub": "u1", "email": "siti@koperasi.co.id"},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "email not verified",
+			claims: jwt.MapClaims{"sub": "u1", "email": "siti@koperasi.co.id", "email_verified": false},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "domain not served by the provider",
+			claims: jwt.MapClaims{"sub": "u1", "email": "admin@bantuaku.id", "email_verified": true},
+			status: http.StatusForbidden,
+		},
+	}
+
+	h := &Handler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/sso/callback", nil)
+			h.completeSSOLogin(w, r, testSSOConfig(), tt.claims)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestSSOIdentity(t *testing.T) {
+	id, err := ssoIdentity(testSSOConfig(), jwt.MapClaims{"sub": "u1", "email": "Siti@Koperasi.co.id", "email_verified": true})
+	if err != nil || id.Email != "siti@koperasi.co.id" {
+		t.Errorf("ssoIdentity = %+v, %v", id, err)
+	}
+	_, err = ssoIdentity(testSSOConfig(), jwt.MapClaims{"sub": "u1", "email": "siti@koperasi.co.id.evil.com", "email_verified": true})
+	if errors.GetErrorCode(err) != errors.ErrCodeForbidden {
+		t.Errorf("lookalike domain: got %v", err)
+	}
+}
+
+func TestSSOAutoLinkError(t *testing.T) {
+	tests := []struct {
+		name string
+		acct ssoAccount
+		want errors.ErrorCode // Empty when the account may be linked
+	}{
+		{name: "plain user", acct: ssoAccount{Role: "user"}},
+		{name: "admin", acct: ssoAccount{Role: "admin"}, want: errors.ErrCodeForbidden},
+		{name: "super admin", acct: ssoAccount{Role: "super_admin"}, want: errors.ErrCodeForbidden},
+		{name: "member of another company", acct: ssoAccount{Role: "user", OtherCompany: true}, want: errors.ErrCodeForbidden},
+		{name: "partner member", acct: ssoAccount{Role: "user", Partner: true}, want: errors.ErrCodeForbidden},
+		{name: "linked to another provider", acct: ssoAccount{Role: "user", LinkedConfig: "cfg-2", LinkedSubject: "u1"}, want: errors.ErrCodeConflict},
+		{name: "linked to another subject", acct: ssoAccount{Role: "user", LinkedConfig: "cfg-1", LinkedSubject: "u2"}, want: errors.ErrCodeConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ssoAutoLinkError(testSSOConfig(), tt.acct)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected link to be allowed, got %v", err)
+				}
+				return
+			}
+			if got := errors.GetErrorCode(err); got != tt.want {
+				t.Errorf("error code = %q, want %q (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSSODomains(t *testing.T) {
+	assigned := testSSOConfig()
+	tests := []struct {
+		name      string
+		requested []string
+		existing  *ssoConfig
+		admin     bool
+		want      []string
+		wantErr   errors.ErrorCode
+	}{
+		{name: "admin assigns", requested: []string{" @Koperasi.co.id "}, admin: true, want: []string{"koperasi.co.id"}},
+		{name: "admin must assign one", admin: true, wantErr: errors.ErrCodeValidation},
+		{name: "public mail domain", requested: []string{"gmail.com"}, admin: true, wantErr: errors.ErrCodeValidation},
+		{name: "not a domain", requested: []string{"koperasi"}, admin: true, wantErr: errors.ErrCodeValidation},
+		{name: "partner without assigned domains", requested: []string{"koperasi.co.id"}, wantErr: errors.ErrCodeForbidden},
+		{name: "partner claims another domain", requested: []string{"pesaing.co.id"}, existing: assigned, wantErr: errors.ErrCodeForbidden},
+		{name: "partner keeps assigned domains", existing: assigned, want: []string{"koperasi.co.id"}},
+		{name: "partner repeats assigned domains", requested: []string{"KOPERASI.co.id"}, existing: assigned, want: []string{"koperasi.co.id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ssoDomains(tt.requested, tt.existing, tt.admin)
+			if tt.wantErr != "" {
+				if code := errors.GetErrorCode(err); code != tt.wantErr {
+					t.Errorf("error code = %q, want %q (%v)", code, tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ssoDomains = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/slo"
+	"github.com/bantuaku/backend/services/statuspage"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// statusPageCacheKey caches the public status page so polling clients do
+	// not each run the health checks
+	statusPageCacheKey = "status_page"
+	statusPageCacheTTL = 30 * time.Second
+
+	// statusIncidentDays is how long resolved incidents stay on the status page
+	statusIncidentDays = 14
+
+	// statusCheckWindow is how far back provider calls and integration runs are
+	// looked at
+	statusCheckWindow = 15 * time.Minute
+)
+
+// StatusPage is the public status page payload
+type StatusPage struct {
+	Status      string                   `json:"status"` // Worst component status
+	Description string                   `json:"description"`
+	Components  []models.ComponentStatus `json:"components"`
+	Incidents   []models.StatusIncident  `json:"incidents"`
+	Maintenance *MaintenanceNotice       `json:"maintenance,omitempty"` // Set while maintenance mode is on
+	UpdatedAt   time.Time                `json:"updated_at"`
+}
+
+// MaintenanceNotice announces ongoing maintenance on the status page
+type MaintenanceNotice struct {
+	Message string     `json:"message"`
+	EndsAt  *time.Time `json:"ends_at,omitempty"`
+}
+
+// CreateStatusIncidentRequest opens an incident on the status page
+type CreateStatusIncidentRequest struct {
+	Title      string   `json:"title" validate:"required,max:255"`
+	Impact     string   `json:"impact" validate:"required,oneof:minor|major|critical"`
+	Components []string `json:"components" validate:"required"`
+	Status     string   `json:"status" validate:"oneof:investigating|identified|monitoring"` // Defaults to investigating
+	Message    string   `json:"message" validate:"required,max:5000"`
+}
+
+// StatusIncidentUpdateRequest posts progress on an incident. Impact and
+// components are left unchanged when omitted.
+type StatusIncidentUpdateRequest struct {
+	Status     string   `json:"status" validate:"required,oneof:investigating|identified|monitoring|resolved"`
+	Message    string   `json:"message" validate:"required,max:5000"`
+	Impact     string   `json:"impact,omitempty" validate:"oneof:minor|major|critical"`
+	Components []string `json:"components,omitempty"`
+}
+
+// GetStatusPage reports coarse health of the API, database, AI provider,
+// integrations and billing together with open and recent incidents. It is
+// public so the frontend can render a status page; results are cached briefly.
+func (h *Handler) GetStatusPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.redis == nil {
+		h.respondJSON(w, r, http.StatusOK, h.buildStatusPage(ctx))
+		return
+	}
+	if cached, err := h.redis.Get(ctx, statusPageCacheKey); err == nil && cached != "" {
+		var page StatusPage
+		if json.Unmarshal([]byte(cached), &page) == nil {
+			h.respondJSON(w, r, http.StatusOK, page)
+			return
+		}
+	}
+
+	page := h.buildStatusPage(ctx)
+	if data, err := json.Marshal(page); err == nil {
+		h.redis.Set(ctx, statusPageCacheKey, data, statusPageCacheTTL)
+	}
+	h.respondJSON(w, r, http.StatusOK, page)
+}
+
+// dropStatusPageCache makes the next status page request see incident changes
+func (h *Handler) dropStatusPageCache(ctx context.Context) {
+	if h.redis != nil {
+		h.dropStatusPageCache(ctx)
+	}
+}
+
+func (h *Handler) buildStatusPage(ctx context.Context) StatusPage {
+	now := time.Now()
+	statuses := map[string]string{}
+
+	// Every objective the metrics middleware tracks is served by the API
+	var sloStatuses []string
+	for _, s := range h.slo.Status(now, h.config.SLOBurnAlert) {
+		sloStatuses = append(sloStatuses, sloComponentStatus(s.State))
+	}
+	statuses[statuspage.ComponentAPI] = statuspage.Worst(sloStatuses...)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	dbErr := h.db.Pool().Ping(pingCtx)
+	cancel()
+	var incidents []models.StatusIncident
+	if dbErr != nil {
+		logger.Error("Status page database check failed", "error", dbErr.Error())
+		statuses[statuspage.ComponentDatabase] = statuspage.StatusMajorOutage
+	} else {
+		statuses[statuspage.ComponentDatabase] = statuspage.StatusOperational
+		statuses[statuspage.ComponentAIProvider] = h.aiProviderStatus(ctx, now)
+		statuses[statuspage.ComponentIntegrations] = h.integrationsStatus(ctx, now)
+
+		var err error
+		incidents, err = h.loadStatusIncidents(ctx, `
+			WHERE resolved_at IS NULL OR resolved_at >= NOW() - make_interval(days => $1)
+			ORDER BY (resolved_at IS NULL) DESC, started_at DESC
+			LIMIT 20
+		`, statusIncidentDays)
+		if err != nil {
+			logger.Error("Failed to load status incidents", "error", err.Error())
+		}
+	}
+	// Billing has no external provider: its status comes from incidents only
+
+	for _, incident := range incidents {
+		if incident.ResolvedAt != nil {
+			continue
+		}
+		for _, c := range incident.Components {
+			statuses[c] = statuspage.Worst(statuses[c], statuspage.ImpactStatus(incident.Impact))
+		}
+	}
+
+	page := StatusPage{Incidents: incidents, UpdatedAt: now}
+	if mode := h.maint.Current(); mode.Enabled {
+		page.Maintenance = &MaintenanceNotice{Message: mode.Notice(), EndsAt: mode.EndsAt}
+	}
+	if page.Incidents == nil {
+		page.Incidents = []models.StatusIncident{}
+	}
+	var all []string
+	for _, c := range statuspage.Components() {
+		status := statuspage.Worst(statuses[c.ID])
+		page.Components = append(page.Components, models.ComponentStatus{ID: c.ID, Label: c.Label, Status: status})
+		all = append(all, status)
+	}
+	page.Status = statuspage.Worst(all...)
+	page.Description = statuspage.Overall(all)
+	return page
+}
+
+// sloComponentStatus maps an SLO state to a component status
+func sloComponentStatus(state string) string {
+	switch state {
+	case slo.StateBreached:
+		return statuspage.StatusPartialOutage
+	case slo.StateBurning:
+		return statuspage.StatusDegraded
+	}
+	return statuspage.StatusOperational
+}
+
+// aiProviderStatus judges the AI provider by the recent failure rate of the
+// sampled provider calls. Without sampling there is nothing to judge.
+func (h *Handler) aiProviderStatus(ctx context.Context, now time.Time) string {
+	if h.config.ProviderLogSamplePercent <= 0 {
+		return statuspage.StatusOperational
+	}
+	var failed, total int
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE error IS NOT NULL OR status_code >= 500), COUNT(*)
+		FROM provider_calls
+		WHERE created_at >= $1
+	`, now.Add(-statusCheckWindow)).Scan(&failed, &total)
+	if err != nil {
+		logger.Warn("Status page AI provider check failed", "error", err.Error())
+		return statuspage.StatusOperational
+	}
+	return statuspage.ErrorRateStatus(failed, total, 10)
+}
+
+// integrationsStatus judges integrations by the share of recent runs, across
+// all companies, that failed
+func (h *Handler) integrationsStatus(ctx context.Context, now time.Time) string {
+	var failed, total int
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE failure_streak > 0), COUNT(*)
+		FROM integration_health
+		WHERE updated_at >= $1
+	`, now.Add(-statusCheckWindow)).Scan(&failed, &total)
+	if err != nil {
+		logger.Warn("Status page integrations check failed", "error", err.Error())
+		return statuspage.StatusOperational
+	}
+	return statuspage.ErrorRateStatus(failed, total, 5)
+}
+
+// ListStatusIncidents lists every status page incident, newest first (admin only)
+func (h *Handler) ListStatusIncidents(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, err := h.parsePage(r)
+	if err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	var total int
+	if err := h.db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM status_incidents`).Scan(&total); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "count status incidents"), r)
+		return
+	}
+	incidents, err := h.loadStatusIncidents(ctx, `ORDER BY started_at DESC LIMIT $1 OFFSET $2`, pageSize, (page-1)*pageSize)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "list status incidents"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response.Page{
+		Items:    incidents,
+		Page:     page,
+		PageSize: pageSize,
+		Total:    total,
+	})
+}
+
+// CreateStatusIncident opens an incident with its first update (admin only)
+func (h *Handler) CreateStatusIncident(w http.ResponseWriter, r *http.Request) {
+	var req CreateStatusIncidentRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	components := statuspage.Normalize(req.Components)
+	if err := statuspage.ValidateComponents(components); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid components", err.Error()), r)
+		return
+	}
+	if req.Status == "" {
+		req.Status = statuspage.IncidentInvestigating
+	}
+
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	id := uuid.New().String()
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO status_incidents (id, title, status, impact, components, created_by)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+	`, id, strings.TrimSpace(req.Title), req.Status, req.Impact, components, userID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create status incident"), r)
+		return
+	}
+	if err := insertStatusIncidentUpdate(ctx, tx, id, req.Status, req.Message, userID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create status incident update"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	logger.Info("Status incident opened", "incident_id", id, "impact", req.Impact, "admin_id", userID)
+	h.respondStatusIncident(w, r, http.StatusCreated, id)
+}
+
+// PostStatusIncidentUpdate records progress on an incident and moves it to the
+// given status; "resolved" closes it (admin only)
+func (h *Handler) PostStatusIncidentUpdate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	var req StatusIncidentUpdateRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	var components []string
+	if len(req.Components) > 0 {
+		components = statuspage.Normalize(req.Components)
+		if err := statuspage.ValidateComponents(components); err != nil {
+			h.respondError(w, errors.NewValidationError("Invalid components", err.Error()), r)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	userID := middleware.GetUserID(ctx)
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE status_incidents
+		SET status = $2,
+			impact = COALESCE(NULLIF($3, ''), impact),
+			components = COALESCE($4, components),
+			resolved_at = CASE WHEN $2 = 'resolved' THEN COALESCE(resolved_at, NOW()) ELSE NULL END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, id, req.Status, req.Impact, components)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update status incident"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Incident"), r)
+		return
+	}
+	if err := insertStatusIncidentUpdate(ctx, tx, id, req.Status, req.Message, userID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create status incident update"), r)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	logger.Info("Status incident updated", "incident_id", id, "status", req.Status, "admin_id", userID)
+	h.respondStatusIncident(w, r, http.StatusOK, id)
+}
+
+// DeleteStatusIncident removes an incident posted by mistake (admin only)
+func (h *Handler) DeleteStatusIncident(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	tag, err := h.db.Pool().Exec(r.Context(), `DELETE FROM status_incidents WHERE id = $1`, id)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete status incident"), r)
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		h.respondError(w, errors.NewNotFoundError("Incident"), r)
+		return
+	}
+	h.dropStatusPageCache(r.Context())
+
+	logger.Info("Status incident deleted", "incident_id", id, "admin_id", middleware.GetUserID(r.Context()))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func insertStatusIncidentUpdate(ctx context.Context, tx pgx.Tx, incidentID, status, message, userID string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO status_incident_updates (id, incident_id, status, message, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+	`, uuid.New().String(), incidentID, status, strings.TrimSpace(message), userID)
+	return err
+}
+
+// respondStatusIncident drops the cached status page and responds with the
+// incident as stored
+func (h *Handler) respondStatusIncident(w http.ResponseWriter, r *http.Request, code int, id string) {
+	ctx := r.Context()
+	h.dropStatusPageCache(ctx)
+	incidents, err := h.loadStatusIncidents(ctx, `WHERE id = $1`, id)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load status incident"), r)
+		return
+	}
+	if len(incidents) == 0 {
+		h.respondError(w, errors.NewNotFoundError("Incident"), r)
+		return
+	}
+	h.respondJSON(w, r, code, incidents[0])
+}
+
+// loadStatusIncidents loads incidents selected by clause, with their updates
+// newest first
+func (h *Handler) loadStatusIncidents(ctx context.Context, clause string, args ...interface{}) ([]models.StatusIncident, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, title, status, impact, components, started_at, resolved_at, COALESCE(created_by, ''), updated_at
+		FROM status_incidents
+	`+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	incidents := []models.StatusIncident{}
+	index := map[string]int{}
+	var ids []string
+	for rows.Next() {
+		i := models.StatusIncident{Updates: []models.StatusIncidentUpdate{}}
+		if err := rows.Scan(&i.ID, &i.Title, &i.Status, &i.Impact, &i.Components, &i.StartedAt, &i.ResolvedAt,
+			&i.CreatedBy, &i.UpdatedAt); err != nil {
+			continue
+		}
+		index[i.ID] = len(incidents)
+		ids = append(ids, i.ID)
+		incidents = append(incidents, i)
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return incidents, nil
+	}
+
+	rows, err = h.db.Pool().Query(ctx, `
+		SELECT id, incident_id, status, message, created_at
+		FROM status_incident_updates
+		WHERE incident_id = ANY($1)
+		ORDER BY created_at DESC
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var u models.StatusIncidentUpdate
+		var incidentID string
+		if rows.Scan(&u.ID, &incidentID, &u.Status, &u.Message, &u.CreatedAt) != nil {
+			continue
+		}
+		i := index[incidentID]
+		incidents[i].Updates = append(incidents[i].Updates, u)
+	}
+	return incidents, nil
+}
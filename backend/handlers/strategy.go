@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/aispend"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/services/portfolio"
+	"github.com/bantuaku/backend/services/quality"
+	"github.com/bantuaku/backend/services/strategy"
+	"github.com/bantuaku/backend/validation"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// GeneratePlanRequest represents a request to generate a monthly action plan
+type GeneratePlanRequest struct {
+	Month      string `json:"month,omitempty"` // YYYY-MM, defaults to the current month
+	Regenerate bool   `json:"regenerate,omitempty"`
+}
+
+// UpdateTaskRequest represents a checklist status change
+type UpdateTaskRequest struct {
+	Status string `json:"status" validate:"required,oneof:todo|done"`
+}
+
+// GenerateStrategyPlan builds and stores a monthly action plan with a task checklist
+func (h *Handler) GenerateStrategyPlan(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req GeneratePlanRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	ctx := r.Context()
+	loc := h.companyLocation(ctx, companyID)
+	month, err := planMonth(req.Month, loc)
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid month", "month must be formatted as YYYY-MM"), r)
+		return
+	}
+
+	if existing, err := h.loadStrategyPlan(ctx, companyID, month); err == nil {
+		if !req.Regenerate {
+			h.respondError(w, errors.NewConflictError("Plan already exists for this month", existing.ID), r)
+			return
+		}
+	} else if err != pgx.ErrNoRows {
+		h.respondError(w, errors.NewDatabaseError(err, "load strategy plan"), r)
+		return
+	}
+
+	planCtx := h.gatherPlanContext(ctx, companyID, month, loc)
+	plan := strategy.RuleBasedPlan(planCtx)
+	generatedBy := "rules"
+
+	if h.config.KolosalAPIKey != "" {
+		client := h.kolosalClient()
+		resp, err := client.CreateChatCompletion(aispend.WithFeature(ctx, aispend.FeatureStrategy, month.Format("2006-01")), kolosal.ChatCompletionRequest{
+			Model: "default",
+			Messages: []kolosal.ChatCompletionMessage{
+				{Role: "system", Content: h.withBrandVoice(ctx, companyID, "Kamu adalah konsultan bisnis untuk UMKM Indonesia. Jawab dalam Bahasa Indonesia.")},
+				{Role: "user", Content: strategy.BuildPrompt(planCtx)},
+			},
+			MaxTokens:   1500,
+			Temperature: 0.4,
+		})
+		if err == nil && len(resp.Choices) > 0 {
+			if aiPlan, perr := strategy.ParsePlan(resp.Choices[0].Message.Content); perr == nil {
+				plan = aiPlan
+				generatedBy = "ai"
+			} else {
+				logger.Warn("Falling back to rule-based plan", "company_id", companyID, "error", perr.Error())
+			}
+		} else if err != nil {
+			logger.Warn("Falling back to rule-based plan", "company_id", companyID, "error", err.Error())
+		}
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM strategy_plans WHERE company_id = $1 AND month = $2`, companyID, month); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "replace strategy plan"), r)
+		return
+	}
+
+	planID := uuid.New().String()
+	_, err = tx.Exec(ctx, `
+		INSERT INTO strategy_plans (id, company_id, month, summary, generated_by, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NOW())
+	`, planID, companyID, month, plan.Summary, generatedBy, middleware.GetUserID(ctx))
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "create strategy plan"), r)
+		return
+	}
+	for i, t := range plan.Tasks {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO strategy_tasks (id, plan_id, company_id, title, description, category, effort, impact, sort_order)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, uuid.New().String(), planID, companyID, t.Title, t.Description, t.Category, t.Effort, t.Impact, i)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "create strategy task"), r)
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	created, err := h.loadStrategyPlan(ctx, companyID, month)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load strategy plan"), r)
+		return
+	}
+
+	logger.Info("Strategy plan generated", "company_id", companyID, "month", created.Month,
+		"tasks", len(created.Tasks), "generated_by", generatedBy)
+
+	h.respondJSON(w, r, http.StatusCreated, created)
+}
+
+// GetStrategyPlan returns the plan for ?month=YYYY-MM (default: current month)
+func (h *Handler) GetStrategyPlan(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	month, err := planMonth(r.URL.Query().Get("month"), h.companyLocation(r.Context(), companyID))
+	if err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid month", "month must be formatted as YYYY-MM"), r)
+		return
+	}
+
+	plan, err := h.loadStrategyPlan(r.Context(), companyID, month)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Strategy plan"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load strategy plan"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, plan)
+}
+
+// UpdateStrategyTask marks a checklist item complete or incomplete
+func (h *Handler) UpdateStrategyTask(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	var req UpdateTaskRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	var task models.StrategyTask
+	err := h.db.Pool().QueryRow(r.Context(), `
+		UPDATE strategy_tasks
+		SET status = $3,
+			completed_at = CASE WHEN $3 = 'done' THEN NOW() ELSE NULL END,
+			completed_by = CASE WHEN $3 = 'done' THEN NULLIF($4, '') ELSE NULL END
+		WHERE id = $1 AND company_id = $2
+		RETURNING id, plan_id, title, COALESCE(description, ''), category, effort, impact, status, completed_at
+	`, r.PathValue("id"), companyID, req.Status, middleware.GetUserID(r.Context())).Scan(
+		&task.ID, &task.PlanID, &task.Title, &task.Description, &task.Category, &task.Effort, &task.Impact,
+		&task.Status, &task.CompletedAt)
+	if err == pgx.ErrNoRows {
+		h.respondError(w, errors.NewNotFoundError("Task"), r)
+		return
+	}
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update strategy task"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, task)
+}
+
+func (h *Handler) loadStrategyPlan(ctx context.Context, companyID string, month time.Time) (*models.StrategyPlan, error) {
+	plan := &models.StrategyPlan{CompanyID: companyID, Tasks: []models.StrategyTask{}}
+	var planMonth time.Time
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT id, month, COALESCE(summary, ''), generated_by, created_at
+		FROM strategy_plans WHERE company_id = $1 AND month = $2
+	`, companyID, month).Scan(&plan.ID, &planMonth, &plan.Summary, &plan.GeneratedBy, &plan.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	plan.Month = planMonth.Format("2006-01")
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, plan_id, title, COALESCE(description, ''), category, effort, impact, status, completed_at
+		FROM strategy_tasks WHERE plan_id = $1
+		ORDER BY sort_order
+	`, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.StrategyTask
+		if err := rows.Scan(&t.ID, &t.PlanID, &t.Title, &t.Description, &t.Category, &t.Effort, &t.Impact,
+			&t.Status, &t.CompletedAt); err != nil {
+			return nil, err
+		}
+		plan.Tasks = append(plan.Tasks, t)
+		plan.Progress.Total++
+		if t.Status == models.TaskStatusDone {
+			plan.Progress.Done++
+		} else if t.Impact == "high" {
+			plan.Progress.OpenHighImpact++
+		}
+	}
+	return plan, rows.Err()
+}
+
+func (h *Handler) openHighImpactTasks(ctx context.Context, companyID string, month time.Time) ([]models.StrategyTask, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT t.id, t.plan_id, t.title, COALESCE(t.description, ''), t.category, t.effort, t.impact, t.status
+		FROM strategy_tasks t
+		JOIN strategy_plans p ON p.id = t.plan_id
+		WHERE t.company_id = $1 AND p.month = $2 AND t.status = 'todo' AND t.impact = 'high'
+		ORDER BY t.sort_order
+	`, companyID, month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.StrategyTask{}
+	for rows.Next() {
+		var t models.StrategyTask
+		if err := rows.Scan(&t.ID, &t.PlanID, &t.Title, &t.Description, &t.Category, &t.Effort, &t.Impact, &t.Status); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// gatherPlanContext collects the business data a plan is generated from
+func (h *Handler) gatherPlanContext(ctx context.Context, companyID string, month time.Time, loc *time.Location) strategy.Context {
+	c := strategy.Context{Month: month}
+	h.db.Pool().QueryRow(ctx, `
+		SELECT name, COALESCE(industry, '') FROM companies WHERE id = $1
+	`, companyID).Scan(&c.CompanyName, &c.Industry)
+
+	since := localDate(time.Now(), loc).AddDate(0, 0, -30)
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT p.name, COALESCE(p.unit, 'pcs'), COALESCE(SUM(s.quantity), 0), COALESCE(SUM(s.quantity * s.price), 0)
+		FROM products p
+		LEFT JOIN sales_history s ON s.product_id = p.id AND s.sale_date >= $2 AND NOT COALESCE(s.excluded, false)
+		WHERE p.company_id = $1 AND COALESCE(p.is_active, true)
+		GROUP BY p.id, p.name, p.unit
+		ORDER BY 4 DESC
+	`, companyID, since)
+	if err == nil {
+		for rows.Next() {
+			var p strategy.ProductStat
+			if rows.Scan(&p.Name, &p.Unit, &p.Quantity, &p.Revenue) != nil {
+				continue
+			}
+			if p.Quantity == 0 {
+				c.IdleProducts = append(c.IdleProducts, p.Name)
+			} else if len(c.TopProducts) < 5 {
+				c.TopProducts = append(c.TopProducts, p)
+			}
+		}
+		rows.Close()
+	}
+
+	if results, err := h.classifyPortfolio(ctx, companyID, defaultPortfolioWindowDays); err == nil {
+		c.FocusProducts = portfolio.FocusProducts(results)
+	}
+
+	var thisMonth, lastMonth float64
+	h.db.Pool().QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(quantity * price) FILTER (WHERE sale_date >= $2), 0),
+			COALESCE(SUM(quantity * price) FILTER (WHERE sale_date >= $3 AND sale_date < $2), 0)
+		FROM sales_history
+		WHERE company_id = $1 AND NOT COALESCE(excluded, false)
+	`, companyID, month.AddDate(0, -1, 0), month.AddDate(0, -2, 0)).Scan(&thisMonth, &lastMonth)
+	if lastMonth > 0 {
+		c.RevenueTrend = (thisMonth - lastMonth) / lastMonth * 100
+	}
+
+	// Carry over data issues from products that cannot be forecast yet
+	now := time.Now().In(loc)
+	points, products, err := h.loadDailySales(ctx, companyID, "", localDate(now, loc).AddDate(0, 0, -defaultQualityWindowDays))
+	if err == nil {
+		insufficient := 0
+		for _, p := range products {
+			if quality.Assess(points[p.id], now, defaultQualityWindowDays).Blocking() {
+				insufficient++
+			}
+		}
+		if insufficient > 0 {
+			c.DataIssues = append(c.DataIssues, fmt.Sprintf("%d produk belum punya data penjualan yang cukup untuk prediksi", insufficient))
+		}
+	}
+
+	if open, err := h.openHighImpactTasks(ctx, companyID, month.AddDate(0, -1, 0)); err == nil {
+		for _, t := range open {
+			c.OpenLastMonth = append(c.OpenLastMonth, t.Title)
+		}
+	}
+
+	return c
+}
+
+// planMonth parses YYYY-MM, defaulting to the current month in loc
+func planMonth(s string, loc *time.Location) (time.Time, error) {
+	if s == "" {
+		now := time.Now().In(loc)
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Parse("2006-01", s)
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/units"
+	"github.com/bantuaku/backend/validation"
+)
+
+// CustomUnitRequest defines a product-specific unit relative to the product unit
+type CustomUnitRequest struct {
+	Code   string  `json:"code" validate:"required,max:20"`
+	Label  string  `json:"label" validate:"required,max:100"`
+	Amount float64 `json:"amount"` // Product units per one of this unit, e.g. 50 for "1 karung = 50 kg"
+}
+
+// UpdateProductUnitsRequest represents a request to configure a product's units
+type UpdateProductUnitsRequest struct {
+	Unit        string              `json:"unit" validate:"required,max:20"`
+	CustomUnits []CustomUnitRequest `json:"custom_units"`
+}
+
+// ProductUnitsResponse describes the units a product's sales can be entered in
+type ProductUnitsResponse struct {
+	ProductID   string       `json:"product_id"`
+	Unit        units.Unit   `json:"unit"`
+	CustomUnits []units.Unit `json:"custom_units"`
+	Accepted    []units.Unit `json:"accepted"` // All units a sale may be recorded in
+}
+
+// ListUnits returns the built-in units of measure
+func (h *Handler) ListUnits(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, r, http.StatusOK, units.Catalog())
+}
+
+// GetProductUnits returns a product's unit and custom units
+func (h *Handler) GetProductUnits(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	productID := r.PathValue("id")
+	if !h.productBelongsToCompany(r.Context(), productID, companyID) {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	unit, custom, err := h.loadProductUnits(r.Context(), h.db.Pool(), productID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load product units"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, productUnitsResponse(productID, unit, custom))
+}
+
+// UpdateProductUnits sets a product's unit and custom units.
+// Changing to another unit of the same dimension converts existing sales history;
+// switching dimension (e.g. pcs to kg) is only allowed before any sales are recorded.
+func (h *Handler) UpdateProductUnits(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+
+	productID := r.PathValue("id")
+	if !h.productBelongsToCompany(r.Context(), productID, companyID) {
+		h.respondError(w, errors.NewNotFoundError("Product"), r)
+		return
+	}
+
+	var req UpdateProductUnitsRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+
+	newUnit, ok := units.Get(req.Unit)
+	if !ok {
+		h.respondError(w, errors.NewValidationError("Unknown unit", req.Unit), r)
+		return
+	}
+	custom := make([]units.Unit, 0, len(req.CustomUnits))
+	seen := map[string]bool{}
+	for i, cu := range req.CustomUnits {
+		if err := validation.Validate(&req.CustomUnits[i]); err != nil {
+			h.respondError(w, err, r)
+			return
+		}
+		code := strings.ToLower(strings.TrimSpace(cu.Code))
+		if _, builtin := units.Get(code); builtin || seen[code] {
+			h.respondError(w, errors.NewValidationError("Duplicate unit code", code), r)
+			return
+		}
+		if cu.Amount <= 0 {
+			h.respondError(w, errors.NewValidationError("Validation failed", fmt.Sprintf("custom_units[%d].amount: must be greater than 0", i)), r)
+			return
+		}
+		seen[code] = true
+		custom = append(custom, units.Custom(code, cu.Label, cu.Amount, newUnit))
+	}
+
+	ctx := r.Context()
+	oldUnit, _, err := h.loadProductUnits(ctx, h.db.Pool(), productID)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load product units"), r)
+		return
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if oldUnit.Code != newUnit.Code {
+		if oldUnit.Dimension != newUnit.Dimension {
+			var hasSales bool
+			if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM sales_history WHERE product_id = $1)`, productID).Scan(&hasSales); err != nil {
+				h.respondError(w, errors.NewDatabaseError(err, "check sales history"), r)
+				return
+			}
+			if hasSales {
+				h.respondError(w, errors.NewBusinessRuleError("unit_dimension",
+					fmt.Sprintf("Satuan %s tidak dapat diubah ke %s karena sudah ada riwayat penjualan.", oldUnit.Code, newUnit.Code)), r)
+				return
+			}
+		} else {
+			ratio := oldUnit.Factor / newUnit.Factor
+			_, err = tx.Exec(ctx, `
+				UPDATE sales_history
+				SET quantity = ROUND(quantity * $2, 3), original_quantity = ROUND(original_quantity * $2, 3),
+					price = ROUND(price / $2, 2)
+				WHERE product_id = $1
+			`, productID, ratio)
+			if err != nil {
+				h.respondError(w, errors.NewDatabaseError(err, "convert sales history"), r)
+				return
+			}
+		}
+	}
+
+	if _, err = tx.Exec(ctx, `UPDATE products SET unit = $2, updated_at = NOW() WHERE id = $1`, productID, newUnit.Code); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "update product unit"), r)
+		return
+	}
+	if _, err = tx.Exec(ctx, `DELETE FROM product_units WHERE product_id = $1`, productID); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "replace custom units"), r)
+		return
+	}
+	for i, cu := range custom {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO product_units (product_id, code, label, amount) VALUES ($1, $2, $3, $4)
+		`, productID, cu.Code, cu.Label, req.CustomUnits[i].Amount)
+		if err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "insert custom unit"), r)
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+
+	h.redis.Delete(ctx, fmt.Sprintf("forecast:%s", productID))
+
+	h.respondJSON(w, r, http.StatusOK, productUnitsResponse(productID, newUnit, custom))
+}
+
+// loadProductUnits returns the product's unit and its custom units
+func (h *Handler) loadProductUnits(ctx context.Context, q importQuerier, productID string) (units.Unit, []units.Unit, error) {
+	rows, err := q.Query(ctx, `SELECT COALESCE(unit, '') FROM products WHERE id = $1`, productID)
+	if err != nil {
+		return units.Unit{}, nil, err
+	}
+	code := units.DefaultUnit
+	for rows.Next() {
+		if err := rows.Scan(&code); err != nil {
+			rows.Close()
+			return units.Unit{}, nil, err
+		}
+	}
+	rows.Close()
+
+	// Products created before units existed may carry free-text units
+	unit, ok := units.Get(code)
+	if !ok {
+		unit, _ = units.Get(units.DefaultUnit)
+	}
+
+	rows, err = q.Query(ctx, `SELECT code, label, amount FROM product_units WHERE product_id = $1 ORDER BY code`, productID)
+	if err != nil {
+		return units.Unit{}, nil, err
+	}
+	defer rows.Close()
+
+	custom := []units.Unit{}
+	for rows.Next() {
+		var c, label string
+		var amount float64
+		if err := rows.Scan(&c, &label, &amount); err != nil {
+			return units.Unit{}, nil, err
+		}
+		custom = append(custom, units.Custom(c, label, amount, unit))
+	}
+	return unit, custom, rows.Err()
+}
+
+// saleQuantity is a sale line normalized to the product's unit
+type saleQuantity struct {
+	Quantity     float64 // In the product's unit
+	Price        float64 // Per product unit
+	Unit         string  // Unit as entered
+	UnitQuantity float64 // Quantity as entered
+}
+
+// convertSale validates a sale entered in unitCode and normalizes quantity and price
+// to the product's unit. An empty unitCode means the product's unit.
+func convertSale(productUnit units.Unit, custom []units.Unit, qty, price float64, unitCode string) (saleQuantity, error) {
+	saleUnit := productUnit
+	if unitCode != "" {
+		u, ok := units.Resolve(strings.ToLower(strings.TrimSpace(unitCode)), custom)
+		if !ok {
+			return saleQuantity{}, fmt.Errorf("unknown unit: %s", unitCode)
+		}
+		saleUnit = u
+	}
+
+	converted, err := units.ToProductUnit(qty, saleUnit, productUnit)
+	if err != nil {
+		return saleQuantity{}, err
+	}
+
+	// Price is given per entered unit; keep quantity * price unchanged
+	return saleQuantity{
+		Quantity:     converted,
+		Price:        math.Round(price*qty/converted*100) / 100,
+		Unit:         saleUnit.Code,
+		UnitQuantity: qty,
+	}, nil
+}
+
+func (h *Handler) productBelongsToCompany(ctx context.Context, productID, companyID string) bool {
+	var exists bool
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM products WHERE id = $1 AND company_id = $2)
+	`, productID, companyID).Scan(&exists)
+	return err == nil && exists
+}
+
+func productUnitsResponse(productID string, unit units.Unit, custom []units.Unit) ProductUnitsResponse {
+	resp := ProductUnitsResponse{ProductID: productID, Unit: unit, CustomUnits: custom, Accepted: []units.Unit{}}
+	for _, u := range units.Catalog() {
+		if u.Dimension == unit.Dimension {
+			resp.Accepted = append(resp.Accepted, u)
+		}
+	}
+	resp.Accepted = append(resp.Accepted, custom...)
+	return resp
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bantuaku/backend/services/importer"
+	"github.com/bantuaku/backend/services/units"
+)
+
+func TestConvertSale(t *testing.T) {
+	kg, _ := units.Get("kg")
+	pcs, _ := units.Get("pcs")
+	custom := []units.Unit{units.Custom("karung", "Karung (50 kg)", 50, kg)}
+
+	tests := []struct {
+		name        string
+		productUnit units.Unit
+		qty, price  float64
+		unitCode    string
+		want        saleQuantity
+		wantErr     bool
+	}{
+		{name: "product unit by default", productUnit: kg, qty: 2, price: 15000, want: saleQuantity{Quantity: 2, Price: 15000, Unit: "kg", UnitQuantity: 2}},
+		{name: "catalog unit", productUnit: kg, qty: 500, price: 20, unitCode: "gram", want: saleQuantity{Quantity: 0.5, Price: 20000, Unit: "gram", UnitQuantity: 500}},
+		{name: "custom unit, padded and upper case", productUnit: kg, qty: 2, price: 600000, unitCode: " Karung ", want: saleQuantity{Quantity: 100, Price: 12000, Unit: "karung", UnitQuantity: 2}},
+		{name: "total kept when rounding", productUnit: pcs, qty: 1, price: 10000, unitCode: "lusin", want: saleQuantity{Quantity: 12, Price: 833.33, Unit: "lusin", UnitQuantity: 1}},
+		{name: "unknown unit", productUnit: kg, qty: 1, price: 1, unitCode: "ember", wantErr: true},
+		{name: "other dimension", productUnit: pcs, qty: 1, price: 1, unitCode: "kg", wantErr: true},
+		{name: "fraction of a whole unit", productUnit: pcs, qty: 0.5, price: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertSale(tt.productUnit, custom, tt.qty, tt.price, tt.unitCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("convertSale = %+v, %v; want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportSaleUsesUnitCache(t *testing.T) {
+	kg, _ := units.Get("kg")
+	cache := map[string]importProductUnits{"p1": {unit: kg, custom: []units.Unit{}}}
+	h := &Handler{}
+
+	// A nil querier would panic if the cached product were loaded again
+	sale, err := h.importSale(context.Background(), nil, cache, "p1", importer.Row{Quantity: 250, UnitPrice: 30, Unit: "gram"})
+	if err != nil || sale.Quantity != 0.25 || sale.Price != 30000 {
+		t.Errorf("importSale = %+v, %v", sale, err)
+	}
+
+	// New products take the row's unit
+	sale, err = h.importSale(context.Background(), nil, cache, "", importer.Row{Quantity: 3, UnitPrice: 5000, Unit: "liter"})
+	if err != nil || sale.Unit != "liter" || sale.Quantity != 3 {
+		t.Errorf("importSale for a new product = %+v, %v", sale, err)
+	}
+	if _, err := h.importSale(context.Background(), nil, cache, "", importer.Row{Quantity: 1, Unit: "ember"}); err == nil {
+		t.Error("unknown unit for a new product should fail")
+	}
+}
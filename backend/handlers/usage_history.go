@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/metering"
+)
+
+// usageHistoryMonths is how many months of usage history are returned
+const usageHistoryMonths = 12
+
+// UsageMonth is a company's usage of a metric in one calendar month
+type UsageMonth struct {
+	Month string `json:"month"` // YYYY-MM
+	Used  int64  `json:"used"`
+	Limit int64  `json:"limit"` // -1 when unlimited
+	Plan  string `json:"plan,omitempty"`
+}
+
+// UsageHistory is a company's monthly usage per metric, oldest month first
+type UsageHistory struct {
+	CompanyID string                  `json:"company_id"`
+	Months    []string                `json:"months"`
+	Metrics   map[string][]UsageMonth `json:"metrics"`
+}
+
+// GetUsageHistory returns the company's usage over the last 12 months
+func (h *Handler) GetUsageHistory(w http.ResponseWriter, r *http.Request) {
+	companyID := middleware.GetCompanyID(r.Context())
+	if companyID == "" {
+		h.respondError(w, errors.NewUnauthorizedError("Company not found in context"), r)
+		return
+	}
+	h.respondUsageHistory(w, r, companyID)
+}
+
+// AdminGetUsageHistory returns any company's usage over the last 12 months
+// (admin only)
+func (h *Handler) AdminGetUsageHistory(w http.ResponseWriter, r *http.Request) {
+	companyID := r.PathValue("id")
+	var exists bool
+	if err := h.db.Pool().QueryRow(r.Context(), `SELECT EXISTS(SELECT 1 FROM companies WHERE id = $1)`, companyID).Scan(&exists); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load company"), r)
+		return
+	}
+	if !exists {
+		h.respondError(w, errors.NewNotFoundError("Company"), r)
+		return
+	}
+	h.respondUsageHistory(w, r, companyID)
+}
+
+// respondUsageHistory reads past months from the snapshots and counts the
+// current month live, so it is always up to date
+func (h *Handler) respondUsageHistory(w http.ResponseWriter, r *http.Request, companyID string) {
+	ctx := r.Context()
+	now := time.Now()
+	months := metering.Months(now, usageHistoryMonths)
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT metric, month, used, usage_limit, plan
+		FROM usage_snapshots
+		WHERE company_id = $1 AND month >= $2
+	`, companyID, months[0])
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "load usage history"), r)
+		return
+	}
+	snapshots := map[string]UsageMonth{}
+	for rows.Next() {
+		var metric string
+		var month time.Time
+		var u UsageMonth
+		if rows.Scan(&metric, &month, &u.Used, &u.Limit, &u.Plan) != nil {
+			continue
+		}
+		u.Month = month.Format("2006-01")
+		snapshots[metric+":"+u.Month] = u
+	}
+	rows.Close()
+
+	plan := h.CompanyPlan(ctx, companyID)
+	history := UsageHistory{CompanyID: companyID, Metrics: map[string][]UsageMonth{}}
+	for _, month := range months {
+		history.Months = append(history.Months, month.Format("2006-01"))
+	}
+	for _, m := range metering.Metrics {
+		series := make([]UsageMonth, 0, len(months))
+		for i, month := range months {
+			label := history.Months[i]
+			if i == len(months)-1 {
+				used, err := h.usageBetween(ctx, companyID, m, month, month.AddDate(0, 1, 0))
+				if err != nil {
+					h.respondError(w, errors.NewDatabaseError(err, "load usage"), r)
+					return
+				}
+				series = append(series, UsageMonth{Month: label, Used: used, Limit: m.Limit(plan), Plan: plan})
+				continue
+			}
+			u, ok := snapshots[m.Name+":"+label]
+			if !ok {
+				u = UsageMonth{Month: label, Limit: m.Limit(plan)}
+			}
+			series = append(series, u)
+		}
+		history.Metrics[m.Name] = series
+	}
+
+	h.respondJSON(w, r, http.StatusOK, history)
+}
+
+// SnapshotUsage writes each active company's usage of the current month to
+// usage_snapshots, and finalizes the previous month once. It is run
+// periodically from main.
+func (h *Handler) SnapshotUsage(ctx context.Context) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT id, COALESCE(subscription_plan, 'free') FROM companies
+		WHERE COALESCE(status, 'active') = 'active' AND merged_into IS NULL
+	`)
+	if err != nil {
+		logger.Error("Usage snapshot failed to list companies", "error", err.Error())
+		return
+	}
+	type company struct{ id, plan string }
+	var companies []company
+	for rows.Next() {
+		var c company
+		if rows.Scan(&c.id, &c.plan) == nil {
+			companies = append(companies, c)
+		}
+	}
+	rows.Close()
+
+	current := metering.MonthStart(time.Now())
+	previous := current.AddDate(0, -1, 0)
+	written, failed := 0, 0
+	for _, c := range companies {
+		var finalized bool
+		err := h.db.Pool().QueryRow(ctx, `
+			SELECT EXISTS(SELECT 1 FROM usage_snapshots WHERE company_id = $1 AND month = $2 AND final)
+		`, c.id, previous).Scan(&finalized)
+		if err != nil {
+			failed++
+			continue
+		}
+		months := []time.Time{current}
+		if !finalized {
+			months = append(months, previous)
+		}
+		for _, month := range months {
+			if err := h.snapshotCompanyUsage(ctx, c.id, c.plan, month, month.Before(current)); err != nil {
+				logger.Warn("Failed to snapshot usage", "company_id", c.id, "month", month.Format("2006-01"), "error", err.Error())
+				failed++
+				continue
+			}
+			written++
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	logger.Info("Usage snapshot completed", "companies", len(companies), "snapshots", written, "failed", failed)
+}
+
+// snapshotCompanyUsage stores a company's usage of every metric in month
+func (h *Handler) snapshotCompanyUsage(ctx context.Context, companyID, plan string, month time.Time, final bool) error {
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for _, m := range metering.Metrics {
+		used, err := h.usageBetween(ctx, companyID, m, month, month.AddDate(0, 1, 0))
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO usage_snapshots (company_id, metric, month, used, usage_limit, plan, final, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+			ON CONFLICT (company_id, metric, month) DO UPDATE
+			SET used = EXCLUDED.used,
+				-- A finished month keeps the plan it was last snapshotted under
+				usage_limit = CASE WHEN EXCLUDED.final THEN usage_snapshots.usage_limit ELSE EXCLUDED.usage_limit END,
+				plan = CASE WHEN EXCLUDED.final THEN usage_snapshots.plan ELSE EXCLUDED.plan END,
+				final = EXCLUDED.final, updated_at = NOW()
+		`, companyID, m.Name, month, used, m.Limit(plan), plan, final)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
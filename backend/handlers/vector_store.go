@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bantuaku/backend/errors"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/embeddings"
+	"github.com/bantuaku/backend/services/kolosal"
+	"github.com/bantuaku/backend/validation"
+)
+
+// Vector search limits
+const (
+	defaultVectorSearchLimit = 5
+	maxVectorSearchLimit     = 20
+)
+
+// VectorSearchRequest represents a semantic search within one namespace
+type VectorSearchRequest struct {
+	Namespace string  `json:"namespace" validate:"required"`
+	Query     string  `json:"query" validate:"required,max:1000"`
+	Limit     int     `json:"limit,omitempty"`
+	MinScore  float64 `json:"min_score,omitempty"`
+}
+
+// ListEmbeddingNamespaces returns per-namespace vector store stats (platform admin only)
+func (h *Handler) ListEmbeddingNamespaces(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.embeddingNamespaceStats(r.Context())
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "embedding namespace stats"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"namespaces": stats,
+	})
+}
+
+// DeleteEmbeddingNamespace removes every chunk and embedding in a namespace (platform admin only)
+func (h *Handler) DeleteEmbeddingNamespace(w http.ResponseWriter, r *http.Request) {
+	namespace := r.PathValue("namespace")
+	if err := embeddings.ValidateNamespace(namespace); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid namespace", err.Error()), r)
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "begin transaction"), r)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	chunks, err := tx.Exec(ctx, `DELETE FROM embedding_chunks WHERE namespace = $1`, namespace)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete namespace chunks"), r)
+		return
+	}
+	vectors, err := tx.Exec(ctx, `DELETE FROM embeddings WHERE namespace = $1`, namespace)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "delete namespace embeddings"), r)
+		return
+	}
+	// Without chunks, regulation documents would be skipped as unchanged on the next index run
+	if namespace == embeddings.NamespaceRegulations {
+		if _, err := tx.Exec(ctx, `DELETE FROM regulation_documents`); err != nil {
+			h.respondError(w, errors.NewDatabaseError(err, "delete regulation documents"), r)
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "commit transaction"), r)
+		return
+	}
+	if namespace == embeddings.NamespaceRegulations {
+		h.invalidateAnswerCache(ctx, "regulations index deleted")
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"namespace":          namespace,
+		"chunks_deleted":     chunks.RowsAffected(),
+		"embeddings_deleted": vectors.RowsAffected(),
+	})
+}
+
+// SearchEmbeddings runs a semantic search in one namespace (platform admin only)
+func (h *Handler) SearchEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req VectorSearchRequest
+	if err := h.parseJSON(r, &req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := validation.Validate(&req); err != nil {
+		h.respondError(w, err, r)
+		return
+	}
+	if err := embeddings.ValidateNamespace(req.Namespace); err != nil {
+		h.respondError(w, errors.NewValidationError("Invalid namespace", err.Error()), r)
+		return
+	}
+	if h.config.KolosalAPIKey == "" {
+		h.respondError(w, errors.NewExternalServiceError("Kolosal.ai", "Embeddings are not configured", "KOLOSAL_API_KEY is not set"), r)
+		return
+	}
+
+	client := h.kolosalClient()
+	matches, err := h.searchVectors(r.Context(), client, req.Namespace, nil, req.Query, req.Limit, req.MinScore)
+	if err != nil {
+		h.respondError(w, errors.NewInternalError(err, "Vector search failed"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]interface{}{
+		"namespace": req.Namespace,
+		"matches":   matches,
+	})
+}
+
+// CollectEmbeddingGarbage deletes embeddings no chunk references anymore, optionally
+// limited to ?namespace= (platform admin only)
+func (h *Handler) CollectEmbeddingGarbage(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+	if namespace != "" {
+		if err := embeddings.ValidateNamespace(namespace); err != nil {
+			h.respondError(w, errors.NewValidationError("Invalid namespace", err.Error()), r)
+			return
+		}
+	}
+
+	removed, err := h.collectOrphanEmbeddings(r.Context(), namespace)
+	if err != nil {
+		h.respondError(w, errors.NewDatabaseError(err, "collect orphan embeddings"), r)
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]int{"orphans_removed": removed})
+}
+
+// embedTexts stores texts as a source's chunks and returns their embeddings, in
+// order; texts whose embedding could not be loaded get nil
+func (h *Handler) embedTexts(ctx context.Context, namespace, source string, texts []string) ([][]float32, error) {
+	hashes := make([]string, len(texts))
+	for i, t := range texts {
+		hashes[i] = embeddings.ContentHash(t)
+	}
+	var usage models.EmbeddingUsage
+	client := h.kolosalClient()
+	if err := h.storeChunks(ctx, client, namespace, source, texts, &usage); err != nil {
+		return nil, err
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT content_hash, embedding FROM embeddings WHERE namespace = $1 AND content_hash = ANY($2) AND model = $3
+	`, namespace, hashes, h.config.EmbeddingModel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	byHash := map[string][]float32{}
+	for rows.Next() {
+		var hash string
+		var vector []float32
+		if rows.Scan(&hash, &vector) == nil {
+			byHash[hash] = vector
+		}
+	}
+	vectors := make([][]float32, len(texts))
+	for i, hash := range hashes {
+		vectors[i] = byHash[hash]
+	}
+	return vectors, rows.Err()
+}
+
+// storeChunks replaces a source's chunks in a namespace, embedding only content
+// that has no stored embedding in that namespace for the configured model
+func (h *Handler) storeChunks(ctx context.Context, client *kolosal.Client, namespace, sourceID string, chunks []string, usage *models.EmbeddingUsage) error {
+	hashes := make([]string, len(chunks))
+	contentByHash := map[string]string{}
+	for i, c := range chunks {
+		hashes[i] = embeddings.ContentHash(c)
+		contentByHash[hashes[i]] = c
+	}
+
+	known := map[string]bool{}
+	knownTokens := map[string]int{}
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT content_hash, token_count FROM embeddings
+		WHERE namespace = $1 AND content_hash = ANY($2) AND model = $3
+	`, namespace, hashes, h.config.EmbeddingModel)
+	if err != nil {
+		return fmt.Errorf("lookup embeddings: %w", err)
+	}
+	for rows.Next() {
+		var hash string
+		var tokens int
+		if rows.Scan(&hash, &tokens) == nil {
+			known[hash] = true
+			knownTokens[hash] = tokens
+		}
+	}
+	rows.Close()
+
+	missing := embeddings.MissingHashes(hashes, known)
+	usage.ChunksTotal += len(chunks)
+	usage.ChunksEmbedded += len(missing)
+	usage.ChunksReused += len(chunks) - len(missing)
+	seen := map[string]bool{}
+	for _, hash := range hashes {
+		if known[hash] {
+			usage.TokensSaved += knownTokens[hash]
+		} else if seen[hash] {
+			// Repeated chunk within this source; it is embedded only once
+			usage.TokensSaved += embeddings.EstimateTokens(contentByHash[hash])
+		}
+		seen[hash] = true
+	}
+
+	for start := 0; start < len(missing); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+		batch := missing[start:end]
+		inputs := make([]string, len(batch))
+		for i, hash := range batch {
+			inputs[i] = contentByHash[hash]
+		}
+
+		resp, err := client.CreateEmbeddings(ctx, kolosal.EmbeddingRequest{Model: h.config.EmbeddingModel, Input: inputs})
+		if err != nil {
+			return fmt.Errorf("embed chunks: %w", err)
+		}
+		if len(resp.Data) != len(batch) {
+			return fmt.Errorf("embed chunks: got %d embeddings for %d inputs", len(resp.Data), len(batch))
+		}
+		usage.TokensSpent += resp.Usage.TotalTokens
+
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(batch) {
+				return fmt.Errorf("embed chunks: unexpected index %d", d.Index)
+			}
+			_, err := h.db.Pool().Exec(ctx, `
+				INSERT INTO embeddings (namespace, content_hash, model, embedding, token_count, created_at)
+				VALUES ($1, $2, $3, $4, $5, NOW())
+				ON CONFLICT (namespace, content_hash) DO UPDATE
+				SET model = EXCLUDED.model, embedding = EXCLUDED.embedding,
+					token_count = EXCLUDED.token_count, created_at = EXCLUDED.created_at
+			`, namespace, batch[d.Index], h.config.EmbeddingModel, d.Embedding, embeddings.EstimateTokens(inputs[d.Index]))
+			if err != nil {
+				return fmt.Errorf("store embedding: %w", err)
+			}
+		}
+	}
+
+	tx, err := h.db.Pool().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM embedding_chunks WHERE namespace = $1 AND source_id = $2`, namespace, sourceID); err != nil {
+		return fmt.Errorf("clear chunks: %w", err)
+	}
+	for i, c := range chunks {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO embedding_chunks (namespace, source_id, chunk_index, content, content_hash)
+			VALUES ($1, $2, $3, $4, $5)
+		`, namespace, sourceID, i, c, hashes[i])
+		if err != nil {
+			return fmt.Errorf("save chunk: %w", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// sourceEmbeddingTokens sums the tokens originally spent embedding a source's chunks
+func (h *Handler) sourceEmbeddingTokens(ctx context.Context, namespace, sourceID string) (int, error) {
+	var tokens int
+	err := h.db.Pool().QueryRow(ctx, `
+		SELECT COALESCE(SUM(e.token_count), 0)
+		FROM embedding_chunks c
+		JOIN embeddings e ON e.namespace = c.namespace AND e.content_hash = c.content_hash
+		WHERE c.namespace = $1 AND c.source_id = $2
+	`, namespace, sourceID).Scan(&tokens)
+	return tokens, err
+}
+
+// searchVectors embeds query and ranks the namespace's chunks by cosine similarity.
+// A non-nil sourceIDs restricts the search to those sources.
+func (h *Handler) searchVectors(ctx context.Context, client *kolosal.Client, namespace string, sourceIDs []string, query string, limit int, minScore float64) ([]models.VectorMatch, error) {
+	if limit <= 0 {
+		limit = defaultVectorSearchLimit
+	}
+	if limit > maxVectorSearchLimit {
+		limit = maxVectorSearchLimit
+	}
+
+	resp, err := client.CreateEmbeddings(ctx, kolosal.EmbeddingRequest{Model: h.config.EmbeddingModel, Input: []string{query}})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	if len(resp.Data) != 1 {
+		return nil, fmt.Errorf("embed query: got %d embeddings", len(resp.Data))
+	}
+
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT c.source_id, c.chunk_index, c.content, e.embedding
+		FROM embedding_chunks c
+		JOIN embeddings e ON e.namespace = c.namespace AND e.content_hash = c.content_hash
+		WHERE c.namespace = $1 AND e.model = $2 AND ($3::text[] IS NULL OR c.source_id = ANY($3))
+	`, namespace, h.config.EmbeddingModel, sourceIDs)
+	if err != nil {
+		return nil, fmt.Errorf("load vectors: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []models.VectorMatch
+	var vectors [][]float32
+	for rows.Next() {
+		m := models.VectorMatch{Namespace: namespace}
+		var vector []float32
+		if err := rows.Scan(&m.SourceID, &m.ChunkIndex, &m.Content, &vector); err != nil {
+			continue
+		}
+		chunks = append(chunks, m)
+		vectors = append(vectors, vector)
+	}
+
+	matches := []models.VectorMatch{}
+	for _, s := range embeddings.TopK(resp.Data[0].Embedding, vectors, limit, minScore) {
+		m := chunks[s.Index]
+		m.Score = s.Score
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
+
+// collectOrphanEmbeddings deletes embeddings whose content no chunk in the same
+// namespace references. An empty namespace collects across all namespaces.
+func (h *Handler) collectOrphanEmbeddings(ctx context.Context, namespace string) (int, error) {
+	tag, err := h.db.Pool().Exec(ctx, `
+		DELETE FROM embeddings e
+		WHERE ($1 = '' OR e.namespace = $1)
+			AND NOT EXISTS (
+				SELECT 1 FROM embedding_chunks c
+				WHERE c.namespace = e.namespace AND c.content_hash = e.content_hash
+			)
+	`, namespace)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (h *Handler) embeddingNamespaceStats(ctx context.Context) ([]models.EmbeddingNamespaceStats, error) {
+	rows, err := h.db.Pool().Query(ctx, `
+		SELECT n.namespace,
+			(SELECT COUNT(DISTINCT source_id) FROM embedding_chunks c WHERE c.namespace = n.namespace),
+			(SELECT COUNT(*) FROM embedding_chunks c WHERE c.namespace = n.namespace),
+			(SELECT COUNT(*) FROM embeddings e WHERE e.namespace = n.namespace),
+			(SELECT COALESCE(SUM(token_count), 0) FROM embeddings e WHERE e.namespace = n.namespace)
+		FROM (
+			SELECT namespace FROM embedding_chunks
+			UNION
+			SELECT namespace FROM embeddings
+		) n
+		ORDER BY n.namespace
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []models.EmbeddingNamespaceStats{}
+	for rows.Next() {
+		var s models.EmbeddingNamespaceStats
+		if err := rows.Scan(&s.Namespace, &s.Sources, &s.Chunks, &s.Embeddings, &s.Tokens); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bantuaku/backend/config"
+	"github.com/bantuaku/backend/handlers"
+	"github.com/bantuaku/backend/services/kbli"
+	"github.com/bantuaku/backend/services/storage"
+)
+
+// runKBLI implements `bantuaku kbli`, which loads the KBLI 2020 classification
+// used to standardize company industries, and returns the exit code
+func runKBLI(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("kbli", flag.ContinueOnError)
+	file := fs.String("file", "", "CSV of code,title rows covering KBLI 2020 down to 5-digit codes (required)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bantuaku kbli -file kbli2020.csv")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *file == "" {
+		fs.Usage()
+		return 2
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open %s: %v\n", *file, err)
+		return 1
+	}
+	defer f.Close()
+	list, err := kbli.ParseCSV(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse %s: %v\n", *file, err)
+		return 1
+	}
+
+	db, err := storage.NewPostgres(cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	h, err := handlers.New(db, nil, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	n, err := h.ImportKBLI(context.Background(), list)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import KBLI: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Imported %d KBLI categories into %s\n", n, maskDatabaseURL(cfg.DatabaseURL))
+	return 0
+}
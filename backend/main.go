@@ -12,6 +12,9 @@ import (
 	"github.com/bantuaku/backend/handlers"
 	"github.com/bantuaku/backend/logger"
 	"github.com/bantuaku/backend/middleware"
+	"github.com/bantuaku/backend/services/cors"
+	"github.com/bantuaku/backend/services/lifecycle"
+	"github.com/bantuaku/backend/services/ratelimit"
 	"github.com/bantuaku/backend/services/storage"
 )
 
@@ -26,8 +29,30 @@ func main() {
 		Output: os.Stdout,
 	})
 
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "anonymize" {
+		os.Exit(runAnonymize(cfg, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "regions" {
+		os.Exit(runRegions(cfg, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "kbli" {
+		os.Exit(runKBLI(cfg, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rageval" {
+		os.Exit(runRAGEval(cfg, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "check-config" {
+		os.Exit(runCheckConfig(cfg))
+	}
+
 	log := logger.Default()
-	log.Info("Starting Bantuaku API server", "version", "0.1.0")
+	log.Info("Starting Bantuaku API server", "version", "0.1.0", "environment", cfg.Environment)
+
+	// Fail fast on configuration the server cannot run with
+	if !checkConfig(cfg, log) {
+		os.Exit(1)
+	}
 
 	// Initialize database connection
 	log.Info("Connecting to database", "url", maskDatabaseURL(cfg.DatabaseURL))
@@ -50,75 +75,381 @@ func main() {
 		log.Info("Redis connection established")
 	}
 
+	if err := middleware.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Error("Invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+
 	// Create handler with dependencies
-	h := handlers.New(db, redis, cfg)
+	h, err := handlers.New(db, redis, cfg)
+	if err != nil {
+		log.Error("Failed to initialize handlers", "error", err)
+		os.Exit(1)
+	}
+	middleware.SetAdminSessionCheck(h.ValidateAdminSession)
+	middleware.SetCompanyAccess(h.CompanyRole)
 	log.Info("HTTP handlers initialized")
 
+	if cfg.DevMocks {
+		if err := h.SeedDemoCompany(context.Background()); err != nil {
+			log.Warn("Failed to seed demo company", "error", err.Error())
+		}
+		log.Warn("Development mocks enabled: AI providers, Exa and email are faked", "demo_email", handlers.DemoEmail, "demo_password", handlers.DemoPassword)
+	}
+
+	// Rate limits: built-in profiles plus admin overrides, reloaded periodically below
+	h.ReloadRateLimits(context.Background())
+	// Platform settings such as maintenance mode, likewise reloaded below
+	h.ReloadSettings(context.Background())
+	limiter := h.RateLimiter()
+	semaphore := h.Semaphore()
+	aiQueue, aiQueueWait := h.AIQueue(), time.Duration(cfg.AIQueueWaitSeconds)*time.Second
+
 	// Setup router
 	mux := http.NewServeMux()
 
 	// Health check
 	mux.HandleFunc("GET /healthz", h.HealthCheck)
+	mux.HandleFunc("GET /api/v1/slo/status", h.GetSLOStatus)
+	mux.HandleFunc("GET /status", h.GetStatusPage)
 
 	// Auth routes (public)
-	mux.HandleFunc("POST /api/v1/auth/register", h.Register)
-	mux.HandleFunc("POST /api/v1/auth/login", h.Login)
+	mux.HandleFunc("POST /api/v1/auth/register", middleware.RateLimit(limiter, ratelimit.ProfileAuth, nil, h.Register))
+	mux.HandleFunc("POST /api/v1/auth/login", middleware.RateLimit(limiter, ratelimit.ProfileAuth, nil, h.Login))
+	mux.HandleFunc("POST /api/v1/auth/set-password", middleware.RateLimit(limiter, ratelimit.ProfileAuth, nil, h.SetPassword))
+	mux.HandleFunc("GET /api/v1/auth/sso/start", middleware.RateLimit(limiter, ratelimit.ProfileAuth, nil, h.StartSSO))
+	mux.HandleFunc("POST /api/v1/auth/sso/callback", middleware.RateLimit(limiter, ratelimit.ProfileAuth, nil, h.SSOCallback))
+	mux.HandleFunc("POST /api/v1/auth/admin-devices/approve", middleware.RateLimit(limiter, ratelimit.ProfileAuth, nil, h.ApproveAdminDevice))
+	mux.HandleFunc("POST /api/v1/auth/sso/link", middleware.Auth(cfg.JWTSecret, middleware.RateLimit(limiter, ratelimit.ProfileAuth, nil, h.LinkSSO)))
 
 	// Protected routes
 	mux.HandleFunc("GET /api/v1/products", middleware.Auth(cfg.JWTSecret, h.ListProducts))
 	mux.HandleFunc("POST /api/v1/products", middleware.Auth(cfg.JWTSecret, h.CreateProduct))
+	mux.HandleFunc("GET /api/v1/products/export", middleware.Auth(cfg.JWTSecret, h.ExportCatalog))
+	mux.HandleFunc("POST /api/v1/products/merge", middleware.Auth(cfg.JWTSecret, h.MergeProducts))
 	mux.HandleFunc("GET /api/v1/products/{id}", middleware.Auth(cfg.JWTSecret, h.GetProduct))
 	mux.HandleFunc("PUT /api/v1/products/{id}", middleware.Auth(cfg.JWTSecret, h.UpdateProduct))
 	mux.HandleFunc("DELETE /api/v1/products/{id}", middleware.Auth(cfg.JWTSecret, h.DeleteProduct))
+	mux.HandleFunc("PUT /api/v1/products/{id}/purchasing", middleware.Auth(cfg.JWTSecret, h.UpdatePurchasingSettings))
+	mux.HandleFunc("GET /api/v1/products/{id}/images", middleware.Auth(cfg.JWTSecret, h.ListProductImages))
+	mux.HandleFunc("POST /api/v1/products/{id}/images", middleware.Auth(cfg.JWTSecret, h.UploadProductImage))
+	mux.HandleFunc("PUT /api/v1/products/{id}/images/{image_id}/primary", middleware.Auth(cfg.JWTSecret, h.SetPrimaryProductImage))
+	mux.HandleFunc("DELETE /api/v1/products/{id}/images/{image_id}", middleware.Auth(cfg.JWTSecret, h.DeleteProductImage))
+	mux.HandleFunc("GET /api/v1/products/{id}/images/{image_id}/{variant}", middleware.Auth(cfg.JWTSecret, h.ServeProductImage))
+	mux.HandleFunc("GET /api/v1/products/{id}/units", middleware.Auth(cfg.JWTSecret, h.GetProductUnits))
+	mux.HandleFunc("PUT /api/v1/products/{id}/units", middleware.Auth(cfg.JWTSecret, h.UpdateProductUnits))
+	mux.HandleFunc("GET /api/v1/units", middleware.Auth(cfg.JWTSecret, h.ListUnits))
+	mux.HandleFunc("GET /api/v1/products/{id}/pricing", middleware.Auth(cfg.JWTSecret, h.GetProductPricing))
+	mux.HandleFunc("POST /api/v1/pricing/calculate", middleware.Auth(cfg.JWTSecret, h.CalculatePricing))
 
 	// Sales data input
 	mux.HandleFunc("POST /api/v1/sales/manual", middleware.Auth(cfg.JWTSecret, h.RecordSale))
-	mux.HandleFunc("POST /api/v1/sales/import-csv", middleware.Auth(cfg.JWTSecret, h.ImportCSV))
+	mux.HandleFunc("POST /api/v1/sales/import-csv", middleware.Auth(cfg.JWTSecret, middleware.ConcurrencyLimit(semaphore, ratelimit.OperationImport, h.CompanyPlan, h.ImportCSV)))
 	mux.HandleFunc("GET /api/v1/sales", middleware.Auth(cfg.JWTSecret, h.ListSales))
+	mux.HandleFunc("GET /api/v1/sales/anomalies", middleware.Auth(cfg.JWTSecret, h.ListSalesAnomalies))
+	mux.HandleFunc("POST /api/v1/sales/{id}/review", middleware.Auth(cfg.JWTSecret, h.ReviewSale))
+	mux.HandleFunc("POST /api/v1/sales/bulk/preview", middleware.Auth(cfg.JWTSecret, h.PreviewBulkSales))
+	mux.HandleFunc("POST /api/v1/sales/bulk", middleware.Auth(cfg.JWTSecret, h.ApplyBulkSales))
+	mux.HandleFunc("PUT /api/v1/sales/{id}", middleware.Auth(cfg.JWTSecret, h.UpdateSale))
+	mux.HandleFunc("DELETE /api/v1/sales/{id}", middleware.Auth(cfg.JWTSecret, h.DeleteSale))
+	mux.HandleFunc("GET /api/v1/sales/{id}/changes", middleware.Auth(cfg.JWTSecret, h.ListSaleChanges))
 
 	// WooCommerce integration
 	mux.HandleFunc("POST /api/v1/integrations/woocommerce/connect", middleware.Auth(cfg.JWTSecret, h.WooCommerceConnect))
 	mux.HandleFunc("GET /api/v1/integrations/woocommerce/sync-status", middleware.Auth(cfg.JWTSecret, h.WooCommerceSyncStatus))
-	mux.HandleFunc("POST /api/v1/integrations/woocommerce/sync-now", middleware.Auth(cfg.JWTSecret, h.WooCommerceSyncNow))
+	mux.HandleFunc("GET /api/v1/integrations/health", middleware.Auth(cfg.JWTSecret, h.GetIntegrationHealth))
+	mux.HandleFunc("POST /api/v1/integrations/woocommerce/credentials/rotate", middleware.Auth(cfg.JWTSecret, h.StartWooCommerceRotation))
+	mux.HandleFunc("PUT /api/v1/integrations/woocommerce/credentials", middleware.Auth(cfg.JWTSecret, h.UpdateWooCommerceCredentials))
+	mux.HandleFunc("POST /api/v1/integrations/woocommerce/credentials/callback", middleware.RateLimit(limiter, ratelimit.ProfileAuth, nil, h.WooCommerceRotationCallback))
+	mux.HandleFunc("POST /api/v1/integrations/woocommerce/sync-now", middleware.Auth(cfg.JWTSecret, middleware.ConcurrencyLimit(semaphore, ratelimit.OperationSync, h.CompanyPlan, h.WooCommerceSyncNow)))
 
 	// Forecasting
+	mux.HandleFunc("GET /api/v1/sales/quality", middleware.Auth(cfg.JWTSecret, h.GetSalesQuality))
 	mux.HandleFunc("GET /api/v1/forecasts/{product_id}", middleware.Auth(cfg.JWTSecret, h.GetForecast))
+	mux.HandleFunc("GET /api/v1/forecasts/{product_id}/explain", middleware.Auth(cfg.JWTSecret, h.ExplainForecast))
+	mux.HandleFunc("GET /api/v1/forecasts/{product_id}/analog", middleware.Auth(cfg.JWTSecret, h.GetAnalogForecast))
+	mux.HandleFunc("GET /api/v1/forecasts/{product_id}/series", middleware.Auth(cfg.JWTSecret, h.GetForecastSeries))
+	mux.HandleFunc("GET /api/v1/purchasing/plan", middleware.Auth(cfg.JWTSecret, h.GetPurchasePlan))
 	mux.HandleFunc("GET /api/v1/recommendations", middleware.Auth(cfg.JWTSecret, h.GetRecommendations))
+	mux.HandleFunc("GET /api/v1/recommendations/reorder", middleware.Auth(cfg.JWTSecret, h.GetReorderRecommendations))
+	mux.HandleFunc("GET /api/v1/analytics/portfolio", middleware.Auth(cfg.JWTSecret, h.GetPortfolio))
+
+	// Inventory
+	mux.HandleFunc("GET /api/v1/inventory", middleware.Auth(cfg.JWTSecret, h.ListInventory))
+	mux.HandleFunc("GET /api/v1/inventory/movements", middleware.Auth(cfg.JWTSecret, h.ListStockMovements))
+	mux.HandleFunc("POST /api/v1/inventory/movements", middleware.Auth(cfg.JWTSecret, h.RecordStockMovement))
+	mux.HandleFunc("GET /api/v1/inventory/{product_id}", middleware.Auth(cfg.JWTSecret, h.GetStockLevel))
+	mux.HandleFunc("PUT /api/v1/inventory/{product_id}", middleware.Auth(cfg.JWTSecret, h.UpdateStockLevel))
 
 	// Sentiment & Market
 	mux.HandleFunc("GET /api/v1/sentiment/{product_id}", middleware.Auth(cfg.JWTSecret, h.GetSentiment))
 	mux.HandleFunc("GET /api/v1/market/trends", middleware.Auth(cfg.JWTSecret, h.GetMarketTrends))
+	mux.HandleFunc("GET /api/v1/market/keywords", middleware.Auth(cfg.JWTSecret, h.GetMarketKeywords))
 
 	// AI Assistant (legacy)
-	mux.HandleFunc("POST /api/v1/ai/analyze", middleware.Auth(cfg.JWTSecret, h.AIAnalyze))
+	mux.HandleFunc("POST /api/v1/ai/analyze", middleware.Auth(cfg.JWTSecret, middleware.Queue(aiQueue, aiQueueWait, h.AIAnalyze)))
 
 	// Chat & Conversations (NEW)
 	mux.HandleFunc("POST /api/v1/chat/start", middleware.Auth(cfg.JWTSecret, h.StartConversation))
-	mux.HandleFunc("POST /api/v1/chat/message", middleware.Auth(cfg.JWTSecret, h.SendMessage))
+	mux.HandleFunc("POST /api/v1/chat/message", middleware.Auth(cfg.JWTSecret, middleware.RateLimit(limiter, ratelimit.ProfileAI, h.CompanyPlan, middleware.ConcurrencyLimit(semaphore, ratelimit.OperationChat, h.CompanyPlan, middleware.Queue(aiQueue, aiQueueWait, h.SendMessage)))))
+	mux.HandleFunc("POST /api/v1/chat/message/stream", middleware.Auth(cfg.JWTSecret, middleware.RateLimit(limiter, ratelimit.ProfileAI, h.CompanyPlan, middleware.ConcurrencyLimit(semaphore, ratelimit.OperationChat, h.CompanyPlan, middleware.Queue(aiQueue, aiQueueWait, h.SendMessageStream)))))
+	mux.HandleFunc("POST /api/v1/chat/messages/{id}/feedback", middleware.Auth(cfg.JWTSecret, middleware.RateLimit(limiter, ratelimit.ProfileAI, h.CompanyPlan, middleware.ConcurrencyLimit(semaphore, ratelimit.OperationChat, h.CompanyPlan, middleware.Queue(aiQueue, aiQueueWait, h.SubmitMessageFeedback)))))
+	mux.HandleFunc("POST /api/v1/chat/messages/{id}/regenerate", middleware.Auth(cfg.JWTSecret, middleware.RateLimit(limiter, ratelimit.ProfileAI, h.CompanyPlan, middleware.ConcurrencyLimit(semaphore, ratelimit.OperationChat, h.CompanyPlan, middleware.Queue(aiQueue, aiQueueWait, h.RegenerateMessage)))))
+	mux.HandleFunc("GET /api/v1/chat/messages/{id}/regenerations", middleware.Auth(cfg.JWTSecret, h.ListMessageRegenerations))
+	mux.HandleFunc("POST /api/v1/chat/messages/{id}/regenerations/{regeneration_id}/accept", middleware.Auth(cfg.JWTSecret, h.AcceptMessageRegeneration))
 	mux.HandleFunc("GET /api/v1/chat/conversations", middleware.Auth(cfg.JWTSecret, h.GetConversations))
+	mux.HandleFunc("DELETE /api/v1/chat/conversations/{id}", middleware.Auth(cfg.JWTSecret, h.DeleteConversation))
+	mux.HandleFunc("PUT /api/v1/chat/conversations/{id}/archive", middleware.Auth(cfg.JWTSecret, h.SetConversationArchived))
 	mux.HandleFunc("GET /api/v1/chat/messages", middleware.Auth(cfg.JWTSecret, h.GetMessages))
+	mux.HandleFunc("GET /api/v1/chat/suggestions", middleware.Auth(cfg.JWTSecret, h.GetChatSuggestions))
+	mux.HandleFunc("POST /api/v1/chat/suggestions/{id}/dismiss", middleware.Auth(cfg.JWTSecret, h.DismissChatSuggestion))
+	mux.HandleFunc("GET /api/v1/chat/analytics", middleware.Auth(cfg.JWTSecret, h.GetChatAnalytics))
+	mux.HandleFunc("GET /api/v1/chat/conversations/{id}/archives", middleware.Auth(cfg.JWTSecret, h.ListConversationArchives))
+	mux.HandleFunc("GET /api/v1/chat/conversations/{id}/archives/{archive_id}", middleware.Auth(cfg.JWTSecret, h.GetConversationArchive))
 
 	// File Uploads (NEW)
 	mux.HandleFunc("POST /api/v1/files/upload", middleware.Auth(cfg.JWTSecret, h.UploadFile))
 	mux.HandleFunc("GET /api/v1/files/{id}", middleware.Auth(cfg.JWTSecret, h.GetFile))
+	mux.HandleFunc("GET /api/v1/files/{id}/content", middleware.Auth(cfg.JWTSecret, h.GetFileContent))
+	mux.HandleFunc("PUT /api/v1/files/{id}/visibility", middleware.Auth(cfg.JWTSecret, h.UpdateFileVisibility))
+	mux.HandleFunc("GET /api/v1/files/{id}/shares", middleware.Auth(cfg.JWTSecret, h.ListFileShares))
+	mux.HandleFunc("POST /api/v1/files/{id}/shares", middleware.Auth(cfg.JWTSecret, h.ShareFile))
+	mux.HandleFunc("DELETE /api/v1/files/{id}/shares/{user_id}", middleware.Auth(cfg.JWTSecret, h.UnshareFile))
+	mux.HandleFunc("GET /api/v1/files/{id}/access-log", middleware.Auth(cfg.JWTSecret, h.GetFileAccessLog))
 
 	// Insights (NEW - Four Outcome Types)
-	mux.HandleFunc("POST /api/v1/insights/forecast", middleware.Auth(cfg.JWTSecret, h.GenerateForecastInsight))
-	mux.HandleFunc("POST /api/v1/insights/market", middleware.Auth(cfg.JWTSecret, h.GenerateMarketInsight))
-	mux.HandleFunc("POST /api/v1/insights/marketing", middleware.Auth(cfg.JWTSecret, h.GenerateMarketingInsight))
-	mux.HandleFunc("POST /api/v1/insights/regulation", middleware.Auth(cfg.JWTSecret, h.GenerateRegulationInsight))
+	mux.HandleFunc("POST /api/v1/insights/forecast", middleware.Auth(cfg.JWTSecret, middleware.Queue(aiQueue, aiQueueWait, h.GenerateForecastInsight)))
+	mux.HandleFunc("POST /api/v1/insights/market", middleware.Auth(cfg.JWTSecret, middleware.Queue(aiQueue, aiQueueWait, h.GenerateMarketInsight)))
+	mux.HandleFunc("POST /api/v1/insights/marketing", middleware.Auth(cfg.JWTSecret, middleware.Queue(aiQueue, aiQueueWait, h.GenerateMarketingInsight)))
+	mux.HandleFunc("POST /api/v1/insights/regulation", middleware.Auth(cfg.JWTSecret, middleware.Queue(aiQueue, aiQueueWait, h.GenerateRegulationInsight)))
 	mux.HandleFunc("GET /api/v1/insights", middleware.Auth(cfg.JWTSecret, h.GetInsights))
+	mux.HandleFunc("POST /api/v1/insights/{id}/adopt", middleware.Auth(cfg.JWTSecret, h.AdoptInsight))
+	mux.HandleFunc("POST /api/v1/insights/{id}/actions", middleware.Auth(cfg.JWTSecret, h.CreateInsightAction))
+	mux.HandleFunc("POST /api/v1/insights/{id}/outcomes", middleware.Auth(cfg.JWTSecret, h.CreateInsightOutcome))
+	mux.HandleFunc("GET /api/v1/insights/{id}/outcomes", middleware.Auth(cfg.JWTSecret, h.GetInsightOutcomes))
 
 	// Dashboard
 	mux.HandleFunc("GET /api/v1/dashboard/summary", middleware.Auth(cfg.JWTSecret, h.DashboardSummary))
+	mux.HandleFunc("GET /api/v1/dashboard/health", middleware.Auth(cfg.JWTSecret, h.GetBusinessHealth))
+
+	// Monthly strategy plans
+	mux.HandleFunc("POST /api/v1/strategy/plans", middleware.Auth(cfg.JWTSecret, h.GenerateStrategyPlan))
+	mux.HandleFunc("GET /api/v1/strategy/plans", middleware.Auth(cfg.JWTSecret, h.GetStrategyPlan))
+	mux.HandleFunc("PUT /api/v1/strategy/tasks/{id}", middleware.Auth(cfg.JWTSecret, h.UpdateStrategyTask))
+	mux.HandleFunc("GET /api/v1/digest/weekly", middleware.Auth(cfg.JWTSecret, h.GetWeeklyDigest))
+
+	// Analytics & notifications
+	mux.HandleFunc("GET /api/v1/analytics/slow-movers", middleware.Auth(cfg.JWTSecret, h.GetSlowMovers))
+	mux.HandleFunc("GET /api/v1/notifications", middleware.Auth(cfg.JWTSecret, h.ListNotifications))
+	mux.HandleFunc("POST /api/v1/notifications/{id}/read", middleware.Auth(cfg.JWTSecret, h.MarkNotificationRead))
+	mux.HandleFunc("GET /api/v1/notifications/preferences", middleware.Auth(cfg.JWTSecret, h.GetNotificationPreferences))
+	mux.HandleFunc("PUT /api/v1/notifications/preferences", middleware.Auth(cfg.JWTSecret, h.UpdateNotificationPreferences))
+	mux.HandleFunc("DELETE /api/v1/notifications/preferences", middleware.Auth(cfg.JWTSecret, h.ResetNotificationPreferences))
+	mux.HandleFunc("GET /api/v1/notifications/quiet-hours", middleware.Auth(cfg.JWTSecret, h.GetQuietHours))
+	mux.HandleFunc("PUT /api/v1/notifications/quiet-hours", middleware.Auth(cfg.JWTSecret, middleware.RequireCompanyRole(h.UpdateQuietHours, "owner", "manager")))
+
+	// Predictions
+	mux.HandleFunc("POST /api/v1/predictions", middleware.Auth(cfg.JWTSecret, middleware.RateLimit(limiter, ratelimit.ProfileAI, h.CompanyPlan, h.StartPrediction)))
+	mux.HandleFunc("GET /api/v1/predictions", middleware.Auth(cfg.JWTSecret, h.ListPredictionJobs))
+	mux.HandleFunc("GET /api/v1/predictions/active", middleware.Auth(cfg.JWTSecret, h.GetActiveJob))
+	mux.HandleFunc("GET /api/v1/predictions/{job_id}", middleware.Auth(cfg.JWTSecret, h.GetJob))
+	mux.HandleFunc("GET /api/v1/predictions/{job_id}/events", middleware.Auth(cfg.JWTSecret, h.StreamPredictionEvents))
+	mux.HandleFunc("GET /api/v1/predictions/{job_id}/results/{step}", middleware.Auth(cfg.JWTSecret, h.GetJobStepResult))
+	mux.HandleFunc("GET /api/v1/predictions/{job_id}/diff/{previous_job_id}", middleware.Auth(cfg.JWTSecret, h.DiffPredictionJobs))
+
+	// Market research
+	mux.HandleFunc("POST /api/v1/market/research", middleware.Auth(cfg.JWTSecret, middleware.RateLimit(limiter, ratelimit.ProfileAI, h.CompanyPlan, middleware.ConcurrencyLimit(semaphore, ratelimit.OperationResearch, h.CompanyPlan, middleware.Queue(aiQueue, aiQueueWait, h.ResearchMarket)))))
+	mux.HandleFunc("GET /api/v1/market/research/search", middleware.Auth(cfg.JWTSecret, h.SearchMarketResearch))
+
+	// Imports from bookkeeping/POS tools
+	mux.HandleFunc("GET /api/v1/imports/adapters", middleware.Auth(cfg.JWTSecret, h.ListImportAdapters))
+	mux.HandleFunc("POST /api/v1/imports/mappings/suggest", middleware.Auth(cfg.JWTSecret, middleware.Queue(aiQueue, aiQueueWait, h.SuggestImportMapping)))
+	mux.HandleFunc("GET /api/v1/imports/mappings", middleware.Auth(cfg.JWTSecret, h.ListImportMappings))
+	mux.HandleFunc("PUT /api/v1/imports/mappings/{source}", middleware.Auth(cfg.JWTSecret, h.SaveImportMapping))
+	mux.HandleFunc("DELETE /api/v1/imports/mappings/{source}", middleware.Auth(cfg.JWTSecret, h.DeleteImportMapping))
+	mux.HandleFunc("POST /api/v1/imports/{adapter}/preview", middleware.Auth(cfg.JWTSecret, h.PreviewImport))
+	mux.HandleFunc("POST /api/v1/imports/{adapter}", middleware.Auth(cfg.JWTSecret, middleware.RateLimit(limiter, ratelimit.ProfileImport, h.CompanyPlan, middleware.ConcurrencyLimit(semaphore, ratelimit.OperationImport, h.CompanyPlan, h.RunImport))))
+
+	// POS/QRIS sales webhooks
+	mux.HandleFunc("GET /api/v1/webhooks/sales", middleware.Auth(cfg.JWTSecret, h.ListSalesWebhooks))
+	mux.HandleFunc("POST /api/v1/webhooks/sales", middleware.Auth(cfg.JWTSecret, h.CreateSalesWebhook))
+	mux.HandleFunc("POST /api/v1/webhooks/sales/{id}/rotate", middleware.Auth(cfg.JWTSecret, h.RotateSalesWebhookSecret))
+	mux.HandleFunc("POST /api/v1/webhooks/sales/{id}/ingest", h.ReceiveSalesWebhook)
+
+	// Company settings
+	mux.HandleFunc("GET /api/v1/company/settings", middleware.Auth(cfg.JWTSecret, h.GetCompanySettings))
+	mux.HandleFunc("PUT /api/v1/company/settings", middleware.Auth(cfg.JWTSecret, h.UpdateCompanySettings))
+	mux.HandleFunc("GET /api/v1/usage", middleware.Auth(cfg.JWTSecret, h.GetUsage))
+	mux.HandleFunc("GET /api/v1/usage/history", middleware.Auth(cfg.JWTSecret, h.GetUsageHistory))
+	mux.HandleFunc("GET /api/v1/billing/plan-preview", middleware.Auth(cfg.JWTSecret, h.GetPlanPreview))
+	mux.HandleFunc("GET /api/v1/billing", middleware.Auth(cfg.JWTSecret, h.GetBilling))
+	mux.HandleFunc("GET /api/v1/billing/orders", middleware.Auth(cfg.JWTSecret, h.ListBillingOrders))
+	mux.HandleFunc("POST /api/v1/billing/checkout", middleware.Auth(cfg.JWTSecret, middleware.RequireCompanyRole(h.CreateBillingCheckout, "owner")))
+	mux.HandleFunc("POST /api/v1/webhooks/billing/{provider}", h.ReceiveBillingNotification)
+	mux.HandleFunc("GET /api/v1/company/location", middleware.Auth(cfg.JWTSecret, h.GetCompanyLocation))
+	mux.HandleFunc("PUT /api/v1/company/location", middleware.Auth(cfg.JWTSecret, h.UpdateCompanyLocation))
+	mux.HandleFunc("GET /api/v1/company/industry", middleware.Auth(cfg.JWTSecret, h.GetCompanyIndustry))
+	mux.HandleFunc("PUT /api/v1/company/industry", middleware.Auth(cfg.JWTSecret, h.UpdateCompanyIndustry))
+	mux.HandleFunc("GET /api/v1/kbli", middleware.Auth(cfg.JWTSecret, h.SearchKBLI))
+	mux.HandleFunc("PUT /api/v1/company/legal-form", middleware.Auth(cfg.JWTSecret, h.UpdateCompanyLegalForm))
+	mux.HandleFunc("GET /api/v1/kb/articles", middleware.Auth(cfg.JWTSecret, h.ListKBArticles))
+	mux.HandleFunc("GET /api/v1/kb/articles/{slug}", middleware.Auth(cfg.JWTSecret, h.GetKBArticle))
+	mux.HandleFunc("GET /api/v1/compliance/checklist", middleware.Auth(cfg.JWTSecret, h.GetComplianceChecklist))
+	mux.HandleFunc("PUT /api/v1/compliance/checklist/{requirement}", middleware.Auth(cfg.JWTSecret, h.UpdateComplianceItem))
+	mux.HandleFunc("GET /api/v1/regions", middleware.Auth(cfg.JWTSecret, h.ListRegions))
+	mux.HandleFunc("GET /api/v1/regions/{code}/stats", middleware.Auth(cfg.JWTSecret, h.GetRegionStats))
+	mux.HandleFunc("GET /api/v1/company/assistant-preferences", middleware.Auth(cfg.JWTSecret, h.GetAssistantPreferences))
+	mux.HandleFunc("PUT /api/v1/company/assistant-preferences", middleware.Auth(cfg.JWTSecret, h.UpdateAssistantPreferences))
+	mux.HandleFunc("GET /api/v1/company/lifecycle", middleware.Auth(cfg.JWTSecret, h.GetCompanyLifecycle))
+	mux.HandleFunc("POST /api/v1/company/reactivate", middleware.Auth(cfg.JWTSecret, h.ReactivateCompany))
+
+	// Company backups
+	mux.HandleFunc("POST /api/v1/company/backup", middleware.Auth(cfg.JWTSecret, h.CreateCompanyBackup))
+	mux.HandleFunc("GET /api/v1/company/backups", middleware.Auth(cfg.JWTSecret, h.ListCompanyBackups))
+
+	// Company team (owners manage members and invites; viewers are read-only)
+	mux.HandleFunc("GET /api/v1/company/members", middleware.Auth(cfg.JWTSecret, h.ListCompanyMembers))
+	mux.HandleFunc("PUT /api/v1/company/members/{user_id}", middleware.Auth(cfg.JWTSecret, middleware.RequireCompanyRole(h.UpdateCompanyMember, "owner")))
+	mux.HandleFunc("DELETE /api/v1/company/members/{user_id}", middleware.Auth(cfg.JWTSecret, middleware.RequireCompanyRole(h.RemoveCompanyMember, "owner")))
+	mux.HandleFunc("DELETE /api/v1/company/members/me", middleware.Auth(cfg.JWTSecret, h.LeaveCompany))
+	mux.HandleFunc("POST /api/v1/company/invites", middleware.Auth(cfg.JWTSecret, middleware.RequireCompanyRole(h.CreateCompanyInvite, "owner")))
+	mux.HandleFunc("DELETE /api/v1/company/invites/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireCompanyRole(h.RevokeCompanyInvite, "owner")))
+	mux.HandleFunc("GET /api/v1/company/invites/received", middleware.Auth(cfg.JWTSecret, h.ListReceivedInvites))
+	mux.HandleFunc("POST /api/v1/company/invites/{id}/accept", middleware.Auth(cfg.JWTSecret, h.AcceptCompanyInvite))
+	mux.HandleFunc("POST /api/v1/company/invites/{id}/decline", middleware.Auth(cfg.JWTSecret, h.DeclineCompanyInvite))
+	mux.HandleFunc("GET /api/v1/company/memberships", middleware.Auth(cfg.JWTSecret, h.ListMyCompanies))
+	mux.HandleFunc("POST /api/v1/company/switch", middleware.Auth(cfg.JWTSecret, h.SwitchCompany))
+
+	// Exports (background jobs with signed download links)
+	mux.HandleFunc("POST /api/v1/exports", middleware.Auth(cfg.JWTSecret, h.RequestExport))
+	mux.HandleFunc("GET /api/v1/exports", middleware.Auth(cfg.JWTSecret, h.ListExports))
+	mux.HandleFunc("GET /api/v1/exports/{id}", middleware.Auth(cfg.JWTSecret, h.GetExport))
+	mux.HandleFunc("GET /api/v1/exports/{id}/download", h.DownloadExport)
+
+	// Partners (white-label)
+	mux.HandleFunc("GET /api/v1/partners/{slug}/branding", h.GetPartnerBranding)
+	mux.HandleFunc("GET /api/v1/partner", middleware.Auth(cfg.JWTSecret, h.GetMyPartner))
+	mux.HandleFunc("PUT /api/v1/partner/branding", middleware.Auth(cfg.JWTSecret, h.UpdatePartnerBranding))
+	mux.HandleFunc("GET /api/v1/partner/companies", middleware.Auth(cfg.JWTSecret, h.ListPartnerCompanies))
+	mux.HandleFunc("GET /api/v1/partner/usage", middleware.Auth(cfg.JWTSecret, h.GetPartnerUsage))
+	mux.HandleFunc("POST /api/v1/partners/{id}/members", middleware.Auth(cfg.JWTSecret, h.AddPartnerMember))
+	mux.HandleFunc("GET /api/v1/partners/{id}/sso", middleware.Auth(cfg.JWTSecret, h.GetPartnerSSO))
+	mux.HandleFunc("PUT /api/v1/partners/{id}/sso", middleware.Auth(cfg.JWTSecret, h.PutPartnerSSO))
+	mux.HandleFunc("DELETE /api/v1/partners/{id}/sso", middleware.Auth(cfg.JWTSecret, h.DeletePartnerSSO))
+
+	// Admin
+	mux.HandleFunc("GET /api/v1/admin/devices", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListAdminDevices, "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/devices/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("admin.device.revoke", false, h.RevokeAdminDevice), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/ip-allowlist", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListAdminIPAllowlist, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/ip-allowlist", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("admin.ip_allowlist.add", true, h.AddAdminIPAllowlistEntry), "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/ip-allowlist/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("admin.ip_allowlist.delete", true, h.DeleteAdminIPAllowlistEntry), "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/users", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("user.create", false, h.AdminCreateUser), "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/users/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("user.delete", true, h.AdminDeleteUser), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/users/{id}/reset-link", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminSendPasswordResetLink, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/bulk/users/suspend", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("user.bulk_suspend", true, h.AdminBulkSuspendUsers), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/bulk/users/activate", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("user.bulk_activate", true, h.AdminBulkActivateUsers), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/bulk/users/plan", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("company.subscription.bulk_update", true, h.AdminBulkChangePlan), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/bulk/users/email", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("user.bulk_email", true, h.AdminBulkEmailUsers), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/bulk-operations", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListBulkOperations, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/bulk-operations/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetBulkOperation, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/partners", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListPartners, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/partners", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("partner.create", false, h.CreatePartner), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/partners/{id}/companies", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("partner.company.assign", true, h.AssignPartnerCompany), "admin", "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/companies/{id}/subscription", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("company.subscription.update", true, h.AdminUpdateCompanySubscription), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/companies/{id}/sso", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetCompanySSO, "admin", "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/companies/{id}/sso", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("company.sso.update", true, h.PutCompanySSO), "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/companies/{id}/sso", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("company.sso.delete", true, h.DeleteCompanySSO), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/companies/directory", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminCompanyDirectory, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/companies/map", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminCompanyMap, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/compliance/rules", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListComplianceRules, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/compliance/rules", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("compliance.rule.create", false, h.CreateComplianceRule), "admin", "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/compliance/rules/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("compliance.rule.update", true, h.UpdateComplianceRule), "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/compliance/rules/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("compliance.rule.delete", false, h.DeleteComplianceRule), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/kb/articles", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminListKBArticles, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/kb/articles", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("kb.article.create", true, h.CreateKBArticle), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/kb/articles/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminGetKBArticle, "admin", "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/kb/articles/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("kb.article.update", true, h.UpdateKBArticle), "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/kb/articles/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("kb.article.delete", false, h.DeleteKBArticle), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/kb/articles/{id}/versions", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListKBArticleVersions, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/kb/articles/{id}/versions/{version}/restore", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("kb.article.restore", false, h.RestoreKBArticleVersion), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/kb/articles/{id}/index", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.IndexKBArticle, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/rag-eval/cases", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListRAGEvalCases, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/rag-eval/cases", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("rag_eval.case.create", false, h.CreateRAGEvalCase), "admin", "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/rag-eval/cases/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("rag_eval.case.update", false, h.UpdateRAGEvalCase), "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/rag-eval/cases/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("rag_eval.case.delete", false, h.DeleteRAGEvalCase), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/rag-eval/runs", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListRAGEvalRuns, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/rag-eval/runs/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetRAGEvalRun, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/kbli/suggestions", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GenerateKBLISuggestions, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/kbli/suggestions", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListKBLISuggestions, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/kbli/suggestions/{id}/confirm", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("kbli.suggestion.confirm", false, h.ConfirmKBLISuggestion), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/kbli/suggestions/{id}/reject", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("kbli.suggestion.reject", false, h.RejectKBLISuggestion), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/duplicates/companies", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListDuplicateCompanies, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/duplicates/companies/merge", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("company.merge", true, h.MergeDuplicateCompanies), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/companies/{id}/duplicate-products", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListDuplicateProducts, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/duplicates/products/merge", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("product.merge", true, h.MergeDuplicateProducts), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/companies/{id}/backups", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminListCompanyBackups, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/companies/{id}/backups/{backup_id}/restore", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("company.backup.restore", true, h.RestoreCompanyBackup), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/regulations/index", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.IndexRegulations, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/regulations/index-jobs", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListRegulationIndexJobs, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/embeddings/gc", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.CollectEmbeddingGarbage, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/embeddings/namespaces", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListEmbeddingNamespaces, "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/embeddings/namespaces/{namespace}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("embeddings.namespace.delete", true, h.DeleteEmbeddingNamespace), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/embeddings/search", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.SearchEmbeddings, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/predictions", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminListPredictionJobs, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/predictions/failures", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminPredictionFailures, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/predictions/{job_id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminGetPredictionJob, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/trends/coverage", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetKeywordCoverage, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/trends/industry-keywords", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListIndustryKeywords, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/trends/industry-keywords", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("trends.industry_keywords.add", false, h.AddIndustryKeywords), "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/trends/industry-keywords/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("trends.industry_keywords.delete", false, h.DeleteIndustryKeyword), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/integrations/health", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminFailingIntegrations, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/ocr/quality", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminOCRQuality, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/chat/evaluation-dataset", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminChatEvaluationDataset, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/chat/engagement", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminChatEngagement, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/audit-logs", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListAuditLogs, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/audit-logs/{id}/payload", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("audit.payload.view", false, h.GetAuditPayload), "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/ai-spend", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetAISpend, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/ai-spend/features", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetAIUsageByFeature, "admin", "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/ai-spend/settings", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("settings.ai_spend.update", true, h.UpdateAISpendSettings), "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/companies/{id}/ai-spend-cap", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("company.ai_spend_cap.update", true, h.AdminUpdateAISpendCap), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/provider-calls", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListProviderCalls, "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/provider-calls/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetProviderCall, "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/provider-calls/{id}/replay", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("provider_call.replay", false, h.ReplayProviderCall), "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/maintenance", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetMaintenance, "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/maintenance", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("settings.maintenance.update", true, h.UpdateMaintenance), "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/companies/{id}/usage/history", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.AdminGetUsageHistory, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/slo/alerts", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListSLOAlerts, "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/status/incidents", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.ListStatusIncidents, "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/status/incidents", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("status.incident.create", true, h.CreateStatusIncident), "admin", "super_admin")))
+	mux.HandleFunc("POST /api/v1/admin/status/incidents/{id}/updates", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("status.incident.update", true, h.PostStatusIncidentUpdate), "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/status/incidents/{id}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("status.incident.delete", false, h.DeleteStatusIncident), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/rate-limits", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetRateLimits, "admin", "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/rate-limits/profiles/{name}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("rate_limit.profile.update", false, h.UpdateRateLimitProfile), "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/rate-limits/profiles/{name}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("rate_limit.profile.delete", false, h.DeleteRateLimitProfile), "admin", "super_admin")))
+	mux.HandleFunc("PUT /api/v1/admin/rate-limits/plans/{plan}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("rate_limit.plan.update", true, h.UpdatePlanMultiplier), "admin", "super_admin")))
+	mux.HandleFunc("DELETE /api/v1/admin/rate-limits/plans/{plan}", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.Audited("rate_limit.plan.delete", true, h.DeletePlanMultiplier), "admin", "super_admin")))
+	mux.HandleFunc("GET /api/v1/admin/rate-limits/concurrency", middleware.Auth(cfg.JWTSecret, middleware.RequireRole(h.GetConcurrencyStats, "admin", "super_admin")))
+
+	// CORS: the web app's origins get credentialed access; server-to-server
+	// callbacks and webhooks are never called from browsers
+	corsPolicy := cors.Policy{Origins: cors.ParseOrigins(cfg.CORSOrigins), Credentials: true, MaxAge: cfg.CORSMaxAge}
+	corsOverrides := map[string]cors.Policy{
+		"/healthz":          {Origins: []string{"*"}, MaxAge: cfg.CORSMaxAge},
+		"/api/v1/slo/":      {Origins: []string{"*"}, MaxAge: cfg.CORSMaxAge},
+		"/status":           {Origins: []string{"*"}, MaxAge: cfg.CORSMaxAge},
+		"/api/v1/webhooks/": {},
+		"/api/v1/integrations/woocommerce/credentials/callback": {},
+	}
 
 	// Apply middleware stack
 	handler := middleware.Chain(
 		mux,
 		middleware.RequestID,
+		middleware.Metrics(h.SLOTracker()),
 		middleware.StructuredLogger,
 		middleware.ErrorHandler,
-		middleware.CORS(cfg.CORSOrigin),
+		middleware.CORS(corsPolicy, corsOverrides),
+		middleware.Maintenance(h.Maintenance(), cfg.JWTSecret),
 		middleware.Recover,
 	)
 
@@ -140,6 +471,66 @@ func main() {
 		}
 	}()
 
+	// Scheduled jobs
+	jobs := h.Lifecycle()
+	jobs.Go(func() { h.CacheBus().Run(jobs.Context()) })
+	// Reloads catch up on invalidations an instance missed while disconnected
+	runPeriodically(jobs, time.Minute, h.ReloadRateLimits)
+	runPeriodically(jobs, time.Minute, h.ReloadSettings)
+	runPeriodically(jobs, time.Hour, h.PurgeExpiredExports)
+	runPeriodically(jobs, time.Minute, h.CheckSLOAlerts)
+	if cfg.SlowMoverScanHours > 0 {
+		runPeriodically(jobs, time.Duration(cfg.SlowMoverScanHours)*time.Hour, h.ScanSlowMovers)
+		log.Info("Slow mover scan scheduled", "interval_hours", cfg.SlowMoverScanHours)
+	}
+	if cfg.MarketMonitorHours > 0 {
+		runPeriodically(jobs, time.Duration(cfg.MarketMonitorHours)*time.Hour, h.MonitorMarkets)
+		log.Info("Market monitoring scheduled", "interval_hours", cfg.MarketMonitorHours)
+	}
+	if cfg.AuditEncryptionKey != "" {
+		runPeriodically(jobs, time.Hour, h.PurgeAuditPayloads)
+		log.Info("Audit payload capture enabled", "retention_days", cfg.AuditPayloadDays)
+	}
+	if cfg.AIUsageRetentionDays > 0 {
+		runPeriodically(jobs, 24*time.Hour, h.PurgeAIUsage)
+		log.Info("AI usage retention enabled", "retention_days", cfg.AIUsageRetentionDays)
+	}
+	if cfg.ProviderLogSamplePercent > 0 {
+		runPeriodically(jobs, time.Hour, h.PurgeProviderCalls)
+		log.Info("Provider call recording enabled", "sample_percent", cfg.ProviderLogSamplePercent, "ttl_hours", cfg.ProviderLogTTLHours)
+	}
+	if cfg.ComplianceReminderHours > 0 {
+		runPeriodically(jobs, time.Duration(cfg.ComplianceReminderHours)*time.Hour, h.RemindComplianceExpiry)
+		log.Info("Permit expiry reminders scheduled", "interval_hours", cfg.ComplianceReminderHours)
+	}
+	if cfg.DormancyMonths > 0 {
+		runPeriodically(jobs, time.Duration(cfg.DormancyScanHours)*time.Hour, h.ScanDormantCompanies)
+		log.Info("Dormancy scan scheduled", "inactive_months", cfg.DormancyMonths, "grace_days", cfg.DormancyGraceDays)
+	}
+	if cfg.UsageSnapshotHours > 0 {
+		runPeriodically(jobs, time.Duration(cfg.UsageSnapshotHours)*time.Hour, h.SnapshotUsage)
+		log.Info("Usage snapshots scheduled", "interval_hours", cfg.UsageSnapshotHours)
+	}
+	if cfg.ChatArchiveHours > 0 {
+		runPeriodically(jobs, time.Duration(cfg.ChatArchiveHours)*time.Hour, h.ArchiveConversations)
+		log.Info("Message archival scheduled", "interval_hours", cfg.ChatArchiveHours, "retain_messages", cfg.ChatRetainMessages)
+	}
+	if cfg.ChatSuggestionHours > 0 {
+		runPeriodically(jobs, time.Duration(cfg.ChatSuggestionHours)*time.Hour, h.RefreshChatSuggestions)
+		log.Info("Suggestion feed refresh scheduled", "interval_hours", cfg.ChatSuggestionHours)
+	}
+	// Plans paid earlier still lapse after billing is turned off
+	if cfg.BillingSyncMinutes > 0 {
+		runPeriodically(jobs, time.Duration(cfg.BillingSyncMinutes)*time.Minute, h.ExpireLapsedPlans)
+	}
+	if cfg.BillingProvider != "" {
+		log.Info("Billing enabled", "provider", cfg.BillingProvider, "production", cfg.MidtransProduction)
+	}
+	if cfg.NotificationSummaryMinutes > 0 {
+		runPeriodically(jobs, time.Duration(cfg.NotificationSummaryMinutes)*time.Minute, h.DeliverHeldNotifications)
+		log.Info("Held notification delivery scheduled", "interval_minutes", cfg.NotificationSummaryMinutes)
+	}
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -149,14 +540,38 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Requests finish first, so none starts a job after jobs are stopped
 	if err := server.Shutdown(ctx); err != nil {
 		log.Error("Server forced to shutdown", "error", err)
 		os.Exit(1)
 	}
+	jobsCtx, cancelJobs := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelJobs()
+	if err := jobs.Stop(jobsCtx); err != nil {
+		log.Warn("Background jobs still running at exit", "running", jobs.Running())
+	}
 
 	log.Info("Server exited properly")
 }
 
+// runPeriodically runs job every interval until jobs stop. Shutdown cancels
+// the context passed to a run in progress and waits for it to return.
+func runPeriodically(jobs *lifecycle.Manager, interval time.Duration, job func(context.Context)) {
+	jobs.Go(func() {
+		ctx := jobs.Context()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				job(ctx)
+			}
+		}
+	})
+}
+
 // maskDatabaseURL masks sensitive information in database URL for logging
 func maskDatabaseURL(url string) string {
 	if url == "" {
@@ -2,12 +2,24 @@ package middleware
 
 import (
 	"context"
+	"math"
+	"net"
 	"net/http"
+	"net/netip"
+	"strconv"
 	"strings"
 	"time"
 
 	apperrors "github.com/bantuaku/backend/errors"
 	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/response"
+	"github.com/bantuaku/backend/services/cors"
+	"github.com/bantuaku/backend/services/ipaccess"
+	"github.com/bantuaku/backend/services/maintenance"
+	"github.com/bantuaku/backend/services/membership"
+	"github.com/bantuaku/backend/services/ratelimit"
+	"github.com/bantuaku/backend/services/slo"
+	"github.com/bantuaku/backend/services/workqueue"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
@@ -18,6 +30,10 @@ const (
 	RequestIDKey contextKey = "request_id"
 	UserIDKey    contextKey = "user_id"
 	StoreIDKey   contextKey = "store_id"
+	RoleKey      contextKey = "role"
+	DeviceIDKey  contextKey = "device_id"
+
+	CompanyRoleKey contextKey = "company_role"
 )
 
 // Chain applies multiple middleware to a handler
@@ -66,6 +82,76 @@ func StructuredLogger(next http.Handler) http.Handler {
 	})
 }
 
+// Metrics records every request's status and duration against the service
+// level objective its path belongs to. Requests turned away by maintenance
+// mode are planned downtime and not recorded.
+func Metrics(tracker *slo.Tracker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+			if w.Header().Get(maintenanceHeader) != "" {
+				return
+			}
+			tracker.Record(r.URL.Path, wrapped.statusCode, time.Since(start), start)
+		})
+	}
+}
+
+// maintenanceHeader marks responses refused because of maintenance mode
+const maintenanceHeader = "X-Maintenance-Mode"
+
+// Maintenance answers 503 with a friendly message while maintenance mode is on,
+// except for always-available paths, allowlisted paths and users, and super
+// admins. The caller is identified from a valid bearer token if one is sent;
+// routes still authenticate as usual afterwards.
+func Maintenance(sw *maintenance.Switch, jwtSecret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mode := sw.Current()
+			if !mode.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var userID, role string
+			if claims, ok := bearerClaims(r, jwtSecret); ok {
+				userID, _ = claims["user_id"].(string)
+				role, _ = claims["role"].(string)
+			}
+			if mode.Allows(r.URL.Path, userID, role) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			retryAfter := mode.RetryAfter(time.Now())
+			w.Header().Set(maintenanceHeader, "on")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			appErr := apperrors.NewMaintenanceError(mode.Notice(), retryAfter)
+			response.Error(w, r, appErr)
+		})
+	}
+}
+
+// bearerClaims returns the claims of a valid bearer token, if the request has one
+func bearerClaims(r *http.Request, jwtSecret string) (jwt.MapClaims, bool) {
+	tokenString, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, false
+	}
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(jwtSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	return claims, ok
+}
+
 // Logger logs request details (alias for StructuredLogger for backward compatibility)
 func Logger(next http.Handler) http.Handler {
 	return StructuredLogger(next)
@@ -135,7 +221,7 @@ func ErrorHandler(next http.Handler) http.Handler {
 				appErr := apperrors.NewAppError(apperrors.ErrCodeInternal, "panic recovered", "")
 
 				log.LogError(appErr, "Panic recovered in HTTP handler", r.Context())
-				apperrors.WriteJSONError(w, appErr, appErr.Code)
+				response.Error(w, r, appErr)
 			}
 		}()
 
@@ -146,7 +232,11 @@ func ErrorHandler(next http.Handler) http.Handler {
 		// Handle any errors that might have been set
 		if wrapped.err != nil {
 			log.LogError(wrapped.err, "Error in HTTP handler", r.Context())
-			apperrors.WriteJSONError(w, wrapped.err, wrapped.errCode)
+			err := wrapped.err
+			if _, ok := err.(*apperrors.AppError); !ok {
+				err = apperrors.NewAppError(wrapped.errCode, err.Error(), "")
+			}
+			response.Error(w, r, err)
 		}
 	})
 }
@@ -171,6 +261,12 @@ func (erw *errorResponseWriter) WriteHeader(code int) {
 	erw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, to flush
+// streamed responses
+func (erw *errorResponseWriter) Unwrap() http.ResponseWriter {
+	return erw.ResponseWriter
+}
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -181,33 +277,77 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// CORS handles Cross-Origin Resource Sharing
-func CORS(allowedOrigin string) func(http.Handler) http.Handler {
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// CORS request headers browsers may send and response headers scripts may read
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders  = "Content-Type, Authorization, X-Request-ID, X-Device-ID"
+	corsExposeHeaders = "X-Request-ID, Retry-After, X-Maintenance-Mode, X-Queue-Position, X-Queue-Wait-Ms, X-Category-Suggestions, " +
+		"X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, " +
+		"X-Usage-Metric, X-Usage-Limit, X-Usage-Used, X-Usage-Remaining, X-Usage-Reset"
+)
+
+// CORS handles Cross-Origin Resource Sharing. Requests are checked against the
+// policy of the longest matching path prefix in overrides, falling back to
+// policy. Disallowed origins get no CORS headers, and their preflights are
+// refused. Origins allowed only through "*" never get credentials.
+func CORS(policy cors.Policy, overrides map[string]cors.Policy) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
+			p := policy
+			matched := 0
+			for prefix, override := range overrides {
+				if len(prefix) > matched && strings.HasPrefix(r.URL.Path, prefix) {
+					p, matched = override, len(prefix)
+				}
+			}
 
-			// Log CORS request
-			requestID, _ := r.Context().Value(RequestIDKey).(string)
-			log := logger.With("request_id", requestID)
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && origin != "" && r.Header.Get("Access-Control-Request-Method") != ""
 
-			if origin != "" {
-				log.Debug("CORS request", "origin", origin, "allowed_origin", allowedOrigin)
+			// Responses differ by origin unless every origin gets the same "*"
+			if !p.Wildcard() || p.Credentials {
+				w.Header().Add("Vary", "Origin")
+			}
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
 			}
 
-			// Set CORS headers
-			if allowedOrigin == "*" || origin == allowedOrigin {
-				w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-			} else {
-				// For production, you might want to implement more sophisticated origin checking
-				w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
+			allowed, listed := p.Match(origin)
+			if origin != "" && !allowed {
+				requestID, _ := r.Context().Value(RequestIDKey).(string)
+				logger.With("request_id", requestID).Debug("CORS origin rejected", "origin", origin, "path", r.URL.Path)
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
 			}
 
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			if allowed {
+				if listed && p.Credentials {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				} else if p.Wildcard() && !p.Credentials {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				} else {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+				w.Header().Set("Access-Control-Expose-Headers", corsExposeHeaders)
+			}
 
-			if r.Method == "OPTIONS" {
+			if r.Method == http.MethodOptions {
+				if preflight {
+					w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+					w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+					if p.MaxAge > 0 {
+						w.Header().Set("Access-Control-Max-Age", strconv.Itoa(p.MaxAge))
+					}
+				}
 				w.WriteHeader(http.StatusNoContent)
 				return
 			}
@@ -233,52 +373,213 @@ func Recover(next http.Handler) http.Handler {
 				log.LogError(appErr, "Panic recovered in HTTP handler", r.Context())
 
 				// Return proper JSON error response
-				apperrors.WriteJSONError(w, appErr, appErr.Code)
+				response.Error(w, r, appErr)
 			}
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
 
-// RateLimiter implements basic rate limiting
-func RateLimiter(requestsPerMinute int) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// In a real implementation, you would use a proper rate limiting library
-			// like github.com/ulule/limiter or implement token bucket algorithm
+// PlanLookup returns the subscription plan of a company for rate limit scaling
+type PlanLookup func(ctx context.Context, companyID string) string
 
-			// For now, just log that rate limiting would be applied here
-			requestID, _ := r.Context().Value(RequestIDKey).(string)
-			log := logger.With("request_id", requestID)
+// RateLimit enforces the named rate limit profile per company, or per client IP
+// on unauthenticated routes. Limits are scaled by the company's plan; Redis
+// errors let the request through rather than failing the API.
+func RateLimit(limiter *ratelimit.Limiter, profile string, plans PlanLookup, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-			log.Debug(
-				"Rate limiting middleware applied",
-				"requests_per_minute", requestsPerMinute,
-				"client_ip", getClientIP(r),
-			)
+		requestID, _ := r.Context().Value(RequestIDKey).(string)
+		log := logger.With("request_id", requestID)
+
+		key, plan := "ip:"+getClientIP(r), ""
+		if companyID := GetCompanyID(r.Context()); companyID != "" {
+			key = "company:" + companyID
+			if plans != nil {
+				plan = plans(r.Context(), companyID)
+			}
+		}
 
+		decision, err := limiter.Allow(r.Context(), profile, plan, key)
+		if err != nil {
+			log.Warn("Rate limit check failed, allowing request", "profile", profile, "error", err.Error())
 			next.ServeHTTP(w, r)
-		})
+			return
+		}
+		setRateLimitHeaders(w, decision)
+		if !decision.Allowed {
+			retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			appErr := apperrors.NewRateLimitError(decision.RetryAfter)
+			log.Debug("Rate limit exceeded", "profile", profile, "key", key, "retry_after", retryAfter)
+			response.Error(w, r, appErr)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// setRateLimitHeaders reports the rate limit on the response: requests per
+// window, requests that could still be made at once, and the Unix time at
+// which the limit is fully replenished
+func setRateLimitHeaders(w http.ResponseWriter, d ratelimit.Decision) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(d.ResetAfter).Unix(), 10))
+}
+
+// ConcurrencyLimit caps how many requests of an expensive operation a company
+// may have in flight, rejecting the rest with 429 and a Retry-After hint.
+// It must be wrapped by Auth; Redis errors let the request through.
+func ConcurrencyLimit(semaphore *ratelimit.Semaphore, operation string, plans PlanLookup, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		companyID := GetCompanyID(r.Context())
+		if semaphore == nil || companyID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestID, _ := r.Context().Value(RequestIDKey).(string)
+		log := logger.With("request_id", requestID)
+
+		plan := ""
+		if plans != nil {
+			plan = plans(r.Context(), companyID)
+		}
+		release, ok, retryAfter, err := semaphore.Acquire(r.Context(), operation, plan, companyID)
+		if err != nil {
+			log.Warn("Concurrency check failed, allowing request", "operation", operation, "error", err.Error())
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			appErr := apperrors.NewRateLimitError(retryAfter)
+			log.Warn("Concurrency limit saturated", "operation", operation, "company_id", companyID, "plan", plan)
+			response.Error(w, r, appErr)
+			return
+		}
+		defer release()
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// Queue admits requests through a bounded work queue. Queued requests get
+// X-Queue-Position and X-Queue-Wait-Ms headers; when the queue is full or the
+// wait exceeds maxWait the request is rejected with 503 and Retry-After.
+func Queue(queue *workqueue.Queue, maxWait time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if queue == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestID, _ := r.Context().Value(RequestIDKey).(string)
+		log := logger.With("request_id", requestID)
+
+		ticket, release, err := queue.Acquire(r.Context(), maxWait)
+		if err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+			stats := queue.Stats()
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(maxWait.Seconds()))))
+			appErr := apperrors.NewOverloadError("AI service is busy, please retry shortly", maxWait)
+			log.Warn("AI request rejected by work queue", "reason", err.Error(), "active", stats.Active, "waiting", stats.Waiting)
+			response.Error(w, r, appErr)
+			return
+		}
+		defer release()
+
+		if ticket.Position > 0 {
+			w.Header().Set("X-Queue-Position", strconv.Itoa(ticket.Position))
+			w.Header().Set("X-Queue-Wait-Ms", strconv.FormatInt(ticket.Waited.Milliseconds(), 10))
+		}
+		next.ServeHTTP(w, r)
 	}
 }
 
-// getClientIP extracts the real client IP from request
+// trustedProxies are the reverse proxies whose forwarding headers are honoured;
+// see SetTrustedProxies
+var trustedProxies []netip.Prefix
+
+// SetTrustedProxies sets the addresses or CIDR ranges of the reverse proxies in
+// front of the API (comma-separated). Forwarding headers are ignored unless the
+// connection comes from one of them.
+func SetTrustedProxies(entries string) error {
+	var prefixes []netip.Prefix
+	for _, e := range strings.Split(entries, ",") {
+		if strings.TrimSpace(e) == "" {
+			continue
+		}
+		p, err := ipaccess.ParsePrefix(e)
+		if err != nil {
+			return err
+		}
+		prefixes = append(prefixes, p)
+	}
+	trustedProxies = prefixes
+	return nil
+}
+
+// ClientIP returns the client address of a request, honouring proxy headers
+// from trusted proxies only
+func ClientIP(r *http.Request) string {
+	return getClientIP(r)
+}
+
+// getClientIP returns the connecting address unless it is a trusted proxy. Then
+// X-Forwarded-For is read from the right, skipping trusted proxies, since only
+// the hops they appended can be believed; X-Real-IP is used without it.
 func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (for reverse proxies)
-	xForwardedFor := r.Header.Get("X-Forwarded-For")
-	if xForwardedFor != "" {
-		ips := strings.Split(xForwardedFor, ",")
-		return strings.TrimSpace(ips[0])
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !isTrustedProxy(remote) {
+		return remote
 	}
 
-	// Check X-Real-IP header
-	xRealIP := r.Header.Get("X-Real-IP")
-	if xRealIP != "" {
-		return xRealIP
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(hop); err != nil {
+				break // A malformed hop: trust nothing further left
+			}
+			if !isTrustedProxy(hop) {
+				return hop
+			}
+			remote = hop
+		}
+		return remote
 	}
+	if xRealIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); xRealIP != "" {
+		if _, err := netip.ParseAddr(xRealIP); err == nil {
+			return xRealIP
+		}
+	}
+	return remote
+}
 
-	// Fall back to RemoteAddr
-	return strings.Split(r.RemoteAddr, ":")[0]
+func isTrustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
 }
 
 // Auth validates JWT tokens and extracts user/store info
@@ -292,7 +593,7 @@ func Auth(jwtSecret string, next http.HandlerFunc) http.HandlerFunc {
 		if authHeader == "" {
 			err := apperrors.NewUnauthorizedError("Missing authorization header")
 			log.LogError(err, "Authentication failed - missing header", r.Context())
-			apperrors.WriteJSONError(w, err, err.Code)
+			response.Error(w, r, err)
 			return
 		}
 
@@ -300,7 +601,7 @@ func Auth(jwtSecret string, next http.HandlerFunc) http.HandlerFunc {
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			err := apperrors.NewUnauthorizedError("Invalid authorization header format")
 			log.LogError(err, "Authentication failed - invalid format", r.Context())
-			apperrors.WriteJSONError(w, err, err.Code)
+			response.Error(w, r, err)
 			return
 		}
 
@@ -320,16 +621,16 @@ func Auth(jwtSecret string, next http.HandlerFunc) http.HandlerFunc {
 				if strings.Contains(err.Error(), "expired") || strings.Contains(err.Error(), "exp") {
 					appErr := apperrors.NewAppError(apperrors.ErrCodeTokenExpired, "Token has expired", "")
 					log.LogError(appErr, "Authentication failed - expired token", r.Context())
-					apperrors.WriteJSONError(w, appErr, appErr.Code)
+					response.Error(w, r, appErr)
 				} else {
 					appErr := apperrors.NewUnauthorizedError("Invalid token")
 					log.LogError(appErr, "Authentication failed - invalid token", r.Context())
-					apperrors.WriteJSONError(w, appErr, appErr.Code)
+					response.Error(w, r, appErr)
 				}
 			} else {
 				appErr := apperrors.NewUnauthorizedError("Invalid token")
 				log.LogError(appErr, "Authentication failed - invalid token", r.Context())
-				apperrors.WriteJSONError(w, appErr, appErr.Code)
+				response.Error(w, r, appErr)
 			}
 			return
 		}
@@ -338,21 +639,69 @@ func Auth(jwtSecret string, next http.HandlerFunc) http.HandlerFunc {
 		if !ok {
 			err := apperrors.NewUnauthorizedError("Invalid token claims")
 			log.LogError(err, "Authentication failed - invalid claims", r.Context())
-			apperrors.WriteJSONError(w, err, err.Code)
+			response.Error(w, r, err)
 			return
 		}
 
 		userID, _ := claims["user_id"].(string)
 		storeID, _ := claims["store_id"].(string)
+		role, _ := claims["role"].(string)
+		deviceID, _ := claims["device_id"].(string)
+		if role == "" {
+			role = "user"
+		}
 
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, UserIDKey, userID)
 		ctx = context.WithValue(ctx, StoreIDKey, storeID)
+		ctx = context.WithValue(ctx, RoleKey, role)
+		ctx = context.WithValue(ctx, DeviceIDKey, deviceID)
+
+		// Admin tokens are checked on every request, not only on admin routes, as
+		// handlers also grant platform admins access by role
+		if (role == "admin" || role == "super_admin") && adminSessionCheck != nil {
+			if err := adminSessionCheck(r.WithContext(ctx)); err != nil {
+				appErr, ok := err.(*apperrors.AppError)
+				if !ok {
+					appErr = apperrors.NewForbiddenError(err.Error())
+				}
+				log.LogError(appErr, "Authentication failed - admin session rejected", r.Context())
+				response.Error(w, r, appErr)
+				return
+			}
+		}
+
+		// Access to the token's company follows the current membership, so a
+		// removed or downgraded member loses access before the token expires
+		if companyAccess != nil && storeID != "" {
+			companyRole, err := companyAccess(ctx, userID, storeID)
+			if err != nil {
+				appErr := apperrors.NewInternalError(err, "Failed to check company access")
+				log.LogError(appErr, "Authentication failed - company access check", r.Context())
+				response.Error(w, r, appErr)
+				return
+			}
+			// Platform admins keep reaching the admin API without a membership
+			if companyRole == "" && role != "admin" && role != "super_admin" {
+				appErr := apperrors.NewForbiddenError("You are not a member of this company")
+				log.LogError(appErr, "Authorization failed - not a company member", r.Context())
+				response.Error(w, r, appErr)
+				return
+			}
+			if !membership.Allows(companyRole, r.Method, r.URL.Path) {
+				appErr := apperrors.NewForbiddenError("Your role in this company is read-only")
+				log.LogError(appErr, "Authorization failed - read-only member", r.Context())
+				response.Error(w, r, appErr)
+				return
+			}
+			ctx = context.WithValue(ctx, CompanyRoleKey, companyRole)
+		}
 
 		log.Debug(
 			"Authentication successful",
 			"user_id", userID,
 			"store_id", storeID,
+			"role", role,
 		)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -371,9 +720,92 @@ func GetStoreID(ctx context.Context) string {
 	return storeID
 }
 
+// companyAccess resolves company roles; see SetCompanyAccess
+var companyAccess func(ctx context.Context, userID, companyID string) (string, error)
+
+// SetCompanyAccess installs the lookup Auth uses to resolve the user's role in
+// the token's company from its membership. It returns "" for non-members.
+func SetCompanyAccess(resolve func(ctx context.Context, userID, companyID string) (string, error)) {
+	companyAccess = resolve
+}
+
+// GetCompanyRole extracts the user's role in the company (owner, manager,
+// viewer) from context
+func GetCompanyRole(ctx context.Context) string {
+	role, _ := ctx.Value(CompanyRoleKey).(string)
+	return role
+}
+
+// RequireCompanyRole only lets members with one of roles in the token's
+// company through. Use it inside Auth.
+func RequireCompanyRole(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role := GetCompanyRole(r.Context())
+		for _, allowed := range roles {
+			if role == allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		requestID, _ := r.Context().Value(RequestIDKey).(string)
+		err := apperrors.NewForbiddenError("Your role in this company does not allow this action")
+		logger.With("request_id", requestID).LogError(err, "Authorization failed - company role not allowed", r.Context())
+		response.Error(w, r, err)
+	}
+}
+
+// GetRole extracts the platform role (user, admin, super_admin) from context
+func GetRole(ctx context.Context) string {
+	role, _ := ctx.Value(RoleKey).(string)
+	return role
+}
+
+// adminSessionCheck validates privileged sessions; see SetAdminSessionCheck
+var adminSessionCheck func(r *http.Request) error
+
+// SetAdminSessionCheck installs a check run by Auth on every request made with
+// an admin or super_admin token, e.g. IP allowlisting and device revocation.
+// A returned *errors.AppError is written as is; other errors are reported as forbidden.
+func SetAdminSessionCheck(check func(r *http.Request) error) {
+	adminSessionCheck = check
+}
+
+// GetDeviceID extracts the admin device ID from context ("" for non-admin tokens)
+func GetDeviceID(ctx context.Context) string {
+	deviceID, _ := ctx.Value(DeviceIDKey).(string)
+	return deviceID
+}
+
+// RequireRole rejects requests whose platform role is not in the allowed list.
+// It must be wrapped by Auth so the role is present in context.
+func RequireRole(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		role := GetRole(r.Context())
+		for _, allowed := range roles {
+			if role == allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		requestID, _ := r.Context().Value(RequestIDKey).(string)
+		log := logger.With("request_id", requestID)
+
+		err := apperrors.NewForbiddenError("Insufficient role for this action")
+		log.LogError(err, "Authorization failed - role not allowed", r.Context())
+		response.Error(w, r, err)
+	}
+}
+
 // GetCompanyID extracts company ID from context (same as store_id for now)
 // TODO: Update JWT to use company_id instead of store_id
 func GetCompanyID(ctx context.Context) string {
 	// For now, store_id in JWT is actually company_id after migration
 	return GetStoreID(ctx)
 }
+
+// WithCompanyID attributes work done outside a request, such as a scheduled
+// job, to a company
+func WithCompanyID(ctx context.Context, companyID string) context.Context {
+	return context.WithValue(ctx, StoreIDKey, companyID)
+}
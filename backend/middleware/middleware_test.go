@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	if err := SetTrustedProxies("10.0.0.0/8, 2001:db8::1"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetTrustedProxies("")
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		realIP string
+		want   string
+	}{
+		{name: "direct client ignores forwarding headers", remote: "203.0.113.7:5000", xff: "198.51.100.1", realIP: "198.51.100.2", want: "203.0.113.7"},
+		{name: "ipv6 remote", remote: "[2001:db8::7]:443", want: "2001:db8::7"},
+		{name: "trusted proxy, rightmost untrusted hop", remote: "10.0.0.2:80", xff: "198.51.100.1, 203.0.113.9, 10.0.0.3", want: "203.0.113.9"},
+		{name: "spoofed leftmost entry is not used", remote: "10.0.0.2:80", xff: "192.0.2.1, 203.0.113.9", want: "203.0.113.9"},
+		{name: "malformed hop stops the walk", remote: "10.0.0.2:80", xff: "203.0.113.9, bogus, 10.0.0.3", want: "10.0.0.3"},
+		{name: "trusted ipv6 proxy with X-Real-IP", remote: "[2001:db8::1]:443", realIP: "203.0.113.4", want: "203.0.113.4"},
+		{name: "trusted proxy without headers", remote: "10.0.0.2:80", want: "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := SetTrustedProxies("not-a-cidr"); err == nil {
+		t.Error("expected an invalid proxy entry to be rejected")
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// AdminDevice is a browser or client an admin has signed in from
+type AdminDevice struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Email       string     `json:"email,omitempty"`
+	UserAgent   string     `json:"user_agent"`
+	FirstIP     string     `json:"first_ip"`
+	LastIP      string     `json:"last_ip"`
+	FirstSeenAt time.Time  `json:"first_seen_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+	ApprovedAt  *time.Time `json:"approved_at,omitempty"` // Unset while the emailed approval is pending
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	Current     bool       `json:"current"` // The device making the request
+}
+
+// AdminIPAllowEntry is an address range admin sessions are accepted from
+type AdminIPAllowEntry struct {
+	ID          string    `json:"id"`
+	CIDR        string    `json:"cidr"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
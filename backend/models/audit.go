@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog records an admin mutation
+type AuditLog struct {
+	ID          string    `json:"id"`
+	ActorUserID string    `json:"actor_user_id,omitempty"`
+	ActorEmail  string    `json:"actor_email,omitempty"`
+	ActorRole   string    `json:"actor_role"`
+	Action      string    `json:"action"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`
+	ResourceID  string    `json:"resource_id,omitempty"`
+	StatusCode  int       `json:"status_code"`
+	IP          string    `json:"ip,omitempty"`
+	RequestID   string    `json:"request_id,omitempty"`
+	HasPayload  bool      `json:"has_payload"` // A captured request body is available to super admins
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ProviderCall is a sampled AI provider request/response pair with secrets
+// and personal data redacted, kept for debugging and replay
+type ProviderCall struct {
+	ID         string          `json:"id"`
+	Provider   string          `json:"provider"`
+	Endpoint   string          `json:"endpoint"`
+	CompanyID  string          `json:"company_id,omitempty"`
+	Request    json.RawMessage `json:"request,omitempty"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	StatusCode int             `json:"status_code"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs int             `json:"duration_ms"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ExpiresAt  time.Time       `json:"expires_at"`
+}
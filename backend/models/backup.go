@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+)
+
+// SnapshotVersion is the current company snapshot format version.
+// Bump it whenever the snapshot structure changes incompatibly.
+const SnapshotVersion = 1
+
+// CompanyBackup represents a stored company snapshot
+type CompanyBackup struct {
+	ID                string     `json:"id"`
+	CompanyID         string     `json:"company_id"`
+	SnapshotVersion   int        `json:"snapshot_version"`
+	StoragePath       string     `json:"-"`
+	StorageRegion     string     `json:"storage_region"`
+	SizeBytes         int64      `json:"size_bytes"`
+	ProductCount      int        `json:"product_count"`
+	SalesCount        int        `json:"sales_count"`
+	ConversationCount int        `json:"conversation_count"`
+	CreatedBy         string     `json:"created_by,omitempty"`
+	RestoredAt        *time.Time `json:"restored_at,omitempty"`
+	RestoredBy        string     `json:"restored_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// CompanySnapshot is the serialized content of a company backup
+type CompanySnapshot struct {
+	Version       int                    `json:"version"`
+	CompanyID     string                 `json:"company_id"`
+	CreatedAt     time.Time              `json:"created_at"`
+	Settings      CompanySettings        `json:"settings"`
+	Products      []SnapshotProduct      `json:"products"`
+	Sales         []SnapshotSale         `json:"sales"`
+	Conversations []SnapshotConversation `json:"conversations"`
+}
+
+// CompanySettings holds the editable company profile fields captured in a snapshot
+type CompanySettings struct {
+	Name               string            `json:"name"`
+	Description        string            `json:"description,omitempty"`
+	Industry           string            `json:"industry,omitempty"`
+	BusinessModel      string            `json:"business_model,omitempty"`
+	FoundedYear        *int              `json:"founded_year,omitempty"`
+	LocationRegion     string            `json:"location_region,omitempty"`
+	City               string            `json:"city,omitempty"`
+	Country            string            `json:"country,omitempty"`
+	Website            string            `json:"website,omitempty"`
+	SocialMediaHandles map[string]string `json:"social_media_handles,omitempty"`
+	Marketplaces       map[string]string `json:"marketplaces,omitempty"`
+	Timezone           string            `json:"timezone,omitempty"`
+}
+
+// SnapshotProduct is a product row captured in a snapshot
+type SnapshotProduct struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	SKU       string    `json:"sku,omitempty"`
+	Category  string    `json:"category,omitempty"`
+	UnitPrice float64   `json:"unit_price"`
+	Cost      float64   `json:"cost"`
+	Unit      string    `json:"unit,omitempty"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SnapshotSale is a sales_history row captured in a snapshot
+type SnapshotSale struct {
+	ID        int64     `json:"id"`
+	ProductID string    `json:"product_id"`
+	Quantity  float64   `json:"quantity"`
+	Price     float64   `json:"price"`
+	SaleDate  time.Time `json:"sale_date"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SnapshotConversation is conversation metadata (without messages) captured in a snapshot
+type SnapshotConversation struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Title     string    `json:"title,omitempty"`
+	Purpose   string    `json:"purpose,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// BillingOrder is a plan purchase paid through the payment gateway
+type BillingOrder struct {
+	ID            string     `json:"id"`
+	CompanyID     string     `json:"company_id"`
+	RequestedBy   string     `json:"requested_by,omitempty"`
+	Provider      string     `json:"provider"`
+	Plan          string     `json:"plan"`
+	Amount        int64      `json:"amount"` // Rupiah
+	Status        string     `json:"status"`
+	PaymentType   string     `json:"payment_type,omitempty"`
+	RedirectURL   string     `json:"redirect_url,omitempty"` // Payment page; set while pending
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	PlanExpiresAt *time.Time `json:"plan_expires_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// PlanPrice is what a paid plan costs per period
+type PlanPrice struct {
+	Plan       string `json:"plan"`
+	Amount     int64  `json:"amount"`
+	Currency   string `json:"currency"`
+	PeriodDays int    `json:"period_days"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BulkOperation is an admin action applied to many users in the background
+type BulkOperation struct {
+	ID          string          `json:"id"`
+	Action      string          `json:"action"`
+	Params      json.RawMessage `json:"params"`
+	Filter      json.RawMessage `json:"filter,omitempty"`
+	Status      string          `json:"status"`
+	Total       int             `json:"total"`
+	Succeeded   int             `json:"succeeded"`
+	Skipped     int             `json:"skipped"`
+	Failed      int             `json:"failed"`
+	RequestedBy string          `json:"requested_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// BulkOperationItem is the result of a bulk operation for one user
+type BulkOperationItem struct {
+	UserID      string     `json:"user_id"`
+	Email       string     `json:"email,omitempty"`
+	Status      string     `json:"status"`
+	Message     string     `json:"message,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
@@ -31,3 +31,12 @@ type CompanyProfile struct {
 	SalesData   []*Sale       `json:"sales_data,omitempty"` // Aggregated
 	LastUpdated time.Time     `json:"last_updated"`
 }
+
+// CompanyLifecycle describes a company's dormancy state
+type CompanyLifecycle struct {
+	Status       string     `json:"status"` // "active" or "archived"
+	LastActiveAt *time.Time `json:"last_active_at,omitempty"`
+	DormantAt    *time.Time `json:"dormant_at,omitempty"` // Flagged inactive; cleared by any activity
+	ArchiveAt    *time.Time `json:"archive_at,omitempty"` // When a dormant company will be archived
+	ArchivedAt   *time.Time `json:"archived_at,omitempty"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// ComplianceRule makes a requirement apply to companies matching all of its
+// non-empty KBLI prefix, legal form and province constraints
+type ComplianceRule struct {
+	ID            string    `json:"id"`
+	Requirement   string    `json:"requirement"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	Authority     string    `json:"authority,omitempty"`
+	ReferenceURL  string    `json:"reference_url,omitempty"`
+	KBLIPrefixes  []string  `json:"kbli_prefixes"`
+	LegalForms    []string  `json:"legal_forms"`
+	ProvinceCodes []string  `json:"province_codes"`
+	Priority      int       `json:"priority"`
+	Active        bool      `json:"active"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ComplianceItem is a requirement on a company's checklist with its progress
+type ComplianceItem struct {
+	Requirement     string              `json:"requirement"`
+	Title           string              `json:"title"`
+	Description     string              `json:"description"`
+	Authority       string              `json:"authority,omitempty"`
+	ReferenceURL    string              `json:"reference_url,omitempty"`
+	Reasons         []string            `json:"reasons"`
+	RuleIDs         []string            `json:"rule_ids"`
+	Status          string              `json:"status"`  // missing, in_progress, obtained or not_applicable
+	Expired         bool                `json:"expired"` // Obtained, but past expires_on
+	ReferenceNumber string              `json:"reference_number,omitempty"`
+	ObtainedOn      *time.Time          `json:"obtained_on,omitempty"`
+	ExpiresOn       *time.Time          `json:"expires_on,omitempty"`
+	Document        *ComplianceDocument `json:"document,omitempty"`
+	Notes           string              `json:"notes,omitempty"`
+	UpdatedAt       *time.Time          `json:"updated_at,omitempty"`
+}
+
+// ComplianceDocument is the uploaded permit or certificate attached to a checklist item
+type ComplianceDocument struct {
+	FileUploadID     string `json:"file_upload_id"`
+	OriginalFilename string `json:"original_filename"`
+}
+
+// ComplianceChecklist lists the requirements that apply to a company. Missing
+// profile fields mean only requirements for every business are included.
+type ComplianceChecklist struct {
+	KBLICode     string           `json:"kbli_code,omitempty"`
+	LegalForm    string           `json:"legal_form,omitempty"`
+	ProvinceCode string           `json:"province_code,omitempty"`
+	Missing      []string         `json:"missing"` // Profile fields to fill in for a complete checklist
+	Items        []ComplianceItem `json:"items"`
+	Done         int              `json:"done"`  // Obtained and not expired
+	Total        int              `json:"total"` // Items that apply, excluding not_applicable
+}
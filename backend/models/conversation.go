@@ -23,5 +23,97 @@ type Message struct {
 	Content           string                 `json:"content"`
 	StructuredPayload map[string]interface{} `json:"structured_payload,omitempty"` // JSONB - extracted fields, tool calls
 	FileUploadID      *string                `json:"file_upload_id,omitempty"`
+	Attachments       []MessageAttachment    `json:"attachments,omitempty"` // Files attached to a user message
 	CreatedAt         time.Time              `json:"created_at"`
 }
+
+// MessageAttachment is a file attached to a chat message
+type MessageAttachment struct {
+	FileID           string `json:"file_id"`
+	OriginalFilename string `json:"original_filename"`
+	SourceType       string `json:"source_type"`
+}
+
+// ChatAnalytics summarizes how a company uses the assistant over a period
+type ChatAnalytics struct {
+	Days              int              `json:"days"`
+	TotalMessages     int              `json:"total_messages"`
+	FailedMessages    int              `json:"failed_messages"`
+	AvgResponseMs     float64          `json:"avg_response_ms"`
+	MessagesPerDay    []ChatDailyCount `json:"messages_per_day"`
+	TopTools          []ChatToolUsage  `json:"top_tools"`
+	Topics            []ChatTopic      `json:"topics"`
+	TopicsUnavailable bool             `json:"topics_unavailable,omitempty"` // Embeddings are not configured
+}
+
+// ChatTopic is a cluster of similar questions
+type ChatTopic struct {
+	Label    string   `json:"label"`
+	Count    int      `json:"count"`
+	Examples []string `json:"examples"`
+}
+
+// ChatDailyCount is the number of messages on one company-local day
+type ChatDailyCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// ChatToolUsage counts calls to one chat tool
+type ChatToolUsage struct {
+	Tool  string `json:"tool"`
+	Calls int    `json:"calls"`
+}
+
+// ChatPlanEngagement is assistant usage aggregated over the companies on one plan
+type ChatPlanEngagement struct {
+	Plan              string  `json:"plan"`
+	Companies         int     `json:"companies"`
+	ActiveCompanies   int     `json:"active_companies"`
+	Messages          int     `json:"messages"`
+	MessagesPerActive float64 `json:"messages_per_active_company"`
+	AvgResponseMs     float64 `json:"avg_response_ms"`
+}
+
+// MessageFeedback is a user's rating of an assistant answer
+type MessageFeedback struct {
+	MessageID string    `json:"message_id"`
+	Rating    string    `json:"rating"`           // up or down
+	Reason    string    `json:"reason,omitempty"` // incorrect, incomplete, irrelevant or other
+	Comment   string    `json:"comment,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MessageRegeneration is an alternative answer to a disliked message, produced
+// by one retrieval and model variant
+type MessageRegeneration struct {
+	ID            string     `json:"id"`
+	MessageID     string     `json:"message_id"`
+	Variant       string     `json:"variant"`
+	Model         string     `json:"model"`
+	ContextChunks int        `json:"context_chunks"` // Retrieved passages given to the model
+	Content       string     `json:"content"`
+	Accepted      bool       `json:"accepted"`
+	CreatedAt     time.Time  `json:"created_at"`
+	AcceptedAt    *time.Time `json:"accepted_at,omitempty"`
+}
+
+// ChatEvalExample pairs a disliked answer with the alternative the user accepted
+type ChatEvalExample struct {
+	MessageID       string    `json:"message_id"`
+	Question        string    `json:"question"`
+	OriginalAnswer  string    `json:"original_answer"`
+	AcceptedAnswer  string    `json:"accepted_answer"`
+	AcceptedVariant string    `json:"accepted_variant"`
+	VariantsTried   []string  `json:"variants_tried"`
+	Reason          string    `json:"reason,omitempty"`
+	AcceptedAt      time.Time `json:"accepted_at"`
+}
+
+// ChatVariantStats counts how often a regeneration variant was generated and accepted
+type ChatVariantStats struct {
+	Variant   string  `json:"variant"`
+	Generated int     `json:"generated"`
+	Accepted  int     `json:"accepted"`
+	Rate      float64 `json:"acceptance_rate"`
+}
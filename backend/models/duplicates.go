@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// DuplicateRecord summarizes one side of a duplicate candidate
+type DuplicateRecord struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Detail    string    `json:"detail,omitempty"` // Owner email for companies, SKU for products
+	Products  int       `json:"products,omitempty"`
+	Sales     int       `json:"sales"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DuplicateCandidate is a pair of records that look like duplicates. Merging
+// usually keeps the record with more sales history.
+type DuplicateCandidate struct {
+	A         DuplicateRecord `json:"a"`
+	B         DuplicateRecord `json:"b"`
+	Score     float64         `json:"score"`
+	NameScore float64         `json:"name_score"`
+	Shared    []string        `json:"shared,omitempty"` // Metadata fields with equal values
+}
+
+// DuplicateMerge records a merge of one company or product into another
+type DuplicateMerge struct {
+	ID       string         `json:"id"`
+	Kind     string         `json:"kind"`
+	SourceID string         `json:"source_id"`
+	TargetID string         `json:"target_id"`
+	Moved    map[string]int `json:"moved"` // Rows moved per table
+}
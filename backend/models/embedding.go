@@ -0,0 +1,29 @@
+package models
+
+// EmbeddingUsage counts chunks processed by the vector store and the embedding
+// tokens spent or avoided through content-hash reuse
+type EmbeddingUsage struct {
+	ChunksTotal    int `json:"chunks_total"`
+	ChunksReused   int `json:"chunks_reused"`
+	ChunksEmbedded int `json:"chunks_embedded"`
+	TokensSpent    int `json:"tokens_spent"`
+	TokensSaved    int `json:"tokens_saved"`
+}
+
+// EmbeddingNamespaceStats describes the contents of one vector store namespace
+type EmbeddingNamespaceStats struct {
+	Namespace  string `json:"namespace"`
+	Sources    int    `json:"sources"`
+	Chunks     int    `json:"chunks"`
+	Embeddings int    `json:"embeddings"`
+	Tokens     int    `json:"tokens"`
+}
+
+// VectorMatch is a chunk returned by semantic search
+type VectorMatch struct {
+	Namespace  string  `json:"namespace"`
+	SourceID   string  `json:"source_id"`
+	ChunkIndex int     `json:"chunk_index"`
+	Content    string  `json:"content"`
+	Score      float64 `json:"score"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// ExportParams narrows what an export contains
+type ExportParams struct {
+	From string `json:"from,omitempty"` // Inclusive start date, YYYY-MM-DD
+	To   string `json:"to,omitempty"`   // Inclusive end date, YYYY-MM-DD
+}
+
+// ExportJob is a background export whose artifact is kept in object storage
+type ExportJob struct {
+	ID            string       `json:"id"`
+	CompanyID     string       `json:"company_id"`
+	RequestedBy   string       `json:"requested_by,omitempty"`
+	Kind          string       `json:"kind"`
+	Format        string       `json:"format"`
+	Params        ExportParams `json:"params"`
+	Status        string       `json:"status"`
+	StoragePath   string       `json:"-"`
+	StorageRegion string       `json:"storage_region,omitempty"`
+	SizeBytes     int64        `json:"size_bytes"`
+	RowCount      int          `json:"row_count"`
+	Error         string       `json:"error,omitempty"`
+	DownloadURL   string       `json:"download_url,omitempty"` // Signed, short-lived; set on completed jobs
+	CreatedAt     time.Time    `json:"created_at"`
+	StartedAt     *time.Time   `json:"started_at,omitempty"`
+	FinishedAt    *time.Time   `json:"finished_at,omitempty"`
+	ExpiresAt     *time.Time   `json:"expires_at,omitempty"`
+}
@@ -1,23 +1,49 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
-// FileUpload represents an uploaded file (CSV/XLSX/PDF)
+// FileUpload represents an uploaded file (CSV/XLSX/DOCX/PDF or a photo)
 type FileUpload struct {
-	ID               string     `json:"id"`
-	CompanyID        string     `json:"company_id"`
-	UserID           string     `json:"user_id"`
-	SourceType       string     `json:"source_type"` // "csv", "xlsx", "pdf"
-	OriginalFilename string     `json:"original_filename"`
-	StoragePath      string     `json:"storage_path"`
-	MimeType         string     `json:"mime_type,omitempty"`
-	SizeBytes        int64      `json:"size_bytes"`
-	Status           string     `json:"status"` // "uploaded", "processing", "processed", "failed"
-	ErrorMessage     string     `json:"error_message,omitempty"`
-	CreatedAt        time.Time  `json:"created_at"`
-	ProcessedAt      *time.Time `json:"processed_at,omitempty"`
+	ID               string          `json:"id"`
+	CompanyID        string          `json:"company_id"`
+	UserID           string          `json:"user_id"`
+	SourceType       string          `json:"source_type"` // "csv", "xlsx", "docx", "pdf", "image"
+	OriginalFilename string          `json:"original_filename"`
+	StoragePath      string          `json:"storage_path"`
+	StorageRegion    string          `json:"storage_region"` // Data residency region, e.g. "id-jkt"
+	MimeType         string          `json:"mime_type,omitempty"`
+	SizeBytes        int64           `json:"size_bytes"`
+	Status           string          `json:"status"`     // "uploaded", "processing", "processed", "failed"
+	Visibility       string          `json:"visibility"` // "company" or "private"
+	ErrorMessage     string          `json:"error_message,omitempty"`
+	Preprocessing    json.RawMessage `json:"preprocessing,omitempty"` // How a photo was prepared for OCR
+	CreatedAt        time.Time       `json:"created_at"`
+	ProcessedAt      *time.Time      `json:"processed_at,omitempty"`
+}
+
+// File visibility levels
+const (
+	FileVisibilityCompany = "company" // Everyone in the company
+	FileVisibilityPrivate = "private" // Only the uploader and users it is shared with
+)
+
+// FileShare grants one user access to a private file
+type FileShare struct {
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	SharedBy  string    `json:"shared_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FileAccessEntry is one access log record of a file
+type FileAccessEntry struct {
+	UserID    string    `json:"user_id"`
+	Action    string    `json:"action"` // "view", "visibility", "share", "unshare"
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // ExtractedData represents data extracted from a file
@@ -41,3 +67,13 @@ type ExtractedSale struct {
 	Price       float64   `json:"price"`
 	Date        time.Time `json:"date"`
 }
+
+// OCREngineStats summarizes one OCR engine over a window of scanned PDFs
+type OCREngineStats struct {
+	Engine        string  `json:"engine"`
+	Attempts      int     `json:"attempts"`       // Times the engine was tried
+	Failures      int     `json:"failures"`       // Attempts that returned an error
+	Accepted      int     `json:"accepted"`       // Files whose text came from this engine
+	AvgConfidence float64 `json:"avg_confidence"` // Of accepted results, 0-1
+	LowConfidence int     `json:"low_confidence"` // Accepted below OCR_MIN_CONFIDENCE because no engine did better
+}
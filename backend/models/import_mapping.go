@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// ImportColumnMapping is a confirmed mapping of a spreadsheet's columns to the
+// canonical import fields, saved per source so files of the same origin import directly
+type ImportColumnMapping struct {
+	ID        string            `json:"id"`
+	CompanyID string            `json:"company_id"`
+	Source    string            `json:"source"`  // User-chosen name of the file's origin
+	Mapping   map[string]string `json:"mapping"` // Canonical field -> column header
+	Headers   []string          `json:"headers"` // Header row the mapping was confirmed against
+	CreatedBy string            `json:"created_by,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
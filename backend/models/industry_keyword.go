@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// IndustryKeyword is a trends keyword tracked once for an industry and shared
+// with every company in it
+type IndustryKeyword struct {
+	ID        string    `json:"id"`
+	Industry  string    `json:"industry"`
+	Keyword   string    `json:"keyword"`
+	CreatedBy *string   `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
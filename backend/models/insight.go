@@ -80,3 +80,66 @@ type Regulation struct {
 	EffectiveDate       *string  `json:"effective_date,omitempty"`
 	ComplianceChecklist []string `json:"compliance_checklist,omitempty"`
 }
+
+// Insight action types
+const (
+	InsightActionCampaign    = "campaign"
+	InsightActionPriceChange = "price_change"
+	InsightActionOther       = "other"
+)
+
+// InsightAdoption is a recommendation the company decided to follow
+type InsightAdoption struct {
+	ID             string          `json:"id"`
+	InsightID      string          `json:"insight_id"`
+	Recommendation string          `json:"recommendation"`
+	ProductID      *string         `json:"product_id,omitempty"`
+	BaselineDays   int             `json:"baseline_days"`
+	Notes          string          `json:"notes,omitempty"`
+	AdoptedAt      time.Time       `json:"adopted_at"`
+	Actions        []InsightAction `json:"actions"`
+	Outcomes       []InsightMetric `json:"outcomes"`
+	SalesImpact    *SalesImpact    `json:"sales_impact,omitempty"`
+}
+
+// InsightAction is a campaign, price change or other step taken after adoption
+type InsightAction struct {
+	ID          string     `json:"id"`
+	AdoptionID  string     `json:"adoption_id"`
+	Type        string     `json:"type"`
+	Description string     `json:"description"`
+	ProductID   *string    `json:"product_id,omitempty"`
+	OldPrice    *float64   `json:"old_price,omitempty"`
+	NewPrice    *float64   `json:"new_price,omitempty"`
+	Channel     string     `json:"channel,omitempty"`
+	StartedOn   time.Time  `json:"started_on"`
+	EndedOn     *time.Time `json:"ended_on,omitempty"`
+}
+
+// InsightMetric is an outcome metric reported by the user
+type InsightMetric struct {
+	ID            string    `json:"id"`
+	AdoptionID    string    `json:"adoption_id"`
+	Metric        string    `json:"metric"`
+	BaselineValue *float64  `json:"baseline_value,omitempty"`
+	Value         float64   `json:"value"`
+	Change        *float64  `json:"change,omitempty"` // Percent change from baseline
+	MeasuredOn    time.Time `json:"measured_on"`
+	Notes         string    `json:"notes,omitempty"`
+}
+
+// SalesImpact compares daily sales before and after a recommendation was adopted
+type SalesImpact struct {
+	BaselineFrom     string  `json:"baseline_from"`
+	BaselineTo       string  `json:"baseline_to"`
+	AfterFrom        string  `json:"after_from"`
+	AfterTo          string  `json:"after_to"`
+	DaysObserved     int     `json:"days_observed"`
+	BaselineRevenue  float64 `json:"baseline_revenue_per_day"`
+	AfterRevenue     float64 `json:"after_revenue_per_day"`
+	RevenueChange    float64 `json:"revenue_change"` // Percent
+	BaselineQuantity float64 `json:"baseline_quantity_per_day"`
+	AfterQuantity    float64 `json:"after_quantity_per_day"`
+	QuantityChange   float64 `json:"quantity_change"` // Percent
+	Verdict          string  `json:"verdict"`         // improved, declined, no_change, too_early
+}
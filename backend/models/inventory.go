@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// StockLevel is a product's stock on hand next to its forecast demand
+type StockLevel struct {
+	ProductID    string     `json:"product_id"`
+	ProductName  string     `json:"product_name"`
+	SKU          string     `json:"sku,omitempty"`
+	Unit         string     `json:"unit"`
+	OnHand       *float64   `json:"stock_on_hand"` // nil until stock is first recorded
+	ReorderPoint *float64   `json:"reorder_point,omitempty"`
+	Status       string     `json:"status"`                  // in_stock, low, out_of_stock, not_tracked
+	DailyDemand  float64    `json:"daily_demand"`            // Forecast sales per day
+	Demand30d    int        `json:"demand_30d"`              // Forecast sales over the next 30 days
+	DaysOfCover  *int       `json:"days_of_cover,omitempty"` // How long the stock lasts at the forecast rate
+	LastMovedAt  *time.Time `json:"last_moved_at,omitempty"`
+}
+
+// StockMovement is one change to a product's stock on hand
+type StockMovement struct {
+	ID          int64     `json:"id"`
+	ProductID   string    `json:"product_id"`
+	ProductName string    `json:"product_name,omitempty"`
+	Kind        string    `json:"kind"`     // purchase, sale, adjustment
+	Quantity    float64   `json:"quantity"` // Signed change in the product's unit
+	Balance     float64   `json:"balance"`  // Stock on hand after the movement
+	SaleID      *int64    `json:"sale_id,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ReorderSummary is what the dashboard shows of the reorder recommendations
+type ReorderSummary struct {
+	OrderNow  int            `json:"order_now"`  // At or below the reorder point
+	OrderSoon int            `json:"order_soon"` // Reaching it within the review period
+	Next      []ReorderAlert `json:"next"`       // Most urgent first
+}
+
+// ReorderAlert is a product due for a reorder
+type ReorderAlert struct {
+	ProductID     string  `json:"product_id"`
+	ProductName   string  `json:"product_name"`
+	Status        string  `json:"status"` // order_now, order_soon
+	ReorderDate   string  `json:"reorder_date"`
+	OrderQuantity float64 `json:"order_quantity"`
+	Unit          string  `json:"unit"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// KBLICategory is an entry of the KBLI 2020 business classification
+type KBLICategory struct {
+	Code       string `json:"code"`
+	Title      string `json:"title"`
+	Level      string `json:"level"`
+	Section    string `json:"section"`
+	ParentCode string `json:"parent_code,omitempty"`
+}
+
+// CompanyIndustry is a company's free-text industry and its KBLI code
+type CompanyIndustry struct {
+	Industry   string          `json:"industry"`
+	KBLICode   string          `json:"kbli_code,omitempty"`
+	KBLITitle  string          `json:"kbli_title,omitempty"`
+	Section    string          `json:"section,omitempty"`
+	Suggestion *KBLISuggestion `json:"suggestion,omitempty"` // Pending suggestion awaiting confirmation
+}
+
+// KBLISuggestion is a KBLI code proposed for a company's free-text industry
+type KBLISuggestion struct {
+	ID          string     `json:"id"`
+	CompanyID   string     `json:"company_id"`
+	CompanyName string     `json:"company_name,omitempty"`
+	Industry    string     `json:"industry"`
+	KBLICode    string     `json:"kbli_code"`
+	KBLITitle   string     `json:"kbli_title"`
+	Score       float64    `json:"score"`
+	Method      string     `json:"method"` // "embedding" or "lexical"
+	Status      string     `json:"status"` // "pending", "confirmed" or "rejected"
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// KBArticle is a curated knowledge-base article, e.g. a permit guide or a
+// marketing playbook. Body is omitted from listings.
+type KBArticle struct {
+	ID          string     `json:"id"`
+	Slug        string     `json:"slug"`
+	Title       string     `json:"title"`
+	Category    string     `json:"category"`
+	Summary     string     `json:"summary"`
+	Body        string     `json:"body,omitempty"`
+	Status      string     `json:"status"` // draft, published or archived
+	Pinned      bool       `json:"pinned"`
+	Version     int        `json:"version"`
+	ChunkCount  int        `json:"chunk_count"`
+	Indexed     bool       `json:"indexed"` // The current version is embedded for retrieval
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// KBArticleVersion is one saved revision of an article's content
+type KBArticleVersion struct {
+	Version   int       `json:"version"`
+	Title     string    `json:"title"`
+	Category  string    `json:"category"`
+	Summary   string    `json:"summary"`
+	Body      string    `json:"body"`
+	EditedBy  string    `json:"edited_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KBCitation is a knowledge-base article retrieved as a source for an answer
+type KBCitation struct {
+	ArticleID string  `json:"article_id"`
+	Slug      string  `json:"slug"`
+	Title     string  `json:"title"`
+	URL       string  `json:"url"`
+	Excerpt   string  `json:"excerpt"`
+	Pinned    bool    `json:"pinned"`
+	Score     float64 `json:"score"`
+}
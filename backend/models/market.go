@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// MarketResearchArticle is a web page fetched during market research
+type MarketResearchArticle struct {
+	ID          string     `json:"id"`
+	URL         string     `json:"url"`
+	Title       string     `json:"title"`
+	Excerpt     string     `json:"excerpt,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	FetchedAt   time.Time  `json:"fetched_at"`
+	Score       float64    `json:"score,omitempty"` // Similarity, set by semantic search
+}
+
+// MarketResearch is the set of articles returned for one research query
+type MarketResearch struct {
+	Query     string                  `json:"query"`
+	Reused    bool                    `json:"reused"` // Served from the archive instead of a new web search
+	CreatedAt time.Time               `json:"created_at"`
+	Articles  []MarketResearchArticle `json:"articles"`
+}
@@ -26,15 +26,31 @@ type Store struct {
 
 // Product represents a product catalog item
 type Product struct {
-	ID          string    `json:"id"`
-	StoreID     string    `json:"store_id"`
-	ProductName string    `json:"product_name"`
-	SKU         string    `json:"sku,omitempty"`
-	Category    string    `json:"category,omitempty"`
-	UnitPrice   float64   `json:"unit_price"`
-	Cost        float64   `json:"cost,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           string    `json:"id"`
+	StoreID      string    `json:"store_id"`
+	ProductName  string    `json:"product_name"`
+	SKU          string    `json:"sku,omitempty"`
+	Category     string    `json:"category,omitempty"`
+	UnitPrice    float64   `json:"unit_price"`
+	Cost         float64   `json:"cost,omitempty"`
+	ImageURL     string    `json:"image_url,omitempty"`     // Primary image
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"` // Thumbnail of the primary image
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ProductImage is a photo attached to a product, with a generated thumbnail
+type ProductImage struct {
+	ID           string    `json:"id"`
+	ProductID    string    `json:"product_id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	SizeBytes    int64     `json:"size_bytes"`
+	IsPrimary    bool      `json:"is_primary"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 // Sale represents a sales history entry
@@ -42,7 +58,8 @@ type Sale struct {
 	ID        int64     `json:"id"`
 	StoreID   string    `json:"store_id"`
 	ProductID string    `json:"product_id"`
-	Quantity  int       `json:"quantity"`
+	Quantity  float64   `json:"quantity"` // In the product's unit
+	Unit      string    `json:"unit,omitempty"`
 	Price     float64   `json:"price"`
 	SaleDate  time.Time `json:"sale_date"`
 	Source    string    `json:"source"` // manual, csv, woocommerce, shopee
@@ -71,6 +88,8 @@ type Recommendation struct {
 	ProjectedDemand int    `json:"projected_demand"` // 30-day projected demand
 	Reason          string `json:"reason"`
 	RiskLevel       string `json:"risk_level"` // low, medium, high
+	ABCClass        string `json:"abc_class,omitempty"`
+	FocusProduct    bool   `json:"focus_product"`
 }
 
 // Integration represents an external platform integration
@@ -78,7 +97,7 @@ type Integration struct {
 	ID           string     `json:"id"`
 	StoreID      string     `json:"store_id"`
 	Platform     string     `json:"platform"` // woocommerce, shopee, tokopedia
-	Status       string     `json:"status"`   // connected, disconnected, error
+	Status       string     `json:"status"`   // connected, disconnected, error, auth_failed
 	LastSync     *time.Time `json:"last_sync,omitempty"`
 	ErrorMessage string     `json:"error_message,omitempty"`
 	Metadata     string     `json:"metadata,omitempty"` // JSON string for platform-specific data
@@ -123,11 +142,55 @@ type DashboardSummary struct {
 	// Insights Summary
 	InsightsSummary InsightsCounts `json:"insights_summary"`
 
+	// Business health
+	Health *BusinessHealth `json:"health,omitempty"`
+
+	// Products to restock, from forecasts and stock on hand
+	Reorder *ReorderSummary `json:"reorder,omitempty"`
+
 	// Recent Activity
 	RecentConversations []ConversationSummary `json:"recent_conversations,omitempty"`
 	RecentFileUploads   []FileUploadSummary   `json:"recent_file_uploads,omitempty"`
 }
 
+// BusinessHealth is the composite health score with its breakdown and recent history
+type BusinessHealth struct {
+	Score   int            `json:"score"` // 0-100
+	Level   string         `json:"level"` // healthy, watch, at_risk, no_data
+	Factors []HealthFactor `json:"factors"`
+	History []HealthPoint  `json:"history"`
+}
+
+// HealthFactor is one component of the business health score
+type HealthFactor struct {
+	Key       string  `json:"key"`
+	Label     string  `json:"label"`
+	Score     int     `json:"score"`
+	Weight    float64 `json:"weight"`
+	Value     float64 `json:"value"`
+	Detail    string  `json:"detail"`
+	Available bool    `json:"available"`
+}
+
+// HealthPoint is a daily health score snapshot
+type HealthPoint struct {
+	Date  string `json:"date"`
+	Score int    `json:"score"`
+	Level string `json:"level"`
+}
+
+// WeeklyDigest summarizes the last seven days and nags about unfinished plan work
+type WeeklyDigest struct {
+	PeriodStart       string         `json:"period_start"` // YYYY-MM-DD, company time zone
+	PeriodEnd         string         `json:"period_end"`
+	Revenue           float64        `json:"revenue"`
+	RevenueTrend      float64        `json:"revenue_trend"` // percentage change from the previous week
+	TopSellingProduct string         `json:"top_selling_product,omitempty"`
+	PlanProgress      *PlanProgress  `json:"plan_progress,omitempty"`
+	Reminders         []StrategyTask `json:"reminders"` // Open high-impact tasks from this month's plan
+	Message           string         `json:"message,omitempty"`
+}
+
 // InsightsCounts represents counts of each insight type
 type InsightsCounts struct {
 	Forecast   int `json:"forecast"`
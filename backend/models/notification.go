@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Notification types
+const (
+	NotificationSlowMover       = "slow_mover"
+	NotificationMarketShift     = "market_shift"
+	NotificationIntegrationAuth = "integration_auth_failed"
+	NotificationPermitExpiry    = "permit_expiry"
+	NotificationExportReady     = "export_ready"
+	NotificationDormancy        = "company_dormant"
+	NotificationPlanPaid        = "plan_paid"
+	NotificationPlanLapsed      = "plan_lapsed"
+	NotificationLowStock        = "low_stock"
+)
+
+// Notification is an in-app message for a company
+type Notification struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Title     string                 `json:"title"`
+	Message   string                 `json:"message"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+	ReadAt    *time.Time             `json:"read_at,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
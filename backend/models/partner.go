@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// Partner represents a white-label partner (incubator, cooperative) that owns many companies
+type Partner struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	Slug               string    `json:"slug"`
+	LogoURL            string    `json:"logo_url,omitempty"`
+	PrimaryColor       string    `json:"primary_color,omitempty"`
+	SecondaryColor     string    `json:"secondary_color,omitempty"`
+	EmailSenderName    string    `json:"email_sender_name,omitempty"`
+	EmailSenderAddress string    `json:"email_sender_address,omitempty"`
+	StorageRegion      string    `json:"storage_region,omitempty"` // Overrides the deployment storage region
+	Status             string    `json:"status"`                   // "active", "suspended"
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// PartnerBranding is the public subset of a partner used to theme the frontend
+type PartnerBranding struct {
+	Name               string `json:"name"`
+	Slug               string `json:"slug"`
+	LogoURL            string `json:"logo_url,omitempty"`
+	PrimaryColor       string `json:"primary_color,omitempty"`
+	SecondaryColor     string `json:"secondary_color,omitempty"`
+	EmailSenderName    string `json:"email_sender_name,omitempty"`
+	EmailSenderAddress string `json:"email_sender_address,omitempty"`
+}
+
+// PartnerMember represents a user who administers a partner
+type PartnerMember struct {
+	PartnerID string    `json:"partner_id"`
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email,omitempty"`
+	Role      string    `json:"role"` // "owner", "admin"
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PartnerCompanyUsage represents usage metrics for a single company in a partner cohort
+type PartnerCompanyUsage struct {
+	CompanyID             string     `json:"company_id"`
+	CompanyName           string     `json:"company_name"`
+	Plan                  string     `json:"plan"`
+	PlanExpiresAt         *time.Time `json:"plan_expires_at,omitempty"` // nil for free plans and plans set by an admin
+	PlanPrice             int64      `json:"plan_price"`                // Monthly list price in rupiah, 0 for free
+	BilledThisMonth       int64      `json:"billed_this_month"`         // Plan orders paid this month; refunded ones are left out
+	LastPaymentAt         *time.Time `json:"last_payment_at,omitempty"`
+	Status                string     `json:"status"`
+	Products              int        `json:"products"`
+	SalesRecords          int        `json:"sales_records"`
+	SalesRevenueThisMonth float64    `json:"sales_revenue_this_month"` // The company's own sales, not Bantuaku billing
+	Conversations         int        `json:"conversations"`
+	Insights              int        `json:"insights"`
+	FileUploads           int        `json:"file_uploads"`
+}
+
+// PartnerUsageRollup aggregates usage and billing data across a partner's companies
+type PartnerUsageRollup struct {
+	PartnerID          string                `json:"partner_id"`
+	PeriodStart        time.Time             `json:"period_start"`
+	TotalCompanies     int                   `json:"total_companies"`
+	ActiveCompanies    int                   `json:"active_companies"`
+	CompaniesByPlan    map[string]int        `json:"companies_by_plan"`
+	MonthlyPlanValue   int64                 `json:"monthly_plan_value"` // Sum of active companies' plan prices
+	TotalBilled        int64                 `json:"total_billed_this_month"`
+	TotalSalesRevenue  float64               `json:"total_sales_revenue_this_month"`
+	TotalSales         int                   `json:"total_sales_records"`
+	TotalConversations int                   `json:"total_conversations"`
+	TotalInsights      int                   `json:"total_insights"`
+	TotalFileUploads   int                   `json:"total_file_uploads"`
+	Companies          []PartnerCompanyUsage `json:"companies"`
+}
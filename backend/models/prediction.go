@@ -0,0 +1,91 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PredictionJob is a multi-step business prediction run for a company
+type PredictionJob struct {
+	ID          string             `json:"id"`
+	Status      string             `json:"status"`
+	CurrentStep string             `json:"current_step,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	Progress    PredictionProgress `json:"progress"`
+	Steps       []PredictionStep   `json:"steps"`
+	CreatedAt   time.Time          `json:"created_at"`
+	StartedAt   *time.Time         `json:"started_at,omitempty"`
+	FinishedAt  *time.Time         `json:"finished_at,omitempty"`
+}
+
+// PredictionProgress counts finished steps
+type PredictionProgress struct {
+	Completed int `json:"completed"`
+	Total     int `json:"total"`
+}
+
+// PredictionStep is the state and result of one step of a prediction job
+type PredictionStep struct {
+	Step       string          `json:"step"`
+	Status     string          `json:"status"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// PredictionJobSummary is a prediction run as listed in the analysis history
+type PredictionJobSummary struct {
+	ID               string     `json:"id"`
+	Status           string     `json:"status"`
+	Error            string     `json:"error,omitempty"`
+	StepsCompleted   int        `json:"steps_completed"`
+	StepsTotal       int        `json:"steps_total"`
+	SectionsWithData []string   `json:"sections_with_data"`
+	DurationSeconds  *float64   `json:"duration_seconds,omitempty"` // Set once the job has finished
+	CreatedAt        time.Time  `json:"created_at"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+}
+
+// AdminPredictionJob is a prediction run as listed for operators across companies
+type AdminPredictionJob struct {
+	ID              string     `json:"id"`
+	CompanyID       string     `json:"company_id"`
+	CompanyName     string     `json:"company_name"`
+	Status          string     `json:"status"`
+	CurrentStep     string     `json:"current_step,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	DurationSeconds *float64   `json:"duration_seconds,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+}
+
+// PredictionStepTiming is how long a step ran and, when it failed, what caused it
+type PredictionStepTiming struct {
+	Step            string   `json:"step"`
+	Status          string   `json:"status"`
+	DurationSeconds *float64 `json:"duration_seconds,omitempty"`
+	Error           string   `json:"error,omitempty"`
+	ErrorProvider   string   `json:"error_provider,omitempty"`
+	ErrorKind       string   `json:"error_kind,omitempty"`
+}
+
+// PredictionStepFailures aggregates the outcomes of one step over a period
+type PredictionStepFailures struct {
+	Step               string  `json:"step"`
+	Completed          int     `json:"completed"`
+	Failed             int     `json:"failed"`
+	TimedOut           int     `json:"timed_out"`
+	AvgDurationSeconds float64 `json:"avg_duration_seconds"` // Of completed runs
+}
+
+// PredictionProviderFailures counts step failures attributed to a provider and kind of error
+type PredictionProviderFailures struct {
+	Provider string    `json:"provider"`
+	Kind     string    `json:"kind"`
+	Failures int       `json:"failures"`
+	Steps    []string  `json:"steps"`
+	LastSeen time.Time `json:"last_seen"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// RAGEvalCase is a curated question with the sources and key points a good
+// answer should draw on
+type RAGEvalCase struct {
+	ID              string    `json:"id"`
+	Question        string    `json:"question"`
+	Namespace       string    `json:"namespace,omitempty"`       // Empty searches regulations and the knowledge base
+	ExpectedSources []string  `json:"expected_sources"`          // Regulation source URLs or knowledge-base slugs
+	ExpectedAnswer  string    `json:"expected_answer,omitempty"` // Key points a good answer mentions
+	Tags            []string  `json:"tags"`
+	Active          bool      `json:"active"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// RAGEvalRun is one evaluation of the retrieval and answer stack. Metrics are
+// averages over the cases they apply to and are null when none did.
+type RAGEvalRun struct {
+	ID             string     `json:"id"`
+	Label          string     `json:"label,omitempty"`
+	EmbeddingModel string     `json:"embedding_model"`
+	ChatModel      string     `json:"chat_model,omitempty"` // Empty when answers were not generated
+	TopK           int        `json:"top_k"`
+	Cases          int        `json:"cases"`
+	Precision      *float64   `json:"precision"`
+	Recall         *float64   `json:"recall"`
+	MRR            *float64   `json:"mrr"`
+	Faithfulness   *float64   `json:"faithfulness"`
+	AnswerCoverage *float64   `json:"answer_coverage"`
+	Status         string     `json:"status"` // running, completed or failed
+	Error          string     `json:"error,omitempty"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// RAGEvalResult is one case's outcome in a run
+type RAGEvalResult struct {
+	CaseID         string   `json:"case_id"`
+	Question       string   `json:"question"`
+	Retrieved      []string `json:"retrieved"` // Source keys, best first
+	Answer         string   `json:"answer,omitempty"`
+	Precision      *float64 `json:"precision"`
+	Recall         *float64 `json:"recall"`
+	ReciprocalRank *float64 `json:"reciprocal_rank"`
+	Faithfulness   *float64 `json:"faithfulness"`
+	AnswerCoverage *float64 `json:"answer_coverage"`
+}
@@ -0,0 +1,46 @@
+package models
+
+// CompanyLocation is where a company operates, by administrative region and
+// optionally coordinates
+type CompanyLocation struct {
+	ProvinceCode string   `json:"province_code,omitempty"`
+	Province     string   `json:"province,omitempty"`
+	RegencyCode  string   `json:"regency_code,omitempty"`
+	Regency      string   `json:"regency,omitempty"`
+	DistrictCode string   `json:"district_code,omitempty"`
+	District     string   `json:"district,omitempty"`
+	Address      string   `json:"address,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+	Geocoded     bool     `json:"geocoded"` // Coordinates were looked up, not given
+}
+
+// IndustryCount is the number of companies in an industry
+type IndustryCount struct {
+	Industry  string `json:"industry"`
+	Companies int    `json:"companies"`
+}
+
+// RegionStats aggregates the companies in a region. Industries with fewer
+// companies than the privacy threshold are folded into "lainnya".
+type RegionStats struct {
+	Code       string          `json:"code"`
+	Name       string          `json:"name"`
+	Level      string          `json:"level"`
+	Companies  int             `json:"companies"`
+	Products   int             `json:"products"`
+	Industries []IndustryCount `json:"industries"`
+}
+
+// DirectoryCompany is a company as listed in the admin directory and map
+type DirectoryCompany struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Industry  string   `json:"industry,omitempty"`
+	Status    string   `json:"status"`
+	Province  string   `json:"province,omitempty"`
+	Regency   string   `json:"regency,omitempty"`
+	District  string   `json:"district,omitempty"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// RegulationDocument is a regulation source split into embedded chunks
+type RegulationDocument struct {
+	ID         string    `json:"id"`
+	SourceURL  string    `json:"source_url"`
+	Title      string    `json:"title"`
+	Category   string    `json:"category,omitempty"`
+	ChunkCount int       `json:"chunk_count"`
+	IndexedAt  time.Time `json:"indexed_at"`
+}
+
+// RegulationIndexJob summarizes one indexing run, including the embedding work
+// avoided by reusing chunks whose content had not changed
+type RegulationIndexJob struct {
+	ID                 string `json:"id"`
+	Status             string `json:"status"`
+	Documents          int    `json:"documents"`
+	DocumentsUnchanged int    `json:"documents_unchanged"`
+	EmbeddingUsage
+	OrphansRemoved int        `json:"orphans_removed"`
+	Error          string     `json:"error,omitempty"`
+	StartedAt      time.Time  `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SaleChange records an edit or deletion of a sales record
+type SaleChange struct {
+	ID        int64           `json:"id"`
+	SaleID    int64           `json:"sale_id"`
+	Action    string          `json:"action"` // update, delete
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after,omitempty"`
+	ChangedBy string          `json:"changed_by,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Sales webhook event statuses
+const (
+	WebhookEventAccepted  = "accepted"
+	WebhookEventDuplicate = "duplicate"
+	WebhookEventRejected  = "rejected"
+)
+
+// SalesWebhookPayload is the JSON body POS/QRIS providers push for one transaction
+type SalesWebhookPayload struct {
+	ExternalID string             `json:"external_id" validate:"required,max:128"`
+	OccurredAt time.Time          `json:"occurred_at"`
+	Items      []SalesWebhookItem `json:"items"`
+}
+
+// SalesWebhookItem is a single line item in a pushed transaction
+type SalesWebhookItem struct {
+	SKU         string  `json:"sku,omitempty"`
+	ProductName string  `json:"product_name"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit,omitempty"` // Defaults to the product's unit
+	UnitPrice   float64 `json:"unit_price"`
+}
+
+// SalesWebhookSource is a data source configured to receive pushed sales
+type SalesWebhookSource struct {
+	ID        string            `json:"id"`
+	Provider  string            `json:"provider"`
+	Status    string            `json:"status"`
+	Secret    string            `json:"secret,omitempty"` // Only returned when the source is created
+	URL       string            `json:"url"`
+	Stats     SalesWebhookStats `json:"stats"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// SalesWebhookStats summarizes deliveries received by a webhook source
+type SalesWebhookStats struct {
+	Accepted       int        `json:"accepted"`
+	Duplicates     int        `json:"duplicates"`
+	Rejected       int        `json:"rejected"`
+	ItemsIngested  int        `json:"items_ingested"`
+	LastReceivedAt *time.Time `json:"last_received_at,omitempty"`
+}
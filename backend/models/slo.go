@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// SLOAlert is raised when an endpoint group burns its error budget too fast or
+// exhausts it, and resolved once it recovers
+type SLOAlert struct {
+	ID           string     `json:"id"`
+	SLOName      string     `json:"slo_name"`
+	State        string     `json:"state"`
+	Availability float64    `json:"availability"`
+	P95Ms        int        `json:"p95_ms"`
+	BurnRate5m   float64    `json:"burn_rate_5m"`
+	BurnRate1h   float64    `json:"burn_rate_1h"`
+	FiredAt      time.Time  `json:"fired_at"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// SSOConfig is an OpenID Connect identity provider for a company or, for partner
+// cohorts, for all of a partner's companies
+type SSOConfig struct {
+	ID             string            `json:"id"`
+	CompanyID      string            `json:"company_id,omitempty"`
+	PartnerID      string            `json:"partner_id,omitempty"`
+	Issuer         string            `json:"issuer"`
+	ClientID       string            `json:"client_id"`
+	HasSecret      bool              `json:"has_client_secret"` // The secret itself is never returned
+	EmailDomains   []string          `json:"email_domains"`
+	DomainClaim    string            `json:"domain_claim"`
+	CompanyMapping map[string]string `json:"company_mapping,omitempty"` // Claim value -> company ID
+	DefaultRole    string            `json:"default_role"`
+	Enabled        bool              `json:"enabled"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
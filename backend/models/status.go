@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// StatusIncident is an incident published on the status page
+type StatusIncident struct {
+	ID         string                 `json:"id"`
+	Title      string                 `json:"title"`
+	Status     string                 `json:"status"`
+	Impact     string                 `json:"impact"`
+	Components []string               `json:"components"`
+	StartedAt  time.Time              `json:"started_at"`
+	ResolvedAt *time.Time             `json:"resolved_at,omitempty"`
+	CreatedBy  string                 `json:"created_by,omitempty"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+	Updates    []StatusIncidentUpdate `json:"updates"`
+}
+
+// StatusIncidentUpdate is a progress note posted on an incident
+type StatusIncidentUpdate struct {
+	ID        string    `json:"id"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ComponentStatus is the current status of a status page component
+type ComponentStatus struct {
+	ID     string `json:"id"`
+	Label  string `json:"label"`
+	Status string `json:"status"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// Strategy task statuses
+const (
+	TaskStatusTodo = "todo"
+	TaskStatusDone = "done"
+)
+
+// StrategyPlan is a monthly action plan for a company
+type StrategyPlan struct {
+	ID          string         `json:"id"`
+	CompanyID   string         `json:"company_id"`
+	Month       string         `json:"month"` // YYYY-MM
+	Summary     string         `json:"summary"`
+	GeneratedBy string         `json:"generated_by"` // "ai" or "rules"
+	Tasks       []StrategyTask `json:"tasks"`
+	Progress    PlanProgress   `json:"progress"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// StrategyTask is a checklist item in a strategy plan
+type StrategyTask struct {
+	ID          string     `json:"id"`
+	PlanID      string     `json:"plan_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Category    string     `json:"category"` // sales, marketing, operations, finance, data
+	Effort      string     `json:"effort"`   // low, medium, high
+	Impact      string     `json:"impact"`   // low, medium, high
+	Status      string     `json:"status"`   // todo, done
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// PlanProgress summarizes checklist completion
+type PlanProgress struct {
+	Total          int `json:"total"`
+	Done           int `json:"done"`
+	OpenHighImpact int `json:"open_high_impact"`
+}
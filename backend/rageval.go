@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bantuaku/backend/config"
+	"github.com/bantuaku/backend/handlers"
+	"github.com/bantuaku/backend/models"
+	"github.com/bantuaku/backend/services/rageval"
+	"github.com/bantuaku/backend/services/storage"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// runRAGEval implements `bantuaku rageval`, which scores the curated evaluation
+// questions against the current retrieval and prompt stack, and returns the
+// exit code: 1 when the run fails, 3 when a metric regressed beyond -max-drop
+func runRAGEval(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("rageval", flag.ContinueOnError)
+	label := fs.String("label", "", "name for the run, e.g. a release or branch")
+	tag := fs.String("tag", "", "only run cases with this tag")
+	topK := fs.Int("k", 5, "chunks retrieved per question")
+	answers := fs.Bool("answers", true, "generate answers and score faithfulness and coverage (calls the chat model)")
+	maxDrop := fs.Float64("max-drop", 0.05, "largest allowed drop of any metric from the previous completed run")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bantuaku rageval [-label release-1.4] [-tag perizinan] [-k 5] [-answers=false] [-max-drop 0.05]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *topK < 1 || *topK > 20 {
+		fmt.Fprintln(os.Stderr, "-k must be between 1 and 20")
+		return 2
+	}
+
+	db, err := storage.NewPostgres(cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	h, err := handlers.New(db, nil, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	run, err := h.RunRAGEvaluation(ctx, handlers.RAGEvalOptions{Label: *label, Tag: *tag, TopK: *topK, Answers: *answers})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "evaluate: %v\n", err)
+		return 1
+	}
+
+	previous, err := h.PreviousRAGEvalRun(ctx, run)
+	if err != nil && err != pgx.ErrNoRows {
+		fmt.Fprintf(os.Stderr, "load previous run: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Run %s: %d cases, k=%d\n", run.ID, run.Cases, run.TopK)
+	fmt.Printf("%-16s %9s %9s\n", "metric", "current", "previous")
+	current := runSummary(run)
+	var baseline rageval.Summary
+	if previous != nil {
+		baseline = runSummary(previous)
+	}
+	for _, m := range []struct {
+		name      string
+		cur, prev *float64
+	}{
+		{"precision", current.Precision, baseline.Precision},
+		{"recall", current.Recall, baseline.Recall},
+		{"mrr", current.MRR, baseline.MRR},
+		{"faithfulness", current.Faithfulness, baseline.Faithfulness},
+		{"answer_coverage", current.AnswerCoverage, baseline.AnswerCoverage},
+	} {
+		fmt.Printf("%-16s %9s %9s\n", m.name, formatMetric(m.cur), formatMetric(m.prev))
+	}
+
+	if previous == nil {
+		return 0
+	}
+	if regressed := rageval.Regressions(baseline, current, *maxDrop); len(regressed) > 0 {
+		fmt.Fprintf(os.Stderr, "Regressed since run %s: %s\n", previous.ID, strings.Join(regressed, ", "))
+		return 3
+	}
+	return 0
+}
+
+func runSummary(run *models.RAGEvalRun) rageval.Summary {
+	return rageval.Summary{
+		Cases:          run.Cases,
+		Precision:      run.Precision,
+		Recall:         run.Recall,
+		MRR:            run.MRR,
+		Faithfulness:   run.Faithfulness,
+		AnswerCoverage: run.AnswerCoverage,
+	}
+}
+
+func formatMetric(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", *v)
+}
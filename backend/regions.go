@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bantuaku/backend/config"
+	"github.com/bantuaku/backend/handlers"
+	"github.com/bantuaku/backend/services/regions"
+	"github.com/bantuaku/backend/services/storage"
+)
+
+// runRegions implements `bantuaku regions`, which loads the Kemendagri list of
+// regencies and districts used to validate company locations, and returns the
+// exit code
+func runRegions(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("regions", flag.ContinueOnError)
+	file := fs.String("file", "", "CSV of code,name rows, e.g. wilayah.csv from the Kemendagri region list (required)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: bantuaku regions -file wilayah.csv")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *file == "" {
+		fs.Usage()
+		return 2
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open %s: %v\n", *file, err)
+		return 1
+	}
+	defer f.Close()
+	list, err := regions.ParseCSV(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse %s: %v\n", *file, err)
+		return 1
+	}
+
+	db, err := storage.NewPostgres(cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect to database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	h, err := handlers.New(db, nil, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	n, err := h.ImportRegions(context.Background(), list)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import regions: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Imported %d regions into %s\n", n, maskDatabaseURL(cfg.DatabaseURL))
+	return 0
+}
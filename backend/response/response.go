@@ -0,0 +1,113 @@
+// Package response writes API responses.
+//
+// Clients that send "Accept: application/vnd.bantuaku.v2+json" get every
+// response, success or error, in the same envelope:
+//
+//	{"data": ..., "error": {...}, "meta": {...}, "request_id": "..."}
+//
+// Everyone else gets the version 1 bodies the API has always returned: the
+// resource itself on success and the bare error object on failure.
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/bantuaku/backend/errors"
+)
+
+// MediaTypeV2 is the Accept value that selects the enveloped responses
+const MediaTypeV2 = "application/vnd.bantuaku.v2+json"
+
+// RequestIDHeader carries the request ID set by the RequestID middleware
+const RequestIDHeader = "X-Request-ID"
+
+// Envelope is the body of every version 2 response
+type Envelope struct {
+	Data      interface{}            `json:"data"`
+	Error     *errors.AppError       `json:"error,omitempty"`
+	Meta      map[string]interface{} `json:"meta,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// Page is one page of a list. Version 1 renders it as an object holding the
+// items under ItemsKey next to page, page_size, total and Extra; the envelope
+// puts the items in data and the rest in meta.
+type Page struct {
+	Items    interface{}
+	ItemsKey string // Defaults to "items"
+	Page     int
+	PageSize int
+	Total    int
+	Extra    map[string]interface{} // Fields describing the whole list, e.g. the operation the items belong to
+}
+
+// MarshalJSON renders the version 1 shape
+func (p Page) MarshalJSON() ([]byte, error) {
+	body := p.meta()
+	key := p.ItemsKey
+	if key == "" {
+		key = "items"
+	}
+	body[key] = p.Items
+	return json.Marshal(body)
+}
+
+func (p Page) meta() map[string]interface{} {
+	meta := map[string]interface{}{
+		"page":      p.Page,
+		"page_size": p.PageSize,
+		"total":     p.Total,
+	}
+	for k, v := range p.Extra {
+		meta[k] = v
+	}
+	return meta
+}
+
+// Enveloped reports whether the client asked for version 2 responses
+func Enveloped(r *http.Request) bool {
+	return r != nil && strings.Contains(r.Header.Get("Accept"), MediaTypeV2)
+}
+
+// JSON writes data with the given status
+func JSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	if !Enveloped(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if data != nil {
+			json.NewEncoder(w).Encode(data)
+		}
+		return
+	}
+
+	env := Envelope{Data: data, RequestID: w.Header().Get(RequestIDHeader)}
+	if page, ok := data.(Page); ok {
+		env.Data, env.Meta = page.Items, page.meta()
+	}
+	write(w, status, env)
+}
+
+// Error writes err with the status of its error code. Errors that are not
+// AppErrors are reported as internal errors.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	code := errors.GetErrorCode(err)
+	if !Enveloped(r) {
+		errors.WriteJSONError(w, err, code)
+		return
+	}
+
+	appErr, ok := err.(*errors.AppError)
+	if !ok {
+		appErr = errors.NewAppError(code, err.Error(), "")
+	}
+	write(w, errors.HTTPStatusFromErrorCode(code), Envelope{Error: appErr, RequestID: w.Header().Get(RequestIDHeader)})
+}
+
+func write(w http.ResponseWriter, status int, env Envelope) {
+	w.Header().Set("Content-Type", MediaTypeV2)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(env)
+}
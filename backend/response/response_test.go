@@ -0,0 +1,86 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bantuaku/backend/errors"
+)
+
+func request(accept string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	return r
+}
+
+func TestPageV1(t *testing.T) {
+	w := httptest.NewRecorder()
+	JSON(w, request(""), http.StatusOK, Page{
+		Items: []string{"a"}, ItemsKey: "jobs", Page: 2, PageSize: 10, Total: 11,
+		Extra: map[string]interface{}{"operation": "op-1"},
+	})
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("content type = %q", w.Header().Get("Content-Type"))
+	}
+	if _, ok := body["data"]; ok {
+		t.Errorf("v1 response should not be enveloped: %s", w.Body)
+	}
+	if body["page"] != float64(2) || body["total"] != float64(11) || body["operation"] != "op-1" || len(body["jobs"].([]interface{})) != 1 {
+		t.Errorf("unexpected v1 page %s", w.Body)
+	}
+}
+
+func TestPageV2(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(RequestIDHeader, "req-1")
+	JSON(w, request(MediaTypeV2), http.StatusOK, Page{
+		Items: []string{"a", "b"}, Page: 1, PageSize: 20, Total: 2,
+		Extra: map[string]interface{}{"categories": []string{"tax"}},
+	})
+
+	var env struct {
+		Data      []string               `json:"data"`
+		Error     *errors.AppError       `json:"error"`
+		Meta      map[string]interface{} `json:"meta"`
+		RequestID string                 `json:"request_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatal(err)
+	}
+	if w.Header().Get("Content-Type") != MediaTypeV2 {
+		t.Errorf("content type = %q", w.Header().Get("Content-Type"))
+	}
+	if len(env.Data) != 2 || env.Error != nil || env.RequestID != "req-1" {
+		t.Errorf("unexpected envelope %s", w.Body)
+	}
+	if env.Meta["total"] != float64(2) || env.Meta["categories"] == nil {
+		t.Errorf("unexpected meta %v", env.Meta)
+	}
+}
+
+func TestError(t *testing.T) {
+	w := httptest.NewRecorder()
+	Error(w, request(""), errors.NewNotFoundError("Product"))
+	var v1 errors.AppError
+	json.Unmarshal(w.Body.Bytes(), &v1)
+	if w.Code != http.StatusNotFound || v1.Code != errors.ErrCodeNotFound {
+		t.Errorf("v1: status %d body %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	Error(w, request("application/json, "+MediaTypeV2), errors.NewValidationError("Invalid page", ""))
+	var env Envelope
+	json.Unmarshal(w.Body.Bytes(), &env)
+	if w.Code != http.StatusBadRequest || env.Error == nil || env.Error.Code != errors.ErrCodeValidation || env.Data != nil {
+		t.Errorf("v2: status %d body %s", w.Code, w.Body)
+	}
+}
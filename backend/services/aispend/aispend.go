@@ -0,0 +1,183 @@
+package aispend
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Spend levels, from least to most restricted
+const (
+	LevelOK        = "ok"        // Under the throttle threshold
+	LevelThrottled = "throttled" // Past the threshold: completions use the cheaper model
+	LevelCapped    = "capped"    // At the cap: non-urgent jobs are deferred as well
+)
+
+// DefaultThrottlePercent is the share of a cap past which completions are
+// downgraded
+const DefaultThrottlePercent = 80
+
+// Price is what a model costs, in USD per million tokens, plus a fee per call
+// for endpoints not billed by the token
+type Price struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+	PerCall    float64 `json:"per_call,omitempty"`
+}
+
+// DefaultPrice applies to models missing from the pricing table
+var DefaultPrice = Price{Prompt: 1, Completion: 3}
+
+// Prices is the token pricing table. Model names are matched without their
+// provider prefix, so "openai/gpt-4o-mini" uses the "gpt-4o-mini" entry.
+var Prices = map[string]Price{
+	"gpt-4o":                 {Prompt: 2.5, Completion: 10},
+	"gpt-4o-mini":            {Prompt: 0.15, Completion: 0.6},
+	"claude-3.5-haiku":       {Prompt: 0.8, Completion: 4},
+	"gemini-2.0-flash-001":   {Prompt: 0.1, Completion: 0.4},
+	"llama-3.1-8b-instruct":  {Prompt: 0.02, Completion: 0.05},
+	"text-embedding-3-small": {Prompt: 0.02},
+	"text-embedding-3-large": {Prompt: 0.13},
+	ModelOCR:                 {PerCall: 0.002},
+}
+
+// Settings are the admin-configured spending caps. A cap of 0 is unlimited.
+type Settings struct {
+	GlobalMonthlyUSD  float64          `json:"global_monthly_usd"`
+	CompanyMonthlyUSD float64          `json:"company_monthly_usd"` // Default for companies without their own cap
+	ThrottlePercent   float64          `json:"throttle_percent"`    // 0 uses DefaultThrottlePercent
+	CheapModel        string           `json:"cheap_model"`         // Used for completions past the threshold; empty keeps the requested model
+	Prices            map[string]Price `json:"prices,omitempty"`    // Overrides of the pricing table
+}
+
+// Validate reports the first problem with the settings
+func (s Settings) Validate() error {
+	if s.GlobalMonthlyUSD < 0 || s.CompanyMonthlyUSD < 0 {
+		return fmt.Errorf("caps must not be negative")
+	}
+	if s.ThrottlePercent < 0 || s.ThrottlePercent > 100 {
+		return fmt.Errorf("throttle_percent must be between 0 and 100")
+	}
+	for model, p := range s.Prices {
+		if p.Prompt < 0 || p.Completion < 0 || p.PerCall < 0 {
+			return fmt.Errorf("price of %s must not be negative", model)
+		}
+	}
+	return nil
+}
+
+// Threshold returns the throttle threshold in percent
+func (s Settings) Threshold() float64 {
+	if s.ThrottlePercent <= 0 {
+		return DefaultThrottlePercent
+	}
+	return s.ThrottlePercent
+}
+
+// PriceOf looks a model up in the overrides, then the pricing table
+func (s Settings) PriceOf(model string) Price {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if p, ok := s.Prices[name]; ok {
+		return p
+	}
+	if p, ok := Prices[name]; ok {
+		return p
+	}
+	return DefaultPrice
+}
+
+// Usage is the tokens one call consumed
+type Usage struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Estimated        bool // The response reported no usage; counts are guessed from body sizes
+}
+
+// Cost returns what usage cost, in USD
+func (s Settings) Cost(u Usage) float64 {
+	p := s.PriceOf(u.Model)
+	return p.PerCall + (float64(u.PromptTokens)*p.Prompt+float64(u.CompletionTokens)*p.Completion)/1e6
+}
+
+// ParseUsage reads the model and token usage of a chat completion or
+// embeddings call. When the response reports none, as streamed completions
+// often don't, they are estimated at four bytes per token.
+func ParseUsage(request, response []byte) Usage {
+	var body struct {
+		Model string `json:"model"`
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	var req struct {
+		Model string `json:"model"`
+	}
+	json.Unmarshal(request, &req)
+	json.Unmarshal(response, &body)
+
+	u := Usage{Model: body.Model}
+	if u.Model == "" {
+		u.Model = req.Model
+	}
+	if body.Usage != nil && (body.Usage.PromptTokens > 0 || body.Usage.TotalTokens > 0) {
+		u.PromptTokens, u.CompletionTokens = body.Usage.PromptTokens, body.Usage.CompletionTokens
+		if u.PromptTokens == 0 {
+			u.PromptTokens = body.Usage.TotalTokens - u.CompletionTokens
+		}
+		return u
+	}
+	u.PromptTokens, u.CompletionTokens, u.Estimated = len(request)/4, len(response)/4, true
+	return u
+}
+
+// Status is spending against a cap over the current month
+type Status struct {
+	SpentUSD float64 `json:"spent_usd"`
+	CapUSD   float64 `json:"cap_usd"`           // 0 is unlimited
+	Percent  float64 `json:"percent,omitempty"` // Of the cap
+	Level    string  `json:"level"`
+}
+
+// NewStatus compares spent against cap
+func NewStatus(spent, cap, threshold float64) Status {
+	s := Status{SpentUSD: round(spent), CapUSD: cap, Level: LevelOK}
+	if cap <= 0 {
+		return s
+	}
+	s.Percent = math.Round(spent/cap*1000) / 10
+	switch {
+	case spent >= cap:
+		s.Level = LevelCapped
+	case s.Percent >= threshold:
+		s.Level = LevelThrottled
+	}
+	return s
+}
+
+// Worst returns the more restricted of two levels
+func Worst(a, b string) string {
+	rank := map[string]int{LevelOK: 0, LevelThrottled: 1, LevelCapped: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// MonthStart returns the start of t's calendar month in UTC, the period caps
+// apply to
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,81 @@
+package aispend
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestCost(t *testing.T) {
+	s := Settings{Prices: map[string]Price{"house-model": {Prompt: 10, Completion: 20}}}
+	if got := s.Cost(Usage{Model: "openai/gpt-4o-mini", PromptTokens: 1e6, CompletionTokens: 1e6}); math.Abs(got-0.75) > 1e-9 {
+		t.Errorf("gpt-4o-mini cost = %v, want 0.75", got)
+	}
+	if got := s.Cost(Usage{Model: "house-model", PromptTokens: 1000, CompletionTokens: 500}); math.Abs(got-0.02) > 1e-9 {
+		t.Errorf("override cost = %v, want 0.02", got)
+	}
+	if s.PriceOf("unknown") != DefaultPrice {
+		t.Error("expected unknown models to use the default price")
+	}
+}
+
+func TestParseUsage(t *testing.T) {
+	u := ParseUsage([]byte(`{"model":"default"}`), []byte(`{"model":"gpt-4o","usage":{"prompt_tokens":120,"completion_tokens":30}}`))
+	if u.Model != "gpt-4o" || u.PromptTokens != 120 || u.CompletionTokens != 30 || u.Estimated {
+		t.Errorf("unexpected usage %+v", u)
+	}
+	u = ParseUsage([]byte(`{"model":"text-embedding-3-small","input":["a"]}`), []byte(`{"usage":{"total_tokens":9}}`))
+	if u.Model != "text-embedding-3-small" || u.PromptTokens != 9 {
+		t.Errorf("unexpected embeddings usage %+v", u)
+	}
+	req := []byte(`{"model":"gpt-4o","messages":[]}`)
+	if u := ParseUsage(req, []byte(`{"choices":[]}`)); !u.Estimated || u.PromptTokens != len(req)/4 {
+		t.Errorf("expected estimated usage, got %+v", u)
+	}
+}
+
+func TestNewStatus(t *testing.T) {
+	tests := []struct {
+		spent, cap float64
+		want       string
+	}{
+		{50, 0, LevelOK},
+		{50, 100, LevelOK},
+		{80, 100, LevelThrottled},
+		{100, 100, LevelCapped},
+	}
+	for _, tt := range tests {
+		if got := NewStatus(tt.spent, tt.cap, DefaultThrottlePercent).Level; got != tt.want {
+			t.Errorf("NewStatus(%v, %v) = %s, want %s", tt.spent, tt.cap, got, tt.want)
+		}
+	}
+	if Worst(LevelThrottled, LevelOK) != LevelThrottled || Worst(LevelThrottled, LevelCapped) != LevelCapped {
+		t.Error("unexpected Worst result")
+	}
+}
+
+func TestAttributionOf(t *testing.T) {
+	ctx := context.Background()
+	if a := AttributionOf(ctx, "/v1/embeddings"); a.Feature != FeatureEmbedding {
+		t.Errorf("unattributed embeddings feature = %q", a.Feature)
+	}
+	if a := AttributionOf(ctx, "/ocr"); a.Feature != FeatureOCR {
+		t.Errorf("unattributed OCR feature = %q", a.Feature)
+	}
+	if a := AttributionOf(ctx, "/v1/chat/completions"); a.Feature != FeatureOther {
+		t.Errorf("unattributed completion feature = %q", a.Feature)
+	}
+
+	ctx = WithStep(WithFeature(ctx, FeaturePrediction, "job-1"), "market")
+	want := Attribution{Feature: FeaturePrediction, ReferenceID: "job-1", Step: "market"}
+	if a := AttributionOf(ctx, "/v1/embeddings"); a != want {
+		t.Errorf("AttributionOf = %+v, want %+v", a, want)
+	}
+}
+
+func TestOCRCost(t *testing.T) {
+	var s Settings
+	if got := s.Cost(OCRUsage([]byte("extracted text"))); math.Abs(got-0.002) > 1e-9 {
+		t.Errorf("OCR cost = %v, want the per-call price", got)
+	}
+}
@@ -0,0 +1,84 @@
+package aispend
+
+import (
+	"context"
+
+	"github.com/bantuaku/backend/services/kolosal"
+)
+
+// Features AI calls are attributed to
+const (
+	FeatureChat                = "chat"                 // Assistant answers, with their tool calls
+	FeatureChatSummary         = "chat_summary"         // Rolling summaries of archived messages
+	FeatureChatRegeneration    = "chat_regeneration"    // Alternative answers after negative feedback
+	FeaturePrediction          = "prediction"           // Prediction job steps
+	FeatureMarketInsight       = "market_insight"       // Market shift insights from monitoring
+	FeatureMarketResearch      = "market_research"      // Market research search and indexing
+	FeatureRegulations         = "regulations"          // Regulation indexing
+	FeatureForecastExplanation = "forecast_explanation" // Forecast explanations
+	FeaturePricing             = "pricing"              // Price recommendation narratives
+	FeatureStrategy            = "strategy"             // Strategy plans
+	FeatureOCR                 = "ocr"                  // Text extraction from uploads
+	FeatureImportMapping       = "import_mapping"       // Column mapping suggestions for imports
+	FeatureCatalog             = "catalog"              // Catalog categorization
+	FeatureKnowledgeBase       = "knowledge_base"       // Knowledge base indexing and citations
+	FeatureChatAnalytics       = "chat_analytics"       // Topic clustering of chat questions
+	FeatureEvaluation          = "evaluation"           // RAG evaluation runs
+	FeatureEmbedding           = "embedding"            // Other embeddings
+	FeatureOther               = "other"
+)
+
+// Attribution is what an AI call was made for
+type Attribution struct {
+	Feature     string
+	ReferenceID string // The job, insight, conversation or record the call served; may be empty
+	Step        string // Part of the job, e.g. a prediction step; may be empty
+}
+
+type attributionKey struct{}
+
+// WithFeature attributes the AI calls made with ctx to feature and, when
+// referenceID is set, to that job or record
+func WithFeature(ctx context.Context, feature, referenceID string) context.Context {
+	return context.WithValue(ctx, attributionKey{}, Attribution{Feature: feature, ReferenceID: referenceID})
+}
+
+// WithStep narrows the attribution of ctx to a step of its job
+func WithStep(ctx context.Context, step string) context.Context {
+	a, _ := ctx.Value(attributionKey{}).(Attribution)
+	a.Step = step
+	return context.WithValue(ctx, attributionKey{}, a)
+}
+
+// AttributionOf returns what a call to endpoint made with ctx was for. Calls
+// without an attribution count as embeddings or OCR by their endpoint, and as
+// other features otherwise.
+func AttributionOf(ctx context.Context, endpoint string) Attribution {
+	a, _ := ctx.Value(attributionKey{}).(Attribution)
+	if a.Feature != "" {
+		return a
+	}
+	switch {
+	case endpoint == kolosal.EndpointEmbeddings:
+		a.Feature = FeatureEmbedding
+	case IsOCR(endpoint):
+		a.Feature = FeatureOCR
+	default:
+		a.Feature = FeatureOther
+	}
+	return a
+}
+
+// ModelOCR is the pricing table entry of OCR calls, which are billed per call
+const ModelOCR = "kolosal-ocr"
+
+// IsOCR reports whether endpoint is an OCR endpoint
+func IsOCR(endpoint string) bool {
+	return endpoint == kolosal.EndpointOCR || endpoint == kolosal.EndpointOCRForm
+}
+
+// OCRUsage is the usage of an OCR call. OCR reports no tokens, so the
+// extracted text is counted as completion tokens at four bytes per token.
+func OCRUsage(response []byte) Usage {
+	return Usage{Model: ModelOCR, CompletionTokens: len(response) / 4, Estimated: true}
+}
@@ -0,0 +1,150 @@
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	firstNames    = []string{"Budi", "Siti", "Agus", "Dewi", "Rina", "Andi", "Sri", "Eko", "Putri", "Joko", "Wulan", "Hendra", "Intan", "Rudi", "Fitri", "Bayu", "Lina", "Yusuf", "Maya", "Dimas"}
+	lastNames     = []string{"Santoso", "Wijaya", "Pratama", "Saputra", "Lestari", "Hidayat", "Kusuma", "Nugroho", "Permata", "Setiawan", "Halim", "Siregar", "Gunawan", "Rahayu", "Susanto"}
+	bizPrefixes   = []string{"Toko", "CV", "UD", "PT", "Warung"}
+	bizWords      = []string{"Maju", "Sinar", "Berkah", "Sumber", "Cahaya", "Mitra", "Karya", "Sentra", "Bintang", "Harapan"}
+	bizSuffixes   = []string{"Jaya", "Abadi", "Makmur", "Sentosa", "Mandiri", "Lestari", "Sejahtera", "Utama", "Bersama", "Nusantara"}
+	vocabulary    = []string{"produk", "penjualan", "stok", "harga", "pelanggan", "bulan", "minggu", "toko", "pesanan", "promo", "laporan", "kategori", "naik", "turun", "target", "musim", "pasar", "diskon", "barang", "tren"}
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	urlPattern    = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s"'<>]+`)
+	phonePattern  = regexp.MustCompile(`(?:\+62|\b62|\b0)8[0-9][0-9 \-]{6,13}[0-9]\b`)
+	handlePattern = regexp.MustCompile(`(^|[^A-Za-z0-9._%+\-])@([A-Za-z0-9_.]{2,30})`)
+)
+
+// Faker replaces personal data with realistic fakes. Replacements are
+// deterministic for a salt: the same email becomes the same fake everywhere it
+// appears, so relationships between records survive anonymization, while
+// fakes can't be reversed without the salt.
+type Faker struct {
+	salt []byte
+}
+
+// New creates a Faker. Use a fresh random salt for every anonymization run
+// that leaves production.
+func New(salt string) *Faker {
+	return &Faker{salt: []byte(salt)}
+}
+
+// Email returns a fake address in the reserved example.com domain
+func (f *Faker) Email(original string) string {
+	if original == "" {
+		return ""
+	}
+	sum := f.sum("email", strings.ToLower(strings.TrimSpace(original)))
+	first := pick(firstNames, sum, 0)
+	last := pick(lastNames, sum, 1)
+	return strings.ToLower(first+"."+last) + "." + hex.EncodeToString(sum[8:11]) + "@example.com"
+}
+
+// PersonName returns a fake Indonesian full name
+func (f *Faker) PersonName(original string) string {
+	if original == "" {
+		return ""
+	}
+	sum := f.sum("person", original)
+	return pick(firstNames, sum, 0) + " " + pick(lastNames, sum, 1)
+}
+
+// CompanyName returns a fake business name such as "CV Sinar Makmur"
+func (f *Faker) CompanyName(original string) string {
+	if original == "" {
+		return ""
+	}
+	sum := f.sum("company", original)
+	return pick(bizPrefixes, sum, 0) + " " + pick(bizWords, sum, 1) + " " + pick(bizSuffixes, sum, 2)
+}
+
+// Handle returns a fake social media or marketplace handle, keeping a leading "@"
+func (f *Faker) Handle(original string) string {
+	if original == "" {
+		return ""
+	}
+	sum := f.sum("handle", strings.ToLower(strings.TrimPrefix(original, "@")))
+	handle := strings.ToLower(pick(bizWords, sum, 0)+pick(bizSuffixes, sum, 1)) + fmt.Sprintf("%03d", binary.BigEndian.Uint16(sum[10:12])%1000)
+	if strings.HasPrefix(original, "@") {
+		return "@" + handle
+	}
+	return handle
+}
+
+// URL returns a fake address under example.com
+func (f *Faker) URL(original string) string {
+	if original == "" {
+		return ""
+	}
+	return "https://" + strings.TrimPrefix(f.Handle(original), "@") + ".example.com"
+}
+
+// Phone returns a fake Indonesian mobile number
+func (f *Faker) Phone(original string) string {
+	sum := f.sum("phone", original)
+	return fmt.Sprintf("0812%08d", binary.BigEndian.Uint32(sum[4:8])%100000000)
+}
+
+// Text replaces free text with filler of about the same number of words, for
+// fields that are entirely customer-written
+func (f *Faker) Text(original string) string {
+	n := len(strings.Fields(original))
+	if n == 0 {
+		return original
+	}
+	if n > 60 {
+		n = 60
+	}
+	sum := f.sum("text", original)
+	words := make([]string, n)
+	for i := range words {
+		words[i] = vocabulary[int(sum[i%len(sum)]+byte(i*7))%len(vocabulary)]
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ") + "."
+}
+
+// Scrub keeps text readable but replaces emails, URLs, phone numbers, @handles
+// and any known originals (such as the company's real name) with their fakes.
+// Replacements never contain quotes or backslashes, so JSON text stays valid.
+func (f *Faker) Scrub(text string, known map[string]string) string {
+	if text == "" {
+		return text
+	}
+	for original, fake := range known {
+		if len(original) >= 3 {
+			text = replaceFold(text, original, fake)
+		}
+	}
+	text = emailPattern.ReplaceAllStringFunc(text, f.Email)
+	text = urlPattern.ReplaceAllStringFunc(text, f.URL)
+	text = phonePattern.ReplaceAllStringFunc(text, f.Phone)
+	return handlePattern.ReplaceAllStringFunc(text, func(m string) string {
+		parts := handlePattern.FindStringSubmatch(m)
+		return parts[1] + f.Handle("@"+parts[2])
+	})
+}
+
+func (f *Faker) sum(kind, value string) []byte {
+	mac := hmac.New(sha256.New, f.salt)
+	mac.Write([]byte(kind + ":" + value))
+	return mac.Sum(nil)
+}
+
+func pick(list []string, sum []byte, slot int) string {
+	return list[int(binary.BigEndian.Uint16(sum[slot*2:slot*2+2]))%len(list)]
+}
+
+// replaceFold replaces every case-insensitive occurrence of old in s
+func replaceFold(s, old, replacement string) string {
+	re := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(old))
+	return re.ReplaceAllLiteralString(s, replacement)
+}
@@ -0,0 +1,57 @@
+package anonymize
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDeterministic(t *testing.T) {
+	f := New("salt-1")
+	a, b := f.Email("Owner@Toko.id"), f.Email("owner@toko.id")
+	if a != b {
+		t.Errorf("same address gave %q and %q", a, b)
+	}
+	if !strings.HasSuffix(a, "@example.com") || strings.Contains(a, "toko") {
+		t.Errorf("Email = %q", a)
+	}
+	if New("salt-2").Email("owner@toko.id") == a {
+		t.Error("different salts should give different fakes")
+	}
+	if f.CompanyName("Toko Ibu Ani") == "Toko Ibu Ani" || f.PersonName("Ani") == "Ani" {
+		t.Error("names were not replaced")
+	}
+	if got := f.Handle("@ibuani_shop"); !strings.HasPrefix(got, "@") {
+		t.Errorf("Handle lost its @: %q", got)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	f := New("salt")
+	text := `Hubungi ani@tokoani.id atau 0812-3456-7890, cek https://tokoani.id dan @tokoani. Toko Ibu Ani buka jam 8.`
+	got := f.Scrub(text, map[string]string{"Toko Ibu Ani": "CV Maju Jaya"})
+	for _, leak := range []string{"ani@tokoani.id", "3456", "https://tokoani.id", "@tokoani", "Ibu Ani"} {
+		if strings.Contains(got, leak) {
+			t.Errorf("Scrub leaked %q: %s", leak, got)
+		}
+	}
+	if !strings.Contains(got, "CV Maju Jaya") || !strings.Contains(got, "buka jam 8") {
+		t.Errorf("Scrub changed too much: %s", got)
+	}
+}
+
+func TestScrubKeepsJSONValid(t *testing.T) {
+	f := New("salt")
+	payload := `{"email":"ani@tokoani.id","site":"https://tokoani.id/shop","note":"ig @tokoani"}`
+	var v map[string]string
+	if err := json.Unmarshal([]byte(f.Scrub(payload, nil)), &v); err != nil {
+		t.Fatalf("scrubbed JSON is invalid: %v", err)
+	}
+}
+
+func TestText(t *testing.T) {
+	got := New("salt").Text("Saya mau tanya stok kopi bubuk")
+	if len(strings.Fields(got)) != 6 || strings.Contains(got, "kopi") {
+		t.Errorf("Text = %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+package auditlog
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	body := []byte(`{"plan":"pro","client_secret":"s3cr3t","nested":{"Password":"x","note":"ok"},"keys":[{"access_token":"t"}],"amount":12.50}`)
+	var got map[string]interface{}
+	if err := json.Unmarshal(Redact(body), &got); err != nil {
+		t.Fatalf("redacted body is not JSON: %v", err)
+	}
+	if got["plan"] != "pro" || got["client_secret"] != Redacted {
+		t.Errorf("unexpected top level: %v", got)
+	}
+	nested := got["nested"].(map[string]interface{})
+	if nested["Password"] != Redacted || nested["note"] != "ok" {
+		t.Errorf("unexpected nested: %v", nested)
+	}
+	if got["keys"].([]interface{})[0].(map[string]interface{})["access_token"] != Redacted {
+		t.Errorf("array entries not redacted: %v", got["keys"])
+	}
+	if got["amount"] != 12.5 {
+		t.Errorf("amount = %v", got["amount"])
+	}
+}
+
+func TestRedactNonJSON(t *testing.T) {
+	if got := string(Redact([]byte("password=hunter2"))); got != `{"unparsed_bytes":16}` {
+		t.Errorf("Redact = %s", got)
+	}
+	if Redact(nil) != nil {
+		t.Error("empty body should stay empty")
+	}
+}
+
+func TestSealer(t *testing.T) {
+	s, err := NewSealer("audit-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := s.Seal([]byte(`{"plan":"pro"}`), []byte("entry-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := s.Open(sealed, []byte("entry-1"))
+	if err != nil || string(plain) != `{"plan":"pro"}` {
+		t.Errorf("Open = %s, %v", plain, err)
+	}
+	if _, err := s.Open(sealed, []byte("entry-2")); err == nil {
+		t.Error("payload opened under another entry")
+	}
+	if _, err := NewSealer(""); err == nil {
+		t.Error("expected empty key to fail")
+	}
+}
@@ -0,0 +1,95 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Order statuses
+const (
+	StatusPending  = "pending"
+	StatusPaid     = "paid"
+	StatusFailed   = "failed"   // Denied, cancelled or failed
+	StatusExpired  = "expired"  // Not paid before the checkout expired
+	StatusRefunded = "refunded" // Paid, then refunded
+)
+
+// Period is how long one paid order keeps a plan active
+const Period = 30 * 24 * time.Hour
+
+// Prices are the monthly price of each paid plan in rupiah
+var Prices = map[string]int64{
+	"pro":        149000,
+	"enterprise": 499000,
+}
+
+// ErrInvalidSignature is returned for notifications that were not signed by
+// the provider
+var ErrInvalidSignature = errors.New("invalid notification signature")
+
+// Checkout describes a plan purchase to collect payment for
+type Checkout struct {
+	OrderID       string
+	Plan          string
+	Amount        int64 // Rupiah
+	CustomerName  string
+	CustomerEmail string
+	FinishURL     string // Where the customer returns after paying
+}
+
+// Session is a hosted payment page created for a checkout
+type Session struct {
+	Token       string `json:"token,omitempty"`
+	RedirectURL string `json:"redirect_url"`
+}
+
+// Event is a verified payment status change reported by a provider
+type Event struct {
+	OrderID        string
+	Status         string
+	ProviderStatus string // The provider's own status, e.g. "settlement"
+	PaymentType    string // e.g. "qris", "gopay", "bank_transfer"
+	Amount         int64
+	TransactionID  string
+}
+
+// Provider is a payment gateway. Drivers create hosted checkouts and verify
+// the notifications the gateway sends when a payment's status changes.
+type Provider interface {
+	Name() string
+	CreateCheckout(ctx context.Context, c Checkout) (Session, error)
+	// ParseNotification verifies and decodes a notification body, returning
+	// ErrInvalidSignature when it was not signed by the provider
+	ParseNotification(body []byte) (Event, error)
+}
+
+// PlanPrice returns the monthly price of a paid plan
+func PlanPrice(plan string) (int64, bool) {
+	price, ok := Prices[plan]
+	return price, ok
+}
+
+// Extend returns when a plan paid at now expires, given its current expiry.
+// Paying before the plan expires adds a period to the remaining time.
+func Extend(current *time.Time, now time.Time) time.Time {
+	start := now
+	if current != nil && current.After(now) {
+		start = *current
+	}
+	return start.Add(Period)
+}
+
+// Shorten returns a plan's expiry after one paid period is refunded, and
+// whether no time is left so the plan ends now
+func Shorten(current, now time.Time) (time.Time, bool) {
+	expiresAt := current.Add(-Period)
+	return expiresAt, !expiresAt.After(now)
+}
+
+// CanTransition reports whether an order may move from one status to another:
+// pending orders to any other status, and paid orders only to refunded
+func CanTransition(from, to string) bool {
+	return from == StatusPending && to != StatusPending ||
+		from == StatusPaid && to == StatusRefunded
+}
@@ -0,0 +1,117 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMidtransCheckout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, _ := r.BasicAuth(); user != "server-key" || r.URL.Path != "/snap/v1/transactions" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var tx midtransTransaction
+		json.NewDecoder(r.Body).Decode(&tx)
+		if tx.TransactionDetails.OrderID != "order-1" || tx.TransactionDetails.GrossAmount != 149000 {
+			t.Errorf("unexpected transaction %+v", tx.TransactionDetails)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"token":"tok","redirect_url":"https://pay.example/tok"}`))
+	}))
+	defer server.Close()
+
+	m := NewMidtrans("server-key", false)
+	m.BaseURL = server.URL
+	session, err := m.CreateCheckout(context.Background(), Checkout{OrderID: "order-1", Plan: "pro", Amount: 149000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Token != "tok" || session.RedirectURL != "https://pay.example/tok" {
+		t.Errorf("unexpected session %+v", session)
+	}
+}
+
+func TestMidtransNotification(t *testing.T) {
+	m := NewMidtrans("server-key", false)
+	body := func(status, fraud, signature string) []byte {
+		b, _ := json.Marshal(midtransNotification{
+			OrderID: "order-1", StatusCode: "200", GrossAmount: "149000.00", SignatureKey: signature,
+			TransactionStatus: status, FraudStatus: fraud, PaymentType: "qris",
+		})
+		return b
+	}
+	valid := m.signature("order-1", "200", "149000.00")
+
+	event, err := m.ParseNotification(body("settlement", "", valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Status != StatusPaid || event.Amount != 149000 || event.PaymentType != "qris" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if _, err := m.ParseNotification(body("settlement", "", "forged")); err != ErrInvalidSignature {
+		t.Errorf("forged signature: err = %v", err)
+	}
+	for status, want := range map[[2]string]string{
+		{"capture", "accept"}:    StatusPaid,
+		{"capture", "challenge"}: StatusPending,
+		{"expire", ""}:           StatusExpired,
+		{"cancel", ""}:           StatusFailed,
+		{"refund", ""}:           StatusRefunded,
+	} {
+		if got := midtransStatus(status[0], status[1]); got != want {
+			t.Errorf("%v: status = %s, want %s", status, got, want)
+		}
+	}
+}
+
+func TestExtend(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got := Extend(nil, now); !got.Equal(now.Add(Period)) {
+		t.Errorf("new plan expires %v", got)
+	}
+	remaining := now.Add(10 * 24 * time.Hour)
+	if got := Extend(&remaining, now); !got.Equal(remaining.Add(Period)) {
+		t.Errorf("renewal should add to the remaining time, got %v", got)
+	}
+	lapsed := now.Add(-time.Hour)
+	if got := Extend(&lapsed, now); !got.Equal(now.Add(Period)) {
+		t.Errorf("lapsed plan should restart now, got %v", got)
+	}
+}
+
+func TestShorten(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	renewed := now.Add(Period + 10*24*time.Hour)
+	if got, ended := Shorten(renewed, now); ended || !got.Equal(now.Add(10*24*time.Hour)) {
+		t.Errorf("refunding a renewal = %v, %v", got, ended)
+	}
+	for _, current := range []time.Time{now.Add(Period), now.Add(Period / 2)} {
+		if _, ended := Shorten(current, now); !ended {
+			t.Errorf("refunding the only period (expiring %v) should end the plan", current)
+		}
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	allowed := map[[2]string]bool{
+		{StatusPending, StatusPaid}:     true,
+		{StatusPending, StatusFailed}:   true,
+		{StatusPending, StatusExpired}:  true,
+		{StatusPending, StatusRefunded}: true,
+		{StatusPaid, StatusRefunded}:    true,
+	}
+	statuses := []string{StatusPending, StatusPaid, StatusFailed, StatusExpired, StatusRefunded}
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if got := CanTransition(from, to); got != allowed[[2]string{from, to}] {
+				t.Errorf("CanTransition(%s, %s) = %v", from, to, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Midtrans Snap endpoints
+const (
+	MidtransSandboxURL    = "https://app.sandbox.midtrans.com"
+	MidtransProductionURL = "https://app.midtrans.com"
+)
+
+// midtransCheckoutHours is how long a Snap checkout can be paid
+const midtransCheckoutHours = 24
+
+// Midtrans collects payments through Midtrans Snap, whose hosted page offers
+// QRIS, e-wallets such as GoPay and ShopeePay, virtual account bank transfers
+// and cards, as enabled on the merchant dashboard
+type Midtrans struct {
+	BaseURL    string
+	ServerKey  string
+	HTTPClient *http.Client
+}
+
+// NewMidtrans creates a Midtrans driver for the sandbox, or for production
+// when production is set
+func NewMidtrans(serverKey string, production bool) *Midtrans {
+	baseURL := MidtransSandboxURL
+	if production {
+		baseURL = MidtransProductionURL
+	}
+	return &Midtrans{
+		BaseURL:    baseURL,
+		ServerKey:  serverKey,
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Name returns "midtrans"
+func (m *Midtrans) Name() string {
+	return "midtrans"
+}
+
+type midtransItem struct {
+	ID       string `json:"id"`
+	Price    int64  `json:"price"`
+	Quantity int    `json:"quantity"`
+	Name     string `json:"name"`
+}
+
+type midtransTransaction struct {
+	TransactionDetails struct {
+		OrderID     string `json:"order_id"`
+		GrossAmount int64  `json:"gross_amount"`
+	} `json:"transaction_details"`
+	ItemDetails     []midtransItem `json:"item_details"`
+	CustomerDetails struct {
+		FirstName string `json:"first_name,omitempty"`
+		Email     string `json:"email,omitempty"`
+	} `json:"customer_details"`
+	Callbacks *struct {
+		Finish string `json:"finish"`
+	} `json:"callbacks,omitempty"`
+	Expiry struct {
+		Unit     string `json:"unit"`
+		Duration int    `json:"duration"`
+	} `json:"expiry"`
+}
+
+// CreateCheckout creates a Snap transaction and returns its payment page
+func (m *Midtrans) CreateCheckout(ctx context.Context, c Checkout) (Session, error) {
+	var tx midtransTransaction
+	tx.TransactionDetails.OrderID = c.OrderID
+	tx.TransactionDetails.GrossAmount = c.Amount
+	tx.ItemDetails = []midtransItem{{
+		ID:       c.Plan,
+		Price:    c.Amount,
+		Quantity: 1,
+		Name:     fmt.Sprintf("Bantuaku %s (%d hari)", c.Plan, int(Period.Hours()/24)),
+	}}
+	tx.CustomerDetails.FirstName = c.CustomerName
+	tx.CustomerDetails.Email = c.CustomerEmail
+	if c.FinishURL != "" {
+		tx.Callbacks = &struct {
+			Finish string `json:"finish"`
+		}{c.FinishURL}
+	}
+	tx.Expiry.Unit = "hours"
+	tx.Expiry.Duration = midtransCheckoutHours
+
+	body, err := json.Marshal(tx)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.BaseURL+"/snap/v1/transactions", bytes.NewReader(body))
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(m.ServerKey, "")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.HTTPClient.Do(req)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return Session{}, fmt.Errorf("midtrans error: %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	var session Session
+	if err := json.Unmarshal(respBody, &session); err != nil {
+		return Session{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if session.RedirectURL == "" {
+		return Session{}, fmt.Errorf("midtrans returned no payment page")
+	}
+	return session, nil
+}
+
+// midtransNotification is the body of a Midtrans HTTP notification
+type midtransNotification struct {
+	OrderID           string `json:"order_id"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	PaymentType       string `json:"payment_type"`
+	TransactionID     string `json:"transaction_id"`
+}
+
+// ParseNotification verifies a notification's signature, the SHA-512 of the
+// order ID, status code, gross amount and server key, and maps its status
+func (m *Midtrans) ParseNotification(body []byte) (Event, error) {
+	var n midtransNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return Event{}, fmt.Errorf("failed to unmarshal notification: %w", err)
+	}
+	if n.OrderID == "" || n.SignatureKey == "" {
+		return Event{}, ErrInvalidSignature
+	}
+	if subtle.ConstantTimeCompare([]byte(m.signature(n.OrderID, n.StatusCode, n.GrossAmount)), []byte(n.SignatureKey)) != 1 {
+		return Event{}, ErrInvalidSignature
+	}
+
+	amount, err := strconv.ParseFloat(n.GrossAmount, 64)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid gross amount %q", n.GrossAmount)
+	}
+	return Event{
+		OrderID:        n.OrderID,
+		Status:         midtransStatus(n.TransactionStatus, n.FraudStatus),
+		ProviderStatus: n.TransactionStatus,
+		PaymentType:    n.PaymentType,
+		Amount:         int64(math.Round(amount)),
+		TransactionID:  n.TransactionID,
+	}, nil
+}
+
+func (m *Midtrans) signature(orderID, statusCode, grossAmount string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + m.ServerKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// midtransStatus maps a Midtrans transaction status to an order status. Card
+// payments flagged for fraud review stay pending until Midtrans decides.
+func midtransStatus(transactionStatus, fraudStatus string) string {
+	switch transactionStatus {
+	case "settlement":
+		return StatusPaid
+	case "capture":
+		if fraudStatus == "" || fraudStatus == "accept" {
+			return StatusPaid
+		}
+		if fraudStatus == "deny" {
+			return StatusFailed
+		}
+		return StatusPending
+	case "deny", "cancel", "failure":
+		return StatusFailed
+	case "expire":
+		return StatusExpired
+	case "refund", "partial_refund", "chargeback", "partial_chargeback":
+		return StatusRefunded
+	default:
+		return StatusPending
+	}
+}
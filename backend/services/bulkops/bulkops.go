@@ -0,0 +1,127 @@
+package bulkops
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/services/mailer"
+)
+
+// MaxTargets bounds how many users one operation may touch
+const MaxTargets = 5000
+
+// Actions
+const (
+	ActionSuspend    = "suspend"
+	ActionActivate   = "activate"
+	ActionChangePlan = "change_plan"
+	ActionEmail      = "email"
+)
+
+// Operation statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// Item statuses
+const (
+	ItemPending   = "pending"
+	ItemSucceeded = "succeeded"
+	ItemSkipped   = "skipped" // Nothing to change, or the user is protected
+	ItemFailed    = "failed"
+)
+
+// Plans a company can be moved to
+var Plans = []string{"free", "pro", "enterprise"}
+
+// Filter selects users by attributes instead of by ID. All set fields must match.
+type Filter struct {
+	Role          string `json:"role,omitempty"`
+	Plan          string `json:"plan,omitempty"`         // Plan of a company the user owns
+	EmailDomain   string `json:"email_domain,omitempty"` // e.g. "example.com"
+	CreatedAfter  string `json:"created_after,omitempty"`
+	CreatedBefore string `json:"created_before,omitempty"`
+	Suspended     *bool  `json:"suspended,omitempty"`
+}
+
+// IsEmpty reports whether no field is set, i.e. the filter would match everyone
+func (f Filter) IsEmpty() bool {
+	return f.Role == "" && f.Plan == "" && f.EmailDomain == "" && f.CreatedAfter == "" &&
+		f.CreatedBefore == "" && f.Suspended == nil
+}
+
+// Validate checks the filter's values
+func (f Filter) Validate() error {
+	for name, value := range map[string]string{"created_after": f.CreatedAfter, "created_before": f.CreatedBefore} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fmt.Errorf("%s must be a date in YYYY-MM-DD format", name)
+		}
+	}
+	if f.Plan != "" && !validPlan(f.Plan) {
+		return fmt.Errorf("plan must be one of %s", strings.Join(Plans, ", "))
+	}
+	if strings.Contains(f.EmailDomain, "@") {
+		return errors.New("email_domain must not contain @")
+	}
+	return nil
+}
+
+// Params are the action-specific inputs of an operation
+type Params struct {
+	Reason  string `json:"reason,omitempty"`  // suspend
+	Plan    string `json:"plan,omitempty"`    // change_plan
+	Subject string `json:"subject,omitempty"` // email
+	Body    string `json:"body,omitempty"`    // email; {{email}} and {{company}} are replaced per recipient
+}
+
+// Validate checks that params carry what action needs
+func Validate(action string, p Params) error {
+	switch action {
+	case ActionSuspend:
+		if len(p.Reason) > 500 {
+			return errors.New("reason must be at most 500 characters")
+		}
+	case ActionActivate:
+	case ActionChangePlan:
+		if !validPlan(p.Plan) {
+			return fmt.Errorf("plan must be one of %s", strings.Join(Plans, ", "))
+		}
+	case ActionEmail:
+		if strings.TrimSpace(p.Subject) == "" || strings.TrimSpace(p.Body) == "" {
+			return errors.New("subject and body are required")
+		}
+		if len(p.Subject) > 200 {
+			return errors.New("subject must be at most 200 characters")
+		}
+	default:
+		return fmt.Errorf("unknown action: %s", action)
+	}
+	return nil
+}
+
+// Email renders the email for one recipient
+func Email(p Params, to, company string) mailer.Message {
+	r := strings.NewReplacer("{{email}}", to, "{{company}}", company)
+	return mailer.Message{
+		To:      to,
+		Subject: r.Replace(p.Subject),
+		Body:    r.Replace(p.Body),
+	}
+}
+
+func validPlan(plan string) bool {
+	for _, p := range Plans {
+		if p == plan {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package bulkops
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		action string
+		params Params
+		ok     bool
+	}{
+		{ActionSuspend, Params{Reason: "chargeback"}, true},
+		{ActionActivate, Params{}, true},
+		{ActionChangePlan, Params{Plan: "pro"}, true},
+		{ActionChangePlan, Params{Plan: "gold"}, false},
+		{ActionEmail, Params{Subject: "Hi", Body: "Hello {{email}}"}, true},
+		{ActionEmail, Params{Subject: "Hi"}, false},
+		{"delete", Params{}, false},
+	}
+	for _, c := range cases {
+		if err := Validate(c.action, c.params); (err == nil) != c.ok {
+			t.Errorf("Validate(%s, %+v) = %v", c.action, c.params, err)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	if !(Filter{}).IsEmpty() {
+		t.Error("zero filter is not empty")
+	}
+	suspended := false
+	if (Filter{Suspended: &suspended}).IsEmpty() {
+		t.Error("filter on suspended=false is empty")
+	}
+	if err := (Filter{CreatedAfter: "2025-01-32"}).Validate(); err == nil {
+		t.Error("invalid date accepted")
+	}
+	if err := (Filter{EmailDomain: "@example.com"}).Validate(); err == nil {
+		t.Error("domain with @ accepted")
+	}
+	if err := (Filter{Plan: "pro", CreatedBefore: "2025-01-31"}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEmail(t *testing.T) {
+	msg := Email(Params{Subject: "Untuk {{company}}", Body: "Halo {{email}}"}, "ani@example.com", "Toko Ani")
+	if msg.To != "ani@example.com" || msg.Subject != "Untuk Toko Ani" || msg.Body != "Halo ani@example.com" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+}
@@ -0,0 +1,166 @@
+package cachebus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Channel is the Redis channel invalidation events are published on
+const Channel = "bantuaku:cache-invalidate"
+
+// Kinds of cached data
+const (
+	KindSettings    = "settings"     // Platform settings; no keys
+	KindRateLimits  = "rate_limits"  // Rate limit profiles and plan multipliers; no keys
+	KindCompanyPlan = "company_plan" // Keys are company IDs
+	KindMembership  = "membership"   // Keys are "<user ID>:<company ID>"
+	KindPrediction  = "prediction"   // Keys are IDs of prediction jobs that progressed; nothing is cached
+)
+
+// Event tells every instance that cached data of a kind is stale
+type Event struct {
+	Kind   string   `json:"kind"`
+	Keys   []string `json:"keys,omitempty"` // Empty means everything of the kind
+	Origin string   `json:"origin"`         // Publishing instance, which already applied it
+}
+
+// Handler drops or reloads the cached data an event names
+type Handler func(ctx context.Context, keys []string)
+
+// Bus fans invalidation events out to every API instance over Redis pub/sub.
+// Delivery is best effort: an instance that is disconnected misses events, so
+// periodic reloads stay in place as a backstop.
+type Bus struct {
+	client   *redis.Client // Nil runs the bus for this instance only
+	origin   string
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// New returns a bus publishing through client, which may be nil
+func New(client *redis.Client) *Bus {
+	return &Bus{client: client, origin: uuid.New().String(), handlers: map[string][]Handler{}}
+}
+
+// Subscribe registers fn for events of kind
+func (b *Bus) Subscribe(kind string, fn Handler) {
+	b.mu.Lock()
+	b.handlers[kind] = append(b.handlers[kind], fn)
+	b.mu.Unlock()
+}
+
+// Publish applies an invalidation on this instance, then announces it to the
+// others. The local handlers have run when it returns, even if publishing fails.
+func (b *Bus) Publish(ctx context.Context, kind string, keys ...string) error {
+	b.dispatch(ctx, kind, keys)
+	if b.client == nil {
+		return nil
+	}
+	data, err := json.Marshal(Event{Kind: kind, Keys: keys, Origin: b.origin})
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, Channel, data).Err()
+}
+
+// Run applies events published by other instances until ctx is done
+func (b *Bus) Run(ctx context.Context) {
+	if b.client == nil {
+		return
+	}
+	sub := b.client.Subscribe(ctx, Channel)
+	defer sub.Close()
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			b.receive(ctx, []byte(msg.Payload))
+		}
+	}
+}
+
+// receive applies an event from the channel unless this instance sent it
+func (b *Bus) receive(ctx context.Context, payload []byte) bool {
+	var e Event
+	if json.Unmarshal(payload, &e) != nil || e.Origin == b.origin {
+		return false
+	}
+	b.dispatch(ctx, e.Kind, e.Keys)
+	return true
+}
+
+func (b *Bus) dispatch(ctx context.Context, kind string, keys []string) {
+	b.mu.RLock()
+	handlers := b.handlers[kind]
+	b.mu.RUnlock()
+	for _, fn := range handlers {
+		fn(ctx, keys)
+	}
+}
+
+// Local is a small in-process cache with per-entry expiry, for values read on
+// hot paths. Keep entries short-lived and invalidate them through the bus.
+type Local struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]localEntry
+}
+
+type localEntry struct {
+	value   string
+	expires time.Time
+}
+
+// NewLocal returns a cache keeping entries for ttl
+func NewLocal(ttl time.Duration) *Local {
+	return &Local{ttl: ttl, entries: map[string]localEntry{}}
+}
+
+// Get returns a live entry
+func (l *Local) Get(key string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return "", false
+	}
+	return e.value, true
+}
+
+// Set stores an entry, dropping expired ones now and then so the map stays small
+func (l *Local) Set(key, value string) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) >= 1024 {
+		for k, e := range l.entries {
+			if now.After(e.expires) {
+				delete(l.entries, k)
+			}
+		}
+	}
+	l.entries[key] = localEntry{value: value, expires: now.Add(l.ttl)}
+}
+
+// Delete drops entries; no keys drops everything
+func (l *Local) Delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(keys) == 0 {
+		l.entries = map[string]localEntry{}
+		return
+	}
+	for _, k := range keys {
+		delete(l.entries, k)
+	}
+}
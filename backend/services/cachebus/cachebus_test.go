@@ -0,0 +1,57 @@
+package cachebus
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPublishRunsLocalHandlers(t *testing.T) {
+	b := New(nil)
+	var got []string
+	b.Subscribe(KindCompanyPlan, func(ctx context.Context, keys []string) { got = append(got, keys...) })
+
+	if err := b.Publish(context.Background(), KindCompanyPlan, "c1", "c2"); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(got) != 2 || got[0] != "c1" {
+		t.Errorf("got %v, want [c1 c2]", got)
+	}
+}
+
+func TestReceiveSkipsOwnEvents(t *testing.T) {
+	b := New(nil)
+	calls := 0
+	b.Subscribe(KindSettings, func(ctx context.Context, keys []string) { calls++ })
+
+	own, _ := json.Marshal(Event{Kind: KindSettings, Origin: b.origin})
+	other, _ := json.Marshal(Event{Kind: KindSettings, Origin: "other"})
+	if b.receive(context.Background(), own) {
+		t.Error("expected own event to be skipped")
+	}
+	if !b.receive(context.Background(), other) || calls != 1 {
+		t.Errorf("expected other instance's event to be applied once, got %d calls", calls)
+	}
+	if b.receive(context.Background(), []byte("not json")) {
+		t.Error("expected malformed event to be skipped")
+	}
+}
+
+func TestLocal(t *testing.T) {
+	l := NewLocal(time.Minute)
+	l.Set("a", "pro")
+	if v, ok := l.Get("a"); !ok || v != "pro" {
+		t.Errorf("got %q %v", v, ok)
+	}
+	l.Delete("a")
+	if _, ok := l.Get("a"); ok {
+		t.Error("expected deleted entry to be gone")
+	}
+
+	expired := NewLocal(-time.Second)
+	expired.Set("b", "free")
+	if _, ok := expired.Get("b"); ok {
+		t.Error("expected expired entry to be gone")
+	}
+}
@@ -0,0 +1,210 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported export formats
+const (
+	FormatShopee      = "shopee"
+	FormatTokopedia   = "tokopedia"
+	FormatWooCommerce = "woocommerce"
+)
+
+// MaxCategories bounds how many distinct categories are sent for AI mapping
+const MaxCategories = 50
+
+// Product is a catalog item as exported to a marketplace
+type Product struct {
+	Name     string
+	SKU      string
+	Category string // The seller's own category
+	Price    float64
+	Active   bool
+}
+
+// Format describes a marketplace bulk-upload template
+type Format struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	FileType    string   `json:"file_type"` // "xlsx" or "csv"
+	Columns     []string `json:"columns"`
+	UploadHint  string   `json:"upload_hint"`
+	CategoryTip string   `json:"category_tip"` // What the category column expects
+	row         func(p Product, category string) []string
+}
+
+// formats mirror the columns of each marketplace's bulk-upload template. Columns
+// the catalog has no data for (weight, stock, photos) are left empty for the seller.
+var formats = []Format{
+	{
+		Name:        FormatShopee,
+		Label:       "Shopee",
+		FileType:    "xlsx",
+		Columns:     []string{"Kategori", "Nama Produk", "Deskripsi Produk", "SKU Induk", "Harga", "Stok", "Berat (gram)", "Foto Sampul"},
+		UploadHint:  "Seller Centre → Produk → Upload Massal → salin baris ke template unggah Shopee.",
+		CategoryTip: "Jalur kategori Shopee, mis. \"Makanan & Minuman > Minuman > Kopi\"",
+		row: func(p Product, category string) []string {
+			return []string{category, p.Name, p.Name, p.SKU, price(p.Price), "", "", ""}
+		},
+	},
+	{
+		Name:        FormatTokopedia,
+		Label:       "Tokopedia",
+		FileType:    "xlsx",
+		Columns:     []string{"Nama Produk*", "Deskripsi Produk", "Kategori*", "Berat* (Gram)", "Minimum Pemesanan*", "Kondisi*", "Gambar 1*", "SKU Name", "Status*", "Jumlah Stok*", "Harga (Rp)*"},
+		UploadHint:  "Seller → Tambah Produk Massal → unduh template, salin baris dari file ini.",
+		CategoryTip: "Jalur kategori Tokopedia, mis. \"Makanan & Minuman > Kopi > Kopi Bubuk\"",
+		row: func(p Product, category string) []string {
+			status := "Aktif"
+			if !p.Active {
+				status = "Nonaktif"
+			}
+			return []string{p.Name, p.Name, category, "", "1", "Baru", "", p.SKU, status, "", price(p.Price)}
+		},
+	},
+	{
+		Name:        FormatWooCommerce,
+		Label:       "WooCommerce",
+		FileType:    "csv",
+		Columns:     []string{"Type", "SKU", "Name", "Published", "Short description", "Description", "Regular price", "Categories", "Images", "In stock?"},
+		UploadHint:  "WordPress → Products → Import, pilih file CSV ini.",
+		CategoryTip: "Kategori WooCommerce bertingkat dipisah \" > \", mis. \"Minuman > Kopi\"",
+		row: func(p Product, category string) []string {
+			published := "1"
+			if !p.Active {
+				published = "0"
+			}
+			return []string{"simple", p.SKU, p.Name, published, "", "", price(p.Price), category, "", "1"}
+		},
+	},
+}
+
+// Formats returns the supported export formats
+func Formats() []Format {
+	return formats
+}
+
+// Get returns the export format with the given name
+func Get(name string) (Format, bool) {
+	for _, f := range formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Rows renders products as template rows, header first. Categories are
+// translated through mapping where a suggestion exists.
+func (f Format) Rows(products []Product, mapping map[string]string) [][]string {
+	rows := make([][]string, 0, len(products)+1)
+	rows = append(rows, f.Columns)
+	for _, p := range products {
+		category := p.Category
+		if mapped := mapping[strings.TrimSpace(p.Category)]; mapped != "" {
+			category = mapped
+		}
+		rows = append(rows, f.row(p, category))
+	}
+	return rows
+}
+
+// Filename returns the download name of an export
+func (f Format) Filename(date string) string {
+	return fmt.Sprintf("katalog-%s-%s.%s", f.Name, date, f.FileType)
+}
+
+// ContentType returns the MIME type of the export file
+func (f Format) ContentType() string {
+	if f.FileType == "xlsx" {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Write encodes rows in the format's file type
+func (f Format) Write(w io.Writer, rows [][]string) error {
+	if f.FileType == "xlsx" {
+		return WriteXLSX(w, f.Label, rows)
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// Categories returns the distinct non-empty categories of products, sorted
+func Categories(products []Product) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, p := range products {
+		if c := strings.TrimSpace(p.Category); c != "" && !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// BuildCategoryPrompt renders the user prompt asking an AI provider to map the
+// seller's categories to the marketplace's category tree
+func BuildCategoryPrompt(f Format, categories []string, examples map[string][]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Petakan kategori produk penjual ke kategori %s yang paling sesuai.\n", f.Label)
+	fmt.Fprintf(&b, "Format kategori tujuan: %s.\n\n", f.CategoryTip)
+	b.WriteString("Kategori penjual (dengan contoh produk):\n")
+	for i, c := range categories {
+		if i >= MaxCategories {
+			break
+		}
+		fmt.Fprintf(&b, "- %q", c)
+		if ex := examples[c]; len(ex) > 0 {
+			fmt.Fprintf(&b, ": %s", strings.Join(ex, ", "))
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString(`
+Balas HANYA dengan JSON berformat:
+{"kategori penjual": "jalur kategori marketplace"}`)
+	return b.String()
+}
+
+// ParseCategoryMapping extracts a JSON category mapping from an AI response,
+// keeping only the categories that were asked for
+func ParseCategoryMapping(content string, categories []string) (map[string]string, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("no JSON object in response")
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(content[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("invalid category JSON: %w", err)
+	}
+
+	mapping := map[string]string{}
+	for _, c := range categories {
+		if v := strings.TrimSpace(raw[c]); v != "" && len(v) <= 255 {
+			mapping[c] = v
+		}
+	}
+	if len(mapping) == 0 {
+		return nil, fmt.Errorf("no categories mapped")
+	}
+	return mapping, nil
+}
+
+// price formats a rupiah price without decimals, as marketplace templates expect
+func price(v float64) string {
+	return strconv.FormatFloat(v, 'f', 0, 64)
+}
@@ -0,0 +1,83 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bantuaku/backend/services/docparse"
+)
+
+var testProducts = []Product{
+	{Name: "Kopi Susu Gula Aren", SKU: "KOPI-01", Category: "Minuman", Price: 18000, Active: true},
+	{Name: "Roti Bakar <Keju & Coklat>", Category: "Makanan", Price: 22500.4, Active: false},
+}
+
+func TestRowsApplyCategoryMapping(t *testing.T) {
+	f, ok := Get(FormatWooCommerce)
+	if !ok {
+		t.Fatal("woocommerce format not registered")
+	}
+
+	rows := f.Rows(testProducts, map[string]string{"Minuman": "Minuman > Kopi"})
+	if len(rows) != 3 || len(rows[1]) != len(f.Columns) {
+		t.Fatalf("unexpected rows: %v", rows)
+	}
+	if rows[1][7] != "Minuman > Kopi" || rows[2][7] != "Makanan" {
+		t.Errorf("categories = %q, %q", rows[1][7], rows[2][7])
+	}
+	if rows[2][3] != "0" || rows[2][6] != "22500" {
+		t.Errorf("inactive product row = %v", rows[2])
+	}
+}
+
+func TestWriteXLSXRoundTrip(t *testing.T) {
+	f, _ := Get(FormatShopee)
+	rows := f.Rows(testProducts, nil)
+
+	var buf bytes.Buffer
+	if err := f.Write(&buf, rows); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	parsed, err := docparse.Parse(context.Background(), docparse.KindXLSX, buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated workbook does not parse: %v", err)
+	}
+	if len(parsed.Tables) != 1 || parsed.Tables[0].Name != "Shopee" {
+		t.Fatalf("unexpected tables: %+v", parsed.Tables)
+	}
+	got := parsed.Tables[0].Rows
+	if got[0][1] != "Nama Produk" || got[2][1] != "Roti Bakar <Keju & Coklat>" || got[1][4] != "18000" {
+		t.Errorf("unexpected cells: %v", got)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestParseCategoryMapping(t *testing.T) {
+	content := "```json\n{\"Minuman\": \"Makanan & Minuman > Minuman\", \"Lainnya\": \"X\", \"Makanan\": \"\"}\n```"
+	got, err := ParseCategoryMapping(content, []string{"Minuman", "Makanan"})
+	if err != nil {
+		t.Fatalf("ParseCategoryMapping failed: %v", err)
+	}
+	if len(got) != 1 || got["Minuman"] != "Makanan & Minuman > Minuman" {
+		t.Errorf("unexpected mapping: %v", got)
+	}
+	if _, err := ParseCategoryMapping("maaf", []string{"Minuman"}); err == nil {
+		t.Error("expected error without JSON")
+	}
+}
+
+func TestCategories(t *testing.T) {
+	got := Categories(append(testProducts, Product{Name: "Es Teh", Category: " Minuman "}, Product{Name: "Tanpa"}))
+	if len(got) != 2 || got[0] != "Makanan" || got[1] != "Minuman" {
+		t.Errorf("Categories = %v", got)
+	}
+}
@@ -0,0 +1,104 @@
+package chatarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// MaxSummaryLength caps the rolling summary, in characters
+const MaxSummaryLength = 2000
+
+// Message is the part of a chat message used to summarize archived history
+type Message struct {
+	Sender    string
+	Content   string
+	CreatedAt time.Time
+}
+
+// Compress encodes v as gzip-compressed JSON
+func Compress(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(v); err != nil {
+		return nil, fmt.Errorf("encode archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decodes gzip-compressed JSON produced by Compress into v
+func Decompress(data []byte, v interface{}) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return fmt.Errorf("decompress archive: %w", err)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// RuleSummary extends the previous rolling summary with a rule-based note about
+// newly archived messages, used when AI is unavailable
+func RuleSummary(previous string, archived []Message) string {
+	if len(archived) == 0 {
+		return previous
+	}
+	var questions []string
+	for _, m := range archived {
+		if m.Sender != "user" || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		questions = append(questions, truncate(strings.Join(strings.Fields(m.Content), " "), 80))
+		if len(questions) == 5 {
+			break
+		}
+	}
+
+	note := fmt.Sprintf("%d pesan (%s – %s) diarsipkan.", len(archived),
+		archived[0].CreatedAt.Format("2006-01-02"), archived[len(archived)-1].CreatedAt.Format("2006-01-02"))
+	if len(questions) > 0 {
+		note += " Pertanyaan yang dibahas: " + strings.Join(questions, "; ") + "."
+	}
+	return Trim(strings.TrimSpace(previous + "\n" + note))
+}
+
+// BuildSummaryPrompt asks the model to fold archived messages into the rolling summary
+func BuildSummaryPrompt(previous string, archived []Message) string {
+	var b strings.Builder
+	b.WriteString("Perbarui ringkasan percakapan antara pemilik UMKM dan asisten bisnis.\n")
+	if previous != "" {
+		fmt.Fprintf(&b, "\nRingkasan sebelumnya:\n%s\n", previous)
+	}
+	b.WriteString("\nPesan yang akan diarsipkan:\n")
+	for _, m := range archived {
+		fmt.Fprintf(&b, "[%s] %s\n", m.Sender, truncate(m.Content, 500))
+	}
+	fmt.Fprintf(&b, "\nTulis ringkasan baru maksimal %d karakter dalam Bahasa Indonesia: fakta bisnis, keputusan, dan pertanyaan yang belum terjawab.", MaxSummaryLength)
+	return b.String()
+}
+
+// Trim shortens a summary to MaxSummaryLength, keeping the most recent text
+func Trim(summary string) string {
+	r := []rune(summary)
+	if len(r) <= MaxSummaryLength {
+		return summary
+	}
+	return "…" + string(r[len(r)-MaxSummaryLength+1:])
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
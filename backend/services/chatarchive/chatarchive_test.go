@@ -0,0 +1,52 @@
+package chatarchive
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	in := []Message{{Sender: "user", Content: strings.Repeat("stok kopi ", 200)}}
+	data, err := Compress(in)
+	if err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	if len(data) >= len(in[0].Content) {
+		t.Errorf("archive not compressed: %d bytes", len(data))
+	}
+
+	var out []Message
+	if err := Decompress(data, &out); err != nil {
+		t.Fatalf("Decompress: %v", err)
+	}
+	if len(out) != 1 || out[0].Content != in[0].Content {
+		t.Errorf("round trip = %+v", out)
+	}
+}
+
+func TestRuleSummary(t *testing.T) {
+	day := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	archived := []Message{
+		{Sender: "user", Content: "Berapa stok kopi?", CreatedAt: day},
+		{Sender: "assistant", Content: "Stok kopi 20 kg.", CreatedAt: day},
+		{Sender: "user", Content: "Promo apa\nyang cocok?", CreatedAt: day.AddDate(0, 0, 2)},
+	}
+	got := RuleSummary("Pemilik menjual kopi.", archived)
+	for _, want := range []string{"Pemilik menjual kopi.", "3 pesan (2026-05-01 – 2026-05-03)", "Berapa stok kopi?; Promo apa yang cocok?"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary %q missing %q", got, want)
+		}
+	}
+	if RuleSummary("x", nil) != "x" {
+		t.Error("nothing archived should keep the summary")
+	}
+}
+
+func TestTrim(t *testing.T) {
+	long := strings.Repeat("a", MaxSummaryLength) + "akhir"
+	got := Trim(long)
+	if len([]rune(got)) != MaxSummaryLength || !strings.HasSuffix(got, "akhir") {
+		t.Errorf("Trim kept %d runes, suffix ok %v", len([]rune(got)), strings.HasSuffix(got, "akhir"))
+	}
+}
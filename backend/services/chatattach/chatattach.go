@@ -0,0 +1,111 @@
+package chatattach
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits on what attachments add to a chat prompt
+const (
+	MaxFiles       = 5     // Files attached to one message
+	MaxFileRunes   = 4000  // Content of one file
+	MaxTotalRunes  = 12000 // Content of all files of a message
+	MaxSourceRunes = 40000 // Text of a long file handed to the model to summarize
+)
+
+// File is an attachment's content as it goes into the prompt
+type File struct {
+	Name    string
+	Text    string
+	Summary bool   // Text is a summary of a longer file
+	Error   string // Why the file could not be read; Text is empty
+}
+
+// Budget is how many characters each of n attachments may use, so together
+// they stay within MaxTotalRunes
+func Budget(n int) int {
+	if n < 1 {
+		return MaxFileRunes
+	}
+	if per := MaxTotalRunes / n; per < MaxFileRunes {
+		return per
+	}
+	return MaxFileRunes
+}
+
+// Fits reports whether text can go into the prompt whole within budget
+func Fits(text string, budget int) bool {
+	return len([]rune(text)) <= budget
+}
+
+// Truncate cuts text to budget characters, marking the cut
+func Truncate(text string, budget int) string {
+	r := []rune(text)
+	if len(r) <= budget {
+		return text
+	}
+	return string(r[:budget]) + "\n[…terpotong]"
+}
+
+// BuildSummaryPrompt asks the model to summarize a file too long for the chat
+// prompt in at most budget characters. Only the first MaxSourceRunes of the
+// file are sent.
+func BuildSummaryPrompt(name, text string, budget int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ringkas isi berkas %q yang dilampirkan pemilik UMKM ke percakapan dengan asisten bisnis.\n", name)
+	fmt.Fprintf(&b, "Pertahankan angka, tanggal, nama produk, dan total yang penting. Maksimal %d karakter, dalam Bahasa Indonesia.\n\n", budget)
+	r := []rune(text)
+	if len(r) > MaxSourceRunes {
+		b.WriteString(string(r[:MaxSourceRunes]))
+		b.WriteString("\n[…sisa berkas tidak disertakan]")
+	} else {
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// Context renders attachments as the block added to the user's message, with
+// each file held to its share of the budget
+func Context(files []File) string {
+	if len(files) == 0 {
+		return ""
+	}
+	budget := Budget(len(files))
+	var b strings.Builder
+	b.WriteString("Berkas yang dilampirkan pengguna:")
+	for _, f := range files {
+		fmt.Fprintf(&b, "\n\n--- %s", f.Name)
+		switch {
+		case f.Error != "":
+			fmt.Fprintf(&b, " (tidak dapat dibaca: %s) ---", f.Error)
+			continue
+		case f.Summary:
+			b.WriteString(" (ringkasan) ---")
+		default:
+			b.WriteString(" ---")
+		}
+		text := strings.TrimSpace(f.Text)
+		if text == "" {
+			text = "[berkas kosong]"
+		}
+		b.WriteString("\n" + Truncate(text, budget))
+	}
+	return b.String()
+}
+
+// Question is the user's message with the attachment context appended
+func Question(message string, files []File) string {
+	if block := Context(files); block != "" {
+		return message + "\n\n" + block
+	}
+	return message
+}
+
+// Names notes which files a past message had attached, for the conversation
+// history sent to the model
+func Names(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return "[Lampiran: " + strings.Join(names, ", ") + "]"
+}
@@ -0,0 +1,44 @@
+package chatattach
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBudget(t *testing.T) {
+	if got := Budget(1); got != MaxFileRunes {
+		t.Errorf("Budget(1) = %d", got)
+	}
+	if got := Budget(MaxFiles); got*MaxFiles > MaxTotalRunes {
+		t.Errorf("Budget(%d) = %d exceeds the total", MaxFiles, got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	long := strings.Repeat("a", MaxFileRunes+10)
+	block := Context([]File{
+		{Name: "nota.pdf", Text: "Total Rp 50.000"},
+		{Name: "stok.xlsx", Text: long},
+		{Name: "foto.jpg", Error: "ocr gagal"},
+	})
+	if !strings.Contains(block, "--- nota.pdf ---\nTotal Rp 50.000") {
+		t.Errorf("missing file content:\n%s", block)
+	}
+	if !strings.Contains(block, "[…terpotong]") || strings.Contains(block, long) {
+		t.Error("long file should be truncated to its budget")
+	}
+	if !strings.Contains(block, "foto.jpg (tidak dapat dibaca: ocr gagal)") {
+		t.Error("unreadable file should be noted")
+	}
+
+	if got := Question("Halo", nil); got != "Halo" {
+		t.Errorf("Question without files = %q", got)
+	}
+}
+
+func TestBuildSummaryPrompt(t *testing.T) {
+	p := BuildSummaryPrompt("laporan.pdf", strings.Repeat("x", MaxSourceRunes+5), 2000)
+	if !strings.Contains(p, "laporan.pdf") || !strings.Contains(p, "sisa berkas tidak disertakan") {
+		t.Errorf("unexpected prompt: %.200s", p)
+	}
+}
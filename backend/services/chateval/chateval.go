@@ -0,0 +1,129 @@
+// Package chateval defines the alternate ways an assistant answer can be
+// regenerated after negative feedback, and the hybrid ranking they use.
+package chateval
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Variant names. VariantOriginal is the answer as first given.
+const (
+	VariantOriginal       = "original"
+	VariantMoreContext    = "more_context"
+	VariantHybrid         = "hybrid"
+	VariantAlternateModel = "alternate_model"
+)
+
+// hybridKeywordWeight is the share of a hybrid score that comes from keyword overlap
+const hybridKeywordWeight = 0.3
+
+// Variant is a set of generation and retrieval parameters
+type Variant struct {
+	Name            string
+	HistoryMessages int    // Previous conversation messages sent to the model
+	ContextChunks   int    // Retrieved chunks added to the prompt; 0 adds none
+	Hybrid          bool   // Rerank retrieved chunks by keyword overlap as well as similarity
+	Model           string // "" uses the default chat model
+	Temperature     float64
+}
+
+// Variants returns the regeneration variants in the order they are tried.
+// The alternate model variant is only offered when alternateModel is set.
+func Variants(alternateModel string) []Variant {
+	variants := []Variant{
+		{Name: VariantMoreContext, HistoryMessages: 20, ContextChunks: 8, Temperature: 0.5},
+		{Name: VariantHybrid, HistoryMessages: 10, ContextChunks: 8, Hybrid: true, Temperature: 0.5},
+	}
+	if alternateModel != "" {
+		variants = append(variants, Variant{Name: VariantAlternateModel, HistoryMessages: 10, ContextChunks: 5, Hybrid: true, Model: alternateModel, Temperature: 0.7})
+	}
+	return variants
+}
+
+// Next returns the first variant whose name is not in tried
+func Next(variants []Variant, tried []string) (Variant, bool) {
+	done := map[string]bool{}
+	for _, name := range tried {
+		done[name] = true
+	}
+	for _, v := range variants {
+		if !done[v.Name] {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// Lookup returns the variant named name
+func Lookup(variants []Variant, name string) (Variant, bool) {
+	for _, v := range variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// Passage is a retrieved chunk of text with its similarity to the query
+type Passage struct {
+	SourceID string
+	Content  string
+	Score    float64
+}
+
+// Rerank orders passages by a blend of their similarity score and the share
+// of the query's keywords they contain, keeping the best limit. Keyword
+// overlap catches exact terms (permit names, product codes) that embeddings
+// tend to blur.
+func Rerank(query string, passages []Passage, limit int) []Passage {
+	terms := keywords(query)
+	out := make([]Passage, len(passages))
+	for i, p := range passages {
+		p.Score = (1-hybridKeywordWeight)*p.Score + hybridKeywordWeight*overlap(terms, p.Content)
+		out[i] = p
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// keywords returns the distinct lowercase words of at least three characters
+func keywords(text string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, w := range words(text) {
+		if len([]rune(w)) >= 3 && !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// overlap is the fraction of terms that appear as words in text
+func overlap(terms []string, text string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	present := map[string]bool{}
+	for _, w := range words(text) {
+		present[w] = true
+	}
+	found := 0
+	for _, t := range terms {
+		if present[t] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(terms))
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
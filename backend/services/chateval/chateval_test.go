@@ -0,0 +1,45 @@
+package chateval
+
+import (
+	"testing"
+)
+
+func TestVariants(t *testing.T) {
+	if got := len(Variants("")); got != 2 {
+		t.Errorf("variants without alternate model = %d, want 2", got)
+	}
+	variants := Variants("llama-3")
+	if alt, ok := Lookup(variants, VariantAlternateModel); !ok || alt.Model != "llama-3" {
+		t.Errorf("alternate model variant = %+v, %v", alt, ok)
+	}
+}
+
+func TestNext(t *testing.T) {
+	variants := Variants("llama-3")
+	v, ok := Next(variants, []string{VariantOriginal})
+	if !ok || v.Name != VariantMoreContext {
+		t.Errorf("first variant = %q, want %q", v.Name, VariantMoreContext)
+	}
+	v, ok = Next(variants, []string{VariantOriginal, VariantMoreContext})
+	if !ok || v.Name != VariantHybrid {
+		t.Errorf("second variant = %q, want %q", v.Name, VariantHybrid)
+	}
+	if _, ok := Next(variants, []string{VariantMoreContext, VariantHybrid, VariantAlternateModel}); ok {
+		t.Error("expected no variant left")
+	}
+}
+
+func TestRerank(t *testing.T) {
+	passages := []Passage{
+		{SourceID: "general", Content: "Tips memulai usaha makanan rumahan", Score: 0.62},
+		{SourceID: "pirt", Content: "Syarat mengurus SPP-IRT untuk pangan olahan rumah tangga", Score: 0.58},
+		{SourceID: "other", Content: "Strategi promosi di media sosial", Score: 0.2},
+	}
+	ranked := Rerank("cara mengurus SPP-IRT", passages, 2)
+	if len(ranked) != 2 || ranked[0].SourceID != "pirt" {
+		t.Fatalf("Rerank = %+v, want pirt first", ranked)
+	}
+	if ranked[1].SourceID != "general" {
+		t.Errorf("second = %q, want general", ranked[1].SourceID)
+	}
+}
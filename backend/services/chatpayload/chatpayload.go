@@ -0,0 +1,134 @@
+package chatpayload
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Block types
+const (
+	TypeTable     = "table"
+	TypeChart     = "chart"
+	TypeChecklist = "checklist"
+	TypeCitations = "citations"
+)
+
+// Chart kinds
+const (
+	ChartBar  = "bar"
+	ChartLine = "line"
+)
+
+// MaxBlocks bounds the blocks attached to one reply
+const MaxBlocks = 8
+
+// Block is a rich component the frontend renders next to the reply. Exactly
+// one of the content fields is set, matching Type.
+type Block struct {
+	Type      string          `json:"type"`
+	Title     string          `json:"title,omitempty"`
+	Source    string          `json:"source,omitempty"` // The tool the data came from
+	Table     *Table          `json:"table,omitempty"`
+	Chart     *Chart          `json:"chart,omitempty"`
+	Checklist []ChecklistItem `json:"checklist,omitempty"`
+	Citations []Citation      `json:"citations,omitempty"`
+}
+
+// Table is tabular data with named columns
+type Table struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// Chart is a chart spec: one value per label for each series
+type Chart struct {
+	Kind   string   `json:"kind"` // bar, line
+	Labels []string `json:"labels"`
+	Series []Series `json:"series"`
+	Unit   string   `json:"unit,omitempty"` // e.g. IDR
+}
+
+// Series is a named line or set of bars
+type Series struct {
+	Name   string    `json:"name"`
+	Values []float64 `json:"values"`
+}
+
+// ChecklistItem is a step the user can tick off
+type ChecklistItem struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// Citation is a source the reply draws on
+type Citation struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Excerpt string `json:"excerpt,omitempty"`
+}
+
+// taskItem matches a markdown task list item, "- [ ] text" or "* [x] text"
+var taskItem = regexp.MustCompile(`^\s*[-*+]\s+\[( |x|X)\]\s+(.+?)\s*$`)
+
+// ChecklistFromMarkdown extracts the task list items of a reply as a checklist
+// block; it returns nil when the reply has fewer than two
+func ChecklistFromMarkdown(text string) *Block {
+	var items []ChecklistItem
+	for _, line := range strings.Split(text, "\n") {
+		if m := taskItem.FindStringSubmatch(line); m != nil {
+			items = append(items, ChecklistItem{Text: m[2], Done: m[1] != " "})
+		}
+	}
+	if len(items) < 2 {
+		return nil
+	}
+	return &Block{Type: TypeChecklist, Checklist: items}
+}
+
+// Payload builds a message's structured payload from blocks, keeping the
+// first MaxBlocks and merging citations into one block without duplicate URLs.
+// It returns nil without blocks, so messages without rich content store none.
+func Payload(blocks []Block) map[string]interface{} {
+	var out []Block
+	citations := -1
+	seen := map[string]bool{}
+	for _, b := range blocks {
+		if b.Type == TypeCitations {
+			var fresh []Citation
+			for _, c := range b.Citations {
+				if !seen[c.URL] {
+					seen[c.URL] = true
+					fresh = append(fresh, c)
+				}
+			}
+			if citations >= 0 {
+				out[citations].Citations = append(out[citations].Citations, fresh...)
+				continue
+			}
+			if len(fresh) == 0 {
+				continue
+			}
+			b.Citations = fresh
+			citations = len(out)
+		}
+		if len(out) == MaxBlocks {
+			break
+		}
+		out = append(out, b)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	// Round-trip through JSON so the payload matches what is read back from the database
+	raw, err := json.Marshal(map[string]interface{}{"blocks": out})
+	if err != nil {
+		return nil
+	}
+	var payload map[string]interface{}
+	if json.Unmarshal(raw, &payload) != nil {
+		return nil
+	}
+	return payload
+}
@@ -0,0 +1,35 @@
+package chatpayload
+
+import "testing"
+
+func TestChecklistFromMarkdown(t *testing.T) {
+	b := ChecklistFromMarkdown("Langkah:\n- [ ] Daftar NIB\n- [x] Buka rekening\n* [ ] Urus PIRT\n- bukan tugas")
+	if b == nil || len(b.Checklist) != 3 {
+		t.Fatalf("expected 3 items, got %+v", b)
+	}
+	if b.Checklist[0].Text != "Daftar NIB" || b.Checklist[0].Done || !b.Checklist[1].Done {
+		t.Errorf("unexpected items %+v", b.Checklist)
+	}
+	if ChecklistFromMarkdown("- [ ] Satu saja") != nil {
+		t.Error("a single item should not make a checklist")
+	}
+}
+
+func TestPayloadMergesCitations(t *testing.T) {
+	payload := Payload([]Block{
+		{Type: TypeCitations, Citations: []Citation{{Title: "NIB", URL: "/kb/nib"}}},
+		{Type: TypeTable, Table: &Table{Columns: []string{"a"}, Rows: [][]interface{}{{1}}}},
+		{Type: TypeCitations, Citations: []Citation{{Title: "NIB", URL: "/kb/nib"}, {Title: "PIRT", URL: "/kb/pirt"}}},
+	})
+	blocks := payload["blocks"].([]interface{})
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(blocks))
+	}
+	citations := blocks[0].(map[string]interface{})["citations"].([]interface{})
+	if len(citations) != 2 {
+		t.Errorf("expected 2 distinct citations, got %v", citations)
+	}
+	if Payload(nil) != nil {
+		t.Error("expected no payload without blocks")
+	}
+}
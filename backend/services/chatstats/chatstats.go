@@ -0,0 +1,102 @@
+package chatstats
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bantuaku/backend/services/embeddings"
+)
+
+// DefaultTopicThreshold is the cosine similarity above which two questions share a topic
+const DefaultTopicThreshold = 0.8
+
+// Topic is a cluster of similar questions
+type Topic struct {
+	Label    string   `json:"label"`
+	Count    int      `json:"count"`
+	Examples []string `json:"examples"`
+}
+
+// Cluster groups questions by embedding similarity: each question joins the first
+// topic whose seed is at least threshold similar, or starts a new one. Topics are
+// returned largest first, at most limit of them.
+func Cluster(questions []string, vectors [][]float32, threshold float64, limit int) []Topic {
+	type cluster struct {
+		seed    []float32
+		members []string
+	}
+	var clusters []*cluster
+	for i, v := range vectors {
+		if i >= len(questions) {
+			break
+		}
+		var best *cluster
+		bestScore := threshold
+		for _, c := range clusters {
+			if s := embeddings.Cosine(c.seed, v); s >= bestScore {
+				best, bestScore = c, s
+			}
+		}
+		if best == nil {
+			best = &cluster{seed: v}
+			clusters = append(clusters, best)
+		}
+		best.members = append(best.members, questions[i])
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool { return len(clusters[i].members) > len(clusters[j].members) })
+	topics := []Topic{}
+	for _, c := range clusters {
+		if len(topics) == limit {
+			break
+		}
+		examples := c.members
+		if len(examples) > 3 {
+			examples = examples[:3]
+		}
+		topics = append(topics, Topic{Label: Label(c.members), Count: len(c.members), Examples: examples})
+	}
+	return topics
+}
+
+// stopwords are common Indonesian and English words ignored when labelling topics
+var stopwords = map[string]bool{
+	"yang": true, "dan": true, "di": true, "ke": true, "dari": true, "untuk": true, "apa": true, "apakah": true,
+	"bagaimana": true, "berapa": true, "saya": true, "aku": true, "kami": true, "ini": true, "itu": true,
+	"dengan": true, "ada": true, "bisa": true, "tolong": true, "mohon": true, "bulan": true, "cara": true,
+	"agar": true, "supaya": true, "jika": true, "kalau": true, "atau": true, "juga": true, "sudah": true,
+	"the": true, "a": true, "an": true, "to": true, "of": true, "how": true, "what": true, "is": true,
+}
+
+// Label names a topic by its two most frequent meaningful words
+func Label(questions []string) string {
+	counts := map[string]int{}
+	for _, q := range questions {
+		seen := map[string]bool{}
+		for _, w := range strings.FieldsFunc(strings.ToLower(q), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			if len([]rune(w)) < 3 || stopwords[w] || seen[w] {
+				continue
+			}
+			seen[w] = true
+			counts[w]++
+		}
+	}
+	words := make([]string, 0, len(counts))
+	for w := range counts {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if counts[words[i]] != counts[words[j]] {
+			return counts[words[i]] > counts[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	if len(words) > 2 {
+		words = words[:2]
+	}
+	if len(words) == 0 {
+		return "lainnya"
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,34 @@
+package chatstats
+
+import "testing"
+
+func TestCluster(t *testing.T) {
+	questions := []string{
+		"Berapa stok kopi yang harus saya beli?",
+		"Stok kopi cukup untuk bulan depan?",
+		"Bagaimana cara promosi di Instagram?",
+		"Kapan stok kopi habis?",
+	}
+	vectors := [][]float32{{1, 0}, {0.95, 0.1}, {0, 1}, {0.9, 0.05}}
+
+	topics := Cluster(questions, vectors, DefaultTopicThreshold, 5)
+	if len(topics) != 2 {
+		t.Fatalf("got %d topics, want 2: %+v", len(topics), topics)
+	}
+	if topics[0].Count != 3 || topics[0].Label != "kopi stok" {
+		t.Errorf("largest topic = %+v", topics[0])
+	}
+	if topics[1].Count != 1 || len(topics[1].Examples) != 1 {
+		t.Errorf("second topic = %+v", topics[1])
+	}
+
+	if got := Cluster(questions, vectors, DefaultTopicThreshold, 1); len(got) != 1 {
+		t.Errorf("limit not applied: %d topics", len(got))
+	}
+}
+
+func TestLabel(t *testing.T) {
+	if got := Label([]string{"apa itu?"}); got != "lainnya" {
+		t.Errorf("Label of stopwords = %q", got)
+	}
+}
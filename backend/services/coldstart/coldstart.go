@@ -0,0 +1,155 @@
+package coldstart
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/bantuaku/backend/services/dedupe"
+	"github.com/bantuaku/backend/services/embeddings"
+)
+
+const (
+	// MinSalesDays is how many days with sales a product needs in the window
+	// to be forecast from its own history, and to serve as an analog
+	MinSalesDays = 7
+	// MaxAnalogs is how many analogs a provisional forecast averages
+	MaxAnalogs = 5
+	// MinScore is the lowest similarity an analog may have
+	MinScore = 0.35
+	// MinIndustryCompanies is how many other companies industry analogs must
+	// come from, so no single company's sales can be read off the forecast
+	MinIndustryCompanies = 3
+	// MaxConfidence caps the confidence of provisional forecasts
+	MaxConfidence = 0.3
+)
+
+// Score weights
+const (
+	weightName     = 0.6
+	weightCategory = 0.25
+	weightPrice    = 0.15
+)
+
+// Analog sources
+const (
+	SourceCompany  = "company"  // Another product of the same company
+	SourceIndustry = "industry" // A product of another company in the same industry
+)
+
+// Product is a product to forecast or a candidate analog
+type Product struct {
+	ID        string
+	CompanyID string
+	Name      string
+	Category  string
+	Price     float64
+	Vector    []float32 // Embedding of the name; nil falls back to lexical similarity
+	Source    string
+	DailyRate float64 // Mean sales per day with sales; candidates only
+}
+
+// Analog is a product a provisional forecast is based on. Industry analogs
+// carry no ID or name.
+type Analog struct {
+	ProductID     string  `json:"product_id,omitempty"`
+	Name          string  `json:"name,omitempty"`
+	Category      string  `json:"category,omitempty"`
+	Source        string  `json:"source"`
+	Score         float64 `json:"score"`
+	NameScore     float64 `json:"name_score"`
+	SameCategory  bool    `json:"same_category"`
+	SamePriceBand bool    `json:"same_price_band"`
+	DailyRate     float64 `json:"daily_rate"`
+	companyID     string
+}
+
+// SamePriceBand reports whether two prices are within 50% of each other
+func SamePriceBand(a, b float64) bool {
+	if a <= 0 || b <= 0 {
+		return false
+	}
+	return math.Max(a, b)/math.Min(a, b) <= 1.5
+}
+
+// Score rates how well candidate stands in for target: mostly name similarity
+// (cosine of embeddings when both have one, otherwise trigrams), plus a shared
+// category and price band
+func Score(target, candidate Product) Analog {
+	a := Analog{
+		ProductID: candidate.ID,
+		Name:      candidate.Name,
+		Category:  candidate.Category,
+		Source:    candidate.Source,
+		DailyRate: candidate.DailyRate,
+		companyID: candidate.CompanyID,
+	}
+	if len(target.Vector) > 0 && len(candidate.Vector) > 0 {
+		a.NameScore = embeddings.Cosine(target.Vector, candidate.Vector)
+	} else {
+		a.NameScore = dedupe.NameSimilarity(target.Name, candidate.Name)
+	}
+	a.SameCategory = target.Category != "" && strings.EqualFold(strings.TrimSpace(target.Category), strings.TrimSpace(candidate.Category))
+	a.SamePriceBand = SamePriceBand(target.Price, candidate.Price)
+	a.Score = weightName * math.Max(a.NameScore, 0)
+	if a.SameCategory {
+		a.Score += weightCategory
+	}
+	if a.SamePriceBand {
+		a.Score += weightPrice
+	}
+	a.Score = math.Round(a.Score*1000) / 1000
+	return a
+}
+
+// Select returns the best analogs for target, best first. Industry analogs are
+// only used when they come from at least MinIndustryCompanies companies, and
+// lose their IDs and names.
+func Select(target Product, candidates []Product) []Analog {
+	var analogs []Analog
+	for _, c := range candidates {
+		if c.ID == target.ID || c.DailyRate <= 0 {
+			continue
+		}
+		if a := Score(target, c); a.Score >= MinScore {
+			analogs = append(analogs, a)
+		}
+	}
+	sort.SliceStable(analogs, func(i, j int) bool { return analogs[i].Score > analogs[j].Score })
+	if len(analogs) > MaxAnalogs {
+		analogs = analogs[:MaxAnalogs]
+	}
+
+	companies := map[string]bool{}
+	for _, a := range analogs {
+		if a.Source == SourceIndustry {
+			companies[a.companyID] = true
+		}
+	}
+	kept := analogs[:0]
+	for _, a := range analogs {
+		if a.Source == SourceIndustry {
+			if len(companies) < MinIndustryCompanies {
+				continue
+			}
+			a.ProductID, a.Name = "", ""
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
+
+// Forecast averages the analogs' daily rates weighted by score. Confidence
+// grows with similarity but stays at most MaxConfidence.
+func Forecast(analogs []Analog) (daily, confidence float64) {
+	var sum, weights float64
+	for _, a := range analogs {
+		sum += a.DailyRate * a.Score
+		weights += a.Score
+	}
+	if weights == 0 {
+		return 0, 0
+	}
+	meanScore := weights / float64(len(analogs))
+	return sum / weights, math.Round(MaxConfidence*math.Min(meanScore, 1)*100) / 100
+}
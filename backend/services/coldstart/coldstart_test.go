@@ -0,0 +1,49 @@
+package coldstart
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	target := Product{Name: "Kopi Susu Gula Aren", Category: "Minuman", Price: 18000}
+	a := Score(target, Product{ID: "p1", Name: "Kopi Susu", Category: "minuman", Price: 15000, DailyRate: 12})
+	if !a.SameCategory || !a.SamePriceBand || a.Score < MinScore {
+		t.Errorf("expected a close analog, got %+v", a)
+	}
+	b := Score(target, Product{ID: "p2", Name: "Keripik Singkong", Category: "Makanan", Price: 50000, DailyRate: 3})
+	if b.Score >= MinScore {
+		t.Errorf("expected an unrelated product to score low, got %+v", b)
+	}
+}
+
+func TestSelectHidesSparseIndustryPool(t *testing.T) {
+	target := Product{ID: "t", Name: "Kopi Susu", Category: "Minuman", Price: 15000}
+	industry := func(id, company string) Product {
+		return Product{ID: id, CompanyID: company, Name: "Kopi Susu", Category: "Minuman", Price: 16000, Source: SourceIndustry, DailyRate: 10}
+	}
+	// Two companies are too few to hide each one's sales
+	got := Select(target, []Product{industry("a", "c1"), industry("b", "c2")})
+	if len(got) != 0 {
+		t.Fatalf("expected industry analogs from 2 companies to be dropped, got %+v", got)
+	}
+	got = Select(target, []Product{industry("a", "c1"), industry("b", "c2"), industry("c", "c3")})
+	if len(got) != 3 {
+		t.Fatalf("expected 3 industry analogs, got %+v", got)
+	}
+	for _, a := range got {
+		if a.ProductID != "" || a.Name != "" {
+			t.Errorf("industry analog leaks identity: %+v", a)
+		}
+	}
+}
+
+func TestForecast(t *testing.T) {
+	daily, confidence := Forecast([]Analog{{DailyRate: 10, Score: 1}, {DailyRate: 20, Score: 0.5}})
+	if daily < 13.33 || daily > 13.34 {
+		t.Errorf("daily = %v, want 13.33", daily)
+	}
+	if confidence > MaxConfidence || confidence <= 0 {
+		t.Errorf("confidence = %v", confidence)
+	}
+	if d, c := Forecast(nil); d != 0 || c != 0 {
+		t.Errorf("empty forecast = %v, %v", d, c)
+	}
+}
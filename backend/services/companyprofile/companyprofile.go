@@ -0,0 +1,44 @@
+package companyprofile
+
+// Field is a company profile field that counts toward its completeness
+type Field struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// Fields lists the profile fields onboarding asks for, in the order they are
+// asked
+var Fields = []Field{
+	{Key: "name", Label: "Nama usaha"},
+	{Key: "industry", Label: "Bidang usaha"},
+	{Key: "description", Label: "Deskripsi usaha"},
+	{Key: "business_model", Label: "Model bisnis"},
+	{Key: "location_region", Label: "Provinsi"},
+	{Key: "city", Label: "Kota/kabupaten"},
+	{Key: "founded_year", Label: "Tahun berdiri"},
+	{Key: "website", Label: "Situs web"},
+}
+
+// Completeness is how much of the profile is filled in
+type Completeness struct {
+	Percent  int     `json:"percent"`
+	Filled   int     `json:"filled"`
+	Total    int     `json:"total"`
+	Missing  []Field `json:"missing"` // In the order onboarding asks for them
+	Complete bool    `json:"complete"`
+}
+
+// Assess measures completeness from which fields are filled in, by key
+func Assess(filled map[string]bool) Completeness {
+	c := Completeness{Total: len(Fields), Missing: []Field{}}
+	for _, f := range Fields {
+		if filled[f.Key] {
+			c.Filled++
+		} else {
+			c.Missing = append(c.Missing, f)
+		}
+	}
+	c.Percent = c.Filled * 100 / c.Total
+	c.Complete = c.Filled == c.Total
+	return c
+}
@@ -0,0 +1,24 @@
+package companyprofile
+
+import "testing"
+
+func TestAssess(t *testing.T) {
+	c := Assess(map[string]bool{"name": true, "industry": true, "city": false})
+	if c.Filled != 2 || c.Total != len(Fields) || c.Complete {
+		t.Errorf("unexpected completeness %+v", c)
+	}
+	if c.Percent != 2*100/len(Fields) {
+		t.Errorf("percent = %d", c.Percent)
+	}
+	if len(c.Missing) != len(Fields)-2 || c.Missing[0].Key != "description" {
+		t.Errorf("missing should follow the onboarding order, got %+v", c.Missing)
+	}
+
+	all := map[string]bool{}
+	for _, f := range Fields {
+		all[f.Key] = true
+	}
+	if c := Assess(all); !c.Complete || c.Percent != 100 || len(c.Missing) != 0 {
+		t.Errorf("full profile: %+v", c)
+	}
+}
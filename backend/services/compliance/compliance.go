@@ -0,0 +1,230 @@
+package compliance
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Legal forms a company can register as
+const (
+	LegalFormIndividual   = "perorangan" // Unincorporated sole proprietor
+	LegalFormUD           = "ud"
+	LegalFormCV           = "cv"
+	LegalFormFirma        = "firma"
+	LegalFormPT           = "pt"
+	LegalFormPTPerorangan = "pt_perorangan" // Single-founder PT for micro and small businesses
+	LegalFormKoperasi     = "koperasi"
+)
+
+// LegalForms lists the valid legal forms
+var LegalForms = []string{LegalFormIndividual, LegalFormUD, LegalFormCV, LegalFormFirma, LegalFormPT, LegalFormPTPerorangan, LegalFormKoperasi}
+
+// Checklist item statuses tracked per company
+const (
+	StatusMissing       = "missing"
+	StatusInProgress    = "in_progress"
+	StatusObtained      = "obtained"
+	StatusNotApplicable = "not_applicable" // The company says the requirement does not apply to it
+)
+
+// DefaultReminderDays are the days before expiry at which a permit's owner is
+// reminded to renew it
+var DefaultReminderDays = []int{30, 7, 1}
+
+// Rule says a requirement applies to businesses matching all of its
+// constraints. An empty constraint matches everything.
+type Rule struct {
+	ID           string
+	Requirement  string // Short code of the permit or certificate, e.g. "NIB", "PIRT", "HALAL"
+	Title        string
+	Description  string
+	Authority    string
+	ReferenceURL string
+	KBLIPrefixes []string // e.g. "10" matches every food manufacturing code
+	LegalForms   []string
+	Provinces    []string // Region codes of provinces
+	Priority     int      // Lower comes first in the checklist
+}
+
+// Profile is what the rules are evaluated against
+type Profile struct {
+	KBLICode     string
+	LegalForm    string
+	ProvinceCode string
+}
+
+// Item is one requirement on a company's checklist with the rules that put it there
+type Item struct {
+	Requirement  string   `json:"requirement"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	Authority    string   `json:"authority,omitempty"`
+	ReferenceURL string   `json:"reference_url,omitempty"`
+	RuleIDs      []string `json:"rule_ids"`
+	Reasons      []string `json:"reasons"` // Why it applies, e.g. "KBLI 10", "bentuk usaha pt"
+	priority     int
+}
+
+// ValidLegalForm reports whether form is a known legal form
+func ValidLegalForm(form string) bool {
+	for _, f := range LegalForms {
+		if f == form {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidStatus reports whether status is a known checklist status
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusMissing, StatusInProgress, StatusObtained, StatusNotApplicable:
+		return true
+	}
+	return false
+}
+
+// Matches reports whether a rule applies to a profile. A rule constrained by
+// KBLI, legal form or province never matches a profile missing that field, so
+// an incomplete profile only gets the requirements every business has.
+func (r Rule) Matches(p Profile) bool {
+	if len(r.KBLIPrefixes) > 0 && (p.KBLICode == "" || !hasPrefix(p.KBLICode, r.KBLIPrefixes)) {
+		return false
+	}
+	if len(r.LegalForms) > 0 && !contains(r.LegalForms, p.LegalForm) {
+		return false
+	}
+	if len(r.Provinces) > 0 && !contains(r.Provinces, p.ProvinceCode) {
+		return false
+	}
+	return true
+}
+
+// Evaluate returns the checklist for a profile: one item per requirement,
+// merging every matching rule, ordered by priority then requirement. The same
+// rules and profile always give the same checklist.
+func Evaluate(rules []Rule, p Profile) []Item {
+	byRequirement := map[string]*Item{}
+	for _, r := range rules {
+		if !r.Matches(p) {
+			continue
+		}
+		item, ok := byRequirement[r.Requirement]
+		if !ok {
+			item = &Item{
+				Requirement:  r.Requirement,
+				Title:        r.Title,
+				Description:  r.Description,
+				Authority:    r.Authority,
+				ReferenceURL: r.ReferenceURL,
+				RuleIDs:      []string{},
+				Reasons:      []string{},
+				priority:     r.Priority,
+			}
+			byRequirement[r.Requirement] = item
+		}
+		if r.Priority < item.priority {
+			item.priority = r.Priority
+		}
+		item.RuleIDs = append(item.RuleIDs, r.ID)
+		item.Reasons = appendUnique(item.Reasons, reasons(r, p)...)
+	}
+
+	items := make([]Item, 0, len(byRequirement))
+	for _, item := range byRequirement {
+		sort.Strings(item.RuleIDs)
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].priority != items[j].priority {
+			return items[i].priority < items[j].priority
+		}
+		return items[i].Requirement < items[j].Requirement
+	})
+	return items
+}
+
+// ReminderDue returns the reminder threshold, in days before expiry, that an
+// expiry date has reached: the smallest of thresholds not below the days left,
+// or 0 once expired. ok is false while no threshold is reached yet. Dates are
+// compared by calendar day in now's location.
+func ReminderDue(expiresOn, now time.Time, thresholds []int) (threshold int, ok bool) {
+	daysLeft := daysUntil(expiresOn, now)
+	if daysLeft < 0 {
+		return 0, true
+	}
+	threshold = -1
+	for _, t := range thresholds {
+		if daysLeft <= t && (threshold < 0 || t < threshold) {
+			threshold = t
+		}
+	}
+	if threshold < 0 {
+		return 0, false
+	}
+	return threshold, true
+}
+
+// Expired reports whether an expiry date is before now's calendar day
+func Expired(expiresOn, now time.Time) bool {
+	return daysUntil(expiresOn, now) < 0
+}
+
+// daysUntil counts calendar days from now to a date, in now's location
+func daysUntil(date, now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, now.Location())
+	return int(math.Round(day.Sub(today).Hours() / 24))
+}
+
+// reasons describes which constraints of a matching rule the profile met
+func reasons(r Rule, p Profile) []string {
+	var out []string
+	if len(r.KBLIPrefixes) > 0 {
+		for _, prefix := range r.KBLIPrefixes {
+			if strings.HasPrefix(p.KBLICode, prefix) {
+				out = append(out, "KBLI "+prefix)
+				break
+			}
+		}
+	}
+	if len(r.LegalForms) > 0 {
+		out = append(out, "bentuk usaha "+p.LegalForm)
+	}
+	if len(r.Provinces) > 0 {
+		out = append(out, "provinsi "+p.ProvinceCode)
+	}
+	if len(out) == 0 {
+		out = append(out, "semua usaha")
+	}
+	return out
+}
+
+func hasPrefix(code string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(code, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,90 @@
+package compliance
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testRules = []Rule{
+	{ID: "nib", Requirement: "NIB", Title: "NIB", Priority: 1},
+	{ID: "pirt", Requirement: "PIRT", Title: "SPP-IRT", KBLIPrefixes: []string{"10", "11"}, LegalForms: []string{LegalFormIndividual, LegalFormUD}, Priority: 20},
+	{ID: "bpom-md", Requirement: "BPOM", Title: "Izin Edar BPOM", KBLIPrefixes: []string{"10", "11"}, LegalForms: []string{LegalFormPT}, Priority: 20},
+	{ID: "halal-food", Requirement: "HALAL", Title: "Sertifikat Halal", KBLIPrefixes: []string{"10", "11"}, Priority: 30},
+	{ID: "halal-resto", Requirement: "HALAL", Title: "Sertifikat Halal", KBLIPrefixes: []string{"56"}, Priority: 30},
+	{ID: "jkt", Requirement: "LOCAL", Title: "Izin lokal", Provinces: []string{"31"}, Priority: 40},
+}
+
+func requirements(items []Item) []string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.Requirement)
+	}
+	return out
+}
+
+func TestEvaluate(t *testing.T) {
+	home := Evaluate(testRules, Profile{KBLICode: "10794", LegalForm: LegalFormIndividual, ProvinceCode: "32"})
+	if got, want := requirements(home), []string{"NIB", "PIRT", "HALAL"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("home food business = %v, want %v", got, want)
+	}
+
+	pt := Evaluate(testRules, Profile{KBLICode: "10794", LegalForm: LegalFormPT, ProvinceCode: "31"})
+	if got, want := requirements(pt), []string{"NIB", "BPOM", "HALAL", "LOCAL"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PT in Jakarta = %v, want %v", got, want)
+	}
+
+	resto := Evaluate(testRules, Profile{KBLICode: "56101", LegalForm: LegalFormCV})
+	if len(resto) != 2 || resto[1].Requirement != "HALAL" || resto[1].RuleIDs[0] != "halal-resto" {
+		t.Errorf("restaurant = %+v", resto)
+	}
+}
+
+func TestEvaluateIncompleteProfile(t *testing.T) {
+	items := Evaluate(testRules, Profile{})
+	if got := requirements(items); !reflect.DeepEqual(got, []string{"NIB"}) {
+		t.Errorf("empty profile = %v, want only NIB", got)
+	}
+	if items[0].Reasons[0] != "semua usaha" {
+		t.Errorf("reasons = %v", items[0].Reasons)
+	}
+}
+
+func TestValid(t *testing.T) {
+	if !ValidLegalForm(LegalFormPTPerorangan) || ValidLegalForm("llc") {
+		t.Error("unexpected ValidLegalForm result")
+	}
+	if !ValidStatus(StatusObtained) || ValidStatus("done") {
+		t.Error("unexpected ValidStatus result")
+	}
+}
+
+func TestReminderDue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 15, 0, 0, 0, time.UTC)
+	cases := []struct {
+		expires   time.Time
+		threshold int
+		ok        bool
+	}{
+		{now.AddDate(0, 2, 0), 0, false},
+		{now.AddDate(0, 0, 30), 30, true},
+		{now.AddDate(0, 0, 10), 30, true},
+		{now.AddDate(0, 0, 7), 7, true},
+		{now.AddDate(0, 0, 1), 1, true},
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), 1, true}, // Expires today
+		{now.AddDate(0, 0, -1), 0, true},
+	}
+	for _, c := range cases {
+		threshold, ok := ReminderDue(c.expires, now, DefaultReminderDays)
+		if threshold != c.threshold || ok != c.ok {
+			t.Errorf("ReminderDue(%s) = %d, %v, want %d, %v", c.expires.Format("2006-01-02"), threshold, ok, c.threshold, c.ok)
+		}
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	if Expired(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), now) || !Expired(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), now) {
+		t.Error("unexpected Expired result")
+	}
+}
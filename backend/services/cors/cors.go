@@ -0,0 +1,77 @@
+package cors
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Policy decides which cross-origin callers may use a set of routes
+type Policy struct {
+	// Origins are exact origins ("https://app.bantuaku.id"), wildcard subdomain
+	// patterns ("https://*.bantuaku.id") or "*" for any origin
+	Origins []string
+	// Credentials lets allowed origins send cookies and Authorization headers.
+	// It is never granted to an origin matched only by "*".
+	Credentials bool
+	// MaxAge is how long, in seconds, browsers may cache a preflight response
+	MaxAge int
+}
+
+// ParseOrigins splits a comma-separated CORS_ORIGINS value, dropping empty
+// entries and trailing slashes
+func ParseOrigins(value string) []string {
+	var origins []string
+	for _, o := range strings.Split(value, ",") {
+		o = strings.TrimRight(strings.TrimSpace(o), "/")
+		if o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
+
+// Match reports whether origin may make cross-origin requests, and whether it
+// matched a listed origin or pattern rather than only "*". Only listed origins
+// may make credentialed requests.
+func (p Policy) Match(origin string) (allowed, listed bool) {
+	if origin == "" {
+		return false, false
+	}
+	for _, o := range p.Origins {
+		if o == "*" {
+			allowed = true
+			continue
+		}
+		if matchOrigin(o, origin) {
+			return true, true
+		}
+	}
+	return allowed, false
+}
+
+// Wildcard reports whether the policy allows any origin
+func (p Policy) Wildcard() bool {
+	for _, o := range p.Origins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOrigin compares an origin against an exact origin or a "scheme://*.domain"
+// pattern. A pattern matches subdomains at any depth but not the bare domain.
+func matchOrigin(pattern, origin string) bool {
+	if strings.EqualFold(pattern, origin) {
+		return true
+	}
+	scheme, host, ok := strings.Cut(pattern, "://*.")
+	if !ok {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" || u.Path != "" || !strings.EqualFold(u.Scheme, scheme) {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(u.Host), "."+strings.ToLower(host))
+}
@@ -0,0 +1,45 @@
+package cors
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	p := Policy{Origins: []string{"https://app.bantuaku.id", "https://*.bantuaku.id", "http://localhost:3000"}}
+	cases := map[string]bool{
+		"https://app.bantuaku.id":      true,
+		"https://staging.bantuaku.id":  true,
+		"https://a.b.bantuaku.id":      true,
+		"https://bantuaku.id":          false,
+		"http://staging.bantuaku.id":   false,
+		"https://evilbantuaku.id":      false,
+		"https://bantuaku.id.evil.com": false,
+		"http://localhost:3000":        true,
+		"http://localhost:3001":        false,
+		"null":                         false,
+		"":                             false,
+	}
+	for origin, want := range cases {
+		if got, _ := p.Match(origin); got != want {
+			t.Errorf("Match(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	p := Policy{Origins: []string{"*", "https://app.bantuaku.id"}}
+	if allowed, listed := p.Match("https://example.com"); !allowed || listed {
+		t.Errorf("any origin should be allowed but not listed, got %v, %v", allowed, listed)
+	}
+	if allowed, listed := p.Match("https://app.bantuaku.id"); !allowed || !listed {
+		t.Errorf("listed origin should be listed, got %v, %v", allowed, listed)
+	}
+	if !p.Wildcard() {
+		t.Error("expected wildcard policy")
+	}
+}
+
+func TestParseOrigins(t *testing.T) {
+	got := ParseOrigins(" https://app.bantuaku.id/, ,http://localhost:3000")
+	if len(got) != 2 || got[0] != "https://app.bantuaku.id" || got[1] != "http://localhost:3000" {
+		t.Errorf("ParseOrigins = %v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package dedupe
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bantuaku/backend/services/embeddings"
+)
+
+// metadataBoost is added to a pair's score for every metadata field the records share
+const metadataBoost = 0.08
+
+// legalForms are dropped when comparing business names, so "CV Maju Jaya" and
+// "Toko Maju Jaya" compare as the same name
+var legalForms = map[string]bool{"toko": true, "cv": true, "pt": true, "ud": true, "tbk": true, "warung": true, "official": true, "store": true, "shop": true}
+
+// Record is a company or product to compare
+type Record struct {
+	ID     string
+	Name   string
+	Vector []float32 // Embedding of the name; nil falls back to lexical similarity
+	// Meta holds normalized identifying fields, e.g. "website", "owner", "sku".
+	// Empty values are ignored.
+	Meta map[string]string
+}
+
+// Candidate is a pair of records that are likely duplicates
+type Candidate struct {
+	A, B      string   // Record IDs, A sorted before B
+	Score     float64  // 0-1 combined likelihood
+	NameScore float64  // Similarity of the names alone
+	Shared    []string // Metadata fields with equal values
+}
+
+// Candidates compares every pair of records and returns those scoring at least
+// minScore, best first, capped at limit (0 means no cap). The name similarity is
+// the cosine of the embeddings when both records have one, otherwise a trigram
+// comparison of the normalized names; each shared metadata field adds to it.
+func Candidates(records []Record, minScore float64, limit int) []Candidate {
+	grams := make([]map[string]bool, len(records))
+	for i, r := range records {
+		grams[i] = trigrams(NormalizeName(r.Name))
+	}
+
+	var out []Candidate
+	for i := 0; i < len(records); i++ {
+		for j := i + 1; j < len(records); j++ {
+			a, b := records[i], records[j]
+			var nameScore float64
+			if len(a.Vector) > 0 && len(b.Vector) > 0 {
+				nameScore = embeddings.Cosine(a.Vector, b.Vector)
+			} else {
+				nameScore = dice(grams[i], grams[j])
+			}
+			shared := SharedMeta(a.Meta, b.Meta)
+			score := nameScore + metadataBoost*float64(len(shared))
+			if score > 1 {
+				score = 1
+			}
+			if score < minScore {
+				continue
+			}
+			c := Candidate{A: a.ID, B: b.ID, Score: score, NameScore: nameScore, Shared: shared}
+			if c.B < c.A {
+				c.A, c.B = c.B, c.A
+			}
+			out = append(out, c)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// SharedMeta returns the sorted metadata keys both records have with equal, non-empty values
+func SharedMeta(a, b map[string]string) []string {
+	var shared []string
+	for k, v := range a {
+		if v != "" && strings.EqualFold(v, b[k]) {
+			shared = append(shared, k)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// NormalizeName lowercases a name, keeps letters and digits, and drops legal
+// forms and shop words
+func NormalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := words[:0]
+	for _, w := range words {
+		if !legalForms[w] {
+			kept = append(kept, w)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// NormalizeDomain reduces a website to its host without "www.", for comparing sites
+func NormalizeDomain(website string) string {
+	s := strings.ToLower(strings.TrimSpace(website))
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	s = strings.TrimPrefix(s, "www.")
+	if i := strings.IndexAny(s, "/?#"); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// NameSimilarity compares two names by the trigrams of their normalized forms
+func NameSimilarity(a, b string) float64 {
+	return dice(trigrams(NormalizeName(a)), trigrams(NormalizeName(b)))
+}
+
+func trigrams(s string) map[string]bool {
+	grams := map[string]bool{}
+	runes := []rune(" " + s + " ")
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])] = true
+	}
+	return grams
+}
+
+// dice is the Dice coefficient of two trigram sets
+func dice(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for g := range a {
+		if b[g] {
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
@@ -0,0 +1,39 @@
+package dedupe
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	if got := NormalizeName("CV. Maju-Jaya (Official Store)"); got != "maju jaya" {
+		t.Errorf("NormalizeName = %q", got)
+	}
+	if got := NormalizeDomain("https://www.TokoMaju.id/shop?x=1"); got != "tokomaju.id" {
+		t.Errorf("NormalizeDomain = %q", got)
+	}
+}
+
+func TestCandidatesLexical(t *testing.T) {
+	records := []Record{
+		{ID: "1", Name: "Toko Maju Jaya", Meta: map[string]string{"website": "majujaya.id", "city": "bandung"}},
+		{ID: "2", Name: "CV Maju Jaya", Meta: map[string]string{"website": "majujaya.id", "city": "Bandung"}},
+		{ID: "3", Name: "Kopi Senja", Meta: map[string]string{"city": "bandung"}},
+	}
+	got := Candidates(records, 0.8, 0)
+	if len(got) != 1 || got[0].A != "1" || got[0].B != "2" {
+		t.Fatalf("Candidates = %+v", got)
+	}
+	if got[0].Score != 1 || len(got[0].Shared) != 2 {
+		t.Errorf("unexpected score or shared fields: %+v", got[0])
+	}
+}
+
+func TestCandidatesUsesEmbeddings(t *testing.T) {
+	records := []Record{
+		{ID: "b", Name: "Kopi Susu 1L", Vector: []float32{1, 0.1}},
+		{ID: "a", Name: "Es Kopi Susu Literan", Vector: []float32{1, 0.12}},
+		{ID: "c", Name: "Kopi Susu 1L Promo"}, // No embedding: only compared lexically
+	}
+	got := Candidates(records, 0.9, 1)
+	if len(got) != 1 || got[0].A != "a" || got[0].B != "b" {
+		t.Fatalf("Candidates = %+v", got)
+	}
+}
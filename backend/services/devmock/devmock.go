@@ -0,0 +1,216 @@
+// Package devmock fakes the external APIs the backend calls, so it can run
+// locally without provider accounts or network access. Answers are
+// deterministic: the same request always gets the same response.
+package devmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/bantuaku/backend/services/exa"
+	"github.com/bantuaku/backend/services/kolosal"
+)
+
+// Model is the model name fake completions and embeddings report
+const Model = "dev-mock"
+
+// EmbeddingDimensions is the length of fake embedding vectors
+const EmbeddingDimensions = 256
+
+// HTTPClient returns a client whose requests are answered by Transport
+func HTTPClient() *http.Client {
+	return &http.Client{Transport: Transport{}}
+}
+
+// Transport answers Kolosal (and OpenRouter-compatible) and Exa API requests
+// in process, whatever host they are sent to
+type Transport struct{}
+
+// RoundTrip implements http.RoundTripper
+func (Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+
+	p := req.URL.Path
+	switch {
+	case strings.HasSuffix(p, kolosal.EndpointChatCompletions) || strings.HasSuffix(p, "/chat/completions"):
+		var r kolosal.ChatCompletionRequest
+		if err := json.Unmarshal(body, &r); err != nil {
+			return respond(req, http.StatusBadRequest, "application/json", errorBody(err))
+		}
+		if r.Stream {
+			return respond(req, http.StatusOK, "text/event-stream", chatStream(r))
+		}
+		return respondJSON(req, ChatCompletion(r))
+	case strings.HasSuffix(p, kolosal.EndpointEmbeddings) || strings.HasSuffix(p, "/embeddings"):
+		var r kolosal.EmbeddingRequest
+		if err := json.Unmarshal(body, &r); err != nil {
+			return respond(req, http.StatusBadRequest, "application/json", errorBody(err))
+		}
+		return respondJSON(req, Embeddings(r))
+	case strings.HasSuffix(p, kolosal.EndpointOCR):
+		return respondJSON(req, kolosal.OCRResponse{Text: "TOKO CONTOH\nKopi Arabica 250g 2 x 85.000\nTOTAL 170.000", Confidence: ptr(0.9)})
+	case strings.HasSuffix(p, kolosal.EndpointOCRForm):
+		return respondJSON(req, kolosal.OCRFormResponse{Fields: map[string]interface{}{"merchant": "Toko Contoh", "total": 170000}})
+	case strings.HasSuffix(p, "/search"):
+		var r exa.SearchRequest
+		if err := json.Unmarshal(body, &r); err != nil {
+			return respond(req, http.StatusBadRequest, "application/json", errorBody(err))
+		}
+		return respondJSON(req, Search(r))
+	}
+	return respond(req, http.StatusNotFound, "application/json", errorBody(fmt.Errorf("devmock: no fake for %s", p)))
+}
+
+// ChatCompletion answers with a fixed reply quoting the last user message.
+// It never calls tools, so callers wanting structured output fall back to
+// their rule-based results.
+func ChatCompletion(r kolosal.ChatCompletionRequest) kolosal.ChatCompletionResponse {
+	content := reply(r)
+	usage := &kolosal.Usage{PromptTokens: promptTokens(r), CompletionTokens: len(content) / 4}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return kolosal.ChatCompletionResponse{
+		Model:   Model,
+		Choices: []kolosal.ChatChoice{{Message: kolosal.ChatCompletionMessage{Role: "assistant", Content: content}}},
+		Usage:   usage,
+	}
+}
+
+// Embeddings hashes the words of each input into a unit vector, so texts
+// sharing words are similar
+func Embeddings(r kolosal.EmbeddingRequest) kolosal.EmbeddingResponse {
+	var resp kolosal.EmbeddingResponse
+	for i, text := range r.Input {
+		vector := make([]float32, EmbeddingDimensions)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			h.Write([]byte(strings.Trim(word, ".,;:!?\"'()")))
+			vector[h.Sum32()%EmbeddingDimensions]++
+		}
+		normalize(vector)
+		resp.Data = append(resp.Data, struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}{Index: i, Embedding: vector})
+		resp.Usage.TotalTokens += len(text) / 4
+	}
+	return resp
+}
+
+// Search returns three articles about the query
+func Search(r exa.SearchRequest) exa.SearchResponse {
+	n := r.NumResults
+	if n <= 0 || n > 3 {
+		n = 3
+	}
+	h := fnv.New32a()
+	h.Write([]byte(r.Query))
+	id := h.Sum32()
+	var resp exa.SearchResponse
+	for i := 0; i < n; i++ {
+		resp.Results = append(resp.Results, exa.SearchResult{
+			ID:            fmt.Sprintf("mock-%08x-%d", id, i+1),
+			URL:           fmt.Sprintf("https://example.com/berita/%08x-%d", id, i+1),
+			Title:         fmt.Sprintf("Contoh berita %d: %s", i+1, r.Query),
+			PublishedDate: fmt.Sprintf("2024-01-%02dT00:00:00Z", i+1),
+			Text:          fmt.Sprintf("Artikel contoh dari mode pengembangan tentang %s. Permintaan pasar stabil dengan kenaikan menjelang akhir bulan.", r.Query),
+		})
+	}
+	return resp
+}
+
+// reply is the fake answer to a chat request
+func reply(r kolosal.ChatCompletionRequest) string {
+	question := ""
+	for i := len(r.Messages) - 1; i >= 0; i-- {
+		if r.Messages[i].Role == "user" {
+			question = r.Messages[i].Content
+			break
+		}
+	}
+	if len(question) > 200 {
+		question = question[:200] + "..."
+	}
+	return fmt.Sprintf("Ini jawaban contoh dari mode pengembangan (DEV_MOCKS), bukan dari AI. Pertanyaan: %q", question)
+}
+
+// promptTokens estimates the prompt size like providers count it
+func promptTokens(r kolosal.ChatCompletionRequest) int {
+	n := 0
+	for _, m := range r.Messages {
+		n += len(m.Content) / 4
+	}
+	return n
+}
+
+// chatStream sends the reply as server-sent events, a few words at a time
+func chatStream(r kolosal.ChatCompletionRequest) []byte {
+	var buf bytes.Buffer
+	words := strings.SplitAfter(reply(r), " ")
+	for i := 0; i < len(words); i += 4 {
+		piece := strings.Join(words[i:min(i+4, len(words))], "")
+		chunk, _ := json.Marshal(map[string]interface{}{
+			"model":   Model,
+			"choices": []map[string]interface{}{{"delta": map[string]string{"content": piece}}},
+		})
+		fmt.Fprintf(&buf, "data: %s\n\n", chunk)
+	}
+	buf.WriteString("data: [DONE]\n\n")
+	return buf.Bytes()
+}
+
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		v[0] = 1
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+func respondJSON(req *http.Request, v interface{}) (*http.Response, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return respond(req, http.StatusOK, "application/json", body)
+}
+
+func respond(req *http.Request, status int, contentType string, body []byte) (*http.Response, error) {
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:        http.Header{"Content-Type": {contentType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+	}, nil
+}
+
+func errorBody(err error) []byte {
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return body
+}
+
+func ptr(f float64) *float64 { return &f }
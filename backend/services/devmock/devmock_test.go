@@ -0,0 +1,78 @@
+package devmock
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bantuaku/backend/services/exa"
+	"github.com/bantuaku/backend/services/kolosal"
+)
+
+func mockClient() *kolosal.Client {
+	c := kolosal.NewClient("dev-mock")
+	c.HTTPClient = HTTPClient()
+	return c
+}
+
+func TestChatCompletion(t *testing.T) {
+	ctx := context.Background()
+	req := kolosal.ChatCompletionRequest{
+		Model:    kolosal.DefaultModel,
+		Messages: []kolosal.ChatCompletionMessage{{Role: "user", Content: "Berapa stok kopi?"}},
+	}
+	resp, err := mockClient().CreateChatCompletion(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer := resp.Choices[0].Message.Content
+	if !strings.Contains(answer, "Berapa stok kopi?") {
+		t.Errorf("answer %q should quote the question", answer)
+	}
+
+	var streamed strings.Builder
+	_, err = mockClient().CreateChatCompletionStream(ctx, req, func(content string) error {
+		streamed.WriteString(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed.String() != answer {
+		t.Errorf("streamed %q, want %q", streamed.String(), answer)
+	}
+}
+
+func TestEmbeddingsAreDeterministic(t *testing.T) {
+	ctx := context.Background()
+	req := kolosal.EmbeddingRequest{Model: "m", Input: []string{"kopi arabica", "kopi arabica", "pajak usaha"}}
+	resp, err := mockClient().CreateEmbeddings(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 3 || len(resp.Data[0].Embedding) != EmbeddingDimensions {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	dot := func(a, b []float32) (s float32) {
+		for i := range a {
+			s += a[i] * b[i]
+		}
+		return s
+	}
+	same, other := dot(resp.Data[0].Embedding, resp.Data[1].Embedding), dot(resp.Data[0].Embedding, resp.Data[2].Embedding)
+	if same < 0.999 || other >= same {
+		t.Errorf("similarity of equal texts %v, of different texts %v", same, other)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	c := exa.NewClient("dev-mock")
+	c.HTTPClient = HTTPClient()
+	resp, err := c.Search(context.Background(), exa.SearchRequest{Query: "harga kopi", NumResults: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 3 || !strings.Contains(resp.Results[0].Title, "harga kopi") {
+		t.Errorf("unexpected results %+v", resp.Results)
+	}
+}
@@ -0,0 +1,163 @@
+package docparse
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Supported document kinds
+const (
+	KindCSV  = "csv"
+	KindXLSX = "xlsx"
+	KindDOCX = "docx"
+	KindPDF  = "pdf"
+
+	KindImage = "image" // Photos of receipts and documents; read by OCR, not Parse
+)
+
+// Extraction limits keep parse results small enough to store and prompt with
+const (
+	MaxTextLength = 200000  // Runes of extracted text
+	MaxTableRows  = 5000    // Rows kept per table
+	MaxCells      = 500000  // Spreadsheet cells kept across all sheets, counting empty padding
+	MaxCellBytes  = 8 << 20 // Bytes of spreadsheet cell text kept across all sheets
+)
+
+// ErrUnsupported is returned for document kinds without a parser
+var ErrUnsupported = errors.New("unsupported document type")
+
+// Table is a grid of cell text, e.g. one spreadsheet sheet or one document table
+type Table struct {
+	Name string     `json:"name,omitempty"`
+	Rows [][]string `json:"rows"`
+}
+
+// Result is the content extracted from one document
+type Result struct {
+	Parser    string  `json:"parser"`
+	Text      string  `json:"text"`
+	Tables    []Table `json:"tables"`
+	Truncated bool    `json:"truncated"` // Text or tables were cut at the extraction limits
+}
+
+// KindFromFilename returns the document kind of a file name, or "" if unsupported
+func KindFromFilename(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return KindCSV
+	case ".xlsx":
+		return KindXLSX
+	case ".docx":
+		return KindDOCX
+	case ".pdf":
+		return KindPDF
+	}
+	return ""
+}
+
+// Parse extracts text and tables from a document
+func Parse(ctx context.Context, kind string, data []byte) (*Result, error) {
+	var res *Result
+	var err error
+	switch kind {
+	case KindCSV:
+		res, err = parseCSV(data)
+	case KindXLSX:
+		res, err = parseXLSX(ctx, data)
+	case KindDOCX:
+		res, err = parseDOCX(ctx, data)
+	case KindPDF:
+		res, err = parsePDF(ctx, data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.limit()
+	return res, nil
+}
+
+// cellBudget tracks how much of MaxCells and MaxCellBytes a spreadsheet has used
+type cellBudget struct {
+	cells int
+	bytes int
+}
+
+// add reports whether a row of cells still fits in the budget
+func (b *cellBudget) add(row []string) bool {
+	b.cells += len(row)
+	for _, c := range row {
+		b.bytes += len(c)
+	}
+	return b.cells <= MaxCells && b.bytes <= MaxCellBytes
+}
+
+// limit applies the extraction limits
+func (r *Result) limit() {
+	if runes := []rune(r.Text); len(runes) > MaxTextLength {
+		r.Text = string(runes[:MaxTextLength])
+		r.Truncated = true
+	}
+	for i := range r.Tables {
+		if len(r.Tables[i].Rows) > MaxTableRows {
+			r.Tables[i].Rows = r.Tables[i].Rows[:MaxTableRows]
+			r.Truncated = true
+		}
+	}
+	if r.Tables == nil {
+		r.Tables = []Table{}
+	}
+}
+
+// TableText renders rows as tab-separated lines
+func TableText(rows [][]string) string {
+	var b strings.Builder
+	for _, row := range rows {
+		b.WriteString(strings.Join(row, "\t"))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// parseCSV reads a comma, semicolon or tab separated file as one table
+func parseCSV(data []byte) (*Result, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = csvDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv: %w", err)
+		}
+		rows = append(rows, record)
+	}
+	return &Result{Parser: "csv", Text: TableText(rows), Tables: []Table{{Rows: rows}}}, nil
+}
+
+// csvDelimiter picks the most frequent candidate delimiter in the first line
+func csvDelimiter(data []byte) rune {
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+	best, bestCount := ',', 0
+	for _, d := range []rune{',', ';', '\t'} {
+		if n := bytes.Count(line, []byte(string(d))); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,143 @@
+package docparse
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// officeFile builds an in-memory zip archive from part names and contents
+func officeFile(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseXLSX(t *testing.T) {
+	data := officeFile(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="Penjualan" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       `<sst><si><t>Tanggal</t></si><si><t>Produk</t></si><si><r><t>Kopi </t></r><r><t>Susu</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+			<row r="2"><c r="A2"><v>45292</v></c><c r="C2" t="s"><v>2</v></c><c r="D2" t="inlineStr"><is><t>catatan</t></is></c></row>
+		</sheetData></worksheet>`,
+	})
+
+	res, err := Parse(context.Background(), KindXLSX, data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(res.Tables) != 1 || res.Tables[0].Name != "Penjualan" {
+		t.Fatalf("tables = %+v", res.Tables)
+	}
+	want := [][]string{{"Tanggal", "", "Produk"}, {"45292", "", "Kopi Susu", "catatan"}}
+	if !reflect.DeepEqual(res.Tables[0].Rows, want) {
+		t.Errorf("rows = %q, want %q", res.Tables[0].Rows, want)
+	}
+	if !strings.Contains(res.Text, "# Penjualan\nTanggal\t\tProduk\n") {
+		t.Errorf("text = %q", res.Text)
+	}
+}
+
+func TestParseDOCX(t *testing.T) {
+	data := officeFile(t, map[string]string{
+		"word/document.xml": `<w:document xmlns:w="w"><w:body>
+			<w:p><w:pPr><w:tabs><w:tab w:val="left"/></w:tabs></w:pPr><w:r><w:t>Laporan</w:t></w:r><w:r><w:tab/><w:t>Mei</w:t></w:r></w:p>
+			<w:tbl>
+				<w:tr><w:tc><w:p><w:r><w:t>Produk</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Qty</w:t></w:r></w:p></w:tc></w:tr>
+				<w:tr><w:tc><w:p><w:r><w:t>Kopi</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>12</w:t></w:r></w:p></w:tc></w:tr>
+			</w:tbl>
+			<w:p><w:r><w:t>Selesai</w:t></w:r></w:p>
+		</w:body></w:document>`,
+	})
+
+	res, err := Parse(context.Background(), KindDOCX, data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if want := "Laporan\tMei\nProduk\tQty\nKopi\t12\nSelesai\n"; res.Text != want {
+		t.Errorf("text = %q, want %q", res.Text, want)
+	}
+	if len(res.Tables) != 1 || !reflect.DeepEqual(res.Tables[0].Rows, [][]string{{"Produk", "Qty"}, {"Kopi", "12"}}) {
+		t.Errorf("tables = %+v", res.Tables)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	res, err := Parse(context.Background(), KindCSV, []byte("tanggal;produk\n2026-05-01;Kopi\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !reflect.DeepEqual(res.Tables[0].Rows, [][]string{{"tanggal", "produk"}, {"2026-05-01", "Kopi"}}) {
+		t.Errorf("rows = %q", res.Tables[0].Rows)
+	}
+}
+
+func TestKindFromFilename(t *testing.T) {
+	for name, want := range map[string]string{"a.XLSX": KindXLSX, "b.docx": KindDOCX, "c.pdf": KindPDF, "d.xls": ""} {
+		if got := KindFromFilename(name); got != want {
+			t.Errorf("KindFromFilename(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if _, err := Parse(context.Background(), "xls", nil); err == nil {
+		t.Error("unsupported kind should fail")
+	}
+}
+
+func TestParseXLSXLimitsCells(t *testing.T) {
+	// Each row reaches the last column, so it counts as 16384 cells
+	var sheet strings.Builder
+	sheet.WriteString("<worksheet><sheetData>")
+	for i := 1; i <= 40; i++ {
+		sheet.WriteString(`<row><c r="A1" t="inlineStr"><is><t>x</t></is></c><c r="XFD1"><v>1</v></c></row>`)
+	}
+	sheet.WriteString("</sheetData></worksheet>")
+	data := officeFile(t, map[string]string{
+		"xl/workbook.xml":          `<workbook><sheets><sheet name="Lebar"/><sheet name="Kedua"/></sheets></workbook>`,
+		"xl/worksheets/sheet1.xml": sheet.String(),
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData><row><c r="A1"><v>2</v></c></row></sheetData></worksheet>`,
+	})
+
+	res, err := Parse(context.Background(), KindXLSX, data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !res.Truncated {
+		t.Error("expected the result to be marked truncated")
+	}
+	if len(res.Tables) != 1 || len(res.Tables[0].Rows) != MaxCells/16384 {
+		t.Errorf("got %d tables, first with %d rows", len(res.Tables), len(res.Tables[0].Rows))
+	}
+}
+
+func TestParseStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	docx := officeFile(t, map[string]string{"word/document.xml": `<w:document xmlns:w="w"><w:body/></w:document>`})
+	if _, err := Parse(ctx, KindDOCX, docx); err != context.Canceled {
+		t.Errorf("docx: err = %v, want context.Canceled", err)
+	}
+	xlsx := officeFile(t, map[string]string{
+		"xl/workbook.xml":          `<workbook><sheets><sheet name="A"/></sheets></workbook>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData/></worksheet>`,
+	})
+	if _, err := Parse(ctx, KindXLSX, xlsx); err != context.Canceled {
+		t.Errorf("xlsx: err = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,341 @@
+package docparse
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Office documents are zip archives of XML parts; only the parts holding text
+// are read, without evaluating formulas or styles
+
+// maxPartSize bounds a single decompressed archive part
+const maxPartSize = 50 << 20
+
+// readPart returns a part of an office archive, or nil if it is absent
+func readPart(archive *zip.Reader, name string) ([]byte, error) {
+	for _, f := range archive.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(io.LimitReader(rc, maxPartSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxPartSize {
+			return nil, fmt.Errorf("%s is too large", name)
+		}
+		return data, nil
+	}
+	return nil, nil
+}
+
+// parseXLSX extracts every sheet as a table, stopping at MaxCells or
+// MaxCellBytes across the workbook
+func parseXLSX(ctx context.Context, data []byte) (*Result, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open xlsx: %w", err)
+	}
+
+	shared, err := xlsxSharedStrings(archive)
+	if err != nil {
+		return nil, err
+	}
+	sheets, err := xlsxSheets(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Parser: "xlsx"}
+	var text strings.Builder
+	budget := &cellBudget{}
+	for _, sheet := range sheets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		part, err := readPart(archive, sheet.path)
+		if err != nil || part == nil {
+			continue
+		}
+		rows, full, err := xlsxRows(part, shared, budget)
+		if err != nil {
+			return nil, fmt.Errorf("read sheet %s: %w", sheet.name, err)
+		}
+		res.Tables = append(res.Tables, Table{Name: sheet.name, Rows: rows})
+		text.WriteString("# " + sheet.name + "\n")
+		text.WriteString(TableText(rows))
+		if full {
+			res.Truncated = true
+			break
+		}
+	}
+	res.Text = text.String()
+	return res, nil
+}
+
+type xlsxSheet struct {
+	name string
+	path string
+}
+
+// xlsxSheets lists sheets in workbook order with their part paths
+func xlsxSheets(archive *zip.Reader) ([]xlsxSheet, error) {
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+
+	part, err := readPart(archive, "xl/workbook.xml")
+	if err != nil || part == nil {
+		return nil, fmt.Errorf("xlsx has no workbook")
+	}
+	if err := xml.Unmarshal(part, &workbook); err != nil {
+		return nil, fmt.Errorf("read workbook: %w", err)
+	}
+	if part, _ := readPart(archive, "xl/_rels/workbook.xml.rels"); part != nil {
+		xml.Unmarshal(part, &rels)
+	}
+
+	targets := map[string]string{}
+	for _, r := range rels.Relationships {
+		target := strings.TrimPrefix(r.Target, "/")
+		if !strings.HasPrefix(target, "xl/") {
+			target = path.Join("xl", target)
+		}
+		targets[r.ID] = target
+	}
+
+	sheets := make([]xlsxSheet, 0, len(workbook.Sheets))
+	for i, s := range workbook.Sheets {
+		p, ok := targets[s.RID]
+		if !ok {
+			p = fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		}
+		sheets = append(sheets, xlsxSheet{name: s.Name, path: p})
+	}
+	return sheets, nil
+}
+
+// xlsxSharedStrings reads the shared string table cells refer to by index
+func xlsxSharedStrings(archive *zip.Reader) ([]string, error) {
+	part, err := readPart(archive, "xl/sharedStrings.xml")
+	if err != nil || part == nil {
+		return nil, err
+	}
+	var sst struct {
+		Items []struct {
+			T    string `xml:"t"`
+			Runs []struct {
+				T string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := xml.Unmarshal(part, &sst); err != nil {
+		return nil, fmt.Errorf("read shared strings: %w", err)
+	}
+	out := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		s := si.T
+		for _, r := range si.Runs {
+			s += r.T
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// xlsxRows reads a worksheet into rows, placing cells by their column
+// reference. It reports whether the budget ran out, in which case the row that
+// did not fit is left out.
+func xlsxRows(part []byte, shared []string, budget *cellBudget) ([][]string, bool, error) {
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(part, &sheet); err != nil {
+		return nil, false, err
+	}
+
+	rows := [][]string{}
+	for _, r := range sheet.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			col := columnIndex(c.Ref)
+			if col < 0 {
+				col = i
+			}
+			if col > 16383 {
+				continue
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			switch c.Type {
+			case "s":
+				if n, err := strconv.Atoi(c.Value); err == nil && n >= 0 && n < len(shared) {
+					row[col] = shared[n]
+				}
+			case "inlineStr":
+				row[col] = c.Inline
+			case "b":
+				row[col] = map[string]string{"1": "TRUE", "0": "FALSE"}[c.Value]
+			default:
+				row[col] = c.Value
+			}
+		}
+		if len(row) == 0 {
+			continue
+		}
+		if !budget.add(row) {
+			return rows, true, nil
+		}
+		rows = append(rows, row)
+		if len(rows) > MaxTableRows {
+			break
+		}
+	}
+	return rows, false, nil
+}
+
+// columnIndex converts the letters of a cell reference like "C7" to a zero-based column
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
+
+// parseDOCX extracts paragraphs as text and tables as tables
+func parseDOCX(ctx context.Context, data []byte) (*Result, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open docx: %w", err)
+	}
+	part, err := readPart(archive, "word/document.xml")
+	if err != nil || part == nil {
+		return nil, fmt.Errorf("docx has no document body")
+	}
+
+	res := &Result{Parser: "docx"}
+	var text, para strings.Builder
+	var table [][]string
+	var row []string
+	var cell []string
+	tableDepth := 0
+	inTabStops := false // <w:tabs> in paragraph properties also holds <w:tab> elements
+
+	decoder := xml.NewDecoder(bytes.NewReader(part))
+	for n := 0; ; n++ {
+		if n%10000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read document: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "tbl":
+				tableDepth++
+				if tableDepth == 1 {
+					table = nil
+				}
+			case "tr":
+				if tableDepth == 1 {
+					row = nil
+				}
+			case "tc":
+				if tableDepth == 1 {
+					cell = nil
+				}
+			case "t":
+				var s string
+				if err := decoder.DecodeElement(&s, &t); err == nil {
+					para.WriteString(s)
+				}
+			case "tabs":
+				inTabStops = true
+			case "tab":
+				if !inTabStops {
+					para.WriteByte('\t')
+				}
+			case "br", "cr":
+				para.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "tabs":
+				inTabStops = false
+			case "p":
+				p := strings.TrimSpace(para.String())
+				para.Reset()
+				if tableDepth > 0 {
+					if p != "" {
+						cell = append(cell, p)
+					}
+				} else if p != "" {
+					text.WriteString(p + "\n")
+				}
+			case "tc":
+				if tableDepth == 1 {
+					row = append(row, strings.Join(cell, " "))
+				}
+			case "tr":
+				if tableDepth == 1 {
+					table = append(table, row)
+				}
+			case "tbl":
+				if tableDepth == 1 && len(table) > 0 {
+					res.Tables = append(res.Tables, Table{Name: fmt.Sprintf("Tabel %d", len(res.Tables)+1), Rows: table})
+					text.WriteString(TableText(table))
+				}
+				tableDepth--
+			}
+		}
+	}
+
+	res.Text = text.String()
+	return res, nil
+}
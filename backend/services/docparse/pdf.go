@@ -0,0 +1,95 @@
+package docparse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrPDFToolMissing is returned when pdftotext (poppler-utils) is not installed
+var ErrPDFToolMissing = errors.New("pdftotext is not installed")
+
+// pdfTimeout bounds one pdftotext run
+const pdfTimeout = 30 * time.Second
+
+// parsePDF extracts the text layer of a PDF with pdftotext, keeping the page
+// layout so columns of tabular reports stay aligned. Scanned PDFs without a
+// text layer yield empty text.
+func parsePDF(ctx context.Context, data []byte) (*Result, error) {
+	bin, err := exec.LookPath("pdftotext")
+	if err != nil {
+		return nil, ErrPDFToolMissing
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pdfTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, "-layout", "-enc", "UTF-8", "-", "-")
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdftotext: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// Pages are separated by form feeds
+	text := strings.ReplaceAll(stdout.String(), "\f", "\n")
+	return &Result{Parser: "pdftotext", Text: strings.TrimSpace(text)}, nil
+}
+
+// ErrRasterToolMissing is returned when pdftoppm (poppler-utils) is not installed
+var ErrRasterToolMissing = errors.New("pdftoppm is not installed")
+
+// rasterDPI is the resolution pages are rendered at for OCR
+const rasterDPI = 200
+
+// RenderPages renders up to maxPages pages of a PDF to PNG images with
+// pdftoppm, for OCR engines that only read images
+func RenderPages(ctx context.Context, data []byte, maxPages int) ([][]byte, error) {
+	bin, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		return nil, ErrRasterToolMissing
+	}
+
+	dir, err := os.MkdirTemp("", "docparse-pages-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(ctx, pdfTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, "-png", "-r", strconv.Itoa(rasterDPI), "-l", strconv.Itoa(maxPages), "-", filepath.Join(dir, "page"))
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdftoppm: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	// Pages are written as page-1.png, page-01.png... so sorted names are in page order
+	names, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	pages := make([][]byte, 0, len(names))
+	for _, name := range names {
+		png, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, png)
+	}
+	return pages, nil
+}
@@ -0,0 +1,48 @@
+package dormancy
+
+import "time"
+
+// Company lifecycle statuses, stored in companies.status
+const (
+	StatusActive   = "active"
+	StatusArchived = "archived" // Data kept, but skipped by scheduled jobs, benchmarks and stats
+)
+
+// Decision is what a scan should do with a company
+type Decision int
+
+const (
+	Keep    Decision = iota // Nothing to change
+	Flag                    // Inactive for too long: mark dormant and warn the owner
+	Archive                 // Dormant past the grace period
+	Clear                   // Active again after being flagged
+)
+
+// Policy decides when inactive companies are flagged and archived
+type Policy struct {
+	InactiveMonths int // Months without activity before a company is flagged dormant
+	GraceDays      int // Days between flagging and archiving
+}
+
+// Decide returns the decision for a company last active at lastActive and
+// flagged dormant at dormantAt (nil when not flagged)
+func (p Policy) Decide(lastActive time.Time, dormantAt *time.Time, now time.Time) Decision {
+	if dormantAt != nil {
+		if lastActive.After(*dormantAt) {
+			return Clear
+		}
+		if !now.Before(p.ArchiveAt(*dormantAt)) {
+			return Archive
+		}
+		return Keep
+	}
+	if lastActive.Before(now.AddDate(0, -p.InactiveMonths, 0)) {
+		return Flag
+	}
+	return Keep
+}
+
+// ArchiveAt is when a company flagged at dormantAt gets archived
+func (p Policy) ArchiveAt(dormantAt time.Time) time.Time {
+	return dormantAt.AddDate(0, 0, p.GraceDays)
+}
@@ -0,0 +1,31 @@
+package dormancy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecide(t *testing.T) {
+	p := Policy{InactiveMonths: 6, GraceDays: 30}
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	flagged := now.AddDate(0, 0, -10)
+	longFlagged := now.AddDate(0, 0, -30)
+
+	cases := []struct {
+		name       string
+		lastActive time.Time
+		dormantAt  *time.Time
+		want       Decision
+	}{
+		{"recently active", now.AddDate(0, -1, 0), nil, Keep},
+		{"inactive", now.AddDate(0, -7, 0), nil, Flag},
+		{"flagged within grace", now.AddDate(0, -7, 0), &flagged, Keep},
+		{"grace over", now.AddDate(0, -8, 0), &longFlagged, Archive},
+		{"active after flag", now.AddDate(0, 0, -1), &flagged, Clear},
+	}
+	for _, c := range cases {
+		if got := p.Decide(c.lastActive, c.dormantAt, now); got != c.want {
+			t.Errorf("%s: got %d, want %d", c.name, got, c.want)
+		}
+	}
+}
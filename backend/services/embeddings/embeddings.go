@@ -0,0 +1,129 @@
+package embeddings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Chunking defaults, in characters
+const (
+	DefaultChunkSize    = 1200
+	DefaultChunkOverlap = 200
+)
+
+// Chunk splits text into overlapping chunks of at most size characters,
+// preferring to break on paragraph, sentence and word boundaries
+func Chunk(text string, size, overlap int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if size <= 0 {
+		size = DefaultChunkSize
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	runes := []rune(text)
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			chunks = append(chunks, strings.TrimSpace(string(runes[start:])))
+			break
+		}
+		end = breakPoint(runes, start+size/2, end)
+		chunks = append(chunks, strings.TrimSpace(string(runes[start:end])))
+
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// breakPoint finds the best boundary in runes[min:max], falling back to max
+func breakPoint(runes []rune, min, max int) int {
+	for _, sep := range []string{"\n\n", ". ", "\n", " "} {
+		sepRunes := []rune(sep)
+		for i := max - len(sepRunes); i >= min; i-- {
+			if string(runes[i:i+len(sepRunes)]) == sep {
+				return i + len(sepRunes)
+			}
+		}
+	}
+	return max
+}
+
+// ContentHash identifies chunk content independent of case and whitespace, so
+// re-fetched documents with cosmetic differences reuse their embeddings
+func ContentHash(content string) string {
+	normalized := strings.Join(strings.FieldsFunc(strings.ToLower(content), unicode.IsSpace), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// MissingHashes returns the distinct hashes that are not in known, in first-seen order
+func MissingHashes(hashes []string, known map[string]bool) []string {
+	seen := map[string]bool{}
+	var missing []string
+	for _, h := range hashes {
+		if known[h] || seen[h] {
+			continue
+		}
+		seen[h] = true
+		missing = append(missing, h)
+	}
+	return missing
+}
+
+// EstimateTokens approximates token usage for texts that were not sent to the provider
+func EstimateTokens(text string) int {
+	return (len([]rune(text)) + 3) / 4
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if they differ in length
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// Scored is a candidate's position in the input slice and its similarity to the query
+type Scored struct {
+	Index int
+	Score float64
+}
+
+// TopK returns the k candidates most similar to query, best first, skipping
+// candidates below minScore
+func TopK(query []float32, candidates [][]float32, k int, minScore float64) []Scored {
+	var scored []Scored
+	for i, c := range candidates {
+		if s := Cosine(query, c); s >= minScore {
+			scored = append(scored, Scored{Index: i, Score: s})
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if k > 0 && len(scored) > k {
+		scored = scored[:k]
+	}
+	return scored
+}
@@ -0,0 +1,62 @@
+package embeddings
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunk(t *testing.T) {
+	text := strings.Repeat("Pelaku usaha wajib memiliki NIB. ", 100)
+	chunks := Chunk(text, 300, 50)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if len([]rune(c)) > 300 {
+			t.Errorf("chunk %d has %d characters", i, len([]rune(c)))
+		}
+		if !strings.HasSuffix(c, ".") && i < len(chunks)-1 {
+			t.Errorf("chunk %d should end on a sentence boundary: %q", i, c[len(c)-20:])
+		}
+	}
+
+	if got := Chunk("  ", 300, 50); got != nil {
+		t.Errorf("Chunk of blank text = %v, want nil", got)
+	}
+}
+
+func TestContentHashIgnoresCaseAndWhitespace(t *testing.T) {
+	a := ContentHash("Izin  PIRT\nberlaku 5 tahun")
+	b := ContentHash("izin pirt berlaku 5 tahun ")
+	if a != b {
+		t.Error("hashes should match for cosmetically different content")
+	}
+	if a == ContentHash("izin pirt berlaku 3 tahun") {
+		t.Error("hashes should differ for different content")
+	}
+}
+
+func TestMissingHashes(t *testing.T) {
+	got := MissingHashes([]string{"a", "b", "a", "c"}, map[string]bool{"b": true})
+	if strings.Join(got, ",") != "a,c" {
+		t.Errorf("MissingHashes = %v, want [a c]", got)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	if got := Cosine([]float32{1, 0}, []float32{1, 0}); got != 1 {
+		t.Errorf("Cosine of equal vectors = %v", got)
+	}
+	if got := Cosine([]float32{1, 0}, []float32{0, 1}); got != 0 {
+		t.Errorf("Cosine of orthogonal vectors = %v", got)
+	}
+}
+
+func TestTopK(t *testing.T) {
+	query := []float32{1, 0}
+	candidates := [][]float32{{0, 1}, {1, 1}, {1, 0}, {-1, 0}}
+	got := TopK(query, candidates, 2, 0)
+	if len(got) != 2 || got[0].Index != 2 || got[1].Index != 1 {
+		t.Errorf("TopK = %+v, want indexes [2 1]", got)
+	}
+}
@@ -0,0 +1,44 @@
+package embeddings
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Shared namespaces. Company documents live in per-company namespaces, see CompanyDocsNamespace.
+const (
+	NamespaceRegulations    = "regulations"
+	NamespaceMarketResearch = "market_research"
+	NamespaceDuplicates     = "duplicates"     // Company and product names compared for duplicate detection and analog forecasts
+	NamespaceKBLI           = "kbli"           // KBLI titles and free-text industries mapped onto them
+	NamespaceKnowledgeBase  = "knowledge_base" // Curated articles written by admins
+)
+
+var companyNamespacePattern = regexp.MustCompile(`^company:[0-9a-zA-Z-]{1,36}:docs$`)
+
+// CompanyDocsNamespace returns the namespace holding a company's own documents
+func CompanyDocsNamespace(companyID string) string {
+	return fmt.Sprintf("company:%s:docs", companyID)
+}
+
+// ValidateNamespace reports whether namespace is a known shared namespace or a
+// well-formed company namespace
+func ValidateNamespace(namespace string) error {
+	switch {
+	case namespace == NamespaceRegulations, namespace == NamespaceMarketResearch, namespace == NamespaceDuplicates,
+		namespace == NamespaceKBLI, namespace == NamespaceKnowledgeBase:
+		return nil
+	case companyNamespacePattern.MatchString(namespace):
+		return nil
+	}
+	return fmt.Errorf("unknown namespace %q", namespace)
+}
+
+// NamespaceCompanyID returns the company owning namespace, or "" for shared namespaces
+func NamespaceCompanyID(namespace string) string {
+	if !companyNamespacePattern.MatchString(namespace) {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(namespace, "company:"), ":docs")
+}
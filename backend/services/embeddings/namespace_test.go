@@ -0,0 +1,24 @@
+package embeddings
+
+import "testing"
+
+func TestValidateNamespace(t *testing.T) {
+	companyNS := CompanyDocsNamespace("3f1c2a9e-0000-4000-8000-000000000001")
+	for _, ns := range []string{NamespaceRegulations, NamespaceMarketResearch, companyNS} {
+		if err := ValidateNamespace(ns); err != nil {
+			t.Errorf("ValidateNamespace(%q) = %v", ns, err)
+		}
+	}
+	for _, ns := range []string{"", "company::docs", "company:x:files", "other"} {
+		if ValidateNamespace(ns) == nil {
+			t.Errorf("ValidateNamespace(%q) should fail", ns)
+		}
+	}
+
+	if got := NamespaceCompanyID(companyNS); got != "3f1c2a9e-0000-4000-8000-000000000001" {
+		t.Errorf("NamespaceCompanyID = %q", got)
+	}
+	if got := NamespaceCompanyID(NamespaceRegulations); got != "" {
+		t.Errorf("NamespaceCompanyID(regulations) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,117 @@
+package exa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	ExaAPIBaseURL  = "https://api.exa.ai"
+	DefaultTimeout = 30 * time.Second
+)
+
+// Client represents an Exa search API client
+type Client struct {
+	APIKey     string
+	HTTPClient *http.Client
+	BaseURL    string
+}
+
+// APIError is a non-200 response from the Exa API
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %d - %s", e.StatusCode, e.Body)
+}
+
+// Transient reports whether the request may succeed if retried
+func (e *APIError) Transient() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// NewClient creates a new Exa API client
+func NewClient(apiKey string) *Client {
+	return &Client{
+		APIKey: apiKey,
+		HTTPClient: &http.Client{
+			Timeout: DefaultTimeout,
+		},
+		BaseURL: ExaAPIBaseURL,
+	}
+}
+
+// SearchRequest represents a search request
+type SearchRequest struct {
+	Query              string          `json:"query"`
+	NumResults         int             `json:"numResults,omitempty"`
+	StartPublishedDate string          `json:"startPublishedDate,omitempty"` // ISO 8601
+	Contents           *SearchContents `json:"contents,omitempty"`
+}
+
+// SearchContents selects which page contents are returned with each result
+type SearchContents struct {
+	Text *TextOptions `json:"text,omitempty"`
+}
+
+// TextOptions limits the returned page text
+type TextOptions struct {
+	MaxCharacters int `json:"maxCharacters,omitempty"`
+}
+
+// SearchResponse represents a search response
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+}
+
+// SearchResult is a single page returned by search
+type SearchResult struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	PublishedDate string `json:"publishedDate,omitempty"`
+	Text          string `json:"text,omitempty"`
+}
+
+// Search calls the Exa search API
+func (c *Client) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	url := fmt.Sprintf("%s/search", c.BaseURL)
+
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.APIKey)
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var searchResp SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &searchResp, nil
+}
@@ -0,0 +1,71 @@
+package exportjob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// Export kinds
+const (
+	KindSales         = "sales"         // Sales history as CSV
+	KindConversations = "conversations" // Chat messages as JSON lines
+	KindAccount       = "account"       // Full company snapshot as gzipped JSON
+)
+
+// Job statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusExpired   = "expired" // Artifact deleted after the retention period
+)
+
+// Format describes the artifact produced for a kind
+type Format struct {
+	Name        string // Stored on the job, e.g. "csv"
+	Extension   string // File name suffix, including the leading dot
+	ContentType string
+}
+
+var formats = map[string]Format{
+	KindSales:         {Name: "csv", Extension: ".csv", ContentType: "text/csv; charset=utf-8"},
+	KindConversations: {Name: "jsonl", Extension: ".jsonl", ContentType: "application/x-ndjson"},
+	KindAccount:       {Name: "json.gz", Extension: ".json.gz", ContentType: "application/gzip"},
+}
+
+// Kinds lists the supported export kinds
+func Kinds() []string {
+	return []string{KindSales, KindConversations, KindAccount}
+}
+
+// FormatFor returns the artifact format for kind and whether kind is supported
+func FormatFor(kind string) (Format, bool) {
+	f, ok := formats[kind]
+	return f, ok
+}
+
+// Sign returns the signature for a download link to job id that is valid until expires
+func Sign(secret, id string, expires time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("export:" + id + ":" + strconv.FormatInt(expires.Unix(), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a download link signature and that the link has not expired.
+// expires is the Unix timestamp carried in the link.
+func Verify(secret, id, expires, signature string, now time.Time) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return false
+	}
+	at := time.Unix(unix, 0)
+	if !now.Before(at) {
+		return false
+	}
+	expected := Sign(secret, id, at)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -0,0 +1,44 @@
+package exportjob
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	expires := now.Add(15 * time.Minute)
+	sig := Sign("secret", "job-1", expires)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+
+	if !Verify("secret", "job-1", exp, sig, now) {
+		t.Fatal("valid link rejected")
+	}
+	if Verify("secret", "job-2", exp, sig, now) {
+		t.Error("signature accepted for another job")
+	}
+	if Verify("other", "job-1", exp, sig, now) {
+		t.Error("signature accepted with another secret")
+	}
+	if Verify("secret", "job-1", strconv.FormatInt(expires.Add(time.Hour).Unix(), 10), sig, now) {
+		t.Error("signature accepted with extended expiry")
+	}
+	if Verify("secret", "job-1", exp, sig, expires) {
+		t.Error("expired link accepted")
+	}
+	if Verify("secret", "job-1", "soon", sig, now) {
+		t.Error("malformed expiry accepted")
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+	for _, kind := range Kinds() {
+		if _, ok := FormatFor(kind); !ok {
+			t.Errorf("no format for %s", kind)
+		}
+	}
+	if _, ok := FormatFor("invoices"); ok {
+		t.Error("unknown kind has a format")
+	}
+}
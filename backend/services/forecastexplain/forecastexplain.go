@@ -0,0 +1,166 @@
+package forecastexplain
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/services/forecasting"
+	"github.com/bantuaku/backend/services/quality"
+)
+
+// Weights of the forecast ensemble's components
+const (
+	WeightRecentAverage   = 0.4
+	WeightSmoothedLevel   = 0.35
+	WeightTrendProjection = 0.25
+)
+
+// Components are the numbers a forecast's daily rate is built from
+type Components struct {
+	Algorithm       string  `json:"algorithm"`        // ensemble, simple_average, no_data
+	RecentAverage   float64 `json:"recent_average"`   // Mean of the last 7 days with sales
+	SmoothedLevel   float64 `json:"smoothed_level"`   // Exponentially smoothed level
+	TrendPerDay     float64 `json:"trend_per_day"`    // Change per day with sales
+	TrendProjection float64 `json:"trend_projection"` // Last day projected 7 days along the trend
+	DailyRate       float64 `json:"daily_rate"`       // Forecast demand per day
+	WindowDays      int     `json:"window_days"`
+	DaysWithSales   int     `json:"days_with_sales"`
+	WindowAverage   float64 `json:"window_average"` // Mean over days with sales
+}
+
+// WeekdayFactor is how a weekday's sales compare with the average day; 1.2
+// means 20% above average
+type WeekdayFactor struct {
+	Weekday string  `json:"weekday"`
+	Factor  float64 `json:"factor"`
+}
+
+// Adjustment is a sales record the user corrected or excluded, changing what
+// the forecast is built from
+type Adjustment struct {
+	Kind             string    `json:"kind"` // corrected, excluded
+	Date             time.Time `json:"date"`
+	Quantity         float64   `json:"quantity"`
+	OriginalQuantity float64   `json:"original_quantity,omitempty"`
+}
+
+// Point is a day's total sales
+type Point = quality.DailyPoint
+
+// weekdays are Indonesian weekday names, indexed by time.Weekday
+var weekdays = [7]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+// WeekdayFactors compares each weekday's mean sales over the window [from, to],
+// counting days without sales as zero, with the mean day. It returns nil when
+// the window is shorter than four weeks or has no sales.
+func WeekdayFactors(points []Point, from, to time.Time) []WeekdayFactor {
+	profile, ok := forecasting.WeekdayProfile(points, from, to)
+	if !ok {
+		return nil
+	}
+	factors := make([]WeekdayFactor, 0, 7)
+	// Monday first, as Indonesian calendars show weeks
+	for i := 1; i <= 7; i++ {
+		wd := time.Weekday(i % 7)
+		factors = append(factors, WeekdayFactor{Weekday: weekdays[wd], Factor: round2(profile[wd])})
+	}
+	return factors
+}
+
+// Explanation is everything a forecast explanation is grounded in
+type Explanation struct {
+	ProductName string
+	Unit        string
+	Forecast30d int
+	Components  Components
+	Weekdays    []WeekdayFactor
+	Adjustments []Adjustment
+}
+
+// Facts lists the explanation's numbers as plain Indonesian statements
+func (e Explanation) Facts() []string {
+	c := e.Components
+	facts := []string{
+		fmt.Sprintf("Prakiraan 30 hari: %d %s (%.2f %s per hari).", e.Forecast30d, e.Unit, c.DailyRate, e.Unit),
+		fmt.Sprintf("Data: %d hari dengan penjualan dalam %d hari terakhir, rata-rata %.2f %s per hari penjualan.", c.DaysWithSales, c.WindowDays, c.WindowAverage, e.Unit),
+	}
+	switch c.Algorithm {
+	case "ensemble":
+		facts = append(facts,
+			fmt.Sprintf("Rata-rata 7 hari penjualan terakhir: %.2f (bobot %.0f%%).", c.RecentAverage, WeightRecentAverage*100),
+			fmt.Sprintf("Level yang dihaluskan (exponential smoothing): %.2f (bobot %.0f%%).", c.SmoothedLevel, WeightSmoothedLevel*100),
+			fmt.Sprintf("Tren: %s %.2f per hari penjualan, proyeksi 7 hari ke depan %.2f (bobot %.0f%%).", trendWord(c.TrendPerDay), math.Abs(c.TrendPerDay), c.TrendProjection, WeightTrendProjection*100),
+		)
+	case "simple_average":
+		facts = append(facts, "Data kurang dari 7 hari penjualan, jadi prakiraan memakai rata-rata sederhana.")
+	default:
+		facts = append(facts, "Belum ada data penjualan, jadi prakiraan bernilai nol.")
+	}
+	if peak, low, ok := weekdayExtremes(e.Weekdays); ok {
+		facts = append(facts, fmt.Sprintf("Pola mingguan: %s paling ramai (%.2fx rata-rata), %s paling sepi (%.2fx). Pola ini tidak mengubah angka harian prakiraan.",
+			peak.Weekday, peak.Factor, low.Weekday, low.Factor))
+	}
+	excluded, corrected := 0, 0
+	for _, a := range e.Adjustments {
+		if a.Kind == "excluded" {
+			excluded++
+		} else {
+			corrected++
+		}
+	}
+	if excluded+corrected > 0 {
+		facts = append(facts, fmt.Sprintf("Penyesuaian data oleh pengguna: %d catatan dikecualikan dan %d dikoreksi.", excluded, corrected))
+	} else {
+		facts = append(facts, "Tidak ada penyesuaian acara atau koreksi data; prakiraan murni dari riwayat penjualan.")
+	}
+	return facts
+}
+
+// Prompt asks for a short plain-Indonesian explanation that uses only the facts
+func (e Explanation) Prompt() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Jelaskan kepada pemilik UMKM mengapa prakiraan penjualan produk %q bernilai seperti ini.\n", e.ProductName)
+	b.WriteString("Gunakan HANYA fakta berikut; jangan menambah angka, acara, atau faktor lain.\n\nFakta:\n")
+	for _, f := range e.Facts() {
+		fmt.Fprintf(&b, "- %s\n", f)
+	}
+	b.WriteString("\nTulis 3-5 kalimat dalam bahasa Indonesia sederhana, tanpa istilah teknis dan tanpa format markdown.")
+	return b.String()
+}
+
+// Template is the explanation used when AI is unavailable
+func (e Explanation) Template() string {
+	return strings.Join(e.Facts(), " ")
+}
+
+func weekdayExtremes(factors []WeekdayFactor) (WeekdayFactor, WeekdayFactor, bool) {
+	if len(factors) == 0 {
+		return WeekdayFactor{}, WeekdayFactor{}, false
+	}
+	peak, low := factors[0], factors[0]
+	for _, f := range factors[1:] {
+		if f.Factor > peak.Factor {
+			peak = f
+		}
+		if f.Factor < low.Factor {
+			low = f
+		}
+	}
+	return peak, low, peak.Factor > low.Factor
+}
+
+func trendWord(perDay float64) string {
+	switch {
+	case perDay > 0.005:
+		return "naik"
+	case perDay < -0.005:
+		return "turun"
+	}
+	return "datar, berubah"
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,51 @@
+package forecastexplain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeekdayFactors(t *testing.T) {
+	from := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC) // Monday
+	to := from.AddDate(0, 0, 27)
+	var points []Point
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		q := 10.0
+		if d.Weekday() == time.Saturday {
+			q = 24
+		}
+		points = append(points, Point{Date: d, Quantity: q})
+	}
+	factors := WeekdayFactors(points, from, to)
+	if len(factors) != 7 || factors[0].Weekday != "Senin" || factors[6].Weekday != "Minggu" {
+		t.Fatalf("unexpected factors: %+v", factors)
+	}
+	// Mean day is 12: Saturdays are 2x, other days 10/12
+	if factors[5].Factor != 2 || factors[0].Factor != 0.83 {
+		t.Errorf("unexpected factors: %+v", factors)
+	}
+
+	if WeekdayFactors(points, from, from.AddDate(0, 0, 13)) != nil {
+		t.Error("expected no factors for two weeks of history")
+	}
+}
+
+func TestFacts(t *testing.T) {
+	e := Explanation{
+		ProductName: "Kopi Susu",
+		Unit:        "cup",
+		Forecast30d: 300,
+		Components:  Components{Algorithm: "ensemble", RecentAverage: 11, SmoothedLevel: 9, TrendPerDay: 0.2, TrendProjection: 12, DailyRate: 10, WindowDays: 90, DaysWithSales: 60, WindowAverage: 9.5},
+		Adjustments: []Adjustment{{Kind: "excluded"}},
+	}
+	text := e.Template()
+	for _, want := range []string{"300 cup", "naik 0.20", "1 catatan dikecualikan"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("template %q lacks %q", text, want)
+		}
+	}
+	if !strings.Contains(e.Prompt(), "HANYA") {
+		t.Error("prompt does not restrict the model to the facts")
+	}
+}
@@ -0,0 +1,169 @@
+package forecasting
+
+import (
+	"math"
+	"time"
+
+	"github.com/bantuaku/backend/services/quality"
+)
+
+// Granularities
+const (
+	GranularityDaily   = "daily"
+	GranularityWeekly  = "weekly"
+	GranularityMonthly = "monthly"
+)
+
+// Models
+const (
+	ModelWeekdaySeasonal = "weekday_seasonal" // Daily rate scaled by the weekday's share of sales
+	ModelHoltLinear      = "holt_linear"      // Level and trend smoothed over weekly totals
+	ModelEnsemble        = "ensemble"         // The daily rate held flat over 30-day periods
+)
+
+// Spec describes a granularity: how many periods it forecasts, how much
+// history it reads, its model and the lowest plan that may use it
+type Spec struct {
+	Granularity string `json:"granularity"`
+	Periods     int    `json:"periods"`
+	HistoryDays int    `json:"history_days"`
+	Model       string `json:"model"`
+	MinPlan     string `json:"min_plan"`
+}
+
+var specs = map[string]Spec{
+	GranularityDaily:   {GranularityDaily, 30, 90, ModelWeekdaySeasonal, "pro"},
+	GranularityWeekly:  {GranularityWeekly, 13, 182, ModelHoltLinear, "pro"},
+	GranularityMonthly: {GranularityMonthly, 3, 90, ModelEnsemble, "free"},
+}
+
+var planRank = map[string]int{"free": 0, "pro": 1, "enterprise": 2}
+
+// Lookup returns the spec of a granularity
+func Lookup(granularity string) (Spec, bool) {
+	s, ok := specs[granularity]
+	return s, ok
+}
+
+// Allows reports whether plan may use the granularity. Unknown plans count as free.
+func (s Spec) Allows(plan string) bool {
+	return planRank[plan] >= planRank[s.MinPlan]
+}
+
+// Period is the forecast demand of one day, week or 30-day period
+type Period struct {
+	Start    string  `json:"start"` // YYYY-MM-DD
+	End      string  `json:"end"`   // YYYY-MM-DD, inclusive
+	Quantity float64 `json:"quantity"`
+}
+
+// minProfileWeeks is how many weeks of history weekday factors and weekly
+// trends need
+const minProfileWeeks = 4
+
+// Holt smoothing factors for weekly totals
+const (
+	holtAlpha = 0.5
+	holtBeta  = 0.2
+)
+
+// WeekdayProfile compares each weekday's mean sales over [from, to], counting
+// days without sales as zero, with the mean day; factors are indexed by
+// time.Weekday. It reports false when the window is shorter than four weeks or
+// has no sales.
+func WeekdayProfile(points []quality.DailyPoint, from, to time.Time) ([7]float64, bool) {
+	var factors [7]float64
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days < minProfileWeeks*7 {
+		return factors, false
+	}
+	var sums, counts [7]float64
+	total := 0.0
+	for d := 0; d < days; d++ {
+		counts[from.AddDate(0, 0, d).Weekday()]++
+	}
+	for _, p := range points {
+		if p.Date.Before(from) || p.Date.After(to) {
+			continue
+		}
+		sums[p.Date.Weekday()] += p.Quantity
+		total += p.Quantity
+	}
+	if total == 0 {
+		return factors, false
+	}
+	mean := total / float64(days)
+	for wd := range factors {
+		if counts[wd] > 0 {
+			factors[wd] = sums[wd] / counts[wd] / mean
+		}
+	}
+	return factors, true
+}
+
+// Daily forecasts n days from start: the daily rate scaled by each weekday's
+// factor over the history before start, or held flat without enough history
+func Daily(points []quality.DailyPoint, dailyRate float64, historyFrom, start time.Time, n int) []Period {
+	factors, ok := WeekdayProfile(points, historyFrom, start.AddDate(0, 0, -1))
+	periods := make([]Period, n)
+	for i := range periods {
+		day := start.AddDate(0, 0, i)
+		q := dailyRate
+		if ok {
+			q *= factors[day.Weekday()]
+		}
+		periods[i] = Period{Start: day.Format("2006-01-02"), End: day.Format("2006-01-02"), Quantity: round2(q)}
+	}
+	return periods
+}
+
+// Weekly forecasts n weeks from start with Holt's linear trend over the weekly
+// totals of the history before start. Leading weeks before the first sale are
+// ignored; with fewer than four weeks left the daily rate is held flat.
+func Weekly(points []quality.DailyPoint, dailyRate float64, historyFrom, start time.Time, n int) []Period {
+	weeks := int(start.Sub(historyFrom).Hours()/24) / 7
+	totals := make([]float64, weeks)
+	// Weeks are counted back from start, so the last one ends the day before
+	first := start.AddDate(0, 0, -7*weeks)
+	for _, p := range points {
+		if p.Date.Before(first) || !p.Date.Before(start) {
+			continue
+		}
+		totals[int(p.Date.Sub(first).Hours()/24)/7] += p.Quantity
+	}
+	for len(totals) > 0 && totals[0] == 0 {
+		totals = totals[1:]
+	}
+
+	level, trend := dailyRate*7, 0.0
+	if len(totals) >= minProfileWeeks {
+		level, trend = totals[0], totals[1]-totals[0]
+		for _, t := range totals[1:] {
+			prev := level
+			level = holtAlpha*t + (1-holtAlpha)*(level+trend)
+			trend = holtBeta*(level-prev) + (1-holtBeta)*trend
+		}
+	}
+
+	periods := make([]Period, n)
+	for i := range periods {
+		from := start.AddDate(0, 0, 7*i)
+		q := math.Max(level+float64(i+1)*trend, 0)
+		periods[i] = Period{Start: from.Format("2006-01-02"), End: from.AddDate(0, 0, 6).Format("2006-01-02"), Quantity: round2(q)}
+	}
+	return periods
+}
+
+// Monthly forecasts n 30-day periods from start at the daily rate
+func Monthly(dailyRate float64, start time.Time, n int) []Period {
+	periods := make([]Period, n)
+	for i := range periods {
+		from := start.AddDate(0, 0, 30*i)
+		periods[i] = Period{Start: from.Format("2006-01-02"), End: from.AddDate(0, 0, 29).Format("2006-01-02"), Quantity: round2(dailyRate * 30)}
+	}
+	return periods
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
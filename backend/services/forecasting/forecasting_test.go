@@ -0,0 +1,69 @@
+package forecasting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bantuaku/backend/services/quality"
+)
+
+func series(from time.Time, days int, qty func(time.Time) float64) []quality.DailyPoint {
+	var points []quality.DailyPoint
+	for i := 0; i < days; i++ {
+		d := from.AddDate(0, 0, i)
+		points = append(points, quality.DailyPoint{Date: d, Quantity: qty(d)})
+	}
+	return points
+}
+
+func TestAllows(t *testing.T) {
+	daily, _ := Lookup(GranularityDaily)
+	monthly, _ := Lookup(GranularityMonthly)
+	if daily.Allows("free") || !daily.Allows("pro") || !daily.Allows("enterprise") {
+		t.Error("daily forecasts should need the pro plan")
+	}
+	if !monthly.Allows("free") || !monthly.Allows("") {
+		t.Error("monthly forecasts should be open to every plan")
+	}
+	if _, ok := Lookup("hourly"); ok {
+		t.Error("unknown granularity found")
+	}
+}
+
+func TestDailyFollowsWeekdays(t *testing.T) {
+	from := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC) // Monday
+	points := series(from, 28, func(d time.Time) float64 {
+		if d.Weekday() == time.Saturday {
+			return 24
+		}
+		return 10
+	})
+	start := from.AddDate(0, 0, 28)
+	periods := Daily(points, 12, from, start, 7)
+	if len(periods) != 7 || periods[0].Start != "2025-06-30" {
+		t.Fatalf("unexpected periods: %+v", periods)
+	}
+	if periods[5].Quantity != 24 || periods[0].Quantity != 10 {
+		t.Errorf("expected Saturday 24 and Monday 10, got %+v", periods)
+	}
+}
+
+func TestWeeklyTrend(t *testing.T) {
+	from := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	// Sales grow by one unit a day each week: weekly totals 7, 14, 21, ...
+	points := series(from, 56, func(d time.Time) float64 { return float64(int(d.Sub(from).Hours()/24)/7 + 1) })
+	start := from.AddDate(0, 0, 56)
+	periods := Weekly(points, 0, from, start, 2)
+	if periods[0].End != "2025-03-09" {
+		t.Errorf("unexpected week bounds: %+v", periods[0])
+	}
+	if periods[0].Quantity <= 56 || periods[1].Quantity <= periods[0].Quantity {
+		t.Errorf("expected a rising trend past the last week (56), got %+v", periods)
+	}
+
+	// Without enough history the daily rate is held flat
+	flat := Weekly(points[:7], 3, from, from.AddDate(0, 0, 7), 2)
+	if flat[0].Quantity != 21 || flat[1].Quantity != 21 {
+		t.Errorf("expected flat weeks of 21, got %+v", flat)
+	}
+}
@@ -0,0 +1,92 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+// ErrNotFound is returned when an address has no match
+var ErrNotFound = errors.New("address not found")
+
+// Point is a WGS84 coordinate
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Client looks up coordinates from a Nominatim-compatible search API
+// (OpenStreetMap or a self-hosted instance), limited to Indonesia
+type Client struct {
+	BaseURL    string
+	UserAgent  string // Nominatim's usage policy requires an identifying agent
+	HTTPClient *http.Client
+}
+
+// NewClient creates a geocoding client for baseURL
+func NewClient(baseURL, userAgent string) *Client {
+	return &Client{
+		BaseURL:    baseURL,
+		UserAgent:  userAgent,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// Search returns the best match for a free-form address such as
+// "Sukasari, Kota Bandung, Jawa Barat"
+func (c *Client) Search(ctx context.Context, query string) (Point, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "json")
+	params.Set("limit", "1")
+	params.Set("countrycodes", "id")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return Point{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return Point{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Point{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Point{}, fmt.Errorf("geocoder error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return Point{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(results) == 0 {
+		return Point{}, ErrNotFound
+	}
+	lat, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid latitude %q", results[0].Lat)
+	}
+	lng, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid longitude %q", results[0].Lon)
+	}
+	return Point{Latitude: lat, Longitude: lng}, nil
+}
@@ -0,0 +1,31 @@
+package geocode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("countrycodes") != "id" || r.Header.Get("User-Agent") != "bantuaku-test" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.URL.Query().Get("q") == "nowhere" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"lat":"-6.8868","lon":"107.5806"}]`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "bantuaku-test")
+	p, err := c.Search(context.Background(), "Sukasari, Kota Bandung")
+	if err != nil || p.Latitude != -6.8868 || p.Longitude != 107.5806 {
+		t.Errorf("Search = %+v, %v", p, err)
+	}
+	if _, err := c.Search(context.Background(), "nowhere"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
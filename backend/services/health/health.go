@@ -0,0 +1,208 @@
+package health
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Health levels
+const (
+	LevelHealthy = "healthy"
+	LevelWatch   = "watch"
+	LevelAtRisk  = "at_risk"
+	LevelNoData  = "no_data"
+)
+
+// Scoring thresholds
+const (
+	healthyMin     = 70
+	watchMin       = 45
+	maxTrendDrop   = -30.0 // Revenue change (percent) that scores 0
+	maxTrendGain   = 20.0  // Revenue change (percent) that scores 100
+	healthyMargin  = 40.0  // Gross margin (percent) that scores 100
+	diversifiedHHI = 0.2   // Herfindahl index of five equal products, scores 100
+)
+
+// Factor keys
+const (
+	FactorRevenueTrend     = "revenue_trend"
+	FactorMargin           = "margin"
+	FactorDataCompleteness = "data_completeness"
+	FactorForecastAccuracy = "forecast_accuracy"
+	FactorDiversification  = "diversification"
+)
+
+// Inputs are the measurements the score is built from. Nil pointers mean the
+// measurement is unavailable; its weight is spread over the other factors.
+type Inputs struct {
+	RevenueTrend     *float64  // Percent change in revenue vs. the previous month
+	GrossMargin      *float64  // Percent, over sales of products with a known cost
+	QualityScores    []int     // Per-product data quality scores (0-100)
+	ForecastError    *float64  // Mean absolute percentage error of past forecasts
+	RevenueByProduct []float64 // Revenue per product over the recent window
+}
+
+// Factor is one component of the health score
+type Factor struct {
+	Key       string  `json:"key"`
+	Label     string  `json:"label"`
+	Score     int     `json:"score"` // 0-100
+	Weight    float64 `json:"weight"`
+	Value     float64 `json:"value"` // Underlying measurement, e.g. margin percent
+	Detail    string  `json:"detail"`
+	Available bool    `json:"available"`
+}
+
+// Score is the composite business health score
+type Score struct {
+	Score   int      `json:"score"` // 0-100
+	Level   string   `json:"level"`
+	Factors []Factor `json:"factors"`
+}
+
+var weights = map[string]float64{
+	FactorRevenueTrend:     25,
+	FactorMargin:           25,
+	FactorDataCompleteness: 20,
+	FactorForecastAccuracy: 15,
+	FactorDiversification:  15,
+}
+
+// Compute scores each available factor and combines them by weight
+func Compute(in Inputs) Score {
+	factors := []Factor{
+		revenueTrendFactor(in.RevenueTrend),
+		marginFactor(in.GrossMargin),
+		dataFactor(in.QualityScores),
+		accuracyFactor(in.ForecastError),
+		diversificationFactor(in.RevenueByProduct),
+	}
+
+	var total, weightSum float64
+	for i := range factors {
+		factors[i].Weight = weights[factors[i].Key]
+		if factors[i].Available {
+			total += float64(factors[i].Score) * factors[i].Weight
+			weightSum += factors[i].Weight
+		}
+	}
+
+	s := Score{Factors: factors, Level: LevelNoData}
+	if weightSum == 0 {
+		return s
+	}
+	s.Score = int(math.Round(total / weightSum))
+	switch {
+	case s.Score >= healthyMin:
+		s.Level = LevelHealthy
+	case s.Score >= watchMin:
+		s.Level = LevelWatch
+	default:
+		s.Level = LevelAtRisk
+	}
+	return s
+}
+
+// Weakest returns up to n available factors with the lowest scores, weakest first
+func (s Score) Weakest(n int) []Factor {
+	available := []Factor{}
+	for _, f := range s.Factors {
+		if f.Available {
+			available = append(available, f)
+		}
+	}
+	sort.SliceStable(available, func(i, j int) bool { return available[i].Score < available[j].Score })
+	if len(available) > n {
+		available = available[:n]
+	}
+	return available
+}
+
+func revenueTrendFactor(trend *float64) Factor {
+	f := Factor{Key: FactorRevenueTrend, Label: "Tren omzet", Detail: "Belum ada omzet bulan lalu untuk dibandingkan."}
+	if trend == nil {
+		return f
+	}
+	f.Available = true
+	f.Value = *trend
+	f.Score = scale(*trend, maxTrendDrop, maxTrendGain)
+	f.Detail = fmt.Sprintf("Omzet berubah %+.1f%% dibanding bulan lalu.", *trend)
+	return f
+}
+
+func marginFactor(margin *float64) Factor {
+	f := Factor{Key: FactorMargin, Label: "Margin", Detail: "Isi modal per produk agar margin bisa dihitung."}
+	if margin == nil {
+		return f
+	}
+	f.Available = true
+	f.Value = *margin
+	f.Score = scale(*margin, 0, healthyMargin)
+	f.Detail = fmt.Sprintf("Margin kotor %.1f%% dari penjualan.", *margin)
+	return f
+}
+
+func dataFactor(scores []int) Factor {
+	f := Factor{Key: FactorDataCompleteness, Label: "Kelengkapan data", Detail: "Belum ada produk aktif."}
+	if len(scores) == 0 {
+		return f
+	}
+	sum := 0
+	for _, s := range scores {
+		sum += s
+	}
+	f.Available = true
+	f.Value = float64(sum) / float64(len(scores))
+	f.Score = int(math.Round(f.Value))
+	f.Detail = fmt.Sprintf("Rata-rata skor kualitas data penjualan %d dari 100 untuk %d produk.", f.Score, len(scores))
+	return f
+}
+
+func accuracyFactor(mape *float64) Factor {
+	f := Factor{Key: FactorForecastAccuracy, Label: "Akurasi prediksi", Detail: "Belum ada prediksi lama yang bisa dibandingkan dengan penjualan aktual."}
+	if mape == nil {
+		return f
+	}
+	f.Available = true
+	f.Value = *mape
+	f.Score = scale(100-*mape, 0, 100)
+	f.Detail = fmt.Sprintf("Prediksi meleset rata-rata %.0f%% dari penjualan aktual.", *mape)
+	return f
+}
+
+func diversificationFactor(revenue []float64) Factor {
+	f := Factor{Key: FactorDiversification, Label: "Diversifikasi produk", Detail: "Belum ada omzet untuk dihitung."}
+	var total float64
+	for _, r := range revenue {
+		if r > 0 {
+			total += r
+		}
+	}
+	if total == 0 {
+		return f
+	}
+
+	var hhi, top float64
+	for _, r := range revenue {
+		if r <= 0 {
+			continue
+		}
+		share := r / total
+		hhi += share * share
+		top = math.Max(top, share)
+	}
+	f.Available = true
+	f.Value = hhi
+	f.Score = scale(1-hhi, 0, 1-diversifiedHHI)
+	f.Detail = fmt.Sprintf("Produk terbesar menyumbang %.0f%% omzet.", top*100)
+	return f
+}
+
+// scale maps v linearly from [low, high] to 0-100, clamped
+func scale(v, low, high float64) int {
+	if high == low {
+		return 0
+	}
+	return int(math.Round(math.Max(0, math.Min(1, (v-low)/(high-low))) * 100))
+}
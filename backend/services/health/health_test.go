@@ -0,0 +1,38 @@
+package health
+
+import "testing"
+
+func ptr(v float64) *float64 { return &v }
+
+func TestCompute(t *testing.T) {
+	s := Compute(Inputs{
+		RevenueTrend:     ptr(0),              // 60
+		GrossMargin:      ptr(40),             // 100
+		QualityScores:    []int{80, 60},       // 70
+		RevenueByProduct: []float64{100, 100}, // HHI 0.5 -> 63
+	})
+
+	// Forecast accuracy is unavailable, so its weight is spread over the rest:
+	// (60*25 + 100*25 + 70*20 + 63*15) / 85 = 74.6
+	if s.Score != 75 {
+		t.Errorf("Score = %d, want 75", s.Score)
+	}
+	if s.Level != LevelHealthy {
+		t.Errorf("Level = %s, want %s", s.Level, LevelHealthy)
+	}
+
+	weakest := s.Weakest(2)
+	if len(weakest) != 2 || weakest[0].Key != FactorRevenueTrend || weakest[1].Key != FactorDiversification {
+		t.Errorf("Weakest = %+v", weakest)
+	}
+}
+
+func TestComputeNoData(t *testing.T) {
+	s := Compute(Inputs{})
+	if s.Level != LevelNoData || s.Score != 0 {
+		t.Errorf("got %+v, want no_data", s)
+	}
+	if len(s.Factors) != 5 {
+		t.Errorf("all factors should be reported, got %d", len(s.Factors))
+	}
+}
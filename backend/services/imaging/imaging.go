@@ -0,0 +1,143 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // Register the GIF decoder for Decode
+	"image/jpeg"
+	"image/png"
+)
+
+// Image limits
+const (
+	ThumbnailSize = 320        // Longest edge of a thumbnail in pixels
+	MaxPixels     = 40_000_000 // Larger images are rejected before decoding
+	jpegQuality   = 82
+)
+
+// ErrUnsupportedFormat is returned for images other than JPEG, PNG and GIF
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// Encoded is an encoded image with its dimensions
+type Encoded struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// Decode reads a JPEG, PNG or GIF image, rejecting images too large to process
+func Decode(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, "", fmt.Errorf("image of %dx%d pixels is too large", cfg.Width, cfg.Height)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("decode %s: %w", format, err)
+	}
+	return img, format, nil
+}
+
+// Thumbnail scales img down so its longest edge is at most size pixels,
+// averaging the source pixels each thumbnail pixel covers. Smaller images are
+// returned unchanged.
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	tw, th := size, size
+	if w > h {
+		th = max(1, h*size/w)
+	} else {
+		tw = max(1, w*size/h)
+	}
+
+	src := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	dst := image.NewNRGBA(image.Rect(0, 0, tw, th))
+
+	for y := 0; y < th; y++ {
+		y0, y1 := y*h/th, max((y+1)*h/th, y*h/th+1)
+		for x := 0; x < tw; x++ {
+			x0, x1 := x*w/tw, max((x+1)*w/tw, x*w/tw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					// Weight colour by alpha so transparent pixels do not darken edges
+					r += uint64(p[0]) * uint64(p[3])
+					g += uint64(p[1]) * uint64(p[3])
+					bl += uint64(p[2]) * uint64(p[3])
+					a += uint64(p[3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(bl / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// Encode writes img as PNG when the source was a PNG (to keep transparency)
+// and as JPEG otherwise
+func Encode(img image.Image, sourceFormat string) (*Encoded, error) {
+	var buf bytes.Buffer
+	out := &Encoded{Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	switch sourceFormat {
+	case "png":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		out.ContentType, out.Extension = "image/png", ".png"
+	case "jpeg", "gif":
+		if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		out.ContentType, out.Extension = "image/jpeg", ".jpg"
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, sourceFormat)
+	}
+	out.Data = buf.Bytes()
+	return out, nil
+}
+
+// ContentType returns the MIME type of a decoded image format
+func ContentType(format string) string {
+	switch format {
+	case "jpeg":
+		return "image/jpeg"
+	case "png":
+		return "image/png"
+	case "gif":
+		return "image/gif"
+	}
+	return "application/octet-stream"
+}
+
+// flatten draws img over white, since JPEG has no transparency
+func flatten(img image.Image) image.Image {
+	if _, ok := img.(*image.YCbCr); ok {
+		return img
+	}
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Over)
+	return out
+}
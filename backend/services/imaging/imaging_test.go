@@ -0,0 +1,72 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func testPNG(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestThumbnailKeepsAspectRatio(t *testing.T) {
+	img, format, err := Decode(testPNG(t, 800, 400, color.NRGBA{R: 200, G: 100, B: 50, A: 255}))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if format != "png" {
+		t.Fatalf("format = %q, want png", format)
+	}
+
+	thumb := Thumbnail(img, 200)
+	if b := thumb.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Fatalf("thumbnail is %dx%d, want 200x100", b.Dx(), b.Dy())
+	}
+	r, g, b, a := thumb.At(50, 50).RGBA()
+	if r>>8 != 200 || g>>8 != 100 || b>>8 != 50 || a>>8 != 255 {
+		t.Errorf("averaged colour = %d,%d,%d,%d", r>>8, g>>8, b>>8, a>>8)
+	}
+}
+
+func TestThumbnailSmallImageUnchanged(t *testing.T) {
+	img, _, _ := Decode(testPNG(t, 64, 48, color.White))
+	if thumb := Thumbnail(img, ThumbnailSize); thumb != img {
+		t.Error("images within the thumbnail size should be returned unchanged")
+	}
+}
+
+func TestEncodeFormats(t *testing.T) {
+	img, _, _ := Decode(testPNG(t, 10, 10, color.NRGBA{A: 0}))
+
+	out, err := Encode(img, "png")
+	if err != nil || out.ContentType != "image/png" || out.Extension != ".png" {
+		t.Fatalf("png encode = %+v, %v", out, err)
+	}
+	out, err = Encode(img, "gif")
+	if err != nil || out.ContentType != "image/jpeg" {
+		t.Fatalf("gif encode = %+v, %v", out, err)
+	}
+	if _, _, err := Decode(out.Data); err != nil {
+		t.Errorf("encoded jpeg does not decode: %v", err)
+	}
+}
+
+func TestDecodeRejectsNonImages(t *testing.T) {
+	if _, _, err := Decode([]byte("%PDF-1.4 not an image")); err == nil {
+		t.Error("expected error for non-image data")
+	}
+}
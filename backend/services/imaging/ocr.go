@@ -0,0 +1,217 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+)
+
+// OCR preprocessing defaults
+const (
+	OCRMaxEdge     = 2000 // Longest edge OCR images are scaled down to, in pixels
+	ocrJPEGQuality = 85
+	contrastClip   = 0.01 // Share of darkest and lightest pixels clipped when stretching contrast
+)
+
+// OCRParams records what PrepareForOCR did to an image
+type OCRParams struct {
+	Orientation    int     `json:"orientation"`     // EXIF orientation of the original, 1 when upright or unknown
+	Rotation       int     `json:"rotation"`        // Degrees rotated clockwise to undo it
+	Mirrored       bool    `json:"mirrored"`        // Flipped horizontally to undo it
+	OriginalWidth  int     `json:"original_width"`  // Before rotation
+	OriginalHeight int     `json:"original_height"` // Before rotation
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	Scale          float64 `json:"scale"`         // Output width over upright input width
+	ContrastLow    uint8   `json:"contrast_low"`  // Gray level stretched to black
+	ContrastHigh   uint8   `json:"contrast_high"` // Gray level stretched to white
+	Quality        int     `json:"jpeg_quality"`
+	OriginalBytes  int     `json:"original_bytes"`
+	Bytes          int     `json:"bytes"`
+}
+
+// PrepareForOCR turns a photo of a receipt or document into a small grayscale
+// JPEG that OCR reads better: it undoes the camera's EXIF rotation, scales the
+// image down to OCRMaxEdge, stretches contrast so faded thermal print stands
+// out, and compresses it.
+func PrepareForOCR(data []byte) (*Encoded, *OCRParams, error) {
+	img, _, err := Decode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	params := &OCRParams{
+		Orientation:    ExifOrientation(data),
+		OriginalWidth:  img.Bounds().Dx(),
+		OriginalHeight: img.Bounds().Dy(),
+		Quality:        ocrJPEGQuality,
+		OriginalBytes:  len(data),
+	}
+
+	img, params.Rotation, params.Mirrored = orient(img, params.Orientation)
+	uprightWidth := img.Bounds().Dx()
+	img = Thumbnail(img, OCRMaxEdge)
+	gray := toGray(img)
+	params.ContrastLow, params.ContrastHigh = stretchContrast(gray)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, gray, &jpeg.Options{Quality: ocrJPEGQuality}); err != nil {
+		return nil, nil, err
+	}
+	out := &Encoded{
+		Data:        buf.Bytes(),
+		ContentType: "image/jpeg",
+		Extension:   ".jpg",
+		Width:       gray.Bounds().Dx(),
+		Height:      gray.Bounds().Dy(),
+	}
+	params.Width, params.Height, params.Bytes = out.Width, out.Height, len(out.Data)
+	params.Scale = float64(out.Width) / float64(uprightWidth)
+	return out, params, nil
+}
+
+// ExifOrientation returns the EXIF orientation tag (1-8) of a JPEG, or 1 when
+// there is none
+func ExifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || size < 2 || i+2+size > len(data) {
+			return 1 // Image data starts; no EXIF before it
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && len(segment) > 14 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// tiffOrientation reads tag 0x0112 from the first IFD of a TIFF header
+func tiffOrientation(tiff []byte) int {
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		off := ifd + 2 + e*12
+		if off+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[off:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[off+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orient undoes an EXIF orientation, returning the clockwise rotation applied
+// and whether the image was mirrored
+func orient(img image.Image, orientation int) (image.Image, int, bool) {
+	rotation := map[int]int{3: 180, 4: 180, 5: 90, 6: 90, 7: 270, 8: 270}[orientation]
+	mirrored := orientation == 2 || orientation == 4 || orientation == 5 || orientation == 7
+	if rotation == 0 && !mirrored {
+		return img, 0, false
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	ow, oh := w, h
+	if rotation == 90 || rotation == 270 {
+		ow, oh = h, w
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, ow, oh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx := x
+			if mirrored {
+				sx = w - 1 - x
+			}
+			var dx, dy int
+			switch rotation {
+			case 90:
+				dx, dy = h-1-y, x
+			case 180:
+				dx, dy = w-1-x, h-1-y
+			case 270:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			out.Set(dx, dy, img.At(b.Min.X+sx, b.Min.Y+y))
+		}
+	}
+	return out, rotation, mirrored
+}
+
+func toGray(img image.Image) *image.Gray {
+	b := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			// Transparent areas become white paper
+			r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			lum := (19595*r + 38470*g + 7471*bl + 1<<15) >> 24
+			white := uint32(255) * (0xffff - a) / 0xffff
+			gray.Pix[y*gray.Stride+x] = uint8(min(lum+white, 255))
+		}
+	}
+	return gray
+}
+
+// stretchContrast maps the gray levels between the clipped darkest and
+// lightest pixels onto the full range, returning the levels used
+func stretchContrast(gray *image.Gray) (low, high uint8) {
+	var hist [256]int
+	for _, v := range gray.Pix {
+		hist[v]++
+	}
+	clip := int(float64(len(gray.Pix)) * contrastClip)
+	low, high = 0, 255
+	for seen := 0; low < 255; low++ {
+		if seen += hist[low]; seen > clip {
+			break
+		}
+	}
+	for seen := 0; high > 0; high-- {
+		if seen += hist[high]; seen > clip {
+			break
+		}
+	}
+	if high <= low {
+		return low, high // Blank image; nothing to stretch
+	}
+
+	span := int(high) - int(low)
+	for i, v := range gray.Pix {
+		switch {
+		case v <= low:
+			gray.Pix[i] = 0
+		case v >= high:
+			gray.Pix[i] = 255
+		default:
+			gray.Pix[i] = uint8((int(v) - int(low)) * 255 / span)
+		}
+	}
+	return low, high
+}
@@ -0,0 +1,64 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// withExif inserts an APP1 segment with a little-endian orientation tag after
+// the SOI marker of a JPEG
+func withExif(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 1, 0, 0x12, 0x01, 3, 0, 1, 0, 0, 0, byte(orientation), 0, 0, 0, 0, 0, 0, 0}
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	size := len(segment) + 2
+	app1 := append([]byte{0xFF, 0xE1, byte(size >> 8), byte(size)}, segment...)
+	return append(append(append([]byte{}, jpg[:2]...), app1...), jpg[2:]...)
+}
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Faded print: everything between 100 and 180
+			img.SetGray(x, y, color.Gray{Y: uint8(100 + (x*80)/w)})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExifOrientation(t *testing.T) {
+	jpg := testJPEG(t, 10, 10)
+	if got := ExifOrientation(jpg); got != 1 {
+		t.Errorf("orientation without EXIF = %d, want 1", got)
+	}
+	if got := ExifOrientation(withExif(t, jpg, 6)); got != 6 {
+		t.Errorf("orientation = %d, want 6", got)
+	}
+}
+
+func TestPrepareForOCR(t *testing.T) {
+	data := withExif(t, testJPEG(t, 3000, 1000), 6)
+	out, params, err := PrepareForOCR(data)
+	if err != nil {
+		t.Fatalf("PrepareForOCR() error = %v", err)
+	}
+	// Rotated upright to 1000x3000, then scaled to fit 2000
+	if params.Rotation != 90 || out.Width != 666 || out.Height != 2000 {
+		t.Errorf("rotation %d, size %dx%d; want 90 and 666x2000", params.Rotation, out.Width, out.Height)
+	}
+	if params.ContrastLow < 95 || params.ContrastHigh > 185 || params.ContrastHigh <= params.ContrastLow {
+		t.Errorf("contrast levels = %d-%d, want around the 100-180 input range", params.ContrastLow, params.ContrastHigh)
+	}
+	if params.Bytes != len(out.Data) || out.ContentType != "image/jpeg" {
+		t.Errorf("params = %+v", params)
+	}
+}
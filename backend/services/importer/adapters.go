@@ -0,0 +1,119 @@
+package importer
+
+// Canonical fields every adapter maps into
+const (
+	FieldDate        = "date"
+	FieldProductName = "product_name"
+	FieldSKU         = "sku"
+	FieldCategory    = "category"
+	FieldQuantity    = "quantity"
+	FieldUnit        = "unit"
+	FieldUnitPrice   = "unit_price"
+	FieldTotal       = "total"
+	FieldChannel     = "channel"
+)
+
+// FieldDoc documents how an adapter's source column maps to a canonical field
+type FieldDoc struct {
+	Field       string   `json:"field"`
+	Required    bool     `json:"required"`
+	Columns     []string `json:"columns"` // Accepted header names in the export file, matched case-insensitively
+	Description string   `json:"description"`
+}
+
+// Adapter describes how to read an export file from a bookkeeping/POS tool
+type Adapter struct {
+	Name        string     `json:"name"`
+	Label       string     `json:"label"`
+	Description string     `json:"description"`
+	ExportHint  string     `json:"export_hint"` // Where to find the export in the source tool
+	Fields      []FieldDoc `json:"fields"`
+	DateFormats []string   `json:"date_formats"`
+	Decimal     string     `json:"decimal,omitempty"` // Decimal separator of amounts, "," or "."; empty detects it per value
+}
+
+// TemplateAdapterName is the adapter of Bantuaku's own CSV template
+const TemplateAdapterName = "csv"
+
+var adapters = []Adapter{
+	{
+		Name:        TemplateAdapterName,
+		Label:       "Template CSV Bantuaku",
+		Description: "File CSV atau spreadsheet dengan kolom template Bantuaku, dalam bahasa Inggris atau Indonesia.",
+		ExportHint:  "Isi kolom product_name, quantity dan sale_date (wajib), serta price, unit, sku dan channel bila ada.",
+		Fields: []FieldDoc{
+			{Field: FieldDate, Required: true, Columns: []string{"sale_date", "tanggal", "date"}, Description: "Tanggal penjualan"},
+			{Field: FieldProductName, Required: true, Columns: []string{"product_name", "nama produk", "produk"}, Description: "Nama produk, sama dengan katalog"},
+			{Field: FieldSKU, Columns: []string{"sku", "kode barang"}, Description: "SKU produk, dicocokkan sebelum nama"},
+			{Field: FieldQuantity, Required: true, Columns: []string{"quantity", "jumlah", "qty"}, Description: "Jumlah terjual"},
+			{Field: FieldUnit, Columns: []string{"unit", "satuan"}, Description: "Satuan (pcs, kg, liter, ...); bawaan satuan produk"},
+			{Field: FieldUnitPrice, Columns: []string{"price", "harga", "unit_price"}, Description: "Harga jual per satuan"},
+			{Field: FieldTotal, Columns: []string{"total"}, Description: "Total nilai baris (dipakai jika harga kosong)"},
+			{Field: FieldChannel, Columns: []string{"channel", "kanal"}, Description: "Kanal penjualan"},
+		},
+		DateFormats: []string{"2006-01-02", "02/01/2006", "02-01-2006", "2006/01/02", "02 Jan 2006", "2 Jan 2006", "01/02/2006"},
+	},
+	{
+		Name:        "bukuwarung",
+		Label:       "BukuWarung",
+		Description: "Laporan transaksi penjualan dari aplikasi BukuWarung.",
+		ExportHint:  "Menu Laporan → Transaksi → Unduh Laporan, simpan sebagai CSV.",
+		Fields: []FieldDoc{
+			{Field: FieldDate, Required: true, Columns: []string{"tanggal", "tanggal transaksi"}, Description: "Tanggal transaksi"},
+			{Field: FieldProductName, Required: true, Columns: []string{"nama barang", "produk", "catatan"}, Description: "Nama barang yang terjual"},
+			{Field: FieldCategory, Columns: []string{"kategori"}, Description: "Kategori barang"},
+			{Field: FieldQuantity, Required: true, Columns: []string{"jumlah", "qty", "kuantitas"}, Description: "Jumlah barang terjual"},
+			{Field: FieldUnit, Columns: []string{"satuan", "unit"}, Description: "Satuan barang (pcs, kg, liter, ...)"},
+			{Field: FieldUnitPrice, Columns: []string{"harga satuan", "harga jual"}, Description: "Harga jual per unit"},
+			{Field: FieldTotal, Columns: []string{"total", "nominal", "total penjualan"}, Description: "Total nilai transaksi (dipakai jika harga satuan kosong)"},
+		},
+		DateFormats: []string{"02/01/2006", "02-01-2006", "2006-01-02", "02 Jan 2006"},
+	},
+	{
+		Name:        "moka",
+		Label:       "Moka POS",
+		Description: "Laporan penjualan per item dari Moka Backoffice.",
+		ExportHint:  "Backoffice → Reports → Item Sales → Export, pilih format CSV.",
+		Fields: []FieldDoc{
+			{Field: FieldDate, Required: true, Columns: []string{"date", "tanggal"}, Description: "Tanggal penjualan"},
+			{Field: FieldProductName, Required: true, Columns: []string{"item", "item name", "nama item"}, Description: "Nama item"},
+			{Field: FieldSKU, Columns: []string{"sku"}, Description: "SKU item"},
+			{Field: FieldCategory, Columns: []string{"category", "kategori"}, Description: "Kategori item"},
+			{Field: FieldQuantity, Required: true, Columns: []string{"items sold", "quantity", "qty"}, Description: "Jumlah item terjual"},
+			{Field: FieldTotal, Columns: []string{"gross sales", "net sales", "penjualan kotor"}, Description: "Total penjualan item"},
+		},
+		DateFormats: []string{"2006-01-02", "02/01/2006", "02 Jan 2006", "Jan 02, 2006"},
+	},
+	{
+		Name:        "accurate",
+		Label:       "Accurate Online",
+		Description: "Laporan Rincian Penjualan per Barang dari Accurate Online.",
+		ExportHint:  "Laporan → Penjualan → Rincian Penjualan per Barang → Ekspor ke CSV.",
+		Fields: []FieldDoc{
+			{Field: FieldDate, Required: true, Columns: []string{"tanggal", "tgl faktur"}, Description: "Tanggal faktur"},
+			{Field: FieldSKU, Columns: []string{"kode barang", "no. barang"}, Description: "Kode barang"},
+			{Field: FieldProductName, Required: true, Columns: []string{"nama barang", "keterangan barang"}, Description: "Nama barang"},
+			{Field: FieldCategory, Columns: []string{"kategori barang", "kategori"}, Description: "Kategori barang"},
+			{Field: FieldQuantity, Required: true, Columns: []string{"kuantitas", "qty"}, Description: "Kuantitas terjual"},
+			{Field: FieldUnit, Columns: []string{"satuan", "unit"}, Description: "Satuan barang"},
+			{Field: FieldUnitPrice, Columns: []string{"harga satuan", "@harga"}, Description: "Harga satuan"},
+			{Field: FieldTotal, Columns: []string{"total harga", "jumlah"}, Description: "Total harga baris faktur"},
+		},
+		DateFormats: []string{"02/01/2006", "02-01-2006", "2006-01-02", "02 Jan 2006"},
+	},
+}
+
+// Adapters returns all registered importer adapters
+func Adapters() []Adapter {
+	return adapters
+}
+
+// Get returns the adapter with the given name
+func Get(name string) (Adapter, bool) {
+	for _, a := range adapters {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Adapter{}, false
+}
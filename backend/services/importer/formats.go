@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// monthAbbreviations maps Indonesian and English month names, in full and
+// abbreviated, to the abbreviations Go date layouts parse
+var monthAbbreviations = map[string]string{
+	"januari": "Jan", "january": "Jan", "jan": "Jan",
+	"februari": "Feb", "february": "Feb", "feb": "Feb", "peb": "Feb",
+	"maret": "Mar", "march": "Mar", "mar": "Mar",
+	"april": "Apr", "apr": "Apr",
+	"mei": "May", "may": "May",
+	"juni": "Jun", "june": "Jun", "jun": "Jun",
+	"juli": "Jul", "july": "Jul", "jul": "Jul",
+	"agustus": "Aug", "august": "Aug", "agu": "Aug", "agt": "Aug", "aug": "Aug",
+	"september": "Sep", "sept": "Sep", "sep": "Sep",
+	"oktober": "Oct", "october": "Oct", "okt": "Oct", "oct": "Oct",
+	"november": "Nov", "nopember": "Nov", "nov": "Nov", "nop": "Nov",
+	"desember": "Dec", "december": "Dec", "des": "Dec", "dec": "Dec",
+}
+
+var monthNamePattern = regexp.MustCompile(`[A-Za-z]+`)
+
+// dateTokens are the parts of a date pattern, longest first so "YYYY" is not
+// read as two "YY". Days and months accept one or two digits either way.
+var dateTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"},
+	{"YY", "06"},
+	{"MMMM", "Jan"},
+	{"MMM", "Jan"},
+	{"MM", "1"},
+	{"M", "1"},
+	{"DD", "2"},
+	{"D", "2"},
+}
+
+// DateLayout converts a date pattern such as "DD/MM/YYYY", "D MMMM YYYY" or
+// "YYYY-MM-DD" to a Go layout. MMM and MMMM accept Indonesian and English
+// month names.
+func DateLayout(pattern string) (string, error) {
+	var layout strings.Builder
+	var day, month, year bool
+	for rest := strings.ToUpper(strings.TrimSpace(pattern)); rest != ""; {
+		matched := false
+		for _, t := range dateTokens {
+			if strings.HasPrefix(rest, t.token) {
+				layout.WriteString(t.layout)
+				rest = rest[len(t.token):]
+				switch t.token[0] {
+				case 'D':
+					day = true
+				case 'M':
+					month = true
+				case 'Y':
+					year = true
+				}
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if c := rest[0]; strings.IndexByte(" /-.,", c) < 0 {
+			return "", fmt.Errorf("unsupported character %q in date format %q", c, pattern)
+		}
+		layout.WriteByte(rest[0])
+		rest = rest[1:]
+	}
+	if !day || !month || !year {
+		return "", fmt.Errorf("date format %q needs a day (D), month (M) and year (Y)", pattern)
+	}
+	return layout.String(), nil
+}
+
+// WithFormats returns the adapter reading dates with dateFormat, a pattern for
+// DateLayout, before its own formats, and amounts with decimal as the decimal
+// separator. Empty values keep the adapter's behavior.
+func (a Adapter) WithFormats(dateFormat, decimal string) (Adapter, error) {
+	if dateFormat != "" {
+		layout, err := DateLayout(dateFormat)
+		if err != nil {
+			return a, err
+		}
+		a.DateFormats = append([]string{layout}, a.DateFormats...)
+	}
+	switch decimal {
+	case "":
+	case ",", ".":
+		a.Decimal = decimal
+	default:
+		return a, fmt.Errorf("decimal separator must be \",\" or \".\", got %q", decimal)
+	}
+	return a, nil
+}
@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CustomAdapterName is the adapter name of imports that use a saved column mapping
+const CustomAdapterName = "custom"
+
+// MaxMappingSamples bounds the sample rows shown to the user and the AI provider
+const MaxMappingSamples = 5
+
+// MappingFields are the canonical fields a user maps their own columns to
+var MappingFields = []FieldDoc{
+	{Field: FieldDate, Required: true, Description: "Tanggal transaksi"},
+	{Field: FieldProductName, Required: true, Description: "Nama produk yang terjual"},
+	{Field: FieldQuantity, Required: true, Description: "Jumlah terjual"},
+	{Field: FieldUnitPrice, Description: "Harga jual per unit"},
+	{Field: FieldTotal, Description: "Total nilai baris (dipakai jika harga satuan kosong)"},
+	{Field: FieldChannel, Description: "Kanal penjualan, misalnya toko, Tokopedia, Shopee"},
+	{Field: FieldSKU, Description: "Kode barang / SKU"},
+	{Field: FieldCategory, Description: "Kategori produk"},
+	{Field: FieldUnit, Description: "Satuan (pcs, kg, liter, ...)"},
+}
+
+// customDateFormats are tried for files without a known source tool
+var customDateFormats = []string{"02/01/2006", "02-01-2006", "2006-01-02", "02 Jan 2006", "2 Jan 2006", "Jan 02, 2006", "02.01.2006"}
+
+// mappingAliases are extra header names recognized when suggesting a mapping
+var mappingAliases = map[string][]string{
+	FieldDate:        {"tgl", "waktu", "order date", "tanggal pesanan", "tanggal order", "created at"},
+	FieldProductName: {"nama produk", "product", "product name", "produk", "barang", "nama"},
+	FieldQuantity:    {"jumlah produk", "jml", "pcs", "terjual", "qty terjual"},
+	FieldUnitPrice:   {"harga", "price", "unit price", "harga produk"},
+	FieldTotal:       {"subtotal", "total pembayaran", "omzet", "amount"},
+	FieldChannel:     {"channel", "kanal", "platform", "marketplace", "toko", "sumber", "source"},
+	FieldSKU:         {"kode", "kode produk", "product code"},
+}
+
+var (
+	dateValuePattern   = regexp.MustCompile(`^\d{1,4}[-/.]\d{1,2}[-/.]\d{1,4}`)
+	amountValuePattern = regexp.MustCompile(`^(Rp\.?\s*)?[\d.,]+$`)
+)
+
+// ValidateMapping checks that a mapping covers every required field with
+// columns present in the header, and that no column is used twice
+func ValidateMapping(mapping map[string]string, header []string) error {
+	present := map[string]bool{}
+	for _, h := range header {
+		present[normalizeHeader(h)] = true
+	}
+
+	used := map[string]string{}
+	for field, column := range mapping {
+		if !knownMappingField(field) {
+			return fmt.Errorf("unknown field %q", field)
+		}
+		key := normalizeHeader(column)
+		if key == "" {
+			continue
+		}
+		if len(header) > 0 && !present[key] {
+			return fmt.Errorf("column %q for %s is not in the file", column, field)
+		}
+		if other, dup := used[key]; dup {
+			return fmt.Errorf("column %q is mapped to both %s and %s", column, other, field)
+		}
+		used[key] = field
+	}
+	for _, f := range MappingFields {
+		if f.Required && normalizeHeader(mapping[f.Field]) == "" {
+			return fmt.Errorf("missing column for %s", f.Field)
+		}
+	}
+	return nil
+}
+
+// CustomAdapter builds an adapter that reads files with a saved column mapping
+func CustomAdapter(label string, mapping map[string]string) Adapter {
+	a := Adapter{
+		Name:        CustomAdapterName,
+		Label:       label,
+		Description: "Pemetaan kolom yang disimpan pengguna",
+		DateFormats: customDateFormats,
+	}
+	for _, f := range MappingFields {
+		if column := normalizeHeader(mapping[f.Field]); column != "" {
+			a.Fields = append(a.Fields, FieldDoc{Field: f.Field, Required: f.Required, Columns: []string{column}, Description: f.Description})
+		}
+	}
+	return a
+}
+
+// SuggestMapping proposes a mapping from header names, falling back to the
+// shape of the sample values for date and amount columns
+func SuggestMapping(header []string, samples [][]string) map[string]string {
+	mapping := map[string]string{}
+	taken := map[int]bool{}
+
+	aliases := map[string][]string{}
+	for _, a := range adapters {
+		for _, f := range a.Fields {
+			aliases[f.Field] = append(aliases[f.Field], f.Columns...)
+		}
+	}
+	for field, extra := range mappingAliases {
+		aliases[field] = append(aliases[field], extra...)
+	}
+
+	for _, f := range MappingFields {
+		for _, alias := range aliases[f.Field] {
+			if i := headerIndex(header, alias); i >= 0 && !taken[i] {
+				mapping[f.Field] = strings.TrimSpace(header[i])
+				taken[i] = true
+				break
+			}
+		}
+	}
+
+	// Columns whose samples all look like dates or amounts fill remaining gaps
+	sniff := func(field string, pattern *regexp.Regexp) {
+		if _, ok := mapping[field]; ok {
+			return
+		}
+		for i := range header {
+			if !taken[i] && columnMatches(samples, i, pattern) {
+				mapping[field] = strings.TrimSpace(header[i])
+				taken[i] = true
+				return
+			}
+		}
+	}
+	sniff(FieldDate, dateValuePattern)
+	sniff(FieldQuantity, amountValuePattern)
+	sniff(FieldUnitPrice, amountValuePattern)
+	return mapping
+}
+
+// BuildMappingPrompt renders the user prompt asking an AI provider for a JSON mapping
+func BuildMappingPrompt(header []string, samples [][]string) string {
+	var b strings.Builder
+	b.WriteString("Petakan kolom file penjualan berikut ke field standar.\n\n")
+	fmt.Fprintf(&b, "Kolom: %s\n", strings.Join(quoteAll(header), ", "))
+	if len(samples) > 0 {
+		b.WriteString("Contoh baris:\n")
+		for i, row := range samples {
+			if i >= MaxMappingSamples {
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", strings.Join(quoteAll(row), ", "))
+		}
+	}
+	b.WriteString("\nField standar:\n")
+	fields := make([]string, 0, len(MappingFields))
+	for _, f := range MappingFields {
+		req := ""
+		if f.Required {
+			req = " (wajib)"
+		}
+		fmt.Fprintf(&b, "- %s: %s%s\n", f.Field, f.Description, req)
+		fields = append(fields, fmt.Sprintf("%q: \"nama kolom atau kosong\"", f.Field))
+	}
+	fmt.Fprintf(&b, `
+Balas HANYA dengan JSON berformat:
+{%s}
+Gunakan nama kolom persis seperti di atas dan setiap kolom paling banyak satu kali.`, strings.Join(fields, ", "))
+	return b.String()
+}
+
+// ParseMapping extracts a JSON mapping from an AI response, dropping unknown
+// fields and columns that are not in the header
+func ParseMapping(content string, header []string) (map[string]string, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("no JSON object in response")
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(content[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("invalid mapping JSON: %w", err)
+	}
+
+	mapping := map[string]string{}
+	taken := map[int]bool{}
+	for _, f := range MappingFields {
+		i := headerIndex(header, raw[f.Field])
+		if i < 0 || taken[i] {
+			continue
+		}
+		mapping[f.Field] = strings.TrimSpace(header[i])
+		taken[i] = true
+	}
+	if err := ValidateMapping(mapping, header); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// normalizeHeader lowercases and trims a header name for matching
+func normalizeHeader(h string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+}
+
+func headerIndex(header []string, name string) int {
+	key := normalizeHeader(name)
+	if key == "" {
+		return -1
+	}
+	for i, h := range header {
+		if normalizeHeader(h) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func knownMappingField(field string) bool {
+	for _, f := range MappingFields {
+		if f.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// columnMatches reports whether every non-empty sample value in column i matches pattern
+func columnMatches(samples [][]string, i int, pattern *regexp.Regexp) bool {
+	seen := false
+	for _, row := range samples {
+		if i >= len(row) || strings.TrimSpace(row[i]) == "" {
+			continue
+		}
+		if !pattern.MatchString(strings.TrimSpace(row[i])) {
+			return false
+		}
+		seen = true
+	}
+	return seen
+}
+
+func quoteAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = fmt.Sprintf("%q", v)
+	}
+	return out
+}
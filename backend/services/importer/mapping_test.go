@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuggestMapping(t *testing.T) {
+	header := []string{"Order Date", "Nama Produk", "Jml", "Harga", "Platform", "Catatan Internal"}
+	samples := [][]string{{"05/01/2025", "Kopi Susu", "2", "18.000", "Shopee", "-"}}
+
+	got := SuggestMapping(header, samples)
+	want := map[string]string{
+		FieldDate:        "Order Date",
+		FieldProductName: "Nama Produk",
+		FieldQuantity:    "Jml",
+		FieldUnitPrice:   "Harga",
+		FieldChannel:     "Platform",
+	}
+	for field, column := range want {
+		if got[field] != column {
+			t.Errorf("%s mapped to %q, want %q", field, got[field], column)
+		}
+	}
+	if err := ValidateMapping(got, header); err != nil {
+		t.Errorf("suggested mapping is invalid: %v", err)
+	}
+}
+
+func TestSuggestMappingSniffsValues(t *testing.T) {
+	header := []string{"A", "B", "C"}
+	samples := [][]string{{"Teh Manis", "2025-01-05", "3"}}
+
+	got := SuggestMapping(header, samples)
+	if got[FieldDate] != "B" || got[FieldQuantity] != "C" {
+		t.Errorf("unexpected mapping: %v", got)
+	}
+}
+
+func TestValidateMapping(t *testing.T) {
+	header := []string{"Tgl", "Barang", "Qty"}
+
+	if err := ValidateMapping(map[string]string{FieldDate: "Tgl", FieldProductName: "Barang"}, header); err == nil {
+		t.Error("expected error for missing quantity")
+	}
+	if err := ValidateMapping(map[string]string{FieldDate: "Tgl", FieldProductName: "Barang", FieldQuantity: "Stok"}, header); err == nil {
+		t.Error("expected error for column not in file")
+	}
+	if err := ValidateMapping(map[string]string{FieldDate: "Tgl", FieldProductName: "Barang", FieldQuantity: "Qty", FieldUnitPrice: "qty"}, header); err == nil {
+		t.Error("expected error for column mapped twice")
+	}
+	if err := ValidateMapping(map[string]string{FieldDate: "Tgl", FieldProductName: "Barang", FieldQuantity: "Qty", "warna": "Tgl"}, header); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	header := []string{"Tgl", "Barang", "Qty", "Toko"}
+	content := "Berikut pemetaannya:\n```json\n{\"date\": \"tgl\", \"product_name\": \"Barang\", \"quantity\": \"Qty\", \"channel\": \"Toko\", \"sku\": \"Kode\"}\n```"
+
+	got, err := ParseMapping(content, header)
+	if err != nil {
+		t.Fatalf("ParseMapping failed: %v", err)
+	}
+	if got[FieldDate] != "Tgl" || got[FieldChannel] != "Toko" {
+		t.Errorf("unexpected mapping: %v", got)
+	}
+	if _, ok := got[FieldSKU]; ok {
+		t.Error("column not in the header should be dropped")
+	}
+
+	if _, err := ParseMapping(`{"date": "Tgl"}`, header); err == nil {
+		t.Error("expected error for incomplete mapping")
+	}
+}
+
+func TestCustomAdapterParsesTable(t *testing.T) {
+	adapter := CustomAdapter("Kasir", map[string]string{
+		FieldDate: "Tgl", FieldProductName: "Barang", FieldQuantity: "Qty", FieldTotal: "Total", FieldChannel: "Toko",
+	})
+
+	result, err := ParseTable(adapter, [][]string{
+		{"Tgl", "Barang", "Qty", "Total", "Toko"},
+		{"5 Jan 2025", "Roti Bakar", "2", "30.000", "Tokopedia"},
+	})
+	if err != nil {
+		t.Fatalf("ParseTable failed: %v", err)
+	}
+	if len(result.Rows) != 1 {
+		t.Fatalf("expected 1 row, got %d: %+v", len(result.Rows), result.Errors)
+	}
+	row := result.Rows[0]
+	if row.UnitPrice != 15000 || row.Channel != "Tokopedia" || !row.Date.Equal(time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected row: %+v", row)
+	}
+}
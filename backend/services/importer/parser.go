@@ -0,0 +1,297 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Row is a single normalized sales line read from an export file
+type Row struct {
+	Line        int       `json:"line"`
+	Date        time.Time `json:"date"`
+	ProductName string    `json:"product_name"`
+	SKU         string    `json:"sku,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Quantity    float64   `json:"quantity"`
+	Unit        string    `json:"unit,omitempty"`
+	UnitPrice   float64   `json:"unit_price"`
+	Channel     string    `json:"channel,omitempty"`
+}
+
+// RowError describes why a line could not be imported
+type RowError struct {
+	Line   int    `json:"line"`
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"` // The rejected cell
+	Error  string `json:"error"`
+}
+
+// Result holds the outcome of parsing an export file
+type Result struct {
+	Mapping map[string]string `json:"mapping"` // canonical field -> source column header
+	Rows    []Row             `json:"rows"`
+	Errors  []RowError        `json:"errors"`
+}
+
+// Parse reads a CSV export using the adapter's column aliases and date formats
+func Parse(adapter Adapter, r io.Reader) (*Result, error) {
+	br := bufio.NewReader(r)
+	reader := csv.NewReader(br)
+	reader.Comma = detectDelimiter(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	return parseRecords(adapter, reader.Read, false)
+}
+
+// ParseTable reads an export already split into rows, e.g. a spreadsheet sheet.
+// Date cells may hold spreadsheet serial numbers instead of formatted dates.
+func ParseTable(adapter Adapter, rows [][]string) (*Result, error) {
+	next := 0
+	read := func() ([]string, error) {
+		if next >= len(rows) {
+			return nil, io.EOF
+		}
+		next++
+		return rows[next-1], nil
+	}
+	return parseRecords(adapter, read, true)
+}
+
+// parseRecords maps the header record and parses every following record
+func parseRecords(adapter Adapter, read func() ([]string, error), serialDates bool) (*Result, error) {
+	header, err := read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("failed to read header: file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns, mapping := mapColumns(adapter, header)
+	for _, f := range adapter.Fields {
+		if f.Required {
+			if _, ok := columns[f.Field]; !ok {
+				return nil, fmt.Errorf("missing required column for %s (expected one of: %s)", f.Field, strings.Join(f.Columns, ", "))
+			}
+		}
+	}
+
+	result := &Result{Mapping: mapping, Rows: []Row{}, Errors: []RowError{}}
+	line := 1
+	for {
+		record, err := read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			result.Errors = append(result.Errors, RowError{Line: line, Error: "Baris tidak dapat dibaca"})
+			continue
+		}
+		if isBlank(record) {
+			continue
+		}
+		if idx, ok := columns[FieldDate]; ok && serialDates && idx < len(record) {
+			record[idx] = serialDate(record[idx])
+		}
+
+		row, rowErr := parseRow(adapter, columns, mapping, record, line)
+		if rowErr != nil {
+			result.Errors = append(result.Errors, *rowErr)
+			continue
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, nil
+}
+
+// serialDate converts a spreadsheet serial day number such as "45292" to an
+// ISO date, leaving any other value unchanged
+func serialDate(s string) string {
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 1 || n > 100000 {
+		return s
+	}
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return epoch.AddDate(0, 0, int(n)).Format("2006-01-02")
+}
+
+func parseRow(adapter Adapter, columns map[string]int, mapping map[string]string, record []string, line int) (Row, *RowError) {
+	get := func(field string) string {
+		idx, ok := columns[field]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	row := Row{
+		Line:        line,
+		ProductName: get(FieldProductName),
+		SKU:         get(FieldSKU),
+		Category:    get(FieldCategory),
+		Unit:        strings.ToLower(get(FieldUnit)),
+		Channel:     get(FieldChannel),
+	}
+	if row.ProductName == "" {
+		return row, &RowError{Line: line, Column: mapping[FieldProductName], Error: "Nama produk kosong"}
+	}
+
+	date, err := ParseDate(get(FieldDate), adapter.DateFormats)
+	if err != nil {
+		return row, &RowError{Line: line, Column: mapping[FieldDate], Value: get(FieldDate), Error: "Format tanggal tidak dikenali"}
+	}
+	row.Date = date
+
+	qty, err := ParseAmountWith(get(FieldQuantity), adapter.Decimal)
+	if err != nil || qty <= 0 {
+		return row, &RowError{Line: line, Column: mapping[FieldQuantity], Value: get(FieldQuantity), Error: "Jumlah harus lebih dari 0"}
+	}
+	row.Quantity = qty
+
+	if s := get(FieldUnitPrice); s != "" {
+		price, err := ParseAmountWith(s, adapter.Decimal)
+		if err != nil || price < 0 {
+			return row, &RowError{Line: line, Column: mapping[FieldUnitPrice], Value: s, Error: "Harga satuan tidak valid"}
+		}
+		row.UnitPrice = price
+	} else if s := get(FieldTotal); s != "" {
+		total, err := ParseAmountWith(s, adapter.Decimal)
+		if err != nil || total < 0 {
+			return row, &RowError{Line: line, Column: mapping[FieldTotal], Value: s, Error: "Total tidak valid"}
+		}
+		row.UnitPrice = math.Round(total/qty*100) / 100
+	}
+
+	return row, nil
+}
+
+// mapColumns resolves each canonical field to a header index using the adapter aliases
+func mapColumns(adapter Adapter, header []string) (map[string]int, map[string]string) {
+	normalized := make(map[string]int, len(header))
+	for i, h := range header {
+		key := normalizeHeader(h)
+		if _, exists := normalized[key]; !exists {
+			normalized[key] = i
+		}
+	}
+
+	columns := map[string]int{}
+	mapping := map[string]string{}
+	for _, f := range adapter.Fields {
+		for _, alias := range f.Columns {
+			if idx, ok := normalized[alias]; ok {
+				columns[f.Field] = idx
+				mapping[f.Field] = strings.TrimSpace(header[idx])
+				break
+			}
+		}
+	}
+	return columns, mapping
+}
+
+// ParseAmount parses numbers as written in Indonesian exports:
+// "Rp 15.000", "15.000,50", "1,234.50" and plain "15000" are all accepted.
+func ParseAmount(s string) (float64, error) {
+	return ParseAmountWith(s, "")
+}
+
+// ParseAmountWith parses an amount whose decimal separator is decimal, "," or
+// "."; the other is read as a thousands separator. An empty decimal detects it
+// per value as ParseAmount does, which reads "1.500" as 1500.
+func ParseAmountWith(s, decimal string) (float64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "Rp."), "Rp")
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+	switch decimal {
+	case ",":
+		return strconv.ParseFloat(strings.Replace(strings.ReplaceAll(s, ".", ""), ",", ".", 1), 64)
+	case ".":
+		return strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	}
+
+	lastDot := strings.LastIndex(s, ".")
+	lastComma := strings.LastIndex(s, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot {
+			// 15.000,50 -> Indonesian decimal comma
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			// 1,234.50 -> English thousands separator
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastComma >= 0:
+		if strings.Count(s, ",") == 1 && len(s)-lastComma-1 != 3 {
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastDot >= 0:
+		if strings.Count(s, ".") > 1 || len(s)-lastDot-1 == 3 {
+			// 15.000 or 1.500.000 -> Indonesian thousands separator
+			s = strings.ReplaceAll(s, ".", "")
+		}
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+// ParseDate tries each layout in order, ignoring any trailing time component.
+// Month names may be Indonesian ("3 Agustus 2025", "03 Agt 2025") or English,
+// in full or abbreviated; layouts spell them "Jan".
+func ParseDate(s string, layouts []string) (time.Time, error) {
+	s = monthNamePattern.ReplaceAllStringFunc(strings.TrimSpace(s), func(word string) string {
+		if abbr, ok := monthAbbreviations[strings.ToLower(word)]; ok {
+			return abbr
+		}
+		return word
+	})
+	candidates := []string{s}
+	if i := strings.IndexAny(s, " T"); i > 0 {
+		candidates = append(candidates, s[:i])
+	}
+
+	for _, c := range candidates {
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, c); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date: %q", s)
+}
+
+// detectDelimiter peeks at the header line and picks ';' when it dominates ','
+func detectDelimiter(br *bufio.Reader) rune {
+	peek, _ := br.Peek(4096)
+	firstLine := string(peek)
+	if i := strings.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		return ';'
+	}
+	return ','
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
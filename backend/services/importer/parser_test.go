@@ -0,0 +1,176 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"15000", 15000},
+		{"Rp 15.000", 15000},
+		{"Rp15.000,50", 15000.5},
+		{"1.500.000", 1500000},
+		{"1,234.50", 1234.5},
+		{"12,5", 12.5},
+		{"2", 2},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.input)
+		if err != nil {
+			t.Errorf("ParseAmount(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAmount(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := ParseAmount(""); err == nil {
+		t.Error("ParseAmount(\"\") should fail")
+	}
+}
+
+func TestParseMokaExport(t *testing.T) {
+	adapter, ok := Get("moka")
+	if !ok {
+		t.Fatal("moka adapter not registered")
+	}
+
+	csv := "Date,Category,Item,SKU,Items Sold,Gross Sales\n" +
+		"2025-01-05,Minuman,Es Kopi Susu,KOPI-01,4,\"72,000\"\n" +
+		"2025-01-05,Minuman,,KOPI-02,1,18000\n" +
+		"05/01/2025,Snack,Roti Bakar,,0,0\n"
+
+	result, err := Parse(adapter, strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(result.Rows) != 1 {
+		t.Fatalf("expected 1 valid row, got %d", len(result.Rows))
+	}
+	row := result.Rows[0]
+	if row.ProductName != "Es Kopi Susu" || row.Quantity != 4 || row.UnitPrice != 18000 {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	if !row.Date.Equal(time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected date: %v", row.Date)
+	}
+
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 row errors, got %d: %+v", len(result.Errors), result.Errors)
+	}
+	if result.Mapping[FieldQuantity] != "Items Sold" {
+		t.Errorf("quantity mapped to %q", result.Mapping[FieldQuantity])
+	}
+}
+
+func TestParseSemicolonDelimited(t *testing.T) {
+	adapter, _ := Get("accurate")
+
+	csv := "Tanggal;Kode Barang;Nama Barang;Kuantitas;Harga Satuan\n" +
+		"05/01/2025;BRG-1;Gula Aren 500g;3;42.000\n"
+
+	result, err := Parse(adapter, strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0].UnitPrice != 42000 || result.Rows[0].SKU != "BRG-1" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
+
+func TestParseMissingRequiredColumn(t *testing.T) {
+	adapter, _ := Get("bukuwarung")
+
+	_, err := Parse(adapter, strings.NewReader("Tanggal,Nama Barang\n01/01/2025,Kopi\n"))
+	if err == nil {
+		t.Fatal("expected error for missing quantity column")
+	}
+}
+
+func TestParseTableSerialDates(t *testing.T) {
+	adapter, _ := Get("accurate")
+
+	rows := [][]string{
+		{"Tanggal", "Kode Barang", "Nama Barang", "Kuantitas", "Harga Satuan"},
+		{"45662", "BRG-1", "Gula Aren 500g", "3", "42000"},
+		{"05/01/2025", "BRG-2", "Kopi Bubuk", "1", "25000"},
+	}
+
+	result, err := ParseTable(adapter, rows)
+	if err != nil {
+		t.Fatalf("ParseTable failed: %v", err)
+	}
+	if len(result.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d: %+v", len(result.Rows), result.Errors)
+	}
+	want := time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)
+	for _, row := range result.Rows {
+		if !row.Date.Equal(want) {
+			t.Errorf("row %d date = %v, want %v", row.Line, row.Date, want)
+		}
+	}
+}
+
+func TestDateFormats(t *testing.T) {
+	want := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct{ pattern, value string }{
+		{"DD/MM/YYYY", "03/08/2025"},
+		{"D/M/YYYY", "3/8/2025"},
+		{"MM/DD/YYYY", "08/03/2025"},
+		{"D MMMM YYYY", "3 Agustus 2025"},
+		{"DD MMM YY", "03 Agt 25"},
+		{"YYYY-MM-DD", "2025-08-03"},
+	} {
+		layout, err := DateLayout(tt.pattern)
+		if err != nil {
+			t.Errorf("DateLayout(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got, err := ParseDate(tt.value, []string{layout}); err != nil || !got.Equal(want) {
+			t.Errorf("%s: ParseDate(%q) = %v, %v", tt.pattern, tt.value, got, err)
+		}
+	}
+	if _, err := DateLayout("DD/MM"); err == nil {
+		t.Error("a pattern without a year should be rejected")
+	}
+	if got, err := ParseDate("17 Desember 2024", []string{"2 Jan 2006"}); err != nil || got.Month() != time.December {
+		t.Errorf("Indonesian month name: %v, %v", got, err)
+	}
+}
+
+func TestParseTemplateWithFormats(t *testing.T) {
+	adapter, ok := Get(TemplateAdapterName)
+	if !ok {
+		t.Fatal("template adapter not registered")
+	}
+	adapter, err := adapter.WithFormats("MM/DD/YYYY", ",")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csv := "product_name;quantity;sale_date;price\n" +
+		"Kopi Susu;2;12/31/2025;1.500\n" +
+		"Teh Manis;1;31/12/2025;8.000,5\n" +
+		"Roti;banyak;12/30/2025;5000\n"
+	result, err := Parse(adapter, strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rows) != 2 || result.Rows[0].UnitPrice != 1500 || result.Rows[1].UnitPrice != 8000.5 {
+		t.Fatalf("unexpected rows: %+v", result.Rows)
+	}
+	if !result.Rows[1].Date.Equal(time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("day-first fallback: %v", result.Rows[1].Date)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 4 || result.Errors[0].Column != "quantity" || result.Errors[0].Value != "banyak" {
+		t.Errorf("unexpected errors: %+v", result.Errors)
+	}
+}
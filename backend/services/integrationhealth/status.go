@@ -0,0 +1,55 @@
+package integrationhealth
+
+import (
+	"time"
+)
+
+// Integration statuses, from best to worst
+const (
+	StatusHealthy       = "healthy"        // Last run succeeded recently
+	StatusStale         = "stale"          // No success within the staleness window
+	StatusDegraded      = "degraded"       // Recent failures, but fewer than FailingStreak in a row
+	StatusFailing       = "failing"        // FailingStreak or more failures in a row, or rejected credentials
+	StatusNotConfigured = "not_configured" // Not set up; not counted in the overall status
+)
+
+// FailingStreak is the number of consecutive failures that marks an integration failing
+const FailingStreak = 3
+
+// Run is what is known about an integration's recent runs
+type Run struct {
+	Configured    bool
+	AuthFailed    bool // The provider rejected the stored credentials
+	LastSuccessAt *time.Time
+	LastFailureAt *time.Time
+	FailureStreak int
+}
+
+// Status classifies an integration. staleAfter is how long it may go without a
+// successful run before its data is considered stale; zero disables the check.
+func Status(run Run, staleAfter time.Duration, now time.Time) string {
+	switch {
+	case !run.Configured:
+		return StatusNotConfigured
+	case run.AuthFailed || run.FailureStreak >= FailingStreak:
+		return StatusFailing
+	case run.FailureStreak > 0:
+		return StatusDegraded
+	case staleAfter > 0 && (run.LastSuccessAt == nil || now.Sub(*run.LastSuccessAt) > staleAfter):
+		return StatusStale
+	}
+	return StatusHealthy
+}
+
+// Overall returns the worst status among configured integrations, or
+// StatusNotConfigured when none are configured
+func Overall(statuses []string) string {
+	rank := map[string]int{StatusHealthy: 1, StatusStale: 2, StatusDegraded: 3, StatusFailing: 4}
+	worst := StatusNotConfigured
+	for _, s := range statuses {
+		if rank[s] > rank[worst] {
+			worst = s
+		}
+	}
+	return worst
+}
@@ -0,0 +1,45 @@
+package integrationhealth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatus(t *testing.T) {
+	now := time.Date(2026, 5, 10, 9, 0, 0, 0, time.UTC)
+	recent := now.Add(-time.Hour)
+	old := now.Add(-10 * 24 * time.Hour)
+	week := 7 * 24 * time.Hour
+
+	cases := []struct {
+		name string
+		run  Run
+		want string
+	}{
+		{"not configured", Run{}, StatusNotConfigured},
+		{"healthy", Run{Configured: true, LastSuccessAt: &recent}, StatusHealthy},
+		{"never succeeded", Run{Configured: true}, StatusStale},
+		{"stale", Run{Configured: true, LastSuccessAt: &old}, StatusStale},
+		{"one failure", Run{Configured: true, LastSuccessAt: &recent, FailureStreak: 1}, StatusDegraded},
+		{"failure streak", Run{Configured: true, LastSuccessAt: &recent, FailureStreak: FailingStreak}, StatusFailing},
+		{"auth failed", Run{Configured: true, LastSuccessAt: &recent, AuthFailed: true}, StatusFailing},
+	}
+	for _, c := range cases {
+		if got := Status(c.run, week, now); got != c.want {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+	}
+
+	if got := Status(Run{Configured: true}, 0, now); got != StatusHealthy {
+		t.Errorf("no staleness window: got %s", got)
+	}
+}
+
+func TestOverall(t *testing.T) {
+	if got := Overall([]string{StatusHealthy, StatusNotConfigured, StatusDegraded, StatusStale}); got != StatusDegraded {
+		t.Errorf("got %s, want degraded", got)
+	}
+	if got := Overall([]string{StatusNotConfigured}); got != StatusNotConfigured {
+		t.Errorf("got %s, want not_configured", got)
+	}
+}
@@ -0,0 +1,63 @@
+package inventory
+
+import "math"
+
+// Movement kinds
+const (
+	KindPurchase   = "purchase"   // Stock received from a supplier
+	KindSale       = "sale"       // Stock sold, or returned when a sale is corrected
+	KindAdjustment = "adjustment" // Stock takes, breakage, spoilage and other corrections
+)
+
+// Stock statuses
+const (
+	StatusInStock    = "in_stock"
+	StatusLow        = "low"
+	StatusOut        = "out_of_stock"
+	StatusNotTracked = "not_tracked"
+)
+
+// Delta is the change a movement of kind makes to the stock on hand. Purchases
+// add quantity; adjustments carry their own sign. Sales are recorded by the
+// sales that cause them and are not accepted here.
+func Delta(kind string, quantity float64) (float64, bool) {
+	switch kind {
+	case KindPurchase:
+		return quantity, quantity > 0
+	case KindAdjustment:
+		return quantity, quantity != 0
+	}
+	return 0, false
+}
+
+// Status classifies the stock on hand against the reorder point. A nil stock
+// on hand means the product's stock was never recorded.
+func Status(onHand, reorderPoint *float64) string {
+	switch {
+	case onHand == nil:
+		return StatusNotTracked
+	case *onHand <= 0:
+		return StatusOut
+	case IsLow(*onHand, reorderPoint):
+		return StatusLow
+	}
+	return StatusInStock
+}
+
+// IsLow reports whether onHand has reached the reorder point
+func IsLow(onHand float64, reorderPoint *float64) bool {
+	return reorderPoint != nil && onHand <= *reorderPoint
+}
+
+// DaysOfCover is how many days the stock on hand lasts at the forecast daily
+// sales rate, rounded down. It is nil when there is no forecast demand.
+func DaysOfCover(onHand, dailyRate float64) *int {
+	if dailyRate <= 0 {
+		return nil
+	}
+	days := 0
+	if onHand > 0 {
+		days = int(math.Floor(onHand/dailyRate + 1e-9))
+	}
+	return &days
+}
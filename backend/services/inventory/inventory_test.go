@@ -0,0 +1,51 @@
+package inventory
+
+import "testing"
+
+func ptr(f float64) *float64 { return &f }
+
+func TestStatus(t *testing.T) {
+	cases := []struct {
+		onHand, reorder *float64
+		want            string
+	}{
+		{nil, ptr(5), StatusNotTracked},
+		{ptr(0), ptr(5), StatusOut},
+		{ptr(-2), nil, StatusOut},
+		{ptr(5), ptr(5), StatusLow},
+		{ptr(6), ptr(5), StatusInStock},
+		{ptr(1), nil, StatusInStock},
+	}
+	for _, c := range cases {
+		if got := Status(c.onHand, c.reorder); got != c.want {
+			t.Errorf("Status(%v, %v) = %s, want %s", c.onHand, c.reorder, got, c.want)
+		}
+	}
+}
+
+func TestDelta(t *testing.T) {
+	if d, ok := Delta(KindPurchase, 10); !ok || d != 10 {
+		t.Errorf("purchase: %v %v", d, ok)
+	}
+	if _, ok := Delta(KindPurchase, -1); ok {
+		t.Error("negative purchase accepted")
+	}
+	if d, ok := Delta(KindAdjustment, -3); !ok || d != -3 {
+		t.Errorf("adjustment: %v %v", d, ok)
+	}
+	if _, ok := Delta(KindSale, 1); ok {
+		t.Error("sale movements must come from sales")
+	}
+}
+
+func TestDaysOfCover(t *testing.T) {
+	if DaysOfCover(10, 0) != nil {
+		t.Error("no demand should have no cover")
+	}
+	if d := DaysOfCover(10, 3); d == nil || *d != 3 {
+		t.Errorf("cover = %v, want 3", d)
+	}
+	if d := DaysOfCover(-4, 2); d == nil || *d != 0 {
+		t.Errorf("negative stock cover = %v, want 0", d)
+	}
+}
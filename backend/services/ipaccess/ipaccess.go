@@ -0,0 +1,61 @@
+package ipaccess
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ParsePrefix parses an allowlist entry: a CIDR range or a single address,
+// which is treated as a /32 (IPv4) or /128 (IPv6) range
+func ParsePrefix(entry string) (netip.Prefix, error) {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		p, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", entry)
+		}
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q", entry)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Allowed reports whether ip falls in any of the allowlist entries. An empty
+// allowlist allows every address; entries that fail to parse are ignored.
+func Allowed(ip string, entries []string) bool {
+	if len(entries) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, e := range entries {
+		if p, err := ParsePrefix(e); err == nil && p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Network returns the coarse network an address belongs to (/24 for IPv4, /48
+// for IPv6), used to notice logins from a new location without a GeoIP database
+func Network(ip string) string {
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	bits := 24
+	if addr.Is6() {
+		bits = 48
+	}
+	p, _ := addr.Prefix(bits)
+	return p.String()
+}
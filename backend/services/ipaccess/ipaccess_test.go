@@ -0,0 +1,43 @@
+package ipaccess
+
+import "testing"
+
+func TestAllowed(t *testing.T) {
+	entries := []string{"203.0.113.0/24", "198.51.100.7", "2001:db8::/32"}
+	cases := map[string]bool{
+		"203.0.113.45":       true,
+		"198.51.100.7":       true,
+		"198.51.100.8":       false,
+		"::ffff:203.0.113.9": true,
+		"2001:db8:1::1":      true,
+		"10.0.0.1":           false,
+		"not-an-ip":          false,
+	}
+	for ip, want := range cases {
+		if got := Allowed(ip, entries); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", ip, got, want)
+		}
+	}
+	if !Allowed("10.0.0.1", nil) {
+		t.Error("empty allowlist should allow every address")
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	p, err := ParsePrefix("203.0.113.45/24")
+	if err != nil || p.String() != "203.0.113.0/24" {
+		t.Errorf("ParsePrefix = %v, %v", p, err)
+	}
+	if _, err := ParsePrefix("203.0.113.300"); err == nil {
+		t.Error("expected invalid address to fail")
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	if got := Network("203.0.113.45"); got != "203.0.113.0/24" {
+		t.Errorf("Network = %q", got)
+	}
+	if got := Network("2001:db8:1:2::1"); got != "2001:db8:1::/48" {
+		t.Errorf("Network = %q", got)
+	}
+}
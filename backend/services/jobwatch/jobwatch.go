@@ -0,0 +1,61 @@
+package jobwatch
+
+import "sync"
+
+// Hub wakes up the watchers of a job when it changes. Wake-ups carry no data
+// and coalesce: a watcher that is busy sees one pending wake-up however many
+// changes happened, and reloads the job's state itself.
+type Hub struct {
+	mu       sync.Mutex
+	watchers map[string]map[chan struct{}]struct{}
+}
+
+// NewHub returns an empty hub
+func NewHub() *Hub {
+	return &Hub{watchers: map[string]map[chan struct{}]struct{}{}}
+}
+
+// Watch returns a channel that receives when the job changes, and a function
+// that stops watching
+func (h *Hub) Watch(jobID string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	if h.watchers[jobID] == nil {
+		h.watchers[jobID] = map[chan struct{}]struct{}{}
+	}
+	h.watchers[jobID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.watchers[jobID], ch)
+			if len(h.watchers[jobID]) == 0 {
+				delete(h.watchers, jobID)
+			}
+			h.mu.Unlock()
+		})
+	}
+}
+
+// Notify wakes up the watchers of the jobs; it never blocks
+func (h *Hub) Notify(jobIDs ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, id := range jobIDs {
+		for ch := range h.watchers[id] {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Watchers returns how many watchers a job has
+func (h *Hub) Watchers(jobID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.watchers[jobID])
+}
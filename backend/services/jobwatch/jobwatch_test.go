@@ -0,0 +1,48 @@
+package jobwatch
+
+import "testing"
+
+func TestNotifyCoalesces(t *testing.T) {
+	h := NewHub()
+	ch, stop := h.Watch("job-1")
+	defer stop()
+	other, stopOther := h.Watch("job-2")
+	defer stopOther()
+
+	h.Notify("job-1")
+	h.Notify("job-1")
+	select {
+	case <-ch:
+	default:
+		t.Fatal("expected a wake-up")
+	}
+	select {
+	case <-ch:
+		t.Fatal("wake-ups should coalesce")
+	default:
+	}
+	select {
+	case <-other:
+		t.Fatal("another job's watcher should not wake up")
+	default:
+	}
+}
+
+func TestStopWatching(t *testing.T) {
+	h := NewHub()
+	_, stop := h.Watch("job-1")
+	_, stop2 := h.Watch("job-1")
+	if n := h.Watchers("job-1"); n != 2 {
+		t.Fatalf("watchers = %d, want 2", n)
+	}
+	stop()
+	stop()
+	if n := h.Watchers("job-1"); n != 1 {
+		t.Fatalf("watchers = %d, want 1", n)
+	}
+	stop2()
+	if n := h.Watchers("job-1"); n != 0 {
+		t.Fatalf("watchers = %d, want 0", n)
+	}
+	h.Notify("job-1") // No watchers left; must not block
+}
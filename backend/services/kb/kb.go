@@ -0,0 +1,97 @@
+// Package kb holds the rules for curated knowledge-base articles: categories,
+// slugs and how retrieved articles are ranked as citations.
+package kb
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Article categories
+const (
+	CategoryPermits    = "perizinan"
+	CategoryMarketing  = "pemasaran"
+	CategoryFinance    = "keuangan"
+	CategoryOperations = "operasional"
+	CategoryGeneral    = "umum"
+)
+
+// Categories lists the valid categories in display order
+var Categories = []string{CategoryPermits, CategoryMarketing, CategoryFinance, CategoryOperations, CategoryGeneral}
+
+// Article statuses. Only published articles are embedded and visible to users.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+// maxSlugLength keeps slugs readable in URLs
+const maxSlugLength = 80
+
+// ValidCategory reports whether category is one of Categories
+func ValidCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Slugify turns a title into a lowercase, hyphen-separated URL slug, e.g.
+// "Cara Mengurus NIB di OSS" becomes "cara-mengurus-nib-di-oss"
+func Slugify(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+	})
+	slug := ""
+	for _, w := range words {
+		if len(slug)+len(w)+1 > maxSlugLength {
+			break
+		}
+		if slug != "" {
+			slug += "-"
+		}
+		slug += w
+	}
+	return slug
+}
+
+// Hit is a retrieved chunk of an article
+type Hit struct {
+	ArticleID string
+	Pinned    bool
+	Score     float64
+}
+
+// Rank keeps each article's best-scoring hit and orders the articles with
+// pinned ones first, then by score, capped at limit (0 means no cap). Pinned
+// articles are the curated answer to a topic, so they are cited ahead of
+// scraped content even when another source scores slightly higher.
+func Rank(hits []Hit, limit int) []Hit {
+	best := map[string]int{}
+	var out []Hit
+	for _, h := range hits {
+		if i, ok := best[h.ArticleID]; ok {
+			if h.Score > out[i].Score {
+				out[i] = h
+			}
+			continue
+		}
+		best[h.ArticleID] = len(out)
+		out = append(out, h)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Pinned != out[j].Pinned {
+			return out[i].Pinned
+		}
+		return out[i].Score > out[j].Score
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
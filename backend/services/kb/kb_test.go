@@ -0,0 +1,55 @@
+package kb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Cara Mengurus NIB di OSS":         "cara-mengurus-nib-di-oss",
+		"  Sertifikat Halal: Self-Declare": "sertifikat-halal-self-declare",
+		"Promosi 11.11 & Harbolnas!":       "promosi-11-11-harbolnas",
+		"":                                 "",
+	}
+	for title, want := range tests {
+		if got := Slugify(title); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", title, got, want)
+		}
+	}
+
+	long := Slugify(strings.Repeat("panduan ", 20))
+	if len(long) > maxSlugLength || strings.HasSuffix(long, "-") {
+		t.Errorf("long slug = %q", long)
+	}
+}
+
+func TestValidCategory(t *testing.T) {
+	if !ValidCategory(CategoryPermits) || ValidCategory("Perizinan") || ValidCategory("") {
+		t.Error("unexpected category validation")
+	}
+}
+
+func TestRank(t *testing.T) {
+	hits := []Hit{
+		{ArticleID: "a", Score: 0.6},
+		{ArticleID: "b", Score: 0.9},
+		{ArticleID: "a", Score: 0.8},
+		{ArticleID: "p", Pinned: true, Score: 0.5},
+		{ArticleID: "c", Score: 0.7},
+	}
+
+	var ids []string
+	for _, h := range Rank(hits, 3) {
+		ids = append(ids, h.ArticleID)
+	}
+	if want := []string{"p", "b", "a"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Rank = %v, want %v", ids, want)
+	}
+
+	ranked := Rank(hits, 0)
+	if len(ranked) != 4 || ranked[2].Score != 0.8 {
+		t.Errorf("best hit per article not kept: %+v", ranked)
+	}
+}
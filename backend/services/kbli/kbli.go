@@ -0,0 +1,129 @@
+package kbli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Levels of the KBLI 2020 hierarchy
+const (
+	LevelSection  = "kategori"       // A-U
+	LevelDivision = "golongan_pokok" // 2 digits
+	LevelGroup    = "golongan"       // 3 digits
+	LevelClass    = "subgolongan"    // 4 digits
+	LevelSubclass = "kelompok"       // 5 digits, the code used for business licensing
+)
+
+var codePattern = regexp.MustCompile(`^([A-U]|\d{2,5})$`)
+
+// Sections are the top-level KBLI 2020 categories
+var Sections = map[string]string{
+	"A": "Pertanian, Kehutanan dan Perikanan",
+	"B": "Pertambangan dan Penggalian",
+	"C": "Industri Pengolahan",
+	"D": "Pengadaan Listrik, Gas, Uap/Air Panas dan Udara Dingin",
+	"E": "Treatment Air, Treatment Air Limbah, Treatment dan Pemulihan Material Sampah, dan Aktivitas Remediasi",
+	"F": "Konstruksi",
+	"G": "Perdagangan Besar dan Eceran; Reparasi dan Perawatan Mobil dan Sepeda Motor",
+	"H": "Pengangkutan dan Pergudangan",
+	"I": "Penyediaan Akomodasi dan Penyediaan Makan Minum",
+	"J": "Informasi dan Komunikasi",
+	"K": "Aktivitas Keuangan dan Asuransi",
+	"L": "Real Estat",
+	"M": "Aktivitas Profesional, Ilmiah dan Teknis",
+	"N": "Aktivitas Penyewaan dan Sewa Guna Usaha Tanpa Hak Opsi, Ketenagakerjaan, Agen Perjalanan dan Penunjang Usaha Lainnya",
+	"O": "Administrasi Pemerintahan, Pertahanan dan Jaminan Sosial Wajib",
+	"P": "Pendidikan",
+	"Q": "Aktivitas Kesehatan Manusia dan Aktivitas Sosial",
+	"R": "Kesenian, Hiburan dan Rekreasi",
+	"S": "Aktivitas Jasa Lainnya",
+	"T": "Aktivitas Rumah Tangga sebagai Pemberi Kerja; Aktivitas yang Menghasilkan Barang dan Jasa oleh Rumah Tangga yang Digunakan untuk Memenuhi Kebutuhan Sendiri",
+	"U": "Aktivitas Badan Internasional dan Badan Ekstra Internasional Lainnya",
+}
+
+// sectionRanges maps the last division of each section to the section, in order
+var sectionRanges = []struct {
+	last    int
+	section string
+}{
+	{3, "A"}, {9, "B"}, {33, "C"}, {35, "D"}, {39, "E"}, {43, "F"}, {47, "G"}, {53, "H"}, {56, "I"}, {63, "J"},
+	{66, "K"}, {68, "L"}, {75, "M"}, {82, "N"}, {84, "O"}, {85, "P"}, {88, "Q"}, {93, "R"}, {96, "S"}, {98, "T"}, {99, "U"},
+}
+
+// Category is one entry of the KBLI 2020 classification
+type Category struct {
+	Code       string `json:"code"`
+	Title      string `json:"title"`
+	Level      string `json:"level"`
+	Section    string `json:"section"`
+	ParentCode string `json:"parent_code,omitempty"`
+}
+
+// ParseCode returns the level, section and parent of a KBLI code. A division's
+// parent is its section; deeper codes drop their last digit.
+func ParseCode(code string) (level, section, parent string, err error) {
+	if !codePattern.MatchString(code) {
+		return "", "", "", fmt.Errorf("invalid KBLI code %q", code)
+	}
+	if len(code) == 1 {
+		return LevelSection, code, "", nil
+	}
+	division, _ := strconv.Atoi(code[:2])
+	for _, r := range sectionRanges {
+		if division <= r.last {
+			section = r.section
+			break
+		}
+	}
+	if section == "" || division == 0 {
+		return "", "", "", fmt.Errorf("invalid KBLI division in %q", code)
+	}
+	switch len(code) {
+	case 2:
+		return LevelDivision, section, section, nil
+	case 3:
+		return LevelGroup, section, code[:2], nil
+	case 4:
+		return LevelClass, section, code[:3], nil
+	default:
+		return LevelSubclass, section, code[:4], nil
+	}
+}
+
+// ParseCSV reads "code,title" lines of the KBLI 2020 list. Headers and blank
+// lines are skipped; titles may be quoted and contain commas.
+func ParseCSV(r io.Reader) ([]Category, error) {
+	var out []Category
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		code, title, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ",")
+		code = strings.Trim(strings.TrimSpace(code), `"`)
+		title = strings.Trim(strings.TrimSpace(title), `"`)
+		if !ok || code == "" || title == "" {
+			continue
+		}
+		level, section, parent, err := ParseCode(code)
+		if err != nil {
+			if line == 1 {
+				continue // Header
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, Category{Code: code, Title: title, Level: level, Section: section, ParentCode: parent})
+	}
+	return out, scanner.Err()
+}
+
+// Label formats a category for prompts and display, e.g. "56101 Restoran"
+func Label(code, title string) string {
+	if code == "" {
+		return title
+	}
+	return code + " " + title
+}
@@ -0,0 +1,51 @@
+package kbli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCode(t *testing.T) {
+	cases := map[string][3]string{
+		"I":     {LevelSection, "I", ""},
+		"56":    {LevelDivision, "I", "I"},
+		"561":   {LevelGroup, "I", "56"},
+		"5610":  {LevelClass, "I", "561"},
+		"56101": {LevelSubclass, "I", "5610"},
+		"01111": {LevelSubclass, "A", "0111"},
+		"47911": {LevelSubclass, "G", "4791"},
+	}
+	for code, want := range cases {
+		level, section, parent, err := ParseCode(code)
+		if err != nil || level != want[0] || section != want[1] || parent != want[2] {
+			t.Errorf("ParseCode(%q) = %q, %q, %q, %v", code, level, section, parent, err)
+		}
+	}
+	for _, bad := range []string{"", "Z", "5", "561011", "00", "ab"} {
+		if _, _, _, err := ParseCode(bad); err == nil {
+			t.Errorf("ParseCode(%q) should fail", bad)
+		}
+	}
+}
+
+func TestSectionsCoverRanges(t *testing.T) {
+	for _, r := range sectionRanges {
+		if Sections[r.section] == "" {
+			t.Errorf("section %s has no title", r.section)
+		}
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	csv := "kode,judul\nI,Penyediaan Akomodasi\n56101,\"Restoran, Rumah Makan\"\n\n"
+	got, err := ParseCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1].Title != "Restoran, Rumah Makan" || got[1].Section != "I" || got[1].ParentCode != "5610" {
+		t.Errorf("ParseCSV = %+v", got)
+	}
+	if _, err := ParseCSV(strings.NewReader("56101,Restoran\nxx,Bad\n")); err == nil {
+		t.Error("expected invalid code to fail")
+	}
+}
@@ -1,35 +1,160 @@
 package kolosal
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/bantuaku/backend/logger"
+	"github.com/bantuaku/backend/services/providerlog"
 )
 
 const (
 	KolosalAPIBaseURL = "https://api.kolosal.ai"
-	DefaultTimeout    = 30 * time.Second
+	DefaultTimeout    = 30 * time.Second // Per attempt, for calls that aren't streamed
+	StreamTimeout     = 5 * time.Minute  // For a whole streamed completion
+	DefaultMaxRetries = 2
+)
+
+// Retry backoff bounds; Retry-After is honored up to retryMaxDelay
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 8 * time.Second
+)
+
+// maxErrorBody bounds how much of an error response is kept in an APIError
+const maxErrorBody = 500
+
+// API paths of the calls a Recorder receives
+const (
+	EndpointChatCompletions = "/v1/chat/completions"
+	EndpointEmbeddings      = "/v1/embeddings"
+	EndpointOCR             = "/ocr"
+	EndpointOCRForm         = "/ocrform"
 )
 
 // Client represents a Kolosal.ai API client
 type Client struct {
 	APIKey     string
-	HTTPClient *http.Client
+	HTTPClient *http.Client // Has no overall timeout; calls are bounded by their context and Timeout
 	BaseURL    string
+	Timeout    time.Duration // Per attempt, on top of the caller's context; 0 relies on the context alone
+	MaxRetries int           // Retries of 429, 5xx and network errors
+	Recorder   Recorder      // Optional; receives chat completion and embeddings calls
+	Routing    *Routing      // Model and provider routing for OpenRouter-compatible APIs; nil for Kolosal
+
+	// ModelFor, when set, picks the model a chat completion is sent to, e.g. a
+	// cheaper one once a spending cap is near
+	ModelFor func(ctx context.Context, model string) string
+}
+
+// DefaultModel asks the provider for its default model. With Routing set it is
+// replaced by the first routed model.
+const DefaultModel = "default"
+
+// Routing selects models and upstream providers on OpenRouter-compatible APIs.
+// Kolosal ignores it.
+type Routing struct {
+	Models   []string             // Preferred model first, then fallbacks tried in order when it is unavailable
+	Provider *ProviderPreferences // nil lets the API choose
+}
+
+// ProviderPreferences restricts which upstream providers serve a request
+type ProviderPreferences struct {
+	Order          []string  `json:"order,omitempty"`           // Providers tried first, in order
+	AllowFallbacks *bool     `json:"allow_fallbacks,omitempty"` // false fails rather than using providers outside Order
+	MaxPrice       *MaxPrice `json:"max_price,omitempty"`
+}
+
+// MaxPrice caps what a request may cost, in USD per million tokens
+type MaxPrice struct {
+	Prompt     float64 `json:"prompt,omitempty"`
+	Completion float64 `json:"completion,omitempty"`
+}
+
+// apply fills in the routed models and provider preferences of a request. An
+// explicitly chosen model stays first, ahead of the routed fallbacks.
+func (r *Routing) apply(req *ChatCompletionRequest) {
+	if r == nil {
+		return
+	}
+	if len(r.Models) > 0 {
+		if req.Model == "" || req.Model == DefaultModel {
+			req.Model = r.Models[0]
+		}
+		req.Models = []string{req.Model}
+		for _, m := range r.Models {
+			if m != req.Model {
+				req.Models = append(req.Models, m)
+			}
+		}
+	}
+	req.Provider = r.Provider
+}
+
+// Call is one chat completion or embeddings request and its outcome
+type Call struct {
+	Endpoint   string // EndpointChatCompletions or EndpointEmbeddings
+	Request    []byte // JSON request body
+	Response   []byte // Response body; empty when no response was received
+	StatusCode int    // 0 when no response was received
+	Duration   time.Duration
+	Err        error
+}
+
+// Recorder receives calls made by a Client, e.g. to log them for debugging.
+// It runs synchronously after each call and must not retain the slices.
+type Recorder interface {
+	Record(ctx context.Context, call Call)
+}
+
+// APIError is a non-200 response from the Kolosal.ai API. Body is truncated
+// and has personal data masked, since it often echoes the request.
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // From the Retry-After header, if any
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %d - %s", e.StatusCode, e.Body)
+}
+
+// Transient reports whether the request may succeed if retried
+func (e *APIError) Transient() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	text := providerlog.ScrubText(string(body))
+	if len(text) > maxErrorBody {
+		text = text[:maxErrorBody] + "..."
+	}
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: text}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		apiErr.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return apiErr
 }
 
 // NewClient creates a new Kolosal.ai API client
 func NewClient(apiKey string) *Client {
 	return &Client{
-		APIKey: apiKey,
-		HTTPClient: &http.Client{
-			Timeout: DefaultTimeout,
-		},
-		BaseURL: KolosalAPIBaseURL,
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{},
+		BaseURL:    KolosalAPIBaseURL,
+		Timeout:    DefaultTimeout,
+		MaxRetries: DefaultMaxRetries,
 	}
 }
 
@@ -39,17 +164,103 @@ type ChatCompletionRequest struct {
 	Messages    []ChatCompletionMessage `json:"messages"`
 	MaxTokens   int                     `json:"max_tokens,omitempty"`
 	Temperature float64                 `json:"temperature,omitempty"`
+	Tools       []Tool                  `json:"tools,omitempty"`
+	ToolChoice  interface{}             `json:"tool_choice,omitempty"` // "auto", "none" or a ForcedToolChoice
+	Stream      bool                    `json:"stream,omitempty"`      // Set by CreateChatCompletionStream
+
+	// Set from Client.Routing; only sent to OpenRouter-compatible APIs
+	Models   []string             `json:"models,omitempty"`
+	Provider *ProviderPreferences `json:"provider,omitempty"`
+}
+
+// ForcedToolChoice makes the model call the named function, for structured output
+func ForcedToolChoice(name string) map[string]interface{} {
+	return map[string]interface{}{"type": "function", "function": map[string]string{"name": name}}
 }
 
 // ChatCompletionMessage represents a message in a chat completion
 type ChatCompletionMessage struct {
-	Role    string `json:"role"` // "system", "user", "assistant"
-	Content string `json:"content"`
+	Role       string        `json:"role"` // "system", "user", "assistant", "tool"
+	Content    string        `json:"content"`
+	Parts      []ContentPart `json:"-"`                      // Sent instead of Content when set, e.g. to show a vision model an image
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`   // Set on assistant messages that call tools
+	ToolCallID string        `json:"tool_call_id,omitempty"` // Set on tool result messages
+}
+
+// ContentPart is one piece of a multimodal message
+type ContentPart struct {
+	Type     string    `json:"type"` // "text" or "image_url"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL points at an image, either by URL or as a data: URL
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+// ImagePart returns a content part carrying an image inline
+func ImagePart(data []byte, contentType string) ContentPart {
+	return ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)}}
+}
+
+// MarshalJSON sends Parts as the message content when they are set
+func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
+	type message ChatCompletionMessage
+	if len(m.Parts) == 0 {
+		return json.Marshal(message(m))
+	}
+	return json.Marshal(struct {
+		message
+		Content []ContentPart `json:"content"`
+	}{message(m), m.Parts})
+}
+
+// Tool describes a function the model may call
+type Tool struct {
+	Type     string       `json:"type"` // "function"
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction is a callable function with a JSON schema for its arguments
+type ToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolCall is a function call requested by the model
+type ToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON-encoded arguments
+	} `json:"function"`
 }
 
 // ChatCompletionResponse represents a chat completion response
 type ChatCompletionResponse struct {
+	Model   string       `json:"model,omitempty"` // The model that answered, which may be a routed fallback
 	Choices []ChatChoice `json:"choices"`
+	Usage   *Usage       `json:"usage,omitempty"`
+}
+
+// Usage is the tokens a completion consumed, as reported by the API
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// selectModel lets ModelFor replace the requested model
+func (c *Client) selectModel(ctx context.Context, req *ChatCompletionRequest) {
+	if c.ModelFor == nil {
+		return
+	}
+	if model := c.ModelFor(ctx, req.Model); model != "" {
+		req.Model = model
+	}
 }
 
 // ChatChoice represents a choice in the chat completion response
@@ -59,38 +270,299 @@ type ChatChoice struct {
 
 // CreateChatCompletion calls Kolosal.ai chat completions API
 func (c *Client) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
-	url := fmt.Sprintf("%s/v1/chat/completions", c.BaseURL)
+	c.selectModel(ctx, &req)
+	c.Routing.apply(&req)
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	body, err := c.post(ctx, EndpointChatCompletions, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var chatResp ChatCompletionResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
 
+	return &chatResp, nil
+}
+
+// ChatCompletionChunk is one event of a streamed chat completion
+type ChatCompletionChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// CreateChatCompletionStream streams a chat completion, calling onDelta with
+// each piece of content as it arrives, and returns the assembled message like
+// CreateChatCompletion. An error from onDelta stops the stream. Only failures
+// before the first event are retried.
+func (c *Client) CreateChatCompletionStream(ctx context.Context, req ChatCompletionRequest, onDelta func(content string) error) (*ChatCompletionResponse, error) {
+	req.Stream = true
+	c.selectModel(ctx, &req)
+	c.Routing.apply(&req)
 	reqBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	ctx, cancel := context.WithTimeout(ctx, StreamTimeout)
+	defer cancel()
+
+	started := time.Now()
+	var msg ChatCompletionMessage
+	var model string
+	status := 0
+	err = c.withRetries(ctx, EndpointChatCompletions, func(ctx context.Context) error {
+		httpReq, err := c.newRequest(ctx, EndpointChatCompletions, reqBody)
+		if err != nil {
+			return err
+		}
+		httpReq.Header.Set("Accept", "text/event-stream")
+		resp, err := c.HTTPClient.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("failed to send request: %w", err)
+		}
+		defer resp.Body.Close()
+		status = resp.StatusCode
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return newAPIError(resp, body)
+		}
+		return permanent(readChatStream(resp.Body, &msg, &model, onDelta))
+	})
+
+	result := &ChatCompletionResponse{Model: model, Choices: []ChatChoice{{Message: msg}}}
+	if c.Recorder != nil {
+		respBody, _ := json.Marshal(result)
+		c.Recorder.Record(ctx, Call{Endpoint: EndpointChatCompletions, Request: reqBody, Response: respBody, StatusCode: status, Duration: time.Since(started), Err: err})
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	return result, nil
+}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
+// readChatStream reads server-sent events into msg and model until the stream ends
+func readChatStream(r io.Reader, msg *ChatCompletionMessage, model *string, onDelta func(string) error) error {
+	msg.Role = "assistant"
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var chunk ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream event: %w", err)
+		}
+		if chunk.Model != "" {
+			*model = chunk.Model
+		}
+		for _, choice := range chunk.Choices {
+			for _, tc := range choice.Delta.ToolCalls {
+				for len(msg.ToolCalls) <= tc.Index {
+					msg.ToolCalls = append(msg.ToolCalls, ToolCall{Type: "function"})
+				}
+				call := &msg.ToolCalls[tc.Index]
+				if tc.ID != "" {
+					call.ID = tc.ID
+				}
+				call.Function.Name += tc.Function.Name
+				call.Function.Arguments += tc.Function.Arguments
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+			msg.Content += choice.Delta.Content
+			if onDelta != nil {
+				if err := onDelta(choice.Delta.Content); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil
+}
+
+// Replay sends a recorded request body to a chat completions or embeddings
+// endpoint once more and returns the raw response. It is neither retried nor
+// passed to the Recorder.
+func (c *Client) Replay(ctx context.Context, endpoint string, reqBody []byte) (int, []byte, error) {
+	if endpoint != EndpointChatCompletions && endpoint != EndpointEmbeddings {
+		return 0, nil, fmt.Errorf("cannot replay endpoint %q", endpoint)
+	}
+	resp, body, err := c.exchange(ctx, endpoint, reqBody)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+// post sends a JSON request, reports it to the Recorder and returns the body
+// of a 200 response
+func (c *Client) post(ctx context.Context, endpoint string, reqBody []byte) ([]byte, error) {
+	started := time.Now()
+	status, body, err := c.send(ctx, endpoint, reqBody)
+	if c.Recorder != nil {
+		c.Recorder.Record(ctx, Call{Endpoint: endpoint, Request: reqBody, Response: body, StatusCode: status, Duration: time.Since(started), Err: err})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// send posts a JSON request, retrying transient failures, and returns the
+// body of a 200 response or an *APIError
+func (c *Client) send(ctx context.Context, endpoint string, reqBody []byte) (int, []byte, error) {
+	var status int
+	var body []byte
+	err := c.withRetries(ctx, endpoint, func(ctx context.Context) error {
+		resp, respBody, err := c.exchange(ctx, endpoint, reqBody)
+		if err != nil {
+			return err
+		}
+		status, body = resp.StatusCode, respBody
+		if resp.StatusCode != http.StatusOK {
+			return newAPIError(resp, respBody)
+		}
+		return nil
+	})
+	return status, body, err
+}
 
+// exchange makes a single request, bounded by Timeout, and reads the response
+func (c *Client) exchange(ctx context.Context, endpoint string, reqBody []byte) (*http.Response, []byte, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	httpReq, err := c.newRequest(ctx, endpoint, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
 	resp, err := c.HTTPClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, string(body))
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
 	}
+	return resp, body, nil
+}
 
-	var chatResp ChatCompletionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+func (c *Client) newRequest(ctx context.Context, endpoint string, reqBody []byte) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
+	return httpReq, nil
+}
 
-	return &chatResp, nil
+// permanentError stops withRetries from retrying the error it wraps
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// withRetries runs fn, retrying rate limits, 5xx responses and network errors
+// up to MaxRetries times with exponential backoff, while ctx is not done.
+// Only metadata is logged, never request or response bodies.
+func (c *Client) withRetries(ctx context.Context, endpoint string, fn func(context.Context) error) error {
+	started := time.Now()
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx)
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if err == nil {
+			logger.Debug("Kolosal call succeeded", "endpoint", endpoint, "attempts", attempt+1, "duration_ms", time.Since(started).Milliseconds())
+			return nil
+		}
+		if attempt >= c.MaxRetries || !retryable(ctx, err) {
+			return err
+		}
+
+		wait := backoff(attempt, err, rand.Float64)
+		logger.Warn("Kolosal call failed, retrying", "endpoint", endpoint, "attempt", attempt+1, "wait_ms", wait.Milliseconds(), "error", err.Error())
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether err may go away on retry. Errors other than API
+// responses are network failures, including a per-attempt timeout.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Transient()
+	}
+	return true
+}
+
+// backoff returns the wait before retry number attempt (0-based): exponential
+// with jitter over the upper half, or the server's Retry-After if longer, both
+// capped at retryMaxDelay. jitter must return a value in [0, 1).
+func backoff(attempt int, err error, jitter func() float64) time.Duration {
+	d := retryBaseDelay << attempt
+	if d > retryMaxDelay || d <= 0 {
+		d = retryMaxDelay
+	}
+	d = d/2 + time.Duration(jitter()*float64(d/2))
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > d {
+		d = min(apiErr.RetryAfter, retryMaxDelay)
+	}
+	return d
 }
 
 // OCRRequest represents an OCR request
@@ -102,39 +574,24 @@ type OCRRequest struct {
 
 // OCRResponse represents an OCR response
 type OCRResponse struct {
-	Text string `json:"text"`
+	Text       string   `json:"text"`
+	Confidence *float64 `json:"confidence,omitempty"` // 0-1, when the API reports one
 }
 
 // OCR performs OCR on an image
 func (c *Client) OCR(ctx context.Context, req OCRRequest) (*OCRResponse, error) {
-	url := fmt.Sprintf("%s/ocr", c.BaseURL)
-
 	reqBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	body, err := c.post(ctx, EndpointOCR, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
-
-	resp, err := c.HTTPClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	var ocrResp OCRResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ocrResp); err != nil {
+	if err := json.Unmarshal(body, &ocrResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -155,36 +612,57 @@ type OCRFormResponse struct {
 
 // OCRForm performs OCR form extraction on an image
 func (c *Client) OCRForm(ctx context.Context, req OCRFormRequest) (*OCRFormResponse, error) {
-	url := fmt.Sprintf("%s/ocrform", c.BaseURL)
-
 	reqBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	body, err := c.post(ctx, EndpointOCRForm, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIKey))
+	var ocrFormResp OCRFormResponse
+	if err := json.Unmarshal(body, &ocrFormResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
 
-	resp, err := c.HTTPClient.Do(httpReq)
+	return &ocrFormResp, nil
+}
+
+// EmbeddingRequest represents an embeddings request
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse represents an embeddings response
+type EmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage struct {
+		TotalTokens int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// CreateEmbeddings calls Kolosal.ai embeddings API
+func (c *Client) CreateEmbeddings(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, string(body))
+	body, err := c.post(ctx, EndpointEmbeddings, reqBody)
+	if err != nil {
+		return nil, err
 	}
 
-	var ocrFormResp OCRFormResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ocrFormResp); err != nil {
+	var embResp EmbeddingResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &ocrFormResp, nil
+	return &embResp, nil
 }
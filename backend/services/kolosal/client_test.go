@@ -0,0 +1,146 @@
+package kolosal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testClient(url string) *Client {
+	c := NewClient("test-key")
+	c.BaseURL = url
+	return c
+}
+
+func TestCreateChatCompletionRetriesTransientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, `{"error":"overloaded, contact budi@toko.id"}`, http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Halo"}}]}`)
+	}))
+	defer srv.Close()
+
+	resp, err := testClient(srv.URL).CreateChatCompletion(context.Background(), ChatCompletionRequest{Model: "default"})
+	if err != nil {
+		t.Fatalf("CreateChatCompletion() error = %v", err)
+	}
+	if calls != 2 || resp.Choices[0].Message.Content != "Halo" {
+		t.Errorf("calls = %d, content = %q; want 2 calls and the second response", calls, resp.Choices[0].Message.Content)
+	}
+}
+
+func TestCreateChatCompletionDoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, `{"error":"bad request from budi@toko.id"}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	_, err := testClient(srv.URL).CreateChatCompletion(context.Background(), ChatCompletionRequest{})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("error = %v, want a 400 APIError", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if strings.Contains(apiErr.Body, "budi@toko.id") {
+		t.Errorf("APIError body keeps personal data: %q", apiErr.Body)
+	}
+}
+
+func TestBackoffHonorsRetryAfter(t *testing.T) {
+	noJitter := func() float64 { return 0 }
+	if got := backoff(1, errors.New("network"), noJitter); got != retryBaseDelay {
+		t.Errorf("backoff(1) = %v, want %v", got, retryBaseDelay)
+	}
+	limited := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 3 * time.Second}
+	if got := backoff(0, limited, noJitter); got != 3*time.Second {
+		t.Errorf("backoff with Retry-After = %v, want 3s", got)
+	}
+	limited.RetryAfter = time.Minute
+	if got := backoff(0, limited, noJitter); got != retryMaxDelay {
+		t.Errorf("backoff with long Retry-After = %v, want %v", got, retryMaxDelay)
+	}
+}
+
+func TestCreateChatCompletionStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Ha\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"c1\",\"function\":{\"name\":\"get_sales\",\"arguments\":\"{\\\"days\\\"\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\":30}\"}}]}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	var deltas []string
+	resp, err := testClient(srv.URL).CreateChatCompletionStream(context.Background(), ChatCompletionRequest{}, func(s string) error {
+		deltas = append(deltas, s)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("CreateChatCompletionStream() error = %v", err)
+	}
+	msg := resp.Choices[0].Message
+	if msg.Content != "Halo" || len(deltas) != 2 {
+		t.Errorf("content = %q from %d deltas, want \"Halo\" from 2", msg.Content, len(deltas))
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "c1" || msg.ToolCalls[0].Function.Arguments != `{"days":30}` {
+		t.Errorf("tool calls = %+v, want one assembled get_sales call", msg.ToolCalls)
+	}
+}
+
+func TestRoutingKeepsExplicitModelFirst(t *testing.T) {
+	routing := &Routing{Models: []string{"a/primary", "b/fallback"}}
+
+	req := ChatCompletionRequest{Model: DefaultModel}
+	routing.apply(&req)
+	if req.Model != "a/primary" || len(req.Models) != 2 {
+		t.Errorf("default model routed to %q with %v", req.Model, req.Models)
+	}
+
+	req = ChatCompletionRequest{Model: "c/alternate"}
+	routing.apply(&req)
+	if strings.Join(req.Models, ",") != "c/alternate,a/primary,b/fallback" {
+		t.Errorf("explicit model routed as %v", req.Models)
+	}
+}
+
+func TestModelForReplacesRequestedModel(t *testing.T) {
+	c := &Client{ModelFor: func(ctx context.Context, model string) string { return "cheap/model" }}
+	req := ChatCompletionRequest{Model: DefaultModel}
+	c.selectModel(context.Background(), &req)
+	if req.Model != "cheap/model" {
+		t.Errorf("model = %q, want cheap/model", req.Model)
+	}
+}
+
+func TestMessageWithPartsMarshalsContentArray(t *testing.T) {
+	msg := ChatCompletionMessage{Role: "user", Content: "ignored", Parts: []ContentPart{{Type: "text", Text: "Baca"}, ImagePart([]byte("png"), "image/png")}}
+	got, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"role":"user","content":[{"type":"text","text":"Baca"},{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}}]}`
+	if string(got) != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+
+	plain, _ := json.Marshal(ChatCompletionMessage{Role: "user", Content: "Halo"})
+	if string(plain) != `{"role":"user","content":"Halo"}` {
+		t.Errorf("Marshal() without parts = %s", plain)
+	}
+}
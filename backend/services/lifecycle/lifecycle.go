@@ -0,0 +1,81 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+)
+
+// Manager owns the lifetime of background work: it gives jobs a context that is
+// cancelled at shutdown, and lets shutdown wait for them to wind down
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	stopped bool
+	running int
+}
+
+// New returns a manager whose context is derived from parent
+func New(parent context.Context) *Manager {
+	ctx, cancel := context.WithCancel(parent)
+	return &Manager{ctx: ctx, cancel: cancel}
+}
+
+// Context is cancelled when the manager stops
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Go runs fn in a goroutine that Stop waits for. It runs nothing and returns
+// false once the manager has stopped.
+func (m *Manager) Go(fn func()) bool {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return false
+	}
+	m.wg.Add(1)
+	m.running++
+	m.mu.Unlock()
+
+	go func() {
+		defer func() {
+			m.mu.Lock()
+			m.running--
+			m.mu.Unlock()
+			m.wg.Done()
+		}()
+		fn()
+	}()
+	return true
+}
+
+// Running returns how many goroutines started by Go have not returned
+func (m *Manager) Running() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.running
+}
+
+// Stop cancels the manager's context and waits until every goroutine started
+// by Go has returned, or until ctx is done, returning its error then
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopped = true
+	m.mu.Unlock()
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
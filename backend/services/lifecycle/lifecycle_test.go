@@ -0,0 +1,45 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStopCancelsAndWaits(t *testing.T) {
+	m := New(context.Background())
+	finished := make(chan struct{})
+	m.Go(func() {
+		<-m.Context().Done()
+		time.Sleep(10 * time.Millisecond) // Winding down
+		close(finished)
+	})
+	if n := m.Running(); n != 1 {
+		t.Fatalf("running = %d, want 1", n)
+	}
+
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Stop returned before the job finished")
+	}
+	if m.Go(func() {}) {
+		t.Error("Go should refuse work after Stop")
+	}
+}
+
+func TestStopGivesUp(t *testing.T) {
+	m := New(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	m.Go(func() { <-release }) // Ignores cancellation
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.Stop(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Stop = %v, want deadline exceeded", err)
+	}
+}
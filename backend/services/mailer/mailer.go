@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/bantuaku/backend/logger"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends transactional email
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config holds SMTP settings; an empty Host selects the log mailer
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// New returns an SMTP mailer, or a mailer that only logs messages when SMTP is
+// not configured (development)
+func New(cfg Config) Mailer {
+	if cfg.Host == "" {
+		return LogMailer{}
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &SMTPMailer{cfg: cfg}
+}
+
+// SMTPMailer sends email through an SMTP relay, using STARTTLS when offered
+type SMTPMailer struct {
+	cfg Config
+}
+
+// Send delivers a message
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(m.cfg.Host, fmt.Sprint(m.cfg.Port))
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, m.cfg.From, []string{msg.To}, compose(m.cfg.From, msg))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("send mail to %s: %w", msg.To, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LogMailer logs messages instead of sending them
+type LogMailer struct{}
+
+// Send logs the message
+func (LogMailer) Send(ctx context.Context, msg Message) error {
+	logger.Info("Email not sent (SMTP not configured)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
+
+// compose renders the RFC 5322 message
+func compose(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", sanitizeHeader(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// sanitizeHeader strips line breaks that would inject extra headers
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
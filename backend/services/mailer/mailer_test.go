@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSelectsLogMailerWithoutHost(t *testing.T) {
+	if _, ok := New(Config{}).(LogMailer); !ok {
+		t.Error("expected log mailer when SMTP host is empty")
+	}
+	m, ok := New(Config{Host: "smtp.example.com"}).(*SMTPMailer)
+	if !ok || m.cfg.Port != 587 {
+		t.Errorf("expected SMTP mailer on port 587, got %+v", m)
+	}
+}
+
+func TestComposeStripsHeaderInjection(t *testing.T) {
+	raw := string(compose("noreply@bantuaku.id", Message{
+		To:      "budi@example.com",
+		Subject: "Halo\r\nBcc: attacker@example.com",
+		Body:    "baris 1\nbaris 2",
+	}))
+
+	if strings.Contains(raw, "\r\nBcc:") {
+		t.Errorf("subject injected a header:\n%s", raw)
+	}
+	if !strings.Contains(raw, "\r\n\r\nbaris 1\r\nbaris 2") {
+		t.Errorf("body not CRLF-terminated:\n%s", raw)
+	}
+}
+
+func TestInviteEmailContainsLink(t *testing.T) {
+	msg := InviteEmail("budi@example.com", "https://app.bantuaku.id/set-password?token=abc", time.Date(2025, 1, 5, 10, 0, 0, 0, time.UTC))
+	if msg.To != "budi@example.com" || !strings.Contains(msg.Body, "token=abc") || !strings.Contains(msg.Body, "05 Jan 2025") {
+		t.Errorf("unexpected invite: %+v", msg)
+	}
+}
@@ -0,0 +1,202 @@
+package mailer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// InviteEmail asks a user created by an admin to set their password
+func InviteEmail(to, link string, expires time.Time) Message {
+	return Message{
+		To:      to,
+		Subject: "Selamat datang di Bantuaku – atur kata sandi Anda",
+		Body: fmt.Sprintf(`Halo,
+
+Akun Bantuaku untuk %s telah dibuat oleh admin. Atur kata sandi Anda melalui tautan berikut:
+
+%s
+
+Tautan ini berlaku sampai %s. Jika Anda tidak merasa meminta akun ini, abaikan email ini.
+
+Salam,
+Tim Bantuaku
+`, to, link, expires.Format("02 Jan 2006 15:04 MST")),
+	}
+}
+
+// ResetEmail sends a password reset link requested by an admin
+func ResetEmail(to, link string, expires time.Time) Message {
+	return Message{
+		To:      to,
+		Subject: "Atur ulang kata sandi Bantuaku",
+		Body: fmt.Sprintf(`Halo,
+
+Admin meminta pengaturan ulang kata sandi untuk akun Bantuaku %s. Buat kata sandi baru melalui tautan berikut:
+
+%s
+
+Tautan ini berlaku sampai %s dan hanya bisa dipakai sekali. Kata sandi lama tetap berlaku sampai Anda menggantinya.
+
+Salam,
+Tim Bantuaku
+`, to, link, expires.Format("02 Jan 2006 15:04 MST")),
+	}
+}
+
+// NewAdminLoginEmail alerts an admin to a sign-in from a device or network not seen before
+func NewAdminLoginEmail(to, userAgent, ip string, newDevice bool, at time.Time) Message {
+	what := "jaringan baru"
+	if newDevice {
+		what = "perangkat baru"
+	}
+	return Message{
+		To:      to,
+		Subject: "Peringatan keamanan: login admin dari " + what,
+		Body: fmt.Sprintf(`Halo,
+
+Akun admin Bantuaku %s baru saja masuk dari %s.
+
+Waktu: %s
+Alamat IP: %s
+Perangkat: %s
+
+Jika ini bukan Anda, segera cabut perangkat tersebut di menu Keamanan Admin dan ganti kata sandi Anda.
+
+Salam,
+Tim Bantuaku
+`, to, what, at.Format("02 Jan 2006 15:04 MST"), ip, userAgent),
+	}
+}
+
+// NewAdminDeviceApprovalEmail asks an admin to approve a device that tried to sign in
+func NewAdminDeviceApprovalEmail(to, userAgent, ip, link string, expires time.Time) Message {
+	return Message{
+		To:      to,
+		Subject: "Setujui perangkat baru untuk akun admin Bantuaku",
+		Body: fmt.Sprintf(`Halo,
+
+Akun admin Bantuaku %s mencoba masuk dari perangkat baru.
+
+Alamat IP: %s
+Perangkat: %s
+
+Jika ini Anda, setujui perangkat tersebut lewat tautan berikut, lalu masuk kembali:
+
+%s
+
+Tautan ini berlaku sampai %s. Jika ini bukan Anda, abaikan email ini dan segera ganti kata sandi Anda.
+
+Salam,
+Tim Bantuaku
+`, to, ip, userAgent, link, expires.Format("02 Jan 2006 15:04 MST")),
+	}
+}
+
+// IntegrationAuthFailedEmail tells a company owner an integration's credentials
+// were rejected and syncing has stopped
+func IntegrationAuthFailedEmail(to, platform, link string) Message {
+	return Message{
+		To:      to,
+		Subject: fmt.Sprintf("Koneksi %s di Bantuaku terputus", platform),
+		Body: fmt.Sprintf(`Halo,
+
+%s menolak kunci API yang tersimpan di Bantuaku, kemungkinan karena kunci tersebut dicabut atau kedaluwarsa. Sinkronisasi produk dan penjualan dihentikan sampai kunci baru dipasang, sehingga prediksi bisa tertinggal.
+
+Pasang kunci baru melalui:
+
+%s
+
+Salam,
+Tim Bantuaku
+`, platform, link),
+	}
+}
+
+// DormancyEmail warns a company owner that an inactive company will be archived
+func DormancyEmail(to, company, link string, archiveOn time.Time) Message {
+	return Message{
+		To:      to,
+		Subject: fmt.Sprintf("%s akan diarsipkan di Bantuaku", company),
+		Body: fmt.Sprintf(`Halo,
+
+Kami tidak melihat aktivitas di %s selama beberapa bulan terakhir. Jika tetap tidak aktif, perusahaan ini akan diarsipkan pada %s: data Anda tetap tersimpan, tetapi prediksi, pemantauan pasar dan pengingat otomatis dihentikan.
+
+Cukup masuk ke Bantuaku untuk tetap aktif:
+
+%s
+
+Perusahaan yang sudah diarsipkan dapat diaktifkan kembali kapan saja setelah masuk.
+
+Salam,
+Tim Bantuaku
+`, company, archiveOn.Format("02 Jan 2006"), link),
+	}
+}
+
+// NotificationSummaryEmail lists the notifications held during a company's
+// quiet hours in one message
+func NotificationSummaryEmail(to, company string, lines []string, link string) Message {
+	return Message{
+		To:      to,
+		Subject: fmt.Sprintf("Ringkasan notifikasi %s: %d pembaruan", company, len(lines)),
+		Body: fmt.Sprintf(`Halo,
+
+Selama jam tenang, ada %d pembaruan untuk %s:
+
+- %s
+
+Lihat detailnya di:
+
+%s
+
+Salam,
+Tim Bantuaku
+`, len(lines), company, strings.Join(lines, "\n- "), link),
+	}
+}
+
+// AISpendAlertEmail tells a platform admin that AI spending reached a cap's
+// throttle threshold or the cap itself
+func AISpendAlertEmail(to, scope, level string, spent, cap float64, link string) Message {
+	action := "Jawaban AI kini memakai model yang lebih murah."
+	if level == "capped" {
+		action = "Jawaban AI kini memakai model yang lebih murah dan pekerjaan AI terjadwal ditunda sampai batas dinaikkan atau bulan berganti."
+	}
+	return Message{
+		To:      to,
+		Subject: fmt.Sprintf("Pengeluaran AI %s mencapai %.0f%% dari batas", scope, spent/cap*100),
+		Body: fmt.Sprintf(`Halo,
+
+Pengeluaran AI %s bulan ini sebesar $%.2f dari batas $%.2f. %s
+
+Lihat rinciannya di:
+
+%s
+
+Salam,
+Tim Bantuaku
+`, scope, spent, cap, action, link),
+	}
+}
+
+// CompanyInviteEmail invites someone to join a company on Bantuaku
+func CompanyInviteEmail(to, company, inviter, role, link string, expires time.Time) Message {
+	return Message{
+		To:      to,
+		Subject: fmt.Sprintf("Undangan bergabung dengan %s di Bantuaku", company),
+		Body: fmt.Sprintf(`Halo,
+
+%s mengundang Anda bergabung dengan %s di Bantuaku sebagai %s.
+
+Masuk atau daftar dengan email %s, lalu terima undangan melalui tautan berikut:
+
+%s
+
+Undangan ini berlaku sampai %s. Jika Anda tidak mengenal pengirimnya, abaikan email ini.
+
+Salam,
+Tim Bantuaku
+`, inviter, company, role, to, link, expires.Format("02 Jan 2006 15:04 MST")),
+	}
+}
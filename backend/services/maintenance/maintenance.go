@@ -0,0 +1,106 @@
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMessage is shown when maintenance is enabled without a message
+const DefaultMessage = "Bantuaku is undergoing maintenance and will be back shortly. Thank you for your patience."
+
+// defaultRetryAfter is suggested to clients when no end time is announced
+const defaultRetryAfter = 5 * time.Minute
+
+// alwaysAllowed are paths that keep working during maintenance: health checks,
+// the status page and login, so super admins can sign in to end it
+var alwaysAllowed = []string{"/healthz", "/status", "/api/v1/slo/status", "/api/v1/auth/login"}
+
+// Mode is the maintenance setting
+type Mode struct {
+	Enabled        bool       `json:"enabled"`
+	Message        string     `json:"message,omitempty"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`          // Announced end, used for Retry-After
+	AllowedPaths   []string   `json:"allowed_paths,omitempty"`    // Path prefixes that stay available
+	AllowedUserIDs []string   `json:"allowed_user_ids,omitempty"` // Users who keep full access
+	UpdatedBy      string     `json:"updated_by,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks allowlisted paths
+func (m Mode) Validate() error {
+	for _, p := range m.AllowedPaths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("allowed path %q must start with /", p)
+		}
+	}
+	return nil
+}
+
+// Allows reports whether a request may proceed. Super admins always may.
+func (m Mode) Allows(path, userID, role string) bool {
+	if !m.Enabled || role == "super_admin" {
+		return true
+	}
+	for _, p := range alwaysAllowed {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	for _, p := range m.AllowedPaths {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	if userID != "" {
+		for _, id := range m.AllowedUserIDs {
+			if id == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Notice returns the message shown to blocked clients
+func (m Mode) Notice() string {
+	if m.Message != "" {
+		return m.Message
+	}
+	return DefaultMessage
+}
+
+// RetryAfter suggests when clients should try again: until the announced end,
+// but at least a minute
+func (m Mode) RetryAfter(now time.Time) time.Duration {
+	if m.EndsAt == nil {
+		return defaultRetryAfter
+	}
+	return max(m.EndsAt.Sub(now), time.Minute)
+}
+
+// Switch holds the live maintenance mode
+type Switch struct {
+	mu   sync.RWMutex
+	mode Mode
+}
+
+// NewSwitch returns a switch with maintenance off
+func NewSwitch() *Switch {
+	return &Switch{}
+}
+
+// Set replaces the live mode
+func (s *Switch) Set(m Mode) {
+	s.mu.Lock()
+	s.mode = m
+	s.mu.Unlock()
+}
+
+// Current returns the live mode
+func (s *Switch) Current() Mode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mode
+}
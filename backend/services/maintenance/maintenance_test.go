@@ -0,0 +1,51 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllows(t *testing.T) {
+	m := Mode{Enabled: true, AllowedPaths: []string{"/api/v1/webhooks/"}, AllowedUserIDs: []string{"u1"}}
+	cases := []struct {
+		path, userID, role string
+		want               bool
+	}{
+		{"/api/v1/products", "", "", false},
+		{"/api/v1/products", "u2", "admin", false},
+		{"/api/v1/products", "u3", "super_admin", true},
+		{"/api/v1/products", "u1", "user", true},
+		{"/healthz", "", "", true},
+		{"/statusx", "", "", false},
+		{"/api/v1/webhooks/woocommerce", "", "", true},
+	}
+	for _, c := range cases {
+		if got := m.Allows(c.path, c.userID, c.role); got != c.want {
+			t.Errorf("%s as %q/%q: got %v, want %v", c.path, c.userID, c.role, got, c.want)
+		}
+	}
+	if !(Mode{}).Allows("/api/v1/products", "", "") {
+		t.Error("expected everything allowed when disabled")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Now()
+	if got := (Mode{}).RetryAfter(now); got != defaultRetryAfter {
+		t.Errorf("no end: got %v", got)
+	}
+	end := now.Add(30 * time.Minute)
+	if got := (Mode{EndsAt: &end}).RetryAfter(now); got != 30*time.Minute {
+		t.Errorf("announced end: got %v", got)
+	}
+	past := now.Add(-time.Hour)
+	if got := (Mode{EndsAt: &past}).RetryAfter(now); got != time.Minute {
+		t.Errorf("past end: got %v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Mode{AllowedPaths: []string{"api/v1"}}).Validate(); err == nil {
+		t.Error("expected relative path to be rejected")
+	}
+}
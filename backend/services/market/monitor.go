@@ -0,0 +1,136 @@
+package market
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Monitoring intervals by subscription plan. Paid plans get weekly market
+// summaries; free companies are checked every four weeks.
+const (
+	PaidMonitorInterval = 7 * 24 * time.Hour
+	FreeMonitorInterval = 28 * 24 * time.Hour
+)
+
+// MonitorInterval returns how often a company on plan is monitored
+func MonitorInterval(plan string) time.Duration {
+	if plan == "" || plan == "free" {
+		return FreeMonitorInterval
+	}
+	return PaidMonitorInterval
+}
+
+// Due reports whether a company last monitored at last is due again at now.
+// A zero last time means the company was never monitored.
+func Due(plan string, last, now time.Time) bool {
+	if last.IsZero() {
+		return true
+	}
+	// Allow an hour of slack so a daily scheduler does not drift a day late
+	return now.Sub(last) >= MonitorInterval(plan)-time.Hour
+}
+
+// BuildQuery builds the monitoring search query from the company's industry,
+// city and top product categories. It returns "" when there is nothing to search for.
+func BuildQuery(industry, city string, categories []string) string {
+	var terms []string
+	if industry = strings.TrimSpace(industry); industry != "" {
+		terms = append(terms, industry)
+	}
+	for _, c := range categories {
+		if c = strings.TrimSpace(c); c != "" && !strings.EqualFold(c, industry) {
+			terms = append(terms, c)
+		}
+		if len(terms) >= 3 {
+			break
+		}
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+
+	query := "tren pasar " + strings.Join(terms, " ")
+	if city = strings.TrimSpace(city); city != "" {
+		query += " " + city
+	}
+	return query + " Indonesia"
+}
+
+// Article is the part of an archived article monitoring compares
+type Article struct {
+	ID      string
+	Title   string
+	Excerpt string
+}
+
+// Shift is the difference between two monitoring snapshots
+type Shift struct {
+	New     []Article
+	Dropped []Article
+}
+
+// Changed reports whether the new snapshot contains anything not seen before
+func (s Shift) Changed() bool {
+	return len(s.New) > 0
+}
+
+// Diff compares the current snapshot against the previous one by article ID
+func Diff(previous, current []Article) Shift {
+	prev := map[string]bool{}
+	for _, a := range previous {
+		prev[a.ID] = true
+	}
+	cur := map[string]bool{}
+	var shift Shift
+	for _, a := range current {
+		cur[a.ID] = true
+		if !prev[a.ID] {
+			shift.New = append(shift.New, a)
+		}
+	}
+	for _, a := range previous {
+		if !cur[a.ID] {
+			shift.Dropped = append(shift.Dropped, a)
+		}
+	}
+	return shift
+}
+
+// Summary is the rule-based "what changed in your market" text used when AI is unavailable
+func Summary(query string, shift Shift) string {
+	if !shift.Changed() {
+		return fmt.Sprintf("Tidak ada perkembangan baru untuk \"%s\" sejak pemantauan sebelumnya.", query)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ada %d kabar baru di pasar Anda (\"%s\"):", len(shift.New), query)
+	for i, a := range shift.New {
+		if i == 3 {
+			fmt.Fprintf(&b, "\n- dan %d lainnya", len(shift.New)-3)
+			break
+		}
+		fmt.Fprintf(&b, "\n- %s", a.Title)
+	}
+	return b.String()
+}
+
+// BuildPrompt asks the model to summarize what changed in the market
+func BuildPrompt(companyName, query string, shift Shift) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Anda adalah analis pasar untuk UMKM \"%s\". ", companyName)
+	fmt.Fprintf(&b, "Berikut artikel baru minggu ini untuk pencarian \"%s\" yang belum muncul pada pemantauan sebelumnya:\n", query)
+	for _, a := range shift.New {
+		fmt.Fprintf(&b, "\n- %s\n  %s", a.Title, truncate(a.Excerpt, 400))
+	}
+	b.WriteString("\n\nRingkas dalam maksimal 4 kalimat bahasa Indonesia: apa yang berubah di pasar, ")
+	b.WriteString("mengapa penting bagi bisnis ini, dan satu tindakan yang disarankan. Jangan mengarang fakta di luar artikel.")
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(strings.TrimSpace(s)); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return strings.TrimSpace(s)
+}
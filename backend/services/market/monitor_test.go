@@ -0,0 +1,52 @@
+package market
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDue(t *testing.T) {
+	now := time.Date(2026, 3, 15, 2, 0, 0, 0, time.UTC)
+	if !Due("free", time.Time{}, now) {
+		t.Error("never-monitored company should be due")
+	}
+	if !Due("pro", now.Add(-7*24*time.Hour), now) {
+		t.Error("paid company should be due after a week")
+	}
+	if Due("free", now.Add(-7*24*time.Hour), now) {
+		t.Error("free company should not be due after a week")
+	}
+	if !Due("free", now.Add(-28*24*time.Hour), now) {
+		t.Error("free company should be due after four weeks")
+	}
+}
+
+func TestBuildQuery(t *testing.T) {
+	got := BuildQuery("Kuliner", "Bandung", []string{"kuliner", "Minuman", "Snack", "Frozen"})
+	want := "tren pasar Kuliner Minuman Snack Bandung Indonesia"
+	if got != want {
+		t.Errorf("BuildQuery = %q, want %q", got, want)
+	}
+	if got := BuildQuery("", "Bandung", nil); got != "" {
+		t.Errorf("BuildQuery without terms = %q, want empty", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	previous := []Article{{ID: "a"}, {ID: "b"}}
+	current := []Article{{ID: "b"}, {ID: "c", Title: "Harga gula naik"}}
+	shift := Diff(previous, current)
+	if len(shift.New) != 1 || shift.New[0].ID != "c" {
+		t.Errorf("New = %+v, want [c]", shift.New)
+	}
+	if len(shift.Dropped) != 1 || shift.Dropped[0].ID != "a" {
+		t.Errorf("Dropped = %+v, want [a]", shift.Dropped)
+	}
+	if !strings.Contains(Summary("gula", shift), "Harga gula naik") {
+		t.Error("summary should list new headlines")
+	}
+	if Diff(current, current).Changed() {
+		t.Error("identical snapshots should not be a change")
+	}
+}
@@ -0,0 +1,101 @@
+package membership
+
+import (
+	"fmt"
+	"net/http"
+	pathpkg "path"
+	"strings"
+	"time"
+)
+
+// Roles of a company member
+const (
+	RoleOwner   = "owner"   // Manages the team besides everything managers do
+	RoleManager = "manager" // Reads and changes the company's data
+	RoleViewer  = "viewer"  // Read-only access
+)
+
+// Roles lists every role, most privileged first
+var Roles = []string{RoleOwner, RoleManager, RoleViewer}
+
+// InviteTTL is how long an invite can be accepted
+const InviteTTL = 7 * 24 * time.Hour
+
+// MaxPendingInvites bounds the open invites of a company
+const MaxPendingInvites = 20
+
+// viewerWritePaths are the only paths viewers may send writes to: asking the
+// assistant, their own conversations and personal settings, which change none
+// of the company's data. Chat tools that do change it check the role
+// themselves; company-wide chat actions such as dismissing suggestions and
+// company settings such as quiet hours are not listed. Paths match as
+// prefixes, except those with a "*" segment, which match exactly.
+var viewerWritePaths = []string{
+	"/api/v1/chat/start",
+	"/api/v1/chat/message", // Also message/stream and messages/{id}/...
+	"/api/v1/chat/conversations/",
+	"/api/v1/notifications/*/read",
+	"/api/v1/notifications/preferences",
+	"/api/v1/company/invites/",
+	"/api/v1/company/switch",
+	"/api/v1/company/members/me",
+}
+
+// Valid reports whether role is a known role
+func Valid(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanWrite reports whether a member with role may change the company's data.
+// Platform admins without a membership have no role and are not limited here.
+func CanWrite(role string) bool {
+	return role != RoleViewer
+}
+
+// Invitable reports whether an invite may grant role. Ownership is given by
+// promoting an existing member.
+func Invitable(role string) bool {
+	return role == RoleManager || role == RoleViewer
+}
+
+// Allows reports whether a member with role may make a request with method to
+// path. Viewers may only read, apart from a few personal actions.
+func Allows(role, method, path string) bool {
+	if CanWrite(role) {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	for _, p := range viewerWritePaths {
+		if strings.Contains(p, "*") {
+			if ok, _ := pathpkg.Match(p, path); ok {
+				return true
+			}
+			continue
+		}
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckChange reports whether a member with role from may be changed to role
+// to (empty when removed), given the company's number of owners. A company
+// always keeps at least one owner.
+func CheckChange(from, to string, owners int) error {
+	if to != "" && !Valid(to) {
+		return fmt.Errorf("role must be one of: %s", strings.Join(Roles, ", "))
+	}
+	if from == RoleOwner && to != RoleOwner && owners <= 1 {
+		return fmt.Errorf("the company must keep at least one owner; promote another member first")
+	}
+	return nil
+}
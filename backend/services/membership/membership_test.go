@@ -0,0 +1,55 @@
+package membership
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		role, method, path string
+		want               bool
+	}{
+		{RoleViewer, "GET", "/api/v1/products", true},
+		{RoleViewer, "POST", "/api/v1/products", false},
+		{RoleViewer, "DELETE", "/api/v1/sales/5", false},
+		{RoleViewer, "POST", "/api/v1/chat/message", true},
+		{RoleViewer, "POST", "/api/v1/chat/messages/abc/regenerate", true},
+		{RoleViewer, "PUT", "/api/v1/chat/conversations/abc/archive", true},
+		{RoleViewer, "POST", "/api/v1/chat/suggestions/abc/dismiss", false},
+		{RoleViewer, "POST", "/api/v1/chat/tools", false},
+		{RoleViewer, "POST", "/api/v1/notifications/abc/read", true},
+		{RoleViewer, "POST", "/api/v1/notifications/abc/read/more", false},
+		{RoleViewer, "PUT", "/api/v1/notifications/preferences", true},
+		{RoleViewer, "DELETE", "/api/v1/notifications/preferences", true},
+		{RoleViewer, "PUT", "/api/v1/notifications/quiet-hours", false},
+		{RoleManager, "PUT", "/api/v1/notifications/quiet-hours", true},
+		{RoleViewer, "POST", "/api/v1/company/invites", false},
+		{RoleViewer, "POST", "/api/v1/company/invites/abc/accept", true},
+		{RoleManager, "POST", "/api/v1/products", true},
+		{RoleOwner, "DELETE", "/api/v1/sales/5", true},
+	}
+	for _, tt := range tests {
+		if got := Allows(tt.role, tt.method, tt.path); got != tt.want {
+			t.Errorf("Allows(%s, %s, %s) = %v, want %v", tt.role, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCheckChange(t *testing.T) {
+	if err := CheckChange(RoleOwner, RoleViewer, 1); err == nil {
+		t.Error("expected demoting the last owner to fail")
+	}
+	if err := CheckChange(RoleOwner, "", 1); err == nil {
+		t.Error("expected removing the last owner to fail")
+	}
+	if err := CheckChange(RoleOwner, RoleManager, 2); err != nil {
+		t.Errorf("unexpected error demoting one of two owners: %v", err)
+	}
+	if err := CheckChange(RoleViewer, "admin", 1); err == nil {
+		t.Error("expected an unknown role to fail")
+	}
+	if CanWrite(RoleViewer) || !CanWrite(RoleManager) || !CanWrite("") {
+		t.Error("only viewers are read-only")
+	}
+	if !Invitable(RoleViewer) || Invitable(RoleOwner) {
+		t.Error("unexpected Invitable result")
+	}
+}
@@ -0,0 +1,235 @@
+package metering
+
+import (
+	"fmt"
+	"time"
+)
+
+// Periods usage is counted over. Calendar periods start at midnight UTC.
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+	PeriodNone  = "none" // Running total
+)
+
+// Sources of a metric's count
+const (
+	SourceCounter = "counter" // usage_counters, incremented atomically when usage is consumed
+	SourceQuery   = "query"   // Counted from the rows the usage creates
+)
+
+// Metric names
+const (
+	MetricChatMessages = "chat_messages"
+	MetricPredictions  = "predictions"
+	MetricFileUploads  = "file_uploads"
+)
+
+// Unlimited is the limit of metrics a plan does not cap
+const Unlimited = -1
+
+// Metric declares how a kind of usage is counted and capped
+type Metric struct {
+	Name     string
+	Label    string
+	Source   string
+	Query    string // SourceQuery: counts usage, given $1 company ID and the period as $2 start and $3 end
+	Period   string
+	LimitKey string // Key of the plan limit in Limits
+}
+
+// Metrics are the metered kinds of usage
+var Metrics = []Metric{
+	{
+		Name:     MetricChatMessages,
+		Label:    "chat messages",
+		Source:   SourceCounter,
+		Period:   PeriodMonth,
+		LimitKey: "chat_messages_per_month",
+	},
+	{
+		Name:     MetricPredictions,
+		Label:    "predictions",
+		Source:   SourceQuery,
+		Query:    `SELECT COUNT(*) FROM prediction_jobs WHERE company_id = $1 AND created_at >= $2 AND created_at < $3`,
+		Period:   PeriodMonth,
+		LimitKey: "predictions_per_month",
+	},
+	{
+		Name:     MetricFileUploads,
+		Label:    "file uploads",
+		Source:   SourceQuery,
+		Query:    `SELECT COUNT(*) FROM file_uploads WHERE company_id = $1 AND created_at >= $2 AND created_at < $3`,
+		Period:   PeriodMonth,
+		LimitKey: "file_uploads_per_month",
+	},
+}
+
+// Limits caps each limit key per plan. Plans missing from a key get the free
+// plan's limit.
+var Limits = map[string]map[string]int64{
+	"chat_messages_per_month": {"free": 300, "pro": 5000, "enterprise": Unlimited},
+	"predictions_per_month":   {"free": 5, "pro": 100, "enterprise": Unlimited},
+	"file_uploads_per_month":  {"free": 50, "pro": 1000, "enterprise": Unlimited},
+}
+
+// Lookup returns the metric named name
+func Lookup(name string) (Metric, bool) {
+	for _, m := range Metrics {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Metric{}, false
+}
+
+// Limit returns the cap of a metric on plan
+func (m Metric) Limit(plan string) int64 {
+	limits := Limits[m.LimitKey]
+	if limits == nil {
+		return Unlimited
+	}
+	if limit, ok := limits[plan]; ok {
+		return limit
+	}
+	return limits["free"]
+}
+
+// PeriodStart returns when the metric's current period began; the Unix epoch
+// for running totals
+func (m Metric) PeriodStart(now time.Time) time.Time {
+	now = now.UTC()
+	switch m.Period {
+	case PeriodDay:
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	case PeriodMonth:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Unix(0, 0).UTC()
+}
+
+// ResetsAt returns when the current period ends, nil for running totals
+func (m Metric) ResetsAt(now time.Time) *time.Time {
+	start := m.PeriodStart(now)
+	var end time.Time
+	switch m.Period {
+	case PeriodDay:
+		end = start.AddDate(0, 0, 1)
+	case PeriodMonth:
+		end = start.AddDate(0, 1, 0)
+	default:
+		return nil
+	}
+	return &end
+}
+
+// Bounds returns the current period as [start, end). Running totals end in
+// the far future.
+func (m Metric) Bounds(now time.Time) (time.Time, time.Time) {
+	if end := m.ResetsAt(now); end != nil {
+		return m.PeriodStart(now), *end
+	}
+	return m.PeriodStart(now), time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// MonthStart returns the first instant of t's calendar month in UTC
+func MonthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Months returns the starts of the last n calendar months up to now's, oldest first
+func Months(now time.Time, n int) []time.Time {
+	current := MonthStart(now)
+	months := make([]time.Time, n)
+	for i := range months {
+		months[i] = current.AddDate(0, i-n+1, 0)
+	}
+	return months
+}
+
+// Usage is a company's consumption of a metric in the current period
+type Usage struct {
+	Metric    string     `json:"metric"`
+	Period    string     `json:"period"`
+	Used      int64      `json:"used"`
+	Limit     int64      `json:"limit"` // -1 when unlimited
+	Remaining int64      `json:"remaining"`
+	ResetsAt  *time.Time `json:"resets_at,omitempty"`
+}
+
+// NewUsage describes used units of m against plan's limit
+func NewUsage(m Metric, plan string, used int64, now time.Time) Usage {
+	u := Usage{Metric: m.Name, Period: m.Period, Used: used, Limit: m.Limit(plan), Remaining: Unlimited, ResetsAt: m.ResetsAt(now)}
+	if u.Limit != Unlimited {
+		u.Remaining = max(u.Limit-used, 0)
+	}
+	return u
+}
+
+// WithLimit returns the usage against limit instead of the plan's, such as
+// Unlimited when limits are not enforced
+func (u Usage) WithLimit(limit int64) Usage {
+	u.Limit, u.Remaining = limit, Unlimited
+	if limit != Unlimited {
+		u.Remaining = max(limit-u.Used, 0)
+	}
+	return u
+}
+
+// Allows reports whether n more units fit under the limit
+func (u Usage) Allows(n int64) bool {
+	return u.Limit == Unlimited || u.Used+n <= u.Limit
+}
+
+// Message explains a reached limit
+func (u Usage) Message(label string) string {
+	switch u.Period {
+	case PeriodDay:
+		return fmt.Sprintf("Daily limit of %d %s reached", u.Limit, label)
+	case PeriodMonth:
+		return fmt.Sprintf("Monthly limit of %d %s reached", u.Limit, label)
+	}
+	return fmt.Sprintf("Limit of %d %s reached", u.Limit, label)
+}
+
+// KnownPlan reports whether any limit is defined for plan
+func KnownPlan(plan string) bool {
+	for _, limits := range Limits {
+		if _, ok := limits[plan]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanComparison sets a metric's current usage against another plan's limit
+type PlanComparison struct {
+	Metric        string     `json:"metric"`
+	Period        string     `json:"period"`
+	Used          int64      `json:"used"`
+	CurrentLimit  int64      `json:"current_limit"`
+	PlanLimit     int64      `json:"plan_limit"`
+	PlanRemaining int64      `json:"plan_remaining"`
+	BlockedNow    bool       `json:"blocked_now"` // The current plan allows no more this period
+	Unblocks      bool       `json:"unblocks"`    // Blocked now, but the plan would allow more
+	ResetsAt      *time.Time `json:"resets_at,omitempty"`
+}
+
+// Compare reports what switching to plan would change for a metric, given its
+// current usage
+func Compare(m Metric, current Usage, plan string, now time.Time) PlanComparison {
+	target := NewUsage(m, plan, current.Used, now)
+	blocked := !current.Allows(1)
+	return PlanComparison{
+		Metric:        m.Name,
+		Period:        m.Period,
+		Used:          current.Used,
+		CurrentLimit:  current.Limit,
+		PlanLimit:     target.Limit,
+		PlanRemaining: target.Remaining,
+		BlockedNow:    blocked,
+		Unblocks:      blocked && target.Allows(1),
+		ResetsAt:      current.ResetsAt,
+	}
+}
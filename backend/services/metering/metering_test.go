@@ -0,0 +1,82 @@
+package metering
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriods(t *testing.T) {
+	now := time.Date(2025, 12, 31, 22, 0, 0, 0, time.UTC)
+	chat, _ := Lookup(MetricChatMessages)
+	if got := chat.PeriodStart(now); !got.Equal(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("period start: got %v", got)
+	}
+	if got := chat.ResetsAt(now); got == nil || !got.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("resets at: got %v", got)
+	}
+	total := Metric{Period: PeriodNone}
+	if total.PeriodStart(now).Unix() != 0 || total.ResetsAt(now) != nil {
+		t.Error("expected running total to have no period")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	m, _ := Lookup(MetricPredictions)
+	now := time.Now()
+
+	u := NewUsage(m, "free", 4, now)
+	if !u.Allows(1) || u.Allows(2) || u.Remaining != 1 {
+		t.Errorf("free: got %+v", u)
+	}
+	if u := NewUsage(m, "unknown", 5, now); u.Limit != Limits[m.LimitKey]["free"] {
+		t.Errorf("unknown plan should get the free limit, got %d", u.Limit)
+	}
+	if u := NewUsage(m, "enterprise", 1e6, now); !u.Allows(1) || u.Remaining != Unlimited {
+		t.Errorf("enterprise: got %+v", u)
+	}
+	if u := NewUsage(m, "free", 4, now).WithLimit(Unlimited); !u.Allows(10) || u.Remaining != Unlimited {
+		t.Errorf("unenforced: got %+v", u)
+	}
+	if u := NewUsage(m, "enterprise", 7, now).WithLimit(5); u.Remaining != 0 || u.Allows(1) {
+		t.Errorf("lowered limit: got %+v", u)
+	}
+}
+
+func TestMetricsDeclared(t *testing.T) {
+	for _, m := range Metrics {
+		if _, ok := Limits[m.LimitKey]; !ok {
+			t.Errorf("%s: no limits for %q", m.Name, m.LimitKey)
+		}
+		if (m.Source == SourceQuery) != (m.Query != "") {
+			t.Errorf("%s: query metrics need a query and counters must not have one", m.Name)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	m, _ := Lookup(MetricPredictions)
+	now := time.Now()
+	current := NewUsage(m, "free", 5, now)
+
+	c := Compare(m, current, "pro", now)
+	if !c.BlockedNow || !c.Unblocks || c.PlanRemaining != 95 {
+		t.Errorf("free to pro: got %+v", c)
+	}
+	if c := Compare(m, current, "free", now); c.Unblocks {
+		t.Errorf("same plan should unblock nothing, got %+v", c)
+	}
+	if c := Compare(m, NewUsage(m, "free", 1, now), "pro", now); c.BlockedNow || c.Unblocks {
+		t.Errorf("not blocked: got %+v", c)
+	}
+	if !KnownPlan("pro") || KnownPlan("platinum") {
+		t.Error("unexpected KnownPlan result")
+	}
+}
+
+func TestMonths(t *testing.T) {
+	months := Months(time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC), 12)
+	if len(months) != 12 || !months[0].Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) ||
+		!months[11].Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v .. %v", months[0], months[len(months)-1])
+	}
+}
@@ -0,0 +1,119 @@
+package notifyprefs
+
+import "fmt"
+
+// Event types users can turn notifications on or off for
+const (
+	EventPredictions  = "predictions"
+	EventReorders     = "reorders"
+	EventRegulations  = "regulations"
+	EventDigests      = "digests"
+	EventBilling      = "billing"
+	EventIntegrations = "integrations"
+	EventExports      = "exports"
+	EventAccount      = "account"
+)
+
+// Delivery channels
+const (
+	ChannelInApp    = "in_app"
+	ChannelEmail    = "email"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// Events lists every event type in display order
+var Events = []string{EventPredictions, EventReorders, EventRegulations, EventDigests, EventBilling, EventIntegrations, EventExports, EventAccount}
+
+// Channels lists every channel in display order
+var Channels = []string{ChannelInApp, ChannelEmail, ChannelWhatsApp}
+
+// channelPlans is the lowest plan a channel is available on; channels not
+// listed are available on every plan
+var channelPlans = map[string]string{ChannelWhatsApp: "pro"}
+
+var planRank = map[string]int{"free": 0, "pro": 1, "enterprise": 2}
+
+// emailDefaults are the events emailed by default on every plan; paid plans
+// also get digests
+var emailDefaults = map[string]bool{EventRegulations: true, EventBilling: true, EventIntegrations: true, EventAccount: true}
+
+// whatsAppDefaults are the events sent over WhatsApp by default where it is
+// available
+var whatsAppDefaults = map[string]bool{EventRegulations: true, EventBilling: true}
+
+// Setting turns one event type on or off for one channel
+type Setting struct {
+	Event   string `json:"event"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Preferences holds, per event type and channel, whether notifications are sent
+type Preferences map[string]map[string]bool
+
+// Available reports whether channel can be used on plan
+func Available(channel, plan string) bool {
+	min, ok := channelPlans[channel]
+	return !ok || planRank[plan] >= planRank[min]
+}
+
+// Defaults returns the preferences of a user who changed nothing: everything
+// in the app, important events by email, and the most urgent ones on WhatsApp
+// for plans that include it
+func Defaults(plan string) Preferences {
+	paid := planRank[plan] > 0
+	p := Preferences{}
+	for _, event := range Events {
+		p[event] = map[string]bool{
+			ChannelInApp:    true,
+			ChannelEmail:    emailDefaults[event] || (paid && event == EventDigests),
+			ChannelWhatsApp: Available(ChannelWhatsApp, plan) && whatsAppDefaults[event],
+		}
+	}
+	return p
+}
+
+// Validate checks that s names a known event type and a channel available on
+// plan
+func Validate(s Setting, plan string) error {
+	if !contains(Events, s.Event) {
+		return fmt.Errorf("unknown event %q", s.Event)
+	}
+	if !contains(Channels, s.Channel) {
+		return fmt.Errorf("unknown channel %q", s.Channel)
+	}
+	if s.Enabled && !Available(s.Channel, plan) {
+		return fmt.Errorf("%s notifications require the %s plan", s.Channel, channelPlans[s.Channel])
+	}
+	return nil
+}
+
+// Apply overrides the defaults with a user's saved settings. Settings of
+// unknown events, and channels the plan no longer includes, are ignored.
+func (p Preferences) Apply(settings []Setting, plan string) {
+	for _, s := range settings {
+		if _, ok := p[s.Event]; !ok || !contains(Channels, s.Channel) || !Available(s.Channel, plan) {
+			continue
+		}
+		p[s.Event][s.Channel] = s.Enabled
+	}
+}
+
+// Allows reports whether event is sent over channel. Events without
+// preferences are always sent.
+func (p Preferences) Allows(event, channel string) bool {
+	channels, ok := p[event]
+	if !ok {
+		return true
+	}
+	return channels[channel]
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package notifyprefs
+
+import "testing"
+
+func TestDefaults(t *testing.T) {
+	free := Defaults("free")
+	if !free.Allows(EventReorders, ChannelInApp) || free.Allows(EventReorders, ChannelEmail) {
+		t.Error("reorders should be in-app only by default")
+	}
+	if free.Allows(EventDigests, ChannelEmail) || !Defaults("pro").Allows(EventDigests, ChannelEmail) {
+		t.Error("digests should be emailed on paid plans only")
+	}
+	if free.Allows(EventRegulations, ChannelWhatsApp) || !Defaults("pro").Allows(EventRegulations, ChannelWhatsApp) {
+		t.Error("WhatsApp should only be on for plans that include it")
+	}
+	if !free.Allows("unknown", ChannelEmail) {
+		t.Error("events without preferences should be sent")
+	}
+}
+
+func TestApply(t *testing.T) {
+	p := Defaults("free")
+	p.Apply([]Setting{
+		{Event: EventRegulations, Channel: ChannelEmail, Enabled: false},
+		{Event: EventReorders, Channel: ChannelWhatsApp, Enabled: true}, // Saved on a paid plan
+		{Event: "removed", Channel: ChannelEmail, Enabled: true},
+	}, "free")
+	if p.Allows(EventRegulations, ChannelEmail) {
+		t.Error("expected saved setting to override the default")
+	}
+	if p.Allows(EventReorders, ChannelWhatsApp) {
+		t.Error("expected a channel the plan lacks to stay off")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		s    Setting
+		plan string
+		ok   bool
+	}{
+		{Setting{EventDigests, ChannelEmail, true}, "free", true},
+		{Setting{EventDigests, ChannelWhatsApp, true}, "free", false},
+		{Setting{EventDigests, ChannelWhatsApp, false}, "free", true},
+		{Setting{EventDigests, ChannelWhatsApp, true}, "enterprise", true},
+		{Setting{"weather", ChannelEmail, true}, "pro", false},
+		{Setting{EventDigests, "sms", true}, "pro", false},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.s, tt.plan); (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v, %s) = %v", tt.s, tt.plan, err)
+		}
+	}
+}
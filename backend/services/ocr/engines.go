@@ -0,0 +1,159 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/bantuaku/backend/services/docparse"
+	"github.com/bantuaku/backend/services/kolosal"
+)
+
+// visionPrompt asks a vision model for a plain transcription of one page
+const visionPrompt = "Salin semua teks pada halaman dokumen ini persis seperti tertulis, baris demi baris. " +
+	"Jangan menambahkan penjelasan, ringkasan atau format Markdown. Jika tidak ada teks, balas kosong."
+
+// Kolosal is Kolosal's OCR endpoint, sent the whole document
+type Kolosal struct {
+	Client   *kolosal.Client
+	Language string
+}
+
+func (k Kolosal) Name() string { return EngineKolosal }
+
+func (k Kolosal) Extract(ctx context.Context, doc Document) (string, float64, error) {
+	resp, err := k.Client.OCR(ctx, kolosal.OCRRequest{
+		Image:    base64.StdEncoding.EncodeToString(doc.Data),
+		Language: k.Language,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	if resp.Confidence != nil {
+		return resp.Text, *resp.Confidence, nil
+	}
+	return resp.Text, TextConfidence(resp.Text), nil
+}
+
+// Vision transcribes an image, or the rendered pages of a PDF, with a
+// vision-capable chat model
+type Vision struct {
+	Client *kolosal.Client
+	Model  string
+}
+
+func (v Vision) Name() string { return EngineVision }
+
+func (v Vision) Extract(ctx context.Context, doc Document) (string, float64, error) {
+	pages, contentType, err := doc.images(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	var texts []string
+	for i, page := range pages {
+		resp, err := v.Client.CreateChatCompletion(ctx, kolosal.ChatCompletionRequest{
+			Model: v.Model,
+			Messages: []kolosal.ChatCompletionMessage{{
+				Role:  "user",
+				Parts: []kolosal.ContentPart{{Type: "text", Text: visionPrompt}, kolosal.ImagePart(page, contentType)},
+			}},
+			MaxTokens: 4000,
+		})
+		if err != nil {
+			return "", 0, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if len(resp.Choices) > 0 {
+			texts = append(texts, strings.TrimSpace(resp.Choices[0].Message.Content))
+		}
+	}
+	text := strings.Join(texts, "\n\n")
+	return text, TextConfidence(text), nil
+}
+
+// images returns the document as images: the image itself, or up to MaxPages
+// rendered pages of a PDF
+func (d Document) images(ctx context.Context) ([][]byte, string, error) {
+	if !d.IsPDF() {
+		return [][]byte{d.Data}, d.ContentType, nil
+	}
+	pages, err := docparse.RenderPages(ctx, d.Data, MaxPages)
+	return pages, "image/png", err
+}
+
+// Tesseract runs the local tesseract binary on an image, or the rendered pages
+// of a PDF, and reports its mean word confidence
+type Tesseract struct {
+	Language string // e.g. "ind+eng"
+}
+
+func (t Tesseract) Name() string { return EngineTesseract }
+
+func (t Tesseract) Extract(ctx context.Context, doc Document) (string, float64, error) {
+	bin, err := exec.LookPath("tesseract")
+	if err != nil {
+		return "", 0, fmt.Errorf("tesseract is not installed")
+	}
+	pages, _, err := doc.images(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var texts []string
+	var confSum float64
+	var words int
+	for i, page := range pages {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, bin, "stdin", "stdout", "-l", t.Language, "tsv")
+		cmd.Stdin = bytes.NewReader(page)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", 0, fmt.Errorf("tesseract page %d: %w: %s", i+1, err, strings.TrimSpace(stderr.String()))
+		}
+		text, sum, n := parseTSV(stdout.String())
+		texts = append(texts, text)
+		confSum += sum
+		words += n
+	}
+	if words == 0 {
+		return "", 0, nil
+	}
+	return strings.Join(texts, "\n\n"), confSum / float64(words) / 100, nil
+}
+
+// parseTSV rebuilds the lines of tesseract's TSV output and sums the
+// confidence (0-100) of its words
+func parseTSV(tsv string) (text string, confSum float64, words int) {
+	var lines []string
+	var line []string
+	lastKey := ""
+	for i, row := range strings.Split(tsv, "\n") {
+		cols := strings.Split(row, "\t")
+		if i == 0 || len(cols) < 12 || cols[0] != "5" {
+			continue // Header and non-word rows
+		}
+		word := strings.TrimSpace(cols[11])
+		conf, err := strconv.ParseFloat(cols[10], 64)
+		if word == "" || err != nil || conf < 0 {
+			continue
+		}
+		// page, block, paragraph and line numbers identify a line
+		key := strings.Join(cols[1:5], ".")
+		if key != lastKey && len(line) > 0 {
+			lines = append(lines, strings.Join(line, " "))
+			line = nil
+		}
+		lastKey = key
+		line = append(line, word)
+		confSum += conf
+		words++
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, " "))
+	}
+	return strings.Join(lines, "\n"), confSum, words
+}
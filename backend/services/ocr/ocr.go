@@ -0,0 +1,132 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Engine names, recorded with each OCR result
+const (
+	EngineKolosal   = "kolosal_ocr"
+	EngineVision    = "vision"
+	EngineTesseract = "tesseract"
+)
+
+// EngineNames lists the engines in the order the chain tries them
+var EngineNames = []string{EngineKolosal, EngineVision, EngineTesseract}
+
+// MaxPages bounds how many pages of a PDF are rendered for the image-based engines
+const MaxPages = 10
+
+// DefaultMinConfidence is the confidence a result needs to stop the chain
+const DefaultMinConfidence = 0.6
+
+// ErrNoText is returned when every engine failed or found no text
+var ErrNoText = errors.New("no engine extracted any text")
+
+// Document is a scanned PDF or an image, e.g. a photo of a receipt
+type Document struct {
+	Data        []byte
+	ContentType string // "application/pdf", "image/jpeg" or "image/png"
+}
+
+// IsPDF reports whether the document is a PDF rather than an image
+func (d Document) IsPDF() bool {
+	return d.ContentType == "application/pdf"
+}
+
+// Engine extracts text from a document. Confidence is in [0, 1].
+type Engine interface {
+	Name() string
+	Extract(ctx context.Context, doc Document) (text string, confidence float64, err error)
+}
+
+// Attempt is the outcome of one engine in the chain
+type Attempt struct {
+	Engine     string  `json:"engine"`
+	Confidence float64 `json:"confidence"`
+	Chars      int     `json:"chars"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Outcome is the accepted text and the engine that produced it
+type Outcome struct {
+	Text       string    `json:"-"`
+	Engine     string    `json:"engine"`
+	Confidence float64   `json:"confidence"`
+	Attempts   []Attempt `json:"attempts"`
+}
+
+// Run tries engines in order and accepts the first result with at least
+// minConfidence. When none reaches it, the most confident non-empty result is
+// accepted instead, so a poor scan still yields something to review.
+func Run(ctx context.Context, engines []Engine, minConfidence float64, doc Document) (*Outcome, error) {
+	out := &Outcome{Attempts: []Attempt{}}
+	var errs []string
+	for _, engine := range engines {
+		text, confidence, err := engine.Extract(ctx, doc)
+		text = strings.TrimSpace(text)
+		attempt := Attempt{Engine: engine.Name(), Confidence: confidence, Chars: len([]rune(text))}
+		if err != nil {
+			attempt.Error = err.Error()
+			errs = append(errs, fmt.Sprintf("%s: %v", engine.Name(), err))
+		}
+		out.Attempts = append(out.Attempts, attempt)
+		if err != nil || text == "" {
+			continue
+		}
+
+		if out.Text == "" || confidence > out.Confidence {
+			out.Text, out.Engine, out.Confidence = text, engine.Name(), confidence
+		}
+		if confidence >= minConfidence || ctx.Err() != nil {
+			break
+		}
+	}
+	if out.Text == "" {
+		if len(errs) > 0 {
+			return out, fmt.Errorf("%w: %s", ErrNoText, strings.Join(errs, "; "))
+		}
+		return out, ErrNoText
+	}
+	return out, nil
+}
+
+// TextConfidence estimates how plausible extracted text is, for engines that
+// report no confidence of their own: the share of visible characters that are
+// letters, digits or common punctuation, lowered for very short text and for
+// word salad of single characters.
+func TextConfidence(text string) float64 {
+	visible, clean := 0, 0
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		visible++
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(".,:;-/()%'\"&@#+=", r) {
+			clean++
+		}
+	}
+	if visible == 0 {
+		return 0
+	}
+	score := float64(clean) / float64(visible)
+
+	words := strings.Fields(text)
+	single := 0
+	for _, w := range words {
+		if len([]rune(w)) == 1 {
+			single++
+		}
+	}
+	if len(words) >= 5 && float64(single)/float64(len(words)) > 0.5 {
+		score *= 0.5
+	}
+	if visible < 20 {
+		score *= float64(visible) / 20
+	}
+	return score
+}
@@ -0,0 +1,78 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeEngine struct {
+	name       string
+	text       string
+	confidence float64
+	err        error
+	calls      *int
+}
+
+func (f fakeEngine) Name() string { return f.name }
+
+func (f fakeEngine) Extract(ctx context.Context, doc Document) (string, float64, error) {
+	*f.calls++
+	return f.text, f.confidence, f.err
+}
+
+func TestRunStopsAtFirstConfidentResult(t *testing.T) {
+	var calls int
+	engines := []Engine{
+		fakeEngine{name: EngineKolosal, err: errors.New("API error: 503"), calls: &calls},
+		fakeEngine{name: EngineVision, text: "Faktur No. 12", confidence: 0.9, calls: &calls},
+		fakeEngine{name: EngineTesseract, text: "Faktur", confidence: 0.95, calls: &calls},
+	}
+	out, err := Run(context.Background(), engines, DefaultMinConfidence, Document{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if out.Engine != EngineVision || calls != 2 || len(out.Attempts) != 2 || out.Attempts[0].Error == "" {
+		t.Errorf("Run() = %+v after %d calls, want vision accepted after a failed first attempt", out, calls)
+	}
+}
+
+func TestRunKeepsBestResultBelowThreshold(t *testing.T) {
+	var calls int
+	engines := []Engine{
+		fakeEngine{name: EngineKolosal, text: "F@k#ur", confidence: 0.3, calls: &calls},
+		fakeEngine{name: EngineTesseract, text: "Faktur", confidence: 0.5, calls: &calls},
+	}
+	out, err := Run(context.Background(), engines, DefaultMinConfidence, Document{})
+	if err != nil || out.Engine != EngineTesseract || out.Text != "Faktur" {
+		t.Errorf("Run() = %+v, %v; want the tesseract result", out, err)
+	}
+}
+
+func TestRunFailsWithoutText(t *testing.T) {
+	var calls int
+	_, err := Run(context.Background(), []Engine{fakeEngine{name: EngineTesseract, calls: &calls}}, DefaultMinConfidence, Document{})
+	if !errors.Is(err, ErrNoText) {
+		t.Errorf("Run() error = %v, want ErrNoText", err)
+	}
+}
+
+func TestTextConfidence(t *testing.T) {
+	good := TextConfidence("Laporan penjualan bulan Maret 2024: total Rp 12.500.000")
+	garbled := TextConfidence("~~|}{ ¦¦ ~ ^^ |} {~ ¦ ^")
+	if good < 0.9 || garbled > 0.3 {
+		t.Errorf("TextConfidence() good = %.2f, garbled = %.2f", good, garbled)
+	}
+}
+
+func TestParseTSV(t *testing.T) {
+	tsv := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"4\t1\t1\t1\t1\t0\t0\t0\t0\t0\t-1\t\n" +
+		"5\t1\t1\t1\t1\t1\t0\t0\t0\t0\t90\tTotal\n" +
+		"5\t1\t1\t1\t1\t2\t0\t0\t0\t0\t80\tPenjualan\n" +
+		"5\t1\t1\t1\t2\t1\t0\t0\t0\t0\t70\tRp\n"
+	text, sum, words := parseTSV(tsv)
+	if text != "Total Penjualan\nRp" || sum != 240 || words != 3 {
+		t.Errorf("parseTSV() = %q, %v, %d", text, sum, words)
+	}
+}
@@ -0,0 +1,253 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultTimeout bounds each request to an identity provider
+const DefaultTimeout = 15 * time.Second
+
+// Provider is the subset of an issuer's discovery document used for the
+// authorization code flow
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client talks to OpenID Connect identity providers
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// NewClient creates a new OIDC client
+func NewClient() *Client {
+	return &Client{HTTPClient: &http.Client{Timeout: DefaultTimeout}}
+}
+
+// Discover fetches the issuer's /.well-known/openid-configuration
+func (c *Client) Discover(ctx context.Context, issuer string) (*Provider, error) {
+	issuer = strings.TrimRight(issuer, "/")
+	var p Provider
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", &p); err != nil {
+		return nil, fmt.Errorf("discover %s: %w", issuer, err)
+	}
+	if strings.TrimRight(p.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery issuer %q does not match %q", p.Issuer, issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document for %s is incomplete", issuer)
+	}
+	return &p, nil
+}
+
+// AuthCodeURL returns the URL that starts a login at the provider
+func (p *Provider) AuthCodeURL(clientID, redirectURI, state, nonce string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {clientID},
+		"redirect_uri":  {redirectURI},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// Exchange redeems an authorization code and returns the raw ID token
+func (c *Client) Exchange(ctx context.Context, p *Provider, clientID, clientSecret, redirectURI, code string) (string, error) {
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirectURI},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, body)
+	}
+
+	var tok struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("decode token response: %w", err)
+	}
+	if tok.IDToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+	return tok.IDToken, nil
+}
+
+// Verify checks an ID token's RS256 signature against the provider's keys and
+// validates its issuer, audience, expiry and nonce. It returns the token claims.
+func (c *Client) Verify(ctx context.Context, p *Provider, rawIDToken, clientID, nonce string) (jwt.MapClaims, error) {
+	keys, err := c.keys(ctx, p.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		if kid == "" && len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("invalid id token: nonce mismatch")
+	}
+	return claims, nil
+}
+
+// Identity is the user asserted by a verified ID token
+type Identity struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+// IdentityFromClaims extracts the user identity. Tokens without an email, or
+// whose email the provider does not mark verified, are rejected.
+func IdentityFromClaims(claims jwt.MapClaims) (Identity, error) {
+	id := Identity{}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.Name, _ = claims["name"].(string)
+	id.Email = strings.TrimSpace(strings.ToLower(id.Email))
+	if id.Subject == "" {
+		return id, fmt.Errorf("id token has no subject")
+	}
+	if id.Email == "" || !strings.Contains(id.Email, "@") {
+		return id, fmt.Errorf("id token has no email")
+	}
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return id, fmt.Errorf("email %s is not verified by the identity provider", id.Email)
+	}
+	return id, nil
+}
+
+// ClaimValue returns a claim as a string for company mapping. The pseudo-claim
+// "email_domain" is the domain part of the email claim.
+func ClaimValue(claims jwt.MapClaims, name string) string {
+	if name == "email_domain" {
+		email, _ := claims["email"].(string)
+		return EmailDomain(email)
+	}
+	switch v := claims[name].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return fmt.Sprint(v)
+	case []interface{}:
+		if len(v) > 0 {
+			if s, ok := v[0].(string); ok {
+				return strings.TrimSpace(s)
+			}
+		}
+	}
+	return ""
+}
+
+// EmailDomain returns the lower-cased domain of an email address
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// keys fetches the provider's RSA signing keys, by key id
+func (c *Client) keys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch signing keys: %w", err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no RSA signing keys at %s", jwksURI)
+	}
+	return keys, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
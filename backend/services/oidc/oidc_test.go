@@ -0,0 +1,126 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestProvider(t *testing.T) (*Client, *Provider, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(Provider{
+				Issuer:                srv.URL,
+				AuthorizationEndpoint: srv.URL + "/authorize",
+				TokenEndpoint:         srv.URL + "/token",
+				JWKSURI:               srv.URL + "/jwks",
+			})
+		case "/jwks":
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+				"kid": "k1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient()
+	p, err := c.Discover(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	return c, p, key
+}
+
+func signIDToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = "k1"
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestVerify(t *testing.T) {
+	c, p, key := newTestProvider(t)
+	valid := jwt.MapClaims{
+		"iss":            p.Issuer,
+		"aud":            "bantuaku",
+		"sub":            "user-1",
+		"email":          "Siti@Koperasi.co.id",
+		"email_verified": true,
+		"nonce":          "n1",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+
+	claims, err := c.Verify(context.Background(), p, signIDToken(t, key, valid), "bantuaku", "n1")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	id, err := IdentityFromClaims(claims)
+	if err != nil || id.Email != "siti@koperasi.co.id" || id.Subject != "user-1" {
+		t.Errorf("unexpected identity %+v (%v)", id, err)
+	}
+	if got := ClaimValue(claims, "email_domain"); got != "koperasi.co.id" {
+		t.Errorf("email_domain = %q", got)
+	}
+
+	cases := map[string]func(jwt.MapClaims){
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other" },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong nonce":    func(c jwt.MapClaims) { c["nonce"] = "n2" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+	}
+	for name, mutate := range cases {
+		claims := jwt.MapClaims{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		mutate(claims)
+		if _, err := c.Verify(context.Background(), p, signIDToken(t, key, claims), "bantuaku", "n1"); err == nil {
+			t.Errorf("%s: expected verification to fail", name)
+		}
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := c.Verify(context.Background(), p, signIDToken(t, other, valid), "bantuaku", "n1"); err == nil {
+		t.Error("token signed by an unknown key was accepted")
+	}
+}
+
+func TestIdentityRejectsUnverifiedEmail(t *testing.T) {
+	_, err := IdentityFromClaims(jwt.MapClaims{"sub": "u", "email": "a@b.id", "email_verified": false})
+	if err == nil {
+		t.Error("expected unverified email to be rejected")
+	}
+	if _, err := IdentityFromClaims(jwt.MapClaims{"sub": "u", "email": "a@b.id"}); err == nil {
+		t.Error("expected email without email_verified to be rejected")
+	}
+	if _, err := IdentityFromClaims(jwt.MapClaims{"sub": "u", "email": "a@b.id", "email_verified": "true"}); err == nil {
+		t.Error("expected a non-boolean email_verified to be rejected")
+	}
+}
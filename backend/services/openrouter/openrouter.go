@@ -0,0 +1,59 @@
+package openrouter
+
+import (
+	"strings"
+
+	"github.com/bantuaku/backend/services/kolosal"
+)
+
+// BaseURL serves OpenRouter's OpenAI-compatible API under /api/v1
+const BaseURL = "https://openrouter.ai/api"
+
+// Options are the routing settings read from config
+type Options struct {
+	Models             string  // Comma-separated; preferred model first, then fallbacks
+	ProviderOrder      string  // Comma-separated upstream providers to try first
+	AllowFallbacks     bool    // Allow providers outside ProviderOrder
+	MaxPromptPrice     float64 // USD per million prompt tokens; 0 is uncapped
+	MaxCompletionPrice float64 // USD per million completion tokens; 0 is uncapped
+}
+
+// NewClient returns a chat client for OpenRouter. It speaks the same API as
+// Kolosal, so the Kolosal client is reused with OpenRouter's base URL and the
+// routing options applied to every chat completion.
+func NewClient(apiKey string, opts Options) *kolosal.Client {
+	client := kolosal.NewClient(apiKey)
+	client.BaseURL = BaseURL
+	client.Routing = Routing(opts)
+	return client
+}
+
+// Routing converts routing options to the request fields OpenRouter expects
+func Routing(opts Options) *kolosal.Routing {
+	routing := &kolosal.Routing{Models: splitList(opts.Models)}
+
+	order := splitList(opts.ProviderOrder)
+	if len(order) == 0 && opts.AllowFallbacks && opts.MaxPromptPrice <= 0 && opts.MaxCompletionPrice <= 0 {
+		return routing
+	}
+	prefs := &kolosal.ProviderPreferences{Order: order}
+	if !opts.AllowFallbacks {
+		allow := false
+		prefs.AllowFallbacks = &allow
+	}
+	if opts.MaxPromptPrice > 0 || opts.MaxCompletionPrice > 0 {
+		prefs.MaxPrice = &kolosal.MaxPrice{Prompt: opts.MaxPromptPrice, Completion: opts.MaxCompletionPrice}
+	}
+	routing.Provider = prefs
+	return routing
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
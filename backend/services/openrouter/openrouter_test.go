@@ -0,0 +1,25 @@
+package openrouter
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRoutingDefaultsLeaveProviderUnset(t *testing.T) {
+	r := Routing(Options{Models: "anthropic/claude-3.5-haiku, openai/gpt-4o-mini,", AllowFallbacks: true})
+	if len(r.Models) != 2 || r.Models[1] != "openai/gpt-4o-mini" {
+		t.Errorf("Models = %v", r.Models)
+	}
+	if r.Provider != nil {
+		t.Errorf("Provider = %+v, want nil", r.Provider)
+	}
+}
+
+func TestRoutingProviderPreferences(t *testing.T) {
+	r := Routing(Options{ProviderOrder: "together,deepinfra", MaxCompletionPrice: 2})
+	got, _ := json.Marshal(r.Provider)
+	want := `{"order":["together","deepinfra"],"allow_fallbacks":false,"max_price":{"completion":2}}`
+	if string(got) != want {
+		t.Errorf("provider = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package portfolio
+
+import (
+	"sort"
+)
+
+// ABC classes
+const (
+	ClassA = "A"
+	ClassB = "B"
+	ClassC = "C"
+)
+
+// Cumulative share thresholds: the products making up the first 80% are A,
+// the next 15% are B and the long tail is C
+const (
+	ThresholdA = 0.80
+	ThresholdB = 0.95
+)
+
+// Item is a product's sales totals over the analysis window
+type Item struct {
+	ProductID   string
+	ProductName string
+	Quantity    float64
+	Revenue     float64
+	Margin      float64 // Revenue minus cost of goods sold
+}
+
+// Classification is the ABC result for one product
+type Classification struct {
+	ProductID    string  `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	Quantity     float64 `json:"quantity"`
+	Revenue      float64 `json:"revenue"`
+	Margin       float64 `json:"margin"`
+	RevenueShare float64 `json:"revenue_share"` // Percent of total revenue
+	MarginShare  float64 `json:"margin_share"`  // Percent of total positive margin
+	RevenueClass string  `json:"revenue_class"`
+	MarginClass  string  `json:"margin_class"`
+	Class        string  `json:"class"` // Best of the revenue and margin classes
+	FocusProduct bool    `json:"focus_product"`
+}
+
+// Summary counts products and revenue per class
+type Summary struct {
+	Class        string  `json:"class"`
+	Products     int     `json:"products"`
+	Revenue      float64 `json:"revenue"`
+	RevenueShare float64 `json:"revenue_share"`
+}
+
+// Classify runs a Pareto classification by revenue and by margin. Results are
+// sorted by revenue, highest first. Focus products are A-class items that
+// earn a positive margin.
+func Classify(items []Item) []Classification {
+	results := make([]Classification, len(items))
+	for i, it := range items {
+		results[i] = Classification{
+			ProductID:   it.ProductID,
+			ProductName: it.ProductName,
+			Quantity:    it.Quantity,
+			Revenue:     it.Revenue,
+			Margin:      it.Margin,
+		}
+	}
+
+	assign(results, func(c *Classification) float64 { return c.Revenue }, func(c *Classification, share float64, class string) {
+		c.RevenueShare = share
+		c.RevenueClass = class
+	})
+	assign(results, func(c *Classification) float64 { return c.Margin }, func(c *Classification, share float64, class string) {
+		c.MarginShare = share
+		c.MarginClass = class
+	})
+
+	for i := range results {
+		c := &results[i]
+		c.Class = c.RevenueClass
+		if c.MarginClass < c.Class {
+			c.Class = c.MarginClass
+		}
+		c.FocusProduct = c.Class == ClassA && c.Margin > 0
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Revenue > results[j].Revenue })
+	return results
+}
+
+// Summarize totals the classification per class, always returning A, B and C
+func Summarize(results []Classification) []Summary {
+	summary := []Summary{{Class: ClassA}, {Class: ClassB}, {Class: ClassC}}
+	var total float64
+	for _, c := range results {
+		total += c.Revenue
+	}
+	for _, c := range results {
+		s := &summary[c.RevenueClass[0]-'A']
+		s.Products++
+		s.Revenue += c.Revenue
+	}
+	if total > 0 {
+		for i := range summary {
+			summary[i].RevenueShare = summary[i].Revenue / total * 100
+		}
+	}
+	return summary
+}
+
+// FocusProducts returns the names of focus products, in revenue order
+func FocusProducts(results []Classification) []string {
+	names := []string{}
+	for _, c := range results {
+		if c.FocusProduct {
+			names = append(names, c.ProductName)
+		}
+	}
+	return names
+}
+
+// assign ranks items by value and sets their share and class. Items with no
+// positive value are always C.
+func assign(results []Classification, value func(*Classification) float64, set func(*Classification, float64, string)) {
+	order := make([]int, len(results))
+	var total float64
+	for i := range results {
+		order[i] = i
+		if v := value(&results[i]); v > 0 {
+			total += v
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return value(&results[order[a]]) > value(&results[order[b]]) })
+
+	var cumulative float64
+	for _, i := range order {
+		c := &results[i]
+		v := value(c)
+		if v <= 0 || total == 0 {
+			set(c, 0, ClassC)
+			continue
+		}
+
+		// Classify on the share before this item so the top seller is always A
+		class := ClassC
+		switch {
+		case cumulative < ThresholdA:
+			class = ClassA
+		case cumulative < ThresholdB:
+			class = ClassB
+		}
+		share := v / total
+		cumulative += share
+		set(c, share*100, class)
+	}
+}
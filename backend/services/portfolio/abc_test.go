@@ -0,0 +1,41 @@
+package portfolio
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	results := Classify([]Item{
+		{ProductID: "c", ProductName: "Teh", Revenue: 40, Margin: 5},
+		{ProductID: "a", ProductName: "Kopi", Revenue: 700, Margin: 100},
+		{ProductID: "b", ProductName: "Roti", Revenue: 200, Margin: -10},
+		{ProductID: "d", ProductName: "Air", Revenue: 60, Margin: 90},
+		{ProductID: "e", ProductName: "Gula", Revenue: 0, Margin: 0},
+	})
+
+	want := map[string]struct {
+		revenueClass, marginClass string
+		focus                     bool
+	}{
+		"a": {ClassA, ClassA, true},
+		"b": {ClassA, ClassC, false}, // A by revenue but sold at a loss
+		"d": {ClassB, ClassA, true},  // Small seller with a fat margin
+		"c": {ClassC, ClassC, false},
+		"e": {ClassC, ClassC, false},
+	}
+	for _, c := range results {
+		w := want[c.ProductID]
+		if c.RevenueClass != w.revenueClass || c.MarginClass != w.marginClass || c.FocusProduct != w.focus {
+			t.Errorf("%s: got revenue=%s margin=%s focus=%v, want %+v", c.ProductID, c.RevenueClass, c.MarginClass, c.FocusProduct, w)
+		}
+	}
+	if results[0].ProductID != "a" {
+		t.Errorf("results should be sorted by revenue, got %s first", results[0].ProductID)
+	}
+
+	summary := Summarize(results)
+	if summary[0].Products != 2 || summary[1].Products != 1 || summary[2].Products != 2 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if got := FocusProducts(results); len(got) != 2 || got[0] != "Kopi" || got[1] != "Air" {
+		t.Errorf("FocusProducts = %v", got)
+	}
+}
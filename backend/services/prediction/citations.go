@@ -0,0 +1,102 @@
+package prediction
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Grounding records how well a section's citations are backed by the sources
+// actually retrieved for it. Score is Verified/Cited, or 0 when nothing was cited.
+type Grounding struct {
+	Score    float64  `json:"score"`
+	Cited    int      `json:"cited"`
+	Verified int      `json:"verified"`
+	Removed  []string `json:"removed,omitempty"` // Citations stripped because they were not retrieved
+}
+
+var (
+	markdownLink = regexp.MustCompile(`\[([^\]]*)\]\((https?://[^)\s]+)\)`)
+	bareURL      = regexp.MustCompile(`https?://[^\s<>()\[\]"']+`)
+	emptyBullet  = regexp.MustCompile(`(?m)^[ \t]*(?:[-*•]|\d+\.)[ \t]*$\n?`)
+)
+
+// NewGrounding scores cited items against how many were verified
+func NewGrounding(cited, verified int, removed []string) Grounding {
+	g := Grounding{Cited: cited, Verified: verified, Removed: removed}
+	if cited > 0 {
+		g.Score = math.Round(float64(verified)/float64(cited)*100) / 100
+	}
+	return g
+}
+
+// CheckCitations cross-checks the URLs cited in text against sources, strips any
+// that were not retrieved (keeping a markdown link's label) and scores the result
+func CheckCitations(text string, sources []string) (string, Grounding) {
+	retrieved := map[string]bool{}
+	for _, s := range sources {
+		retrieved[normalizeURL(s)] = true
+	}
+
+	cited := map[string]bool{}
+	removed := []string{}
+	check := func(u string) bool {
+		key := normalizeURL(u)
+		if !cited[key] {
+			cited[key] = true
+			if !retrieved[key] {
+				removed = append(removed, u)
+			}
+		}
+		return retrieved[key]
+	}
+
+	text = markdownLink.ReplaceAllStringFunc(text, func(m string) string {
+		parts := markdownLink.FindStringSubmatch(m)
+		if check(parts[2]) {
+			return m
+		}
+		return parts[1]
+	})
+	text = replaceBareURLs(text, check)
+	text = emptyBullet.ReplaceAllString(text, "")
+
+	return strings.TrimSpace(text), NewGrounding(len(cited), len(cited)-len(removed), removed)
+}
+
+// replaceBareURLs removes URLs outside markdown links for which keep returns false
+func replaceBareURLs(text string, keep func(string) bool) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range bareURL.FindAllStringIndex(text, -1) {
+		// Skip URLs that are the target of a kept markdown link
+		if loc[0] > 0 && text[loc[0]-1] == '(' && loc[0] > 1 && text[loc[0]-2] == ']' {
+			continue
+		}
+		u := strings.TrimRight(text[loc[0]:loc[1]], ".,;:")
+		b.WriteString(text[last:loc[0]])
+		if keep(u) {
+			b.WriteString(u)
+		}
+		last = loc[0] + len(u)
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// normalizeURL makes equivalent URLs compare equal: scheme, "www." prefix,
+// trailing slash and fragment are ignored
+func normalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return strings.ToLower(strings.TrimSpace(raw))
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	path := strings.TrimSuffix(u.Path, "/")
+	key := host + path
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
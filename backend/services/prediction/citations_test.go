@@ -0,0 +1,34 @@
+package prediction
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckCitations(t *testing.T) {
+	sources := []string{"https://www.kompas.com/kopi-naik/", "https://katadata.co.id/umkm"}
+	text := "Permintaan kopi naik [Kompas](https://kompas.com/kopi-naik).\n\nSumber Data:\n" +
+		"- https://katadata.co.id/umkm\n" +
+		"- https://contoh-palsu.id/berita\n" +
+		"- [Laporan](https://tidak-ada.com/x)"
+
+	cleaned, g := CheckCitations(text, sources)
+	if strings.Contains(cleaned, "contoh-palsu") || strings.Contains(cleaned, "tidak-ada.com") {
+		t.Errorf("hallucinated citations kept: %q", cleaned)
+	}
+	for _, want := range []string{"[Kompas](https://kompas.com/kopi-naik)", "https://katadata.co.id/umkm", "- Laporan"} {
+		if !strings.Contains(cleaned, want) {
+			t.Errorf("cleaned text %q missing %q", cleaned, want)
+		}
+	}
+	if strings.Count(cleaned, "\n-") != 2 {
+		t.Errorf("expected the emptied bullet to be dropped: %q", cleaned)
+	}
+	if g.Cited != 4 || g.Verified != 2 || g.Score != 0.5 || len(g.Removed) != 2 {
+		t.Errorf("grounding = %+v", g)
+	}
+
+	if _, g := CheckCitations("Tanpa sumber.", sources); g.Cited != 0 || g.Score != 0 {
+		t.Errorf("uncited grounding = %+v", g)
+	}
+}
@@ -0,0 +1,149 @@
+package prediction
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Diff compares two prediction runs section by section
+type Diff struct {
+	KeywordsAdded      []string       `json:"keywords_added"`
+	KeywordsRemoved    []string       `json:"keywords_removed"`
+	Forecast           *ForecastDelta `json:"forecast,omitempty"`
+	RegulationsAdded   []string       `json:"regulations_added"`
+	RegulationsRemoved []string       `json:"regulations_removed"`
+	MarketChanged      bool           `json:"market_changed"`
+	MarketTrend        *TrendChange   `json:"market_trend,omitempty"` // Set when both runs have a structured outlook with different trends
+	MarketingChanged   bool           `json:"marketing_changed"`
+	SourcesAdded       []string       `json:"sources_added"`
+}
+
+// ForecastDelta is the change in total 30-day forecast between runs
+type ForecastDelta struct {
+	Previous      float64  `json:"previous"`
+	Current       float64  `json:"current"`
+	Change        float64  `json:"change"`
+	ChangePercent *float64 `json:"change_percent,omitempty"` // Nil when the previous forecast was zero
+}
+
+// TrendChange is a change in the structured market trend between runs
+type TrendChange struct {
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
+
+// Compare diffs current against previous. Sections missing from either run are skipped.
+func Compare(previous, current Results) Diff {
+	d := Diff{KeywordsAdded: []string{}, KeywordsRemoved: []string{}, RegulationsAdded: []string{}, RegulationsRemoved: []string{}, SourcesAdded: []string{}}
+
+	if previous.Keywords != nil && current.Keywords != nil {
+		d.KeywordsAdded, d.KeywordsRemoved = setDiff(previous.Keywords.Keywords, current.Keywords.Keywords)
+	}
+	if previous.Regulations != nil && current.Regulations != nil {
+		d.RegulationsAdded, d.RegulationsRemoved = setDiff(previous.Regulations.Regulations, current.Regulations.Regulations)
+	}
+	if previous.Market != nil && current.Market != nil {
+		d.MarketChanged = strings.TrimSpace(previous.Market.Summary) != strings.TrimSpace(current.Market.Summary)
+		d.SourcesAdded, _ = setDiff(previous.Market.Sources, current.Market.Sources)
+		if p, c := previous.Market.Outlook, current.Market.Outlook; p != nil && c != nil && p.Trend != c.Trend {
+			d.MarketTrend = &TrendChange{Previous: p.Trend, Current: c.Trend}
+		}
+	}
+	if previous.Marketing != nil && current.Marketing != nil {
+		d.MarketingChanged = strings.TrimSpace(previous.Marketing.Summary) != strings.TrimSpace(current.Marketing.Summary)
+	}
+	if previous.Forecast != nil && current.Forecast != nil {
+		delta := &ForecastDelta{
+			Previous: previous.Forecast.TotalUnits30d,
+			Current:  current.Forecast.TotalUnits30d,
+			Change:   current.Forecast.TotalUnits30d - previous.Forecast.TotalUnits30d,
+		}
+		if delta.Previous != 0 {
+			pct := math.Round(delta.Change/delta.Previous*1000) / 10
+			delta.ChangePercent = &pct
+		}
+		d.Forecast = delta
+	}
+	return d
+}
+
+// setDiff returns items in current but not previous, and in previous but not
+// current, compared case-insensitively and sorted
+func setDiff(previous, current []string) ([]string, []string) {
+	prev := map[string]bool{}
+	for _, s := range previous {
+		prev[strings.ToLower(strings.TrimSpace(s))] = true
+	}
+	cur := map[string]bool{}
+	for _, s := range current {
+		cur[strings.ToLower(strings.TrimSpace(s))] = true
+	}
+
+	added, removed := []string{}, []string{}
+	for _, s := range current {
+		if !prev[strings.ToLower(strings.TrimSpace(s))] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range previous {
+		if !cur[strings.ToLower(strings.TrimSpace(s))] {
+			removed = append(removed, s)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// trendLabels names market trends in Indonesian
+var trendLabels = map[string]string{"up": "naik", "stable": "stabil", "down": "turun"}
+
+// Summary is the rule-based description of notable changes, used when AI is unavailable
+func Summary(d Diff) string {
+	var lines []string
+	if d.Forecast != nil {
+		switch {
+		case d.Forecast.ChangePercent != nil:
+			lines = append(lines, fmt.Sprintf("Perkiraan permintaan 30 hari berubah %+.1f%% (%.0f → %.0f unit).", *d.Forecast.ChangePercent, d.Forecast.Previous, d.Forecast.Current))
+		case d.Forecast.Change != 0:
+			lines = append(lines, fmt.Sprintf("Perkiraan permintaan 30 hari kini %.0f unit.", d.Forecast.Current))
+		}
+	}
+	if d.MarketTrend != nil {
+		lines = append(lines, fmt.Sprintf("Arah pasar berubah dari %s menjadi %s.", trendLabels[d.MarketTrend.Previous], trendLabels[d.MarketTrend.Current]))
+	}
+	if len(d.KeywordsAdded) > 0 {
+		lines = append(lines, "Kata kunci baru: "+strings.Join(d.KeywordsAdded, ", ")+".")
+	}
+	if len(d.KeywordsRemoved) > 0 {
+		lines = append(lines, "Kata kunci yang tidak lagi relevan: "+strings.Join(d.KeywordsRemoved, ", ")+".")
+	}
+	if len(d.RegulationsAdded) > 0 {
+		lines = append(lines, "Peraturan baru yang perlu diperhatikan: "+strings.Join(d.RegulationsAdded, "; ")+".")
+	}
+	if d.MarketChanged && len(d.SourcesAdded) > 0 {
+		lines = append(lines, fmt.Sprintf("Prediksi pasar diperbarui berdasarkan %d sumber baru.", len(d.SourcesAdded)))
+	}
+	if len(lines) == 0 {
+		return "Tidak ada perubahan berarti dibanding analisis sebelumnya."
+	}
+	return strings.Join(lines, " ")
+}
+
+// BuildDiffPrompt asks the model to explain the notable changes between runs
+func BuildDiffPrompt(previous, current Results, d Diff) string {
+	var b strings.Builder
+	b.WriteString("Bandingkan dua hasil analisis bisnis bulanan untuk sebuah UMKM dan jelaskan perubahan yang paling penting.\n\n")
+	b.WriteString("Perubahan terdeteksi:\n")
+	b.WriteString(Summary(d))
+	if previous.Market != nil && current.Market != nil && d.MarketChanged {
+		fmt.Fprintf(&b, "\n\nPrediksi pasar sebelumnya:\n%s\n\nPrediksi pasar terbaru:\n%s", previous.Market.Summary, current.Market.Summary)
+	}
+	if previous.Marketing != nil && current.Marketing != nil && d.MarketingChanged {
+		fmt.Fprintf(&b, "\n\nRekomendasi marketing sebelumnya:\n%s\n\nRekomendasi marketing terbaru:\n%s", previous.Marketing.Summary, current.Marketing.Summary)
+	}
+	b.WriteString("\n\nTulis maksimal 5 kalimat dalam bahasa Indonesia yang menyoroti evolusi bisnis dan pasar, lalu satu saran tindakan. Jangan mengarang angka.")
+	return b.String()
+}
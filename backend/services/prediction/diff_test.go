@@ -0,0 +1,96 @@
+package prediction
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	previous := Results{
+		Keywords:    &KeywordsResult{Keywords: []string{"kopi susu", "Kopi Gula Aren"}},
+		Regulations: &RegulationsResult{Regulations: []string{"NIB"}},
+		Forecast:    &ForecastResult{TotalUnits30d: 200},
+		Market:      &MarketResult{Summary: "Permintaan stabil", Sources: []string{"https://a"}},
+	}
+	current := Results{
+		Keywords:    &KeywordsResult{Keywords: []string{"kopi gula aren", "cold brew"}},
+		Regulations: &RegulationsResult{Regulations: []string{"NIB", "Sertifikasi Halal"}},
+		Forecast:    &ForecastResult{TotalUnits30d: 250},
+		Market:      &MarketResult{Summary: "Permintaan naik", Sources: []string{"https://a", "https://b"}},
+	}
+
+	d := Compare(previous, current)
+	if strings.Join(d.KeywordsAdded, ",") != "cold brew" || strings.Join(d.KeywordsRemoved, ",") != "kopi susu" {
+		t.Errorf("keywords added %v removed %v", d.KeywordsAdded, d.KeywordsRemoved)
+	}
+	if strings.Join(d.RegulationsAdded, ",") != "Sertifikasi Halal" || len(d.RegulationsRemoved) != 0 {
+		t.Errorf("regulations added %v removed %v", d.RegulationsAdded, d.RegulationsRemoved)
+	}
+	if d.Forecast == nil || d.Forecast.Change != 50 || *d.Forecast.ChangePercent != 25 {
+		t.Errorf("forecast delta = %+v", d.Forecast)
+	}
+	if !d.MarketChanged || strings.Join(d.SourcesAdded, ",") != "https://b" {
+		t.Errorf("market changed %v sources added %v", d.MarketChanged, d.SourcesAdded)
+	}
+	if d.MarketingChanged {
+		t.Error("marketing missing from both runs should not be a change")
+	}
+
+	summary := Summary(d)
+	for _, want := range []string{"+25.0%", "cold brew", "Sertifikasi Halal"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary %q should mention %q", summary, want)
+		}
+	}
+}
+
+func TestCompareUnchanged(t *testing.T) {
+	r := Results{Forecast: &ForecastResult{TotalUnits30d: 0}}
+	d := Compare(r, r)
+	if d.Forecast.ChangePercent != nil {
+		t.Error("change percent should be nil when the previous forecast is zero")
+	}
+	if got := Summary(d); !strings.HasPrefix(got, "Tidak ada perubahan") {
+		t.Errorf("Summary = %q", got)
+	}
+}
+
+func TestResultsSet(t *testing.T) {
+	var r Results
+	if err := r.Set(StepKeywords, []byte(`{"keywords":["a"]}`)); err != nil || r.Keywords.Keywords[0] != "a" {
+		t.Errorf("Set keywords: %v %+v", err, r.Keywords)
+	}
+	if err := r.Set("unknown", []byte(`{}`)); err == nil {
+		t.Error("Set should reject unknown steps")
+	}
+}
+
+func TestIsStep(t *testing.T) {
+	if !IsStep(StepKeywords) || IsStep("social") {
+		t.Error("IsStep should accept only known steps")
+	}
+}
+
+func TestSectionsWithData(t *testing.T) {
+	r := Results{
+		Keywords: &KeywordsResult{Keywords: []string{"kopi"}},
+		Market:   &MarketResult{Summary: "Prediksi pasar belum tersedia"},
+		Forecast: &ForecastResult{TotalUnits30d: 12},
+	}
+	if got := strings.Join(r.SectionsWithData(), ","); got != "keywords,forecast" {
+		t.Errorf("SectionsWithData = %s, want keywords,forecast", got)
+	}
+}
+
+func TestCompareMarketTrend(t *testing.T) {
+	previous := Results{Market: &MarketResult{Summary: "a", Outlook: &MarketOutlook{Trend: "stable"}}}
+	current := Results{Market: &MarketResult{Summary: "b", Outlook: &MarketOutlook{Trend: "up"}}}
+
+	d := Compare(previous, current)
+	if d.MarketTrend == nil || d.MarketTrend.Previous != "stable" || d.MarketTrend.Current != "up" {
+		t.Fatalf("market trend = %+v", d.MarketTrend)
+	}
+	if s := Summary(d); !strings.Contains(s, "stabil menjadi naik") {
+		t.Errorf("summary %q does not mention the trend change", s)
+	}
+}
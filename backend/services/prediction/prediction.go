@@ -0,0 +1,143 @@
+package prediction
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Steps of a prediction job, in execution order
+const (
+	StepKeywords    = "keywords"
+	StepMarket      = "market_prediction"
+	StepMarketing   = "marketing_recommendations"
+	StepRegulations = "regulations"
+	StepForecast    = "forecast"
+)
+
+// Steps lists every step in the order a job runs them
+var Steps = []string{StepKeywords, StepMarket, StepMarketing, StepRegulations, StepForecast}
+
+// IsStep reports whether step names a prediction step
+func IsStep(step string) bool {
+	for _, s := range Steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Job and step statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusTimedOut  = "timed_out" // Step only: the step exceeded its deadline
+)
+
+// KeywordsResult holds the search keywords derived from the company profile and
+// catalog. Keywords also lists the shared industry keywords that were added,
+// which Shared names.
+type KeywordsResult struct {
+	Keywords []string `json:"keywords"`
+	Shared   []string `json:"shared,omitempty"`
+}
+
+// MarketResult is the market prediction narrative and the research it is based on.
+// Outlook is set when the provider returned valid structured output; Grounding
+// is set for AI-written narratives.
+type MarketResult struct {
+	Summary   string         `json:"summary"`
+	Sources   []string       `json:"sources,omitempty"`
+	Outlook   *MarketOutlook `json:"outlook,omitempty"`
+	Grounding *Grounding     `json:"grounding,omitempty"`
+}
+
+// MarketingResult holds marketing recommendations, structured in Plan when available
+type MarketingResult struct {
+	Summary       string         `json:"summary"`
+	FocusProducts []string       `json:"focus_products,omitempty"`
+	Plan          *MarketingPlan `json:"plan,omitempty"`
+	Grounding     *Grounding     `json:"grounding,omitempty"`
+}
+
+// RegulationsResult lists regulations relevant to the business, with their
+// obligations and priority in Items when available
+type RegulationsResult struct {
+	Regulations []string         `json:"regulations"`
+	Summary     string           `json:"summary"`
+	Items       []RegulationItem `json:"items,omitempty"`
+	Sources     []string         `json:"sources,omitempty"`
+	Grounding   *Grounding       `json:"grounding,omitempty"`
+}
+
+// ForecastResult is the 30-day demand forecast across the catalog
+type ForecastResult struct {
+	TotalUnits30d float64           `json:"total_units_30d"`
+	Products      []ProductForecast `json:"products"`
+}
+
+// ProductForecast is one product's 30-day forecast
+type ProductForecast struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Units30d    float64 `json:"units_30d"`
+	Algorithm   string  `json:"algorithm"`
+}
+
+// Results are the decoded step results of one job. Steps that did not
+// complete are nil.
+type Results struct {
+	Keywords    *KeywordsResult
+	Market      *MarketResult
+	Marketing   *MarketingResult
+	Regulations *RegulationsResult
+	Forecast    *ForecastResult
+}
+
+// Set decodes raw into the result for step
+func (r *Results) Set(step string, raw []byte) error {
+	var target interface{}
+	switch step {
+	case StepKeywords:
+		r.Keywords = &KeywordsResult{}
+		target = r.Keywords
+	case StepMarket:
+		r.Market = &MarketResult{}
+		target = r.Market
+	case StepMarketing:
+		r.Marketing = &MarketingResult{}
+		target = r.Marketing
+	case StepRegulations:
+		r.Regulations = &RegulationsResult{}
+		target = r.Regulations
+	case StepForecast:
+		r.Forecast = &ForecastResult{}
+		target = r.Forecast
+	default:
+		return fmt.Errorf("unknown step %q", step)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+// SectionsWithData lists the completed steps whose results contain usable data, in step order
+func (r Results) SectionsWithData() []string {
+	sections := []string{}
+	if r.Keywords != nil && len(r.Keywords.Keywords) > 0 {
+		sections = append(sections, StepKeywords)
+	}
+	if r.Market != nil && len(r.Market.Sources) > 0 {
+		sections = append(sections, StepMarket)
+	}
+	if r.Marketing != nil && r.Marketing.Summary != "" {
+		sections = append(sections, StepMarketing)
+	}
+	if r.Regulations != nil && len(r.Regulations.Regulations) > 0 {
+		sections = append(sections, StepRegulations)
+	}
+	if r.Forecast != nil && r.Forecast.TotalUnits30d > 0 {
+		sections = append(sections, StepForecast)
+	}
+	return sections
+}
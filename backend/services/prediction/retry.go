@@ -0,0 +1,61 @@
+package prediction
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// StepTimeout bounds how long a single attempt at a step may run. Steps that
+// call web search and the AI provider get the most time.
+func StepTimeout(step string) time.Duration {
+	switch step {
+	case StepMarket:
+		return 2 * time.Minute
+	case StepMarketing, StepRegulations:
+		return 90 * time.Second
+	default:
+		return time.Minute
+	}
+}
+
+// RetryPolicy controls retries of transient provider errors
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetry retries a step up to twice, waiting roughly 2s then 4s
+var DefaultRetry = RetryPolicy{Attempts: 3, BaseDelay: 2 * time.Second, MaxDelay: 15 * time.Second}
+
+// Backoff returns the wait before retry number attempt (0-based): exponential,
+// capped at MaxDelay, with jitter over the upper half so concurrent jobs spread out.
+// jitter must return a value in [0, 1).
+func (p RetryPolicy) Backoff(attempt int, jitter func() float64) time.Duration {
+	d := p.BaseDelay << attempt
+	if d > p.MaxDelay || d <= 0 {
+		d = p.MaxDelay
+	}
+	return d/2 + time.Duration(jitter()*float64(d/2))
+}
+
+// Retry calls fn until it succeeds, returns an error transient rejects, the
+// attempts are used up or ctx is done. It returns fn's last error.
+func Retry(ctx context.Context, p RetryPolicy, transient func(error) bool, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < p.Attempts; attempt++ {
+		if err = fn(); err == nil || !transient(err) || attempt == p.Attempts-1 {
+			return err
+		}
+
+		timer := time.NewTimer(p.Backoff(attempt, rand.Float64))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+	return err
+}
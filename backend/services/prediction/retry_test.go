@@ -0,0 +1,50 @@
+package prediction
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("503")
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{Attempts: 3, BaseDelay: 2 * time.Second, MaxDelay: 5 * time.Second}
+	if got := p.Backoff(0, func() float64 { return 0 }); got != time.Second {
+		t.Errorf("Backoff(0) without jitter = %v, want 1s", got)
+	}
+	if got := p.Backoff(1, func() float64 { return 0.5 }); got != 3*time.Second {
+		t.Errorf("Backoff(1) with half jitter = %v, want 3s", got)
+	}
+	if got := p.Backoff(5, func() float64 { return 0 }); got != 2500*time.Millisecond {
+		t.Errorf("Backoff should cap at MaxDelay, got %v", got)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	p := RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	transient := func(err error) bool { return err == errTransient }
+
+	calls := 0
+	err := Retry(context.Background(), p, transient, func() error {
+		calls++
+		if calls < 3 {
+			return errTransient
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Retry = %v after %d calls, want success after 3", err, calls)
+	}
+
+	calls = 0
+	permanent := errors.New("400")
+	err = Retry(context.Background(), p, transient, func() error {
+		calls++
+		return permanent
+	})
+	if err != permanent || calls != 1 {
+		t.Errorf("Retry should not retry permanent errors: %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,241 @@
+package prediction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Allowed values for structured section fields
+var (
+	Trends     = []string{"up", "stable", "down"}
+	Levels     = []string{"low", "medium", "high"}
+	Channels   = []string{"social_media", "marketplace", "whatsapp", "offline", "other"}
+	Priorities = Levels
+)
+
+// MarketOutlook is the structured market prediction
+type MarketOutlook struct {
+	Summary       string   `json:"summary"`
+	Trend         string   `json:"trend"`      // One of Trends
+	Confidence    string   `json:"confidence"` // One of Levels
+	Drivers       []string `json:"drivers"`
+	Risks         []string `json:"risks"`
+	Opportunities []string `json:"opportunities"`
+}
+
+// MarketingPlan is the structured set of marketing recommendations
+type MarketingPlan struct {
+	Summary         string                    `json:"summary"`
+	Recommendations []MarketingRecommendation `json:"recommendations"`
+}
+
+// MarketingRecommendation is one concrete marketing action
+type MarketingRecommendation struct {
+	Action    string `json:"action"`
+	Product   string `json:"product,omitempty"`
+	Channel   string `json:"channel"` // One of Channels
+	Rationale string `json:"rationale"`
+}
+
+// RegulationsBrief is the structured summary of relevant regulations
+type RegulationsBrief struct {
+	Summary string           `json:"summary"`
+	Items   []RegulationItem `json:"items"`
+}
+
+// RegulationItem is what a regulation requires of the business and how urgent it is
+type RegulationItem struct {
+	Title      string `json:"title"`
+	Obligation string `json:"obligation"`
+	Priority   string `json:"priority"` // One of Priorities
+}
+
+// stringList is a JSON schema for an array of strings
+func stringList(description string, maxItems int) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": description,
+		"items":       map[string]interface{}{"type": "string"},
+		"maxItems":    maxItems,
+	}
+}
+
+// enum is a JSON schema for a string restricted to values
+func enum(description string, values []string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description, "enum": values}
+}
+
+// Schema returns the JSON schema the provider must follow for a step's structured
+// output, or nil for steps computed without AI
+func Schema(step string) map[string]interface{} {
+	summary := map[string]interface{}{"type": "string", "description": "Narasi singkat dalam Bahasa Indonesia, maksimal 5 kalimat"}
+	switch step {
+	case StepMarket:
+		return map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"summary":       summary,
+				"trend":         enum("Arah permintaan pasar 1-3 bulan ke depan", Trends),
+				"confidence":    enum("Tingkat keyakinan berdasarkan kualitas sumber", Levels),
+				"drivers":       stringList("Faktor pendorong utama", 5),
+				"risks":         stringList("Risiko yang perlu diwaspadai", 5),
+				"opportunities": stringList("Peluang yang bisa dimanfaatkan", 5),
+			},
+			"required":             []string{"summary", "trend", "confidence", "drivers", "risks", "opportunities"},
+			"additionalProperties": false,
+		}
+	case StepMarketing:
+		return map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"summary": summary,
+				"recommendations": map[string]interface{}{
+					"type":     "array",
+					"minItems": 1,
+					"maxItems": 5,
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"action":    map[string]interface{}{"type": "string", "description": "Tindakan konkret"},
+							"product":   map[string]interface{}{"type": "string", "description": "Produk yang dipromosikan, jika ada"},
+							"channel":   enum("Kanal pemasaran", Channels),
+							"rationale": map[string]interface{}{"type": "string", "description": "Alasan singkat"},
+						},
+						"required": []string{"action", "channel", "rationale"},
+					},
+				},
+			},
+			"required":             []string{"summary", "recommendations"},
+			"additionalProperties": false,
+		}
+	case StepRegulations:
+		return map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"summary": summary,
+				"items": map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"title":      map[string]interface{}{"type": "string", "description": "Judul peraturan persis seperti pada daftar"},
+							"obligation": map[string]interface{}{"type": "string", "description": "Kewajiban bagi usaha ini"},
+							"priority":   enum("Seberapa mendesak untuk dipenuhi", Priorities),
+						},
+						"required": []string{"title", "obligation", "priority"},
+					},
+				},
+			},
+			"required":             []string{"summary", "items"},
+			"additionalProperties": false,
+		}
+	}
+	return nil
+}
+
+// DecodeStructured parses and validates a step's structured output. The returned
+// value is a *MarketOutlook, *MarketingPlan or *RegulationsBrief.
+func DecodeStructured(step string, raw []byte) (interface{}, error) {
+	var target interface {
+		validate() error
+	}
+	switch step {
+	case StepMarket:
+		target = &MarketOutlook{}
+	case StepMarketing:
+		target = &MarketingPlan{}
+	case StepRegulations:
+		target = &RegulationsBrief{}
+	default:
+		return nil, fmt.Errorf("step %q has no structured output", step)
+	}
+	if err := json.Unmarshal(trimCodeFence(raw), target); err != nil {
+		return nil, fmt.Errorf("decode %s output: %w", step, err)
+	}
+	if err := target.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s output: %w", step, err)
+	}
+	return target, nil
+}
+
+func (o *MarketOutlook) validate() error {
+	if strings.TrimSpace(o.Summary) == "" {
+		return fmt.Errorf("summary is required")
+	}
+	if err := oneOf("trend", o.Trend, Trends); err != nil {
+		return err
+	}
+	if err := oneOf("confidence", o.Confidence, Levels); err != nil {
+		return err
+	}
+	o.Drivers, o.Risks, o.Opportunities = compact(o.Drivers), compact(o.Risks), compact(o.Opportunities)
+	return nil
+}
+
+func (p *MarketingPlan) validate() error {
+	if strings.TrimSpace(p.Summary) == "" {
+		return fmt.Errorf("summary is required")
+	}
+	if len(p.Recommendations) == 0 {
+		return fmt.Errorf("at least one recommendation is required")
+	}
+	for i, r := range p.Recommendations {
+		if strings.TrimSpace(r.Action) == "" {
+			return fmt.Errorf("recommendations[%d].action is required", i)
+		}
+		if err := oneOf(fmt.Sprintf("recommendations[%d].channel", i), r.Channel, Channels); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *RegulationsBrief) validate() error {
+	if strings.TrimSpace(b.Summary) == "" {
+		return fmt.Errorf("summary is required")
+	}
+	if b.Items == nil {
+		b.Items = []RegulationItem{}
+	}
+	for i, item := range b.Items {
+		if strings.TrimSpace(item.Title) == "" {
+			return fmt.Errorf("items[%d].title is required", i)
+		}
+		if err := oneOf(fmt.Sprintf("items[%d].priority", i), item.Priority, Priorities); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimCodeFence strips a markdown code fence around JSON answered as plain content
+func trimCodeFence(raw []byte) []byte {
+	s := strings.TrimSpace(string(raw))
+	if !strings.HasPrefix(s, "```") {
+		return raw
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "```"), "json")
+	return []byte(strings.TrimSpace(strings.TrimSuffix(s, "```")))
+}
+
+// oneOf checks that value is one of the allowed values
+func oneOf(field, value string, allowed []string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got %q", field, strings.Join(allowed, ", "), value)
+}
+
+// compact drops blank entries, returning an empty (not nil) slice
+func compact(items []string) []string {
+	out := []string{}
+	for _, s := range items {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
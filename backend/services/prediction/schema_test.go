@@ -0,0 +1,51 @@
+package prediction
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeStructured(t *testing.T) {
+	v, err := DecodeStructured(StepMarket, []byte(`{"summary":"Permintaan naik","trend":"up","confidence":"medium","drivers":["Ramadan", " "],"risks":null,"opportunities":[]}`))
+	if err != nil {
+		t.Fatalf("DecodeStructured: %v", err)
+	}
+	o := v.(*MarketOutlook)
+	if o.Trend != "up" || len(o.Drivers) != 1 || o.Risks == nil {
+		t.Errorf("outlook = %+v", o)
+	}
+
+	fenced := "```json\n{\"summary\":\"x\",\"items\":[{\"title\":\"NIB\",\"obligation\":\"Daftar OSS\",\"priority\":\"high\"}]}\n```"
+	if _, err := DecodeStructured(StepRegulations, []byte(fenced)); err != nil {
+		t.Errorf("fenced output: %v", err)
+	}
+
+	cases := []struct {
+		step, raw, want string
+	}{
+		{StepMarket, `{"summary":"x","trend":"naik","confidence":"high"}`, "trend must be one of"},
+		{StepMarket, `{"trend":"up","confidence":"high"}`, "summary is required"},
+		{StepMarketing, `{"summary":"x","recommendations":[]}`, "at least one recommendation"},
+		{StepMarketing, `{"summary":"x","recommendations":[{"action":"Promo","channel":"tv"}]}`, "recommendations[0].channel"},
+		{StepRegulations, `{"summary":"x","items":[{"title":"NIB","priority":"urgent"}]}`, "items[0].priority"},
+		{StepRegulations, `not json`, "decode regulations output"},
+		{StepForecast, `{}`, "has no structured output"},
+	}
+	for _, c := range cases {
+		_, err := DecodeStructured(c.step, []byte(c.raw))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("DecodeStructured(%s, %s) error = %v, want %q", c.step, c.raw, err, c.want)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	for _, step := range []string{StepMarket, StepMarketing, StepRegulations} {
+		if Schema(step) == nil {
+			t.Errorf("Schema(%s) = nil", step)
+		}
+	}
+	if Schema(StepForecast) != nil {
+		t.Error("forecast has no structured output")
+	}
+}
@@ -0,0 +1,166 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultTargetMargin is the gross margin (percent of price) used when none is given
+const DefaultTargetMargin = 30.0
+
+// Headroom above the recommended price worth testing before demand drops off
+const priceHeadroom = 0.15
+
+// Input holds the numbers needed to analyze a product's price
+type Input struct {
+	UnitCost      float64 `json:"unit_cost"`
+	Price         float64 `json:"price"`
+	FixedCosts    float64 `json:"fixed_costs"`    // Monthly fixed costs allocated to this product
+	MonthlyVolume float64 `json:"monthly_volume"` // Average units sold per month
+	TargetMargin  float64 `json:"target_margin"`  // Gross margin as a percent of price
+}
+
+// PriceRange is a suggested selling price band
+type PriceRange struct {
+	Min         float64 `json:"min"`         // Lowest price that still covers costs at the current volume
+	Recommended float64 `json:"recommended"` // Price that reaches the target margin
+	Max         float64 `json:"max"`
+}
+
+// Result is the break-even and pricing analysis for a product
+type Result struct {
+	Input
+	ContributionMargin float64    `json:"contribution_margin"` // Price minus unit cost
+	CurrentMargin      float64    `json:"current_margin"`      // Percent of price
+	BreakEvenUnits     *float64   `json:"break_even_units"`    // Per month; nil when the price does not cover unit cost
+	BreakEvenRevenue   *float64   `json:"break_even_revenue"`
+	MonthlyProfit      float64    `json:"monthly_profit"` // At the current price and volume
+	SuggestedPrice     PriceRange `json:"suggested_price"`
+	Warnings           []string   `json:"warnings"`
+}
+
+// Validate reports the first problem with the input
+func (in Input) Validate() error {
+	switch {
+	case in.UnitCost <= 0:
+		return fmt.Errorf("unit_cost must be greater than zero")
+	case in.Price < 0 || in.FixedCosts < 0 || in.MonthlyVolume < 0:
+		return fmt.Errorf("price, fixed_costs and monthly_volume must not be negative")
+	case in.TargetMargin < 0 || in.TargetMargin >= 95:
+		return fmt.Errorf("target_margin must be between 0 and 95")
+	}
+	return nil
+}
+
+// Analyze computes break-even volume and a suggested price range
+func Analyze(in Input) Result {
+	if in.TargetMargin == 0 {
+		in.TargetMargin = DefaultTargetMargin
+	}
+
+	r := Result{Input: in, Warnings: []string{}}
+	r.ContributionMargin = in.Price - in.UnitCost
+	if in.Price > 0 {
+		r.CurrentMargin = r.ContributionMargin / in.Price * 100
+	}
+	r.MonthlyProfit = in.MonthlyVolume*r.ContributionMargin - in.FixedCosts
+
+	if r.ContributionMargin > 0 {
+		units := math.Ceil(in.FixedCosts / r.ContributionMargin)
+		revenue := units * in.Price
+		r.BreakEvenUnits = &units
+		r.BreakEvenRevenue = &revenue
+	}
+
+	// The floor spreads fixed costs over the current volume; with no sales yet only unit cost counts
+	floor := in.UnitCost
+	if in.MonthlyVolume > 0 {
+		floor += in.FixedCosts / in.MonthlyVolume
+	}
+	target := in.UnitCost / (1 - in.TargetMargin/100)
+	if target < floor {
+		target = floor
+	}
+	r.SuggestedPrice = PriceRange{
+		Min:         RoundPrice(floor),
+		Recommended: RoundPrice(target),
+		Max:         RoundPrice(target * (1 + priceHeadroom)),
+	}
+
+	switch {
+	case in.Price > 0 && r.ContributionMargin <= 0:
+		r.Warnings = append(r.Warnings, "Harga jual di bawah atau sama dengan modal per unit; setiap penjualan merugi.")
+	case r.BreakEvenUnits != nil && in.MonthlyVolume < *r.BreakEvenUnits:
+		r.Warnings = append(r.Warnings, fmt.Sprintf("Volume penjualan %.0f/bulan masih di bawah titik impas %.0f/bulan.", in.MonthlyVolume, *r.BreakEvenUnits))
+	}
+	if in.Price > 0 && in.Price < r.SuggestedPrice.Min {
+		r.Warnings = append(r.Warnings, "Harga saat ini belum menutup biaya tetap pada volume penjualan sekarang.")
+	}
+	if in.MonthlyVolume == 0 {
+		r.Warnings = append(r.Warnings, "Belum ada data penjualan; batas bawah harga hanya memperhitungkan modal per unit.")
+	}
+	return r
+}
+
+// RoundPrice rounds up to a common retail price step: Rp 100 below Rp 5.000, Rp 500 above
+func RoundPrice(p float64) float64 {
+	step := 500.0
+	if p < 5000 {
+		step = 100
+	}
+	return math.Ceil(p/step) * step
+}
+
+// Narrative writes a plain-Indonesian pricing explanation without calling an AI provider
+func Narrative(productName string, r Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s dijual Rp %s dengan modal Rp %s per unit, margin kotor %.0f%%. ",
+		productName, formatRupiah(r.Price), formatRupiah(r.UnitCost), r.CurrentMargin)
+	if r.BreakEvenUnits != nil {
+		fmt.Fprintf(&b, "Untuk menutup biaya tetap Rp %s per bulan, perlu terjual minimal %.0f unit per bulan. ",
+			formatRupiah(r.FixedCosts), *r.BreakEvenUnits)
+	}
+	fmt.Fprintf(&b, "Rentang harga yang disarankan Rp %s - Rp %s, dengan harga ideal Rp %s untuk mencapai margin %.0f%%.",
+		formatRupiah(r.SuggestedPrice.Min), formatRupiah(r.SuggestedPrice.Max), formatRupiah(r.SuggestedPrice.Recommended), r.TargetMargin)
+	for _, w := range r.Warnings {
+		b.WriteString(" " + w)
+	}
+	return b.String()
+}
+
+// BuildPrompt asks an AI provider for a short pricing narrative grounded in the computed numbers
+func BuildPrompt(productName string, r Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Produk: %s\n", productName)
+	fmt.Fprintf(&b, "Harga jual: Rp %s\nModal per unit: Rp %s\nBiaya tetap per bulan: Rp %s\n",
+		formatRupiah(r.Price), formatRupiah(r.UnitCost), formatRupiah(r.FixedCosts))
+	fmt.Fprintf(&b, "Rata-rata terjual: %.0f unit/bulan\nMargin kotor saat ini: %.1f%%\nTarget margin: %.0f%%\n",
+		r.MonthlyVolume, r.CurrentMargin, r.TargetMargin)
+	if r.BreakEvenUnits != nil {
+		fmt.Fprintf(&b, "Titik impas: %.0f unit/bulan\n", *r.BreakEvenUnits)
+	}
+	fmt.Fprintf(&b, "Laba bulanan saat ini: Rp %s\n", formatRupiah(r.MonthlyProfit))
+	fmt.Fprintf(&b, "Rentang harga disarankan: Rp %s - Rp %s (ideal Rp %s)\n",
+		formatRupiah(r.SuggestedPrice.Min), formatRupiah(r.SuggestedPrice.Max), formatRupiah(r.SuggestedPrice.Recommended))
+	b.WriteString("\nJelaskan dalam 3-4 kalimat Bahasa Indonesia yang sederhana apakah harga ini sehat, apa risikonya, " +
+		"dan langkah konkret soal harga. Gunakan hanya angka di atas, jangan mengarang angka baru.")
+	return b.String()
+}
+
+// formatRupiah formats a whole-rupiah amount with dot thousand separators
+func formatRupiah(v float64) string {
+	neg := v < 0
+	s := fmt.Sprintf("%.0f", math.Abs(v))
+	var out []byte
+	for i := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			out = append(out, '.')
+		}
+		out = append(out, s[i])
+	}
+	if neg {
+		return "-" + string(out)
+	}
+	return string(out)
+}
@@ -0,0 +1,47 @@
+package pricing
+
+import "testing"
+
+func TestAnalyze(t *testing.T) {
+	r := Analyze(Input{UnitCost: 7000, Price: 10000, FixedCosts: 1500000, MonthlyVolume: 400})
+
+	if r.TargetMargin != DefaultTargetMargin {
+		t.Errorf("TargetMargin = %v, want default %v", r.TargetMargin, DefaultTargetMargin)
+	}
+	if r.BreakEvenUnits == nil || *r.BreakEvenUnits != 500 {
+		t.Fatalf("BreakEvenUnits = %v, want 500", r.BreakEvenUnits)
+	}
+	if r.MonthlyProfit != -300000 {
+		t.Errorf("MonthlyProfit = %v, want -300000", r.MonthlyProfit)
+	}
+	// Floor: 7000 + 1.500.000/400 = 10.750; target: 7000/0.7 = 10.000 raised to the floor
+	if r.SuggestedPrice.Min != 11000 || r.SuggestedPrice.Recommended != 11000 || r.SuggestedPrice.Max != 12500 {
+		t.Errorf("SuggestedPrice = %+v", r.SuggestedPrice)
+	}
+	if len(r.Warnings) != 2 {
+		t.Errorf("expected break-even and price floor warnings, got %v", r.Warnings)
+	}
+
+	loss := Analyze(Input{UnitCost: 5000, Price: 4000, TargetMargin: 40})
+	if loss.BreakEvenUnits != nil {
+		t.Error("BreakEvenUnits should be nil when price does not cover unit cost")
+	}
+}
+
+func TestRoundPrice(t *testing.T) {
+	tests := map[float64]float64{1234: 1300, 4999: 5000, 10750: 11000, 12000: 12000}
+	for in, want := range tests {
+		if got := RoundPrice(in); got != want {
+			t.Errorf("RoundPrice(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestFormatRupiah(t *testing.T) {
+	tests := map[float64]string{0: "0", 950: "950", 1500000: "1.500.000", -300000: "-300.000"}
+	for in, want := range tests {
+		if got := formatRupiah(in); got != want {
+			t.Errorf("formatRupiah(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
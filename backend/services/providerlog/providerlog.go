@@ -0,0 +1,90 @@
+package providerlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+
+	"github.com/bantuaku/backend/services/auditlog"
+)
+
+// maxVectorLength is the longest numeric array kept verbatim. Longer ones are
+// embedding vectors and are replaced by their length.
+const maxVectorLength = 32
+
+// piiPatterns replace personal data inside string values. NIK and NPWP run
+// before phone numbers so their digits aren't mistaken for one.
+var piiPatterns = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), "[EMAIL]"},
+	{regexp.MustCompile(`\b\d{2}\.\d{3}\.\d{3}\.\d-\d{3}\.\d{3}\b`), "[NPWP]"},
+	{regexp.MustCompile(`\b\d{16}\b`), "[NIK]"},
+	{regexp.MustCompile(`\b\d{15}\b`), "[NPWP]"},
+	{regexp.MustCompile(`(?:\+62|\b62|\b0)8\d{1,3}[\s\-]?\d{3,4}[\s\-]?\d{3,5}\b`), "[PHONE]"},
+}
+
+// Redact returns a provider request or response body that is safe to store:
+// secret keys are redacted as in audit logs, emails, phone numbers, NIK and
+// NPWP inside strings are masked, and embedding vectors are reduced to their
+// length. Bodies that aren't JSON keep only their size.
+func Redact(body []byte) []byte {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		out, _ := json.Marshal(map[string]interface{}{"unparsed_bytes": len(body)})
+		return out
+	}
+	out, _ := json.Marshal(redactValue(v))
+	return out
+}
+
+// ScrubText masks emails, phone numbers, NIK and NPWP in free text
+func ScrubText(s string) string {
+	for _, p := range piiPatterns {
+		s = p.re.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			// Token counts such as max_tokens match the secret key list but
+			// are needed to replay a request
+			if _, isNumber := val.(json.Number); auditlog.IsSecretKey(k) && !isNumber {
+				t[k] = auditlog.Redacted
+			} else {
+				t[k] = redactValue(val)
+			}
+		}
+		return t
+	case []interface{}:
+		if len(t) > maxVectorLength && allNumbers(t) {
+			return map[string]interface{}{"vector_length": len(t)}
+		}
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+		return t
+	case string:
+		return ScrubText(t)
+	default:
+		return v
+	}
+}
+
+func allNumbers(values []interface{}) bool {
+	for _, v := range values {
+		if _, ok := v.(json.Number); !ok {
+			return false
+		}
+	}
+	return true
+}
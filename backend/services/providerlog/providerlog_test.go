@@ -0,0 +1,45 @@
+package providerlog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	body := []byte(`{"model":"default","max_tokens":512,"api_key":"k","messages":[{"role":"user","content":"Email budi@toko.id atau WA 0812-3456-7890, NIK 3171234567890001"}]}`)
+	var got struct {
+		MaxTokens json.Number `json:"max_tokens"`
+		APIKey    string      `json:"api_key"`
+		Messages  []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(Redact(body), &got); err != nil {
+		t.Fatalf("redacted body is not JSON: %v", err)
+	}
+	if got.MaxTokens != "512" {
+		t.Errorf("max_tokens = %q, want 512", got.MaxTokens)
+	}
+	if got.APIKey != "[REDACTED]" {
+		t.Errorf("api_key = %q, want redacted", got.APIKey)
+	}
+	want := "Email [EMAIL] atau WA [PHONE], NIK [NIK]"
+	if got.Messages[0].Content != want {
+		t.Errorf("content = %q, want %q", got.Messages[0].Content, want)
+	}
+}
+
+func TestRedactVectors(t *testing.T) {
+	vector := strings.Repeat("0.1,", 63) + "0.1"
+	body := []byte(`{"data":[{"embedding":[` + vector + `],"index":0}]}`)
+	if got := string(Redact(body)); !strings.Contains(got, `"vector_length":64`) {
+		t.Errorf("Redact() = %s, want vector replaced by its length", got)
+	}
+}
+
+func TestScrubTextNPWP(t *testing.T) {
+	if got := ScrubText("NPWP 01.234.567.8-901.000"); got != "NPWP [NPWP]" {
+		t.Errorf("ScrubText() = %q", got)
+	}
+}
@@ -0,0 +1,140 @@
+package purchasing
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Plan bounds
+const (
+	DefaultLeadTimeDays = 7  // Used for products without a lead time
+	DefaultHorizonDays  = 28 // How far ahead orders are planned
+	MaxHorizonDays      = 91
+	DefaultReviewDays   = 7 // How often orders are placed
+	MaxLeadTimeDays     = 365
+)
+
+// Item is a product to plan purchases for
+type Item struct {
+	ProductID        string
+	Name             string
+	SKU              string
+	Unit             string
+	Supplier         string
+	LeadTimeDays     *int // nil uses DefaultLeadTimeDays
+	MinOrderQuantity float64
+	UnitCost         float64
+	DailyRate        float64 // Forecast sales per day
+}
+
+// Order is one purchase to place
+type Order struct {
+	ProductID       string  `json:"product_id"`
+	ProductName     string  `json:"product_name"`
+	SKU             string  `json:"sku,omitempty"`
+	Unit            string  `json:"unit"`
+	Supplier        string  `json:"supplier,omitempty"`
+	OrderDate       string  `json:"order_date"`   // YYYY-MM-DD
+	ArrivalDate     string  `json:"arrival_date"` // YYYY-MM-DD
+	CoversFrom      string  `json:"covers_from"`  // First day of demand the order covers
+	CoversTo        string  `json:"covers_to"`    // Last day, inclusive
+	Demand          float64 `json:"demand"`       // Forecast sales over the covered days
+	Quantity        float64 `json:"quantity"`     // Demand less surplus of earlier orders, rounded up to the minimum order
+	UnitCost        float64 `json:"unit_cost"`
+	TotalCost       float64 `json:"total_cost"`
+	DefaultLeadTime bool    `json:"default_lead_time,omitempty"` // The product has no lead time set
+}
+
+// Build plans the orders for items over horizon days from today, placing one
+// order per product every review days. Each order arrives after the product's
+// lead time and covers the demand until the next order arrives; demand before
+// the first arrival is left to the stock on hand. Quantities are rounded up to
+// whole units and to the minimum order quantity, and any surplus is taken off
+// later orders, which are skipped while it lasts.
+func Build(items []Item, today time.Time, horizon, review int) []Order {
+	var orders []Order
+	for _, it := range items {
+		if it.DailyRate <= 0 {
+			continue
+		}
+		lead, defaultLead := DefaultLeadTimeDays, true
+		if it.LeadTimeDays != nil {
+			lead, defaultLead = *it.LeadTimeDays, false
+		}
+		surplus := 0.0
+		for t := 0; t < horizon; t += review {
+			days := min(review, horizon-t)
+			demand := it.DailyRate * float64(days)
+			need := demand - surplus
+			if need <= 0 {
+				surplus = -need
+				continue
+			}
+			qty := math.Max(math.Ceil(need-1e-9), it.MinOrderQuantity)
+			surplus = qty - need
+
+			arrival := today.AddDate(0, 0, t+lead)
+			orders = append(orders, Order{
+				ProductID:       it.ProductID,
+				ProductName:     it.Name,
+				SKU:             it.SKU,
+				Unit:            it.Unit,
+				Supplier:        it.Supplier,
+				OrderDate:       today.AddDate(0, 0, t).Format("2006-01-02"),
+				ArrivalDate:     arrival.Format("2006-01-02"),
+				CoversFrom:      arrival.Format("2006-01-02"),
+				CoversTo:        arrival.AddDate(0, 0, days-1).Format("2006-01-02"),
+				Demand:          round2(demand),
+				Quantity:        qty,
+				UnitCost:        it.UnitCost,
+				TotalCost:       round2(qty * it.UnitCost),
+				DefaultLeadTime: defaultLead,
+			})
+		}
+	}
+	sort.SliceStable(orders, func(i, j int) bool {
+		a, b := orders[i], orders[j]
+		if a.OrderDate != b.OrderDate {
+			return a.OrderDate < b.OrderDate
+		}
+		if a.Supplier != b.Supplier {
+			return a.Supplier < b.Supplier
+		}
+		return a.ProductName < b.ProductName
+	})
+	return orders
+}
+
+// TotalCost sums the cost of orders
+func TotalCost(orders []Order) float64 {
+	total := 0.0
+	for _, o := range orders {
+		total += o.TotalCost
+	}
+	return round2(total)
+}
+
+// WriteCSV writes orders as CSV, one row per order
+func WriteCSV(w io.Writer, orders []Order) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"order_date", "supplier", "product_id", "product_name", "sku", "quantity", "unit",
+		"unit_cost", "total_cost", "arrival_date", "covers_from", "covers_to", "demand"})
+	for _, o := range orders {
+		cw.Write([]string{
+			o.OrderDate, o.Supplier, o.ProductID, o.ProductName, o.SKU,
+			strconv.FormatFloat(o.Quantity, 'f', -1, 64), o.Unit,
+			strconv.FormatFloat(o.UnitCost, 'f', 2, 64), strconv.FormatFloat(o.TotalCost, 'f', 2, 64),
+			o.ArrivalDate, o.CoversFrom, o.CoversTo, strconv.FormatFloat(o.Demand, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,58 @@
+package purchasing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var today = time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+
+func TestBuildWeeklyOrders(t *testing.T) {
+	lead := 3
+	orders := Build([]Item{{ProductID: "p1", Name: "Kopi", LeadTimeDays: &lead, UnitCost: 1000, DailyRate: 1.5}}, today, 28, 7)
+	if len(orders) != 4 {
+		t.Fatalf("expected 4 weekly orders, got %d", len(orders))
+	}
+	first := orders[0]
+	if first.OrderDate != "2025-06-02" || first.ArrivalDate != "2025-06-05" || first.CoversTo != "2025-06-11" {
+		t.Errorf("unexpected first order dates: %+v", first)
+	}
+	// 10.5 a week: 11 ordered, with the half unit left over taken off the next order
+	if first.Quantity != 11 || orders[1].Quantity != 10 || first.TotalCost != 11000 {
+		t.Errorf("unexpected quantities: %v, %v", first.Quantity, orders[1].Quantity)
+	}
+	if first.DefaultLeadTime {
+		t.Error("lead time was set")
+	}
+}
+
+func TestBuildMinimumOrderSkipsWeeks(t *testing.T) {
+	orders := Build([]Item{{ProductID: "p1", Name: "Gula", MinOrderQuantity: 20, DailyRate: 1}}, today, 28, 7)
+	// 20 covers nearly three weeks, so only the first and third weeks order
+	if len(orders) != 2 || orders[0].Quantity != 20 || orders[1].OrderDate != "2025-06-16" {
+		t.Fatalf("unexpected orders: %+v", orders)
+	}
+	if !orders[0].DefaultLeadTime || orders[0].ArrivalDate != "2025-06-09" {
+		t.Errorf("expected the default lead time, got %+v", orders[0])
+	}
+}
+
+func TestBuildSkipsProductsWithoutDemand(t *testing.T) {
+	if orders := Build([]Item{{ProductID: "p1", DailyRate: 0}}, today, 28, 7); len(orders) != 0 {
+		t.Errorf("expected no orders, got %+v", orders)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	orders := Build([]Item{{ProductID: "p1", Name: "Teh, celup", Supplier: "CV Maju", DailyRate: 1}}, today, 7, 7)
+	if err := WriteCSV(&buf, orders); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"Teh, celup"`) || !strings.HasPrefix(lines[1], "2025-06-02,CV Maju,p1") {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
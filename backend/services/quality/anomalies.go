@@ -0,0 +1,121 @@
+package quality
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Anomaly kinds
+const (
+	AnomalyOutlier   = "outlier"
+	AnomalyDuplicate = "duplicate"
+)
+
+// Modified z-score above which a quantity is flagged (Iglewicz & Hoaglin)
+const outlierThreshold = 3.5
+
+// SaleRecord is a single sales_history row considered for anomaly detection
+type SaleRecord struct {
+	ID        int64
+	ProductID string
+	Quantity  float64
+	Price     float64
+	SaleDate  time.Time
+	Source    string
+}
+
+// Anomaly flags a sales record that likely needs correction
+type Anomaly struct {
+	SaleID            int64   `json:"sale_id"`
+	Kind              string  `json:"kind"`
+	Score             float64 `json:"score,omitempty"` // Modified z-score for outliers
+	ExpectedQuantity  float64 `json:"expected_quantity"`
+	DuplicateOfSaleID int64   `json:"duplicate_of_sale_id,omitempty"`
+	Reason            string  `json:"reason"`
+}
+
+// DetectAnomalies flags outlier quantities (median/MAD per product) and
+// repeated identical rows such as a file imported twice.
+func DetectAnomalies(records []SaleRecord) []Anomaly {
+	anomalies := []Anomaly{}
+	flagged := map[int64]bool{}
+
+	// Duplicates: same product, day, quantity, price and source
+	sorted := append([]SaleRecord(nil), records...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	firstSeen := map[string]int64{}
+	for _, rec := range sorted {
+		key := fmt.Sprintf("%s|%s|%.3f|%.2f|%s", rec.ProductID, rec.SaleDate.Format("2006-01-02"), rec.Quantity, rec.Price, rec.Source)
+		if orig, ok := firstSeen[key]; ok {
+			anomalies = append(anomalies, Anomaly{
+				SaleID:            rec.ID,
+				Kind:              AnomalyDuplicate,
+				DuplicateOfSaleID: orig,
+				Reason:            fmt.Sprintf("Catatan sama persis dengan penjualan #%d pada tanggal yang sama; kemungkinan terimpor dua kali.", orig),
+			})
+			flagged[rec.ID] = true
+			continue
+		}
+		firstSeen[key] = rec.ID
+	}
+
+	// Outliers per product, using the robust modified z-score
+	byProduct := map[string][]SaleRecord{}
+	for _, rec := range records {
+		if !flagged[rec.ID] {
+			byProduct[rec.ProductID] = append(byProduct[rec.ProductID], rec)
+		}
+	}
+	for _, recs := range byProduct {
+		if len(recs) < 5 {
+			continue
+		}
+		quantities := make([]float64, len(recs))
+		for i, rec := range recs {
+			quantities[i] = rec.Quantity
+		}
+		med := median(quantities)
+		deviations := make([]float64, len(quantities))
+		for i, q := range quantities {
+			deviations[i] = math.Abs(q - med)
+		}
+		mad := median(deviations)
+		if mad == 0 {
+			continue
+		}
+
+		for _, rec := range recs {
+			score := 0.6745 * (rec.Quantity - med) / mad
+			if math.Abs(score) <= outlierThreshold {
+				continue
+			}
+			expected := math.Round(med*1000) / 1000
+			reason := fmt.Sprintf("Jumlah %g jauh dari kebiasaan (median %g).", rec.Quantity, expected)
+			if expected > 0 && rec.Quantity >= expected*10 {
+				reason += " Periksa kemungkinan salah ketik, misalnya kelebihan angka nol."
+			}
+			anomalies = append(anomalies, Anomaly{
+				SaleID:           rec.ID,
+				Kind:             AnomalyOutlier,
+				Score:            math.Round(score*100) / 100,
+				ExpectedQuantity: expected,
+				Reason:           reason,
+			})
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool { return anomalies[i].SaleID < anomalies[j].SaleID })
+	return anomalies
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,121 @@
+package quality
+
+import (
+	"testing"
+	"time"
+)
+
+var day = time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// series returns one record per day for a product, with IDs starting at firstID
+func series(product string, firstID int64, quantities ...float64) []SaleRecord {
+	recs := make([]SaleRecord, len(quantities))
+	for i, q := range quantities {
+		recs[i] = SaleRecord{ID: firstID + int64(i), ProductID: product, Quantity: q, Price: 10000, SaleDate: day.AddDate(0, 0, i), Source: "manual"}
+	}
+	return recs
+}
+
+func TestDetectAnomaliesDuplicates(t *testing.T) {
+	base := SaleRecord{ProductID: "p1", Quantity: 3, Price: 12000, SaleDate: day, Source: "csv"}
+	dup := func(id int64, change func(*SaleRecord)) SaleRecord {
+		r := base
+		r.ID = id
+		if change != nil {
+			change(&r)
+		}
+		return r
+	}
+	records := []SaleRecord{
+		dup(7, func(r *SaleRecord) { r.SaleDate = day.Add(5 * time.Hour) }), // Same day, later import
+		dup(3, nil),
+		dup(9, func(r *SaleRecord) { r.Source = "pos" }),
+		dup(10, func(r *SaleRecord) { r.ProductID = "p2" }),
+		dup(11, func(r *SaleRecord) { r.SaleDate = day.AddDate(0, 0, 1) }),
+		dup(12, func(r *SaleRecord) { r.Price = 12500 }),
+		dup(4, nil),
+	}
+
+	got := DetectAnomalies(records)
+	if len(got) != 2 {
+		t.Fatalf("anomalies = %+v, want 2 duplicates", got)
+	}
+	for i, id := range []int64{4, 7} {
+		if got[i].SaleID != id || got[i].Kind != AnomalyDuplicate || got[i].DuplicateOfSaleID != 3 {
+			t.Errorf("anomaly %d = %+v, want sale %d duplicating #3", i, got[i], id)
+		}
+	}
+}
+
+func TestDetectAnomaliesOutliers(t *testing.T) {
+	// The base series has median 10 and MAD 1, so the modified z-score of
+	// a quantity q is 0.6745 * (q - 10), which passes 3.5 beyond about 15.19
+	base := []float64{9, 10, 10, 11, 9, 11}
+	tests := []struct {
+		name    string
+		records []SaleRecord
+		want    []int64
+	}{
+		{name: "within the threshold", records: series("p1", 1, append(base, 15)...)},
+		{name: "above the threshold", records: series("p1", 1, append(base, 16)...), want: []int64{7}},
+		{name: "below the threshold", records: series("p1", 1, append(base, 4)...), want: []int64{7}},
+		{name: "zero MAD", records: series("p1", 1, 5, 5, 5, 5, 5, 100)},
+		{name: "fewer than five records", records: series("p1", 1, 10, 10, 11, 1000)},
+		{
+			name:    "products are scored separately",
+			records: append(series("p1", 1, append(base, 16)...), series("p2", 20, 100, 110, 90, 105, 95, 16)...),
+			want:    []int64{7, 25},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectAnomalies(tt.records)
+			if len(got) != len(tt.want) {
+				t.Fatalf("anomalies = %+v, want sales %v", got, tt.want)
+			}
+			for i, a := range got {
+				if a.SaleID != tt.want[i] || a.Kind != AnomalyOutlier {
+					t.Errorf("anomaly %d = %+v, want outlier sale %d", i, a, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectAnomaliesOutlierDetails(t *testing.T) {
+	got := DetectAnomalies(series("p1", 1, 9, 10, 10, 11, 9, 11, 100))
+	if len(got) != 1 {
+		t.Fatalf("anomalies = %+v", got)
+	}
+	a := got[0]
+	if a.ExpectedQuantity != 10 || a.Score != 60.71 {
+		t.Errorf("expected quantity = %g, score = %g", a.ExpectedQuantity, a.Score)
+	}
+	if want := "Jumlah 100 jauh dari kebiasaan (median 10). Periksa kemungkinan salah ketik, misalnya kelebihan angka nol."; a.Reason != want {
+		t.Errorf("reason = %q", a.Reason)
+	}
+}
+
+func TestDetectAnomaliesSkipsDuplicatesWhenScoring(t *testing.T) {
+	// Four copies of one sale would otherwise pull the median to 50
+	recs := series("p1", 1, 9, 10, 11, 10, 9)
+	for id := int64(10); id < 14; id++ {
+		recs = append(recs, SaleRecord{ID: id, ProductID: "p1", Quantity: 50, Price: 10000, SaleDate: day.AddDate(0, 0, 30), Source: "csv"})
+	}
+	got := DetectAnomalies(recs)
+	outliers, duplicates := 0, 0
+	for _, a := range got {
+		switch a.Kind {
+		case AnomalyOutlier:
+			outliers++
+			if a.SaleID != 10 {
+				t.Errorf("outlier = %+v, want the first copy", a)
+			}
+		case AnomalyDuplicate:
+			duplicates++
+		}
+	}
+	if outliers != 1 || duplicates != 3 {
+		t.Errorf("got %d outliers and %d duplicates: %+v", outliers, duplicates, got)
+	}
+}
@@ -0,0 +1,202 @@
+package quality
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Quality levels, from best to worst
+const (
+	LevelGood         = "good"
+	LevelFair         = "fair"
+	LevelPoor         = "poor"
+	LevelInsufficient = "insufficient"
+)
+
+// Minimum number of days with sales before a forecast is attempted
+const MinSalesDays = 7
+
+// DailyPoint is the total quantity sold on one day
+type DailyPoint struct {
+	Date     time.Time
+	Quantity float64
+}
+
+// Issue is a detected data problem with a suggested fix, written for the end user
+type Issue struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Fix     string `json:"fix"`
+}
+
+// Report scores a product's sales history over a lookback window
+type Report struct {
+	Score             int     `json:"score"` // 0-100
+	Level             string  `json:"level"`
+	WindowDays        int     `json:"window_days"`
+	DaysWithSales     int     `json:"days_with_sales"`
+	Coverage          float64 `json:"coverage"` // Share of days since the first sale that have sales
+	DaysSinceLastSale int     `json:"days_since_last_sale"`
+	LongestGapDays    int     `json:"longest_gap_days"`
+	OutlierCount      int     `json:"outlier_count"`
+	Issues            []Issue `json:"issues"`
+}
+
+// Blocking reports whether the series is too weak to forecast from
+func (r Report) Blocking() bool {
+	return r.Level == LevelInsufficient
+}
+
+// Assess scores daily sales points (in any order) within the window ending at now.
+// Score weights: coverage 40, recency 25, gaps 20, outliers 15.
+func Assess(points []DailyPoint, now time.Time, windowDays int) Report {
+	today := truncateDay(now)
+	start := today.AddDate(0, 0, -windowDays+1)
+
+	byDay := map[time.Time]float64{}
+	for _, p := range points {
+		d := truncateDay(p.Date)
+		if d.Before(start) || d.After(today) || p.Quantity <= 0 {
+			continue
+		}
+		byDay[d] += p.Quantity
+	}
+
+	report := Report{WindowDays: windowDays, DaysWithSales: len(byDay), Issues: []Issue{}}
+	if len(byDay) == 0 {
+		report.Level = LevelInsufficient
+		report.DaysSinceLastSale = -1
+		report.Issues = append(report.Issues, Issue{
+			Code:    "no_sales",
+			Message: fmt.Sprintf("Tidak ada data penjualan dalam %d hari terakhir.", windowDays),
+			Fix:     "Catat penjualan harian atau impor laporan penjualan dari aplikasi kasir Anda.",
+		})
+		return report
+	}
+
+	days := make([]time.Time, 0, len(byDay))
+	for d := range byDay {
+		days = append(days, d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	first, last := days[0], days[len(days)-1]
+	span := daysBetween(first, today) + 1
+	report.Coverage = math.Round(float64(len(days))/float64(span)*100) / 100
+	report.DaysSinceLastSale = daysBetween(last, today)
+
+	// A series that stops early is gappy too, so the trailing gap counts
+	for i := 1; i < len(days); i++ {
+		if gap := daysBetween(days[i-1], days[i]) - 1; gap > report.LongestGapDays {
+			report.LongestGapDays = gap
+		}
+	}
+	if report.DaysSinceLastSale > report.LongestGapDays {
+		report.LongestGapDays = report.DaysSinceLastSale
+	}
+
+	quantities := make([]float64, 0, len(days))
+	for _, d := range days {
+		quantities = append(quantities, byDay[d])
+	}
+	report.OutlierCount = countOutliers(quantities)
+
+	// Component scores
+	coverageScore := report.Coverage * 40
+	recencyScore := 25 * math.Max(0, 1-float64(report.DaysSinceLastSale)/30)
+	gapScore := 20 * math.Max(0, 1-float64(report.LongestGapDays)/30)
+	outlierScore := 15 * math.Max(0, 1-float64(report.OutlierCount)/math.Max(1, float64(len(days))*0.1))
+	report.Score = int(math.Round(coverageScore + recencyScore + gapScore + outlierScore))
+
+	if report.DaysWithSales < MinSalesDays {
+		report.Issues = append(report.Issues, Issue{
+			Code:    "too_few_days",
+			Message: fmt.Sprintf("Hanya ada %d hari dengan penjualan; minimal %d hari dibutuhkan untuk prediksi.", report.DaysWithSales, MinSalesDays),
+			Fix:     "Lengkapi riwayat penjualan minimal satu minggu, misalnya dengan mengimpor data dari BukuWarung, Moka, atau Accurate.",
+		})
+	}
+	if report.Coverage < 0.5 {
+		report.Issues = append(report.Issues, Issue{
+			Code:    "low_coverage",
+			Message: fmt.Sprintf("Hanya %.0f%% hari memiliki catatan penjualan.", report.Coverage*100),
+			Fix:     "Jika toko buka tetapi penjualan tidak tercatat, masukkan penjualan hari tersebut. Jika tidak ada penjualan, catat sebagai 0 agar pola tetap terbaca.",
+		})
+	}
+	if report.DaysSinceLastSale > 14 {
+		report.Issues = append(report.Issues, Issue{
+			Code:    "stale",
+			Message: fmt.Sprintf("Penjualan terakhir tercatat %d hari yang lalu.", report.DaysSinceLastSale),
+			Fix:     "Perbarui data penjualan terbaru atau hubungkan webhook POS/QRIS agar data masuk otomatis.",
+		})
+	}
+	if report.LongestGapDays >= 7 {
+		report.Issues = append(report.Issues, Issue{
+			Code:    "gap",
+			Message: fmt.Sprintf("Terdapat jeda %d hari tanpa catatan penjualan.", report.LongestGapDays),
+			Fix:     "Periksa apakah ada laporan yang belum diimpor pada periode tersebut.",
+		})
+	}
+	if report.OutlierCount > 0 {
+		report.Issues = append(report.Issues, Issue{
+			Code:    "outliers",
+			Message: fmt.Sprintf("Ada %d hari dengan penjualan jauh di atas atau di bawah kebiasaan.", report.OutlierCount),
+			Fix:     "Pastikan jumlah tidak salah ketik (misalnya kelebihan angka nol) atau tandai sebagai promo/event khusus.",
+		})
+	}
+
+	switch {
+	case report.DaysWithSales < MinSalesDays:
+		report.Level = LevelInsufficient
+	case report.Score >= 75:
+		report.Level = LevelGood
+	case report.Score >= 50:
+		report.Level = LevelFair
+	default:
+		report.Level = LevelPoor
+	}
+
+	return report
+}
+
+// countOutliers counts values outside 1.5x the interquartile range
+func countOutliers(values []float64) int {
+	if len(values) < 4 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	q1 := percentile(sorted, 0.25)
+	q3 := percentile(sorted, 0.75)
+	iqr := q3 - q1
+	if iqr == 0 {
+		return 0
+	}
+	low, high := q1-1.5*iqr, q3+1.5*iqr
+
+	count := 0
+	for _, v := range values {
+		if v < low || v > high {
+			count++
+		}
+	}
+	return count
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	frac := pos - float64(lower)
+	return sorted[lower] + (sorted[upper]-sorted[lower])*frac
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
@@ -0,0 +1,158 @@
+package quality
+
+import (
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 5, 31, 15, 0, 0, 0, time.UTC)
+
+// daily returns one point per day ending today, oldest first
+func daily(quantities ...float64) []DailyPoint {
+	points := make([]DailyPoint, len(quantities))
+	for i, q := range quantities {
+		points[i] = DailyPoint{Date: now.AddDate(0, 0, i-len(quantities)+1), Quantity: q}
+	}
+	return points
+}
+
+func repeat(q float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = q
+	}
+	return out
+}
+
+func issueCodes(r Report) map[string]bool {
+	codes := map[string]bool{}
+	for _, i := range r.Issues {
+		codes[i.Code] = true
+	}
+	return codes
+}
+
+func TestAssess(t *testing.T) {
+	tests := []struct {
+		name       string
+		points     []DailyPoint
+		score      int
+		level      string
+		outliers   int
+		issues     []string
+		sinceLast  int
+		longestGap int
+	}{
+		{
+			name:      "no points",
+			points:    nil,
+			level:     LevelInsufficient,
+			issues:    []string{"no_sales"},
+			sinceLast: -1,
+		},
+		{
+			name: "only points outside the window or without quantity",
+			points: []DailyPoint{
+				{Date: now.AddDate(0, 0, -40), Quantity: 5},
+				{Date: now.AddDate(0, 0, 1), Quantity: 5},
+				{Date: now, Quantity: 0},
+			},
+			level:     LevelInsufficient,
+			issues:    []string{"no_sales"},
+			sinceLast: -1,
+		},
+		{
+			name:   "every day equal",
+			points: daily(repeat(5, 30)...),
+			score:  100,
+			level:  LevelGood,
+		},
+		{
+			name:   "too few days",
+			points: daily(repeat(5, 6)...),
+			score:  100, // Coverage counts from the first sale, so a short series still scores well
+			level:  LevelInsufficient,
+			issues: []string{"too_few_days"},
+		},
+		{
+			name:       "stale series",
+			points:     daily(append(repeat(5, 10), repeat(0, 20)...)...),
+			level:      LevelPoor,
+			issues:     []string{"low_coverage", "stale", "gap"},
+			sinceLast:  20,
+			longestGap: 20,
+		},
+		{
+			name:     "one spike",
+			points:   daily(append([]float64{4, 6, 5, 4, 6, 5, 4, 6, 5}, 60)...),
+			level:    LevelGood,
+			outliers: 1,
+			issues:   []string{"outliers"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Assess(tt.points, now, 30)
+			if tt.score != 0 && r.Score != tt.score {
+				t.Errorf("score = %d, want %d", r.Score, tt.score)
+			}
+			if r.Level != tt.level {
+				t.Errorf("level = %q, want %q (score %d)", r.Level, tt.level, r.Score)
+			}
+			if r.OutlierCount != tt.outliers {
+				t.Errorf("outliers = %d, want %d", r.OutlierCount, tt.outliers)
+			}
+			if r.DaysSinceLastSale != tt.sinceLast || r.LongestGapDays != tt.longestGap {
+				t.Errorf("days since last sale = %d, longest gap = %d, want %d and %d",
+					r.DaysSinceLastSale, r.LongestGapDays, tt.sinceLast, tt.longestGap)
+			}
+			codes := issueCodes(r)
+			if len(codes) != len(tt.issues) {
+				t.Errorf("issues = %v, want %v", r.Issues, tt.issues)
+			}
+			for _, c := range tt.issues {
+				if !codes[c] {
+					t.Errorf("missing issue %q in %v", c, r.Issues)
+				}
+			}
+			if r.Blocking() != (tt.level == LevelInsufficient) {
+				t.Errorf("Blocking() = %v for level %q", r.Blocking(), r.Level)
+			}
+		})
+	}
+}
+
+func TestAssessAddsUpSameDay(t *testing.T) {
+	points := daily(repeat(5, 10)...)
+	points = append(points, DailyPoint{Date: now.Add(-time.Hour), Quantity: 3})
+	if r := Assess(points, now, 30); r.DaysWithSales != 10 {
+		t.Errorf("days with sales = %d, want 10", r.DaysWithSales)
+	}
+}
+
+func TestCountOutliers(t *testing.T) {
+	// For {2, 2, 4, 4, x} with x >= 4 the quartiles are 2 and 4, so values
+	// outside [-1, 7] are outliers
+	tests := []struct {
+		name   string
+		values []float64
+		want   int
+	}{
+		{"empty", nil, 0},
+		{"fewer than four values", []float64{1, 100, 1000}, 0},
+		{"all equal", repeat(5, 10), 0},
+		{"spike over equal values", append(repeat(5, 10), 500), 0}, // Zero spread, nothing to compare against
+		{"on the upper fence", []float64{2, 2, 4, 4, 7}, 0},
+		{"past the upper fence", []float64{2, 2, 4, 4, 7.5}, 1},
+		{"on the lower fence", []float64{-1, 2, 2, 4, 4}, 0},
+		{"past the lower fence", []float64{-1.5, 2, 2, 4, 4}, 1},
+		{"both sides, any order", []float64{4, -5, 2, 20, 2, 4}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countOutliers(tt.values); got != tt.want {
+				t.Errorf("countOutliers(%v) = %d, want %d", tt.values, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+// Package queryspec turns structured query specs proposed by the assistant into
+// company-scoped SQL over an allowlisted subset of the schema. Specs never carry
+// SQL: every table, column, operator and aggregate comes from the allowlist,
+// and every value is bound as a parameter.
+package queryspec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Limits
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+	MaxColumns   = 8
+	MaxFilters   = 10
+	MaxInValues  = 50
+	Timeout      = 3 * time.Second // Statement timeout of a query
+)
+
+// Field types
+const (
+	TypeText   = "text"
+	TypeNumber = "number"
+	TypeDate   = "date"
+	TypeBool   = "bool"
+)
+
+// field is an allowlisted column
+type field struct {
+	expr string
+	typ  string
+}
+
+// dataset is an allowlisted table, scoped to a company by $1
+type dataset struct {
+	from   string
+	scope  string
+	fields map[string]field
+}
+
+var datasets = map[string]dataset{
+	"sales": {
+		from:  "sales_history s JOIN products p ON p.id = s.product_id",
+		scope: "s.company_id = $1 AND NOT COALESCE(s.excluded, false)",
+		fields: map[string]field{
+			"sale_date":    {"s.sale_date", TypeDate},
+			"product_name": {"p.name", TypeText},
+			"category":     {"COALESCE(p.category, '')", TypeText},
+			"channel":      {"COALESCE(s.channel, '')", TypeText},
+			"source":       {"s.source", TypeText},
+			"quantity":     {"s.quantity::float8", TypeNumber},
+			"price":        {"COALESCE(s.price, 0)::float8", TypeNumber},
+			"revenue":      {"(s.quantity * COALESCE(s.price, 0))::float8", TypeNumber},
+		},
+	},
+	"products": {
+		from:  "products p",
+		scope: "p.company_id = $1 AND p.merged_into IS NULL",
+		fields: map[string]field{
+			"name":          {"p.name", TypeText},
+			"sku":           {"COALESCE(p.sku, '')", TypeText},
+			"category":      {"COALESCE(p.category, '')", TypeText},
+			"unit":          {"COALESCE(p.unit, 'pcs')", TypeText},
+			"unit_price":    {"COALESCE(p.unit_price, 0)::float8", TypeNumber},
+			"cost":          {"COALESCE(p.cost, 0)::float8", TypeNumber},
+			"supplier_name": {"COALESCE(p.supplier_name, '')", TypeText},
+			"is_active":     {"COALESCE(p.is_active, true)", TypeBool},
+			"created_at":    {"p.created_at::date", TypeDate},
+		},
+	},
+}
+
+// aggregates are the allowed aggregates; numeric ones only apply to numbers
+var aggregates = map[string]struct {
+	format  string
+	numeric bool
+}{
+	"sum":            {"SUM(%s)::float8", true},
+	"avg":            {"AVG(%s)::float8", true},
+	"min":            {"MIN(%s)", false},
+	"max":            {"MAX(%s)", false},
+	"count":          {"COUNT(*)", false},
+	"count_distinct": {"COUNT(DISTINCT %s)", false},
+}
+
+// operators are the allowed filter operators
+var operators = map[string]string{
+	"eq": "=", "ne": "<>", "gt": ">", "gte": ">=", "lt": "<", "lte": "<=",
+	"contains": "ILIKE", "in": "IN",
+}
+
+// Column is a selected field, optionally aggregated. Count takes no field.
+type Column struct {
+	Field     string `json:"field,omitempty"`
+	Aggregate string `json:"aggregate,omitempty"`
+}
+
+// Name is the column's name in the result: the field, or aggregate_field
+func (c Column) Name() string {
+	switch {
+	case c.Aggregate == "":
+		return c.Field
+	case c.Field == "":
+		return c.Aggregate
+	}
+	return c.Aggregate + "_" + c.Field
+}
+
+// Filter restricts rows by a field
+type Filter struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"` // A list for "in"
+}
+
+// Order sorts by a result column name
+type Order struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc,omitempty"`
+}
+
+// Spec is a query proposed by the assistant
+type Spec struct {
+	Dataset string   `json:"dataset"`
+	Columns []Column `json:"columns"`
+	Filters []Filter `json:"filters,omitempty"`
+	GroupBy []string `json:"group_by,omitempty"`
+	OrderBy []Order  `json:"order_by,omitempty"`
+	Limit   int      `json:"limit,omitempty"`
+}
+
+// Query is a built query. Args start with the company ID.
+type Query struct {
+	SQL     string
+	Args    []interface{}
+	Columns []string
+	Limit   int
+}
+
+// Describe lists the datasets and their fields as text, for tool descriptions
+func Describe() string {
+	names := make([]string, 0, len(datasets))
+	for name := range datasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		var fields []string
+		for f, def := range datasets[name].fields {
+			fields = append(fields, f+" ("+def.typ+")")
+		}
+		sort.Strings(fields)
+		parts = append(parts, name+": "+strings.Join(fields, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Build validates spec and builds its SQL for companyID. The query fetches one
+// row more than the limit, so callers can tell when results were cut off.
+func Build(spec Spec, companyID string) (Query, error) {
+	d, ok := datasets[spec.Dataset]
+	if !ok {
+		return Query{}, fmt.Errorf("unknown dataset %q", spec.Dataset)
+	}
+	if len(spec.Columns) == 0 || len(spec.Columns) > MaxColumns {
+		return Query{}, fmt.Errorf("select between 1 and %d columns", MaxColumns)
+	}
+	if len(spec.Filters) > MaxFilters {
+		return Query{}, fmt.Errorf("use at most %d filters", MaxFilters)
+	}
+	lookup := func(name string) (field, error) {
+		f, ok := d.fields[name]
+		if !ok {
+			return field{}, fmt.Errorf("unknown field %q in dataset %s", name, spec.Dataset)
+		}
+		return f, nil
+	}
+
+	q := Query{Args: []interface{}{companyID}, Limit: spec.Limit}
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+
+	grouped := map[string]bool{}
+	var groupExprs []string
+	for _, name := range spec.GroupBy {
+		f, err := lookup(name)
+		if err != nil {
+			return Query{}, err
+		}
+		if !grouped[name] {
+			grouped[name] = true
+			groupExprs = append(groupExprs, f.expr)
+		}
+	}
+
+	var selects []string
+	names := map[string]bool{}
+	aggregated := false
+	for _, c := range spec.Columns {
+		var expr string
+		if c.Aggregate == "" {
+			f, err := lookup(c.Field)
+			if err != nil {
+				return Query{}, err
+			}
+			expr = f.expr
+		} else {
+			agg, ok := aggregates[c.Aggregate]
+			if !ok {
+				return Query{}, fmt.Errorf("unknown aggregate %q", c.Aggregate)
+			}
+			aggregated = true
+			if c.Aggregate == "count" {
+				c.Field = ""
+				expr = agg.format
+			} else {
+				f, err := lookup(c.Field)
+				if err != nil {
+					return Query{}, err
+				}
+				if agg.numeric && f.typ != TypeNumber {
+					return Query{}, fmt.Errorf("%s needs a number field, %s is %s", c.Aggregate, c.Field, f.typ)
+				}
+				expr = fmt.Sprintf(agg.format, f.expr)
+			}
+		}
+		name := c.Name()
+		if names[name] {
+			return Query{}, fmt.Errorf("column %q is selected twice", name)
+		}
+		names[name] = true
+		q.Columns = append(q.Columns, name)
+		selects = append(selects, fmt.Sprintf(`%s AS "%s"`, expr, name))
+	}
+	if aggregated || len(grouped) > 0 {
+		for _, c := range spec.Columns {
+			if c.Aggregate == "" && !grouped[c.Field] {
+				return Query{}, fmt.Errorf("field %q must be aggregated or listed in group_by", c.Field)
+			}
+		}
+	}
+
+	conditions := []string{d.scope}
+	for _, flt := range spec.Filters {
+		f, err := lookup(flt.Field)
+		if err != nil {
+			return Query{}, err
+		}
+		cond, args, err := filterSQL(f, flt, len(q.Args))
+		if err != nil {
+			return Query{}, err
+		}
+		conditions = append(conditions, cond)
+		q.Args = append(q.Args, args...)
+	}
+
+	var orders []string
+	for _, o := range spec.OrderBy {
+		if !names[o.Column] {
+			return Query{}, fmt.Errorf("order_by column %q is not selected", o.Column)
+		}
+		dir := "ASC"
+		if o.Desc {
+			dir = "DESC"
+		}
+		orders = append(orders, fmt.Sprintf(`"%s" %s`, o.Column, dir))
+	}
+
+	sql := "SELECT " + strings.Join(selects, ", ") + " FROM " + d.from + " WHERE " + strings.Join(conditions, " AND ")
+	if len(groupExprs) > 0 {
+		sql += " GROUP BY " + strings.Join(groupExprs, ", ")
+	}
+	if len(orders) > 0 {
+		sql += " ORDER BY " + strings.Join(orders, ", ")
+	}
+	q.SQL = sql + fmt.Sprintf(" LIMIT %d", q.Limit+1)
+	return q, nil
+}
+
+// filterSQL builds a filter's condition, binding its values after the n
+// arguments already bound
+func filterSQL(f field, flt Filter, n int) (string, []interface{}, error) {
+	op, ok := operators[flt.Op]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown operator %q", flt.Op)
+	}
+	switch flt.Op {
+	case "contains":
+		if f.typ != TypeText {
+			return "", nil, fmt.Errorf("contains needs a text field, %s is %s", flt.Field, f.typ)
+		}
+		s, ok := flt.Value.(string)
+		if !ok {
+			return "", nil, fmt.Errorf("%s: contains needs a text value", flt.Field)
+		}
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+		return fmt.Sprintf("%s ILIKE $%d", f.expr, n+1), []interface{}{"%" + escaped + "%"}, nil
+	case "in":
+		list, ok := flt.Value.([]interface{})
+		if !ok || len(list) == 0 || len(list) > MaxInValues {
+			return "", nil, fmt.Errorf("%s: in needs a list of 1 to %d values", flt.Field, MaxInValues)
+		}
+		var args []interface{}
+		var holders []string
+		for _, v := range list {
+			arg, err := value(f, flt.Field, v)
+			if err != nil {
+				return "", nil, err
+			}
+			args = append(args, arg)
+			holders = append(holders, fmt.Sprintf("$%d", n+len(args)))
+		}
+		return fmt.Sprintf("%s IN (%s)", f.expr, strings.Join(holders, ", ")), args, nil
+	}
+	if f.typ == TypeBool && flt.Op != "eq" && flt.Op != "ne" {
+		return "", nil, fmt.Errorf("%s: a bool field only supports eq and ne", flt.Field)
+	}
+	arg, err := value(f, flt.Field, flt.Value)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s %s $%d", f.expr, op, n+1), []interface{}{arg}, nil
+}
+
+// value converts a JSON value to the field's type
+func value(f field, name string, v interface{}) (interface{}, error) {
+	switch f.typ {
+	case TypeNumber:
+		if n, ok := v.(float64); ok {
+			return n, nil
+		}
+	case TypeBool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case TypeDate:
+		if s, ok := v.(string); ok {
+			if d, err := time.Parse("2006-01-02", s); err == nil {
+				return d, nil
+			}
+		}
+	default:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: expected a %s value", name, f.typ)
+}
@@ -0,0 +1,74 @@
+package queryspec
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func spec(t *testing.T, s string) Spec {
+	t.Helper()
+	var out Spec
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestBuildGroupedQuery(t *testing.T) {
+	q, err := Build(spec(t, `{
+		"dataset": "sales",
+		"columns": [{"field": "channel"}, {"field": "revenue", "aggregate": "sum"}],
+		"filters": [{"field": "sale_date", "op": "gte", "value": "2025-01-01"}, {"field": "category", "op": "in", "value": ["Minuman", "Makanan"]}],
+		"group_by": ["channel"],
+		"order_by": [{"column": "sum_revenue", "desc": true}],
+		"limit": 5
+	}`), "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SELECT COALESCE(s.channel, '') AS "channel", SUM((s.quantity * COALESCE(s.price, 0))::float8)::float8 AS "sum_revenue" ` +
+		`FROM sales_history s JOIN products p ON p.id = s.product_id ` +
+		`WHERE s.company_id = $1 AND NOT COALESCE(s.excluded, false) AND s.sale_date >= $2 AND COALESCE(p.category, '') IN ($3, $4) ` +
+		`GROUP BY COALESCE(s.channel, '') ORDER BY "sum_revenue" DESC LIMIT 6`
+	if q.SQL != want {
+		t.Errorf("unexpected SQL:\n%s", q.SQL)
+	}
+	if len(q.Args) != 4 || q.Args[0] != "c1" || q.Args[3] != "Makanan" {
+		t.Errorf("unexpected args %v", q.Args)
+	}
+	if strings.Join(q.Columns, ",") != "channel,sum_revenue" {
+		t.Errorf("unexpected columns %v", q.Columns)
+	}
+}
+
+func TestBuildRejectsOutsideAllowlist(t *testing.T) {
+	cases := map[string]string{
+		"table":     `{"dataset": "users", "columns": [{"field": "email"}]}`,
+		"field":     `{"dataset": "products", "columns": [{"field": "company_id"}]}`,
+		"aggregate": `{"dataset": "sales", "columns": [{"field": "quantity", "aggregate": "stddev"}]}`,
+		"sum text":  `{"dataset": "sales", "columns": [{"field": "channel", "aggregate": "sum"}]}`,
+		"ungrouped": `{"dataset": "sales", "columns": [{"field": "channel"}, {"aggregate": "count"}]}`,
+		"operator":  `{"dataset": "sales", "columns": [{"field": "channel"}], "filters": [{"field": "channel", "op": "regex", "value": "x"}]}`,
+		"value":     `{"dataset": "sales", "columns": [{"field": "channel"}], "filters": [{"field": "quantity", "op": "gt", "value": "1; DROP TABLE sales_history"}]}`,
+		"order":     `{"dataset": "sales", "columns": [{"field": "channel"}], "order_by": [{"column": "1; --"}]}`,
+	}
+	for name, s := range cases {
+		if _, err := Build(spec(t, s), "c1"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBuildCapsLimit(t *testing.T) {
+	q, err := Build(spec(t, `{"dataset": "products", "columns": [{"field": "name"}], "filters": [{"field": "name", "op": "contains", "value": "50%"}], "limit": 10000}`), "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Limit != MaxLimit || !strings.HasSuffix(q.SQL, "LIMIT 201") {
+		t.Errorf("expected the limit capped at %d: %s", MaxLimit, q.SQL)
+	}
+	if q.Args[1] != `%50\%%` {
+		t.Errorf("expected the pattern escaped, got %v", q.Args[1])
+	}
+}
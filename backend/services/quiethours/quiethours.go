@@ -0,0 +1,63 @@
+package quiethours
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default quiet hours, in the company's local time
+const (
+	DefaultStart = 21
+	DefaultEnd   = 7
+)
+
+// Window is the time of day, in whole local hours, when non-urgent
+// notifications are held. Start is inclusive and End exclusive; a window may
+// span midnight.
+type Window struct {
+	Enabled bool `json:"enabled"`
+	Start   int  `json:"start"`
+	End     int  `json:"end"`
+}
+
+// Default returns the quiet hours of a company that changed nothing
+func Default() Window {
+	return Window{Enabled: true, Start: DefaultStart, End: DefaultEnd}
+}
+
+// Validate checks that both hours are valid and the window is not empty
+func (w Window) Validate() error {
+	if w.Start < 0 || w.Start > 23 || w.End < 0 || w.End > 23 {
+		return fmt.Errorf("start and end must be hours between 0 and 23")
+	}
+	if w.Start == w.End {
+		return fmt.Errorf("start and end must differ")
+	}
+	return nil
+}
+
+// Contains reports whether t, in its own location, falls in the window
+func (w Window) Contains(t time.Time) bool {
+	if !w.Enabled || w.Start == w.End {
+		return false
+	}
+	hour := t.Hour()
+	if w.Start < w.End {
+		return hour >= w.Start && hour < w.End
+	}
+	return hour >= w.Start || hour < w.End
+}
+
+// Hold returns when a notification raised at t should be delivered, and
+// whether it is held at all: urgent ones and ones outside the window are
+// delivered at once, the rest when the window ends.
+func (w Window) Hold(t time.Time, urgent bool) (time.Time, bool) {
+	if urgent || !w.Contains(t) {
+		return t, false
+	}
+	end := time.Date(t.Year(), t.Month(), t.Day(), w.End, 0, 0, 0, t.Location())
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end, true
+}
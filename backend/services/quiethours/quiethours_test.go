@@ -0,0 +1,45 @@
+package quiethours
+
+import (
+	"testing"
+	"time"
+)
+
+var wib = time.FixedZone("WIB", 7*60*60)
+
+func TestHold(t *testing.T) {
+	w := Default()
+	tests := []struct {
+		at     time.Time
+		urgent bool
+		want   time.Time
+		held   bool
+	}{
+		{time.Date(2026, 3, 9, 2, 0, 0, 0, wib), false, time.Date(2026, 3, 9, 7, 0, 0, 0, wib), true},
+		{time.Date(2026, 3, 9, 22, 30, 0, 0, wib), false, time.Date(2026, 3, 10, 7, 0, 0, 0, wib), true},
+		{time.Date(2026, 3, 9, 2, 0, 0, 0, wib), true, time.Date(2026, 3, 9, 2, 0, 0, 0, wib), false},
+		{time.Date(2026, 3, 9, 7, 0, 0, 0, wib), false, time.Date(2026, 3, 9, 7, 0, 0, 0, wib), false},
+		{time.Date(2026, 3, 9, 20, 59, 0, 0, wib), false, time.Date(2026, 3, 9, 20, 59, 0, 0, wib), false},
+	}
+	for _, tt := range tests {
+		got, held := w.Hold(tt.at, tt.urgent)
+		if held != tt.held || !got.Equal(tt.want) {
+			t.Errorf("Hold(%v, %v) = %v, %v; want %v, %v", tt.at, tt.urgent, got, held, tt.want, tt.held)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	day := Window{Enabled: true, Start: 12, End: 14}
+	if !day.Contains(time.Date(2026, 3, 9, 13, 0, 0, 0, wib)) || day.Contains(time.Date(2026, 3, 9, 14, 0, 0, 0, wib)) {
+		t.Error("unexpected result for a window within one day")
+	}
+	off := Default()
+	off.Enabled = false
+	if off.Contains(time.Date(2026, 3, 9, 2, 0, 0, 0, wib)) {
+		t.Error("a disabled window should contain nothing")
+	}
+	if (Window{Start: 5, End: 5}).Validate() == nil || (Window{Start: 24, End: 5}).Validate() == nil {
+		t.Error("expected invalid windows to fail validation")
+	}
+}
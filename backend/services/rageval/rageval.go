@@ -0,0 +1,222 @@
+// Package rageval scores retrieval and answers against curated evaluation
+// cases: precision, recall and reciprocal rank of the retrieved sources, and
+// lexical faithfulness and coverage of the generated answer.
+package rageval
+
+import (
+	"strings"
+	"unicode"
+)
+
+// supportThreshold is the share of a sentence's content words that must occur
+// in the retrieved context for the sentence to count as supported
+const supportThreshold = 0.6
+
+// stopwords are common Indonesian and English words ignored when comparing text
+var stopwords = map[string]bool{
+	"yang": true, "dan": true, "di": true, "ke": true, "dari": true, "untuk": true, "dengan": true, "atau": true,
+	"ini": true, "itu": true, "pada": true, "dalam": true, "adalah": true, "akan": true, "bisa": true, "dapat": true,
+	"juga": true, "tidak": true, "ada": true, "anda": true, "kamu": true, "saya": true, "kami": true, "kita": true,
+	"oleh": true, "sebagai": true, "agar": true, "jika": true, "harus": true, "lebih": true, "sudah": true,
+	"belum": true, "karena": true, "serta": true, "para": true, "setiap": true, "bagi": true, "the": true,
+	"and": true, "for": true, "with": true,
+}
+
+// Scores are one case's metrics. Retrieval metrics apply only to cases with
+// expected sources, answer metrics only when an answer was generated.
+type Scores struct {
+	HasSources     bool
+	Precision      float64 // Share of retrieved sources that were expected
+	Recall         float64 // Share of expected sources that were retrieved
+	ReciprocalRank float64 // 1/rank of the first expected source, 0 if none was retrieved
+
+	HasAnswer      bool
+	Faithfulness   float64 // Share of answer sentences supported by the retrieved context
+	AnswerCoverage float64 // Share of the expected answer's content words found in the answer; HasExpected only
+	HasExpected    bool
+}
+
+// Retrieval scores retrieved source keys, best first, against the expected ones.
+// Duplicates in retrieved count once.
+func Retrieval(retrieved, expected []string) (precision, recall, reciprocalRank float64) {
+	want := map[string]bool{}
+	for _, e := range expected {
+		want[e] = true
+	}
+	seen := map[string]bool{}
+	var unique, hits int
+	for _, r := range retrieved {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		unique++
+		if want[r] {
+			hits++
+			if reciprocalRank == 0 {
+				reciprocalRank = 1 / float64(unique)
+			}
+		}
+	}
+	if unique > 0 {
+		precision = float64(hits) / float64(unique)
+	}
+	if len(want) > 0 {
+		recall = float64(hits) / float64(len(want))
+	}
+	return precision, recall, reciprocalRank
+}
+
+// Faithfulness is the share of answer sentences whose content words mostly
+// occur in the retrieved context. It is a lexical proxy: paraphrases score
+// low, so compare it across runs rather than reading it as an absolute.
+func Faithfulness(answer string, context []string) float64 {
+	known := map[string]bool{}
+	for _, c := range context {
+		for _, w := range contentWords(c) {
+			known[w] = true
+		}
+	}
+
+	var total, supported int
+	for _, s := range splitSentences(answer) {
+		words := contentWords(s)
+		if len(words) == 0 {
+			continue
+		}
+		total++
+		found := 0
+		for _, w := range words {
+			if known[w] {
+				found++
+			}
+		}
+		if float64(found)/float64(len(words)) >= supportThreshold {
+			supported++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(supported) / float64(total)
+}
+
+// Coverage is the share of the expected answer's distinct content words that
+// appear in answer
+func Coverage(answer, expected string) float64 {
+	present := map[string]bool{}
+	for _, w := range contentWords(answer) {
+		present[w] = true
+	}
+	want := map[string]bool{}
+	for _, w := range contentWords(expected) {
+		want[w] = true
+	}
+	if len(want) == 0 {
+		return 0
+	}
+	found := 0
+	for w := range want {
+		if present[w] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(want))
+}
+
+// Summary averages each metric over the cases it applies to; nil means no case applied
+type Summary struct {
+	Cases          int
+	Precision      *float64
+	Recall         *float64
+	MRR            *float64
+	Faithfulness   *float64
+	AnswerCoverage *float64
+}
+
+// Summarize averages case scores into a run summary
+func Summarize(scores []Scores) Summary {
+	var precision, recall, rr, faith, coverage mean
+	for _, s := range scores {
+		if s.HasSources {
+			precision.add(s.Precision)
+			recall.add(s.Recall)
+			rr.add(s.ReciprocalRank)
+		}
+		if s.HasAnswer {
+			faith.add(s.Faithfulness)
+			if s.HasExpected {
+				coverage.add(s.AnswerCoverage)
+			}
+		}
+	}
+	return Summary{
+		Cases:          len(scores),
+		Precision:      precision.value(),
+		Recall:         recall.value(),
+		MRR:            rr.value(),
+		Faithfulness:   faith.value(),
+		AnswerCoverage: coverage.value(),
+	}
+}
+
+// Regressions names the metrics that dropped by more than maxDrop from
+// baseline to current. Metrics missing from either summary are skipped.
+func Regressions(baseline, current Summary, maxDrop float64) []string {
+	metrics := []struct {
+		name      string
+		base, cur *float64
+	}{
+		{"precision", baseline.Precision, current.Precision},
+		{"recall", baseline.Recall, current.Recall},
+		{"mrr", baseline.MRR, current.MRR},
+		{"faithfulness", baseline.Faithfulness, current.Faithfulness},
+		{"answer_coverage", baseline.AnswerCoverage, current.AnswerCoverage},
+	}
+	var out []string
+	for _, m := range metrics {
+		if m.base != nil && m.cur != nil && *m.base-*m.cur > maxDrop {
+			out = append(out, m.name)
+		}
+	}
+	return out
+}
+
+type mean struct {
+	sum float64
+	n   int
+}
+
+func (m *mean) add(v float64) {
+	m.sum += v
+	m.n++
+}
+
+func (m mean) value() *float64 {
+	if m.n == 0 {
+		return nil
+	}
+	v := m.sum / float64(m.n)
+	return &v
+}
+
+// splitSentences splits text at sentence punctuation and line breaks
+func splitSentences(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return r == '.' || r == '!' || r == '?' || r == '\n'
+	})
+}
+
+// contentWords returns the lowercase words of text without stopwords and
+// words shorter than three characters
+func contentWords(text string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) >= 3 && !stopwords[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
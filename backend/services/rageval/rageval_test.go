@@ -0,0 +1,58 @@
+package rageval
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestRetrieval(t *testing.T) {
+	p, r, rr := Retrieval([]string{"x", "a", "x", "y", "b"}, []string{"a", "b", "c"})
+	if !near(p, 0.5) || !near(r, 2.0/3) || !near(rr, 0.5) {
+		t.Errorf("Retrieval = %v, %v, %v; want 0.5, 0.667, 0.5", p, r, rr)
+	}
+	p, r, rr = Retrieval(nil, []string{"a"})
+	if p != 0 || r != 0 || rr != 0 {
+		t.Errorf("empty retrieval = %v, %v, %v", p, r, rr)
+	}
+}
+
+func TestFaithfulness(t *testing.T) {
+	context := []string{"NIB diterbitkan melalui sistem OSS secara gratis untuk usaha mikro."}
+	answer := "NIB diterbitkan lewat OSS secara gratis. Prosesnya membutuhkan notaris berlisensi khusus!"
+	if got := Faithfulness(answer, context); !near(got, 0.5) {
+		t.Errorf("Faithfulness = %v, want 0.5", got)
+	}
+	if got := Faithfulness("", context); got != 0 {
+		t.Errorf("empty answer = %v", got)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	got := Coverage("Daftar NIB di OSS, lalu ajukan sertifikat halal.", "NIB OSS sertifikat halal BPJPH")
+	if !near(got, 0.8) {
+		t.Errorf("Coverage = %v, want 0.8", got)
+	}
+}
+
+func TestSummarizeAndRegressions(t *testing.T) {
+	s := Summarize([]Scores{
+		{HasSources: true, Precision: 1, Recall: 1, ReciprocalRank: 1, HasAnswer: true, Faithfulness: 0.5},
+		{HasSources: true, Precision: 0, Recall: 0, ReciprocalRank: 0},
+		{HasAnswer: true, Faithfulness: 1, HasExpected: true, AnswerCoverage: 0.4},
+	})
+	if s.Cases != 3 || !near(*s.Precision, 0.5) || !near(*s.Faithfulness, 0.75) || !near(*s.AnswerCoverage, 0.4) {
+		t.Errorf("Summarize = %+v", s)
+	}
+
+	baseline := s
+	worse := 0.3
+	current := s
+	current.Precision = &worse
+	current.Faithfulness = nil
+	if got, want := Regressions(baseline, current, 0.05), []string{"precision"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Regressions = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Expensive operations limited by concurrency
+const (
+	OperationChat     = "chat"
+	OperationImport   = "import"
+	OperationSync     = "sync"
+	OperationResearch = "research"
+)
+
+// ConcurrencyLimit caps how many requests of one operation a company may run at once
+type ConcurrencyLimit struct {
+	Slots      int           // Concurrent requests at a plan multiplier of 1
+	Lease      time.Duration // Slots of crashed requests are reclaimed after this long
+	RetryAfter time.Duration // Hint returned to rejected clients
+}
+
+// DefaultConcurrencyLimits apply per company to the expensive endpoints
+var DefaultConcurrencyLimits = map[string]ConcurrencyLimit{
+	OperationChat:     {Slots: 3, Lease: 2 * time.Minute, RetryAfter: 5 * time.Second},
+	OperationImport:   {Slots: 2, Lease: 10 * time.Minute, RetryAfter: 30 * time.Second},
+	OperationSync:     {Slots: 1, Lease: 10 * time.Minute, RetryAfter: 30 * time.Second},
+	OperationResearch: {Slots: 2, Lease: 3 * time.Minute, RetryAfter: 10 * time.Second},
+}
+
+// ScaledSlots returns the concurrent requests allowed at a plan multiplier
+func (c ConcurrencyLimit) ScaledSlots(multiplier float64) int {
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	slots := int(float64(c.Slots) * multiplier)
+	if slots < 1 {
+		slots = 1
+	}
+	return slots
+}
+
+// acquireScript drops expired holders, then takes a slot if one is free.
+// Holders are sorted set members scored by lease expiry in milliseconds.
+var acquireScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// ConcurrencyStats reports saturation of one operation on this instance
+type ConcurrencyStats struct {
+	Operation string `json:"operation"`
+	Acquired  int64  `json:"acquired"`
+	Rejected  int64  `json:"rejected"`
+	InFlight  int64  `json:"in_flight"`
+	PeakLoad  int64  `json:"peak_in_flight"`
+}
+
+// Semaphore is a distributed per-key counting semaphore in Redis
+type Semaphore struct {
+	client   *redis.Client
+	registry *Registry
+
+	mu    sync.Mutex
+	stats map[string]*ConcurrencyStats
+}
+
+// NewSemaphore creates a semaphore whose slots scale with the registry's plan multipliers
+func NewSemaphore(client *redis.Client, registry *Registry) *Semaphore {
+	return &Semaphore{client: client, registry: registry, stats: map[string]*ConcurrencyStats{}}
+}
+
+// Acquire takes a slot of operation for key. When ok is false the caller
+// should retry after retryAfter; otherwise release must be called when done.
+func (s *Semaphore) Acquire(ctx context.Context, operation, plan, key string) (release func(), ok bool, retryAfter time.Duration, err error) {
+	limit, known := DefaultConcurrencyLimits[operation]
+	if !known {
+		return nil, false, 0, fmt.Errorf("unknown operation %q", operation)
+	}
+	slots := limit.ScaledSlots(s.registry.Multiplier(plan))
+
+	redisKey := fmt.Sprintf("concurrency:%s:%s", operation, key)
+	token := uuid.New().String()
+	now := time.Now()
+	acquired, err := acquireScript.Run(ctx, s.client, []string{redisKey},
+		now.UnixMilli(), now.Add(limit.Lease).UnixMilli(), slots, token, limit.Lease.Milliseconds()).Int()
+	if err != nil {
+		return nil, false, 0, err
+	}
+	if acquired == 0 {
+		s.record(operation, false)
+		return nil, false, limit.RetryAfter, nil
+	}
+
+	s.record(operation, true)
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			s.client.ZRem(releaseCtx, redisKey, token)
+			s.done(operation)
+		})
+	}
+	return release, true, 0, nil
+}
+
+// record counts an acquisition attempt
+func (s *Semaphore) record(operation string, acquired bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.statsFor(operation)
+	if !acquired {
+		st.Rejected++
+		return
+	}
+	st.Acquired++
+	st.InFlight++
+	if st.InFlight > st.PeakLoad {
+		st.PeakLoad = st.InFlight
+	}
+}
+
+// done marks a slot released
+func (s *Semaphore) done(operation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statsFor(operation).InFlight--
+}
+
+// statsFor returns the counters of an operation; s.mu must be held
+func (s *Semaphore) statsFor(operation string) *ConcurrencyStats {
+	st, ok := s.stats[operation]
+	if !ok {
+		st = &ConcurrencyStats{Operation: operation}
+		s.stats[operation] = st
+	}
+	return st
+}
+
+// Stats returns a snapshot of the saturation counters, by operation
+func (s *Semaphore) Stats() []ConcurrencyStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]ConcurrencyStats, 0, len(s.stats))
+	for _, st := range s.stats {
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Operation < out[j].Operation })
+	return out
+}
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScaledSlots(t *testing.T) {
+	c := ConcurrencyLimit{Slots: 2, Lease: time.Minute}
+	for _, tc := range []struct {
+		multiplier float64
+		want       int
+	}{{1, 2}, {3, 6}, {0.1, 1}, {0, 2}} {
+		if got := c.ScaledSlots(tc.multiplier); got != tc.want {
+			t.Errorf("ScaledSlots(%v) = %d, want %d", tc.multiplier, got, tc.want)
+		}
+	}
+}
+
+func TestSemaphoreStats(t *testing.T) {
+	s := NewSemaphore(nil, NewRegistry())
+	s.record(OperationImport, true)
+	s.record(OperationImport, true)
+	s.done(OperationImport)
+	s.record(OperationImport, false)
+	s.record(OperationChat, true)
+
+	stats := s.Stats()
+	if len(stats) != 2 || stats[0].Operation != OperationChat {
+		t.Fatalf("stats = %+v", stats)
+	}
+	want := ConcurrencyStats{Operation: OperationImport, Acquired: 2, Rejected: 1, InFlight: 1, PeakLoad: 2}
+	if stats[1] != want {
+		t.Errorf("import stats = %+v, want %+v", stats[1], want)
+	}
+}
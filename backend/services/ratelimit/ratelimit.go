@@ -0,0 +1,231 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Built-in profile names
+const (
+	ProfileDefault = "default"
+	ProfileAI      = "ai"
+	ProfileImport  = "import"
+	ProfileAuth    = "auth"
+)
+
+// Profile allows Requests per Window on average, with up to Burst extra
+// requests accepted at once
+type Profile struct {
+	Name     string        `json:"name"`
+	Requests int           `json:"requests"`
+	Window   time.Duration `json:"-"`
+	Burst    int           `json:"burst"`
+}
+
+// DefaultProfiles apply until an admin overrides them
+var DefaultProfiles = map[string]Profile{
+	ProfileDefault: {Name: ProfileDefault, Requests: 120, Window: time.Minute, Burst: 30},
+	ProfileAI:      {Name: ProfileAI, Requests: 20, Window: time.Minute, Burst: 5},
+	ProfileImport:  {Name: ProfileImport, Requests: 10, Window: time.Minute, Burst: 2},
+	ProfileAuth:    {Name: ProfileAuth, Requests: 10, Window: time.Minute, Burst: 5},
+}
+
+// DefaultPlanMultipliers scale every profile by subscription plan
+var DefaultPlanMultipliers = map[string]float64{
+	"free":       1,
+	"pro":        3,
+	"enterprise": 10,
+}
+
+// Validate checks that a profile can be enforced
+func (p Profile) Validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if p.Requests < 1 {
+		return fmt.Errorf("requests must be at least 1")
+	}
+	if p.Window < time.Second || p.Window > 24*time.Hour {
+		return fmt.Errorf("window must be between 1 second and 24 hours")
+	}
+	if p.Burst < 0 {
+		return fmt.Errorf("burst must not be negative")
+	}
+	return nil
+}
+
+// Scale returns the profile with requests and burst multiplied, keeping at least one request
+func (p Profile) Scale(multiplier float64) Profile {
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	p.Requests = int(math.Max(1, math.Round(float64(p.Requests)*multiplier)))
+	p.Burst = int(math.Round(float64(p.Burst) * multiplier))
+	return p
+}
+
+// Registry holds the live profiles and plan multipliers. It is safe for
+// concurrent use and can be reloaded while serving.
+type Registry struct {
+	mu          sync.RWMutex
+	profiles    map[string]Profile
+	multipliers map[string]float64
+}
+
+// NewRegistry returns a registry holding the defaults
+func NewRegistry() *Registry {
+	r := &Registry{}
+	r.Replace(nil, nil)
+	return r
+}
+
+// Replace swaps in overrides on top of the defaults
+func (r *Registry) Replace(profiles map[string]Profile, multipliers map[string]float64) {
+	p := make(map[string]Profile, len(DefaultProfiles)+len(profiles))
+	for name, profile := range DefaultProfiles {
+		p[name] = profile
+	}
+	for name, profile := range profiles {
+		p[name] = profile
+	}
+	m := make(map[string]float64, len(DefaultPlanMultipliers)+len(multipliers))
+	for plan, v := range DefaultPlanMultipliers {
+		m[plan] = v
+	}
+	for plan, v := range multipliers {
+		m[plan] = v
+	}
+
+	r.mu.Lock()
+	r.profiles, r.multipliers = p, m
+	r.mu.Unlock()
+}
+
+// Profiles returns a copy of the live profiles
+func (r *Registry) Profiles() map[string]Profile {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]Profile, len(r.profiles))
+	for k, v := range r.profiles {
+		out[k] = v
+	}
+	return out
+}
+
+// Multipliers returns a copy of the live plan multipliers
+func (r *Registry) Multipliers() map[string]float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]float64, len(r.multipliers))
+	for k, v := range r.multipliers {
+		out[k] = v
+	}
+	return out
+}
+
+// Multiplier returns the throughput multiplier of a plan, 1 when unknown
+func (r *Registry) Multiplier(plan string) float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if m, ok := r.multipliers[plan]; ok {
+		return m
+	}
+	return 1
+}
+
+// Effective returns the named profile scaled for plan, falling back to the default profile
+func (r *Registry) Effective(name, plan string) Profile {
+	r.mu.RLock()
+	p, ok := r.profiles[name]
+	if !ok {
+		p = r.profiles[ProfileDefault]
+	}
+	r.mu.RUnlock()
+	return p.Scale(r.Multiplier(plan))
+}
+
+// Decision is the outcome of a rate limit check
+type Decision struct {
+	Allowed    bool
+	Limit      int           // Requests per window
+	Remaining  int           // Requests that could still be made immediately
+	RetryAfter time.Duration // Set when not allowed
+	ResetAfter time.Duration // Until the limiter is fully replenished
+}
+
+// GCRA applies the generic cell rate algorithm: tat is the stored theoretical
+// arrival time (zero when unset). It returns the new tat to store when allowed.
+func GCRA(tat, now time.Time, p Profile) (time.Time, Decision) {
+	interval := p.Window / time.Duration(p.Requests)
+	tolerance := interval * time.Duration(p.Burst)
+	if tat.Before(now) {
+		tat = now
+	}
+
+	d := Decision{Limit: p.Requests}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-(tolerance + interval))
+	if now.Before(allowAt) {
+		d.RetryAfter = allowAt.Sub(now)
+		d.ResetAfter = tat.Sub(now)
+		return tat, d
+	}
+
+	d.Allowed = true
+	d.Remaining = int(now.Sub(allowAt) / interval)
+	d.ResetAfter = newTAT.Sub(now)
+	return newTAT, d
+}
+
+// Limiter enforces profiles across instances with state in Redis
+type Limiter struct {
+	client   *redis.Client
+	registry *Registry
+}
+
+// NewLimiter creates a limiter backed by client
+func NewLimiter(client *redis.Client, registry *Registry) *Limiter {
+	return &Limiter{client: client, registry: registry}
+}
+
+// Registry returns the limiter's live profiles
+func (l *Limiter) Registry() *Registry {
+	return l.registry
+}
+
+// Allow checks and records one request for key under the named profile scaled for plan
+func (l *Limiter) Allow(ctx context.Context, profile, plan, key string) (Decision, error) {
+	p := l.registry.Effective(profile, plan)
+	redisKey := fmt.Sprintf("ratelimit:%s:%s", p.Name, key)
+
+	var decision Decision
+	err := l.client.Watch(ctx, func(tx *redis.Tx) error {
+		var tat time.Time
+		if s, err := tx.Get(ctx, redisKey).Result(); err == nil {
+			if ns, err := strconv.ParseInt(s, 10, 64); err == nil {
+				tat = time.Unix(0, ns)
+			}
+		} else if err != redis.Nil {
+			return err
+		}
+
+		now := time.Now()
+		newTAT, d := GCRA(tat, now, p)
+		decision = d
+		if !d.Allowed {
+			return nil
+		}
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, redisKey, strconv.FormatInt(newTAT.UnixNano(), 10), newTAT.Sub(now)+time.Second)
+			return nil
+		})
+		return err
+	}, redisKey)
+	return decision, err
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGCRA(t *testing.T) {
+	p := Profile{Name: "t", Requests: 60, Window: time.Minute, Burst: 2}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var tat time.Time
+	var d Decision
+	for i := 0; i < 3; i++ {
+		tat, d = GCRA(tat, now, p)
+		if !d.Allowed {
+			t.Fatalf("request %d within burst rejected", i+1)
+		}
+		if d.Remaining != 2-i {
+			t.Errorf("request %d remaining = %d, want %d", i+1, d.Remaining, 2-i)
+		}
+	}
+
+	_, d = GCRA(tat, now, p)
+	if d.Allowed || d.RetryAfter != time.Second {
+		t.Fatalf("request beyond burst = %+v, want rejected with 1s retry", d)
+	}
+
+	if _, d = GCRA(tat, now.Add(time.Second), p); !d.Allowed {
+		t.Error("request after one interval should be allowed")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	if got := r.Effective(ProfileAI, "pro"); got.Requests != DefaultProfiles[ProfileAI].Requests*3 {
+		t.Errorf("pro ai requests = %d", got.Requests)
+	}
+	if got := r.Effective("unknown", "unknown-plan"); got.Name != ProfileDefault || got.Requests != DefaultProfiles[ProfileDefault].Requests {
+		t.Errorf("fallback profile = %+v", got)
+	}
+
+	r.Replace(map[string]Profile{ProfileAI: {Name: ProfileAI, Requests: 5, Window: time.Minute, Burst: 1}}, map[string]float64{"pro": 2})
+	if got := r.Effective(ProfileAI, "pro"); got.Requests != 10 || got.Burst != 2 {
+		t.Errorf("overridden profile = %+v", got)
+	}
+	if got := r.Effective(ProfileImport, "free"); got != DefaultProfiles[ProfileImport] {
+		t.Errorf("defaults lost on reload: %+v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Profile{Name: "x", Requests: 1, Window: time.Minute}).Validate(); err != nil {
+		t.Errorf("valid profile rejected: %v", err)
+	}
+	for _, p := range []Profile{
+		{Requests: 1, Window: time.Minute},
+		{Name: "x", Window: time.Minute},
+		{Name: "x", Requests: 1, Window: time.Millisecond},
+		{Name: "x", Requests: 1, Window: time.Minute, Burst: -1},
+	} {
+		if p.Validate() == nil {
+			t.Errorf("invalid profile accepted: %+v", p)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package regions
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Administrative levels, from the Kemendagri region code. Villages are not used.
+const (
+	LevelProvince = "province"
+	LevelRegency  = "regency" // Kabupaten or kota
+	LevelDistrict = "district"
+)
+
+// keepUpper lists abbreviations that stay capitalized in region names
+var keepUpper = map[string]bool{"DKI": true, "DI": true}
+
+var codePattern = regexp.MustCompile(`^\d{2}(\.\d{2}(\.\d{2})?)?$`)
+
+// Region is an Indonesian administrative region, identified by its Kemendagri
+// code: "32" (Jawa Barat), "32.73" (Kota Bandung), "32.73.01" (Sukasari)
+type Region struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Level      string `json:"level"`
+	ParentCode string `json:"parent_code,omitempty"`
+}
+
+// ParseCode returns the level and parent of a region code
+func ParseCode(code string) (level, parent string, err error) {
+	if !codePattern.MatchString(code) {
+		return "", "", fmt.Errorf("invalid region code %q", code)
+	}
+	switch strings.Count(code, ".") {
+	case 0:
+		return LevelProvince, "", nil
+	case 1:
+		return LevelRegency, code[:2], nil
+	default:
+		return LevelDistrict, code[:5], nil
+	}
+}
+
+// CheckChain verifies that the given codes are at the right levels and nested:
+// the regency lies in the province and the district in the regency. Empty
+// codes are skipped, but a district requires a regency and a regency a province.
+func CheckChain(province, regency, district string) error {
+	want := []struct{ code, level, parent string }{
+		{province, LevelProvince, ""},
+		{regency, LevelRegency, province},
+		{district, LevelDistrict, regency},
+	}
+	for i, w := range want {
+		if w.code == "" {
+			for _, rest := range want[i+1:] {
+				if rest.code != "" {
+					return fmt.Errorf("%s_code requires %s_code", rest.level, w.level)
+				}
+			}
+			return nil
+		}
+		level, parent, err := ParseCode(w.code)
+		if err != nil {
+			return err
+		}
+		if level != w.level {
+			return fmt.Errorf("%s is a %s code, not a %s code", w.code, level, w.level)
+		}
+		if parent != w.parent {
+			return fmt.Errorf("%s is not in %s", w.code, w.parent)
+		}
+	}
+	return nil
+}
+
+// ParseCSV reads "code,name" lines, as published in the Kemendagri region
+// lists. Headers, blank lines and village rows are skipped.
+func ParseCSV(r io.Reader) ([]Region, error) {
+	var out []Region
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		code, name, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ",")
+		code = strings.Trim(strings.TrimSpace(code), `"`)
+		name = strings.Trim(strings.TrimSpace(name), `"`)
+		if !ok || code == "" || name == "" {
+			continue
+		}
+		if strings.Count(code, ".") == 3 {
+			continue // Village
+		}
+		level, parent, err := ParseCode(code)
+		if err != nil {
+			if line == 1 {
+				continue // Header
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, Region{Code: code, Name: TidyName(name), Level: level, ParentCode: parent})
+	}
+	return out, scanner.Err()
+}
+
+// TidyName title-cases names published in capitals ("KOTA BANDUNG" becomes
+// "Kota Bandung"), keeping abbreviations such as DKI and DI
+func TidyName(name string) string {
+	if name != strings.ToUpper(name) {
+		return name
+	}
+	words := strings.Fields(strings.ToLower(name))
+	for i, w := range words {
+		if upper := strings.ToUpper(w); keepUpper[upper] {
+			words[i] = upper
+		} else {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// InIndonesia reports whether a coordinate lies in Indonesia's bounding box
+func InIndonesia(lat, lng float64) bool {
+	return lat >= -11.5 && lat <= 6.5 && lng >= 94.5 && lng <= 141.5
+}
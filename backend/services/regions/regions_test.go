@@ -0,0 +1,76 @@
+package regions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCode(t *testing.T) {
+	cases := map[string][2]string{
+		"32":       {LevelProvince, ""},
+		"32.73":    {LevelRegency, "32"},
+		"32.73.01": {LevelDistrict, "32.73"},
+	}
+	for code, want := range cases {
+		level, parent, err := ParseCode(code)
+		if err != nil || level != want[0] || parent != want[1] {
+			t.Errorf("ParseCode(%q) = %q, %q, %v", code, level, parent, err)
+		}
+	}
+	for _, bad := range []string{"", "3", "32.7", "32.73.01.1001", "ab"} {
+		if _, _, err := ParseCode(bad); err == nil {
+			t.Errorf("ParseCode(%q) should fail", bad)
+		}
+	}
+}
+
+func TestCheckChain(t *testing.T) {
+	if err := CheckChain("32", "32.73", "32.73.01"); err != nil {
+		t.Errorf("valid chain rejected: %v", err)
+	}
+	if err := CheckChain("32", "", ""); err != nil {
+		t.Errorf("province only rejected: %v", err)
+	}
+	if err := CheckChain("32", "33.74", ""); err == nil {
+		t.Error("regency outside province accepted")
+	}
+	if err := CheckChain("32", "", "32.73.01"); err == nil {
+		t.Error("district without regency accepted")
+	}
+	if err := CheckChain("32.73", "", ""); err == nil {
+		t.Error("regency code as province accepted")
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	csv := "kode,nama\n32,JAWA BARAT\n32.73,KOTA BANDUNG\n32.73.01,Sukasari\n32.73.01.1001,Sarijadi\n\n"
+	got, err := ParseCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[2].ParentCode != "32.73" || got[1].Level != LevelRegency || got[1].Name != "Kota Bandung" {
+		t.Errorf("ParseCSV = %+v", got)
+	}
+	if _, err := ParseCSV(strings.NewReader("32,JAWA BARAT\nxx,Bad\n")); err == nil {
+		t.Error("expected invalid code to fail")
+	}
+}
+
+func TestTidyName(t *testing.T) {
+	cases := map[string]string{
+		"KOTA BANDUNG": "Kota Bandung",
+		"DKI JAKARTA":  "DKI Jakarta",
+		"Sukasari":     "Sukasari",
+	}
+	for in, want := range cases {
+		if got := TidyName(in); got != want {
+			t.Errorf("TidyName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestInIndonesia(t *testing.T) {
+	if !InIndonesia(-6.9, 107.6) || InIndonesia(1.35, 103.8-10) || InIndonesia(35.7, 139.7) {
+		t.Error("unexpected InIndonesia result")
+	}
+}
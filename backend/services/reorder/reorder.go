@@ -0,0 +1,185 @@
+package reorder
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bantuaku/backend/services/purchasing"
+)
+
+// ServiceZ is the safety factor for computed safety stock: about 95% of lead
+// times end without running out
+const ServiceZ = 1.65
+
+// Recommendation statuses, most urgent first
+const (
+	StatusOrderNow   = "order_now"   // Stock is at or below the reorder point
+	StatusOrderSoon  = "order_soon"  // Stock reaches the reorder point within the review period
+	StatusOK         = "ok"          // Stock lasts beyond the review period
+	StatusNotTracked = "not_tracked" // Stock on hand is unknown, so there is no date
+)
+
+var statusOrder = map[string]int{StatusOrderNow: 0, StatusOrderSoon: 1, StatusOK: 2, StatusNotTracked: 3}
+
+// Item is a product to recommend a reorder for
+type Item struct {
+	ProductID        string
+	Name             string
+	SKU              string
+	Unit             string
+	Supplier         string
+	LeadTimeDays     *int     // nil uses purchasing.DefaultLeadTimeDays
+	SafetyStock      *float64 // nil computes it from DailyStdDev
+	MinOrderQuantity float64
+	UnitCost         float64
+	OnHand           *float64 // nil when stock is not tracked
+	DailyRate        float64  // Forecast sales per day
+	DailyStdDev      float64  // How much daily sales vary
+}
+
+// Recommendation is when to reorder a product and how much
+type Recommendation struct {
+	ProductID             string   `json:"product_id"`
+	ProductName           string   `json:"product_name"`
+	SKU                   string   `json:"sku,omitempty"`
+	Unit                  string   `json:"unit"`
+	Supplier              string   `json:"supplier,omitempty"`
+	Status                string   `json:"status"`
+	OnHand                *float64 `json:"stock_on_hand"`
+	DailyRate             float64  `json:"daily_rate"`
+	LeadTimeDays          int      `json:"lead_time_days"`
+	DefaultLeadTime       bool     `json:"default_lead_time,omitempty"` // The product has no lead time set
+	SafetyStock           float64  `json:"safety_stock"`
+	SafetyStockConfigured bool     `json:"safety_stock_configured"` // Set on the product rather than computed
+	ReorderPoint          float64  `json:"reorder_point"`           // Demand over the lead time plus safety stock
+	OrderQuantity         float64  `json:"order_quantity"`          // Brings stock up to cover the review period
+	ReorderDate           string   `json:"reorder_date,omitempty"`  // YYYY-MM-DD; today when overdue
+	StockoutDate          string   `json:"stockout_date,omitempty"` // When stock runs out without an order
+	UnitCost              float64  `json:"unit_cost"`
+	TotalCost             float64  `json:"total_cost"`
+}
+
+// StdDev is the population standard deviation of daily sales
+func StdDev(daily []float64) float64 {
+	if len(daily) == 0 {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range daily {
+		mean += v
+	}
+	mean /= float64(len(daily))
+	variance := 0.0
+	for _, v := range daily {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(daily)))
+}
+
+// SafetyStock covers the variation of daily sales over the lead time, rounded
+// up to whole units
+func SafetyStock(stdDev float64, leadDays int) float64 {
+	return ceil(ServiceZ * stdDev * math.Sqrt(float64(max(leadDays, 1))))
+}
+
+// Recommend works out a product's reorder point and order quantity from its
+// forecast rate, lead time and safety stock. Stock is reordered when it falls
+// to the reorder point, and the order brings it up to the reorder point plus
+// review days of demand, rounded up to whole units and the minimum order
+// quantity. Without a stock count there is no reorder date.
+func Recommend(it Item, today time.Time, review int) Recommendation {
+	lead, defaultLead := purchasing.DefaultLeadTimeDays, true
+	if it.LeadTimeDays != nil {
+		lead, defaultLead = *it.LeadTimeDays, false
+	}
+	rec := Recommendation{
+		ProductID:       it.ProductID,
+		ProductName:     it.Name,
+		SKU:             it.SKU,
+		Unit:            it.Unit,
+		Supplier:        it.Supplier,
+		OnHand:          it.OnHand,
+		DailyRate:       round2(it.DailyRate),
+		LeadTimeDays:    lead,
+		DefaultLeadTime: defaultLead,
+		UnitCost:        it.UnitCost,
+	}
+	if it.SafetyStock != nil {
+		rec.SafetyStock, rec.SafetyStockConfigured = *it.SafetyStock, true
+	} else {
+		rec.SafetyStock = SafetyStock(it.DailyStdDev, lead)
+	}
+	rec.ReorderPoint = ceil(it.DailyRate*float64(lead) + rec.SafetyStock)
+	cycle := it.DailyRate * float64(review)
+
+	need := cycle
+	switch {
+	case it.OnHand == nil:
+		rec.Status = StatusNotTracked
+	case *it.OnHand <= rec.ReorderPoint:
+		rec.Status = StatusOrderNow
+		rec.ReorderDate = today.Format("2006-01-02")
+		need = rec.ReorderPoint + cycle - *it.OnHand
+	default:
+		days := int(math.Floor((*it.OnHand-rec.ReorderPoint)/it.DailyRate + 1e-9))
+		rec.Status = StatusOK
+		if days <= review {
+			rec.Status = StatusOrderSoon
+		}
+		rec.ReorderDate = today.AddDate(0, 0, days).Format("2006-01-02")
+	}
+	if it.OnHand != nil {
+		days := 0
+		if *it.OnHand > 0 {
+			days = int(math.Floor(*it.OnHand/it.DailyRate + 1e-9))
+		}
+		rec.StockoutDate = today.AddDate(0, 0, days).Format("2006-01-02")
+	}
+	rec.OrderQuantity = math.Max(ceil(need), it.MinOrderQuantity)
+	rec.TotalCost = round2(rec.OrderQuantity * it.UnitCost)
+	return rec
+}
+
+// Build recommends reorders for items with forecast demand, most urgent first
+func Build(items []Item, today time.Time, review int) []Recommendation {
+	recs := []Recommendation{}
+	for _, it := range items {
+		if it.DailyRate <= 0 {
+			continue
+		}
+		recs = append(recs, Recommend(it, today, review))
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		a, b := recs[i], recs[j]
+		if statusOrder[a.Status] != statusOrder[b.Status] {
+			return statusOrder[a.Status] < statusOrder[b.Status]
+		}
+		if a.ReorderDate != b.ReorderDate {
+			return a.ReorderDate < b.ReorderDate
+		}
+		return a.ProductName < b.ProductName
+	})
+	return recs
+}
+
+// Due counts the recommendations to order now and soon
+func Due(recs []Recommendation) (now, soon int) {
+	for _, r := range recs {
+		switch r.Status {
+		case StatusOrderNow:
+			now++
+		case StatusOrderSoon:
+			soon++
+		}
+	}
+	return now, soon
+}
+
+func ceil(v float64) float64 {
+	return math.Ceil(v - 1e-9)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,60 @@
+package reorder
+
+import (
+	"testing"
+	"time"
+)
+
+func ptr(f float64) *float64 { return &f }
+
+func TestRecommend(t *testing.T) {
+	today := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	lead := 5
+	it := Item{ProductID: "p1", Name: "Kopi", Unit: "kg", LeadTimeDays: &lead, SafetyStock: ptr(4), DailyRate: 2, UnitCost: 1000}
+
+	// Reorder point 2*5+4 = 14; 30 on hand lasts 8 days above it
+	it.OnHand = ptr(30)
+	rec := Recommend(it, today, 7)
+	if rec.ReorderPoint != 14 || rec.Status != StatusOK || rec.ReorderDate != "2026-03-10" || rec.OrderQuantity != 14 {
+		t.Errorf("above reorder point: %+v", rec)
+	}
+	if rec.StockoutDate != "2026-03-17" || rec.TotalCost != 14000 || !rec.SafetyStockConfigured {
+		t.Errorf("stockout and cost: %+v", rec)
+	}
+
+	// At 10 on hand the order tops stock up to 14 + 14
+	it.OnHand = ptr(10)
+	if rec := Recommend(it, today, 7); rec.Status != StatusOrderNow || rec.ReorderDate != "2026-03-02" || rec.OrderQuantity != 18 {
+		t.Errorf("below reorder point: %+v", rec)
+	}
+
+	it.OnHand, it.MinOrderQuantity = nil, 50
+	if rec := Recommend(it, today, 7); rec.Status != StatusNotTracked || rec.ReorderDate != "" || rec.OrderQuantity != 50 {
+		t.Errorf("untracked with minimum order: %+v", rec)
+	}
+}
+
+func TestSafetyStock(t *testing.T) {
+	if got := SafetyStock(2, 4); got != 7 { // 1.65 * 2 * 2 = 6.6
+		t.Errorf("SafetyStock = %v", got)
+	}
+	if got := StdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9}); got != 2 {
+		t.Errorf("StdDev = %v", got)
+	}
+}
+
+func TestBuildOrder(t *testing.T) {
+	today := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	recs := Build([]Item{
+		{ProductID: "a", Name: "A", DailyRate: 1},
+		{ProductID: "b", Name: "B", DailyRate: 1, OnHand: ptr(100)},
+		{ProductID: "c", Name: "C", DailyRate: 1, OnHand: ptr(0)},
+		{ProductID: "d", Name: "D"},
+	}, today, 7)
+	if len(recs) != 3 || recs[0].ProductID != "c" || recs[1].ProductID != "b" || recs[2].ProductID != "a" {
+		t.Errorf("unexpected order: %+v", recs)
+	}
+	if now, soon := Due(recs); now != 1 || soon != 0 {
+		t.Errorf("Due = %d, %d", now, soon)
+	}
+}
@@ -0,0 +1,217 @@
+package salesanalytics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Query bounds
+const (
+	MaxRangeDays = 366 // Longest custom date range
+	MaxGroups    = 10  // Product and channel groups returned, largest first
+)
+
+// metrics are the SQL aggregates over sales_history s, by metric name
+var metrics = map[string]string{
+	"revenue":       "COALESCE(SUM(s.quantity * s.price), 0)::float8",
+	"quantity":      "COALESCE(SUM(s.quantity), 0)::float8",
+	"transactions":  "COUNT(*)::float8",
+	"average_price": "COALESCE(SUM(s.quantity * s.price) / NULLIF(SUM(s.quantity), 0), 0)::float8",
+}
+
+// groupKeys are the SQL group keys, by group name
+var groupKeys = map[string]string{
+	"product": "p.name",
+	"channel": "COALESCE(NULLIF(s.channel, ''), '-')",
+	"day":     "to_char(s.sale_date, 'YYYY-MM-DD')",
+	"week":    "to_char(date_trunc('week', s.sale_date), 'YYYY-MM-DD')",
+	"month":   "to_char(s.sale_date, 'YYYY-MM')",
+}
+
+// Metrics lists the metric names
+func Metrics() []string { return sortedKeys(metrics) }
+
+// GroupBys lists the group names
+func GroupBys() []string { return sortedKeys(groupKeys) }
+
+// Periods lists the named periods
+func Periods() []string {
+	return []string{"today", "yesterday", "last_7_days", "last_30_days", "this_week", "last_week", "this_month", "last_month", "this_year", "last_year"}
+}
+
+// Range is an inclusive range of days
+type Range struct {
+	Name string `json:"name,omitempty"`
+	From string `json:"from"` // YYYY-MM-DD
+	To   string `json:"to"`   // YYYY-MM-DD, inclusive
+
+	from, to time.Time
+}
+
+// Bounds returns the first and last day of the range
+func (r Range) Bounds() (time.Time, time.Time) { return r.from, r.to }
+
+func newRange(name string, from, to time.Time) Range {
+	return Range{Name: name, From: from.Format("2006-01-02"), To: to.Format("2006-01-02"), from: from, to: to}
+}
+
+// Resolve returns the range of a named period as of today, and the period it
+// is compared against: the one before it of the same kind. Periods running to
+// date (this_week, this_month, this_year) are compared with the same number of
+// days at the start of the previous one.
+func Resolve(period string, today time.Time) (Range, Range, error) {
+	day := func(n int) time.Time { return today.AddDate(0, 0, n) }
+	weekStart := day(-((int(today.Weekday()) + 6) % 7)) // Monday
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+	yearStart := time.Date(today.Year(), 1, 1, 0, 0, 0, 0, today.Location())
+
+	switch period {
+	case "today":
+		return newRange(period, today, today), newRange("yesterday", day(-1), day(-1)), nil
+	case "yesterday":
+		return newRange(period, day(-1), day(-1)), newRange("", day(-2), day(-2)), nil
+	case "last_7_days":
+		return newRange(period, day(-6), today), newRange("", day(-13), day(-7)), nil
+	case "last_30_days":
+		return newRange(period, day(-29), today), newRange("", day(-59), day(-30)), nil
+	case "this_week":
+		return newRange(period, weekStart, today), newRange("", weekStart.AddDate(0, 0, -7), day(-7)), nil
+	case "last_week":
+		return newRange(period, weekStart.AddDate(0, 0, -7), weekStart.AddDate(0, 0, -1)),
+			newRange("", weekStart.AddDate(0, 0, -14), weekStart.AddDate(0, 0, -8)), nil
+	case "this_month":
+		prevStart := monthStart.AddDate(0, -1, 0)
+		prevTo := prevStart.AddDate(0, 0, today.Day()-1)
+		if prevTo.Month() != prevStart.Month() {
+			prevTo = monthStart.AddDate(0, 0, -1)
+		}
+		return newRange(period, monthStart, today), newRange("", prevStart, prevTo), nil
+	case "last_month":
+		prevStart := monthStart.AddDate(0, -1, 0)
+		return newRange(period, prevStart, monthStart.AddDate(0, 0, -1)),
+			newRange("", prevStart.AddDate(0, -1, 0), prevStart.AddDate(0, 0, -1)), nil
+	case "this_year":
+		prevStart := yearStart.AddDate(-1, 0, 0)
+		prevTo := prevStart.AddDate(0, 0, today.YearDay()-1)
+		if prevTo.Year() != prevStart.Year() {
+			prevTo = yearStart.AddDate(0, 0, -1)
+		}
+		return newRange(period, yearStart, today), newRange("", prevStart, prevTo), nil
+	case "last_year":
+		prevStart := yearStart.AddDate(-1, 0, 0)
+		return newRange(period, prevStart, yearStart.AddDate(0, 0, -1)),
+			newRange("", prevStart.AddDate(-1, 0, 0), prevStart.AddDate(0, 0, -1)), nil
+	}
+	return Range{}, Range{}, fmt.Errorf("unknown period %q; use one of: %s", period, strings.Join(Periods(), ", "))
+}
+
+// Custom returns the range between two YYYY-MM-DD dates and the range of the
+// same length just before it
+func Custom(from, to string, loc *time.Location) (Range, Range, error) {
+	f, err := time.ParseInLocation("2006-01-02", from, loc)
+	if err != nil {
+		return Range{}, Range{}, fmt.Errorf("from must be a date (YYYY-MM-DD)")
+	}
+	t, err := time.ParseInLocation("2006-01-02", to, loc)
+	if err != nil {
+		return Range{}, Range{}, fmt.Errorf("to must be a date (YYYY-MM-DD)")
+	}
+	days := int(t.Sub(f).Hours()/24) + 1
+	if days < 1 || days > MaxRangeDays {
+		return Range{}, Range{}, fmt.Errorf("the range must run forward and span at most %d days", MaxRangeDays)
+	}
+	return newRange("", f, t), newRange("", f.AddDate(0, 0, -days), f.AddDate(0, 0, -1)), nil
+}
+
+// Params selects what to measure. ProductID and Channel filter when set.
+type Params struct {
+	Metric    string
+	GroupBy   string
+	ProductID string
+	Channel   string
+}
+
+// Validate checks the metric and group against the templates
+func (p Params) Validate() error {
+	if _, ok := metrics[p.Metric]; !ok {
+		return fmt.Errorf("unknown metric %q; use one of: %s", p.Metric, strings.Join(Metrics(), ", "))
+	}
+	if _, ok := groupKeys[p.GroupBy]; p.GroupBy != "" && !ok {
+		return fmt.Errorf("unknown group_by %q; use one of: %s", p.GroupBy, strings.Join(GroupBys(), ", "))
+	}
+	return nil
+}
+
+// SQL returns the query for p. It only interpolates fixed fragments; values
+// are bound as $1 company ID, $2 first day, $3 last day, $4 product ID and
+// $5 channel, the last two empty for no filter. Grouped queries return
+// (key, value) rows, others a single value.
+func (p Params) SQL() string {
+	where := `s.company_id = $1 AND s.sale_date >= $2 AND s.sale_date <= $3 AND NOT COALESCE(s.excluded, false)
+		AND ($4 = '' OR s.product_id = $4) AND ($5 = '' OR s.channel = $5)`
+	if p.GroupBy == "" {
+		return fmt.Sprintf(`SELECT %s FROM sales_history s WHERE %s`, metrics[p.Metric], where)
+	}
+	from, order, limit := "sales_history s", "1", ""
+	if p.GroupBy == "product" {
+		from = "sales_history s JOIN products p ON p.id = s.product_id"
+	}
+	if p.GroupBy == "product" || p.GroupBy == "channel" {
+		order, limit = "2 DESC, 1", fmt.Sprintf(" LIMIT %d", MaxGroups)
+	}
+	return fmt.Sprintf(`SELECT %s AS key, %s FROM %s WHERE %s GROUP BY 1 ORDER BY %s%s`,
+		groupKeys[p.GroupBy], metrics[p.Metric], from, where, order, limit)
+}
+
+// Group is a metric's value for one group
+type Group struct {
+	Key   string  `json:"key"`
+	Value float64 `json:"value"`
+}
+
+// Comparison is the metric over the previous period
+type Comparison struct {
+	Period        Range    `json:"period"`
+	Value         float64  `json:"value"`
+	Change        float64  `json:"change"`
+	ChangePercent *float64 `json:"change_percent,omitempty"` // Unset when the previous value is zero
+}
+
+// Result is the answer to an analytics query
+type Result struct {
+	Metric   string      `json:"metric"`
+	Period   Range       `json:"period"`
+	Product  string      `json:"product,omitempty"`
+	Channel  string      `json:"channel,omitempty"`
+	GroupBy  string      `json:"group_by,omitempty"`
+	Value    float64     `json:"value"`
+	Groups   []Group     `json:"groups,omitempty"`
+	Previous *Comparison `json:"previous,omitempty"`
+}
+
+// Compare sets the comparison with the previous period's value
+func (r *Result) Compare(period Range, value float64) {
+	c := &Comparison{Period: period, Value: Round(value), Change: Round(r.Value - value)}
+	if value != 0 {
+		pct := Round((r.Value - value) / value * 100)
+		c.ChangePercent = &pct
+	}
+	r.Previous = c
+}
+
+// Round rounds to two decimals
+func Round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,70 @@
+package salesanalytics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	today := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC) // Monday
+	cases := []struct {
+		period, from, to, prevFrom, prevTo string
+	}{
+		{"today", "2025-03-31", "2025-03-31", "2025-03-30", "2025-03-30"},
+		{"last_7_days", "2025-03-25", "2025-03-31", "2025-03-18", "2025-03-24"},
+		{"this_week", "2025-03-31", "2025-03-31", "2025-03-24", "2025-03-24"},
+		{"last_week", "2025-03-24", "2025-03-30", "2025-03-17", "2025-03-23"},
+		// February is shorter than the 31 days so far, so all of it is compared
+		{"this_month", "2025-03-01", "2025-03-31", "2025-02-01", "2025-02-28"},
+		{"last_month", "2025-02-01", "2025-02-28", "2025-01-01", "2025-01-31"},
+		{"this_year", "2025-01-01", "2025-03-31", "2024-01-01", "2024-03-30"},
+	}
+	for _, c := range cases {
+		cur, prev, err := Resolve(c.period, today)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cur.From != c.from || cur.To != c.to || prev.From != c.prevFrom || prev.To != c.prevTo {
+			t.Errorf("%s: got %s..%s vs %s..%s", c.period, cur.From, cur.To, prev.From, prev.To)
+		}
+	}
+	if _, _, err := Resolve("next_month", today); err == nil {
+		t.Error("expected an error for an unknown period")
+	}
+}
+
+func TestCustom(t *testing.T) {
+	cur, prev, err := Custom("2025-01-10", "2025-01-19", time.UTC)
+	if err != nil || cur.From != "2025-01-10" || prev.From != "2024-12-31" || prev.To != "2025-01-09" {
+		t.Errorf("unexpected ranges %+v %+v %v", cur, prev, err)
+	}
+	if _, _, err := Custom("2025-01-19", "2025-01-10", time.UTC); err == nil {
+		t.Error("expected an error for a backwards range")
+	}
+}
+
+func TestSQLOnlyUsesTemplates(t *testing.T) {
+	if err := (Params{Metric: "revenue; DROP TABLE sales_history"}).Validate(); err == nil {
+		t.Fatal("expected an unknown metric to be rejected")
+	}
+	if err := (Params{Metric: "revenue", GroupBy: "p.name"}).Validate(); err == nil {
+		t.Fatal("expected an unknown group to be rejected")
+	}
+	q := Params{Metric: "quantity", GroupBy: "product"}.SQL()
+	if !strings.Contains(q, "GROUP BY 1 ORDER BY 2 DESC, 1 LIMIT 10") || !strings.Contains(q, "$5") {
+		t.Errorf("unexpected query: %s", q)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	r := Result{Value: 150}
+	r.Compare(Range{}, 100)
+	if r.Previous.Change != 50 || *r.Previous.ChangePercent != 50 {
+		t.Errorf("unexpected comparison %+v", r.Previous)
+	}
+	r.Compare(Range{}, 0)
+	if r.Previous.ChangePercent != nil {
+		t.Error("expected no percentage against zero")
+	}
+}
@@ -0,0 +1,102 @@
+package salesbulk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BatchSize is how many sales records are changed per transaction
+const BatchSize = 500
+
+// MaxRecords bounds how many sales records one operation may change
+const MaxRecords = 50000
+
+// MaxShiftDays bounds how far shift_dates may move sale dates
+const MaxShiftDays = 31
+
+// Actions
+const (
+	ActionAssignChannel = "assign_channel" // Set the sales channel
+	ActionRemapProduct  = "remap_product"  // Move the records to another product
+	ActionShiftDates    = "shift_dates"    // Move sale dates by whole days
+)
+
+// Filter selects a company's sales records. All set fields must match.
+type Filter struct {
+	ProductID      string `json:"product_id,omitempty"`
+	Source         string `json:"source,omitempty"` // manual, csv, import, webhook, ...
+	Channel        string `json:"channel,omitempty"`
+	WithoutChannel bool   `json:"without_channel,omitempty"`
+	From           string `json:"from,omitempty"` // Sale date, YYYY-MM-DD, inclusive
+	To             string `json:"to,omitempty"`   // Sale date, YYYY-MM-DD, inclusive
+}
+
+// IsEmpty reports whether no field is set, i.e. the filter would match every record
+func (f Filter) IsEmpty() bool {
+	return f.ProductID == "" && f.Source == "" && f.Channel == "" && !f.WithoutChannel && f.From == "" && f.To == ""
+}
+
+// Validate checks the filter's values
+func (f Filter) Validate() error {
+	var from, to time.Time
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   *time.Time
+	}{{"from", f.From, &from}, {"to", f.To, &to}} {
+		if d.value == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", d.value)
+		if err != nil {
+			return fmt.Errorf("%s must be a date in YYYY-MM-DD format", d.name)
+		}
+		*d.dst = t
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return errors.New("to must not be before from")
+	}
+	if f.Channel != "" && f.WithoutChannel {
+		return errors.New("channel and without_channel cannot be combined")
+	}
+	return nil
+}
+
+// Params are the action-specific inputs of an operation
+type Params struct {
+	Channel   string `json:"channel,omitempty"`    // assign_channel
+	ProductID string `json:"product_id,omitempty"` // remap_product: the product to move to
+	Days      int    `json:"days,omitempty"`       // shift_dates: negative moves dates back
+}
+
+// Validate checks that params carry what action needs, given the filter
+func Validate(action string, p Params, f Filter) error {
+	switch action {
+	case ActionAssignChannel:
+		if strings.TrimSpace(p.Channel) == "" {
+			return errors.New("channel is required")
+		}
+		if len(p.Channel) > 50 {
+			return errors.New("channel must be at most 50 characters")
+		}
+	case ActionRemapProduct:
+		if p.ProductID == "" {
+			return errors.New("product_id is required")
+		}
+		if f.ProductID == "" {
+			return errors.New("the filter must name the product to move records from")
+		}
+		if f.ProductID == p.ProductID {
+			return errors.New("product_id must differ from the filter's product")
+		}
+	case ActionShiftDates:
+		if p.Days == 0 || p.Days > MaxShiftDays || p.Days < -MaxShiftDays {
+			return fmt.Errorf("days must be between -%d and %d and not 0", MaxShiftDays, MaxShiftDays)
+		}
+	default:
+		return fmt.Errorf("unknown action: %s", action)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package salesbulk
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	byProduct := Filter{ProductID: "p1"}
+	cases := []struct {
+		action string
+		params Params
+		filter Filter
+		ok     bool
+	}{
+		{ActionAssignChannel, Params{Channel: "shopee"}, Filter{Source: "csv"}, true},
+		{ActionAssignChannel, Params{Channel: " "}, Filter{Source: "csv"}, false},
+		{ActionRemapProduct, Params{ProductID: "p2"}, byProduct, true},
+		{ActionRemapProduct, Params{ProductID: "p2"}, Filter{Source: "csv"}, false},
+		{ActionRemapProduct, Params{ProductID: "p1"}, byProduct, false},
+		{ActionShiftDates, Params{Days: -1}, byProduct, true},
+		{ActionShiftDates, Params{Days: 0}, byProduct, false},
+		{ActionShiftDates, Params{Days: 60}, byProduct, false},
+		{"delete", Params{}, byProduct, false},
+	}
+	for _, c := range cases {
+		if err := Validate(c.action, c.params, c.filter); (err == nil) != c.ok {
+			t.Errorf("Validate(%s, %+v, %+v) = %v", c.action, c.params, c.filter, err)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	if !(Filter{}).IsEmpty() {
+		t.Error("zero filter is not empty")
+	}
+	if (Filter{WithoutChannel: true}).IsEmpty() {
+		t.Error("filter on missing channel is empty")
+	}
+	if err := (Filter{From: "2025-02-30"}).Validate(); err == nil {
+		t.Error("invalid date accepted")
+	}
+	if err := (Filter{From: "2025-03-01", To: "2025-02-01"}).Validate(); err == nil {
+		t.Error("reversed range accepted")
+	}
+	if err := (Filter{Channel: "tokopedia", WithoutChannel: true}).Validate(); err == nil {
+		t.Error("conflicting channel filters accepted")
+	}
+	if err := (Filter{Source: "csv", From: "2025-01-01", To: "2025-01-31"}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,58 @@
+package semcache
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/bantuaku/backend/services/embeddings"
+)
+
+// Question length bounds, in runes, for answers worth caching
+const (
+	MinQuestionLength = 6
+	MaxQuestionLength = 300
+)
+
+// DefaultSimilarity is the cosine similarity above which two questions share an answer
+const DefaultSimilarity = 0.92
+
+// Normalize lowercases a question and strips punctuation and extra whitespace,
+// so "Apa itu NIB?" and "apa itu nib" embed and compare the same
+func Normalize(question string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(question) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Cacheable reports whether a normalized question is short and general enough to
+// share an answer. Questions quoting numbers (prices, quantities, dates) are
+// usually about the asker's own data and are never cached.
+func Cacheable(normalized string) bool {
+	n := len([]rune(normalized))
+	if n < MinQuestionLength || n > MaxQuestionLength {
+		return false
+	}
+	return !strings.ContainsFunc(normalized, unicode.IsDigit)
+}
+
+// Match returns the index of the cached question most similar to query and its
+// score, or -1 when none reaches threshold
+func Match(query []float32, cached [][]float32, threshold float64) (int, float64) {
+	best := embeddings.TopK(query, cached, 1, threshold)
+	if len(best) == 0 {
+		return -1, 0
+	}
+	return best[0].Index, best[0].Score
+}
@@ -0,0 +1,36 @@
+package semcache
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"Apa itu NIB?":               "apa itu nib",
+		"  apa   itu NIB ?? ":        "apa itu nib",
+		"Bagaimana cara daftar-PIRT": "bagaimana cara daftar pirt",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	if !Cacheable(Normalize("Apa itu NIB?")) {
+		t.Error("general question should be cacheable")
+	}
+	for _, q := range []string{"hai", "stok kopi saya 20 kg cukup", ""} {
+		if Cacheable(Normalize(q)) {
+			t.Errorf("%q should not be cacheable", q)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cached := [][]float32{{1, 0}, {0.7, 0.7}}
+	if i, score := Match([]float32{0.72, 0.69}, cached, DefaultSimilarity); i != 1 || score < DefaultSimilarity {
+		t.Errorf("Match = %d, %v", i, score)
+	}
+	if i, _ := Match([]float32{0, 1}, cached, DefaultSimilarity); i != -1 {
+		t.Errorf("dissimilar query matched %d", i)
+	}
+}
@@ -0,0 +1,59 @@
+package settings
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Keys of platform settings
+const (
+	KeyMaintenance = "maintenance"
+	KeyAISpend     = "ai_spend"
+)
+
+// Store holds platform settings loaded from the database so they can change at
+// runtime. Values are kept as JSON and decoded by the feature that owns them.
+type Store struct {
+	mu     sync.RWMutex
+	values map[string]json.RawMessage
+}
+
+// NewStore returns an empty store; features fall back to their defaults until
+// it is first loaded
+func NewStore() *Store {
+	return &Store{values: map[string]json.RawMessage{}}
+}
+
+// Replace swaps in a freshly loaded set of settings
+func (s *Store) Replace(values map[string]json.RawMessage) {
+	v := make(map[string]json.RawMessage, len(values))
+	for key, value := range values {
+		v[key] = value
+	}
+	s.mu.Lock()
+	s.values = v
+	s.mu.Unlock()
+}
+
+// Decode decodes the setting stored under key into v. It returns false, leaving
+// v untouched, when the key is not set or its value does not decode.
+func (s *Store) Decode(key string, v interface{}) bool {
+	s.mu.RLock()
+	raw, ok := s.values[key]
+	s.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
+
+// All returns a copy of every setting
+func (s *Store) All() map[string]json.RawMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]json.RawMessage, len(s.values))
+	for key, value := range s.values {
+		out[key] = value
+	}
+	return out
+}
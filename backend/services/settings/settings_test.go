@@ -0,0 +1,22 @@
+package settings
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	s := NewStore()
+	var v struct{ Enabled bool }
+	if s.Decode(KeyMaintenance, &v) {
+		t.Fatal("expected unset key to report false")
+	}
+
+	s.Replace(map[string]json.RawMessage{KeyMaintenance: json.RawMessage(`{"Enabled":true}`), "broken": json.RawMessage(`{`)})
+	if !s.Decode(KeyMaintenance, &v) || !v.Enabled {
+		t.Errorf("got %+v, want enabled", v)
+	}
+	if s.Decode("broken", &v) {
+		t.Error("expected invalid JSON to report false")
+	}
+}
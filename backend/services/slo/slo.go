@@ -0,0 +1,243 @@
+package slo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBounds are the upper bounds, in milliseconds, of the latency
+// histogram used to estimate p95
+var latencyBounds = []int{25, 50, 100, 200, 300, 500, 750, 1000, 1500, 2000, 3000, 5000, 7500, 10000, 15000, 30000}
+
+// latencyQuantile is the share of requests that must meet an objective's
+// latency target
+const latencyQuantile = 0.95
+
+// minBurnRequests is how many requests the short window needs before its burn
+// rate is trusted, so a single failure on a quiet endpoint does not alert
+const minBurnRequests = 20
+
+// Objective is the target for a group of endpoints
+type Objective struct {
+	Name         string
+	Prefixes     []string // Request paths starting with any of these belong to the group
+	Availability float64  // Percent of requests that must not fail with a 5xx
+	LatencyMs    int      // p95 latency target
+}
+
+// ParseObjectives parses "name=/prefix|/prefix:availability:p95ms" entries
+// separated by commas, e.g. "chat=/api/v1/chat:99.5:8000,api=/api/v1:99.9:1000"
+func ParseObjectives(spec string) ([]Objective, error) {
+	var objectives []Objective
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rest, ok := strings.Cut(entry, "=")
+		parts := strings.Split(rest, ":")
+		if !ok || name == "" || len(parts) != 3 {
+			return nil, fmt.Errorf("invalid objective %q: want name=/prefix:availability:p95ms", entry)
+		}
+		availability, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || availability <= 0 || availability >= 100 {
+			return nil, fmt.Errorf("invalid availability in %q: want a percentage below 100", entry)
+		}
+		latency, err := strconv.Atoi(parts[2])
+		if err != nil || latency <= 0 {
+			return nil, fmt.Errorf("invalid p95 latency in %q", entry)
+		}
+		var prefixes []string
+		for _, p := range strings.Split(parts[0], "|") {
+			if p = strings.TrimSpace(p); p != "" {
+				prefixes = append(prefixes, p)
+			}
+		}
+		if len(prefixes) == 0 {
+			return nil, fmt.Errorf("objective %q has no path prefix", name)
+		}
+		objectives = append(objectives, Objective{Name: name, Prefixes: prefixes, Availability: availability, LatencyMs: latency})
+	}
+	return objectives, nil
+}
+
+// Status is an objective's compliance over the tracking window
+type Status struct {
+	Name                 string  `json:"name"`
+	AvailabilityTarget   float64 `json:"availability_target"`
+	LatencyTargetMs      int     `json:"latency_target_ms"`
+	Requests             int64   `json:"requests"`
+	Availability         float64 `json:"availability"` // Percent
+	P95Ms                int     `json:"p95_ms"`       // Histogram upper bound; the largest bound when beyond it
+	LatencyCompliance    float64 `json:"latency_compliance"`
+	ErrorBudgetRemaining float64 `json:"error_budget_remaining"` // 1 untouched, 0 spent
+	BurnRate5m           float64 `json:"burn_rate_5m"`           // Budget consumption speed; 1 spends it exactly over the window
+	BurnRate1h           float64 `json:"burn_rate_1h"`
+	State                string  `json:"state"` // "ok", "burning", "breached"
+}
+
+// States
+const (
+	StateOK       = "ok"
+	StateBurning  = "burning"
+	StateBreached = "breached"
+)
+
+type bucket struct {
+	minute              int64
+	total, errors, slow int64
+	hist                [17]int64 // len(latencyBounds)+1, the last counting overflow
+}
+
+// Tracker keeps per-minute request counts for each objective over a rolling
+// window. It is in-memory, so each API instance reports its own traffic.
+type Tracker struct {
+	mu         sync.Mutex
+	objectives []Objective
+	window     time.Duration
+	rings      [][]bucket
+}
+
+// NewTracker tracks objectives over window, rounded up to whole minutes
+func NewTracker(objectives []Objective, window time.Duration) *Tracker {
+	minutes := int((window + time.Minute - 1) / time.Minute)
+	if minutes < 60 {
+		minutes = 60
+	}
+	t := &Tracker{objectives: objectives, window: time.Duration(minutes) * time.Minute}
+	for range objectives {
+		t.rings = append(t.rings, make([]bucket, minutes))
+	}
+	return t
+}
+
+// Window returns how far back Status looks
+func (t *Tracker) Window() time.Duration {
+	return t.window
+}
+
+// Record counts a finished request against the objective its path belongs to.
+// Responses with a 5xx status count against availability.
+func (t *Tracker) Record(path string, status int, duration time.Duration, at time.Time) {
+	i := t.match(path)
+	if i < 0 {
+		return
+	}
+	minute := at.Unix() / 60
+	ms := int(duration.Milliseconds())
+	slot := sort.SearchInts(latencyBounds, ms)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ring := t.rings[i]
+	b := &ring[minute%int64(len(ring))]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	b.total++
+	if status >= 500 {
+		b.errors++
+	}
+	if ms > t.objectives[i].LatencyMs {
+		b.slow++
+	}
+	b.hist[slot]++
+}
+
+// Status reports every objective at now. An objective is burning when both its
+// 5-minute and 1-hour burn rates reach burnAlert.
+func (t *Tracker) Status(now time.Time, burnAlert float64) []Status {
+	statuses := make([]Status, len(t.objectives))
+	for i, o := range t.objectives {
+		all := t.sum(i, now, len(t.rings[i]))
+		short := t.sum(i, now, 5)
+		hour := t.sum(i, now, 60)
+
+		s := Status{
+			Name:                 o.Name,
+			AvailabilityTarget:   o.Availability,
+			LatencyTargetMs:      o.LatencyMs,
+			Requests:             all.total,
+			Availability:         100,
+			LatencyCompliance:    1,
+			ErrorBudgetRemaining: 1,
+			State:                StateOK,
+		}
+		if all.total > 0 {
+			s.Availability = 100 * (1 - float64(all.errors)/float64(all.total))
+			s.LatencyCompliance = 1 - float64(all.slow)/float64(all.total)
+			s.P95Ms = p95(all)
+			allowed := (1 - o.Availability/100) * float64(all.total)
+			s.ErrorBudgetRemaining = max(0, 1-float64(all.errors)/allowed)
+		}
+		s.BurnRate5m = burnRate(o, short)
+		s.BurnRate1h = burnRate(o, hour)
+
+		switch {
+		case s.ErrorBudgetRemaining <= 0 || s.LatencyCompliance < latencyQuantile:
+			s.State = StateBreached
+		case short.total >= minBurnRequests && s.BurnRate5m >= burnAlert && s.BurnRate1h >= burnAlert:
+			s.State = StateBurning
+		}
+		statuses[i] = s
+	}
+	return statuses
+}
+
+func (t *Tracker) match(path string) int {
+	best, bestLen := -1, 0
+	for i, o := range t.objectives {
+		for _, p := range o.Prefixes {
+			if strings.HasPrefix(path, p) && len(p) > bestLen {
+				best, bestLen = i, len(p)
+			}
+		}
+	}
+	return best
+}
+
+// sum adds up the last minutes buckets of objective i
+func (t *Tracker) sum(i int, now time.Time, minutes int) bucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current := now.Unix() / 60
+	var total bucket
+	for _, b := range t.rings[i] {
+		if b.total == 0 || b.minute > current || b.minute <= current-int64(minutes) {
+			continue
+		}
+		total.total += b.total
+		total.errors += b.errors
+		total.slow += b.slow
+		for j, n := range b.hist {
+			total.hist[j] += n
+		}
+	}
+	return total
+}
+
+// burnRate is the larger of the availability and latency budget burn rates
+func burnRate(o Objective, b bucket) float64 {
+	if b.total == 0 {
+		return 0
+	}
+	errorRate := float64(b.errors) / float64(b.total) / (1 - o.Availability/100)
+	slowRate := float64(b.slow) / float64(b.total) / (1 - latencyQuantile)
+	return max(errorRate, slowRate)
+}
+
+func p95(b bucket) int {
+	threshold := int64(float64(b.total)*latencyQuantile + 0.5)
+	var seen int64
+	for i, n := range b.hist {
+		seen += n
+		if seen >= threshold && i < len(latencyBounds) {
+			return latencyBounds[i]
+		}
+	}
+	return latencyBounds[len(latencyBounds)-1]
+}
@@ -0,0 +1,76 @@
+package slo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseObjectives(t *testing.T) {
+	objectives, err := ParseObjectives("chat=/api/v1/chat:99.5:8000, api=/api/v1|/healthz:99.9:1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objectives) != 2 || objectives[1].Name != "api" || len(objectives[1].Prefixes) != 2 || objectives[0].LatencyMs != 8000 {
+		t.Fatalf("unexpected objectives: %+v", objectives)
+	}
+	for _, bad := range []string{"chat=/api/v1/chat:99.5", "chat=/api:100:500", "=/api:99:500", "chat=:99:500"} {
+		if _, err := ParseObjectives(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestTrackerStatus(t *testing.T) {
+	objectives, _ := ParseObjectives("chat=/api/v1/chat:99:1000,api=/api/v1:99.9:500")
+	tracker := NewTracker(objectives, 24*time.Hour)
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// Healthy API traffic an hour ago, failing chat traffic right now
+	for i := 0; i < 1000; i++ {
+		tracker.Record("/api/v1/products", 200, 120*time.Millisecond, now.Add(-time.Hour))
+	}
+	for i := 0; i < 100; i++ {
+		status := 200
+		if i%5 == 0 {
+			status = 502
+		}
+		tracker.Record("/api/v1/chat/message", status, 400*time.Millisecond, now)
+	}
+	tracker.Record("/healthz", 500, time.Millisecond, now)
+
+	statuses := tracker.Status(now, 10)
+	chat, api := statuses[0], statuses[1]
+	if chat.Requests != 100 || chat.Availability != 80 || chat.State != StateBreached {
+		t.Errorf("chat: %+v", chat)
+	}
+	if chat.BurnRate5m < 19 || chat.P95Ms != 500 {
+		t.Errorf("chat burn/p95: %+v", chat)
+	}
+	if api.Requests != 1000 || api.State != StateOK || api.BurnRate5m != 0 || api.P95Ms != 200 {
+		t.Errorf("api: %+v", api)
+	}
+}
+
+func TestTrackerBurning(t *testing.T) {
+	objectives, _ := ParseObjectives("api=/api:99:1000")
+	tracker := NewTracker(objectives, 24*time.Hour)
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	// Plenty of good traffic earlier in the window leaves budget, then a burst of errors
+	for i := 0; i < 100000; i++ {
+		tracker.Record("/api/x", 200, 10*time.Millisecond, now.Add(-10*time.Hour))
+	}
+	for i := 0; i < 100; i++ {
+		status := 200
+		if i%4 == 0 {
+			status = 500
+		}
+		tracker.Record("/api/x", status, 10*time.Millisecond, now)
+	}
+	if s := tracker.Status(now, 10)[0]; s.State != StateBurning {
+		t.Errorf("expected burning: %+v", s)
+	}
+	// Outside the window everything is forgotten
+	if s := tracker.Status(now.Add(25*time.Hour), 10)[0]; s.Requests != 0 || s.State != StateOK {
+		t.Errorf("expected empty window: %+v", s)
+	}
+}
@@ -0,0 +1,112 @@
+package slowmovers
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Reasons a product is flagged
+const (
+	ReasonNoSales   = "no_sales"
+	ReasonDeclining = "declining"
+)
+
+// Suggested actions
+const (
+	ActionBundle      = "bundle"
+	ActionDiscount    = "discount"
+	ActionDiscontinue = "discontinue"
+)
+
+// DeclineThreshold is the drop in daily velocity, as a fraction, that flags a product
+const DeclineThreshold = 0.5
+
+// ProductSales is a product's sales velocity over two consecutive windows
+type ProductSales struct {
+	ProductID         string
+	ProductName       string
+	DaysSinceLastSale int     // -1 if the product has never sold
+	AgeDays           int     // Days since the product was created
+	RecentQuantity    float64 // Sold in the last window
+	PriorQuantity     float64 // Sold in the window before that
+}
+
+// SlowMover is a flagged product with a suggested action
+type SlowMover struct {
+	ProductID         string  `json:"product_id"`
+	ProductName       string  `json:"product_name"`
+	Reason            string  `json:"reason"`
+	DaysSinceLastSale int     `json:"days_since_last_sale"` // -1 if never sold
+	RecentVelocity    float64 `json:"recent_velocity"`      // Units per day in the last window
+	PriorVelocity     float64 `json:"prior_velocity"`
+	VelocityChange    float64 `json:"velocity_change"` // Percent
+	Action            string  `json:"action"`
+	Suggestion        string  `json:"suggestion"`
+}
+
+// Detect flags products with no sales in noSalesDays or whose velocity over the
+// last windowDays fell by DeclineThreshold or more versus the window before.
+// Products younger than noSalesDays are skipped. Results are ordered worst first.
+func Detect(products []ProductSales, noSalesDays, windowDays int) []SlowMover {
+	flagged := []SlowMover{}
+	for _, p := range products {
+		if p.AgeDays < noSalesDays {
+			continue
+		}
+
+		m := SlowMover{
+			ProductID:         p.ProductID,
+			ProductName:       p.ProductName,
+			DaysSinceLastSale: p.DaysSinceLastSale,
+			RecentVelocity:    p.RecentQuantity / float64(windowDays),
+			PriorVelocity:     p.PriorQuantity / float64(windowDays),
+		}
+		if m.PriorVelocity > 0 {
+			m.VelocityChange = (m.RecentVelocity - m.PriorVelocity) / m.PriorVelocity * 100
+		}
+
+		switch {
+		case p.DaysSinceLastSale < 0 || p.DaysSinceLastSale >= noSalesDays:
+			m.Reason = ReasonNoSales
+		case m.PriorVelocity > 0 && m.VelocityChange <= -DeclineThreshold*100:
+			m.Reason = ReasonDeclining
+		default:
+			continue
+		}
+		m.Action, m.Suggestion = suggest(p, m, noSalesDays)
+		flagged = append(flagged, m)
+	}
+
+	sort.SliceStable(flagged, func(i, j int) bool {
+		if flagged[i].Reason != flagged[j].Reason {
+			return flagged[i].Reason == ReasonNoSales
+		}
+		if flagged[i].Reason == ReasonNoSales {
+			return idleDays(flagged[i]) > idleDays(flagged[j])
+		}
+		return flagged[i].VelocityChange < flagged[j].VelocityChange
+	})
+	return flagged
+}
+
+// suggest picks an action: discontinue long-dead products, discount products that
+// stopped selling recently, and bundle products that still sell but are slowing down
+func suggest(p ProductSales, m SlowMover, noSalesDays int) (string, string) {
+	if m.Reason == ReasonNoSales {
+		if p.DaysSinceLastSale < 0 || p.DaysSinceLastSale >= noSalesDays*3 {
+			return ActionDiscontinue, fmt.Sprintf("%s tidak terjual lebih dari %d hari. Pertimbangkan untuk menghentikan produk ini dan menjual sisa stok dengan harga modal.",
+				p.ProductName, noSalesDays*3)
+		}
+		return ActionDiscount, fmt.Sprintf("%s tidak terjual selama %d hari. Coba diskon 15-25%% atau promo cuci gudang untuk menghabiskan stok.",
+			p.ProductName, p.DaysSinceLastSale)
+	}
+	return ActionBundle, fmt.Sprintf("Penjualan %s turun %.0f%%. Gabungkan dengan produk terlaris dalam paket bundling agar tetap bergerak.",
+		p.ProductName, -m.VelocityChange)
+}
+
+func idleDays(m SlowMover) int {
+	if m.DaysSinceLastSale < 0 {
+		return int(^uint(0) >> 1)
+	}
+	return m.DaysSinceLastSale
+}
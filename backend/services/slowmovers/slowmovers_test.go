@@ -0,0 +1,28 @@
+package slowmovers
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	products := []ProductSales{
+		{ProductID: "healthy", ProductName: "Kopi", DaysSinceLastSale: 1, AgeDays: 200, RecentQuantity: 90, PriorQuantity: 100},
+		{ProductID: "declining", ProductName: "Roti", DaysSinceLastSale: 2, AgeDays: 200, RecentQuantity: 20, PriorQuantity: 60},
+		{ProductID: "idle", ProductName: "Teh", DaysSinceLastSale: 40, AgeDays: 200, PriorQuantity: 5},
+		{ProductID: "dead", ProductName: "Sirup", DaysSinceLastSale: -1, AgeDays: 200},
+		{ProductID: "new", ProductName: "Matcha", DaysSinceLastSale: -1, AgeDays: 3},
+	}
+
+	got := Detect(products, 30, 30)
+	want := []struct{ id, reason, action string }{
+		{"dead", ReasonNoSales, ActionDiscontinue},
+		{"idle", ReasonNoSales, ActionDiscount},
+		{"declining", ReasonDeclining, ActionBundle},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d slow movers, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].ProductID != w.id || got[i].Reason != w.reason || got[i].Action != w.action {
+			t.Errorf("slow mover %d = %s/%s/%s, want %+v", i, got[i].ProductID, got[i].Reason, got[i].Action, w)
+		}
+	}
+}
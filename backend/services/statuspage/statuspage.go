@@ -0,0 +1,142 @@
+package statuspage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Component statuses, from best to worst
+const (
+	StatusOperational   = "operational"
+	StatusDegraded      = "degraded"
+	StatusPartialOutage = "partial_outage"
+	StatusMajorOutage   = "major_outage"
+)
+
+var statusRank = map[string]int{StatusOperational: 0, StatusDegraded: 1, StatusPartialOutage: 2, StatusMajorOutage: 3}
+
+// Components shown on the status page
+const (
+	ComponentAPI          = "api"
+	ComponentDatabase     = "database"
+	ComponentAIProvider   = "ai_provider"
+	ComponentIntegrations = "integrations"
+	ComponentBilling      = "billing"
+)
+
+// Component names a status page component
+type Component struct {
+	ID    string
+	Label string
+}
+
+// Components returns the status page components in display order
+func Components() []Component {
+	return []Component{
+		{ComponentAPI, "API"},
+		{ComponentDatabase, "Database"},
+		{ComponentAIProvider, "AI Assistant"},
+		{ComponentIntegrations, "Integrations"},
+		{ComponentBilling, "Billing"},
+	}
+}
+
+// Incident states, in the order an incident usually moves through them
+const (
+	IncidentInvestigating = "investigating"
+	IncidentIdentified    = "identified"
+	IncidentMonitoring    = "monitoring"
+	IncidentResolved      = "resolved"
+)
+
+// Incident impacts
+const (
+	ImpactMinor    = "minor"
+	ImpactMajor    = "major"
+	ImpactCritical = "critical"
+)
+
+// Worst returns the worst of statuses, or StatusOperational when there are none
+func Worst(statuses ...string) string {
+	worst := StatusOperational
+	for _, s := range statuses {
+		if statusRank[s] > statusRank[worst] {
+			worst = s
+		}
+	}
+	return worst
+}
+
+// ImpactStatus is the status an open incident of impact gives the components
+// it affects
+func ImpactStatus(impact string) string {
+	switch impact {
+	case ImpactCritical:
+		return StatusMajorOutage
+	case ImpactMajor:
+		return StatusPartialOutage
+	}
+	return StatusDegraded
+}
+
+// ErrorRateStatus classifies a failure count. Fewer than minSamples attempts
+// are too few to judge and count as operational.
+func ErrorRateStatus(failed, total, minSamples int) string {
+	if total == 0 || total < minSamples {
+		return StatusOperational
+	}
+	rate := float64(failed) / float64(total)
+	switch {
+	case rate >= 0.75:
+		return StatusMajorOutage
+	case rate >= 0.25:
+		return StatusPartialOutage
+	case rate >= 0.05:
+		return StatusDegraded
+	}
+	return StatusOperational
+}
+
+// ValidateComponents checks that every id names a status page component
+func ValidateComponents(ids []string) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("at least one component is required")
+	}
+	known := map[string]bool{}
+	for _, c := range Components() {
+		known[c.ID] = true
+	}
+	for _, id := range ids {
+		if !known[id] {
+			return fmt.Errorf("unknown component %q", id)
+		}
+	}
+	return nil
+}
+
+// Overall summarizes component statuses as a single headline
+func Overall(statuses []string) string {
+	switch Worst(statuses...) {
+	case StatusOperational:
+		return "All systems operational"
+	case StatusMajorOutage:
+		return "Major outage"
+	case StatusPartialOutage:
+		return "Partial outage"
+	}
+	return "Degraded performance"
+}
+
+// Normalize trims and lowercases component ids and drops duplicates
+func Normalize(ids []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id != "" && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package statuspage
+
+import "testing"
+
+func TestErrorRateStatus(t *testing.T) {
+	cases := []struct {
+		failed, total int
+		want          string
+	}{
+		{0, 0, StatusOperational},
+		{5, 8, StatusOperational}, // Too few samples
+		{1, 100, StatusOperational},
+		{10, 100, StatusDegraded},
+		{30, 100, StatusPartialOutage},
+		{90, 100, StatusMajorOutage},
+	}
+	for _, c := range cases {
+		if got := ErrorRateStatus(c.failed, c.total, 10); got != c.want {
+			t.Errorf("%d/%d: got %s, want %s", c.failed, c.total, got, c.want)
+		}
+	}
+}
+
+func TestWorst(t *testing.T) {
+	if got := Worst(); got != StatusOperational {
+		t.Errorf("empty: got %s", got)
+	}
+	if got := Worst(StatusDegraded, ImpactStatus(ImpactMajor), StatusOperational); got != StatusPartialOutage {
+		t.Errorf("got %s, want %s", got, StatusPartialOutage)
+	}
+}
+
+func TestValidateComponents(t *testing.T) {
+	if err := ValidateComponents(Normalize([]string{" API", "billing", "api"})); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateComponents([]string{"payments"}); err == nil {
+		t.Error("expected unknown component to be rejected")
+	}
+	if err := ValidateComponents(nil); err == nil {
+		t.Error("expected empty components to be rejected")
+	}
+}
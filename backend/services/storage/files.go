@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StorageRegions lists the regions a deployment may pin files to.
+// Indonesian regions are prefixed with "id-" for data residency checks.
+var StorageRegions = map[string]string{
+	"id-jkt": "Jakarta, Indonesia",
+	"id-btm": "Batam, Indonesia",
+	"id-sby": "Surabaya, Indonesia",
+	"sg-sin": "Singapore",
+}
+
+// IsValidRegion reports whether a region is known to the file store
+func IsValidRegion(region string) bool {
+	_, ok := StorageRegions[region]
+	return ok
+}
+
+// IsIndonesianRegion reports whether a region keeps data inside Indonesia
+func IsIndonesianRegion(region string) bool {
+	return IsValidRegion(region) && strings.HasPrefix(region, "id-")
+}
+
+// FileStore persists uploaded documents and exports under a region-pinned root.
+// Each region maps to its own directory (or mounted bucket) below baseDir.
+type FileStore struct {
+	baseDir       string
+	defaultRegion string
+}
+
+// NewFileStore creates a file store rooted at baseDir with a deployment default region
+func NewFileStore(baseDir, defaultRegion string) (*FileStore, error) {
+	if !IsValidRegion(defaultRegion) {
+		return nil, fmt.Errorf("unknown storage region: %s", defaultRegion)
+	}
+	return &FileStore{baseDir: baseDir, defaultRegion: defaultRegion}, nil
+}
+
+// DefaultRegion returns the deployment-wide storage region
+func (f *FileStore) DefaultRegion() string {
+	return f.defaultRegion
+}
+
+// Put stores the content under key in the given region and returns its storage path
+func (f *FileStore) Put(ctx context.Context, region, key string, r io.Reader) (string, int64, error) {
+	if region == "" {
+		region = f.defaultRegion
+	}
+	if !IsValidRegion(region) {
+		return "", 0, fmt.Errorf("unknown storage region: %s", region)
+	}
+
+	storagePath := filepath.Join(region, filepath.Clean("/" + key)[1:])
+	fullPath := filepath.Join(f.baseDir, storagePath)
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	dst, err := os.Create(fullPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer dst.Close()
+
+	size, err := io.Copy(dst, r)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return storagePath, size, ctx.Err()
+}
+
+// Open returns a reader for a previously stored file
+func (f *FileStore) Open(storagePath string) (io.ReadCloser, error) {
+	return os.Open(f.fullPath(storagePath))
+}
+
+// Delete removes a stored file
+func (f *FileStore) Delete(storagePath string) error {
+	err := os.Remove(f.fullPath(storagePath))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (f *FileStore) fullPath(storagePath string) string {
+	return filepath.Join(f.baseDir, filepath.Clean("/" + storagePath)[1:])
+}
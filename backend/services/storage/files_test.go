@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestStore(t *testing.T) (*FileStore, string) {
+	t.Helper()
+	root := t.TempDir()
+	base := filepath.Join(root, "files")
+	store, err := NewFileStore(base, "id-jkt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, root
+}
+
+func TestRegions(t *testing.T) {
+	tests := []struct {
+		region     string
+		valid, idn bool
+	}{
+		{"id-jkt", true, true},
+		{"sg-sin", true, false},
+		{"id-xyz", false, false}, // Prefix alone is not enough
+		{"", false, false},
+		{"../id-jkt", false, false},
+	}
+	for _, tt := range tests {
+		if got := IsValidRegion(tt.region); got != tt.valid {
+			t.Errorf("IsValidRegion(%q) = %v", tt.region, got)
+		}
+		if got := IsIndonesianRegion(tt.region); got != tt.idn {
+			t.Errorf("IsIndonesianRegion(%q) = %v", tt.region, got)
+		}
+	}
+
+	if _, err := NewFileStore(t.TempDir(), "us-east"); err == nil {
+		t.Error("NewFileStore accepted an unknown default region")
+	}
+}
+
+func TestPutRegions(t *testing.T) {
+	store, _ := newTestStore(t)
+	ctx := context.Background()
+
+	path, size, err := store.Put(ctx, "", "c1/a.csv", strings.NewReader("abc"))
+	if err != nil || path != filepath.Join("id-jkt", "c1", "a.csv") || size != 3 {
+		t.Errorf("Put to the default region = %q, %d, %v", path, size, err)
+	}
+	if path, _, err := store.Put(ctx, "sg-sin", "c1/a.csv", strings.NewReader("abc")); err != nil || path != filepath.Join("sg-sin", "c1", "a.csv") {
+		t.Errorf("Put to sg-sin = %q, %v", path, err)
+	}
+	for _, region := range []string{"us-east", "../sg-sin", "id-jkt/../sg-sin"} {
+		if _, _, err := store.Put(ctx, region, "c1/a.csv", strings.NewReader("abc")); err == nil {
+			t.Errorf("Put accepted region %q", region)
+		}
+	}
+}
+
+func TestPutKeepsKeysInsideRegion(t *testing.T) {
+	store, root := newTestStore(t)
+	for key, want := range map[string]string{
+		"../../escape.txt":           "id-jkt/escape.txt",
+		"c1/../../../escape.txt":     "id-jkt/escape.txt",
+		"/etc/escape.txt":            "id-jkt/etc/escape.txt",
+		"../sg-sin/c2/other.txt":     "id-jkt/sg-sin/c2/other.txt",
+		"c1/./nested/../receipt.png": "id-jkt/c1/receipt.png",
+	} {
+		path, _, err := store.Put(context.Background(), "id-jkt", key, strings.NewReader("x"))
+		if err != nil {
+			t.Errorf("Put(%q): %v", key, err)
+			continue
+		}
+		if path != filepath.FromSlash(want) {
+			t.Errorf("Put(%q) stored at %q, want %q", key, path, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "escape.txt")); !os.IsNotExist(err) {
+		t.Error("a key escaped the store's directory")
+	}
+}
+
+func TestOpenAndDeleteStayInsideStore(t *testing.T) {
+	store, root := newTestStore(t)
+	secret := filepath.Join(root, "secret.txt")
+	if err := os.WriteFile(secret, []byte("outside"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"../secret.txt", "id-jkt/../../secret.txt", "/../secret.txt"} {
+		if f, err := store.Open(p); err == nil {
+			data, _ := io.ReadAll(f)
+			f.Close()
+			t.Errorf("Open(%q) read %q from outside the store", p, data)
+		}
+		if err := store.Delete(p); err != nil {
+			t.Errorf("Delete(%q): %v", p, err)
+		}
+	}
+	if _, err := os.Stat(secret); err != nil {
+		t.Errorf("file outside the store was removed: %v", err)
+	}
+
+	path, _, err := store.Put(context.Background(), "", "c1/a.csv", strings.NewReader("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := store.Open(path)
+	if err != nil {
+		t.Fatalf("Open(%q): %v", path, err)
+	}
+	f.Close()
+	if err := store.Delete(path); err != nil {
+		t.Errorf("Delete(%q): %v", path, err)
+	}
+	if _, err := store.Open(path); !os.IsNotExist(err) {
+		t.Errorf("file still readable after Delete: %v", err)
+	}
+}
@@ -0,0 +1,219 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Allowed task attributes
+var (
+	Categories = []string{"sales", "marketing", "operations", "finance", "data"}
+	Levels     = []string{"low", "medium", "high"}
+)
+
+// MaxTasks caps the size of a monthly checklist
+const MaxTasks = 10
+
+// ProductStat is a product's sales over the last 30 days
+type ProductStat struct {
+	Name     string
+	Quantity float64
+	Unit     string
+	Revenue  float64
+}
+
+// Context is the business data a monthly plan is based on
+type Context struct {
+	CompanyName   string
+	Industry      string
+	Month         time.Time
+	RevenueTrend  float64 // Percent change vs. the previous month
+	TopProducts   []ProductStat
+	FocusProducts []string // A-class products by revenue or margin
+	IdleProducts  []string // Active products without sales in the last 30 days
+	DataIssues    []string // Human-readable data quality problems
+	OpenLastMonth []string // High-impact tasks left unfinished last month
+}
+
+// Task is a generated checklist item
+type Task struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Effort      string `json:"effort"`
+	Impact      string `json:"impact"`
+}
+
+// Plan is a generated monthly plan
+type Plan struct {
+	Summary string `json:"summary"`
+	Tasks   []Task `json:"tasks"`
+}
+
+// RuleBasedPlan builds a plan from the business data without calling an AI provider
+func RuleBasedPlan(c Context) Plan {
+	plan := Plan{Tasks: []Task{}}
+
+	for _, title := range c.OpenLastMonth {
+		plan.Tasks = append(plan.Tasks, Task{
+			Title:       "Selesaikan: " + title,
+			Description: "Tugas berdampak tinggi dari bulan lalu yang belum selesai.",
+			Category:    "operations",
+			Effort:      "medium",
+			Impact:      "high",
+		})
+	}
+
+	if len(c.DataIssues) > 0 {
+		plan.Tasks = append(plan.Tasks, Task{
+			Title:       "Rapikan data penjualan",
+			Description: "Perbaiki masalah data agar prediksi lebih akurat: " + strings.Join(c.DataIssues, "; "),
+			Category:    "data",
+			Effort:      "low",
+			Impact:      "high",
+		})
+	}
+
+	if len(c.TopProducts) > 0 {
+		top := c.TopProducts[0]
+		plan.Tasks = append(plan.Tasks, Task{
+			Title:       fmt.Sprintf("Jaga ketersediaan %s", top.Name),
+			Description: fmt.Sprintf("%s adalah produk terlaris (%g %s dalam 30 hari). Pastikan stok dan bahan baku cukup untuk bulan ini.", top.Name, top.Quantity, top.Unit),
+			Category:    "operations",
+			Effort:      "low",
+			Impact:      "high",
+		})
+		if len(c.TopProducts) > 1 {
+			plan.Tasks = append(plan.Tasks, Task{
+				Title:       fmt.Sprintf("Buat paket bundling %s + %s", top.Name, c.TopProducts[1].Name),
+				Description: "Gabungkan dua produk terlaris dalam satu paket hemat untuk menaikkan nilai transaksi rata-rata.",
+				Category:    "marketing",
+				Effort:      "medium",
+				Impact:      "medium",
+			})
+		}
+	}
+
+	if len(c.IdleProducts) > 0 {
+		names := c.IdleProducts
+		if len(names) > 3 {
+			names = names[:3]
+		}
+		plan.Tasks = append(plan.Tasks, Task{
+			Title:       "Evaluasi produk yang tidak laku",
+			Description: fmt.Sprintf("Tidak ada penjualan 30 hari terakhir untuk: %s. Pertimbangkan promo cuci gudang atau hentikan produksi.", strings.Join(names, ", ")),
+			Category:    "sales",
+			Effort:      "medium",
+			Impact:      "medium",
+		})
+	}
+
+	if c.RevenueTrend < -10 {
+		plan.Tasks = append(plan.Tasks, Task{
+			Title:       "Aktifkan kembali pelanggan lama",
+			Description: fmt.Sprintf("Omzet turun %.0f%% dibanding bulan lalu. Kirim promo khusus ke pelanggan lama lewat WhatsApp atau media sosial.", -c.RevenueTrend),
+			Category:    "marketing",
+			Effort:      "low",
+			Impact:      "high",
+		})
+	}
+
+	plan.Tasks = append(plan.Tasks, Task{
+		Title:       "Tinjau harga dan margin",
+		Description: "Bandingkan harga jual dengan biaya bahan dan harga pesaing, lalu sesuaikan produk dengan margin tipis.",
+		Category:    "finance",
+		Effort:      "medium",
+		Impact:      "medium",
+	})
+
+	if len(plan.Tasks) > MaxTasks {
+		plan.Tasks = plan.Tasks[:MaxTasks]
+	}
+	plan.Summary = fmt.Sprintf("Rencana aksi %s untuk %s: %d tugas, fokus pada tugas berdampak tinggi terlebih dahulu.",
+		monthLabel(c.Month), c.CompanyName, len(plan.Tasks))
+	return plan
+}
+
+// BuildPrompt renders the user prompt asking an AI provider for a JSON plan
+func BuildPrompt(c Context) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Buat rencana aksi bulanan untuk %s (%s), bulan %s.\n\n", c.CompanyName, c.Industry, monthLabel(c.Month))
+	fmt.Fprintf(&b, "Perubahan omzet vs bulan lalu: %.1f%%\n", c.RevenueTrend)
+	if len(c.TopProducts) > 0 {
+		b.WriteString("Produk terlaris 30 hari terakhir:\n")
+		for _, p := range c.TopProducts {
+			fmt.Fprintf(&b, "- %s: %g %s, omzet Rp %.0f\n", p.Name, p.Quantity, p.Unit, p.Revenue)
+		}
+	}
+	if len(c.FocusProducts) > 0 {
+		fmt.Fprintf(&b, "Produk fokus (kelas A, prioritaskan dalam rencana): %s\n", strings.Join(c.FocusProducts, ", "))
+	}
+	if len(c.IdleProducts) > 0 {
+		fmt.Fprintf(&b, "Produk tanpa penjualan 30 hari: %s\n", strings.Join(c.IdleProducts, ", "))
+	}
+	if len(c.DataIssues) > 0 {
+		fmt.Fprintf(&b, "Masalah data: %s\n", strings.Join(c.DataIssues, "; "))
+	}
+	if len(c.OpenLastMonth) > 0 {
+		fmt.Fprintf(&b, "Tugas penting bulan lalu yang belum selesai: %s\n", strings.Join(c.OpenLastMonth, "; "))
+	}
+	fmt.Fprintf(&b, `
+Balas HANYA dengan JSON berformat:
+{"summary": "...", "tasks": [{"title": "...", "description": "...", "category": "%s", "effort": "%s", "impact": "%s"}]}
+Maksimal %d tugas yang konkret dan bisa dikerjakan dalam sebulan.`,
+		strings.Join(Categories, "|"), strings.Join(Levels, "|"), strings.Join(Levels, "|"), MaxTasks)
+	return b.String()
+}
+
+// ParsePlan extracts and validates a JSON plan from an AI response
+func ParsePlan(content string) (Plan, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return Plan{}, fmt.Errorf("no JSON object in response")
+	}
+
+	var plan Plan
+	if err := json.Unmarshal([]byte(content[start:end+1]), &plan); err != nil {
+		return Plan{}, fmt.Errorf("invalid plan JSON: %w", err)
+	}
+
+	valid := make([]Task, 0, len(plan.Tasks))
+	for _, t := range plan.Tasks {
+		t.Title = strings.TrimSpace(t.Title)
+		t.Category = strings.ToLower(t.Category)
+		t.Effort = strings.ToLower(t.Effort)
+		t.Impact = strings.ToLower(t.Impact)
+		if t.Title == "" || len(t.Title) > 255 || !contains(Categories, t.Category) ||
+			!contains(Levels, t.Effort) || !contains(Levels, t.Impact) {
+			continue
+		}
+		valid = append(valid, t)
+	}
+	if len(valid) == 0 {
+		return Plan{}, fmt.Errorf("plan has no valid tasks")
+	}
+	if len(valid) > MaxTasks {
+		valid = valid[:MaxTasks]
+	}
+	plan.Tasks = valid
+	return plan, nil
+}
+
+var monthNames = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+func monthLabel(t time.Time) string {
+	return fmt.Sprintf("%s %d", monthNames[t.Month()-1], t.Year())
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
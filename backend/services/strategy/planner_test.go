@@ -0,0 +1,53 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePlan(t *testing.T) {
+	content := "Berikut rencananya:\n```json\n" + `{"summary": "Fokus omzet", "tasks": [
+		{"title": "Promo WhatsApp", "description": "Kirim promo", "category": "Marketing", "effort": "low", "impact": "HIGH"},
+		{"title": "", "category": "sales", "effort": "low", "impact": "low"},
+		{"title": "Sewa ruko", "category": "real-estate", "effort": "high", "impact": "high"}
+	]}` + "\n```"
+
+	plan, err := ParsePlan(content)
+	if err != nil {
+		t.Fatalf("ParsePlan returned error: %v", err)
+	}
+	if plan.Summary != "Fokus omzet" {
+		t.Errorf("Summary = %q", plan.Summary)
+	}
+	if len(plan.Tasks) != 1 {
+		t.Fatalf("got %d tasks, want 1 (invalid tasks dropped)", len(plan.Tasks))
+	}
+	if got := plan.Tasks[0]; got.Category != "marketing" || got.Impact != "high" {
+		t.Errorf("task not normalized: %+v", got)
+	}
+
+	if _, err := ParsePlan("maaf, saya tidak bisa"); err == nil {
+		t.Error("ParsePlan should fail without JSON")
+	}
+}
+
+func TestRuleBasedPlanCarriesOverOpenTasks(t *testing.T) {
+	plan := RuleBasedPlan(Context{
+		CompanyName:   "Kopi Kita",
+		Month:         time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		RevenueTrend:  -25,
+		OpenLastMonth: []string{"Daftar GoFood"},
+	})
+
+	if len(plan.Tasks) == 0 || plan.Tasks[0].Title != "Selesaikan: Daftar GoFood" {
+		t.Fatalf("first task should carry over last month's open task, got %+v", plan.Tasks)
+	}
+	for _, task := range plan.Tasks {
+		if task.Impact == "" || task.Effort == "" || task.Category == "" {
+			t.Errorf("task missing attributes: %+v", task)
+		}
+	}
+	if plan.Summary == "" {
+		t.Error("Summary should not be empty")
+	}
+}
@@ -0,0 +1,138 @@
+package suggestions
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bantuaku/backend/services/purchasing"
+	"github.com/bantuaku/backend/services/slowmovers"
+)
+
+// Kinds
+const (
+	KindReorder    = "reorder"
+	KindSlowMover  = "slow_mover"
+	KindSalesTrend = "sales_trend"
+)
+
+// Feed bounds
+const (
+	PerCompany = 3              // Suggestions shown at once
+	TTL        = 72 * time.Hour // How long a suggestion stays without being regenerated
+	TrendShift = 0.2            // Week-over-week revenue change worth a suggestion
+	DueDays    = 3              // Orders due this soon are suggested
+)
+
+// Suggestion is a personalized prompt offered to the user. Subject identifies
+// what it is about, so a dismissed suggestion is not offered again.
+type Suggestion struct {
+	Kind     string `json:"kind"`
+	Subject  string `json:"-"`
+	Title    string `json:"title"`  // Shown on the card
+	Prompt   string `json:"prompt"` // Sent to the assistant when the card is picked
+	Priority int    `json:"-"`
+}
+
+// Key identifies the suggestion among a company's suggestions
+func (s Suggestion) Key() string {
+	return s.Kind + ":" + s.Subject
+}
+
+// Reorder suggests placing a purchase order that is due soon
+func Reorder(o purchasing.Order, today time.Time) Suggestion {
+	when := "hari ini"
+	if due, err := time.ParseInLocation("2006-01-02", o.OrderDate, today.Location()); err == nil {
+		if days := int(due.Sub(today).Hours() / 24); days > 0 {
+			when = fmt.Sprintf("dalam %d hari", days)
+		}
+	}
+	supplier := ""
+	if o.Supplier != "" {
+		supplier = " dari " + o.Supplier
+	}
+	return Suggestion{
+		Kind:     KindReorder,
+		Subject:  o.ProductID + ":" + o.OrderDate,
+		Title:    fmt.Sprintf("Waktunya memesan %s%s %s (sekitar %s %s) — mau saya buatkan rencana belanjanya?", o.ProductName, supplier, when, formatQuantity(o.Quantity), o.Unit),
+		Prompt:   fmt.Sprintf("Buatkan rencana belanja untuk %s: berapa yang perlu saya pesan dan kapan?", o.ProductName),
+		Priority: 30,
+	}
+}
+
+// SlowMover suggests acting on a product that stopped selling or slowed down
+func SlowMover(m slowmovers.SlowMover) Suggestion {
+	title := fmt.Sprintf("Penjualan %s turun %.0f%% — mau saya carikan cara mendorongnya lagi?", m.ProductName, math.Abs(m.VelocityChange))
+	if m.DaysSinceLastSale < 0 {
+		title = fmt.Sprintf("%s belum pernah terjual — mau saya bantu rencanakan promosinya?", m.ProductName)
+	} else if m.DaysSinceLastSale > 0 && m.RecentVelocity == 0 {
+		title = fmt.Sprintf("%s tidak terjual %d hari terakhir — mau saya bantu rencanakan promosinya?", m.ProductName, m.DaysSinceLastSale)
+	}
+	return Suggestion{
+		Kind:     KindSlowMover,
+		Subject:  m.ProductID,
+		Title:    title,
+		Prompt:   fmt.Sprintf("Penjualan %s sedang lesu. Apa yang sebaiknya saya lakukan untuk menjualnya lagi?", m.ProductName),
+		Priority: 20,
+	}
+}
+
+// SalesTrend suggests looking into a week's revenue when it moved by TrendShift
+// or more against the week before
+func SalesTrend(current, previous float64, weekStart time.Time) (Suggestion, bool) {
+	if previous <= 0 {
+		return Suggestion{}, false
+	}
+	change := (current - previous) / previous
+	if math.Abs(change) < TrendShift {
+		return Suggestion{}, false
+	}
+	s := Suggestion{Kind: KindSalesTrend, Priority: 25}
+	pct := math.Round(math.Abs(change) * 100)
+	if change < 0 {
+		s.Subject = "down:" + weekStart.Format("2006-01-02")
+		s.Title = fmt.Sprintf("Omzet 7 hari terakhir turun %.0f%% dibanding minggu sebelumnya — mau saya analisis penyebabnya?", pct)
+		s.Prompt = "Omzet saya 7 hari terakhir turun dibanding minggu sebelumnya. Produk dan kanal mana yang paling berpengaruh, dan apa yang bisa saya lakukan?"
+	} else {
+		s.Subject = "up:" + weekStart.Format("2006-01-02")
+		s.Title = fmt.Sprintf("Omzet 7 hari terakhir naik %.0f%% dibanding minggu sebelumnya — mau tahu apa pendorongnya?", pct)
+		s.Prompt = "Omzet saya 7 hari terakhir naik dibanding minggu sebelumnya. Produk dan kanal mana yang mendorongnya, dan bagaimana mempertahankannya?"
+	}
+	return s, true
+}
+
+// Pick chooses up to n suggestions, highest priority first, taking one of each
+// kind before a second of any. Dismissed keys are skipped.
+func Pick(candidates []Suggestion, dismissed map[string]bool, n int) []Suggestion {
+	var open []Suggestion
+	for _, c := range candidates {
+		if !dismissed[c.Key()] {
+			open = append(open, c)
+		}
+	}
+	sort.SliceStable(open, func(i, j int) bool { return open[i].Priority > open[j].Priority })
+
+	var picked []Suggestion
+	taken := map[int]bool{}
+	kinds := map[string]bool{}
+	for i, c := range open {
+		if len(picked) < n && !kinds[c.Kind] {
+			picked = append(picked, c)
+			taken[i], kinds[c.Kind] = true, true
+		}
+	}
+	for i, c := range open {
+		if len(picked) < n && !taken[i] {
+			picked = append(picked, c)
+		}
+	}
+	return picked
+}
+
+func formatQuantity(q float64) string {
+	if q == math.Trunc(q) {
+		return fmt.Sprintf("%.0f", q)
+	}
+	return fmt.Sprintf("%.2f", q)
+}
@@ -0,0 +1,54 @@
+package suggestions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bantuaku/backend/services/purchasing"
+	"github.com/bantuaku/backend/services/slowmovers"
+)
+
+func TestPickSpreadsKinds(t *testing.T) {
+	candidates := []Suggestion{
+		{Kind: KindReorder, Subject: "a", Priority: 30},
+		{Kind: KindReorder, Subject: "b", Priority: 30},
+		{Kind: KindSlowMover, Subject: "c", Priority: 20},
+		{Kind: KindSalesTrend, Subject: "d", Priority: 25},
+	}
+	picked := Pick(candidates, nil, 3)
+	if len(picked) != 3 || picked[0].Subject != "a" || picked[1].Subject != "d" || picked[2].Subject != "c" {
+		t.Errorf("expected one of each kind, got %+v", picked)
+	}
+
+	picked = Pick(candidates, map[string]bool{"reorder:a": true, "sales_trend:d": true}, 3)
+	if len(picked) != 2 || picked[0].Subject != "b" || picked[1].Subject != "c" {
+		t.Errorf("expected dismissed suggestions skipped, got %+v", picked)
+	}
+}
+
+func TestSalesTrend(t *testing.T) {
+	week := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	if _, ok := SalesTrend(110, 100, week); ok {
+		t.Error("a 10% change should not be suggested")
+	}
+	s, ok := SalesTrend(65, 100, week)
+	if !ok || s.Subject != "down:2025-06-02" || !strings.Contains(s.Title, "turun 35%") {
+		t.Errorf("unexpected suggestion %+v", s)
+	}
+	if _, ok := SalesTrend(50, 0, week); ok {
+		t.Error("no suggestion without a previous week")
+	}
+}
+
+func TestReorderAndSlowMover(t *testing.T) {
+	today := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	s := Reorder(purchasing.Order{ProductID: "p1", ProductName: "Kopi", Supplier: "CV Maju", OrderDate: "2025-06-04", Quantity: 12, Unit: "kg"}, today)
+	if s.Key() != "reorder:p1:2025-06-04" || !strings.Contains(s.Title, "Kopi dari CV Maju dalam 2 hari (sekitar 12 kg)") {
+		t.Errorf("unexpected reorder suggestion %+v", s)
+	}
+	m := SlowMover(slowmovers.SlowMover{ProductID: "p2", ProductName: "Teh", DaysSinceLastSale: 40})
+	if !strings.Contains(m.Title, "tidak terjual 40 hari") {
+		t.Errorf("unexpected slow mover suggestion %+v", m)
+	}
+}
@@ -0,0 +1,140 @@
+package trendkeywords
+
+import (
+	"sort"
+	"strings"
+)
+
+// Limits on shared keywords
+const (
+	MaxKeywordLength  = 100
+	MaxPerRequest     = 100 // Keywords added in one request
+	MaxSharedInPrompt = 10  // Shared keywords added to a company's own
+)
+
+// Normalize lowercases a keyword or industry and collapses its whitespace, so
+// "Kuliner  " and "kuliner" are tracked once
+func Normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// Merge appends up to limit shared keywords that are not already among own,
+// comparing normalized keywords. Own keywords keep their order and spelling.
+func Merge(own, shared []string, limit int) []string {
+	seen := make(map[string]bool, len(own))
+	merged := make([]string, 0, len(own)+min(len(shared), limit))
+	for _, k := range own {
+		seen[Normalize(k)] = true
+		merged = append(merged, k)
+	}
+	added := 0
+	for _, k := range shared {
+		n := Normalize(k)
+		if n == "" || seen[n] || added == limit {
+			continue
+		}
+		seen[n] = true
+		merged = append(merged, k)
+		added++
+	}
+	return merged
+}
+
+// Company is a company's industry and the keywords its last prediction used
+type Company struct {
+	Industry string
+	Keywords []string
+}
+
+// KeywordUsage is how many companies track a keyword
+type KeywordUsage struct {
+	Keyword   string `json:"keyword"`
+	Companies int    `json:"companies"`
+	Shared    bool   `json:"shared"` // Also tracked centrally for the industry
+}
+
+// IndustryCoverage is how well one industry's companies are covered by keywords
+type IndustryCoverage struct {
+	Industry              string         `json:"industry"` // Empty for companies without one
+	Companies             int            `json:"companies"`
+	CompaniesWithKeywords int            `json:"companies_with_keywords"` // Their own, from a prediction
+	Uncovered             int            `json:"uncovered"`               // Neither their own nor shared keywords
+	SharedKeywords        int            `json:"shared_keywords"`
+	DuplicateKeywords     int            `json:"duplicate_keywords"` // Tracked by more than one company and not shared
+	TopKeywords           []KeywordUsage `json:"top_keywords"`
+}
+
+// Coverage reports keyword coverage per industry, the industries with the most
+// companies first. shared maps normalized industries to their shared
+// keywords; industries with shared keywords but no companies are included.
+func Coverage(companies []Company, shared map[string][]string, top int) []IndustryCoverage {
+	type tally struct {
+		coverage IndustryCoverage
+		counts   map[string]int
+	}
+	industries := map[string]*tally{}
+	get := func(industry string) *tally {
+		t := industries[industry]
+		if t == nil {
+			t = &tally{coverage: IndustryCoverage{Industry: industry}, counts: map[string]int{}}
+			industries[industry] = t
+		}
+		return t
+	}
+	for industry, keywords := range shared {
+		get(industry).coverage.SharedKeywords = len(keywords)
+	}
+
+	for _, c := range companies {
+		industry := Normalize(c.Industry)
+		t := get(industry)
+		t.coverage.Companies++
+		own := map[string]bool{}
+		for _, k := range c.Keywords {
+			if n := Normalize(k); n != "" {
+				own[n] = true
+			}
+		}
+		if len(own) > 0 {
+			t.coverage.CompaniesWithKeywords++
+		} else if len(shared[industry]) == 0 {
+			t.coverage.Uncovered++
+		}
+		for k := range own {
+			t.counts[k]++
+		}
+	}
+
+	report := make([]IndustryCoverage, 0, len(industries))
+	for industry, t := range industries {
+		isShared := map[string]bool{}
+		for _, k := range shared[industry] {
+			isShared[Normalize(k)] = true
+		}
+		usage := make([]KeywordUsage, 0, len(t.counts))
+		for k, n := range t.counts {
+			usage = append(usage, KeywordUsage{Keyword: k, Companies: n, Shared: isShared[k]})
+			if n > 1 && !isShared[k] {
+				t.coverage.DuplicateKeywords++
+			}
+		}
+		sort.Slice(usage, func(i, j int) bool {
+			if usage[i].Companies != usage[j].Companies {
+				return usage[i].Companies > usage[j].Companies
+			}
+			return usage[i].Keyword < usage[j].Keyword
+		})
+		if len(usage) > top {
+			usage = usage[:top]
+		}
+		t.coverage.TopKeywords = usage
+		report = append(report, t.coverage)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Companies != report[j].Companies {
+			return report[i].Companies > report[j].Companies
+		}
+		return report[i].Industry < report[j].Industry
+	})
+	return report
+}
@@ -0,0 +1,43 @@
+package trendkeywords
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	got := Merge([]string{"Kuliner", "Kopi Susu"}, []string{"kopi susu", "kuliner", "makanan ringan", "katering"}, 1)
+	want := []string{"Kuliner", "Kopi Susu", "makanan ringan"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge = %v, want %v", got, want)
+	}
+	if got := Normalize("  Kopi   SUSU "); got != "kopi susu" {
+		t.Errorf("Normalize = %q", got)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	report := Coverage([]Company{
+		{Industry: "Kuliner", Keywords: []string{"kopi", "Kue"}},
+		{Industry: "kuliner ", Keywords: []string{"Kopi"}},
+		{Industry: "Kuliner"},
+		{Industry: "Fashion"},
+	}, map[string][]string{"kuliner": {"kue"}, "kerajinan": {"batik"}}, 5)
+
+	if len(report) != 3 || report[0].Industry != "kuliner" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	k := report[0]
+	if k.Companies != 3 || k.CompaniesWithKeywords != 2 || k.Uncovered != 0 || k.SharedKeywords != 1 || k.DuplicateKeywords != 1 {
+		t.Errorf("kuliner: %+v", k)
+	}
+	if k.TopKeywords[0] != (KeywordUsage{Keyword: "kopi", Companies: 2}) || !k.TopKeywords[1].Shared {
+		t.Errorf("top keywords: %+v", k.TopKeywords)
+	}
+	if report[1].Industry != "fashion" || report[1].Uncovered != 1 {
+		t.Errorf("fashion: %+v", report[1])
+	}
+	if report[2].Industry != "kerajinan" || report[2].Companies != 0 || report[2].SharedKeywords != 1 {
+		t.Errorf("kerajinan: %+v", report[2])
+	}
+}
@@ -0,0 +1,103 @@
+package units
+
+import (
+	"fmt"
+	"math"
+)
+
+// Dimensions group units that can be converted into each other
+const (
+	DimensionCount   = "count"
+	DimensionMass    = "mass"
+	DimensionVolume  = "volume"
+	DimensionServing = "serving"
+)
+
+// DefaultUnit is used for products without a configured unit
+const DefaultUnit = "pcs"
+
+// Unit is a unit of measure with its factor relative to the dimension's base unit
+type Unit struct {
+	Code       string  `json:"code"`
+	Label      string  `json:"label"`
+	Dimension  string  `json:"dimension"`
+	Factor     float64 `json:"factor"`     // Base units (pcs, gram, ml, porsi) per one of this unit
+	Fractional bool    `json:"fractional"` // Whether quantities like 0.5 are allowed
+}
+
+var catalog = []Unit{
+	{Code: "pcs", Label: "Pcs", Dimension: DimensionCount, Factor: 1},
+	{Code: "lusin", Label: "Lusin (12 pcs)", Dimension: DimensionCount, Factor: 12},
+	{Code: "kodi", Label: "Kodi (20 pcs)", Dimension: DimensionCount, Factor: 20},
+	{Code: "gram", Label: "Gram", Dimension: DimensionMass, Factor: 1, Fractional: true},
+	{Code: "ons", Label: "Ons (100 gram)", Dimension: DimensionMass, Factor: 100, Fractional: true},
+	{Code: "kg", Label: "Kilogram", Dimension: DimensionMass, Factor: 1000, Fractional: true},
+	{Code: "kuintal", Label: "Kuintal (100 kg)", Dimension: DimensionMass, Factor: 100000, Fractional: true},
+	{Code: "ton", Label: "Ton", Dimension: DimensionMass, Factor: 1000000, Fractional: true},
+	{Code: "ml", Label: "Mililiter", Dimension: DimensionVolume, Factor: 1, Fractional: true},
+	{Code: "liter", Label: "Liter", Dimension: DimensionVolume, Factor: 1000, Fractional: true},
+	{Code: "porsi", Label: "Porsi", Dimension: DimensionServing, Factor: 1},
+}
+
+// Catalog returns the built-in units
+func Catalog() []Unit {
+	return catalog
+}
+
+// Get returns a built-in unit by code
+func Get(code string) (Unit, bool) {
+	for _, u := range catalog {
+		if u.Code == code {
+			return u, true
+		}
+	}
+	return Unit{}, false
+}
+
+// Custom builds a product-specific unit such as "karung" = 50 kg
+func Custom(code, label string, amount float64, of Unit) Unit {
+	return Unit{
+		Code:      code,
+		Label:     label,
+		Dimension: of.Dimension,
+		Factor:    amount * of.Factor,
+	}
+}
+
+// Resolve looks a unit up in the product's custom units first, then the catalog
+func Resolve(code string, custom []Unit) (Unit, bool) {
+	for _, u := range custom {
+		if u.Code == code {
+			return u, true
+		}
+	}
+	return Get(code)
+}
+
+// Convert converts qty from one unit into another of the same dimension.
+// The result is rounded to 3 decimals to match the database precision.
+func Convert(qty float64, from, to Unit) (float64, error) {
+	if from.Dimension != to.Dimension {
+		return 0, fmt.Errorf("cannot convert %s to %s", from.Code, to.Code)
+	}
+	return math.Round(qty*from.Factor/to.Factor*1000) / 1000, nil
+}
+
+// ToProductUnit validates a sale entered in saleUnit and converts it to the product's unit
+func ToProductUnit(qty float64, saleUnit, productUnit Unit) (float64, error) {
+	if qty <= 0 {
+		return 0, fmt.Errorf("quantity must be greater than 0")
+	}
+	if !saleUnit.Fractional && qty != math.Trunc(qty) {
+		return 0, fmt.Errorf("quantity in %s must be a whole number", saleUnit.Code)
+	}
+
+	converted, err := Convert(qty, saleUnit, productUnit)
+	if err != nil {
+		return 0, err
+	}
+	if converted <= 0 {
+		return 0, fmt.Errorf("quantity is too small for %s", productUnit.Code)
+	}
+	return converted, nil
+}
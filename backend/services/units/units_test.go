@@ -0,0 +1,83 @@
+package units
+
+import "testing"
+
+func mustGet(t *testing.T, code string) Unit {
+	t.Helper()
+	u, ok := Get(code)
+	if !ok {
+		t.Fatalf("unit %q missing from the catalog", code)
+	}
+	return u
+}
+
+func TestConvert(t *testing.T) {
+	karung := Custom("karung", "Karung (50 kg)", 50, mustGet(t, "kg"))
+	tests := []struct {
+		qty      float64
+		from, to Unit
+		want     float64
+	}{
+		{2, mustGet(t, "lusin"), mustGet(t, "pcs"), 24},
+		{30, mustGet(t, "pcs"), mustGet(t, "kodi"), 1.5},
+		{250, mustGet(t, "gram"), mustGet(t, "kg"), 0.25},
+		{1, mustGet(t, "gram"), mustGet(t, "ons"), 0.01},
+		{1, mustGet(t, "pcs"), mustGet(t, "lusin"), 0.083}, // Rounded to 3 decimals
+		{1.5, mustGet(t, "liter"), mustGet(t, "ml"), 1500},
+		{3, karung, mustGet(t, "kuintal"), 1.5},
+		{0.2, mustGet(t, "ton"), karung, 4},
+	}
+	for _, tt := range tests {
+		got, err := Convert(tt.qty, tt.from, tt.to)
+		if err != nil || got != tt.want {
+			t.Errorf("Convert(%g %s to %s) = %g, %v; want %g", tt.qty, tt.from.Code, tt.to.Code, got, err, tt.want)
+		}
+	}
+
+	if _, err := Convert(1, mustGet(t, "kg"), mustGet(t, "liter")); err == nil {
+		t.Error("converting mass to volume should fail")
+	}
+}
+
+func TestToProductUnit(t *testing.T) {
+	tests := []struct {
+		name          string
+		qty           float64
+		sale, product string
+		want          float64
+		wantErr       bool
+	}{
+		{name: "same unit", qty: 3, sale: "pcs", product: "pcs", want: 3},
+		{name: "larger sale unit", qty: 2, sale: "kg", product: "gram", want: 2000},
+		{name: "fraction of a fractional unit", qty: 0.5, sale: "kg", product: "kg", want: 0.5},
+		{name: "fraction of a whole unit", qty: 1.5, sale: "pcs", product: "pcs", wantErr: true},
+		{name: "zero", qty: 0, sale: "pcs", product: "pcs", wantErr: true},
+		{name: "negative", qty: -2, sale: "kg", product: "kg", wantErr: true},
+		{name: "other dimension", qty: 1, sale: "liter", product: "kg", wantErr: true},
+		{name: "rounds to nothing", qty: 0.1, sale: "gram", product: "ton", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToProductUnit(tt.qty, mustGet(t, tt.sale), mustGet(t, tt.product))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %g", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ToProductUnit = %g, %v; want %g", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePrefersCustomUnits(t *testing.T) {
+	custom := []Unit{Custom("pcs", "Pack (10 pcs)", 10, mustGet(t, "pcs"))}
+	if u, ok := Resolve("pcs", custom); !ok || u.Factor != 10 {
+		t.Errorf("Resolve = %+v, %v", u, ok)
+	}
+	if _, ok := Resolve("karung", nil); ok {
+		t.Error("unknown unit resolved")
+	}
+}
@@ -0,0 +1,82 @@
+package woocommerce
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds credential checks against a store
+const DefaultTimeout = 10 * time.Second
+
+// ErrUnauthorized means the store rejected the API credentials
+var ErrUnauthorized = errors.New("woocommerce rejected the API credentials")
+
+// IsAuthFailure reports whether a WooCommerce REST response status means the
+// credentials are invalid or were revoked
+func IsAuthFailure(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// BasicAuth returns the Authorization header value for REST API keys
+func BasicAuth(consumerKey, consumerSecret string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(consumerKey+":"+consumerSecret))
+}
+
+// VerifyCredentials checks API keys by reading the store's system status.
+// Rejected keys return ErrUnauthorized; an unreachable store returns another error.
+func VerifyCredentials(ctx context.Context, storeURL, consumerKey, consumerSecret string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(storeURL, "/")+"/wp-json/wc/v3/system_status", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", BasicAuth(consumerKey, consumerSecret))
+
+	resp, err := (&http.Client{Timeout: DefaultTimeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("reach store: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if IsAuthFailure(resp.StatusCode) {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("store returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// AuthorizeURL returns the store's app-authorization page. After the merchant
+// approves, WooCommerce generates a new key pair and POSTs it to callbackURL
+// with user_id set to the given value, then sends the browser to returnURL.
+func AuthorizeURL(storeURL, appName, userID, returnURL, callbackURL string) string {
+	q := url.Values{
+		"app_name":     {appName},
+		"scope":        {"read"},
+		"user_id":      {userID},
+		"return_url":   {returnURL},
+		"callback_url": {callbackURL},
+	}
+	return strings.TrimRight(storeURL, "/") + "/wc-auth/v1/authorize?" + q.Encode()
+}
+
+// KeysPageURL returns the admin page where a merchant creates API keys by hand
+func KeysPageURL(storeURL string) string {
+	return strings.TrimRight(storeURL, "/") + "/wp-admin/admin.php?page=wc-settings&tab=advanced&section=keys&create-key=1"
+}
+
+// KeySuffix returns the last characters of a key, safe to show and log
+func KeySuffix(key string) string {
+	if len(key) <= 4 {
+		return key
+	}
+	return key[len(key)-4:]
+}
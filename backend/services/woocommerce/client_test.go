@@ -0,0 +1,44 @@
+package woocommerce
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestVerifyCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-json/wc/v3/system_status" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != BasicAuth("ck_new", "cs_new") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	if err := VerifyCredentials(context.Background(), srv.URL+"/", "ck_new", "cs_new"); err != nil {
+		t.Errorf("valid credentials: %v", err)
+	}
+	if err := VerifyCredentials(context.Background(), srv.URL, "ck_old", "cs_old"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("revoked credentials: got %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestAuthorizeURL(t *testing.T) {
+	raw := AuthorizeURL("https://toko.example.com/", "Bantuaku", "tok123", "https://app.example.com/integrations", "https://api.example.com/cb")
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if u.Path != "/wc-auth/v1/authorize" || q.Get("user_id") != "tok123" || q.Get("scope") != "read" || q.Get("callback_url") != "https://api.example.com/cb" {
+		t.Errorf("unexpected authorize URL %s", raw)
+	}
+}
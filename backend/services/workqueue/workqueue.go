@@ -0,0 +1,149 @@
+package workqueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrFull is returned when every worker is busy and the queue is at its depth
+	ErrFull = errors.New("work queue is full")
+	// ErrTimeout is returned when a queued request waited longer than allowed
+	ErrTimeout = errors.New("timed out waiting in work queue")
+)
+
+// Ticket describes how a request got its slot
+type Ticket struct {
+	Position int           // Requests ahead on arrival, counting itself; 0 when it ran immediately
+	Waited   time.Duration // Time spent queued
+}
+
+// Stats is a snapshot of the queue
+type Stats struct {
+	Workers   int   `json:"workers"`
+	Depth     int   `json:"depth"`
+	Active    int   `json:"active"`
+	Waiting   int   `json:"waiting"`
+	Completed int64 `json:"completed"`
+	Rejected  int64 `json:"rejected"`
+	TimedOut  int64 `json:"timed_out"`
+}
+
+// Queue runs at most workers requests at once and holds up to depth more in
+// FIFO order, so slow providers build a bounded backlog instead of piling up
+// requests until server write timeouts fire
+type Queue struct {
+	mu      sync.Mutex
+	workers int
+	depth   int
+	active  int
+	waiting []chan struct{}
+
+	completed, rejected, timedOut int64
+}
+
+// New creates a queue; workers is at least 1 and depth at least 0
+func New(workers, depth int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	if depth < 0 {
+		depth = 0
+	}
+	return &Queue{workers: workers, depth: depth}
+}
+
+// Acquire waits up to maxWait for a worker slot. On success release must be
+// called when the work is done.
+func (q *Queue) Acquire(ctx context.Context, maxWait time.Duration) (Ticket, func(), error) {
+	q.mu.Lock()
+	if q.active < q.workers && len(q.waiting) == 0 {
+		q.active++
+		q.mu.Unlock()
+		return Ticket{}, q.releaseFunc(), nil
+	}
+	if len(q.waiting) >= q.depth {
+		q.rejected++
+		q.mu.Unlock()
+		return Ticket{}, nil, ErrFull
+	}
+	granted := make(chan struct{})
+	q.waiting = append(q.waiting, granted)
+	ticket := Ticket{Position: len(q.waiting)}
+	q.mu.Unlock()
+
+	start := time.Now()
+	timer := time.NewTimer(maxWait)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-granted:
+		ticket.Waited = time.Since(start)
+		return ticket, q.releaseFunc(), nil
+	case <-timer.C:
+		err = ErrTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	q.mu.Lock()
+	for i, ch := range q.waiting {
+		if ch == granted {
+			q.waiting = append(q.waiting[:i], q.waiting[i+1:]...)
+			if err == ErrTimeout {
+				q.timedOut++
+			}
+			q.mu.Unlock()
+			return ticket, nil, err
+		}
+	}
+	// The slot was handed over while giving up; pass it on
+	q.handOff()
+	q.mu.Unlock()
+	return ticket, nil, err
+}
+
+// releaseFunc returns an idempotent release of one worker slot
+func (q *Queue) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(q.release)
+	}
+}
+
+// release marks work done and hands its slot on
+func (q *Queue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.completed++
+	q.handOff()
+}
+
+// handOff gives a slot to the oldest waiter, or frees it; q.mu must be held
+func (q *Queue) handOff() {
+	if len(q.waiting) > 0 {
+		next := q.waiting[0]
+		q.waiting = q.waiting[1:]
+		close(next)
+		return
+	}
+	q.active--
+}
+
+// Stats returns a snapshot of the queue
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return Stats{
+		Workers:   q.workers,
+		Depth:     q.depth,
+		Active:    q.active,
+		Waiting:   len(q.waiting),
+		Completed: q.completed,
+		Rejected:  q.rejected,
+		TimedOut:  q.timedOut,
+	}
+}
@@ -0,0 +1,67 @@
+package workqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueueFIFO(t *testing.T) {
+	q := New(1, 2)
+	ctx := context.Background()
+
+	ticket, release, err := q.Acquire(ctx, time.Second)
+	if err != nil || ticket.Position != 0 {
+		t.Fatalf("first acquire = %+v, %v", ticket, err)
+	}
+
+	type result struct {
+		ticket  Ticket
+		release func()
+		err     error
+	}
+	waiters := make(chan result, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			ticket, release, err := q.Acquire(ctx, time.Second)
+			waiters <- result{ticket, release, err}
+		}()
+		for q.Stats().Waiting != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if _, _, err := q.Acquire(ctx, time.Second); err != ErrFull {
+		t.Fatalf("acquire beyond depth = %v, want ErrFull", err)
+	}
+
+	release()
+	first := <-waiters
+	if first.err != nil || first.ticket.Position != 1 {
+		t.Fatalf("first waiter = %+v", first)
+	}
+	first.release()
+	second := <-waiters
+	if second.err != nil || second.ticket.Position != 2 {
+		t.Fatalf("second waiter = %+v", second)
+	}
+	second.release()
+
+	stats := q.Stats()
+	if stats.Active != 0 || stats.Waiting != 0 || stats.Completed != 3 || stats.Rejected != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestQueueTimeout(t *testing.T) {
+	q := New(1, 1)
+	_, release, _ := q.Acquire(context.Background(), time.Second)
+	defer release()
+
+	if _, _, err := q.Acquire(context.Background(), 10*time.Millisecond); err != ErrTimeout {
+		t.Fatalf("err = %v, want ErrTimeout", err)
+	}
+	if stats := q.Stats(); stats.Waiting != 0 || stats.TimedOut != 1 {
+		t.Errorf("stats after timeout = %+v", stats)
+	}
+}
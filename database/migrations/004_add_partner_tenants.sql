@@ -0,0 +1,38 @@
+-- Bantuaku - Partner (White-label) Tenants
+-- Migration 004: Partner layer for incubators that manage many companies
+-- PostgreSQL 18
+
+-- Platform-level role for each user
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) DEFAULT 'user';  -- 'user', 'admin', 'super_admin'
+
+-- Partners (incubators, cooperatives, agencies)
+CREATE TABLE IF NOT EXISTS partners (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(100) UNIQUE NOT NULL,
+    logo_url VARCHAR(500),
+    primary_color VARCHAR(7),  -- Hex color, e.g. '#7C3AED'
+    secondary_color VARCHAR(7),
+    email_sender_name VARCHAR(255),
+    email_sender_address VARCHAR(255),
+    status VARCHAR(20) DEFAULT 'active',  -- 'active', 'suspended'
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Partner Members (users who administer a partner)
+CREATE TABLE IF NOT EXISTS partner_members (
+    partner_id VARCHAR(36) NOT NULL REFERENCES partners(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) DEFAULT 'admin',  -- 'owner', 'admin'
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (partner_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_partner_members_user_id ON partner_members(user_id);
+
+-- Companies can belong to a partner cohort
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ DEFAULT NOW();
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS partner_id VARCHAR(36) REFERENCES partners(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_companies_partner_id ON companies(partner_id);
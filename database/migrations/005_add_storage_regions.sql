@@ -0,0 +1,11 @@
+-- Bantuaku - Regional Data Residency for File Storage
+-- Migration 005: Record the storage region of every stored file
+-- PostgreSQL 18
+
+-- Region the file physically lives in (e.g. 'id-jkt')
+ALTER TABLE file_uploads ADD COLUMN IF NOT EXISTS storage_region VARCHAR(20);
+
+-- Partners may pin their cohort's files to a specific region
+ALTER TABLE partners ADD COLUMN IF NOT EXISTS storage_region VARCHAR(20);
+
+CREATE INDEX IF NOT EXISTS idx_file_uploads_storage_region ON file_uploads(storage_region);
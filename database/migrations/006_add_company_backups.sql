@@ -0,0 +1,21 @@
+-- Bantuaku - Company Data Snapshots
+-- Migration 006: Versioned company backups stored in object storage
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS company_backups (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    snapshot_version INT NOT NULL,  -- Snapshot format version
+    storage_path VARCHAR(500) NOT NULL,
+    storage_region VARCHAR(20),
+    size_bytes BIGINT NOT NULL,
+    product_count INT DEFAULT 0,
+    sales_count INT DEFAULT 0,
+    conversation_count INT DEFAULT 0,
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    restored_at TIMESTAMPTZ,
+    restored_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_company_backups_company_id ON company_backups(company_id, created_at DESC);
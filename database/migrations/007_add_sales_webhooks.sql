@@ -0,0 +1,25 @@
+-- Bantuaku - POS/QRIS Sales Webhooks
+-- Migration 007: Inbound sales webhooks backed by data_sources, with dedupe and ingestion stats
+-- PostgreSQL 18
+
+-- Per-source signing secret for inbound webhooks (type = 'pos_webhook')
+ALTER TABLE data_sources ADD COLUMN IF NOT EXISTS webhook_secret VARCHAR(128);
+
+-- Link pushed sales lines back to the provider transaction
+ALTER TABLE sales_history ADD COLUMN IF NOT EXISTS external_id VARCHAR(128);
+CREATE INDEX IF NOT EXISTS idx_sales_history_external_id ON sales_history(data_source_id, external_id);
+
+-- Every webhook delivery, used for dedupe and per-source ingestion stats
+CREATE TABLE IF NOT EXISTS sales_webhook_events (
+    id BIGSERIAL PRIMARY KEY,
+    data_source_id VARCHAR(36) NOT NULL REFERENCES data_sources(id) ON DELETE CASCADE,
+    external_id VARCHAR(128),
+    status VARCHAR(20) NOT NULL,  -- 'accepted', 'duplicate', 'rejected'
+    item_count INT DEFAULT 0,
+    error TEXT,
+    received_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sales_webhook_events_accepted
+    ON sales_webhook_events(data_source_id, external_id) WHERE status = 'accepted';
+CREATE INDEX IF NOT EXISTS idx_sales_webhook_events_received_at ON sales_webhook_events(data_source_id, received_at DESC);
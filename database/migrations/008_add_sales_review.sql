@@ -0,0 +1,11 @@
+-- Bantuaku - Sales Anomaly Review
+-- Migration 008: Track user review of anomalous sales records
+-- PostgreSQL 18
+
+ALTER TABLE sales_history ADD COLUMN IF NOT EXISTS excluded BOOLEAN DEFAULT false;
+ALTER TABLE sales_history ADD COLUMN IF NOT EXISTS review_status VARCHAR(20);  -- 'confirmed', 'corrected', 'excluded'
+ALTER TABLE sales_history ADD COLUMN IF NOT EXISTS original_quantity INT;      -- Quantity before correction
+ALTER TABLE sales_history ADD COLUMN IF NOT EXISTS reviewed_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE sales_history ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_sales_history_excluded ON sales_history(company_id) WHERE excluded;
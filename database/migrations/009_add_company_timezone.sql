@@ -0,0 +1,5 @@
+-- Bantuaku - Company Time Zone
+-- Migration 009: Per-company time zone for sales dates and reporting
+-- PostgreSQL 18
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Jakarta';
@@ -0,0 +1,22 @@
+-- Bantuaku - Units of Measure
+-- Migration 010: Fractional sales quantities and per-product units
+-- PostgreSQL 18
+
+-- Quantities are stored in the product's unit, which may be fractional (e.g. 1.5 kg)
+ALTER TABLE sales_history ALTER COLUMN quantity TYPE NUMERIC(14, 3);
+ALTER TABLE sales_history ALTER COLUMN original_quantity TYPE NUMERIC(14, 3);
+ALTER TABLE sales_history ADD COLUMN IF NOT EXISTS unit VARCHAR(20);                -- Unit the sale was entered in
+ALTER TABLE sales_history ADD COLUMN IF NOT EXISTS unit_quantity NUMERIC(14, 3);    -- Quantity as entered
+
+UPDATE products SET unit = 'pcs' WHERE unit IS NULL OR unit = '';
+ALTER TABLE products ALTER COLUMN unit SET DEFAULT 'pcs';
+
+-- Product-specific units defined relative to the product unit (e.g. 1 karung = 50 kg)
+CREATE TABLE IF NOT EXISTS product_units (
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    code VARCHAR(20) NOT NULL,
+    label VARCHAR(100) NOT NULL,
+    amount NUMERIC(14, 4) NOT NULL,  -- Product units per one of this unit
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (product_id, code)
+);
@@ -0,0 +1,33 @@
+-- Bantuaku - Monthly Strategy Plans
+-- Migration 011: Monthly action plans with a task checklist
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS strategy_plans (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    month DATE NOT NULL,  -- First day of the plan month
+    summary TEXT,
+    generated_by VARCHAR(20) NOT NULL DEFAULT 'rules',  -- 'ai', 'rules'
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (company_id, month)
+);
+
+CREATE TABLE IF NOT EXISTS strategy_tasks (
+    id VARCHAR(36) PRIMARY KEY,
+    plan_id VARCHAR(36) NOT NULL REFERENCES strategy_plans(id) ON DELETE CASCADE,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    category VARCHAR(32) NOT NULL,  -- 'sales', 'marketing', 'operations', 'finance', 'data'
+    effort VARCHAR(10) NOT NULL,    -- 'low', 'medium', 'high'
+    impact VARCHAR(10) NOT NULL,    -- 'low', 'medium', 'high'
+    status VARCHAR(10) NOT NULL DEFAULT 'todo',  -- 'todo', 'done'
+    sort_order INT DEFAULT 0,
+    completed_at TIMESTAMPTZ,
+    completed_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_strategy_tasks_plan_id ON strategy_tasks(plan_id, sort_order);
+CREATE INDEX IF NOT EXISTS idx_strategy_tasks_open ON strategy_tasks(company_id, impact) WHERE status = 'todo';
@@ -0,0 +1,51 @@
+-- Bantuaku - Insight Outcomes
+-- Migration 012: Adopted recommendations, follow-up actions and outcome metrics
+-- PostgreSQL 18
+
+-- A recommendation from an insight that the company decided to follow
+CREATE TABLE IF NOT EXISTS insight_adoptions (
+    id VARCHAR(36) PRIMARY KEY,
+    insight_id VARCHAR(36) NOT NULL REFERENCES insights(id) ON DELETE CASCADE,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    recommendation TEXT NOT NULL,
+    product_id VARCHAR(36) REFERENCES products(id) ON DELETE SET NULL,  -- Limits sales comparison to one product
+    baseline_days INT NOT NULL DEFAULT 30,
+    notes TEXT,
+    adopted_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    adopted_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_insight_adoptions_insight_id ON insight_adoptions(insight_id);
+
+-- Campaigns, price changes and other actions taken to follow an adopted recommendation
+CREATE TABLE IF NOT EXISTS insight_actions (
+    id VARCHAR(36) PRIMARY KEY,
+    adoption_id VARCHAR(36) NOT NULL REFERENCES insight_adoptions(id) ON DELETE CASCADE,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    action_type VARCHAR(20) NOT NULL,  -- 'campaign', 'price_change', 'other'
+    description TEXT NOT NULL,
+    product_id VARCHAR(36) REFERENCES products(id) ON DELETE SET NULL,
+    old_price NUMERIC(12, 2),
+    new_price NUMERIC(12, 2),
+    channel VARCHAR(50),
+    started_on DATE NOT NULL,
+    ended_on DATE,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_insight_actions_adoption_id ON insight_actions(adoption_id);
+
+-- Metrics reported after the fact (e.g. followers, leads) next to the computed sales impact
+CREATE TABLE IF NOT EXISTS insight_outcomes (
+    id VARCHAR(36) PRIMARY KEY,
+    adoption_id VARCHAR(36) NOT NULL REFERENCES insight_adoptions(id) ON DELETE CASCADE,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    metric VARCHAR(100) NOT NULL,
+    baseline_value NUMERIC(14, 2),
+    value NUMERIC(14, 2) NOT NULL,
+    measured_on DATE NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_insight_outcomes_adoption_id ON insight_outcomes(adoption_id);
@@ -0,0 +1,19 @@
+-- Bantuaku - Notifications
+-- Migration 013: In-app notifications raised by scheduled analyses
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,      -- 'slow_mover', ...
+    title VARCHAR(255) NOT NULL,
+    message TEXT NOT NULL,
+    data JSONB,                     -- Type-specific payload, e.g. product id and suggested action
+    dedupe_key VARCHAR(255),        -- Prevents the same alert being raised twice
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_company_created ON notifications(company_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(company_id) WHERE read_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_dedupe ON notifications(company_id, dedupe_key) WHERE dedupe_key IS NOT NULL;
@@ -0,0 +1,26 @@
+-- Bantuaku - Business Health Score
+-- Migration 014: Daily health score history and forecast snapshots for accuracy tracking
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS business_health_scores (
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    score_date DATE NOT NULL,  -- Company-local day
+    score INT NOT NULL,
+    level VARCHAR(20) NOT NULL,  -- 'healthy', 'watch', 'at_risk', 'no_data'
+    factors JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (company_id, score_date)
+);
+
+-- One forecast per product per day, compared later against actual sales
+CREATE TABLE IF NOT EXISTS forecast_snapshots (
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    forecast_date DATE NOT NULL,
+    forecast_30d NUMERIC(14, 3) NOT NULL,
+    algorithm VARCHAR(50),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (product_id, forecast_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_forecast_snapshots_company_date ON forecast_snapshots(company_id, forecast_date);
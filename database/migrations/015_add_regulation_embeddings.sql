@@ -0,0 +1,49 @@
+-- Bantuaku - Regulation Embeddings
+-- Migration 015: Regulation documents, content-addressed embeddings and indexing job history
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS regulation_documents (
+    id VARCHAR(36) PRIMARY KEY,
+    source_url TEXT NOT NULL UNIQUE,
+    title VARCHAR(500) NOT NULL,
+    category VARCHAR(100),
+    content_hash CHAR(64) NOT NULL,  -- Hash of the whole document, used to skip unchanged documents
+    chunk_count INT NOT NULL DEFAULT 0,
+    indexed_at TIMESTAMPTZ DEFAULT NOW(),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Embeddings are keyed by content hash so identical chunks are embedded once
+CREATE TABLE IF NOT EXISTS embeddings (
+    content_hash CHAR(64) PRIMARY KEY,
+    model VARCHAR(100) NOT NULL,
+    embedding REAL[] NOT NULL,
+    token_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS regulation_chunks (
+    document_id VARCHAR(36) NOT NULL REFERENCES regulation_documents(id) ON DELETE CASCADE,
+    chunk_index INT NOT NULL,
+    content TEXT NOT NULL,
+    content_hash CHAR(64) NOT NULL,
+    PRIMARY KEY (document_id, chunk_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_regulation_chunks_hash ON regulation_chunks(content_hash);
+
+CREATE TABLE IF NOT EXISTS regulation_index_jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    status VARCHAR(20) NOT NULL,  -- 'completed', 'failed'
+    documents INT NOT NULL DEFAULT 0,
+    documents_unchanged INT NOT NULL DEFAULT 0,
+    chunks_total INT NOT NULL DEFAULT 0,
+    chunks_reused INT NOT NULL DEFAULT 0,
+    chunks_embedded INT NOT NULL DEFAULT 0,
+    tokens_spent INT NOT NULL DEFAULT 0,
+    tokens_saved INT NOT NULL DEFAULT 0,
+    orphans_removed INT NOT NULL DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
@@ -0,0 +1,22 @@
+-- Bantuaku - Embedding Namespaces
+-- Migration 016: Partition the vector store into namespaces (regulations, market_research, company:{id}:docs)
+-- PostgreSQL 18
+
+-- Chunks now belong to any source (regulation document, research article, company file)
+ALTER TABLE regulation_chunks RENAME TO embedding_chunks;
+ALTER TABLE embedding_chunks RENAME COLUMN document_id TO source_id;
+ALTER TABLE embedding_chunks DROP CONSTRAINT IF EXISTS regulation_chunks_document_id_fkey;
+ALTER TABLE embedding_chunks ADD COLUMN IF NOT EXISTS namespace VARCHAR(100) NOT NULL DEFAULT 'regulations';
+ALTER TABLE embedding_chunks DROP CONSTRAINT IF EXISTS regulation_chunks_pkey;
+ALTER TABLE embedding_chunks ADD PRIMARY KEY (namespace, source_id, chunk_index);
+
+DROP INDEX IF EXISTS idx_regulation_chunks_hash;
+CREATE INDEX IF NOT EXISTS idx_embedding_chunks_namespace_hash ON embedding_chunks(namespace, content_hash);
+
+-- Identical content is embedded once per namespace, so deleting a namespace never affects another
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS namespace VARCHAR(100) NOT NULL DEFAULT 'regulations';
+ALTER TABLE embeddings DROP CONSTRAINT IF EXISTS embeddings_pkey;
+ALTER TABLE embeddings ADD PRIMARY KEY (namespace, content_hash);
+
+ALTER TABLE embedding_chunks ALTER COLUMN namespace DROP DEFAULT;
+ALTER TABLE embeddings ALTER COLUMN namespace DROP DEFAULT;
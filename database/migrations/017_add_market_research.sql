@@ -0,0 +1,32 @@
+-- Bantuaku - Market Research Archive
+-- Migration 017: Persist web research results per company for reuse and semantic search
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS market_research_queries (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    query TEXT NOT NULL,
+    query_hash CHAR(64) NOT NULL,  -- Normalized query, used to reuse recent results
+    result_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_market_research_queries_lookup ON market_research_queries(company_id, query_hash, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS market_research_articles (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title VARCHAR(500) NOT NULL,
+    excerpt TEXT,
+    published_at TIMESTAMPTZ,
+    fetched_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (company_id, url)
+);
+
+CREATE TABLE IF NOT EXISTS market_research_query_articles (
+    query_id VARCHAR(36) NOT NULL REFERENCES market_research_queries(id) ON DELETE CASCADE,
+    article_id VARCHAR(36) NOT NULL REFERENCES market_research_articles(id) ON DELETE CASCADE,
+    rank INT NOT NULL,
+    PRIMARY KEY (query_id, article_id)
+);
@@ -0,0 +1,15 @@
+-- Bantuaku - Market Monitoring
+-- Migration 018: Periodic market research snapshots, diffed to detect market shifts
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS market_snapshots (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    query TEXT NOT NULL,
+    article_ids TEXT[] NOT NULL,
+    new_articles INT NOT NULL DEFAULT 0,
+    insight_id VARCHAR(36) REFERENCES insights(id) ON DELETE SET NULL,  -- Set when the snapshot produced a market shift insight
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_market_snapshots_company_created ON market_snapshots(company_id, created_at DESC);
@@ -0,0 +1,28 @@
+-- Bantuaku - Prediction Jobs
+-- Migration 019: Multi-step monthly business prediction runs and their per-step results
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS prediction_jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,  -- 'pending', 'running', 'completed', 'failed'
+    current_step VARCHAR(50),
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_prediction_jobs_company_created ON prediction_jobs(company_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS prediction_job_steps (
+    job_id VARCHAR(36) NOT NULL REFERENCES prediction_jobs(id) ON DELETE CASCADE,
+    step VARCHAR(50) NOT NULL,
+    position INT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    result JSONB,
+    error TEXT,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    PRIMARY KEY (job_id, step)
+);
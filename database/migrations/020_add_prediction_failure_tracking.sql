@@ -0,0 +1,10 @@
+-- Bantuaku - Prediction Failure Tracking
+-- Migration 020: Record which provider caused a failed prediction step, for admin failure analytics
+-- PostgreSQL 18
+
+ALTER TABLE prediction_job_steps ADD COLUMN IF NOT EXISTS error_provider VARCHAR(20);  -- 'kolosal', 'exa', 'network', 'internal'
+ALTER TABLE prediction_job_steps ADD COLUMN IF NOT EXISTS error_kind VARCHAR(20);      -- 'timeout', 'rate_limited', 'server_error', 'client_error', 'error'
+
+CREATE INDEX IF NOT EXISTS idx_prediction_jobs_status_created ON prediction_jobs(status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_prediction_job_steps_failed ON prediction_job_steps(status, finished_at DESC)
+    WHERE status IN ('failed', 'timed_out');
@@ -0,0 +1,5 @@
+-- Bantuaku - Assistant Preferences
+-- Migration 021: Per-company brand voice merged into AI prompts (pro plans)
+-- PostgreSQL 18
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS assistant_preferences JSONB;  -- tone, formality, greeting, taboo_topics
@@ -0,0 +1,17 @@
+-- Bantuaku - Chat Usage Analytics
+-- Migration 022: One row per assistant exchange, for owner and admin usage analytics
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS chat_usage_events (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id VARCHAR(36),
+    conversation_id VARCHAR(36),
+    question TEXT NOT NULL,
+    response_ms INT NOT NULL,
+    tools TEXT[],  -- Chat tools called while answering
+    failed BOOLEAN DEFAULT false,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_usage_events_company_created ON chat_usage_events(company_id, created_at DESC);
@@ -0,0 +1,19 @@
+-- Bantuaku - Message Archives
+-- Migration 023: Compressed archives of old messages in oversized conversations, plus a rolling summary
+-- PostgreSQL 18
+
+ALTER TABLE conversations ADD COLUMN IF NOT EXISTS summary TEXT;
+ALTER TABLE conversations ADD COLUMN IF NOT EXISTS archived_message_count INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS message_archives (
+    id VARCHAR(36) PRIMARY KEY,
+    conversation_id VARCHAR(36) NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    message_count INT NOT NULL,
+    first_message_at TIMESTAMPTZ NOT NULL,
+    last_message_at TIMESTAMPTZ NOT NULL,
+    payload BYTEA NOT NULL,  -- gzip-compressed JSON array of messages
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_archives_conversation ON message_archives(conversation_id, first_message_at);
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_created ON messages(conversation_id, created_at);
@@ -0,0 +1,17 @@
+-- Bantuaku - Rate Limit Profiles
+-- Migration 024: Admin overrides for rate limit profiles and per-plan throughput multipliers
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS rate_limit_profiles (
+    name VARCHAR(50) PRIMARY KEY,
+    requests INT NOT NULL CHECK (requests > 0),
+    window_seconds INT NOT NULL CHECK (window_seconds > 0),
+    burst INT NOT NULL DEFAULT 0 CHECK (burst >= 0),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS rate_limit_plan_multipliers (
+    plan VARCHAR(50) PRIMARY KEY,
+    multiplier NUMERIC(6,2) NOT NULL CHECK (multiplier > 0),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
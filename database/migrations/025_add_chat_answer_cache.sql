@@ -0,0 +1,17 @@
+-- Bantuaku - Chat Answer Cache
+-- Migration 025: Semantic cache of general chat answers, shared by companies with the same assistant voice
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS chat_answer_cache (
+    id VARCHAR(36) PRIMARY KEY,
+    scope VARCHAR(64) NOT NULL,  -- "default", or a hash of the brand voice the answer was written in
+    question TEXT NOT NULL,      -- Normalized question
+    answer TEXT NOT NULL,
+    model VARCHAR(100) NOT NULL, -- Embedding model of the vector
+    embedding REAL[] NOT NULL,
+    hits INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_answer_cache_scope ON chat_answer_cache(scope, expires_at);
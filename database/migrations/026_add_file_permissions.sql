@@ -0,0 +1,26 @@
+-- Bantuaku - File Permissions
+-- Migration 026: Per-file visibility, sharing with individual users, and a per-file access log
+-- PostgreSQL 18
+
+-- 'company' files are visible to everyone in the company, 'private' ones only to the uploader and shared users
+ALTER TABLE file_uploads ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'company';
+
+CREATE TABLE IF NOT EXISTS file_shares (
+    file_id VARCHAR(36) NOT NULL REFERENCES file_uploads(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    shared_by VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (file_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS file_access_log (
+    id BIGSERIAL PRIMARY KEY,
+    file_id VARCHAR(36) NOT NULL REFERENCES file_uploads(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL,
+    action VARCHAR(20) NOT NULL,  -- 'view', 'visibility', 'share', 'unshare'
+    detail TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_shares_user ON file_shares(user_id);
+CREATE INDEX IF NOT EXISTS idx_file_access_log_file ON file_access_log(file_id, created_at DESC);
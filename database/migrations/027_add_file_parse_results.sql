@@ -0,0 +1,12 @@
+-- Bantuaku - Document Parsing
+-- Migration 027: Text and tables extracted from uploaded PDF, XLSX, DOCX and CSV files
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS file_parse_results (
+    file_id VARCHAR(36) PRIMARY KEY REFERENCES file_uploads(id) ON DELETE CASCADE,
+    parser VARCHAR(20) NOT NULL,       -- 'pdftotext', 'ocr', 'xlsx', 'docx', 'csv'
+    text TEXT NOT NULL DEFAULT '',
+    tables JSONB NOT NULL DEFAULT '[]',
+    truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    parsed_at TIMESTAMPTZ DEFAULT NOW()
+);
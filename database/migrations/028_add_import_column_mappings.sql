@@ -0,0 +1,17 @@
+-- Bantuaku - Import Column Mappings
+-- Migration 028: Saved spreadsheet column mappings per import source, and the sales channel of imported rows
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS import_column_mappings (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    source VARCHAR(64) NOT NULL,       -- User-chosen name of the file's origin, e.g. 'kasir-toko-a'
+    mapping JSONB NOT NULL,            -- Canonical field -> column header
+    headers JSONB NOT NULL DEFAULT '[]', -- Header row the mapping was confirmed against
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (company_id, source)
+);
+
+ALTER TABLE sales_history ADD COLUMN IF NOT EXISTS channel VARCHAR(50);
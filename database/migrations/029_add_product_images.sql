@@ -0,0 +1,22 @@
+-- Bantuaku - Product Images
+-- Migration 029: Product photos with generated thumbnails, stored through the region-pinned file store
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS product_images (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    storage_path VARCHAR(500) NOT NULL,
+    thumbnail_path VARCHAR(500) NOT NULL,
+    storage_region VARCHAR(20),
+    content_type VARCHAR(50) NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_product ON product_images(product_id, created_at);
+-- At most one primary image per product; it is the one shown in product listings
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_images_primary ON product_images(product_id) WHERE is_primary;
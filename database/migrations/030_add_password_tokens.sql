@@ -0,0 +1,18 @@
+-- Bantuaku - Password Tokens
+-- Migration 030: Set-password invitations and admin-issued reset links
+-- PostgreSQL 18
+
+-- Users created by an admin without a password have no hash until they accept the invitation
+ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL;
+
+CREATE TABLE IF NOT EXISTS password_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY, -- SHA-256 of the emailed token; the token itself is never stored
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(20) NOT NULL CHECK (purpose IN ('invite', 'reset')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_tokens_user ON password_tokens(user_id, created_at DESC);
@@ -0,0 +1,40 @@
+-- Bantuaku - Single Sign-On
+-- Migration 031: OIDC identity providers per company or partner, and company membership for provisioned staff
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS sso_configs (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) UNIQUE REFERENCES companies(id) ON DELETE CASCADE,
+    partner_id VARCHAR(36) UNIQUE REFERENCES partners(id) ON DELETE CASCADE,
+    issuer VARCHAR(500) NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    client_secret TEXT NOT NULL,
+    email_domains TEXT[] NOT NULL DEFAULT '{}', -- Login emails routed to this provider
+    domain_claim VARCHAR(100) NOT NULL DEFAULT 'email_domain', -- Claim that picks a partner company
+    company_mapping JSONB NOT NULL DEFAULT '{}', -- Claim value -> company_id (partner providers)
+    default_role VARCHAR(20) NOT NULL DEFAULT 'member',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK ((company_id IS NULL) <> (partner_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_sso_configs_email_domains ON sso_configs USING GIN (email_domains);
+
+-- Users who belong to a company besides its owner
+CREATE TABLE IF NOT EXISTS company_members (
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member', -- 'admin', 'member'
+    source VARCHAR(20) NOT NULL DEFAULT 'manual', -- 'manual', 'sso'
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (company_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_company_members_user_id ON company_members(user_id);
+
+-- SSO-managed users sign in only through their identity provider
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sso_config_id VARCHAR(36) REFERENCES sso_configs(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sso_subject VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_sso_subject ON users(sso_config_id, sso_subject) WHERE sso_config_id IS NOT NULL;
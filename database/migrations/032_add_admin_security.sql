@@ -0,0 +1,29 @@
+-- Bantuaku - Admin Account Security
+-- Migration 032: IP allowlist and known-device tracking for admin and super_admin sessions
+-- PostgreSQL 18
+
+-- When any entry exists, admin sessions are only accepted from these ranges
+CREATE TABLE IF NOT EXISTS admin_ip_allowlist (
+    id VARCHAR(36) PRIMARY KEY,
+    cidr VARCHAR(50) UNIQUE NOT NULL,
+    description VARCHAR(255),
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS admin_devices (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_key VARCHAR(64) NOT NULL, -- SHA-256 of the client's X-Device-ID, or of its user agent
+    user_agent TEXT,
+    first_ip VARCHAR(45),
+    last_ip VARCHAR(45),
+    last_network VARCHAR(50), -- Coarse network of last_ip, for new-location alerts
+    first_seen_at TIMESTAMPTZ DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ,
+    revoked_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE (user_id, device_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_devices_user ON admin_devices(user_id, last_seen_at DESC);
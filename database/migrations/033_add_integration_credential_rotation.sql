@@ -0,0 +1,7 @@
+-- Bantuaku - Integration Credential Rotation
+-- Migration 033: Track credential rotations and authentication failures of integrations
+-- PostgreSQL 18
+
+-- status gains 'auth_failed': the platform rejected the stored credentials and syncing is paused
+ALTER TABLE integrations ADD COLUMN IF NOT EXISTS credentials_rotated_at TIMESTAMPTZ;
+ALTER TABLE integrations ADD COLUMN IF NOT EXISTS auth_failed_at TIMESTAMPTZ;
@@ -0,0 +1,16 @@
+-- Bantuaku - Integration Health
+-- Migration 034: Outcome of the latest runs of each integration, for the health dashboard
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS integration_health (
+    company_id VARCHAR(36) NOT NULL DEFAULT '', -- '' for platform-wide services such as email
+    service VARCHAR(50) NOT NULL, -- 'woocommerce', 'exa', 'email', ...
+    last_success_at TIMESTAMPTZ,
+    last_failure_at TIMESTAMPTZ,
+    last_error TEXT,
+    failure_streak INT NOT NULL DEFAULT 0, -- Consecutive failures since the last success
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (company_id, service)
+);
+
+CREATE INDEX IF NOT EXISTS idx_integration_health_failing ON integration_health(failure_streak) WHERE failure_streak > 0;
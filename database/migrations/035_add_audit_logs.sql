@@ -0,0 +1,29 @@
+-- Bantuaku - Admin Audit Log
+-- Migration 035: Audit entries for admin mutations, with encrypted request bodies for destructive ones
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id VARCHAR(36) PRIMARY KEY,
+    actor_user_id VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    actor_role VARCHAR(20) NOT NULL,
+    action VARCHAR(100) NOT NULL, -- e.g. "user.delete", "company.subscription.update"
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    resource_id VARCHAR(255),
+    status_code INT NOT NULL,
+    ip VARCHAR(45),
+    request_id VARCHAR(64),
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created ON audit_logs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action, created_at DESC);
+
+-- Redacted, AES-GCM encrypted request bodies, deleted after expires_at
+CREATE TABLE IF NOT EXISTS audit_payloads (
+    audit_log_id VARCHAR(36) PRIMARY KEY REFERENCES audit_logs(id) ON DELETE CASCADE,
+    ciphertext BYTEA NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_payloads_expires ON audit_payloads(expires_at);
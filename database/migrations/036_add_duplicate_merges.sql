@@ -0,0 +1,22 @@
+-- Bantuaku - Duplicate Detection
+-- Migration 036: Merged companies and products point at the record they were merged into
+-- PostgreSQL 18
+
+-- Merged records are kept (inactive) rather than deleted, so anything still
+-- referencing them keeps working and merges can be audited
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS merged_into VARCHAR(36) REFERENCES companies(id) ON DELETE SET NULL;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS merged_at TIMESTAMPTZ;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS merged_into VARCHAR(36) REFERENCES products(id) ON DELETE SET NULL;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS merged_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS duplicate_merges (
+    id VARCHAR(36) PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL, -- 'company' or 'product'
+    source_id VARCHAR(36) NOT NULL,
+    target_id VARCHAR(36) NOT NULL,
+    moved JSONB NOT NULL DEFAULT '{}', -- Rows moved per table
+    merged_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_duplicate_merges_created ON duplicate_merges(created_at DESC);
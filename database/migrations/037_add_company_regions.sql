@@ -0,0 +1,68 @@
+-- Bantuaku - Company Regions
+-- Migration 037: Indonesian administrative regions and company geo fields
+-- PostgreSQL 18
+
+-- Kemendagri region codes: "32" province, "32.73" regency/city, "32.73.01" district.
+-- Provinces are seeded here; regencies and districts are loaded from the
+-- official list with `go run . regions -file wilayah.csv`.
+CREATE TABLE IF NOT EXISTS regions (
+    code VARCHAR(8) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    level VARCHAR(10) NOT NULL, -- 'province', 'regency' or 'district'
+    parent_code VARCHAR(8) REFERENCES regions(code) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_regions_parent ON regions(parent_code);
+
+INSERT INTO regions (code, name, level) VALUES
+    ('11', 'Aceh', 'province'),
+    ('12', 'Sumatera Utara', 'province'),
+    ('13', 'Sumatera Barat', 'province'),
+    ('14', 'Riau', 'province'),
+    ('15', 'Jambi', 'province'),
+    ('16', 'Sumatera Selatan', 'province'),
+    ('17', 'Bengkulu', 'province'),
+    ('18', 'Lampung', 'province'),
+    ('19', 'Kepulauan Bangka Belitung', 'province'),
+    ('21', 'Kepulauan Riau', 'province'),
+    ('31', 'DKI Jakarta', 'province'),
+    ('32', 'Jawa Barat', 'province'),
+    ('33', 'Jawa Tengah', 'province'),
+    ('34', 'DI Yogyakarta', 'province'),
+    ('35', 'Jawa Timur', 'province'),
+    ('36', 'Banten', 'province'),
+    ('51', 'Bali', 'province'),
+    ('52', 'Nusa Tenggara Barat', 'province'),
+    ('53', 'Nusa Tenggara Timur', 'province'),
+    ('61', 'Kalimantan Barat', 'province'),
+    ('62', 'Kalimantan Tengah', 'province'),
+    ('63', 'Kalimantan Selatan', 'province'),
+    ('64', 'Kalimantan Timur', 'province'),
+    ('65', 'Kalimantan Utara', 'province'),
+    ('71', 'Sulawesi Utara', 'province'),
+    ('72', 'Sulawesi Tengah', 'province'),
+    ('73', 'Sulawesi Selatan', 'province'),
+    ('74', 'Sulawesi Tenggara', 'province'),
+    ('75', 'Gorontalo', 'province'),
+    ('76', 'Sulawesi Barat', 'province'),
+    ('81', 'Maluku', 'province'),
+    ('82', 'Maluku Utara', 'province'),
+    ('91', 'Papua', 'province'),
+    ('92', 'Papua Barat', 'province'),
+    ('93', 'Papua Selatan', 'province'),
+    ('94', 'Papua Tengah', 'province'),
+    ('95', 'Papua Pegunungan', 'province'),
+    ('96', 'Papua Barat Daya', 'province')
+ON CONFLICT (code) DO NOTHING;
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS province_code VARCHAR(8) REFERENCES regions(code);
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS regency_code VARCHAR(8) REFERENCES regions(code);
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS district_code VARCHAR(8) REFERENCES regions(code);
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS address TEXT;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS geocoded BOOLEAN NOT NULL DEFAULT FALSE; -- Coordinates came from the geocoder, not the user
+
+CREATE INDEX IF NOT EXISTS idx_companies_province ON companies(province_code);
+CREATE INDEX IF NOT EXISTS idx_companies_regency ON companies(regency_code);
+CREATE INDEX IF NOT EXISTS idx_companies_lat_lng ON companies(latitude, longitude) WHERE latitude IS NOT NULL;
@@ -0,0 +1,60 @@
+-- Bantuaku - Industry Taxonomy
+-- Migration 038: KBLI 2020 categories, company KBLI codes and mapping suggestions
+-- PostgreSQL 18
+
+-- Sections (A-U) are seeded here; the full list down to 5-digit codes is
+-- loaded with `go run . kbli -file kbli2020.csv`
+CREATE TABLE IF NOT EXISTS kbli_categories (
+    code VARCHAR(5) PRIMARY KEY,
+    title TEXT NOT NULL,
+    level VARCHAR(20) NOT NULL, -- 'kategori', 'golongan_pokok', 'golongan', 'subgolongan' or 'kelompok'
+    section CHAR(1) NOT NULL,
+    parent_code VARCHAR(5) REFERENCES kbli_categories(code) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_kbli_categories_level ON kbli_categories(level);
+
+INSERT INTO kbli_categories (code, title, level, section) VALUES
+    ('A', 'Pertanian, Kehutanan dan Perikanan', 'kategori', 'A'),
+    ('B', 'Pertambangan dan Penggalian', 'kategori', 'B'),
+    ('C', 'Industri Pengolahan', 'kategori', 'C'),
+    ('D', 'Pengadaan Listrik, Gas, Uap/Air Panas dan Udara Dingin', 'kategori', 'D'),
+    ('E', 'Treatment Air, Treatment Air Limbah, Treatment dan Pemulihan Material Sampah, dan Aktivitas Remediasi', 'kategori', 'E'),
+    ('F', 'Konstruksi', 'kategori', 'F'),
+    ('G', 'Perdagangan Besar dan Eceran; Reparasi dan Perawatan Mobil dan Sepeda Motor', 'kategori', 'G'),
+    ('H', 'Pengangkutan dan Pergudangan', 'kategori', 'H'),
+    ('I', 'Penyediaan Akomodasi dan Penyediaan Makan Minum', 'kategori', 'I'),
+    ('J', 'Informasi dan Komunikasi', 'kategori', 'J'),
+    ('K', 'Aktivitas Keuangan dan Asuransi', 'kategori', 'K'),
+    ('L', 'Real Estat', 'kategori', 'L'),
+    ('M', 'Aktivitas Profesional, Ilmiah dan Teknis', 'kategori', 'M'),
+    ('N', 'Aktivitas Penyewaan dan Sewa Guna Usaha Tanpa Hak Opsi, Ketenagakerjaan, Agen Perjalanan dan Penunjang Usaha Lainnya', 'kategori', 'N'),
+    ('O', 'Administrasi Pemerintahan, Pertahanan dan Jaminan Sosial Wajib', 'kategori', 'O'),
+    ('P', 'Pendidikan', 'kategori', 'P'),
+    ('Q', 'Aktivitas Kesehatan Manusia dan Aktivitas Sosial', 'kategori', 'Q'),
+    ('R', 'Kesenian, Hiburan dan Rekreasi', 'kategori', 'R'),
+    ('S', 'Aktivitas Jasa Lainnya', 'kategori', 'S'),
+    ('T', 'Aktivitas Rumah Tangga sebagai Pemberi Kerja; Aktivitas yang Menghasilkan Barang dan Jasa oleh Rumah Tangga yang Digunakan untuk Memenuhi Kebutuhan Sendiri', 'kategori', 'T'),
+    ('U', 'Aktivitas Badan Internasional dan Badan Ekstra Internasional Lainnya', 'kategori', 'U')
+ON CONFLICT (code) DO NOTHING;
+
+-- The free-text industry stays as the label; the code sits alongside it
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS industry_kbli VARCHAR(5) REFERENCES kbli_categories(code);
+CREATE INDEX IF NOT EXISTS idx_companies_industry_kbli ON companies(industry_kbli);
+
+-- Suggested codes for existing free-text industries, applied once confirmed
+CREATE TABLE IF NOT EXISTS kbli_suggestions (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    industry TEXT NOT NULL, -- The free text the suggestion was made for
+    kbli_code VARCHAR(5) NOT NULL REFERENCES kbli_categories(code) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    method VARCHAR(20) NOT NULL, -- 'embedding' or 'lexical'
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending', 'confirmed' or 'rejected'
+    decided_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    decided_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_kbli_suggestions_status ON kbli_suggestions(status, created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kbli_suggestions_pending ON kbli_suggestions(company_id) WHERE status = 'pending';
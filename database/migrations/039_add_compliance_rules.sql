@@ -0,0 +1,63 @@
+-- Bantuaku - Compliance Checklist
+-- Migration 039: Regulation applicability rules, company legal forms and checklist status
+-- PostgreSQL 18
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS legal_form VARCHAR(20); -- perorangan, ud, cv, firma, pt, pt_perorangan, koperasi
+
+-- A rule puts a requirement on the checklist of every company matching all of
+-- its non-empty constraints
+CREATE TABLE IF NOT EXISTS compliance_rules (
+    id VARCHAR(36) PRIMARY KEY,
+    requirement VARCHAR(30) NOT NULL, -- e.g. 'NIB', 'PIRT', 'HALAL', 'BPOM'
+    title VARCHAR(200) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    authority VARCHAR(200) NOT NULL DEFAULT '',
+    reference_url TEXT NOT NULL DEFAULT '',
+    kbli_prefixes TEXT[] NOT NULL DEFAULT '{}',
+    legal_forms TEXT[] NOT NULL DEFAULT '{}',
+    province_codes TEXT[] NOT NULL DEFAULT '{}',
+    priority INTEGER NOT NULL DEFAULT 50,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO compliance_rules (id, requirement, title, description, authority, reference_url, kbli_prefixes, legal_forms, priority) VALUES
+    ('nib', 'NIB', 'Nomor Induk Berusaha (NIB)',
+        'Identitas berusaha yang wajib dimiliki setiap pelaku usaha, sekaligus berlaku sebagai izin dasar untuk usaha risiko rendah.',
+        'Kementerian Investasi/BKPM melalui OSS RBA', 'https://oss.go.id', '{}', '{}', 1),
+    ('npwp', 'NPWP', 'Nomor Pokok Wajib Pajak (NPWP)',
+        'NPWP pribadi untuk usaha perorangan atau NPWP badan untuk badan usaha, dibutuhkan untuk NIB dan pelaporan pajak.',
+        'Direktorat Jenderal Pajak', 'https://www.pajak.go.id', '{}', '{}', 2),
+    ('ahu', 'AHU', 'Pengesahan atau Pendaftaran Badan Usaha',
+        'Akta pendirian dan pengesahan (PT, koperasi) atau pendaftaran (CV, firma) badan usaha.',
+        'Kementerian Hukum melalui AHU Online', 'https://ahu.go.id', '{}', '{pt,pt_perorangan,cv,firma,koperasi}', 3),
+    ('pirt', 'PIRT', 'Sertifikat Produksi Pangan Industri Rumah Tangga (SPP-IRT)',
+        'Izin edar pangan olahan produksi rumah tangga dengan risiko rendah.',
+        'Dinas Kesehatan Kabupaten/Kota melalui OSS', 'https://oss.go.id', '{10,11}', '{perorangan,ud,cv,pt_perorangan}', 20),
+    ('bpom-food', 'BPOM', 'Izin Edar BPOM',
+        'Izin edar (MD) untuk pangan olahan produksi industri, atau notifikasi untuk kosmetik dan obat.',
+        'Badan Pengawas Obat dan Makanan', 'https://www.pom.go.id', '{10,11}', '{pt,firma,koperasi}', 20),
+    ('bpom-cosmetics', 'BPOM', 'Izin Edar BPOM',
+        'Izin edar (MD) untuk pangan olahan produksi industri, atau notifikasi untuk kosmetik dan obat.',
+        'Badan Pengawas Obat dan Makanan', 'https://www.pom.go.id', '{2023,21}', '{}', 20),
+    ('slhs', 'SLHS', 'Sertifikat Laik Higiene Sanitasi (SLHS)',
+        'Sertifikat kelayakan higiene dan sanitasi untuk usaha penyediaan makanan dan minuman.',
+        'Dinas Kesehatan Kabupaten/Kota', '', '{56}', '{}', 25),
+    ('halal', 'HALAL', 'Sertifikat Halal',
+        'Wajib untuk makanan, minuman, kosmetik dan obat yang beredar di Indonesia. Usaha mikro dan kecil dapat memakai skema pernyataan mandiri (self declare).',
+        'BPJPH Kementerian Agama', 'https://halal.go.id', '{10,11,56,2023,21}', '{}', 30)
+ON CONFLICT (id) DO NOTHING;
+
+-- Progress on each requirement, kept even if the rule later stops applying
+CREATE TABLE IF NOT EXISTS company_compliance (
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    requirement VARCHAR(30) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'todo', -- 'todo', 'in_progress', 'done' or 'not_applicable'
+    reference_number VARCHAR(100), -- Permit or certificate number
+    expires_on DATE,
+    notes TEXT,
+    updated_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (company_id, requirement)
+);
@@ -0,0 +1,14 @@
+-- Bantuaku - Compliance Tracking
+-- Migration 040: Checklist statuses, permit documents and expiry reminders
+-- PostgreSQL 18
+
+-- Statuses follow the permit lifecycle: missing, in_progress, obtained (or not_applicable)
+UPDATE company_compliance SET status = 'missing' WHERE status = 'todo';
+UPDATE company_compliance SET status = 'obtained' WHERE status = 'done';
+ALTER TABLE company_compliance ALTER COLUMN status SET DEFAULT 'missing';
+
+-- The permit or certificate itself, uploaded through the file upload system
+ALTER TABLE company_compliance ADD COLUMN IF NOT EXISTS file_upload_id VARCHAR(36) REFERENCES file_uploads(id) ON DELETE SET NULL;
+ALTER TABLE company_compliance ADD COLUMN IF NOT EXISTS obtained_on DATE;
+
+CREATE INDEX IF NOT EXISTS idx_company_compliance_expiry ON company_compliance(expires_on) WHERE status = 'obtained';
@@ -0,0 +1,39 @@
+-- Bantuaku - Knowledge Base
+-- Migration 041: Curated articles and their edit history
+-- PostgreSQL 18
+
+-- Published articles are chunked into the 'knowledge_base' embeddings
+-- namespace with the article id as source_id
+CREATE TABLE IF NOT EXISTS kb_articles (
+    id VARCHAR(36) PRIMARY KEY,
+    slug VARCHAR(80) NOT NULL UNIQUE,
+    title VARCHAR(200) NOT NULL,
+    category VARCHAR(20) NOT NULL, -- perizinan, pemasaran, keuangan, operasional, umum
+    summary TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft', -- draft, published, archived
+    pinned BOOLEAN NOT NULL DEFAULT FALSE, -- Cited ahead of scraped sources
+    version INTEGER NOT NULL DEFAULT 1,
+    chunk_count INTEGER NOT NULL DEFAULT 0,
+    indexed_version INTEGER, -- Version whose content is embedded, NULL when not indexed
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    updated_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    published_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_articles_browse ON kb_articles(status, category, pinned DESC, updated_at DESC);
+
+-- Every saved revision of an article's content, including the current one
+CREATE TABLE IF NOT EXISTS kb_article_versions (
+    article_id VARCHAR(36) NOT NULL REFERENCES kb_articles(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    category VARCHAR(20) NOT NULL,
+    summary TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    edited_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (article_id, version)
+);
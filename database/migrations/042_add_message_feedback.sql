@@ -0,0 +1,39 @@
+-- Bantuaku - Answer Feedback
+-- Migration 042: Ratings on assistant answers and regenerated alternatives
+-- PostgreSQL 18
+
+-- Neither table references messages: feedback and the evaluation examples it
+-- produces are kept when old messages are archived
+CREATE TABLE IF NOT EXISTS message_feedback (
+    message_id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    rating VARCHAR(10) NOT NULL, -- 'up' or 'down'
+    reason VARCHAR(20), -- incorrect, incomplete, irrelevant, other
+    comment TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_feedback_company_created ON message_feedback(company_id, created_at DESC);
+
+-- Each alternative answer generated for a disliked message, with the variant
+-- (retrieval and model parameters) that produced it. The first regeneration
+-- also records the original answer as variant 'original', so the accepted row
+-- pairs with it as an evaluation example.
+CREATE TABLE IF NOT EXISTS message_regenerations (
+    id VARCHAR(36) PRIMARY KEY,
+    message_id VARCHAR(36) NOT NULL,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    question TEXT NOT NULL, -- The user message the answer replied to
+    variant VARCHAR(30) NOT NULL,
+    model VARCHAR(100) NOT NULL,
+    context_chunks INTEGER NOT NULL DEFAULT 0,
+    content TEXT NOT NULL,
+    accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    accepted_at TIMESTAMPTZ,
+    UNIQUE (message_id, variant)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_regenerations_accepted ON message_regenerations(accepted_at DESC) WHERE accepted;
@@ -0,0 +1,67 @@
+-- Bantuaku - RAG Evaluation
+-- Migration 043: Curated evaluation questions and the scored runs against them
+-- PostgreSQL 18
+
+-- expected_sources are regulation source URLs or knowledge-base slugs
+CREATE TABLE IF NOT EXISTS rag_eval_cases (
+    id VARCHAR(36) PRIMARY KEY,
+    question TEXT NOT NULL,
+    namespace VARCHAR(50), -- 'regulations' or 'knowledge_base'; NULL searches both
+    expected_sources TEXT[] NOT NULL DEFAULT '{}',
+    expected_answer TEXT NOT NULL DEFAULT '', -- Key points a good answer mentions
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Metrics are averages over the cases they apply to; NULL when none did
+CREATE TABLE IF NOT EXISTS rag_eval_runs (
+    id VARCHAR(36) PRIMARY KEY,
+    label VARCHAR(100) NOT NULL DEFAULT '',
+    embedding_model VARCHAR(100) NOT NULL,
+    chat_model VARCHAR(100), -- NULL when answers were not generated
+    top_k INTEGER NOT NULL,
+    cases INTEGER NOT NULL DEFAULT 0,
+    source_precision DOUBLE PRECISION,
+    source_recall DOUBLE PRECISION,
+    mrr DOUBLE PRECISION,
+    faithfulness DOUBLE PRECISION,
+    answer_coverage DOUBLE PRECISION,
+    status VARCHAR(20) NOT NULL DEFAULT 'running', -- running, completed, failed
+    error TEXT,
+    started_at TIMESTAMPTZ DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_rag_eval_runs_started ON rag_eval_runs(started_at DESC);
+
+-- Results keep the question so they remain readable after a case is edited or deleted
+CREATE TABLE IF NOT EXISTS rag_eval_results (
+    run_id VARCHAR(36) NOT NULL REFERENCES rag_eval_runs(id) ON DELETE CASCADE,
+    case_id VARCHAR(36) NOT NULL,
+    question TEXT NOT NULL,
+    retrieved TEXT[] NOT NULL DEFAULT '{}',
+    answer TEXT,
+    source_precision DOUBLE PRECISION,
+    source_recall DOUBLE PRECISION,
+    reciprocal_rank DOUBLE PRECISION,
+    faithfulness DOUBLE PRECISION,
+    answer_coverage DOUBLE PRECISION,
+    PRIMARY KEY (run_id, case_id)
+);
+
+-- Starter questions with expected key points; add expected_sources once the
+-- matching regulations or articles are indexed
+INSERT INTO rag_eval_cases (id, question, expected_answer, tags) VALUES
+    ('seed-nib', 'Bagaimana cara mendapatkan NIB untuk usaha mikro?',
+        'NIB diurus melalui OSS RBA secara online dan gratis, membutuhkan NIK dan NPWP, dan berlaku sebagai izin usaha risiko rendah.', '{perizinan}'),
+    ('seed-pirt', 'Apa syarat mengurus SPP-IRT untuk makanan rumahan?',
+        'SPP-IRT diajukan lewat OSS untuk pangan olahan risiko rendah produksi rumah tangga, dengan penyuluhan keamanan pangan dan pemeriksaan sarana produksi oleh Dinas Kesehatan.', '{perizinan,pangan}'),
+    ('seed-halal', 'Apakah usaha kecil bisa mendapat sertifikat halal gratis?',
+        'Usaha mikro dan kecil dapat memakai skema self declare melalui BPJPH dengan pendamping proses produk halal, termasuk program sertifikasi halal gratis.', '{perizinan,halal}'),
+    ('seed-pajak', 'Berapa tarif pajak penghasilan final untuk UMKM?',
+        'PPh final UMKM sebesar 0,5 persen dari omzet bruto sesuai PP 55 tahun 2022, dengan omzet sampai 500 juta per tahun tidak dikenai pajak untuk wajib pajak orang pribadi.', '{keuangan,pajak}'),
+    ('seed-promosi', 'Bagaimana strategi promosi produk UMKM di marketplace?',
+        'Optimalkan foto dan deskripsi produk, gunakan kata kunci, ikuti kampanye dan voucher marketplace, kumpulkan ulasan pembeli, dan manfaatkan iklan berbayar secara terukur.', '{pemasaran}')
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,21 @@
+-- Bantuaku - Provider Call Log
+-- Migration 044: Sampled, redacted Kolosal chat/embedding calls kept for replay
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS provider_calls (
+    id VARCHAR(36) PRIMARY KEY,
+    provider VARCHAR(50) NOT NULL DEFAULT 'kolosal',
+    endpoint VARCHAR(100) NOT NULL,
+    company_id VARCHAR(36) REFERENCES companies(id) ON DELETE CASCADE,
+    request JSONB NOT NULL,
+    response JSONB,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_provider_calls_created_at ON provider_calls(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_provider_calls_company_id ON provider_calls(company_id);
+CREATE INDEX IF NOT EXISTS idx_provider_calls_expires_at ON provider_calls(expires_at);
@@ -0,0 +1,11 @@
+-- Bantuaku - OCR Quality
+-- Migration 045: Record which OCR engine produced a file's text and how confident it was
+-- PostgreSQL 18
+
+-- ocr_attempts lists every engine tried, in order, with its confidence or error
+ALTER TABLE file_parse_results
+    ADD COLUMN IF NOT EXISTS ocr_engine VARCHAR(20),       -- 'kolosal_ocr', 'vision', 'tesseract'
+    ADD COLUMN IF NOT EXISTS ocr_confidence REAL,
+    ADD COLUMN IF NOT EXISTS ocr_attempts JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_file_parse_results_ocr_engine ON file_parse_results(ocr_engine, parsed_at) WHERE ocr_engine IS NOT NULL;
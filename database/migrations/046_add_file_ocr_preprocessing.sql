@@ -0,0 +1,9 @@
+-- Bantuaku - OCR Preprocessing
+-- Migration 046: Keep the cleaned-up image OCR read for photo uploads and how it was derived
+-- PostgreSQL 18
+
+-- preprocessing holds the EXIF rotation undone, scale, contrast levels, JPEG
+-- quality and byte sizes before and after
+ALTER TABLE file_uploads
+    ADD COLUMN IF NOT EXISTS ocr_image_path TEXT,
+    ADD COLUMN IF NOT EXISTS preprocessing JSONB;
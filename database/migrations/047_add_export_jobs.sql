@@ -0,0 +1,25 @@
+-- Bantuaku - Export Jobs
+-- Migration 047: Background exports stored in object storage and served via signed links
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    requested_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    kind VARCHAR(30) NOT NULL,            -- 'sales', 'conversations', 'account'
+    format VARCHAR(20) NOT NULL,          -- 'csv', 'jsonl', 'json.gz'
+    params JSONB NOT NULL DEFAULT '{}',   -- Date range filters
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- 'pending', 'running', 'completed', 'failed', 'expired'
+    storage_path TEXT,
+    storage_region VARCHAR(20),
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    row_count INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ               -- Artifact is deleted after this
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_company_created ON export_jobs(company_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_export_jobs_expires_at ON export_jobs(expires_at) WHERE status = 'completed';
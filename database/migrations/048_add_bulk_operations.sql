@@ -0,0 +1,33 @@
+-- Bantuaku - Admin Bulk Operations
+-- Migration 048: User suspension and background bulk operations with per-user results
+-- PostgreSQL 18
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMPTZ;  -- Suspended users cannot sign in
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_reason TEXT;
+
+CREATE TABLE IF NOT EXISTS bulk_operations (
+    id VARCHAR(36) PRIMARY KEY,
+    action VARCHAR(30) NOT NULL,          -- 'suspend', 'activate', 'change_plan', 'email'
+    params JSONB NOT NULL DEFAULT '{}',
+    filter JSONB,                         -- Set when targets were selected by filter rather than by ID
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- 'pending', 'running', 'completed', 'failed'
+    total INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    requested_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_operations_created_at ON bulk_operations(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS bulk_operation_items (
+    operation_id VARCHAR(36) NOT NULL REFERENCES bulk_operations(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL,         -- Not a foreign key so reports outlive deleted users
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',  -- 'pending', 'succeeded', 'skipped', 'failed'
+    message TEXT,
+    processed_at TIMESTAMPTZ,
+    PRIMARY KEY (operation_id, user_id)
+);
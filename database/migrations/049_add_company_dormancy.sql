@@ -0,0 +1,9 @@
+-- Bantuaku - Company Dormancy
+-- Migration 049: Activity tracking, dormancy flags and archival of inactive companies
+-- PostgreSQL 18
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMPTZ;  -- Last sign-in; data changes count as activity too
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS dormant_at TIMESTAMPTZ;      -- Flagged inactive and owner warned
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;     -- Set with status = 'archived'
+
+CREATE INDEX IF NOT EXISTS idx_companies_status ON companies(status);
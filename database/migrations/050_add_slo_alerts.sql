@@ -0,0 +1,19 @@
+-- Bantuaku - SLO Alerts
+-- Migration 050: Alerts raised when an endpoint group burns its error budget too fast
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS slo_alerts (
+    id VARCHAR(36) PRIMARY KEY,
+    slo_name VARCHAR(50) NOT NULL,
+    state VARCHAR(20) NOT NULL,           -- 'burning', 'breached'
+    availability NUMERIC(7, 4) NOT NULL,  -- Percent over the SLO window when raised
+    p95_ms INTEGER NOT NULL DEFAULT 0,
+    burn_rate_5m NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    burn_rate_1h NUMERIC(10, 2) NOT NULL DEFAULT 0,
+    fired_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ
+);
+
+-- One open alert per objective, shared by all API instances
+CREATE UNIQUE INDEX IF NOT EXISTS idx_slo_alerts_open ON slo_alerts(slo_name) WHERE resolved_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_slo_alerts_fired_at ON slo_alerts(fired_at DESC);
@@ -0,0 +1,30 @@
+-- Bantuaku - Status Page Incidents
+-- Migration 051: Incidents and their updates shown on the public status page
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS status_incidents (
+    id VARCHAR(36) PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'investigating', -- 'investigating', 'identified', 'monitoring', 'resolved'
+    impact VARCHAR(20) NOT NULL DEFAULT 'minor',         -- 'minor', 'major', 'critical'
+    components TEXT[] NOT NULL,                           -- 'api', 'database', 'ai_provider', 'integrations', 'billing'
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ,
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_incidents_started_at ON status_incidents(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_status_incidents_open ON status_incidents(status) WHERE resolved_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS status_incident_updates (
+    id VARCHAR(36) PRIMARY KEY,
+    incident_id VARCHAR(36) NOT NULL REFERENCES status_incidents(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    message TEXT NOT NULL,
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_status_incident_updates_incident ON status_incident_updates(incident_id, created_at);
@@ -0,0 +1,10 @@
+-- Bantuaku - Platform Settings
+-- Migration 052: Runtime settings shared by all API instances, such as maintenance mode
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS platform_settings (
+    key VARCHAR(100) PRIMARY KEY, -- 'maintenance', ...
+    value JSONB NOT NULL,
+    updated_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
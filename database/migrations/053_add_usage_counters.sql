@@ -0,0 +1,12 @@
+-- Bantuaku - Usage Metering
+-- Migration 053: Per-company usage counters of metered actions, one row per metric and period
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS usage_counters (
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    metric VARCHAR(50) NOT NULL,      -- 'chat_messages', ...
+    period_start TIMESTAMPTZ NOT NULL, -- Start of the calendar period; epoch for running totals
+    count BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (company_id, metric, period_start)
+);
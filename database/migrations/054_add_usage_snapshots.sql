@@ -0,0 +1,17 @@
+-- Bantuaku - Usage History
+-- Migration 054: Monthly usage per company and metric, written by the usage snapshot job
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS usage_snapshots (
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    metric VARCHAR(50) NOT NULL,
+    month DATE NOT NULL,               -- First day of the month
+    used BIGINT NOT NULL DEFAULT 0,
+    usage_limit BIGINT NOT NULL,       -- -1 when unlimited
+    plan VARCHAR(50) NOT NULL,         -- Plan when the snapshot was taken
+    final BOOLEAN NOT NULL DEFAULT false, -- The month is over and the figure no longer changes
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (company_id, metric, month)
+);
+
+CREATE INDEX IF NOT EXISTS idx_usage_snapshots_month ON usage_snapshots(month);
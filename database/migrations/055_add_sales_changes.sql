@@ -0,0 +1,16 @@
+-- Bantuaku - Sales Corrections
+-- Migration 055: Audit trail of edits and deletions of sales records
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS sales_history_changes (
+    id BIGSERIAL PRIMARY KEY,
+    sale_id BIGINT NOT NULL,           -- No foreign key: deleted sales keep their trail
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL,       -- 'update', 'delete'
+    before JSONB NOT NULL,
+    after JSONB,                       -- NULL for deletions
+    changed_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sales_history_changes_sale ON sales_history_changes(company_id, sale_id, created_at DESC);
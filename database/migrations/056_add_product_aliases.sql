@@ -0,0 +1,15 @@
+-- Bantuaku - Product Aliases
+-- Migration 056: Names and SKUs of merged products, so imports keep matching the surviving product
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS product_aliases (
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL,         -- 'name' or 'sku'
+    alias VARCHAR(255) NOT NULL,       -- Lowercased and trimmed, as imports compare them
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    merged_from VARCHAR(36),           -- The merged product the alias came from
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (company_id, kind, alias)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_aliases_product ON product_aliases(product_id);
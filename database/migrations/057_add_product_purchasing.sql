@@ -0,0 +1,7 @@
+-- Bantuaku - Product Purchasing
+-- Migration 057: Supplier, lead time and minimum order quantity per product, for purchase plans
+-- PostgreSQL 18
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS supplier_name VARCHAR(255);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS lead_time_days INTEGER;                -- Days from order to delivery; NULL uses the default
+ALTER TABLE products ADD COLUMN IF NOT EXISTS min_order_quantity NUMERIC(12, 3);     -- In the product's unit
@@ -0,0 +1,20 @@
+-- Bantuaku - Chat Suggestions
+-- Migration 058: Personalized prompts offered to each company, generated from recent data events
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS chat_suggestions (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL,         -- reorder, slow_mover, sales_trend
+    subject VARCHAR(100) NOT NULL,     -- What the suggestion is about, e.g. a product ID
+    title TEXT NOT NULL,
+    prompt TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    dismissed_at TIMESTAMPTZ,
+    dismissed_by VARCHAR(36),
+    UNIQUE (company_id, kind, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_chat_suggestions_active ON chat_suggestions(company_id, expires_at) WHERE dismissed_at IS NULL;
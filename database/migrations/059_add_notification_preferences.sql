@@ -0,0 +1,14 @@
+-- Bantuaku - Notification Preferences
+-- Migration 059: Per-user choices of which event types are sent over which channel
+-- PostgreSQL 18
+
+-- Only settings a user changed are stored; everything else follows the plan's defaults
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    event VARCHAR(30) NOT NULL,        -- predictions, reorders, regulations, digests, billing, integrations, exports, account
+    channel VARCHAR(20) NOT NULL,      -- in_app, email, whatsapp
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, company_id, event, channel)
+);
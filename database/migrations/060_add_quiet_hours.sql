@@ -0,0 +1,14 @@
+-- Bantuaku - Quiet Hours
+-- Migration 060: Hold non-urgent notifications overnight and deliver them as one morning summary
+-- PostgreSQL 18
+
+-- Hours are in the company's time zone
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS quiet_hours_enabled BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS quiet_hours_start SMALLINT NOT NULL DEFAULT 21;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS quiet_hours_end SMALLINT NOT NULL DEFAULT 7;
+
+-- Held notifications stay hidden until held_until; released_at is set once the summary went out
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS held_until TIMESTAMPTZ;
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS released_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_held ON notifications(held_until) WHERE held_until IS NOT NULL AND released_at IS NULL;
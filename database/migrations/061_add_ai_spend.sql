@@ -0,0 +1,33 @@
+-- Bantuaku - AI Spend
+-- Migration 061: Monthly AI provider spend per company and model, caps and cap alerts
+-- PostgreSQL 18
+
+-- company_id is '' for calls not made on behalf of a company
+CREATE TABLE IF NOT EXISTS ai_spend (
+    company_id VARCHAR(36) NOT NULL DEFAULT '',
+    month DATE NOT NULL,
+    model VARCHAR(200) NOT NULL,
+    calls INTEGER NOT NULL DEFAULT 0,
+    estimated_calls INTEGER NOT NULL DEFAULT 0, -- Calls whose token counts were estimated
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    cost_usd NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (company_id, month, model)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_spend_month ON ai_spend(month);
+
+-- NULL follows the platform default cap; 0 is unlimited
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS ai_spend_cap_usd NUMERIC(12, 2);
+
+-- One alert per scope, month and level; scope is '' for the global cap
+CREATE TABLE IF NOT EXISTS ai_spend_alerts (
+    scope VARCHAR(36) NOT NULL,
+    month DATE NOT NULL,
+    level VARCHAR(20) NOT NULL,        -- throttled, capped
+    spent_usd NUMERIC(14, 6) NOT NULL,
+    cap_usd NUMERIC(12, 2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (scope, month, level)
+);
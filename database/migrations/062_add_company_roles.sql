@@ -0,0 +1,36 @@
+-- Bantuaku - Company Roles
+-- Migration 062: Every user with access to a company is a member with a role, and owners invite staff
+-- PostgreSQL 18
+
+-- Members so far had full access; owners join as members too
+UPDATE company_members SET role = 'manager' WHERE role IN ('admin', 'member');
+ALTER TABLE company_members ALTER COLUMN role SET DEFAULT 'viewer';
+ALTER TABLE company_members ADD COLUMN IF NOT EXISTS invited_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL;
+
+INSERT INTO company_members (company_id, user_id, role, source)
+SELECT id, owner_user_id, 'owner', 'owner' FROM companies WHERE owner_user_id IS NOT NULL
+ON CONFLICT (company_id, user_id) DO UPDATE SET role = 'owner';
+
+COMMENT ON COLUMN company_members.role IS 'owner, manager, viewer';
+COMMENT ON COLUMN company_members.source IS 'owner, invite, sso, merge, manual';
+
+UPDATE sso_configs SET default_role = 'manager' WHERE default_role IN ('admin', 'member');
+ALTER TABLE sso_configs ALTER COLUMN default_role SET DEFAULT 'manager';
+
+CREATE TABLE IF NOT EXISTS company_invites (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL,         -- manager, viewer
+    invited_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    declined_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+-- One open invite per company and email
+CREATE UNIQUE INDEX IF NOT EXISTS idx_company_invites_pending ON company_invites(company_id, email)
+    WHERE accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_company_invites_email ON company_invites(email);
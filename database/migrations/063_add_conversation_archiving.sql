@@ -0,0 +1,8 @@
+-- Bantuaku - Conversation Archiving
+-- Migration 063: Users archive conversations to hide them from their list
+-- PostgreSQL 18
+
+-- Not to be confused with message archives (023), which compress old messages
+ALTER TABLE conversations ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_conversations_company_active ON conversations(company_id, updated_at DESC) WHERE archived_at IS NULL;
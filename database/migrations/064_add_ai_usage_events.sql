@@ -0,0 +1,23 @@
+-- Bantuaku - AI Usage Attribution
+-- Migration 064: Every AI provider call with the feature, job or record it was made for
+-- PostgreSQL 18
+
+-- company_id is '' for calls not made on behalf of a company. ai_spend keeps
+-- the monthly totals caps are checked against; these rows break them down.
+CREATE TABLE IF NOT EXISTS ai_usage_events (
+    id BIGSERIAL PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL DEFAULT '',
+    feature VARCHAR(50) NOT NULL,
+    reference_id VARCHAR(100),         -- Prediction job, insight, conversation or record
+    step VARCHAR(50),                  -- Part of the job, e.g. a prediction step
+    endpoint VARCHAR(50) NOT NULL,
+    model VARCHAR(200) NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    estimated BOOLEAN NOT NULL DEFAULT FALSE,
+    cost_usd NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_usage_events_created ON ai_usage_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_ai_usage_events_company_created ON ai_usage_events(company_id, created_at);
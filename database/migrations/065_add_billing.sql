@@ -0,0 +1,29 @@
+-- Bantuaku - Billing
+-- Migration 065: Plan purchases through a payment gateway and when paid plans lapse
+-- PostgreSQL 18
+
+-- NULL for free plans and plans set by an admin, which never lapse
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS plan_expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_companies_plan_expires ON companies(plan_expires_at) WHERE plan_expires_at IS NOT NULL;
+
+-- One row per checkout; id is the order ID sent to the gateway
+CREATE TABLE IF NOT EXISTS billing_orders (
+    id VARCHAR(36) PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    requested_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    provider VARCHAR(30) NOT NULL,
+    plan VARCHAR(30) NOT NULL,
+    amount BIGINT NOT NULL,                -- Rupiah
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    provider_status VARCHAR(50),           -- The gateway's own status, e.g. settlement
+    payment_type VARCHAR(50),              -- e.g. qris, gopay, bank_transfer
+    transaction_id VARCHAR(100),
+    redirect_url TEXT,
+    paid_at TIMESTAMPTZ,
+    plan_expires_at TIMESTAMPTZ,           -- Expiry the payment extended the plan to
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_orders_company_created ON billing_orders(company_id, created_at DESC);
@@ -0,0 +1,27 @@
+-- Bantuaku - Inventory
+-- Migration 066: Stock on hand per product and the movements that change it
+-- PostgreSQL 18
+
+-- NULL until the product's stock is first recorded; untracked products are
+-- left alone by sales
+ALTER TABLE products ADD COLUMN IF NOT EXISTS stock_on_hand NUMERIC(14, 3);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_point NUMERIC(14, 3);
+-- Set when a low-stock notification is sent, cleared once stock is back above
+-- the reorder point so the next dip alerts again
+ALTER TABLE products ADD COLUMN IF NOT EXISTS low_stock_alerted_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    company_id VARCHAR(36) NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,             -- purchase, sale, adjustment
+    quantity NUMERIC(14, 3) NOT NULL,      -- Signed change in the product's unit
+    balance NUMERIC(14, 3) NOT NULL,       -- Stock on hand after the movement
+    sale_id BIGINT REFERENCES sales_history(id) ON DELETE SET NULL,
+    note TEXT,
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_company_created ON stock_movements(company_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_created ON stock_movements(product_id, created_at DESC);
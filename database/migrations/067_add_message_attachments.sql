@@ -0,0 +1,16 @@
+-- Bantuaku - Chat message attachments
+-- Migration 067: Files attached to chat messages, and cached summaries of
+-- files too long to hand to the chat model whole
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS message_attachments (
+    message_id VARCHAR(36) NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    file_id VARCHAR(36) NOT NULL REFERENCES file_uploads(id) ON DELETE CASCADE,
+    position SMALLINT NOT NULL DEFAULT 0,  -- Order the files were attached in
+    PRIMARY KEY (message_id, file_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_attachments_file ON message_attachments(file_id);
+
+-- Cleared whenever the file is parsed again
+ALTER TABLE file_parse_results ADD COLUMN IF NOT EXISTS summary TEXT;
@@ -0,0 +1,6 @@
+-- Bantuaku - Reorder recommendations
+-- Migration 068: Safety stock per product, for reorder points
+-- PostgreSQL 18
+
+-- In the product's unit; NULL computes it from how much daily sales vary
+ALTER TABLE products ADD COLUMN IF NOT EXISTS safety_stock NUMERIC(14, 3);
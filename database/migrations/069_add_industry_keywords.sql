@@ -0,0 +1,13 @@
+-- Bantuaku - Industry keywords
+-- Migration 069: Trends keywords tracked centrally per industry and shared with
+-- every company in it
+-- PostgreSQL 18
+
+CREATE TABLE IF NOT EXISTS industry_keywords (
+    id VARCHAR(36) PRIMARY KEY,
+    industry VARCHAR(100) NOT NULL,        -- Lowercased, matched against companies.industry
+    keyword VARCHAR(100) NOT NULL,         -- Lowercased
+    created_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (industry, keyword)
+);
@@ -0,0 +1,14 @@
+-- Bantuaku - Admin device approval
+-- Migration 070: New admin devices are approved from an emailed link
+-- PostgreSQL 18
+
+-- A new X-Device-ID cannot stand in for a revoked device: only an admin's first
+-- device is approved on sign-in, later ones from the link emailed to the admin
+ALTER TABLE admin_devices ADD COLUMN IF NOT EXISTS approved_at TIMESTAMPTZ;
+ALTER TABLE admin_devices ADD COLUMN IF NOT EXISTS approval_token_hash VARCHAR(64); -- SHA-256 of the emailed token
+ALTER TABLE admin_devices ADD COLUMN IF NOT EXISTS approval_expires_at TIMESTAMPTZ;
+
+UPDATE admin_devices SET approved_at = first_seen_at WHERE approved_at IS NULL AND revoked_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_devices_approval ON admin_devices(approval_token_hash)
+    WHERE approval_token_hash IS NOT NULL;
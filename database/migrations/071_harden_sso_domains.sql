@@ -0,0 +1,11 @@
+-- Bantuaku - SSO domain hardening
+-- Migration 071: Providers default to read-only members, and providers that
+-- claim public mailbox domains are switched off until an admin reviews them
+-- PostgreSQL 18
+
+ALTER TABLE sso_configs ALTER COLUMN default_role SET DEFAULT 'viewer';
+
+UPDATE sso_configs SET enabled = FALSE, updated_at = NOW()
+WHERE email_domains && ARRAY['gmail.com', 'googlemail.com', 'yahoo.com', 'yahoo.co.id', 'ymail.com',
+    'hotmail.com', 'outlook.com', 'live.com', 'msn.com', 'icloud.com', 'me.com', 'aol.com', 'proton.me',
+    'protonmail.com', 'gmx.com', 'mail.com', 'zoho.com', 'yandex.com', 'rocketmail.com']::text[];
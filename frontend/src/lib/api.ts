@@ -2,6 +2,16 @@ import { useAuthStore } from '@/state/auth'
 
 const API_BASE = '/api/v1'
 
+// A random ID kept in this browser; admin sign-ins are bound to it
+function deviceId(): string {
+  let id = localStorage.getItem('bantuaku_device_id')
+  if (!id) {
+    id = crypto.randomUUID()
+    localStorage.setItem('bantuaku_device_id', id)
+  }
+  return id
+}
+
 interface RequestOptions {
   method?: string
   body?: unknown
@@ -13,6 +23,7 @@ async function request<T>(endpoint: string, options: RequestOptions = {}): Promi
   
   const headers: Record<string, string> = {
     'Content-Type': 'application/json',
+    'X-Device-ID': deviceId(),
     ...options.headers,
   }
   
@@ -226,7 +237,9 @@ export interface RecordSaleRequest {
 
 export interface ImportResult {
   success_count: number
-  errors: { row: number; error: string }[]
+  dry_run: boolean
+  mapping: Record<string, string>
+  errors: { row: number; column?: string; value?: string; error: string }[]
 }
 
 export interface WooCommerceSyncStatus {